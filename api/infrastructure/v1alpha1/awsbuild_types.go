@@ -0,0 +1,257 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AWSBuildFinalizer is set on the AWSBuilds so their instance and key pair are deleted before them.
+	AWSBuildFinalizer = "awsbuild.infrastructure.forge.build"
+
+	// AWSAccessKeyIDKey is the key of the access key ID in the credentials secrets of the AWSBuilds.
+	AWSAccessKeyIDKey = "accessKeyID"
+
+	// AWSSecretAccessKeyKey is the key of the secret access key in the credentials secrets of the AWSBuilds.
+	AWSSecretAccessKeyKey = "secretAccessKey"
+
+	// AWSSessionTokenKey is the key of the optional session token in the credentials secrets of the AWSBuilds.
+	AWSSessionTokenKey = "sessionToken"
+
+	// DefaultAWSInstanceType is the instance type of the instances when not set.
+	DefaultAWSInstanceType = "t3.medium"
+
+	// DefaultAWSUsername is the user the connector connects as when not set, the default user of the Amazon Linux AMIs.
+	DefaultAWSUsername = "ec2-user"
+)
+
+// AWSBuildSpec defines the EC2 instance a Build runs its provisioners on, and the AMI created from it.
+type AWSBuildSpec struct {
+	// Region is the region the instance and the AMI are created in, e.g. eu-west-1.
+	// +kubebuilder:validation:MinLength=1
+	Region string `json:"region"`
+
+	// SourceAMI is the ID of the AMI the instance is launched from, e.g. ami-0c1c30571d2dae5c9.
+	// +kubebuilder:validation:Pattern=`^ami-[0-9a-f]+$`
+	SourceAMI string `json:"sourceAMI"`
+
+	// InstanceType is the instance type of the instance, defaults to t3.medium.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// SubnetID is the subnet the instance is launched in, the default subnet of the default VPC if not set.
+	// +optional
+	SubnetID string `json:"subnetID,omitempty"`
+
+	// SecurityGroupIDs are the security groups of the instance, they must allow SSH from forge.
+	// The default security group of the VPC is used if not set.
+	// +optional
+	SecurityGroupIDs []string `json:"securityGroupIDs,omitempty"`
+
+	// PublicIP associates a public IP to the instance, the connector connects to it.
+	// The connector connects to the private IP of the instance when false. Defaults to true.
+	// +optional
+	PublicIP *bool `json:"publicIP,omitempty"`
+
+	// RootVolumeSize is the size of the root volume in GiB, defaults to the size of the source AMI.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RootVolumeSize int32 `json:"rootVolumeSize,omitempty"`
+
+	// RootVolumeType is the type of the root volume, e.g. gp3, defaults to the type of the source AMI.
+	// +optional
+	// +kubebuilder:validation:Enum=gp2;gp3;io1;io2;st1;sc1;standard
+	RootVolumeType string `json:"rootVolumeType,omitempty"`
+
+	// Spot launches the instance as a Spot Instance. An interrupted instance fails the AWSBuild with the Preempted
+	// reason, the Build recreates it according to its preemptionPolicy.
+	// +optional
+	Spot *AWSSpotOptions `json:"spot,omitempty"`
+
+	// IAMInstanceProfile is the name of the instance profile of the instance, if any.
+	// +optional
+	IAMInstanceProfile string `json:"iamInstanceProfile,omitempty"`
+
+	// Username is the user the connector connects as, the default user of the source AMI, defaults to ec2-user.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Tags are added to the instance, its volumes, the AMI and its snapshots.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// CredentialsRef is the secret holding the credentials of the AWS API in its accessKeyID, secretAccessKey
+	// and optional sessionToken keys, in the namespace of the AWSBuild. The secret of the ProviderIdentity of the
+	// Build is used when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// AMI configures the AMI created from the instance, named after the spec.imageName of the Build.
+	// +optional
+	AMI AWSAMISpec `json:"ami,omitempty"`
+}
+
+// AWSSpotOptions configures the Spot Instance of an AWSBuild.
+type AWSSpotOptions struct {
+	// MaxPrice is the maximum hourly price in USD, e.g. "0.05", defaults to the On-Demand price.
+	// +optional
+	MaxPrice string `json:"maxPrice,omitempty"`
+}
+
+// AWSAMISpec configures the AMI created from the instance.
+type AWSAMISpec struct {
+	// Description is the description of the AMI.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// LaunchPermissions are the IDs of the accounts the AMI and its snapshots are shared with.
+	// +optional
+	LaunchPermissions []string `json:"launchPermissions,omitempty"`
+
+	// Copies are the copies of the AMI made to other regions or accounts once it is available.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Copies []AWSAMICopy `json:"copies,omitempty"`
+}
+
+// AWSAMICopy is a copy of the AMI to another region or account.
+// +kubebuilder:validation:XValidation:rule="!has(self.accountID) || has(self.roleARN)",message="roleARN is required to copy the AMI to another account"
+type AWSAMICopy struct {
+	// Name identifies the copy in the status.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Region is the region the AMI is copied to, defaults to the region of the AWSBuild.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// AccountID is the account the AMI is copied to. The AMI and its snapshots are shared with the account,
+	// and the copy is made with the role of roleARN.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]{12}$`
+	AccountID string `json:"accountID,omitempty"`
+
+	// RoleARN is the role assumed to copy the AMI, required to copy it to another account.
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+
+	// Encrypted encrypts the snapshots of the copy, with the KMS key of kmsKeyID or the default EBS key.
+	// +optional
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	// KMSKeyID is the KMS key encrypting the snapshots of the copy.
+	// +optional
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
+}
+
+// AWSAMICopyStatus is the status of a copy of the AMI.
+type AWSAMICopyStatus struct {
+	// Name is the name of the copy in spec.ami.copies.
+	Name string `json:"name"`
+
+	// Region is the region of the copy.
+	Region string `json:"region"`
+
+	// AccountID is the account of the copy, empty if it is the account of the AWSBuild.
+	// +optional
+	AccountID string `json:"accountID,omitempty"`
+
+	// ImageID is the ID of the copy.
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+
+	// State is the state of the copy, pending, available or failed.
+	// +optional
+	State string `json:"state,omitempty"`
+}
+
+// AWSBuildStatus defines the observed state of AWSBuild.
+type AWSBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// InstanceID is the ID of the instance.
+	// +optional
+	InstanceID string `json:"instanceID,omitempty"`
+
+	// KeyPairName is the name of the key pair authorizing the connector on the instance, it is deleted along with
+	// the instance.
+	// +optional
+	KeyPairName string `json:"keyPairName,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// ImageID is the ID of the AMI created from the instance.
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+
+	// Copies are the copies of the AMI.
+	// +optional
+	Copies []AWSAMICopyStatus `json:"copies,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=awsbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Region",type="string",JSONPath=".spec.region",description="Region of the instance"
+//+kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".status.instanceID",description="ID of the instance"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the instance is running"
+//+kubebuilder:printcolumn:name="AMI",type="string",JSONPath=".status.imageRef",description="AMI created from the instance"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of AWSBuild"
+
+// AWSBuild is the Schema for the awsbuilds API
+type AWSBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AWSBuildSpec   `json:"spec,omitempty"`
+	Status AWSBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *AWSBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *AWSBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *AWSBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// AWSBuildList contains a list of AWSBuild
+type AWSBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AWSBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &AWSBuild{}, &AWSBuildList{})
+}
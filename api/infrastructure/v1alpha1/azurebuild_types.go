@@ -0,0 +1,296 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AzureBuildFinalizer is set on the AzureBuilds so their VM is deleted before them.
+	AzureBuildFinalizer = "azurebuild.infrastructure.forge.build"
+
+	// AzureTenantIDKey is the key of the tenant ID of the service principal in the credentials secrets of the
+	// AzureBuilds.
+	AzureTenantIDKey = "tenantID"
+
+	// AzureClientIDKey is the key of the client ID of the service principal in the credentials secrets of the
+	// AzureBuilds.
+	AzureClientIDKey = "clientID"
+
+	// AzureClientSecretKey is the key of the client secret of the service principal in the credentials secrets of
+	// the AzureBuilds.
+	AzureClientSecretKey = "clientSecret"
+
+	// DefaultAzureVMSize is the size of the VMs when not set.
+	DefaultAzureVMSize = "Standard_D2s_v5"
+
+	// DefaultAzureUsername is the admin user the connector connects as when not set.
+	DefaultAzureUsername = "forge"
+)
+
+// AzureOSType is the operating system of the VM, it selects how the VM is generalized.
+// +kubebuilder:validation:Enum=Linux;Windows
+type AzureOSType string
+
+const (
+	// AzureOSTypeLinux VMs are deprovisioned with waagent before being generalized.
+	AzureOSTypeLinux AzureOSType = "Linux"
+
+	// AzureOSTypeWindows VMs are generalized with sysprep, the connector authenticates with a password.
+	AzureOSTypeWindows AzureOSType = "Windows"
+)
+
+// AzureBuildSpec defines the Azure VM a Build runs its provisioners on, and the gallery image version captured
+// from it.
+type AzureBuildSpec struct {
+	// SubscriptionID is the subscription the VM and the image version are created in.
+	// +kubebuilder:validation:MinLength=1
+	SubscriptionID string `json:"subscriptionID"`
+
+	// ResourceGroup is the resource group the VM and its network interface are created in.
+	// +kubebuilder:validation:MinLength=1
+	ResourceGroup string `json:"resourceGroup"`
+
+	// Location is the region the VM is created in, e.g. westeurope.
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+
+	// VMSize is the size of the VM, defaults to Standard_D2s_v5.
+	// +optional
+	VMSize string `json:"vmSize,omitempty"`
+
+	// OSType is the operating system of the source image, Linux or Windows, defaults to Linux.
+	// +optional
+	OSType AzureOSType `json:"osType,omitempty"`
+
+	// SourceImage is the marketplace or gallery image the VM boots from.
+	SourceImage AzureImageReference `json:"sourceImage"`
+
+	// SubnetID is the resource ID of the subnet of the VM.
+	// +kubebuilder:validation:Pattern=`^/subscriptions/.+/subnets/[^/]+$`
+	SubnetID string `json:"subnetID"`
+
+	// NetworkSecurityGroupID is the resource ID of the network security group of the network interface of the VM,
+	// e.g. to allow SSH.
+	// +optional
+	NetworkSecurityGroupID string `json:"networkSecurityGroupID,omitempty"`
+
+	// PublicIP attaches a public IP to the VM, the connector connects to it.
+	// The connector connects to the private IP of the VM when false. Defaults to true.
+	// +optional
+	PublicIP *bool `json:"publicIP,omitempty"`
+
+	// OSDiskSizeGB is the size of the OS disk in GB, defaults to the size of the source image.
+	// +optional
+	// +kubebuilder:validation:Minimum=30
+	OSDiskSizeGB int32 `json:"osDiskSizeGB,omitempty"`
+
+	// OSDiskType is the storage account type of the OS disk, defaults to Premium_LRS.
+	// +optional
+	// +kubebuilder:validation:Enum=Standard_LRS;StandardSSD_LRS;Premium_LRS;StandardSSD_ZRS;Premium_ZRS
+	OSDiskType string `json:"osDiskType,omitempty"`
+
+	// Spot creates the VM as an Azure Spot VM, deleted when evicted. An evicted VM fails the AzureBuild with the
+	// Preempted reason, the Build recreates it according to its preemptionPolicy.
+	// +optional
+	Spot *AzureSpotOptions `json:"spot,omitempty"`
+
+	// Username is the admin user created on the VM for the connector, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Tags are added to the VM, its network resources and the image version.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// CredentialsRef is the secret holding the service principal in its tenantID, clientID and clientSecret keys,
+	// in the namespace of the AzureBuild. The secret of the ProviderIdentity of the Build is used when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Gallery is the image definition of the Azure Compute Gallery the image version is captured into.
+	Gallery AzureGallerySpec `json:"gallery"`
+}
+
+// AzureSpotOptions configures the Azure Spot VM of an AzureBuild.
+type AzureSpotOptions struct {
+	// MaxPrice is the maximum hourly price in US dollars, e.g. "0.05", the VM is evicted when the price exceeds it.
+	// The VM is only evicted for capacity when not set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	MaxPrice string `json:"maxPrice,omitempty"`
+}
+
+// AzureImageReference is a marketplace image, by publisher, offer, SKU and version, or the resource ID of a gallery
+// image version or managed image.
+// +kubebuilder:validation:XValidation:rule="has(self.id) != (has(self.publisher) && has(self.offer) && has(self.sku))",message="exactly one of id and publisher, offer and sku must be set"
+type AzureImageReference struct {
+	// Publisher is the publisher of the marketplace image, e.g. Canonical.
+	// +optional
+	Publisher string `json:"publisher,omitempty"`
+
+	// Offer is the offer of the marketplace image, e.g. 0001-com-ubuntu-server-jammy.
+	// +optional
+	Offer string `json:"offer,omitempty"`
+
+	// SKU is the SKU of the marketplace image, e.g. 22_04-lts-gen2.
+	// +optional
+	SKU string `json:"sku,omitempty"`
+
+	// Version is the version of the marketplace image, defaults to latest.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ID is the resource ID of a gallery image, gallery image version or managed image.
+	// +optional
+	ID string `json:"id,omitempty"`
+}
+
+// AzureGallerySpec configures the gallery image version captured from the VM.
+type AzureGallerySpec struct {
+	// ResourceGroup is the resource group of the gallery, defaults to the resource group of the VM.
+	// +optional
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+
+	// Name is the name of the gallery.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ImageDefinition is the image definition of the gallery the version is created in, its OS type, state and
+	// generation must match the source image.
+	// +kubebuilder:validation:MinLength=1
+	ImageDefinition string `json:"imageDefinition"`
+
+	// Version is the version of the image, defaults to a version derived from the time the image is captured,
+	// <year>.<month><day>.<hour><minute><second>.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+\.[0-9]+\.[0-9]+$`
+	Version string `json:"version,omitempty"`
+
+	// TargetRegions are the regions the image version is replicated to, the location of the VM is always one.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	TargetRegions []AzureTargetRegion `json:"targetRegions,omitempty"`
+
+	// ReplicaCount is the number of replicas of the image version in each region without its own, defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ReplicaCount int32 `json:"replicaCount,omitempty"`
+
+	// ExcludeFromLatest excludes the image version from the latest version of the image definition.
+	// +optional
+	ExcludeFromLatest bool `json:"excludeFromLatest,omitempty"`
+}
+
+// AzureTargetRegion is a region a gallery image version is replicated to.
+type AzureTargetRegion struct {
+	// Name is the name of the region, e.g. northeurope.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ReplicaCount is the number of replicas of the image version in the region, defaults to the replicaCount of
+	// the gallery.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ReplicaCount int32 `json:"replicaCount,omitempty"`
+
+	// StorageAccountType is the storage account type of the replicas, defaults to Standard_LRS.
+	// +optional
+	// +kubebuilder:validation:Enum=Standard_LRS;Standard_ZRS;Premium_LRS
+	StorageAccountType string `json:"storageAccountType,omitempty"`
+}
+
+// AzureBuildStatus defines the observed state of AzureBuild.
+type AzureBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// VMName is the name of the VM, its network interface and public IP are named after it.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+
+	// VMID is the unique ID of the VM.
+	// +optional
+	VMID string `json:"vmID,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// PendingOperation is the URL of the asynchronous operation creating, deprovisioning or deallocating the VM,
+	// until it is done.
+	// +optional
+	PendingOperation string `json:"pendingOperation,omitempty"`
+
+	// Deprovisioned is true once the VM has been deprovisioned with waagent or sysprep, before being generalized.
+	// +optional
+	Deprovisioned bool `json:"deprovisioned,omitempty"`
+
+	// ImageVersion is the version of the gallery image captured from the VM.
+	// +optional
+	ImageVersion string `json:"imageVersion,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=azurebuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Resource Group",type="string",JSONPath=".spec.resourceGroup",description="Resource group of the VM"
+//+kubebuilder:printcolumn:name="Location",type="string",JSONPath=".spec.location",description="Location of the VM"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the VM is running"
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.imageVersion",description="Gallery image version captured from the VM"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of AzureBuild"
+
+// AzureBuild is the Schema for the azurebuilds API
+type AzureBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AzureBuildSpec   `json:"spec,omitempty"`
+	Status AzureBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *AzureBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *AzureBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *AzureBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// AzureBuildList contains a list of AzureBuild
+type AzureBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AzureBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &AzureBuild{}, &AzureBuildList{})
+}
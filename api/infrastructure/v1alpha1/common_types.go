@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	builderror "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// ReadyCondition summarizes the conditions of an infrastructure build, it is mirrored by the
+	// InfrastructureReady condition of its Build.
+	ReadyCondition = "Ready"
+
+	// MachineReadyCondition reports the build machine is running and its connector credentials are published.
+	MachineReadyCondition = "MachineReady"
+
+	// ImageReadyCondition reports the image has been created from the build machine.
+	ImageReadyCondition = "ImageReady"
+)
+
+const (
+	// MachineProvisioningReason (Severity=Info) documents a build machine being created or started.
+	MachineProvisioningReason = "MachineProvisioning"
+
+	// MachineRunningReason documents a running build machine.
+	MachineRunningReason = "MachineRunning"
+
+	// MachineStoppingReason (Severity=Info) documents a build machine being stopped before its image is created.
+	MachineStoppingReason = "MachineStopping"
+
+	// MachineGeneralizingReason (Severity=Info) documents a build machine being deprovisioned and generalized
+	// before its image is captured.
+	MachineGeneralizingReason = "MachineGeneralizing"
+
+	// WaitingForBuildReason (Severity=Info) documents an infrastructure build waiting for its Build to own it.
+	WaitingForBuildReason = "WaitingForBuild"
+
+	// WaitingForProvisionersReason (Severity=Info) documents an image waiting for the provisioners of the Build.
+	WaitingForProvisionersReason = "WaitingForProvisioners"
+
+	// ImageCreatingReason (Severity=Info) documents an image being created from the build machine.
+	ImageCreatingReason = "ImageCreating"
+
+	// ImageExportingReason (Severity=Info) documents an image being exported to its additional destinations.
+	ImageExportingReason = "ImageExporting"
+
+	// ImageAvailableReason documents an image created and exported.
+	ImageAvailableReason = "ImageAvailable"
+
+	// DeletingReason (Severity=Info) documents the resources of an infrastructure build being deleted.
+	DeletingReason = "Deleting"
+
+	// ProvisioningFailedReason (Severity=Error) documents an infrastructure build which failed.
+	ProvisioningFailedReason = "ProvisioningFailed"
+)
+
+// BuildStatus is the part of the status of the infrastructure builds read by the core controller, as defined
+// by the infrastructure provider contract.
+type BuildStatus struct {
+	// MachineReady is true once the build machine is running and the connector credentials of the Build
+	// hold its address.
+	// +optional
+	MachineReady bool `json:"machineReady,omitempty"`
+
+	// Ready is true once the image has been created from the build machine, after the provisioners of the Build
+	// completed.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// ImageRef is the reference of the image created from the build machine.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// Artifact describes the image created from the build machine.
+	// +optional
+	Artifact *buildv1.BuildArtifact `json:"artifact,omitempty"`
+
+	// SourceImageRef is the image the build machine has been created from. The Build only skips the provisioners
+	// which already ran on the image of its spec.from once the machine is reported created from it.
+	// +optional
+	SourceImageRef string `json:"sourceImageRef,omitempty"`
+
+	// Replications are the statuses of the targets of the spec.artifact.replication of the Build, reported before
+	// status.ready. The targets not reported once status.ready is true are failed by the Build controller.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +listMapKey=name
+	Replications []buildv1.ReplicationStatus `json:"replications,omitempty"`
+
+	// FailureReason is set when the infrastructure build failed and will not recover, it fails the Build.
+	// +optional
+	FailureReason *builderror.BuildStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage describes why the infrastructure build failed.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines the current state of the infrastructure build.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
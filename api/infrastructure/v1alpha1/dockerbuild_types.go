@@ -0,0 +1,146 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DockerBuildFinalizer is set on the DockerBuilds so their container is removed before them.
+	DockerBuildFinalizer = "dockerbuild.infrastructure.forge.build"
+)
+
+// DockerBuildSpec defines the container a Build runs its provisioners in, with the docker-exec connector, and the
+// repository the container is pushed to once committed as an image.
+type DockerBuildSpec struct {
+	// Image is the base image the container is created from, e.g. ubuntu:24.04. The image must have /bin/sh.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Platform is the platform of the base image and of the container, e.g. linux/arm64, the platform of the
+	// Docker Engine is used when not set.
+	// +optional
+	Platform string `json:"platform,omitempty"`
+
+	// Username is the user the commands of the provisioners run as when the connector of the Build has no
+	// username, the user of the base image is used when not set.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Env are the environment variables of the container, as NAME=value. They are kept in the image.
+	// +optional
+	Env []string `json:"env,omitempty"`
+
+	// Changes are the Dockerfile instructions applied to the configuration of the image when the container is
+	// committed, e.g. CMD ["/bin/bash"]. The entrypoint and the command of the base image are kept otherwise.
+	// +optional
+	Changes []string `json:"changes,omitempty"`
+
+	// Repository is the repository the image is pushed to, e.g. ghcr.io/example/base.
+	// +kubebuilder:validation:MinLength=1
+	Repository string `json:"repository"`
+
+	// Tag is the tag of the image, defaults to the spec.imageName of the Build or the name of the DockerBuild.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`
+	Tag string `json:"tag,omitempty"`
+
+	// PullSecretRef is the kubernetes.io/dockerconfigjson secret, in the namespace of the DockerBuild, holding the
+	// credentials of the registry of the base image. The image is pulled anonymously when not set.
+	// +optional
+	PullSecretRef *corev1.LocalObjectReference `json:"pullSecretRef,omitempty"`
+
+	// PushSecretRef is the kubernetes.io/dockerconfigjson secret, in the namespace of the DockerBuild, holding the
+	// credentials of the registry of the repository.
+	// +optional
+	PushSecretRef *corev1.LocalObjectReference `json:"pushSecretRef,omitempty"`
+
+	// CredentialsRef is the secret describing the Docker Engine, in the namespace of the DockerBuild: its host,
+	// e.g. tcp://docker.example.com:2376, and optionally its caCert, clientCert and clientKey. The secret of the
+	// ProviderIdentity of the Build is used when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+}
+
+// DockerBuildStatus defines the observed state of DockerBuild.
+type DockerBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// ContainerName is the name of the container.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+
+	// ContainerID is the ID of the container the connector runs the commands in.
+	// +optional
+	ContainerID string `json:"containerID,omitempty"`
+
+	// ImageID is the ID of the image committed from the container.
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+
+	// Digest is the digest of the manifest of the image pushed to the repository.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=dockerbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Container",type="string",JSONPath=".status.containerName",description="Name of the container"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the container is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Image pushed from the container"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of DockerBuild"
+
+// DockerBuild is the Schema for the dockerbuilds API
+type DockerBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DockerBuildSpec   `json:"spec,omitempty"`
+	Status DockerBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *DockerBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *DockerBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *DockerBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// DockerBuildList contains a list of DockerBuild
+type DockerBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DockerBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &DockerBuild{}, &DockerBuildList{})
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GCPBuildFinalizer is set on the GCPBuilds so their instance is deleted before them.
+	GCPBuildFinalizer = "gcpbuild.infrastructure.forge.build"
+
+	// GCPCredentialsKey is the key of the service account key, in JSON, in the credentials secrets of the GCPBuilds.
+	GCPCredentialsKey = "credentials.json"
+
+	// DefaultGCPMachineType is the machine type of the instances when not set.
+	DefaultGCPMachineType = "e2-standard-2"
+
+	// DefaultGCPNetwork is the network of the instances when not set.
+	DefaultGCPNetwork = "global/networks/default"
+
+	// DefaultGCPUsername is the user the connector connects as when not set.
+	DefaultGCPUsername = "forge"
+)
+
+// GCPBuildSpec defines the Compute Engine instance a Build runs its provisioners on, and the image created from it.
+// +kubebuilder:validation:XValidation:rule="has(self.sourceImage) != has(self.sourceImageFamily)",message="exactly one of sourceImage and sourceImageFamily must be set"
+type GCPBuildSpec struct {
+	// Project is the project the instance and the image are created in.
+	// +kubebuilder:validation:MinLength=1
+	Project string `json:"project"`
+
+	// Zone is the zone the instance is created in, e.g. europe-west1-b.
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// MachineType is the machine type of the instance, defaults to e2-standard-2.
+	// +optional
+	MachineType string `json:"machineType,omitempty"`
+
+	// SourceImage is the image the instance boots from, e.g. ubuntu-2204-jammy-v20240614.
+	// +optional
+	SourceImage string `json:"sourceImage,omitempty"`
+
+	// SourceImageFamily is the image family the instance boots from, the latest image of the family is used,
+	// e.g. ubuntu-2204-lts.
+	// +optional
+	SourceImageFamily string `json:"sourceImageFamily,omitempty"`
+
+	// SourceImageProject is the project of the source image, e.g. ubuntu-os-cloud, defaults to the project.
+	// +optional
+	SourceImageProject string `json:"sourceImageProject,omitempty"`
+
+	// DiskSizeGB is the size of the boot disk in GB, defaults to the size of the source image.
+	// +optional
+	// +kubebuilder:validation:Minimum=10
+	DiskSizeGB int64 `json:"diskSizeGB,omitempty"`
+
+	// DiskType is the type of the boot disk, e.g. pd-ssd, defaults to pd-balanced.
+	// +optional
+	DiskType string `json:"diskType,omitempty"`
+
+	// Network is the network of the instance, defaults to global/networks/default.
+	// +optional
+	Network string `json:"network,omitempty"`
+
+	// Subnetwork is the subnetwork of the instance, e.g. regions/europe-west1/subnetworks/builds.
+	// +optional
+	Subnetwork string `json:"subnetwork,omitempty"`
+
+	// PublicIP attaches an ephemeral external IP to the instance, the connector connects to it.
+	// The connector connects to the internal IP of the instance when false. Defaults to true.
+	// +optional
+	PublicIP *bool `json:"publicIP,omitempty"`
+
+	// Spot creates the instance as a Spot VM, deleted when preempted. A preempted instance fails the GCPBuild with
+	// the Preempted reason, the Build recreates it according to its preemptionPolicy.
+	// +optional
+	Spot bool `json:"spot,omitempty"`
+
+	// Tags are the network tags of the instance, e.g. to allow SSH through the firewall.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Username is the user created on the instance for the connector, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CredentialsRef is the secret holding the service account key in its credentials.json key,
+	// in the namespace of the GCPBuild. The secret of the ProviderIdentity of the Build is used when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Image configures the image created from the instance, named after the spec.imageName of the Build.
+	// +optional
+	Image GCPImageSpec `json:"image,omitempty"`
+}
+
+// GCPImageSpec configures the Compute Engine image created from the instance.
+type GCPImageSpec struct {
+	// Family is the image family the image is added to.
+	// +optional
+	Family string `json:"family,omitempty"`
+
+	// StorageLocations are the Cloud Storage locations the image is stored in, e.g. eu.
+	// +optional
+	StorageLocations []string `json:"storageLocations,omitempty"`
+
+	// ExportURL is the gs://<bucket>/<object>.tar.gz Cloud Storage object the image is also exported to, with
+	// Cloud Build.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^gs://[^/]+/.+\.tar\.gz$`
+	ExportURL string `json:"exportURL,omitempty"`
+}
+
+// GCPBuildStatus defines the observed state of GCPBuild.
+type GCPBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// InstanceID is the ID of the instance.
+	// +optional
+	InstanceID string `json:"instanceID,omitempty"`
+
+	// InstanceName is the name of the instance.
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// PendingOperation is the self link of the operation creating the instance or the image, until it is done.
+	// +optional
+	PendingOperation string `json:"pendingOperation,omitempty"`
+
+	// ExportBuildID is the ID of the Cloud Build exporting the image to spec.image.exportURL.
+	// +optional
+	ExportBuildID string `json:"exportBuildID,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=gcpbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Project",type="string",JSONPath=".spec.project",description="Project of the instance"
+//+kubebuilder:printcolumn:name="Zone",type="string",JSONPath=".spec.zone",description="Zone of the instance"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the instance is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Image created from the instance"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of GCPBuild"
+
+// GCPBuild is the Schema for the gcpbuilds API
+type GCPBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GCPBuildSpec   `json:"spec,omitempty"`
+	Status GCPBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *GCPBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *GCPBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *GCPBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+// GetSourceImage returns the path of the source image of the instance, relative to the Compute Engine API.
+func (s *GCPBuildSpec) GetSourceImage() string {
+	project := s.SourceImageProject
+	if project == "" {
+		project = s.Project
+	}
+	if s.SourceImageFamily != "" {
+		return fmt.Sprintf("projects/%s/global/images/family/%s", project, s.SourceImageFamily)
+	}
+	if strings.Contains(s.SourceImage, "/") {
+		return s.SourceImage
+	}
+	return fmt.Sprintf("projects/%s/global/images/%s", project, s.SourceImage)
+}
+
+//+kubebuilder:object:root=true
+
+// GCPBuildList contains a list of GCPBuild
+type GCPBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GCPBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &GCPBuild{}, &GCPBuildList{})
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const (
+	// KubeVirtBuildFinalizer is set on the KubeVirtBuilds so their VM is deleted before them.
+	KubeVirtBuildFinalizer = "kubevirtbuild.infrastructure.forge.build"
+
+	// DefaultKubeVirtUsername is the user the connector connects as when not set.
+	DefaultKubeVirtUsername = "forge"
+)
+
+// KubeVirtBuildSpec defines the KubeVirt VM a Build runs its provisioners on, in the namespace of the
+// KubeVirtBuild, and where its disk is exported to as a qcow2 image.
+type KubeVirtBuildSpec struct {
+	// Source is the base image the disk of the VM is imported from by CDI. The image must run cloud-init with
+	// the NoCloud datasource.
+	Source KubeVirtDiskSource `json:"source"`
+
+	// DiskSize is the size of the disk of the VM, at least the virtual size of the base image.
+	DiskSize resource.Quantity `json:"diskSize"`
+
+	// StorageClassName is the storage class of the disk of the VM, the default one is used when not set.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// CPUCores is the number of CPU cores of the VM, defaults to 2.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	CPUCores uint32 `json:"cpuCores,omitempty"`
+
+	// Memory is the memory of the VM, defaults to 4Gi.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// Username is the user the connector connects as, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Export is where the disk of the VM is exported to as a qcow2 image, named after the spec.imageName of
+	// the Build.
+	Export KubeVirtExportSpec `json:"export"`
+}
+
+// KubeVirtDiskSource is the base image of a VM, exactly one of its fields must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.http), has(self.registry), has(self.pvc)].filter(x, x).size() == 1",message="exactly one of http, registry and pvc must be set"
+type KubeVirtDiskSource struct {
+	// HTTP is the http(s) URL of a qcow2 or raw image, e.g. a cloud image of a distribution.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	HTTP string `json:"http,omitempty"`
+
+	// Registry is the docker:// URL of a container disk image.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^docker://`
+	Registry string `json:"registry,omitempty"`
+
+	// PVC is a PVC holding the image, it is cloned.
+	// +optional
+	PVC *KubeVirtPVCSource `json:"pvc,omitempty"`
+}
+
+// KubeVirtPVCSource is a PVC holding a disk image.
+type KubeVirtPVCSource struct {
+	// Namespace is the namespace of the PVC, defaults to the one of the KubeVirtBuild.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the PVC.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// KubeVirtExportSpec is where the disk of a VM is exported to, exactly one of its fields must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.pvc) != has(self.objectStorage)",message="exactly one of pvc and objectStorage must be set"
+type KubeVirtExportSpec struct {
+	// PVC writes the image to a PVC of the namespace of the KubeVirtBuild, created if it does not exist.
+	// +optional
+	PVC *KubeVirtPVCExport `json:"pvc,omitempty"`
+
+	// ObjectStorage uploads the image to an S3 compatible object storage.
+	// +optional
+	ObjectStorage *buildv1.ObjectStorageDestination `json:"objectStorage,omitempty"`
+}
+
+// KubeVirtPVCExport is the PVC images are written to. The PVCs created by the controller are not owned by the
+// KubeVirtBuilds, the images outlive them.
+type KubeVirtPVCExport struct {
+	// Name is the name of the PVC.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Size is the size of the PVC when created, defaults to the diskSize of the KubeVirtBuild.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName is the storage class of the PVC when created, the default one is used when not set.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// KubeVirtBuildStatus defines the observed state of KubeVirtBuild.
+type KubeVirtBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// VMName is the name of the VirtualMachine, of its DataVolume and of its cloud-init secret.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// ExportJobName is the name of the Job exporting the disk of the VM.
+	// +optional
+	ExportJobName string `json:"exportJobName,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=kubevirtbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="VM",type="string",JSONPath=".status.vmName",description="Name of the VirtualMachine"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the VM is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Image exported from the VM"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of KubeVirtBuild"
+
+// KubeVirtBuild is the Schema for the kubevirtbuilds API
+type KubeVirtBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubeVirtBuildSpec   `json:"spec,omitempty"`
+	Status KubeVirtBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *KubeVirtBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *KubeVirtBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *KubeVirtBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// KubeVirtBuildList contains a list of KubeVirtBuild
+type KubeVirtBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeVirtBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &KubeVirtBuild{}, &KubeVirtBuildList{})
+}
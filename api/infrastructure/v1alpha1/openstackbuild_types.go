@@ -0,0 +1,204 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OpenStackBuildFinalizer is set on the OpenStackBuilds so their instance and floating IP are deleted before
+	// them.
+	OpenStackBuildFinalizer = "openstackbuild.infrastructure.forge.build"
+
+	// OpenStackAuthURLKey is the key of the URL of the Identity service, e.g. https://keystone.example.com:5000/v3,
+	// in the credentials secrets of the OpenStackBuilds.
+	OpenStackAuthURLKey = "authURL"
+
+	// OpenStackRegionKey is the optional key of the region of the endpoints in the credentials secrets of the
+	// OpenStackBuilds.
+	OpenStackRegionKey = "region"
+
+	// OpenStackUsernameKey is the key of the user in the credentials secrets of the OpenStackBuilds authenticating
+	// with a password.
+	OpenStackUsernameKey = "username"
+
+	// OpenStackPasswordKey is the key of the password of the user in the credentials secrets of the
+	// OpenStackBuilds.
+	OpenStackPasswordKey = "password"
+
+	// OpenStackDomainNameKey is the optional key of the domain of the user and of the project in the credentials
+	// secrets of the OpenStackBuilds, defaults to Default.
+	OpenStackDomainNameKey = "domainName"
+
+	// OpenStackProjectNameKey is the key of the project the user is scoped to in the credentials secrets of the
+	// OpenStackBuilds authenticating with a password, unless they have a projectID.
+	OpenStackProjectNameKey = "projectName"
+
+	// OpenStackProjectIDKey is the key of the ID of the project the user is scoped to in the credentials secrets
+	// of the OpenStackBuilds.
+	OpenStackProjectIDKey = "projectID"
+
+	// OpenStackApplicationCredentialIDKey is the key of the ID of the application credential in the credentials
+	// secrets of the OpenStackBuilds authenticating with an application credential, which is scoped to its project.
+	OpenStackApplicationCredentialIDKey = "applicationCredentialID"
+
+	// OpenStackApplicationCredentialSecretKey is the key of the secret of the application credential in the
+	// credentials secrets of the OpenStackBuilds.
+	OpenStackApplicationCredentialSecretKey = "applicationCredentialSecret"
+
+	// OpenStackCACertKey is the optional key of the PEM encoded CA certificates of the endpoints in the credentials
+	// secrets of the OpenStackBuilds.
+	OpenStackCACertKey = "caCert"
+
+	// DefaultOpenStackUsername is the user the connector connects as when not set.
+	DefaultOpenStackUsername = "forge"
+)
+
+// OpenStackBuildSpec defines the Nova instance a Build runs its provisioners on, and the Glance image created from
+// it.
+type OpenStackBuildSpec struct {
+	// Image is the name or the ID of the Glance image the instance boots from. The image must run cloud-init.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Flavor is the name or the ID of the flavor of the instance.
+	// +kubebuilder:validation:MinLength=1
+	Flavor string `json:"flavor"`
+
+	// Network is the name or the ID of the network the instance is attached to. A network is allocated to the
+	// project by Neutron when not set.
+	// +optional
+	Network string `json:"network,omitempty"`
+
+	// FloatingIP allocates a floating IP to the instance, the connector connects to it. The connector connects
+	// to the fixed IP of the instance, e.g. on a provider network, when not set.
+	// +optional
+	FloatingIP *OpenStackFloatingIP `json:"floatingIP,omitempty"`
+
+	// SecurityGroups are the names of the security groups of the instance, they must allow the connector.
+	// The default security group of the project is used when not set.
+	// +optional
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+
+	// AvailabilityZone is the availability zone of the instance.
+	// +optional
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+
+	// Username is the user the connector connects as, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CredentialsRef is the secret holding the authURL of the Identity service and the user or the application
+	// credential, in the namespace of the OpenStackBuild. The secret of the ProviderIdentity of the Build is used
+	// when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Snapshot configures the Glance image created from the instance, named after the spec.imageName of the Build.
+	// +optional
+	Snapshot OpenStackSnapshotSpec `json:"snapshot,omitempty"`
+}
+
+// OpenStackFloatingIP is the floating IP of an instance.
+type OpenStackFloatingIP struct {
+	// Network is the name or the ID of the external network the floating IP is allocated from.
+	// +kubebuilder:validation:MinLength=1
+	Network string `json:"network"`
+}
+
+// OpenStackSnapshotSpec configures the Glance image created from the instance.
+type OpenStackSnapshotSpec struct {
+	// Properties are the properties of the image, e.g. os_distro: ubuntu. The image metadata of the Build is added
+	// to them.
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// OpenStackBuildStatus defines the observed state of OpenStackBuild.
+type OpenStackBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// InstanceName is the name of the instance.
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+
+	// InstanceID is the ID of the instance.
+	// +optional
+	InstanceID string `json:"instanceID,omitempty"`
+
+	// FloatingIPID is the ID of the floating IP of the instance.
+	// +optional
+	FloatingIPID string `json:"floatingIPID,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// ImageID is the ID of the Glance image created from the instance.
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=openstackbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".status.instanceName",description="Name of the instance"
+//+kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.address",description="IP the connector connects to"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the instance is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Image created from the instance"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of OpenStackBuild"
+
+// OpenStackBuild is the Schema for the openstackbuilds API
+type OpenStackBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OpenStackBuildSpec   `json:"spec,omitempty"`
+	Status OpenStackBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *OpenStackBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *OpenStackBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *OpenStackBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// OpenStackBuildList contains a list of OpenStackBuild
+type OpenStackBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OpenStackBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &OpenStackBuild{}, &OpenStackBuildList{})
+}
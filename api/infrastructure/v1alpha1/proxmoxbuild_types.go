@@ -0,0 +1,243 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ProxmoxBuildFinalizer is set on the ProxmoxBuilds so the VM of a failed or deleted build is deleted before
+	// them.
+	ProxmoxBuildFinalizer = "proxmoxbuild.infrastructure.forge.build"
+
+	// ProxmoxURLKey is the key of the URL of the API of the Proxmox VE cluster, e.g. https://pve.example.com:8006,
+	// in the credentials secrets of the ProxmoxBuilds.
+	ProxmoxURLKey = "url"
+
+	// ProxmoxTokenIDKey is the key of the ID of the API token, e.g. forge@pve!builds, in the credentials secrets
+	// of the ProxmoxBuilds authenticating with an API token.
+	ProxmoxTokenIDKey = "tokenID"
+
+	// ProxmoxTokenSecretKey is the key of the secret of the API token in the credentials secrets of the
+	// ProxmoxBuilds.
+	ProxmoxTokenSecretKey = "tokenSecret"
+
+	// ProxmoxUsernameKey is the key of the user, e.g. forge@pve, in the credentials secrets of the ProxmoxBuilds
+	// authenticating with a password.
+	ProxmoxUsernameKey = "username"
+
+	// ProxmoxPasswordKey is the key of the password of the user in the credentials secrets of the ProxmoxBuilds.
+	ProxmoxPasswordKey = "password"
+
+	// ProxmoxCACertKey is the optional key of the PEM encoded CA certificates of the API in the credentials secrets
+	// of the ProxmoxBuilds.
+	ProxmoxCACertKey = "caCert"
+
+	// ProxmoxInsecureKey is the optional key of the credentials secrets of the ProxmoxBuilds skipping the
+	// verification of the certificate of the API when "true", e.g. for the self-signed certificates of the nodes.
+	ProxmoxInsecureKey = "insecureSkipTLSVerify"
+
+	// DefaultProxmoxUsername is the user the connector connects as when not set.
+	DefaultProxmoxUsername = "forge"
+)
+
+// ProxmoxCustomization is how the connector credentials are authorized on a cloned VM.
+// +kubebuilder:validation:Enum=CloudInit;None
+type ProxmoxCustomization string
+
+const (
+	// ProxmoxCustomizationCloudInit authorizes the connector credentials with a cloud-init drive, the template
+	// must run cloud-init with the NoCloud datasource.
+	ProxmoxCustomizationCloudInit ProxmoxCustomization = "CloudInit"
+
+	// ProxmoxCustomizationNone does not customize the VM, the connector credentials must be valid in the template.
+	ProxmoxCustomizationNone ProxmoxCustomization = "None"
+)
+
+// ProxmoxBuildSpec defines the Proxmox VE VM a Build runs its provisioners on, which is converted into a template
+// once they are done.
+// +kubebuilder:validation:XValidation:rule="has(self.clone) != has(self.iso)",message="exactly one of clone and iso must be set"
+type ProxmoxBuildSpec struct {
+	// Node is the node the VM is created on.
+	// +kubebuilder:validation:MinLength=1
+	Node string `json:"node"`
+
+	// Clone clones the VM from a template.
+	// +optional
+	Clone *ProxmoxCloneSource `json:"clone,omitempty"`
+
+	// ISO creates the VM and boots it from an ISO image.
+	// +optional
+	ISO *ProxmoxISOSource `json:"iso,omitempty"`
+
+	// Pool is the resource pool the VM is added to.
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// Cores is the number of cores of the VM, defaults to the one of the template, or 2 for ISOs.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Cores int32 `json:"cores,omitempty"`
+
+	// MemoryMiB is the memory of the VM in MiB, defaults to the one of the template, or 4096 for ISOs.
+	// +optional
+	// +kubebuilder:validation:Minimum=256
+	MemoryMiB int64 `json:"memoryMiB,omitempty"`
+
+	// Username is the user the connector connects as, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CredentialsRef is the secret holding the url of the API of the cluster and the API token or the user, in
+	// the namespace of the ProxmoxBuild. The secret of the ProviderIdentity of the Build is used when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Template configures the template the VM is converted into, named after the spec.imageName of the Build.
+	// +optional
+	Template ProxmoxTemplateSpec `json:"template,omitempty"`
+}
+
+// ProxmoxCloneSource is the template a VM is cloned from.
+type ProxmoxCloneSource struct {
+	// Template is the name of the template.
+	// +kubebuilder:validation:MinLength=1
+	Template string `json:"template"`
+
+	// Storage is the storage of the disks of a full clone, defaults to the storage of the disks of the template.
+	// +optional
+	Storage string `json:"storage,omitempty"`
+
+	// Linked creates a linked clone, sharing the disks of the template, instead of a full clone.
+	// +optional
+	Linked bool `json:"linked,omitempty"`
+
+	// Customization is how the connector credentials are authorized on the VM, defaults to CloudInit.
+	// +optional
+	Customization ProxmoxCustomization `json:"customization,omitempty"`
+
+	// CloudInitStorage is the storage of a cloud-init drive attached to the VM as ide2, e.g. local-lvm. The
+	// cloud-init drive of the template is used when not set.
+	// +optional
+	CloudInitStorage string `json:"cloudInitStorage,omitempty"`
+}
+
+// ProxmoxISOSource is the ISO image a new VM boots from. The installation must be unattended, e.g. with an
+// autoinstall or kickstart file on the ISO image, and authorize the connector credentials.
+type ProxmoxISOSource struct {
+	// ISO is the volume of the ISO image, e.g. local:iso/ubuntu-24.04-autoinstall.iso.
+	// +kubebuilder:validation:MinLength=1
+	ISO string `json:"iso"`
+
+	// OSType is the type of the guest OS, e.g. l26 or win11, defaults to l26.
+	// +optional
+	OSType string `json:"osType,omitempty"`
+
+	// Storage is the storage of the disk of the VM.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// DiskSizeGiB is the size of the disk of the VM in GiB.
+	// +kubebuilder:validation:Minimum=1
+	DiskSizeGiB int64 `json:"diskSizeGiB"`
+
+	// Bridge is the bridge of the network device of the VM, defaults to vmbr0.
+	// +optional
+	Bridge string `json:"bridge,omitempty"`
+}
+
+// ProxmoxTemplateSpec configures the template the VM is converted into.
+type ProxmoxTemplateSpec struct {
+	// Description is the description of the template.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// ProxmoxBuildStatus defines the observed state of ProxmoxBuild.
+type ProxmoxBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// VMName is the name of the VM.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+
+	// VMID is the ID of the VM, and of the template once converted.
+	// +optional
+	VMID int32 `json:"vmID,omitempty"`
+
+	// Task is the ID (UPID) of the task of the VM being waited for, e.g. its clone.
+	// +optional
+	Task string `json:"task,omitempty"`
+
+	// Configured is true once the hardware and the cloud-init drive of the VM have been configured.
+	// +optional
+	Configured bool `json:"configured,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=proxmoxbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Node",type="string",JSONPath=".spec.node",description="Node of the VM"
+//+kubebuilder:printcolumn:name="VM",type="string",JSONPath=".status.vmName",description="Name of the VM"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the VM is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Template the VM has been converted into"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ProxmoxBuild"
+
+// ProxmoxBuild is the Schema for the proxmoxbuilds API
+type ProxmoxBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProxmoxBuildSpec   `json:"spec,omitempty"`
+	Status ProxmoxBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *ProxmoxBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *ProxmoxBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *ProxmoxBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxBuildList contains a list of ProxmoxBuild
+type ProxmoxBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ProxmoxBuild{}, &ProxmoxBuildList{})
+}
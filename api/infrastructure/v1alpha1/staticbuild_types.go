@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StaticBuildSpec defines a pre-existing machine a Build runs its provisioners on, e.g. lab hardware or a
+// long-lived builder. The machine is neither created nor deleted, nor captured into an image.
+type StaticBuildSpec struct {
+	// Address is the host name or the IP the connector connects to.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// CredentialsRef is the secret holding the username, and the privateKey or the password, of the machine in the
+	// namespace of the StaticBuild. Its credentials are copied into the connector credentials secret of the Build,
+	// which must hold them when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// ImageRef is reported as the image of the Build once its provisioners are done, e.g. the name of the
+	// machine. The Build has no image when not set.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+}
+
+// StaticBuildStatus defines the observed state of StaticBuild.
+type StaticBuildStatus struct {
+	BuildStatus `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=staticbuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description="Address of the machine"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the connector credentials are published"
+//+kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Whether the provisioners of the Build are done"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of StaticBuild"
+
+// StaticBuild is the Schema for the staticbuilds API
+type StaticBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StaticBuildSpec   `json:"spec,omitempty"`
+	Status StaticBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *StaticBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *StaticBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *StaticBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// StaticBuildList contains a list of StaticBuild
+type StaticBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StaticBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &StaticBuild{}, &StaticBuildList{})
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VSphereBuildFinalizer is set on the VSphereBuilds so their VM is deleted before them.
+	VSphereBuildFinalizer = "vspherebuild.infrastructure.forge.build"
+
+	// VSphereServerKey is the key of the address of vCenter, e.g. vcenter.example.com, in the credentials secrets
+	// of the VSphereBuilds.
+	VSphereServerKey = "server"
+
+	// VSphereUsernameKey is the key of the vCenter user in the credentials secrets of the VSphereBuilds.
+	VSphereUsernameKey = "username"
+
+	// VSpherePasswordKey is the key of the password of the vCenter user in the credentials secrets of the
+	// VSphereBuilds.
+	VSpherePasswordKey = "password"
+
+	// VSphereCACertKey is the optional key of the PEM encoded CA certificates of vCenter in the credentials secrets
+	// of the VSphereBuilds.
+	VSphereCACertKey = "caCert"
+
+	// VSphereInsecureKey is the optional key of the credentials secrets of the VSphereBuilds skipping the
+	// verification of the certificate of vCenter when "true".
+	VSphereInsecureKey = "insecureSkipTLSVerify"
+
+	// DefaultVSphereUsername is the user the connector connects as when not set.
+	DefaultVSphereUsername = "forge"
+)
+
+// VSphereCustomization is how the connector credentials are authorized on a cloned VM.
+// +kubebuilder:validation:Enum=CloudInit;None
+type VSphereCustomization string
+
+const (
+	// VSphereCustomizationCloudInit authorizes the connector key with a cloud-init guest customization, the
+	// template must run cloud-init with the VMware datasource.
+	VSphereCustomizationCloudInit VSphereCustomization = "CloudInit"
+
+	// VSphereCustomizationNone does not customize the VM, the connector credentials must be valid in the template.
+	VSphereCustomizationNone VSphereCustomization = "None"
+)
+
+// VSphereTemplateFormat is the format of the content library item created from the VM.
+// +kubebuilder:validation:Enum=VMTX;OVF
+type VSphereTemplateFormat string
+
+const (
+	// VSphereTemplateFormatVMTX creates a VM template library item, which is also a template of the inventory.
+	VSphereTemplateFormatVMTX VSphereTemplateFormat = "VMTX"
+
+	// VSphereTemplateFormatOVF creates an OVF template library item, which can be synchronized to subscribed
+	// libraries.
+	VSphereTemplateFormatOVF VSphereTemplateFormat = "OVF"
+)
+
+// VSphereBuildSpec defines the vSphere VM a Build runs its provisioners on, and the content library template
+// created from it.
+// +kubebuilder:validation:XValidation:rule="has(self.clone) != has(self.iso)",message="exactly one of clone and iso must be set"
+type VSphereBuildSpec struct {
+	// Datacenter is the datacenter the names of the placement and of the source are looked up in.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// Clone clones the VM from a template.
+	// +optional
+	Clone *VSphereCloneSource `json:"clone,omitempty"`
+
+	// ISO creates the VM and boots it from ISO images.
+	// +optional
+	ISO *VSphereISOSource `json:"iso,omitempty"`
+
+	// Placement is where the VM is created.
+	// +optional
+	Placement VSpherePlacement `json:"placement,omitempty"`
+
+	// NumCPUs is the number of CPUs of the VM, defaults to the one of the template, or 2 for ISOs.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	NumCPUs int32 `json:"numCPUs,omitempty"`
+
+	// MemoryMiB is the memory of the VM in MiB, defaults to the one of the template, or 4096 for ISOs.
+	// +optional
+	// +kubebuilder:validation:Minimum=256
+	MemoryMiB int64 `json:"memoryMiB,omitempty"`
+
+	// Username is the user the connector connects as, defaults to forge.
+	// The connector credentials of the Build are generated with this user when the secret does not exist.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CredentialsRef is the secret holding the address of vCenter and the user in its server, username and
+	// password keys, in the namespace of the VSphereBuild. The secret of the ProviderIdentity of the Build is used
+	// when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Template configures the content library item created from the VM, named after the spec.imageName of
+	// the Build.
+	Template VSphereTemplateSpec `json:"template"`
+}
+
+// VSphereCloneSource is the template a VM is cloned from.
+type VSphereCloneSource struct {
+	// Template is the name of the VM template of the content library if set, of a VM of the inventory otherwise.
+	// +kubebuilder:validation:MinLength=1
+	Template string `json:"template"`
+
+	// Library is the content library of the template.
+	// +optional
+	Library string `json:"library,omitempty"`
+
+	// Customization is how the connector credentials are authorized on the VM, defaults to CloudInit.
+	// +optional
+	Customization VSphereCustomization `json:"customization,omitempty"`
+}
+
+// VSphereISOSource are the ISO images a new VM boots from. The installation must be unattended, e.g. with a
+// kickstart or autounattend file on the ISO images, and authorize the connector credentials.
+type VSphereISOSource struct {
+	// Paths are the datastore paths of the ISO images attached to the CD-ROM drives of the VM, the first one
+	// boots, e.g. "[datastore1] iso/rhel-9.4-ks.iso".
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=4
+	Paths []string `json:"paths"`
+
+	// GuestOS is the guest OS identifier of the VM, e.g. RHEL_9_64 or WINDOWS_SERVER_2021.
+	// +kubebuilder:validation:MinLength=1
+	GuestOS string `json:"guestOS"`
+
+	// Firmware is the firmware of the VM, BIOS or EFI, defaults to EFI.
+	// +optional
+	// +kubebuilder:validation:Enum=BIOS;EFI
+	Firmware string `json:"firmware,omitempty"`
+
+	// DiskSizeGiB is the size of the disk of the VM in GiB.
+	// +kubebuilder:validation:Minimum=1
+	DiskSizeGiB int64 `json:"diskSizeGiB"`
+
+	// Network is the name of the network of the network adapter of the VM.
+	// +kubebuilder:validation:MinLength=1
+	Network string `json:"network"`
+}
+
+// VSpherePlacement is where a VM or template is created, by name. The names are looked up in the datacenter of
+// the VSphereBuild.
+type VSpherePlacement struct {
+	// Folder is the VM folder.
+	// +optional
+	Folder string `json:"folder,omitempty"`
+
+	// ResourcePool is the resource pool.
+	// +optional
+	ResourcePool string `json:"resourcePool,omitempty"`
+
+	// Cluster is the cluster, the VM is placed in its root resource pool when resourcePool is not set.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Host is the ESXi host.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Datastore is the datastore of the disks.
+	// +optional
+	Datastore string `json:"datastore,omitempty"`
+}
+
+// VSphereTemplateSpec configures the content library item created from the VM.
+type VSphereTemplateSpec struct {
+	// Library is the content library the item is created in.
+	// +kubebuilder:validation:MinLength=1
+	Library string `json:"library"`
+
+	// Format is the format of the item, VMTX or OVF, defaults to VMTX.
+	// +optional
+	Format VSphereTemplateFormat `json:"format,omitempty"`
+
+	// Description is the description of the item.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Placement is where the template of the inventory of a VMTX item is created, defaults to the placement of
+	// the VM.
+	// +optional
+	Placement *VSpherePlacement `json:"placement,omitempty"`
+}
+
+// VSphereBuildStatus defines the observed state of VSphereBuild.
+type VSphereBuildStatus struct {
+	BuildStatus `json:",inline"`
+
+	// VMName is the name of the VM.
+	// +optional
+	VMName string `json:"vmName,omitempty"`
+
+	// VMID is the managed object ID of the VM, e.g. vm-42.
+	// +optional
+	VMID string `json:"vmID,omitempty"`
+
+	// Customized is true once the guest customization authorizing the connector credentials has been set on
+	// the VM.
+	// +optional
+	Customized bool `json:"customized,omitempty"`
+
+	// Address is the IP the connector connects to.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// LibraryItemID is the ID of the content library item created from the VM.
+	// +optional
+	LibraryItemID string `json:"libraryItemID,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:metadata:labels="forge.build/v1alpha1=v1alpha1"
+//+kubebuilder:resource:path=vspherebuilds,scope=Namespaced,categories=forge
+//+kubebuilder:printcolumn:name="Library",type="string",JSONPath=".spec.template.library",description="Content library of the template"
+//+kubebuilder:printcolumn:name="VM",type="string",JSONPath=".status.vmName",description="Name of the VM"
+//+kubebuilder:printcolumn:name="Machine Ready",type="boolean",JSONPath=".status.machineReady",description="Whether the VM is running"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Template created from the VM"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of VSphereBuild"
+
+// VSphereBuild is the Schema for the vspherebuilds API
+type VSphereBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VSphereBuildSpec   `json:"spec,omitempty"`
+	Status VSphereBuildStatus `json:"status,omitempty"`
+}
+
+// GetBuildStatus returns the part of the status of this object read by the Build controller.
+func (b *VSphereBuild) GetBuildStatus() *BuildStatus {
+	return &b.Status.BuildStatus
+}
+
+// GetConditions returns the set of conditions for this object.
+func (b *VSphereBuild) GetConditions() []metav1.Condition {
+	return b.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (b *VSphereBuild) SetConditions(conditions []metav1.Condition) {
+	b.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// VSphereBuildList contains a list of VSphereBuild
+type VSphereBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &VSphereBuild{}, &VSphereBuildList{})
+}
@@ -0,0 +1,1438 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	apiv1alpha1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/errors"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSAMICopy) DeepCopyInto(out *AWSAMICopy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSAMICopy.
+func (in *AWSAMICopy) DeepCopy() *AWSAMICopy {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSAMICopy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSAMICopyStatus) DeepCopyInto(out *AWSAMICopyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSAMICopyStatus.
+func (in *AWSAMICopyStatus) DeepCopy() *AWSAMICopyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSAMICopyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSAMISpec) DeepCopyInto(out *AWSAMISpec) {
+	*out = *in
+	if in.LaunchPermissions != nil {
+		in, out := &in.LaunchPermissions, &out.LaunchPermissions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Copies != nil {
+		in, out := &in.Copies, &out.Copies
+		*out = make([]AWSAMICopy, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSAMISpec.
+func (in *AWSAMISpec) DeepCopy() *AWSAMISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSAMISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBuild) DeepCopyInto(out *AWSBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBuild.
+func (in *AWSBuild) DeepCopy() *AWSBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBuildList) DeepCopyInto(out *AWSBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AWSBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBuildList.
+func (in *AWSBuildList) DeepCopy() *AWSBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AWSBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBuildSpec) DeepCopyInto(out *AWSBuildSpec) {
+	*out = *in
+	if in.SecurityGroupIDs != nil {
+		in, out := &in.SecurityGroupIDs, &out.SecurityGroupIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PublicIP != nil {
+		in, out := &in.PublicIP, &out.PublicIP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Spot != nil {
+		in, out := &in.Spot, &out.Spot
+		*out = new(AWSSpotOptions)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.AMI.DeepCopyInto(&out.AMI)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBuildSpec.
+func (in *AWSBuildSpec) DeepCopy() *AWSBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBuildStatus) DeepCopyInto(out *AWSBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+	if in.Copies != nil {
+		in, out := &in.Copies, &out.Copies
+		*out = make([]AWSAMICopyStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBuildStatus.
+func (in *AWSBuildStatus) DeepCopy() *AWSBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSSpotOptions) DeepCopyInto(out *AWSSpotOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSSpotOptions.
+func (in *AWSSpotOptions) DeepCopy() *AWSSpotOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSSpotOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureBuild) DeepCopyInto(out *AzureBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBuild.
+func (in *AzureBuild) DeepCopy() *AzureBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzureBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureBuildList) DeepCopyInto(out *AzureBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AzureBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBuildList.
+func (in *AzureBuildList) DeepCopy() *AzureBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzureBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureBuildSpec) DeepCopyInto(out *AzureBuildSpec) {
+	*out = *in
+	out.SourceImage = in.SourceImage
+	if in.PublicIP != nil {
+		in, out := &in.PublicIP, &out.PublicIP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Spot != nil {
+		in, out := &in.Spot, &out.Spot
+		*out = new(AzureSpotOptions)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.Gallery.DeepCopyInto(&out.Gallery)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBuildSpec.
+func (in *AzureBuildSpec) DeepCopy() *AzureBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureBuildStatus) DeepCopyInto(out *AzureBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureBuildStatus.
+func (in *AzureBuildStatus) DeepCopy() *AzureBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureGallerySpec) DeepCopyInto(out *AzureGallerySpec) {
+	*out = *in
+	if in.TargetRegions != nil {
+		in, out := &in.TargetRegions, &out.TargetRegions
+		*out = make([]AzureTargetRegion, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureGallerySpec.
+func (in *AzureGallerySpec) DeepCopy() *AzureGallerySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureGallerySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureImageReference) DeepCopyInto(out *AzureImageReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureImageReference.
+func (in *AzureImageReference) DeepCopy() *AzureImageReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureImageReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureSpotOptions) DeepCopyInto(out *AzureSpotOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureSpotOptions.
+func (in *AzureSpotOptions) DeepCopy() *AzureSpotOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureSpotOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureTargetRegion) DeepCopyInto(out *AzureTargetRegion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureTargetRegion.
+func (in *AzureTargetRegion) DeepCopy() *AzureTargetRegion {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureTargetRegion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildStatus) DeepCopyInto(out *BuildStatus) {
+	*out = *in
+	if in.Artifact != nil {
+		in, out := &in.Artifact, &out.Artifact
+		*out = new(apiv1alpha1.BuildArtifact)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replications != nil {
+		in, out := &in.Replications, &out.Replications
+		*out = make([]apiv1alpha1.ReplicationStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.BuildStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildStatus.
+func (in *BuildStatus) DeepCopy() *BuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerBuild) DeepCopyInto(out *DockerBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerBuild.
+func (in *DockerBuild) DeepCopy() *DockerBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DockerBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerBuildList) DeepCopyInto(out *DockerBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DockerBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerBuildList.
+func (in *DockerBuildList) DeepCopy() *DockerBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DockerBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerBuildSpec) DeepCopyInto(out *DockerBuildSpec) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PullSecretRef != nil {
+		in, out := &in.PullSecretRef, &out.PullSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PushSecretRef != nil {
+		in, out := &in.PushSecretRef, &out.PushSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerBuildSpec.
+func (in *DockerBuildSpec) DeepCopy() *DockerBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerBuildStatus) DeepCopyInto(out *DockerBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerBuildStatus.
+func (in *DockerBuildStatus) DeepCopy() *DockerBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPBuild) DeepCopyInto(out *GCPBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPBuild.
+func (in *GCPBuild) DeepCopy() *GCPBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GCPBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPBuildList) DeepCopyInto(out *GCPBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GCPBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPBuildList.
+func (in *GCPBuildList) DeepCopy() *GCPBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GCPBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPBuildSpec) DeepCopyInto(out *GCPBuildSpec) {
+	*out = *in
+	if in.PublicIP != nil {
+		in, out := &in.PublicIP, &out.PublicIP
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.Image.DeepCopyInto(&out.Image)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPBuildSpec.
+func (in *GCPBuildSpec) DeepCopy() *GCPBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPBuildStatus) DeepCopyInto(out *GCPBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPBuildStatus.
+func (in *GCPBuildStatus) DeepCopy() *GCPBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPImageSpec) DeepCopyInto(out *GCPImageSpec) {
+	*out = *in
+	if in.StorageLocations != nil {
+		in, out := &in.StorageLocations, &out.StorageLocations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPImageSpec.
+func (in *GCPImageSpec) DeepCopy() *GCPImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtBuild) DeepCopyInto(out *KubeVirtBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtBuild.
+func (in *KubeVirtBuild) DeepCopy() *KubeVirtBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeVirtBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtBuildList) DeepCopyInto(out *KubeVirtBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KubeVirtBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtBuildList.
+func (in *KubeVirtBuildList) DeepCopy() *KubeVirtBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeVirtBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtBuildSpec) DeepCopyInto(out *KubeVirtBuildSpec) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	out.DiskSize = in.DiskSize.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	in.Export.DeepCopyInto(&out.Export)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtBuildSpec.
+func (in *KubeVirtBuildSpec) DeepCopy() *KubeVirtBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtBuildStatus) DeepCopyInto(out *KubeVirtBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtBuildStatus.
+func (in *KubeVirtBuildStatus) DeepCopy() *KubeVirtBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtDiskSource) DeepCopyInto(out *KubeVirtDiskSource) {
+	*out = *in
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(KubeVirtPVCSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtDiskSource.
+func (in *KubeVirtDiskSource) DeepCopy() *KubeVirtDiskSource {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtDiskSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtExportSpec) DeepCopyInto(out *KubeVirtExportSpec) {
+	*out = *in
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(KubeVirtPVCExport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectStorage != nil {
+		in, out := &in.ObjectStorage, &out.ObjectStorage
+		*out = new(apiv1alpha1.ObjectStorageDestination)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtExportSpec.
+func (in *KubeVirtExportSpec) DeepCopy() *KubeVirtExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtPVCExport) DeepCopyInto(out *KubeVirtPVCExport) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtPVCExport.
+func (in *KubeVirtPVCExport) DeepCopy() *KubeVirtPVCExport {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtPVCExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVirtPVCSource) DeepCopyInto(out *KubeVirtPVCSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVirtPVCSource.
+func (in *KubeVirtPVCSource) DeepCopy() *KubeVirtPVCSource {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVirtPVCSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackBuild) DeepCopyInto(out *OpenStackBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackBuild.
+func (in *OpenStackBuild) DeepCopy() *OpenStackBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackBuildList) DeepCopyInto(out *OpenStackBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OpenStackBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackBuildList.
+func (in *OpenStackBuildList) DeepCopy() *OpenStackBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OpenStackBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackBuildSpec) DeepCopyInto(out *OpenStackBuildSpec) {
+	*out = *in
+	if in.FloatingIP != nil {
+		in, out := &in.FloatingIP, &out.FloatingIP
+		*out = new(OpenStackFloatingIP)
+		**out = **in
+	}
+	if in.SecurityGroups != nil {
+		in, out := &in.SecurityGroups, &out.SecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.Snapshot.DeepCopyInto(&out.Snapshot)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackBuildSpec.
+func (in *OpenStackBuildSpec) DeepCopy() *OpenStackBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackBuildStatus) DeepCopyInto(out *OpenStackBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackBuildStatus.
+func (in *OpenStackBuildStatus) DeepCopy() *OpenStackBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackFloatingIP) DeepCopyInto(out *OpenStackFloatingIP) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackFloatingIP.
+func (in *OpenStackFloatingIP) DeepCopy() *OpenStackFloatingIP {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackFloatingIP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenStackSnapshotSpec) DeepCopyInto(out *OpenStackSnapshotSpec) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenStackSnapshotSpec.
+func (in *OpenStackSnapshotSpec) DeepCopy() *OpenStackSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenStackSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxBuild) DeepCopyInto(out *ProxmoxBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxBuild.
+func (in *ProxmoxBuild) DeepCopy() *ProxmoxBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProxmoxBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxBuildList) DeepCopyInto(out *ProxmoxBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProxmoxBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxBuildList.
+func (in *ProxmoxBuildList) DeepCopy() *ProxmoxBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProxmoxBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxBuildSpec) DeepCopyInto(out *ProxmoxBuildSpec) {
+	*out = *in
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(ProxmoxCloneSource)
+		**out = **in
+	}
+	if in.ISO != nil {
+		in, out := &in.ISO, &out.ISO
+		*out = new(ProxmoxISOSource)
+		**out = **in
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	out.Template = in.Template
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxBuildSpec.
+func (in *ProxmoxBuildSpec) DeepCopy() *ProxmoxBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxBuildStatus) DeepCopyInto(out *ProxmoxBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxBuildStatus.
+func (in *ProxmoxBuildStatus) DeepCopy() *ProxmoxBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxCloneSource) DeepCopyInto(out *ProxmoxCloneSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxCloneSource.
+func (in *ProxmoxCloneSource) DeepCopy() *ProxmoxCloneSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxCloneSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxISOSource) DeepCopyInto(out *ProxmoxISOSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxISOSource.
+func (in *ProxmoxISOSource) DeepCopy() *ProxmoxISOSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxISOSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxmoxTemplateSpec) DeepCopyInto(out *ProxmoxTemplateSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxTemplateSpec.
+func (in *ProxmoxTemplateSpec) DeepCopy() *ProxmoxTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxmoxTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticBuild) DeepCopyInto(out *StaticBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticBuild.
+func (in *StaticBuild) DeepCopy() *StaticBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StaticBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticBuildList) DeepCopyInto(out *StaticBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StaticBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticBuildList.
+func (in *StaticBuildList) DeepCopy() *StaticBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StaticBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticBuildSpec) DeepCopyInto(out *StaticBuildSpec) {
+	*out = *in
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticBuildSpec.
+func (in *StaticBuildSpec) DeepCopy() *StaticBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticBuildStatus) DeepCopyInto(out *StaticBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticBuildStatus.
+func (in *StaticBuildStatus) DeepCopy() *StaticBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereBuild) DeepCopyInto(out *VSphereBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereBuild.
+func (in *VSphereBuild) DeepCopy() *VSphereBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereBuildList) DeepCopyInto(out *VSphereBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereBuildList.
+func (in *VSphereBuildList) DeepCopy() *VSphereBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereBuildSpec) DeepCopyInto(out *VSphereBuildSpec) {
+	*out = *in
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(VSphereCloneSource)
+		**out = **in
+	}
+	if in.ISO != nil {
+		in, out := &in.ISO, &out.ISO
+		*out = new(VSphereISOSource)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Placement = in.Placement
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereBuildSpec.
+func (in *VSphereBuildSpec) DeepCopy() *VSphereBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereBuildStatus) DeepCopyInto(out *VSphereBuildStatus) {
+	*out = *in
+	in.BuildStatus.DeepCopyInto(&out.BuildStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereBuildStatus.
+func (in *VSphereBuildStatus) DeepCopy() *VSphereBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereCloneSource) DeepCopyInto(out *VSphereCloneSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereCloneSource.
+func (in *VSphereCloneSource) DeepCopy() *VSphereCloneSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereCloneSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereISOSource) DeepCopyInto(out *VSphereISOSource) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereISOSource.
+func (in *VSphereISOSource) DeepCopy() *VSphereISOSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereISOSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSpherePlacement) DeepCopyInto(out *VSpherePlacement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSpherePlacement.
+func (in *VSpherePlacement) DeepCopy() *VSpherePlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(VSpherePlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereTemplateSpec) DeepCopyInto(out *VSphereTemplateSpec) {
+	*out = *in
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(VSpherePlacement)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereTemplateSpec.
+func (in *VSphereTemplateSpec) DeepCopy() *VSphereTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
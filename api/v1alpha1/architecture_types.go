@@ -0,0 +1,54 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// Architecture is a CPU architecture an image is built for.
+// +kubebuilder:validation:Enum=amd64;arm64
+type Architecture string
+
+const (
+	// ArchitectureAMD64 is the x86-64 architecture.
+	ArchitectureAMD64 Architecture = "amd64"
+
+	// ArchitectureARM64 is the 64-bit ARM architecture.
+	ArchitectureARM64 Architecture = "arm64"
+)
+
+// ArchitectureStatus is the status of the Build of an architecture of a multi-architecture Build.
+type ArchitectureStatus struct {
+	// Architecture is the architecture the Build builds the image for.
+	Architecture Architecture `json:"architecture"`
+
+	// BuildName is the name of the Build of the architecture.
+	BuildName string `json:"buildName"`
+
+	// Phase is the phase of the Build of the architecture.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// ImageRef is the reference of the image built for the architecture.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// Artifact describes the image built for the architecture.
+	// +optional
+	Artifact *BuildArtifact `json:"artifact,omitempty"`
+
+	// FailureMessage is the failure message of the Build of the architecture.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GitSHAAnnotation is set on a Build to the commit it is built from, it is the {{ .GitSHA }} of the name policy.
+	GitSHAAnnotation = "forge.build/git-sha"
+
+	// ArtifactTimestampLayout is the layout of the {{ .Timestamp }} of the name policy.
+	ArtifactTimestampLayout = "20060102-150405"
+
+	// SigningKeyKey and SigningPasswordKey are the keys of the secret holding the cosign private key signing the
+	// exports, and its password.
+	SigningKeyKey      = "cosign.key"
+	SigningPasswordKey = "cosign.password"
+
+	// DefaultFulcioURL, DefaultRekorURL and DefaultSigningAudience are the defaults of the keyless signatures.
+	DefaultFulcioURL       = "https://fulcio.sigstore.dev"
+	DefaultRekorURL        = "https://rekor.sigstore.dev"
+	DefaultSigningAudience = "sigstore"
+
+	// DefaultSyftVersion is the version of syft installed on the machines generating their SBOM without syft.
+	DefaultSyftVersion = "v1.14.0"
+
+	// SBOMConfigMapSuffix is appended to the name of a Build for the ConfigMap its SBOM is stored in by default.
+	SBOMConfigMapSuffix = "-sbom"
+
+	// SPDXMediaType and CycloneDXMediaType are the media types of the SBOMs.
+	SPDXMediaType      = "application/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
+// artifactNamePattern matches the image names accepted by the infrastructure providers.
+var artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,252}$`)
+
+// ArtifactSpec defines how the machine image produced by a Build is named, replicated and signed.
+type ArtifactSpec struct {
+	// NamePolicy is the Go template of the name of the machine image, e.g. ubuntu-2204-{{ .Timestamp }}-{{ .Serial }}.
+	// It is rendered once, when the Build starts, into spec.imageName which takes precedence when already set.
+	// The template can use:
+	// - {{ .BuildName }}, the name of the Build.
+	// - {{ .Timestamp }}, the creation time of the Build in UTC, formatted as 20060102-150405.
+	// - {{ .GitSHA }}, the value of the forge.build/git-sha annotation of the Build, which must be set.
+	// - {{ .Serial }}, the serial of the Build within its BuildTemplate, starting at 1, it requires spec.templateRef.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	NamePolicy string `json:"namePolicy,omitempty"`
+
+	// Replication copies the machine image to other regions and shares it with other accounts once it has been
+	// created, the status of each target is reported in status.replications.
+	// +optional
+	Replication *ReplicationSpec `json:"replication,omitempty"`
+
+	// Signing signs the exports of the Build with cosign once they are published, along with an in-toto attestation
+	// of their SLSA provenance. The signatures and the attestations are published alongside the exports.
+	// +optional
+	Signing *SigningSpec `json:"signing,omitempty"`
+
+	// SBOM generates the software bill of materials of the machine once the provisioners completed, before the
+	// image is created. It is reported in status.sbom, and attached as a referrer to the OCI artifacts of the exports.
+	// +optional
+	SBOM *SBOMSpec `json:"sbom,omitempty"`
+
+	// ExpireAfter is how long the machine image is kept once the Build completed, it sets the expiration time of
+	// the Image cataloguing it. The image does not expire when not set.
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+}
+
+// SBOMSpec defines how the software bill of materials of the machine of a Build is generated and stored.
+type SBOMSpec struct {
+	// Generator generates the SBOM on the machine: Syft scans its filesystem with syft, installed in a temporary
+	// directory when it is not on the PATH, PackageInventory lists the packages of dpkg, rpm or apk. Defaults to Syft.
+	// +optional
+	// +kubebuilder:default=Syft
+	// +kubebuilder:validation:Enum=Syft;PackageInventory
+	Generator SBOMGenerator `json:"generator,omitempty"`
+
+	// Format is the format of the SBOM, SPDX 2.3 or CycloneDX 1.5 JSON. Defaults to SPDX.
+	// +optional
+	// +kubebuilder:default=SPDX
+	// +kubebuilder:validation:Enum=SPDX;CycloneDX
+	Format SBOMFormat `json:"format,omitempty"`
+
+	// SyftVersion is the version of syft installed when the machine has none, defaults to v1.14.0.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+$`
+	SyftVersion string `json:"syftVersion,omitempty"`
+
+	// Destination is where the SBOM is stored, defaults to the ConfigMap named after the Build with the -sbom
+	// suffix. Only the SBOMs stored in a ConfigMap or a Secret are attached to the OCI artifacts of the exports.
+	// +optional
+	Destination *ExtractDestination `json:"destination,omitempty"`
+}
+
+// SBOMGenerator is how the software bill of materials of a machine is generated.
+type SBOMGenerator string
+
+const (
+	// SBOMGeneratorSyft scans the filesystem of the machine with syft.
+	SBOMGeneratorSyft SBOMGenerator = "Syft"
+
+	// SBOMGeneratorPackageInventory lists the packages installed on the machine with its package manager.
+	SBOMGeneratorPackageInventory SBOMGenerator = "PackageInventory"
+)
+
+// SBOMFormat is the format of a software bill of materials.
+type SBOMFormat string
+
+const (
+	// SBOMFormatSPDX is the SPDX 2.3 JSON format.
+	SBOMFormatSPDX SBOMFormat = "SPDX"
+
+	// SBOMFormatCycloneDX is the CycloneDX 1.5 JSON format.
+	SBOMFormatCycloneDX SBOMFormat = "CycloneDX"
+)
+
+// SBOMStatus is the software bill of materials generated from the machine of a Build.
+type SBOMStatus struct {
+	// Generator is how the SBOM has been generated.
+	Generator SBOMGenerator `json:"generator"`
+
+	// Format is the format of the SBOM.
+	Format SBOMFormat `json:"format"`
+
+	// Location is where the SBOM is stored, e.g. configmap/ubuntu-sbom/sbom.spdx.json.
+	Location string `json:"location"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the SBOM.
+	SHA256 string `json:"sha256"`
+
+	// Size is the size of the SBOM in bytes.
+	Size int64 `json:"size"`
+}
+
+// SigningSpec defines how the exports of a Build are signed, exactly one of KeyRef and Keyless must be set. The
+// Vagrant boxes are not signed, their checksums are in their catalog or their box registry.
+// +kubebuilder:validation:XValidation:rule="has(self.keyRef) != has(self.keyless)",message="exactly one of keyRef and keyless must be set"
+type SigningSpec struct {
+	// KeyRef is the secret holding the cosign private key in its cosign.key key, and its password in its
+	// cosign.password key if it is encrypted.
+	// +optional
+	KeyRef *corev1.LocalObjectReference `json:"keyRef,omitempty"`
+
+	// Keyless signs with a short-lived certificate issued by Fulcio to the service account of the export Jobs.
+	// +optional
+	Keyless *KeylessSigning `json:"keyless,omitempty"`
+
+	// RekorURL is the transparency log the signatures are recorded in. It defaults to https://rekor.sigstore.dev
+	// for the keyless signatures, the signatures made with a key are not recorded when not set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://.+$`
+	RekorURL string `json:"rekorURL,omitempty"`
+}
+
+// KeylessSigning defines the certificate authority issuing the signing certificates of the keyless signatures.
+type KeylessSigning struct {
+	// FulcioURL is the Fulcio certificate authority, defaults to https://fulcio.sigstore.dev. It must trust the
+	// issuer of the service account tokens of the cluster.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://.+$`
+	FulcioURL string `json:"fulcioURL,omitempty"`
+
+	// Audience is the audience of the service account token presented to Fulcio, defaults to sigstore.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// ReplicationSpec defines the targets the infrastructure provider replicates the machine image to. The failure
+// of a target is reported in its status and does not fail the Build.
+type ReplicationSpec struct {
+	// Regions are the regions the image is copied to, in the account of the Build.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:MinLength=1
+	Regions []string `json:"regions,omitempty"`
+
+	// Accounts are the AWS accounts, GCP projects or Azure subscriptions the image is shared with: they are granted
+	// the permission to launch the image and its copies.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:MinLength=1
+	Accounts []string `json:"accounts,omitempty"`
+}
+
+// ReplicationTargetType is the type of a target of the replication of an image.
+type ReplicationTargetType string
+
+const (
+	// ReplicationTargetRegion is a region the image is copied to.
+	ReplicationTargetRegion ReplicationTargetType = "Region"
+
+	// ReplicationTargetAccount is an account the image is shared with.
+	ReplicationTargetAccount ReplicationTargetType = "Account"
+)
+
+// ReplicationPhase is the phase of the replication of an image to a target.
+type ReplicationPhase string
+
+const (
+	// ReplicationPhasePending is the phase of a target the image is being replicated to.
+	ReplicationPhasePending ReplicationPhase = "Pending"
+
+	// ReplicationPhaseReplicated is the phase of a target the image has been replicated to.
+	ReplicationPhaseReplicated ReplicationPhase = "Replicated"
+
+	// ReplicationPhaseFailed is the phase of a target the image could not be replicated to.
+	ReplicationPhaseFailed ReplicationPhase = "Failed"
+)
+
+// ReplicationStatus is the status of the replication of the image to a target.
+type ReplicationStatus struct {
+	// Type is the type of the target, Region or Account.
+	// +kubebuilder:validation:Enum=Region;Account
+	Type ReplicationTargetType `json:"type"`
+
+	// Name is the region or the account of the target.
+	Name string `json:"name"`
+
+	// Phase is the phase of the replication, one of Pending, Replicated or Failed.
+	// +kubebuilder:validation:Enum=Pending;Replicated;Failed
+	Phase ReplicationPhase `json:"phase"`
+
+	// ImageRef is the reference of the image in the target, e.g. the AMI ID of the copy in a region.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// Message describes why the image could not be replicated to the target.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// GetFormat returns the format of the SBOM.
+func (s *SBOMSpec) GetFormat() SBOMFormat {
+	if s.Format == "" {
+		return SBOMFormatSPDX
+	}
+	return s.Format
+}
+
+// GetGenerator returns how the SBOM is generated.
+func (s *SBOMSpec) GetGenerator() SBOMGenerator {
+	if s.Generator == "" {
+		return SBOMGeneratorSyft
+	}
+	return s.Generator
+}
+
+// GetDestination returns where the SBOM of the Build is stored.
+func (s *SBOMSpec) GetDestination(build *Build) ExtractDestination {
+	if s.Destination != nil {
+		return *s.Destination
+	}
+	return ExtractDestination{ConfigMapRef: &corev1.LocalObjectReference{Name: build.Name + SBOMConfigMapSuffix}}
+}
+
+// Key returns the name the SBOM is stored under, e.g. sbom.spdx.json.
+func (f SBOMFormat) Key() string {
+	if f == SBOMFormatCycloneDX {
+		return "sbom.cdx.json"
+	}
+	return "sbom.spdx.json"
+}
+
+// MediaType returns the media type of the SBOMs in the format.
+func (f SBOMFormat) MediaType() string {
+	if f == SBOMFormatCycloneDX {
+		return CycloneDXMediaType
+	}
+	return SPDXMediaType
+}
+
+// ArtifactNameData holds the values the name policy of an artifact is rendered with.
+type ArtifactNameData struct {
+	BuildName string
+	Timestamp string
+	GitSHA    string
+	Serial    int64
+}
+
+// NewArtifactNameData returns the values the name policy of the artifact of the Build is rendered with,
+// serial is the serial of the Build within its BuildTemplate.
+func NewArtifactNameData(build *Build, serial int64) ArtifactNameData {
+	return ArtifactNameData{
+		BuildName: build.Name,
+		Timestamp: build.CreationTimestamp.UTC().Format(ArtifactTimestampLayout),
+		GitSHA:    build.Annotations[GitSHAAnnotation],
+		Serial:    serial,
+	}
+}
+
+// Targets returns the targets of the replication, the regions then the accounts, in the Pending phase.
+func (r *ReplicationSpec) Targets() []ReplicationStatus {
+	if r == nil {
+		return nil
+	}
+	targets := make([]ReplicationStatus, 0, len(r.Regions)+len(r.Accounts))
+	for _, region := range r.Regions {
+		targets = append(targets, ReplicationStatus{Type: ReplicationTargetRegion, Name: region, Phase: ReplicationPhasePending})
+	}
+	for _, account := range r.Accounts {
+		targets = append(targets, ReplicationStatus{Type: ReplicationTargetAccount, Name: account, Phase: ReplicationPhasePending})
+	}
+	return targets
+}
+
+// UsesSerial returns true if the name policy uses the serial of the Build.
+func (a *ArtifactSpec) UsesSerial() bool {
+	return strings.Contains(a.NamePolicy, ".Serial")
+}
+
+// UsesGitSHA returns true if the name policy uses the commit of the Build.
+func (a *ArtifactSpec) UsesGitSHA() bool {
+	return strings.Contains(a.NamePolicy, ".GitSHA")
+}
+
+// RenderName returns the name of the artifact rendered from the name policy.
+func (a *ArtifactSpec) RenderName(data ArtifactNameData) (string, error) {
+	tpl, err := template.New("namePolicy").Option("missingkey=error").Parse(a.NamePolicy)
+	if err != nil {
+		return "", fmt.Errorf("invalid name policy: %w", err)
+	}
+	name := &bytes.Buffer{}
+	if err := tpl.Execute(name, data); err != nil {
+		return "", fmt.Errorf("invalid name policy: %w", err)
+	}
+	if !artifactNamePattern.MatchString(name.String()) {
+		return "", fmt.Errorf("invalid image name %q rendered from the name policy, it must match %s", name.String(), artifactNamePattern)
+	}
+	return name.String(), nil
+}
+
+// sampleArtifactNameData are the values the name policies are checked with.
+var sampleArtifactNameData = ArtifactNameData{
+	BuildName: "build",
+	Timestamp: time.Unix(0, 0).UTC().Format(ArtifactTimestampLayout),
+	GitSHA:    "0000000",
+	Serial:    1,
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderName(t *testing.T) {
+	build := &Build{ObjectMeta: metav1.ObjectMeta{
+		Name:              "ubuntu-nightly",
+		CreationTimestamp: metav1.NewTime(time.Date(2024, 5, 1, 2, 3, 4, 0, time.FixedZone("CEST", 2*3600))),
+		Annotations:       map[string]string{GitSHAAnnotation: "4f2c9e1"},
+	}}
+
+	testcases := []struct {
+		name     string
+		policy   string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "all tokens",
+			policy:   "{{ .BuildName }}-{{ .Timestamp }}-{{ .GitSHA }}-{{ .Serial }}",
+			expected: "ubuntu-nightly-20240501-000304-4f2c9e1-7",
+		},
+		{
+			name:     "static name",
+			policy:   "ubuntu",
+			expected: "ubuntu",
+		},
+		{
+			name:    "unknown token",
+			policy:  "ubuntu-{{ .Version }}",
+			wantErr: "can't evaluate field Version",
+		},
+		{
+			name:    "invalid template",
+			policy:  "ubuntu-{{ .Serial",
+			wantErr: "invalid name policy",
+		},
+		{
+			name:    "invalid image name",
+			policy:  "ubuntu/{{ .Serial }}",
+			wantErr: `invalid image name "ubuntu/7"`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			artifact := &ArtifactSpec{NamePolicy: tc.policy}
+			name, err := artifact.RenderName(NewArtifactNameData(build, 7))
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(name).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiconversion "k8s.io/apimachinery/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/forge-build/forge/api/v1beta1"
+)
+
+// ConvertTo converts this Build to the Hub version (v1beta1).
+func (src *Build) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Build)
+	return Convert_v1alpha1_Build_To_v1beta1_Build(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *Build) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Build)
+	return Convert_v1beta1_Build_To_v1alpha1_Build(src, dst, nil)
+}
+
+// ConvertTo converts this BuildList to the Hub version (v1beta1).
+func (src *BuildList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.BuildList)
+	return Convert_v1alpha1_BuildList_To_v1beta1_BuildList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *BuildList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.BuildList)
+	return Convert_v1beta1_BuildList_To_v1alpha1_BuildList(src, dst, nil)
+}
+
+// Convert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec moves the ssh options of the connector
+// to the ssh settings of the connector.
+func Convert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec(in *ConnectorSpec, out *v1beta1.ConnectorSpec, s apiconversion.Scope) error {
+	if err := autoConvert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec(in, out, s); err != nil {
+		return err
+	}
+	out.SSH.Port = in.Port
+	out.SSH.Username = in.Username
+	if in.Bastion != nil {
+		out.SSH.Bastion = &v1beta1.BastionSpec{}
+		if err := Convert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec(in.Bastion, out.SSH.Bastion, s); err != nil {
+			return err
+		}
+	}
+	out.SSH.HostKeyPolicy = v1beta1.HostKeyPolicy(in.HostKeyPolicy)
+	if in.Sudo != nil {
+		out.SSH.Sudo = &v1beta1.SudoSpec{}
+		if err := Convert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec(in.Sudo, out.SSH.Sudo, s); err != nil {
+			return err
+		}
+	}
+	out.SSH.Timeout = in.Timeout
+	return nil
+}
+
+// Convert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec moves the ssh settings of the connector
+// to the ssh options of the connector.
+func Convert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec(in *v1beta1.ConnectorSpec, out *ConnectorSpec, s apiconversion.Scope) error {
+	if err := autoConvert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec(in, out, s); err != nil {
+		return err
+	}
+	out.Port = in.SSH.Port
+	out.Username = in.SSH.Username
+	if in.SSH.Bastion != nil {
+		out.Bastion = &BastionSpec{}
+		if err := Convert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec(in.SSH.Bastion, out.Bastion, s); err != nil {
+			return err
+		}
+	}
+	out.HostKeyPolicy = HostKeyPolicy(in.SSH.HostKeyPolicy)
+	if in.SSH.Sudo != nil {
+		out.Sudo = &SudoSpec{}
+		if err := Convert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec(in.SSH.Sudo, out.Sudo, s); err != nil {
+			return err
+		}
+	}
+	out.Timeout = in.SSH.Timeout
+	return nil
+}
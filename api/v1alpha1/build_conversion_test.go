@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/api/v1beta1"
+	utilconversion "github.com/forge-build/forge/util/conversion"
+)
+
+func TestFuzzyConversion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(v1beta1.AddToScheme(scheme)).To(Succeed())
+
+	t.Run("for Build", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme: scheme,
+		Hub:    &v1beta1.Build{},
+		Spoke:  &buildv1.Build{},
+	}))
+}
+
+func TestConvertConnector(t *testing.T) {
+	g := NewWithT(t)
+
+	spoke := &buildv1.Build{Spec: buildv1.BuildSpec{Connector: buildv1.ConnectorSpec{
+		Type:          buildv1.ConnectorTypeSSH,
+		Port:          2222,
+		Username:      "ubuntu",
+		Bastion:       &buildv1.BastionSpec{Credentials: corev1.LocalObjectReference{Name: "bastion"}, Port: 2200},
+		HostKeyPolicy: buildv1.HostKeyPolicyStrict,
+		Sudo:          &buildv1.SudoSpec{User: "admin", Password: true},
+		Timeout:       &metav1.Duration{Duration: 30 * time.Second},
+		Credentials:   &corev1.LocalObjectReference{Name: "ssh-credentials"},
+	}}}
+	hub := &v1beta1.Build{}
+	g.Expect(spoke.ConvertTo(hub)).To(Succeed())
+	g.Expect(hub.Spec.Connector).To(Equal(v1beta1.ConnectorSpec{
+		Type: v1beta1.ConnectorTypeSSH,
+		SSH: v1beta1.SSHConnectorSpec{
+			Port:          2222,
+			Username:      "ubuntu",
+			Bastion:       &v1beta1.BastionSpec{Credentials: corev1.LocalObjectReference{Name: "bastion"}, Port: 2200},
+			HostKeyPolicy: v1beta1.HostKeyPolicy("Strict"),
+			Sudo:          &v1beta1.SudoSpec{User: "admin", Password: true},
+			Timeout:       &metav1.Duration{Duration: 30 * time.Second},
+		},
+		Credentials: &corev1.LocalObjectReference{Name: "ssh-credentials"},
+	}))
+
+	restored := &buildv1.Build{}
+	g.Expect(restored.ConvertFrom(hub)).To(Succeed())
+	g.Expect(restored.Spec.Connector).To(Equal(spoke.Spec.Connector))
+}
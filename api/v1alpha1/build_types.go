@@ -17,10 +17,13 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"path"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
-	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	builderror "github.com/forge-build/forge/pkg/errors"
 )
@@ -29,46 +32,445 @@ const (
 	// BuildFinalizer is the finalizer used by the Build controller to
 	// cleanup the build resources when a Build is being deleted.
 	BuildFinalizer = "build.forge.build"
+
+	// DefaultKubeconfigKey is the key of the secret holding the kubeconfig of a provisioner when not set.
+	DefaultKubeconfigKey = "value"
+
+	// DefaultFileMode is the permission bits of the files uploaded by the steps of a provisioner, or by a
+	// built-in/file provisioner, when not set.
+	DefaultFileMode int32 = 0o644
+
+	// DefaultPowerShellMaxReboots is the number of reboots a script of a built-in/powershell provisioner
+	// may request when not set.
+	DefaultPowerShellMaxReboots int32 = 10
+
+	// DefaultPowerShellRebootTimeout is how long a built-in/powershell provisioner waits for the machine
+	// to be reachable again after a reboot when not set.
+	DefaultPowerShellRebootTimeout = 30 * time.Minute
+
+	// DefaultRestartTimeout is how long a built-in/restart provisioner waits for the machine to be reachable
+	// and ready after the reboot when not set.
+	DefaultRestartTimeout = 15 * time.Minute
+
+	// KubeconfigSecretAnnotation is set on the provisioner Jobs given a kubeconfig, to audit which
+	// Jobs had access to which kubeconfig secret.
+	KubeconfigSecretAnnotation = "forge.build/kubeconfig-secret"
+
+	// ScriptsHashAnnotation is set on the provisioner Jobs running the scripts of a ConfigMap, it records the hash
+	// of the scripts so the Job is replaced when the content of the ConfigMap changes.
+	ScriptsHashAnnotation = "forge.build/scripts-hash"
+
+	// ProvisionerAttemptAnnotation is set on the provisioner Jobs, it records the attempt of the provisioner run by
+	// the Job, starting from 1, so the Jobs of the previous attempts are told apart.
+	ProvisionerAttemptAnnotation = "forge.build/provisioner-attempt"
+
+	// DefaultProvisionerRetryDelay is how long to wait before recreating the failed Job of a provisioner when its
+	// retry delay is not set.
+	DefaultProvisionerRetryDelay = 30 * time.Second
+
+	// MaskedVariablesAnnotation is set on the variables secret of a Build, it lists the comma separated names
+	// of the variables whose values must be masked in the logs and the status of the provisioners.
+	MaskedVariablesAnnotation = "forge.build/masked-variables"
+
+	// ApprovedProvisionersAnnotation is set on a Build, it lists the comma separated names of the provisioners
+	// requiring approval which are allowed to run.
+	ApprovedProvisionersAnnotation = "forge.build/approved-provisioners"
 )
 
 // BuildSpec defines the desired state of Build
 type BuildSpec struct {
-	// Paused can be used to prevent controllers from processing the Cluster and all its associated objects.
+	// Paused freezes the Build: the Forge controllers, including the shell provisioner controller, stop processing
+	// the Build and its associated objects until it is unpaused. The forge.build/paused annotation has the same effect.
 	// +optional
 	Paused bool `json:"paused,omitempty"`
 
-	// Connector is the connector to the infrastructure machine
+	// TemplateRef is a reference to the BuildTemplate this Build is instantiated from.
+	// The connector, infrastructure and provisioners of the template are used when they are not set on the Build.
+	// +optional
+	TemplateRef *BuildTemplateReference `json:"templateRef,omitempty"`
+
+	// Variables are the values of the variables declared by the BuildTemplate, they are exported as environment
+	// variables to the shell provisioners. Variables sourced from a Secret or a ConfigMap are not substituted
+	// in the BuildTemplate, the values sourced from a Secret are masked in the logs and the status of the provisioners.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Variables []BuildVariable `json:"variables,omitempty"`
+
+	// Connector is the connector to the infrastructure machine, it is required unless set by the BuildTemplate.
 	// e.g., connector: {type: "ssh", credentials: {name: "aws-credentials", namespace: "default"}}
-	// +kubebuilder:validation:Required
-	Connector ConnectorSpec `json:"connector"`
+	// +optional
+	Connector ConnectorSpec `json:"connector,omitempty"`
 
-	// InfrastructureRef is a reference to the infrastructure object which contains the types of machines to build.
+	// InfrastructureRef is a reference to the infrastructure object which contains the types of machines to build,
+	// it is required unless set by the BuildTemplate.
 	// e.g. infrastructureRef: {kind: "AWSBuild", name: "ubuntu-2204"}
-	// +kubebuilder:validation:Required
-	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef"`
+	// +optional
+	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// IdentityRef is a reference to the ProviderIdentity holding the credentials used by the infrastructure provider.
+	// The Build fails before creating any infrastructure when the credentials are reported invalid.
+	// +optional
+	IdentityRef *corev1.LocalObjectReference `json:"identityRef,omitempty"`
+
+	// ImageName is the name of the machine image produced by the Build, infrastructure providers
+	// use it to name the exported image when set.
+	// +optional
+	ImageName string `json:"imageName,omitempty"`
+
+	// Artifact defines how the machine image produced by the Build is named, when spec.imageName is not set.
+	// +optional
+	Artifact *ArtifactSpec `json:"artifact,omitempty"`
+
+	// Scan scans the machine for vulnerabilities once the provisioners completed, the Build fails without
+	// creating its image when the scan does not pass.
+	// +optional
+	Scan *ScanSpec `json:"scan,omitempty"`
+
+	// ImageMetadata maps labels and annotations of the Build to the metadata of the image, the infrastructure
+	// providers set the resolved metadata, reported in status.imageMetadata, on the image they export.
+	// +optional
+	ImageMetadata *ImageMetadataSpec `json:"imageMetadata,omitempty"`
 
 	// Provisioners is a list of provisioners to run on the infrastructure machine
 	// +optional
 	Provisioners []ProvisionerSpec `json:"provisioners,omitempty"`
 
+	// From is the image of a previous Build the machine is created from, instead of the source image of the
+	// infrastructure. The provisioners unchanged since that Build are skipped, see status.cache.
+	// +optional
+	From *BuildSource `json:"from,omitempty"`
+
+	// Workspace is a temporary object storage prefix dedicated to the Build, its shell provisioners get presigned
+	// URLs of its objects to exchange large intermediate artifacts. It is purged once the Build has finished.
+	// +optional
+	Workspace *WorkspaceSpec `json:"workspace,omitempty"`
+
+	// ProvisionerJobPlacement defines in which namespace the Jobs of the shell provisioners run, overriding the
+	// default placement of the controller. Core runs them in the namespace of the Forge controller, Build in the
+	// namespace of the Build, so the quotas, network policies and RBAC of the namespace apply to them.
+	// +optional
+	// +kubebuilder:validation:Enum=Core;Build
+	ProvisionerJobPlacement ProvisionerJobPlacement `json:"provisionerJobPlacement,omitempty"`
+
 	// DeleteCascade is a flag to specify whether the built image(s)
 	// going to be cleaned up when the build is deleted.
 	// +optional
 	DeleteCascade bool `json:"deleteCascade,omitempty"`
+
+	// RetryPolicy defines how a failed Build is retried, the infrastructure is recreated and the provisioners
+	// are run again on every retry. Failed Builds are not retried if not set.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// PreemptionPolicy defines how the Build recovers from the preemption of its spot or preemptible machine: the
+	// infrastructure is recreated and the provisioners are run again on the new machine, without failing the Build.
+	// The default policy applies when not set.
+	// +optional
+	PreemptionPolicy *PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+
+	// Timeouts defines the deadlines of the phases of the Build. A Build exceeding one of them is failed
+	// and its infrastructure is deleted, unless the Build is going to be retried.
+	// +optional
+	Timeouts *BuildTimeouts `json:"timeouts,omitempty"`
+
+	// TTLSecondsAfterFinished limits the lifetime of a Build which has finished, either completed or failed
+	// without a retry. Once the TTL has expired the Build is deleted along with its infrastructure and
+	// provisioner Jobs. The Build is deleted as soon as it finishes if set to zero, and never if unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// Simulate runs the Build in simulation mode: no infrastructure is created, the scripts of the shell
+	// provisioners are checked with bash -n and shellcheck, and the Jobs which would run them are rendered
+	// in the <build>-simulation ConfigMap. The issues found are reported in the status of the provisioners.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="simulate is immutable"
+	Simulate bool `json:"simulate,omitempty"`
+
+	// DryRun runs the Build in dry-run mode: no infrastructure is created and nothing runs, neither on a machine nor
+	// in a Job. The Jobs, the scripts and the connector configuration the provisioners would use are rendered in the
+	// <build>-plan ConfigMap, e.g. to review the templates in CI.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="dryRun is immutable"
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Exports packages the produced image in other formats, e.g. Vagrant boxes, and publishes them once the image
+	// has been exported. The Build completes once all the exports have been published.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	Exports []ExportSpec `json:"exports,omitempty"`
+
+	// Architectures are the CPU architectures the image is built for. The Build creates a Build per architecture,
+	// each with its own copy of the infrastructure object with spec.architecture set, and records the image built
+	// for every architecture in status.architectures. The image is built for the architecture of the infrastructure
+	// object when not set.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="architectures is immutable"
+	Architectures []Architecture `json:"architectures,omitempty"`
+}
+
+// Rehearsal returns true if the Build runs in simulation or dry-run mode, no infrastructure is created and the
+// provisioners don't run on a machine.
+func (s *BuildSpec) Rehearsal() bool {
+	return s.Simulate || s.DryRun
+}
+
+const (
+	// DefaultRetryInitialBackoff is the delay before the first retry of a Build when not set in the RetryPolicy.
+	DefaultRetryInitialBackoff = time.Minute
+
+	// DefaultRetryMaxBackoff is the maximum delay between two retries of a Build when not set in the RetryPolicy.
+	DefaultRetryMaxBackoff = 30 * time.Minute
+
+	// DefaultMaxPreemptions is the number of times the machine of a Build is recreated as a spot machine when not
+	// set in the PreemptionPolicy.
+	DefaultMaxPreemptions = 2
+)
+
+// RetryPolicy defines how a failed Build is retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times the Build is retried.
+	// +kubebuilder:validation:Minimum=0
+	MaxRetries int32 `json:"maxRetries"`
+
+	// Backoff defines the delay between retries.
+	// +optional
+	Backoff *RetryBackoff `json:"backoff,omitempty"`
+
+	// RetryOn is the list of failure reasons the Build is retried on, e.g. CreateError or ProvisionerFailed.
+	// The Build is retried on any failure but InvalidConfiguration and InvalidCredentials if not set.
+	// +optional
+	RetryOn []builderror.BuildStatusError `json:"retryOn,omitempty"`
+}
+
+// PreemptionFallback is what a Build does once its machine has been preempted more than its MaxPreemptions.
+// +kubebuilder:validation:Enum=OnDemand;Fail
+type PreemptionFallback string
+
+const (
+	// PreemptionFallbackOnDemand recreates the machine on demand, without its spec.spot.
+	PreemptionFallbackOnDemand PreemptionFallback = "OnDemand"
+
+	// PreemptionFallbackFail fails the Build with the Preempted reason, it may still be retried by its RetryPolicy.
+	PreemptionFallbackFail PreemptionFallback = "Fail"
+)
+
+// PreemptionPolicy defines how a Build recovers from the preemption of its machine.
+type PreemptionPolicy struct {
+	// MaxPreemptions is the number of times the machine is recreated as a spot machine after a preemption,
+	// defaults to 2.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxPreemptions *int32 `json:"maxPreemptions,omitempty"`
+
+	// Fallback is what the Build does on the next preemption, defaults to OnDemand.
+	// +optional
+	Fallback PreemptionFallback `json:"fallback,omitempty"`
+}
+
+// RetryBackoff defines an exponential backoff, the delay doubles after every retry.
+type RetryBackoff struct {
+	// Initial is the delay before the first retry, defaults to 1m.
+	// +optional
+	Initial *metav1.Duration `json:"initial,omitempty"`
+
+	// Max is the maximum delay between two retries, defaults to 30m.
+	// +optional
+	Max *metav1.Duration `json:"max,omitempty"`
+}
+
+// BuildTimeouts defines the deadlines of a Build, the deadlines apply to every attempt of the Build.
+// A deadline which is not set never expires.
+type BuildTimeouts struct {
+	// MachineReadyTimeout is how long to wait for the infrastructure machine to be ready.
+	// +optional
+	MachineReadyTimeout *metav1.Duration `json:"machineReadyTimeout,omitempty"`
+
+	// ConnectionTimeout is how long to wait for the connection to the machine once the machine is ready.
+	// +optional
+	ConnectionTimeout *metav1.Duration `json:"connectionTimeout,omitempty"`
+
+	// ProvisioningTimeout is how long to wait for the provisioners to complete once the machine is connected.
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// OverallDeadline is how long to wait for the image to be exported.
+	// +optional
+	OverallDeadline *metav1.Duration `json:"overallDeadline,omitempty"`
+}
+
+// BuildTemplateReference is a reference to a BuildTemplate.
+type BuildTemplateReference struct {
+	// Name of the BuildTemplate.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace of the BuildTemplate, defaults to the namespace of the Build.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BuildVariable is the value of a variable of a Build.
+type BuildVariable struct {
+	// Name of the variable.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Value of the variable.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom is the source of the value of the variable, it cannot be used with value.
+	// +optional
+	ValueFrom *BuildVariableSource `json:"valueFrom,omitempty"`
+}
+
+// BuildVariableSource is the source of the value of a variable, exactly one of its fields must be set.
+type BuildVariableSource struct {
+	// SecretKeyRef selects a key of a Secret in the namespace of the Build.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// ConfigMapKeyRef selects a key of a ConfigMap in the namespace of the Build.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
 }
 
+const (
+	// ConnectorTypeSSH is the type of the connector connecting to the infrastructure machine with SSH.
+	ConnectorTypeSSH = "ssh"
+
+	// ConnectorTypeDockerExec is the type of the connector running the commands in the container of the
+	// infrastructure machine with docker exec, see DockerBuild.
+	ConnectorTypeDockerExec = "docker-exec"
+
+	// DefaultSSHPort is the port of the ssh connector when not set.
+	DefaultSSHPort = 22
+
+	// DefaultSSHUsername is the username of the ssh connector when not set.
+	DefaultSSHUsername = "root"
+)
+
 // ConnectorSpec defines the connector to the infrastructure machine
 type ConnectorSpec struct {
-	// Type is the type of connector to the infrastructure machine.
+	// Type is the type of connector to the infrastructure machine, ssh or docker-exec.
+	// The docker-exec connector only supports the provisioners run by a Job.
 	// e.g., type: "ssh"
 	Type string `json:"type"`
 
+	// Port is the port to connect to on the infrastructure machine, defaults to 22 for the ssh connector.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+
+	// Username is the user to connect as when the credentials secret has no username,
+	// defaults to root for the ssh connector.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Bastion is the bastion host to connect through to reach the infrastructure machine.
+	// +optional
+	Bastion *BastionSpec `json:"bastion,omitempty"`
+
+	// HostKeyPolicy defines how the host key of the infrastructure machine is verified, defaults to Insecure.
+	// Insecure accepts any host key, TrustOnFirstUse pins the host key presented by the first connection
+	// and Strict requires the host key to match the hostKey of the credentials secret.
+	// +optional
+	// +kubebuilder:validation:Enum=Insecure;TrustOnFirstUse;Strict
+	HostKeyPolicy HostKeyPolicy `json:"hostKeyPolicy,omitempty"`
+
+	// Sudo runs the commands on the infrastructure machine with sudo.
+	// +optional
+	Sudo *SudoSpec `json:"sudo,omitempty"`
+
+	// Timeout is how long a single connection attempt may take, defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
 	// Credentials is a reference to the secret containing the credentials to connect to the infrastructure machine
 	// The secret should contain the following
 	// - username
 	// - password and/or privateKey
 	// - host
 	Credentials *corev1.LocalObjectReference `json:"credentials,omitempty"`
+
+	// CredentialsProviderRef resolves the password and/or privateKey of the connector from an external secret
+	// manager on every connection, they are never stored in the credentials secret, which only holds what the
+	// infrastructure provider publishes, e.g. the host. It is not supported by the provisioners run by a Job.
+	// +optional
+	CredentialsProviderRef *CredentialsProviderReference `json:"credentialsProviderRef,omitempty"`
+
+	// EphemeralCredentials generates a key pair for this Build only in the credentials secret, named
+	// <build>-ssh-credentials when credentials is not set. The infrastructure provider authorizes its public key
+	// on the machine through its user data or metadata, and the secret is shredded once the Build has finished.
+	// +optional
+	EphemeralCredentials bool `json:"ephemeralCredentials,omitempty"`
+}
+
+// ProvisionerJobPlacement defines in which namespace the Jobs of the shell provisioners run.
+type ProvisionerJobPlacement string
+
+const (
+	// ProvisionerJobPlacementCore runs the Jobs in the namespace of the Forge controller.
+	ProvisionerJobPlacementCore ProvisionerJobPlacement = "Core"
+
+	// ProvisionerJobPlacementBuild runs the Jobs in the namespace of the Build.
+	ProvisionerJobPlacementBuild ProvisionerJobPlacement = "Build"
+)
+
+// HostKeyPolicy defines how the host key of the infrastructure machine is verified.
+type HostKeyPolicy string
+
+const (
+	// HostKeyPolicyInsecure accepts any host key.
+	HostKeyPolicyInsecure HostKeyPolicy = "Insecure"
+
+	// HostKeyPolicyTrustOnFirstUse pins the host key presented by the first connection to the machine.
+	HostKeyPolicyTrustOnFirstUse HostKeyPolicy = "TrustOnFirstUse"
+
+	// HostKeyPolicyStrict requires the host key to match the hostKey of the credentials secret.
+	HostKeyPolicyStrict HostKeyPolicy = "Strict"
+
+	// DefaultConnectorTimeout is how long a single connection attempt may take when the timeout is not set.
+	DefaultConnectorTimeout = 10 * time.Second
+)
+
+// GetTimeout returns the timeout of a single connection attempt.
+func (c *ConnectorSpec) GetTimeout() time.Duration {
+	if c.Timeout == nil {
+		return DefaultConnectorTimeout
+	}
+	return c.Timeout.Duration
+}
+
+// BastionSpec defines the bastion host to connect through.
+type BastionSpec struct {
+	// Credentials is a reference to the secret containing the credentials to connect to the bastion host,
+	// in the same format as the credentials of the connector.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
+
+	// Port is the port to connect to on the bastion host, defaults to 22.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+}
+
+// SudoSpec defines how commands are run with sudo.
+type SudoSpec struct {
+	// User is the user to run the commands as, defaults to root.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// Password pipes the password of the credentials secret to sudo, otherwise sudo must not prompt for a password.
+	// +optional
+	Password bool `json:"password,omitempty"`
 }
 
 // ProvisionerSpec defines the provisioner to run on the infrastructure machine
@@ -77,34 +479,163 @@ type ProvisionerSpec struct {
 	// +optional
 	UUID *string `json:"uuid,omitempty"`
 
+	// Name identifies the provisioner within the Build, it is used to reference the provisioner in DependsOn.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name,omitempty"`
+
+	// Order defines when the provisioner runs, provisioners with a lower order run first.
+	// Provisioners with the same order run in the order they are listed.
+	// +optional
+	Order *int32 `json:"order,omitempty"`
+
+	// DependsOn is the list of provisioner names which must complete before this provisioner runs.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// ConcurrencyGroup runs the provisioner in parallel with the other provisioners of the group, each in its own
+	// Job against the same machine. Once a provisioner of the group runs, the next provisioners of the group start
+	// as soon as the provisioners they depend on completed, the provisioners outside of the group wait for it.
+	// Only the provisioners running in a Job may be part of a group.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	ConcurrencyGroup string `json:"concurrencyGroup,omitempty"`
+
 	// Type is the type of provisioner to run on the infrastructure machine
 	// e.g., type: "builtin" or type: "external"
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=built-in/shell;external
+	// +kubebuilder:validation:Enum=built-in/shell;built-in/verify;built-in/ansible;built-in/file;built-in/powershell;built-in/chef-solo;built-in/puppet-apply;built-in/restart;built-in/harden;built-in/extract;external
 	Type ProvisionerType `json:"type"`
 
 	// AllowFail is a flag to allow the provisioner to fail
 	// +optional
 	AllowFail bool `json:"allowFail,omitempty"`
 
+	// RequireApproval pauses the Build before the provisioner runs, until the provisioner is approved
+	// with the forge.build/approved-provisioners annotation, e.g. with forgectl build approve.
+	// A provisioner requiring approval must have a name.
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
 	// Run is the command to run on the infrastructure machine
 	// +optional
 	Run *string `json:"run,omitempty"`
 
-	// RunConfigMapRef is the reference of the configmap containing the script to run on the infrastructure machine
+	// RunConfigMapRef is the reference of the configmap, in the namespace of the Build, containing the scripts
+	// to run on the infrastructure machine. Every key of the configmap is a script, they are run in the lexical
+	// order of their keys and the provisioner is restarted when the configmap changes while it is running.
 	// +optional
 	RunConfigMapRef *corev1.ObjectReference `json:"runConfigMapRef,omitempty"`
 
+	// Steps are the steps of a built-in/shell provisioner, run in order by a single Job instead of Run or
+	// RunConfigMapRef. The outcome of every step is reported in the status of the provisioner.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Steps []ProvisionerStep `json:"steps,omitempty"`
+
+	// Kubeconfig is a kubeconfig made available to the script of a built-in/shell provisioner through
+	// the KUBECONFIG environment variable, e.g. to get a join token from a workload cluster.
+	// +optional
+	Kubeconfig *KubeconfigSource `json:"kubeconfig,omitempty"`
+
+	// Verify are the assertions checked on the infrastructure machine by a built-in/verify provisioner.
+	// +optional
+	Verify *VerifySpec `json:"verify,omitempty"`
+
+	// Ansible is the playbook run on the infrastructure machine by a built-in/ansible provisioner.
+	// +optional
+	Ansible *AnsibleSpec `json:"ansible,omitempty"`
+
+	// File are the files uploaded to the infrastructure machine by a built-in/file provisioner.
+	// +optional
+	File *FileProvisionerSpec `json:"file,omitempty"`
+
+	// PowerShell are the scripts run on a Windows infrastructure machine by a built-in/powershell provisioner.
+	// +optional
+	PowerShell *PowerShellProvisionerSpec `json:"powershell,omitempty"`
+
+	// ChefSolo are the cookbooks run with chef-solo on the infrastructure machine by a built-in/chef-solo provisioner.
+	// +optional
+	ChefSolo *ChefSoloSpec `json:"chefSolo,omitempty"`
+
+	// PuppetApply is the manifest applied with puppet apply on the infrastructure machine by a built-in/puppet-apply
+	// provisioner.
+	// +optional
+	PuppetApply *PuppetApplySpec `json:"puppetApply,omitempty"`
+
+	// Restart is how a built-in/restart provisioner reboots the infrastructure machine, the defaults reboot
+	// a Linux machine when not set.
+	// +optional
+	Restart *RestartSpec `json:"restart,omitempty"`
+
+	// Harden is the hardening profile applied to the infrastructure machine by a built-in/harden provisioner.
+	// +optional
+	Harden *HardenSpec `json:"harden,omitempty"`
+
+	// Extract are the files downloaded from the infrastructure machine by a built-in/extract provisioner.
+	// +optional
+	Extract *ExtractProvisionerSpec `json:"extract,omitempty"`
+
+	// Class is the name of the ProvisionerClass running an external provisioner.
+	// +optional
+	Class string `json:"class,omitempty"`
+
+	// Parameters are the parameters of an external provisioner, they are checked against the schema of its
+	// ProvisionerClass.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Parameters *apiextensionsv1.JSON `json:"parameters,omitempty"`
+
 	// Ref is a reference to the provisioner object which contains the types of provisioners to run.
 	Ref *corev1.ObjectReference `json:"ref,omitempty"`
 
-	// Retries is the number of retries for the provisioner
-	// before marking it as failed
+	// Retries is the number of times the Job of a provisioner running in a Job is recreated after it failed,
+	// before marking the provisioner as failed, e.g. so a flaky package mirror doesn't fail the Build.
+	// The attempts are recorded in the status of the provisioner. No retry when not set.
 	// +optional
-	// +kube:validation:Minimum=0
-	// +kube:validation:default=1
+	// +kubebuilder:validation:Minimum=0
 	Retries *int32 `json:"retries,omitempty"`
 
+	// RetryDelay is how long to wait before recreating the failed Job of the provisioner, defaults to 30s.
+	// +optional
+	RetryDelay *metav1.Duration `json:"retryDelay,omitempty"`
+
+	// BackoffLimit is the number of retries of the Pod of the Job running a built-in/shell or built-in/ansible
+	// provisioner before the Job fails, defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds is how long the Job running a built-in/shell or built-in/ansible provisioner may run,
+	// retries included, before the provisioner fails, e.g. when its script hangs on the infrastructure machine.
+	// No limit when not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Image is the image of the Job running a built-in/shell or built-in/ansible provisioner,
+	// it defaults to the image configured on the controller.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ImagePullPolicy is the pull policy of the image of the Job running a built-in/shell or built-in/ansible
+	// provisioner, it defaults to the pull policy configured on the controller.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// ImagePullSecrets are the secrets used to pull the image of the Job running a built-in/shell or built-in/ansible
+	// provisioner, they default to the secrets configured on the controller. The secrets must exist in the namespace
+	// of the Job, see spec.provisionerJobPlacement.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// PodTemplate customizes the Pod of the Job running a built-in/shell or built-in/ansible provisioner,
+	// e.g. where it is scheduled.
+	// +optional
+	PodTemplate *ProvisionerPodTemplate `json:"podTemplate,omitempty"`
+
 	// Status is the status of the provisioner
 	// +optional
 	// +kubebuilder:validation:Enum=Pending;Running;Completed;Failed;Unknown
@@ -118,78 +649,1245 @@ type ProvisionerSpec struct {
 	// FailureMessage is the message of the provisioner failure
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Issues are the problems found in the script of the provisioner when the Build is simulated.
+	// +optional
+	Issues []string `json:"issues,omitempty"`
+
+	// Results are the results of the assertions of a built-in/verify provisioner.
+	// +optional
+	Results []VerificationResult `json:"results,omitempty"`
 }
 
 type ProvisionerType string
 
 const (
-	ProvisionerTypeShell    ProvisionerType = "built-in/shell"
-	ProvisionerTypeExternal ProvisionerType = "external"
+	ProvisionerTypeShell       ProvisionerType = "built-in/shell"
+	ProvisionerTypeVerify      ProvisionerType = "built-in/verify"
+	ProvisionerTypeAnsible     ProvisionerType = "built-in/ansible"
+	ProvisionerTypeFile        ProvisionerType = "built-in/file"
+	ProvisionerTypePowerShell  ProvisionerType = "built-in/powershell"
+	ProvisionerTypeChefSolo    ProvisionerType = "built-in/chef-solo"
+	ProvisionerTypePuppetApply ProvisionerType = "built-in/puppet-apply"
+	ProvisionerTypeRestart     ProvisionerType = "built-in/restart"
+	ProvisionerTypeHarden      ProvisionerType = "built-in/harden"
+	ProvisionerTypeExtract     ProvisionerType = "built-in/extract"
+	ProvisionerTypeExternal    ProvisionerType = "external"
 )
 
-// BuildPhase BuildStatus defines the observed state of Build
-type BuildPhase string
+// RunsInJob returns true if the provisioners of the type run in a Job, the chef-solo and puppet-apply provisioners
+// run as the steps of a built-in/shell provisioner.
+func (t ProvisionerType) RunsInJob() bool {
+	switch t {
+	case ProvisionerTypeShell, ProvisionerTypeAnsible, ProvisionerTypeChefSolo, ProvisionerTypePuppetApply:
+		return true
+	}
+	return false
+}
 
-const (
-	BuildPhasePending     BuildPhase = "Pending"
-	BuildPhaseBuilding    BuildPhase = "Building"
-	BuildPhaseTerminating BuildPhase = "Terminating"
-	BuildPhaseCompleted   BuildPhase = "Completed"
-	BuildPhaseFailed      BuildPhase = "Failed"
-	BuildPhaseUnknown     BuildPhase = "Unknown"
-)
+// GetRetryDelay returns how long to wait before recreating the failed Job of the provisioner.
+func (p *ProvisionerSpec) GetRetryDelay() time.Duration {
+	if p.RetryDelay == nil {
+		return DefaultProvisionerRetryDelay
+	}
+	return p.RetryDelay.Duration
+}
 
-type ProvisionerStatus string
+// KubeconfigSource references a kubeconfig handed to a provisioner until it expires.
+type KubeconfigSource struct {
+	// SecretRef is the secret in the namespace of the Build holding the kubeconfig.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
 
-const (
-	ProvisionerStatusPending   ProvisionerStatus = "Pending"
-	ProvisionerStatusRunning   ProvisionerStatus = "Running"
-	ProvisionerStatusCompleted ProvisionerStatus = "Completed"
-	ProvisionerStatusFailed    ProvisionerStatus = "Failed"
-	ProvisionerStatusUnknown   ProvisionerStatus = "Unknown"
-)
+	// Key is the key of the secret holding the kubeconfig.
+	// +optional
+	// +kubebuilder:default=value
+	Key string `json:"key,omitempty"`
 
-type BuildStatus struct {
-	// FailureDomains is a slice of failure domain objects synced from the infrastructure provider.
+	// ExpirationTime is the time after which the kubeconfig is not handed to the provisioner anymore,
+	// the provisioner Job is stopped at this time. The credentials of the kubeconfig should be scoped
+	// to the needs of the provisioner and expire at the same time.
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// GetKey returns the key of the secret holding the kubeconfig.
+func (k *KubeconfigSource) GetKey() string {
+	if k.Key == "" {
+		return DefaultKubeconfigKey
+	}
+	return k.Key
+}
+
+// AnsibleSpec defines the playbook run by a built-in/ansible provisioner, exactly one of Playbook,
+// PlaybookConfigMapRef and Git must be set.
+type AnsibleSpec struct {
+	// Playbook is the inline playbook to run.
 	// +optional
-	FailureDomains FailureDomains `json:"failureDomains,omitempty"`
+	Playbook string `json:"playbook,omitempty"`
 
-	// FailureReason indicates that there is a fatal problem reconciling the
-	// state, and will be set to a token value suitable for
-	// programmatic interpretation.
+	// PlaybookConfigMapRef is the reference of the ConfigMap, in the namespace of the Build, holding the playbook
+	// and the files it uses, e.g. templates or variable files, under their file name. The provisioner is restarted
+	// when the ConfigMap changes while it is running.
 	// +optional
-	FailureReason *builderror.BuildStatusError `json:"failureReason,omitempty"`
+	PlaybookConfigMapRef *corev1.LocalObjectReference `json:"playbookConfigMapRef,omitempty"`
 
-	// FailureMessage indicates that there is a fatal problem reconciling the
-	// state, and will be set to a descriptive error message.
+	// Git is the git repository holding the playbook.
 	// +optional
-	FailureMessage *string `json:"failureMessage,omitempty"`
+	Git *AnsibleGitSource `json:"git,omitempty"`
 
-	// Conditions define the current service state of the cluster.
+	// PlaybookPath is the path of the playbook to run in the ConfigMap or in the git repository,
+	// defaults to playbook.yml.
 	// +optional
-	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+	PlaybookPath string `json:"playbookPath,omitempty"`
 
-	// InfrastructureReady is the state of the machine, which will be seted to true after it successfully in running state
-	//+optional
-	InfrastructureReady bool `json:"infrastructureReady,omitempty"`
+	// Requirements is the content of the requirements.yml file listing the roles and collections
+	// installed with ansible-galaxy before the playbook runs.
+	// +optional
+	Requirements string `json:"requirements,omitempty"`
 
-	// Connected describes if the connection to the underlying infrastructure machine has been established
-	//+optional
-	Connected bool `json:"connected,omitempty"`
+	// ExtraVars are the variables passed to the playbook with --extra-vars.
+	// +optional
+	ExtraVars map[string]string `json:"extraVars,omitempty"`
 
-	// ProvisionersReady describes the state of provisioners for the Build
-	// once all provisioners have finished successfully, this will be true
-	//+optional
-	ProvisionersReady bool `json:"provisionersReady,omitempty"`
+	// Tags only runs the plays and tasks tagged with these tags.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
 
-	// Build Phase which is used to track the state of the build process
-	// E.g. Pending, Building, Terminating, Failed etc.
-	//+optional
-	Phase string `json:"phase,omitempty"`
+	// SkipTags skips the plays and tasks tagged with these tags.
+	// +optional
+	SkipTags []string `json:"skipTags,omitempty"`
+}
 
-	// Ready is the state of the build process, true if machine image is ready, false if not
-	//+optional
+// AnsibleGitSource is the git repository holding the playbook of a built-in/ansible provisioner, or the files of
+// a built-in/chef-solo or built-in/puppet-apply provisioner.
+type AnsibleGitSource struct {
+	// URL is the URL of the repository, e.g. https://github.com/example/playbooks.git.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit checked out, defaults to the default branch of the repository.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// SecretRef is the secret, in the namespace of the Build, holding the username and password keys used
+	// to clone a private repository over https, the password may be an access token.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// ChefSoloSpec defines the cookbooks run with chef-solo by a built-in/chef-solo provisioner, the files of the source
+// are copied to the infrastructure machine before chef-solo runs. Chef Infra Client must be installed on the machine,
+// e.g. with InstallCommand.
+type ChefSoloSpec struct {
+	// Source holds the cookbooks, the roles and the data bags.
+	Source ConfigManagementSource `json:"source"`
+
+	// CookbookPaths are the paths of the cookbook directories in the source, defaults to cookbooks.
+	// +optional
+	CookbookPaths []string `json:"cookbookPaths,omitempty"`
+
+	// RolesPath is the path of the roles directory in the source.
+	// +optional
+	RolesPath string `json:"rolesPath,omitempty"`
+
+	// DataBagsPath is the path of the data bags directory in the source.
+	// +optional
+	DataBagsPath string `json:"dataBagsPath,omitempty"`
+
+	// RunList is the run list of the node, e.g. recipe[base] or role[web].
+	// +kubebuilder:validation:MinItems=1
+	RunList []string `json:"runList"`
+
+	// Attributes is the JSON object of the attributes of the node, e.g. {"nginx": {"port": 8080}}.
+	// +optional
+	Attributes string `json:"attributes,omitempty"`
+
+	// InstallCommand is run on the machine when chef-solo is not installed.
+	// +optional
+	InstallCommand string `json:"installCommand,omitempty"`
+}
+
+// PuppetApplySpec defines the manifest applied with puppet apply by a built-in/puppet-apply provisioner, the files of
+// the source are copied to the infrastructure machine before the manifest is applied. Puppet must be installed on
+// the machine, e.g. with InstallCommand.
+type PuppetApplySpec struct {
+	// Source holds the manifests, the modules and the hiera data.
+	Source ConfigManagementSource `json:"source"`
+
+	// ManifestPath is the path of the manifest applied in the source, defaults to manifests/site.pp.
+	// +optional
+	ManifestPath string `json:"manifestPath,omitempty"`
+
+	// ModulePaths are the paths of the module directories in the source, defaults to modules.
+	// +optional
+	ModulePaths []string `json:"modulePaths,omitempty"`
+
+	// HieraConfigPath is the path of the hiera configuration in the source.
+	// +optional
+	HieraConfigPath string `json:"hieraConfigPath,omitempty"`
+
+	// Facts are the custom facts of the node, exported as FACTER_ environment variables.
+	// +optional
+	Facts map[string]string `json:"facts,omitempty"`
+
+	// ExtraArguments are appended to the puppet apply command.
+	// +optional
+	ExtraArguments []string `json:"extraArguments,omitempty"`
+
+	// InstallCommand is run on the machine when puppet is not installed.
+	// +optional
+	InstallCommand string `json:"installCommand,omitempty"`
+}
+
+// ConfigManagementSource holds the files of a built-in/chef-solo or built-in/puppet-apply provisioner, exactly one
+// of configMapRef and git must be set.
+type ConfigManagementSource struct {
+	// ConfigMapRef is the reference of the ConfigMap, in the namespace of the Build, holding the files. The provisioner
+	// is restarted when the ConfigMap changes while it is running.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// Items are the paths of the keys of the ConfigMap in the source, as the keys cannot hold directories.
+	// Every key of the ConfigMap is copied under its name when empty.
+	// +optional
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+
+	// Git is the git repository holding the files, it is cloned by the Job of the provisioner.
+	// +optional
+	Git *AnsibleGitSource `json:"git,omitempty"`
+}
+
+// AnsiblePlayStatus is the outcome of a play of a built-in/ansible provisioner.
+type AnsiblePlayStatus struct {
+	// Name is the name of the play.
+	Name string `json:"name"`
+
+	// Phase is the phase of the play, either Completed or Failed.
+	Phase ProvisionerStatus `json:"phase"`
+
+	// Ok is the number of tasks which succeeded, changed included.
+	// +optional
+	Ok int32 `json:"ok,omitempty"`
+
+	// Changed is the number of tasks which changed the infrastructure machine.
+	// +optional
+	Changed int32 `json:"changed,omitempty"`
+
+	// Failed is the number of tasks which failed.
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// Skipped is the number of tasks which have been skipped.
+	// +optional
+	Skipped int32 `json:"skipped,omitempty"`
+
+	// Unreachable is the number of tasks which failed as the infrastructure machine was unreachable.
+	// +optional
+	Unreachable int32 `json:"unreachable,omitempty"`
+
+	// Message describes the failure of the play.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FileProvisionerSpec defines the files uploaded to the infrastructure machine by a built-in/file provisioner.
+type FileProvisionerSpec struct {
+	// Files are the files uploaded, in order. A file the machine already holds with the same content is not
+	// uploaded again, so the provisioner can be re-run.
+	// +kubebuilder:validation:MinItems=1
+	Files []FileUpload `json:"files"`
+}
+
+// FileUpload is a file uploaded to the infrastructure machine, exactly one of configMapKeyRef, secretKeyRef,
+// url and oci must be set. The content is fetched by the controller and may not exceed 64MiB.
+type FileUpload struct {
+	// Destination is the absolute path of the file on the infrastructure machine, its parent directories are created.
+	// +kubebuilder:validation:MinLength=1
+	Destination string `json:"destination"`
+
+	// Mode is the permission bits of the file, defaults to 0644.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	Mode *int32 `json:"mode,omitempty"`
+
+	// Owner is the owner of the file, as user or user:group, it defaults to the user running the commands
+	// of the connector.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z_][a-z0-9_.-]*(:[a-z_][a-z0-9_.-]*)?$`
+	Owner string `json:"owner,omitempty"`
+
+	// SHA256 is the expected hex encoded SHA-256 checksum of the content, the provisioner fails when
+	// the content of the source does not match.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-fA-F0-9]{64}$`
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ConfigMapKeyRef is the key of a ConfigMap, in the namespace of the Build, holding the content
+	// in its data or its binaryData.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// SecretKeyRef is the key of a Secret, in the namespace of the Build, holding the content.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// URL is the http or https URL the content is downloaded from.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// OCI is the OCI artifact holding the content.
+	// +optional
+	OCI *OCIArtifactSource `json:"oci,omitempty"`
+}
+
+// OCIArtifactSource is a file of an OCI artifact, e.g. pushed with oras.
+type OCIArtifactSource struct {
+	// Reference is the reference of the artifact, e.g. ghcr.io/example/assets:v1 or ghcr.io/example/assets@sha256:...
+	// +kubebuilder:validation:MinLength=1
+	Reference string `json:"reference"`
+
+	// File is the title of the layer of the artifact holding the content, its org.opencontainers.image.title
+	// annotation. It may be omitted when the artifact has a single layer.
+	// +optional
+	File string `json:"file,omitempty"`
+
+	// PullSecretRef is the kubernetes.io/dockerconfigjson secret, in the namespace of the Build, holding
+	// the credentials of the registry.
+	// +optional
+	PullSecretRef *corev1.LocalObjectReference `json:"pullSecretRef,omitempty"`
+}
+
+// GetMode returns the permission bits of the file.
+func (f *FileUpload) GetMode() int32 {
+	return ptr.Deref(f.Mode, DefaultFileMode)
+}
+
+// UploadedFileStatus is the outcome of a file of a built-in/file provisioner.
+type UploadedFileStatus struct {
+	// Destination is the path of the file on the infrastructure machine.
+	Destination string `json:"destination"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the content of the file.
+	SHA256 string `json:"sha256"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// Uploaded is false when the machine already held the file with the same content.
+	Uploaded bool `json:"uploaded"`
+}
+
+// ExtractProvisionerSpec defines the files downloaded from the infrastructure machine by a built-in/extract
+// provisioner, e.g. build logs, package manifests or generated configs, and where they are stored.
+type ExtractProvisionerSpec struct {
+	// Files are the files downloaded, in order. A file may not exceed 64MiB.
+	// +kubebuilder:validation:MinItems=1
+	Files []ExtractFile `json:"files"`
+
+	// Destination is where the files are stored.
+	Destination ExtractDestination `json:"destination"`
+}
+
+// ExtractFile is a file downloaded from the infrastructure machine.
+type ExtractFile struct {
+	// Path is the absolute path of the file on the infrastructure machine. The file is copied with the connector's
+	// sudo before it is downloaded, so it may be readable by root only.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Key is the name the file is stored under, defaults to the base name of the path. It is the key of the file
+	// in a ConfigMap or a Secret, the name of its object under the prefix of an object storage URL, or the title
+	// of its layer in an OCI artifact.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +kubebuilder:validation:MaxLength=253
+	Key string `json:"key,omitempty"`
+
+	// Optional skips the file when it does not exist on the machine instead of failing the provisioner.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// GetKey returns the name the file is stored under.
+func (f *ExtractFile) GetKey() string {
+	if f.Key != "" {
+		return f.Key
+	}
+	return path.Base(f.Path)
+}
+
+// ExtractDestination is where a built-in/extract provisioner stores the files, exactly one of configMapRef,
+// secretRef, objectStorage and oci must be set.
+type ExtractDestination struct {
+	// ConfigMapRef is the ConfigMap, in the namespace of the Build, the files are stored in under their key.
+	// It is created if needed and owned by the Build, the files may not exceed 1MiB in total.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// SecretRef is the Secret, in the namespace of the Build, the files are stored in under their key.
+	// It is created if needed and owned by the Build, the files may not exceed 1MiB in total.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// ObjectStorage is the S3 compatible bucket the files are uploaded to, under the prefix of its URL. A Google
+	// Cloud Storage bucket is reached through its https://storage.googleapis.com endpoint with HMAC keys.
+	// +optional
+	ObjectStorage *ObjectStorageDestination `json:"objectStorage,omitempty"`
+
+	// OCI is the OCI artifact the files are pushed as, a layer per file.
+	// +optional
+	OCI *OCIArtifactDestination `json:"oci,omitempty"`
+}
+
+// OCIArtifactDestination is an OCI artifact pushed to a registry, e.g. to be pulled with oras.
+type OCIArtifactDestination struct {
+	// Reference is the tagged reference the artifact is pushed to, e.g. ghcr.io/example/build-logs:ubuntu.
+	// +kubebuilder:validation:MinLength=1
+	Reference string `json:"reference"`
+
+	// PushSecretRef is the kubernetes.io/dockerconfigjson secret, in the namespace of the Build, holding
+	// the credentials of the registry.
+	// +optional
+	PushSecretRef *corev1.LocalObjectReference `json:"pushSecretRef,omitempty"`
+}
+
+// ExtractedFileStatus is the outcome of a file of a built-in/extract provisioner.
+type ExtractedFileStatus struct {
+	// Path is the path of the file on the infrastructure machine.
+	Path string `json:"path"`
+
+	// Key is the name the file is stored under.
+	Key string `json:"key"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the content of the file.
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Size is the size of the file in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// Location is where the file is stored, e.g. s3://logs/ubuntu/build.log or ghcr.io/example/build-logs@sha256:...
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Missing is true when the optional file does not exist on the machine, it is not stored.
+	// +optional
+	Missing bool `json:"missing,omitempty"`
+}
+
+// PowerShellProvisionerSpec defines the scripts run by a built-in/powershell provisioner on a Windows infrastructure
+// machine, through the ssh connector and the OpenSSH server of the machine.
+type PowerShellProvisionerSpec struct {
+	// Scripts are the scripts run, in order. A script exiting with 3010 requests a reboot of the machine, a script
+	// exiting with 1641 has initiated the reboot itself: the script runs again once the machine is back, with the
+	// FORGE_REBOOT_COUNT environment variable set to its number of reboots, until it exits with 0. The scripts
+	// must be idempotent, a script interrupted by the loss of the connection runs again from its start.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Scripts []PowerShellScript `json:"scripts"`
+
+	// MaxReboots is the number of reboots a script may request, the provisioner fails once a script requests
+	// more reboots. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReboots *int32 `json:"maxReboots,omitempty"`
+
+	// RebootTimeout is how long to wait for the machine to be reachable again after a reboot, it accounts for
+	// the updates installed while rebooting. Defaults to 30m.
+	// +optional
+	RebootTimeout *metav1.Duration `json:"rebootTimeout,omitempty"`
+}
+
+// PowerShellScript is a script of a built-in/powershell provisioner, exactly one of inline and configMapKeyRef
+// must be set.
+type PowerShellScript struct {
+	// Name identifies the script in the progress of the provisioner.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Inline is the content of the script.
+	// +optional
+	Inline string `json:"inline,omitempty"`
+
+	// ConfigMapKeyRef is the key of a ConfigMap, in the namespace of the Build, holding the content of the script.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// GetMaxReboots returns the number of reboots a script may request.
+func (s *PowerShellProvisionerSpec) GetMaxReboots() int32 {
+	return ptr.Deref(s.MaxReboots, DefaultPowerShellMaxReboots)
+}
+
+// GetRebootTimeout returns how long to wait for the machine to be reachable again after a reboot.
+func (s *PowerShellProvisionerSpec) GetRebootTimeout() time.Duration {
+	if s.RebootTimeout == nil {
+		return DefaultPowerShellRebootTimeout
+	}
+	return s.RebootTimeout.Duration
+}
+
+// PowerShellScriptStatus is the progress of a script of a built-in/powershell provisioner.
+type PowerShellScriptStatus struct {
+	// Name is the name of the script.
+	Name string `json:"name"`
+
+	// Phase is the phase of the script, one of Pending, Running, Rebooting, Completed or Failed.
+	// +kubebuilder:validation:Enum=Pending;Running;Rebooting;Completed;Failed
+	Phase string `json:"phase"`
+
+	// Reboots is the number of reboots requested by the script.
+	// +optional
+	Reboots int32 `json:"reboots,omitempty"`
+
+	// ExitCode is the exit code of the last run of the script.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// RebootStartedAt is the time the last reboot requested by the script started.
+	// +optional
+	RebootStartedAt *metav1.Time `json:"rebootStartedAt,omitempty"`
+}
+
+// RestartSpec defines how a built-in/restart provisioner reboots the infrastructure machine, through the connector,
+// and when the machine is ready again.
+type RestartSpec struct {
+	// Command is the command rebooting the machine. It must return before the machine shuts down, e.g. by
+	// scheduling the reboot with "shutdown.exe /r /t 5" on Windows. Defaults to rebooting a Linux machine
+	// in the background, which requires the connector user to be root or the connector sudo.
+	// +optional
+	Command string `json:"command,omitempty"`
+
+	// CheckCommand is run once the machine is reachable again, the machine is ready once it exits with 0.
+	// It runs again until the timeout, e.g. to wait for a service started at boot.
+	// +optional
+	CheckCommand string `json:"checkCommand,omitempty"`
+
+	// Timeout is how long to wait for the machine to be reachable and ready after the reboot. Defaults to 15m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// GetTimeout returns how long to wait for the machine to be reachable and ready after the reboot.
+func (s *RestartSpec) GetTimeout() time.Duration {
+	if s == nil || s.Timeout == nil {
+		return DefaultRestartTimeout
+	}
+	return s.Timeout.Duration
+}
+
+// HardenProfile is a set of hardening recommendations.
+type HardenProfile string
+
+// HardenPlatform is an operating system supported by the hardening profiles.
+type HardenPlatform string
+
+const (
+	// HardenProfileCISLevel1 applies the CIS Level 1 recommendations.
+	HardenProfileCISLevel1 HardenProfile = "cis-level1"
+
+	// HardenProfileCISLevel2 applies the CIS Level 1 and Level 2 recommendations.
+	HardenProfileCISLevel2 HardenProfile = "cis-level2"
+)
+
+const (
+	HardenPlatformUbuntu  HardenPlatform = "ubuntu"
+	HardenPlatformRHEL    HardenPlatform = "rhel"
+	HardenPlatformWindows HardenPlatform = "windows"
+)
+
+// HardenSpec defines the hardening profile applied by a built-in/harden provisioner. The recommendations of the
+// profile bundled with the controller are applied, then checked, in order.
+type HardenSpec struct {
+	// Profile is the hardening profile applied, cis-level2 includes the recommendations of cis-level1.
+	// +kubebuilder:validation:Enum=cis-level1;cis-level2
+	Profile HardenProfile `json:"profile"`
+
+	// Platform is the operating system of the machine. The ubuntu and rhel profiles require the connector user
+	// to be root or the connector sudo, the windows profiles run with PowerShell through the OpenSSH server
+	// of the machine.
+	// +kubebuilder:validation:Enum=ubuntu;rhel;windows
+	Platform HardenPlatform `json:"platform"`
+
+	// Exclude are the IDs of the recommendations not applied, as reported in the compliance summary. The
+	// recommendation disabling the root login over ssh is always excluded when the connector logs in as root.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ComplianceSummary is the compliance of the machine with the profile applied by a built-in/harden provisioner.
+type ComplianceSummary struct {
+	// Benchmark describes the profile applied, e.g. Forge subset of the CIS Ubuntu Linux 22.04 LTS Benchmark v1.0.0
+	// Level 1. The profiles apply a subset of the recommendations of the benchmarks.
+	Benchmark string `json:"benchmark"`
+
+	// Compliant is the number of recommendations the machine complies with.
+	Compliant int32 `json:"compliant"`
+
+	// NonCompliant is the number of recommendations the machine does not comply with.
+	NonCompliant int32 `json:"nonCompliant"`
+
+	// Excluded is the number of recommendations of the profile excluded by the provisioner.
+	// +optional
+	Excluded int32 `json:"excluded,omitempty"`
+
+	// Rules are the compliance of the machine with every recommendation applied.
+	// +optional
+	Rules []ComplianceRuleStatus `json:"rules,omitempty"`
+}
+
+// ComplianceRuleStatus is the compliance of the machine with a recommendation of a hardening profile.
+type ComplianceRuleStatus struct {
+	// ID is the ID of the recommendation in its benchmark.
+	ID string `json:"id"`
+
+	// Title is the title of the recommendation.
+	Title string `json:"title"`
+
+	// Compliant is true if the machine complies with the recommendation once applied.
+	Compliant bool `json:"compliant"`
+
+	// Message describes why the machine does not comply with the recommendation.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// VerifySpec defines the assertions checked on the infrastructure machine after provisioning.
+type VerifySpec struct {
+	// Files are the assertions on the files of the machine.
+	// +optional
+	Files []FileAssertion `json:"files,omitempty"`
+
+	// Packages are the assertions on the packages installed on the machine.
+	// +optional
+	Packages []PackageAssertion `json:"packages,omitempty"`
+
+	// Services are the assertions on the systemd services of the machine.
+	// +optional
+	Services []ServiceAssertion `json:"services,omitempty"`
+
+	// Commands are the assertions on the exit code and the output of commands run on the machine.
+	// +optional
+	Commands []CommandAssertion `json:"commands,omitempty"`
+
+	// Suites are the goss or InSpec test suites run on the machine, every test of a suite is an assertion.
+	// The tool of a suite must be installed on the machine.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Suites []VerifySuite `json:"suites,omitempty"`
+}
+
+// FileAssertion asserts the existence and the content of a file.
+type FileAssertion struct {
+	// Path is the absolute path of the file.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Absent asserts the file does not exist, the other assertions are ignored.
+	// +optional
+	Absent bool `json:"absent,omitempty"`
+
+	// SHA256 is the expected hex encoded SHA-256 checksum of the content of the file.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-fA-F0-9]{64}$`
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Contains is a string the content of the file must contain.
+	// +optional
+	Contains string `json:"contains,omitempty"`
+}
+
+// PackageAssertion asserts a package is installed, using dpkg or rpm.
+type PackageAssertion struct {
+	// Name is the name of the package.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Version is the expected version of the package, a trailing * matches the versions with the given prefix.
+	// Any version matches if empty.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// ServiceAssertion asserts the state of a systemd service.
+type ServiceAssertion struct {
+	// Name is the name of the systemd unit.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Running asserts the service is active, or inactive if false. Defaults to true.
+	// +optional
+	Running *bool `json:"running,omitempty"`
+
+	// Enabled asserts the service is enabled, or disabled if false. Not checked if unset.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ProvisionerPodTemplate customizes the Pod of the Job running a built-in/shell or built-in/ansible provisioner.
+type ProvisionerPodTemplate struct {
+	// Resources are the compute resources of the container running the provisioner.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector must match the labels of the node the Pod is scheduled on.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are the tolerations of the Pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity are the scheduling constraints of the Pod. The Pod is always scheduled on a linux node,
+	// unless a node affinity is set.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// ServiceAccountName is the service account the Pod runs as, in the namespace of the controller.
+	// It must be allowed to read the secrets of the Builds, defaults to forge-provisioner-shell.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// SecurityContext is the security context of the Pod.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// ContainerSecurityContext is the security context of the container running the provisioner.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+}
+
+// ProvisionerStep is a step of a built-in/shell provisioner, exactly one of Run, RunConfigMapRef and File is set.
+type ProvisionerStep struct {
+	// Name identifies the step within the provisioner.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Run is the script run by the step.
+	// +optional
+	Run *string `json:"run,omitempty"`
+
+	// RunConfigMapRef is the reference of the configmap, in the namespace of the Build, containing the scripts
+	// run by the step, in the lexical order of their keys.
+	// +optional
+	RunConfigMapRef *corev1.LocalObjectReference `json:"runConfigMapRef,omitempty"`
+
+	// File is the file uploaded to the infrastructure machine by the step.
+	// +optional
+	File *ProvisionerFile `json:"file,omitempty"`
+}
+
+// ProvisionerFile is a file uploaded to the infrastructure machine.
+type ProvisionerFile struct {
+	// Content is the content of the file.
+	Content string `json:"content"`
+
+	// Destination is the absolute path of the file on the infrastructure machine.
+	// +kubebuilder:validation:MinLength=1
+	Destination string `json:"destination"`
+
+	// Mode is the permission bits of the file, defaults to 0644.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	Mode *int32 `json:"mode,omitempty"`
+}
+
+// GetMode returns the permission bits of the file.
+func (f *ProvisionerFile) GetMode() int32 {
+	return ptr.Deref(f.Mode, DefaultFileMode)
+}
+
+// ProvisionerStepStatus is the outcome of a step of a built-in/shell provisioner.
+type ProvisionerStepStatus struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Phase is the phase of the step, one of Pending, Completed or Failed.
+	Phase ProvisionerStatus `json:"phase"`
+
+	// Message describes the failure of the step.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CommandAssertion asserts the exit code and the output of a command run on the machine.
+type CommandAssertion struct {
+	// Name describes the assertion, it defaults to the command.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Command is the command, run with sh. Its output is its standard output and error combined.
+	// +kubebuilder:validation:MinLength=1
+	Command string `json:"command"`
+
+	// ExitCode is the expected exit code of the command. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=255
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// Contains is a string the output of the command must contain.
+	// +optional
+	Contains string `json:"contains,omitempty"`
+
+	// Matches is a regular expression the output of the command must match.
+	// +optional
+	Matches string `json:"matches,omitempty"`
+}
+
+// VerifySuiteType is the tool running a test suite.
+type VerifySuiteType string
+
+const (
+	VerifySuiteTypeGoss   VerifySuiteType = "goss"
+	VerifySuiteTypeInSpec VerifySuiteType = "inspec"
+)
+
+// VerifySuite is a goss or InSpec test suite run on the machine by a built-in/verify provisioner.
+type VerifySuite struct {
+	// Name identifies the suite in the assertions of the provisioner.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Type is the tool running the suite, goss validates a gossfile and inspec executes a control file.
+	// +kubebuilder:validation:Enum=goss;inspec
+	Type VerifySuiteType `json:"type"`
+
+	// ConfigMapKeyRef is the key of a ConfigMap, in the namespace of the Build, holding the suite.
+	ConfigMapKeyRef corev1.ConfigMapKeySelector `json:"configMapKeyRef"`
+}
+
+// VerificationReport is the outcome of the assertions of a built-in/verify provisioner.
+type VerificationReport struct {
+	// Total is the number of assertions checked.
+	Total int32 `json:"total"`
+
+	// Passed is the number of assertions which hold.
+	Passed int32 `json:"passed"`
+
+	// Failed is the number of assertions which do not hold.
+	Failed int32 `json:"failed"`
+
+	// Failures are the assertions which do not hold, the results of all the assertions are recorded
+	// in the provisioner.
+	// +optional
+	Failures []VerificationResult `json:"failures,omitempty"`
+}
+
+// VerificationResult is the result of an assertion of a built-in/verify provisioner.
+type VerificationResult struct {
+	// Assertion describes the assertion, e.g. file /etc/motd exists.
+	Assertion string `json:"assertion"`
+
+	// Passed is true if the assertion holds on the machine.
+	Passed bool `json:"passed"`
+
+	// Message describes what has been found on the machine when the assertion does not hold.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BuildPhase is a string representation of the Build lifecycle, computed by the core controller.
+type BuildPhase string
+
+const (
+	// BuildPhasePending is the first state a Build is assigned by the controller after being created.
+	BuildPhasePending BuildPhase = "Pending"
+
+	// BuildPhaseProvisioning is the state when the infrastructure machine is being provisioned.
+	BuildPhaseProvisioning BuildPhase = "Provisioning"
+
+	// BuildPhaseConnecting is the state when the infrastructure machine is running
+	// and the controller is connecting to it.
+	BuildPhaseConnecting BuildPhase = "Connecting"
+
+	// BuildPhaseBuilding is the state when the provisioners are running on the infrastructure machine.
+	BuildPhaseBuilding BuildPhase = "Building"
+
+	// BuildPhaseExporting is the state when all the provisioners completed
+	// and the machine image is being exported.
+	BuildPhaseExporting BuildPhase = "Exporting"
+
+	// BuildPhaseCompleted is the state when the machine image has been exported.
+	BuildPhaseCompleted BuildPhase = "Completed"
+
+	// BuildPhaseFailed is the state when the Build failed and requires user intervention.
+	BuildPhaseFailed BuildPhase = "Failed"
+
+	// BuildPhaseTerminating is the state when a delete request has been sent to the API Server.
+	BuildPhaseTerminating BuildPhase = "Terminating"
+
+	// BuildPhaseUnknown is returned if the Build state cannot be determined.
+	BuildPhaseUnknown BuildPhase = "Unknown"
+)
+
+type ProvisionerStatus string
+
+const (
+	ProvisionerStatusPending   ProvisionerStatus = "Pending"
+	ProvisionerStatusRunning   ProvisionerStatus = "Running"
+	ProvisionerStatusCompleted ProvisionerStatus = "Completed"
+	ProvisionerStatusFailed    ProvisionerStatus = "Failed"
+	ProvisionerStatusUnknown   ProvisionerStatus = "Unknown"
+)
+
+type BuildStatus struct {
+	// FailureDomains is a slice of failure domain objects synced from the infrastructure provider.
+	// +optional
+	FailureDomains FailureDomains `json:"failureDomains,omitempty"`
+
+	// FailureReason indicates that there is a fatal problem reconciling the
+	// state, and will be set to a token value suitable for
+	// programmatic interpretation.
+	// +optional
+	FailureReason *builderror.BuildStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage indicates that there is a fatal problem reconciling the
+	// state, and will be set to a descriptive error message.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions define the current state of the Build, e.g. Ready, InfrastructureReady,
+	// MachineReady, Connected, ProvisionersReady and ImageExported.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// InfrastructureReady is the state of the machine, which will be seted to true after it successfully in running state
+	//+optional
+	InfrastructureReady bool `json:"infrastructureReady,omitempty"`
+
+	// Connected describes if the connection to the underlying infrastructure machine has been established
+	//+optional
+	Connected bool `json:"connected,omitempty"`
+
+	// Connection describes the connection established to the infrastructure machine, it is set once connected.
+	//+optional
+	Connection *ConnectionStatus `json:"connection,omitempty"`
+
+	// ProvisionersReady describes the state of provisioners for the Build
+	// once all provisioners have finished successfully, this will be true
+	//+optional
+	ProvisionersReady bool `json:"provisionersReady,omitempty"`
+
+	// Build Phase which is used to track the state of the build process
+	// E.g. Pending, Provisioning, Connecting, Building, Exporting, Completed, Failed or Terminating.
+	//+optional
+	//+kubebuilder:validation:Enum=Pending;Provisioning;Connecting;Building;Exporting;Completed;Failed;Terminating;Unknown
+	Phase string `json:"phase,omitempty"`
+
+	// ImageRef is the reference of the machine image produced by the Build, e.g. an AMI ID,
+	// as reported by the infrastructure provider in status.imageRef.
+	//+optional
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// Artifact describes the machine image produced by the Build, as reported by the infrastructure provider
+	// in status.artifact. It is set from status.imageRef for the providers not reporting an artifact.
+	//+optional
+	Artifact *BuildArtifact `json:"artifact,omitempty"`
+
+	// Replications are the statuses of the targets of spec.artifact.replication, as reported by the infrastructure
+	// provider. A failed target does not fail the Build.
+	//+optional
+	//+listType=map
+	//+listMapKey=type
+	//+listMapKey=name
+	Replications []ReplicationStatus `json:"replications,omitempty"`
+
+	// SBOM is the software bill of materials of the machine, generated as per spec.artifact.sbom once the
+	// provisioners completed.
+	//+optional
+	SBOM *SBOMStatus `json:"sbom,omitempty"`
+
+	// Scan is the outcome of the vulnerability scan of spec.scan.
+	//+optional
+	Scan *ScanStatus `json:"scan,omitempty"`
+
+	// Image is the name of the Image cataloguing the machine image, created once the Build completed.
+	//+optional
+	Image string `json:"image,omitempty"`
+
+	// Cache records the content hashes of the provisioners, and the ones skipped as they already ran on the image
+	// of spec.from.
+	//+optional
+	Cache *CacheStatus `json:"cache,omitempty"`
+
+	// ImageMetadata is the metadata the infrastructure provider sets on the image, resolved from spec.imageMetadata
+	// along with the name, namespace and UID of the Build. It is no longer updated once the image has been exported.
+	//+optional
+	ImageMetadata map[string]string `json:"imageMetadata,omitempty"`
+
+	// Ready is the state of the build process, true if machine image is ready, false if not
+	//+optional
 	Ready bool `json:"ready,omitempty"`
+
+	// StartTime is the time the current attempt of the Build started, the deadlines of the Build are relative to it.
+	//+optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the Build finished, either completed or failed without a retry.
+	//+optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Retries is the number of times the Build has been retried.
+	//+optional
+	Retries int32 `json:"retries,omitempty"`
+
+	// Preemptions is the number of times the machine of the Build has been preempted, over all its attempts.
+	//+optional
+	Preemptions int32 `json:"preemptions,omitempty"`
+
+	// LastPreemptionTime is the time the machine of the Build was last preempted, the MachineReadyTimeout of the
+	// recreated machine starts then.
+	//+optional
+	LastPreemptionTime *metav1.Time `json:"lastPreemptionTime,omitempty"`
+
+	// NextRetryTime is the time the failed Build is going to be retried, if a retry is scheduled.
+	//+optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// FailedAttempts records the failures of the previous attempts of the Build.
+	//+optional
+	FailedAttempts []BuildAttempt `json:"failedAttempts,omitempty"`
+
+	// Exports are the statuses of the exports of the Build.
+	//+optional
+	//+listType=map
+	//+listMapKey=name
+	Exports []ExportStatus `json:"exports,omitempty"`
+
+	// Architectures are the statuses of the Builds of the architectures of a multi-architecture Build.
+	//+optional
+	//+listType=map
+	//+listMapKey=architecture
+	Architectures []ArchitectureStatus `json:"architectures,omitempty"`
+
+	// Workspace is the status of the workspace of the Build.
+	//+optional
+	Workspace *WorkspaceStatus `json:"workspace,omitempty"`
+
+	// VariablesSecretName is the name of the secret holding the values of the variables exported to the provisioners.
+	//+optional
+	VariablesSecretName string `json:"variablesSecretName,omitempty"`
+
+	// AwaitingApproval is the name of the provisioner waiting to be approved before it runs.
+	//+optional
+	AwaitingApproval string `json:"awaitingApproval,omitempty"`
+
+	// Provisioners are the statuses of the provisioners run as Jobs, e.g. the shell provisioners,
+	// so the step which failed can be told apart.
+	//+optional
+	//+listType=map
+	//+listMapKey=uuid
+	Provisioners []BuildProvisionerStatus `json:"provisioners,omitempty"`
+
+	// Health summarizes the state of the Build for the GitOps tools.
+	//+optional
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// Progress estimates how far the current attempt of the Build has got, e.g. for the progress bars of dashboards.
+	//+optional
+	Progress *BuildProgress `json:"progress,omitempty"`
+}
+
+// BuildProgress estimates the progress of the current attempt of a Build, it is computed by the core controller on
+// every reconciliation. The steps of a Build are the provisioning of its infrastructure, the connection to its
+// machine, each of its provisioners, the export of its image and the publication of its exports, the ones of a
+// rehearsal leave out the infrastructure and the connection. The steps of a multi-architecture Build are the Builds
+// of its architectures.
+type BuildProgress struct {
+	// CurrentStep is the number of the step in progress, starting from 1. It is totalSteps once every step is done.
+	CurrentStep int32 `json:"currentStep"`
+
+	// TotalSteps is the number of steps of the Build.
+	TotalSteps int32 `json:"totalSteps"`
+
+	// Message describes the step in progress, e.g. Running provisioner harden.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Percent is the percentage of the steps done, all the steps weigh the same.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percent int32 `json:"percent"`
+}
+
+// BuildArtifact is a provider-agnostic description of the machine image produced by a Build,
+// so it can be consumed by downstream tooling. Infrastructure providers report it in the status.artifact
+// field of their infrastructure object once the image has been exported.
+type BuildArtifact struct {
+	// ID is the identifier of the image in the infrastructure, e.g. an AMI ID.
+	// +kubebuilder:validation:MinLength=1
+	ID string `json:"id"`
+
+	// Location is the URI of the image, e.g. s3://images/ubuntu-2204.qcow2 or
+	// projects/forge/global/images/ubuntu-2204.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Checksum is the checksum of the image, prefixed with the algorithm, e.g. sha256:2c26b4...
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// SizeBytes is the size of the image in bytes.
+	// +optional
+	SizeBytes *int64 `json:"sizeBytes,omitempty"`
+
+	// Format is the format of the image, e.g. ami, qcow2, vhd or raw.
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// CreatedAt is the time the image has been created.
+	// +optional
+	CreatedAt *metav1.Time `json:"createdAt,omitempty"`
+}
+
+// BuildProvisionerStatus is the status of a provisioner of a Build run as a Job.
+type BuildProvisionerStatus struct {
+	// UUID is the unique ID of the provisioner, as set in spec.provisioners.
+	UUID string `json:"uuid"`
+
+	// Name is the name of the provisioner.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Type is the type of the provisioner.
+	Type ProvisionerType `json:"type"`
+
+	// Phase is the phase of the provisioner, one of Pending, Running, Completed or Failed.
+	// +optional
+	Phase ProvisionerStatus `json:"phase,omitempty"`
+
+	// StartedAt is the time the Job of the provisioner started.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is the time the Job of the provisioner completed or failed.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// ExitCode is the exit code of the provisioner, once its Job finished.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// Attempts is the number of Jobs created for the provisioner, retries included.
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// NextAttemptAt is the time the failed Job of the provisioner is recreated, while it waits for its retry delay.
+	// +optional
+	NextAttemptAt *metav1.Time `json:"nextAttemptAt,omitempty"`
+
+	// Message describes the outcome of the provisioner, e.g. the reason it failed, or the progress of
+	// a running built-in/powershell provisioner.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Logs are the last lines of the logs of the Job of the provisioner, recorded when it failed.
+	// +optional
+	Logs string `json:"logs,omitempty"`
+
+	// LogsConfigMapName is the name of the ConfigMap holding the longer logs of the Job of the provisioner,
+	// recorded when it failed, under the name or the UUID of the provisioner.
+	// +optional
+	LogsConfigMapName string `json:"logsConfigMapName,omitempty"`
+
+	// Steps are the outcomes of the steps of a built-in/shell provisioner, once its Job finished.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Steps []ProvisionerStepStatus `json:"steps,omitempty"`
+
+	// Plays are the outcomes of the plays of a built-in/ansible provisioner, once its Job finished.
+	// +optional
+	Plays []AnsiblePlayStatus `json:"plays,omitempty"`
+
+	// Files are the outcomes of the files of a built-in/file provisioner, once they have been uploaded.
+	// +optional
+	Files []UploadedFileStatus `json:"files,omitempty"`
+
+	// Scripts are the progress of the scripts of a built-in/powershell provisioner.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Scripts []PowerShellScriptStatus `json:"scripts,omitempty"`
+
+	// RestartedAt is the time a built-in/restart provisioner rebooted the machine.
+	// +optional
+	RestartedAt *metav1.Time `json:"restartedAt,omitempty"`
+
+	// Report is the outcome of the assertions of a built-in/verify provisioner.
+	// +optional
+	Report *VerificationReport `json:"report,omitempty"`
+
+	// Compliance is the compliance of the machine with the profile applied by a built-in/harden provisioner.
+	// +optional
+	Compliance *ComplianceSummary `json:"compliance,omitempty"`
+
+	// Extracted are the outcomes of the files of a built-in/extract provisioner, once they have been stored.
+	// +optional
+	Extracted []ExtractedFileStatus `json:"extracted,omitempty"`
+}
+
+// BuildAttempt records the failure of an attempt of a Build.
+type BuildAttempt struct {
+	// Attempt is the number of the attempt, starting from 0 for the first attempt.
+	Attempt int32 `json:"attempt"`
+
+	// FailureReason is the failure reason of the attempt.
+	// +optional
+	FailureReason *builderror.BuildStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage is the failure message of the attempt.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// FailureTime is the time the failure of the attempt has been observed.
+	FailureTime metav1.Time `json:"failureTime"`
+}
+
+// ConnectionStatus describes the connection negotiated with the infrastructure machine, so it can be
+// troubleshot and audited without access to the controller logs. It holds no credentials.
+type ConnectionStatus struct {
+	// Address is the address the connector connected to, as host:port.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// AuthMethod is the authentication method used by the connector, either key or password.
+	// +optional
+	// +kubebuilder:validation:Enum=key;password
+	AuthMethod string `json:"authMethod,omitempty"`
+
+	// HostKeyType is the algorithm of the host key presented by the machine, e.g. ssh-ed25519.
+	// +optional
+	HostKeyType string `json:"hostKeyType,omitempty"`
+
+	// HostKeyFingerprint is the SHA256 fingerprint of the host key presented by the machine,
+	// e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s.
+	// +optional
+	HostKeyFingerprint string `json:"hostKeyFingerprint,omitempty"`
+
+	// ServerVersion is the version reported by the SSH server of the machine, e.g. SSH-2.0-OpenSSH_9.6.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	ServerVersion string `json:"serverVersion,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -198,6 +1896,12 @@ type BuildStatus struct {
 //+kubebuilder:printcolumn:name="Infrastructure",type="string",JSONPath=".spec.infrastructureRef.kind",description="Kind of infrastructure"
 //+kubebuilder:printcolumn:name="Connection",type="string",JSONPath=".status.connected",description="Connection"
 //+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Build Phase"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageRef",description="Reference of the built machine image"
+//+kubebuilder:printcolumn:name="Location",type="string",JSONPath=".status.artifact.location",description="Location of the built machine image",priority=1
+//+kubebuilder:printcolumn:name="Retries",type="integer",JSONPath=".status.retries",description="Number of retries",priority=1
+//+kubebuilder:printcolumn:name="Health",type="string",JSONPath=".status.health.status",description="Health of the Build",priority=1
+//+kubebuilder:printcolumn:name="Progress",type="integer",JSONPath=".status.progress.percent",description="Percentage of the steps of the Build done",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Build"
 
 // Build is the Schema for the builds API
 type Build struct {
@@ -218,12 +1922,12 @@ type BuildList struct {
 }
 
 // GetConditions returns the set of conditions for this object.
-func (c *Build) GetConditions() clusterv1.Conditions {
+func (c *Build) GetConditions() []metav1.Condition {
 	return c.Status.Conditions
 }
 
 // SetConditions sets the conditions on this object.
-func (c *Build) SetConditions(conditions clusterv1.Conditions) {
+func (c *Build) SetConditions(conditions []metav1.Condition) {
 	c.Status.Conditions = conditions
 }
 
@@ -269,21 +1973,24 @@ type FailureDomainSpec struct {
 
 // ANCHOR_END: ClusterStatus
 
-// SetTypedPhase sets the Phase field to the string representation of ClusterPhase.
+// SetTypedPhase sets the Phase field to the string representation of BuildPhase.
 func (c *BuildStatus) SetTypedPhase(p BuildPhase) {
 	c.Phase = string(p)
 }
 
 // GetTypedPhase attempts to parse the Phase field and return
-// the typed ClusterPhase representation as described in `machine_phase_types.go`.
+// the typed BuildPhase representation.
 func (c *BuildStatus) GetTypedPhase() BuildPhase {
 	switch phase := BuildPhase(c.Phase); phase {
 	case
 		BuildPhasePending,
+		BuildPhaseProvisioning,
+		BuildPhaseConnecting,
 		BuildPhaseBuilding,
-		BuildPhaseTerminating,
+		BuildPhaseExporting,
 		BuildPhaseCompleted,
-		BuildPhaseFailed:
+		BuildPhaseFailed,
+		BuildPhaseTerminating:
 		return phase
 	default:
 		return BuildPhaseUnknown
@@ -0,0 +1,867 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// imageMetadataNamePattern matches the image metadata keys accepted by all the clouds.
+var imageMetadataNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+
+// variableNamePattern matches the names of the variables, they are exported as environment variables.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// maxWorkspaceURLExpiration is the longest validity of a presigned URL accepted by the object storages.
+const maxWorkspaceURLExpiration = 7 * 24 * time.Hour
+
+// SetupWebhookWithManager sets up the Build webhooks with the manager.
+func (r *Build) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-forge-build-v1alpha1-build,mutating=true,failurePolicy=fail,sideEffects=None,groups=forge.build,resources=builds,verbs=create;update,versions=v1alpha1,name=mbuild.forge.build,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &Build{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *Build) Default() {
+	defaultConnector(&r.Spec.Connector)
+}
+
+// defaultConnector sets the default port and username of the ssh connector.
+func defaultConnector(connector *ConnectorSpec) {
+	if connector.Type != ConnectorTypeSSH {
+		return
+	}
+	if connector.Port == 0 {
+		connector.Port = DefaultSSHPort
+	}
+	if connector.Username == "" {
+		connector.Username = DefaultSSHUsername
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-forge-build-v1alpha1-build,mutating=false,failurePolicy=fail,sideEffects=None,groups=forge.build,resources=builds,verbs=create;update,versions=v1alpha1,name=vbuild.forge.build,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Build{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *Build) ValidateCreate() (admission.Warnings, error) {
+	return nil, r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *Build) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	oldBuild, ok := old.(*Build)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a Build but got a %T", old))
+	}
+	return nil, r.validate(oldBuild)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *Build) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *Build) validate(old *Build) error {
+	var oldSpec *BuildSpec
+	if old != nil {
+		oldSpec = &old.Spec
+	}
+	allErrs := validateBuildSpec(field.NewPath("spec"), &r.Spec, oldSpec)
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Build").GroupKind(), r.Name, allErrs)
+}
+
+// validateBuildSpec validates the spec of a Build, oldSpec is nil when the Build is created.
+func validateBuildSpec(path *field.Path, spec, oldSpec *BuildSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// Builds instantiated from a BuildTemplate get the connector and infrastructure from the template.
+	if spec.TemplateRef == nil {
+		if spec.Connector.Type == "" {
+			allErrs = append(allErrs, field.Required(path.Child("connector", "type"), "must be set when spec.templateRef is not set"))
+		}
+		if spec.InfrastructureRef == nil {
+			allErrs = append(allErrs, field.Required(path.Child("infrastructureRef"), "must be set when spec.templateRef is not set"))
+		}
+	}
+	if spec.Connector.Type != "" && spec.Connector.Type != ConnectorTypeSSH && spec.Connector.Type != ConnectorTypeDockerExec {
+		allErrs = append(allErrs, field.NotSupported(path.Child("connector", "type"), spec.Connector.Type,
+			[]string{ConnectorTypeSSH, ConnectorTypeDockerExec}))
+	}
+	allErrs = append(allErrs, validateConnector(path.Child("connector"), &spec.Connector)...)
+	allErrs = append(allErrs, validateVariables(path.Child("variables"), spec.Variables)...)
+	if ref := spec.InfrastructureRef; ref != nil {
+		refPath := path.Child("infrastructureRef")
+		if ref.APIVersion == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("apiVersion"), "must be set"))
+		}
+		if ref.Kind == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("kind"), "must be set"))
+		}
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("name"), "must be set"))
+		}
+	}
+
+	provisionersPath := path.Child("provisioners")
+	if _, err := ProvisionerExecutionOrder(spec.Provisioners); err != nil {
+		names := make([]string, 0, len(spec.Provisioners))
+		for _, p := range spec.Provisioners {
+			names = append(names, p.Name)
+		}
+		allErrs = append(allErrs, field.Invalid(provisionersPath, names, err.Error()))
+	}
+	uuids := map[string]bool{}
+	for i := range spec.Provisioners {
+		p := &spec.Provisioners[i]
+		if p.UUID != nil {
+			if uuids[*p.UUID] {
+				allErrs = append(allErrs, field.Duplicate(provisionersPath.Index(i).Child("uuid"), *p.UUID))
+			}
+			uuids[*p.UUID] = true
+		}
+		if p.RequireApproval && p.Name == "" {
+			allErrs = append(allErrs, field.Required(provisionersPath.Index(i).Child("name"), "must be set when requireApproval is set"))
+		}
+		allErrs = append(allErrs, validateKubeconfig(provisionersPath.Index(i), p, oldSpec)...)
+		allErrs = append(allErrs, validateProvisionerJob(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerConnector(provisionersPath.Index(i), p, &spec.Connector)...)
+		allErrs = append(allErrs, validateProvisionerSteps(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerAnsible(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerFile(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerPowerShell(provisionersPath.Index(i), p, &spec.Connector)...)
+		allErrs = append(allErrs, validateProvisionerConfigManagement(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerRestart(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerVerify(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerHarden(provisionersPath.Index(i), p, &spec.Connector)...)
+		allErrs = append(allErrs, validateProvisionerExtract(provisionersPath.Index(i), p)...)
+		allErrs = append(allErrs, validateProvisionerExternal(provisionersPath.Index(i), p)...)
+	}
+
+	if spec.DryRun && spec.Simulate {
+		allErrs = append(allErrs, field.Forbidden(path.Child("dryRun"), "not allowed with spec.simulate"))
+	}
+
+	if len(spec.Architectures) > 0 && len(spec.Exports) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path.Child("exports"),
+			"not supported with spec.architectures, an export packages the image of a single architecture"))
+	}
+
+	allErrs = append(allErrs, validateArtifact(path, spec, oldSpec)...)
+	allErrs = append(allErrs, validateFrom(path.Child("from"), spec)...)
+	allErrs = append(allErrs, validateImageMetadata(path.Child("imageMetadata"), spec.ImageMetadata)...)
+	allErrs = append(allErrs, validateWorkspace(path.Child("workspace"), spec.Workspace)...)
+	if spec.Scan != nil && spec.Scan.Destination != nil {
+		allErrs = append(allErrs, validateExtractDestination(path.Child("scan", "destination"), spec.Scan.Destination)...)
+	}
+	allErrs = append(allErrs, validateTimeouts(path.Child("timeouts"), spec.Timeouts)...)
+	allErrs = append(allErrs, validateRetryPolicy(path.Child("retryPolicy"), spec.RetryPolicy)...)
+	return allErrs
+}
+
+// validateProvisionerConnector validates the provisioner is supported by the connector, the provisioners connecting
+// to the machine from the controller require the ssh connector.
+func validateProvisionerConnector(path *field.Path, p *ProvisionerSpec, connector *ConnectorSpec) field.ErrorList {
+	// The Jobs mount the credentials secret, the credentials resolved by the controllers are not in it.
+	if connector.CredentialsProviderRef != nil && p.Type.RunsInJob() {
+		return field.ErrorList{field.Forbidden(path.Child("type"), fmt.Sprintf("%s provisioners are not supported by a connector with a credentialsProviderRef",
+			p.Type))}
+	}
+	if connector.Type != ConnectorTypeDockerExec || p.Type.RunsInJob() || p.Type == ProvisionerTypeExternal {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(path.Child("type"), fmt.Sprintf("%s provisioners are not supported by the %s connector",
+		p.Type, ConnectorTypeDockerExec))}
+}
+
+// validateProvisionerJob validates the settings of the Job running the provisioner, e.g. its image, are only set
+// on the provisioners run by a Job.
+func validateProvisionerJob(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	// The external provisioners may be run by the Job of their ProvisionerClass.
+	if p.Type.RunsInJob() || p.Type == ProvisionerTypeExternal {
+		return nil
+	}
+	detail := fmt.Sprintf("only allowed for %s, %s, %s, %s and %s provisioners",
+		ProvisionerTypeShell, ProvisionerTypeAnsible, ProvisionerTypeChefSolo, ProvisionerTypePuppetApply, ProvisionerTypeExternal)
+	if p.Image != "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("image"), detail))
+	}
+	if p.ImagePullPolicy != "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("imagePullPolicy"), detail))
+	}
+	if len(p.ImagePullSecrets) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path.Child("imagePullSecrets"), detail))
+	}
+	if p.PodTemplate != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("podTemplate"), detail))
+	}
+	if p.BackoffLimit != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("backoffLimit"), detail))
+	}
+	if p.ActiveDeadlineSeconds != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("activeDeadlineSeconds"), detail))
+	}
+	if p.ConcurrencyGroup != "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("concurrencyGroup"), detail))
+	}
+	if p.Retries != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("retries"), detail))
+	}
+	if p.RetryDelay != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("retryDelay"), detail))
+	}
+	return allErrs
+}
+
+// validateProvisionerAnsible validates the playbook is only set on the ansible provisioners, which require it,
+// and it has exactly one source.
+func validateProvisionerAnsible(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	ansiblePath := path.Child("ansible")
+	if p.Type != ProvisionerTypeAnsible {
+		if p.Ansible != nil {
+			allErrs = append(allErrs, field.Forbidden(ansiblePath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeAnsible)))
+		}
+		return allErrs
+	}
+	if p.Ansible == nil {
+		return append(allErrs, field.Required(ansiblePath, fmt.Sprintf("must be set for %s provisioners", ProvisionerTypeAnsible)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeAnsible)))
+	}
+
+	set := 0
+	for _, isSet := range []bool{p.Ansible.Playbook != "", p.Ansible.PlaybookConfigMapRef != nil, p.Ansible.Git != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		allErrs = append(allErrs, field.Invalid(ansiblePath, p.Name, "exactly one of playbook, playbookConfigMapRef and git must be set"))
+	}
+	if p.Ansible.Playbook != "" && p.Ansible.PlaybookPath != "" {
+		allErrs = append(allErrs, field.Forbidden(ansiblePath.Child("playbookPath"), "may not be set with an inline playbook"))
+	}
+	if playbookPath := p.Ansible.PlaybookPath; strings.HasPrefix(playbookPath, "/") || strings.Contains(playbookPath, "..") {
+		allErrs = append(allErrs, field.Invalid(ansiblePath.Child("playbookPath"), playbookPath, "must be a relative path within the playbook sources"))
+	}
+	if git := p.Ansible.Git; git != nil && !strings.HasPrefix(git.URL, "https://") && !strings.HasPrefix(git.URL, "http://") {
+		allErrs = append(allErrs, field.Invalid(ansiblePath.Child("git", "url"), git.URL, "must be an http or https URL"))
+	}
+	return allErrs
+}
+
+// validateProvisionerFile validates the files are only set on the file provisioners, which require them,
+// and every file has exactly one source and an absolute destination.
+func validateProvisionerFile(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	filePath := path.Child("file")
+	if p.Type != ProvisionerTypeFile {
+		if p.File != nil {
+			allErrs = append(allErrs, field.Forbidden(filePath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeFile)))
+		}
+		return allErrs
+	}
+	if p.File == nil {
+		return append(allErrs, field.Required(filePath, fmt.Sprintf("must be set for %s provisioners", ProvisionerTypeFile)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeFile)))
+	}
+
+	destinations := map[string]bool{}
+	for i, f := range p.File.Files {
+		fPath := filePath.Child("files").Index(i)
+		if !strings.HasPrefix(f.Destination, "/") {
+			allErrs = append(allErrs, field.Invalid(fPath.Child("destination"), f.Destination, "must be an absolute path"))
+		}
+		if destinations[f.Destination] {
+			allErrs = append(allErrs, field.Duplicate(fPath.Child("destination"), f.Destination))
+		}
+		destinations[f.Destination] = true
+
+		set := 0
+		for _, isSet := range []bool{f.ConfigMapKeyRef != nil, f.SecretKeyRef != nil, f.URL != "", f.OCI != nil} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			allErrs = append(allErrs, field.Invalid(fPath, f.Destination, "exactly one of configMapKeyRef, secretKeyRef, url and oci must be set"))
+		}
+		if f.URL != "" && !strings.HasPrefix(f.URL, "https://") && !strings.HasPrefix(f.URL, "http://") {
+			allErrs = append(allErrs, field.Invalid(fPath.Child("url"), f.URL, "must be an http or https URL"))
+		}
+	}
+	return allErrs
+}
+
+// validateProvisionerPowerShell validates the scripts are only set on the powershell provisioners, which require them,
+// every script has exactly one source and the connector does not wrap the commands with sudo, which Windows lacks.
+func validateProvisionerPowerShell(path *field.Path, p *ProvisionerSpec, connector *ConnectorSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	psPath := path.Child("powershell")
+	if p.Type != ProvisionerTypePowerShell {
+		if p.PowerShell != nil {
+			allErrs = append(allErrs, field.Forbidden(psPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypePowerShell)))
+		}
+		return allErrs
+	}
+	if p.PowerShell == nil {
+		return append(allErrs, field.Required(psPath, fmt.Sprintf("must be set for %s provisioners", ProvisionerTypePowerShell)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypePowerShell)))
+	}
+	if connector.Sudo != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("the connector sudo is not supported by %s provisioners", ProvisionerTypePowerShell)))
+	}
+
+	names := map[string]bool{}
+	for i, script := range p.PowerShell.Scripts {
+		scriptPath := psPath.Child("scripts").Index(i)
+		if names[script.Name] {
+			allErrs = append(allErrs, field.Duplicate(scriptPath.Child("name"), script.Name))
+		}
+		names[script.Name] = true
+		if (script.Inline != "") == (script.ConfigMapKeyRef != nil) {
+			allErrs = append(allErrs, field.Invalid(scriptPath, script.Name, "exactly one of inline and configMapKeyRef must be set"))
+		}
+	}
+	return allErrs
+}
+
+// validateProvisionerHarden validates the profile is only set on the harden provisioners, which require it,
+// and the connector does not wrap the commands of the windows profiles with sudo.
+func validateProvisionerHarden(path *field.Path, p *ProvisionerSpec, connector *ConnectorSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	hardenPath := path.Child("harden")
+	if p.Type != ProvisionerTypeHarden {
+		if p.Harden != nil {
+			allErrs = append(allErrs, field.Forbidden(hardenPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeHarden)))
+		}
+		return allErrs
+	}
+	if p.Harden == nil {
+		return append(allErrs, field.Required(hardenPath, fmt.Sprintf("must be set for %s provisioners", ProvisionerTypeHarden)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeHarden)))
+	}
+	if p.Harden.Platform == HardenPlatformWindows && connector.Sudo != nil {
+		allErrs = append(allErrs, field.Forbidden(hardenPath.Child("platform"), "the connector sudo is not supported by the windows profiles"))
+	}
+	return allErrs
+}
+
+// validateProvisionerExtract validates the files are only set on the extract provisioners, which require them,
+// every file has an absolute path and a unique key, and the files have exactly one destination.
+func validateProvisionerExtract(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	extractPath := path.Child("extract")
+	if p.Type != ProvisionerTypeExtract {
+		if p.Extract != nil {
+			allErrs = append(allErrs, field.Forbidden(extractPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeExtract)))
+		}
+		return allErrs
+	}
+	if p.Extract == nil {
+		return append(allErrs, field.Required(extractPath, fmt.Sprintf("must be set for %s provisioners", ProvisionerTypeExtract)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeExtract)))
+	}
+
+	keys := map[string]bool{}
+	for i := range p.Extract.Files {
+		f := &p.Extract.Files[i]
+		fPath := extractPath.Child("files").Index(i)
+		if !strings.HasPrefix(f.Path, "/") {
+			allErrs = append(allErrs, field.Invalid(fPath.Child("path"), f.Path, "must be an absolute path"))
+		}
+		if keys[f.GetKey()] {
+			allErrs = append(allErrs, field.Duplicate(fPath.Child("key"), f.GetKey()))
+		}
+		keys[f.GetKey()] = true
+	}
+
+	return append(allErrs, validateExtractDestination(extractPath.Child("destination"), &p.Extract.Destination)...)
+}
+
+// validateExtractDestination validates exactly one destination is set, and the OCI artifacts are pushed to a tag.
+func validateExtractDestination(path *field.Path, dest *ExtractDestination) field.ErrorList {
+	var allErrs field.ErrorList
+	set := 0
+	for _, isSet := range []bool{dest.ConfigMapRef != nil, dest.SecretRef != nil, dest.ObjectStorage != nil, dest.OCI != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		allErrs = append(allErrs, field.Invalid(path, "", "exactly one of configMapRef, secretRef, objectStorage and oci must be set"))
+	}
+	if dest.OCI != nil && strings.Contains(dest.OCI.Reference, "@") {
+		allErrs = append(allErrs, field.Invalid(path.Child("oci", "reference"), dest.OCI.Reference, "must be a tagged reference"))
+	}
+	return allErrs
+}
+
+// validateProvisionerExternal validates the class and the parameters are only set on the external provisioners,
+// which require a class. The parameters are checked against the schema of the class when the provisioner starts.
+func validateProvisionerExternal(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if p.Type != ProvisionerTypeExternal {
+		detail := fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeExternal)
+		if p.Class != "" {
+			allErrs = append(allErrs, field.Forbidden(path.Child("class"), detail))
+		}
+		if p.Parameters != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("parameters"), detail))
+		}
+		return allErrs
+	}
+	if p.Class == "" {
+		allErrs = append(allErrs, field.Required(path.Child("class"), fmt.Sprintf("must be set for %s provisioners", ProvisionerTypeExternal)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeExternal)))
+	}
+	return allErrs
+}
+
+// validateProvisionerVerify validates the regular expressions of the command assertions of a verify provisioner
+// compile and its suites reference a ConfigMap key.
+func validateProvisionerVerify(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	if p.Verify == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	verifyPath := path.Child("verify")
+	for i, c := range p.Verify.Commands {
+		if c.Matches == "" {
+			continue
+		}
+		if _, err := regexp.Compile(c.Matches); err != nil {
+			allErrs = append(allErrs, field.Invalid(verifyPath.Child("commands").Index(i).Child("matches"), c.Matches, err.Error()))
+		}
+	}
+	for i, suite := range p.Verify.Suites {
+		refPath := verifyPath.Child("suites").Index(i).Child("configMapKeyRef")
+		if suite.ConfigMapKeyRef.Name == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("name"), "the name of the ConfigMap holding the suite"))
+		}
+		if suite.ConfigMapKeyRef.Key == "" {
+			allErrs = append(allErrs, field.Required(refPath.Child("key"), "the key of the ConfigMap holding the suite"))
+		}
+	}
+	return allErrs
+}
+
+// validateProvisionerRestart validates the restart spec is only set on the restart provisioners, which do not run
+// a script, and its timeout is positive.
+func validateProvisionerRestart(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	restartPath := path.Child("restart")
+	if p.Type != ProvisionerTypeRestart {
+		if p.Restart != nil {
+			allErrs = append(allErrs, field.Forbidden(restartPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeRestart)))
+		}
+		return allErrs
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", ProvisionerTypeRestart)))
+	}
+	if p.Restart != nil && p.Restart.Timeout != nil && p.Restart.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(restartPath.Child("timeout"), p.Restart.Timeout.Duration.String(), "must be positive"))
+	}
+	return allErrs
+}
+
+// validateProvisionerConfigManagement validates the chef-solo and puppet-apply specs are only set on their provisioners,
+// which require them, and their source has exactly one origin.
+func validateProvisionerConfigManagement(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	specs := []struct {
+		t      ProvisionerType
+		name   string
+		set    bool
+		source *ConfigManagementSource
+	}{
+		{t: ProvisionerTypeChefSolo, name: "chefSolo", set: p.ChefSolo != nil},
+		{t: ProvisionerTypePuppetApply, name: "puppetApply", set: p.PuppetApply != nil},
+	}
+	if p.ChefSolo != nil {
+		specs[0].source = &p.ChefSolo.Source
+	}
+	if p.PuppetApply != nil {
+		specs[1].source = &p.PuppetApply.Source
+	}
+	for _, spec := range specs {
+		specPath := path.Child(spec.name)
+		if p.Type != spec.t {
+			if spec.set {
+				allErrs = append(allErrs, field.Forbidden(specPath, fmt.Sprintf("only allowed for %s provisioners", spec.t)))
+			}
+			continue
+		}
+		if !spec.set {
+			allErrs = append(allErrs, field.Required(specPath, fmt.Sprintf("must be set for %s provisioners", spec.t)))
+			continue
+		}
+		if p.Run != nil || p.RunConfigMapRef != nil {
+			allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("run and runConfigMapRef are not allowed for %s provisioners", spec.t)))
+		}
+		sourcePath := specPath.Child("source")
+		source := spec.source
+		if (source.ConfigMapRef != nil) == (source.Git != nil) {
+			allErrs = append(allErrs, field.Invalid(sourcePath, "", "exactly one of configMapRef and git must be set"))
+		}
+		if len(source.Items) > 0 && source.ConfigMapRef == nil {
+			allErrs = append(allErrs, field.Forbidden(sourcePath.Child("items"), "only allowed with configMapRef"))
+		}
+		for i, item := range source.Items {
+			if item.Path == "" || strings.HasPrefix(item.Path, "/") || strings.Contains(item.Path, "..") {
+				allErrs = append(allErrs, field.Invalid(sourcePath.Child("items").Index(i).Child("path"), item.Path,
+					"must be a relative path without .."))
+			}
+		}
+		if git := source.Git; git != nil && !strings.HasPrefix(git.URL, "https://") && !strings.HasPrefix(git.URL, "http://") {
+			allErrs = append(allErrs, field.Invalid(sourcePath.Child("git", "url"), git.URL, "must be an http or https URL"))
+		}
+	}
+	if p.Type == ProvisionerTypePuppetApply && p.PuppetApply != nil {
+		names := make([]string, 0, len(p.PuppetApply.Facts))
+		for name := range p.PuppetApply.Facts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !variableNamePattern.MatchString(name) {
+				allErrs = append(allErrs, field.Invalid(path.Child("puppetApply", "facts").Key(name), name,
+					"must be a valid environment variable name, the facts are exported as FACTER_ variables"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateProvisionerSteps validates the steps are only set on the shell provisioners, instead of their script,
+// and every step does exactly one thing.
+func validateProvisionerSteps(path *field.Path, p *ProvisionerSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(p.Steps) == 0 {
+		return nil
+	}
+	stepsPath := path.Child("steps")
+	if p.Type != ProvisionerTypeShell {
+		return append(allErrs, field.Forbidden(stepsPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeShell)))
+	}
+	if p.Run != nil || p.RunConfigMapRef != nil {
+		allErrs = append(allErrs, field.Forbidden(stepsPath, "may not be set with run or runConfigMapRef"))
+	}
+	names := map[string]bool{}
+	for i, step := range p.Steps {
+		stepPath := stepsPath.Index(i)
+		if names[step.Name] {
+			allErrs = append(allErrs, field.Duplicate(stepPath.Child("name"), step.Name))
+		}
+		names[step.Name] = true
+
+		set := 0
+		for _, isSet := range []bool{step.Run != nil, step.RunConfigMapRef != nil, step.File != nil} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			allErrs = append(allErrs, field.Invalid(stepPath, step.Name, "exactly one of run, runConfigMapRef and file must be set"))
+		}
+		if step.File != nil && !strings.HasPrefix(step.File.Destination, "/") {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("file", "destination"), step.File.Destination, "must be an absolute path"))
+		}
+	}
+	return allErrs
+}
+
+// validateConnector validates the bastion of the connector references its credentials and the timeout is positive,
+// the ssh settings are not allowed with the docker-exec connector.
+func validateConnector(path *field.Path, connector *ConnectorSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if connector.Type == ConnectorTypeDockerExec {
+		detail := fmt.Sprintf("not supported by the %s connector", ConnectorTypeDockerExec)
+		if connector.Port != 0 {
+			allErrs = append(allErrs, field.Forbidden(path.Child("port"), detail))
+		}
+		if connector.Bastion != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("bastion"), detail))
+		}
+		if connector.HostKeyPolicy != "" && connector.HostKeyPolicy != HostKeyPolicyInsecure {
+			allErrs = append(allErrs, field.Forbidden(path.Child("hostKeyPolicy"), detail))
+		}
+		if connector.Sudo != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("sudo"), detail+", the commands run as the username of the connector"))
+		}
+	}
+	if connector.CredentialsProviderRef != nil && connector.EphemeralCredentials {
+		allErrs = append(allErrs, field.Forbidden(path.Child("credentialsProviderRef"), "not allowed with ephemeralCredentials"))
+	}
+	if connector.Bastion != nil && connector.Bastion.Credentials.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("bastion", "credentials", "name"), "must be set"))
+	}
+	allErrs = append(allErrs, validatePositiveDuration(path.Child("timeout"), connector.Timeout)...)
+	return allErrs
+}
+
+// validateVariables validates the variables can be exported as environment variables, and that the value
+// of a variable comes from a single source.
+func validateVariables(path *field.Path, variables []BuildVariable) field.ErrorList {
+	var allErrs field.ErrorList
+	for i := range variables {
+		v := &variables[i]
+		variablePath := path.Index(i)
+		if !variableNamePattern.MatchString(v.Name) {
+			allErrs = append(allErrs, field.Invalid(variablePath.Child("name"), v.Name, "must be a valid environment variable name"))
+		}
+		if v.ValueFrom == nil {
+			continue
+		}
+		sourcePath := variablePath.Child("valueFrom")
+		if v.Value != "" {
+			allErrs = append(allErrs, field.Forbidden(variablePath.Child("value"), "cannot be set with valueFrom"))
+		}
+		switch source := v.ValueFrom; {
+		case source.SecretKeyRef != nil && source.ConfigMapKeyRef != nil:
+			allErrs = append(allErrs, field.Invalid(sourcePath, "", "only one of secretKeyRef and configMapKeyRef can be set"))
+		case source.SecretKeyRef != nil:
+			allErrs = append(allErrs, validateKeySelector(sourcePath.Child("secretKeyRef"), source.SecretKeyRef.Name, source.SecretKeyRef.Key)...)
+		case source.ConfigMapKeyRef != nil:
+			allErrs = append(allErrs, validateKeySelector(sourcePath.Child("configMapKeyRef"), source.ConfigMapKeyRef.Name, source.ConfigMapKeyRef.Key)...)
+		default:
+			allErrs = append(allErrs, field.Required(sourcePath, "one of secretKeyRef and configMapKeyRef must be set"))
+		}
+	}
+	return allErrs
+}
+
+func validateKeySelector(path *field.Path, name, key string) field.ErrorList {
+	var allErrs field.ErrorList
+	if name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "must be set"))
+	}
+	if key == "" {
+		allErrs = append(allErrs, field.Required(path.Child("key"), "must be set"))
+	}
+	return allErrs
+}
+
+// validateKubeconfig validates the kubeconfig handed to a provisioner, it must only be given to shell provisioners
+// and must not be expired when it is set, the kubeconfig of an existing Build is left to expire.
+func validateKubeconfig(path *field.Path, p *ProvisionerSpec, oldSpec *BuildSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if p.Kubeconfig == nil {
+		return nil
+	}
+
+	kubeconfigPath := path.Child("kubeconfig")
+	if p.Type != ProvisionerTypeShell {
+		allErrs = append(allErrs, field.Forbidden(kubeconfigPath, fmt.Sprintf("only allowed for %s provisioners", ProvisionerTypeShell)))
+	}
+	if p.Kubeconfig.SecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(kubeconfigPath.Child("secretRef", "name"), "must be set"))
+	}
+
+	if oldSpec != nil {
+		for _, oldProvisioner := range oldSpec.Provisioners {
+			if equality.Semantic.DeepEqual(oldProvisioner.Kubeconfig, p.Kubeconfig) {
+				return allErrs
+			}
+		}
+	}
+	if !p.Kubeconfig.ExpirationTime.After(time.Now()) {
+		allErrs = append(allErrs, field.Invalid(kubeconfigPath.Child("expirationTime"),
+			p.Kubeconfig.ExpirationTime.UTC().Format(time.RFC3339), "must be in the future"))
+	}
+	return allErrs
+}
+
+// validateImageMetadata validates the image metadata keys are valid on all the clouds, unique,
+// and do not override the keys tracing the image back to its Build.
+func validateImageMetadata(path *field.Path, metadata *ImageMetadataSpec) field.ErrorList {
+	if metadata == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	names := map[string]bool{}
+	validate := func(mappingsPath *field.Path, mappings []ImageMetadataMapping) {
+		for i := range mappings {
+			namePath := mappingsPath.Index(i).Child("name")
+			name := mappings[i].GetName()
+			switch {
+			case !imageMetadataNamePattern.MatchString(name):
+				allErrs = append(allErrs, field.Invalid(namePath, name,
+					fmt.Sprintf("must be set, the name of key %q is not a valid image metadata key", mappings[i].Key)))
+			case strings.HasPrefix(name, "forge-build-"):
+				allErrs = append(allErrs, field.Forbidden(namePath, "the forge-build- prefix is reserved"))
+			case names[name]:
+				allErrs = append(allErrs, field.Duplicate(namePath, name))
+			}
+			names[name] = true
+		}
+	}
+	validate(path.Child("labels"), metadata.Labels)
+	validate(path.Child("annotations"), metadata.Annotations)
+	return allErrs
+}
+
+// validateArtifact validates the name policy of the artifact renders a valid image name, that the name of the
+// image is not changed once set so the image of a Build is never renamed, and that there are exports to sign.
+func validateArtifact(path *field.Path, spec, oldSpec *BuildSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if oldSpec != nil && oldSpec.ImageName != "" && spec.ImageName != oldSpec.ImageName {
+		allErrs = append(allErrs, field.Forbidden(path.Child("imageName"), "is immutable once set"))
+	}
+	artifact := spec.Artifact
+	if artifact != nil && artifact.Signing != nil && !slices.ContainsFunc(spec.Exports, func(export ExportSpec) bool {
+		return export.Format != ExportFormatVagrantBox
+	}) {
+		allErrs = append(allErrs, field.Required(path.Child("exports"),
+			"spec.artifact.signing signs the exports of the Build, at least one of them must not be a Vagrant box"))
+	}
+	if artifact != nil && artifact.SBOM != nil && artifact.SBOM.Destination != nil {
+		allErrs = append(allErrs, validateExtractDestination(path.Child("artifact", "sbom", "destination"), artifact.SBOM.Destination)...)
+	}
+	if artifact != nil && artifact.ExpireAfter != nil && artifact.ExpireAfter.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("artifact", "expireAfter"), artifact.ExpireAfter.Duration.String(), "must be positive"))
+	}
+	if artifact == nil || artifact.NamePolicy == "" {
+		return allErrs
+	}
+	policyPath := path.Child("artifact", "namePolicy")
+	if _, err := artifact.RenderName(sampleArtifactNameData); err != nil {
+		allErrs = append(allErrs, field.Invalid(policyPath, artifact.NamePolicy, err.Error()))
+	}
+	if artifact.UsesSerial() && spec.TemplateRef == nil {
+		allErrs = append(allErrs, field.Invalid(policyPath, artifact.NamePolicy, "{{ .Serial }} requires spec.templateRef"))
+	}
+	return allErrs
+}
+
+// validateWorkspace validates the presigned URLs of the workspace can be generated, and that the objects
+// are handed to the provisioners in distinct environment variables.
+// validateFrom validates spec.from references either a Build or an image, the images of a multi-architecture Build
+// are created by the Builds of its architectures from their own source image.
+func validateFrom(path *field.Path, spec *BuildSpec) field.ErrorList {
+	from := spec.From
+	if from == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch {
+	case from.BuildRef == nil && from.ImageRef == "":
+		allErrs = append(allErrs, field.Required(path, "one of buildRef or imageRef must be set"))
+	case from.BuildRef != nil && from.ImageRef != "":
+		allErrs = append(allErrs, field.Forbidden(path.Child("imageRef"), "not allowed with buildRef"))
+	case from.BuildRef != nil && from.BuildRef.Name == "":
+		allErrs = append(allErrs, field.Required(path.Child("buildRef", "name"), "must be set"))
+	}
+	if len(spec.Architectures) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path, "not supported with spec.architectures"))
+	}
+	return allErrs
+}
+
+func validateWorkspace(path *field.Path, workspace *WorkspaceSpec) field.ErrorList {
+	if workspace == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	envs := map[string]bool{}
+	for i, object := range workspace.Objects {
+		env, _ := WorkspaceObjectEnv(object)
+		if envs[env] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("objects").Index(i), object))
+		}
+		envs[env] = true
+	}
+	expirationPath := path.Child("urlExpiration")
+	allErrs = append(allErrs, validatePositiveDuration(expirationPath, workspace.URLExpiration)...)
+	if workspace.URLExpiration != nil && workspace.URLExpiration.Duration > maxWorkspaceURLExpiration {
+		allErrs = append(allErrs, field.Invalid(expirationPath, workspace.URLExpiration.Duration.String(),
+			fmt.Sprintf("must not be greater than %s", maxWorkspaceURLExpiration)))
+	}
+	return allErrs
+}
+
+// validateTimeouts validates the timeouts are positive.
+func validateTimeouts(path *field.Path, timeouts *BuildTimeouts) field.ErrorList {
+	if timeouts == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validatePositiveDuration(path.Child("machineReadyTimeout"), timeouts.MachineReadyTimeout)...)
+	allErrs = append(allErrs, validatePositiveDuration(path.Child("connectionTimeout"), timeouts.ConnectionTimeout)...)
+	allErrs = append(allErrs, validatePositiveDuration(path.Child("provisioningTimeout"), timeouts.ProvisioningTimeout)...)
+	allErrs = append(allErrs, validatePositiveDuration(path.Child("overallDeadline"), timeouts.OverallDeadline)...)
+	return allErrs
+}
+
+// validateRetryPolicy validates the backoff of the retry policy is positive and its initial delay is not above its maximum.
+func validateRetryPolicy(path *field.Path, policy *RetryPolicy) field.ErrorList {
+	if policy == nil || policy.Backoff == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	backoffPath := path.Child("backoff")
+	allErrs = append(allErrs, validatePositiveDuration(backoffPath.Child("initial"), policy.Backoff.Initial)...)
+	allErrs = append(allErrs, validatePositiveDuration(backoffPath.Child("max"), policy.Backoff.Max)...)
+	if policy.Backoff.Initial != nil && policy.Backoff.Max != nil && policy.Backoff.Initial.Duration > policy.Backoff.Max.Duration {
+		allErrs = append(allErrs, field.Invalid(backoffPath.Child("initial"), policy.Backoff.Initial.Duration.String(),
+			"must not be greater than spec.retryPolicy.backoff.max"))
+	}
+	return allErrs
+}
+
+func validatePositiveDuration(path *field.Path, d *metav1.Duration) field.ErrorList {
+	if d == nil || d.Duration > 0 {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, d.Duration.String(), "must be greater than zero")}
+}
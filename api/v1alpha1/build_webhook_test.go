@@ -0,0 +1,812 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestBuildDefault(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &Build{Spec: BuildSpec{Connector: ConnectorSpec{Type: ConnectorTypeSSH}}}
+	build.Default()
+	g.Expect(build.Spec.Connector.Port).To(Equal(int32(DefaultSSHPort)))
+	g.Expect(build.Spec.Connector.Username).To(Equal(DefaultSSHUsername))
+
+	build = &Build{Spec: BuildSpec{Connector: ConnectorSpec{Type: ConnectorTypeSSH, Port: 2222, Username: "ubuntu"}}}
+	build.Default()
+	g.Expect(build.Spec.Connector.Port).To(Equal(int32(2222)))
+	g.Expect(build.Spec.Connector.Username).To(Equal("ubuntu"))
+
+	// Builds instantiated from a BuildTemplate are defaulted once the template sets their connector.
+	build = &Build{Spec: BuildSpec{TemplateRef: &BuildTemplateReference{Name: "ubuntu"}}}
+	build.Default()
+	g.Expect(build.Spec.Connector).To(Equal(ConnectorSpec{}))
+}
+
+func TestValidateBuild(t *testing.T) {
+	infrastructureRef := &corev1.ObjectReference{APIVersion: "infrastructure.forge.build/v1alpha1", Kind: "DockerBuild", Name: "ubuntu"}
+
+	testcases := []struct {
+		name    string
+		spec    BuildSpec
+		wantErr []string
+	}{
+		{
+			name: "valid Build",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Timeouts:          &BuildTimeouts{OverallDeadline: &metav1.Duration{Duration: time.Hour}},
+			},
+		},
+		{
+			name: "Build from a template",
+			spec: BuildSpec{TemplateRef: &BuildTemplateReference{Name: "ubuntu"}},
+		},
+		{
+			name:    "missing connector and infrastructure",
+			spec:    BuildSpec{},
+			wantErr: []string{"spec.connector.type", "spec.infrastructureRef"},
+		},
+		{
+			name: "unsupported connector",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: "winrm"},
+				InfrastructureRef: infrastructureRef,
+			},
+			wantErr: []string{`spec.connector.type: Unsupported value: "winrm"`},
+		},
+		{
+			name: "docker-exec connector",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeDockerExec, Username: "root"},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Run: ptr.To("apt-get update")},
+					{Type: ProvisionerTypeAnsible, Ansible: &AnsibleSpec{Git: &AnsibleGitSource{URL: "https://github.com/example/playbooks.git"}, PlaybookPath: "site.yml"}},
+				},
+			},
+		},
+		{
+			name: "invalid docker-exec connector",
+			spec: BuildSpec{
+				Connector: ConnectorSpec{
+					Type:          ConnectorTypeDockerExec,
+					Port:          22,
+					Bastion:       &BastionSpec{Credentials: corev1.LocalObjectReference{Name: "bastion"}},
+					HostKeyPolicy: HostKeyPolicyStrict,
+					Sudo:          &SudoSpec{},
+				},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Run: ptr.To("true")},
+					{Type: ProvisionerTypeRestart},
+				},
+			},
+			wantErr: []string{
+				"spec.connector.port: Forbidden",
+				"spec.connector.bastion: Forbidden",
+				"spec.connector.hostKeyPolicy: Forbidden",
+				"spec.connector.sudo: Forbidden",
+				"spec.provisioners[1].type: Forbidden",
+			},
+		},
+		{
+			name: "invalid connector options",
+			spec: BuildSpec{
+				Connector: ConnectorSpec{
+					Type:    ConnectorTypeSSH,
+					Bastion: &BastionSpec{Port: 22},
+					Timeout: &metav1.Duration{},
+				},
+				InfrastructureRef: infrastructureRef,
+			},
+			wantErr: []string{"spec.connector.bastion.credentials.name", "spec.connector.timeout"},
+		},
+		{
+			name: "connector with a credentialsProviderRef",
+			spec: BuildSpec{
+				Connector: ConnectorSpec{
+					Type:                   ConnectorTypeSSH,
+					CredentialsProviderRef: &CredentialsProviderReference{Name: "vault", Path: "forge/ssh"},
+					EphemeralCredentials:   true,
+				},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Run: ptr.To("true")},
+					{Type: ProvisionerTypeRestart},
+				},
+			},
+			wantErr: []string{"spec.connector.credentialsProviderRef: Forbidden", "spec.provisioners[0].type: Forbidden"},
+		},
+		{
+			name: "invalid variables",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Variables: []BuildVariable{
+					{Name: "REGISTRY_TOKEN", ValueFrom: &BuildVariableSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}}},
+					{Name: "os-version", Value: "22.04"},
+					{Name: "MIRROR", Value: "https://mirror", ValueFrom: &BuildVariableSource{}},
+				},
+			},
+			wantErr: []string{
+				"spec.variables[0].valueFrom.secretKeyRef.name",
+				"spec.variables[1].name",
+				"spec.variables[2].value: Forbidden",
+				"spec.variables[2].valueFrom: Required",
+			},
+		},
+		{
+			name: "infrastructureRef without kind",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: &corev1.ObjectReference{Name: "ubuntu"},
+			},
+			wantErr: []string{"spec.infrastructureRef.apiVersion", "spec.infrastructureRef.kind"},
+		},
+		{
+			name: "duplicate provisioner UUIDs",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, UUID: ptr.To("a")},
+					{Type: ProvisionerTypeShell, UUID: ptr.To("a")},
+				},
+			},
+			wantErr: []string{`spec.provisioners[1].uuid: Duplicate value: "a"`},
+		},
+		{
+			name: "provisioner requiring approval without name",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners:      []ProvisionerSpec{{Type: ProvisionerTypeShell, RequireApproval: true}},
+			},
+			wantErr: []string{"spec.provisioners[0].name: Required"},
+		},
+		{
+			name: "job of a verify provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Image: "registry.local/forge-provisioner-shell:v1", ImagePullPolicy: corev1.PullAlways},
+					{
+						Type:             ProvisionerTypeVerify,
+						Image:            "registry.local/forge-provisioner-shell:v1",
+						ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+						PodTemplate:      &ProvisionerPodTemplate{NodeSelector: map[string]string{"pool": "builders"}},
+					},
+				},
+			},
+			wantErr: []string{"spec.provisioners[1].image: Forbidden", "spec.provisioners[1].imagePullSecrets: Forbidden", "spec.provisioners[1].podTemplate: Forbidden"},
+		},
+		{
+			name: "dry run of a simulation",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Simulate:          true,
+				DryRun:            true,
+			},
+			wantErr: []string{"spec.dryRun: Forbidden"},
+		},
+		{
+			name: "concurrency group and retries of a restart provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Run: ptr.To("make go"), ConcurrencyGroup: "toolchains"},
+					{Type: ProvisionerTypeRestart, ConcurrencyGroup: "toolchains", Retries: ptr.To[int32](2)},
+				},
+			},
+			wantErr: []string{"spec.provisioners[1].concurrencyGroup: Forbidden", "spec.provisioners[1].retries: Forbidden"},
+		},
+		{
+			name: "steps of a shell provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Steps: []ProvisionerStep{
+						{Name: "motd", File: &ProvisionerFile{Content: "Built by Forge", Destination: "/etc/motd"}},
+						{Name: "update", Run: ptr.To("apt-get update")},
+						{Name: "install", RunConfigMapRef: &corev1.LocalObjectReference{Name: "install-scripts"}},
+					}},
+				},
+			},
+		},
+		{
+			name: "invalid steps",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeShell, Run: ptr.To("apt-get update"), Steps: []ProvisionerStep{
+						{Name: "motd", File: &ProvisionerFile{Content: "Built by Forge", Destination: "etc/motd"}},
+						{Name: "motd", Run: ptr.To("cat /etc/motd"), File: &ProvisionerFile{Destination: "/etc/motd"}},
+					}},
+					{Type: ProvisionerTypeVerify, Steps: []ProvisionerStep{{Name: "update", Run: ptr.To("apt-get update")}}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].steps: Forbidden",
+				"spec.provisioners[0].steps[0].file.destination: Invalid",
+				"spec.provisioners[0].steps[1].name: Duplicate",
+				"spec.provisioners[0].steps[1]: Invalid",
+				"spec.provisioners[1].steps: Forbidden",
+			},
+		},
+		{
+			name: "invalid timeouts and backoff",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Timeouts:          &BuildTimeouts{ConnectionTimeout: &metav1.Duration{Duration: -time.Minute}},
+				RetryPolicy: &RetryPolicy{MaxRetries: 1, Backoff: &RetryBackoff{
+					Initial: &metav1.Duration{Duration: time.Hour},
+					Max:     &metav1.Duration{Duration: time.Minute},
+				}},
+			},
+			wantErr: []string{"spec.timeouts.connectionTimeout", "spec.retryPolicy.backoff.initial"},
+		},
+		{
+			name: "invalid image metadata",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				ImageMetadata: &ImageMetadataSpec{
+					Labels: []ImageMetadataMapping{
+						{Key: "example.com/team"},
+						{Key: "example.com/9lives"},
+						{Key: "owner", Name: "forge-build-name"},
+					},
+					Annotations: []ImageMetadataMapping{{Key: "team"}},
+				},
+			},
+			wantErr: []string{
+				"spec.imageMetadata.labels[1].name",
+				"spec.imageMetadata.labels[2].name: Forbidden",
+				`spec.imageMetadata.annotations[0].name: Duplicate value: "team"`,
+			},
+		},
+		{
+			name: "invalid workspace",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Workspace: &WorkspaceSpec{
+					Objects:       []string{"rootfs.tar", "rootfs-tar"},
+					URLExpiration: &metav1.Duration{Duration: 8 * 24 * time.Hour},
+				},
+			},
+			wantErr: []string{
+				`spec.workspace.objects[1]: Duplicate value: "rootfs-tar"`,
+				"spec.workspace.urlExpiration",
+			},
+		},
+		{
+			name: "Build from a template with a name policy",
+			spec: BuildSpec{
+				TemplateRef: &BuildTemplateReference{Name: "ubuntu"},
+				Artifact:    &ArtifactSpec{NamePolicy: "ubuntu-{{ .Timestamp }}-{{ .GitSHA }}-{{ .Serial }}"},
+			},
+		},
+		{
+			name: "invalid name policies",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Artifact:          &ArtifactSpec{NamePolicy: "ubuntu/{{ .Commit }}-{{ .Serial }}"},
+			},
+			wantErr: []string{
+				`can't evaluate field Commit`,
+				"{{ .Serial }} requires spec.templateRef",
+			},
+		},
+		{
+			name: "signing without exports to sign",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Artifact:          &ArtifactSpec{Signing: &SigningSpec{Keyless: &KeylessSigning{}}},
+				Exports: []ExportSpec{{
+					Name:    "vagrant",
+					Format:  ExportFormatVagrantBox,
+					Vagrant: &VagrantExport{BoxName: "forge/ubuntu", Providers: []VagrantProvider{VagrantProviderLibvirt}},
+				}},
+			},
+			wantErr: []string{"spec.exports: Required value: spec.artifact.signing signs the exports"},
+		},
+		{
+			name: "scan without destination",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Scan:              &ScanSpec{Destination: &ExtractDestination{}},
+			},
+			wantErr: []string{"spec.scan.destination: Invalid value"},
+		},
+		{
+			name: "sbom with two destinations",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Artifact: &ArtifactSpec{SBOM: &SBOMSpec{Destination: &ExtractDestination{
+					ConfigMapRef: &corev1.LocalObjectReference{Name: "sbom"},
+					OCI:          &OCIArtifactDestination{Reference: "ghcr.io/forge-build/sbom@sha256:abc"},
+				}}},
+			},
+			wantErr: []string{
+				"spec.artifact.sbom.destination: Invalid value: \"\": exactly one of configMapRef, secretRef, objectStorage and oci must be set",
+				"spec.artifact.sbom.destination.oci.reference: Invalid value",
+			},
+		},
+		{
+			name: "negative expireAfter",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Artifact:          &ArtifactSpec{ExpireAfter: &metav1.Duration{Duration: -time.Hour}},
+			},
+			wantErr: []string{"spec.artifact.expireAfter: Invalid value: \"-1h0m0s\": must be positive"},
+		},
+		{
+			name: "invalid from",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Architectures:     []Architecture{ArchitectureAMD64, ArchitectureARM64},
+				From:              &BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "ubuntu"}, ImageRef: "ami-1"},
+			},
+			wantErr: []string{"spec.from.imageRef: Forbidden", "spec.from: Forbidden"},
+		},
+		{
+			name: "exports of a multi-architecture Build",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Architectures:     []Architecture{ArchitectureAMD64, ArchitectureARM64},
+				Exports:           []ExportSpec{{Name: "desktop", Format: ExportFormatOVA}},
+			},
+			wantErr: []string{"spec.exports: Forbidden"},
+		},
+		{
+			name: "ansible provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{{
+					Type:    ProvisionerTypeAnsible,
+					Image:   "registry.local/forge-provisioner-ansible:v1",
+					Ansible: &AnsibleSpec{Git: &AnsibleGitSource{URL: "https://github.com/example/playbooks.git"}, PlaybookPath: "site.yml"},
+				}},
+			},
+		},
+		{
+			name: "invalid ansible provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeAnsible},
+					{
+						Type: ProvisionerTypeAnsible,
+						Ansible: &AnsibleSpec{
+							Playbook:             "- hosts: all",
+							PlaybookConfigMapRef: &corev1.LocalObjectReference{Name: "playbook"},
+						},
+					},
+					{
+						Type:    ProvisionerTypeAnsible,
+						Ansible: &AnsibleSpec{Git: &AnsibleGitSource{URL: "git@github.com:example/playbooks.git"}, PlaybookPath: "../site.yml"},
+					},
+					{Type: ProvisionerTypeShell, Run: ptr.To("true"), Ansible: &AnsibleSpec{Playbook: "- hosts: all"}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].ansible: Required",
+				"spec.provisioners[1].ansible: Invalid",
+				"spec.provisioners[2].ansible.git.url",
+				"spec.provisioners[2].ansible.playbookPath",
+				"spec.provisioners[3].ansible: Forbidden",
+			},
+		},
+		{
+			name: "file provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{{
+					Type: ProvisionerTypeFile,
+					File: &FileProvisionerSpec{Files: []FileUpload{
+						{Destination: "/etc/motd", ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "assets"}, Key: "motd"}},
+						{Destination: "/opt/agent/agent.tar.gz", OCI: &OCIArtifactSource{Reference: "ghcr.io/example/agent:v1"}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "invalid file provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypeFile},
+					{
+						Type: ProvisionerTypeFile,
+						File: &FileProvisionerSpec{Files: []FileUpload{
+							{Destination: "etc/motd", URL: "ftp://example.com/motd"},
+							{Destination: "/etc/motd", URL: "https://example.com/motd", OCI: &OCIArtifactSource{Reference: "ghcr.io/example/motd:v1"}},
+							{Destination: "/etc/motd", URL: "https://example.com/motd"},
+						}},
+					},
+					{Type: ProvisionerTypeShell, Run: ptr.To("true"), File: &FileProvisionerSpec{}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].file: Required",
+				"spec.provisioners[1].file.files[0].destination: Invalid",
+				"spec.provisioners[1].file.files[0].url: Invalid",
+				"spec.provisioners[1].file.files[1]: Invalid",
+				"spec.provisioners[1].file.files[2].destination: Duplicate",
+				"spec.provisioners[2].file: Forbidden",
+			},
+		},
+		{
+			name: "valid powershell provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{{
+					Type: ProvisionerTypePowerShell,
+					PowerShell: &PowerShellProvisionerSpec{Scripts: []PowerShellScript{
+						{Name: "features", Inline: "Install-WindowsFeature -Name Web-Server"},
+						{Name: "updates", ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "scripts"}, Key: "updates.ps1"}},
+					}},
+				}},
+			},
+		},
+		{
+			name: "invalid powershell provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH, Sudo: &SudoSpec{}},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Type: ProvisionerTypePowerShell},
+					{
+						Type: ProvisionerTypePowerShell,
+						PowerShell: &PowerShellProvisionerSpec{Scripts: []PowerShellScript{
+							{Name: "updates"},
+							{Name: "updates", Inline: "exit 0"},
+						}},
+					},
+					{Type: ProvisionerTypeShell, Run: ptr.To("true"), PowerShell: &PowerShellProvisionerSpec{}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].powershell: Required",
+				"spec.provisioners[1]: Forbidden",
+				"spec.provisioners[1].powershell.scripts[0]: Invalid",
+				"spec.provisioners[1].powershell.scripts[1].name: Duplicate",
+				"spec.provisioners[2].powershell: Forbidden",
+			},
+		},
+		{
+			name: "valid chef-solo and puppet-apply provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{
+						Name: "chef",
+						Type: ProvisionerTypeChefSolo,
+						ChefSolo: &ChefSoloSpec{
+							Source:  ConfigManagementSource{Git: &AnsibleGitSource{URL: "https://github.com/example/cookbooks.git"}},
+							RunList: []string{"recipe[base]"},
+						},
+					},
+					{
+						Name: "puppet",
+						Type: ProvisionerTypePuppetApply,
+						PuppetApply: &PuppetApplySpec{
+							Source: ConfigManagementSource{
+								ConfigMapRef: &corev1.LocalObjectReference{Name: "manifests"},
+								Items:        []corev1.KeyToPath{{Key: "site.pp", Path: "manifests/site.pp"}},
+							},
+							Facts: map[string]string{"role": "web"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid chef-solo and puppet-apply provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "chef", Type: ProvisionerTypeChefSolo},
+					{
+						Name: "puppet",
+						Type: ProvisionerTypePuppetApply,
+						PuppetApply: &PuppetApplySpec{
+							Source: ConfigManagementSource{
+								Git:   &AnsibleGitSource{URL: "git@github.com:example/manifests.git"},
+								Items: []corev1.KeyToPath{{Key: "site.pp", Path: "../site.pp"}},
+							},
+							Facts: map[string]string{"server-role": "web"},
+						},
+					},
+					{Name: "shell", Type: ProvisionerTypeShell, Run: ptr.To("true"), ChefSolo: &ChefSoloSpec{}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].chefSolo: Required",
+				"spec.provisioners[1].puppetApply.source.items: Forbidden",
+				"spec.provisioners[1].puppetApply.source.items[0].path: Invalid",
+				"spec.provisioners[1].puppetApply.source.git.url: Invalid",
+				"spec.provisioners[1].puppetApply.facts[server-role]: Invalid",
+				"spec.provisioners[2].chefSolo: Forbidden",
+			},
+		},
+		{
+			name: "valid restart provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "reboot", Type: ProvisionerTypeRestart},
+					{
+						Name: "reboot-windows",
+						Type: ProvisionerTypeRestart,
+						Restart: &RestartSpec{
+							Command:      "shutdown.exe /r /t 5",
+							CheckCommand: "Get-Service sshd",
+							Timeout:      &metav1.Duration{Duration: time.Hour},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid restart provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "reboot", Type: ProvisionerTypeRestart, Run: ptr.To("reboot")},
+					{Name: "reboot-now", Type: ProvisionerTypeRestart, Restart: &RestartSpec{Timeout: &metav1.Duration{}}},
+					{Name: "shell", Type: ProvisionerTypeShell, Run: ptr.To("true"), Restart: &RestartSpec{}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0]: Forbidden",
+				"spec.provisioners[1].restart.timeout: Invalid",
+				"spec.provisioners[2].restart: Forbidden",
+			},
+		},
+		{
+			name: "invalid verify provisioner",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{{
+					Name: "verify",
+					Type: ProvisionerTypeVerify,
+					Verify: &VerifySpec{
+						Commands: []CommandAssertion{
+							{Command: "nginx -v", Matches: `nginx/1\.2[0-9]`},
+							{Command: "nginx -v", Matches: `nginx/(1`},
+						},
+						Suites: []VerifySuite{{Name: "base", Type: VerifySuiteTypeGoss}},
+					},
+				}},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].verify.commands[1].matches: Invalid",
+				"spec.provisioners[0].verify.suites[0].configMapKeyRef.name: Required",
+				"spec.provisioners[0].verify.suites[0].configMapKeyRef.key: Required",
+			},
+		},
+		{
+			name: "invalid harden provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH, Sudo: &SudoSpec{}},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "cis", Type: ProvisionerTypeHarden},
+					{
+						Name:   "cis-windows",
+						Type:   ProvisionerTypeHarden,
+						Harden: &HardenSpec{Profile: HardenProfileCISLevel1, Platform: HardenPlatformWindows},
+					},
+					{
+						Name:   "shell",
+						Type:   ProvisionerTypeShell,
+						Run:    ptr.To("true"),
+						Harden: &HardenSpec{Profile: HardenProfileCISLevel1, Platform: HardenPlatformUbuntu},
+					},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].harden: Required",
+				"spec.provisioners[1].harden.platform: Forbidden",
+				"spec.provisioners[2].harden: Forbidden",
+			},
+		},
+		{
+			name: "invalid extract provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "logs", Type: ProvisionerTypeExtract},
+					{
+						Name: "manifests",
+						Type: ProvisionerTypeExtract,
+						Extract: &ExtractProvisionerSpec{
+							Files: []ExtractFile{
+								{Path: "var/log/build.log"},
+								{Path: "/tmp/build.log"},
+							},
+							Destination: ExtractDestination{
+								ConfigMapRef: &corev1.LocalObjectReference{Name: "logs"},
+								SecretRef:    &corev1.LocalObjectReference{Name: "logs"},
+							},
+						},
+					},
+					{
+						Name: "oci",
+						Type: ProvisionerTypeExtract,
+						Extract: &ExtractProvisionerSpec{
+							Files:       []ExtractFile{{Path: "/var/log/build.log"}},
+							Destination: ExtractDestination{OCI: &OCIArtifactDestination{Reference: "ghcr.io/example/logs@sha256:0123"}},
+						},
+					},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].extract: Required",
+				"spec.provisioners[1].extract.files[0].path: Invalid",
+				"spec.provisioners[1].extract.files[1].key: Duplicate",
+				"spec.provisioners[1].extract.destination: Invalid",
+				"spec.provisioners[2].extract.destination.oci.reference: Invalid",
+			},
+		},
+		{
+			name: "invalid external provisioners",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: infrastructureRef,
+				Provisioners: []ProvisionerSpec{
+					{Name: "seed", Type: ProvisionerTypeExternal},
+					{Name: "vault", Type: ProvisionerTypeExternal, Class: "vault", Run: ptr.To("echo")},
+					{Name: "shell", Type: ProvisionerTypeShell, Run: ptr.To("echo"), Class: "vault",
+						Parameters: &apiextensionsv1.JSON{Raw: []byte(`{"path":"secret/ci"}`)}},
+				},
+			},
+			wantErr: []string{
+				"spec.provisioners[0].class: Required",
+				"spec.provisioners[1]: Forbidden",
+				"spec.provisioners[2].class: Forbidden",
+				"spec.provisioners[2].parameters: Forbidden",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &Build{Spec: tc.spec}
+			_, err := build.ValidateCreate()
+			if len(tc.wantErr) == 0 {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			for _, want := range tc.wantErr {
+				g.Expect(err).To(MatchError(ContainSubstring(want)))
+			}
+		})
+	}
+}
+
+func TestValidateImageNameImmutable(t *testing.T) {
+	g := NewWithT(t)
+
+	newBuild := func(imageName string) *Build {
+		return &Build{Spec: BuildSpec{TemplateRef: &BuildTemplateReference{Name: "ubuntu"}, ImageName: imageName}}
+	}
+	g.Expect(newBuild("ubuntu-1").validate(newBuild(""))).To(Succeed())
+	g.Expect(newBuild("ubuntu-1").validate(newBuild("ubuntu-1"))).To(Succeed())
+	g.Expect(newBuild("ubuntu-2").validate(newBuild("ubuntu-1"))).To(MatchError(ContainSubstring("spec.imageName: Forbidden")))
+}
+
+func TestValidateKubeconfig(t *testing.T) {
+	future := metav1.NewTime(time.Now().Add(time.Hour))
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	newBuild := func(provisioners ...ProvisionerSpec) *Build {
+		return &Build{Spec: BuildSpec{
+			Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+			InfrastructureRef: &corev1.ObjectReference{APIVersion: "infrastructure.forge.build/v1alpha1", Kind: "DockerBuild", Name: "ubuntu"},
+			Provisioners:      provisioners,
+		}}
+	}
+	shell := func(kubeconfig *KubeconfigSource) ProvisionerSpec {
+		return ProvisionerSpec{Type: ProvisionerTypeShell, Run: ptr.To("kubeadm token create"), Kubeconfig: kubeconfig}
+	}
+
+	testcases := []struct {
+		name    string
+		build   *Build
+		old     *Build
+		wantErr string
+	}{
+		{
+			name:  "valid kubeconfig",
+			build: newBuild(shell(&KubeconfigSource{SecretRef: corev1.LocalObjectReference{Name: "workload"}, ExpirationTime: future})),
+		},
+		{
+			name:    "expired kubeconfig",
+			build:   newBuild(shell(&KubeconfigSource{SecretRef: corev1.LocalObjectReference{Name: "workload"}, ExpirationTime: past})),
+			wantErr: "spec.provisioners[0].kubeconfig.expirationTime",
+		},
+		{
+			name:    "missing secret name",
+			build:   newBuild(shell(&KubeconfigSource{ExpirationTime: future})),
+			wantErr: "spec.provisioners[0].kubeconfig.secretRef.name",
+		},
+		{
+			name: "kubeconfig on a verify provisioner",
+			build: newBuild(ProvisionerSpec{
+				Type:       ProvisionerTypeVerify,
+				Kubeconfig: &KubeconfigSource{SecretRef: corev1.LocalObjectReference{Name: "workload"}, ExpirationTime: future},
+			}),
+			wantErr: "only allowed for built-in/shell provisioners",
+		},
+		{
+			name:  "unchanged kubeconfig expired after the Build was created",
+			build: newBuild(shell(&KubeconfigSource{SecretRef: corev1.LocalObjectReference{Name: "workload"}, ExpirationTime: past})),
+			old:   newBuild(shell(&KubeconfigSource{SecretRef: corev1.LocalObjectReference{Name: "workload"}, ExpirationTime: past})),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := tc.build.validate(tc.old)
+			if tc.wantErr == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildSetSpec defines the desired state of BuildSet
+// +kubebuilder:validation:XValidation:rule="!has(self.quorum) || self.quorum <= size(self.targets)",message="quorum must not exceed the number of targets"
+type BuildSetSpec struct {
+	// Targets are the infrastructure targets the image is built for, one Build is created per target.
+	// The Build of a removed target is deleted, the Build of a target is not recreated once it has finished.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	Targets []BuildSetTarget `json:"targets"`
+
+	// Quorum is the number of Builds which must complete for the BuildSet to be ready, defaults to all the targets.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Quorum *int32 `json:"quorum,omitempty"`
+
+	// BuildTemplate describes the Builds created by the BuildSet, the infrastructure of every Build is set by its target.
+	// +kubebuilder:validation:XValidation:rule="!has(self.spec.infrastructureRef)",message="the infrastructureRef of the Builds is set by the targets"
+	BuildTemplate BuildSetTemplate `json:"buildTemplate"`
+}
+
+// BuildSetTarget is an infrastructure target of a BuildSet.
+type BuildSetTarget struct {
+	// Name of the target, the Build of the target is named after the BuildSet suffixed with the target name.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// InfrastructureRef is a reference to the infrastructure object of the target, e.g. an AWSBuild.
+	// Every target must reference its own infrastructure object.
+	InfrastructureRef corev1.ObjectReference `json:"infrastructureRef"`
+
+	// Connector overrides the connector of the BuildTemplate for this target.
+	// +optional
+	Connector *ConnectorSpec `json:"connector,omitempty"`
+
+	// IdentityRef overrides the identityRef of the BuildTemplate for this target.
+	// +optional
+	IdentityRef *corev1.LocalObjectReference `json:"identityRef,omitempty"`
+
+	// Variables are added to the variables of the BuildTemplate for this target, they take precedence
+	// over the variables of the BuildTemplate with the same name.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Variables []BuildVariable `json:"variables,omitempty"`
+}
+
+// BuildSetTemplate describes the Builds created by a BuildSet.
+type BuildSetTemplate struct {
+	// Metadata are the labels and annotations set on the Builds.
+	// +optional
+	Metadata BuildSetTemplateMetadata `json:"metadata,omitempty"`
+
+	// Spec is the specification of the Builds.
+	Spec BuildSpec `json:"spec"`
+}
+
+// BuildSetTemplateMetadata is the metadata set on the Builds created by a BuildSet.
+type BuildSetTemplateMetadata struct {
+	// Labels set on the Builds.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations set on the Builds.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// BuildSetStatus defines the observed state of BuildSet
+type BuildSetStatus struct {
+	// Builds are the statuses of the Builds of the targets.
+	// +optional
+	// +listType=map
+	// +listMapKey=target
+	Builds []BuildSetBuildStatus `json:"builds,omitempty"`
+
+	// Completed is the number of Builds which have completed.
+	// +optional
+	Completed int32 `json:"completed,omitempty"`
+
+	// Failed is the number of Builds which have failed and are not going to be retried.
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// Ready is true once the quorum of Builds has completed.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// Health summarizes the state of the BuildSet for the GitOps tools.
+	// +optional
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// Conditions define the current state of the BuildSet.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// BuildSetBuildStatus is the status of the Build of a BuildSet target.
+type BuildSetBuildStatus struct {
+	// Target is the name of the target.
+	Target string `json:"target"`
+
+	// Name is the name of the Build.
+	Name string `json:"name"`
+
+	// Phase is the phase of the Build.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// ImageRef is the reference of the machine image produced by the Build.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// FailureMessage is the failure message of the Build.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=buildsets,scope=Namespaced,categories=forge,singular=buildset
+//+kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Whether the quorum of Builds has completed"
+//+kubebuilder:printcolumn:name="Completed",type="integer",JSONPath=".status.completed",description="Number of completed Builds"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed",description="Number of failed Builds"
+//+kubebuilder:printcolumn:name="Quorum",type="integer",JSONPath=".spec.quorum",description="Number of Builds which must complete",priority=1
+//+kubebuilder:printcolumn:name="Health",type="string",JSONPath=".status.health.status",description="Health of the BuildSet",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of BuildSet"
+
+// BuildSet is the Schema for the buildsets API
+type BuildSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BuildSetSpec   `json:"spec,omitempty"`
+	Status BuildSetStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (s *BuildSet) GetConditions() []metav1.Condition {
+	return s.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (s *BuildSet) SetConditions(conditions []metav1.Condition) {
+	s.Status.Conditions = conditions
+}
+
+// GetQuorum returns the number of Builds which must complete for the BuildSet to be ready.
+func (s *BuildSet) GetQuorum() int32 {
+	if s.Spec.Quorum == nil {
+		return int32(len(s.Spec.Targets))
+	}
+	return *s.Spec.Quorum
+}
+
+//+kubebuilder:object:root=true
+
+// BuildSetList contains a list of BuildSet
+type BuildSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BuildSet `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &BuildSet{}, &BuildSetList{})
+}
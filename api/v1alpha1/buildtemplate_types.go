@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildTemplateSpec defines a reusable, parameterized Build definition.
+type BuildTemplateSpec struct {
+	// Variables declares the variables which can be set by the Builds instantiating the template.
+	// Variables are referenced as ${name} in the string fields of the template.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Variables []BuildTemplateVariable `json:"variables,omitempty"`
+
+	// Template is the Build definition instantiated by the Builds referencing this template.
+	Template BuildTemplateResource `json:"template"`
+}
+
+// BuildTemplateVariable declares a variable of a BuildTemplate.
+type BuildTemplateVariable struct {
+	// Name of the variable.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+
+	// Description of the variable.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Required specifies if the variable must be set by the Build when it has no default.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// Default is the value of the variable when it is not set by the Build.
+	// +optional
+	Default *string `json:"default,omitempty"`
+}
+
+// BuildTemplateResource describes the Build created from a BuildTemplate.
+type BuildTemplateResource struct {
+	// Spec is the specification of the Build.
+	Spec BuildTemplateResourceSpec `json:"spec"`
+}
+
+// BuildTemplateResourceSpec is the part of the BuildSpec defined by a BuildTemplate.
+type BuildTemplateResourceSpec struct {
+	// Connector is the connector to the infrastructure machine.
+	// +optional
+	Connector *ConnectorSpec `json:"connector,omitempty"`
+
+	// InfrastructureRef is a reference to an infrastructure template, e.g. AWSBuildTemplate,
+	// which is cloned for every Build instantiated from the template.
+	// +optional
+	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// Provisioners is a list of provisioners to run on the infrastructure machine.
+	// +optional
+	Provisioners []ProvisionerSpec `json:"provisioners,omitempty"`
+
+	// DeleteCascade is a flag to specify whether the built image(s)
+	// going to be cleaned up when the build is deleted.
+	// +optional
+	DeleteCascade bool `json:"deleteCascade,omitempty"`
+
+	// Artifact defines how the machine images produced by the Builds are named.
+	// +optional
+	Artifact *ArtifactSpec `json:"artifact,omitempty"`
+}
+
+// BuildTemplateStatus defines the observed state of BuildTemplate.
+type BuildTemplateStatus struct {
+	// Serial is the last serial allocated to a Build instantiated from the template,
+	// it is the {{ .Serial }} of the name policy of the artifacts.
+	// +optional
+	Serial int64 `json:"serial,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=buildtemplates,scope=Namespaced,categories=forge,singular=buildtemplate
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of BuildTemplate"
+
+// BuildTemplate is the Schema for the buildtemplates API
+type BuildTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BuildTemplateSpec   `json:"spec,omitempty"`
+	Status BuildTemplateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BuildTemplateList contains a list of BuildTemplate
+type BuildTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BuildTemplate `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &BuildTemplate{}, &BuildTemplateList{})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BuildSource is the image of a previous Build the machine of a Build is created from, instead of the source image
+// of its infrastructure. The provisioners are layers: the Build skips its first provisioners whose content is
+// unchanged since the previous Build, and runs the others on top of its image.
+type BuildSource struct {
+	// BuildRef is a Build of the namespace which completed successfully, on the same kind of infrastructure.
+	// The Image cataloguing its machine image is used once the Build has been deleted.
+	// +optional
+	BuildRef *corev1.LocalObjectReference `json:"buildRef,omitempty"`
+
+	// ImageRef is the reference of a machine image in the infrastructure, e.g. an AMI ID. The provisioners it has
+	// been built with are read from the Image cataloguing it, all the provisioners run if there is none.
+	// +optional
+	ImageRef string `json:"imageRef,omitempty"`
+}
+
+// CacheStatus records the content hashes of the provisioners of a Build, and the ones skipped when the Build
+// starts from the image of spec.from.
+type CacheStatus struct {
+	// SourceImageRef is the machine image of spec.from the machine is created from.
+	// +optional
+	SourceImageRef string `json:"sourceImageRef,omitempty"`
+
+	// SourceBuild is the name of the Build of the source image, if known.
+	// +optional
+	SourceBuild string `json:"sourceBuild,omitempty"`
+
+	// Layers are the provisioners of the Build in their execution order, with the hash of their content.
+	// +optional
+	Layers []ProvisionerLayer `json:"layers,omitempty"`
+
+	// Hits is the number of provisioners skipped, their content being unchanged since the source image was built.
+	// +optional
+	Hits int32 `json:"hits,omitempty"`
+}
+
+// ProvisionerLayer is the content hash of a provisioner of a Build.
+type ProvisionerLayer struct {
+	// Name is the name of the provisioner, if any.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Hash is the sha256 of the definition of the provisioner, and of the scripts of its runConfigMapRef.
+	Hash string `json:"hash"`
+
+	// Cached is true if the provisioner has been skipped, as it already ran on the source image.
+	// +optional
+	Cached bool `json:"cached,omitempty"`
+}
@@ -16,6 +16,10 @@ const (
 	// tool uses this label for implementing provider's lifecycle operations.
 	ProviderNameLabel = "forge.build/provider"
 
+	// ProviderVersionAnnotation is the annotation set by forgectl on the components of a provider, with the version
+	// of the provider they have been installed from.
+	ProviderVersionAnnotation = "forge.build/provider-version"
+
 	// ManagedByLabel is an annotation that can be applied to InfraBuild resources to signify that
 	// some external system is managing the build infrastructure.
 	//
@@ -39,11 +43,16 @@ const (
 	// on the reconciled object.
 	PausedAnnotation = "forge.build/paused"
 
-	// WatchLabel is a label othat can be applied to any Build API object.
+	// AllowedInfrastructureKindsAnnotation is set on a Namespace, it lists the comma separated kinds of the
+	// infrastructure objects the Builds of the namespace may use when the controller runs in multi-tenancy mode,
+	// e.g. AWSBuild,GCPBuild, or * for all of them. The Builds of a namespace without it are not allowed any.
+	AllowedInfrastructureKindsAnnotation = "forge.build/allowed-infrastructure-kinds"
+
+	// WatchLabel is a label that can be applied to any Build API object.
 	//
 	// Controllers which allow for selective reconciliation may check this label and proceed
 	// with reconciliation of the object only if this label and a configured value is present.
-	WatchLabel = "cluster.x-k8s.io/watch-filter"
+	WatchLabel = "forge.build/watch-filter"
 
 	// BuildSecretType defines the type of secret created by core components.
 	BuildSecretType corev1.SecretType = "forge.build/secret" //nolint:gosec
@@ -51,6 +60,40 @@ const (
 	// ProvisionerIDLabel is the label set on job linked to a Build and
 	// provisioners.
 	ProvisionerIDLabel = "forge.build/provisioner-uuid"
+
+	// ScheduledBuildNameLabel is the label set on the Builds created by a ScheduledBuild.
+	ScheduledBuildNameLabel = "forge.build/scheduled-build-name"
+
+	// ScheduledTimeAnnotation is the annotation set on the Builds created by a ScheduledBuild,
+	// with the RFC 3339 time the Build has been scheduled for.
+	ScheduledTimeAnnotation = "forge.build/scheduled-at"
+
+	// BuildSetNameLabel is the label set on the Builds created by a BuildSet.
+	BuildSetNameLabel = "forge.build/build-set-name"
+
+	// BuildSetTargetLabel is the label set on the Builds created by a BuildSet, with the name of their target.
+	BuildSetTargetLabel = "forge.build/build-set-target"
+
+	// ParentBuildNameLabel is the label set on the Builds created by a multi-architecture Build.
+	ParentBuildNameLabel = "forge.build/parent-build-name"
+
+	// ArchitectureLabel is the label set on the Builds created by a multi-architecture Build and on their
+	// infrastructure objects, with the architecture they build the image for.
+	ArchitectureLabel = "forge.build/architecture"
+
+	// EphemeralCredentialsLabel is the label set to true on the connector credentials secrets generated for a
+	// single Build, which are shredded once the Build has finished.
+	EphemeralCredentialsLabel = "forge.build/ephemeral-credentials"
+)
+
+// Legacy label keys, inherited from Cluster API. They are still read for compatibility,
+// see the util/labels package, but are no longer written.
+const (
+	// LegacyWatchLabel is the Cluster API key of WatchLabel.
+	LegacyWatchLabel = "cluster.x-k8s.io/watch-filter"
+
+	// LegacyProviderNameLabel is the Cluster API key of ProviderNameLabel.
+	LegacyProviderNameLabel = "cluster.x-k8s.io/provider"
 )
 
 const (
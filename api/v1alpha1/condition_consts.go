@@ -19,8 +19,8 @@ package v1alpha1
 import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 const (
-	// ReadyCondition defines the Ready condition type that summarizes the operational state of a Cluster API object.
-	ReadyCondition clusterv1.ConditionType = "Ready"
+	// ReadyCondition defines the Ready condition type that summarizes the operational state of a Forge object.
+	ReadyCondition = "Ready"
 )
 
 // Common ConditionReason used by Cluster API objects.
@@ -44,12 +44,16 @@ const (
 	// This condition is mirrored from the Ready condition in the infrastructure ref object, and
 	// the absence of this condition might signal problems in the reconcile external loops or the fact that
 	// the infrastructure provider does not implement the Ready condition yet.
-	InfrastructureReadyCondition clusterv1.ConditionType = "InfrastructureReady"
+	InfrastructureReadyCondition = "InfrastructureReady"
 
 	// WaitingForInfrastructureFallbackReason (Severity=Info) documents a cluster/machine/machinepool waiting for the underlying infrastructure
 	// to be available.
 	// NOTE: This reason is used only as a fallback when the infrastructure object is not reporting its own ready condition.
 	WaitingForInfrastructureFallbackReason = "WaitingForInfrastructure"
+
+	// InfrastructureNotAllowedReason (Severity=Error) documents a Build whose infrastructure is not allowed in its
+	// namespace by the multi-tenancy mode of the controller.
+	InfrastructureNotAllowedReason = "InfrastructureNotAllowed"
 )
 
 // ANCHOR_END: CommonConditions
@@ -80,8 +84,8 @@ const (
 	// WaitingForConnectionReason (Severity=Info) documents a build waiting for the connection to the infrastructure.
 	WaitingForConnectionReason = "WaitingForConnection"
 
-	// MachineReadyCondition reports the ready condition from the Machine object that is used as the builder machine.
-	MachineReadyCondition clusterv1.ConditionType = "MachineReady"
+	// MachineReadyCondition reports if the infrastructure machine used as the builder machine is up and running.
+	MachineReadyCondition = "MachineReady"
 
 	// WaitingForControlPlaneFallbackReason (Severity=Info) documents a cluster waiting for the control plane
 	// to be available.
@@ -99,9 +103,228 @@ const (
 const (
 	// ProvisionersReadyCondition reports a summary of current status of the build object defined for this machine.
 	// This condition is mirrored from the Ready condition in the provisioners.
-	ProvisionersReadyCondition clusterv1.ConditionType = "ProvisionersReady"
+	ProvisionersReadyCondition = "ProvisionersReady"
+)
+
+// Conditions and condition Reasons for the Build object.
+const (
+	// ConnectedCondition reports if the connection to the infrastructure machine has been established.
+	ConnectedCondition = "Connected"
+
+	// ImageExportedCondition reports if the machine image has been exported by the infrastructure provider.
+	ImageExportedCondition = "ImageExported"
+
+	// ProvisionerReadyConditionPrefix is the prefix of the per-provisioner condition types,
+	// the provisioner UUID is appended to it, see ProvisionerReadyCondition.
+	ProvisionerReadyConditionPrefix = "ProvisionerReady-"
+
+	// ReadyReason documents a Build for which all the summarized conditions are True.
+	ReadyReason = "Ready"
+
+	// MachineProvisionedReason documents an infrastructure machine which is up and running.
+	MachineProvisionedReason = "MachineProvisioned"
+
+	// WaitingForMachineReason (Severity=Info) documents a build waiting for the infrastructure machine to be running.
+	WaitingForMachineReason = "WaitingForMachine"
+
+	// MachinePreemptedReason (Severity=Warning) documents a spot or preemptible machine reclaimed by the cloud
+	// provider, the machine is being recreated.
+	MachinePreemptedReason = "MachinePreempted"
+
+	// ConnectionEstablishedReason documents a successful connection to the infrastructure machine.
+	ConnectionEstablishedReason = "ConnectionEstablished"
+
+	// ConnectionFailedReason (Severity=Warning) documents a failed attempt to connect to the infrastructure machine.
+	ConnectionFailedReason = "ConnectionFailed"
+
+	// ProvisionerPendingReason (Severity=Info) documents a provisioner which has not started yet.
+	ProvisionerPendingReason = "ProvisionerPending"
+
+	// ProvisionerRunningReason (Severity=Info) documents a provisioner which is running.
+	ProvisionerRunningReason = "ProvisionerRunning"
+
+	// ProvisionerRetryingReason (Severity=Info) documents a provisioner whose Job failed and is recreated once its
+	// retry delay elapsed.
+	ProvisionerRetryingReason = "ProvisionerRetrying"
+
+	// ProvisionerSucceededReason documents a provisioner which completed successfully.
+	ProvisionerSucceededReason = "ProvisionerSucceeded"
+
+	// ProvisionerFailedReason (Severity=Error) documents a provisioner which failed.
+	ProvisionerFailedReason = "ProvisionerFailed"
+
+	// ProvisionerDeadlineExceededReason (Severity=Error) documents a provisioner whose Job has been stopped
+	// after running longer than its active deadline.
+	ProvisionerDeadlineExceededReason = "ProvisionerDeadlineExceeded"
+
+	// WaitingForApprovalReason (Severity=Info) documents a build waiting for a provisioner to be approved before it runs.
+	WaitingForApprovalReason = "WaitingForApproval"
+
+	// ProvisionersSucceededReason documents a build for which all the provisioners completed.
+	ProvisionersSucceededReason = "ProvisionersSucceeded"
+
+	// ImageExportedReason documents a build for which the machine image has been exported.
+	ImageExportedReason = "ImageExported"
+
+	// WaitingForImageExportReason (Severity=Info) documents a build waiting for the image to be exported.
+	WaitingForImageExportReason = "WaitingForImageExport"
+
+	// SimulatedReason documents a Build in simulation mode for which the provisioners have been checked,
+	// no image is exported.
+	SimulatedReason = "Simulated"
+
+	// DryRunReason documents a Build in dry-run mode for which the plan of the provisioners has been rendered,
+	// no image is exported.
+	DryRunReason = "DryRun"
+)
+
+// Conditions and condition Reasons for the exports of a Build.
+const (
+	// ExportsPublishedCondition reports if the exports of the Build have been packaged and published.
+	ExportsPublishedCondition = "ExportsPublished"
+
+	// ExportsPublishedReason documents a Build for which all the exports have been published.
+	ExportsPublishedReason = "ExportsPublished"
+
+	// WaitingForExportsReason (Severity=Info) documents a Build waiting for its exports to be published.
+	WaitingForExportsReason = "WaitingForExports"
+
+	// ExportFailedReason (Severity=Error) documents a Build for which an export failed.
+	ExportFailedReason = "ExportFailed"
 )
 
+// Conditions and condition Reasons for the architectures of a multi-architecture Build.
+const (
+	// ArchitecturesReadyCondition reports if the image has been built for all the architectures of the Build.
+	ArchitecturesReadyCondition = "ArchitecturesReady"
+
+	// ArchitecturesBuiltReason documents a Build for which the image has been built for all the architectures.
+	ArchitecturesBuiltReason = "ArchitecturesBuilt"
+
+	// WaitingForArchitecturesReason (Severity=Info) documents a Build waiting for the Builds of its architectures.
+	WaitingForArchitecturesReason = "WaitingForArchitectures"
+
+	// ArchitectureBuildFailedReason (Severity=Error) documents a Build for which the Build of an architecture failed.
+	ArchitectureBuildFailedReason = "ArchitectureBuildFailed"
+)
+
+// Conditions and condition Reasons for Builds instantiated from a BuildTemplate.
+const (
+	// TemplateResolvedCondition reports if the BuildTemplate referenced by the Build has been instantiated.
+	TemplateResolvedCondition = "TemplateResolved"
+
+	// TemplateResolvedReason documents a Build for which the BuildTemplate has been instantiated.
+	TemplateResolvedReason = "TemplateResolved"
+
+	// TemplateNotFoundReason (Severity=Warning) documents a Build referencing a BuildTemplate which does not exist.
+	TemplateNotFoundReason = "TemplateNotFound"
+
+	// TemplateRenderFailedReason (Severity=Error) documents a Build for which the BuildTemplate can't be rendered,
+	// e.g. because a required variable is not set.
+	TemplateRenderFailedReason = "TemplateRenderFailed"
+)
+
+// Conditions and condition Reasons for ScheduledBuilds.
+const (
+	// ScheduleValidCondition reports if the schedule of the ScheduledBuild can be parsed.
+	ScheduleValidCondition = "ScheduleValid"
+
+	// ScheduleValidReason documents a ScheduledBuild with a valid schedule.
+	ScheduleValidReason = "ScheduleValid"
+
+	// InvalidScheduleReason (Severity=Error) documents a ScheduledBuild with a schedule which can't be parsed.
+	InvalidScheduleReason = "InvalidSchedule"
+)
+
+// Condition Reasons for the Ready condition of BuildSets.
+const (
+	// QuorumReachedReason documents a BuildSet whose quorum of Builds has completed.
+	QuorumReachedReason = "QuorumReached"
+
+	// WaitingForBuildsReason documents a BuildSet waiting for its Builds to complete.
+	WaitingForBuildsReason = "WaitingForBuilds"
+
+	// QuorumUnreachableReason (Severity=Error) documents a BuildSet with too many failed Builds for its quorum to complete.
+	QuorumUnreachableReason = "QuorumUnreachable"
+)
+
+// Condition Reasons for the Ready condition of ImageRetentionPolicies.
+const (
+	// RetentionAppliedReason documents an ImageRetentionPolicy whose expired Images have been deleted.
+	RetentionAppliedReason = "RetentionApplied"
+
+	// InvalidSelectorReason (Severity=Error) documents an ImageRetentionPolicy with a selector which can't be parsed.
+	InvalidSelectorReason = "InvalidSelector"
+
+	// ImageDeletionFailedReason (Severity=Warning) documents an ImageRetentionPolicy which failed to delete the
+	// machine image of an expired Image, the deletion is retried at the next evaluation.
+	ImageDeletionFailedReason = "ImageDeletionFailed"
+)
+
+// Conditions and condition Reasons for Builds queued by the concurrency limits of the controller.
+const (
+	// AdmittedCondition reports if the Build has been admitted by the concurrency limits of the controller,
+	// the Builds waiting to be admitted stay in the Pending phase.
+	AdmittedCondition = "Admitted"
+
+	// AdmittedReason documents a Build admitted by the concurrency limits of the controller.
+	AdmittedReason = "Admitted"
+
+	// ConcurrencyLimitReachedReason (Severity=Info) documents a Build waiting for other Builds to finish,
+	// the number of Builds in progress in its namespace or in the cluster has reached the limit of the controller.
+	ConcurrencyLimitReachedReason = "ConcurrencyLimitReached"
+)
+
+// Conditions and condition Reasons for Builds exceeding their timeouts.
+const (
+	// DeadlineExceededCondition is True when the Build has been failed because one of its timeouts has been exceeded.
+	DeadlineExceededCondition = "DeadlineExceeded"
+
+	// MachineReadyTimeoutReason (Severity=Error) documents a Build whose machine has not been ready in time.
+	MachineReadyTimeoutReason = "MachineReadyTimeout"
+
+	// ConnectionTimeoutReason (Severity=Error) documents a Build which has not connected to its machine in time.
+	ConnectionTimeoutReason = "ConnectionTimeout"
+
+	// ProvisioningTimeoutReason (Severity=Error) documents a Build whose provisioners have not completed in time.
+	ProvisioningTimeoutReason = "ProvisioningTimeout"
+
+	// OverallDeadlineExceededReason (Severity=Error) documents a Build which has not completed in time.
+	OverallDeadlineExceededReason = "OverallDeadlineExceeded"
+)
+
+// Conditions and condition Reasons for ProviderIdentities and the Builds referencing them.
+const (
+	// CredentialsValidCondition reports if the credentials of a ProviderIdentity have been successfully validated
+	// against the infrastructure provider. On a Build it mirrors the condition of the referenced ProviderIdentity.
+	CredentialsValidCondition = "CredentialsValid"
+
+	// CredentialsValidReason documents credentials which have been successfully validated.
+	CredentialsValidReason = "CredentialsValid"
+
+	// CredentialsInvalidReason (Severity=Error) documents credentials rejected by the infrastructure provider.
+	CredentialsInvalidReason = "CredentialsInvalid"
+
+	// CredentialsSecretNotFoundReason (Severity=Error) documents a ProviderIdentity referencing a secret which does not exist.
+	CredentialsSecretNotFoundReason = "CredentialsSecretNotFound"
+
+	// WaitingForCredentialsValidationReason (Severity=Info) documents a Build waiting for the credentials of
+	// its ProviderIdentity to be validated.
+	WaitingForCredentialsValidationReason = "WaitingForCredentialsValidation"
+
+	// IdentityNotFoundReason (Severity=Warning) documents a Build referencing a ProviderIdentity which does not exist.
+	IdentityNotFoundReason = "IdentityNotFound"
+
+	// IdentityNotValidatedReason (Severity=Error) documents a Build referencing a ProviderIdentity whose credentials
+	// have not been validated in time, e.g. because the infrastructure provider runs no ProviderIdentity controller.
+	IdentityNotValidatedReason = "IdentityNotValidated"
+)
+
+// ProvisionerReadyCondition returns the condition type reporting the state of the provisioner with the given UUID.
+func ProvisionerReadyCondition(uuid string) string {
+	return ProvisionerReadyConditionPrefix + uuid
+}
+
 // Conditions and condition Reasons for the Machine object.
 
 const (
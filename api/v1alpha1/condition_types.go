@@ -37,6 +37,7 @@ const (
 )
 
 // Condition defines an observation of a Cluster API resource operational state.
+// +k8s:conversion-gen=false
 type Condition struct {
 	// Type of condition in CamelCase or in foo.example.com/CamelCase.
 	// Many .condition.type values are consistent across resources like Available, but because arbitrary conditions
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialsProviderSpec defines the external secret manager credentials are resolved from, exactly one of vault,
+// awsSecretsManager and gcpSecretManager must be set. The credentials are fetched on every reconciliation of the
+// objects referencing them and kept in memory only, they are never stored in a Secret.
+// +kubebuilder:validation:XValidation:rule="[has(self.vault), has(self.awsSecretsManager), has(self.gcpSecretManager)].filter(x, x).size() == 1",message="exactly one of vault, awsSecretsManager and gcpSecretManager must be set"
+type CredentialsProviderSpec struct {
+	// Vault resolves the credentials from HashiCorp Vault.
+	// +optional
+	Vault *VaultCredentialsProvider `json:"vault,omitempty"`
+
+	// AWSSecretsManager resolves the credentials from AWS Secrets Manager.
+	// +optional
+	AWSSecretsManager *AWSSecretsManagerCredentialsProvider `json:"awsSecretsManager,omitempty"`
+
+	// GCPSecretManager resolves the credentials from Google Cloud Secret Manager.
+	// +optional
+	GCPSecretManager *GCPSecretManagerCredentialsProvider `json:"gcpSecretManager,omitempty"`
+}
+
+// VaultEngine is the secrets engine of Vault the credentials are read from.
+type VaultEngine string
+
+const (
+	// VaultEngineKVv2 reads the latest version, or the given version, of a secret of a KV version 2 secrets engine.
+	VaultEngineKVv2 VaultEngine = "KVv2"
+
+	// VaultEngineKVv1 reads a secret of a KV version 1 secrets engine.
+	VaultEngineKVv1 VaultEngine = "KVv1"
+
+	// VaultEngineDynamic reads the path as is, e.g. aws/creds/forge, the dynamic secrets engines generate
+	// short-lived credentials on every read.
+	VaultEngineDynamic VaultEngine = "Dynamic"
+)
+
+// VaultCredentialsProvider is a Vault server.
+type VaultCredentialsProvider struct {
+	// Address is the URL of the Vault server, e.g. https://vault.example.com:8200.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Address string `json:"address"`
+
+	// Namespace is the Vault Enterprise namespace of the secrets.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// CABundle is the PEM encoded CA certificates the TLS certificate of the server is verified with, the system
+	// CAs are used when not set.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// Engine is the secrets engine the credentials are read from, KVv2 by default.
+	// +optional
+	// +kubebuilder:validation:Enum=KVv2;KVv1;Dynamic
+	Engine VaultEngine `json:"engine,omitempty"`
+
+	// Mount is the mount path of the KV secrets engine, defaults to secret. It is not used by the Dynamic engine.
+	// +optional
+	Mount string `json:"mount,omitempty"`
+
+	// Auth is how the controllers authenticate with Vault.
+	Auth VaultAuth `json:"auth"`
+}
+
+// VaultAuth is how the controllers authenticate with Vault, exactly one of kubernetes and tokenSecretRef must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.kubernetes) != has(self.tokenSecretRef)",message="exactly one of kubernetes and tokenSecretRef must be set"
+type VaultAuth struct {
+	// Kubernetes logs in with the service account token of the controller.
+	// +optional
+	Kubernetes *VaultKubernetesAuth `json:"kubernetes,omitempty"`
+
+	// TokenSecretRef is the secret holding a Vault token in its token key, in the namespace of the
+	// CredentialsProvider.
+	// +optional
+	TokenSecretRef *corev1.LocalObjectReference `json:"tokenSecretRef,omitempty"`
+}
+
+// VaultKubernetesAuth is the Kubernetes auth method of Vault.
+type VaultKubernetesAuth struct {
+	// Role is the role the controller logs in as.
+	// +kubebuilder:validation:MinLength=1
+	Role string `json:"role"`
+
+	// MountPath is the mount path of the auth method, defaults to kubernetes.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// AWSSecretsManagerCredentialsProvider is the AWS Secrets Manager of a region.
+type AWSSecretsManagerCredentialsProvider struct {
+	// Region is the region of the secrets.
+	// +kubebuilder:validation:MinLength=1
+	Region string `json:"region"`
+
+	// Endpoint is the endpoint of the API, defaults to https://secretsmanager.<region>.amazonaws.com.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// CredentialsRef is the secret holding the credentials reading the secrets in its accessKeyID, secretAccessKey
+	// and optional sessionToken keys, in the namespace of the CredentialsProvider.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
+
+// GCPSecretManagerCredentialsProvider is the Secret Manager of a Google Cloud project.
+type GCPSecretManagerCredentialsProvider struct {
+	// Project is the ID of the project of the secrets.
+	// +kubebuilder:validation:MinLength=1
+	Project string `json:"project"`
+
+	// Endpoint is the endpoint of the API, defaults to https://secretmanager.googleapis.com.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// CredentialsRef is the secret holding the key of the service account reading the secrets in its
+	// credentials.json key, in the namespace of the CredentialsProvider.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
+
+// CredentialsProviderReference references credentials resolved by a CredentialsProvider. The credentials are a set
+// of keys, as the data of a Secret: the keys of the Vault secret, or the keys of the JSON object held by the AWS or
+// GCP secret.
+type CredentialsProviderReference struct {
+	// Name is the name of the CredentialsProvider, in the namespace of the referencing object.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Path identifies the secret: its path in the secrets engine of Vault, e.g. forge/aws, the name or the ARN
+	// of the AWS secret, or the name of the GCP secret.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Version is the version of the secret, the latest by default: the version number of a KVv2 secret,
+	// the version ID of an AWS secret or the version of a GCP secret.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Key stores the whole value of an AWS or GCP secret under this key instead of reading it as a JSON object,
+	// e.g. credentials.json for the service account key of a GCPBuild.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Keys renames the keys of the secret to the keys expected by the consumer of the credentials, e.g.
+	// access_key: accessKeyID for the credentials generated by the AWS secrets engine of Vault. The keys which
+	// are not renamed are kept.
+	// +optional
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=credentialsproviders,scope=Namespaced,categories=forge,singular=credentialsprovider
+//+kubebuilder:printcolumn:name="Vault",type="string",JSONPath=".spec.vault.address",description="Address of the Vault server"
+//+kubebuilder:printcolumn:name="AWS",type="string",JSONPath=".spec.awsSecretsManager.region",description="Region of AWS Secrets Manager"
+//+kubebuilder:printcolumn:name="GCP",type="string",JSONPath=".spec.gcpSecretManager.project",description="Project of GCP Secret Manager"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of CredentialsProvider"
+
+// CredentialsProvider is the Schema for the credentialsproviders API
+type CredentialsProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CredentialsProviderSpec `json:"spec,omitempty"`
+}
+
+// GetEngine returns the secrets engine of Vault the credentials are read from.
+func (v *VaultCredentialsProvider) GetEngine() VaultEngine {
+	if v.Engine == "" {
+		return VaultEngineKVv2
+	}
+	return v.Engine
+}
+
+// GetMount returns the mount path of the KV secrets engine.
+func (v *VaultCredentialsProvider) GetMount() string {
+	if v.Mount == "" {
+		return "secret"
+	}
+	return v.Mount
+}
+
+//+kubebuilder:object:root=true
+
+// CredentialsProviderList contains a list of CredentialsProvider
+type CredentialsProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CredentialsProvider `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &CredentialsProvider{}, &CredentialsProviderList{})
+}
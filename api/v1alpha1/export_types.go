@@ -0,0 +1,321 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ExportNameLabel is the label set on the export Jobs of a Build, with the name of the export.
+	ExportNameLabel = "forge.build/export-name"
+
+	// DefaultBoxRegistryURL is the API of the box registry the Vagrant boxes are published to when not set.
+	DefaultBoxRegistryURL = "https://app.vagrantup.com/api/v1"
+
+	// BoxRegistryTokenKey is the key of the secret holding the access token of a box registry.
+	BoxRegistryTokenKey = "token"
+
+	// ObjectStorageAccessKeyIDKey is the key of the secret holding the access key ID of an object storage.
+	ObjectStorageAccessKeyIDKey = "accessKeyID"
+
+	// ObjectStorageSecretAccessKeyKey is the key of the secret holding the secret access key of an object storage.
+	ObjectStorageSecretAccessKeyKey = "secretAccessKey"
+
+	// AzureBlobSASTokenKey is the key of the secret holding the shared access signature of an Azure Storage container.
+	AzureBlobSASTokenKey = "sasToken"
+
+	// HTTPTokenKey, HTTPUsernameKey and HTTPPasswordKey are the keys of the secret holding the credentials of an
+	// HTTP destination.
+	HTTPTokenKey    = "token"
+	HTTPUsernameKey = "username"
+	HTTPPasswordKey = "password"
+
+	// OCIRegistryUsernameKey and OCIRegistryPasswordKey are the keys of the secret holding the credentials of an OCI
+	// registry, when it is not a kubernetes.io/dockerconfigjson secret.
+	OCIRegistryUsernameKey = "username"
+	OCIRegistryPasswordKey = "password"
+)
+
+// ExportFormat is the format the image produced by a Build is packaged in.
+// +kubebuilder:validation:Enum=VagrantBox;OVA;VMDK;QCOW2;VHD;Raw
+type ExportFormat string
+
+const (
+	// ExportFormatVagrantBox packages the image as a Vagrant box, one per Vagrant provider.
+	ExportFormatVagrantBox ExportFormat = "VagrantBox"
+
+	// ExportFormatOVA packages the image as an OVA appliance, which can be imported in VirtualBox and VMware desktop.
+	ExportFormatOVA ExportFormat = "OVA"
+
+	// ExportFormatVMDK converts the image to a stream optimized VMDK disk.
+	ExportFormatVMDK ExportFormat = "VMDK"
+
+	// ExportFormatQCOW2 converts the image to a qcow2 disk, e.g. for OpenStack or libvirt.
+	ExportFormatQCOW2 ExportFormat = "QCOW2"
+
+	// ExportFormatVHD converts the image to a fixed size VHD disk, as expected by Azure and Hyper-V.
+	ExportFormatVHD ExportFormat = "VHD"
+
+	// ExportFormatRaw converts the image to a raw disk, e.g. to be written to bare metal machines.
+	ExportFormatRaw ExportFormat = "Raw"
+)
+
+// VagrantProvider is a Vagrant provider a box is packaged for.
+// +kubebuilder:validation:Enum=libvirt;virtualbox;vmware_desktop
+type VagrantProvider string
+
+const (
+	// VagrantProviderLibvirt packages a qcow2 disk for the vagrant-libvirt provider.
+	VagrantProviderLibvirt VagrantProvider = "libvirt"
+
+	// VagrantProviderVirtualBox packages an OVF appliance for the VirtualBox provider.
+	VagrantProviderVirtualBox VagrantProvider = "virtualbox"
+
+	// VagrantProviderVMwareDesktop packages a VMX machine for the VMware desktop provider.
+	VagrantProviderVMwareDesktop VagrantProvider = "vmware_desktop"
+)
+
+// ExportSpec defines how the image produced by a Build is packaged and where it is published, so desktop
+// environments and other platforms can consume the same images as production.
+// +kubebuilder:validation:XValidation:rule="self.format != 'VagrantBox' || has(self.vagrant)",message="vagrant is required for the VagrantBox format"
+// +kubebuilder:validation:XValidation:rule="self.format == 'VagrantBox' || !has(self.vagrant)",message="vagrant is only supported by the VagrantBox format"
+// +kubebuilder:validation:XValidation:rule="self.format == 'VagrantBox' || !has(self.destination.boxRegistry)",message="only Vagrant boxes can be published to a box registry"
+// +kubebuilder:validation:XValidation:rule="self.format != 'VagrantBox' || has(self.destination.boxRegistry) || has(self.destination.objectStorage)",message="Vagrant boxes can only be published to a box registry or an object storage"
+// +kubebuilder:validation:XValidation:rule="!has(self.destination.ociRegistry) || self.format in ['QCOW2', 'Raw']",message="only QCOW2 and Raw disks can be pushed to an OCI registry"
+type ExportSpec struct {
+	// Name identifies the export within the Build.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Format is the format the image is packaged in, one of VagrantBox, OVA, VMDK, QCOW2, VHD or Raw.
+	Format ExportFormat `json:"format"`
+
+	// Vagrant defines the Vagrant box, it is required for the VagrantBox format.
+	// +optional
+	Vagrant *VagrantExport `json:"vagrant,omitempty"`
+
+	// Destination is where the packaged image is published.
+	Destination ExportDestination `json:"destination"`
+}
+
+// VagrantExport defines the Vagrant box an image is packaged as.
+type VagrantExport struct {
+	// BoxName is the name of the box, as <organization>/<name>.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`
+	BoxName string `json:"boxName"`
+
+	// Version is the version of the box, it defaults to the creation time of the Build as YYYYMMDD.HHMMSS
+	// so every Build publishes a new version.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+){0,2}$`
+	Version string `json:"version,omitempty"`
+
+	// Providers are the Vagrant providers the box is packaged for.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Providers []VagrantProvider `json:"providers"`
+}
+
+// ExportDestination is where a packaged image is published, exactly one of its fields must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.boxRegistry), has(self.objectStorage), has(self.azureBlob), has(self.http), has(self.ociRegistry)].filter(x, x).size() == 1",message="exactly one of boxRegistry, objectStorage, azureBlob, http and ociRegistry must be set"
+type ExportDestination struct {
+	// BoxRegistry publishes the Vagrant box to a Vagrant Cloud compatible box registry.
+	// +optional
+	BoxRegistry *BoxRegistryDestination `json:"boxRegistry,omitempty"`
+
+	// ObjectStorage uploads the packaged image to an S3 compatible object storage. Vagrant boxes are
+	// published along with a box catalog, so they can be added with vagrant box add <catalog URL>.
+	// +optional
+	ObjectStorage *ObjectStorageDestination `json:"objectStorage,omitempty"`
+
+	// AzureBlob uploads the packaged image to a container of an Azure Storage account.
+	// +optional
+	AzureBlob *AzureBlobDestination `json:"azureBlob,omitempty"`
+
+	// HTTP uploads the packaged image to an HTTP endpoint with a PUT request.
+	// +optional
+	HTTP *HTTPDestination `json:"http,omitempty"`
+
+	// OCIRegistry pushes the QCOW2 or Raw disk to a container registry as an OCI artifact, so it is distributed
+	// with the replication, authentication and retention of the registry, e.g. pulled with oras pull.
+	// +optional
+	OCIRegistry *OCIRegistryDestination `json:"ociRegistry,omitempty"`
+}
+
+// BoxRegistryDestination is a Vagrant Cloud compatible box registry.
+type BoxRegistryDestination struct {
+	// URL is the URL of the API of the registry, defaults to https://app.vagrantup.com/api/v1.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// CredentialsRef is the secret holding the access token of the registry in its token key.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
+
+// GetURL returns the URL of the API of the registry.
+func (d *BoxRegistryDestination) GetURL() string {
+	if d.URL == "" {
+		return DefaultBoxRegistryURL
+	}
+	return d.URL
+}
+
+// ObjectStorageDestination is an S3 compatible object storage, e.g. AWS S3, MinIO, or Google Cloud Storage with
+// its https://storage.googleapis.com endpoint and HMAC keys.
+type ObjectStorageDestination struct {
+	// URL is the bucket and the prefix the files are uploaded to, e.g. s3://images/vagrant.
+	// +kubebuilder:validation:Pattern=`^s3://[a-z0-9][a-z0-9.-]*[a-z0-9](/.*)?$`
+	URL string `json:"url"`
+
+	// Endpoint is the endpoint of the object storage, e.g. https://minio.example.com. It defaults to
+	// the AWS S3 endpoint of the region.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket, defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsRef is the secret holding the credentials of the object storage, in its accessKeyID
+	// and secretAccessKey keys.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
+
+// AzureBlobDestination is a container of an Azure Storage account.
+type AzureBlobDestination struct {
+	// URL is the container and the prefix the files are uploaded to,
+	// e.g. https://forge.blob.core.windows.net/images/desktop.
+	// +kubebuilder:validation:Pattern=`^https://[^/]+/[a-z0-9]([-a-z0-9]*[a-z0-9])?(/.*)?$`
+	URL string `json:"url"`
+
+	// CredentialsRef is the secret holding a shared access signature of the container, granting the create and
+	// write permissions, in its sasToken key.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+}
+
+// HTTPDestination is an HTTP endpoint accepting uploads with PUT requests, e.g. a WebDAV server or an artifact
+// repository.
+type HTTPDestination struct {
+	// URL is the URL the files are uploaded under, the file of an export is uploaded to
+	// <url>/<build>/<export>.<format>.
+	// +kubebuilder:validation:Pattern=`^https?://.+$`
+	URL string `json:"url"`
+
+	// CredentialsRef is the secret holding the credentials of the endpoint: a bearer token in its token key, or a
+	// username and a password in its username and password keys. The uploads are not authenticated when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+}
+
+// OCIRegistryDestination is a repository of a container registry implementing the OCI distribution specification.
+type OCIRegistryDestination struct {
+	// Repository is the repository the artifact is pushed to, e.g. ghcr.io/forge-build/images/ubuntu.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?(:[0-9]+)?/[a-z0-9]+([._/-][a-z0-9]+)*$`
+	Repository string `json:"repository"`
+
+	// Tags are the tags of the artifact, defaulting to the creation time of the Build as YYYYMMDD.HHMMSS so every
+	// Build pushes a new tag.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`
+	Tags []string `json:"tags,omitempty"`
+
+	// Annotations are added to the manifest of the artifact, on top of its creation time and the names of the
+	// Build and of the export.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Insecure pushes to the registry over plain HTTP.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// CredentialsRef is the secret holding the credentials of the registry: a kubernetes.io/dockerconfigjson
+	// secret, or a username and a password in its username and password keys. The artifact is pushed anonymously
+	// when not set.
+	// +optional
+	CredentialsRef *corev1.LocalObjectReference `json:"credentialsRef,omitempty"`
+}
+
+// ExportPhase is the phase of an export.
+type ExportPhase string
+
+const (
+	// ExportPhasePending is the phase of an export waiting for the image to be exported.
+	ExportPhasePending ExportPhase = "Pending"
+
+	// ExportPhaseRunning is the phase of an export whose Job is packaging and publishing the image.
+	ExportPhaseRunning ExportPhase = "Running"
+
+	// ExportPhaseSucceeded is the phase of an export which has been published.
+	ExportPhaseSucceeded ExportPhase = "Succeeded"
+
+	// ExportPhaseFailed is the phase of an export whose Job failed.
+	ExportPhaseFailed ExportPhase = "Failed"
+)
+
+// ExportStatus is the status of an export of a Build.
+type ExportStatus struct {
+	// Name is the name of the export.
+	Name string `json:"name"`
+
+	// Phase is the phase of the export, either Pending, Running, Succeeded or Failed.
+	// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+	Phase ExportPhase `json:"phase"`
+
+	// JobName is the name of the Job packaging and publishing the image.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// URL is where the export is published, e.g. the box catalog or the box registry page.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Checksum is the checksum of the published file, prefixed with the algorithm, e.g. sha256:2c26b4... It is not
+	// set for the Vagrant boxes, whose checksums are in their catalog.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// SizeBytes is the size of the published file in bytes.
+	// +optional
+	SizeBytes *int64 `json:"sizeBytes,omitempty"`
+
+	// Signature is where the cosign signature of the export is published, set when spec.artifact.signing is.
+	// +optional
+	Signature string `json:"signature,omitempty"`
+
+	// Attestation is where the signed SLSA provenance attestation of the export is published, set when
+	// spec.artifact.signing is.
+	// +optional
+	Attestation string `json:"attestation,omitempty"`
+
+	// SBOM is the reference of the software bill of materials of the machine attached as a referrer to the OCI
+	// artifact of the export, set when status.sbom is stored in a ConfigMap or a Secret.
+	// +optional
+	SBOM string `json:"sbom,omitempty"`
+
+	// FailureMessage is the reason the export failed.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// CompletionTime is the time the export succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
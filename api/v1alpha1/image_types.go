@@ -0,0 +1,187 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// BuildUIDLabel is the label set on the Images with the UID of the Build which produced them.
+	BuildUIDLabel = "forge.build/build-uid"
+
+	// BuildTemplateNameLabel is the label set on the Images with the name of the BuildTemplate of their Build.
+	BuildTemplateNameLabel = "forge.build/build-template-name"
+
+	// InfrastructureKindLabel is the label set on the Images with the kind of the infrastructure of their Build,
+	// e.g. AWSBuild.
+	InfrastructureKindLabel = "forge.build/infrastructure-kind"
+)
+
+// ImageSpec records a machine image produced by a Build: where it is, how it has been produced and until when
+// it is kept.
+type ImageSpec struct {
+	// BuildRef is the Build which produced the image, the Image is kept when the Build is deleted.
+	BuildRef ImageBuildReference `json:"buildRef"`
+
+	// TemplateRef is the BuildTemplate the Build has been instantiated from.
+	// +optional
+	TemplateRef *BuildTemplateReference `json:"templateRef,omitempty"`
+
+	// InfrastructureRef is the infrastructure object of the Build, e.g. an AWSBuild.
+	// +optional
+	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// IdentityRef is the ProviderIdentity of the Build, its credentials deregister the machine image once the
+	// Image is deleted by an ImageRetentionPolicy.
+	// +optional
+	IdentityRef *corev1.LocalObjectReference `json:"identityRef,omitempty"`
+
+	// Architecture is the architecture of the image, set for the images of a multi-architecture Build.
+	// +optional
+	Architecture Architecture `json:"architecture,omitempty"`
+
+	// ImageRef is the reference of the machine image in the infrastructure, e.g. an AMI ID.
+	// +kubebuilder:validation:MinLength=1
+	ImageRef string `json:"imageRef"`
+
+	// Artifact describes the machine image, as reported by the infrastructure provider.
+	// +optional
+	Artifact *BuildArtifact `json:"artifact,omitempty"`
+
+	// Replications are the copies of the machine image to other regions and accounts.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +listMapKey=name
+	Replications []ReplicationStatus `json:"replications,omitempty"`
+
+	// Exports are where the image has been published by the exports of the Build.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Exports []ImageExport `json:"exports,omitempty"`
+
+	// Provenance describes how the image has been produced.
+	// +optional
+	Provenance ImageProvenance `json:"provenance,omitempty"`
+
+	// Metadata is the metadata set on the machine image by the infrastructure provider, e.g. its AMI tags.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ExpirationTime is the time the image expires at, as per spec.artifact.expireAfter of the Build.
+	// The image must not be used once it has expired.
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+}
+
+// ImageBuildReference is a reference to the Build which produced an Image.
+type ImageBuildReference struct {
+	// Name of the Build.
+	Name string `json:"name"`
+
+	// UID of the Build.
+	UID types.UID `json:"uid"`
+}
+
+// ImageExport is where the image has been published by an export of its Build.
+type ImageExport struct {
+	// Name is the name of the export.
+	Name string `json:"name"`
+
+	// URL is where the export is published.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Checksum is the checksum of the published file, prefixed with the algorithm, e.g. sha256:2c26b4...
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// Signature is where the cosign signature of the export is published.
+	// +optional
+	Signature string `json:"signature,omitempty"`
+
+	// Attestation is where the signed SLSA provenance attestation of the export is published.
+	// +optional
+	Attestation string `json:"attestation,omitempty"`
+}
+
+// ImageProvenance describes how an image has been produced.
+type ImageProvenance struct {
+	// GitSHA is the commit the Build has been built from, as set in its forge.build/git-sha annotation.
+	// +optional
+	GitSHA string `json:"gitSHA,omitempty"`
+
+	// SpecDigest is the digest of the spec of the Build, as recorded in the SLSA provenance of its exports.
+	// +optional
+	SpecDigest string `json:"specDigest,omitempty"`
+
+	// SBOM is the software bill of materials of the machine of the Build.
+	// +optional
+	SBOM *SBOMStatus `json:"sbom,omitempty"`
+
+	// Scan is the outcome of the vulnerability scan of the machine of the Build.
+	// +optional
+	Scan *ScanStatus `json:"scan,omitempty"`
+
+	// Layers are the content hashes of the provisioners of the Build, the Builds starting from the image skip the
+	// provisioners unchanged since.
+	// +optional
+	Layers []ProvisionerLayer `json:"layers,omitempty"`
+
+	// StartTime is the time the successful attempt of the Build started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the Build completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=images,scope=Namespaced,categories=forge,singular=image
+//+kubebuilder:printcolumn:name="ImageRef",type="string",JSONPath=".spec.imageRef",description="Reference of the machine image"
+//+kubebuilder:printcolumn:name="Build",type="string",JSONPath=".spec.buildRef.name",description="Build which produced the image"
+//+kubebuilder:printcolumn:name="Infrastructure",type="string",JSONPath=".spec.infrastructureRef.kind",description="Infrastructure of the Build",priority=1
+//+kubebuilder:printcolumn:name="Architecture",type="string",JSONPath=".spec.architecture",description="Architecture of the image",priority=1
+//+kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".spec.expirationTime",description="Time the image expires at"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Image"
+
+// Image is the Schema for the images API. An Image is created for every machine image produced by a Build,
+// it is named after the Build and labeled with its lineage, so the images can be selected by label.
+type Image struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ImageSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ImageList contains a list of Image
+type ImageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Image `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &Image{}, &ImageList{})
+}
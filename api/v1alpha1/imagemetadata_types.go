@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "strings"
+
+const (
+	// ImageMetadataBuildNameKey is the key of the image metadata holding the name of the Build of the image.
+	ImageMetadataBuildNameKey = "forge-build-name"
+
+	// ImageMetadataBuildNamespaceKey is the key of the image metadata holding the namespace of the Build of the image.
+	ImageMetadataBuildNamespaceKey = "forge-build-namespace"
+
+	// ImageMetadataBuildUIDKey is the key of the image metadata holding the UID of the Build of the image.
+	ImageMetadataBuildUIDKey = "forge-build-uid"
+)
+
+// ImageMetadataSpec defines the labels and annotations of a Build propagated to the metadata of its image,
+// e.g. AMI tags, GCE image labels or gallery image tags, so the images can be traced back to their Build.
+type ImageMetadataSpec struct {
+	// Labels are the labels of the Build set in the metadata of the image.
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	Labels []ImageMetadataMapping `json:"labels,omitempty"`
+
+	// Annotations are the annotations of the Build set in the metadata of the image.
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	Annotations []ImageMetadataMapping `json:"annotations,omitempty"`
+}
+
+// ImageMetadataMapping maps a label or an annotation of a Build to a key of the metadata of its image.
+type ImageMetadataMapping struct {
+	// Key is the key of the label or the annotation, e.g. example.com/team.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Name is the key of the image metadata the value is set as, it defaults to the name of the key
+	// without its prefix, e.g. team. It is restricted to the keys accepted by all the clouds.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9_-]{0,62}$`
+	Name string `json:"name,omitempty"`
+}
+
+// GetName returns the key of the image metadata the value is set as.
+func (m *ImageMetadataMapping) GetName() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return imageMetadataName(m.Key)
+}
+
+// imageMetadataName returns the name of a label or annotation key without its prefix, lower cased and with
+// the characters not accepted by all the clouds replaced by dashes.
+func imageMetadataName(key string) string {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		key = key[i+1:]
+	}
+	name := []rune(strings.ToLower(key))
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			name[i] = '-'
+		}
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return string(name)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultRetentionInterval is how often the Images are evaluated against an ImageRetentionPolicy by default.
+const DefaultRetentionInterval = time.Hour
+
+// ImageRetentionPolicySpec defines which Images of the namespace are deleted, along with their machine images.
+// A selected Image is deleted once it has expired, unless it is protected or among the keepLast most recent ones.
+// It expires:
+// - after expireAfter since its Build completed, when set;
+// - once past its spec.expirationTime;
+// - once it is not among the keepLast most recent Images, when keepLast is set and expireAfter is not.
+type ImageRetentionPolicySpec struct {
+	// Selector selects the Images the policy applies to, all the Images of the namespace when empty.
+	// +optional
+	Selector metav1.LabelSelector `json:"selector,omitempty"`
+
+	// KeepLast is the number of the most recent selected Images which are never deleted, whatever their age.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	// ExpireAfter is how long the selected Images are kept once their Build completed.
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+
+	// Protect selects the Images which are never deleted, e.g. the images still referenced by a deployment.
+	// +optional
+	Protect *metav1.LabelSelector `json:"protect,omitempty"`
+
+	// Interval is how often the Images are evaluated, defaults to 1h. They are also evaluated when an Image is
+	// created.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// DryRun reports the Images which would be deleted in status.expired without deleting them.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ImageRetentionPolicyStatus defines the observed state of ImageRetentionPolicy.
+type ImageRetentionPolicyStatus struct {
+	// Selected is the number of Images selected by the policy at its last evaluation.
+	// +optional
+	Selected int32 `json:"selected,omitempty"`
+
+	// Expired are the names of the expired Images which have not been deleted, in dry-run mode or when the
+	// deletion of their machine image failed.
+	// +optional
+	Expired []string `json:"expired,omitempty"`
+
+	// Deleted is the number of Images deleted by the policy, along with their machine images.
+	// +optional
+	Deleted int64 `json:"deleted,omitempty"`
+
+	// ReclaimedBytes is the storage reclaimed by the deletion of the machine images and their backing snapshots,
+	// as reported by the infrastructure providers.
+	// +optional
+	ReclaimedBytes int64 `json:"reclaimedBytes,omitempty"`
+
+	// LastEvaluationTime is the last time the Images have been evaluated.
+	// +optional
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions define the current state of the ImageRetentionPolicy.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=imageretentionpolicies,scope=Namespaced,categories=forge,singular=imageretentionpolicy
+//+kubebuilder:printcolumn:name="Keep Last",type="integer",JSONPath=".spec.keepLast",description="Number of the most recent Images kept"
+//+kubebuilder:printcolumn:name="Expire After",type="string",JSONPath=".spec.expireAfter",description="How long the Images are kept"
+//+kubebuilder:printcolumn:name="Selected",type="integer",JSONPath=".status.selected",description="Number of Images selected by the policy"
+//+kubebuilder:printcolumn:name="Deleted",type="integer",JSONPath=".status.deleted",description="Number of Images deleted by the policy"
+//+kubebuilder:printcolumn:name="Reclaimed",type="integer",JSONPath=".status.reclaimedBytes",description="Bytes of storage reclaimed",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ImageRetentionPolicy"
+
+// ImageRetentionPolicy is the Schema for the imageretentionpolicies API. The Images it deletes are deregistered
+// from their infrastructure by the provider of their infrastructure kind, with the credentials of the
+// ProviderIdentity of their Build.
+type ImageRetentionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ImageRetentionPolicySpec   `json:"spec,omitempty"`
+	Status ImageRetentionPolicyStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (p *ImageRetentionPolicy) GetConditions() []metav1.Condition {
+	return p.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (p *ImageRetentionPolicy) SetConditions(conditions []metav1.Condition) {
+	p.Status.Conditions = conditions
+}
+
+// GetInterval returns how often the Images are evaluated.
+func (p *ImageRetentionPolicy) GetInterval() time.Duration {
+	if p.Spec.Interval == nil || p.Spec.Interval.Duration <= 0 {
+		return DefaultRetentionInterval
+	}
+	return p.Spec.Interval.Duration
+}
+
+//+kubebuilder:object:root=true
+
+// ImageRetentionPolicyList contains a list of ImageRetentionPolicy
+type ImageRetentionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ImageRetentionPolicy `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ImageRetentionPolicy{}, &ImageRetentionPolicyList{})
+}
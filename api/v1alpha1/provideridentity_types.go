@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultIdentityValidationInterval is how often the credentials of a ProviderIdentity are validated
+// when the ProviderIdentity does not set a validation interval.
+const DefaultIdentityValidationInterval = 10 * time.Minute
+
+// ProviderIdentitySpec defines the credentials used by an infrastructure provider to create the build machines,
+// exactly one of secretRef and credentialsProviderRef must be set.
+// +kubebuilder:validation:XValidation:rule="(has(self.secretRef) && has(self.secretRef.name) && size(self.secretRef.name) > 0) != has(self.credentialsProviderRef)",message="exactly one of secretRef and credentialsProviderRef must be set"
+type ProviderIdentitySpec struct {
+	// Provider is the name of the infrastructure provider the credentials are for, e.g. aws.
+	// The identity is validated by the controller of this provider.
+	// +kubebuilder:validation:MinLength=1
+	Provider string `json:"provider"`
+
+	// SecretRef is a reference to the secret containing the credentials, in the namespace of the ProviderIdentity.
+	// The keys of the secret are specific to the provider.
+	// +optional
+	SecretRef corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// CredentialsProviderRef resolves the credentials from an external secret manager, with the same keys as
+	// the secret of secretRef. They are fetched whenever the provider needs them and never stored in a Secret.
+	// +optional
+	CredentialsProviderRef *CredentialsProviderReference `json:"credentialsProviderRef,omitempty"`
+
+	// ValidationInterval is how often the credentials are validated, defaults to 10m.
+	// +optional
+	ValidationInterval *metav1.Duration `json:"validationInterval,omitempty"`
+}
+
+// ProviderIdentityStatus defines the observed state of ProviderIdentity.
+type ProviderIdentityStatus struct {
+	// LastValidationTime is the last time the credentials have been validated.
+	// +optional
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the ProviderIdentity.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=provideridentities,scope=Namespaced,categories=forge,singular=provideridentity
+//+kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider",description="Infrastructure provider of the credentials"
+//+kubebuilder:printcolumn:name="Valid",type="string",JSONPath=".status.conditions[?(@.type==\"CredentialsValid\")].status",description="Whether the credentials are valid"
+//+kubebuilder:printcolumn:name="Last Validation",type="date",JSONPath=".status.lastValidationTime",description="Time of the last validation of the credentials"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ProviderIdentity"
+
+// ProviderIdentity is the Schema for the provideridentities API
+type ProviderIdentity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProviderIdentitySpec   `json:"spec,omitempty"`
+	Status ProviderIdentityStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (p *ProviderIdentity) GetConditions() []metav1.Condition {
+	return p.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (p *ProviderIdentity) SetConditions(conditions []metav1.Condition) {
+	p.Status.Conditions = conditions
+}
+
+// GetValidationInterval returns how often the credentials are validated.
+func (p *ProviderIdentity) GetValidationInterval() time.Duration {
+	if p.Spec.ValidationInterval == nil || p.Spec.ValidationInterval.Duration <= 0 {
+		return DefaultIdentityValidationInterval
+	}
+	return p.Spec.ValidationInterval.Duration
+}
+
+//+kubebuilder:object:root=true
+
+// ProviderIdentityList contains a list of ProviderIdentity
+type ProviderIdentityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderIdentity `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ProviderIdentity{}, &ProviderIdentityList{})
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/utils/ptr"
+)
+
+// ProvisionerExecutionOrder returns the indexes of the provisioners in the order they must run.
+// Provisioners are sorted by Order, then by their position in the list, and moved after the
+// provisioners they depend on. An error is returned if names are duplicated, if DependsOn
+// references an unknown provisioner or one with a higher Order, or if the dependencies have a cycle.
+func ProvisionerExecutionOrder(provisioners []ProvisionerSpec) ([]int, error) {
+	byName := map[string]int{}
+	for i, p := range provisioners {
+		if p.Name == "" {
+			continue
+		}
+		if _, ok := byName[p.Name]; ok {
+			return nil, fmt.Errorf("provisioner name %q is not unique", p.Name)
+		}
+		byName[p.Name] = i
+	}
+
+	// Sort by Order, keeping the list order for provisioners with the same Order.
+	candidates := make([]int, len(provisioners))
+	for i := range provisioners {
+		candidates[i] = i
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return ptr.Deref(provisioners[candidates[a]].Order, 0) < ptr.Deref(provisioners[candidates[b]].Order, 0)
+	})
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(provisioners))
+	order := make([]int, 0, len(provisioners))
+	path := []string{}
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("provisioner dependencies have a cycle: %s", strings.Join(append(path, provisioners[i].Name), " -> "))
+		}
+		state[i] = visiting
+		path = append(path, provisioners[i].Name)
+		for _, dep := range provisioners[i].DependsOn {
+			j, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("provisioner %q depends on unknown provisioner %q", provisioners[i].Name, dep)
+			}
+			if ptr.Deref(provisioners[j].Order, 0) > ptr.Deref(provisioners[i].Order, 0) {
+				return fmt.Errorf("provisioner %q depends on provisioner %q which has a higher order", provisioners[i].Name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+
+	for _, i := range candidates {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+func TestProvisionerExecutionOrder(t *testing.T) {
+	testcases := []struct {
+		name         string
+		provisioners []ProvisionerSpec
+		expected     []int
+		wantErr      string
+	}{
+		{
+			name:     "no provisioners",
+			expected: []int{},
+		},
+		{
+			name: "list order",
+			provisioners: []ProvisionerSpec{
+				{Name: "install"}, {Name: "harden"}, {Name: "test"},
+			},
+			expected: []int{0, 1, 2},
+		},
+		{
+			name: "order field",
+			provisioners: []ProvisionerSpec{
+				{Name: "test", Order: ptr.To[int32](3)},
+				{Name: "install", Order: ptr.To[int32](1)},
+				{Name: "harden", Order: ptr.To[int32](2)},
+			},
+			expected: []int{1, 2, 0},
+		},
+		{
+			name: "dependencies",
+			provisioners: []ProvisionerSpec{
+				{Name: "test", DependsOn: []string{"harden"}},
+				{Name: "harden", DependsOn: []string{"install"}},
+				{Name: "install"},
+			},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name: "cycle",
+			provisioners: []ProvisionerSpec{
+				{Name: "install", DependsOn: []string{"test"}},
+				{Name: "harden", DependsOn: []string{"install"}},
+				{Name: "test", DependsOn: []string{"harden"}},
+			},
+			wantErr: "cycle: install -> test -> harden -> install",
+		},
+		{
+			name: "unknown dependency",
+			provisioners: []ProvisionerSpec{
+				{Name: "install", DependsOn: []string{"prepare"}},
+			},
+			wantErr: "unknown provisioner",
+		},
+		{
+			name: "duplicated name",
+			provisioners: []ProvisionerSpec{
+				{Name: "install"}, {Name: "install"},
+			},
+			wantErr: "not unique",
+		},
+		{
+			name: "dependency with a higher order",
+			provisioners: []ProvisionerSpec{
+				{Name: "install", Order: ptr.To[int32](2)},
+				{Name: "harden", Order: ptr.To[int32](1), DependsOn: []string{"install"}},
+			},
+			wantErr: "higher order",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			order, err := ProvisionerExecutionOrder(tc.provisioners)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(order).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProvisionerParametersEnv is the environment variable holding the parameters of the provisioner, as JSON,
+// in the Jobs of the Job ProvisionerClasses.
+const ProvisionerParametersEnv = "FORGE_PROVISIONER_PARAMETERS"
+
+// ProvisionerClassSpec defines a provisioner implemented outside of the core controller. The external provisioners
+// of the Builds reference the class by name, the class either runs them in a Job or calls a webhook.
+type ProvisionerClassSpec struct {
+	// Description describes what the provisioners of the class do.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Job runs the provisioners of the class in a Job. The Job is placed and configured as the Jobs of the
+	// built-in/shell provisioners, its container receives the connection to the machine through the same
+	// arguments and the parameters of the provisioner as JSON in the FORGE_PROVISIONER_PARAMETERS environment variable.
+	// +optional
+	Job *ProvisionerClassJob `json:"job,omitempty"`
+
+	// Webhook runs the provisioners of the class by calling a webhook, until it reports the provisioner
+	// completed or failed.
+	// +optional
+	Webhook *ProvisionerClassWebhook `json:"webhook,omitempty"`
+
+	// Schema is the OpenAPI v3 schema of the parameters of the provisioners of the class. The type, properties,
+	// required, additionalProperties, enum and items of the schema are checked before the provisioner runs.
+	// The parameters are not checked when not set.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	Schema *apiextensionsv1.JSON `json:"schema,omitempty"`
+}
+
+// ProvisionerClassJob is the image of the Jobs running the provisioners of a class.
+type ProvisionerClassJob struct {
+	// Image is the image of the container running the provisioner.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// ImagePullPolicy is the pull policy of the image, defaults to IfNotPresent.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// ProvisionerClassWebhook is the webhook running the provisioners of a class.
+type ProvisionerClassWebhook struct {
+	// URL is the URL the ProvisionRequests are posted to, it must use https.
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
+
+	// CABundle is the PEM encoded CA bundle used to verify the certificate of the webhook,
+	// the system roots are used when not set.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// TimeoutSeconds is how long a call to the webhook may take, defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ProvisionerClassStatus defines the observed state of ProvisionerClass.
+type ProvisionerClassStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=provisionerclasses,scope=Cluster,categories=forge,singular=provisionerclass
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.job.image",description="Image of the Jobs running the provisioners"
+//+kubebuilder:printcolumn:name="Webhook",type="string",JSONPath=".spec.webhook.url",description="Webhook running the provisioners"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ProvisionerClass"
+
+// ProvisionerClass is the Schema for the provisionerclasses API, it lets third parties add provisioners to Forge
+// without changing the core controller.
+type ProvisionerClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProvisionerClassSpec   `json:"spec,omitempty"`
+	Status ProvisionerClassStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProvisionerClassList contains a list of ProvisionerClass
+type ProvisionerClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProvisionerClass `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ProvisionerClass{}, &ProvisionerClassList{})
+}
+
+// ProvisionRequest is the request posted to the webhook of a ProvisionerClass to run a provisioner. The webhook is
+// called until it responds with the Completed or Failed phase, it must be idempotent.
+type ProvisionRequest struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Build is the Build the provisioner belongs to.
+	Build corev1.ObjectReference `json:"build"`
+
+	// Provisioner is the name of the provisioner in the Build.
+	Provisioner string `json:"provisioner"`
+
+	// UUID is the unique ID of this run of the provisioner.
+	UUID string `json:"uuid"`
+
+	// Parameters are the parameters of the provisioner.
+	// +optional
+	Parameters *apiextensionsv1.JSON `json:"parameters,omitempty"`
+
+	// Connector describes how to connect to the infrastructure machine, the credentials are in the secret
+	// of the connector in the namespace of the Build.
+	Connector ConnectorSpec `json:"connector"`
+}
+
+// ProvisionResponse is the response of the webhook of a ProvisionerClass.
+type ProvisionResponse struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Phase is the phase of the provisioner, Running, Completed or Failed.
+	Phase ProvisionerStatus `json:"phase"`
+
+	// Message describes the progress or the outcome of the provisioner.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RequeueAfterSeconds is when the webhook is called again while the provisioner is running, defaults to 10.
+	// +optional
+	RequeueAfterSeconds *int32 `json:"requeueAfterSeconds,omitempty"`
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConcurrencyPolicy describes how the Builds of a ScheduledBuild are handled when they overlap.
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent allows Builds to run concurrently.
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+
+	// ForbidConcurrent skips the next Build if the previous one hasn't finished yet.
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+
+	// ReplaceConcurrent deletes the running Build and replaces it with a new one.
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+// ScheduledBuildSpec defines the desired state of ScheduledBuild
+type ScheduledBuildSpec struct {
+	// Schedule is the schedule of the Builds in Cron format, see https://en.wikipedia.org/wiki/Cron.
+	// e.g. schedule: "0 3 1 * *" to build monthly.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// StartingDeadlineSeconds is the deadline in seconds for starting a Build which missed its scheduled time,
+	// missed Builds are skipped after the deadline.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// ConcurrencyPolicy specifies how to treat concurrent Builds, one of Allow, Forbid or Replace.
+	// +optional
+	// +kubebuilder:default=Forbid
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Suspend tells the controller to suspend the subsequent Builds, it does not apply to the running Builds.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// SuccessfulBuildsHistoryLimit is the number of completed Builds to keep.
+	// +optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	SuccessfulBuildsHistoryLimit *int32 `json:"successfulBuildsHistoryLimit,omitempty"`
+
+	// FailedBuildsHistoryLimit is the number of failed Builds to keep.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	FailedBuildsHistoryLimit *int32 `json:"failedBuildsHistoryLimit,omitempty"`
+
+	// ImageName is the base name of the images, the image of every Build is named after it,
+	// suffixed with the scheduled time of the Build, e.g. ubuntu-golden-20240601-0300.
+	// +optional
+	ImageName string `json:"imageName,omitempty"`
+
+	// BuildTemplate describes the Builds created by the ScheduledBuild.
+	// The Builds must be instantiated from a BuildTemplate, so every Build gets its own infrastructure.
+	// +kubebuilder:validation:XValidation:rule="has(self.spec.templateRef) && !has(self.spec.infrastructureRef)",message="scheduled Builds must set spec.templateRef and must not set spec.infrastructureRef"
+	BuildTemplate ScheduledBuildTemplate `json:"buildTemplate"`
+}
+
+// ScheduledBuildTemplate describes the Builds created by a ScheduledBuild.
+type ScheduledBuildTemplate struct {
+	// Metadata are the labels and annotations set on the Builds.
+	// +optional
+	Metadata ScheduledBuildTemplateMetadata `json:"metadata,omitempty"`
+
+	// Spec is the specification of the Builds.
+	Spec BuildSpec `json:"spec"`
+}
+
+// ScheduledBuildTemplateMetadata is the metadata set on the Builds created by a ScheduledBuild.
+type ScheduledBuildTemplateMetadata struct {
+	// Labels set on the Builds.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations set on the Builds.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ScheduledBuildStatus defines the observed state of ScheduledBuild
+type ScheduledBuildStatus struct {
+	// Active is the list of the running Builds.
+	// +optional
+	Active []corev1.ObjectReference `json:"active,omitempty"`
+
+	// LastScheduleTime is the last time a Build has been scheduled.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastSuccessfulTime is the scheduled time of the last Build which completed.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// LastImageRef is the reference of the image produced by the last Build which completed.
+	// +optional
+	LastImageRef string `json:"lastImageRef,omitempty"`
+
+	// LastFailedTime is the scheduled time of the last Build which failed and is not going to be retried.
+	// +optional
+	LastFailedTime *metav1.Time `json:"lastFailedTime,omitempty"`
+
+	// Health summarizes the state of the ScheduledBuild for the GitOps tools.
+	// +optional
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// Conditions define the current state of the ScheduledBuild.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=scheduledbuilds,scope=Namespaced,categories=forge,singular=scheduledbuild
+//+kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Schedule of the Builds"
+//+kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend",description="Whether the Builds are suspended"
+//+kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime",description="Time since the last Build has been scheduled"
+//+kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.lastImageRef",description="Reference of the last built machine image"
+//+kubebuilder:printcolumn:name="Health",type="string",JSONPath=".status.health.status",description="Health of the ScheduledBuild",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ScheduledBuild"
+
+// ScheduledBuild is the Schema for the scheduledbuilds API
+type ScheduledBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledBuildSpec   `json:"spec,omitempty"`
+	Status ScheduledBuildStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (s *ScheduledBuild) GetConditions() []metav1.Condition {
+	return s.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (s *ScheduledBuild) SetConditions(conditions []metav1.Condition) {
+	s.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ScheduledBuildList contains a list of ScheduledBuild
+type ScheduledBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledBuild `json:"items"`
+}
+
+func init() {
+	objectTypes = append(objectTypes, &ScheduledBuild{}, &ScheduledBuildList{})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager sets up the ScheduledBuild webhooks with the manager.
+func (r *ScheduledBuild) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-forge-build-v1alpha1-scheduledbuild,mutating=true,failurePolicy=fail,sideEffects=None,groups=forge.build,resources=scheduledbuilds,verbs=create;update,versions=v1alpha1,name=mscheduledbuild.forge.build,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ScheduledBuild{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *ScheduledBuild) Default() {
+	defaultConnector(&r.Spec.BuildTemplate.Spec.Connector)
+}
+
+//+kubebuilder:webhook:path=/validate-forge-build-v1alpha1-scheduledbuild,mutating=false,failurePolicy=fail,sideEffects=None,groups=forge.build,resources=scheduledbuilds,verbs=create;update,versions=v1alpha1,name=vscheduledbuild.forge.build,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ScheduledBuild{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *ScheduledBuild) ValidateCreate() (admission.Warnings, error) {
+	return nil, r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *ScheduledBuild) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	oldScheduledBuild, ok := old.(*ScheduledBuild)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ScheduledBuild but got a %T", old))
+	}
+	return nil, r.validate(oldScheduledBuild)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *ScheduledBuild) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *ScheduledBuild) validate(old *ScheduledBuild) error {
+	var allErrs field.ErrorList
+
+	if _, err := cron.ParseStandard(r.Spec.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "schedule"), r.Spec.Schedule, err.Error()))
+	}
+
+	var oldSpec *BuildSpec
+	if old != nil {
+		oldSpec = &old.Spec.BuildTemplate.Spec
+	}
+	allErrs = append(allErrs, validateBuildSpec(field.NewPath("spec", "buildTemplate", "spec"), &r.Spec.BuildTemplate.Spec, oldSpec)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ScheduledBuild").GroupKind(), r.Name, allErrs)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateScheduledBuild(t *testing.T) {
+	testcases := []struct {
+		name     string
+		schedule string
+		spec     BuildSpec
+		wantErr  []string
+	}{
+		{
+			name:     "valid ScheduledBuild",
+			schedule: "0 3 1 * *",
+			spec: BuildSpec{
+				Connector:         ConnectorSpec{Type: ConnectorTypeSSH},
+				InfrastructureRef: &corev1.ObjectReference{APIVersion: "infrastructure.forge.build/v1alpha1", Kind: "DockerBuild", Name: "ubuntu"},
+			},
+		},
+		{
+			name:     "invalid schedule and Build spec",
+			schedule: "every monday",
+			spec:     BuildSpec{Connector: ConnectorSpec{Type: ConnectorTypeSSH}},
+			wantErr:  []string{"spec.schedule", "spec.buildTemplate.spec.infrastructureRef"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheduledBuild := &ScheduledBuild{Spec: ScheduledBuildSpec{
+				Schedule:      tc.schedule,
+				BuildTemplate: ScheduledBuildTemplate{Spec: tc.spec},
+			}}
+			_, err := scheduledBuild.ValidateCreate()
+			if len(tc.wantErr) == 0 {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			for _, want := range tc.wantErr {
+				g.Expect(err).To(MatchError(ContainSubstring(want)))
+			}
+		})
+	}
+}
@@ -0,0 +1,3394 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	unsafe "unsafe"
+
+	v1beta1 "github.com/forge-build/forge/api/v1beta1"
+	errors "github.com/forge-build/forge/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*AnsibleGitSource)(nil), (*v1beta1.AnsibleGitSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AnsibleGitSource_To_v1beta1_AnsibleGitSource(a.(*AnsibleGitSource), b.(*v1beta1.AnsibleGitSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AnsibleGitSource)(nil), (*AnsibleGitSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AnsibleGitSource_To_v1alpha1_AnsibleGitSource(a.(*v1beta1.AnsibleGitSource), b.(*AnsibleGitSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AnsiblePlayStatus)(nil), (*v1beta1.AnsiblePlayStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AnsiblePlayStatus_To_v1beta1_AnsiblePlayStatus(a.(*AnsiblePlayStatus), b.(*v1beta1.AnsiblePlayStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AnsiblePlayStatus)(nil), (*AnsiblePlayStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AnsiblePlayStatus_To_v1alpha1_AnsiblePlayStatus(a.(*v1beta1.AnsiblePlayStatus), b.(*AnsiblePlayStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AnsibleSpec)(nil), (*v1beta1.AnsibleSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AnsibleSpec_To_v1beta1_AnsibleSpec(a.(*AnsibleSpec), b.(*v1beta1.AnsibleSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AnsibleSpec)(nil), (*AnsibleSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AnsibleSpec_To_v1alpha1_AnsibleSpec(a.(*v1beta1.AnsibleSpec), b.(*AnsibleSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ArchitectureStatus)(nil), (*v1beta1.ArchitectureStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ArchitectureStatus_To_v1beta1_ArchitectureStatus(a.(*ArchitectureStatus), b.(*v1beta1.ArchitectureStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ArchitectureStatus)(nil), (*ArchitectureStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ArchitectureStatus_To_v1alpha1_ArchitectureStatus(a.(*v1beta1.ArchitectureStatus), b.(*ArchitectureStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ArtifactSpec)(nil), (*v1beta1.ArtifactSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ArtifactSpec_To_v1beta1_ArtifactSpec(a.(*ArtifactSpec), b.(*v1beta1.ArtifactSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ArtifactSpec)(nil), (*ArtifactSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ArtifactSpec_To_v1alpha1_ArtifactSpec(a.(*v1beta1.ArtifactSpec), b.(*ArtifactSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AzureBlobDestination)(nil), (*v1beta1.AzureBlobDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AzureBlobDestination_To_v1beta1_AzureBlobDestination(a.(*AzureBlobDestination), b.(*v1beta1.AzureBlobDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AzureBlobDestination)(nil), (*AzureBlobDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AzureBlobDestination_To_v1alpha1_AzureBlobDestination(a.(*v1beta1.AzureBlobDestination), b.(*AzureBlobDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BastionSpec)(nil), (*v1beta1.BastionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec(a.(*BastionSpec), b.(*v1beta1.BastionSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BastionSpec)(nil), (*BastionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec(a.(*v1beta1.BastionSpec), b.(*BastionSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BoxRegistryDestination)(nil), (*v1beta1.BoxRegistryDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BoxRegistryDestination_To_v1beta1_BoxRegistryDestination(a.(*BoxRegistryDestination), b.(*v1beta1.BoxRegistryDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BoxRegistryDestination)(nil), (*BoxRegistryDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BoxRegistryDestination_To_v1alpha1_BoxRegistryDestination(a.(*v1beta1.BoxRegistryDestination), b.(*BoxRegistryDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Build)(nil), (*v1beta1.Build)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Build_To_v1beta1_Build(a.(*Build), b.(*v1beta1.Build), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.Build)(nil), (*Build)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Build_To_v1alpha1_Build(a.(*v1beta1.Build), b.(*Build), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildArtifact)(nil), (*v1beta1.BuildArtifact)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildArtifact_To_v1beta1_BuildArtifact(a.(*BuildArtifact), b.(*v1beta1.BuildArtifact), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildArtifact)(nil), (*BuildArtifact)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildArtifact_To_v1alpha1_BuildArtifact(a.(*v1beta1.BuildArtifact), b.(*BuildArtifact), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildAttempt)(nil), (*v1beta1.BuildAttempt)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildAttempt_To_v1beta1_BuildAttempt(a.(*BuildAttempt), b.(*v1beta1.BuildAttempt), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildAttempt)(nil), (*BuildAttempt)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildAttempt_To_v1alpha1_BuildAttempt(a.(*v1beta1.BuildAttempt), b.(*BuildAttempt), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildList)(nil), (*v1beta1.BuildList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildList_To_v1beta1_BuildList(a.(*BuildList), b.(*v1beta1.BuildList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildList)(nil), (*BuildList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildList_To_v1alpha1_BuildList(a.(*v1beta1.BuildList), b.(*BuildList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildProgress)(nil), (*v1beta1.BuildProgress)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildProgress_To_v1beta1_BuildProgress(a.(*BuildProgress), b.(*v1beta1.BuildProgress), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildProgress)(nil), (*BuildProgress)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildProgress_To_v1alpha1_BuildProgress(a.(*v1beta1.BuildProgress), b.(*BuildProgress), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildProvisionerStatus)(nil), (*v1beta1.BuildProvisionerStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildProvisionerStatus_To_v1beta1_BuildProvisionerStatus(a.(*BuildProvisionerStatus), b.(*v1beta1.BuildProvisionerStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildProvisionerStatus)(nil), (*BuildProvisionerStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildProvisionerStatus_To_v1alpha1_BuildProvisionerStatus(a.(*v1beta1.BuildProvisionerStatus), b.(*BuildProvisionerStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildSource)(nil), (*v1beta1.BuildSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildSource_To_v1beta1_BuildSource(a.(*BuildSource), b.(*v1beta1.BuildSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildSource)(nil), (*BuildSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildSource_To_v1alpha1_BuildSource(a.(*v1beta1.BuildSource), b.(*BuildSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildSpec)(nil), (*v1beta1.BuildSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec(a.(*BuildSpec), b.(*v1beta1.BuildSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildSpec)(nil), (*BuildSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec(a.(*v1beta1.BuildSpec), b.(*BuildSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildStatus)(nil), (*v1beta1.BuildStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus(a.(*BuildStatus), b.(*v1beta1.BuildStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildStatus)(nil), (*BuildStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus(a.(*v1beta1.BuildStatus), b.(*BuildStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildTemplateReference)(nil), (*v1beta1.BuildTemplateReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildTemplateReference_To_v1beta1_BuildTemplateReference(a.(*BuildTemplateReference), b.(*v1beta1.BuildTemplateReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildTemplateReference)(nil), (*BuildTemplateReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildTemplateReference_To_v1alpha1_BuildTemplateReference(a.(*v1beta1.BuildTemplateReference), b.(*BuildTemplateReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildTimeouts)(nil), (*v1beta1.BuildTimeouts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildTimeouts_To_v1beta1_BuildTimeouts(a.(*BuildTimeouts), b.(*v1beta1.BuildTimeouts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildTimeouts)(nil), (*BuildTimeouts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildTimeouts_To_v1alpha1_BuildTimeouts(a.(*v1beta1.BuildTimeouts), b.(*BuildTimeouts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildVariable)(nil), (*v1beta1.BuildVariable)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildVariable_To_v1beta1_BuildVariable(a.(*BuildVariable), b.(*v1beta1.BuildVariable), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildVariable)(nil), (*BuildVariable)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildVariable_To_v1alpha1_BuildVariable(a.(*v1beta1.BuildVariable), b.(*BuildVariable), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*BuildVariableSource)(nil), (*v1beta1.BuildVariableSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BuildVariableSource_To_v1beta1_BuildVariableSource(a.(*BuildVariableSource), b.(*v1beta1.BuildVariableSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.BuildVariableSource)(nil), (*BuildVariableSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BuildVariableSource_To_v1alpha1_BuildVariableSource(a.(*v1beta1.BuildVariableSource), b.(*BuildVariableSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CacheStatus)(nil), (*v1beta1.CacheStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CacheStatus_To_v1beta1_CacheStatus(a.(*CacheStatus), b.(*v1beta1.CacheStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.CacheStatus)(nil), (*CacheStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CacheStatus_To_v1alpha1_CacheStatus(a.(*v1beta1.CacheStatus), b.(*CacheStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ChefSoloSpec)(nil), (*v1beta1.ChefSoloSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ChefSoloSpec_To_v1beta1_ChefSoloSpec(a.(*ChefSoloSpec), b.(*v1beta1.ChefSoloSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ChefSoloSpec)(nil), (*ChefSoloSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ChefSoloSpec_To_v1alpha1_ChefSoloSpec(a.(*v1beta1.ChefSoloSpec), b.(*ChefSoloSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CommandAssertion)(nil), (*v1beta1.CommandAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CommandAssertion_To_v1beta1_CommandAssertion(a.(*CommandAssertion), b.(*v1beta1.CommandAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.CommandAssertion)(nil), (*CommandAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CommandAssertion_To_v1alpha1_CommandAssertion(a.(*v1beta1.CommandAssertion), b.(*CommandAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ComplianceRuleStatus)(nil), (*v1beta1.ComplianceRuleStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ComplianceRuleStatus_To_v1beta1_ComplianceRuleStatus(a.(*ComplianceRuleStatus), b.(*v1beta1.ComplianceRuleStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ComplianceRuleStatus)(nil), (*ComplianceRuleStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ComplianceRuleStatus_To_v1alpha1_ComplianceRuleStatus(a.(*v1beta1.ComplianceRuleStatus), b.(*ComplianceRuleStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ComplianceSummary)(nil), (*v1beta1.ComplianceSummary)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ComplianceSummary_To_v1beta1_ComplianceSummary(a.(*ComplianceSummary), b.(*v1beta1.ComplianceSummary), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ComplianceSummary)(nil), (*ComplianceSummary)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ComplianceSummary_To_v1alpha1_ComplianceSummary(a.(*v1beta1.ComplianceSummary), b.(*ComplianceSummary), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ConfigManagementSource)(nil), (*v1beta1.ConfigManagementSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(a.(*ConfigManagementSource), b.(*v1beta1.ConfigManagementSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ConfigManagementSource)(nil), (*ConfigManagementSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(a.(*v1beta1.ConfigManagementSource), b.(*ConfigManagementSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ConnectionStatus)(nil), (*v1beta1.ConnectionStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ConnectionStatus_To_v1beta1_ConnectionStatus(a.(*ConnectionStatus), b.(*v1beta1.ConnectionStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ConnectionStatus)(nil), (*ConnectionStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ConnectionStatus_To_v1alpha1_ConnectionStatus(a.(*v1beta1.ConnectionStatus), b.(*ConnectionStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CredentialsProviderReference)(nil), (*v1beta1.CredentialsProviderReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CredentialsProviderReference_To_v1beta1_CredentialsProviderReference(a.(*CredentialsProviderReference), b.(*v1beta1.CredentialsProviderReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.CredentialsProviderReference)(nil), (*CredentialsProviderReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CredentialsProviderReference_To_v1alpha1_CredentialsProviderReference(a.(*v1beta1.CredentialsProviderReference), b.(*CredentialsProviderReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExportDestination)(nil), (*v1beta1.ExportDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination(a.(*ExportDestination), b.(*v1beta1.ExportDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExportDestination)(nil), (*ExportDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination(a.(*v1beta1.ExportDestination), b.(*ExportDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExportSpec)(nil), (*v1beta1.ExportSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExportSpec_To_v1beta1_ExportSpec(a.(*ExportSpec), b.(*v1beta1.ExportSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExportSpec)(nil), (*ExportSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExportSpec_To_v1alpha1_ExportSpec(a.(*v1beta1.ExportSpec), b.(*ExportSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExportStatus)(nil), (*v1beta1.ExportStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExportStatus_To_v1beta1_ExportStatus(a.(*ExportStatus), b.(*v1beta1.ExportStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExportStatus)(nil), (*ExportStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExportStatus_To_v1alpha1_ExportStatus(a.(*v1beta1.ExportStatus), b.(*ExportStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExtractDestination)(nil), (*v1beta1.ExtractDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination(a.(*ExtractDestination), b.(*v1beta1.ExtractDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExtractDestination)(nil), (*ExtractDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination(a.(*v1beta1.ExtractDestination), b.(*ExtractDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExtractFile)(nil), (*v1beta1.ExtractFile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExtractFile_To_v1beta1_ExtractFile(a.(*ExtractFile), b.(*v1beta1.ExtractFile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExtractFile)(nil), (*ExtractFile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExtractFile_To_v1alpha1_ExtractFile(a.(*v1beta1.ExtractFile), b.(*ExtractFile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExtractProvisionerSpec)(nil), (*v1beta1.ExtractProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExtractProvisionerSpec_To_v1beta1_ExtractProvisionerSpec(a.(*ExtractProvisionerSpec), b.(*v1beta1.ExtractProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExtractProvisionerSpec)(nil), (*ExtractProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExtractProvisionerSpec_To_v1alpha1_ExtractProvisionerSpec(a.(*v1beta1.ExtractProvisionerSpec), b.(*ExtractProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ExtractedFileStatus)(nil), (*v1beta1.ExtractedFileStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ExtractedFileStatus_To_v1beta1_ExtractedFileStatus(a.(*ExtractedFileStatus), b.(*v1beta1.ExtractedFileStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ExtractedFileStatus)(nil), (*ExtractedFileStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ExtractedFileStatus_To_v1alpha1_ExtractedFileStatus(a.(*v1beta1.ExtractedFileStatus), b.(*ExtractedFileStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FailureDomainSpec)(nil), (*v1beta1.FailureDomainSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FailureDomainSpec_To_v1beta1_FailureDomainSpec(a.(*FailureDomainSpec), b.(*v1beta1.FailureDomainSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.FailureDomainSpec)(nil), (*FailureDomainSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_FailureDomainSpec_To_v1alpha1_FailureDomainSpec(a.(*v1beta1.FailureDomainSpec), b.(*FailureDomainSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileAssertion)(nil), (*v1beta1.FileAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileAssertion_To_v1beta1_FileAssertion(a.(*FileAssertion), b.(*v1beta1.FileAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.FileAssertion)(nil), (*FileAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_FileAssertion_To_v1alpha1_FileAssertion(a.(*v1beta1.FileAssertion), b.(*FileAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileProvisionerSpec)(nil), (*v1beta1.FileProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileProvisionerSpec_To_v1beta1_FileProvisionerSpec(a.(*FileProvisionerSpec), b.(*v1beta1.FileProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.FileProvisionerSpec)(nil), (*FileProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_FileProvisionerSpec_To_v1alpha1_FileProvisionerSpec(a.(*v1beta1.FileProvisionerSpec), b.(*FileProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileUpload)(nil), (*v1beta1.FileUpload)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileUpload_To_v1beta1_FileUpload(a.(*FileUpload), b.(*v1beta1.FileUpload), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.FileUpload)(nil), (*FileUpload)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_FileUpload_To_v1alpha1_FileUpload(a.(*v1beta1.FileUpload), b.(*FileUpload), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*HTTPDestination)(nil), (*v1beta1.HTTPDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_HTTPDestination_To_v1beta1_HTTPDestination(a.(*HTTPDestination), b.(*v1beta1.HTTPDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.HTTPDestination)(nil), (*HTTPDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_HTTPDestination_To_v1alpha1_HTTPDestination(a.(*v1beta1.HTTPDestination), b.(*HTTPDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*HardenSpec)(nil), (*v1beta1.HardenSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_HardenSpec_To_v1beta1_HardenSpec(a.(*HardenSpec), b.(*v1beta1.HardenSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.HardenSpec)(nil), (*HardenSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_HardenSpec_To_v1alpha1_HardenSpec(a.(*v1beta1.HardenSpec), b.(*HardenSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*HealthStatus)(nil), (*v1beta1.HealthStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_HealthStatus_To_v1beta1_HealthStatus(a.(*HealthStatus), b.(*v1beta1.HealthStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.HealthStatus)(nil), (*HealthStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_HealthStatus_To_v1alpha1_HealthStatus(a.(*v1beta1.HealthStatus), b.(*HealthStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ImageMetadataMapping)(nil), (*v1beta1.ImageMetadataMapping)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ImageMetadataMapping_To_v1beta1_ImageMetadataMapping(a.(*ImageMetadataMapping), b.(*v1beta1.ImageMetadataMapping), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ImageMetadataMapping)(nil), (*ImageMetadataMapping)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ImageMetadataMapping_To_v1alpha1_ImageMetadataMapping(a.(*v1beta1.ImageMetadataMapping), b.(*ImageMetadataMapping), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ImageMetadataSpec)(nil), (*v1beta1.ImageMetadataSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ImageMetadataSpec_To_v1beta1_ImageMetadataSpec(a.(*ImageMetadataSpec), b.(*v1beta1.ImageMetadataSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ImageMetadataSpec)(nil), (*ImageMetadataSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ImageMetadataSpec_To_v1alpha1_ImageMetadataSpec(a.(*v1beta1.ImageMetadataSpec), b.(*ImageMetadataSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*KeylessSigning)(nil), (*v1beta1.KeylessSigning)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_KeylessSigning_To_v1beta1_KeylessSigning(a.(*KeylessSigning), b.(*v1beta1.KeylessSigning), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.KeylessSigning)(nil), (*KeylessSigning)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_KeylessSigning_To_v1alpha1_KeylessSigning(a.(*v1beta1.KeylessSigning), b.(*KeylessSigning), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*KubeconfigSource)(nil), (*v1beta1.KubeconfigSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_KubeconfigSource_To_v1beta1_KubeconfigSource(a.(*KubeconfigSource), b.(*v1beta1.KubeconfigSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.KubeconfigSource)(nil), (*KubeconfigSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_KubeconfigSource_To_v1alpha1_KubeconfigSource(a.(*v1beta1.KubeconfigSource), b.(*KubeconfigSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OCIArtifactDestination)(nil), (*v1beta1.OCIArtifactDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OCIArtifactDestination_To_v1beta1_OCIArtifactDestination(a.(*OCIArtifactDestination), b.(*v1beta1.OCIArtifactDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.OCIArtifactDestination)(nil), (*OCIArtifactDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_OCIArtifactDestination_To_v1alpha1_OCIArtifactDestination(a.(*v1beta1.OCIArtifactDestination), b.(*OCIArtifactDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OCIArtifactSource)(nil), (*v1beta1.OCIArtifactSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OCIArtifactSource_To_v1beta1_OCIArtifactSource(a.(*OCIArtifactSource), b.(*v1beta1.OCIArtifactSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.OCIArtifactSource)(nil), (*OCIArtifactSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_OCIArtifactSource_To_v1alpha1_OCIArtifactSource(a.(*v1beta1.OCIArtifactSource), b.(*OCIArtifactSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OCIRegistryDestination)(nil), (*v1beta1.OCIRegistryDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OCIRegistryDestination_To_v1beta1_OCIRegistryDestination(a.(*OCIRegistryDestination), b.(*v1beta1.OCIRegistryDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.OCIRegistryDestination)(nil), (*OCIRegistryDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_OCIRegistryDestination_To_v1alpha1_OCIRegistryDestination(a.(*v1beta1.OCIRegistryDestination), b.(*OCIRegistryDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ObjectStorageDestination)(nil), (*v1beta1.ObjectStorageDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination(a.(*ObjectStorageDestination), b.(*v1beta1.ObjectStorageDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ObjectStorageDestination)(nil), (*ObjectStorageDestination)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination(a.(*v1beta1.ObjectStorageDestination), b.(*ObjectStorageDestination), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PackageAssertion)(nil), (*v1beta1.PackageAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PackageAssertion_To_v1beta1_PackageAssertion(a.(*PackageAssertion), b.(*v1beta1.PackageAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PackageAssertion)(nil), (*PackageAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PackageAssertion_To_v1alpha1_PackageAssertion(a.(*v1beta1.PackageAssertion), b.(*PackageAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PowerShellProvisionerSpec)(nil), (*v1beta1.PowerShellProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PowerShellProvisionerSpec_To_v1beta1_PowerShellProvisionerSpec(a.(*PowerShellProvisionerSpec), b.(*v1beta1.PowerShellProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PowerShellProvisionerSpec)(nil), (*PowerShellProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PowerShellProvisionerSpec_To_v1alpha1_PowerShellProvisionerSpec(a.(*v1beta1.PowerShellProvisionerSpec), b.(*PowerShellProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PowerShellScript)(nil), (*v1beta1.PowerShellScript)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PowerShellScript_To_v1beta1_PowerShellScript(a.(*PowerShellScript), b.(*v1beta1.PowerShellScript), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PowerShellScript)(nil), (*PowerShellScript)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PowerShellScript_To_v1alpha1_PowerShellScript(a.(*v1beta1.PowerShellScript), b.(*PowerShellScript), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PowerShellScriptStatus)(nil), (*v1beta1.PowerShellScriptStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PowerShellScriptStatus_To_v1beta1_PowerShellScriptStatus(a.(*PowerShellScriptStatus), b.(*v1beta1.PowerShellScriptStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PowerShellScriptStatus)(nil), (*PowerShellScriptStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PowerShellScriptStatus_To_v1alpha1_PowerShellScriptStatus(a.(*v1beta1.PowerShellScriptStatus), b.(*PowerShellScriptStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PreemptionPolicy)(nil), (*v1beta1.PreemptionPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PreemptionPolicy_To_v1beta1_PreemptionPolicy(a.(*PreemptionPolicy), b.(*v1beta1.PreemptionPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PreemptionPolicy)(nil), (*PreemptionPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PreemptionPolicy_To_v1alpha1_PreemptionPolicy(a.(*v1beta1.PreemptionPolicy), b.(*PreemptionPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerFile)(nil), (*v1beta1.ProvisionerFile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerFile_To_v1beta1_ProvisionerFile(a.(*ProvisionerFile), b.(*v1beta1.ProvisionerFile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerFile)(nil), (*ProvisionerFile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerFile_To_v1alpha1_ProvisionerFile(a.(*v1beta1.ProvisionerFile), b.(*ProvisionerFile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerLayer)(nil), (*v1beta1.ProvisionerLayer)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerLayer_To_v1beta1_ProvisionerLayer(a.(*ProvisionerLayer), b.(*v1beta1.ProvisionerLayer), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerLayer)(nil), (*ProvisionerLayer)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerLayer_To_v1alpha1_ProvisionerLayer(a.(*v1beta1.ProvisionerLayer), b.(*ProvisionerLayer), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerPodTemplate)(nil), (*v1beta1.ProvisionerPodTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerPodTemplate_To_v1beta1_ProvisionerPodTemplate(a.(*ProvisionerPodTemplate), b.(*v1beta1.ProvisionerPodTemplate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerPodTemplate)(nil), (*ProvisionerPodTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerPodTemplate_To_v1alpha1_ProvisionerPodTemplate(a.(*v1beta1.ProvisionerPodTemplate), b.(*ProvisionerPodTemplate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerSpec)(nil), (*v1beta1.ProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerSpec_To_v1beta1_ProvisionerSpec(a.(*ProvisionerSpec), b.(*v1beta1.ProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerSpec)(nil), (*ProvisionerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerSpec_To_v1alpha1_ProvisionerSpec(a.(*v1beta1.ProvisionerSpec), b.(*ProvisionerSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerStep)(nil), (*v1beta1.ProvisionerStep)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerStep_To_v1beta1_ProvisionerStep(a.(*ProvisionerStep), b.(*v1beta1.ProvisionerStep), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerStep)(nil), (*ProvisionerStep)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerStep_To_v1alpha1_ProvisionerStep(a.(*v1beta1.ProvisionerStep), b.(*ProvisionerStep), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProvisionerStepStatus)(nil), (*v1beta1.ProvisionerStepStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ProvisionerStepStatus_To_v1beta1_ProvisionerStepStatus(a.(*ProvisionerStepStatus), b.(*v1beta1.ProvisionerStepStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ProvisionerStepStatus)(nil), (*ProvisionerStepStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProvisionerStepStatus_To_v1alpha1_ProvisionerStepStatus(a.(*v1beta1.ProvisionerStepStatus), b.(*ProvisionerStepStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PuppetApplySpec)(nil), (*v1beta1.PuppetApplySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PuppetApplySpec_To_v1beta1_PuppetApplySpec(a.(*PuppetApplySpec), b.(*v1beta1.PuppetApplySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.PuppetApplySpec)(nil), (*PuppetApplySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_PuppetApplySpec_To_v1alpha1_PuppetApplySpec(a.(*v1beta1.PuppetApplySpec), b.(*PuppetApplySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ReplicationSpec)(nil), (*v1beta1.ReplicationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ReplicationSpec_To_v1beta1_ReplicationSpec(a.(*ReplicationSpec), b.(*v1beta1.ReplicationSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ReplicationSpec)(nil), (*ReplicationSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ReplicationSpec_To_v1alpha1_ReplicationSpec(a.(*v1beta1.ReplicationSpec), b.(*ReplicationSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ReplicationStatus)(nil), (*v1beta1.ReplicationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(a.(*ReplicationStatus), b.(*v1beta1.ReplicationStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ReplicationStatus)(nil), (*ReplicationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(a.(*v1beta1.ReplicationStatus), b.(*ReplicationStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RestartSpec)(nil), (*v1beta1.RestartSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RestartSpec_To_v1beta1_RestartSpec(a.(*RestartSpec), b.(*v1beta1.RestartSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.RestartSpec)(nil), (*RestartSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RestartSpec_To_v1alpha1_RestartSpec(a.(*v1beta1.RestartSpec), b.(*RestartSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RetryBackoff)(nil), (*v1beta1.RetryBackoff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RetryBackoff_To_v1beta1_RetryBackoff(a.(*RetryBackoff), b.(*v1beta1.RetryBackoff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.RetryBackoff)(nil), (*RetryBackoff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RetryBackoff_To_v1alpha1_RetryBackoff(a.(*v1beta1.RetryBackoff), b.(*RetryBackoff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RetryPolicy)(nil), (*v1beta1.RetryPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RetryPolicy_To_v1beta1_RetryPolicy(a.(*RetryPolicy), b.(*v1beta1.RetryPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.RetryPolicy)(nil), (*RetryPolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RetryPolicy_To_v1alpha1_RetryPolicy(a.(*v1beta1.RetryPolicy), b.(*RetryPolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SBOMSpec)(nil), (*v1beta1.SBOMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SBOMSpec_To_v1beta1_SBOMSpec(a.(*SBOMSpec), b.(*v1beta1.SBOMSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.SBOMSpec)(nil), (*SBOMSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SBOMSpec_To_v1alpha1_SBOMSpec(a.(*v1beta1.SBOMSpec), b.(*SBOMSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SBOMStatus)(nil), (*v1beta1.SBOMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SBOMStatus_To_v1beta1_SBOMStatus(a.(*SBOMStatus), b.(*v1beta1.SBOMStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.SBOMStatus)(nil), (*SBOMStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SBOMStatus_To_v1alpha1_SBOMStatus(a.(*v1beta1.SBOMStatus), b.(*SBOMStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ScanIgnore)(nil), (*v1beta1.ScanIgnore)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ScanIgnore_To_v1beta1_ScanIgnore(a.(*ScanIgnore), b.(*v1beta1.ScanIgnore), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ScanIgnore)(nil), (*ScanIgnore)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ScanIgnore_To_v1alpha1_ScanIgnore(a.(*v1beta1.ScanIgnore), b.(*ScanIgnore), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ScanSpec)(nil), (*v1beta1.ScanSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ScanSpec_To_v1beta1_ScanSpec(a.(*ScanSpec), b.(*v1beta1.ScanSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ScanSpec)(nil), (*ScanSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ScanSpec_To_v1alpha1_ScanSpec(a.(*v1beta1.ScanSpec), b.(*ScanSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ScanStatus)(nil), (*v1beta1.ScanStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ScanStatus_To_v1beta1_ScanStatus(a.(*ScanStatus), b.(*v1beta1.ScanStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ScanStatus)(nil), (*ScanStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ScanStatus_To_v1alpha1_ScanStatus(a.(*v1beta1.ScanStatus), b.(*ScanStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ScanSummary)(nil), (*v1beta1.ScanSummary)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary(a.(*ScanSummary), b.(*v1beta1.ScanSummary), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ScanSummary)(nil), (*ScanSummary)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary(a.(*v1beta1.ScanSummary), b.(*ScanSummary), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ScanThresholds)(nil), (*v1beta1.ScanThresholds)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ScanThresholds_To_v1beta1_ScanThresholds(a.(*ScanThresholds), b.(*v1beta1.ScanThresholds), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ScanThresholds)(nil), (*ScanThresholds)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ScanThresholds_To_v1alpha1_ScanThresholds(a.(*v1beta1.ScanThresholds), b.(*ScanThresholds), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ServiceAssertion)(nil), (*v1beta1.ServiceAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ServiceAssertion_To_v1beta1_ServiceAssertion(a.(*ServiceAssertion), b.(*v1beta1.ServiceAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ServiceAssertion)(nil), (*ServiceAssertion)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ServiceAssertion_To_v1alpha1_ServiceAssertion(a.(*v1beta1.ServiceAssertion), b.(*ServiceAssertion), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SigningSpec)(nil), (*v1beta1.SigningSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SigningSpec_To_v1beta1_SigningSpec(a.(*SigningSpec), b.(*v1beta1.SigningSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.SigningSpec)(nil), (*SigningSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SigningSpec_To_v1alpha1_SigningSpec(a.(*v1beta1.SigningSpec), b.(*SigningSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SudoSpec)(nil), (*v1beta1.SudoSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec(a.(*SudoSpec), b.(*v1beta1.SudoSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.SudoSpec)(nil), (*SudoSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec(a.(*v1beta1.SudoSpec), b.(*SudoSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*UploadedFileStatus)(nil), (*v1beta1.UploadedFileStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_UploadedFileStatus_To_v1beta1_UploadedFileStatus(a.(*UploadedFileStatus), b.(*v1beta1.UploadedFileStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.UploadedFileStatus)(nil), (*UploadedFileStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_UploadedFileStatus_To_v1alpha1_UploadedFileStatus(a.(*v1beta1.UploadedFileStatus), b.(*UploadedFileStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*VagrantExport)(nil), (*v1beta1.VagrantExport)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_VagrantExport_To_v1beta1_VagrantExport(a.(*VagrantExport), b.(*v1beta1.VagrantExport), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.VagrantExport)(nil), (*VagrantExport)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VagrantExport_To_v1alpha1_VagrantExport(a.(*v1beta1.VagrantExport), b.(*VagrantExport), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*VerificationReport)(nil), (*v1beta1.VerificationReport)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_VerificationReport_To_v1beta1_VerificationReport(a.(*VerificationReport), b.(*v1beta1.VerificationReport), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.VerificationReport)(nil), (*VerificationReport)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VerificationReport_To_v1alpha1_VerificationReport(a.(*v1beta1.VerificationReport), b.(*VerificationReport), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*VerificationResult)(nil), (*v1beta1.VerificationResult)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_VerificationResult_To_v1beta1_VerificationResult(a.(*VerificationResult), b.(*v1beta1.VerificationResult), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.VerificationResult)(nil), (*VerificationResult)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VerificationResult_To_v1alpha1_VerificationResult(a.(*v1beta1.VerificationResult), b.(*VerificationResult), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*VerifySpec)(nil), (*v1beta1.VerifySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_VerifySpec_To_v1beta1_VerifySpec(a.(*VerifySpec), b.(*v1beta1.VerifySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.VerifySpec)(nil), (*VerifySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VerifySpec_To_v1alpha1_VerifySpec(a.(*v1beta1.VerifySpec), b.(*VerifySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*VerifySuite)(nil), (*v1beta1.VerifySuite)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_VerifySuite_To_v1beta1_VerifySuite(a.(*VerifySuite), b.(*v1beta1.VerifySuite), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.VerifySuite)(nil), (*VerifySuite)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_VerifySuite_To_v1alpha1_VerifySuite(a.(*v1beta1.VerifySuite), b.(*VerifySuite), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WorkspaceSpec)(nil), (*v1beta1.WorkspaceSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_WorkspaceSpec_To_v1beta1_WorkspaceSpec(a.(*WorkspaceSpec), b.(*v1beta1.WorkspaceSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.WorkspaceSpec)(nil), (*WorkspaceSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_WorkspaceSpec_To_v1alpha1_WorkspaceSpec(a.(*v1beta1.WorkspaceSpec), b.(*WorkspaceSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WorkspaceStatus)(nil), (*v1beta1.WorkspaceStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_WorkspaceStatus_To_v1beta1_WorkspaceStatus(a.(*WorkspaceStatus), b.(*v1beta1.WorkspaceStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.WorkspaceStatus)(nil), (*WorkspaceStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_WorkspaceStatus_To_v1alpha1_WorkspaceStatus(a.(*v1beta1.WorkspaceStatus), b.(*WorkspaceStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ConnectorSpec)(nil), (*v1beta1.ConnectorSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec(a.(*ConnectorSpec), b.(*v1beta1.ConnectorSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta1.ConnectorSpec)(nil), (*ConnectorSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec(a.(*v1beta1.ConnectorSpec), b.(*ConnectorSpec), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_AnsibleGitSource_To_v1beta1_AnsibleGitSource(in *AnsibleGitSource, out *v1beta1.AnsibleGitSource, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Ref = in.Ref
+	out.SecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.SecretRef))
+	return nil
+}
+
+// Convert_v1alpha1_AnsibleGitSource_To_v1beta1_AnsibleGitSource is an autogenerated conversion function.
+func Convert_v1alpha1_AnsibleGitSource_To_v1beta1_AnsibleGitSource(in *AnsibleGitSource, out *v1beta1.AnsibleGitSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AnsibleGitSource_To_v1beta1_AnsibleGitSource(in, out, s)
+}
+
+func autoConvert_v1beta1_AnsibleGitSource_To_v1alpha1_AnsibleGitSource(in *v1beta1.AnsibleGitSource, out *AnsibleGitSource, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Ref = in.Ref
+	out.SecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.SecretRef))
+	return nil
+}
+
+// Convert_v1beta1_AnsibleGitSource_To_v1alpha1_AnsibleGitSource is an autogenerated conversion function.
+func Convert_v1beta1_AnsibleGitSource_To_v1alpha1_AnsibleGitSource(in *v1beta1.AnsibleGitSource, out *AnsibleGitSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_AnsibleGitSource_To_v1alpha1_AnsibleGitSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_AnsiblePlayStatus_To_v1beta1_AnsiblePlayStatus(in *AnsiblePlayStatus, out *v1beta1.AnsiblePlayStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = v1beta1.ProvisionerStatus(in.Phase)
+	out.Ok = in.Ok
+	out.Changed = in.Changed
+	out.Failed = in.Failed
+	out.Skipped = in.Skipped
+	out.Unreachable = in.Unreachable
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_AnsiblePlayStatus_To_v1beta1_AnsiblePlayStatus is an autogenerated conversion function.
+func Convert_v1alpha1_AnsiblePlayStatus_To_v1beta1_AnsiblePlayStatus(in *AnsiblePlayStatus, out *v1beta1.AnsiblePlayStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AnsiblePlayStatus_To_v1beta1_AnsiblePlayStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_AnsiblePlayStatus_To_v1alpha1_AnsiblePlayStatus(in *v1beta1.AnsiblePlayStatus, out *AnsiblePlayStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = ProvisionerStatus(in.Phase)
+	out.Ok = in.Ok
+	out.Changed = in.Changed
+	out.Failed = in.Failed
+	out.Skipped = in.Skipped
+	out.Unreachable = in.Unreachable
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_AnsiblePlayStatus_To_v1alpha1_AnsiblePlayStatus is an autogenerated conversion function.
+func Convert_v1beta1_AnsiblePlayStatus_To_v1alpha1_AnsiblePlayStatus(in *v1beta1.AnsiblePlayStatus, out *AnsiblePlayStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_AnsiblePlayStatus_To_v1alpha1_AnsiblePlayStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_AnsibleSpec_To_v1beta1_AnsibleSpec(in *AnsibleSpec, out *v1beta1.AnsibleSpec, s conversion.Scope) error {
+	out.Playbook = in.Playbook
+	out.PlaybookConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PlaybookConfigMapRef))
+	out.Git = (*v1beta1.AnsibleGitSource)(unsafe.Pointer(in.Git))
+	out.PlaybookPath = in.PlaybookPath
+	out.Requirements = in.Requirements
+	out.ExtraVars = *(*map[string]string)(unsafe.Pointer(&in.ExtraVars))
+	out.Tags = *(*[]string)(unsafe.Pointer(&in.Tags))
+	out.SkipTags = *(*[]string)(unsafe.Pointer(&in.SkipTags))
+	return nil
+}
+
+// Convert_v1alpha1_AnsibleSpec_To_v1beta1_AnsibleSpec is an autogenerated conversion function.
+func Convert_v1alpha1_AnsibleSpec_To_v1beta1_AnsibleSpec(in *AnsibleSpec, out *v1beta1.AnsibleSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AnsibleSpec_To_v1beta1_AnsibleSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_AnsibleSpec_To_v1alpha1_AnsibleSpec(in *v1beta1.AnsibleSpec, out *AnsibleSpec, s conversion.Scope) error {
+	out.Playbook = in.Playbook
+	out.PlaybookConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PlaybookConfigMapRef))
+	out.Git = (*AnsibleGitSource)(unsafe.Pointer(in.Git))
+	out.PlaybookPath = in.PlaybookPath
+	out.Requirements = in.Requirements
+	out.ExtraVars = *(*map[string]string)(unsafe.Pointer(&in.ExtraVars))
+	out.Tags = *(*[]string)(unsafe.Pointer(&in.Tags))
+	out.SkipTags = *(*[]string)(unsafe.Pointer(&in.SkipTags))
+	return nil
+}
+
+// Convert_v1beta1_AnsibleSpec_To_v1alpha1_AnsibleSpec is an autogenerated conversion function.
+func Convert_v1beta1_AnsibleSpec_To_v1alpha1_AnsibleSpec(in *v1beta1.AnsibleSpec, out *AnsibleSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_AnsibleSpec_To_v1alpha1_AnsibleSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ArchitectureStatus_To_v1beta1_ArchitectureStatus(in *ArchitectureStatus, out *v1beta1.ArchitectureStatus, s conversion.Scope) error {
+	out.Architecture = v1beta1.Architecture(in.Architecture)
+	out.BuildName = in.BuildName
+	out.Phase = in.Phase
+	out.ImageRef = in.ImageRef
+	out.Artifact = (*v1beta1.BuildArtifact)(unsafe.Pointer(in.Artifact))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	return nil
+}
+
+// Convert_v1alpha1_ArchitectureStatus_To_v1beta1_ArchitectureStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ArchitectureStatus_To_v1beta1_ArchitectureStatus(in *ArchitectureStatus, out *v1beta1.ArchitectureStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ArchitectureStatus_To_v1beta1_ArchitectureStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ArchitectureStatus_To_v1alpha1_ArchitectureStatus(in *v1beta1.ArchitectureStatus, out *ArchitectureStatus, s conversion.Scope) error {
+	out.Architecture = Architecture(in.Architecture)
+	out.BuildName = in.BuildName
+	out.Phase = in.Phase
+	out.ImageRef = in.ImageRef
+	out.Artifact = (*BuildArtifact)(unsafe.Pointer(in.Artifact))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	return nil
+}
+
+// Convert_v1beta1_ArchitectureStatus_To_v1alpha1_ArchitectureStatus is an autogenerated conversion function.
+func Convert_v1beta1_ArchitectureStatus_To_v1alpha1_ArchitectureStatus(in *v1beta1.ArchitectureStatus, out *ArchitectureStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ArchitectureStatus_To_v1alpha1_ArchitectureStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ArtifactSpec_To_v1beta1_ArtifactSpec(in *ArtifactSpec, out *v1beta1.ArtifactSpec, s conversion.Scope) error {
+	out.NamePolicy = in.NamePolicy
+	out.Replication = (*v1beta1.ReplicationSpec)(unsafe.Pointer(in.Replication))
+	out.Signing = (*v1beta1.SigningSpec)(unsafe.Pointer(in.Signing))
+	out.SBOM = (*v1beta1.SBOMSpec)(unsafe.Pointer(in.SBOM))
+	out.ExpireAfter = (*metav1.Duration)(unsafe.Pointer(in.ExpireAfter))
+	return nil
+}
+
+// Convert_v1alpha1_ArtifactSpec_To_v1beta1_ArtifactSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ArtifactSpec_To_v1beta1_ArtifactSpec(in *ArtifactSpec, out *v1beta1.ArtifactSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ArtifactSpec_To_v1beta1_ArtifactSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ArtifactSpec_To_v1alpha1_ArtifactSpec(in *v1beta1.ArtifactSpec, out *ArtifactSpec, s conversion.Scope) error {
+	out.NamePolicy = in.NamePolicy
+	out.Replication = (*ReplicationSpec)(unsafe.Pointer(in.Replication))
+	out.Signing = (*SigningSpec)(unsafe.Pointer(in.Signing))
+	out.SBOM = (*SBOMSpec)(unsafe.Pointer(in.SBOM))
+	out.ExpireAfter = (*metav1.Duration)(unsafe.Pointer(in.ExpireAfter))
+	return nil
+}
+
+// Convert_v1beta1_ArtifactSpec_To_v1alpha1_ArtifactSpec is an autogenerated conversion function.
+func Convert_v1beta1_ArtifactSpec_To_v1alpha1_ArtifactSpec(in *v1beta1.ArtifactSpec, out *ArtifactSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ArtifactSpec_To_v1alpha1_ArtifactSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_AzureBlobDestination_To_v1beta1_AzureBlobDestination(in *AzureBlobDestination, out *v1beta1.AzureBlobDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1alpha1_AzureBlobDestination_To_v1beta1_AzureBlobDestination is an autogenerated conversion function.
+func Convert_v1alpha1_AzureBlobDestination_To_v1beta1_AzureBlobDestination(in *AzureBlobDestination, out *v1beta1.AzureBlobDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AzureBlobDestination_To_v1beta1_AzureBlobDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_AzureBlobDestination_To_v1alpha1_AzureBlobDestination(in *v1beta1.AzureBlobDestination, out *AzureBlobDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1beta1_AzureBlobDestination_To_v1alpha1_AzureBlobDestination is an autogenerated conversion function.
+func Convert_v1beta1_AzureBlobDestination_To_v1alpha1_AzureBlobDestination(in *v1beta1.AzureBlobDestination, out *AzureBlobDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_AzureBlobDestination_To_v1alpha1_AzureBlobDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec(in *BastionSpec, out *v1beta1.BastionSpec, s conversion.Scope) error {
+	out.Credentials = in.Credentials
+	out.Port = in.Port
+	return nil
+}
+
+// Convert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec is an autogenerated conversion function.
+func Convert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec(in *BastionSpec, out *v1beta1.BastionSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BastionSpec_To_v1beta1_BastionSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec(in *v1beta1.BastionSpec, out *BastionSpec, s conversion.Scope) error {
+	out.Credentials = in.Credentials
+	out.Port = in.Port
+	return nil
+}
+
+// Convert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec is an autogenerated conversion function.
+func Convert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec(in *v1beta1.BastionSpec, out *BastionSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_BastionSpec_To_v1alpha1_BastionSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_BoxRegistryDestination_To_v1beta1_BoxRegistryDestination(in *BoxRegistryDestination, out *v1beta1.BoxRegistryDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1alpha1_BoxRegistryDestination_To_v1beta1_BoxRegistryDestination is an autogenerated conversion function.
+func Convert_v1alpha1_BoxRegistryDestination_To_v1beta1_BoxRegistryDestination(in *BoxRegistryDestination, out *v1beta1.BoxRegistryDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BoxRegistryDestination_To_v1beta1_BoxRegistryDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_BoxRegistryDestination_To_v1alpha1_BoxRegistryDestination(in *v1beta1.BoxRegistryDestination, out *BoxRegistryDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1beta1_BoxRegistryDestination_To_v1alpha1_BoxRegistryDestination is an autogenerated conversion function.
+func Convert_v1beta1_BoxRegistryDestination_To_v1alpha1_BoxRegistryDestination(in *v1beta1.BoxRegistryDestination, out *BoxRegistryDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_BoxRegistryDestination_To_v1alpha1_BoxRegistryDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_Build_To_v1beta1_Build(in *Build, out *v1beta1.Build, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_Build_To_v1beta1_Build is an autogenerated conversion function.
+func Convert_v1alpha1_Build_To_v1beta1_Build(in *Build, out *v1beta1.Build, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Build_To_v1beta1_Build(in, out, s)
+}
+
+func autoConvert_v1beta1_Build_To_v1alpha1_Build(in *v1beta1.Build, out *Build, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_Build_To_v1alpha1_Build is an autogenerated conversion function.
+func Convert_v1beta1_Build_To_v1alpha1_Build(in *v1beta1.Build, out *Build, s conversion.Scope) error {
+	return autoConvert_v1beta1_Build_To_v1alpha1_Build(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildArtifact_To_v1beta1_BuildArtifact(in *BuildArtifact, out *v1beta1.BuildArtifact, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Location = in.Location
+	out.Checksum = in.Checksum
+	out.SizeBytes = (*int64)(unsafe.Pointer(in.SizeBytes))
+	out.Format = in.Format
+	out.CreatedAt = (*metav1.Time)(unsafe.Pointer(in.CreatedAt))
+	return nil
+}
+
+// Convert_v1alpha1_BuildArtifact_To_v1beta1_BuildArtifact is an autogenerated conversion function.
+func Convert_v1alpha1_BuildArtifact_To_v1beta1_BuildArtifact(in *BuildArtifact, out *v1beta1.BuildArtifact, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildArtifact_To_v1beta1_BuildArtifact(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildArtifact_To_v1alpha1_BuildArtifact(in *v1beta1.BuildArtifact, out *BuildArtifact, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Location = in.Location
+	out.Checksum = in.Checksum
+	out.SizeBytes = (*int64)(unsafe.Pointer(in.SizeBytes))
+	out.Format = in.Format
+	out.CreatedAt = (*metav1.Time)(unsafe.Pointer(in.CreatedAt))
+	return nil
+}
+
+// Convert_v1beta1_BuildArtifact_To_v1alpha1_BuildArtifact is an autogenerated conversion function.
+func Convert_v1beta1_BuildArtifact_To_v1alpha1_BuildArtifact(in *v1beta1.BuildArtifact, out *BuildArtifact, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildArtifact_To_v1alpha1_BuildArtifact(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildAttempt_To_v1beta1_BuildAttempt(in *BuildAttempt, out *v1beta1.BuildAttempt, s conversion.Scope) error {
+	out.Attempt = in.Attempt
+	out.FailureReason = (*errors.BuildStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.FailureTime = in.FailureTime
+	return nil
+}
+
+// Convert_v1alpha1_BuildAttempt_To_v1beta1_BuildAttempt is an autogenerated conversion function.
+func Convert_v1alpha1_BuildAttempt_To_v1beta1_BuildAttempt(in *BuildAttempt, out *v1beta1.BuildAttempt, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildAttempt_To_v1beta1_BuildAttempt(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildAttempt_To_v1alpha1_BuildAttempt(in *v1beta1.BuildAttempt, out *BuildAttempt, s conversion.Scope) error {
+	out.Attempt = in.Attempt
+	out.FailureReason = (*errors.BuildStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.FailureTime = in.FailureTime
+	return nil
+}
+
+// Convert_v1beta1_BuildAttempt_To_v1alpha1_BuildAttempt is an autogenerated conversion function.
+func Convert_v1beta1_BuildAttempt_To_v1alpha1_BuildAttempt(in *v1beta1.BuildAttempt, out *BuildAttempt, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildAttempt_To_v1alpha1_BuildAttempt(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildList_To_v1beta1_BuildList(in *BuildList, out *v1beta1.BuildList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta1.Build, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_Build_To_v1beta1_Build(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha1_BuildList_To_v1beta1_BuildList is an autogenerated conversion function.
+func Convert_v1alpha1_BuildList_To_v1beta1_BuildList(in *BuildList, out *v1beta1.BuildList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildList_To_v1beta1_BuildList(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildList_To_v1alpha1_BuildList(in *v1beta1.BuildList, out *BuildList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Build, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_Build_To_v1alpha1_Build(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_BuildList_To_v1alpha1_BuildList is an autogenerated conversion function.
+func Convert_v1beta1_BuildList_To_v1alpha1_BuildList(in *v1beta1.BuildList, out *BuildList, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildList_To_v1alpha1_BuildList(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildProgress_To_v1beta1_BuildProgress(in *BuildProgress, out *v1beta1.BuildProgress, s conversion.Scope) error {
+	out.CurrentStep = in.CurrentStep
+	out.TotalSteps = in.TotalSteps
+	out.Message = in.Message
+	out.Percent = in.Percent
+	return nil
+}
+
+// Convert_v1alpha1_BuildProgress_To_v1beta1_BuildProgress is an autogenerated conversion function.
+func Convert_v1alpha1_BuildProgress_To_v1beta1_BuildProgress(in *BuildProgress, out *v1beta1.BuildProgress, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildProgress_To_v1beta1_BuildProgress(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildProgress_To_v1alpha1_BuildProgress(in *v1beta1.BuildProgress, out *BuildProgress, s conversion.Scope) error {
+	out.CurrentStep = in.CurrentStep
+	out.TotalSteps = in.TotalSteps
+	out.Message = in.Message
+	out.Percent = in.Percent
+	return nil
+}
+
+// Convert_v1beta1_BuildProgress_To_v1alpha1_BuildProgress is an autogenerated conversion function.
+func Convert_v1beta1_BuildProgress_To_v1alpha1_BuildProgress(in *v1beta1.BuildProgress, out *BuildProgress, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildProgress_To_v1alpha1_BuildProgress(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildProvisionerStatus_To_v1beta1_BuildProvisionerStatus(in *BuildProvisionerStatus, out *v1beta1.BuildProvisionerStatus, s conversion.Scope) error {
+	out.UUID = in.UUID
+	out.Name = in.Name
+	out.Type = v1beta1.ProvisionerType(in.Type)
+	out.Phase = v1beta1.ProvisionerStatus(in.Phase)
+	out.StartedAt = (*metav1.Time)(unsafe.Pointer(in.StartedAt))
+	out.CompletedAt = (*metav1.Time)(unsafe.Pointer(in.CompletedAt))
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.Attempts = in.Attempts
+	out.NextAttemptAt = (*metav1.Time)(unsafe.Pointer(in.NextAttemptAt))
+	out.Message = in.Message
+	out.Logs = in.Logs
+	out.LogsConfigMapName = in.LogsConfigMapName
+	out.Steps = *(*[]v1beta1.ProvisionerStepStatus)(unsafe.Pointer(&in.Steps))
+	out.Plays = *(*[]v1beta1.AnsiblePlayStatus)(unsafe.Pointer(&in.Plays))
+	out.Files = *(*[]v1beta1.UploadedFileStatus)(unsafe.Pointer(&in.Files))
+	out.Scripts = *(*[]v1beta1.PowerShellScriptStatus)(unsafe.Pointer(&in.Scripts))
+	out.RestartedAt = (*metav1.Time)(unsafe.Pointer(in.RestartedAt))
+	out.Report = (*v1beta1.VerificationReport)(unsafe.Pointer(in.Report))
+	out.Compliance = (*v1beta1.ComplianceSummary)(unsafe.Pointer(in.Compliance))
+	out.Extracted = *(*[]v1beta1.ExtractedFileStatus)(unsafe.Pointer(&in.Extracted))
+	return nil
+}
+
+// Convert_v1alpha1_BuildProvisionerStatus_To_v1beta1_BuildProvisionerStatus is an autogenerated conversion function.
+func Convert_v1alpha1_BuildProvisionerStatus_To_v1beta1_BuildProvisionerStatus(in *BuildProvisionerStatus, out *v1beta1.BuildProvisionerStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildProvisionerStatus_To_v1beta1_BuildProvisionerStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildProvisionerStatus_To_v1alpha1_BuildProvisionerStatus(in *v1beta1.BuildProvisionerStatus, out *BuildProvisionerStatus, s conversion.Scope) error {
+	out.UUID = in.UUID
+	out.Name = in.Name
+	out.Type = ProvisionerType(in.Type)
+	out.Phase = ProvisionerStatus(in.Phase)
+	out.StartedAt = (*metav1.Time)(unsafe.Pointer(in.StartedAt))
+	out.CompletedAt = (*metav1.Time)(unsafe.Pointer(in.CompletedAt))
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.Attempts = in.Attempts
+	out.NextAttemptAt = (*metav1.Time)(unsafe.Pointer(in.NextAttemptAt))
+	out.Message = in.Message
+	out.Logs = in.Logs
+	out.LogsConfigMapName = in.LogsConfigMapName
+	out.Steps = *(*[]ProvisionerStepStatus)(unsafe.Pointer(&in.Steps))
+	out.Plays = *(*[]AnsiblePlayStatus)(unsafe.Pointer(&in.Plays))
+	out.Files = *(*[]UploadedFileStatus)(unsafe.Pointer(&in.Files))
+	out.Scripts = *(*[]PowerShellScriptStatus)(unsafe.Pointer(&in.Scripts))
+	out.RestartedAt = (*metav1.Time)(unsafe.Pointer(in.RestartedAt))
+	out.Report = (*VerificationReport)(unsafe.Pointer(in.Report))
+	out.Compliance = (*ComplianceSummary)(unsafe.Pointer(in.Compliance))
+	out.Extracted = *(*[]ExtractedFileStatus)(unsafe.Pointer(&in.Extracted))
+	return nil
+}
+
+// Convert_v1beta1_BuildProvisionerStatus_To_v1alpha1_BuildProvisionerStatus is an autogenerated conversion function.
+func Convert_v1beta1_BuildProvisionerStatus_To_v1alpha1_BuildProvisionerStatus(in *v1beta1.BuildProvisionerStatus, out *BuildProvisionerStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildProvisionerStatus_To_v1alpha1_BuildProvisionerStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildSource_To_v1beta1_BuildSource(in *BuildSource, out *v1beta1.BuildSource, s conversion.Scope) error {
+	out.BuildRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.BuildRef))
+	out.ImageRef = in.ImageRef
+	return nil
+}
+
+// Convert_v1alpha1_BuildSource_To_v1beta1_BuildSource is an autogenerated conversion function.
+func Convert_v1alpha1_BuildSource_To_v1beta1_BuildSource(in *BuildSource, out *v1beta1.BuildSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildSource_To_v1beta1_BuildSource(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildSource_To_v1alpha1_BuildSource(in *v1beta1.BuildSource, out *BuildSource, s conversion.Scope) error {
+	out.BuildRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.BuildRef))
+	out.ImageRef = in.ImageRef
+	return nil
+}
+
+// Convert_v1beta1_BuildSource_To_v1alpha1_BuildSource is an autogenerated conversion function.
+func Convert_v1beta1_BuildSource_To_v1alpha1_BuildSource(in *v1beta1.BuildSource, out *BuildSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildSource_To_v1alpha1_BuildSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec(in *BuildSpec, out *v1beta1.BuildSpec, s conversion.Scope) error {
+	out.Paused = in.Paused
+	out.TemplateRef = (*v1beta1.BuildTemplateReference)(unsafe.Pointer(in.TemplateRef))
+	out.Variables = *(*[]v1beta1.BuildVariable)(unsafe.Pointer(&in.Variables))
+	if err := Convert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec(&in.Connector, &out.Connector, s); err != nil {
+		return err
+	}
+	out.InfrastructureRef = (*v1.ObjectReference)(unsafe.Pointer(in.InfrastructureRef))
+	out.IdentityRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.IdentityRef))
+	out.ImageName = in.ImageName
+	out.Artifact = (*v1beta1.ArtifactSpec)(unsafe.Pointer(in.Artifact))
+	out.Scan = (*v1beta1.ScanSpec)(unsafe.Pointer(in.Scan))
+	out.ImageMetadata = (*v1beta1.ImageMetadataSpec)(unsafe.Pointer(in.ImageMetadata))
+	out.Provisioners = *(*[]v1beta1.ProvisionerSpec)(unsafe.Pointer(&in.Provisioners))
+	out.From = (*v1beta1.BuildSource)(unsafe.Pointer(in.From))
+	out.Workspace = (*v1beta1.WorkspaceSpec)(unsafe.Pointer(in.Workspace))
+	out.ProvisionerJobPlacement = v1beta1.ProvisionerJobPlacement(in.ProvisionerJobPlacement)
+	out.DeleteCascade = in.DeleteCascade
+	out.RetryPolicy = (*v1beta1.RetryPolicy)(unsafe.Pointer(in.RetryPolicy))
+	out.PreemptionPolicy = (*v1beta1.PreemptionPolicy)(unsafe.Pointer(in.PreemptionPolicy))
+	out.Timeouts = (*v1beta1.BuildTimeouts)(unsafe.Pointer(in.Timeouts))
+	out.TTLSecondsAfterFinished = (*int32)(unsafe.Pointer(in.TTLSecondsAfterFinished))
+	out.Simulate = in.Simulate
+	out.DryRun = in.DryRun
+	out.Exports = *(*[]v1beta1.ExportSpec)(unsafe.Pointer(&in.Exports))
+	out.Architectures = *(*[]v1beta1.Architecture)(unsafe.Pointer(&in.Architectures))
+	return nil
+}
+
+// Convert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec is an autogenerated conversion function.
+func Convert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec(in *BuildSpec, out *v1beta1.BuildSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildSpec_To_v1beta1_BuildSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec(in *v1beta1.BuildSpec, out *BuildSpec, s conversion.Scope) error {
+	out.Paused = in.Paused
+	out.TemplateRef = (*BuildTemplateReference)(unsafe.Pointer(in.TemplateRef))
+	out.Variables = *(*[]BuildVariable)(unsafe.Pointer(&in.Variables))
+	if err := Convert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec(&in.Connector, &out.Connector, s); err != nil {
+		return err
+	}
+	out.InfrastructureRef = (*v1.ObjectReference)(unsafe.Pointer(in.InfrastructureRef))
+	out.IdentityRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.IdentityRef))
+	out.ImageName = in.ImageName
+	out.Artifact = (*ArtifactSpec)(unsafe.Pointer(in.Artifact))
+	out.Scan = (*ScanSpec)(unsafe.Pointer(in.Scan))
+	out.ImageMetadata = (*ImageMetadataSpec)(unsafe.Pointer(in.ImageMetadata))
+	out.Provisioners = *(*[]ProvisionerSpec)(unsafe.Pointer(&in.Provisioners))
+	out.From = (*BuildSource)(unsafe.Pointer(in.From))
+	out.Workspace = (*WorkspaceSpec)(unsafe.Pointer(in.Workspace))
+	out.ProvisionerJobPlacement = ProvisionerJobPlacement(in.ProvisionerJobPlacement)
+	out.DeleteCascade = in.DeleteCascade
+	out.RetryPolicy = (*RetryPolicy)(unsafe.Pointer(in.RetryPolicy))
+	out.PreemptionPolicy = (*PreemptionPolicy)(unsafe.Pointer(in.PreemptionPolicy))
+	out.Timeouts = (*BuildTimeouts)(unsafe.Pointer(in.Timeouts))
+	out.TTLSecondsAfterFinished = (*int32)(unsafe.Pointer(in.TTLSecondsAfterFinished))
+	out.Simulate = in.Simulate
+	out.DryRun = in.DryRun
+	out.Exports = *(*[]ExportSpec)(unsafe.Pointer(&in.Exports))
+	out.Architectures = *(*[]Architecture)(unsafe.Pointer(&in.Architectures))
+	return nil
+}
+
+// Convert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec is an autogenerated conversion function.
+func Convert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec(in *v1beta1.BuildSpec, out *BuildSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildSpec_To_v1alpha1_BuildSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus(in *BuildStatus, out *v1beta1.BuildStatus, s conversion.Scope) error {
+	out.FailureDomains = *(*v1beta1.FailureDomains)(unsafe.Pointer(&in.FailureDomains))
+	out.FailureReason = (*errors.BuildStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Conditions = *(*[]metav1.Condition)(unsafe.Pointer(&in.Conditions))
+	out.InfrastructureReady = in.InfrastructureReady
+	out.Connected = in.Connected
+	out.Connection = (*v1beta1.ConnectionStatus)(unsafe.Pointer(in.Connection))
+	out.ProvisionersReady = in.ProvisionersReady
+	out.Phase = in.Phase
+	out.ImageRef = in.ImageRef
+	out.Artifact = (*v1beta1.BuildArtifact)(unsafe.Pointer(in.Artifact))
+	out.Replications = *(*[]v1beta1.ReplicationStatus)(unsafe.Pointer(&in.Replications))
+	out.SBOM = (*v1beta1.SBOMStatus)(unsafe.Pointer(in.SBOM))
+	out.Scan = (*v1beta1.ScanStatus)(unsafe.Pointer(in.Scan))
+	out.Image = in.Image
+	out.Cache = (*v1beta1.CacheStatus)(unsafe.Pointer(in.Cache))
+	out.ImageMetadata = *(*map[string]string)(unsafe.Pointer(&in.ImageMetadata))
+	out.Ready = in.Ready
+	out.StartTime = (*metav1.Time)(unsafe.Pointer(in.StartTime))
+	out.CompletionTime = (*metav1.Time)(unsafe.Pointer(in.CompletionTime))
+	out.Retries = in.Retries
+	out.Preemptions = in.Preemptions
+	out.LastPreemptionTime = (*metav1.Time)(unsafe.Pointer(in.LastPreemptionTime))
+	out.NextRetryTime = (*metav1.Time)(unsafe.Pointer(in.NextRetryTime))
+	out.FailedAttempts = *(*[]v1beta1.BuildAttempt)(unsafe.Pointer(&in.FailedAttempts))
+	out.Exports = *(*[]v1beta1.ExportStatus)(unsafe.Pointer(&in.Exports))
+	out.Architectures = *(*[]v1beta1.ArchitectureStatus)(unsafe.Pointer(&in.Architectures))
+	out.Workspace = (*v1beta1.WorkspaceStatus)(unsafe.Pointer(in.Workspace))
+	out.VariablesSecretName = in.VariablesSecretName
+	out.AwaitingApproval = in.AwaitingApproval
+	out.Provisioners = *(*[]v1beta1.BuildProvisionerStatus)(unsafe.Pointer(&in.Provisioners))
+	out.Health = (*v1beta1.HealthStatus)(unsafe.Pointer(in.Health))
+	out.Progress = (*v1beta1.BuildProgress)(unsafe.Pointer(in.Progress))
+	return nil
+}
+
+// Convert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus is an autogenerated conversion function.
+func Convert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus(in *BuildStatus, out *v1beta1.BuildStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildStatus_To_v1beta1_BuildStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus(in *v1beta1.BuildStatus, out *BuildStatus, s conversion.Scope) error {
+	out.FailureDomains = *(*FailureDomains)(unsafe.Pointer(&in.FailureDomains))
+	out.FailureReason = (*errors.BuildStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Conditions = *(*[]metav1.Condition)(unsafe.Pointer(&in.Conditions))
+	out.InfrastructureReady = in.InfrastructureReady
+	out.Connected = in.Connected
+	out.Connection = (*ConnectionStatus)(unsafe.Pointer(in.Connection))
+	out.ProvisionersReady = in.ProvisionersReady
+	out.Phase = in.Phase
+	out.ImageRef = in.ImageRef
+	out.Artifact = (*BuildArtifact)(unsafe.Pointer(in.Artifact))
+	out.Replications = *(*[]ReplicationStatus)(unsafe.Pointer(&in.Replications))
+	out.SBOM = (*SBOMStatus)(unsafe.Pointer(in.SBOM))
+	out.Scan = (*ScanStatus)(unsafe.Pointer(in.Scan))
+	out.Image = in.Image
+	out.Cache = (*CacheStatus)(unsafe.Pointer(in.Cache))
+	out.ImageMetadata = *(*map[string]string)(unsafe.Pointer(&in.ImageMetadata))
+	out.Ready = in.Ready
+	out.StartTime = (*metav1.Time)(unsafe.Pointer(in.StartTime))
+	out.CompletionTime = (*metav1.Time)(unsafe.Pointer(in.CompletionTime))
+	out.Retries = in.Retries
+	out.Preemptions = in.Preemptions
+	out.LastPreemptionTime = (*metav1.Time)(unsafe.Pointer(in.LastPreemptionTime))
+	out.NextRetryTime = (*metav1.Time)(unsafe.Pointer(in.NextRetryTime))
+	out.FailedAttempts = *(*[]BuildAttempt)(unsafe.Pointer(&in.FailedAttempts))
+	out.Exports = *(*[]ExportStatus)(unsafe.Pointer(&in.Exports))
+	out.Architectures = *(*[]ArchitectureStatus)(unsafe.Pointer(&in.Architectures))
+	out.Workspace = (*WorkspaceStatus)(unsafe.Pointer(in.Workspace))
+	out.VariablesSecretName = in.VariablesSecretName
+	out.AwaitingApproval = in.AwaitingApproval
+	out.Provisioners = *(*[]BuildProvisionerStatus)(unsafe.Pointer(&in.Provisioners))
+	out.Health = (*HealthStatus)(unsafe.Pointer(in.Health))
+	out.Progress = (*BuildProgress)(unsafe.Pointer(in.Progress))
+	return nil
+}
+
+// Convert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus is an autogenerated conversion function.
+func Convert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus(in *v1beta1.BuildStatus, out *BuildStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildStatus_To_v1alpha1_BuildStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildTemplateReference_To_v1beta1_BuildTemplateReference(in *BuildTemplateReference, out *v1beta1.BuildTemplateReference, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Namespace = in.Namespace
+	return nil
+}
+
+// Convert_v1alpha1_BuildTemplateReference_To_v1beta1_BuildTemplateReference is an autogenerated conversion function.
+func Convert_v1alpha1_BuildTemplateReference_To_v1beta1_BuildTemplateReference(in *BuildTemplateReference, out *v1beta1.BuildTemplateReference, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildTemplateReference_To_v1beta1_BuildTemplateReference(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildTemplateReference_To_v1alpha1_BuildTemplateReference(in *v1beta1.BuildTemplateReference, out *BuildTemplateReference, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Namespace = in.Namespace
+	return nil
+}
+
+// Convert_v1beta1_BuildTemplateReference_To_v1alpha1_BuildTemplateReference is an autogenerated conversion function.
+func Convert_v1beta1_BuildTemplateReference_To_v1alpha1_BuildTemplateReference(in *v1beta1.BuildTemplateReference, out *BuildTemplateReference, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildTemplateReference_To_v1alpha1_BuildTemplateReference(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildTimeouts_To_v1beta1_BuildTimeouts(in *BuildTimeouts, out *v1beta1.BuildTimeouts, s conversion.Scope) error {
+	out.MachineReadyTimeout = (*metav1.Duration)(unsafe.Pointer(in.MachineReadyTimeout))
+	out.ConnectionTimeout = (*metav1.Duration)(unsafe.Pointer(in.ConnectionTimeout))
+	out.ProvisioningTimeout = (*metav1.Duration)(unsafe.Pointer(in.ProvisioningTimeout))
+	out.OverallDeadline = (*metav1.Duration)(unsafe.Pointer(in.OverallDeadline))
+	return nil
+}
+
+// Convert_v1alpha1_BuildTimeouts_To_v1beta1_BuildTimeouts is an autogenerated conversion function.
+func Convert_v1alpha1_BuildTimeouts_To_v1beta1_BuildTimeouts(in *BuildTimeouts, out *v1beta1.BuildTimeouts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildTimeouts_To_v1beta1_BuildTimeouts(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildTimeouts_To_v1alpha1_BuildTimeouts(in *v1beta1.BuildTimeouts, out *BuildTimeouts, s conversion.Scope) error {
+	out.MachineReadyTimeout = (*metav1.Duration)(unsafe.Pointer(in.MachineReadyTimeout))
+	out.ConnectionTimeout = (*metav1.Duration)(unsafe.Pointer(in.ConnectionTimeout))
+	out.ProvisioningTimeout = (*metav1.Duration)(unsafe.Pointer(in.ProvisioningTimeout))
+	out.OverallDeadline = (*metav1.Duration)(unsafe.Pointer(in.OverallDeadline))
+	return nil
+}
+
+// Convert_v1beta1_BuildTimeouts_To_v1alpha1_BuildTimeouts is an autogenerated conversion function.
+func Convert_v1beta1_BuildTimeouts_To_v1alpha1_BuildTimeouts(in *v1beta1.BuildTimeouts, out *BuildTimeouts, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildTimeouts_To_v1alpha1_BuildTimeouts(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildVariable_To_v1beta1_BuildVariable(in *BuildVariable, out *v1beta1.BuildVariable, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Value = in.Value
+	out.ValueFrom = (*v1beta1.BuildVariableSource)(unsafe.Pointer(in.ValueFrom))
+	return nil
+}
+
+// Convert_v1alpha1_BuildVariable_To_v1beta1_BuildVariable is an autogenerated conversion function.
+func Convert_v1alpha1_BuildVariable_To_v1beta1_BuildVariable(in *BuildVariable, out *v1beta1.BuildVariable, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildVariable_To_v1beta1_BuildVariable(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildVariable_To_v1alpha1_BuildVariable(in *v1beta1.BuildVariable, out *BuildVariable, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Value = in.Value
+	out.ValueFrom = (*BuildVariableSource)(unsafe.Pointer(in.ValueFrom))
+	return nil
+}
+
+// Convert_v1beta1_BuildVariable_To_v1alpha1_BuildVariable is an autogenerated conversion function.
+func Convert_v1beta1_BuildVariable_To_v1alpha1_BuildVariable(in *v1beta1.BuildVariable, out *BuildVariable, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildVariable_To_v1alpha1_BuildVariable(in, out, s)
+}
+
+func autoConvert_v1alpha1_BuildVariableSource_To_v1beta1_BuildVariableSource(in *BuildVariableSource, out *v1beta1.BuildVariableSource, s conversion.Scope) error {
+	out.SecretKeyRef = (*v1.SecretKeySelector)(unsafe.Pointer(in.SecretKeyRef))
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	return nil
+}
+
+// Convert_v1alpha1_BuildVariableSource_To_v1beta1_BuildVariableSource is an autogenerated conversion function.
+func Convert_v1alpha1_BuildVariableSource_To_v1beta1_BuildVariableSource(in *BuildVariableSource, out *v1beta1.BuildVariableSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BuildVariableSource_To_v1beta1_BuildVariableSource(in, out, s)
+}
+
+func autoConvert_v1beta1_BuildVariableSource_To_v1alpha1_BuildVariableSource(in *v1beta1.BuildVariableSource, out *BuildVariableSource, s conversion.Scope) error {
+	out.SecretKeyRef = (*v1.SecretKeySelector)(unsafe.Pointer(in.SecretKeyRef))
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	return nil
+}
+
+// Convert_v1beta1_BuildVariableSource_To_v1alpha1_BuildVariableSource is an autogenerated conversion function.
+func Convert_v1beta1_BuildVariableSource_To_v1alpha1_BuildVariableSource(in *v1beta1.BuildVariableSource, out *BuildVariableSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_BuildVariableSource_To_v1alpha1_BuildVariableSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_CacheStatus_To_v1beta1_CacheStatus(in *CacheStatus, out *v1beta1.CacheStatus, s conversion.Scope) error {
+	out.SourceImageRef = in.SourceImageRef
+	out.SourceBuild = in.SourceBuild
+	out.Layers = *(*[]v1beta1.ProvisionerLayer)(unsafe.Pointer(&in.Layers))
+	out.Hits = in.Hits
+	return nil
+}
+
+// Convert_v1alpha1_CacheStatus_To_v1beta1_CacheStatus is an autogenerated conversion function.
+func Convert_v1alpha1_CacheStatus_To_v1beta1_CacheStatus(in *CacheStatus, out *v1beta1.CacheStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CacheStatus_To_v1beta1_CacheStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_CacheStatus_To_v1alpha1_CacheStatus(in *v1beta1.CacheStatus, out *CacheStatus, s conversion.Scope) error {
+	out.SourceImageRef = in.SourceImageRef
+	out.SourceBuild = in.SourceBuild
+	out.Layers = *(*[]ProvisionerLayer)(unsafe.Pointer(&in.Layers))
+	out.Hits = in.Hits
+	return nil
+}
+
+// Convert_v1beta1_CacheStatus_To_v1alpha1_CacheStatus is an autogenerated conversion function.
+func Convert_v1beta1_CacheStatus_To_v1alpha1_CacheStatus(in *v1beta1.CacheStatus, out *CacheStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_CacheStatus_To_v1alpha1_CacheStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ChefSoloSpec_To_v1beta1_ChefSoloSpec(in *ChefSoloSpec, out *v1beta1.ChefSoloSpec, s conversion.Scope) error {
+	if err := Convert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(&in.Source, &out.Source, s); err != nil {
+		return err
+	}
+	out.CookbookPaths = *(*[]string)(unsafe.Pointer(&in.CookbookPaths))
+	out.RolesPath = in.RolesPath
+	out.DataBagsPath = in.DataBagsPath
+	out.RunList = *(*[]string)(unsafe.Pointer(&in.RunList))
+	out.Attributes = in.Attributes
+	out.InstallCommand = in.InstallCommand
+	return nil
+}
+
+// Convert_v1alpha1_ChefSoloSpec_To_v1beta1_ChefSoloSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ChefSoloSpec_To_v1beta1_ChefSoloSpec(in *ChefSoloSpec, out *v1beta1.ChefSoloSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ChefSoloSpec_To_v1beta1_ChefSoloSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ChefSoloSpec_To_v1alpha1_ChefSoloSpec(in *v1beta1.ChefSoloSpec, out *ChefSoloSpec, s conversion.Scope) error {
+	if err := Convert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(&in.Source, &out.Source, s); err != nil {
+		return err
+	}
+	out.CookbookPaths = *(*[]string)(unsafe.Pointer(&in.CookbookPaths))
+	out.RolesPath = in.RolesPath
+	out.DataBagsPath = in.DataBagsPath
+	out.RunList = *(*[]string)(unsafe.Pointer(&in.RunList))
+	out.Attributes = in.Attributes
+	out.InstallCommand = in.InstallCommand
+	return nil
+}
+
+// Convert_v1beta1_ChefSoloSpec_To_v1alpha1_ChefSoloSpec is an autogenerated conversion function.
+func Convert_v1beta1_ChefSoloSpec_To_v1alpha1_ChefSoloSpec(in *v1beta1.ChefSoloSpec, out *ChefSoloSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ChefSoloSpec_To_v1alpha1_ChefSoloSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_CommandAssertion_To_v1beta1_CommandAssertion(in *CommandAssertion, out *v1beta1.CommandAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Command = in.Command
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.Contains = in.Contains
+	out.Matches = in.Matches
+	return nil
+}
+
+// Convert_v1alpha1_CommandAssertion_To_v1beta1_CommandAssertion is an autogenerated conversion function.
+func Convert_v1alpha1_CommandAssertion_To_v1beta1_CommandAssertion(in *CommandAssertion, out *v1beta1.CommandAssertion, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CommandAssertion_To_v1beta1_CommandAssertion(in, out, s)
+}
+
+func autoConvert_v1beta1_CommandAssertion_To_v1alpha1_CommandAssertion(in *v1beta1.CommandAssertion, out *CommandAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Command = in.Command
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.Contains = in.Contains
+	out.Matches = in.Matches
+	return nil
+}
+
+// Convert_v1beta1_CommandAssertion_To_v1alpha1_CommandAssertion is an autogenerated conversion function.
+func Convert_v1beta1_CommandAssertion_To_v1alpha1_CommandAssertion(in *v1beta1.CommandAssertion, out *CommandAssertion, s conversion.Scope) error {
+	return autoConvert_v1beta1_CommandAssertion_To_v1alpha1_CommandAssertion(in, out, s)
+}
+
+func autoConvert_v1alpha1_ComplianceRuleStatus_To_v1beta1_ComplianceRuleStatus(in *ComplianceRuleStatus, out *v1beta1.ComplianceRuleStatus, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Title = in.Title
+	out.Compliant = in.Compliant
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_ComplianceRuleStatus_To_v1beta1_ComplianceRuleStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ComplianceRuleStatus_To_v1beta1_ComplianceRuleStatus(in *ComplianceRuleStatus, out *v1beta1.ComplianceRuleStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ComplianceRuleStatus_To_v1beta1_ComplianceRuleStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ComplianceRuleStatus_To_v1alpha1_ComplianceRuleStatus(in *v1beta1.ComplianceRuleStatus, out *ComplianceRuleStatus, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Title = in.Title
+	out.Compliant = in.Compliant
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_ComplianceRuleStatus_To_v1alpha1_ComplianceRuleStatus is an autogenerated conversion function.
+func Convert_v1beta1_ComplianceRuleStatus_To_v1alpha1_ComplianceRuleStatus(in *v1beta1.ComplianceRuleStatus, out *ComplianceRuleStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ComplianceRuleStatus_To_v1alpha1_ComplianceRuleStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ComplianceSummary_To_v1beta1_ComplianceSummary(in *ComplianceSummary, out *v1beta1.ComplianceSummary, s conversion.Scope) error {
+	out.Benchmark = in.Benchmark
+	out.Compliant = in.Compliant
+	out.NonCompliant = in.NonCompliant
+	out.Excluded = in.Excluded
+	out.Rules = *(*[]v1beta1.ComplianceRuleStatus)(unsafe.Pointer(&in.Rules))
+	return nil
+}
+
+// Convert_v1alpha1_ComplianceSummary_To_v1beta1_ComplianceSummary is an autogenerated conversion function.
+func Convert_v1alpha1_ComplianceSummary_To_v1beta1_ComplianceSummary(in *ComplianceSummary, out *v1beta1.ComplianceSummary, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ComplianceSummary_To_v1beta1_ComplianceSummary(in, out, s)
+}
+
+func autoConvert_v1beta1_ComplianceSummary_To_v1alpha1_ComplianceSummary(in *v1beta1.ComplianceSummary, out *ComplianceSummary, s conversion.Scope) error {
+	out.Benchmark = in.Benchmark
+	out.Compliant = in.Compliant
+	out.NonCompliant = in.NonCompliant
+	out.Excluded = in.Excluded
+	out.Rules = *(*[]ComplianceRuleStatus)(unsafe.Pointer(&in.Rules))
+	return nil
+}
+
+// Convert_v1beta1_ComplianceSummary_To_v1alpha1_ComplianceSummary is an autogenerated conversion function.
+func Convert_v1beta1_ComplianceSummary_To_v1alpha1_ComplianceSummary(in *v1beta1.ComplianceSummary, out *ComplianceSummary, s conversion.Scope) error {
+	return autoConvert_v1beta1_ComplianceSummary_To_v1alpha1_ComplianceSummary(in, out, s)
+}
+
+func autoConvert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(in *ConfigManagementSource, out *v1beta1.ConfigManagementSource, s conversion.Scope) error {
+	out.ConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ConfigMapRef))
+	out.Items = *(*[]v1.KeyToPath)(unsafe.Pointer(&in.Items))
+	out.Git = (*v1beta1.AnsibleGitSource)(unsafe.Pointer(in.Git))
+	return nil
+}
+
+// Convert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource is an autogenerated conversion function.
+func Convert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(in *ConfigManagementSource, out *v1beta1.ConfigManagementSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(in, out, s)
+}
+
+func autoConvert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(in *v1beta1.ConfigManagementSource, out *ConfigManagementSource, s conversion.Scope) error {
+	out.ConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ConfigMapRef))
+	out.Items = *(*[]v1.KeyToPath)(unsafe.Pointer(&in.Items))
+	out.Git = (*AnsibleGitSource)(unsafe.Pointer(in.Git))
+	return nil
+}
+
+// Convert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource is an autogenerated conversion function.
+func Convert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(in *v1beta1.ConfigManagementSource, out *ConfigManagementSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_ConnectionStatus_To_v1beta1_ConnectionStatus(in *ConnectionStatus, out *v1beta1.ConnectionStatus, s conversion.Scope) error {
+	out.Address = in.Address
+	out.AuthMethod = in.AuthMethod
+	out.HostKeyType = in.HostKeyType
+	out.HostKeyFingerprint = in.HostKeyFingerprint
+	out.ServerVersion = in.ServerVersion
+	return nil
+}
+
+// Convert_v1alpha1_ConnectionStatus_To_v1beta1_ConnectionStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ConnectionStatus_To_v1beta1_ConnectionStatus(in *ConnectionStatus, out *v1beta1.ConnectionStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ConnectionStatus_To_v1beta1_ConnectionStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ConnectionStatus_To_v1alpha1_ConnectionStatus(in *v1beta1.ConnectionStatus, out *ConnectionStatus, s conversion.Scope) error {
+	out.Address = in.Address
+	out.AuthMethod = in.AuthMethod
+	out.HostKeyType = in.HostKeyType
+	out.HostKeyFingerprint = in.HostKeyFingerprint
+	out.ServerVersion = in.ServerVersion
+	return nil
+}
+
+// Convert_v1beta1_ConnectionStatus_To_v1alpha1_ConnectionStatus is an autogenerated conversion function.
+func Convert_v1beta1_ConnectionStatus_To_v1alpha1_ConnectionStatus(in *v1beta1.ConnectionStatus, out *ConnectionStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ConnectionStatus_To_v1alpha1_ConnectionStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ConnectorSpec_To_v1beta1_ConnectorSpec(in *ConnectorSpec, out *v1beta1.ConnectorSpec, s conversion.Scope) error {
+	out.Type = v1beta1.ConnectorType(in.Type)
+	// WARNING: in.Port requires manual conversion: does not exist in peer-type
+	// WARNING: in.Username requires manual conversion: does not exist in peer-type
+	// WARNING: in.Bastion requires manual conversion: does not exist in peer-type
+	// WARNING: in.HostKeyPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.Sudo requires manual conversion: does not exist in peer-type
+	// WARNING: in.Timeout requires manual conversion: does not exist in peer-type
+	out.Credentials = (*v1.LocalObjectReference)(unsafe.Pointer(in.Credentials))
+	out.CredentialsProviderRef = (*v1beta1.CredentialsProviderReference)(unsafe.Pointer(in.CredentialsProviderRef))
+	out.EphemeralCredentials = in.EphemeralCredentials
+	return nil
+}
+
+func autoConvert_v1beta1_ConnectorSpec_To_v1alpha1_ConnectorSpec(in *v1beta1.ConnectorSpec, out *ConnectorSpec, s conversion.Scope) error {
+	out.Type = string(in.Type)
+	// WARNING: in.SSH requires manual conversion: does not exist in peer-type
+	out.Credentials = (*v1.LocalObjectReference)(unsafe.Pointer(in.Credentials))
+	out.CredentialsProviderRef = (*CredentialsProviderReference)(unsafe.Pointer(in.CredentialsProviderRef))
+	out.EphemeralCredentials = in.EphemeralCredentials
+	return nil
+}
+
+func autoConvert_v1alpha1_CredentialsProviderReference_To_v1beta1_CredentialsProviderReference(in *CredentialsProviderReference, out *v1beta1.CredentialsProviderReference, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Path = in.Path
+	out.Version = in.Version
+	out.Key = in.Key
+	out.Keys = *(*map[string]string)(unsafe.Pointer(&in.Keys))
+	return nil
+}
+
+// Convert_v1alpha1_CredentialsProviderReference_To_v1beta1_CredentialsProviderReference is an autogenerated conversion function.
+func Convert_v1alpha1_CredentialsProviderReference_To_v1beta1_CredentialsProviderReference(in *CredentialsProviderReference, out *v1beta1.CredentialsProviderReference, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CredentialsProviderReference_To_v1beta1_CredentialsProviderReference(in, out, s)
+}
+
+func autoConvert_v1beta1_CredentialsProviderReference_To_v1alpha1_CredentialsProviderReference(in *v1beta1.CredentialsProviderReference, out *CredentialsProviderReference, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Path = in.Path
+	out.Version = in.Version
+	out.Key = in.Key
+	out.Keys = *(*map[string]string)(unsafe.Pointer(&in.Keys))
+	return nil
+}
+
+// Convert_v1beta1_CredentialsProviderReference_To_v1alpha1_CredentialsProviderReference is an autogenerated conversion function.
+func Convert_v1beta1_CredentialsProviderReference_To_v1alpha1_CredentialsProviderReference(in *v1beta1.CredentialsProviderReference, out *CredentialsProviderReference, s conversion.Scope) error {
+	return autoConvert_v1beta1_CredentialsProviderReference_To_v1alpha1_CredentialsProviderReference(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination(in *ExportDestination, out *v1beta1.ExportDestination, s conversion.Scope) error {
+	out.BoxRegistry = (*v1beta1.BoxRegistryDestination)(unsafe.Pointer(in.BoxRegistry))
+	out.ObjectStorage = (*v1beta1.ObjectStorageDestination)(unsafe.Pointer(in.ObjectStorage))
+	out.AzureBlob = (*v1beta1.AzureBlobDestination)(unsafe.Pointer(in.AzureBlob))
+	out.HTTP = (*v1beta1.HTTPDestination)(unsafe.Pointer(in.HTTP))
+	out.OCIRegistry = (*v1beta1.OCIRegistryDestination)(unsafe.Pointer(in.OCIRegistry))
+	return nil
+}
+
+// Convert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination is an autogenerated conversion function.
+func Convert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination(in *ExportDestination, out *v1beta1.ExportDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination(in *v1beta1.ExportDestination, out *ExportDestination, s conversion.Scope) error {
+	out.BoxRegistry = (*BoxRegistryDestination)(unsafe.Pointer(in.BoxRegistry))
+	out.ObjectStorage = (*ObjectStorageDestination)(unsafe.Pointer(in.ObjectStorage))
+	out.AzureBlob = (*AzureBlobDestination)(unsafe.Pointer(in.AzureBlob))
+	out.HTTP = (*HTTPDestination)(unsafe.Pointer(in.HTTP))
+	out.OCIRegistry = (*OCIRegistryDestination)(unsafe.Pointer(in.OCIRegistry))
+	return nil
+}
+
+// Convert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination is an autogenerated conversion function.
+func Convert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination(in *v1beta1.ExportDestination, out *ExportDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExportSpec_To_v1beta1_ExportSpec(in *ExportSpec, out *v1beta1.ExportSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Format = v1beta1.ExportFormat(in.Format)
+	out.Vagrant = (*v1beta1.VagrantExport)(unsafe.Pointer(in.Vagrant))
+	if err := Convert_v1alpha1_ExportDestination_To_v1beta1_ExportDestination(&in.Destination, &out.Destination, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_ExportSpec_To_v1beta1_ExportSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ExportSpec_To_v1beta1_ExportSpec(in *ExportSpec, out *v1beta1.ExportSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExportSpec_To_v1beta1_ExportSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ExportSpec_To_v1alpha1_ExportSpec(in *v1beta1.ExportSpec, out *ExportSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Format = ExportFormat(in.Format)
+	out.Vagrant = (*VagrantExport)(unsafe.Pointer(in.Vagrant))
+	if err := Convert_v1beta1_ExportDestination_To_v1alpha1_ExportDestination(&in.Destination, &out.Destination, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ExportSpec_To_v1alpha1_ExportSpec is an autogenerated conversion function.
+func Convert_v1beta1_ExportSpec_To_v1alpha1_ExportSpec(in *v1beta1.ExportSpec, out *ExportSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExportSpec_To_v1alpha1_ExportSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExportStatus_To_v1beta1_ExportStatus(in *ExportStatus, out *v1beta1.ExportStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = v1beta1.ExportPhase(in.Phase)
+	out.JobName = in.JobName
+	out.URL = in.URL
+	out.Checksum = in.Checksum
+	out.SizeBytes = (*int64)(unsafe.Pointer(in.SizeBytes))
+	out.Signature = in.Signature
+	out.Attestation = in.Attestation
+	out.SBOM = in.SBOM
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.CompletionTime = (*metav1.Time)(unsafe.Pointer(in.CompletionTime))
+	return nil
+}
+
+// Convert_v1alpha1_ExportStatus_To_v1beta1_ExportStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ExportStatus_To_v1beta1_ExportStatus(in *ExportStatus, out *v1beta1.ExportStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExportStatus_To_v1beta1_ExportStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ExportStatus_To_v1alpha1_ExportStatus(in *v1beta1.ExportStatus, out *ExportStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = ExportPhase(in.Phase)
+	out.JobName = in.JobName
+	out.URL = in.URL
+	out.Checksum = in.Checksum
+	out.SizeBytes = (*int64)(unsafe.Pointer(in.SizeBytes))
+	out.Signature = in.Signature
+	out.Attestation = in.Attestation
+	out.SBOM = in.SBOM
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.CompletionTime = (*metav1.Time)(unsafe.Pointer(in.CompletionTime))
+	return nil
+}
+
+// Convert_v1beta1_ExportStatus_To_v1alpha1_ExportStatus is an autogenerated conversion function.
+func Convert_v1beta1_ExportStatus_To_v1alpha1_ExportStatus(in *v1beta1.ExportStatus, out *ExportStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExportStatus_To_v1alpha1_ExportStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination(in *ExtractDestination, out *v1beta1.ExtractDestination, s conversion.Scope) error {
+	out.ConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ConfigMapRef))
+	out.SecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.SecretRef))
+	out.ObjectStorage = (*v1beta1.ObjectStorageDestination)(unsafe.Pointer(in.ObjectStorage))
+	out.OCI = (*v1beta1.OCIArtifactDestination)(unsafe.Pointer(in.OCI))
+	return nil
+}
+
+// Convert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination is an autogenerated conversion function.
+func Convert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination(in *ExtractDestination, out *v1beta1.ExtractDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination(in *v1beta1.ExtractDestination, out *ExtractDestination, s conversion.Scope) error {
+	out.ConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ConfigMapRef))
+	out.SecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.SecretRef))
+	out.ObjectStorage = (*ObjectStorageDestination)(unsafe.Pointer(in.ObjectStorage))
+	out.OCI = (*OCIArtifactDestination)(unsafe.Pointer(in.OCI))
+	return nil
+}
+
+// Convert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination is an autogenerated conversion function.
+func Convert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination(in *v1beta1.ExtractDestination, out *ExtractDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExtractFile_To_v1beta1_ExtractFile(in *ExtractFile, out *v1beta1.ExtractFile, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Key = in.Key
+	out.Optional = in.Optional
+	return nil
+}
+
+// Convert_v1alpha1_ExtractFile_To_v1beta1_ExtractFile is an autogenerated conversion function.
+func Convert_v1alpha1_ExtractFile_To_v1beta1_ExtractFile(in *ExtractFile, out *v1beta1.ExtractFile, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExtractFile_To_v1beta1_ExtractFile(in, out, s)
+}
+
+func autoConvert_v1beta1_ExtractFile_To_v1alpha1_ExtractFile(in *v1beta1.ExtractFile, out *ExtractFile, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Key = in.Key
+	out.Optional = in.Optional
+	return nil
+}
+
+// Convert_v1beta1_ExtractFile_To_v1alpha1_ExtractFile is an autogenerated conversion function.
+func Convert_v1beta1_ExtractFile_To_v1alpha1_ExtractFile(in *v1beta1.ExtractFile, out *ExtractFile, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExtractFile_To_v1alpha1_ExtractFile(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExtractProvisionerSpec_To_v1beta1_ExtractProvisionerSpec(in *ExtractProvisionerSpec, out *v1beta1.ExtractProvisionerSpec, s conversion.Scope) error {
+	out.Files = *(*[]v1beta1.ExtractFile)(unsafe.Pointer(&in.Files))
+	if err := Convert_v1alpha1_ExtractDestination_To_v1beta1_ExtractDestination(&in.Destination, &out.Destination, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_ExtractProvisionerSpec_To_v1beta1_ExtractProvisionerSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ExtractProvisionerSpec_To_v1beta1_ExtractProvisionerSpec(in *ExtractProvisionerSpec, out *v1beta1.ExtractProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExtractProvisionerSpec_To_v1beta1_ExtractProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ExtractProvisionerSpec_To_v1alpha1_ExtractProvisionerSpec(in *v1beta1.ExtractProvisionerSpec, out *ExtractProvisionerSpec, s conversion.Scope) error {
+	out.Files = *(*[]ExtractFile)(unsafe.Pointer(&in.Files))
+	if err := Convert_v1beta1_ExtractDestination_To_v1alpha1_ExtractDestination(&in.Destination, &out.Destination, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ExtractProvisionerSpec_To_v1alpha1_ExtractProvisionerSpec is an autogenerated conversion function.
+func Convert_v1beta1_ExtractProvisionerSpec_To_v1alpha1_ExtractProvisionerSpec(in *v1beta1.ExtractProvisionerSpec, out *ExtractProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExtractProvisionerSpec_To_v1alpha1_ExtractProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ExtractedFileStatus_To_v1beta1_ExtractedFileStatus(in *ExtractedFileStatus, out *v1beta1.ExtractedFileStatus, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Key = in.Key
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	out.Location = in.Location
+	out.Missing = in.Missing
+	return nil
+}
+
+// Convert_v1alpha1_ExtractedFileStatus_To_v1beta1_ExtractedFileStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ExtractedFileStatus_To_v1beta1_ExtractedFileStatus(in *ExtractedFileStatus, out *v1beta1.ExtractedFileStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ExtractedFileStatus_To_v1beta1_ExtractedFileStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ExtractedFileStatus_To_v1alpha1_ExtractedFileStatus(in *v1beta1.ExtractedFileStatus, out *ExtractedFileStatus, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Key = in.Key
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	out.Location = in.Location
+	out.Missing = in.Missing
+	return nil
+}
+
+// Convert_v1beta1_ExtractedFileStatus_To_v1alpha1_ExtractedFileStatus is an autogenerated conversion function.
+func Convert_v1beta1_ExtractedFileStatus_To_v1alpha1_ExtractedFileStatus(in *v1beta1.ExtractedFileStatus, out *ExtractedFileStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ExtractedFileStatus_To_v1alpha1_ExtractedFileStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_FailureDomainSpec_To_v1beta1_FailureDomainSpec(in *FailureDomainSpec, out *v1beta1.FailureDomainSpec, s conversion.Scope) error {
+	out.Infrastructure = in.Infrastructure
+	out.Attributes = *(*map[string]string)(unsafe.Pointer(&in.Attributes))
+	return nil
+}
+
+// Convert_v1alpha1_FailureDomainSpec_To_v1beta1_FailureDomainSpec is an autogenerated conversion function.
+func Convert_v1alpha1_FailureDomainSpec_To_v1beta1_FailureDomainSpec(in *FailureDomainSpec, out *v1beta1.FailureDomainSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FailureDomainSpec_To_v1beta1_FailureDomainSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_FailureDomainSpec_To_v1alpha1_FailureDomainSpec(in *v1beta1.FailureDomainSpec, out *FailureDomainSpec, s conversion.Scope) error {
+	out.Infrastructure = in.Infrastructure
+	out.Attributes = *(*map[string]string)(unsafe.Pointer(&in.Attributes))
+	return nil
+}
+
+// Convert_v1beta1_FailureDomainSpec_To_v1alpha1_FailureDomainSpec is an autogenerated conversion function.
+func Convert_v1beta1_FailureDomainSpec_To_v1alpha1_FailureDomainSpec(in *v1beta1.FailureDomainSpec, out *FailureDomainSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_FailureDomainSpec_To_v1alpha1_FailureDomainSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileAssertion_To_v1beta1_FileAssertion(in *FileAssertion, out *v1beta1.FileAssertion, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Absent = in.Absent
+	out.SHA256 = in.SHA256
+	out.Contains = in.Contains
+	return nil
+}
+
+// Convert_v1alpha1_FileAssertion_To_v1beta1_FileAssertion is an autogenerated conversion function.
+func Convert_v1alpha1_FileAssertion_To_v1beta1_FileAssertion(in *FileAssertion, out *v1beta1.FileAssertion, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileAssertion_To_v1beta1_FileAssertion(in, out, s)
+}
+
+func autoConvert_v1beta1_FileAssertion_To_v1alpha1_FileAssertion(in *v1beta1.FileAssertion, out *FileAssertion, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Absent = in.Absent
+	out.SHA256 = in.SHA256
+	out.Contains = in.Contains
+	return nil
+}
+
+// Convert_v1beta1_FileAssertion_To_v1alpha1_FileAssertion is an autogenerated conversion function.
+func Convert_v1beta1_FileAssertion_To_v1alpha1_FileAssertion(in *v1beta1.FileAssertion, out *FileAssertion, s conversion.Scope) error {
+	return autoConvert_v1beta1_FileAssertion_To_v1alpha1_FileAssertion(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileProvisionerSpec_To_v1beta1_FileProvisionerSpec(in *FileProvisionerSpec, out *v1beta1.FileProvisionerSpec, s conversion.Scope) error {
+	out.Files = *(*[]v1beta1.FileUpload)(unsafe.Pointer(&in.Files))
+	return nil
+}
+
+// Convert_v1alpha1_FileProvisionerSpec_To_v1beta1_FileProvisionerSpec is an autogenerated conversion function.
+func Convert_v1alpha1_FileProvisionerSpec_To_v1beta1_FileProvisionerSpec(in *FileProvisionerSpec, out *v1beta1.FileProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileProvisionerSpec_To_v1beta1_FileProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_FileProvisionerSpec_To_v1alpha1_FileProvisionerSpec(in *v1beta1.FileProvisionerSpec, out *FileProvisionerSpec, s conversion.Scope) error {
+	out.Files = *(*[]FileUpload)(unsafe.Pointer(&in.Files))
+	return nil
+}
+
+// Convert_v1beta1_FileProvisionerSpec_To_v1alpha1_FileProvisionerSpec is an autogenerated conversion function.
+func Convert_v1beta1_FileProvisionerSpec_To_v1alpha1_FileProvisionerSpec(in *v1beta1.FileProvisionerSpec, out *FileProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_FileProvisionerSpec_To_v1alpha1_FileProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileUpload_To_v1beta1_FileUpload(in *FileUpload, out *v1beta1.FileUpload, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.Mode = (*int32)(unsafe.Pointer(in.Mode))
+	out.Owner = in.Owner
+	out.SHA256 = in.SHA256
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	out.SecretKeyRef = (*v1.SecretKeySelector)(unsafe.Pointer(in.SecretKeyRef))
+	out.URL = in.URL
+	out.OCI = (*v1beta1.OCIArtifactSource)(unsafe.Pointer(in.OCI))
+	return nil
+}
+
+// Convert_v1alpha1_FileUpload_To_v1beta1_FileUpload is an autogenerated conversion function.
+func Convert_v1alpha1_FileUpload_To_v1beta1_FileUpload(in *FileUpload, out *v1beta1.FileUpload, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileUpload_To_v1beta1_FileUpload(in, out, s)
+}
+
+func autoConvert_v1beta1_FileUpload_To_v1alpha1_FileUpload(in *v1beta1.FileUpload, out *FileUpload, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.Mode = (*int32)(unsafe.Pointer(in.Mode))
+	out.Owner = in.Owner
+	out.SHA256 = in.SHA256
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	out.SecretKeyRef = (*v1.SecretKeySelector)(unsafe.Pointer(in.SecretKeyRef))
+	out.URL = in.URL
+	out.OCI = (*OCIArtifactSource)(unsafe.Pointer(in.OCI))
+	return nil
+}
+
+// Convert_v1beta1_FileUpload_To_v1alpha1_FileUpload is an autogenerated conversion function.
+func Convert_v1beta1_FileUpload_To_v1alpha1_FileUpload(in *v1beta1.FileUpload, out *FileUpload, s conversion.Scope) error {
+	return autoConvert_v1beta1_FileUpload_To_v1alpha1_FileUpload(in, out, s)
+}
+
+func autoConvert_v1alpha1_HTTPDestination_To_v1beta1_HTTPDestination(in *HTTPDestination, out *v1beta1.HTTPDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CredentialsRef))
+	return nil
+}
+
+// Convert_v1alpha1_HTTPDestination_To_v1beta1_HTTPDestination is an autogenerated conversion function.
+func Convert_v1alpha1_HTTPDestination_To_v1beta1_HTTPDestination(in *HTTPDestination, out *v1beta1.HTTPDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_HTTPDestination_To_v1beta1_HTTPDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_HTTPDestination_To_v1alpha1_HTTPDestination(in *v1beta1.HTTPDestination, out *HTTPDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.CredentialsRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CredentialsRef))
+	return nil
+}
+
+// Convert_v1beta1_HTTPDestination_To_v1alpha1_HTTPDestination is an autogenerated conversion function.
+func Convert_v1beta1_HTTPDestination_To_v1alpha1_HTTPDestination(in *v1beta1.HTTPDestination, out *HTTPDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_HTTPDestination_To_v1alpha1_HTTPDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_HardenSpec_To_v1beta1_HardenSpec(in *HardenSpec, out *v1beta1.HardenSpec, s conversion.Scope) error {
+	out.Profile = v1beta1.HardenProfile(in.Profile)
+	out.Platform = v1beta1.HardenPlatform(in.Platform)
+	out.Exclude = *(*[]string)(unsafe.Pointer(&in.Exclude))
+	return nil
+}
+
+// Convert_v1alpha1_HardenSpec_To_v1beta1_HardenSpec is an autogenerated conversion function.
+func Convert_v1alpha1_HardenSpec_To_v1beta1_HardenSpec(in *HardenSpec, out *v1beta1.HardenSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_HardenSpec_To_v1beta1_HardenSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_HardenSpec_To_v1alpha1_HardenSpec(in *v1beta1.HardenSpec, out *HardenSpec, s conversion.Scope) error {
+	out.Profile = HardenProfile(in.Profile)
+	out.Platform = HardenPlatform(in.Platform)
+	out.Exclude = *(*[]string)(unsafe.Pointer(&in.Exclude))
+	return nil
+}
+
+// Convert_v1beta1_HardenSpec_To_v1alpha1_HardenSpec is an autogenerated conversion function.
+func Convert_v1beta1_HardenSpec_To_v1alpha1_HardenSpec(in *v1beta1.HardenSpec, out *HardenSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_HardenSpec_To_v1alpha1_HardenSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_HealthStatus_To_v1beta1_HealthStatus(in *HealthStatus, out *v1beta1.HealthStatus, s conversion.Scope) error {
+	out.Status = v1beta1.Health(in.Status)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_HealthStatus_To_v1beta1_HealthStatus is an autogenerated conversion function.
+func Convert_v1alpha1_HealthStatus_To_v1beta1_HealthStatus(in *HealthStatus, out *v1beta1.HealthStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_HealthStatus_To_v1beta1_HealthStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_HealthStatus_To_v1alpha1_HealthStatus(in *v1beta1.HealthStatus, out *HealthStatus, s conversion.Scope) error {
+	out.Status = Health(in.Status)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_HealthStatus_To_v1alpha1_HealthStatus is an autogenerated conversion function.
+func Convert_v1beta1_HealthStatus_To_v1alpha1_HealthStatus(in *v1beta1.HealthStatus, out *HealthStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_HealthStatus_To_v1alpha1_HealthStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ImageMetadataMapping_To_v1beta1_ImageMetadataMapping(in *ImageMetadataMapping, out *v1beta1.ImageMetadataMapping, s conversion.Scope) error {
+	out.Key = in.Key
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1alpha1_ImageMetadataMapping_To_v1beta1_ImageMetadataMapping is an autogenerated conversion function.
+func Convert_v1alpha1_ImageMetadataMapping_To_v1beta1_ImageMetadataMapping(in *ImageMetadataMapping, out *v1beta1.ImageMetadataMapping, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ImageMetadataMapping_To_v1beta1_ImageMetadataMapping(in, out, s)
+}
+
+func autoConvert_v1beta1_ImageMetadataMapping_To_v1alpha1_ImageMetadataMapping(in *v1beta1.ImageMetadataMapping, out *ImageMetadataMapping, s conversion.Scope) error {
+	out.Key = in.Key
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1beta1_ImageMetadataMapping_To_v1alpha1_ImageMetadataMapping is an autogenerated conversion function.
+func Convert_v1beta1_ImageMetadataMapping_To_v1alpha1_ImageMetadataMapping(in *v1beta1.ImageMetadataMapping, out *ImageMetadataMapping, s conversion.Scope) error {
+	return autoConvert_v1beta1_ImageMetadataMapping_To_v1alpha1_ImageMetadataMapping(in, out, s)
+}
+
+func autoConvert_v1alpha1_ImageMetadataSpec_To_v1beta1_ImageMetadataSpec(in *ImageMetadataSpec, out *v1beta1.ImageMetadataSpec, s conversion.Scope) error {
+	out.Labels = *(*[]v1beta1.ImageMetadataMapping)(unsafe.Pointer(&in.Labels))
+	out.Annotations = *(*[]v1beta1.ImageMetadataMapping)(unsafe.Pointer(&in.Annotations))
+	return nil
+}
+
+// Convert_v1alpha1_ImageMetadataSpec_To_v1beta1_ImageMetadataSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ImageMetadataSpec_To_v1beta1_ImageMetadataSpec(in *ImageMetadataSpec, out *v1beta1.ImageMetadataSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ImageMetadataSpec_To_v1beta1_ImageMetadataSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ImageMetadataSpec_To_v1alpha1_ImageMetadataSpec(in *v1beta1.ImageMetadataSpec, out *ImageMetadataSpec, s conversion.Scope) error {
+	out.Labels = *(*[]ImageMetadataMapping)(unsafe.Pointer(&in.Labels))
+	out.Annotations = *(*[]ImageMetadataMapping)(unsafe.Pointer(&in.Annotations))
+	return nil
+}
+
+// Convert_v1beta1_ImageMetadataSpec_To_v1alpha1_ImageMetadataSpec is an autogenerated conversion function.
+func Convert_v1beta1_ImageMetadataSpec_To_v1alpha1_ImageMetadataSpec(in *v1beta1.ImageMetadataSpec, out *ImageMetadataSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ImageMetadataSpec_To_v1alpha1_ImageMetadataSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_KeylessSigning_To_v1beta1_KeylessSigning(in *KeylessSigning, out *v1beta1.KeylessSigning, s conversion.Scope) error {
+	out.FulcioURL = in.FulcioURL
+	out.Audience = in.Audience
+	return nil
+}
+
+// Convert_v1alpha1_KeylessSigning_To_v1beta1_KeylessSigning is an autogenerated conversion function.
+func Convert_v1alpha1_KeylessSigning_To_v1beta1_KeylessSigning(in *KeylessSigning, out *v1beta1.KeylessSigning, s conversion.Scope) error {
+	return autoConvert_v1alpha1_KeylessSigning_To_v1beta1_KeylessSigning(in, out, s)
+}
+
+func autoConvert_v1beta1_KeylessSigning_To_v1alpha1_KeylessSigning(in *v1beta1.KeylessSigning, out *KeylessSigning, s conversion.Scope) error {
+	out.FulcioURL = in.FulcioURL
+	out.Audience = in.Audience
+	return nil
+}
+
+// Convert_v1beta1_KeylessSigning_To_v1alpha1_KeylessSigning is an autogenerated conversion function.
+func Convert_v1beta1_KeylessSigning_To_v1alpha1_KeylessSigning(in *v1beta1.KeylessSigning, out *KeylessSigning, s conversion.Scope) error {
+	return autoConvert_v1beta1_KeylessSigning_To_v1alpha1_KeylessSigning(in, out, s)
+}
+
+func autoConvert_v1alpha1_KubeconfigSource_To_v1beta1_KubeconfigSource(in *KubeconfigSource, out *v1beta1.KubeconfigSource, s conversion.Scope) error {
+	out.SecretRef = in.SecretRef
+	out.Key = in.Key
+	out.ExpirationTime = in.ExpirationTime
+	return nil
+}
+
+// Convert_v1alpha1_KubeconfigSource_To_v1beta1_KubeconfigSource is an autogenerated conversion function.
+func Convert_v1alpha1_KubeconfigSource_To_v1beta1_KubeconfigSource(in *KubeconfigSource, out *v1beta1.KubeconfigSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_KubeconfigSource_To_v1beta1_KubeconfigSource(in, out, s)
+}
+
+func autoConvert_v1beta1_KubeconfigSource_To_v1alpha1_KubeconfigSource(in *v1beta1.KubeconfigSource, out *KubeconfigSource, s conversion.Scope) error {
+	out.SecretRef = in.SecretRef
+	out.Key = in.Key
+	out.ExpirationTime = in.ExpirationTime
+	return nil
+}
+
+// Convert_v1beta1_KubeconfigSource_To_v1alpha1_KubeconfigSource is an autogenerated conversion function.
+func Convert_v1beta1_KubeconfigSource_To_v1alpha1_KubeconfigSource(in *v1beta1.KubeconfigSource, out *KubeconfigSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_KubeconfigSource_To_v1alpha1_KubeconfigSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_OCIArtifactDestination_To_v1beta1_OCIArtifactDestination(in *OCIArtifactDestination, out *v1beta1.OCIArtifactDestination, s conversion.Scope) error {
+	out.Reference = in.Reference
+	out.PushSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PushSecretRef))
+	return nil
+}
+
+// Convert_v1alpha1_OCIArtifactDestination_To_v1beta1_OCIArtifactDestination is an autogenerated conversion function.
+func Convert_v1alpha1_OCIArtifactDestination_To_v1beta1_OCIArtifactDestination(in *OCIArtifactDestination, out *v1beta1.OCIArtifactDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OCIArtifactDestination_To_v1beta1_OCIArtifactDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_OCIArtifactDestination_To_v1alpha1_OCIArtifactDestination(in *v1beta1.OCIArtifactDestination, out *OCIArtifactDestination, s conversion.Scope) error {
+	out.Reference = in.Reference
+	out.PushSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PushSecretRef))
+	return nil
+}
+
+// Convert_v1beta1_OCIArtifactDestination_To_v1alpha1_OCIArtifactDestination is an autogenerated conversion function.
+func Convert_v1beta1_OCIArtifactDestination_To_v1alpha1_OCIArtifactDestination(in *v1beta1.OCIArtifactDestination, out *OCIArtifactDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_OCIArtifactDestination_To_v1alpha1_OCIArtifactDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_OCIArtifactSource_To_v1beta1_OCIArtifactSource(in *OCIArtifactSource, out *v1beta1.OCIArtifactSource, s conversion.Scope) error {
+	out.Reference = in.Reference
+	out.File = in.File
+	out.PullSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PullSecretRef))
+	return nil
+}
+
+// Convert_v1alpha1_OCIArtifactSource_To_v1beta1_OCIArtifactSource is an autogenerated conversion function.
+func Convert_v1alpha1_OCIArtifactSource_To_v1beta1_OCIArtifactSource(in *OCIArtifactSource, out *v1beta1.OCIArtifactSource, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OCIArtifactSource_To_v1beta1_OCIArtifactSource(in, out, s)
+}
+
+func autoConvert_v1beta1_OCIArtifactSource_To_v1alpha1_OCIArtifactSource(in *v1beta1.OCIArtifactSource, out *OCIArtifactSource, s conversion.Scope) error {
+	out.Reference = in.Reference
+	out.File = in.File
+	out.PullSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.PullSecretRef))
+	return nil
+}
+
+// Convert_v1beta1_OCIArtifactSource_To_v1alpha1_OCIArtifactSource is an autogenerated conversion function.
+func Convert_v1beta1_OCIArtifactSource_To_v1alpha1_OCIArtifactSource(in *v1beta1.OCIArtifactSource, out *OCIArtifactSource, s conversion.Scope) error {
+	return autoConvert_v1beta1_OCIArtifactSource_To_v1alpha1_OCIArtifactSource(in, out, s)
+}
+
+func autoConvert_v1alpha1_OCIRegistryDestination_To_v1beta1_OCIRegistryDestination(in *OCIRegistryDestination, out *v1beta1.OCIRegistryDestination, s conversion.Scope) error {
+	out.Repository = in.Repository
+	out.Tags = *(*[]string)(unsafe.Pointer(&in.Tags))
+	out.Annotations = *(*map[string]string)(unsafe.Pointer(&in.Annotations))
+	out.Insecure = in.Insecure
+	out.CredentialsRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CredentialsRef))
+	return nil
+}
+
+// Convert_v1alpha1_OCIRegistryDestination_To_v1beta1_OCIRegistryDestination is an autogenerated conversion function.
+func Convert_v1alpha1_OCIRegistryDestination_To_v1beta1_OCIRegistryDestination(in *OCIRegistryDestination, out *v1beta1.OCIRegistryDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OCIRegistryDestination_To_v1beta1_OCIRegistryDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_OCIRegistryDestination_To_v1alpha1_OCIRegistryDestination(in *v1beta1.OCIRegistryDestination, out *OCIRegistryDestination, s conversion.Scope) error {
+	out.Repository = in.Repository
+	out.Tags = *(*[]string)(unsafe.Pointer(&in.Tags))
+	out.Annotations = *(*map[string]string)(unsafe.Pointer(&in.Annotations))
+	out.Insecure = in.Insecure
+	out.CredentialsRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CredentialsRef))
+	return nil
+}
+
+// Convert_v1beta1_OCIRegistryDestination_To_v1alpha1_OCIRegistryDestination is an autogenerated conversion function.
+func Convert_v1beta1_OCIRegistryDestination_To_v1alpha1_OCIRegistryDestination(in *v1beta1.OCIRegistryDestination, out *OCIRegistryDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_OCIRegistryDestination_To_v1alpha1_OCIRegistryDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination(in *ObjectStorageDestination, out *v1beta1.ObjectStorageDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Endpoint = in.Endpoint
+	out.Region = in.Region
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination is an autogenerated conversion function.
+func Convert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination(in *ObjectStorageDestination, out *v1beta1.ObjectStorageDestination, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination(in, out, s)
+}
+
+func autoConvert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination(in *v1beta1.ObjectStorageDestination, out *ObjectStorageDestination, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Endpoint = in.Endpoint
+	out.Region = in.Region
+	out.CredentialsRef = in.CredentialsRef
+	return nil
+}
+
+// Convert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination is an autogenerated conversion function.
+func Convert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination(in *v1beta1.ObjectStorageDestination, out *ObjectStorageDestination, s conversion.Scope) error {
+	return autoConvert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination(in, out, s)
+}
+
+func autoConvert_v1alpha1_PackageAssertion_To_v1beta1_PackageAssertion(in *PackageAssertion, out *v1beta1.PackageAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Version = in.Version
+	return nil
+}
+
+// Convert_v1alpha1_PackageAssertion_To_v1beta1_PackageAssertion is an autogenerated conversion function.
+func Convert_v1alpha1_PackageAssertion_To_v1beta1_PackageAssertion(in *PackageAssertion, out *v1beta1.PackageAssertion, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PackageAssertion_To_v1beta1_PackageAssertion(in, out, s)
+}
+
+func autoConvert_v1beta1_PackageAssertion_To_v1alpha1_PackageAssertion(in *v1beta1.PackageAssertion, out *PackageAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Version = in.Version
+	return nil
+}
+
+// Convert_v1beta1_PackageAssertion_To_v1alpha1_PackageAssertion is an autogenerated conversion function.
+func Convert_v1beta1_PackageAssertion_To_v1alpha1_PackageAssertion(in *v1beta1.PackageAssertion, out *PackageAssertion, s conversion.Scope) error {
+	return autoConvert_v1beta1_PackageAssertion_To_v1alpha1_PackageAssertion(in, out, s)
+}
+
+func autoConvert_v1alpha1_PowerShellProvisionerSpec_To_v1beta1_PowerShellProvisionerSpec(in *PowerShellProvisionerSpec, out *v1beta1.PowerShellProvisionerSpec, s conversion.Scope) error {
+	out.Scripts = *(*[]v1beta1.PowerShellScript)(unsafe.Pointer(&in.Scripts))
+	out.MaxReboots = (*int32)(unsafe.Pointer(in.MaxReboots))
+	out.RebootTimeout = (*metav1.Duration)(unsafe.Pointer(in.RebootTimeout))
+	return nil
+}
+
+// Convert_v1alpha1_PowerShellProvisionerSpec_To_v1beta1_PowerShellProvisionerSpec is an autogenerated conversion function.
+func Convert_v1alpha1_PowerShellProvisionerSpec_To_v1beta1_PowerShellProvisionerSpec(in *PowerShellProvisionerSpec, out *v1beta1.PowerShellProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PowerShellProvisionerSpec_To_v1beta1_PowerShellProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_PowerShellProvisionerSpec_To_v1alpha1_PowerShellProvisionerSpec(in *v1beta1.PowerShellProvisionerSpec, out *PowerShellProvisionerSpec, s conversion.Scope) error {
+	out.Scripts = *(*[]PowerShellScript)(unsafe.Pointer(&in.Scripts))
+	out.MaxReboots = (*int32)(unsafe.Pointer(in.MaxReboots))
+	out.RebootTimeout = (*metav1.Duration)(unsafe.Pointer(in.RebootTimeout))
+	return nil
+}
+
+// Convert_v1beta1_PowerShellProvisionerSpec_To_v1alpha1_PowerShellProvisionerSpec is an autogenerated conversion function.
+func Convert_v1beta1_PowerShellProvisionerSpec_To_v1alpha1_PowerShellProvisionerSpec(in *v1beta1.PowerShellProvisionerSpec, out *PowerShellProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_PowerShellProvisionerSpec_To_v1alpha1_PowerShellProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_PowerShellScript_To_v1beta1_PowerShellScript(in *PowerShellScript, out *v1beta1.PowerShellScript, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Inline = in.Inline
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	return nil
+}
+
+// Convert_v1alpha1_PowerShellScript_To_v1beta1_PowerShellScript is an autogenerated conversion function.
+func Convert_v1alpha1_PowerShellScript_To_v1beta1_PowerShellScript(in *PowerShellScript, out *v1beta1.PowerShellScript, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PowerShellScript_To_v1beta1_PowerShellScript(in, out, s)
+}
+
+func autoConvert_v1beta1_PowerShellScript_To_v1alpha1_PowerShellScript(in *v1beta1.PowerShellScript, out *PowerShellScript, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Inline = in.Inline
+	out.ConfigMapKeyRef = (*v1.ConfigMapKeySelector)(unsafe.Pointer(in.ConfigMapKeyRef))
+	return nil
+}
+
+// Convert_v1beta1_PowerShellScript_To_v1alpha1_PowerShellScript is an autogenerated conversion function.
+func Convert_v1beta1_PowerShellScript_To_v1alpha1_PowerShellScript(in *v1beta1.PowerShellScript, out *PowerShellScript, s conversion.Scope) error {
+	return autoConvert_v1beta1_PowerShellScript_To_v1alpha1_PowerShellScript(in, out, s)
+}
+
+func autoConvert_v1alpha1_PowerShellScriptStatus_To_v1beta1_PowerShellScriptStatus(in *PowerShellScriptStatus, out *v1beta1.PowerShellScriptStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = in.Phase
+	out.Reboots = in.Reboots
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.RebootStartedAt = (*metav1.Time)(unsafe.Pointer(in.RebootStartedAt))
+	return nil
+}
+
+// Convert_v1alpha1_PowerShellScriptStatus_To_v1beta1_PowerShellScriptStatus is an autogenerated conversion function.
+func Convert_v1alpha1_PowerShellScriptStatus_To_v1beta1_PowerShellScriptStatus(in *PowerShellScriptStatus, out *v1beta1.PowerShellScriptStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PowerShellScriptStatus_To_v1beta1_PowerShellScriptStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_PowerShellScriptStatus_To_v1alpha1_PowerShellScriptStatus(in *v1beta1.PowerShellScriptStatus, out *PowerShellScriptStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = in.Phase
+	out.Reboots = in.Reboots
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.RebootStartedAt = (*metav1.Time)(unsafe.Pointer(in.RebootStartedAt))
+	return nil
+}
+
+// Convert_v1beta1_PowerShellScriptStatus_To_v1alpha1_PowerShellScriptStatus is an autogenerated conversion function.
+func Convert_v1beta1_PowerShellScriptStatus_To_v1alpha1_PowerShellScriptStatus(in *v1beta1.PowerShellScriptStatus, out *PowerShellScriptStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_PowerShellScriptStatus_To_v1alpha1_PowerShellScriptStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_PreemptionPolicy_To_v1beta1_PreemptionPolicy(in *PreemptionPolicy, out *v1beta1.PreemptionPolicy, s conversion.Scope) error {
+	out.MaxPreemptions = (*int32)(unsafe.Pointer(in.MaxPreemptions))
+	out.Fallback = v1beta1.PreemptionFallback(in.Fallback)
+	return nil
+}
+
+// Convert_v1alpha1_PreemptionPolicy_To_v1beta1_PreemptionPolicy is an autogenerated conversion function.
+func Convert_v1alpha1_PreemptionPolicy_To_v1beta1_PreemptionPolicy(in *PreemptionPolicy, out *v1beta1.PreemptionPolicy, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PreemptionPolicy_To_v1beta1_PreemptionPolicy(in, out, s)
+}
+
+func autoConvert_v1beta1_PreemptionPolicy_To_v1alpha1_PreemptionPolicy(in *v1beta1.PreemptionPolicy, out *PreemptionPolicy, s conversion.Scope) error {
+	out.MaxPreemptions = (*int32)(unsafe.Pointer(in.MaxPreemptions))
+	out.Fallback = PreemptionFallback(in.Fallback)
+	return nil
+}
+
+// Convert_v1beta1_PreemptionPolicy_To_v1alpha1_PreemptionPolicy is an autogenerated conversion function.
+func Convert_v1beta1_PreemptionPolicy_To_v1alpha1_PreemptionPolicy(in *v1beta1.PreemptionPolicy, out *PreemptionPolicy, s conversion.Scope) error {
+	return autoConvert_v1beta1_PreemptionPolicy_To_v1alpha1_PreemptionPolicy(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerFile_To_v1beta1_ProvisionerFile(in *ProvisionerFile, out *v1beta1.ProvisionerFile, s conversion.Scope) error {
+	out.Content = in.Content
+	out.Destination = in.Destination
+	out.Mode = (*int32)(unsafe.Pointer(in.Mode))
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerFile_To_v1beta1_ProvisionerFile is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerFile_To_v1beta1_ProvisionerFile(in *ProvisionerFile, out *v1beta1.ProvisionerFile, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerFile_To_v1beta1_ProvisionerFile(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerFile_To_v1alpha1_ProvisionerFile(in *v1beta1.ProvisionerFile, out *ProvisionerFile, s conversion.Scope) error {
+	out.Content = in.Content
+	out.Destination = in.Destination
+	out.Mode = (*int32)(unsafe.Pointer(in.Mode))
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerFile_To_v1alpha1_ProvisionerFile is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerFile_To_v1alpha1_ProvisionerFile(in *v1beta1.ProvisionerFile, out *ProvisionerFile, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerFile_To_v1alpha1_ProvisionerFile(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerLayer_To_v1beta1_ProvisionerLayer(in *ProvisionerLayer, out *v1beta1.ProvisionerLayer, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Hash = in.Hash
+	out.Cached = in.Cached
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerLayer_To_v1beta1_ProvisionerLayer is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerLayer_To_v1beta1_ProvisionerLayer(in *ProvisionerLayer, out *v1beta1.ProvisionerLayer, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerLayer_To_v1beta1_ProvisionerLayer(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerLayer_To_v1alpha1_ProvisionerLayer(in *v1beta1.ProvisionerLayer, out *ProvisionerLayer, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Hash = in.Hash
+	out.Cached = in.Cached
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerLayer_To_v1alpha1_ProvisionerLayer is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerLayer_To_v1alpha1_ProvisionerLayer(in *v1beta1.ProvisionerLayer, out *ProvisionerLayer, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerLayer_To_v1alpha1_ProvisionerLayer(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerPodTemplate_To_v1beta1_ProvisionerPodTemplate(in *ProvisionerPodTemplate, out *v1beta1.ProvisionerPodTemplate, s conversion.Scope) error {
+	out.Resources = in.Resources
+	out.NodeSelector = *(*map[string]string)(unsafe.Pointer(&in.NodeSelector))
+	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
+	out.ServiceAccountName = in.ServiceAccountName
+	out.SecurityContext = (*v1.PodSecurityContext)(unsafe.Pointer(in.SecurityContext))
+	out.ContainerSecurityContext = (*v1.SecurityContext)(unsafe.Pointer(in.ContainerSecurityContext))
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerPodTemplate_To_v1beta1_ProvisionerPodTemplate is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerPodTemplate_To_v1beta1_ProvisionerPodTemplate(in *ProvisionerPodTemplate, out *v1beta1.ProvisionerPodTemplate, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerPodTemplate_To_v1beta1_ProvisionerPodTemplate(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerPodTemplate_To_v1alpha1_ProvisionerPodTemplate(in *v1beta1.ProvisionerPodTemplate, out *ProvisionerPodTemplate, s conversion.Scope) error {
+	out.Resources = in.Resources
+	out.NodeSelector = *(*map[string]string)(unsafe.Pointer(&in.NodeSelector))
+	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
+	out.ServiceAccountName = in.ServiceAccountName
+	out.SecurityContext = (*v1.PodSecurityContext)(unsafe.Pointer(in.SecurityContext))
+	out.ContainerSecurityContext = (*v1.SecurityContext)(unsafe.Pointer(in.ContainerSecurityContext))
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerPodTemplate_To_v1alpha1_ProvisionerPodTemplate is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerPodTemplate_To_v1alpha1_ProvisionerPodTemplate(in *v1beta1.ProvisionerPodTemplate, out *ProvisionerPodTemplate, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerPodTemplate_To_v1alpha1_ProvisionerPodTemplate(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerSpec_To_v1beta1_ProvisionerSpec(in *ProvisionerSpec, out *v1beta1.ProvisionerSpec, s conversion.Scope) error {
+	out.UUID = (*string)(unsafe.Pointer(in.UUID))
+	out.Name = in.Name
+	out.Order = (*int32)(unsafe.Pointer(in.Order))
+	out.DependsOn = *(*[]string)(unsafe.Pointer(&in.DependsOn))
+	out.ConcurrencyGroup = in.ConcurrencyGroup
+	out.Type = v1beta1.ProvisionerType(in.Type)
+	out.AllowFail = in.AllowFail
+	out.RequireApproval = in.RequireApproval
+	out.Run = (*string)(unsafe.Pointer(in.Run))
+	out.RunConfigMapRef = (*v1.ObjectReference)(unsafe.Pointer(in.RunConfigMapRef))
+	out.Steps = *(*[]v1beta1.ProvisionerStep)(unsafe.Pointer(&in.Steps))
+	out.Kubeconfig = (*v1beta1.KubeconfigSource)(unsafe.Pointer(in.Kubeconfig))
+	out.Verify = (*v1beta1.VerifySpec)(unsafe.Pointer(in.Verify))
+	out.Ansible = (*v1beta1.AnsibleSpec)(unsafe.Pointer(in.Ansible))
+	out.File = (*v1beta1.FileProvisionerSpec)(unsafe.Pointer(in.File))
+	out.PowerShell = (*v1beta1.PowerShellProvisionerSpec)(unsafe.Pointer(in.PowerShell))
+	out.ChefSolo = (*v1beta1.ChefSoloSpec)(unsafe.Pointer(in.ChefSolo))
+	out.PuppetApply = (*v1beta1.PuppetApplySpec)(unsafe.Pointer(in.PuppetApply))
+	out.Restart = (*v1beta1.RestartSpec)(unsafe.Pointer(in.Restart))
+	out.Harden = (*v1beta1.HardenSpec)(unsafe.Pointer(in.Harden))
+	out.Extract = (*v1beta1.ExtractProvisionerSpec)(unsafe.Pointer(in.Extract))
+	out.Class = in.Class
+	out.Parameters = (*apiextensionsv1.JSON)(unsafe.Pointer(in.Parameters))
+	out.Ref = (*v1.ObjectReference)(unsafe.Pointer(in.Ref))
+	out.Retries = (*int32)(unsafe.Pointer(in.Retries))
+	out.RetryDelay = (*metav1.Duration)(unsafe.Pointer(in.RetryDelay))
+	out.BackoffLimit = (*int32)(unsafe.Pointer(in.BackoffLimit))
+	out.ActiveDeadlineSeconds = (*int64)(unsafe.Pointer(in.ActiveDeadlineSeconds))
+	out.Image = in.Image
+	out.ImagePullPolicy = v1.PullPolicy(in.ImagePullPolicy)
+	out.ImagePullSecrets = *(*[]v1.LocalObjectReference)(unsafe.Pointer(&in.ImagePullSecrets))
+	out.PodTemplate = (*v1beta1.ProvisionerPodTemplate)(unsafe.Pointer(in.PodTemplate))
+	out.Status = (*v1beta1.ProvisionerStatus)(unsafe.Pointer(in.Status))
+	out.FailureReason = (*string)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Issues = *(*[]string)(unsafe.Pointer(&in.Issues))
+	out.Results = *(*[]v1beta1.VerificationResult)(unsafe.Pointer(&in.Results))
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerSpec_To_v1beta1_ProvisionerSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerSpec_To_v1beta1_ProvisionerSpec(in *ProvisionerSpec, out *v1beta1.ProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerSpec_To_v1beta1_ProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerSpec_To_v1alpha1_ProvisionerSpec(in *v1beta1.ProvisionerSpec, out *ProvisionerSpec, s conversion.Scope) error {
+	out.UUID = (*string)(unsafe.Pointer(in.UUID))
+	out.Name = in.Name
+	out.Order = (*int32)(unsafe.Pointer(in.Order))
+	out.DependsOn = *(*[]string)(unsafe.Pointer(&in.DependsOn))
+	out.ConcurrencyGroup = in.ConcurrencyGroup
+	out.Type = ProvisionerType(in.Type)
+	out.AllowFail = in.AllowFail
+	out.RequireApproval = in.RequireApproval
+	out.Run = (*string)(unsafe.Pointer(in.Run))
+	out.RunConfigMapRef = (*v1.ObjectReference)(unsafe.Pointer(in.RunConfigMapRef))
+	out.Steps = *(*[]ProvisionerStep)(unsafe.Pointer(&in.Steps))
+	out.Kubeconfig = (*KubeconfigSource)(unsafe.Pointer(in.Kubeconfig))
+	out.Verify = (*VerifySpec)(unsafe.Pointer(in.Verify))
+	out.Ansible = (*AnsibleSpec)(unsafe.Pointer(in.Ansible))
+	out.File = (*FileProvisionerSpec)(unsafe.Pointer(in.File))
+	out.PowerShell = (*PowerShellProvisionerSpec)(unsafe.Pointer(in.PowerShell))
+	out.ChefSolo = (*ChefSoloSpec)(unsafe.Pointer(in.ChefSolo))
+	out.PuppetApply = (*PuppetApplySpec)(unsafe.Pointer(in.PuppetApply))
+	out.Restart = (*RestartSpec)(unsafe.Pointer(in.Restart))
+	out.Harden = (*HardenSpec)(unsafe.Pointer(in.Harden))
+	out.Extract = (*ExtractProvisionerSpec)(unsafe.Pointer(in.Extract))
+	out.Class = in.Class
+	out.Parameters = (*apiextensionsv1.JSON)(unsafe.Pointer(in.Parameters))
+	out.Ref = (*v1.ObjectReference)(unsafe.Pointer(in.Ref))
+	out.Retries = (*int32)(unsafe.Pointer(in.Retries))
+	out.RetryDelay = (*metav1.Duration)(unsafe.Pointer(in.RetryDelay))
+	out.BackoffLimit = (*int32)(unsafe.Pointer(in.BackoffLimit))
+	out.ActiveDeadlineSeconds = (*int64)(unsafe.Pointer(in.ActiveDeadlineSeconds))
+	out.Image = in.Image
+	out.ImagePullPolicy = v1.PullPolicy(in.ImagePullPolicy)
+	out.ImagePullSecrets = *(*[]v1.LocalObjectReference)(unsafe.Pointer(&in.ImagePullSecrets))
+	out.PodTemplate = (*ProvisionerPodTemplate)(unsafe.Pointer(in.PodTemplate))
+	out.Status = (*ProvisionerStatus)(unsafe.Pointer(in.Status))
+	out.FailureReason = (*string)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Issues = *(*[]string)(unsafe.Pointer(&in.Issues))
+	out.Results = *(*[]VerificationResult)(unsafe.Pointer(&in.Results))
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerSpec_To_v1alpha1_ProvisionerSpec is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerSpec_To_v1alpha1_ProvisionerSpec(in *v1beta1.ProvisionerSpec, out *ProvisionerSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerSpec_To_v1alpha1_ProvisionerSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerStep_To_v1beta1_ProvisionerStep(in *ProvisionerStep, out *v1beta1.ProvisionerStep, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Run = (*string)(unsafe.Pointer(in.Run))
+	out.RunConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.RunConfigMapRef))
+	out.File = (*v1beta1.ProvisionerFile)(unsafe.Pointer(in.File))
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerStep_To_v1beta1_ProvisionerStep is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerStep_To_v1beta1_ProvisionerStep(in *ProvisionerStep, out *v1beta1.ProvisionerStep, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerStep_To_v1beta1_ProvisionerStep(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerStep_To_v1alpha1_ProvisionerStep(in *v1beta1.ProvisionerStep, out *ProvisionerStep, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Run = (*string)(unsafe.Pointer(in.Run))
+	out.RunConfigMapRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.RunConfigMapRef))
+	out.File = (*ProvisionerFile)(unsafe.Pointer(in.File))
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerStep_To_v1alpha1_ProvisionerStep is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerStep_To_v1alpha1_ProvisionerStep(in *v1beta1.ProvisionerStep, out *ProvisionerStep, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerStep_To_v1alpha1_ProvisionerStep(in, out, s)
+}
+
+func autoConvert_v1alpha1_ProvisionerStepStatus_To_v1beta1_ProvisionerStepStatus(in *ProvisionerStepStatus, out *v1beta1.ProvisionerStepStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = v1beta1.ProvisionerStatus(in.Phase)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_ProvisionerStepStatus_To_v1beta1_ProvisionerStepStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ProvisionerStepStatus_To_v1beta1_ProvisionerStepStatus(in *ProvisionerStepStatus, out *v1beta1.ProvisionerStepStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ProvisionerStepStatus_To_v1beta1_ProvisionerStepStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ProvisionerStepStatus_To_v1alpha1_ProvisionerStepStatus(in *v1beta1.ProvisionerStepStatus, out *ProvisionerStepStatus, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = ProvisionerStatus(in.Phase)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_ProvisionerStepStatus_To_v1alpha1_ProvisionerStepStatus is an autogenerated conversion function.
+func Convert_v1beta1_ProvisionerStepStatus_To_v1alpha1_ProvisionerStepStatus(in *v1beta1.ProvisionerStepStatus, out *ProvisionerStepStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProvisionerStepStatus_To_v1alpha1_ProvisionerStepStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_PuppetApplySpec_To_v1beta1_PuppetApplySpec(in *PuppetApplySpec, out *v1beta1.PuppetApplySpec, s conversion.Scope) error {
+	if err := Convert_v1alpha1_ConfigManagementSource_To_v1beta1_ConfigManagementSource(&in.Source, &out.Source, s); err != nil {
+		return err
+	}
+	out.ManifestPath = in.ManifestPath
+	out.ModulePaths = *(*[]string)(unsafe.Pointer(&in.ModulePaths))
+	out.HieraConfigPath = in.HieraConfigPath
+	out.Facts = *(*map[string]string)(unsafe.Pointer(&in.Facts))
+	out.ExtraArguments = *(*[]string)(unsafe.Pointer(&in.ExtraArguments))
+	out.InstallCommand = in.InstallCommand
+	return nil
+}
+
+// Convert_v1alpha1_PuppetApplySpec_To_v1beta1_PuppetApplySpec is an autogenerated conversion function.
+func Convert_v1alpha1_PuppetApplySpec_To_v1beta1_PuppetApplySpec(in *PuppetApplySpec, out *v1beta1.PuppetApplySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PuppetApplySpec_To_v1beta1_PuppetApplySpec(in, out, s)
+}
+
+func autoConvert_v1beta1_PuppetApplySpec_To_v1alpha1_PuppetApplySpec(in *v1beta1.PuppetApplySpec, out *PuppetApplySpec, s conversion.Scope) error {
+	if err := Convert_v1beta1_ConfigManagementSource_To_v1alpha1_ConfigManagementSource(&in.Source, &out.Source, s); err != nil {
+		return err
+	}
+	out.ManifestPath = in.ManifestPath
+	out.ModulePaths = *(*[]string)(unsafe.Pointer(&in.ModulePaths))
+	out.HieraConfigPath = in.HieraConfigPath
+	out.Facts = *(*map[string]string)(unsafe.Pointer(&in.Facts))
+	out.ExtraArguments = *(*[]string)(unsafe.Pointer(&in.ExtraArguments))
+	out.InstallCommand = in.InstallCommand
+	return nil
+}
+
+// Convert_v1beta1_PuppetApplySpec_To_v1alpha1_PuppetApplySpec is an autogenerated conversion function.
+func Convert_v1beta1_PuppetApplySpec_To_v1alpha1_PuppetApplySpec(in *v1beta1.PuppetApplySpec, out *PuppetApplySpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_PuppetApplySpec_To_v1alpha1_PuppetApplySpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ReplicationSpec_To_v1beta1_ReplicationSpec(in *ReplicationSpec, out *v1beta1.ReplicationSpec, s conversion.Scope) error {
+	out.Regions = *(*[]string)(unsafe.Pointer(&in.Regions))
+	out.Accounts = *(*[]string)(unsafe.Pointer(&in.Accounts))
+	return nil
+}
+
+// Convert_v1alpha1_ReplicationSpec_To_v1beta1_ReplicationSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ReplicationSpec_To_v1beta1_ReplicationSpec(in *ReplicationSpec, out *v1beta1.ReplicationSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ReplicationSpec_To_v1beta1_ReplicationSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ReplicationSpec_To_v1alpha1_ReplicationSpec(in *v1beta1.ReplicationSpec, out *ReplicationSpec, s conversion.Scope) error {
+	out.Regions = *(*[]string)(unsafe.Pointer(&in.Regions))
+	out.Accounts = *(*[]string)(unsafe.Pointer(&in.Accounts))
+	return nil
+}
+
+// Convert_v1beta1_ReplicationSpec_To_v1alpha1_ReplicationSpec is an autogenerated conversion function.
+func Convert_v1beta1_ReplicationSpec_To_v1alpha1_ReplicationSpec(in *v1beta1.ReplicationSpec, out *ReplicationSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ReplicationSpec_To_v1alpha1_ReplicationSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in *ReplicationStatus, out *v1beta1.ReplicationStatus, s conversion.Scope) error {
+	out.Type = v1beta1.ReplicationTargetType(in.Type)
+	out.Name = in.Name
+	out.Phase = v1beta1.ReplicationPhase(in.Phase)
+	out.ImageRef = in.ImageRef
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in *ReplicationStatus, out *v1beta1.ReplicationStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ReplicationStatus_To_v1beta1_ReplicationStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in *v1beta1.ReplicationStatus, out *ReplicationStatus, s conversion.Scope) error {
+	out.Type = ReplicationTargetType(in.Type)
+	out.Name = in.Name
+	out.Phase = ReplicationPhase(in.Phase)
+	out.ImageRef = in.ImageRef
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus is an autogenerated conversion function.
+func Convert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in *v1beta1.ReplicationStatus, out *ReplicationStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ReplicationStatus_To_v1alpha1_ReplicationStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_RestartSpec_To_v1beta1_RestartSpec(in *RestartSpec, out *v1beta1.RestartSpec, s conversion.Scope) error {
+	out.Command = in.Command
+	out.CheckCommand = in.CheckCommand
+	out.Timeout = (*metav1.Duration)(unsafe.Pointer(in.Timeout))
+	return nil
+}
+
+// Convert_v1alpha1_RestartSpec_To_v1beta1_RestartSpec is an autogenerated conversion function.
+func Convert_v1alpha1_RestartSpec_To_v1beta1_RestartSpec(in *RestartSpec, out *v1beta1.RestartSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RestartSpec_To_v1beta1_RestartSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_RestartSpec_To_v1alpha1_RestartSpec(in *v1beta1.RestartSpec, out *RestartSpec, s conversion.Scope) error {
+	out.Command = in.Command
+	out.CheckCommand = in.CheckCommand
+	out.Timeout = (*metav1.Duration)(unsafe.Pointer(in.Timeout))
+	return nil
+}
+
+// Convert_v1beta1_RestartSpec_To_v1alpha1_RestartSpec is an autogenerated conversion function.
+func Convert_v1beta1_RestartSpec_To_v1alpha1_RestartSpec(in *v1beta1.RestartSpec, out *RestartSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_RestartSpec_To_v1alpha1_RestartSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_RetryBackoff_To_v1beta1_RetryBackoff(in *RetryBackoff, out *v1beta1.RetryBackoff, s conversion.Scope) error {
+	out.Initial = (*metav1.Duration)(unsafe.Pointer(in.Initial))
+	out.Max = (*metav1.Duration)(unsafe.Pointer(in.Max))
+	return nil
+}
+
+// Convert_v1alpha1_RetryBackoff_To_v1beta1_RetryBackoff is an autogenerated conversion function.
+func Convert_v1alpha1_RetryBackoff_To_v1beta1_RetryBackoff(in *RetryBackoff, out *v1beta1.RetryBackoff, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RetryBackoff_To_v1beta1_RetryBackoff(in, out, s)
+}
+
+func autoConvert_v1beta1_RetryBackoff_To_v1alpha1_RetryBackoff(in *v1beta1.RetryBackoff, out *RetryBackoff, s conversion.Scope) error {
+	out.Initial = (*metav1.Duration)(unsafe.Pointer(in.Initial))
+	out.Max = (*metav1.Duration)(unsafe.Pointer(in.Max))
+	return nil
+}
+
+// Convert_v1beta1_RetryBackoff_To_v1alpha1_RetryBackoff is an autogenerated conversion function.
+func Convert_v1beta1_RetryBackoff_To_v1alpha1_RetryBackoff(in *v1beta1.RetryBackoff, out *RetryBackoff, s conversion.Scope) error {
+	return autoConvert_v1beta1_RetryBackoff_To_v1alpha1_RetryBackoff(in, out, s)
+}
+
+func autoConvert_v1alpha1_RetryPolicy_To_v1beta1_RetryPolicy(in *RetryPolicy, out *v1beta1.RetryPolicy, s conversion.Scope) error {
+	out.MaxRetries = in.MaxRetries
+	out.Backoff = (*v1beta1.RetryBackoff)(unsafe.Pointer(in.Backoff))
+	out.RetryOn = *(*[]errors.BuildStatusError)(unsafe.Pointer(&in.RetryOn))
+	return nil
+}
+
+// Convert_v1alpha1_RetryPolicy_To_v1beta1_RetryPolicy is an autogenerated conversion function.
+func Convert_v1alpha1_RetryPolicy_To_v1beta1_RetryPolicy(in *RetryPolicy, out *v1beta1.RetryPolicy, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RetryPolicy_To_v1beta1_RetryPolicy(in, out, s)
+}
+
+func autoConvert_v1beta1_RetryPolicy_To_v1alpha1_RetryPolicy(in *v1beta1.RetryPolicy, out *RetryPolicy, s conversion.Scope) error {
+	out.MaxRetries = in.MaxRetries
+	out.Backoff = (*RetryBackoff)(unsafe.Pointer(in.Backoff))
+	out.RetryOn = *(*[]errors.BuildStatusError)(unsafe.Pointer(&in.RetryOn))
+	return nil
+}
+
+// Convert_v1beta1_RetryPolicy_To_v1alpha1_RetryPolicy is an autogenerated conversion function.
+func Convert_v1beta1_RetryPolicy_To_v1alpha1_RetryPolicy(in *v1beta1.RetryPolicy, out *RetryPolicy, s conversion.Scope) error {
+	return autoConvert_v1beta1_RetryPolicy_To_v1alpha1_RetryPolicy(in, out, s)
+}
+
+func autoConvert_v1alpha1_SBOMSpec_To_v1beta1_SBOMSpec(in *SBOMSpec, out *v1beta1.SBOMSpec, s conversion.Scope) error {
+	out.Generator = v1beta1.SBOMGenerator(in.Generator)
+	out.Format = v1beta1.SBOMFormat(in.Format)
+	out.SyftVersion = in.SyftVersion
+	out.Destination = (*v1beta1.ExtractDestination)(unsafe.Pointer(in.Destination))
+	return nil
+}
+
+// Convert_v1alpha1_SBOMSpec_To_v1beta1_SBOMSpec is an autogenerated conversion function.
+func Convert_v1alpha1_SBOMSpec_To_v1beta1_SBOMSpec(in *SBOMSpec, out *v1beta1.SBOMSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SBOMSpec_To_v1beta1_SBOMSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_SBOMSpec_To_v1alpha1_SBOMSpec(in *v1beta1.SBOMSpec, out *SBOMSpec, s conversion.Scope) error {
+	out.Generator = SBOMGenerator(in.Generator)
+	out.Format = SBOMFormat(in.Format)
+	out.SyftVersion = in.SyftVersion
+	out.Destination = (*ExtractDestination)(unsafe.Pointer(in.Destination))
+	return nil
+}
+
+// Convert_v1beta1_SBOMSpec_To_v1alpha1_SBOMSpec is an autogenerated conversion function.
+func Convert_v1beta1_SBOMSpec_To_v1alpha1_SBOMSpec(in *v1beta1.SBOMSpec, out *SBOMSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_SBOMSpec_To_v1alpha1_SBOMSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_SBOMStatus_To_v1beta1_SBOMStatus(in *SBOMStatus, out *v1beta1.SBOMStatus, s conversion.Scope) error {
+	out.Generator = v1beta1.SBOMGenerator(in.Generator)
+	out.Format = v1beta1.SBOMFormat(in.Format)
+	out.Location = in.Location
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	return nil
+}
+
+// Convert_v1alpha1_SBOMStatus_To_v1beta1_SBOMStatus is an autogenerated conversion function.
+func Convert_v1alpha1_SBOMStatus_To_v1beta1_SBOMStatus(in *SBOMStatus, out *v1beta1.SBOMStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SBOMStatus_To_v1beta1_SBOMStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_SBOMStatus_To_v1alpha1_SBOMStatus(in *v1beta1.SBOMStatus, out *SBOMStatus, s conversion.Scope) error {
+	out.Generator = SBOMGenerator(in.Generator)
+	out.Format = SBOMFormat(in.Format)
+	out.Location = in.Location
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	return nil
+}
+
+// Convert_v1beta1_SBOMStatus_To_v1alpha1_SBOMStatus is an autogenerated conversion function.
+func Convert_v1beta1_SBOMStatus_To_v1alpha1_SBOMStatus(in *v1beta1.SBOMStatus, out *SBOMStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_SBOMStatus_To_v1alpha1_SBOMStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ScanIgnore_To_v1beta1_ScanIgnore(in *ScanIgnore, out *v1beta1.ScanIgnore, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Reason = in.Reason
+	out.ExpirationTime = (*metav1.Time)(unsafe.Pointer(in.ExpirationTime))
+	return nil
+}
+
+// Convert_v1alpha1_ScanIgnore_To_v1beta1_ScanIgnore is an autogenerated conversion function.
+func Convert_v1alpha1_ScanIgnore_To_v1beta1_ScanIgnore(in *ScanIgnore, out *v1beta1.ScanIgnore, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ScanIgnore_To_v1beta1_ScanIgnore(in, out, s)
+}
+
+func autoConvert_v1beta1_ScanIgnore_To_v1alpha1_ScanIgnore(in *v1beta1.ScanIgnore, out *ScanIgnore, s conversion.Scope) error {
+	out.ID = in.ID
+	out.Reason = in.Reason
+	out.ExpirationTime = (*metav1.Time)(unsafe.Pointer(in.ExpirationTime))
+	return nil
+}
+
+// Convert_v1beta1_ScanIgnore_To_v1alpha1_ScanIgnore is an autogenerated conversion function.
+func Convert_v1beta1_ScanIgnore_To_v1alpha1_ScanIgnore(in *v1beta1.ScanIgnore, out *ScanIgnore, s conversion.Scope) error {
+	return autoConvert_v1beta1_ScanIgnore_To_v1alpha1_ScanIgnore(in, out, s)
+}
+
+func autoConvert_v1alpha1_ScanSpec_To_v1beta1_ScanSpec(in *ScanSpec, out *v1beta1.ScanSpec, s conversion.Scope) error {
+	out.Scanner = v1beta1.Scanner(in.Scanner)
+	out.ScannerVersion = in.ScannerVersion
+	out.Thresholds = (*v1beta1.ScanThresholds)(unsafe.Pointer(in.Thresholds))
+	out.IgnoreUnfixed = in.IgnoreUnfixed
+	out.Ignore = *(*[]v1beta1.ScanIgnore)(unsafe.Pointer(&in.Ignore))
+	out.Destination = (*v1beta1.ExtractDestination)(unsafe.Pointer(in.Destination))
+	return nil
+}
+
+// Convert_v1alpha1_ScanSpec_To_v1beta1_ScanSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ScanSpec_To_v1beta1_ScanSpec(in *ScanSpec, out *v1beta1.ScanSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ScanSpec_To_v1beta1_ScanSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ScanSpec_To_v1alpha1_ScanSpec(in *v1beta1.ScanSpec, out *ScanSpec, s conversion.Scope) error {
+	out.Scanner = Scanner(in.Scanner)
+	out.ScannerVersion = in.ScannerVersion
+	out.Thresholds = (*ScanThresholds)(unsafe.Pointer(in.Thresholds))
+	out.IgnoreUnfixed = in.IgnoreUnfixed
+	out.Ignore = *(*[]ScanIgnore)(unsafe.Pointer(&in.Ignore))
+	out.Destination = (*ExtractDestination)(unsafe.Pointer(in.Destination))
+	return nil
+}
+
+// Convert_v1beta1_ScanSpec_To_v1alpha1_ScanSpec is an autogenerated conversion function.
+func Convert_v1beta1_ScanSpec_To_v1alpha1_ScanSpec(in *v1beta1.ScanSpec, out *ScanSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ScanSpec_To_v1alpha1_ScanSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_ScanStatus_To_v1beta1_ScanStatus(in *ScanStatus, out *v1beta1.ScanStatus, s conversion.Scope) error {
+	out.Scanner = v1beta1.Scanner(in.Scanner)
+	out.Passed = in.Passed
+	if err := Convert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary(&in.Vulnerabilities, &out.Vulnerabilities, s); err != nil {
+		return err
+	}
+	out.Ignored = in.Ignored
+	out.Message = in.Message
+	out.Report = in.Report
+	out.CompletionTime = in.CompletionTime
+	return nil
+}
+
+// Convert_v1alpha1_ScanStatus_To_v1beta1_ScanStatus is an autogenerated conversion function.
+func Convert_v1alpha1_ScanStatus_To_v1beta1_ScanStatus(in *ScanStatus, out *v1beta1.ScanStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ScanStatus_To_v1beta1_ScanStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_ScanStatus_To_v1alpha1_ScanStatus(in *v1beta1.ScanStatus, out *ScanStatus, s conversion.Scope) error {
+	out.Scanner = Scanner(in.Scanner)
+	out.Passed = in.Passed
+	if err := Convert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary(&in.Vulnerabilities, &out.Vulnerabilities, s); err != nil {
+		return err
+	}
+	out.Ignored = in.Ignored
+	out.Message = in.Message
+	out.Report = in.Report
+	out.CompletionTime = in.CompletionTime
+	return nil
+}
+
+// Convert_v1beta1_ScanStatus_To_v1alpha1_ScanStatus is an autogenerated conversion function.
+func Convert_v1beta1_ScanStatus_To_v1alpha1_ScanStatus(in *v1beta1.ScanStatus, out *ScanStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ScanStatus_To_v1alpha1_ScanStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary(in *ScanSummary, out *v1beta1.ScanSummary, s conversion.Scope) error {
+	out.Critical = in.Critical
+	out.High = in.High
+	out.Medium = in.Medium
+	out.Low = in.Low
+	out.Unknown = in.Unknown
+	return nil
+}
+
+// Convert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary is an autogenerated conversion function.
+func Convert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary(in *ScanSummary, out *v1beta1.ScanSummary, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ScanSummary_To_v1beta1_ScanSummary(in, out, s)
+}
+
+func autoConvert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary(in *v1beta1.ScanSummary, out *ScanSummary, s conversion.Scope) error {
+	out.Critical = in.Critical
+	out.High = in.High
+	out.Medium = in.Medium
+	out.Low = in.Low
+	out.Unknown = in.Unknown
+	return nil
+}
+
+// Convert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary is an autogenerated conversion function.
+func Convert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary(in *v1beta1.ScanSummary, out *ScanSummary, s conversion.Scope) error {
+	return autoConvert_v1beta1_ScanSummary_To_v1alpha1_ScanSummary(in, out, s)
+}
+
+func autoConvert_v1alpha1_ScanThresholds_To_v1beta1_ScanThresholds(in *ScanThresholds, out *v1beta1.ScanThresholds, s conversion.Scope) error {
+	out.Critical = (*int32)(unsafe.Pointer(in.Critical))
+	out.High = (*int32)(unsafe.Pointer(in.High))
+	out.Medium = (*int32)(unsafe.Pointer(in.Medium))
+	out.Low = (*int32)(unsafe.Pointer(in.Low))
+	return nil
+}
+
+// Convert_v1alpha1_ScanThresholds_To_v1beta1_ScanThresholds is an autogenerated conversion function.
+func Convert_v1alpha1_ScanThresholds_To_v1beta1_ScanThresholds(in *ScanThresholds, out *v1beta1.ScanThresholds, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ScanThresholds_To_v1beta1_ScanThresholds(in, out, s)
+}
+
+func autoConvert_v1beta1_ScanThresholds_To_v1alpha1_ScanThresholds(in *v1beta1.ScanThresholds, out *ScanThresholds, s conversion.Scope) error {
+	out.Critical = (*int32)(unsafe.Pointer(in.Critical))
+	out.High = (*int32)(unsafe.Pointer(in.High))
+	out.Medium = (*int32)(unsafe.Pointer(in.Medium))
+	out.Low = (*int32)(unsafe.Pointer(in.Low))
+	return nil
+}
+
+// Convert_v1beta1_ScanThresholds_To_v1alpha1_ScanThresholds is an autogenerated conversion function.
+func Convert_v1beta1_ScanThresholds_To_v1alpha1_ScanThresholds(in *v1beta1.ScanThresholds, out *ScanThresholds, s conversion.Scope) error {
+	return autoConvert_v1beta1_ScanThresholds_To_v1alpha1_ScanThresholds(in, out, s)
+}
+
+func autoConvert_v1alpha1_ServiceAssertion_To_v1beta1_ServiceAssertion(in *ServiceAssertion, out *v1beta1.ServiceAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Running = (*bool)(unsafe.Pointer(in.Running))
+	out.Enabled = (*bool)(unsafe.Pointer(in.Enabled))
+	return nil
+}
+
+// Convert_v1alpha1_ServiceAssertion_To_v1beta1_ServiceAssertion is an autogenerated conversion function.
+func Convert_v1alpha1_ServiceAssertion_To_v1beta1_ServiceAssertion(in *ServiceAssertion, out *v1beta1.ServiceAssertion, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ServiceAssertion_To_v1beta1_ServiceAssertion(in, out, s)
+}
+
+func autoConvert_v1beta1_ServiceAssertion_To_v1alpha1_ServiceAssertion(in *v1beta1.ServiceAssertion, out *ServiceAssertion, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Running = (*bool)(unsafe.Pointer(in.Running))
+	out.Enabled = (*bool)(unsafe.Pointer(in.Enabled))
+	return nil
+}
+
+// Convert_v1beta1_ServiceAssertion_To_v1alpha1_ServiceAssertion is an autogenerated conversion function.
+func Convert_v1beta1_ServiceAssertion_To_v1alpha1_ServiceAssertion(in *v1beta1.ServiceAssertion, out *ServiceAssertion, s conversion.Scope) error {
+	return autoConvert_v1beta1_ServiceAssertion_To_v1alpha1_ServiceAssertion(in, out, s)
+}
+
+func autoConvert_v1alpha1_SigningSpec_To_v1beta1_SigningSpec(in *SigningSpec, out *v1beta1.SigningSpec, s conversion.Scope) error {
+	out.KeyRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.KeyRef))
+	out.Keyless = (*v1beta1.KeylessSigning)(unsafe.Pointer(in.Keyless))
+	out.RekorURL = in.RekorURL
+	return nil
+}
+
+// Convert_v1alpha1_SigningSpec_To_v1beta1_SigningSpec is an autogenerated conversion function.
+func Convert_v1alpha1_SigningSpec_To_v1beta1_SigningSpec(in *SigningSpec, out *v1beta1.SigningSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SigningSpec_To_v1beta1_SigningSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_SigningSpec_To_v1alpha1_SigningSpec(in *v1beta1.SigningSpec, out *SigningSpec, s conversion.Scope) error {
+	out.KeyRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.KeyRef))
+	out.Keyless = (*KeylessSigning)(unsafe.Pointer(in.Keyless))
+	out.RekorURL = in.RekorURL
+	return nil
+}
+
+// Convert_v1beta1_SigningSpec_To_v1alpha1_SigningSpec is an autogenerated conversion function.
+func Convert_v1beta1_SigningSpec_To_v1alpha1_SigningSpec(in *v1beta1.SigningSpec, out *SigningSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_SigningSpec_To_v1alpha1_SigningSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec(in *SudoSpec, out *v1beta1.SudoSpec, s conversion.Scope) error {
+	out.User = in.User
+	out.Password = in.Password
+	return nil
+}
+
+// Convert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec is an autogenerated conversion function.
+func Convert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec(in *SudoSpec, out *v1beta1.SudoSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SudoSpec_To_v1beta1_SudoSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec(in *v1beta1.SudoSpec, out *SudoSpec, s conversion.Scope) error {
+	out.User = in.User
+	out.Password = in.Password
+	return nil
+}
+
+// Convert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec is an autogenerated conversion function.
+func Convert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec(in *v1beta1.SudoSpec, out *SudoSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_SudoSpec_To_v1alpha1_SudoSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_UploadedFileStatus_To_v1beta1_UploadedFileStatus(in *UploadedFileStatus, out *v1beta1.UploadedFileStatus, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	out.Uploaded = in.Uploaded
+	return nil
+}
+
+// Convert_v1alpha1_UploadedFileStatus_To_v1beta1_UploadedFileStatus is an autogenerated conversion function.
+func Convert_v1alpha1_UploadedFileStatus_To_v1beta1_UploadedFileStatus(in *UploadedFileStatus, out *v1beta1.UploadedFileStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_UploadedFileStatus_To_v1beta1_UploadedFileStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_UploadedFileStatus_To_v1alpha1_UploadedFileStatus(in *v1beta1.UploadedFileStatus, out *UploadedFileStatus, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.SHA256 = in.SHA256
+	out.Size = in.Size
+	out.Uploaded = in.Uploaded
+	return nil
+}
+
+// Convert_v1beta1_UploadedFileStatus_To_v1alpha1_UploadedFileStatus is an autogenerated conversion function.
+func Convert_v1beta1_UploadedFileStatus_To_v1alpha1_UploadedFileStatus(in *v1beta1.UploadedFileStatus, out *UploadedFileStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_UploadedFileStatus_To_v1alpha1_UploadedFileStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_VagrantExport_To_v1beta1_VagrantExport(in *VagrantExport, out *v1beta1.VagrantExport, s conversion.Scope) error {
+	out.BoxName = in.BoxName
+	out.Version = in.Version
+	out.Providers = *(*[]v1beta1.VagrantProvider)(unsafe.Pointer(&in.Providers))
+	return nil
+}
+
+// Convert_v1alpha1_VagrantExport_To_v1beta1_VagrantExport is an autogenerated conversion function.
+func Convert_v1alpha1_VagrantExport_To_v1beta1_VagrantExport(in *VagrantExport, out *v1beta1.VagrantExport, s conversion.Scope) error {
+	return autoConvert_v1alpha1_VagrantExport_To_v1beta1_VagrantExport(in, out, s)
+}
+
+func autoConvert_v1beta1_VagrantExport_To_v1alpha1_VagrantExport(in *v1beta1.VagrantExport, out *VagrantExport, s conversion.Scope) error {
+	out.BoxName = in.BoxName
+	out.Version = in.Version
+	out.Providers = *(*[]VagrantProvider)(unsafe.Pointer(&in.Providers))
+	return nil
+}
+
+// Convert_v1beta1_VagrantExport_To_v1alpha1_VagrantExport is an autogenerated conversion function.
+func Convert_v1beta1_VagrantExport_To_v1alpha1_VagrantExport(in *v1beta1.VagrantExport, out *VagrantExport, s conversion.Scope) error {
+	return autoConvert_v1beta1_VagrantExport_To_v1alpha1_VagrantExport(in, out, s)
+}
+
+func autoConvert_v1alpha1_VerificationReport_To_v1beta1_VerificationReport(in *VerificationReport, out *v1beta1.VerificationReport, s conversion.Scope) error {
+	out.Total = in.Total
+	out.Passed = in.Passed
+	out.Failed = in.Failed
+	out.Failures = *(*[]v1beta1.VerificationResult)(unsafe.Pointer(&in.Failures))
+	return nil
+}
+
+// Convert_v1alpha1_VerificationReport_To_v1beta1_VerificationReport is an autogenerated conversion function.
+func Convert_v1alpha1_VerificationReport_To_v1beta1_VerificationReport(in *VerificationReport, out *v1beta1.VerificationReport, s conversion.Scope) error {
+	return autoConvert_v1alpha1_VerificationReport_To_v1beta1_VerificationReport(in, out, s)
+}
+
+func autoConvert_v1beta1_VerificationReport_To_v1alpha1_VerificationReport(in *v1beta1.VerificationReport, out *VerificationReport, s conversion.Scope) error {
+	out.Total = in.Total
+	out.Passed = in.Passed
+	out.Failed = in.Failed
+	out.Failures = *(*[]VerificationResult)(unsafe.Pointer(&in.Failures))
+	return nil
+}
+
+// Convert_v1beta1_VerificationReport_To_v1alpha1_VerificationReport is an autogenerated conversion function.
+func Convert_v1beta1_VerificationReport_To_v1alpha1_VerificationReport(in *v1beta1.VerificationReport, out *VerificationReport, s conversion.Scope) error {
+	return autoConvert_v1beta1_VerificationReport_To_v1alpha1_VerificationReport(in, out, s)
+}
+
+func autoConvert_v1alpha1_VerificationResult_To_v1beta1_VerificationResult(in *VerificationResult, out *v1beta1.VerificationResult, s conversion.Scope) error {
+	out.Assertion = in.Assertion
+	out.Passed = in.Passed
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_VerificationResult_To_v1beta1_VerificationResult is an autogenerated conversion function.
+func Convert_v1alpha1_VerificationResult_To_v1beta1_VerificationResult(in *VerificationResult, out *v1beta1.VerificationResult, s conversion.Scope) error {
+	return autoConvert_v1alpha1_VerificationResult_To_v1beta1_VerificationResult(in, out, s)
+}
+
+func autoConvert_v1beta1_VerificationResult_To_v1alpha1_VerificationResult(in *v1beta1.VerificationResult, out *VerificationResult, s conversion.Scope) error {
+	out.Assertion = in.Assertion
+	out.Passed = in.Passed
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_VerificationResult_To_v1alpha1_VerificationResult is an autogenerated conversion function.
+func Convert_v1beta1_VerificationResult_To_v1alpha1_VerificationResult(in *v1beta1.VerificationResult, out *VerificationResult, s conversion.Scope) error {
+	return autoConvert_v1beta1_VerificationResult_To_v1alpha1_VerificationResult(in, out, s)
+}
+
+func autoConvert_v1alpha1_VerifySpec_To_v1beta1_VerifySpec(in *VerifySpec, out *v1beta1.VerifySpec, s conversion.Scope) error {
+	out.Files = *(*[]v1beta1.FileAssertion)(unsafe.Pointer(&in.Files))
+	out.Packages = *(*[]v1beta1.PackageAssertion)(unsafe.Pointer(&in.Packages))
+	out.Services = *(*[]v1beta1.ServiceAssertion)(unsafe.Pointer(&in.Services))
+	out.Commands = *(*[]v1beta1.CommandAssertion)(unsafe.Pointer(&in.Commands))
+	out.Suites = *(*[]v1beta1.VerifySuite)(unsafe.Pointer(&in.Suites))
+	return nil
+}
+
+// Convert_v1alpha1_VerifySpec_To_v1beta1_VerifySpec is an autogenerated conversion function.
+func Convert_v1alpha1_VerifySpec_To_v1beta1_VerifySpec(in *VerifySpec, out *v1beta1.VerifySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_VerifySpec_To_v1beta1_VerifySpec(in, out, s)
+}
+
+func autoConvert_v1beta1_VerifySpec_To_v1alpha1_VerifySpec(in *v1beta1.VerifySpec, out *VerifySpec, s conversion.Scope) error {
+	out.Files = *(*[]FileAssertion)(unsafe.Pointer(&in.Files))
+	out.Packages = *(*[]PackageAssertion)(unsafe.Pointer(&in.Packages))
+	out.Services = *(*[]ServiceAssertion)(unsafe.Pointer(&in.Services))
+	out.Commands = *(*[]CommandAssertion)(unsafe.Pointer(&in.Commands))
+	out.Suites = *(*[]VerifySuite)(unsafe.Pointer(&in.Suites))
+	return nil
+}
+
+// Convert_v1beta1_VerifySpec_To_v1alpha1_VerifySpec is an autogenerated conversion function.
+func Convert_v1beta1_VerifySpec_To_v1alpha1_VerifySpec(in *v1beta1.VerifySpec, out *VerifySpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_VerifySpec_To_v1alpha1_VerifySpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_VerifySuite_To_v1beta1_VerifySuite(in *VerifySuite, out *v1beta1.VerifySuite, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Type = v1beta1.VerifySuiteType(in.Type)
+	out.ConfigMapKeyRef = in.ConfigMapKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_VerifySuite_To_v1beta1_VerifySuite is an autogenerated conversion function.
+func Convert_v1alpha1_VerifySuite_To_v1beta1_VerifySuite(in *VerifySuite, out *v1beta1.VerifySuite, s conversion.Scope) error {
+	return autoConvert_v1alpha1_VerifySuite_To_v1beta1_VerifySuite(in, out, s)
+}
+
+func autoConvert_v1beta1_VerifySuite_To_v1alpha1_VerifySuite(in *v1beta1.VerifySuite, out *VerifySuite, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Type = VerifySuiteType(in.Type)
+	out.ConfigMapKeyRef = in.ConfigMapKeyRef
+	return nil
+}
+
+// Convert_v1beta1_VerifySuite_To_v1alpha1_VerifySuite is an autogenerated conversion function.
+func Convert_v1beta1_VerifySuite_To_v1alpha1_VerifySuite(in *v1beta1.VerifySuite, out *VerifySuite, s conversion.Scope) error {
+	return autoConvert_v1beta1_VerifySuite_To_v1alpha1_VerifySuite(in, out, s)
+}
+
+func autoConvert_v1alpha1_WorkspaceSpec_To_v1beta1_WorkspaceSpec(in *WorkspaceSpec, out *v1beta1.WorkspaceSpec, s conversion.Scope) error {
+	if err := Convert_v1alpha1_ObjectStorageDestination_To_v1beta1_ObjectStorageDestination(&in.ObjectStorage, &out.ObjectStorage, s); err != nil {
+		return err
+	}
+	out.Objects = *(*[]string)(unsafe.Pointer(&in.Objects))
+	out.URLExpiration = (*metav1.Duration)(unsafe.Pointer(in.URLExpiration))
+	return nil
+}
+
+// Convert_v1alpha1_WorkspaceSpec_To_v1beta1_WorkspaceSpec is an autogenerated conversion function.
+func Convert_v1alpha1_WorkspaceSpec_To_v1beta1_WorkspaceSpec(in *WorkspaceSpec, out *v1beta1.WorkspaceSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_WorkspaceSpec_To_v1beta1_WorkspaceSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_WorkspaceSpec_To_v1alpha1_WorkspaceSpec(in *v1beta1.WorkspaceSpec, out *WorkspaceSpec, s conversion.Scope) error {
+	if err := Convert_v1beta1_ObjectStorageDestination_To_v1alpha1_ObjectStorageDestination(&in.ObjectStorage, &out.ObjectStorage, s); err != nil {
+		return err
+	}
+	out.Objects = *(*[]string)(unsafe.Pointer(&in.Objects))
+	out.URLExpiration = (*metav1.Duration)(unsafe.Pointer(in.URLExpiration))
+	return nil
+}
+
+// Convert_v1beta1_WorkspaceSpec_To_v1alpha1_WorkspaceSpec is an autogenerated conversion function.
+func Convert_v1beta1_WorkspaceSpec_To_v1alpha1_WorkspaceSpec(in *v1beta1.WorkspaceSpec, out *WorkspaceSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_WorkspaceSpec_To_v1alpha1_WorkspaceSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_WorkspaceStatus_To_v1beta1_WorkspaceStatus(in *WorkspaceStatus, out *v1beta1.WorkspaceStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.SecretName = in.SecretName
+	out.ExpirationTime = (*metav1.Time)(unsafe.Pointer(in.ExpirationTime))
+	out.Purged = in.Purged
+	return nil
+}
+
+// Convert_v1alpha1_WorkspaceStatus_To_v1beta1_WorkspaceStatus is an autogenerated conversion function.
+func Convert_v1alpha1_WorkspaceStatus_To_v1beta1_WorkspaceStatus(in *WorkspaceStatus, out *v1beta1.WorkspaceStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_WorkspaceStatus_To_v1beta1_WorkspaceStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_WorkspaceStatus_To_v1alpha1_WorkspaceStatus(in *v1beta1.WorkspaceStatus, out *WorkspaceStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.SecretName = in.SecretName
+	out.ExpirationTime = (*metav1.Time)(unsafe.Pointer(in.ExpirationTime))
+	out.Purged = in.Purged
+	return nil
+}
+
+// Convert_v1beta1_WorkspaceStatus_To_v1alpha1_WorkspaceStatus is an autogenerated conversion function.
+func Convert_v1beta1_WorkspaceStatus_To_v1alpha1_WorkspaceStatus(in *v1beta1.WorkspaceStatus, out *WorkspaceStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_WorkspaceStatus_To_v1alpha1_WorkspaceStatus(in, out, s)
+}
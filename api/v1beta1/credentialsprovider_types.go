@@ -0,0 +1,47 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+// CredentialsProviderReference references credentials resolved by a CredentialsProvider. The credentials are a set
+// of keys, as the data of a Secret: the keys of the Vault secret, or the keys of the JSON object held by the AWS or
+// GCP secret.
+type CredentialsProviderReference struct {
+	// Name is the name of the CredentialsProvider, in the namespace of the referencing object.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Path identifies the secret: its path in the secrets engine of Vault, e.g. forge/aws, the name or the ARN
+	// of the AWS secret, or the name of the GCP secret.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Version is the version of the secret, the latest by default: the version number of a KVv2 secret,
+	// the version ID of an AWS secret or the version of a GCP secret.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Key stores the whole value of an AWS or GCP secret under this key instead of reading it as a JSON object,
+	// e.g. credentials.json for the service account key of a GCPBuild.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Keys renames the keys of the secret to the keys expected by the consumer of the credentials, e.g.
+	// access_key: accessKeyID for the credentials generated by the AWS secrets engine of Vault. The keys which
+	// are not renamed are kept.
+	// +optional
+	Keys map[string]string `json:"keys,omitempty"`
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+// Health is the health of a Forge object, it maps one to one to the health statuses of the GitOps tools,
+// e.g. Argo CD, so they can assess Builds, BuildSets and ScheduledBuilds without a script per resource.
+// +kubebuilder:validation:Enum=Healthy;Progressing;Degraded;Suspended
+type Health string
+
+// HealthStatus summarizes the state of a Forge object, it is computed by the core controllers on every
+// reconciliation. The status.health.status and status.health.message fields are a stable contract,
+// see config/argocd for the Argo CD health check reading them.
+type HealthStatus struct {
+	// Status is the health of the object, one of Healthy, Progressing, Degraded or Suspended.
+	Status Health `json:"status"`
+
+	// Message is a human readable description of the health of the object.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
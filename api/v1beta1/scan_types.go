@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScanSpec defines the vulnerability scan gating a Build. The scanner runs on the machine once the provisioners
+// completed, against the filesystem the image is created from, and the Build fails without creating the image
+// when the vulnerabilities found exceed the thresholds.
+type ScanSpec struct {
+	// Scanner is the vulnerability scanner, Trivy or Grype, installed in a temporary directory along with its
+	// database when it is not on the PATH of the machine. Defaults to Trivy.
+	// +optional
+	// +kubebuilder:default=Trivy
+	// +kubebuilder:validation:Enum=Trivy;Grype
+	Scanner Scanner `json:"scanner,omitempty"`
+
+	// ScannerVersion is the version of the scanner installed when the machine has none, defaults to v0.56.2 for
+	// Trivy and v0.82.2 for Grype.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+$`
+	ScannerVersion string `json:"scannerVersion,omitempty"`
+
+	// Thresholds are the numbers of vulnerabilities of each severity the Build tolerates, defaults to no critical
+	// and no high vulnerability.
+	// +optional
+	Thresholds *ScanThresholds `json:"thresholds,omitempty"`
+
+	// IgnoreUnfixed does not count the vulnerabilities without a fixed version.
+	// +optional
+	IgnoreUnfixed bool `json:"ignoreUnfixed,omitempty"`
+
+	// Ignore are the vulnerabilities which are not counted, e.g. accepted risks.
+	// +optional
+	// +listType=map
+	// +listMapKey=id
+	Ignore []ScanIgnore `json:"ignore,omitempty"`
+
+	// Destination is where the JSON report of the scanner is stored, defaults to the ConfigMap named after the
+	// Build with the -scan suffix.
+	// +optional
+	Destination *ExtractDestination `json:"destination,omitempty"`
+}
+
+// Scanner is a vulnerability scanner.
+type Scanner string
+
+const (
+	// ScannerTrivy is the Trivy scanner of Aqua Security.
+	ScannerTrivy Scanner = "Trivy"
+
+	// ScannerGrype is the Grype scanner of Anchore.
+	ScannerGrype Scanner = "Grype"
+)
+
+// ScanThresholds are the numbers of vulnerabilities of each severity a Build tolerates, a severity without
+// threshold is not limited.
+type ScanThresholds struct {
+	// Critical is the number of critical vulnerabilities tolerated.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Critical *int32 `json:"critical,omitempty"`
+
+	// High is the number of high vulnerabilities tolerated.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	High *int32 `json:"high,omitempty"`
+
+	// Medium is the number of medium vulnerabilities tolerated.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Medium *int32 `json:"medium,omitempty"`
+
+	// Low is the number of low vulnerabilities tolerated.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Low *int32 `json:"low,omitempty"`
+}
+
+// ScanIgnore is a vulnerability which is not counted by the scan.
+type ScanIgnore struct {
+	// ID is the identifier of the vulnerability, e.g. CVE-2024-6387 or GHSA-v778-237x-gjrc.
+	// +kubebuilder:validation:MinLength=1
+	ID string `json:"id"`
+
+	// Reason documents why the vulnerability is ignored.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// ExpirationTime is when the vulnerability is counted again, it is ignored forever when not set.
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+}
+
+// ScanStatus is the outcome of the vulnerability scan of a Build.
+type ScanStatus struct {
+	// Scanner is the scanner which scanned the machine.
+	Scanner Scanner `json:"scanner"`
+
+	// Passed is true when the vulnerabilities found do not exceed the thresholds.
+	Passed bool `json:"passed"`
+
+	// Vulnerabilities are the numbers of vulnerabilities found by severity, without the ignored ones.
+	Vulnerabilities ScanSummary `json:"vulnerabilities"`
+
+	// Ignored is the number of vulnerabilities found which have been ignored.
+	// +optional
+	Ignored int32 `json:"ignored,omitempty"`
+
+	// Message describes why the scan did not pass.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Report is where the JSON report of the scanner is stored, e.g. configmap/ubuntu-scan/scan-report.json.
+	Report string `json:"report"`
+
+	// CompletionTime is when the scan completed.
+	CompletionTime metav1.Time `json:"completionTime"`
+}
+
+// ScanSummary are the numbers of vulnerabilities by severity.
+type ScanSummary struct {
+	Critical int32 `json:"critical"`
+	High     int32 `json:"high"`
+	Medium   int32 `json:"medium"`
+	Low      int32 `json:"low"`
+	Unknown  int32 `json:"unknown"`
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceSpec defines a temporary object storage prefix dedicated to a Build, where its provisioners exchange
+// the large intermediate artifacts, e.g. a root filesystem built by one provisioner and installed by another.
+// The provisioners are handed presigned URLs of the objects of the workspace, they never get the credentials of
+// the object storage. The objects of the workspace are deleted once the Build has finished, or is deleted.
+type WorkspaceSpec struct {
+	// ObjectStorage is the object storage the workspace is created in, the workspace of a Build is the
+	// <namespace>/<name>-<uid>/ prefix under its URL. The credentials must be allowed to list and delete the objects.
+	ObjectStorage ObjectStorageDestination `json:"objectStorage"`
+
+	// Objects are the names of the objects of the workspace. The shell provisioners get a presigned URL to download
+	// and a presigned URL to upload every object, in the FORGE_WORKSPACE_<NAME>_GET_URL and FORGE_WORKSPACE_<NAME>_PUT_URL
+	// environment variables, where NAME is the name of the object upper cased with dots and dashes replaced by underscores.
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:Pattern=`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`
+	Objects []string `json:"objects"`
+
+	// URLExpiration is how long the presigned URLs are valid, at most 168h, defaults to 6h. The URLs are renewed
+	// once half of their validity has elapsed, a provisioner is started with URLs valid for at least half of it.
+	// +optional
+	URLExpiration *metav1.Duration `json:"urlExpiration,omitempty"`
+}
+
+// WorkspaceStatus is the status of the workspace of a Build.
+type WorkspaceStatus struct {
+	// URL is the s3:// URL of the prefix of the workspace.
+	URL string `json:"url"`
+
+	// SecretName is the name of the secret holding the presigned URLs of the objects of the workspace,
+	// it is deleted along with the objects.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// ExpirationTime is the time the presigned URLs of the workspace expire.
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+
+	// Purged is true once the objects of the workspace have been deleted.
+	// +optional
+	Purged bool `json:"purged,omitempty"`
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// fieldOwner is the field manager used by forgectl when applying objects.
+	fieldOwner = client.FieldOwner("forgectl")
+
+	crdKind       = "CustomResourceDefinition"
+	namespaceKind = "Namespace"
+)
+
+// parseManifests decodes a multi-document YAML or JSON stream into unstructured objects,
+// empty documents are skipped.
+func parseManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	objs := []*unstructured.Unstructured{}
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decoding manifests: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetKind() == "" {
+			return nil, fmt.Errorf("decoding manifests: object %q has no kind", obj.GetName())
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// sortForApply orders objects so that CustomResourceDefinitions and Namespaces are applied
+// before the objects depending on them, preserving the original order otherwise.
+func sortForApply(objs []*unstructured.Unstructured) {
+	priority := func(obj *unstructured.Unstructured) int {
+		switch obj.GetKind() {
+		case crdKind:
+			return 0
+		case namespaceKind:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return priority(objs[i]) < priority(objs[j])
+	})
+}
+
+// applyManifests server-side applies the given objects, waiting for the CustomResourceDefinitions
+// to be established before applying the custom resources.
+func applyManifests(ctx context.Context, c client.Client, out io.Writer, objs []*unstructured.Unstructured) error {
+	sortForApply(objs)
+
+	crds := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if obj.GetKind() != crdKind && len(crds) > 0 {
+			if err := waitForCRDs(ctx, c, crds); err != nil {
+				return err
+			}
+			crds = nil
+		}
+		if err := c.Patch(ctx, obj, client.Apply, fieldOwner, client.ForceOwnership); err != nil {
+			return fmt.Errorf("applying %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+		fmt.Fprintf(out, "%s/%s applied\n", obj.GetKind(), obj.GetName())
+		if obj.GetKind() == crdKind {
+			crds = append(crds, obj)
+		}
+	}
+	if len(crds) > 0 {
+		return waitForCRDs(ctx, c, crds)
+	}
+	return nil
+}
+
+// waitForCRDs waits for the given CustomResourceDefinitions to report the Established condition.
+func waitForCRDs(ctx context.Context, c client.Client, crds []*unstructured.Unstructured) error {
+	for _, crd := range crds {
+		err := wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+			current := &unstructured.Unstructured{}
+			current.SetGroupVersionKind(crd.GroupVersionKind())
+			if err := c.Get(ctx, client.ObjectKeyFromObject(crd), current); err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+			conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+			for _, condition := range conditions {
+				m, ok := condition.(map[string]interface{})
+				if ok && m["type"] == "Established" && m["status"] == "True" {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("waiting for CustomResourceDefinition %s to be established: %w", crd.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// readManifests reads the manifests of the given file, - for the input.
+func readManifests(in io.Reader, filename string) ([]*unstructured.Unstructured, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(in)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	return parseManifests(data)
+}
+
+// fetchManifests downloads the manifests at the given URL.
+func fetchManifests(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
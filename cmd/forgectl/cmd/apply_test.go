@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseManifests(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := parseManifests([]byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: manager
+---
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: dockerbuilds.infrastructure.forge.build
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: forge-docker-system
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(objs).To(HaveLen(3))
+
+	sortForApply(objs)
+	g.Expect(objs[0].GetKind()).To(Equal(crdKind))
+	g.Expect(objs[1].GetKind()).To(Equal(namespaceKind))
+	g.Expect(objs[2].GetKind()).To(Equal("ServiceAccount"))
+
+	_, err = parseManifests([]byte("metadata:\n  name: foo\n"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestExamples(t *testing.T) {
+	g := NewWithT(t)
+
+	data, err := examples.ReadFile("examples/docker.yaml")
+	g.Expect(err).ToNot(HaveOccurred())
+	objs, err := parseManifests(data)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	found := false
+	for _, obj := range objs {
+		if obj.GetKind() == "Build" {
+			found = obj.GetName() == quickstartBuildName
+		}
+	}
+	g.Expect(found).To(BeTrue())
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/annotations"
+)
+
+type buildOptions struct {
+	selector      string
+	all           bool
+	allNamespaces bool
+	yes           bool
+}
+
+var buildOpts = &buildOptions{}
+
+// buildOperation is an operation applied in bulk to the selected Builds.
+type buildOperation struct {
+	// verb and done describe the operation in the confirmation summary, e.g. pause and paused.
+	verb string
+	done string
+	// applies returns true if the operation applies to the Build, the other selected Builds are skipped.
+	applies func(build *buildv1.Build) bool
+	// apply applies the operation to the Build.
+	apply func(ctx context.Context, c client.Client, build *buildv1.Build) error
+}
+
+var (
+	pauseOperation = buildOperation{
+		verb: "pause",
+		done: "paused",
+		applies: func(build *buildv1.Build) bool {
+			return !build.Spec.Paused && !isFinished(build)
+		},
+		apply: func(ctx context.Context, c client.Client, build *buildv1.Build) error {
+			return setPaused(ctx, c, build, true)
+		},
+	}
+
+	resumeOperation = buildOperation{
+		verb: "resume",
+		done: "resumed",
+		applies: func(build *buildv1.Build) bool {
+			return build.Spec.Paused
+		},
+		apply: func(ctx context.Context, c client.Client, build *buildv1.Build) error {
+			return setPaused(ctx, c, build, false)
+		},
+	}
+
+	cancelOperation = buildOperation{
+		verb: "cancel",
+		done: "cancelled",
+		applies: func(build *buildv1.Build) bool {
+			return !isFinished(build) && build.DeletionTimestamp.IsZero()
+		},
+		apply: func(ctx context.Context, c client.Client, build *buildv1.Build) error {
+			return client.IgnoreNotFound(c.Delete(ctx, build))
+		},
+	}
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Create, follow and operate Builds",
+	Long: "Create a Build and follow its progress until it finishes. " +
+		"Pause, resume or cancel the Builds selected by name, by label selector or all the Builds of a namespace, " +
+		"or approve the provisioners of a Build. " +
+		"The selected Builds are listed and the operation has to be confirmed, unless --yes is set.",
+}
+
+var buildPauseCmd = &cobra.Command{
+	Use:   "pause [NAME...]",
+	Short: "Pause the in-flight Builds",
+	Long: "Pause the in-flight Builds, the controllers stop reconciling paused Builds and their infrastructure " +
+		"until they are resumed. Completed and failed Builds are skipped.",
+	Example: `  # Pause the Builds of the platform team in all namespaces
+  forgectl build pause -l team=platform --all-namespaces`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBuildOperation(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), pauseOperation, args)
+	},
+}
+
+var buildResumeCmd = &cobra.Command{
+	Use:   "resume [NAME...]",
+	Short: "Resume the paused Builds",
+	Example: `  # Resume the Builds of the platform team in all namespaces
+  forgectl build resume -l team=platform --all-namespaces`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBuildOperation(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), resumeOperation, args)
+	},
+}
+
+var buildCancelCmd = &cobra.Command{
+	Use:   "cancel [NAME...]",
+	Short: "Cancel the in-flight Builds",
+	Long: "Cancel the in-flight Builds by deleting them, the controller deletes their infrastructure. " +
+		"Completed and failed Builds are skipped.",
+	Example: `  # Cancel all the Builds of the imgs namespace without confirmation
+  forgectl build cancel --all --namespace imgs --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBuildOperation(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), cancelOperation, args)
+	},
+}
+
+var buildApproveCmd = &cobra.Command{
+	Use:   "approve NAME [PROVISIONER...]",
+	Short: "Approve the provisioners of a Build requiring approval",
+	Long: "Approve the provisioner the Build is waiting for, or approve the given provisioners ahead of time. " +
+		"The provisioners requiring approval don't run until they are approved.",
+	Example: `  # Approve the provisioner the Build ubuntu is waiting for
+  forgectl build approve ubuntu
+
+  # Approve the wipe-disk provisioner of the Build ubuntu before it is reached
+  forgectl build approve ubuntu wipe-disk`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		namespace, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		return approveProvisioners(cmd.Context(), c, cmd.OutOrStdout(), client.ObjectKey{Namespace: namespace, Name: args[0]}, args[1:])
+	},
+}
+
+func init() {
+	buildCmd.PersistentFlags().StringVarP(&buildOpts.selector, "selector", "l", "",
+		"Label selector of the Builds, e.g. team=platform")
+	buildCmd.PersistentFlags().BoolVar(&buildOpts.all, "all", false,
+		"Select all the Builds of the namespace")
+	buildCmd.PersistentFlags().BoolVarP(&buildOpts.allNamespaces, "all-namespaces", "A", false,
+		"Select the Builds of all namespaces instead of the current namespace")
+	buildCmd.PersistentFlags().BoolVarP(&buildOpts.yes, "yes", "y", false,
+		"Do not ask for confirmation")
+
+	buildCmd.AddCommand(buildPauseCmd, buildResumeCmd, buildCancelCmd, buildApproveCmd)
+	rootCmd.AddCommand(buildCmd)
+}
+
+func runBuildOperation(ctx context.Context, in io.Reader, out io.Writer, op buildOperation, names []string) error {
+	if len(names) == 0 && buildOpts.selector == "" && !buildOpts.all {
+		return errors.New("select the Builds by name, with --selector or with --all")
+	}
+	if len(names) > 0 && (buildOpts.selector != "" || buildOpts.all) {
+		return errors.New("build names can't be combined with --selector or --all")
+	}
+
+	c, err := globalOpts.newClient()
+	if err != nil {
+		return err
+	}
+	namespace := ""
+	if !buildOpts.allNamespaces {
+		if namespace, err = globalOpts.currentNamespace(); err != nil {
+			return err
+		}
+	}
+
+	builds, err := selectBuilds(ctx, c, namespace, buildOpts.selector, names)
+	if err != nil {
+		return err
+	}
+	return applyBuildOperation(ctx, c, in, out, op, builds, buildOpts.yes)
+}
+
+// selectBuilds returns the Builds of the namespace, all namespaces if empty, matching the selector or the names.
+func selectBuilds(ctx context.Context, c client.Client, namespace, selector string, names []string) ([]buildv1.Build, error) {
+	if len(names) > 0 {
+		builds := make([]buildv1.Build, 0, len(names))
+		for _, name := range names {
+			build := buildv1.Build{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &build); err != nil {
+				return nil, fmt.Errorf("getting Build %s: %w", name, err)
+			}
+			builds = append(builds, build)
+		}
+		return builds, nil
+	}
+
+	listOpts := []client.ListOption{}
+	if namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+	if selector != "" {
+		s, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing selector %q: %w", selector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: s})
+	}
+	list := &buildv1.BuildList{}
+	if err := c.List(ctx, list, listOpts...); err != nil {
+		return nil, fmt.Errorf("listing Builds: %w", err)
+	}
+	return list.Items, nil
+}
+
+// applyBuildOperation prints the summary of the Builds the operation applies to, asks for confirmation unless yes
+// is set, and applies the operation. It carries on when the operation fails on a Build and reports the failures.
+func applyBuildOperation(ctx context.Context, c client.Client, in io.Reader, out io.Writer, op buildOperation, builds []buildv1.Build, yes bool) error {
+	selected := []*buildv1.Build{}
+	for i := range builds {
+		if op.applies(&builds[i]) {
+			selected = append(selected, &builds[i])
+		}
+	}
+	if skipped := len(builds) - len(selected); skipped > 0 {
+		fmt.Fprintf(out, "%d selected Builds can't be %s and are skipped\n", skipped, op.done)
+	}
+	if len(selected) == 0 {
+		fmt.Fprintf(out, "No Builds to %s\n", op.verb)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tPHASE\tINFRASTRUCTURE")
+	for _, build := range selected {
+		infrastructure := ""
+		if build.Spec.InfrastructureRef != nil {
+			infrastructure = build.Spec.InfrastructureRef.Kind + "/" + build.Spec.InfrastructureRef.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", build.Namespace, build.Name, build.Status.Phase, infrastructure)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !yes {
+		confirmed, err := confirm(in, out, fmt.Sprintf("%s %d Builds?", capitalize(op.verb), len(selected)))
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, build := range selected {
+		if err := op.apply(ctx, c, build); err != nil {
+			fmt.Fprintf(out, "Build %s not %s: %v\n", client.ObjectKeyFromObject(build), op.done, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Build %s %s\n", client.ObjectKeyFromObject(build), op.done)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d Builds not %s", failed, len(selected), op.done)
+	}
+	return nil
+}
+
+// approveProvisioners approves the given provisioners of the Build, or the provisioner it is waiting for if none is given.
+func approveProvisioners(ctx context.Context, c client.Client, out io.Writer, key client.ObjectKey, provisioners []string) error {
+	build := &buildv1.Build{}
+	if err := c.Get(ctx, key, build); err != nil {
+		return fmt.Errorf("getting Build %s: %w", key, err)
+	}
+	if len(provisioners) == 0 {
+		if build.Status.AwaitingApproval == "" {
+			return fmt.Errorf("build %s is not waiting for approval", key)
+		}
+		provisioners = []string{build.Status.AwaitingApproval}
+	}
+
+	original := build.DeepCopy()
+	for _, name := range provisioners {
+		found := false
+		for _, p := range build.Spec.Provisioners {
+			if p.Name == name && p.RequireApproval {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("build %s has no provisioner %s requiring approval", key, name)
+		}
+		annotations.ApproveProvisioner(build, name)
+	}
+	if err := c.Patch(ctx, build, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("approving the provisioners of Build %s: %w", key, err)
+	}
+	for _, name := range provisioners {
+		fmt.Fprintf(out, "Provisioner %s of Build %s approved\n", name, key)
+	}
+	return nil
+}
+
+// setPaused sets spec.paused of the Build.
+func setPaused(ctx context.Context, c client.Client, build *buildv1.Build, paused bool) error {
+	original := build.DeepCopy()
+	build.Spec.Paused = paused
+	return c.Patch(ctx, build, client.MergeFrom(original))
+}
+
+// isFinished returns true if the Build is completed or failed and not going to be retried.
+func isFinished(build *buildv1.Build) bool {
+	switch build.Status.GetTypedPhase() {
+	case buildv1.BuildPhaseCompleted:
+		return true
+	case buildv1.BuildPhaseFailed:
+		return build.Status.NextRetryTime == nil
+	}
+	return false
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// confirm asks the question and reports whether it is answered yes, printing Aborted otherwise.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("reading confirmation: %w", err)
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		fmt.Fprintln(out, "Aborted")
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// defaultProvisionerNamespace is the namespace of the Jobs of the provisioners placed in the core namespace, see
+// the --shell-provisioner-namespace flag of the controller.
+const defaultProvisionerNamespace = "forge-core"
+
+// streamsGracePeriod is how long the logs of the provisioners are still streamed once the Build finished.
+const streamsGracePeriod = 10 * time.Second
+
+type buildCreateOptions struct {
+	filename             string
+	wait                 bool
+	timeout              time.Duration
+	logs                 bool
+	provisionerNamespace string
+}
+
+var buildCreateOpts = &buildCreateOptions{}
+
+var buildCreateCmd = &cobra.Command{
+	Use:   "create -f FILE",
+	Short: "Create a Build and wait for it to finish",
+	Long: "Apply the Build of the file along with the objects it references, e.g. its infrastructure and the " +
+		"configmaps of its scripts. With --wait, the changes of the phase, conditions and provisioners of the Build " +
+		"and the logs of its provisioners are printed until it finishes, forgectl exits with a non-zero status " +
+		"if the Build fails or doesn't finish within --timeout.",
+	Example: `  # Create the Build of build.yaml and wait for it in a CI pipeline
+  forgectl build create -f build.yaml --wait --timeout 1h
+
+  # Create a Build from the standard input
+  cat build.yaml | forgectl build create -f -`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runBuildCreate(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+var buildTailCmd = &cobra.Command{
+	Use:   "tail NAME",
+	Short: "Print the progress of a Build until it finishes",
+	Long: "Print the changes of the phase, conditions and provisioners of the Build and the logs of its provisioners " +
+		"until it finishes, forgectl exits with a non-zero status if the Build fails or doesn't finish within --timeout.",
+	Example: `  # Follow the Build ubuntu
+  forgectl build tail ubuntu`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		namespace, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		return tailBuild(cmd.Context(), c, cmd.OutOrStdout(), client.ObjectKey{Namespace: namespace, Name: args[0]})
+	},
+}
+
+func init() {
+	buildCreateCmd.Flags().StringVarP(&buildCreateOpts.filename, "filename", "f", "",
+		"The file holding the Build and the objects it references, - for the standard input")
+	buildCreateCmd.Flags().BoolVar(&buildCreateOpts.wait, "wait", false,
+		"Wait for the Build to finish, printing its progress")
+	buildCreateCmd.Flags().DurationVar(&buildCreateOpts.timeout, "timeout", 0,
+		"How long to wait for the Build to finish, 0 waits forever")
+	buildCreateCmd.Flags().BoolVar(&buildCreateOpts.logs, "logs", true,
+		"Print the logs of the provisioners while waiting")
+	buildCreateCmd.Flags().StringVar(&buildCreateOpts.provisionerNamespace, "provisioner-namespace", defaultProvisionerNamespace,
+		"The namespace of the Jobs of the provisioners placed in the core namespace")
+	_ = buildCreateCmd.MarkFlagRequired("filename")
+	buildTailCmd.Flags().DurationVar(&buildCreateOpts.timeout, "timeout", 0,
+		"How long to wait for the Build to finish, 0 waits forever")
+	buildTailCmd.Flags().BoolVar(&buildCreateOpts.logs, "logs", true,
+		"Print the logs of the provisioners")
+	buildTailCmd.Flags().StringVar(&buildCreateOpts.provisionerNamespace, "provisioner-namespace", defaultProvisionerNamespace,
+		"The namespace of the Jobs of the provisioners placed in the core namespace")
+
+	buildCmd.AddCommand(buildCreateCmd, buildTailCmd)
+}
+
+func runBuildCreate(ctx context.Context, in io.Reader, out io.Writer) error {
+	objs, err := readManifests(in, buildCreateOpts.filename)
+	if err != nil {
+		return err
+	}
+
+	c, err := globalOpts.newClient()
+	if err != nil {
+		return err
+	}
+	namespace, err := globalOpts.currentNamespace()
+	if err != nil {
+		return err
+	}
+	key, err := createBuild(ctx, c, out, namespace, objs)
+	if err != nil || !buildCreateOpts.wait {
+		return err
+	}
+	return tailBuild(ctx, c, out, key)
+}
+
+// tailBuild prints the progress of the Build until it finishes, as per the --timeout, --logs and
+// --provisioner-namespace flags.
+func tailBuild(ctx context.Context, c client.Client, out io.Writer, key client.ObjectKey) error {
+	if buildCreateOpts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, buildCreateOpts.timeout)
+		defer cancel()
+	}
+	w := &buildWatcher{
+		client:               c,
+		out:                  &syncWriter{w: out},
+		interval:             2 * time.Second,
+		provisionerNamespace: buildCreateOpts.provisionerNamespace,
+	}
+	if buildCreateOpts.logs {
+		var err error
+		if w.clientset, err = globalOpts.newClientset(); err != nil {
+			return err
+		}
+	}
+	return w.watch(ctx, key)
+}
+
+// createBuild applies the objects in the namespace, unless they set theirs, and returns the key of the Build. The
+// objects with a generated name are created instead. The objects must hold exactly one Build.
+func createBuild(ctx context.Context, c client.Client, out io.Writer, namespace string, objs []*unstructured.Unstructured) (client.ObjectKey, error) {
+	var build *unstructured.Unstructured
+	applied, created := []*unstructured.Unstructured{}, []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if obj.GetNamespace() == "" && obj.GetKind() != crdKind && obj.GetKind() != namespaceKind {
+			obj.SetNamespace(namespace)
+		}
+		if obj.GroupVersionKind().Group == buildv1.GroupVersion.Group && obj.GetKind() == "Build" {
+			if build != nil {
+				return client.ObjectKey{}, errors.New("the file holds more than one Build")
+			}
+			build = obj
+		}
+		if obj.GetName() == "" && obj.GetGenerateName() != "" {
+			created = append(created, obj)
+			continue
+		}
+		applied = append(applied, obj)
+	}
+	if build == nil {
+		return client.ObjectKey{}, errors.New("the file holds no Build")
+	}
+
+	if err := applyManifests(ctx, c, out, applied); err != nil {
+		return client.ObjectKey{}, err
+	}
+	for _, obj := range created {
+		if err := c.Create(ctx, obj, fieldOwner); err != nil {
+			return client.ObjectKey{}, fmt.Errorf("creating %s %s: %w", obj.GetKind(), obj.GetGenerateName(), err)
+		}
+		fmt.Fprintf(out, "%s/%s created\n", obj.GetKind(), obj.GetName())
+	}
+	return client.ObjectKeyFromObject(build), nil
+}
+
+// buildWatcher prints the progress of a Build until it finishes.
+type buildWatcher struct {
+	client client.Client
+	// clientset streams the logs of the provisioners, they are not printed if nil.
+	clientset kubernetes.Interface
+	out       io.Writer
+	interval  time.Duration
+	// provisionerNamespace is the namespace of the Jobs of the provisioners placed in the core namespace.
+	provisionerNamespace string
+	// provisionerID restricts the logs to the provisioner with the UUID, if set.
+	provisionerID string
+	// logsOnly only prints the logs of the provisioners, not the progress of the Build.
+	logsOnly bool
+
+	phase        string
+	progress     string
+	conditions   map[string]string
+	provisioners map[string]string
+	// streamed are the pods whose logs are streamed.
+	streamed map[string]bool
+	streams  sync.WaitGroup
+}
+
+// watch prints the progress of the Build until it finishes. It returns an error if the Build fails, is deleted or
+// the context is done first.
+func (w *buildWatcher) watch(ctx context.Context, key client.ObjectKey) error {
+	w.conditions, w.provisioners, w.streamed = map[string]string{}, map[string]string{}, map[string]bool{}
+	streamsCtx, cancelStreams := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelStreams()
+
+	var completed *buildv1.Build
+	var result error
+	err := wait.PollUntilContextCancel(ctx, w.interval, true, func(ctx context.Context) (bool, error) {
+		build := &buildv1.Build{}
+		if err := w.client.Get(ctx, key, build); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("build %s has been deleted", key)
+			}
+			fmt.Fprintf(w.out, "Failed to get Build %s: %v\n", key, err)
+			return false, nil
+		}
+		if !w.logsOnly {
+			w.report(build)
+		}
+		if w.clientset != nil {
+			w.streamLogs(ctx, streamsCtx, build)
+		}
+
+		switch {
+		case build.Status.GetTypedPhase() == buildv1.BuildPhaseCompleted:
+			completed = build
+			return true, nil
+		case isFinished(build):
+			result = fmt.Errorf("build %s failed: %s: %s", key,
+				ptr.Deref(build.Status.FailureReason, ""), ptr.Deref(build.Status.FailureMessage, ""))
+			return true, nil
+		}
+		return false, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		w.streams.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(streamsGracePeriod):
+	}
+
+	switch {
+	case w.logsOnly && (err == nil || wait.Interrupted(err)):
+		return nil
+	case wait.Interrupted(err):
+		return fmt.Errorf("timed out waiting for Build %s to finish", key)
+	case err != nil:
+		return err
+	case completed != nil:
+		// The logs of the provisioners are printed first.
+		fmt.Fprintf(w.out, "Build %s completed, image %s\n", key, completed.Status.ImageRef)
+	}
+	return result
+}
+
+// report prints the changes of the phase, conditions and provisioners of the Build since the last report.
+func (w *buildWatcher) report(build *buildv1.Build) {
+	if build.Status.Phase != w.phase {
+		w.phase = build.Status.Phase
+		fmt.Fprintf(w.out, "Phase: %s\n", w.phase)
+	}
+	if progress := formatProgress(build.Status.Progress); progress != "" && progress != w.progress {
+		w.progress = progress
+		fmt.Fprintf(w.out, "Progress: %s\n", progress)
+	}
+	for _, condition := range build.Status.Conditions {
+		state := fmt.Sprintf("%s/%s/%s", condition.Status, condition.Reason, condition.Message)
+		if w.conditions[condition.Type] == state {
+			continue
+		}
+		w.conditions[condition.Type] = state
+		line := fmt.Sprintf("Condition %s=%s", condition.Type, condition.Status)
+		if condition.Reason != "" {
+			line += fmt.Sprintf(" (%s)", condition.Reason)
+		}
+		if condition.Message != "" {
+			line += ": " + condition.Message
+		}
+		fmt.Fprintln(w.out, line)
+	}
+	for _, p := range build.Spec.Provisioners {
+		status := string(ptr.Deref(p.Status, buildv1.ProvisionerStatusPending))
+		previous, ok := w.provisioners[p.Name]
+		w.provisioners[p.Name] = status
+		// The provisioners are reported once they start.
+		if previous == status || !ok && status == string(buildv1.ProvisionerStatusPending) {
+			continue
+		}
+		fmt.Fprintf(w.out, "Provisioner %s: %s\n", p.Name, status)
+	}
+}
+
+// formatProgress formats the progress of a Build as a bar, e.g. [=====>    ] 50% step 3/6: Running provisioner harden.
+func formatProgress(progress *buildv1.BuildProgress) string {
+	if progress == nil {
+		return ""
+	}
+	const width = 20
+	filled := int(progress.Percent) * width / 100
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	line := fmt.Sprintf("[%s] %3d%% step %d/%d", bar, progress.Percent, progress.CurrentStep, progress.TotalSteps)
+	if progress.Message != "" {
+		line += ": " + progress.Message
+	}
+	return line
+}
+
+// streamLogs streams the logs of the pods of the provisioners of the Build which started since the last call, each
+// line prefixed with the name of the provisioner.
+func (w *buildWatcher) streamLogs(ctx, streamsCtx context.Context, build *buildv1.Build) {
+	pods, err := provisionerPods(ctx, w.clientset, build, w.provisionerNamespace, w.provisionerID)
+	if err != nil {
+		return
+	}
+	for _, pod := range pods {
+		if w.streamed[pod.Name] || pod.Status.Phase == corev1.PodPending {
+			continue
+		}
+		stream, err := w.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Follow: true}).Stream(streamsCtx)
+		if err != nil {
+			continue
+		}
+		w.streamed[pod.Name] = true
+		prefix := logsPrefix(build, &pod)
+		w.streams.Add(1)
+		go func() {
+			defer w.streams.Done()
+			defer stream.Close()
+			printLines(w.out, stream, prefix)
+		}()
+	}
+}
+
+// syncWriter serializes the writes of the goroutines streaming the logs.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
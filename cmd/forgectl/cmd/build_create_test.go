@@ -0,0 +1,135 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestCreateBuild(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	objs, err := parseManifests([]byte(`
+apiVersion: forge.build/v1alpha1
+kind: Build
+metadata:
+  generateName: ubuntu-
+spec:
+  connector:
+    type: ssh
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+	out := &bytes.Buffer{}
+	key, err := createBuild(ctx, c, out, "images", objs)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(key.Namespace).To(Equal("images"))
+	g.Expect(key.Name).To(HavePrefix("ubuntu-"))
+	g.Expect(out.String()).To(ContainSubstring("Build/" + key.Name + " created"))
+	g.Expect(c.Get(ctx, key, &buildv1.Build{})).To(Succeed())
+
+	objs, err = parseManifests([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: scripts\n"))
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = createBuild(ctx, c, out, "images", objs)
+	g.Expect(err).To(MatchError("the file holds no Build"))
+}
+
+func TestWatchBuild(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{
+			ProvisionerJobPlacement: buildv1.ProvisionerJobPlacementBuild,
+			Provisioners: []buildv1.ProvisionerSpec{
+				{Name: "base", UUID: ptr.To("0b5f"), Status: ptr.To(buildv1.ProvisionerStatusCompleted)},
+				{Name: "app", UUID: ptr.To("1c6a")},
+			},
+		},
+	}
+	build.Status.SetTypedPhase(buildv1.BuildPhaseCompleted)
+	build.Status.ImageRef = "ami-1"
+	build.Status.Progress = &buildv1.BuildProgress{CurrentStep: 5, TotalSteps: 5, Percent: 100, Message: "Build completed"}
+	build.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "forge-provisioner-shell-1", Labels: map[string]string{
+			buildv1.BuildNameLabel:      "ubuntu",
+			buildv1.BuildNamespaceLabel: "images",
+			buildv1.ProvisionerIDLabel:  "0b5f",
+		}},
+		Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+
+	out := &bytes.Buffer{}
+	w := &buildWatcher{
+		client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build(),
+		clientset: kubefake.NewSimpleClientset(pod),
+		out:       &syncWriter{w: out},
+		interval:  time.Millisecond,
+	}
+	g.Expect(w.watch(ctx, client.ObjectKeyFromObject(build))).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Phase: Completed\n"))
+	g.Expect(out.String()).To(ContainSubstring("Progress: [====================] 100% step 5/5: Build completed\n"))
+	g.Expect(out.String()).To(ContainSubstring("Condition Ready=True (Ready)\n"))
+	g.Expect(out.String()).To(ContainSubstring("Provisioner base: Completed\n"))
+	g.Expect(out.String()).ToNot(ContainSubstring("Provisioner app"))
+	g.Expect(out.String()).To(ContainSubstring("[base] fake logs\n"))
+	g.Expect(out.String()).To(HaveSuffix("Build images/ubuntu completed, image ami-1\n"))
+
+	// A failed Build which is not retried is an error.
+	build.Status.SetTypedPhase(buildv1.BuildPhaseFailed)
+	build.Status.FailureReason = ptr.To(forgeerrors.ProvisionerFailedError)
+	build.Status.FailureMessage = ptr.To("provisioner app failed")
+	w = &buildWatcher{
+		client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build(),
+		out:      &bytes.Buffer{},
+		interval: time.Millisecond,
+	}
+	g.Expect(w.watch(ctx, client.ObjectKeyFromObject(build))).To(MatchError("build images/ubuntu failed: ProvisionerFailed: provisioner app failed"))
+
+	// The Build must finish before the deadline.
+	build.Status.SetTypedPhase(buildv1.BuildPhaseBuilding)
+	w.client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build()
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	g.Expect(w.watch(ctx, client.ObjectKeyFromObject(build))).To(MatchError(ContainSubstring("timed out waiting for Build images/ubuntu")))
+}
+
+func TestFormatProgress(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(formatProgress(nil)).To(BeEmpty())
+	g.Expect(formatProgress(&buildv1.BuildProgress{CurrentStep: 1, TotalSteps: 4})).To(Equal("[>                   ]   0% step 1/4"))
+	g.Expect(formatProgress(&buildv1.BuildProgress{CurrentStep: 3, TotalSteps: 4, Percent: 50, Message: "Running provisioner harden"})).
+		To(Equal("[==========>         ]  50% step 3/4: Running provisioner harden"))
+}
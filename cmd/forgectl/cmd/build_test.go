@@ -0,0 +1,214 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func newTestBuild(namespace, name string, phase buildv1.BuildPhase, paused bool, labels map[string]string) *buildv1.Build {
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	build.Spec.Paused = paused
+	build.Status.SetTypedPhase(phase)
+	return build
+}
+
+func TestSelectBuilds(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newTestBuild("imgs", "a", buildv1.BuildPhaseBuilding, false, map[string]string{"team": "platform"}),
+		newTestBuild("imgs", "b", buildv1.BuildPhaseBuilding, false, nil),
+		newTestBuild("other", "c", buildv1.BuildPhaseBuilding, false, map[string]string{"team": "platform"}),
+	).Build()
+
+	names := func(builds []buildv1.Build) []string {
+		out := []string{}
+		for _, build := range builds {
+			out = append(out, build.Namespace+"/"+build.Name)
+		}
+		return out
+	}
+
+	builds, err := selectBuilds(ctx, c, "imgs", "", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(names(builds)).To(ConsistOf("imgs/a", "imgs/b"))
+
+	builds, err = selectBuilds(ctx, c, "", "team=platform", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(names(builds)).To(ConsistOf("imgs/a", "other/c"))
+
+	builds, err = selectBuilds(ctx, c, "imgs", "", []string{"b"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(names(builds)).To(ConsistOf("imgs/b"))
+
+	_, err = selectBuilds(ctx, c, "imgs", "", []string{"c"})
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = selectBuilds(ctx, c, "imgs", "team in (", nil)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestApplyBuildOperation(t *testing.T) {
+	testcases := []struct {
+		name            string
+		op              buildOperation
+		input           string
+		yes             bool
+		expectedPaused  []string
+		expectedDeleted []string
+		expectedOutput  []string
+	}{
+		{
+			name:           "pause confirmed",
+			op:             pauseOperation,
+			input:          "y\n",
+			expectedPaused: []string{"running", "paused"},
+			expectedOutput: []string{"2 selected Builds can't be paused and are skipped", "Pause 1 Builds? [y/N]", "Build imgs/running paused"},
+		},
+		{
+			name:           "pause aborted",
+			op:             pauseOperation,
+			input:          "\n",
+			expectedPaused: []string{"paused"},
+			expectedOutput: []string{"Aborted"},
+		},
+		{
+			name:           "resume without confirmation",
+			op:             resumeOperation,
+			yes:            true,
+			expectedPaused: []string{},
+			expectedOutput: []string{"Build imgs/paused resumed"},
+		},
+		{
+			name:            "cancel",
+			op:              cancelOperation,
+			input:           "yes\n",
+			expectedDeleted: []string{"running", "paused"},
+			expectedOutput:  []string{"Cancel 2 Builds? [y/N]", "Build imgs/running cancelled", "Build imgs/paused cancelled"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newTestBuild("imgs", "running", buildv1.BuildPhaseProvisioning, false, nil),
+				newTestBuild("imgs", "paused", buildv1.BuildPhaseBuilding, true, nil),
+				newTestBuild("imgs", "completed", buildv1.BuildPhaseCompleted, false, nil),
+			).Build()
+			builds, err := selectBuilds(ctx, c, "imgs", "", nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			out := &bytes.Buffer{}
+			g.Expect(applyBuildOperation(ctx, c, strings.NewReader(tc.input), out, tc.op, builds, tc.yes)).To(Succeed())
+			for _, line := range tc.expectedOutput {
+				g.Expect(out.String()).To(ContainSubstring(line))
+			}
+
+			paused := []string{}
+			for _, name := range []string{"running", "paused", "completed"} {
+				build := &buildv1.Build{}
+				err := c.Get(ctx, client.ObjectKey{Namespace: "imgs", Name: name}, build)
+				if apierrors.IsNotFound(err) {
+					g.Expect(tc.expectedDeleted).To(ContainElement(name))
+					continue
+				}
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(tc.expectedDeleted).ToNot(ContainElement(name))
+				if build.Spec.Paused {
+					paused = append(paused, name)
+				}
+			}
+			if len(tc.expectedDeleted) == 0 {
+				g.Expect(paused).To(ConsistOf(tc.expectedPaused))
+			}
+		})
+	}
+}
+
+func TestApproveProvisioners(t *testing.T) {
+	testcases := []struct {
+		name         string
+		provisioners []string
+		awaiting     string
+		expected     string
+		wantErr      string
+	}{
+		{
+			name:     "provisioner the Build is waiting for",
+			awaiting: "wipe-disk",
+			expected: "wipe-disk",
+		},
+		{
+			name:         "provisioners approved ahead of time",
+			provisioners: []string{"wipe-disk", "reboot"},
+			expected:     "wipe-disk,reboot",
+		},
+		{
+			name:    "Build not waiting for approval",
+			wantErr: "not waiting for approval",
+		},
+		{
+			name:         "provisioner not requiring approval",
+			provisioners: []string{"install"},
+			wantErr:      "no provisioner install requiring approval",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			build := newTestBuild("imgs", "ubuntu", buildv1.BuildPhaseBuilding, false, nil)
+			build.Spec.Provisioners = []buildv1.ProvisionerSpec{
+				{Name: "install", Type: buildv1.ProvisionerTypeShell},
+				{Name: "wipe-disk", Type: buildv1.ProvisionerTypeShell, RequireApproval: true},
+				{Name: "reboot", Type: buildv1.ProvisionerTypeShell, RequireApproval: true},
+			}
+			build.Status.AwaitingApproval = tc.awaiting
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build()
+
+			out := &bytes.Buffer{}
+			err := approveProvisioners(ctx, c, out, client.ObjectKeyFromObject(build), tc.provisioners)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(out.String()).To(ContainSubstring("Provisioner wipe-disk of Build imgs/ubuntu approved"))
+
+			updated := &buildv1.Build{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+			g.Expect(updated.Annotations).To(HaveKeyWithValue(buildv1.ApprovedProvisionersAnnotation, tc.expected))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// controllerEndpoint gives access to the HTTP server of a running controller pod through a port forward,
+// so servers bound to the pod loopback interface, e.g. behind kube-rbac-proxy, can be reached.
+type controllerEndpoint struct {
+	pod     string
+	baseURL string
+	stop    chan struct{}
+}
+
+// openControllerEndpoint port forwards to the given port of a running controller pod.
+func openControllerEndpoint(ctx context.Context, namespace, selector string, port int) (*controllerEndpoint, error) {
+	cfg, err := globalOpts.restConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("listing controller pods: %w", err)
+	}
+	var pod *corev1.Pod
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning {
+			pod = &pods.Items[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no running controller pod matching %q in namespace %s", selector, namespace)
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating port forward transport: %w", err)
+	}
+	portForwardURL := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, portForwardURL)
+
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("creating port forward to pod %s: %w", pod.Name, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-ready:
+	case err := <-errCh:
+		return nil, fmt.Errorf("port forwarding to pod %s: %w", pod.Name, err)
+	case <-ctx.Done():
+		close(stop)
+		return nil, ctx.Err()
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stop)
+		return nil, fmt.Errorf("port forwarding to pod %s: %w", pod.Name, err)
+	}
+
+	return &controllerEndpoint{
+		pod:     pod.Name,
+		baseURL: fmt.Sprintf("http://127.0.0.1:%d", ports[0].Local),
+		stop:    stop,
+	}, nil
+}
+
+// get returns the body of the given path.
+func (e *controllerEndpoint) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	u := e.baseURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s from pod %s: %w", path, e.pod, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting %s from pod %s: unexpected status %s", path, e.pod, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// close stops the port forward.
+func (e *controllerEndpoint) close() {
+	close(e.stop)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/forge-build/forge/pkg/connections"
+)
+
+type debugOptions struct {
+	controllerNamespace string
+	selector            string
+	port                int
+	output              string
+}
+
+var debugOpts = &debugOptions{}
+
+type debugConnectionsOptions struct {
+	build string
+}
+
+var debugConnectionsOpts = &debugConnectionsOptions{}
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Inspect the internal state of the Forge controller",
+	Long: "Inspect the internal state of the Forge controller through its debug endpoints, " +
+		"the controller must be started with --enable-debug-endpoints.",
+}
+
+var debugConnectionsCmd = &cobra.Command{
+	Use:   "connections",
+	Short: "List the connections currently open to build machines",
+	Example: `  # List all the open connections
+  forgectl debug connections
+
+  # List the connections of a build
+  forgectl debug connections --build ubuntu-2204 -n builds`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runDebugConnections(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	debugCmd.PersistentFlags().StringVar(&debugOpts.controllerNamespace, "controller-namespace", "forge-system",
+		"The namespace the Forge controller is running in")
+	debugCmd.PersistentFlags().StringVar(&debugOpts.selector, "selector", "control-plane=controller-manager",
+		"The label selector of the Forge controller pods")
+	debugCmd.PersistentFlags().IntVar(&debugOpts.port, "port", 8080,
+		"The port of the controller metrics server serving the debug endpoints")
+	debugCmd.PersistentFlags().StringVarP(&debugOpts.output, "output", "o", "table",
+		"Output format, one of table or json")
+
+	debugConnectionsCmd.Flags().StringVar(&debugConnectionsOpts.build, "build", "",
+		"Only list the connections of the build with the given name, in the current namespace")
+
+	debugCmd.AddCommand(debugConnectionsCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+func runDebugConnections(ctx context.Context, out io.Writer) error {
+	params := url.Values{}
+	if debugConnectionsOpts.build != "" {
+		ns, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		params.Set("owner", ns+"/"+debugConnectionsOpts.build)
+	}
+
+	endpoint, err := openControllerEndpoint(ctx, debugOpts.controllerNamespace, debugOpts.selector, debugOpts.port)
+	if err != nil {
+		return err
+	}
+	defer endpoint.close()
+
+	raw, err := endpoint.get(ctx, connections.Path, params)
+	if err != nil {
+		return fmt.Errorf("%w, is the controller running with --enable-debug-endpoints?", err)
+	}
+
+	infos := []connections.Info{}
+	if err := json.Unmarshal(raw, &infos); err != nil {
+		return fmt.Errorf("decoding connections: %w", err)
+	}
+
+	switch debugOpts.output {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	case "table":
+		return printConnections(out, infos, time.Now())
+	default:
+		return fmt.Errorf("unsupported output format %q", debugOpts.output)
+	}
+}
+
+func printConnections(out io.Writer, infos []connections.Info, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPROTOCOL\tOWNER\tADDRESS\tAGE\tIDLE\tSENT\tRECEIVED")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			info.ID,
+			info.Protocol,
+			info.Owner,
+			info.Address,
+			duration.HumanDuration(now.Sub(info.OpenedAt)),
+			duration.HumanDuration(now.Sub(info.LastActivity)),
+			info.BytesSent,
+			info.BytesReceived,
+		)
+	}
+	return w.Flush()
+}
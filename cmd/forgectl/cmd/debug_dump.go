@@ -0,0 +1,188 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forge-build/forge/pkg/connections"
+)
+
+// summaryMetrics are the prefixes of the metrics copied to the summary of a dump,
+// they report the workqueue depths and the reconcile latencies of the controllers.
+var summaryMetrics = []string{
+	"workqueue_depth",
+	"workqueue_retries_total",
+	"workqueue_queue_duration_seconds_sum",
+	"workqueue_queue_duration_seconds_count",
+	"controller_runtime_active_workers",
+	"controller_runtime_reconcile_time_seconds_sum",
+	"controller_runtime_reconcile_time_seconds_count",
+	"controller_runtime_reconcile_errors_total",
+	"forge_controller_reconcile_duration_seconds",
+	"forge_controller_reconcile_total",
+	"go_goroutines",
+	"process_resident_memory_bytes",
+}
+
+type debugDumpOptions struct {
+	file       string
+	cpuProfile time.Duration
+}
+
+var debugDumpOpts = &debugDumpOptions{}
+
+var debugDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Collect profiles and metrics of the Forge controller into a bundle",
+	Long: "Collect the goroutine and heap profiles, a CPU profile, the workqueue depths and the reconcile latencies " +
+		"of the Forge controller into a tar.gz bundle, the controller must be started with --enable-pprof.",
+	Example: `  # Collect a dump bundle with a 30s CPU profile
+  forgectl debug dump --file forge-dump.tar.gz --cpu-profile 30s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runDebugDump(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	debugDumpCmd.Flags().StringVarP(&debugDumpOpts.file, "file", "f", "",
+		"The file to write the bundle to, defaults to forge-dump-<timestamp>.tar.gz")
+	debugDumpCmd.Flags().DurationVar(&debugDumpOpts.cpuProfile, "cpu-profile", 10*time.Second,
+		"Duration of the CPU profile, 0 disables the CPU profile")
+
+	debugCmd.AddCommand(debugDumpCmd)
+}
+
+// dumpEntry is a file of the dump bundle and where to get it from.
+type dumpEntry struct {
+	name     string
+	path     string
+	params   url.Values
+	optional bool
+}
+
+func runDebugDump(ctx context.Context, out io.Writer) error {
+	file := debugDumpOpts.file
+	if file == "" {
+		file = fmt.Sprintf("forge-dump-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	endpoint, err := openControllerEndpoint(ctx, debugOpts.controllerNamespace, debugOpts.selector, debugOpts.port)
+	if err != nil {
+		return err
+	}
+	defer endpoint.close()
+
+	entries := []dumpEntry{
+		{name: "metrics.txt", path: "/metrics"},
+		{name: "goroutines.txt", path: "/debug/pprof/goroutine", params: url.Values{"debug": {"2"}}},
+		{name: "goroutine.pprof", path: "/debug/pprof/goroutine"},
+		{name: "heap.pprof", path: "/debug/pprof/heap"},
+		{name: "allocs.pprof", path: "/debug/pprof/allocs"},
+		{name: "connections.json", path: connections.Path, optional: true},
+	}
+	if debugDumpOpts.cpuProfile > 0 {
+		seconds := strconv.Itoa(int(debugDumpOpts.cpuProfile.Seconds()))
+		entries = append(entries, dumpEntry{name: "cpu.pprof", path: "/debug/pprof/profile", params: url.Values{"seconds": {seconds}}})
+	}
+
+	files := map[string][]byte{}
+	names := []string{}
+	for _, entry := range entries {
+		fmt.Fprintf(out, "Collecting %s\n", entry.name)
+		data, err := endpoint.get(ctx, entry.path, entry.params)
+		if err != nil {
+			if entry.optional {
+				fmt.Fprintf(out, "Skipping %s: %v\n", entry.name, err)
+				continue
+			}
+			return fmt.Errorf("%w, is the controller running with --enable-pprof?", err)
+		}
+		files[entry.name] = data
+		names = append(names, entry.name)
+	}
+	files["summary.txt"] = summarizeMetrics(files["metrics.txt"])
+	names = append(names, "summary.txt")
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", file, err)
+	}
+	defer f.Close()
+	if err := writeBundle(f, names, files, time.Now()); err != nil {
+		return fmt.Errorf("writing %s: %w", file, err)
+	}
+
+	fmt.Fprintf(out, "Dump of pod %s written to %s\n", endpoint.pod, file)
+	return nil
+}
+
+// summarizeMetrics returns the summaryMetrics samples of the given Prometheus text exposition.
+func summarizeMetrics(metrics []byte) []byte {
+	summary := &bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(metrics))
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, prefix := range summaryMetrics {
+			if strings.HasPrefix(line, prefix+"{") || strings.HasPrefix(line, prefix+" ") {
+				summary.WriteString(line)
+				summary.WriteByte('\n')
+				break
+			}
+		}
+	}
+	return summary.Bytes()
+}
+
+// writeBundle writes the given files, in order, as a tar.gz archive.
+func writeBundle(w io.Writer, names []string, files map[string][]byte, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		data := files[name]
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/forge-build/forge/pkg/connections"
+)
+
+func TestSummarizeMetrics(t *testing.T) {
+	g := NewWithT(t)
+
+	metrics := []byte(`# HELP workqueue_depth Current depth of workqueue
+# TYPE workqueue_depth gauge
+workqueue_depth{controller="build",name="build"} 3
+workqueue_depth_other 1
+controller_runtime_reconcile_time_seconds_bucket{controller="build",le="0.005"} 1
+controller_runtime_reconcile_time_seconds_sum{controller="build"} 1.5
+go_goroutines 42
+`)
+	g.Expect(string(summarizeMetrics(metrics))).To(Equal(`workqueue_depth{controller="build",name="build"} 3
+controller_runtime_reconcile_time_seconds_sum{controller="build"} 1.5
+go_goroutines 42
+`))
+}
+
+func TestWriteBundle(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+	files := map[string][]byte{"metrics.txt": []byte("go_goroutines 42\n"), "heap.pprof": {0x1f, 0x8b}}
+	g.Expect(writeBundle(buf, []string{"metrics.txt", "heap.pprof"}, files, time.Now())).To(Succeed())
+
+	gz, err := gzip.NewReader(buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	tr := tar.NewReader(gz)
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(tr)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(data).To(Equal(files[header.Name]))
+		names = append(names, header.Name)
+	}
+	g.Expect(names).To(Equal([]string{"metrics.txt", "heap.pprof"}))
+}
+
+func TestPrintConnections(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	out := &bytes.Buffer{}
+	g.Expect(printConnections(out, []connections.Info{{
+		ID:            "1",
+		Protocol:      "ssh",
+		Owner:         "default/ubuntu",
+		Address:       "10.0.0.1:22",
+		OpenedAt:      now.Add(-5 * time.Minute),
+		LastActivity:  now.Add(-10 * time.Second),
+		BytesSent:     100,
+		BytesReceived: 20,
+	}}, now)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("default/ubuntu"))
+	g.Expect(out.String()).To(MatchRegexp(`5m\s+10s\s+100\s+20`))
+}
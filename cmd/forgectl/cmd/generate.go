@@ -0,0 +1,176 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/metrics"
+)
+
+// infrastructureGroup is the API group of the infrastructure providers.
+const infrastructureGroup = "infrastructure.forge.build"
+
+type generateAlertsOptions struct {
+	name               string
+	ruleNamespace      string
+	labels             map[string]string
+	providers          []string
+	providerStuckAfter map[string]string
+	stuckAfter         time.Duration
+	errorRate          float64
+	reconcileLatency   time.Duration
+	queueDepth         int
+}
+
+var generateAlertsOpts = &generateAlertsOptions{}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate manifests for operating Forge",
+}
+
+var generateAlertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Generate a PrometheusRule with the Forge SLO alerts",
+	Long: "Generate a PrometheusRule alerting on the Forge controllers error rate, reconcile latency and workqueue depth, " +
+		"and on the Builds stuck for each infrastructure provider. The providers are discovered from the management " +
+		"cluster unless --providers is set.",
+	Example: `  # Generate the alerts for the installed providers
+  forgectl generate alerts | kubectl apply -f -
+
+  # Generate the alerts for the Docker provider, allowing Docker Builds to run for 30m
+  forgectl generate alerts --providers DockerBuild --provider-stuck-after DockerBuild=30m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runGenerateAlerts(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	flags := generateAlertsCmd.Flags()
+	flags.StringVar(&generateAlertsOpts.name, "name", "forge-slo", "The name of the PrometheusRule")
+	flags.StringVar(&generateAlertsOpts.ruleNamespace, "rule-namespace", "forge-system", "The namespace of the PrometheusRule")
+	flags.StringToStringVar(&generateAlertsOpts.labels, "labels", nil,
+		"Labels of the PrometheusRule, e.g. to match the rule selector of Prometheus")
+	flags.StringSliceVar(&generateAlertsOpts.providers, "providers", nil,
+		"The infrastructure kinds to generate alerts for, e.g. DockerBuild, defaults to the providers installed in the cluster")
+	flags.StringToStringVar(&generateAlertsOpts.providerStuckAfter, "provider-stuck-after", nil,
+		"Per infrastructure kind override of --stuck-after, e.g. DockerBuild=30m")
+	flags.DurationVar(&generateAlertsOpts.stuckAfter, "stuck-after", 2*time.Hour,
+		"Alert on Builds which have not completed this long after their creation")
+	flags.Float64Var(&generateAlertsOpts.errorRate, "error-rate", 0.1,
+		"Alert when the ratio of failed reconciles of a controller is above this value")
+	flags.DurationVar(&generateAlertsOpts.reconcileLatency, "reconcile-latency", 30*time.Second,
+		"Alert when the 99th percentile of the reconcile duration of a controller is above this value")
+	flags.IntVar(&generateAlertsOpts.queueDepth, "queue-depth", 100,
+		"Alert when the workqueue depth of a controller is above this value")
+
+	generateCmd.AddCommand(generateAlertsCmd)
+	rootCmd.AddCommand(generateCmd)
+}
+
+func runGenerateAlerts(ctx context.Context, out io.Writer) error {
+	kinds := generateAlertsOpts.providers
+	if len(kinds) == 0 {
+		var err error
+		if kinds, err = installedProviders(ctx); err != nil {
+			return err
+		}
+	}
+
+	providers, err := alertProviders(kinds, generateAlertsOpts.providerStuckAfter)
+	if err != nil {
+		return err
+	}
+
+	rule := metrics.NewPrometheusRule(metrics.RuleOptions{
+		Name:             generateAlertsOpts.name,
+		Namespace:        generateAlertsOpts.ruleNamespace,
+		Labels:           generateAlertsOpts.labels,
+		Providers:        providers,
+		StuckAfter:       generateAlertsOpts.stuckAfter,
+		ErrorRate:        generateAlertsOpts.errorRate,
+		ReconcileLatency: generateAlertsOpts.reconcileLatency,
+		QueueDepth:       generateAlertsOpts.queueDepth,
+	})
+	data, err := yaml.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("encoding PrometheusRule: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// installedProviders returns the infrastructure kinds of the providers installed in the management cluster.
+func installedProviders(ctx context.Context) ([]string, error) {
+	c, err := globalOpts.newClient()
+	if err != nil {
+		return nil, err
+	}
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds); err != nil {
+		return nil, fmt.Errorf("listing CustomResourceDefinitions: %w", err)
+	}
+
+	kinds := []string{}
+	for _, crd := range crds.Items {
+		kind := crd.Spec.Names.Kind
+		if crd.Spec.Group != infrastructureGroup || strings.HasSuffix(kind, buildv1.TemplateSuffix) {
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+// alertProviders returns the providers of the given kinds, with their stuck-after overrides.
+func alertProviders(kinds []string, stuckAfter map[string]string) ([]metrics.Provider, error) {
+	providers := make([]metrics.Provider, 0, len(kinds))
+	known := map[string]bool{}
+	for _, kind := range kinds {
+		known[kind] = true
+		providers = append(providers, metrics.Provider{Kind: kind})
+	}
+	for kind, value := range stuckAfter {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --provider-stuck-after value for %s: %w", kind, err)
+		}
+		if !known[kind] {
+			known[kind] = true
+			providers = append(providers, metrics.Provider{Kind: kind})
+		}
+		for i := range providers {
+			if providers[i].Kind == kind {
+				providers[i].StuckAfter = d
+			}
+		}
+	}
+	return providers, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forge-build/forge/internal/packer"
+)
+
+type importPackerOptions struct {
+	variables map[string]string
+}
+
+var importPackerOpts = &importPackerOptions{}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Translate the templates of other image build tools into Forge manifests",
+}
+
+var importPackerCmd = &cobra.Command{
+	Use:   "packer TEMPLATE",
+	Short: "Translate a Packer HCL2 template into Builds",
+	Long: "Translate every source of the build blocks of a Packer HCL2 template into a Build and its infrastructure " +
+		"object, and print them along with the ConfigMaps holding the scripts, files and playbooks of their " +
+		"provisioners. The amazon-ebs, googlecompute and vsphere-iso builders and the shell, file and ansible " +
+		"provisioners are translated. The parts of the template which can't be translated, e.g. the credentials " +
+		"of the builders or the expressions calling timestamp(), are reported on the standard error to be " +
+		"completed by hand.",
+	Example: `  # Translate ubuntu.pkr.hcl and review the manifests
+  forgectl import packer ubuntu.pkr.hcl --var release=24.04 > ubuntu.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImportPacker(args[0], cmd.OutOrStdout(), cmd.ErrOrStderr())
+	},
+}
+
+func init() {
+	importPackerCmd.Flags().StringToStringVar(&importPackerOpts.variables, "var", nil,
+		"Set a variable of the template, as NAME=VALUE")
+
+	importCmd.AddCommand(importPackerCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImportPacker(filename string, out, errOut io.Writer) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filename, err)
+	}
+	result, err := packer.Import(filename, data, packer.Options{
+		Namespace: globalOpts.namespace,
+		Variables: importPackerOpts.variables,
+		Dir:       filepath.Dir(filename),
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(errOut, "Warning: %s\n", warning)
+	}
+	docs := make([]interface{}, 0, len(result.Objects))
+	for _, obj := range result.Objects {
+		docs = append(docs, obj)
+	}
+	return printDocuments(out, docs)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const (
+	// defaultProviderURL is the location of the infrastructure provider components,
+	// the provider name and version are substituted in order.
+	defaultProviderURL = "https://github.com/forge-build/forge-provider-%s/releases/%s/infrastructure-components.yaml"
+
+	// quickstartBuildName is the name of the Build created by the examples.
+	quickstartBuildName = "forge-quickstart"
+)
+
+//go:embed examples/*.yaml
+var examples embed.FS
+
+type initOptions struct {
+	infrastructure  []string
+	provisioners    []string
+	providerVersion string
+	providerURL     string
+	withExamples    bool
+	wait            bool
+	timeout         time.Duration
+}
+
+var initOpts = &initOptions{}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Install providers in the management cluster",
+	Long: "Install infrastructure and provisioner providers in the management cluster and, optionally, " +
+		"run an example Build to verify the installation end to end. The components of the providers are " +
+		"labeled with the provider and annotated with its version, to be upgraded and deleted with " +
+		"forgectl upgrade provider and forgectl delete provider.",
+	Example: `  # Install the Docker provider, run the quickstart Build and wait for it to complete
+  forgectl init --infrastructure docker --with-examples
+
+  # Install a specific version of the Docker provider
+  forgectl init --infrastructure docker --provider-version v0.1.0
+
+  # Install the AWS and GCP providers and the Ansible provisioner
+  forgectl init --infrastructure aws,gcp --provisioner ansible`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runInit(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	initCmd.Flags().StringSliceVarP(&initOpts.infrastructure, "infrastructure", "i", nil,
+		"The infrastructure providers to install, e.g. aws,gcp")
+	initCmd.Flags().StringSliceVar(&initOpts.provisioners, "provisioner", nil,
+		"The provisioner providers to install, e.g. ansible")
+	initCmd.Flags().StringVar(&initOpts.providerVersion, "provider-version", "latest",
+		"The version of the providers to install")
+	initCmd.Flags().StringVar(&initOpts.providerURL, "provider-url", "",
+		"Install the provider components from this URL or local file instead of the provider release, "+
+			"only used when installing a single provider")
+	initCmd.Flags().BoolVar(&initOpts.withExamples, "with-examples", false,
+		"Apply the example Build of the infrastructure provider and verify it completes, "+
+			"only used when installing a single infrastructure provider")
+	initCmd.Flags().BoolVar(&initOpts.wait, "wait", true,
+		"Wait for the example Build to complete, only used with --with-examples")
+	initCmd.Flags().DurationVar(&initOpts.timeout, "timeout", 30*time.Minute,
+		"How long to wait for the example Build to complete")
+	initCmd.MarkFlagsOneRequired("infrastructure", "provisioner")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(ctx context.Context, out io.Writer) error {
+	providers, err := initProviders(initOpts.infrastructure, initOpts.provisioners)
+	if err != nil {
+		return err
+	}
+	if initOpts.providerURL != "" && len(providers) > 1 {
+		return fmt.Errorf("--provider-url can only be used when installing a single provider")
+	}
+
+	var exampleManifests []byte
+	if initOpts.withExamples {
+		if len(initOpts.infrastructure) != 1 {
+			return fmt.Errorf("--with-examples can only be used when installing a single infrastructure provider")
+		}
+		exampleManifests, err = examples.ReadFile(fmt.Sprintf("examples/%s.yaml", initOpts.infrastructure[0]))
+		if err != nil {
+			return fmt.Errorf("no examples available for the %s infrastructure provider", initOpts.infrastructure[0])
+		}
+	}
+
+	c, err := globalOpts.newClient()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range providers {
+		if err := installProvider(ctx, c, out, p, initOpts.providerVersion, initOpts.providerURL); err != nil {
+			return err
+		}
+	}
+
+	if !initOpts.withExamples {
+		return nil
+	}
+
+	namespace, err := globalOpts.currentNamespace()
+	if err != nil {
+		return err
+	}
+	objs, err := parseManifests(exampleManifests)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		obj.SetNamespace(namespace)
+	}
+	fmt.Fprintf(out, "Applying the %s examples in namespace %s\n", initOpts.infrastructure[0], namespace)
+	if err := applyManifests(ctx, c, out, objs); err != nil {
+		return err
+	}
+
+	if !initOpts.wait {
+		return nil
+	}
+	return waitForBuild(ctx, c, out, client.ObjectKey{Namespace: namespace, Name: quickstartBuildName}, initOpts.timeout)
+}
+
+// initProviders returns the providers to install, the infrastructure providers first.
+func initProviders(infrastructure, provisioners []string) ([]provider, error) {
+	providers := []provider{}
+	add := func(kind providerType, names []string) error {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name == "" || strings.ContainsAny(name, "/ ") {
+				return fmt.Errorf("invalid %s provider %q", kind, name)
+			}
+			providers = append(providers, provider{kind: kind, name: name})
+		}
+		return nil
+	}
+	if err := add(infrastructureProvider, infrastructure); err != nil {
+		return nil, err
+	}
+	if err := add(provisionerProvider, provisioners); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// waitForBuild waits for the Build to complete, reporting its phase transitions.
+func waitForBuild(ctx context.Context, c client.Client, out io.Writer, key client.ObjectKey, timeout time.Duration) error {
+	fmt.Fprintf(out, "Waiting for Build %s to complete\n", key)
+
+	lastPhase := ""
+	build := &buildv1.Build{}
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := c.Get(ctx, key, build); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		if build.Status.Phase != lastPhase {
+			lastPhase = build.Status.Phase
+			fmt.Fprintf(out, "Build %s is %s\n", key, lastPhase)
+		}
+		switch build.Status.GetTypedPhase() {
+		case buildv1.BuildPhaseCompleted:
+			return true, nil
+		case buildv1.BuildPhaseFailed:
+			return false, fmt.Errorf("build %s failed: %s", key, ptr.Deref(build.Status.FailureMessage, "unknown"))
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for Build %s: %w", key, err)
+	}
+
+	if build.Status.ImageRef != "" {
+		fmt.Fprintf(out, "Build %s completed, image: %s\n", key, build.Status.ImageRef)
+	} else {
+		fmt.Fprintf(out, "Build %s completed\n", key)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+type logsOptions struct {
+	provisioner          string
+	follow               bool
+	provisionerNamespace string
+}
+
+var logsOpts = &logsOptions{}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs BUILD",
+	Short: "Print the logs of the provisioners of a Build",
+	Long: "Print the logs of the pods of the provisioner Jobs of the Build, each line prefixed with the name of " +
+		"the provisioner. The logs of the failed provisioners whose Jobs have been deleted are read from the logs " +
+		"recorded in the Build and in its logs ConfigMap.",
+	Example: `  # Print the logs of all the provisioners of the Build ubuntu
+  forgectl logs ubuntu
+
+  # Follow the logs of the provisioner packages until the Build finishes
+  forgectl logs ubuntu --provisioner packages -f`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		clientset, err := globalOpts.newClientset()
+		if err != nil {
+			return err
+		}
+		namespace, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		return printBuildLogs(cmd.Context(), c, clientset, cmd.OutOrStdout(), client.ObjectKey{Namespace: namespace, Name: args[0]})
+	},
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsOpts.provisioner, "provisioner", "",
+		"Only print the logs of the provisioner with the given name or UUID")
+	logsCmd.Flags().BoolVarP(&logsOpts.follow, "follow", "f", false,
+		"Follow the logs until the Build finishes")
+	logsCmd.Flags().StringVar(&logsOpts.provisionerNamespace, "provisioner-namespace", defaultProvisionerNamespace,
+		"The namespace of the Jobs of the provisioners placed in the core namespace")
+
+	rootCmd.AddCommand(logsCmd)
+}
+
+// printBuildLogs prints the logs of the provisioners of the Build, as per the flags. The provisioners without a pod
+// are printed the logs recorded when they failed.
+func printBuildLogs(ctx context.Context, c client.Client, clientset kubernetes.Interface, out io.Writer, key client.ObjectKey) error {
+	build := &buildv1.Build{}
+	if err := c.Get(ctx, key, build); err != nil {
+		return fmt.Errorf("getting Build %s: %w", key, err)
+	}
+	provisionerID := ""
+	if logsOpts.provisioner != "" {
+		for _, p := range build.Spec.Provisioners {
+			if p.Name == logsOpts.provisioner || ptr.Deref(p.UUID, "") == logsOpts.provisioner {
+				provisionerID = ptr.Deref(p.UUID, "")
+			}
+		}
+		if provisionerID == "" {
+			return fmt.Errorf("build %s has no provisioner %s", key, logsOpts.provisioner)
+		}
+	}
+
+	pods, err := provisionerPods(ctx, clientset, build, logsOpts.provisionerNamespace, provisionerID)
+	if err != nil {
+		return err
+	}
+	running := map[string]bool{}
+	for _, pod := range pods {
+		running[pod.Labels[buildv1.ProvisionerIDLabel]] = true
+	}
+	for _, p := range build.Spec.Provisioners {
+		uuid := ptr.Deref(p.UUID, "")
+		if running[uuid] || provisionerID != "" && uuid != provisionerID {
+			continue
+		}
+		if err := printRecordedLogs(ctx, c, out, build, &p); err != nil {
+			return err
+		}
+	}
+
+	if logsOpts.follow {
+		w := &buildWatcher{
+			client:               c,
+			clientset:            clientset,
+			out:                  &syncWriter{w: out},
+			interval:             2 * time.Second,
+			provisionerNamespace: logsOpts.provisionerNamespace,
+			provisionerID:        provisionerID,
+			logsOnly:             true,
+		}
+		return w.watch(ctx, key)
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodPending {
+			continue
+		}
+		logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Stream(ctx)
+		if err != nil {
+			return fmt.Errorf("getting the logs of pod %s: %w", pod.Name, err)
+		}
+		printLines(out, logs, logsPrefix(build, &pod))
+		logs.Close()
+	}
+	return nil
+}
+
+// printRecordedLogs prints the logs recorded when the provisioner failed, from the logs ConfigMap of the Build or
+// from the status of the Build if the ConfigMap doesn't hold them.
+func printRecordedLogs(ctx context.Context, c client.Client, out io.Writer, build *buildv1.Build, p *buildv1.ProvisionerSpec) error {
+	var status *buildv1.BuildProvisionerStatus
+	for i := range build.Status.Provisioners {
+		if build.Status.Provisioners[i].UUID == ptr.Deref(p.UUID, "") {
+			status = &build.Status.Provisioners[i]
+		}
+	}
+	if status == nil {
+		return nil
+	}
+	logs := status.Logs
+	if status.LogsConfigMapName != "" {
+		cm := &corev1.ConfigMap{}
+		err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: status.LogsConfigMapName}, cm)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return fmt.Errorf("getting the logs ConfigMap %s: %w", status.LogsConfigMapName, err)
+		default:
+			for _, key := range []string{p.Name, ptr.Deref(p.UUID, "")} {
+				if recorded, ok := cm.Data[key+".log"]; ok && key != "" {
+					logs = recorded
+					break
+				}
+			}
+		}
+	}
+	printLines(out, strings.NewReader(logs), p.Name)
+	return nil
+}
+
+// provisionerPods returns the pods of the provisioner Jobs of the Build, of the provisioner with the UUID if set,
+// from the oldest.
+func provisionerPods(ctx context.Context, clientset kubernetes.Interface, build *buildv1.Build, provisionerNamespace, provisionerID string) ([]corev1.Pod, error) {
+	namespace := provisionerNamespace
+	if build.Spec.ProvisionerJobPlacement == buildv1.ProvisionerJobPlacementBuild {
+		namespace = build.Namespace
+	}
+	set := labels.Set{buildv1.BuildNameLabel: build.Name, buildv1.BuildNamespaceLabel: build.Namespace}
+	if provisionerID != "" {
+		set[buildv1.ProvisionerIDLabel] = provisionerID
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(set).String()})
+	if err != nil {
+		return nil, fmt.Errorf("listing the provisioner pods of Build %s: %w", build.Name, err)
+	}
+	sort.SliceStable(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+	return pods.Items, nil
+}
+
+// logsPrefix returns the name of the provisioner the pod runs, the name of the pod if unknown.
+func logsPrefix(build *buildv1.Build, pod *corev1.Pod) string {
+	id := pod.Labels[buildv1.ProvisionerIDLabel]
+	for _, p := range build.Spec.Provisioners {
+		if id != "" && ptr.Deref(p.UUID, "") == id {
+			return p.Name
+		}
+	}
+	return pod.Name
+}
+
+// printLines prints the lines read from r, each prefixed with [prefix].
+func printLines(out io.Writer, r io.Reader, prefix string) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintf(out, "[%s] %s\n", prefix, scanner.Text())
+	}
+}
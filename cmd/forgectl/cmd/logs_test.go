@@ -0,0 +1,83 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestPrintBuildLogs(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{
+			Provisioners: []buildv1.ProvisionerSpec{
+				{Name: "base", UUID: ptr.To("0b5f")},
+				{Name: "packages", UUID: ptr.To("1c6a")},
+				{Name: "app", UUID: ptr.To("2d7b")},
+			},
+		},
+	}
+	build.Status.Provisioners = []buildv1.BuildProvisionerStatus{
+		{UUID: "1c6a", Logs: "E: Unable to locate package\n", LogsConfigMapName: "ubuntu-provisioner-logs"},
+		{UUID: "2d7b", Logs: "systemctl: not found\n"},
+	}
+	logs := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu-provisioner-logs"},
+		Data:       map[string]string{"packages.log": "Reading package lists...\nE: Unable to locate package\n"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultProvisionerNamespace, Name: "forge-provisioner-shell-1", Labels: map[string]string{
+			buildv1.BuildNameLabel:      "ubuntu",
+			buildv1.BuildNamespaceLabel: "images",
+			buildv1.ProvisionerIDLabel:  "0b5f",
+		}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, logs).Build()
+	clientset := kubefake.NewSimpleClientset(pod)
+	logsOpts.provisionerNamespace = defaultProvisionerNamespace
+	defer func() { *logsOpts = logsOptions{} }()
+
+	// The logs of the running provisioners are read from their pods, the others from the recorded logs.
+	out := &bytes.Buffer{}
+	g.Expect(printBuildLogs(ctx, c, clientset, out, client.ObjectKeyFromObject(build))).To(Succeed())
+	g.Expect(out.String()).To(Equal("[packages] Reading package lists...\n[packages] E: Unable to locate package\n" +
+		"[app] systemctl: not found\n[base] fake logs\n"))
+
+	out.Reset()
+	logsOpts.provisioner = "app"
+	g.Expect(printBuildLogs(ctx, c, clientset, out, client.ObjectKeyFromObject(build))).To(Succeed())
+	g.Expect(out.String()).To(Equal("[app] systemctl: not found\n"))
+
+	logsOpts.provisioner = "missing"
+	g.Expect(printBuildLogs(ctx, c, clientset, out, client.ObjectKeyFromObject(build))).To(MatchError("build images/ubuntu has no provisioner missing"))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/labels"
+)
+
+type migrateLabelsOptions struct {
+	allNamespaces bool
+	dryRun        bool
+}
+
+var migrateLabelsOpts = &migrateLabelsOptions{}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate Forge objects to the current conventions",
+}
+
+var migrateLabelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Replace the legacy cluster.x-k8s.io label keys with their forge.build key",
+	Long: "Replace the legacy cluster.x-k8s.io label keys, e.g. cluster.x-k8s.io/watch-filter, of the Forge objects " +
+		"and the Forge secrets with their canonical forge.build key. Objects setting both keys keep the value of the canonical key.",
+	Example: `  # Show the objects of all namespaces which would be migrated
+  forgectl migrate labels --all-namespaces --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runMigrateLabels(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	migrateLabelsCmd.Flags().BoolVarP(&migrateLabelsOpts.allNamespaces, "all-namespaces", "A", false,
+		"Migrate the objects of all namespaces instead of the current namespace")
+	migrateLabelsCmd.Flags().BoolVar(&migrateLabelsOpts.dryRun, "dry-run", false,
+		"Only print the objects which would be migrated")
+
+	migrateCmd.AddCommand(migrateLabelsCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrateLabels(ctx context.Context, out io.Writer) error {
+	c, err := globalOpts.newClient()
+	if err != nil {
+		return err
+	}
+
+	listOpts := []client.ListOption{}
+	if !migrateLabelsOpts.allNamespaces {
+		namespace, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	gvks, err := forgeKinds(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	migrated := map[types.UID]bool{}
+	for _, gvk := range gvks {
+		for _, key := range []string{buildv1.WatchLabel, buildv1.ProviderNameLabel} {
+			legacy, _ := labels.LegacyKey(key)
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := c.List(ctx, list, append(listOpts, client.HasLabels{legacy})...); err != nil {
+				return fmt.Errorf("listing %s: %w", gvk.Kind, err)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if migrated[obj.GetUID()] {
+					continue
+				}
+				migrated[obj.GetUID()] = true
+				if err := migrateLabels(ctx, c, obj, migrateLabelsOpts.dryRun); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%s %s migrated%s\n", gvk.Kind, client.ObjectKeyFromObject(obj), dryRunSuffix(migrateLabelsOpts.dryRun))
+			}
+		}
+	}
+	fmt.Fprintf(out, "%d objects migrated%s\n", len(migrated), dryRunSuffix(migrateLabelsOpts.dryRun))
+	return nil
+}
+
+// migrateLabels replaces the legacy label keys of the object.
+func migrateLabels(ctx context.Context, c client.Client, obj *unstructured.Unstructured, dryRun bool) error {
+	original := obj.DeepCopy()
+	if !labels.Migrate(obj) || dryRun {
+		return nil
+	}
+	if err := c.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("migrating %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	return nil
+}
+
+// forgeKinds returns the kinds of the Forge and provider custom resources installed in the cluster, and Secrets.
+func forgeKinds(ctx context.Context, c client.Client) ([]schema.GroupVersionKind, error) {
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds); err != nil {
+		return nil, fmt.Errorf("listing CustomResourceDefinitions: %w", err)
+	}
+
+	gvks := []schema.GroupVersionKind{}
+	for _, crd := range crds.Items {
+		if crd.Spec.Group != buildv1.GroupVersion.Group && !strings.HasSuffix(crd.Spec.Group, "."+buildv1.GroupVersion.Group) {
+			continue
+		}
+		for _, version := range crd.Spec.Versions {
+			if version.Storage {
+				gvks = append(gvks, schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind})
+			}
+		}
+	}
+	sort.Slice(gvks, func(i, j int) bool { return gvks[i].String() < gvks[j].String() })
+	return append(gvks, corev1.SchemeGroupVersion.WithKind("Secret")), nil
+}
+
+func dryRunSuffix(dryRun bool) string {
+	if dryRun {
+		return " (dry run)"
+	}
+	return ""
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/labels"
+)
+
+// providerType is the type of the components of a provider.
+type providerType string
+
+const (
+	// infrastructureProvider creates the machines of the Builds, e.g. aws.
+	infrastructureProvider providerType = "infrastructure"
+	// provisionerProvider runs the provisioners of a ProvisionerClass, e.g. ansible.
+	provisionerProvider providerType = "provisioner"
+)
+
+// providerURLs are the locations of the components of the providers, per type. The provider name and release
+// path are substituted in order.
+var providerURLs = map[providerType]string{
+	infrastructureProvider: defaultProviderURL,
+	provisionerProvider:    "https://github.com/forge-build/forge-provisioner-%s/releases/%s/provisioner-components.yaml",
+}
+
+// providerComponentKinds are the kinds of the components of the providers, listed to find the installed components.
+var providerComponentKinds = []schema.GroupVersionKind{
+	{Group: "apiextensions.k8s.io", Version: "v1", Kind: crdKind},
+	{Version: "v1", Kind: namespaceKind},
+	{Version: "v1", Kind: "ServiceAccount"},
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "Service"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+	{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+	{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"},
+}
+
+// provider identifies a provider by its type and name.
+type provider struct {
+	kind providerType
+	name string
+}
+
+// parseProvider parses a provider given as TYPE-NAME, e.g. provisioner-ansible, or as NAME for an infrastructure
+// provider.
+func parseProvider(s string) (provider, error) {
+	for kind := range providerURLs {
+		if name, ok := strings.CutPrefix(s, string(kind)+"-"); ok && name != "" {
+			return provider{kind: kind, name: name}, nil
+		}
+	}
+	if s == "" || strings.ContainsAny(s, "/ ") {
+		return provider{}, fmt.Errorf("invalid provider %q", s)
+	}
+	return provider{kind: infrastructureProvider, name: s}, nil
+}
+
+// label returns the value of the ProviderNameLabel of the components of the provider.
+func (p provider) label() string {
+	return string(p.kind) + "-" + p.name
+}
+
+func (p provider) String() string {
+	return fmt.Sprintf("%s provider %s", p.kind, p.name)
+}
+
+// components returns the components of the provider, from url if set or from the provider release, labeled with
+// the provider and annotated with the version.
+func (p provider) components(ctx context.Context, version, url string) ([]*unstructured.Unstructured, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case url == "":
+		releasePath := "latest/download"
+		if version != "latest" {
+			releasePath = "download/" + version
+		}
+		data, err = fetchManifests(ctx, fmt.Sprintf(providerURLs[p.kind], p.name, releasePath))
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		data, err = fetchManifests(ctx, url)
+	default:
+		data, err = os.ReadFile(strings.TrimPrefix(url, "file://"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	objs, err := parseManifests(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		labels.Set(obj, buildv1.ProviderNameLabel, p.label())
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[buildv1.ProviderVersionAnnotation] = version
+		obj.SetAnnotations(annotations)
+	}
+	return objs, nil
+}
+
+// installedComponents returns the components of the provider installed in the cluster. The kinds the cluster
+// doesn't serve, e.g. the cert-manager kinds, are skipped.
+func (p provider) installedComponents(ctx context.Context, c client.Client) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for _, gvk := range providerComponentKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.List(ctx, list, client.MatchingLabels{buildv1.ProviderNameLabel: p.label()}); err != nil {
+			if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("listing the %s components of the %s: %w", gvk.Kind, p, err)
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	}
+	return objs, nil
+}
+
+type upgradeProviderOptions struct {
+	providerVersion string
+	providerURL     string
+}
+
+var upgradeProviderOpts = &upgradeProviderOptions{}
+
+type deleteProviderOptions struct {
+	includeCRDs      bool
+	includeNamespace bool
+	yes              bool
+}
+
+var deleteProviderOpts = &deleteProviderOptions{}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the components installed in the management cluster",
+}
+
+var upgradeProviderCmd = &cobra.Command{
+	Use:   "provider PROVIDER",
+	Short: "Upgrade a provider to another version",
+	Long: "Apply the components of another version of the provider, given as NAME for an infrastructure provider " +
+		"or TYPE-NAME, e.g. provisioner-ansible. The components of the installed version which the new version no " +
+		"longer ships are deleted, except the CustomResourceDefinitions and Namespaces.",
+	Example: `  # Upgrade the AWS infrastructure provider to v0.2.0
+  forgectl upgrade provider aws --provider-version v0.2.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := parseProvider(args[0])
+		if err != nil {
+			return err
+		}
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		return upgradeProvider(cmd.Context(), c, cmd.OutOrStdout(), p, upgradeProviderOpts.providerVersion, upgradeProviderOpts.providerURL)
+	},
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete the components installed in the management cluster",
+}
+
+var deleteProviderCmd = &cobra.Command{
+	Use:   "provider PROVIDER",
+	Short: "Delete a provider",
+	Long: "Delete the components of the provider, given as NAME for an infrastructure provider or TYPE-NAME, " +
+		"e.g. provisioner-ansible. Its CustomResourceDefinitions, along with all the objects of their kinds, " +
+		"and its Namespaces are kept unless --include-crds and --include-namespace are set.",
+	Example: `  # Delete the AWS infrastructure provider and its CustomResourceDefinitions
+  forgectl delete provider aws --include-crds`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := parseProvider(args[0])
+		if err != nil {
+			return err
+		}
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		return deleteProvider(cmd.Context(), c, cmd.InOrStdin(), cmd.OutOrStdout(), p)
+	},
+}
+
+func init() {
+	upgradeProviderCmd.Flags().StringVar(&upgradeProviderOpts.providerVersion, "provider-version", "latest",
+		"The version of the provider to upgrade to")
+	upgradeProviderCmd.Flags().StringVar(&upgradeProviderOpts.providerURL, "provider-url", "",
+		"Upgrade to the provider components from this URL or local file instead of the provider release")
+	deleteProviderCmd.Flags().BoolVar(&deleteProviderOpts.includeCRDs, "include-crds", false,
+		"Delete the CustomResourceDefinitions of the provider, along with all the objects of their kinds")
+	deleteProviderCmd.Flags().BoolVar(&deleteProviderOpts.includeNamespace, "include-namespace", false,
+		"Delete the Namespaces of the provider, along with all the objects they contain")
+	deleteProviderCmd.Flags().BoolVarP(&deleteProviderOpts.yes, "yes", "y", false,
+		"Do not ask for confirmation")
+
+	upgradeCmd.AddCommand(upgradeProviderCmd)
+	deleteCmd.AddCommand(deleteProviderCmd)
+	rootCmd.AddCommand(upgradeCmd, deleteCmd)
+}
+
+// installProvider applies the components of the given version of the provider.
+func installProvider(ctx context.Context, c client.Client, out io.Writer, p provider, version, url string) error {
+	components, err := p.components(ctx, version, url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Installing the %s %s\n", p, version)
+	return applyManifests(ctx, c, out, components)
+}
+
+// upgradeProvider applies the components of the given version of the installed provider and deletes the components
+// of the previous version the new version doesn't ship, except the CustomResourceDefinitions and Namespaces.
+func upgradeProvider(ctx context.Context, c client.Client, out io.Writer, p provider, version, url string) error {
+	installed, err := p.installedComponents(ctx, c)
+	if err != nil {
+		return err
+	}
+	if len(installed) == 0 {
+		return fmt.Errorf("the %s is not installed, install it with forgectl init", p)
+	}
+	components, err := p.components(ctx, version, url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Upgrading the %s from %s to %s\n", p, installedVersion(installed), version)
+	if err := applyManifests(ctx, c, out, components); err != nil {
+		return err
+	}
+
+	shipped := map[string]bool{}
+	for _, obj := range components {
+		shipped[componentKey(obj)] = true
+	}
+	for _, obj := range installed {
+		if shipped[componentKey(obj)] || obj.GetKind() == crdKind || obj.GetKind() == namespaceKind {
+			continue
+		}
+		if err := client.IgnoreNotFound(c.Delete(ctx, obj, client.PropagationPolicy("Background"))); err != nil {
+			return fmt.Errorf("deleting %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+		fmt.Fprintf(out, "%s/%s deleted\n", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+// deleteProvider deletes the installed components of the provider, once confirmed unless --yes is set.
+func deleteProvider(ctx context.Context, c client.Client, in io.Reader, out io.Writer, p provider) error {
+	installed, err := p.installedComponents(ctx, c)
+	if err != nil {
+		return err
+	}
+	// The Namespaces are deleted last, so the components they contain are reported as deleted.
+	deleted, namespaces := []*unstructured.Unstructured{}, []*unstructured.Unstructured{}
+	for _, obj := range installed {
+		switch {
+		case obj.GetKind() == crdKind && !deleteProviderOpts.includeCRDs:
+		case obj.GetKind() == namespaceKind && deleteProviderOpts.includeNamespace:
+			namespaces = append(namespaces, obj)
+		case obj.GetKind() == namespaceKind:
+		default:
+			deleted = append(deleted, obj)
+		}
+	}
+	deleted = append(deleted, namespaces...)
+	if len(deleted) == 0 {
+		fmt.Fprintf(out, "No components of the %s to delete\n", p)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tVERSION")
+	for _, obj := range deleted {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", obj.GetKind(), obj.GetNamespace(), obj.GetName(), obj.GetAnnotations()[buildv1.ProviderVersionAnnotation])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !deleteProviderOpts.yes {
+		confirmed, err := confirm(in, out, fmt.Sprintf("Delete the %d components of the %s?", len(deleted), p))
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	for _, obj := range deleted {
+		if err := client.IgnoreNotFound(c.Delete(ctx, obj, client.PropagationPolicy("Background"))); err != nil {
+			return fmt.Errorf("deleting %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+		fmt.Fprintf(out, "%s/%s deleted\n", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+// installedVersion returns the version the components have been installed from, unknown if they have no version.
+func installedVersion(objs []*unstructured.Unstructured) string {
+	for _, obj := range objs {
+		if version := obj.GetAnnotations()[buildv1.ProviderVersionAnnotation]; version != "" {
+			return version
+		}
+	}
+	return "an unknown version"
+}
+
+// componentKey identifies a component across the versions of a provider.
+func componentKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s/%s", obj.GroupVersionKind().Group, obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
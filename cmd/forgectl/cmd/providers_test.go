@@ -0,0 +1,128 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestParseProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	for s, expected := range map[string]provider{
+		"aws":                     {kind: infrastructureProvider, name: "aws"},
+		"infrastructure-gcp":      {kind: infrastructureProvider, name: "gcp"},
+		"provisioner-ansible":     {kind: provisionerProvider, name: "ansible"},
+		"provisioner-ansible-pro": {kind: provisionerProvider, name: "ansible-pro"},
+	} {
+		p, err := parseProvider(s)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(p).To(Equal(expected))
+	}
+	for _, s := range []string{"", "provisioner/ansible"} {
+		_, err := parseProvider(s)
+		g.Expect(err).To(HaveOccurred())
+	}
+	g.Expect(provider{kind: provisionerProvider, name: "ansible"}.label()).To(Equal("provisioner-ansible"))
+
+	providers, err := initProviders([]string{"aws", "gcp"}, []string{"ansible"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(providers).To(Equal([]provider{
+		{kind: infrastructureProvider, name: "aws"},
+		{kind: infrastructureProvider, name: "gcp"},
+		{kind: provisionerProvider, name: "ansible"},
+	}))
+	_, err = initProviders([]string{""}, nil)
+	g.Expect(err).To(MatchError(`invalid infrastructure provider ""`))
+}
+
+func TestProviderComponents(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "infrastructure-components.yaml")
+	g.Expect(os.WriteFile(path, []byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: manager
+  namespace: forge-aws-system
+  labels:
+    cluster.x-k8s.io/provider: infrastructure-aws
+`), 0o600)).To(Succeed())
+
+	objs, err := provider{kind: infrastructureProvider, name: "aws"}.components(context.Background(), "v0.2.0", path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(objs).To(HaveLen(1))
+	g.Expect(objs[0].GetLabels()).To(Equal(map[string]string{buildv1.ProviderNameLabel: "infrastructure-aws"}))
+	g.Expect(objs[0].GetAnnotations()).To(HaveKeyWithValue(buildv1.ProviderVersionAnnotation, "v0.2.0"))
+}
+
+func TestDeleteProvider(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	component := func(obj client.Object, provider string) client.Object {
+		obj.SetLabels(map[string]string{buildv1.ProviderNameLabel: provider})
+		obj.SetAnnotations(map[string]string{buildv1.ProviderVersionAnnotation: "v0.1.0"})
+		return obj
+	}
+	namespace := component(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "forge-aws-system"}}, "infrastructure-aws")
+	serviceAccount := component(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "forge-aws-system", Name: "manager"}}, "infrastructure-aws")
+	other := component(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "forge-gcp-system", Name: "manager"}}, "infrastructure-gcp")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace, serviceAccount, other).Build()
+	aws := provider{kind: infrastructureProvider, name: "aws"}
+
+	installed, err := aws.installedComponents(ctx, c)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(installed).To(HaveLen(2))
+	g.Expect(installedVersion(installed)).To(Equal("v0.1.0"))
+
+	// Nothing is deleted unless confirmed.
+	out := &bytes.Buffer{}
+	g.Expect(deleteProvider(ctx, c, strings.NewReader("n\n"), out, aws)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Delete the 1 components of the infrastructure provider aws? [y/N]: Aborted"))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(serviceAccount), &corev1.ServiceAccount{})).To(Succeed())
+
+	// The Namespace is kept unless --include-namespace is set.
+	out.Reset()
+	g.Expect(deleteProvider(ctx, c, strings.NewReader("y\n"), out, aws)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("ServiceAccount/manager deleted"))
+	g.Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(serviceAccount), &corev1.ServiceAccount{}))).To(BeTrue())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(namespace), &corev1.Namespace{})).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(other), &corev1.ServiceAccount{})).To(Succeed())
+
+	deleteProviderOpts.includeNamespace, deleteProviderOpts.yes = true, true
+	defer func() { deleteProviderOpts.includeNamespace, deleteProviderOpts.yes = false, false }()
+	out.Reset()
+	g.Expect(deleteProvider(ctx, c, strings.NewReader(""), out, aws)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Namespace/forge-aws-system deleted"))
+	g.Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(namespace), &corev1.Namespace{}))).To(BeTrue())
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/buildtemplate"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+)
+
+type renderOptions struct {
+	filename             string
+	set                  []string
+	name                 string
+	jobs                 bool
+	provisionerNamespace string
+}
+
+var renderOpts = &renderOptions{}
+
+var renderCmd = &cobra.Command{
+	Use:   "render -f FILE",
+	Short: "Render the Build instantiated from a BuildTemplate",
+	Long: "Expand the BuildTemplate of the file with the variables set by --set, the defaults of the template for the " +
+		"others, and print the resulting Build for review before applying it. The Build references the template, " +
+		"whose infrastructure template is cloned by the controller when the Build is created. With --jobs, the Jobs " +
+		"which would run the provisioners of the Build in dry-run mode are printed as well, along with the " +
+		"ConfigMaps of their scripts; the scripts and host keys referenced by the provisioners are read from the " +
+		"management cluster.",
+	Example: `  # Render the Build of the ubuntu template for the 24.04 release
+  forgectl render -f buildtemplate.yaml --set release=24.04
+
+  # Review the Jobs of its provisioners, then create it
+  forgectl render -f buildtemplate.yaml --set release=24.04 --jobs
+  forgectl render -f buildtemplate.yaml --set release=24.04 | forgectl build create -f -`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runRender(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderOpts.filename, "filename", "f", "",
+		"The file holding the BuildTemplate, - for the standard input")
+	renderCmd.Flags().StringArrayVar(&renderOpts.set, "set", nil,
+		"Set a variable of the template, as NAME=VALUE, can be repeated")
+	renderCmd.Flags().StringVar(&renderOpts.name, "name", "",
+		"The name of the Build, defaults to the name of the template")
+	renderCmd.Flags().BoolVar(&renderOpts.jobs, "jobs", false,
+		"Print the Jobs which would run the provisioners of the Build in dry-run mode")
+	renderCmd.Flags().StringVar(&renderOpts.provisionerNamespace, "provisioner-namespace", defaultProvisionerNamespace,
+		"The namespace of the Jobs of the provisioners placed in the core namespace")
+	_ = renderCmd.MarkFlagRequired("filename")
+
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(ctx context.Context, in io.Reader, out io.Writer) error {
+	objs, err := readManifests(in, renderOpts.filename)
+	if err != nil {
+		return err
+	}
+	template, err := findBuildTemplate(objs)
+	if err != nil {
+		return err
+	}
+	if template.Namespace == "" {
+		if template.Namespace, err = globalOpts.currentNamespace(); err != nil {
+			return err
+		}
+	}
+	variables, err := parseVariables(renderOpts.set)
+	if err != nil {
+		return err
+	}
+	build, rendered, err := renderBuild(template, renderOpts.name, variables)
+	if err != nil {
+		return err
+	}
+
+	rendered.TypeMeta = metav1.TypeMeta{APIVersion: buildv1.GroupVersion.String(), Kind: "Build"}
+	docs := []interface{}{rendered}
+	if renderOpts.jobs {
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		jobs, err := plannedJobs(ctx, c, build, renderOpts.provisionerNamespace)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, jobs...)
+	}
+	return printDocuments(out, docs)
+}
+
+// findBuildTemplate returns the single BuildTemplate of the objects.
+func findBuildTemplate(objs []*unstructured.Unstructured) (*buildv1.BuildTemplate, error) {
+	var template *buildv1.BuildTemplate
+	for _, obj := range objs {
+		if obj.GroupVersionKind() != buildv1.GroupVersion.WithKind("BuildTemplate") {
+			continue
+		}
+		if template != nil {
+			return nil, fmt.Errorf("the file holds more than one BuildTemplate")
+		}
+		template = &buildv1.BuildTemplate{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, template); err != nil {
+			return nil, fmt.Errorf("decoding BuildTemplate %s: %w", obj.GetName(), err)
+		}
+	}
+	if template == nil {
+		return nil, fmt.Errorf("the file holds no BuildTemplate")
+	}
+	return template, nil
+}
+
+// parseVariables parses the NAME=VALUE variables of --set.
+func parseVariables(set []string) ([]buildv1.BuildVariable, error) {
+	variables := []buildv1.BuildVariable{}
+	for _, s := range set {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected NAME=VALUE", s)
+		}
+		variables = append(variables, buildv1.BuildVariable{Name: name, Value: value})
+	}
+	return variables, nil
+}
+
+// renderBuild returns the Build instantiated from the template, with the fields set by the template as the
+// controller sets them, and the Build to apply, which only references the template. The infrastructure template
+// is cloned by the controller, the infrastructure of the instantiated Build references the clone.
+func renderBuild(template *buildv1.BuildTemplate, name string, variables []buildv1.BuildVariable) (instantiated, manifest *buildv1.Build, err error) {
+	if name == "" {
+		name = template.Name
+	}
+	manifest = &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: template.Namespace, Name: name},
+		Spec: buildv1.BuildSpec{
+			TemplateRef: &buildv1.BuildTemplateReference{Name: template.Name},
+			Variables:   variables,
+		},
+	}
+	spec, err := buildtemplate.Render(template, variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The fields of the template are set on the manifest, so they can be reviewed, the controller doesn't override
+	// them when it instantiates the template.
+	buildtemplate.Merge(&manifest.Spec, spec)
+	instantiated = manifest.DeepCopy()
+	if ref := spec.InfrastructureRef; ref != nil {
+		instantiated.Spec.InfrastructureRef = &corev1.ObjectReference{
+			APIVersion: ref.APIVersion,
+			Kind:       strings.TrimSuffix(ref.Kind, buildv1.TemplateSuffix),
+			Namespace:  instantiated.Namespace,
+			Name:       instantiated.Name,
+		}
+	}
+	if len(variables) > 0 {
+		// The name of the variables secret written by the controller.
+		instantiated.Status.VariablesSecretName = instantiated.Name + "-variables"
+	}
+	return instantiated, manifest, nil
+}
+
+// plannedJobs returns the Jobs which would run the provisioners of the Build in dry-run mode, followed by the
+// ConfigMaps of their scripts.
+func plannedJobs(ctx context.Context, c client.Client, build *buildv1.Build, provisionerNamespace string) ([]interface{}, error) {
+	placement := shellcontroller.PlacementOptions{CoreNamespace: provisionerNamespace}
+	docs := []interface{}{}
+	for i := range build.Spec.Provisioners {
+		spec := &build.Spec.Provisioners[i]
+		if !spec.Type.RunsInJob() {
+			continue
+		}
+		planned, scripts, err := shellcontroller.PlannedJob(ctx, c, build, spec, shellcontroller.ImageOptions{}, placement)
+		if err != nil {
+			return nil, fmt.Errorf("rendering the Job of provisioner %s: %w", shellcontroller.PlanKey(spec), err)
+		}
+		docs = append(docs, planned)
+		if scripts != nil {
+			docs = append(docs, scriptsConfigMap(planned, scripts))
+		}
+	}
+	return docs, nil
+}
+
+// scriptsConfigMap returns the ConfigMap of the scripts mounted by the Job.
+func scriptsConfigMap(job *batchv1.Job, scripts map[string]string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: job.Namespace},
+		Data:       scripts,
+	}
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		if volume.ConfigMap != nil {
+			cm.Name = volume.ConfigMap.Name
+		}
+	}
+	return cm
+}
+
+// printDocuments prints the objects as a multi-document YAML stream.
+func printDocuments(out io.Writer, docs []interface{}) error {
+	for i, doc := range docs {
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("encoding %T: %w", doc, err)
+		}
+		if i > 0 {
+			fmt.Fprintln(out, "---")
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const ubuntuTemplate = `
+apiVersion: forge.build/v1alpha1
+kind: BuildTemplate
+metadata:
+  name: ubuntu
+  namespace: images
+spec:
+  variables:
+  - name: release
+    required: true
+  - name: user
+    default: ubuntu
+  template:
+    spec:
+      connector:
+        type: ssh
+        username: ${user}
+      infrastructureRef:
+        apiVersion: infrastructure.forge.build/v1alpha1
+        kind: AWSBuildTemplate
+        name: ubuntu
+      provisioners:
+      - name: upgrade
+        type: built-in/shell
+        run: do-release-upgrade --to ${release}
+`
+
+func TestRenderBuild(t *testing.T) {
+	g := NewWithT(t)
+
+	objs, err := parseManifests([]byte(ubuntuTemplate))
+	g.Expect(err).ToNot(HaveOccurred())
+	template, err := findBuildTemplate(objs)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	variables, err := parseVariables([]string{"release=24.04"})
+	g.Expect(err).ToNot(HaveOccurred())
+	build, manifest, err := renderBuild(template, "ubuntu-24-04", variables)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(manifest.Name).To(Equal("ubuntu-24-04"))
+	g.Expect(manifest.Namespace).To(Equal("images"))
+	g.Expect(manifest.Spec.TemplateRef.Name).To(Equal("ubuntu"))
+	g.Expect(manifest.Spec.Connector.Username).To(Equal("ubuntu"))
+	g.Expect(*manifest.Spec.Provisioners[0].Run).To(Equal("do-release-upgrade --to 24.04"))
+	g.Expect(manifest.Spec.InfrastructureRef).To(BeNil())
+	g.Expect(build.Spec.InfrastructureRef.Kind).To(Equal("AWSBuild"))
+	g.Expect(build.Spec.InfrastructureRef.Name).To(Equal("ubuntu-24-04"))
+	g.Expect(build.Status.VariablesSecretName).To(Equal("ubuntu-24-04-variables"))
+
+	_, _, err = renderBuild(template, "", nil)
+	g.Expect(err).To(MatchError(ContainSubstring("required variables release of BuildTemplate ubuntu are not set")))
+	_, err = parseVariables([]string{"release"})
+	g.Expect(err).To(MatchError(`invalid variable "release", expected NAME=VALUE`))
+	_, err = findBuildTemplate(nil)
+	g.Expect(err).To(MatchError("the file holds no BuildTemplate"))
+
+	jobs, err := plannedJobs(context.Background(), fake.NewClientBuilder().WithScheme(scheme).Build(), build, "forge-core")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(jobs).To(HaveLen(1))
+	job := jobs[0].(*batchv1.Job)
+	g.Expect(job.Namespace).To(Equal("forge-core"))
+	g.Expect(job.Labels).To(HaveKeyWithValue(buildv1.BuildNameLabel, "ubuntu-24-04"))
+
+	out := &bytes.Buffer{}
+	g.Expect(printDocuments(out, []interface{}{manifest, job})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("run: do-release-upgrade --to 24.04\n"))
+	g.Expect(out.String()).To(ContainSubstring("\n---\n"))
+}
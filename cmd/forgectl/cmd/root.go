@@ -0,0 +1,131 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd implements the forgectl commands.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	utilruntime.Must(buildv1.AddToScheme(scheme))
+}
+
+// globalOptions are the options shared by all the forgectl commands.
+type globalOptions struct {
+	kubeconfig  string
+	kubeContext string
+	namespace   string
+}
+
+var globalOpts = &globalOptions{}
+
+var rootCmd = &cobra.Command{
+	Use:          "forgectl",
+	Short:        "forgectl controls the Forge image builder",
+	Long:         "forgectl is a command line tool to operate Forge builds and the Forge controller.",
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&globalOpts.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use, defaults to the KUBECONFIG environment variable or ~/.kube/config")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.kubeContext, "context", "",
+		"The kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVarP(&globalOpts.namespace, "namespace", "n", "",
+		"The namespace to use, defaults to the namespace of the current kubeconfig context")
+}
+
+// Execute runs the forgectl root command.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// clientConfig returns the kubeconfig loader honoring the global flags.
+func (o *globalOptions) clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.kubeContext}
+	if o.namespace != "" {
+		overrides.Context.Namespace = o.namespace
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// restConfig returns the configuration to connect to the management cluster.
+func (o *globalOptions) restConfig() (*rest.Config, error) {
+	cfg, err := o.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	return cfg, nil
+}
+
+// currentNamespace returns the namespace from the flags or the current kubeconfig context.
+func (o *globalOptions) currentNamespace() (string, error) {
+	ns, _, err := o.clientConfig().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	return ns, nil
+}
+
+// newClient returns a client to the management cluster.
+func (o *globalOptions) newClient() (client.Client, error) {
+	cfg, err := o.restConfig()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	return c, nil
+}
+
+// newClientset returns a Kubernetes clientset to the management cluster, for the APIs the controller-runtime client
+// doesn't serve, e.g. the logs of the pods.
+func (o *globalOptions) newClientset() (kubernetes.Interface, error) {
+	cfg, err := o.restConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+	return clientset, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+// terminalResizeInterval is how often the size of the local terminal is checked during an ssh session.
+const terminalResizeInterval = 500 * time.Millisecond
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh BUILD",
+	Short: "Open a shell on the machine of a Build",
+	Long: "Open an interactive shell on the machine of the Build with the connector of the Build, its credentials " +
+		"secret, bastion and accepted host keys, e.g. to debug a provisioner of a paused Build. " +
+		"The session ends when the machine of the Build is deleted.",
+	Example: `  # Open a shell on the machine of the Build ubuntu
+  forgectl ssh ubuntu`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := globalOpts.newClient()
+		if err != nil {
+			return err
+		}
+		namespace, err := globalOpts.currentNamespace()
+		if err != nil {
+			return err
+		}
+		sshClient, err := sshClientFor(cmd.Context(), c, client.ObjectKey{Namespace: namespace, Name: args[0]})
+		if err != nil {
+			return err
+		}
+		return runShell(cmd.Context(), sshClient, os.Stdin, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshCmd)
+}
+
+// sshClientFor returns the SSH client to the machine of the Build.
+func sshClientFor(ctx context.Context, c client.Client, key client.ObjectKey) (*ssh.SSHClient, error) {
+	build := &buildv1.Build{}
+	if err := c.Get(ctx, key, build); err != nil {
+		return nil, fmt.Errorf("getting Build %s: %w", key, err)
+	}
+	switch {
+	case build.Spec.Connector.Type != buildv1.ConnectorTypeSSH:
+		return nil, fmt.Errorf("build %s connects to its machine with %s, not ssh", key, build.Spec.Connector.Type)
+	case !build.Status.InfrastructureReady:
+		return nil, fmt.Errorf("the machine of Build %s is not ready", key)
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return nil, fmt.Errorf("creating the SSH client of Build %s: %w", key, err)
+	}
+	if sshClient.IP == nil {
+		return nil, fmt.Errorf("the credentials secret of Build %s has no host", key)
+	}
+	return sshClient, nil
+}
+
+// runShell connects to the machine and runs an interactive shell, the local terminal is put in raw mode for the
+// duration of the session and its size is forwarded to the machine.
+func runShell(ctx context.Context, sshClient *ssh.SSHClient, stdin *os.File, stdout, stderr io.Writer) error {
+	if err := sshClient.Connect(); err != nil {
+		return fmt.Errorf("connecting to %s: %w", sshClient.IP, err)
+	}
+	defer sshClient.Disconnect()
+
+	terminal := ssh.Terminal{Term: os.Getenv("TERM")}
+	fd := int(stdin.Fd())
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("setting the terminal in raw mode: %w", err)
+		}
+		defer func() { _ = term.Restore(fd, state) }()
+		terminal.Width, terminal.Height, _ = term.GetSize(fd)
+
+		resize := make(chan ssh.TerminalSize, 1)
+		terminal.Resize = resize
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go watchTerminalSize(ctx, fd, terminal.Width, terminal.Height, resize)
+	}
+
+	err := sshClient.Shell(ctx, terminal, stdin, stdout, stderr)
+	var exitErr interface{ ExitStatus() int }
+	if errors.As(err, &exitErr) {
+		// The exit status of the last command run in the shell, not a failure of the session.
+		return nil
+	}
+	return err
+}
+
+// watchTerminalSize sends the size of the terminal whenever it changes, until the context is done.
+func watchTerminalSize(ctx context.Context, fd, width, height int, resize chan<- ssh.TerminalSize) {
+	ticker := time.NewTicker(terminalResizeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w, h, err := term.GetSize(fd)
+			if err != nil || w == width && h == height {
+				continue
+			}
+			width, height = w, h
+			select {
+			case resize <- ssh.TerminalSize{Width: w, Height: h}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
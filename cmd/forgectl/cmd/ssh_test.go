@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestSSHClientFor(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Connector: buildv1.ConnectorSpec{
+			Type:        buildv1.ConnectorTypeSSH,
+			Port:        2222,
+			Credentials: &corev1.LocalObjectReference{Name: "ubuntu-ssh"},
+		}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu-ssh"},
+		Data:       map[string][]byte{"host": []byte("10.0.0.1"), "username": []byte("ubuntu"), "password": []byte("secret")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, secret).Build()
+	key := client.ObjectKeyFromObject(build)
+
+	// The machine must be ready.
+	_, err := sshClientFor(ctx, c, key)
+	g.Expect(err).To(MatchError("the machine of Build images/ubuntu is not ready"))
+
+	build.Status.InfrastructureReady = true
+	g.Expect(c.Update(ctx, build)).To(Succeed())
+	sshClient, err := sshClientFor(ctx, c, key)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sshClient.IP.String()).To(Equal("10.0.0.1"))
+	g.Expect(sshClient.Port).To(Equal(2222))
+	g.Expect(sshClient.Creds.SSHUser).To(Equal("ubuntu"))
+
+	// The Builds connecting with docker exec have no SSH server.
+	build.Spec.Connector.Type = buildv1.ConnectorTypeDockerExec
+	g.Expect(c.Update(ctx, build)).To(Succeed())
+	_, err = sshClientFor(ctx, c, key)
+	g.Expect(err).To(MatchError(ContainSubstring("connects to its machine with docker-exec, not ssh")))
+}
@@ -21,10 +21,16 @@ import (
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"strings"
+	"time"
 
 	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -34,15 +40,36 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	buildv1beta1 "github.com/forge-build/forge/api/v1beta1"
+	exporterjob "github.com/forge-build/forge/exporter/job"
 	buildctrl "github.com/forge-build/forge/internal/controller"
+	"github.com/forge-build/forge/internal/infrastructure/aws"
+	"github.com/forge-build/forge/internal/infrastructure/azure"
+	"github.com/forge-build/forge/internal/infrastructure/docker"
+	"github.com/forge-build/forge/internal/infrastructure/gcp"
+	"github.com/forge-build/forge/internal/infrastructure/kubevirt"
+	"github.com/forge-build/forge/internal/infrastructure/openstack"
+	"github.com/forge-build/forge/internal/infrastructure/proxmox"
+	"github.com/forge-build/forge/internal/infrastructure/static"
+	"github.com/forge-build/forge/internal/infrastructure/vsphere"
+	"github.com/forge-build/forge/pkg/connections"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	"github.com/forge-build/forge/pkg/drain"
+	"github.com/forge-build/forge/pkg/fairqueue"
+	"github.com/forge-build/forge/pkg/identity"
+	forgelog "github.com/forge-build/forge/pkg/log"
+	"github.com/forge-build/forge/pkg/retention"
+	"github.com/forge-build/forge/provisioner/ansible"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -55,6 +82,8 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(buildv1.AddToScheme(scheme))
+	utilruntime.Must(buildv1beta1.AddToScheme(scheme))
+	utilruntime.Must(infrav1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -62,38 +91,150 @@ var (
 	watchFilterValue string
 	buildConcurrency int
 
+	buildFairQueueing bool
+
+	maxConcurrentBuilds int
+
+	maxConcurrentBuildsPerNamespace int
+
+	exporterImage string
+
+	scheduledBuildConcurrency int
+
+	buildSetConcurrency int
+
+	imageRetentionPolicyConcurrency int
+
+	persistProvisionerLogs bool
+
+	shellProvisionerImage            string
+	shellProvisionerImagePullPolicy  string
+	shellProvisionerImagePullSecrets string
+
+	ansibleProvisionerImage string
+
+	shellProvisionerNamespace    string
+	shellProvisionerJobPlacement string
+
+	shellJobRetryBaseDelay time.Duration
+	shellJobRetryMaxDelay  time.Duration
+
+	infrastructureProviders string
+
+	multiTenancy bool
+
 	ForgeCoreNameSpace = os.Getenv("POD_NAMESPACE")
 )
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaderElectionLeaseDuration time.Duration
+	var leaderElectionRenewDeadline time.Duration
+	var leaderElectionRetryPeriod time.Duration
+	var gracefulShutdownTimeout time.Duration
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var enableDebugEndpoints bool
+	var enablePprof bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8089", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"Namespace of the leader election lease, defaults to the namespace of the controller")
+	flag.DurationVar(&leaderElectionLeaseDuration, "leader-elect-lease-duration", 15*time.Second,
+		"Duration the replicas which are not leading wait before trying to acquire the leadership")
+	flag.DurationVar(&leaderElectionRenewDeadline, "leader-elect-renew-deadline", 10*time.Second,
+		"Duration the leader retries to renew the leadership before giving it up")
+	flag.DurationVar(&leaderElectionRetryPeriod, "leader-elect-retry-period", 2*time.Second,
+		"Duration the replicas wait between two attempts to acquire or renew the leadership")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", drain.DefaultGracePeriod,
+		"Duration the in-flight reconciles are given to finish on SIGTERM, the leadership is released once they have finished. "+
+			"It must be shorter than the terminationGracePeriodSeconds of the Pod")
 	flag.BoolVar(&secureMetrics, "metrics-secure", false,
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 
+	flag.BoolVar(&enableDebugEndpoints, "enable-debug-endpoints", false,
+		"If set, debug endpoints, e.g. the inventory of open connections, are served by the metrics server")
+	flag.BoolVar(&enablePprof, "enable-pprof", false,
+		"If set, pprof endpoints are served by the metrics server under /debug/pprof")
+
 	flag.StringVar(&watchFilterValue, "watch-filter", "",
-		fmt.Sprintf("Label value that the controller watches to reconcile cluster-api objects. Label key is always %s. If unspecified, the controller watches for all cluster-api objects.", buildv1.WatchLabel))
+		fmt.Sprintf("Label value that the controller watches to reconcile cluster-api objects. Label key is always %s, the legacy key %s is still honored. If unspecified, the controller watches for all cluster-api objects.", buildv1.WatchLabel, buildv1.LegacyWatchLabel))
 
 	flag.IntVar(&buildConcurrency, "build-concurrency", 10,
 		"Number of builds to process simultaneously")
 
-	opts := zap.Options{
-		Development: true,
-	}
-	opts.BindFlags(flag.CommandLine)
+	flag.BoolVar(&buildFairQueueing, "build-fair-queueing", true,
+		"Reconcile the builds of every namespace in turn, so a namespace with many builds does not delay the builds of the other namespaces")
+
+	flag.IntVar(&maxConcurrentBuilds, "max-concurrent-builds", 0,
+		"Maximum number of builds in progress in the cluster, the other builds are queued in the Pending phase. 0 means unlimited")
+
+	flag.IntVar(&maxConcurrentBuildsPerNamespace, "max-concurrent-builds-per-namespace", 0,
+		"Maximum number of builds in progress in a namespace, the other builds are queued in the Pending phase. 0 means unlimited")
+
+	flag.StringVar(&exporterImage, "exporter-image", exporterjob.DefaultImage,
+		"The image of the Jobs packaging and publishing the exports of the builds")
+
+	flag.IntVar(&scheduledBuildConcurrency, "scheduledbuild-concurrency", 1,
+		"Number of scheduled builds to process simultaneously")
+
+	flag.IntVar(&buildSetConcurrency, "buildset-concurrency", 1,
+		"Number of build sets to process simultaneously")
+
+	flag.IntVar(&imageRetentionPolicyConcurrency, "imageretentionpolicy-concurrency", 1,
+		"Number of image retention policies to process simultaneously")
+
+	flag.BoolVar(&persistProvisionerLogs, "persist-provisioner-logs", true,
+		"Record the logs of the failed provisioner jobs in a ConfigMap of their build, they are deleted along with the jobs otherwise")
+
+	flag.StringVar(&shellProvisionerImage, "shell-provisioner-image", shelljob.DefaultImage,
+		"The default image of the Jobs running the shell provisioners, overridden by the image of a provisioner")
+
+	flag.StringVar(&shellProvisionerImagePullPolicy, "shell-provisioner-image-pull-policy", string(corev1.PullIfNotPresent),
+		"The default pull policy of the shell provisioner image, one of Always, Never or IfNotPresent")
+
+	flag.StringVar(&shellProvisionerImagePullSecrets, "shell-provisioner-image-pull-secrets", "",
+		"Comma separated names of the secrets, in the namespace of the Jobs, used to pull the shell provisioner image")
+
+	flag.StringVar(&ansibleProvisionerImage, "ansible-provisioner-image", ansible.DefaultImage,
+		"The default image of the Jobs running the ansible provisioners, overridden by the image of a provisioner")
+
+	flag.StringVar(&shellProvisionerNamespace, "shell-provisioner-namespace", shellcontroller.ForgeCoreNamespace,
+		"The namespace of the Jobs running the shell provisioners placed in the core namespace")
+
+	flag.StringVar(&shellProvisionerJobPlacement, "shell-provisioner-job-placement", string(buildv1.ProvisionerJobPlacementCore),
+		"Where the Jobs running the shell provisioners are placed when the Build doesn't set spec.provisionerJobPlacement, "+
+			"Core runs them in --shell-provisioner-namespace and Build in the namespace of the Build")
+
+	flag.DurationVar(&shellJobRetryBaseDelay, "shelljob-retry-base-delay", time.Second,
+		"Delay before processing again a shell provisioner job which hit a transient error, doubled on every consecutive failure")
+
+	flag.DurationVar(&shellJobRetryMaxDelay, "shelljob-retry-max-delay", 5*time.Minute,
+		"Maximum delay before processing again a shell provisioner job which hit a transient error")
+
+	flag.StringVar(&infrastructureProviders, "infrastructure-providers", "",
+		"Comma separated list of the in-tree infrastructure providers to run, e.g. aws,azure,gcp,vsphere,kubevirt,docker,openstack,proxmox,static")
+
+	flag.BoolVar(&multiTenancy, "multi-tenancy", false,
+		"Restrict the Builds of a namespace to the infrastructure kinds listed by the "+buildv1.AllowedInfrastructureKindsAnnotation+
+			" annotation of the namespace and to the credentials of the namespace, the CredentialsProviders cannot authenticate as the controller")
+
+	var logOptions forgelog.Options
+	logOptions.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	if _, err := forgelog.Setup(logOptions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -115,46 +256,108 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	switch corev1.PullPolicy(shellProvisionerImagePullPolicy) {
+	case corev1.PullAlways, corev1.PullNever, corev1.PullIfNotPresent:
+	default:
+		setupLog.Error(nil, "invalid --shell-provisioner-image-pull-policy", "policy", shellProvisionerImagePullPolicy)
+		os.Exit(1)
+	}
+
+	switch buildv1.ProvisionerJobPlacement(shellProvisionerJobPlacement) {
+	case buildv1.ProvisionerJobPlacementCore, buildv1.ProvisionerJobPlacementBuild:
+	default:
+		setupLog.Error(nil, "invalid --shell-provisioner-job-placement", "placement", shellProvisionerJobPlacement)
+		os.Exit(1)
+	}
+
+	// Debug endpoints expose internal state and profiles, only serve them when the metrics
+	// server is protected, either bound to the loopback interface or secured.
+	if (enableDebugEndpoints || enablePprof) && !secureMetrics && !isLoopbackAddress(metricsAddr) {
+		setupLog.Error(nil, "debug endpoints require --metrics-secure or a loopback --metrics-bind-address", "metrics-bind-address", metricsAddr)
+		os.Exit(1)
+	}
+	extraHandlers := map[string]http.Handler{}
+	if enableDebugEndpoints {
+		extraHandlers[connections.Path] = connections.DefaultInventory
+	}
+	if enablePprof {
+		extraHandlers["/debug/pprof/"] = http.HandlerFunc(pprof.Index)
+		extraHandlers["/debug/pprof/cmdline"] = http.HandlerFunc(pprof.Cmdline)
+		extraHandlers["/debug/pprof/profile"] = http.HandlerFunc(pprof.Profile)
+		extraHandlers["/debug/pprof/symbol"] = http.HandlerFunc(pprof.Symbol)
+		extraHandlers["/debug/pprof/trace"] = http.HandlerFunc(pprof.Trace)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
 			TLSOpts:       tlsOpts,
+			ExtraHandlers: extraHandlers,
 		},
-		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "core.forge.build",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
+		WebhookServer:           webhookServer,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "core.forge.build",
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaderElectionLeaseDuration,
+		RenewDeadline:           &leaderElectionRenewDeadline,
+		RetryPeriod:             &leaderElectionRetryPeriod,
+		// The leadership is released as soon as the in-flight reconciles have drained, so the next leader does
+		// not wait for the lease to expire. The program ends as soon as the manager is stopped.
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// The CredentialsProviders of the namespaces must bring their own credentials, not use the ones of the controller.
+	credentialsprovider.AllowControllerIdentity = !multiTenancy
+
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
-	// setupChecks(mgr)
+	// The reconciles of all the controllers are drained when the manager is stopped.
+	drain.DefaultDrainer = drain.NewDrainer(gracefulShutdownTimeout)
+	if err := mgr.Add(drain.DefaultDrainer); err != nil {
+		setupLog.Error(err, "unable to set up the draining of the reconciles")
+		os.Exit(1)
+	}
+
 	err = setupReconcilers(ctx, mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to setup reconcilers")
 		os.Exit(1)
 	}
 
+	enableWebhooks := os.Getenv("ENABLE_WEBHOOKS") != "false"
+	if enableWebhooks {
+		if err = (&buildv1.Build{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Build")
+			os.Exit(1)
+		}
+		if err = (&buildv1.ScheduledBuild{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScheduledBuild")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	setupChecks(mgr, enableWebhooks)
+
+	setupLog.Info("starting manager")
+	if err := mgr.Start(ctx); err != nil {
+		setupLog.Error(err, "problem running manager")
+		os.Exit(1)
+	}
+}
+
+// setupChecks sets up the /healthz and /readyz endpoints. A replica is ready once its webhook server has started,
+// whether it leads or not, and no longer ready while it drains its reconciles on shutdown.
+func setupChecks(mgr ctrl.Manager, enableWebhooks bool) {
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -163,33 +366,64 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("drain", drain.DefaultDrainer.Checker); err != nil {
+		setupLog.Error(err, "unable to create ready check")
+		os.Exit(1)
+	}
+	if !enableWebhooks {
+		return
+	}
 
-	setupLog.Info("starting manager")
-	if err := mgr.Start(ctx); err != nil {
-		setupLog.Error(err, "problem running manager")
+	if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		setupLog.Error(err, "unable to create ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddHealthzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		setupLog.Error(err, "unable to create health check")
 		os.Exit(1)
 	}
 }
 
-//func setupChecks(mgr ctrl.Manager) {
-//	if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
-//		setupLog.Error(err, "unable to create ready check")
-//		os.Exit(1)
-//	}
-//
-//	if err := mgr.AddHealthzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
-//		setupLog.Error(err, "unable to create health check")
-//		os.Exit(1)
-//	}
-//}
-
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) error {
+	buildOptions := concurrency(buildConcurrency)
+	if buildFairQueueing {
+		buildOptions.NewQueue = fairqueue.NewRateLimitingQueue
+	}
 	if err := (&buildctrl.BuildReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+
+		WatchFilterValue:                watchFilterValue,
+		ExporterImage:                   exporterImage,
+		MaxConcurrentBuilds:             maxConcurrentBuilds,
+		MaxConcurrentBuildsPerNamespace: maxConcurrentBuildsPerNamespace,
+		MultiTenancy:                    multiTenancy,
+		ShellProvisionerImage:           shellProvisionerImageOptions(),
+		AnsibleProvisionerImage:         ansibleProvisionerImage,
+		ShellProvisionerPlacement: shellcontroller.PlacementOptions{
+			CoreNamespace: shellProvisionerNamespace,
+			Placement:     buildv1.ProvisionerJobPlacement(shellProvisionerJobPlacement),
+		},
+	}).SetupWithManager(ctx, mgr, buildOptions); err != nil {
+		return err
+	}
+
+	if err := (&buildctrl.ScheduledBuildReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(ctx, mgr, concurrency(scheduledBuildConcurrency)); err != nil {
+		return err
+	}
+
+	if err := (&buildctrl.BuildSetReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 
 		WatchFilterValue: watchFilterValue,
-	}).SetupWithManager(ctx, mgr, concurrency(buildConcurrency)); err != nil {
+	}).SetupWithManager(ctx, mgr, concurrency(buildSetConcurrency)); err != nil {
 		return err
 	}
 
@@ -203,12 +437,144 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) error {
 	if err := (&shellcontroller.ShellJobController{
 		Client:    mgr.GetClient(),
 		Logger:    ctrl.Log.WithName("controllers").WithName("ShellJob"),
-		Namespace: "forge-core", // TODO change to ForgeCoreNameSpace
 		Clientset: clientSet,
-	}).SetupWithManager(mgr); err != nil {
+
+		PersistLogs: persistProvisionerLogs,
+	}).SetupWithManager(mgr, controller.Options{
+		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(shellJobRetryBaseDelay, shellJobRetryMaxDelay),
+	}); err != nil {
 		return err
 	}
-	return nil
+	deleters, err := setupInfrastructureProviders(ctx, mgr)
+	if err != nil {
+		return err
+	}
+
+	return (&buildctrl.ImageRetentionPolicyReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Deleters: deleters,
+
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(ctx, mgr, concurrency(imageRetentionPolicyConcurrency))
+}
+
+// setupInfrastructureProviders sets up the controllers of the in-tree infrastructure providers enabled by the flags,
+// along with the validation of their ProviderIdentities. It returns the deleters of the machine images of the
+// providers, by infrastructure kind.
+func setupInfrastructureProviders(ctx context.Context, mgr ctrl.Manager) (map[string]retention.Deleter, error) {
+	deleters := map[string]retention.Deleter{}
+	for _, provider := range strings.Split(infrastructureProviders, ",") {
+		var err error
+		var validator identity.Validator
+		switch provider = strings.TrimSpace(provider); provider {
+		case "":
+			continue
+		case aws.ProviderName:
+			err = (&aws.AWSBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &aws.Validator{}
+			deleters["AWSBuild"] = &aws.ImageDeleter{}
+		case azure.ProviderName:
+			err = (&azure.AzureBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &azure.Validator{}
+			deleters["AzureBuild"] = &azure.ImageDeleter{}
+		case gcp.ProviderName:
+			err = (&gcp.GCPBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &gcp.Validator{}
+			deleters["GCPBuild"] = &gcp.ImageDeleter{}
+		case vsphere.ProviderName:
+			err = (&vsphere.VSphereBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &vsphere.Validator{}
+		case kubevirt.ProviderName:
+			err = (&kubevirt.KubeVirtBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+				ExporterImage:    exporterImage,
+				MultiTenancy:     multiTenancy,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+		case docker.ProviderName:
+			err = (&docker.DockerBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &docker.Validator{}
+		case openstack.ProviderName:
+			err = (&openstack.OpenStackBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &openstack.Validator{}
+		case proxmox.ProviderName:
+			err = (&proxmox.ProxmoxBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+			validator = &proxmox.Validator{}
+		case static.ProviderName:
+			err = (&static.StaticBuildReconciler{
+				Client:           mgr.GetClient(),
+				WatchFilterValue: watchFilterValue,
+			}).SetupWithManager(ctx, mgr, controller.Options{})
+		default:
+			return nil, errors.Errorf("unknown infrastructure provider %q", provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if validator == nil {
+			// The provider has no credentials, e.g. the VMs of KubeVirt run in the cluster, and the static machines
+			// carry their own.
+			continue
+		}
+		if err := (&identity.Reconciler{
+			Client:           mgr.GetClient(),
+			Provider:         provider,
+			Validator:        validator,
+			WatchFilterValue: watchFilterValue,
+		}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+			return nil, err
+		}
+	}
+	return deleters, nil
+}
+
+// isLoopbackAddress returns true if the given bind address only listens on the loopback interface.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// shellProvisionerImageOptions returns the default image options of the shell provisioners set by the flags.
+func shellProvisionerImageOptions() shellcontroller.ImageOptions {
+	options := shellcontroller.ImageOptions{
+		Image:      shellProvisionerImage,
+		PullPolicy: corev1.PullPolicy(shellProvisionerImagePullPolicy),
+	}
+	for _, name := range strings.Split(shellProvisionerImagePullSecrets, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			options.PullSecrets = append(options.PullSecrets, corev1.LocalObjectReference{Name: name})
+		}
+	}
+	return options
 }
 
 func concurrency(c int) controller.Options {
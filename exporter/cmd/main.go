@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main runs the exporter in the export Jobs of the Builds.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter"
+)
+
+func main() {
+	klog.InitFlags(nil)
+
+	opts := exporter.Options{}
+	cosign := exporter.Cosign{}
+	var format, providers, ociTags string
+	flag.StringVar(&opts.Name, "name", "", "The name of the export")
+	flag.StringVar(&opts.BuildName, "build-name", "", "The name of the Build the image has been produced by")
+	flag.StringVar(&format, "format", "", "The format the image is packaged in, one of VagrantBox, OVA, VMDK, QCOW2, VHD or Raw")
+	flag.StringVar(&opts.Source, "source", "", "The location of the image, either an http(s)://, s3:// or local path")
+	flag.StringVar(&opts.SourceFormat, "source-format", "", "The format of the image, e.g. qcow2 or raw, detected if not set")
+	flag.StringVar(&opts.BoxName, "box-name", "", "The name of the Vagrant box, as <organization>/<name>")
+	flag.StringVar(&opts.BoxVersion, "box-version", "", "The version of the Vagrant box")
+	flag.StringVar(&providers, "providers", "", "The comma separated Vagrant providers the box is packaged for")
+	flag.StringVar(&opts.RegistryURL, "registry-url", "", "The URL of the box registry API the box is published to")
+	flag.StringVar(&opts.ObjectStorageURL, "object-storage-url", "", "The s3://<bucket>/<prefix> URL the export is published to")
+	flag.StringVar(&opts.ObjectStorageEndpoint, "object-storage-endpoint", "", "The endpoint of the object storage, defaults to AWS S3")
+	flag.StringVar(&opts.ObjectStorageRegion, "object-storage-region", "", "The region of the object storage bucket")
+	flag.StringVar(&opts.AzureBlobURL, "azure-blob-url", "", "The https://<account>/<container>/<prefix> URL of the Azure Storage container the export is published to")
+	flag.StringVar(&opts.HTTPURL, "http-url", "", "The URL of the HTTP endpoint the export is uploaded under")
+	flag.StringVar(&opts.OCIRepository, "oci-repository", "", "The <registry>/<name> repository the disk is pushed to as an OCI artifact")
+	flag.StringVar(&ociTags, "oci-tags", "", "The comma separated tags of the OCI artifact")
+	flag.Func("oci-annotation", "An annotation of the manifest of the OCI artifact as <key>=<value>, may be repeated", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid annotation %q, expected <key>=<value>", s)
+		}
+		if opts.OCIAnnotations == nil {
+			opts.OCIAnnotations = map[string]string{}
+		}
+		opts.OCIAnnotations[key] = value
+		return nil
+	})
+	flag.BoolVar(&opts.OCIInsecure, "oci-insecure", false, "Push the OCI artifact over plain HTTP")
+	flag.StringVar(&opts.SBOMFile, "sbom", "", "The SBOM of the machine attached as a referrer to the OCI artifact")
+	flag.StringVar(&opts.SBOMMediaType, "sbom-media-type", buildv1.SPDXMediaType, "The media type of the SBOM")
+	flag.StringVar(&cosign.KeyFile, "signing-key", "", "The cosign private key the export is signed with")
+	flag.StringVar(&cosign.PasswordFile, "signing-password", "", "The file holding the password of the cosign private key")
+	flag.StringVar(&cosign.IdentityTokenFile, "identity-token", "", "The OIDC token the export is signed keyless with")
+	flag.StringVar(&cosign.FulcioURL, "fulcio-url", "", "The Fulcio certificate authority of the keyless signature")
+	flag.StringVar(&cosign.RekorURL, "rekor-url", "", "The Rekor transparency log the signature is recorded in")
+	flag.StringVar(&opts.OutputDir, "output-dir", "", "The directory the export is written to instead of being published")
+	flag.StringVar(&opts.CredentialsDir, "credentials-dir", exporter.CredentialsDir, "The directory holding the credentials of the destination")
+	flag.StringVar(&opts.WorkDir, "work-dir", exporter.WorkDir, "The directory the image is downloaded and packaged in")
+	flag.Parse()
+
+	opts.Format = buildv1.ExportFormat(format)
+	opts.Provenance = []byte(os.Getenv(exporter.ProvenanceEnv))
+	for _, p := range strings.Split(providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			opts.Providers = append(opts.Providers, buildv1.VagrantProvider(p))
+		}
+	}
+	for _, tag := range strings.Split(ociTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.OCITags = append(opts.OCITags, tag)
+		}
+	}
+
+	ctrl.SetLogger(klog.NewKlogr())
+	logger := ctrl.Log.WithName("exporter")
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	logger.Info("Starting export", "name", opts.Name, "format", opts.Format, "source", opts.Source)
+	e := &exporter.Exporter{Options: opts, Converter: exporter.QemuImg{}, Logger: logger}
+	if cosign.KeyFile != "" || cosign.IdentityTokenFile != "" {
+		cosign.WorkDir = opts.WorkDir
+		e.Signer = cosign
+	}
+	result, err := e.Run(ctx)
+	if err != nil {
+		logger.Error(err, "Export failed")
+		// Report the error in the status of the Pod, on top of its logs.
+		_ = os.WriteFile(exporter.TerminationMessagePath, []byte(err.Error()), 0o600)
+		klog.Exit(err)
+	}
+	// Report the result in the status of the Pod, the Build controller records it in the status of the export.
+	if data, err := json.Marshal(result); err == nil {
+		_ = os.WriteFile(exporter.TerminationMessagePath, data, 0o600)
+	}
+	logger.Info("Export published", "url", result.URL, "checksum", result.Checksum)
+}
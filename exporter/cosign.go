@@ -0,0 +1,205 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/forge-build/forge/pkg/oci"
+)
+
+const (
+	// SignatureSuffix and AttestationSuffix are appended to the name of the files of the exports for their cosign
+	// signature bundle and their attestation bundle.
+	SignatureSuffix   = ".sig.bundle"
+	AttestationSuffix = ".att.bundle"
+
+	// ProvenancePredicateType is the type of the predicate of the provenance attestations, as known to cosign.
+	ProvenancePredicateType = "slsaprovenance1"
+)
+
+// Signer signs the exports, and attests their provenance.
+type Signer interface {
+	// SignImage signs the OCI artifact at ref, a reference by digest, and attaches the attestation of the provenance
+	// if any. It returns the references of the signature and of the attestation.
+	SignImage(ctx context.Context, ref string, creds oci.Credentials, provenance []byte) (signature, attestation string, err error)
+	// SignBlob writes the signature bundle of the file at path to path+SignatureSuffix, and the bundle of the
+	// attestation of the provenance if any to path+AttestationSuffix.
+	SignBlob(ctx context.Context, path string, provenance []byte) error
+}
+
+// Cosign signs the exports with the cosign CLI, with a private key or keyless.
+type Cosign struct {
+	// KeyFile is the path of the private key, the exports are signed keyless when empty.
+	KeyFile string
+	// PasswordFile is the path of the password of the private key, which is not encrypted when the file is missing.
+	PasswordFile string
+	// IdentityTokenFile is the path of the OIDC token exchanged for a signing certificate, when keyless.
+	IdentityTokenFile string
+	// FulcioURL is the certificate authority of the keyless signatures.
+	FulcioURL string
+	// RekorURL is the transparency log the signatures are recorded in, the signatures made with a key are not
+	// recorded when empty.
+	RekorURL string
+	// WorkDir is where the predicates and the registry credentials are written.
+	WorkDir string
+}
+
+// SignImage implements Signer.
+func (c Cosign) SignImage(ctx context.Context, ref string, creds oci.Credentials, provenance []byte) (string, string, error) {
+	env, err := c.env()
+	if err != nil {
+		return "", "", err
+	}
+	if creds.Username != "" {
+		// cosign reads the credentials of the registry from the docker config.
+		dir := filepath.Join(c.WorkDir, "docker")
+		if env, err = withDockerConfig(env, dir, ref, creds); err != nil {
+			return "", "", err
+		}
+	}
+	args := c.args()
+	if err := runCosign(ctx, env, append(append([]string{"sign"}, args...), ref)...); err != nil {
+		return "", "", err
+	}
+	repository, digest, _ := strings.Cut(ref, "@")
+	tag := repository + ":" + strings.Replace(digest, ":", "-", 1)
+	if len(provenance) == 0 {
+		return tag + ".sig", "", nil
+	}
+
+	predicate, err := c.writePredicate(provenance)
+	if err != nil {
+		return "", "", err
+	}
+	args = append([]string{"attest", "--type", ProvenancePredicateType, "--predicate", predicate}, args...)
+	if err := runCosign(ctx, env, append(args, ref)...); err != nil {
+		return "", "", err
+	}
+	return tag + ".sig", tag + ".att", nil
+}
+
+// SignBlob implements Signer.
+func (c Cosign) SignBlob(ctx context.Context, path string, provenance []byte) error {
+	env, err := c.env()
+	if err != nil {
+		return err
+	}
+	args := c.args()
+	signArgs := append([]string{"sign-blob", "--bundle", path + SignatureSuffix}, args...)
+	if err := runCosign(ctx, env, append(signArgs, path)...); err != nil {
+		return err
+	}
+	if len(provenance) == 0 {
+		return nil
+	}
+
+	predicate, err := c.writePredicate(provenance)
+	if err != nil {
+		return err
+	}
+	attestArgs := append([]string{"attest-blob", "--type", ProvenancePredicateType, "--predicate", predicate,
+		"--bundle", path + AttestationSuffix}, args...)
+	return runCosign(ctx, env, append(attestArgs, path)...)
+}
+
+// args returns the flags of the signing commands.
+func (c Cosign) args() []string {
+	args := []string{"--yes"}
+	if c.KeyFile != "" {
+		args = append(args, "--key", c.KeyFile)
+		if c.RekorURL == "" {
+			return append(args, "--tlog-upload=false")
+		}
+		return append(args, "--rekor-url", c.RekorURL)
+	}
+
+	// cosign reads the token from the file, it is not exposed in the arguments.
+	args = append(args, "--identity-token", c.IdentityTokenFile)
+	if c.FulcioURL != "" {
+		args = append(args, "--fulcio-url", c.FulcioURL)
+	}
+	if c.RekorURL != "" {
+		args = append(args, "--rekor-url", c.RekorURL)
+	}
+	return args
+}
+
+// env returns the environment of cosign, with the password of the private key.
+func (c Cosign) env() ([]string, error) {
+	env := os.Environ()
+	if c.KeyFile == "" {
+		return env, nil
+	}
+	password, err := os.ReadFile(c.PasswordFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to read the password of the signing key")
+	}
+	// The password is always set, so cosign does not prompt for it.
+	return append(env, "COSIGN_PASSWORD="+strings.TrimSpace(string(password))), nil
+}
+
+func (c Cosign) writePredicate(provenance []byte) (string, error) {
+	predicate := filepath.Join(c.WorkDir, "provenance.json")
+	if err := os.WriteFile(predicate, provenance, 0o600); err != nil {
+		return "", errors.Wrap(err, "failed to write the provenance predicate")
+	}
+	return predicate, nil
+}
+
+// withDockerConfig writes a docker config holding the credentials of the registry of ref to dir, and returns the
+// environment pointing cosign to it.
+func withDockerConfig(env []string, dir, ref string, creds oci.Credentials) ([]string, error) {
+	parsed, err := oci.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+	data, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{parsed.Registry: map[string]string{"auth": auth}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), data, 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed to write the docker config")
+	}
+	return append(env, "DOCKER_CONFIG="+dir), nil
+}
+
+func runCosign(ctx context.Context, env []string, args ...string) error {
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "cosign", args...)
+	cmd.Env = env
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "cosign %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,728 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exporter packages the image produced by a Build in other formats, e.g. Vagrant boxes,
+// and publishes them. It runs in the export Jobs created by the Build controller.
+package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	"github.com/forge-build/forge/exporter/vagrant"
+	"github.com/forge-build/forge/pkg/oci"
+)
+
+const (
+	// ForgeExporterName is the name of the exporter, set as the managed-by label of the export Jobs.
+	ForgeExporterName = "forge-exporter"
+
+	// CredentialsDir is where the secret holding the credentials of the destination is mounted in the export Jobs.
+	CredentialsDir = "/var/run/secrets/export-credentials"
+
+	// WorkDir is where the image is downloaded and packaged in the export Jobs.
+	WorkDir = "/var/lib/forge-exporter"
+
+	// TerminationMessagePath is the file the Result of the export, or its error, is written to.
+	TerminationMessagePath = "/dev/termination-log"
+
+	// SigningDir is where the cosign private key, or the identity token of the keyless signatures, is mounted in the
+	// export Jobs.
+	SigningDir = "/var/run/secrets/signing"
+
+	// ProvenanceEnv is the environment variable holding the SLSA provenance predicate of the export in the export
+	// Jobs.
+	ProvenanceEnv = "FORGE_PROVENANCE"
+
+	// SBOMDir is where the ConfigMap or the Secret holding the SBOM of the machine is mounted in the export Jobs.
+	SBOMDir = "/var/run/forge/sbom"
+
+	// OCIArtifactType is the artifact type of the disks pushed to an OCI registry, their layer has the media type
+	// <OCIArtifactType>.<qcow2|raw>.
+	OCIArtifactType = "application/vnd.forge.build.disk.v1"
+
+	// OCICreatedAnnotation is the annotation of the manifests of the artifacts holding their creation time.
+	OCICreatedAnnotation = "org.opencontainers.image.created"
+)
+
+// CatalogKey returns the key of the catalog of a box published to an object storage under prefix.
+func CatalogKey(prefix, boxName string) string {
+	return path.Join(prefix, boxName, "catalog.json")
+}
+
+// BoxKey returns the key of the box of a provider published to an object storage under prefix.
+func BoxKey(prefix, boxName, version string, provider buildv1.VagrantProvider) string {
+	return path.Join(prefix, boxName, version, vagrant.BoxFileName(provider))
+}
+
+// ArtifactKey returns the key of an OVA or disk export of a Build published to an object storage under prefix.
+func ArtifactKey(prefix, buildName, exportName string, format buildv1.ExportFormat) string {
+	return path.Join(prefix, buildName, fmt.Sprintf("%s.%s", exportName, strings.ToLower(string(format))))
+}
+
+// Options defines an export.
+type Options struct {
+	// Name is the name of the export.
+	Name string
+	// BuildName is the name of the Build the image has been produced by.
+	BuildName string
+	// Format is the format the image is packaged in.
+	Format buildv1.ExportFormat
+	// Source is the location of the image, either an http(s)://, s3:// or local path.
+	Source string
+	// SourceFormat is the format of the image, e.g. qcow2 or raw, it is detected if empty.
+	SourceFormat string
+
+	// BoxName, BoxVersion and Providers define the Vagrant box.
+	BoxName    string
+	BoxVersion string
+	Providers  []buildv1.VagrantProvider
+
+	// RegistryURL is the URL of the box registry API the box is published to.
+	RegistryURL string
+	// ObjectStorageURL, ObjectStorageEndpoint and ObjectStorageRegion define the object storage
+	// the export is published to.
+	ObjectStorageURL      string
+	ObjectStorageEndpoint string
+	ObjectStorageRegion   string
+	// AzureBlobURL is the https://<account>/<container>/<prefix> URL of the Azure Storage container the export is
+	// published to.
+	AzureBlobURL string
+	// HTTPURL is the URL of the HTTP endpoint the export is uploaded under.
+	HTTPURL string
+	// OCIRepository, OCITags, OCIAnnotations and OCIInsecure define the repository of the OCI registry the disk is
+	// pushed to as an artifact.
+	OCIRepository  string
+	OCITags        []string
+	OCIAnnotations map[string]string
+	OCIInsecure    bool
+	// SBOMFile and SBOMMediaType define the SBOM of the machine attached as a referrer to the OCI artifact.
+	SBOMFile      string
+	SBOMMediaType string
+	// OutputDir is a directory the OVA or disk export is written to, e.g. a mounted volume, instead of
+	// being published.
+	OutputDir string
+
+	// Provenance is the SLSA provenance predicate attested along with the signatures of the export.
+	Provenance []byte
+
+	// CredentialsDir is the directory holding the credentials of the destination, one file per key.
+	CredentialsDir string
+	// WorkDir is the directory the image is downloaded and packaged in.
+	WorkDir string
+}
+
+// Result is the outcome of an export, reported by the export Jobs in their termination message.
+type Result struct {
+	// URL is where the export has been published.
+	URL string `json:"url,omitempty"`
+	// Checksum is the checksum of the published file, prefixed with the algorithm.
+	Checksum string `json:"checksum,omitempty"`
+	// SizeBytes is the size of the published file.
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+	// Signature and Attestation are where the signature and the provenance attestation of the export have been
+	// published, when it is signed.
+	Signature   string `json:"signature,omitempty"`
+	Attestation string `json:"attestation,omitempty"`
+	// SBOM is the reference of the SBOM of the machine attached as a referrer to the OCI artifact of the export.
+	SBOM string `json:"sbom,omitempty"`
+}
+
+// Converter converts disk images.
+type Converter interface {
+	// Convert converts the disk at src to dst in the given format, with the given format specific options.
+	Convert(ctx context.Context, src, srcFormat, dst, dstFormat string, options ...string) error
+	// VirtualSize returns the virtual size in bytes of the disk at path.
+	VirtualSize(ctx context.Context, path string) (int64, error)
+}
+
+// Exporter packages an image and publishes it.
+type Exporter struct {
+	Options
+	Converter Converter
+	// Signer signs the OVA and disk exports, they are not signed when nil.
+	Signer Signer
+	Logger logr.Logger
+}
+
+// Run downloads the image, packages it and publishes it.
+func (e *Exporter) Run(ctx context.Context) (*Result, error) {
+	source, err := e.download(ctx)
+	if err != nil {
+		return nil, err
+	}
+	virtualSize, err := e.Converter.VirtualSize(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Format {
+	case buildv1.ExportFormatVagrantBox:
+		return e.exportBoxes(ctx, source, virtualSize)
+	case buildv1.ExportFormatOVA:
+		machine, err := e.convertToVMDK(ctx, source, virtualSize, "streamOptimized")
+		if err != nil {
+			return nil, err
+		}
+		ova := filepath.Join(e.WorkDir, e.Name+".ova")
+		if err := e.writeOVA(ova, machine); err != nil {
+			return nil, err
+		}
+		return e.publishArtifact(ctx, ova)
+	case buildv1.ExportFormatVMDK:
+		machine, err := e.convertToVMDK(ctx, source, virtualSize, "streamOptimized")
+		if err != nil {
+			return nil, err
+		}
+		return e.publishArtifact(ctx, filepath.Join(e.WorkDir, machine.DiskFile))
+	case buildv1.ExportFormatQCOW2:
+		return e.exportDisk(ctx, source, "qcow2")
+	case buildv1.ExportFormatVHD:
+		// Azure only boots fixed size VHDs, whose virtual size is kept as is.
+		return e.exportDisk(ctx, source, "vpc", "subformat=fixed", "force_size=on")
+	case buildv1.ExportFormatRaw:
+		return e.exportDisk(ctx, source, "raw")
+	default:
+		return nil, errors.Errorf("unsupported export format %q", e.Format)
+	}
+}
+
+// exportDisk converts the image to a disk in the qemu-img format, with the given format specific options, and
+// publishes it.
+func (e *Exporter) exportDisk(ctx context.Context, source, format string, options ...string) (*Result, error) {
+	disk := filepath.Join(e.WorkDir, path.Base(ArtifactKey("", e.BuildName, e.Name, e.Format)))
+	if e.OutputDir != "" {
+		// The disk is converted in place, the output directory may be the only volume large enough.
+		disk = filepath.Join(e.OutputDir, path.Base(disk))
+	}
+	if err := e.Converter.Convert(ctx, source, e.SourceFormat, disk, format, options...); err != nil {
+		return nil, err
+	}
+	return e.publishArtifact(ctx, disk)
+}
+
+// download downloads the image to the work directory, local images are used in place.
+func (e *Exporter) download(ctx context.Context) (string, error) {
+	if !strings.Contains(e.Source, "://") || strings.HasPrefix(e.Source, "file://") {
+		return strings.TrimPrefix(e.Source, "file://"), nil
+	}
+
+	dst := filepath.Join(e.WorkDir, "source")
+	f, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	e.Logger.Info("Downloading the image", "source", e.Source)
+	switch {
+	case strings.HasPrefix(e.Source, "s3://"):
+		storage, err := e.objectStorage()
+		if err != nil {
+			return "", errors.Wrap(err, "s3:// images are downloaded with the credentials of the object storage destination")
+		}
+		bucket, key, err := publish.ParseURL(e.Source)
+		if err != nil {
+			return "", err
+		}
+		if err := storage.Download(ctx, bucket, key, f); err != nil {
+			return "", err
+		}
+	case strings.HasPrefix(e.Source, "http://"), strings.HasPrefix(e.Source, "https://"):
+		if err := downloadHTTP(ctx, e.Source, f); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.Errorf("unsupported image location %q, expected an http(s):// or s3:// URL", e.Source)
+	}
+	return dst, f.Close()
+}
+
+func downloadHTTP(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return errors.Wrapf(err, "failed to download %s", url)
+}
+
+// exportBoxes packages a box per provider and publishes them.
+func (e *Exporter) exportBoxes(ctx context.Context, source string, virtualSize int64) (*Result, error) {
+	boxes := make([]publish.Box, 0, len(e.Providers))
+	for _, provider := range e.Providers {
+		box, err := e.packageBox(ctx, provider, source, virtualSize)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to package the %s box", provider)
+		}
+		boxes = append(boxes, box)
+	}
+
+	if e.RegistryURL != "" {
+		token, err := e.credential(buildv1.BoxRegistryTokenKey)
+		if err != nil {
+			return nil, err
+		}
+		e.Logger.Info("Publishing the boxes to the box registry", "box", e.BoxName, "version", e.BoxVersion)
+		if err := publish.NewBoxRegistry(e.RegistryURL, token).Publish(ctx, e.BoxName, e.BoxVersion, boxes); err != nil {
+			return nil, err
+		}
+		return &Result{URL: publish.VersionURL(e.RegistryURL, e.BoxName, e.BoxVersion)}, nil
+	}
+
+	storage, err := e.objectStorage()
+	if err != nil {
+		return nil, err
+	}
+	bucket, prefix, err := publish.ParseURL(e.ObjectStorageURL)
+	if err != nil {
+		return nil, err
+	}
+	providers := make([]vagrant.CatalogProvider, 0, len(boxes))
+	for _, box := range boxes {
+		key := BoxKey(prefix, e.BoxName, e.BoxVersion, buildv1.VagrantProvider(box.Provider))
+		e.Logger.Info("Uploading the box", "provider", box.Provider, "key", key)
+		if err := storage.Upload(ctx, bucket, key, box.Path); err != nil {
+			return nil, err
+		}
+		providers = append(providers, vagrant.CatalogProvider{
+			Name:         box.Provider,
+			URL:          storage.ObjectURL(bucket, key),
+			ChecksumType: vagrant.ChecksumType,
+			Checksum:     box.Checksum,
+		})
+	}
+
+	// The catalog is updated last, so it only lists boxes which have been uploaded.
+	catalogKey := CatalogKey(prefix, e.BoxName)
+	data, err := storage.Get(ctx, bucket, catalogKey)
+	if err != nil && !errors.Is(err, publish.ErrNotFound) {
+		return nil, err
+	}
+	catalog, err := vagrant.ParseCatalog(e.BoxName, data)
+	if err != nil {
+		return nil, err
+	}
+	catalog.SetProviders(e.BoxVersion, providers...)
+	if data, err = catalog.Marshal(); err != nil {
+		return nil, err
+	}
+	e.Logger.Info("Updating the box catalog", "key", catalogKey)
+	if err := storage.Put(ctx, bucket, catalogKey, data, "application/json"); err != nil {
+		return nil, err
+	}
+	return &Result{URL: storage.ObjectURL(bucket, catalogKey)}, nil
+}
+
+// packageBox converts the image for the provider and packages it as a box.
+func (e *Exporter) packageBox(ctx context.Context, provider buildv1.VagrantProvider, source string, virtualSize int64) (publish.Box, error) {
+	var files []vagrant.File
+	switch provider {
+	case buildv1.VagrantProviderLibvirt:
+		disk := filepath.Join(e.WorkDir, "box.img")
+		if err := e.Converter.Convert(ctx, source, e.SourceFormat, disk, "qcow2"); err != nil {
+			return publish.Box{}, err
+		}
+		files = []vagrant.File{{Name: "box.img", Path: disk}}
+	case buildv1.VagrantProviderVirtualBox:
+		machine, err := e.convertToVMDK(ctx, source, virtualSize, "streamOptimized")
+		if err != nil {
+			return publish.Box{}, err
+		}
+		ovf, err := e.writeDescriptor("box.ovf", machine, vagrant.OVF)
+		if err != nil {
+			return publish.Box{}, err
+		}
+		files = []vagrant.File{{Name: "box.ovf", Path: ovf}, {Name: machine.DiskFile, Path: filepath.Join(e.WorkDir, machine.DiskFile)}}
+	case buildv1.VagrantProviderVMwareDesktop:
+		// VMware desktop does not run stream optimized disks, they are only meant to be imported.
+		machine, err := e.convertToVMDK(ctx, source, virtualSize, "monolithicSparse")
+		if err != nil {
+			return publish.Box{}, err
+		}
+		vmx, err := e.writeDescriptor("box.vmx", machine, vagrant.VMX)
+		if err != nil {
+			return publish.Box{}, err
+		}
+		files = []vagrant.File{{Name: "box.vmx", Path: vmx}, {Name: machine.DiskFile, Path: filepath.Join(e.WorkDir, machine.DiskFile)}}
+	default:
+		return publish.Box{}, errors.Errorf("unsupported Vagrant provider %q", provider)
+	}
+
+	boxPath := filepath.Join(e.WorkDir, vagrant.BoxFileName(provider))
+	f, err := os.Create(boxPath)
+	if err != nil {
+		return publish.Box{}, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if err := vagrant.WriteBox(io.MultiWriter(f, hash), vagrant.MetadataFor(provider, virtualSize), files); err != nil {
+		return publish.Box{}, err
+	}
+	if err := f.Close(); err != nil {
+		return publish.Box{}, err
+	}
+	// The disks are not needed once packaged, remove them to limit the space used in the work directory.
+	for _, file := range files {
+		_ = os.Remove(file.Path)
+	}
+	return publish.Box{Provider: string(provider), Path: boxPath, Checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// convertToVMDK converts the image to a VMDK disk with the given subformat.
+func (e *Exporter) convertToVMDK(ctx context.Context, source string, virtualSize int64, subformat string) (vagrant.Machine, error) {
+	diskFile := e.Name + ".vmdk"
+	disk := filepath.Join(e.WorkDir, diskFile)
+	if err := e.Converter.Convert(ctx, source, e.SourceFormat, disk, "vmdk", "subformat="+subformat); err != nil {
+		return vagrant.Machine{}, err
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return vagrant.Machine{}, err
+	}
+	return vagrant.Machine{Name: e.BuildName, DiskFile: diskFile, DiskSize: info.Size(), Capacity: virtualSize}, nil
+}
+
+func (e *Exporter) writeDescriptor(name string, machine vagrant.Machine, render func(vagrant.Machine) ([]byte, error)) (string, error) {
+	data, err := render(machine)
+	if err != nil {
+		return "", err
+	}
+	descriptor := filepath.Join(e.WorkDir, name)
+	return descriptor, os.WriteFile(descriptor, data, 0o600)
+}
+
+func (e *Exporter) writeOVA(ova string, machine vagrant.Machine) error {
+	descriptor, err := e.writeDescriptor(e.Name+".ovf", machine, vagrant.OVF)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(ova)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	disk := vagrant.File{Name: machine.DiskFile, Path: filepath.Join(e.WorkDir, machine.DiskFile)}
+	if err := vagrant.WriteOVA(f, vagrant.File{Path: descriptor}, []vagrant.File{disk}); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// publishArtifact uploads an OVA or disk export to its destination, or writes it to the output directory, and
+// signs it along with the attestation of its provenance when the Exporter has a Signer.
+func (e *Exporter) publishArtifact(ctx context.Context, file string) (*Result, error) {
+	result, err := checksum(file)
+	if err != nil {
+		return nil, err
+	}
+	if e.OCIRepository != "" {
+		creds, err := e.pushArtifact(ctx, file, result)
+		if err != nil {
+			return nil, err
+		}
+		if e.Signer != nil {
+			e.Logger.Info("Signing the image", "reference", result.URL)
+			if result.Signature, result.Attestation, err = e.Signer.SignImage(ctx, result.URL, creds, e.Provenance); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	store, prefix, err := e.fileStore()
+	if err != nil {
+		return nil, err
+	}
+	key := ArtifactKey(prefix, e.BuildName, e.Name, e.Format)
+	result.URL = store.URL(key)
+	e.Logger.Info("Publishing the image", "url", result.URL)
+	if err := store.Upload(ctx, key, file); err != nil {
+		return nil, err
+	}
+	if e.Signer == nil {
+		return result, nil
+	}
+
+	e.Logger.Info("Signing the image", "url", result.URL)
+	if err := e.Signer.SignBlob(ctx, file, e.Provenance); err != nil {
+		return nil, err
+	}
+	if err := store.Upload(ctx, key+SignatureSuffix, file+SignatureSuffix); err != nil {
+		return nil, err
+	}
+	result.Signature = store.URL(key + SignatureSuffix)
+	if len(e.Provenance) > 0 {
+		if err := store.Upload(ctx, key+AttestationSuffix, file+AttestationSuffix); err != nil {
+			return nil, err
+		}
+		result.Attestation = store.URL(key + AttestationSuffix)
+	}
+	return result, nil
+}
+
+// fileStore is a destination the files of the OVA and disk exports are uploaded to.
+type fileStore interface {
+	// Upload uploads the file at path under key.
+	Upload(ctx context.Context, key, path string) error
+	// URL returns the URL of key.
+	URL(key string) string
+}
+
+// fileStore returns the destination of the export, and the prefix of its keys.
+func (e *Exporter) fileStore() (fileStore, string, error) {
+	switch {
+	case e.OutputDir != "":
+		return outputDir(e.OutputDir), "", nil
+	case e.AzureBlobURL != "":
+		containerURL, prefix, err := publish.ParseAzureBlobURL(e.AzureBlobURL)
+		if err != nil {
+			return nil, "", err
+		}
+		sasToken, err := e.credential(buildv1.AzureBlobSASTokenKey)
+		if err != nil {
+			return nil, "", err
+		}
+		container, err := publish.NewAzureBlob(containerURL, sasToken)
+		if err != nil {
+			return nil, "", err
+		}
+		return azureBlobStore{container}, prefix, nil
+	case e.HTTPURL != "":
+		endpoint := publish.NewHTTPEndpoint(e.HTTPURL)
+		// The credentials of an HTTP endpoint are optional.
+		endpoint.Token, _ = e.credential(buildv1.HTTPTokenKey)
+		endpoint.Username, _ = e.credential(buildv1.HTTPUsernameKey)
+		endpoint.Password, _ = e.credential(buildv1.HTTPPasswordKey)
+		return httpStore{endpoint}, "", nil
+	}
+
+	storage, err := e.objectStorage()
+	if err != nil {
+		return nil, "", err
+	}
+	bucket, prefix, err := publish.ParseURL(e.ObjectStorageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return objectStore{storage: storage, bucket: bucket}, prefix, nil
+}
+
+// outputDir writes the files to a directory, by the base name of their key.
+type outputDir string
+
+func (d outputDir) Upload(_ context.Context, key, path string) error {
+	dst := filepath.Join(string(d), filepath.Base(key))
+	if dst == path {
+		return nil
+	}
+	return copyFile(path, dst)
+}
+
+func (d outputDir) URL(key string) string {
+	return "file://" + filepath.Join(string(d), filepath.Base(key))
+}
+
+type objectStore struct {
+	storage *publish.ObjectStorage
+	bucket  string
+}
+
+func (s objectStore) Upload(ctx context.Context, key, path string) error {
+	return s.storage.Upload(ctx, s.bucket, key, path)
+}
+
+func (s objectStore) URL(key string) string {
+	return s.storage.ObjectURL(s.bucket, key)
+}
+
+type azureBlobStore struct {
+	*publish.AzureBlob
+}
+
+func (s azureBlobStore) URL(key string) string {
+	return s.BlobURL(key)
+}
+
+type httpStore struct {
+	*publish.HTTPEndpoint
+}
+
+func (s httpStore) URL(key string) string {
+	return s.FileURL(key)
+}
+
+// pushArtifact pushes the disk at file to the OCI registry as an artifact, and records the digest of its manifest
+// in the URL of the result. The SBOM of the machine is pushed as a referrer of the artifact, if any. It returns the
+// credentials of the registry.
+func (e *Exporter) pushArtifact(ctx context.Context, file string, result *Result) (oci.Credentials, error) {
+	if len(e.OCITags) == 0 {
+		return oci.Credentials{}, errors.New("the OCI artifact has no tag")
+	}
+	ref, err := oci.ParseReference(e.OCIRepository + ":" + e.OCITags[0])
+	if err != nil {
+		return oci.Credentials{}, err
+	}
+	creds, err := e.registryCredentials(ref.Registry)
+	if err != nil {
+		return oci.Credentials{}, err
+	}
+	annotations := map[string]string{
+		OCICreatedAnnotation:    time.Now().UTC().Format(time.RFC3339),
+		buildv1.BuildNameLabel:  e.BuildName,
+		buildv1.ExportNameLabel: e.Name,
+	}
+	maps.Copy(annotations, e.OCIAnnotations)
+
+	e.Logger.Info("Pushing the image", "repository", e.OCIRepository, "tags", e.OCITags)
+	pusher := &oci.Pusher{PlainHTTP: e.OCIInsecure}
+	manifest, err := pusher.PushArtifact(ctx, ref, creds, oci.Artifact{
+		Type: OCIArtifactType,
+		Layers: []oci.Layer{{
+			Title:     filepath.Base(file),
+			MediaType: OCIArtifactType + "." + strings.ToLower(string(e.Format)),
+			File:      file,
+		}},
+		Annotations: annotations,
+		Tags:        e.OCITags[1:],
+	})
+	if err != nil {
+		return oci.Credentials{}, err
+	}
+	result.URL = fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, manifest.Digest)
+	if e.SBOMFile == "" {
+		return creds, nil
+	}
+
+	e.Logger.Info("Attaching the SBOM", "reference", result.URL)
+	sbom, err := pusher.PushArtifact(ctx, oci.Reference{Registry: ref.Registry, Repository: ref.Repository}, creds, oci.Artifact{
+		Type:        e.SBOMMediaType,
+		Layers:      []oci.Layer{{Title: filepath.Base(e.SBOMFile), MediaType: e.SBOMMediaType, File: e.SBOMFile}},
+		Annotations: map[string]string{OCICreatedAnnotation: annotations[OCICreatedAnnotation]},
+		Subject:     &manifest,
+	})
+	if err != nil {
+		return oci.Credentials{}, errors.Wrap(err, "failed to attach the SBOM")
+	}
+	result.SBOM = fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, sbom.Digest)
+	return creds, nil
+}
+
+// registryCredentials returns the credentials of the OCI registry, read from a .dockerconfigjson or from the
+// username and password keys of the credentials directory. They are empty when there are none.
+func (e *Exporter) registryCredentials(registry string) (oci.Credentials, error) {
+	if data, err := os.ReadFile(filepath.Join(e.CredentialsDir, corev1.DockerConfigJsonKey)); err == nil {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials"},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: data},
+		}
+		return oci.CredentialsFor(secret, registry)
+	}
+	username, err := e.credential(buildv1.OCIRegistryUsernameKey)
+	if err != nil {
+		// The registry may allow anonymous pushes.
+		return oci.Credentials{}, nil
+	}
+	password, err := e.credential(buildv1.OCIRegistryPasswordKey)
+	if err != nil {
+		return oci.Credentials{}, err
+	}
+	return oci.Credentials{Username: username, Password: password}, nil
+}
+
+// checksum returns the result of the export of file, with its checksum and size.
+func checksum(file string) (*Result, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute the checksum of %s", file)
+	}
+	return &Result{Checksum: "sha256:" + hex.EncodeToString(hash.Sum(nil)), SizeBytes: size}, nil
+}
+
+// objectStorage returns a client of the object storage destination.
+func (e *Exporter) objectStorage() (*publish.ObjectStorage, error) {
+	if e.ObjectStorageURL == "" {
+		return nil, errors.New("no object storage destination")
+	}
+	accessKeyID, err := e.credential(buildv1.ObjectStorageAccessKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := e.credential(buildv1.ObjectStorageSecretAccessKeyKey)
+	if err != nil {
+		return nil, err
+	}
+	return publish.NewObjectStorage(e.ObjectStorageEndpoint, e.ObjectStorageRegion,
+		publish.Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey})
+}
+
+// credential returns the credential with the given key, read from the credentials directory.
+func (e *Exporter) credential(key string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(e.CredentialsDir, key))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the %s credential", key)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// copyFile copies src to dst, which may be on another volume.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dst)
+	}
+	return out.Close()
+}
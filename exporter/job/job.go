@@ -0,0 +1,307 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter"
+	"github.com/forge-build/forge/pkg/kube"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
+)
+
+const (
+	// ContainerName is the name of the container running the exporter in the Job.
+	ContainerName = "exporter"
+
+	// DefaultImage is the image of the exporter used when not configured.
+	DefaultImage = "ghcr.io/forge-build/forge-exporter:latest"
+
+	// DefaultTimeout is how long an export Job may run.
+	DefaultTimeout = 2 * time.Hour
+
+	// defaultBackoffLimit is the number of retries of an export Job before it is failed.
+	defaultBackoffLimit = 2
+
+	credentialsVolume = "credentials"
+	signingVolume     = "signing"
+	sbomVolume        = "sbom"
+	workVolume        = "work"
+
+	// identityTokenFile is the file of the service account token of the keyless signatures in the signing volume.
+	identityTokenFile = "token"
+)
+
+// ExportJobBuilder builds the Job packaging and publishing an export of a Build.
+type ExportJobBuilder struct {
+	build      *buildv1.Build
+	export     *buildv1.ExportSpec
+	image      string
+	boxVersion string
+	ociTags    []string
+	provenance []byte
+	timeout    time.Duration
+}
+
+// NewExportJobBuilder returns a builder of the Job of the given export of the Build.
+func NewExportJobBuilder(build *buildv1.Build, export *buildv1.ExportSpec) *ExportJobBuilder {
+	return &ExportJobBuilder{build: build, export: export, timeout: DefaultTimeout}
+}
+
+// WithImage sets the image of the exporter.
+func (b *ExportJobBuilder) WithImage(image string) *ExportJobBuilder {
+	b.image = image
+	return b
+}
+
+// WithBoxVersion sets the version Vagrant boxes are published as.
+func (b *ExportJobBuilder) WithBoxVersion(version string) *ExportJobBuilder {
+	b.boxVersion = version
+	return b
+}
+
+// WithOCITags sets the tags the disk is pushed to an OCI registry with.
+func (b *ExportJobBuilder) WithOCITags(tags []string) *ExportJobBuilder {
+	b.ociTags = tags
+	return b
+}
+
+// WithProvenance sets the SLSA provenance predicate attested along with the signature of the export.
+func (b *ExportJobBuilder) WithProvenance(provenance []byte) *ExportJobBuilder {
+	b.provenance = provenance
+	return b
+}
+
+// WithTimeout sets how long the Job may run.
+func (b *ExportJobBuilder) WithTimeout(timeout time.Duration) *ExportJobBuilder {
+	b.timeout = timeout
+	return b
+}
+
+// Build returns the Job, in the namespace of the Build. The caller sets the Build as its owner.
+func (b *ExportJobBuilder) Build() (*batchv1.Job, error) {
+	artifact := b.build.Status.Artifact
+	if artifact == nil || artifact.Location == "" {
+		return nil, fmt.Errorf("the infrastructure provider reported no location for the image of Build %s", b.build.Name)
+	}
+
+	labels := map[string]string{
+		buildv1.ManagedByLabel:  exporter.ForgeExporterName,
+		buildv1.BuildNameLabel:  b.build.Name,
+		buildv1.ExportNameLabel: b.export.Name,
+	}
+	if value, ok := b.build.Labels[buildv1.WatchLabel]; ok {
+		// Keep the Job visible to a Build controller filtering the objects it watches.
+		labels[buildv1.WatchLabel] = value
+	}
+
+	credentials := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	if ref := b.credentialsRef(); ref != nil {
+		credentials = corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: ref.Name}}
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetExportJobName(b.build, b.export.Name),
+			Namespace: b.build.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(defaultBackoffLimit)),
+			Completions:           ptr.To(int32(1)),
+			ActiveDeadlineSeconds: shelljob.DurationSecondsPtr(b.timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Affinity:      shelljob.LinuxNodeAffinity(),
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:                     ContainerName,
+						Image:                    b.image,
+						ImagePullPolicy:          corev1.PullIfNotPresent,
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						Args:                     b.args(),
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: credentialsVolume, MountPath: exporter.CredentialsDir, ReadOnly: true},
+							{Name: workVolume, MountPath: exporter.WorkDir},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: credentialsVolume, VolumeSource: credentials},
+						{Name: workVolume, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+	if signing := b.signing(); signing != nil {
+		b.addSigning(&job.Spec.Template.Spec, signing)
+	}
+	b.addSBOM(&job.Spec.Template.Spec)
+	return job, nil
+}
+
+// addSBOM mounts the SBOM of the machine in the Pod of the Job of an export to an OCI registry, to be attached as a
+// referrer to the artifact of the export. Only the SBOMs stored in a ConfigMap or a Secret are attached.
+func (b *ExportJobBuilder) addSBOM(pod *corev1.PodSpec) {
+	status := b.build.Status.SBOM
+	if b.export.Destination.OCIRegistry == nil || status == nil || b.build.Spec.Artifact == nil || b.build.Spec.Artifact.SBOM == nil {
+		return
+	}
+	key := status.Format.Key()
+	items := []corev1.KeyToPath{{Key: key, Path: key}}
+	volume := corev1.Volume{Name: sbomVolume}
+	switch dest := b.build.Spec.Artifact.SBOM.GetDestination(b.build); {
+	case dest.ConfigMapRef != nil:
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: *dest.ConfigMapRef, Items: items}
+	case dest.SecretRef != nil:
+		volume.Secret = &corev1.SecretVolumeSource{SecretName: dest.SecretRef.Name, Items: items}
+	default:
+		return
+	}
+	container := &pod.Containers[0]
+	container.Args = append(container.Args, "--sbom", path.Join(exporter.SBOMDir, key), "--sbom-media-type", status.Format.MediaType())
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: sbomVolume, MountPath: exporter.SBOMDir, ReadOnly: true})
+	pod.Volumes = append(pod.Volumes, volume)
+}
+
+// signing returns how the export is signed, nil if it is not.
+func (b *ExportJobBuilder) signing() *buildv1.SigningSpec {
+	if b.build.Spec.Artifact == nil || b.export.Format == buildv1.ExportFormatVagrantBox {
+		return nil
+	}
+	return b.build.Spec.Artifact.Signing
+}
+
+// addSigning mounts the cosign private key, or a service account token for the keyless signatures, in the Pod of
+// the Job, and hands the provenance of the export to the exporter.
+func (b *ExportJobBuilder) addSigning(pod *corev1.PodSpec, signing *buildv1.SigningSpec) {
+	container := &pod.Containers[0]
+	volume := corev1.Volume{Name: signingVolume}
+	if signing.KeyRef != nil {
+		volume.Secret = &corev1.SecretVolumeSource{SecretName: signing.KeyRef.Name}
+		container.Args = append(container.Args,
+			"--signing-key", path.Join(exporter.SigningDir, buildv1.SigningKeyKey),
+			"--signing-password", path.Join(exporter.SigningDir, buildv1.SigningPasswordKey))
+	} else {
+		audience := cmp.Or(signing.Keyless.Audience, buildv1.DefaultSigningAudience)
+		volume.Projected = &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+			ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Audience: audience, Path: identityTokenFile},
+		}}}
+		container.Args = append(container.Args,
+			"--identity-token", path.Join(exporter.SigningDir, identityTokenFile),
+			"--fulcio-url", cmp.Or(signing.Keyless.FulcioURL, buildv1.DefaultFulcioURL))
+		if signing.RekorURL == "" {
+			container.Args = append(container.Args, "--rekor-url", buildv1.DefaultRekorURL)
+		}
+	}
+	if signing.RekorURL != "" {
+		container.Args = append(container.Args, "--rekor-url", signing.RekorURL)
+	}
+	if len(b.provenance) > 0 {
+		container.Env = append(container.Env, corev1.EnvVar{Name: exporter.ProvenanceEnv, Value: string(b.provenance)})
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: signingVolume, MountPath: exporter.SigningDir, ReadOnly: true})
+	pod.Volumes = append(pod.Volumes, volume)
+}
+
+// credentialsRef returns the secret holding the credentials of the destination, nil for an HTTP endpoint or an OCI
+// registry without credentials.
+func (b *ExportJobBuilder) credentialsRef() *corev1.LocalObjectReference {
+	destination := b.export.Destination
+	switch {
+	case destination.BoxRegistry != nil:
+		return &destination.BoxRegistry.CredentialsRef
+	case destination.AzureBlob != nil:
+		return &destination.AzureBlob.CredentialsRef
+	case destination.HTTP != nil:
+		return destination.HTTP.CredentialsRef
+	case destination.OCIRegistry != nil:
+		return destination.OCIRegistry.CredentialsRef
+	}
+	return &destination.ObjectStorage.CredentialsRef
+}
+
+func (b *ExportJobBuilder) args() []string {
+	artifact := b.build.Status.Artifact
+	args := []string{
+		"--name", b.export.Name,
+		"--build-name", b.build.Name,
+		"--format", string(b.export.Format),
+		"--source", artifact.Location,
+	}
+	if artifact.Format != "" {
+		args = append(args, "--source-format", artifact.Format)
+	}
+	if vagrant := b.export.Vagrant; vagrant != nil {
+		providers := make([]string, 0, len(vagrant.Providers))
+		for _, p := range vagrant.Providers {
+			providers = append(providers, string(p))
+		}
+		args = append(args, "--box-name", vagrant.BoxName, "--box-version", b.boxVersion,
+			"--providers", strings.Join(providers, ","))
+	}
+	if registry := b.export.Destination.BoxRegistry; registry != nil {
+		args = append(args, "--registry-url", registry.GetURL())
+	}
+	if storage := b.export.Destination.ObjectStorage; storage != nil {
+		args = append(args, "--object-storage-url", storage.URL)
+		if storage.Endpoint != "" {
+			args = append(args, "--object-storage-endpoint", storage.Endpoint)
+		}
+		if storage.Region != "" {
+			args = append(args, "--object-storage-region", storage.Region)
+		}
+	}
+	if container := b.export.Destination.AzureBlob; container != nil {
+		args = append(args, "--azure-blob-url", container.URL)
+	}
+	if endpoint := b.export.Destination.HTTP; endpoint != nil {
+		args = append(args, "--http-url", endpoint.URL)
+	}
+	if registry := b.export.Destination.OCIRegistry; registry != nil {
+		args = append(args, "--oci-repository", registry.Repository, "--oci-tags", strings.Join(b.ociTags, ","))
+		keys := make([]string, 0, len(registry.Annotations))
+		for key := range registry.Annotations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			args = append(args, "--oci-annotation", key+"="+registry.Annotations[key])
+		}
+		if registry.Insecure {
+			args = append(args, "--oci-insecure")
+		}
+	}
+	return args
+}
+
+// GetExportJobName returns the name of the Job of an export of the Build, it changes on every retry of the Build
+// so the Jobs of the previous attempts are not reused.
+func GetExportJobName(build *buildv1.Build, exportName string) string {
+	return fmt.Sprintf("forge-export-%s", kube.ComputeHash([]interface{}{build.UID, build.Namespace, build.Name, exportName, build.Status.Retries}))
+}
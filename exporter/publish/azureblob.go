@@ -0,0 +1,159 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// azureBlobVersion is the version of the Blob service API.
+	azureBlobVersion = "2021-08-06"
+
+	// DefaultBlockSize is the size of the blocks of the blob uploads, smaller files are uploaded at once.
+	DefaultBlockSize = 64 << 20
+)
+
+// AzureBlob is a client of a container of an Azure Storage account, authorized by a shared access signature.
+type AzureBlob struct {
+	// ContainerURL is the URL of the container.
+	ContainerURL *url.URL
+	// SASToken is the shared access signature of the container, without the leading question mark.
+	SASToken string
+	// Client is the HTTP client sending the requests.
+	Client *http.Client
+	// BlockSize is the size of the blocks of the uploads.
+	BlockSize int64
+}
+
+// NewAzureBlob returns a client of the container at containerURL, e.g. https://forge.blob.core.windows.net/images.
+func NewAzureBlob(containerURL, sasToken string) (*AzureBlob, error) {
+	u, err := url.Parse(containerURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, errors.Errorf("invalid Azure Storage container URL %q, expected https://<account>.blob.core.windows.net/<container>", containerURL)
+	}
+	u.Path = "/" + strings.Trim(u.Path, "/")
+	return &AzureBlob{
+		ContainerURL: u,
+		SASToken:     strings.TrimPrefix(sasToken, "?"),
+		Client:       http.DefaultClient,
+		BlockSize:    DefaultBlockSize,
+	}, nil
+}
+
+// ParseAzureBlobURL returns the URL of the container and the prefix of an https://<account>/<container>/<prefix>
+// URL, the prefix has no leading slash.
+func ParseAzureBlobURL(s string) (containerURL, prefix string, err error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", "", errors.Errorf("invalid Azure Storage URL %q, expected https://<account>/<container>/<prefix>", s)
+	}
+	container, prefix, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if container == "" {
+		return "", "", errors.Errorf("invalid Azure Storage URL %q, it has no container", s)
+	}
+	return fmt.Sprintf("https://%s/%s", u.Host, container), prefix, nil
+}
+
+// BlobURL returns the URL of a blob, without the shared access signature.
+func (b *AzureBlob) BlobURL(name string) string {
+	u := *b.ContainerURL
+	u.Path = u.Path + "/" + name
+	u.RawQuery = ""
+	return u.String()
+}
+
+// Upload uploads the file at path as a block blob, in blocks of BlockSize if the file is larger.
+func (b *AzureBlob) Upload(ctx context.Context, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"X-Ms-Blob-Type": {"BlockBlob"}}
+	if info.Size() <= b.BlockSize {
+		return b.do(ctx, name, nil, io.NewSectionReader(f, 0, info.Size()), header)
+	}
+
+	blockList := blockList{}
+	for offset, block := int64(0), 0; offset < info.Size(); offset, block = offset+b.BlockSize, block+1 {
+		// The IDs of the blocks of a blob must have the same length.
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", block)))
+		query := url.Values{"comp": {"block"}, "blockid": {id}}
+		length := min(b.BlockSize, info.Size()-offset)
+		if err := b.do(ctx, name, query, io.NewSectionReader(f, offset, length), nil); err != nil {
+			return errors.Wrapf(err, "failed to upload block %d", block)
+		}
+		blockList.Latest = append(blockList.Latest, id)
+	}
+	body, err := xml.Marshal(blockList)
+	if err != nil {
+		return err
+	}
+	return b.do(ctx, name, url.Values{"comp": {"blocklist"}}, bytes.NewReader(body), nil)
+}
+
+type blockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+// do sends a PUT request to the blob, authorized by the shared access signature.
+func (b *AzureBlob) do(ctx context.Context, name string, query url.Values, body io.Reader, header http.Header) error {
+	target := b.BlobURL(name) + "?" + b.SASToken
+	if len(query) > 0 {
+		target += "&" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, body)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("X-Ms-Version", azureBlobVersion)
+	if sized, ok := body.(interface{ Size() int64 }); ok {
+		req.ContentLength = sized.Size()
+	}
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload %s", b.BlobURL(name))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return errors.Errorf("failed to upload %s: %s %s", b.BlobURL(name), resp.Status, strings.TrimSpace(string(message)))
+}
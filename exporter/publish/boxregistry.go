@@ -0,0 +1,206 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Box is the box of a provider to publish to a box registry.
+type Box struct {
+	// Provider is the Vagrant provider of the box.
+	Provider string
+	// Path is the path of the box file.
+	Path string
+	// Checksum is the hex SHA-256 of the box file.
+	Checksum string
+}
+
+// BoxRegistry is a client of the API of a Vagrant Cloud compatible box registry.
+type BoxRegistry struct {
+	// URL is the URL of the API, e.g. https://app.vagrantup.com/api/v1.
+	URL string
+	// Token is the access token of the registry.
+	Token string
+	// Client is the HTTP client sending the requests.
+	Client *http.Client
+}
+
+// NewBoxRegistry returns a client of the registry API at url.
+func NewBoxRegistry(url, token string) *BoxRegistry {
+	return &BoxRegistry{URL: strings.TrimSuffix(url, "/"), Token: token, Client: http.DefaultClient}
+}
+
+// VersionURL returns the API URL of a version of a box.
+func VersionURL(registryURL, boxName, version string) string {
+	return fmt.Sprintf("%s/box/%s/version/%s", strings.TrimSuffix(registryURL, "/"), boxName, version)
+}
+
+type uploadTarget struct {
+	UploadPath string `json:"upload_path"`
+}
+
+// Publish publishes the boxes as the given version of the box, creating the box, the version and the providers
+// which do not exist yet, and releases the version. Publishing a version again replaces its boxes.
+func (r *BoxRegistry) Publish(ctx context.Context, boxName, version string, boxes []Box) error {
+	organization, name, ok := strings.Cut(boxName, "/")
+	if !ok {
+		return errors.Errorf("invalid box name %q, expected <organization>/<name>", boxName)
+	}
+	boxPath := fmt.Sprintf("/box/%s/%s", organization, name)
+	versionPath := fmt.Sprintf("%s/version/%s", boxPath, version)
+
+	if err := r.ensure(ctx, boxPath, "/boxes", map[string]interface{}{
+		"box": map[string]interface{}{"username": organization, "name": name},
+	}); err != nil {
+		return err
+	}
+	if err := r.ensure(ctx, versionPath, boxPath+"/versions", map[string]interface{}{
+		"version": map[string]interface{}{"version": version},
+	}); err != nil {
+		return err
+	}
+
+	for _, box := range boxes {
+		providerPath := fmt.Sprintf("%s/provider/%s", versionPath, box.Provider)
+		provider := map[string]interface{}{
+			"provider": map[string]interface{}{"name": box.Provider, "checksum": box.Checksum, "checksum_type": "sha256"},
+		}
+		if err := r.ensure(ctx, providerPath, versionPath+"/providers", provider); err != nil {
+			return err
+		}
+		// Update the checksum of a provider published again.
+		if err := r.call(ctx, http.MethodPut, providerPath, provider, nil); err != nil {
+			return err
+		}
+
+		target := uploadTarget{}
+		if err := r.call(ctx, http.MethodGet, providerPath+"/upload", nil, &target); err != nil {
+			return err
+		}
+		if target.UploadPath == "" {
+			return errors.Errorf("the registry returned no upload path for the %s box", box.Provider)
+		}
+		if err := r.upload(ctx, target.UploadPath, box.Path); err != nil {
+			return errors.Wrapf(err, "failed to upload the %s box", box.Provider)
+		}
+	}
+
+	// Releasing a version which has already been released fails, its boxes have been replaced anyway.
+	if err := r.call(ctx, http.MethodPut, versionPath+"/release", nil, nil); err != nil && !isStatus(err, http.StatusUnprocessableEntity) {
+		return err
+	}
+	return nil
+}
+
+// ensure creates the object at path with a POST of body to createPath if it does not exist.
+func (r *BoxRegistry) ensure(ctx context.Context, path, createPath string, body interface{}) error {
+	err := r.call(ctx, http.MethodGet, path, nil, nil)
+	if !isStatus(err, http.StatusNotFound) {
+		return err
+	}
+	return r.call(ctx, http.MethodPost, createPath, body, nil)
+}
+
+// statusError is returned for the responses with an unexpected status code.
+type statusError struct {
+	method, path string
+	code         int
+	status       string
+	message      string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %s %s", e.method, e.path, e.status, e.message)
+}
+
+func isStatus(err error, code int) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.code == code
+}
+
+// call sends a request to the API, decoding the response in out if not nil.
+func (r *BoxRegistry) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &statusError{method: method, path: path, code: resp.StatusCode, status: resp.Status, message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "failed to decode the response to %s %s", method, path)
+}
+
+// upload uploads the file at path to the upload URL returned by the registry.
+func (r *BoxRegistry) upload(ctx context.Context, uploadURL, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return errors.Errorf("%s %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publish
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPEndpoint is an HTTP endpoint accepting uploads with PUT requests.
+type HTTPEndpoint struct {
+	// URL is the URL the files are uploaded under.
+	URL string
+	// Token is the bearer token of the endpoint, if any.
+	Token string
+	// Username and Password are the basic authentication credentials of the endpoint, used when there is no token.
+	Username string
+	Password string
+	// Client is the HTTP client sending the requests.
+	Client *http.Client
+}
+
+// NewHTTPEndpoint returns a client of the endpoint at url.
+func NewHTTPEndpoint(url string) *HTTPEndpoint {
+	return &HTTPEndpoint{URL: strings.TrimSuffix(url, "/"), Client: http.DefaultClient}
+}
+
+// FileURL returns the URL a file is uploaded to.
+func (e *HTTPEndpoint) FileURL(name string) string {
+	return e.URL + "/" + strings.TrimPrefix(name, "/")
+}
+
+// Upload uploads the file at path to the URL of name.
+func (e *HTTPEndpoint) Upload(ctx context.Context, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := e.FileURL(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	switch {
+	case e.Token != "":
+		req.Header.Set("Authorization", "Bearer "+e.Token)
+	case e.Username != "":
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload %s", target)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return errors.Errorf("failed to upload %s: %s %s", target, resp.Status, strings.TrimSpace(string(message)))
+}
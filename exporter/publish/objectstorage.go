@@ -0,0 +1,330 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package publish publishes the packaged images to S3 compatible object storages, Azure Storage containers,
+// HTTP endpoints and Vagrant Cloud compatible box registries.
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRegion is the region of the object storage when not set.
+	DefaultRegion = "us-east-1"
+
+	// DefaultPartSize is the size of the parts of the multipart uploads, smaller files are uploaded at once.
+	DefaultPartSize = 64 << 20
+
+	// MaxPresignExpiration is the longest validity of a presigned URL accepted by the object storages.
+	MaxPresignExpiration = 7 * 24 * time.Hour
+
+	// maxErrorBody is how much of the body of an error response is reported.
+	maxErrorBody = 1024
+)
+
+// ErrNotFound is returned when the requested object does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ObjectStorage is a client of an S3 compatible object storage, the buckets are addressed by path
+// so both AWS S3 and the self-hosted object storages are supported.
+type ObjectStorage struct {
+	// Endpoint is the endpoint of the object storage.
+	Endpoint *url.URL
+	// Region is the region the requests are signed for.
+	Region string
+	// Credentials are the credentials the requests are signed with.
+	Credentials Credentials
+	// Client is the HTTP client sending the requests.
+	Client *http.Client
+	// PartSize is the size of the parts of the multipart uploads.
+	PartSize int64
+
+	now func() time.Time
+}
+
+// NewObjectStorage returns a client of the object storage at endpoint, the AWS S3 endpoint of the region is used
+// if endpoint is empty.
+func NewObjectStorage(endpoint, region string, creds Credentials) (*ObjectStorage, error) {
+	if region == "" {
+		region = DefaultRegion
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("invalid object storage endpoint %q", endpoint)
+	}
+	return &ObjectStorage{
+		Endpoint:    u,
+		Region:      region,
+		Credentials: creds,
+		Client:      http.DefaultClient,
+		PartSize:    DefaultPartSize,
+		now:         time.Now,
+	}, nil
+}
+
+// ParseURL returns the bucket and the key of an s3://<bucket>/<key> URL, the key has no leading slash.
+func ParseURL(s string) (bucket, key string, err error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", errors.Errorf("invalid object storage URL %q, expected s3://<bucket>/<key>", s)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// ObjectURL returns the HTTP URL of an object.
+func (s *ObjectStorage) ObjectURL(bucket, key string) string {
+	u := *s.Endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	u.RawQuery = ""
+	return u.String()
+}
+
+// Get returns the content of an object, ErrNotFound is returned if it does not exist.
+func (s *ObjectStorage) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := s.Download(ctx, bucket, key, out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Download writes the content of an object to w, ErrNotFound is returned if it does not exist.
+func (s *ObjectStorage) Download(ctx context.Context, bucket, key string, w io.Writer) error {
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, nil, emptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return errors.Wrapf(err, "failed to download s3://%s/%s", bucket, key)
+	}
+	return nil
+}
+
+// Put uploads data as an object.
+func (s *ObjectStorage) Put(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, bucket, key, nil, bytes.NewReader(data), hashHex(data), header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Upload uploads the file at path as an object, using a multipart upload if the file is larger than PartSize.
+func (s *ObjectStorage) Upload(ctx context.Context, bucket, key, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() <= s.PartSize {
+		resp, err := s.do(ctx, http.MethodPut, bucket, key, nil, io.NewSectionReader(f, 0, info.Size()), unsignedPayload, nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	return s.uploadMultipart(ctx, bucket, key, f, info.Size())
+}
+
+// Presign returns a URL allowing to send a request with the given method, e.g. GET or PUT, to an object
+// without credentials until it expires.
+func (s *ObjectStorage) Presign(method, bucket, key string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > MaxPresignExpiration {
+		return "", errors.Errorf("invalid expiration %s, it must be positive and at most %s", expires, MaxPresignExpiration)
+	}
+	u, err := url.Parse(s.ObjectURL(bucket, key))
+	if err != nil {
+		return "", err
+	}
+	presignV4(u, method, s.Credentials, s.Region, "s3", expires, s.now())
+	return u.String(), nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the keys of the objects whose key starts with prefix.
+func (s *ObjectStorage) List(ctx context.Context, bucket, prefix string) ([]string, error) {
+	keys := []string{}
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, bucket, "", query, nil, emptyPayloadHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list s3://%s/%s", bucket, prefix)
+		}
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// Delete deletes an object, deleting an object which does not exist is not an error.
+func (s *ObjectStorage) Delete(ctx context.Context, bucket, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, bucket, key, nil, nil, emptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DeletePrefix deletes the objects whose key starts with prefix, it returns the number of objects deleted.
+func (s *ObjectStorage) DeletePrefix(ctx context.Context, bucket, prefix string) (int, error) {
+	keys, err := s.List(ctx, bucket, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		if err := s.Delete(ctx, bucket, key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadMultipart uploads the file in parts of PartSize, the upload is aborted if a part fails.
+func (s *ObjectStorage) uploadMultipart(ctx context.Context, bucket, key string, f io.ReaderAt, size int64) (reterr error) {
+	resp, err := s.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil, emptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+	initiated := initiateMultipartUploadResult{}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return errors.Errorf("failed to start the multipart upload of s3://%s/%s", bucket, key)
+	}
+	uploadID := url.Values{"uploadId": {initiated.UploadID}}
+	defer func() {
+		if reterr == nil {
+			return
+		}
+		// Abort the upload so the parts already uploaded are not kept, the error of the part is reported.
+		if resp, err := s.do(context.WithoutCancel(ctx), http.MethodDelete, bucket, key, uploadID, nil, emptyPayloadHash, nil); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	complete := completeMultipartUpload{}
+	for offset, part := int64(0), 1; offset < size; offset, part = offset+s.PartSize, part+1 {
+		length := min(s.PartSize, size-offset)
+		query := url.Values{"partNumber": {strconv.Itoa(part)}, "uploadId": {initiated.UploadID}}
+		resp, err := s.do(ctx, http.MethodPut, bucket, key, query, io.NewSectionReader(f, offset, length), unsignedPayload, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to upload part %d", part)
+		}
+		resp.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: part, ETag: resp.Header.Get("ETag")})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err = s.do(ctx, http.MethodPost, bucket, key, uploadID, bytes.NewReader(body), hashHex(body), nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do sends a signed request, the body of the response must be closed if no error is returned.
+func (s *ObjectStorage) do(ctx context.Context, method, bucket, key string, query url.Values, body io.Reader, payloadHash string, header http.Header) (*http.Response, error) {
+	target := s.ObjectURL(bucket, key)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if sized, ok := body.(interface{ Size() int64 }); ok {
+		req.ContentLength = sized.Size()
+	}
+	signV4(req, s.Credentials, s.Region, "s3", payloadHash, s.now())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to %s s3://%s/%s", strings.ToLower(method), bucket, key)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, errors.Wrapf(ErrNotFound, "s3://%s/%s", bucket, key)
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return nil, errors.Errorf("failed to %s s3://%s/%s: %s %s", strings.ToLower(method), bucket, key,
+		resp.Status, strings.TrimSpace(string(message)))
+}
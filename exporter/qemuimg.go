@@ -0,0 +1,70 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// QemuImg converts the disk images with qemu-img.
+type QemuImg struct{}
+
+// Convert implements Converter.
+func (QemuImg) Convert(ctx context.Context, src, srcFormat, dst, dstFormat string, options ...string) error {
+	args := []string{"convert"}
+	if srcFormat != "" {
+		args = append(args, "-f", srcFormat)
+	}
+	args = append(args, "-O", dstFormat)
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	_, err := run(ctx, append(args, src, dst)...)
+	return err
+}
+
+// VirtualSize implements Converter.
+func (QemuImg) VirtualSize(ctx context.Context, path string) (int64, error) {
+	out, err := run(ctx, "info", "--output=json", path)
+	if err != nil {
+		return 0, err
+	}
+	info := struct {
+		VirtualSize int64 `json:"virtual-size"`
+	}{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return 0, errors.Wrapf(err, "failed to parse the qemu-img info of %s", path)
+	}
+	return info.VirtualSize, nil
+}
+
+func run(ctx context.Context, args ...string) ([]byte, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "qemu-img", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "qemu-img %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vagrant packages disk images as Vagrant boxes and maintains the box catalogs
+// Vagrant reads to discover the versions of a box published to an object storage.
+package vagrant
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// gib is the unit of the virtual size of libvirt boxes.
+const gib = 1 << 30
+
+// File is a file of a box, read from Path and stored as Name at the root of the box.
+type File struct {
+	Name string
+	Path string
+}
+
+// Metadata is the metadata.json file of a box.
+type Metadata struct {
+	Provider string `json:"provider"`
+	// Format and VirtualSize are only used by the libvirt provider, the virtual size is in GiB.
+	Format      string `json:"format,omitempty"`
+	VirtualSize int64  `json:"virtual_size,omitempty"`
+}
+
+// MetadataFor returns the metadata of a box of the given provider, virtualSize is the size of the disk in bytes.
+func MetadataFor(provider buildv1.VagrantProvider, virtualSize int64) Metadata {
+	metadata := Metadata{Provider: string(provider)}
+	if provider == buildv1.VagrantProviderLibvirt {
+		metadata.Format = "qcow2"
+		metadata.VirtualSize = (virtualSize + gib - 1) / gib
+	}
+	return metadata
+}
+
+// DiskFormat returns the format the disk of a box of the given provider is converted to.
+func DiskFormat(provider buildv1.VagrantProvider) string {
+	if provider == buildv1.VagrantProviderLibvirt {
+		return "qcow2"
+	}
+	return "vmdk"
+}
+
+// Vagrantfile returns the Vagrantfile embedded in a box of the given provider.
+func Vagrantfile(provider buildv1.VagrantProvider) string {
+	vagrantfile := "Vagrant.configure(\"2\") do |config|\n"
+	if provider == buildv1.VagrantProviderLibvirt {
+		vagrantfile += "  config.vm.provider :libvirt do |libvirt|\n" +
+			"    libvirt.driver = \"kvm\"\n" +
+			"  end\n"
+	}
+	return vagrantfile + "end\n"
+}
+
+// WriteBox writes a box of the given provider holding files, along with its metadata.json and Vagrantfile,
+// as a gzipped tar archive.
+func WriteBox(w io.Writer, metadata Metadata, files []File) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	if err := writeTarFile(tw, "metadata.json", data, now); err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, "Vagrantfile", []byte(Vagrantfile(buildv1.VagrantProvider(metadata.Provider))), now); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := copyTarFile(tw, f); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteOVA writes an OVA appliance, a tar archive holding the OVF descriptor first and then the disks.
+func WriteOVA(w io.Writer, descriptor File, disks []File) error {
+	tw := tar.NewWriter(w)
+	for _, f := range append([]File{descriptor}, disks...) {
+		if err := copyTarFile(tw, f); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func copyTarFile(tw *tar.Writer, f File) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	name := f.Name
+	if name == "" {
+		name = filepath.Base(f.Path)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return errors.Wrapf(err, "failed to add %s", name)
+	}
+	return nil
+}
+
+// BoxFileName returns the name of the box file of the given provider.
+func BoxFileName(provider buildv1.VagrantProvider) string {
+	return fmt.Sprintf("%s.box", provider)
+}
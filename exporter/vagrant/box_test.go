@@ -0,0 +1,160 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vagrant
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func readTar(g *WithT, r io.Reader) map[string]string {
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(tr)
+		g.Expect(err).ToNot(HaveOccurred())
+		files[header.Name] = string(data)
+	}
+}
+
+func TestMetadataFor(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(MetadataFor(buildv1.VagrantProviderLibvirt, 10*gib+1)).To(Equal(Metadata{Provider: "libvirt", Format: "qcow2", VirtualSize: 11}))
+	g.Expect(MetadataFor(buildv1.VagrantProviderVirtualBox, 10*gib)).To(Equal(Metadata{Provider: "virtualbox"}))
+}
+
+func TestWriteBox(t *testing.T) {
+	g := NewWithT(t)
+
+	disk := filepath.Join(t.TempDir(), "disk.qcow2")
+	g.Expect(os.WriteFile(disk, []byte("qcow2"), 0o600)).To(Succeed())
+
+	out := &bytes.Buffer{}
+	g.Expect(WriteBox(out, MetadataFor(buildv1.VagrantProviderLibvirt, gib), []File{{Name: "box.img", Path: disk}})).To(Succeed())
+
+	gz, err := gzip.NewReader(out)
+	g.Expect(err).ToNot(HaveOccurred())
+	files := readTar(g, gz)
+	g.Expect(files).To(HaveKeyWithValue("box.img", "qcow2"))
+	g.Expect(files).To(HaveKeyWithValue("Vagrantfile", ContainSubstring("config.vm.provider :libvirt")))
+	metadata := Metadata{}
+	g.Expect(json.Unmarshal([]byte(files["metadata.json"]), &metadata)).To(Succeed())
+	g.Expect(metadata).To(Equal(Metadata{Provider: "libvirt", Format: "qcow2", VirtualSize: 1}))
+}
+
+func TestWriteOVA(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	descriptor, err := OVF(Machine{Name: "ubuntu", DiskFile: "ubuntu.vmdk", DiskSize: 4, Capacity: gib})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(descriptor)).To(ContainSubstring(`<File ovf:href="ubuntu.vmdk" ovf:id="file1" ovf:size="4"/>`))
+	g.Expect(os.WriteFile(filepath.Join(dir, "ubuntu.ovf"), descriptor, 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "ubuntu.vmdk"), []byte("vmdk"), 0o600)).To(Succeed())
+
+	out := &bytes.Buffer{}
+	g.Expect(WriteOVA(out, File{Path: filepath.Join(dir, "ubuntu.ovf")}, []File{{Path: filepath.Join(dir, "ubuntu.vmdk")}})).To(Succeed())
+
+	// The descriptor must be the first file of the appliance.
+	header, err := tar.NewReader(bytes.NewReader(out.Bytes())).Next()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(header.Name).To(Equal("ubuntu.ovf"))
+	g.Expect(readTar(g, out)).To(HaveKeyWithValue("ubuntu.vmdk", "vmdk"))
+}
+
+func TestCatalogSetProviders(t *testing.T) {
+	libvirt := CatalogProvider{Name: "libvirt", URL: "https://example.com/1.0/libvirt.box", Checksum: "a"}
+	virtualbox := CatalogProvider{Name: "virtualbox", URL: "https://example.com/1.0/virtualbox.box", Checksum: "b"}
+
+	testcases := []struct {
+		name      string
+		versions  []CatalogVersion
+		version   string
+		providers []CatalogProvider
+		expected  []CatalogVersion
+	}{
+		{
+			name:      "first version",
+			version:   "1.0",
+			providers: []CatalogProvider{libvirt},
+			expected:  []CatalogVersion{{Version: "1.0", Providers: []CatalogProvider{libvirt}}},
+		},
+		{
+			name:      "new version",
+			versions:  []CatalogVersion{{Version: "1.0", Providers: []CatalogProvider{libvirt}}},
+			version:   "1.1",
+			providers: []CatalogProvider{libvirt},
+			expected: []CatalogVersion{
+				{Version: "1.0", Providers: []CatalogProvider{libvirt}},
+				{Version: "1.1", Providers: []CatalogProvider{libvirt}},
+			},
+		},
+		{
+			name:      "version published again",
+			versions:  []CatalogVersion{{Version: "1.0", Providers: []CatalogProvider{libvirt, virtualbox}}},
+			version:   "1.0",
+			providers: []CatalogProvider{{Name: "libvirt", URL: libvirt.URL, Checksum: "c"}},
+			expected: []CatalogVersion{
+				{Version: "1.0", Providers: []CatalogProvider{{Name: "libvirt", URL: libvirt.URL, Checksum: "c"}, virtualbox}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			catalog := &Catalog{Name: "forge/ubuntu", Versions: tc.versions}
+			catalog.SetProviders(tc.version, tc.providers...)
+			g.Expect(catalog.Versions).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestParseCatalog(t *testing.T) {
+	g := NewWithT(t)
+
+	catalog, err := ParseCatalog("forge/ubuntu", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(catalog.Name).To(Equal("forge/ubuntu"))
+
+	catalog.SetProviders("1.0", CatalogProvider{Name: "libvirt", URL: "https://example.com/libvirt.box"})
+	data, err := catalog.Marshal()
+	g.Expect(err).ToNot(HaveOccurred())
+	parsed, err := ParseCatalog("forge/ubuntu", data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(parsed).To(Equal(catalog))
+
+	_, err = ParseCatalog("forge/debian", data)
+	g.Expect(err).To(MatchError(ContainSubstring("forge/ubuntu")))
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vagrant
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ChecksumType is the checksum algorithm of the boxes listed in the catalogs.
+const ChecksumType = "sha256"
+
+// Catalog is the metadata of a box Vagrant reads to discover its versions, it is added with
+// vagrant box add <catalog URL>.
+type Catalog struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Versions    []CatalogVersion `json:"versions"`
+}
+
+// CatalogVersion is a version of a box in a Catalog.
+type CatalogVersion struct {
+	Version   string            `json:"version"`
+	Providers []CatalogProvider `json:"providers"`
+}
+
+// CatalogProvider is the box of a provider for a version of a box.
+type CatalogProvider struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	ChecksumType string `json:"checksum_type,omitempty"`
+	Checksum     string `json:"checksum,omitempty"`
+}
+
+// ParseCatalog parses a catalog, an empty catalog of the given box is returned if data is empty.
+func ParseCatalog(name string, data []byte) (*Catalog, error) {
+	catalog := &Catalog{Name: name}
+	if len(data) == 0 {
+		return catalog, nil
+	}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the catalog of box %s", name)
+	}
+	if catalog.Name != name {
+		return nil, errors.Errorf("the catalog is the one of box %s, not %s", catalog.Name, name)
+	}
+	return catalog, nil
+}
+
+// SetProviders sets the providers of a version of the box, replacing the ones with the same name
+// so a version can be published again. The other providers of the version are kept.
+func (c *Catalog) SetProviders(version string, providers ...CatalogProvider) {
+	i := 0
+	for i < len(c.Versions) && c.Versions[i].Version != version {
+		i++
+	}
+	if i == len(c.Versions) {
+		c.Versions = append(c.Versions, CatalogVersion{Version: version})
+	}
+
+	v := &c.Versions[i]
+	for _, p := range providers {
+		replaced := false
+		for j := range v.Providers {
+			if v.Providers[j].Name == p.Name {
+				v.Providers[j] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			v.Providers = append(v.Providers, p)
+		}
+	}
+}
+
+// Marshal returns the catalog as JSON.
+func (c *Catalog) Marshal() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
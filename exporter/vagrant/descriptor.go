@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vagrant
+
+import (
+	"bytes"
+	"text/template"
+)
+
+const (
+	// defaultCPUs is the number of CPUs of the machines described by the descriptors.
+	defaultCPUs = 2
+	// defaultMemoryMB is the memory of the machines described by the descriptors.
+	defaultMemoryMB = 2048
+)
+
+// Machine describes the virtual machine of an appliance, its single disk is attached to a SATA controller
+// for OVF and to an LSI Logic controller for VMX.
+type Machine struct {
+	// Name is the name of the machine.
+	Name string
+	// DiskFile is the name of the VMDK disk of the machine, relative to the descriptor.
+	DiskFile string
+	// DiskSize is the size of the VMDK file in bytes.
+	DiskSize int64
+	// Capacity is the virtual size of the disk in bytes.
+	Capacity int64
+}
+
+var ovfTemplate = template.Must(template.New("ovf").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope ovf:version="1.0" xml:lang="en-US" xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <References>
+    <File ovf:href="{{.DiskFile}}" ovf:id="file1" ovf:size="{{.DiskSize}}"/>
+  </References>
+  <DiskSection>
+    <Info>List of the virtual disks used in the package</Info>
+    <Disk ovf:capacity="{{.Capacity}}" ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>Logical networks used in the package</Info>
+    <Network ovf:name="NAT">
+      <Description>Logical network used by this appliance.</Description>
+    </Network>
+  </NetworkSection>
+  <VirtualSystem ovf:id="{{.Name}}">
+    <Info>A virtual machine</Info>
+    <OperatingSystemSection ovf:id="101">
+      <Info>The kind of installed guest operating system</Info>
+      <Description>Linux 64-bit</Description>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements for a virtual machine</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{.Name}}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>virtualbox-2.2</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:Caption>{{.CPUs}} virtual CPU</rasd:Caption>
+        <rasd:ElementName>{{.CPUs}} virtual CPU</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.CPUs}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>MegaBytes</rasd:AllocationUnits>
+        <rasd:Caption>{{.MemoryMB}} MB of memory</rasd:Caption>
+        <rasd:ElementName>{{.MemoryMB}} MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.MemoryMB}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:Caption>sataController0</rasd:Caption>
+        <rasd:ElementName>sataController0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>AHCI</rasd:ResourceSubType>
+        <rasd:ResourceType>20</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Caption>Ethernet adapter on 'NAT'</rasd:Caption>
+        <rasd:Connection>NAT</rasd:Connection>
+        <rasd:ElementName>Ethernet adapter on 'NAT'</rasd:ElementName>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:ResourceSubType>E1000</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:Caption>disk1</rasd:Caption>
+        <rasd:ElementName>disk1</rasd:ElementName>
+        <rasd:HostResource>/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>5</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`))
+
+var vmxTemplate = template.Must(template.New("vmx").Parse(`.encoding = "UTF-8"
+config.version = "8"
+virtualHW.version = "14"
+displayName = "{{.Name}}"
+guestOS = "otherlinux-64"
+numvcpus = "{{.CPUs}}"
+memsize = "{{.MemoryMB}}"
+scsi0.present = "TRUE"
+scsi0.virtualDev = "lsilogic"
+scsi0:0.present = "TRUE"
+scsi0:0.fileName = "{{.DiskFile}}"
+ethernet0.present = "TRUE"
+ethernet0.connectionType = "nat"
+ethernet0.virtualDev = "e1000"
+ethernet0.addressType = "generated"
+`))
+
+type descriptorValues struct {
+	Machine
+	CPUs     int
+	MemoryMB int
+}
+
+// OVF returns the OVF descriptor of the machine, as imported by VirtualBox and VMware desktop.
+func OVF(m Machine) ([]byte, error) {
+	return render(ovfTemplate, m)
+}
+
+// VMX returns the VMX configuration of the machine, as run by VMware desktop.
+func VMX(m Machine) ([]byte, error) {
+	return render(vmxTemplate, m)
+}
+
+func render(t *template.Template, m Machine) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := t.Execute(out, descriptorValues{Machine: m, CPUs: defaultCPUs, MemoryMB: defaultMemoryMB}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildtemplate implements the instantiation of BuildTemplates into Builds.
+package buildtemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// variableRef matches ${name} variable references, and $$ which is an escaped $.
+var variableRef = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ResolveVariables returns the value of every variable declared by the template, using the values
+// set by the Build and falling back to the defaults of the template. The variables of the Build sourced
+// from a Secret or a ConfigMap are only exported to the provisioners, they are ignored.
+func ResolveVariables(template *buildv1.BuildTemplate, variables []buildv1.BuildVariable) (map[string]string, error) {
+	declared := map[string]buildv1.BuildTemplateVariable{}
+	for _, v := range template.Spec.Variables {
+		declared[v.Name] = v
+	}
+
+	values := map[string]string{}
+	for _, v := range variables {
+		if v.ValueFrom != nil {
+			continue
+		}
+		if _, ok := declared[v.Name]; !ok {
+			return nil, errors.Errorf("variable %q is not declared by BuildTemplate %s", v.Name, template.Name)
+		}
+		values[v.Name] = v.Value
+	}
+
+	missing := []string{}
+	for name, v := range declared {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		switch {
+		case v.Default != nil:
+			values[name] = *v.Default
+		case v.Required:
+			missing = append(missing, name)
+		default:
+			values[name] = ""
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errors.Errorf("required variables %s of BuildTemplate %s are not set", strings.Join(missing, ", "), template.Name)
+	}
+	return values, nil
+}
+
+// Render returns the Build definition of the template with the variables substituted.
+func Render(template *buildv1.BuildTemplate, variables []buildv1.BuildVariable) (*buildv1.BuildTemplateResourceSpec, error) {
+	values, err := ResolveVariables(template, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(template.Spec.Template.Spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode BuildTemplate %s", template.Name)
+	}
+
+	var undefined []string
+	rendered := variableRef.ReplaceAllFunc(raw, func(match []byte) []byte {
+		if string(match) == "$$" {
+			return []byte("$")
+		}
+		name := string(variableRef.FindSubmatch(match)[1])
+		value, ok := values[name]
+		if !ok {
+			undefined = append(undefined, name)
+			return match
+		}
+		// Escape the value as a JSON string, without the surrounding quotes.
+		escaped, _ := json.Marshal(value)
+		return escaped[1 : len(escaped)-1]
+	})
+	if len(undefined) > 0 {
+		return nil, errors.Errorf("BuildTemplate %s references undeclared variables %s", template.Name, strings.Join(undefined, ", "))
+	}
+
+	spec := &buildv1.BuildTemplateResourceSpec{}
+	if err := json.Unmarshal(rendered, spec); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode rendered BuildTemplate %s", template.Name)
+	}
+	return spec, nil
+}
+
+// Merge sets the fields of the rendered template on the Build spec, fields already set on the Build take precedence.
+// The InfrastructureRef of the template references an infrastructure template, it is cloned by the caller.
+func Merge(spec *buildv1.BuildSpec, rendered *buildv1.BuildTemplateResourceSpec) {
+	if spec.Connector.Type == "" && rendered.Connector != nil {
+		spec.Connector = *rendered.Connector
+	}
+	if len(spec.Provisioners) == 0 {
+		spec.Provisioners = rendered.Provisioners
+	}
+	spec.DeleteCascade = spec.DeleteCascade || rendered.DeleteCascade
+	if spec.Artifact == nil {
+		spec.Artifact = rendered.Artifact
+	}
+}
+
+// String returns a human readable reference to the template.
+func String(ref *buildv1.BuildTemplateReference, namespace string) string {
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	return fmt.Sprintf("%s/%s", namespace, ref.Name)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildtemplate
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func newTemplate() *buildv1.BuildTemplate {
+	tpl := &buildv1.BuildTemplate{}
+	tpl.Name = "ubuntu"
+	tpl.Spec = buildv1.BuildTemplateSpec{
+		Variables: []buildv1.BuildTemplateVariable{
+			{Name: "hostname", Required: true},
+			{Name: "packages", Default: ptr.To("curl")},
+			{Name: "motd"},
+		},
+		Template: buildv1.BuildTemplateResource{
+			Spec: buildv1.BuildTemplateResourceSpec{
+				Connector: &buildv1.ConnectorSpec{Type: "ssh"},
+				InfrastructureRef: &corev1.ObjectReference{
+					Kind: "DockerBuildTemplate",
+					Name: "ubuntu-${hostname}",
+				},
+				Provisioners: []buildv1.ProvisionerSpec{
+					{Type: buildv1.ProvisionerTypeShell, Run: ptr.To("apt-get install -y ${packages} && echo '${motd}' > /etc/motd && echo $$HOME")},
+				},
+			},
+		},
+	}
+	return tpl
+}
+
+func TestRender(t *testing.T) {
+	testcases := []struct {
+		name          string
+		variables     []buildv1.BuildVariable
+		expectedInfra string
+		expectedRun   string
+		wantErr       string
+	}{
+		{
+			name:          "defaults",
+			variables:     []buildv1.BuildVariable{{Name: "hostname", Value: "builder"}},
+			expectedInfra: "ubuntu-builder",
+			expectedRun:   "apt-get install -y curl && echo '' > /etc/motd && echo $HOME",
+		},
+		{
+			name: "values are escaped",
+			variables: []buildv1.BuildVariable{
+				{Name: "hostname", Value: "builder"},
+				{Name: "packages", Value: "git vim"},
+				{Name: "motd", Value: "built by \"forge\"\n"},
+			},
+			expectedInfra: "ubuntu-builder",
+			expectedRun:   "apt-get install -y git vim && echo 'built by \"forge\"\n' > /etc/motd && echo $HOME",
+		},
+		{
+			name: "variables sourced from a secret are not substituted",
+			variables: []buildv1.BuildVariable{
+				{Name: "hostname", Value: "builder"},
+				{Name: "motd", ValueFrom: &buildv1.BuildVariableSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "motd"}, Key: "value"},
+				}},
+				{Name: "TOKEN", ValueFrom: &buildv1.BuildVariableSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "registry"}, Key: "token"},
+				}},
+			},
+			expectedInfra: "ubuntu-builder",
+			expectedRun:   "apt-get install -y curl && echo '' > /etc/motd && echo $HOME",
+		},
+		{
+			name:    "missing required variable",
+			wantErr: "required variables hostname",
+		},
+		{
+			name: "undeclared variable",
+			variables: []buildv1.BuildVariable{
+				{Name: "hostname", Value: "builder"},
+				{Name: "region", Value: "eu"},
+			},
+			wantErr: "not declared",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rendered, err := Render(newTemplate(), tc.variables)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(rendered.InfrastructureRef.Name).To(Equal(tc.expectedInfra))
+			g.Expect(rendered.Provisioners).To(HaveLen(1))
+			g.Expect(*rendered.Provisioners[0].Run).To(Equal(tc.expectedRun))
+		})
+	}
+}
+
+func TestRenderUndeclaredReference(t *testing.T) {
+	g := NewWithT(t)
+
+	tpl := newTemplate()
+	tpl.Spec.Template.Spec.Provisioners[0].Run = ptr.To("echo ${region}")
+	_, err := Render(tpl, []buildv1.BuildVariable{{Name: "hostname", Value: "builder"}})
+	g.Expect(err).To(MatchError(ContainSubstring("undeclared variables region")))
+}
+
+func TestMerge(t *testing.T) {
+	g := NewWithT(t)
+
+	rendered := &buildv1.BuildTemplateResourceSpec{
+		Connector:     &buildv1.ConnectorSpec{Type: "ssh"},
+		Provisioners:  []buildv1.ProvisionerSpec{{Type: buildv1.ProvisionerTypeShell}},
+		DeleteCascade: true,
+	}
+
+	spec := &buildv1.BuildSpec{}
+	Merge(spec, rendered)
+	g.Expect(spec.Connector.Type).To(Equal("ssh"))
+	g.Expect(spec.Provisioners).To(HaveLen(1))
+	g.Expect(spec.DeleteCascade).To(BeTrue())
+
+	spec = &buildv1.BuildSpec{
+		Connector:    buildv1.ConnectorSpec{Type: "winrm"},
+		Provisioners: []buildv1.ProvisionerSpec{{Type: buildv1.ProvisionerTypeExternal}, {Type: buildv1.ProvisionerTypeExternal}},
+	}
+	Merge(spec, rendered)
+	g.Expect(spec.Connector.Type).To(Equal("winrm"))
+	g.Expect(spec.Provisioners).To(HaveLen(2))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// admissionRequeueAfter is how often a Build waiting to be admitted checks whether Builds have finished.
+const admissionRequeueAfter = 30 * time.Second
+
+// buildAdmission serializes the admission of the Builds, and remembers the Builds admitted by the controller
+// until the cache reports them admitted, so the concurrent reconciles don't admit more Builds than the limits.
+type buildAdmission struct {
+	mu     sync.Mutex
+	recent map[client.ObjectKey]bool
+}
+
+// reconcileAdmission queues the Build in the Pending phase until the number of Builds in progress, in its namespace
+// and in the cluster, is below the concurrency limits of the controller. The Builds of a namespace are admitted in
+// the order they have been created. It returns true while the Build is queued, in which case the other phases must
+// not run in this reconcile.
+func (r *BuildReconciler) reconcileAdmission(ctx context.Context, build *buildv1.Build) (ctrl.Result, bool, error) {
+	if r.MaxConcurrentBuilds <= 0 && r.MaxConcurrentBuildsPerNamespace <= 0 {
+		return ctrl.Result{}, false, nil
+	}
+	if !requiresAdmission(build) || isAdmitted(build) || isFinished(build) {
+		return ctrl.Result{}, false, nil
+	}
+
+	r.admission.mu.Lock()
+	defer r.admission.mu.Unlock()
+	if r.admission.recent == nil {
+		r.admission.recent = map[client.ObjectKey]bool{}
+	}
+
+	builds := &buildv1.BuildList{}
+	if err := r.Client.List(ctx, builds); err != nil {
+		return ctrl.Result{}, true, errors.Wrap(err, "failed to list Builds")
+	}
+
+	key := client.ObjectKeyFromObject(build)
+	listed := map[client.ObjectKey]bool{}
+	inProgress, namespaceInProgress, namespaceAhead := 0, 0, 0
+	for i := range builds.Items {
+		b := &builds.Items[i]
+		k := client.ObjectKeyFromObject(b)
+		listed[k] = true
+		if k == key || !requiresAdmission(b) || isFinished(b) || !b.DeletionTimestamp.IsZero() {
+			delete(r.admission.recent, k)
+			continue
+		}
+		if isAdmitted(b) {
+			// The cache reports the admission, the Build is no longer remembered.
+			delete(r.admission.recent, k)
+		}
+		switch {
+		case isAdmitted(b) || r.admission.recent[k]:
+			inProgress++
+			if b.Namespace == build.Namespace {
+				namespaceInProgress++
+			}
+		case b.Namespace == build.Namespace && b.CreationTimestamp.Before(&build.CreationTimestamp):
+			namespaceAhead++
+		}
+	}
+	for k := range r.admission.recent {
+		if !listed[k] {
+			delete(r.admission.recent, k)
+		}
+	}
+
+	var message string
+	switch {
+	case r.MaxConcurrentBuildsPerNamespace > 0 && namespaceInProgress+namespaceAhead >= r.MaxConcurrentBuildsPerNamespace:
+		message = fmt.Sprintf("%d Build(s) in progress and %d queued ahead in namespace %s, the limit is %d",
+			namespaceInProgress, namespaceAhead, build.Namespace, r.MaxConcurrentBuildsPerNamespace)
+	case r.MaxConcurrentBuilds > 0 && inProgress+namespaceAhead >= r.MaxConcurrentBuilds:
+		message = fmt.Sprintf("%d Build(s) in progress in the cluster, the limit is %d", inProgress, r.MaxConcurrentBuilds)
+	}
+	if message != "" {
+		if conditions.GetReason(build, buildv1.AdmittedCondition) != buildv1.ConcurrencyLimitReachedReason {
+			r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.ConcurrencyLimitReachedReason, "Build queued, %s", message)
+		}
+		conditions.MarkFalse(build, buildv1.AdmittedCondition, buildv1.ConcurrencyLimitReachedReason, "%s", message)
+		return ctrl.Result{RequeueAfter: admissionRequeueAfter}, true, nil
+	}
+
+	r.admission.recent[key] = true
+	if conditions.Has(build, buildv1.AdmittedCondition) {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.AdmittedReason, "Build admitted")
+	}
+	conditions.MarkTrue(build, buildv1.AdmittedCondition, buildv1.AdmittedReason, "")
+	return ctrl.Result{}, false, nil
+}
+
+// requiresAdmission returns false for the Builds which don't create machines: the Builds run in simulation or
+// dry-run mode and the multi-architecture Builds, whose architectures are admitted instead.
+func requiresAdmission(build *buildv1.Build) bool {
+	return !build.Spec.Rehearsal() && len(build.Spec.Architectures) == 0
+}
+
+// isAdmitted returns true if the Build has been admitted. The Builds which started before the concurrency
+// limits were set are admitted.
+func isAdmitted(build *buildv1.Build) bool {
+	return conditions.IsTrue(build, buildv1.AdmittedCondition) || build.Status.StartTime != nil
+}
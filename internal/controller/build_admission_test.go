@@ -0,0 +1,157 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileAdmission(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+	created := time.Now().Add(-time.Hour)
+	newBuild := func(namespace, name string, age time.Duration, admitted bool) *buildv1.Build {
+		build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace, Name: name, CreationTimestamp: metav1.NewTime(created.Add(-age)),
+		}}
+		if admitted {
+			conditions.MarkTrue(build, buildv1.AdmittedCondition, buildv1.AdmittedReason, "")
+		}
+		return build
+	}
+	completed := newBuild("images", "completed", time.Minute, true)
+	conditions.MarkTrue(completed, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	simulated := newBuild("images", "simulated", time.Minute, true)
+	simulated.Spec.Simulate = true
+
+	testcases := []struct {
+		name                   string
+		maxBuilds, maxPerSpace int
+		builds                 []client.Object
+		expectQueued           string
+	}{
+		{
+			name:   "no limits",
+			builds: []client.Object{newBuild("images", "running", time.Minute, true)},
+		},
+		{
+			name:        "below the namespace limit",
+			maxPerSpace: 2,
+			builds:      []client.Object{newBuild("images", "running", time.Minute, true), completed, simulated},
+		},
+		{
+			name:         "namespace limit reached",
+			maxPerSpace:  1,
+			builds:       []client.Object{newBuild("images", "running", time.Minute, true)},
+			expectQueued: "1 Build(s) in progress and 0 queued ahead in namespace images, the limit is 1",
+		},
+		{
+			name:        "namespace limit reached in another namespace",
+			maxPerSpace: 1,
+			builds:      []client.Object{newBuild("ci", "running", time.Minute, true)},
+		},
+		{
+			name:         "older Builds are admitted first",
+			maxPerSpace:  2,
+			builds:       []client.Object{newBuild("images", "running", time.Minute, true), newBuild("images", "queued", time.Second, false)},
+			expectQueued: "1 Build(s) in progress and 1 queued ahead in namespace images, the limit is 2",
+		},
+		{
+			name:        "newer Builds are admitted after",
+			maxPerSpace: 2,
+			builds:      []client.Object{newBuild("images", "running", time.Minute, true), newBuild("images", "queued", -time.Second, false)},
+		},
+		{
+			name:      "global limit reached",
+			maxBuilds: 2,
+			builds: []client.Object{
+				newBuild("images", "running", time.Minute, true),
+				newBuild("ci", "running", time.Minute, true),
+				newBuild("ci", "queued", time.Minute, false),
+			},
+			expectQueued: "2 Build(s) in progress in the cluster, the limit is 2",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := newBuild("images", "ubuntu", 0, false)
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(tc.builds, build.DeepCopy())...).Build()
+			r := &BuildReconciler{
+				Client:                          c,
+				recorder:                        record.NewFakeRecorder(10),
+				MaxConcurrentBuilds:             tc.maxBuilds,
+				MaxConcurrentBuildsPerNamespace: tc.maxPerSpace,
+			}
+
+			res, handled, err := r.reconcileAdmission(context.Background(), build)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tc.expectQueued == "" {
+				g.Expect(handled).To(BeFalse())
+				g.Expect(res.RequeueAfter).To(BeZero())
+				if tc.maxBuilds > 0 || tc.maxPerSpace > 0 {
+					g.Expect(conditions.IsTrue(build, buildv1.AdmittedCondition)).To(BeTrue())
+				}
+				return
+			}
+			g.Expect(handled).To(BeTrue())
+			g.Expect(res.RequeueAfter).To(Equal(admissionRequeueAfter))
+			g.Expect(conditions.GetReason(build, buildv1.AdmittedCondition)).To(Equal(buildv1.ConcurrencyLimitReachedReason))
+			g.Expect(conditions.GetMessage(build, buildv1.AdmittedCondition)).To(Equal(tc.expectQueued))
+		})
+	}
+}
+
+func TestReconcileAdmissionBeforeCacheUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	first := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "first"}}
+	second := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "second"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(first.DeepCopy(), second.DeepCopy()).Build()
+	r := &BuildReconciler{Client: c, recorder: record.NewFakeRecorder(10), MaxConcurrentBuilds: 1}
+
+	_, handled, err := r.reconcileAdmission(context.Background(), first)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(handled).To(BeFalse())
+
+	// The admission of the first Build has not been persisted yet, the second Build is queued nonetheless.
+	_, handled, err = r.reconcileAdmission(context.Background(), second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(handled).To(BeTrue())
+
+	// The second Build is admitted once the first one is gone.
+	g.Expect(c.Delete(context.Background(), first)).To(Succeed())
+	_, handled, err = r.reconcileAdmission(context.Background(), second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(handled).To(BeFalse())
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// waitForApproval returns true if the provisioner requires an approval it has not been given yet, the provisioner
+// is then reported in the status of the Build. awaitingApproval is the provisioner reported by the previous reconcile.
+// The provisioners are not approved in simulation or dry-run mode, as they don't run on a machine.
+func (r *BuildReconciler) waitForApproval(build *buildv1.Build, p *buildv1.ProvisionerSpec, awaitingApproval string) bool {
+	if !p.RequireApproval || p.UUID != nil || build.Spec.Rehearsal() {
+		return false
+	}
+
+	if !annotations.IsProvisionerApproved(build, p.Name) {
+		if awaitingApproval != p.Name {
+			r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.WaitingForApprovalReason,
+				"Provisioner %s is waiting for approval", p.Name)
+		}
+		build.Status.AwaitingApproval = p.Name
+		conditions.MarkFalse(build, buildv1.ProvisionersReadyCondition, buildv1.WaitingForApprovalReason,
+			"Provisioner %s is waiting for approval", p.Name)
+		return true
+	}
+
+	if awaitingApproval == p.Name {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "ProvisionerApproved", "Provisioner %s has been approved", p.Name)
+		conditions.MarkFalse(build, buildv1.ProvisionersReadyCondition, buildv1.WaitingForProvisionersReason,
+			"Waiting for %d provisioner(s) to complete", len(build.Spec.Provisioners))
+	}
+	return false
+}
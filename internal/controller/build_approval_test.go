@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestWaitForApproval(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH},
+			Provisioners: []buildv1.ProvisionerSpec{
+				{Name: "wipe-disk", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("wipefs -a /dev/sdb"), RequireApproval: true},
+			},
+		},
+		Status: buildv1.BuildStatus{Connected: true},
+	}
+
+	// The provisioner does not run until it is approved.
+	for range 2 {
+		_, err := r.reconcileProvisioners(context.Background(), build)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(build.Status.AwaitingApproval).To(Equal("wipe-disk"))
+	g.Expect(build.Spec.Provisioners[0].UUID).To(BeNil())
+	g.Expect(conditions.GetReason(build, buildv1.ProvisionersReadyCondition)).To(Equal(buildv1.WaitingForApprovalReason))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	jobs := &batchv1.JobList{}
+	g.Expect(c.List(context.Background(), jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(BeEmpty())
+
+	annotations.ApproveProvisioner(build, "wipe-disk")
+	_, err := r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.AwaitingApproval).To(BeEmpty())
+	g.Expect(build.Spec.Provisioners[0].Status).To(Equal(ptr.To(buildv1.ProvisionerStatusRunning)))
+	g.Expect(conditions.GetReason(build, buildv1.ProvisionersReadyCondition)).To(Equal(buildv1.WaitingForProvisionersReason))
+	g.Expect(c.List(context.Background(), jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(HaveLen(1))
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/external"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	forgeutil "github.com/forge-build/forge/util"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// architectureField is the field of the infrastructure object of an architecture holding the architecture
+// the infrastructure provider creates the machine for.
+var architectureField = []string{"spec", "architecture"}
+
+// architecturePhases are the phases of the Builds of the architectures, in the order they go through them.
+var architecturePhases = []buildv1.BuildPhase{
+	buildv1.BuildPhasePending,
+	buildv1.BuildPhaseProvisioning,
+	buildv1.BuildPhaseConnecting,
+	buildv1.BuildPhaseBuilding,
+	buildv1.BuildPhaseExporting,
+	buildv1.BuildPhaseCompleted,
+}
+
+// reconcileArchitectures creates a Build per architecture of a multi-architecture Build, each with its own copy
+// of the infrastructure object, and reports the images built for the architectures in the status of the Build.
+// The Build fails once the Build of an architecture has failed and is not going to be retried.
+func (r *BuildReconciler) reconcileArchitectures(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if build.Spec.InfrastructureRef == nil || isFailed(build) {
+		return ctrl.Result{}, nil
+	}
+	if conditions.IsTrue(build, buildv1.ArchitecturesReadyCondition) {
+		log.V(4).Info("Skipping reconcileArchitectures because the image has been built for all the architectures")
+		return ctrl.Result{}, nil
+	}
+	if !conditions.Has(build, buildv1.ArchitecturesReadyCondition) {
+		conditions.MarkFalse(build, buildv1.ArchitecturesReadyCondition, buildv1.WaitingForArchitecturesReason,
+			"Waiting for the image to be built for %d architecture(s)", len(build.Spec.Architectures))
+	}
+
+	builds := &buildv1.BuildList{}
+	if err := r.Client.List(ctx, builds, client.InNamespace(build.Namespace),
+		client.MatchingLabels{buildv1.ParentBuildNameLabel: build.Name}); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list the Builds of the architectures")
+	}
+	byArchitecture := map[buildv1.Architecture]*buildv1.Build{}
+	for i := range builds.Items {
+		if metav1.IsControlledBy(&builds.Items[i], build) {
+			byArchitecture[buildv1.Architecture(builds.Items[i].Labels[buildv1.ArchitectureLabel])] = &builds.Items[i]
+		}
+	}
+
+	for _, architecture := range build.Spec.Architectures {
+		if _, ok := byArchitecture[architecture]; ok {
+			continue
+		}
+		child, err := r.createArchitectureBuild(ctx, build, architecture)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		byArchitecture[architecture] = child
+	}
+
+	build.Status.Architectures = nil
+	built := []string{}
+	var failed *buildv1.Build
+	for _, architecture := range build.Spec.Architectures {
+		child := byArchitecture[architecture]
+		build.Status.Architectures = append(build.Status.Architectures, buildv1.ArchitectureStatus{
+			Architecture:   architecture,
+			BuildName:      child.Name,
+			Phase:          child.Status.Phase,
+			ImageRef:       child.Status.ImageRef,
+			Artifact:       child.Status.Artifact.DeepCopy(),
+			FailureMessage: child.Status.FailureMessage,
+		})
+		switch {
+		case child.Status.GetTypedPhase() == buildv1.BuildPhaseCompleted:
+			built = append(built, string(architecture))
+		case isFailed(child) && !shouldRetry(child) && failed == nil:
+			failed = child
+		}
+	}
+
+	if failed != nil {
+		architecture := failed.Labels[buildv1.ArchitectureLabel]
+		message := ptr.Deref(failed.Status.FailureMessage, "unknown")
+		conditions.MarkFalse(build, buildv1.ArchitecturesReadyCondition, buildv1.ArchitectureBuildFailedReason,
+			"Build %s of architecture %s failed: %s", failed.Name, architecture, message)
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ArchitectureBuildFailedError,
+			"build %s of architecture %s failed: %s", failed.Name, architecture, message)
+	}
+	if len(built) < len(build.Spec.Architectures) {
+		// The Build is reconciled again when the Builds of the architectures change.
+		conditions.MarkFalse(build, buildv1.ArchitecturesReadyCondition, buildv1.WaitingForArchitecturesReason,
+			"Image built for %d of %d architecture(s)", len(built), len(build.Spec.Architectures))
+		return ctrl.Result{}, nil
+	}
+
+	conditions.MarkTrue(build, buildv1.ArchitecturesReadyCondition, buildv1.ArchitecturesBuiltReason,
+		"Image built for %s", strings.Join(built, ", "))
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason,
+		"Image exported for %s", strings.Join(built, ", "))
+	build.Status.Ready = true
+	r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.ArchitecturesBuiltReason, "Image built for %s", strings.Join(built, ", "))
+	return ctrl.Result{}, nil
+}
+
+// createArchitectureBuild creates the Build of an architecture and its copy of the infrastructure object.
+func (r *BuildReconciler) createArchitectureBuild(ctx context.Context, build *buildv1.Build, architecture buildv1.Architecture) (*buildv1.Build, error) {
+	name := fmt.Sprintf("%s-%s", build.Name, architecture)
+	infraRef, err := r.cloneInfrastructureForArchitecture(ctx, build, name, architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	child := architectureBuild(build, name, architecture, infraRef)
+	if err := controllerutil.SetControllerReference(build, child, r.Scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to set the controller reference of the Build")
+	}
+	if err := r.Client.Create(ctx, child); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, errors.Wrapf(err, "failed to create Build %s", name)
+		}
+		// The Build has been created by a previous reconcile and is not in the cache yet.
+		return child, nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Created Build", "Build", name, "architecture", architecture)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "Created", "Created Build %s for architecture %s", name, architecture)
+	return child, nil
+}
+
+// cloneInfrastructureForArchitecture creates a copy of the infrastructure object of the Build, named after
+// the Build of the architecture, with spec.architecture set so the provider creates a machine of the architecture.
+func (r *BuildReconciler) cloneInfrastructureForArchitecture(ctx context.Context, build *buildv1.Build, name string, architecture buildv1.Architecture) (*corev1.ObjectReference, error) {
+	ref := build.Spec.InfrastructureRef
+	source, err := external.Get(ctx, r.Client, ref, build.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get %s %s", ref.Kind, ref.Name))
+		}
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range source.GetLabels() {
+		labels[k] = v
+	}
+	labels[buildv1.ArchitectureLabel] = string(architecture)
+
+	clone := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	clone.SetAPIVersion(source.GetAPIVersion())
+	clone.SetKind(source.GetKind())
+	clone.SetNamespace(source.GetNamespace())
+	clone.SetName(name)
+	clone.SetLabels(labels)
+	clone.SetAnnotations(source.GetAnnotations())
+	// The Build of the architecture becomes the controller of the object once it reconciles it.
+	clone.SetOwnerReferences([]metav1.OwnerReference{forgeutil.BuildOwnerReference(build)})
+	if spec, ok := source.Object["spec"].(map[string]interface{}); ok {
+		clone.Object["spec"] = runtime.DeepCopyJSON(spec)
+	}
+	if err := unstructured.SetNestedField(clone.Object, string(architecture), architectureField...); err != nil {
+		return nil, errors.Wrapf(err, "failed to set the architecture of %s %s", clone.GetKind(), name)
+	}
+	if err := r.Client.Create(ctx, clone); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "failed to create %s %s for architecture %s", clone.GetKind(), name, architecture)
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  build.Namespace,
+		Name:       name,
+	}, nil
+}
+
+// architectureBuild returns the Build of an architecture of a multi-architecture Build. It runs the provisioners
+// of the Build on its own machine, and is deleted along with the multi-architecture Build.
+func architectureBuild(build *buildv1.Build, name string, architecture buildv1.Architecture, infraRef *corev1.ObjectReference) *buildv1.Build {
+	spec := build.Spec.DeepCopy()
+	// The BuildTemplate, if any, has already been instantiated in the spec of the Build.
+	spec.TemplateRef = nil
+	spec.Architectures = nil
+	spec.TTLSecondsAfterFinished = nil
+	spec.InfrastructureRef = infraRef
+	// The name policy has already been rendered in spec.imageName.
+	spec.Artifact = nil
+
+	labels := map[string]string{
+		buildv1.ParentBuildNameLabel: build.Name,
+		buildv1.ArchitectureLabel:    string(architecture),
+	}
+	if value, ok := build.Labels[buildv1.WatchLabel]; ok {
+		// Keep the Build visible to a Build controller filtering the objects it watches.
+		labels[buildv1.WatchLabel] = value
+	}
+	annotations := map[string]string{}
+	if metadata := spec.ImageMetadata; metadata != nil {
+		// The images of the architectures get the metadata of the multi-architecture Build.
+		for _, mapping := range metadata.Labels {
+			if value, ok := build.Labels[mapping.Key]; ok {
+				labels[mapping.Key] = value
+			}
+		}
+		for _, mapping := range metadata.Annotations {
+			if value, ok := build.Annotations[mapping.Key]; ok {
+				annotations[mapping.Key] = value
+			}
+		}
+	}
+
+	return &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   build.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+}
+
+// architecturesPhase returns the phase of a multi-architecture Build, the phase of the least advanced Build
+// of its architectures. It returns false if no Build of the architectures has been created yet.
+func architecturesPhase(build *buildv1.Build) (buildv1.BuildPhase, bool) {
+	if len(build.Status.Architectures) == 0 {
+		return "", false
+	}
+	least := len(architecturePhases) - 1
+	for _, status := range build.Status.Architectures {
+		// The Builds which failed and are going to be retried start over.
+		index := 0
+		for i, phase := range architecturePhases {
+			if buildv1.BuildPhase(status.Phase) == phase {
+				index = i
+			}
+		}
+		least = min(least, index)
+	}
+	return architecturePhases[least], true
+}
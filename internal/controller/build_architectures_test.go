@@ -0,0 +1,160 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func newMultiArchitectureBuild() (*buildv1.Build, *unstructured.Unstructured) {
+	infra := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"instanceType": "m7g.large"},
+	}}
+	infra.SetAPIVersion("infrastructure.forge.build/v1alpha1")
+	infra.SetKind("AWSBuild")
+	infra.SetNamespace("images")
+	infra.SetName("ubuntu")
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ubuntu",
+			Namespace: "images",
+			UID:       "build-uid",
+			Labels:    map[string]string{buildv1.WatchLabel: "forge"},
+		},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.forge.build/v1alpha1",
+				Kind:       "AWSBuild",
+				Name:       "ubuntu",
+			},
+			TTLSecondsAfterFinished: ptr.To(int32(3600)),
+			Architectures:           []buildv1.Architecture{buildv1.ArchitectureAMD64, buildv1.ArchitectureARM64},
+		},
+	}
+	return build, infra
+}
+
+func TestReconcileArchitectures(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build, infra := newMultiArchitectureBuild()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(infra).WithStatusSubresource(&buildv1.Build{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	_, err := r.reconcileArchitectures(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Architectures).To(HaveLen(2))
+	g.Expect(conditions.IsFalse(build, buildv1.ArchitecturesReadyCondition)).To(BeTrue())
+
+	for _, architecture := range []string{"amd64", "arm64"} {
+		child := &buildv1.Build{}
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-" + architecture}, child)).To(Succeed())
+		g.Expect(metav1.IsControlledBy(child, build)).To(BeTrue())
+		g.Expect(child.Labels).To(HaveKeyWithValue(buildv1.ArchitectureLabel, architecture))
+		g.Expect(child.Labels).To(HaveKeyWithValue(buildv1.WatchLabel, "forge"))
+		g.Expect(child.Spec.Architectures).To(BeEmpty())
+		g.Expect(child.Spec.TTLSecondsAfterFinished).To(BeNil())
+		g.Expect(child.Spec.InfrastructureRef.Name).To(Equal("ubuntu-" + architecture))
+
+		clone := &unstructured.Unstructured{}
+		clone.SetGroupVersionKind(infra.GroupVersionKind())
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-" + architecture}, clone)).To(Succeed())
+		g.Expect(clone.Object["spec"]).To(Equal(map[string]interface{}{"instanceType": "m7g.large", "architecture": architecture}))
+
+		child.Status.SetTypedPhase(buildv1.BuildPhaseBuilding)
+		g.Expect(c.Status().Update(ctx, child)).To(Succeed())
+	}
+
+	_, err = r.reconcileArchitectures(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	phase, ok := architecturesPhase(build)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(phase).To(Equal(buildv1.BuildPhaseBuilding))
+
+	for _, architecture := range []string{"amd64", "arm64"} {
+		child := &buildv1.Build{}
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-" + architecture}, child)).To(Succeed())
+		child.Status.SetTypedPhase(buildv1.BuildPhaseCompleted)
+		child.Status.ImageRef = "ami-" + architecture
+		g.Expect(c.Status().Update(ctx, child)).To(Succeed())
+	}
+
+	_, err = r.reconcileArchitectures(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conditions.IsTrue(build, buildv1.ArchitecturesReadyCondition)).To(BeTrue())
+	g.Expect(conditions.IsTrue(build, buildv1.ImageExportedCondition)).To(BeTrue())
+	g.Expect(build.Status.Architectures[0].ImageRef).To(Equal("ami-amd64"))
+	g.Expect(build.Status.Architectures[1].ImageRef).To(Equal("ami-arm64"))
+	g.Expect(isFinished(build)).To(BeTrue())
+}
+
+func TestReconcileArchitecturesFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build, infra := newMultiArchitectureBuild()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(infra).WithStatusSubresource(&buildv1.Build{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	_, err := r.reconcileArchitectures(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	child := &buildv1.Build{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-arm64"}, child)).To(Succeed())
+	child.Spec.RetryPolicy = &buildv1.RetryPolicy{MaxRetries: 1}
+	g.Expect(c.Update(ctx, child)).To(Succeed())
+	child.Status.FailureReason = ptr.To(forgeerrors.ProvisionerFailedError)
+	child.Status.FailureMessage = ptr.To("provisioner install failed")
+	child.Status.SetTypedPhase(buildv1.BuildPhaseFailed)
+	g.Expect(c.Status().Update(ctx, child)).To(Succeed())
+
+	// The Build of the architecture is going to be retried.
+	_, err = r.reconcileArchitectures(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	child.Status.Retries = 1
+	g.Expect(c.Status().Update(ctx, child)).To(Succeed())
+
+	_, err = r.reconcileArchitectures(ctx, build)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTerminal))
+	g.Expect(forgeerrors.ReasonFor(err)).To(Equal(forgeerrors.ArchitectureBuildFailedError))
+	g.Expect(conditions.Get(build, buildv1.ArchitecturesReadyCondition).Reason).To(Equal(buildv1.ArchitectureBuildFailedReason))
+	g.Expect(build.Status.Architectures[1].FailureMessage).To(Equal(ptr.To("provisioner install failed")))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/buildtemplate"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// reconcileArtifactName renders the name policy of the artifact of the Build into spec.imageName, so the name of
+// the image is fixed once the Build has started and repeated Builds never overwrite the images of previous ones.
+func (r *BuildReconciler) reconcileArtifactName(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	artifact := build.Spec.Artifact
+	if artifact == nil || artifact.NamePolicy == "" || build.Spec.ImageName != "" {
+		return ctrl.Result{}, nil
+	}
+
+	if artifact.UsesGitSHA() && build.Annotations[buildv1.GitSHAAnnotation] == "" {
+		return ctrl.Result{}, forgeerrors.ConfigErrorf("the name policy of Build %s requires the %s annotation",
+			build.Name, buildv1.GitSHAAnnotation)
+	}
+	var serial int64
+	if artifact.UsesSerial() {
+		var err error
+		if serial, err = r.allocateSerial(ctx, build); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	name, err := artifact.RenderName(buildv1.NewArtifactNameData(build, serial))
+	if err != nil {
+		return ctrl.Result{}, forgeerrors.NewConfigError(err)
+	}
+	build.Spec.ImageName = name
+
+	ctrl.LoggerFrom(ctx).Info("Named the image of the Build", "imageName", name)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "ArtifactNamed", "Named the image %s", name)
+	return ctrl.Result{}, nil
+}
+
+// allocateSerial increments the serial of the BuildTemplate of the Build and returns it. A serial allocated to
+// a Build failing to persist its image name is not reused, serials may have gaps but are never allocated twice.
+func (r *BuildReconciler) allocateSerial(ctx context.Context, build *buildv1.Build) (int64, error) {
+	if build.Spec.TemplateRef == nil {
+		return 0, forgeerrors.ConfigErrorf("the name policy of Build %s uses the serial but the Build has no templateRef", build.Name)
+	}
+	key := client.ObjectKey{Namespace: build.Spec.TemplateRef.Namespace, Name: build.Spec.TemplateRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = build.Namespace
+	}
+
+	template := &buildv1.BuildTemplate{}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Client.Get(ctx, key, template); err != nil {
+			return err
+		}
+		template.Status.Serial++
+		return r.Client.Status().Update(ctx, template)
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to allocate a serial from BuildTemplate %s",
+			buildtemplate.String(build.Spec.TemplateRef, build.Namespace))
+	}
+	return template.Status.Serial, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestReconcileArtifactName(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	created := metav1.NewTime(time.Date(2024, 5, 1, 2, 3, 4, 0, time.UTC))
+	newBuild := func(name, policy string, annotations map[string]string) *buildv1.Build {
+		return &buildv1.Build{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "images", CreationTimestamp: created, Annotations: annotations},
+			Spec: buildv1.BuildSpec{
+				TemplateRef: &buildv1.BuildTemplateReference{Name: "ubuntu"},
+				Artifact:    &buildv1.ArtifactSpec{NamePolicy: policy},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name     string
+		build    *buildv1.Build
+		expected []string
+		serial   int64
+		wantErr  string
+	}{
+		{
+			name:     "serial is incremented by every Build",
+			build:    newBuild("ubuntu", "ubuntu-{{ .Serial }}", nil),
+			expected: []string{"ubuntu-4", "ubuntu-5"},
+			serial:   5,
+		},
+		{
+			name:     "serial is not allocated when unused",
+			build:    newBuild("ubuntu", "{{ .BuildName }}-{{ .Timestamp }}-{{ .GitSHA }}", map[string]string{buildv1.GitSHAAnnotation: "4f2c9e1"}),
+			expected: []string{"ubuntu-20240501-020304-4f2c9e1", "ubuntu-20240501-020304-4f2c9e1"},
+			serial:   3,
+		},
+		{
+			name:    "missing git SHA",
+			build:   newBuild("ubuntu", "ubuntu-{{ .GitSHA }}", nil),
+			wantErr: "requires the forge.build/git-sha annotation",
+			serial:  3,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			template := &buildv1.BuildTemplate{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+				Status:     buildv1.BuildTemplateStatus{Serial: 3},
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(template).WithStatusSubresource(&buildv1.BuildTemplate{}).Build()
+			r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+			names := []string{}
+			for range 2 {
+				build := tc.build.DeepCopy()
+				_, err := r.reconcileArtifactName(context.Background(), build)
+				if tc.wantErr != "" {
+					g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+					g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+					break
+				}
+				g.Expect(err).ToNot(HaveOccurred())
+				names = append(names, build.Spec.ImageName)
+
+				// The name is rendered once.
+				_, err = r.reconcileArtifactName(context.Background(), build)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(build.Spec.ImageName).To(Equal(names[len(names)-1]))
+			}
+			if tc.wantErr == "" {
+				g.Expect(names).To(Equal(tc.expected))
+			}
+
+			g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(template), template)).To(Succeed())
+			g.Expect(template.Status.Serial).To(Equal(tc.serial))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// sourceBuildRequeueAfter is how often the Build is requeued while the Build of its spec.from has not completed.
+const sourceBuildRequeueAfter = 30 * time.Second
+
+// reconcileCache records the content hashes of the provisioners of the Build in status.cache. When the Build starts
+// from the image of a previous Build, it resolves the source image the machine is created from and skips the first
+// provisioners whose hashes are unchanged since the source image has been built.
+func (r *BuildReconciler) reconcileCache(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if build.Status.Cache != nil || build.Status.InfrastructureReady {
+		return ctrl.Result{}, nil
+	}
+
+	layers, err := r.provisionerLayers(ctx, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	cache := &buildv1.CacheStatus{Layers: layers}
+	if from := build.Spec.From; from != nil {
+		source, err := r.resolveSource(ctx, build, from)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if source == nil {
+			log.V(3).Info("Waiting for the Build of spec.from to complete", "build", from.BuildRef.Name)
+			return ctrl.Result{RequeueAfter: sourceBuildRequeueAfter}, nil
+		}
+		cache.SourceImageRef, cache.SourceBuild = source.imageRef, source.build
+		for cache.Hits < int32(min(len(layers), len(source.layers))) && layers[cache.Hits].Hash == source.layers[cache.Hits].Hash {
+			cache.Hits++
+		}
+	}
+
+	executionOrder, err := buildv1.ProvisionerExecutionOrder(build.Spec.Provisioners)
+	if err != nil {
+		return ctrl.Result{}, forgeerrors.NewConfigError(err)
+	}
+	for n := range cache.Hits {
+		cache.Layers[n].Cached = true
+		build.Spec.Provisioners[executionOrder[n]].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	}
+	build.Status.Cache = cache
+	if build.Spec.From != nil {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "CacheResolved", "Starting from image %s, %d of %d provisioner(s) cached",
+			cache.SourceImageRef, cache.Hits, len(cache.Layers))
+	}
+	return ctrl.Result{}, nil
+}
+
+// resetCacheHits runs the cached provisioners again, when the machine has not been created from the source image.
+func resetCacheHits(build *buildv1.Build) {
+	executionOrder, err := buildv1.ProvisionerExecutionOrder(build.Spec.Provisioners)
+	if err != nil {
+		return
+	}
+	for n := range build.Status.Cache.Hits {
+		build.Status.Cache.Layers[n].Cached = false
+		build.Spec.Provisioners[executionOrder[n]].Status = nil
+	}
+	build.Status.Cache.Hits = 0
+}
+
+// cacheSource is the machine image a Build starts from, with the layers it has been built with.
+type cacheSource struct {
+	imageRef string
+	build    string
+	layers   []buildv1.ProvisionerLayer
+}
+
+// resolveSource returns the image of spec.from, nil while the Build it references has not completed. The layers of
+// a Build which has been deleted are read from the most recent Image cataloguing it.
+func (r *BuildReconciler) resolveSource(ctx context.Context, build *buildv1.Build, from *buildv1.BuildSource) (*cacheSource, error) {
+	if from.ImageRef != "" {
+		source := &cacheSource{imageRef: from.ImageRef}
+		images := &buildv1.ImageList{}
+		if err := r.Client.List(ctx, images, client.InNamespace(build.Namespace)); err != nil {
+			return nil, errors.Wrap(err, "failed to list Images")
+		}
+		if image := latestImage(images.Items, func(image *buildv1.Image) bool { return image.Spec.ImageRef == from.ImageRef }); image != nil {
+			source.build, source.layers = image.Spec.BuildRef.Name, image.Spec.Provenance.Layers
+		}
+		return source, nil
+	}
+
+	name := from.BuildRef.Name
+	previous := &buildv1.Build{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, previous)
+	switch {
+	case apierrors.IsNotFound(err):
+		images := &buildv1.ImageList{}
+		if err := r.Client.List(ctx, images, client.InNamespace(build.Namespace), client.MatchingLabels{buildv1.BuildNameLabel: name}); err != nil {
+			return nil, errors.Wrap(err, "failed to list Images")
+		}
+		image := latestImage(images.Items, func(image *buildv1.Image) bool { return true })
+		if image == nil {
+			return nil, forgeerrors.ConfigErrorf("Build %s of spec.from not found, nor any Image of it", name)
+		}
+		if err := checkSourceKind(build, image.Spec.InfrastructureRef); err != nil {
+			return nil, err
+		}
+		return &cacheSource{imageRef: image.Spec.ImageRef, build: name, layers: image.Spec.Provenance.Layers}, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to get Build %s of spec.from", name)
+	}
+
+	if isFailed(previous) {
+		return nil, forgeerrors.ConfigErrorf("Build %s of spec.from has failed", name)
+	}
+	if err := checkSourceKind(build, previous.Spec.InfrastructureRef); err != nil {
+		return nil, err
+	}
+	if previous.Status.ImageRef == "" || !previous.Status.ProvisionersReady {
+		return nil, nil
+	}
+	source := &cacheSource{imageRef: previous.Status.ImageRef, build: name}
+	if previous.Status.Cache != nil {
+		source.layers = previous.Status.Cache.Layers
+	}
+	return source, nil
+}
+
+// checkSourceKind returns a configuration error if the source image has been built on another kind of
+// infrastructure than the Build.
+func checkSourceKind(build *buildv1.Build, ref *corev1.ObjectReference) error {
+	if build.Spec.InfrastructureRef == nil || ref == nil || ref.Kind == build.Spec.InfrastructureRef.Kind {
+		return nil
+	}
+	return forgeerrors.ConfigErrorf("the image of spec.from has been built by %s, not %s", ref.Kind, build.Spec.InfrastructureRef.Kind)
+}
+
+// latestImage returns the most recent of the images matching, nil if none.
+func latestImage(images []buildv1.Image, match func(*buildv1.Image) bool) *buildv1.Image {
+	var latest *buildv1.Image
+	for i := range images {
+		image := &images[i]
+		if !match(image) {
+			continue
+		}
+		if latest == nil || imageCompletionTime(image).After(imageCompletionTime(latest)) {
+			latest = image
+		}
+	}
+	return latest
+}
+
+// imageCompletionTime returns the time the Build of the Image completed, the creation time of the Image if unknown.
+func imageCompletionTime(image *buildv1.Image) time.Time {
+	if t := image.Spec.Provenance.CompletionTime; t != nil {
+		return t.Time
+	}
+	return image.CreationTimestamp.Time
+}
+
+// provisionerLayers returns the provisioners of the Build in their execution order, hashed by their definition and
+// the scripts of the configmaps they run.
+func (r *BuildReconciler) provisionerLayers(ctx context.Context, build *buildv1.Build) ([]buildv1.ProvisionerLayer, error) {
+	executionOrder, err := buildv1.ProvisionerExecutionOrder(build.Spec.Provisioners)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	layers := make([]buildv1.ProvisionerLayer, 0, len(executionOrder))
+	for _, i := range executionOrder {
+		p := build.Spec.Provisioners[i]
+		var names []string
+		if p.RunConfigMapRef != nil {
+			names = append(names, p.RunConfigMapRef.Name)
+		}
+		for _, step := range p.Steps {
+			if step.RunConfigMapRef != nil {
+				names = append(names, step.RunConfigMapRef.Name)
+			}
+		}
+		scripts := map[string]map[string]string{}
+		for _, name := range names {
+			configMap := &corev1.ConfigMap{}
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, configMap); err != nil {
+				// The provisioner fails once it runs.
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, errors.Wrapf(err, "failed to get configmap %s of provisioner %s", name, p.Name)
+			}
+			scripts[name] = configMap.Data
+		}
+		digest, err := provisionerDigest(p, scripts)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, buildv1.ProvisionerLayer{Name: p.Name, Hash: "sha256:" + digest})
+	}
+	return layers, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestReconcileCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	scripts := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "scripts"},
+		Data:       map[string]string{"10-packages.sh": "apt-get install -y nginx"},
+	}
+	newBuild := func(name string) *buildv1.Build {
+		return &buildv1.Build{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: name},
+			Spec: buildv1.BuildSpec{
+				InfrastructureRef: &corev1.ObjectReference{Kind: "AWSBuild", Name: name},
+				Provisioners: []buildv1.ProvisionerSpec{
+					{Name: "base", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("apt-get update")},
+					{Name: "packages", Type: buildv1.ProvisionerTypeShell, RunConfigMapRef: &corev1.ObjectReference{Name: "scripts"}},
+					{Name: "app", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("systemctl enable nginx")},
+				},
+			},
+		}
+	}
+	previous := newBuild("previous")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scripts, previous).WithStatusSubresource(previous).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	// The layers of a Build are recorded even if it does not start from a previous image.
+	_, err := r.reconcileCache(ctx, previous)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(previous.Status.Cache.Layers).To(HaveLen(3))
+	g.Expect(previous.Status.Cache.Layers[1].Name).To(Equal("packages"))
+	g.Expect(previous.Status.Cache.Layers[1].Hash).To(HavePrefix("sha256:"))
+	g.Expect(previous.Status.Cache.Hits).To(BeZero())
+	g.Expect(recorder.Events).To(BeEmpty())
+	g.Expect(c.Status().Update(ctx, previous)).To(Succeed())
+
+	// The Build waits for the Build of spec.from to complete.
+	build := newBuild("ubuntu")
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "previous"}}
+	build.Spec.Provisioners[2].Run = ptr.To("systemctl enable --now nginx")
+	res, err := r.reconcileCache(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(sourceBuildRequeueAfter))
+	g.Expect(build.Status.Cache).To(BeNil())
+
+	// The provisioners unchanged since the previous Build are skipped, up to the first changed one.
+	previous.Status.ImageRef = "ami-1"
+	previous.Status.ProvisionersReady = true
+	g.Expect(c.Status().Update(ctx, previous)).To(Succeed())
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Cache.SourceImageRef).To(Equal("ami-1"))
+	g.Expect(build.Status.Cache.SourceBuild).To(Equal("previous"))
+	g.Expect(build.Status.Cache.Hits).To(BeEquivalentTo(2))
+	g.Expect(build.Status.Cache.Layers[1].Cached).To(BeTrue())
+	g.Expect(build.Status.Cache.Layers[2].Cached).To(BeFalse())
+	g.Expect(build.Spec.Provisioners[1].Status).To(Equal(ptr.To(buildv1.ProvisionerStatusCompleted)))
+	g.Expect(build.Spec.Provisioners[2].Status).To(BeNil())
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("Starting from image ami-1, 2 of 3 provisioner(s) cached")))
+
+	// The cached provisioners run again when the machine has not been created from the source image.
+	resetCacheHits(build)
+	g.Expect(build.Status.Cache.Hits).To(BeZero())
+	g.Expect(build.Spec.Provisioners[0].Status).To(BeNil())
+
+	// A changed script is a cache miss.
+	scripts.Data["10-packages.sh"] = "apt-get install -y nginx curl"
+	g.Expect(c.Update(ctx, scripts)).To(Succeed())
+	build = newBuild("ubuntu")
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "previous"}}
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Cache.Hits).To(BeEquivalentTo(1))
+
+	// The layers of a deleted Build are read from its Image.
+	image, err := imageFor(previous)
+	g.Expect(err).ToNot(HaveOccurred())
+	image.Name = "previous"
+	g.Expect(c.Create(ctx, image)).To(Succeed())
+	g.Expect(c.Delete(ctx, previous)).To(Succeed())
+	build = newBuild("ubuntu")
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "previous"}}
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Cache.SourceImageRef).To(Equal("ami-1"))
+	g.Expect(build.Status.Cache.Hits).To(BeEquivalentTo(1))
+
+	// A machine image is looked up in the Images, all the provisioners run if it is not catalogued.
+	build = newBuild("ubuntu")
+	build.Spec.From = &buildv1.BuildSource{ImageRef: "ami-0"}
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Cache.SourceImageRef).To(Equal("ami-0"))
+	g.Expect(build.Status.Cache.Hits).To(BeZero())
+
+	// The image of a Build on another infrastructure cannot be started from.
+	build = newBuild("ubuntu")
+	build.Spec.InfrastructureRef.Kind = "GCPBuild"
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "previous"}}
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+
+	// A Build of spec.from which does not exist, nor its Images, is a configuration error.
+	build = newBuild("ubuntu")
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "missing"}}
+	_, err = r.reconcileCache(ctx, build)
+	g.Expect(err).To(MatchError(ContainSubstring("Build missing of spec.from not found")))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestReconcileProvisionersConcurrencyGroup(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH},
+			Provisioners: []buildv1.ProvisionerSpec{
+				{Name: "go", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("install-go"), ConcurrencyGroup: "toolchains"},
+				{Name: "rust", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("install-rust"), ConcurrencyGroup: "toolchains"},
+				{Name: "cargo", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("install-cargo"), ConcurrencyGroup: "toolchains", DependsOn: []string{"rust"}},
+				{Name: "cleanup", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("apt-get clean")},
+			},
+		},
+		Status: buildv1.BuildStatus{Connected: true},
+	}
+
+	// The independent provisioners of the group run at the same time in their own Job.
+	res, err := r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res).To(Equal(ctrl.Result{RequeueAfter: 2 * time.Second}))
+	statuses := func() []buildv1.ProvisionerStatus {
+		var statuses []buildv1.ProvisionerStatus
+		for _, p := range build.Spec.Provisioners {
+			statuses = append(statuses, ptr.Deref(p.Status, buildv1.ProvisionerStatusPending))
+		}
+		return statuses
+	}
+	g.Expect(statuses()).To(Equal([]buildv1.ProvisionerStatus{
+		buildv1.ProvisionerStatusRunning, buildv1.ProvisionerStatusRunning,
+		buildv1.ProvisionerStatusPending, buildv1.ProvisionerStatusPending,
+	}))
+	jobs := &batchv1.JobList{}
+	g.Expect(c.List(context.Background(), jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(HaveLen(2))
+	g.Expect(jobs.Items[0].Name).ToNot(Equal(jobs.Items[1].Name))
+
+	// The provisioner of the group depending on a completed provisioner starts while the others still run.
+	build.Spec.Provisioners[1].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	_, err = r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(statuses()).To(Equal([]buildv1.ProvisionerStatus{
+		buildv1.ProvisionerStatusRunning, buildv1.ProvisionerStatusCompleted,
+		buildv1.ProvisionerStatusRunning, buildv1.ProvisionerStatusPending,
+	}))
+
+	// The provisioners outside of the group wait for the whole group.
+	build.Spec.Provisioners[2].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	_, err = r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Spec.Provisioners[3].Status).To(BeNil())
+
+	build.Spec.Provisioners[0].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	_, err = r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Spec.Provisioners[3].Status).To(Equal(ptr.To(buildv1.ProvisionerStatusRunning)))
+	g.Expect(build.Status.ProvisionersReady).To(BeFalse())
+
+	build.Spec.Provisioners[3].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	_, err = r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.ProvisionersReady).To(BeTrue())
+}
+
+func TestEarliestResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     ctrl.Result
+		expected ctrl.Result
+	}{
+		{name: "none", expected: ctrl.Result{}},
+		{name: "first", a: ctrl.Result{RequeueAfter: time.Second}, expected: ctrl.Result{RequeueAfter: time.Second}},
+		{name: "second", b: ctrl.Result{RequeueAfter: time.Second}, expected: ctrl.Result{RequeueAfter: time.Second}},
+		{name: "shortest", a: ctrl.Result{RequeueAfter: 10 * time.Second}, b: ctrl.Result{RequeueAfter: 2 * time.Second}, expected: ctrl.Result{RequeueAfter: 2 * time.Second}},
+		{name: "right away", a: ctrl.Result{RequeueAfter: 10 * time.Second}, b: ctrl.Result{Requeue: true}, expected: ctrl.Result{Requeue: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(earliestResult(tt.a, tt.b)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -25,19 +25,18 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
-	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
-	"sigs.k8s.io/cluster-api/util/conditions"
-	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
@@ -47,12 +46,26 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/buildtemplate"
 	"github.com/forge-build/forge/internal/external"
+	"github.com/forge-build/forge/pkg/drain"
 	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	forgelog "github.com/forge-build/forge/pkg/log"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/redact"
 	ssh "github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/extract"
+	fileprovisioner "github.com/forge-build/forge/provisioner/file"
+	"github.com/forge-build/forge/provisioner/harden"
+	"github.com/forge-build/forge/provisioner/powershell"
+	"github.com/forge-build/forge/provisioner/restart"
 	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/provisioner/verify"
+	forgeutil "github.com/forge-build/forge/util"
 	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
 	utilconversion "github.com/forge-build/forge/util/conversion"
+	"github.com/forge-build/forge/util/patch"
 	"github.com/forge-build/forge/util/predicates"
 )
 
@@ -62,6 +75,13 @@ const (
 	deleteRequeueAfter = 5 * time.Second
 
 	SSHTimeout = 10 * time.Second
+
+	// BuildControllerName is the name of the Build controller, used in the controller metrics.
+	BuildControllerName = "build"
+
+	// IdentityValidationTimeout is how long after its creation the credentials of a ProviderIdentity must have been
+	// validated, the Builds referencing it fail afterwards.
+	IdentityValidationTimeout = 10 * time.Minute
 )
 
 // BuildReconciler reconciles a Build object
@@ -73,17 +93,55 @@ type BuildReconciler struct {
 	// WatchFilterValue is the label value used to filter events prior to reconciliation.
 	WatchFilterValue string
 
+	// ExporterImage is the image of the Jobs publishing the exports of the Builds.
+	ExporterImage string
+
+	// ShellProvisionerImage is the default image of the Jobs running the shell provisioners.
+	ShellProvisionerImage shellcontroller.ImageOptions
+
+	// AnsibleProvisionerImage is the default image of the Jobs running the ansible provisioners, the pull policy
+	// and the pull secrets of the shell provisioners apply.
+	AnsibleProvisionerImage string
+
+	// ShellProvisionerPlacement defines the namespace of the Jobs running the shell provisioners.
+	ShellProvisionerPlacement shellcontroller.PlacementOptions
+
+	// MaxConcurrentBuilds is the maximum number of Builds in progress in the cluster, 0 means unlimited.
+	MaxConcurrentBuilds int
+
+	// MaxConcurrentBuildsPerNamespace is the maximum number of Builds in progress in a namespace, 0 means unlimited.
+	MaxConcurrentBuildsPerNamespace int
+
+	// MultiTenancy restricts the Builds to the infrastructure objects of their namespace, of the kinds allowed by the
+	// allowed-infrastructure-kinds annotation of the namespace.
+	MultiTenancy bool
+
+	// APIReader reads the Pods of the export Jobs from the API server, not to cache all the Pods of the cluster.
+	// The client is used when not set.
+	APIReader client.Reader
+
 	recorder        record.EventRecorder
 	externalTracker external.ObjectTracker
+	admission       buildAdmission
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if options.LogConstructor == nil {
+		options.LogConstructor = forgelog.BuildLogConstructor(mgr.GetLogger(), BuildControllerName)
+	}
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&buildv1.Build{}).
+		Named(BuildControllerName).
 		WithOptions(options).
+		Owns(&batchv1.Job{}).
+		Owns(&buildv1.Build{}).
+		Watches(
+			&buildv1.ProviderIdentity{},
+			handler.EnqueueRequestsFromMapFunc(r.providerIdentityToBuilds),
+		).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
-		Build(r)
+		Build(metrics.Instrument(BuildControllerName, drain.Reconciler(r)))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
@@ -98,29 +156,46 @@ func (r *BuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager
 }
 
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
-//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch;update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch;update;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;patch;update
 //+kubebuilder:rbac:groups=infrastructure.forge.build;provisioner.forge.build,resources=*,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=forge.build,resources=builds,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=forge.build,resources=builds/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=forge.build,resources=builds/finalizers,verbs=update
+//+kubebuilder:rbac:groups=forge.build,resources=buildtemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=forge.build,resources=buildtemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=provideridentities,verbs=get;list;watch
+//+kubebuilder:rbac:groups=forge.build,resources=credentialsproviders,verbs=get;list;watch
+//+kubebuilder:rbac:groups=forge.build,resources=provisionerclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=forge.build,resources=images,verbs=get;list;watch;create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *BuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
-	r.Logger = ctrl.LoggerFrom(ctx)
-
 	// Fetch the Cluster instance.
 	build := &buildv1.Build{}
 	if err := r.Client.Get(ctx, req.NamespacedName, build); err != nil {
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			metrics.DeleteBuild(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, err
 	}
+	// The secrets of the Build are masked in everything written about it: logs, events, conditions and messages.
+	redactor, err := redact.ForBuild(ctx, r.Client, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	ctx = redact.IntoContext(ctx, redactor)
+	ctx = ctrl.LoggerInto(ctx, redactor.Logger(forgelog.WithProvider(ctrl.LoggerFrom(ctx), infrastructureKind(build))))
+	r.Logger = ctrl.LoggerFrom(ctx)
+	defer func() {
+		reterr = redactor.Error(reterr)
+	}()
 
 	// Return early if the object or Cluster is paused.
 	if annotations.IsPaused(build, build) {
@@ -134,9 +209,11 @@ func (r *BuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ct
 		return ctrl.Result{}, err
 	}
 
+	observed := observeBuild(build)
 	defer func() {
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(ctx, build)
+		reportBuildMetrics(build, observed)
 
 		// Always attempt to Patch the Cluster object and status after each reconciliation.
 		// Patch ObservedGeneration only if the reconciliation is completed successfully
@@ -167,40 +244,130 @@ func (r *BuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ct
 
 func patchBuild(ctx context.Context, patchHelper *patch.Helper, build *buildv1.Build, options ...patch.Option) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
-	conditions.SetSummary(build,
-		conditions.WithConditions(
+	switch {
+	case len(build.Spec.Architectures) > 0:
+		conditions.SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason,
+			buildv1.ArchitecturesReadyCondition,
+			buildv1.ImageExportedCondition,
+		)
+	case build.Spec.Rehearsal():
+		conditions.SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason,
 			buildv1.ProvisionersReadyCondition,
+			buildv1.ImageExportedCondition,
+		)
+	default:
+		summarized := []string{
 			buildv1.InfrastructureReadyCondition,
-		),
-	)
-
-	// Patch the object, ignoring conflicts on the conditions owned by this controller.
-	// Also, if requested, we are adding additional options like e.g. Patch ObservedGeneration when issuing the
-	// patch at the end of the reconcile loop.
-	options = append(options,
-		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
-			buildv1.ReadyCondition,
+			buildv1.MachineReadyCondition,
+			buildv1.ConnectedCondition,
 			buildv1.ProvisionersReadyCondition,
-			buildv1.InfrastructureReadyCondition,
-		}},
-	)
+			buildv1.ImageExportedCondition,
+		}
+		if len(build.Spec.Exports) > 0 {
+			summarized = append(summarized, buildv1.ExportsPublishedCondition)
+		}
+		conditions.SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason, summarized...)
+	}
+
+	build.Status.Health = buildHealth(build)
+	build.Status.Progress = buildProgress(build)
+	redact.FromContext(ctx).Build(build)
+
+	// Patch the object, if requested, we are adding additional options like e.g. Patch ObservedGeneration
+	// when issuing the patch at the end of the reconcile loop.
 	return patchHelper.Patch(ctx, build, options...)
 }
 
 // reconcile handles cluster reconciliation.
 func (r *BuildReconciler) reconcile(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if res, handled, err := r.reconcileRetry(ctx, build); handled {
+		if err != nil {
+			res, _, err := r.handlePhaseError(ctx, build, err)
+			return res, err
+		}
+		return res, nil
+	}
+	if isFinished(build) {
+		if err := r.shredEphemeralCredentials(ctx, build); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileImage(ctx, build); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	ttlResult, handled, err := r.reconcileTTL(ctx, build)
+	if handled {
+		return ctrl.Result{}, err
+	}
+	// The deadlines of the Build start once it has been admitted.
+	if res, handled, err := r.reconcileAdmission(ctx, build); handled {
+		return res, err
+	}
+	timeoutResult, handled, err := r.reconcileTimeouts(ctx, build)
+	if handled {
+		return ctrl.Result{}, err
+	}
+
 	phases := []func(context.Context, *buildv1.Build) (ctrl.Result, error){
+		r.reconcileTemplate,
+		r.reconcileArtifactName,
+		r.reconcileImageMetadata,
+		r.reconcileTenancy,
+		r.reconcileIdentity,
+		r.reconcileWorkspace,
+		r.reconcileVariables,
+		r.reconcileEphemeralCredentials,
+		r.reconcileCache,
 		r.reconcileInfrastructure,
 		r.reconcileConnection,
 		r.reconcileProvisioners,
 		r.reconcileImageProvided,
+		r.reconcileExports,
+	}
+	if build.Spec.Simulate {
+		// Nothing is created on the infrastructure in simulation mode, only the provisioners are checked.
+		phases = []func(context.Context, *buildv1.Build) (ctrl.Result, error){
+			r.reconcileTemplate,
+			r.reconcileProvisioners,
+			r.reconcileSimulation,
+		}
+	}
+	if build.Spec.DryRun {
+		// Nothing is created nor run in dry-run mode, only the plan of the provisioners is rendered.
+		phases = []func(context.Context, *buildv1.Build) (ctrl.Result, error){
+			r.reconcileTemplate,
+			r.reconcileProvisioners,
+			r.reconcileDryRun,
+		}
 	}
+	if len(build.Spec.Architectures) > 0 {
+		// The machines are created and provisioned by the Builds of the architectures.
+		phases = []func(context.Context, *buildv1.Build) (ctrl.Result, error){
+			r.reconcileTemplate,
+			r.reconcileArtifactName,
+			r.reconcileTenancy,
+			r.reconcileArchitectures,
+		}
+	}
+
+	res, err := r.reconcilePhases(ctx, build, phases)
+	return util.LowestNonZeroResult(util.LowestNonZeroResult(res, timeoutResult), ttlResult), err
+}
 
+// reconcilePhases runs the phases in order. The phases after a phase failing the Build do not run.
+func (r *BuildReconciler) reconcilePhases(ctx context.Context, build *buildv1.Build, phases []func(context.Context, *buildv1.Build) (ctrl.Result, error)) (ctrl.Result, error) {
 	res := ctrl.Result{}
 	var errs []error
 	for _, phase := range phases {
 		// Call the inner reconciliation methods.
 		phaseResult, err := phase(ctx, build)
+		if err != nil {
+			var failed bool
+			phaseResult, failed, err = r.handlePhaseError(ctx, build, err)
+			if failed {
+				return ctrl.Result{}, kerrors.NewAggregate(errs)
+			}
+		}
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -212,10 +379,37 @@ func (r *BuildReconciler) reconcile(ctx context.Context, build *buildv1.Build) (
 	return res, kerrors.NewAggregate(errs)
 }
 
+// handlePhaseError decides whether a phase error is retried or fails the Build, based on its category. It returns
+// true if the Build has been failed. Errors without a category are returned as is to rely on the controller rate limiter.
+func (r *BuildReconciler) handlePhaseError(ctx context.Context, build *buildv1.Build, err error) (ctrl.Result, bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	switch category := forgeerrors.Classify(err); category {
+	case forgeerrors.CategoryTransient, forgeerrors.CategoryThrottled:
+		requeueAfter := forgeerrors.RequeueAfter(err)
+		log.Info("Reconciliation hit a retryable error, requeuing", "category", category, "reason", err.Error(), "requeueAfter", requeueAfter)
+		return ctrl.Result{RequeueAfter: requeueAfter}, false, nil
+	case forgeerrors.CategoryTerminal, forgeerrors.CategoryConfigError:
+		log.Error(err, "Reconciliation hit a terminal error, failing the Build", "category", category)
+		build.Status.FailureReason = ptr.To(forgeerrors.ReasonFor(err))
+		build.Status.FailureMessage = ptr.To(err.Error())
+		return ctrl.Result{}, true, nil
+	default:
+		return ctrl.Result{}, false, err
+	}
+}
+
 // reconcileDelete handles cluster deletion.
 func (r *BuildReconciler) reconcileDelete(ctx context.Context, build *buildv1.Build) (reconcile.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 
+	// The Jobs of the shell provisioners run in the forge core namespace, or in the namespace of the Build when it is
+	// placed there, see PlacementOptions.JobNamespace. The Jobs in the core namespace cannot be owned by the Build,
+	// so none is and they are deleted here in both cases, before the machine they provision is deleted.
+	if err := shellcontroller.DeleteJobs(ctx, r.Client, build); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	descendants, err := r.listDescendants(ctx, build)
 	if err != nil {
 		log.Error(err, "Failed to list descendants")
@@ -271,16 +465,15 @@ func (r *BuildReconciler) reconcileDelete(ctx context.Context, build *buildv1.Bu
 		switch {
 		case apierrors.IsNotFound(errors.Cause(err)):
 			// All good - the InfraBuild resource has been deleted
-			conditions.MarkFalse(build, buildv1.InfrastructureReadyCondition, buildv1.DeletedReason, buildv1.ConditionSeverityInfo, "")
+			conditions.MarkFalse(build, buildv1.InfrastructureReadyCondition, buildv1.DeletedReason, "")
 		case err != nil:
 			return reconcile.Result{}, errors.Wrapf(err, "failed to get %s %q for Build %s/%s",
 				path.Join(build.Spec.InfrastructureRef.APIVersion, build.Spec.InfrastructureRef.Kind),
 				build.Spec.InfrastructureRef.Name, build.Namespace, build.Name)
 		default:
 			// Report a summary of current status of the InfraBuild object defined for this build.
-			conditions.SetMirror(build, buildv1.InfrastructureReadyCondition,
-				conditions.UnstructuredGetter(obj),
-				conditions.WithFallbackValue(false, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, ""),
+			conditions.SetMirror(build, buildv1.InfrastructureReadyCondition, obj,
+				conditions.FalseCondition(buildv1.DeletingReason, ""),
 			)
 
 			// Issue a deletion request for the InfraBuild object.
@@ -297,11 +490,163 @@ func (r *BuildReconciler) reconcileDelete(ctx context.Context, build *buildv1.Bu
 		}
 	}
 
+	if err := r.purgeWorkspace(ctx, build); err != nil {
+		if !apierrors.IsNotFound(errors.Cause(err)) {
+			return ctrl.Result{}, err
+		}
+		// The deletion of the Build is not blocked by the deletion of the credentials of its workspace.
+		log.Error(err, "Failed to purge the workspace, its objects must be deleted manually", "url", build.Status.Workspace.URL)
+		r.recorder.Eventf(build, corev1.EventTypeWarning, "WorkspacePurgeFailed", "Failed to purge workspace %s: %v", build.Status.Workspace.URL, err)
+	}
+
 	controllerutil.RemoveFinalizer(build, buildv1.BuildFinalizer)
 	r.recorder.Eventf(build, corev1.EventTypeNormal, "Deleted", "Build %s has been deleted", build.Name)
 	return ctrl.Result{}, nil
 }
 
+// reconcileTemplate instantiates the BuildTemplate referenced by the Build, if any.
+// The fields set by the template are persisted on the Build spec, so the template is only rendered once.
+func (r *BuildReconciler) reconcileTemplate(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if build.Spec.TemplateRef == nil || conditions.IsTrue(build, buildv1.TemplateResolvedCondition) {
+		return ctrl.Result{}, nil
+	}
+
+	templateName := buildtemplate.String(build.Spec.TemplateRef, build.Namespace)
+	key := client.ObjectKey{Namespace: build.Spec.TemplateRef.Namespace, Name: build.Spec.TemplateRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = build.Namespace
+	}
+	template := &buildv1.BuildTemplate{}
+	if err := r.Client.Get(ctx, key, template); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(build, buildv1.TemplateResolvedCondition, buildv1.TemplateNotFoundReason,
+				"BuildTemplate %s not found", templateName)
+			return ctrl.Result{}, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get BuildTemplate %s", templateName))
+		}
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get BuildTemplate %s", templateName)
+	}
+
+	rendered, err := buildtemplate.Render(template, build.Spec.Variables)
+	if err != nil {
+		conditions.MarkFalse(build, buildv1.TemplateResolvedCondition, buildv1.TemplateRenderFailedReason, "%s", err.Error())
+		return ctrl.Result{}, forgeerrors.NewConfigError(err)
+	}
+	buildtemplate.Merge(&build.Spec, rendered)
+
+	if build.Spec.InfrastructureRef == nil && rendered.InfrastructureRef != nil {
+		infraRef, err := r.cloneInfrastructureTemplate(ctx, build, rendered.InfrastructureRef)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		build.Spec.InfrastructureRef = infraRef
+	}
+
+	log.Info("Instantiated BuildTemplate", "BuildTemplate", templateName)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "TemplateResolved", "Instantiated BuildTemplate %s", templateName)
+	conditions.MarkTrue(build, buildv1.TemplateResolvedCondition, buildv1.TemplateResolvedReason, "Instantiated BuildTemplate %s", templateName)
+	return ctrl.Result{}, nil
+}
+
+// cloneInfrastructureTemplate creates the infrastructure object of the Build from the given infrastructure template.
+func (r *BuildReconciler) cloneInfrastructureTemplate(ctx context.Context, build *buildv1.Build, templateRef *corev1.ObjectReference) (*corev1.ObjectReference, error) {
+	infraRef, err := external.CreateFromTemplate(ctx, &external.CreateFromTemplateInput{
+		Client:      r.Client,
+		TemplateRef: templateRef,
+		Namespace:   build.Namespace,
+		Name:        build.Name,
+		ClusterName: build.Name,
+		OwnerRef: &metav1.OwnerReference{
+			APIVersion: buildv1.GroupVersion.String(),
+			Kind:       "Build",
+			Name:       build.Name,
+			UID:        build.UID,
+		},
+	})
+	if err == nil {
+		return infraRef, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "failed to clone %s %s", templateRef.Kind, templateRef.Name)
+	}
+
+	// The infrastructure object has been created by a previous reconcile which failed to persist the reference.
+	return &corev1.ObjectReference{
+		APIVersion: templateRef.APIVersion,
+		Kind:       strings.TrimSuffix(templateRef.Kind, buildv1.TemplateSuffix),
+		Namespace:  build.Namespace,
+		Name:       build.Name,
+	}, nil
+}
+
+// reconcileIdentity fails the Build before its infrastructure is ready when the credentials of the ProviderIdentity
+// it references are reported invalid, instead of waiting for the infrastructure provider to time out.
+// The credentials are no longer checked once the infrastructure is ready, so running Builds are not interrupted.
+func (r *BuildReconciler) reconcileIdentity(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if build.Spec.IdentityRef == nil || build.Status.InfrastructureReady || build.Status.FailureReason != nil {
+		return ctrl.Result{}, nil
+	}
+
+	identity := &buildv1.ProviderIdentity{}
+	key := client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.IdentityRef.Name}
+	if err := r.Client.Get(ctx, key, identity); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(build, buildv1.CredentialsValidCondition, buildv1.IdentityNotFoundReason,
+				"ProviderIdentity %s not found", key.Name)
+			return ctrl.Result{}, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get ProviderIdentity %s", key.Name))
+		}
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get ProviderIdentity %s", key.Name)
+	}
+
+	credentialsValid := conditions.Get(identity, buildv1.CredentialsValidCondition)
+	switch {
+	case credentialsValid == nil || credentialsValid.Status == metav1.ConditionUnknown:
+		// The credentials are validated by the ProviderIdentity controller of the infrastructure provider, the Build
+		// fails instead of waiting forever when no such controller runs.
+		wait := time.Until(identity.CreationTimestamp.Add(IdentityValidationTimeout))
+		if wait <= 0 {
+			conditions.MarkFalse(build, buildv1.CredentialsValidCondition, buildv1.IdentityNotValidatedReason,
+				"The credentials of ProviderIdentity %s have not been validated within %s, check that provider %s runs a ProviderIdentity controller",
+				key.Name, IdentityValidationTimeout, identity.Spec.Provider)
+			return ctrl.Result{}, forgeerrors.ConfigErrorf(
+				"the credentials of ProviderIdentity %s have not been validated within %s, check that provider %s runs a ProviderIdentity controller",
+				key.Name, IdentityValidationTimeout, identity.Spec.Provider)
+		}
+		// The Build is requeued by the ProviderIdentity watch once the credentials have been validated.
+		conditions.MarkFalse(build, buildv1.CredentialsValidCondition, buildv1.WaitingForCredentialsValidationReason,
+			"Waiting for the credentials of ProviderIdentity %s to be validated", key.Name)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	case credentialsValid.Status == metav1.ConditionFalse:
+		conditions.MarkFalse(build, buildv1.CredentialsValidCondition, credentialsValid.Reason,
+			"ProviderIdentity %s: %s", key.Name, credentialsValid.Message)
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.InvalidCredentialsBuildError,
+			"credentials of ProviderIdentity %s are invalid: %s", key.Name, credentialsValid.Message)
+	}
+
+	conditions.MarkTrue(build, buildv1.CredentialsValidCondition, buildv1.CredentialsValidReason,
+		"ProviderIdentity %s credentials are valid", key.Name)
+	return ctrl.Result{}, nil
+}
+
+// providerIdentityToBuilds maps a ProviderIdentity to the Builds referencing it which have no infrastructure yet.
+func (r *BuildReconciler) providerIdentityToBuilds(ctx context.Context, o client.Object) []ctrl.Request {
+	builds := &buildv1.BuildList{}
+	if err := r.Client.List(ctx, builds, client.InNamespace(o.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list Builds referencing ProviderIdentity", "ProviderIdentity", klog.KObj(o))
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, build := range builds.Items {
+		if build.Spec.IdentityRef == nil || build.Spec.IdentityRef.Name != o.GetName() || build.Status.InfrastructureReady {
+			continue
+		}
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&build)})
+	}
+	return requests
+}
+
 // reconcileInfrastructure reconciles the Spec.InfrastructureRef object on a Build.
 func (r *BuildReconciler) reconcileInfrastructure(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
@@ -330,6 +675,10 @@ func (r *BuildReconciler) reconcileInfrastructure(ctx context.Context, build *bu
 		return ctrl.Result{}, nil
 	}
 
+	if isPreempted(build) {
+		return r.reconcilePreemption(ctx, build, infraConfig)
+	}
+
 	// Determine if the infrastructure provider machine is ready.
 	preReconcileInfrastructureReady := build.Status.InfrastructureReady
 	infraReady, err := external.IsMachineReady(infraConfig)
@@ -338,20 +687,32 @@ func (r *BuildReconciler) reconcileInfrastructure(ctx context.Context, build *bu
 	}
 	build.Status.InfrastructureReady = infraReady
 	// Only record the event if the status has changed
-	if preReconcileInfrastructureReady != build.Status.InfrastructureReady {
-		r.recorder.Eventf(build, corev1.EventTypeNormal, "InfrastructureReady", "Build %s InfrastructureReady is now %t", build.Name, build.Status.InfrastructureReady)
+	if !preReconcileInfrastructureReady && build.Status.InfrastructureReady {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "MachineProvisioned", "%s %q machine is ready", infraConfig.GetKind(), infraConfig.GetName())
 	}
 
-	// Report a summary of current status of the infrastructure object defined for this cluster.
-	conditions.SetMirror(build, buildv1.InfrastructureReadyCondition,
-		conditions.UnstructuredGetter(infraConfig),
-		conditions.WithFallbackValue(infraReady, buildv1.WaitingForInfrastructureFallbackReason, buildv1.ConditionSeverityInfo, ""),
-	)
-
 	if !infraReady {
+		conditions.MarkFalse(build, buildv1.MachineReadyCondition, buildv1.WaitingForMachineReason,
+			"Waiting for %s %q to report the machine as ready", infraConfig.GetKind(), infraConfig.GetName())
 		log.V(3).Info("Infrastructure provider is not ready yet")
 		return ctrl.Result{}, nil
 	}
+	conditions.MarkTrue(build, buildv1.MachineReadyCondition, buildv1.MachineProvisionedReason,
+		"%s %q machine is ready", infraConfig.GetKind(), infraConfig.GetName())
+
+	// The cached provisioners run again if the machine has not been created from the image of spec.from, e.g. when
+	// the infrastructure provider does not support it.
+	if cache := build.Status.Cache; cache != nil && cache.Hits > 0 && !build.Status.Connected {
+		sourceImageRef, err := external.SourceImageRefFrom(infraConfig)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if sourceImageRef != cache.SourceImageRef {
+			r.recorder.Eventf(build, corev1.EventTypeWarning, "CacheInvalidated", "%s %q machine has been created from image %q instead of %s, running all the provisioners",
+				infraConfig.GetKind(), infraConfig.GetName(), sourceImageRef, cache.SourceImageRef)
+			resetCacheHits(build)
+		}
+	}
 
 	// Determine if the infrastructure provider is ready.
 	preReconcileReady := build.Status.Ready
@@ -366,10 +727,39 @@ func (r *BuildReconciler) reconcileInfrastructure(ctx context.Context, build *bu
 	}
 
 	// Report a summary of current status of the infrastructure object defined for this build.
-	conditions.SetMirror(build, buildv1.ReadyCondition,
-		conditions.UnstructuredGetter(infraConfig),
-		conditions.WithFallbackValue(ready, buildv1.WaitingForInfrastructureFallbackReason, buildv1.ConditionSeverityInfo, ""),
-	)
+	fallback := conditions.FalseCondition(buildv1.WaitingForInfrastructureFallbackReason, "Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
+	if ready {
+		fallback = conditions.TrueCondition(buildv1.ReadyReason, "")
+	}
+	conditions.SetMirror(build, buildv1.InfrastructureReadyCondition, infraConfig, fallback)
+
+	imageRef, err := external.ImageRefFrom(infraConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if imageRef != "" {
+		build.Status.ImageRef = imageRef
+	}
+	artifact, err := external.ArtifactFrom(infraConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case artifact != nil:
+		build.Status.Artifact = artifact
+		if build.Status.ImageRef == "" {
+			build.Status.ImageRef = artifact.ID
+		}
+	case imageRef != "":
+		// The provider does not report an artifact yet, only its ID is known.
+		build.Status.Artifact = &buildv1.BuildArtifact{ID: imageRef}
+	}
+
+	replications, err := external.ReplicationsFrom(infraConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.reconcileReplications(build, infraConfig.GetKind(), replications, ready)
 
 	if !ready {
 		log.V(3).Info("build is not ready yet")
@@ -444,15 +834,17 @@ func (r *BuildReconciler) reconcileExternal(ctx context.Context, build *buildv1.
 	if err != nil {
 		return external.ReconcileOutput{}, err
 	}
-	if failureReason != "" {
-		clusterStatusError := forgeerrors.BuildStatusError(failureReason)
-		build.Status.FailureReason = &clusterStatusError
-	}
-	if failureMessage != "" {
-		build.Status.FailureMessage = ptr.To(
+	if failureReason != "" || failureMessage != "" {
+		failure := forgeerrors.FromFailure(failureReason,
 			fmt.Sprintf("Failure detected from referenced resource %v with name %q: %s",
 				obj.GroupVersionKind(), obj.GetName(), failureMessage),
 		)
+		if failureReason != "" {
+			build.Status.FailureReason = ptr.To(forgeerrors.ReasonFor(failure))
+		}
+		if failureMessage != "" {
+			build.Status.FailureMessage = ptr.To(failure.Error())
+		}
 	}
 
 	return external.ReconcileOutput{Result: obj}, nil
@@ -468,15 +860,25 @@ func (r *BuildReconciler) reconcileImageProvided(ctx context.Context, build *bui
 		return ctrl.Result{}, nil
 	}
 
-	if build.Status.Ready && conditions.IsTrue(build, buildv1.ReadyCondition) {
+	if conditions.IsTrue(build, buildv1.ImageExportedCondition) {
 		log.V(4).Info("Skipping reconcileImageProvided because Build already provided")
 		return ctrl.Result{}, nil
 	}
 
-	log.V(4).Info("Checking for image exportation")
-	// TODO, Mark the InfraBuild to export the image.
+	// The infrastructure provider creates the image once the provisioners are ready, and reports it ready.
+	if build.Spec.InfrastructureRef != nil && !build.Status.Ready {
+		log.V(4).Info("Waiting for the infrastructure provider to export the image")
+		conditions.MarkFalse(build, buildv1.ImageExportedCondition, buildv1.WaitingForImageExportReason,
+			"Waiting for %s %q to export the image", build.Spec.InfrastructureRef.Kind, build.Spec.InfrastructureRef.Name)
+		return ctrl.Result{}, nil
+	}
 
-	conditions.MarkTrue(build, buildv1.BuildInitializedCondition)
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	if build.Status.Artifact != nil {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "ArtifactExported", "Exported %s artifact %s", build.Status.Artifact.Format, build.Status.Artifact.ID)
+	} else {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "ArtifactExported", "Exported image %s", build.Status.ImageRef)
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -490,59 +892,107 @@ func (r *BuildReconciler) reconcileConnection(ctx context.Context, build *buildv
 		return ctrl.Result{}, nil
 	}
 
+	// The machine may be stopped by the infrastructure provider to export the image once the provisioners are ready.
+	if build.Status.ProvisionersReady {
+		log.V(4).Info("Skipping reconcileConnection because Provisioners are ready")
+		return ctrl.Result{}, nil
+	}
+
 	if build.Spec.Connector.Credentials == nil {
 		log.V(4).Info("Skipping reconcileConnection because secret is not yet set")
 		return ctrl.Result{}, nil
 	}
 
 	log.V(4).Info("Checking for connection to infrastructure machine")
-	conditions.MarkFalse(build, buildv1.MachineReadyCondition, buildv1.WaitingForConnectionReason, buildv1.ConditionSeverityInfo, "")
-	// TODO, Try to connect to the infrastructure machine with spec.connector.
+	if !conditions.Has(build, buildv1.ConnectedCondition) {
+		conditions.MarkFalse(build, buildv1.ConnectedCondition, buildv1.WaitingForConnectionReason, "Connecting to the infrastructure machine")
+	}
 
-	err := r.tryToConnect(ctx, build)
+	info, err := r.tryToConnect(ctx, build)
 	if err != nil {
-		return ctrl.Result{
-			RequeueAfter: 2 * time.Second,
-		}, errors.Wrap(err, "failed to connect to the machine")
+		err = redact.FromContext(ctx).Error(err)
+		metrics.ConnectionRetries.WithLabelValues(infrastructureKind(build), string(build.Spec.Connector.Type)).Inc()
+		conditions.MarkFalse(build, buildv1.ConnectedCondition, buildv1.ConnectionFailedReason, err.Error())
+		r.recorder.Eventf(build, corev1.EventTypeWarning, buildv1.ConnectionFailedReason, "Failed to connect to the machine: %v", err)
+		return ctrl.Result{}, errors.Wrap(err, "failed to connect to the machine")
 	}
+	build.Status.Connection = connectionStatus(info)
 
-	conditions.MarkTrue(build, buildv1.MachineReadyCondition)
+	conditions.MarkTrue(build, buildv1.ConnectedCondition, buildv1.ConnectionEstablishedReason,
+		"Connected to the infrastructure machine using %s", build.Spec.Connector.Type)
 
 	// Determine if the infrastructure provider machine is ready.
 	preReconcileConnected := build.Status.Connected
 	build.Status.Connected = true
 	// Only record the event if the status has changed
 	if preReconcileConnected != build.Status.Connected {
-		r.recorder.Event(build, corev1.EventTypeNormal, "MachineReady", "Machine connection established")
+		reason := "SSHConnected"
+		if build.Spec.Connector.Type == buildv1.ConnectorTypeDockerExec {
+			reason = "ContainerConnected"
+		}
+		r.recorder.Eventf(build, corev1.EventTypeNormal, reason, "Connected to %s using %s, server %s", info.Address, info.AuthMethod, info.ServerVersion)
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *BuildReconciler) tryToConnect(ctx context.Context, build *buildv1.Build) error {
-	secret := &corev1.Secret{}
-	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.Connector.Credentials.Name}, secret); err != nil {
-		return errors.Wrap(err, "failed to get secret")
+// tryToConnect connects to the infrastructure machine, returning the details of the connection.
+func (r *BuildReconciler) tryToConnect(ctx context.Context, build *buildv1.Build) (ssh.ConnectionInfo, error) {
+	if build.Spec.Connector.Type == buildv1.ConnectorTypeDockerExec {
+		return r.tryToConnectContainer(ctx, build)
 	}
-
-	sshClient, err := ssh.NewSSHClient(secret)
+	sshClient, err := forgeutil.NewSSHClient(ctx, r.Client, build)
 	if err != nil {
-		return errors.Wrap(err, "failed to create SSH client")
+		return ssh.ConnectionInfo{}, err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return ssh.ConnectionInfo{}, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
 	}
-	if err = sshClient.WaitForSSH(SSHTimeout); err != nil {
-		return errors.Wrap(err, "failed to connect to the machine via ssh")
+	if err = sshClient.WaitForSSH(max(SSHTimeout, build.Spec.Connector.GetTimeout())); err != nil {
+		return ssh.ConnectionInfo{}, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
 	}
 	defer sshClient.Disconnect()
 
-	return nil
+	return sshClient.ConnectionInfo(), nil
+}
+
+// tryToConnectContainer runs a command in the container of the Build with the docker-exec connector, the address
+// of the connection is the container on the Docker Engine.
+func (r *BuildReconciler) tryToConnectContainer(ctx context.Context, build *buildv1.Build) (ssh.ConnectionInfo, error) {
+	connector, err := forgeutil.NewDockerConnector(ctx, r.Client, build)
+	if err != nil {
+		return ssh.ConnectionInfo{}, err
+	}
+	defer connector.Disconnect()
+	version, err := connector.WaitForContainer(ctx, max(SSHTimeout, build.Spec.Connector.GetTimeout()))
+	if err != nil {
+		return ssh.ConnectionInfo{}, errors.Wrap(err, "failed to connect to the container")
+	}
+	return ssh.ConnectionInfo{
+		Address:       fmt.Sprintf("%s@%s", connector.Container, connector.Client.Endpoint),
+		AuthMethod:    buildv1.ConnectorTypeDockerExec,
+		ServerVersion: fmt.Sprintf("Docker/%s (API %s)", version.Version, version.APIVersion),
+	}, nil
+}
+
+// connectionStatus returns the status of a connection, the credentials it has been established with are not reported.
+func connectionStatus(info ssh.ConnectionInfo) *buildv1.ConnectionStatus {
+	return &buildv1.ConnectionStatus{
+		Address:            info.Address,
+		AuthMethod:         info.AuthMethod,
+		HostKeyType:        info.HostKeyType,
+		HostKeyFingerprint: info.HostKeyFingerprint,
+		ServerVersion:      info.ServerVersion,
+	}
 }
 
 // reconcileProvisioners reconciles the provisioners for the Build.
 func (r *BuildReconciler) reconcileProvisioners(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 
-	// Skip checking if the Infrastructure not ready.
-	if !build.Status.Connected {
+	// Skip checking if the Infrastructure not ready, the provisioners are checked right away when simulating
+	// and planned right away in dry-run mode.
+	if !build.Status.Connected && !build.Spec.Rehearsal() {
 		log.V(4).Info("Skipping reconcileProvisioners because the infrastructure machine is not connected yet")
 		return ctrl.Result{}, nil
 	}
@@ -553,19 +1003,128 @@ func (r *BuildReconciler) reconcileProvisioners(ctx context.Context, build *buil
 	}
 
 	log.V(4).Info("Checking for provisioners")
-	conditions.MarkFalse(build, buildv1.ProvisionersReadyCondition, buildv1.WaitingForProvisionersReason, buildv1.ConditionSeverityInfo, "")
+	// The Job of a provisioner removed from the Build would block the Jobs of the other provisioners.
+	if err := shellcontroller.DeleteStaleJobs(ctx, r.Client, build); err != nil {
+		return ctrl.Result{}, err
+	}
+	defer forgeutil.SetProvisionerConditions(build)
+	defer r.recordProvisionerEvents(build, provisionerStatuses(build))
+	// The outcomes of the provisioners are masked before they are reported in the events and the conditions.
+	defer redact.FromContext(ctx).Build(build)
+	if !conditions.Has(build, buildv1.ProvisionersReadyCondition) {
+		conditions.MarkFalse(build, buildv1.ProvisionersReadyCondition, buildv1.WaitingForProvisionersReason,
+			"Waiting for %d provisioner(s) to complete", len(build.Spec.Provisioners))
+	}
 
-	for i := range build.Spec.Provisioners {
-		// TODO, Run the external provisioner.
-		//if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeExternal {
-		//	// add  ownerRef to the provisioner resource.
-		//	// watch the resource,
-		//	// reconcileExternal similar to infrastructure.
-		//}
-
-		// Builtin Provisioner
-		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeShell {
-			res, err := shellcontroller.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i])
+	executionOrder, err := buildv1.ProvisionerExecutionOrder(build.Spec.Provisioners)
+	if err != nil {
+		return ctrl.Result{}, forgeerrors.NewConfigError(err)
+	}
+	if build.Spec.DryRun {
+		if err := shellcontroller.RecordObjectPlan(ctx, r.Client, build, shellcontroller.ConnectorPlanKey, build.Spec.Connector); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	awaitingApproval := build.Status.AwaitingApproval
+	build.Status.AwaitingApproval = ""
+	// running is the requeue of the provisioners still running, the later provisioners of their concurrency group
+	// start alongside them once the provisioners they depend on completed.
+	var running ctrl.Result
+	runningGroup := ""
+	for n, i := range executionOrder {
+		// The cached provisioners already ran on the image the machine has been created from.
+		if build.Status.Cache != nil && n < int(build.Status.Cache.Hits) {
+			continue
+		}
+		if !running.IsZero() {
+			if runningGroup == "" || build.Spec.Provisioners[i].ConcurrencyGroup != runningGroup {
+				return running, nil
+			}
+			if !dependenciesSucceeded(build, &build.Spec.Provisioners[i]) {
+				continue
+			}
+		}
+
+		if r.waitForApproval(build, &build.Spec.Provisioners[i], awaitingApproval) {
+			// The later provisioners don't run until the provisioner is approved.
+			return running, nil
+		}
+
+		// The provisioners which don't run in a Job are recorded as is in the plan of the Build.
+		if build.Spec.DryRun && !build.Spec.Provisioners[i].Type.RunsInJob() && build.Spec.Provisioners[i].Type != buildv1.ProvisionerTypeExternal {
+			p := &build.Spec.Provisioners[i]
+			if err := r.planProvisioner(ctx, build, p, ".yaml", func() interface{} { return p }); err != nil {
+				return ctrl.Result{}, err
+			}
+			continue
+		}
+
+		if build.Spec.Simulate && !build.Spec.Provisioners[i].Type.RunsInJob() {
+			p := &build.Spec.Provisioners[i]
+			if p.Status == nil || *p.Status != buildv1.ProvisionerStatusCompleted {
+				p.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+				p.Issues = []string{"Only the provisioners running in a Job are checked in simulation mode"}
+			}
+			continue
+		}
+
+		// Builtin Provisioner, the ansible provisioners run in the Jobs of the shell provisioners with their own image,
+		// the chef-solo and puppet-apply provisioners run as the steps of a shell provisioner.
+		if build.Spec.Provisioners[i].Type.RunsInJob() {
+			image := r.ShellProvisionerImage
+			if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeAnsible {
+				image.Image = r.AnsibleProvisionerImage
+			}
+			res, err := shellcontroller.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i], image, r.ShellProvisionerPlacement)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if res.Requeue || res.RequeueAfter > 0 {
+				running, runningGroup = earliestResult(running, res), build.Spec.Provisioners[i].ConcurrencyGroup
+				continue
+			}
+		}
+
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeVerify {
+			if _, err := verify.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i]); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeHarden {
+			if _, err := harden.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i]); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeFile {
+			if _, err := fileprovisioner.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i]); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeExtract {
+			if _, err := extract.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i]); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		// The external provisioners requeue the Build while the Job or the webhook of their ProvisionerClass runs them.
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeExternal {
+			res, err := r.reconcileExternalProvisioner(ctx, build, &build.Spec.Provisioners[i])
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if res.Requeue || res.RequeueAfter > 0 {
+				running, runningGroup = earliestResult(running, res), build.Spec.Provisioners[i].ConcurrencyGroup
+				continue
+			}
+		}
+
+		// The powershell provisioners requeue the Build while their scripts run and the machine reboots.
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypePowerShell {
+			res, err := powershell.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i])
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -573,20 +1132,33 @@ func (r *BuildReconciler) reconcileProvisioners(ctx context.Context, build *buil
 				return res, nil
 			}
 		}
-	}
 
-	provisionersReady := true
-	for _, p := range build.Spec.Provisioners {
-		status := ptr.Deref(p.Status, buildv1.ProvisionerStatusUnknown)
-		if status != buildv1.ProvisionerStatusCompleted &&
-			!(status == buildv1.ProvisionerStatusFailed && p.AllowFail) {
-			provisionersReady = false
-			break
+		// The restart provisioners requeue the Build while the machine reboots.
+		if build.Spec.Provisioners[i].Type == buildv1.ProvisionerTypeRestart {
+			res, err := restart.Reconcile(ctx, r.Client, build, &build.Spec.Provisioners[i])
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if res.Requeue || res.RequeueAfter > 0 {
+				return res, nil
+			}
 		}
 	}
 
-	if provisionersReady {
-		conditions.MarkTrue(build, buildv1.ProvisionersReadyCondition)
+	if !running.IsZero() {
+		return running, nil
+	}
+
+	if forgeutil.ProvisionersSucceeded(build) {
+		// The SBOM is generated from and the scan gates the provisioned machine, before its image is created.
+		if err := r.reconcileSBOM(ctx, build); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileScan(ctx, build); err != nil {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkTrue(build, buildv1.ProvisionersReadyCondition, buildv1.ProvisionersSucceededReason,
+			"%d provisioner(s) completed", len(build.Spec.Provisioners))
 		r.recorder.Event(build, corev1.EventTypeNormal, "ProvisionersReady", "Provisioners are ready")
 		build.Status.ProvisionersReady = true
 	}
@@ -594,18 +1166,92 @@ func (r *BuildReconciler) reconcileProvisioners(ctx context.Context, build *buil
 	return ctrl.Result{}, nil
 }
 
+// provisionerStatuses returns the statuses of the provisioners of the Build.
+func provisionerStatuses(build *buildv1.Build) []buildv1.ProvisionerStatus {
+	statuses := make([]buildv1.ProvisionerStatus, len(build.Spec.Provisioners))
+	for i, p := range build.Spec.Provisioners {
+		statuses[i] = ptr.Deref(p.Status, "")
+	}
+	return statuses
+}
+
+// recordProvisionerEvents emits the events of the provisioners whose status changed from the previous statuses, the
+// outcome of the provisioners run in a Job is reported by the ShellJob controller.
+func (r *BuildReconciler) recordProvisionerEvents(build *buildv1.Build, previous []buildv1.ProvisionerStatus) {
+	for i := range build.Spec.Provisioners {
+		if i < len(previous) {
+			forgeutil.RecordProvisionerEvent(r.recorder, build, &build.Spec.Provisioners[i], previous[i])
+		}
+	}
+}
+
+// dependenciesSucceeded returns true if the provisioners the provisioner depends on succeeded.
+func dependenciesSucceeded(build *buildv1.Build, p *buildv1.ProvisionerSpec) bool {
+	for _, name := range p.DependsOn {
+		for i := range build.Spec.Provisioners {
+			if build.Spec.Provisioners[i].Name == name && !forgeutil.ProvisionerSucceeded(&build.Spec.Provisioners[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// earliestResult returns the result requeuing the Build first.
+func earliestResult(a, b ctrl.Result) ctrl.Result {
+	switch {
+	case a.IsZero():
+		return b
+	case b.IsZero():
+		return a
+	case a.RequeueAfter == 0 || b.RequeueAfter == 0:
+		// A requeue without delay happens right away.
+		return ctrl.Result{Requeue: true}
+	case b.RequeueAfter < a.RequeueAfter:
+		return b
+	}
+	return a
+}
+
+// reconcileSimulation completes a simulated Build once its provisioners have been checked.
+func (r *BuildReconciler) reconcileSimulation(_ context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if !build.Status.ProvisionersReady || conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		return ctrl.Result{}, nil
+	}
+
+	issues := 0
+	for _, p := range build.Spec.Provisioners {
+		issues += len(p.Issues)
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.SimulatedReason,
+		"Simulation completed with %d issue(s), the provisioner Jobs are rendered in ConfigMap %s",
+		issues, shellcontroller.SimulationConfigMapName(build.Name))
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.SimulatedReason,
+		"No image is exported in simulation mode")
+	return ctrl.Result{}, nil
+}
+
 type buildDescendants struct {
 	infraBuild   unstructured.UnstructuredList
 	provisioners unstructured.UnstructuredList
+	builds       buildv1.BuildList
+	jobs         batchv1.JobList
+	secrets      corev1.SecretList
+	configMaps   corev1.ConfigMapList
 }
 
 // length returns the number of descendants.
 func (c *buildDescendants) length() int {
 	return len(c.infraBuild.Items) +
-		len(c.provisioners.Items)
+		len(c.provisioners.Items) +
+		len(c.builds.Items) +
+		len(c.jobs.Items) +
+		len(c.secrets.Items) +
+		len(c.configMaps.Items)
 }
 
-// listDescendants returns a list of all InfraBuilds, and Provisioners for the Build.
+// listDescendants returns a list of all InfraBuilds, Provisioners, Builds of the architectures, export Jobs,
+// Secrets and ConfigMaps for the Build.
 func (r *BuildReconciler) listDescendants(ctx context.Context, build *buildv1.Build) (buildDescendants, error) {
 	var descendants buildDescendants
 
@@ -614,12 +1260,32 @@ func (r *BuildReconciler) listDescendants(ctx context.Context, build *buildv1.Bu
 		client.MatchingLabels(map[string]string{buildv1.BuildNameLabel: build.Name}),
 	}
 
-	// retrieve InfraBuild
-	infraBuildGVK := build.Spec.InfrastructureRef.GroupVersionKind()
-	descendants.infraBuild.SetGroupVersionKind(infraBuildGVK)
-	err := r.List(ctx, &descendants.infraBuild, listOptions...)
+	// retrieve InfraBuild, the Build may be deleted before its BuildTemplate has been instantiated.
+	if build.Spec.InfrastructureRef != nil {
+		infraBuildGVK := build.Spec.InfrastructureRef.GroupVersionKind()
+		descendants.infraBuild.SetGroupVersionKind(infraBuildGVK)
+		err := r.List(ctx, &descendants.infraBuild, listOptions...)
+		if err != nil {
+			return descendants, errors.Wrapf(err, "failed to list objects with kind '%s'", infraBuildGVK.Kind)
+		}
+	}
+
+	// retrieve the Builds of the architectures
+	err := r.List(ctx, &descendants.builds, client.InNamespace(build.Namespace),
+		client.MatchingLabels{buildv1.ParentBuildNameLabel: build.Name})
 	if err != nil {
-		return descendants, errors.Wrapf(err, "failed to list objects with kind '%s'", infraBuildGVK.Kind)
+		return descendants, errors.Wrap(err, "failed to list the Builds of the architectures")
+	}
+
+	// retrieve the export Jobs, the variables, workspace and credentials Secrets and the simulation and plan ConfigMaps.
+	// Only the objects owned by the Build are kept, the deletion is not blocked by objects it cannot delete.
+	for _, list := range []client.ObjectList{&descendants.jobs, &descendants.secrets, &descendants.configMaps} {
+		if err := r.List(ctx, list, listOptions...); err != nil {
+			return descendants, errors.Wrapf(err, "failed to list %T", list)
+		}
+		if err := filterOwnedBy(list, build); err != nil {
+			return descendants, err
+		}
 	}
 
 	// retrieve Provisioners
@@ -660,6 +1326,10 @@ func (c buildDescendants) filterOwnedDescendants(build *buildv1.Build) ([]client
 
 	lists := []client.ObjectList{
 		&c.provisioners,
+		&c.builds,
+		&c.jobs,
+		&c.secrets,
+		&c.configMaps,
 		&c.infraBuild,
 	}
 
@@ -672,6 +1342,21 @@ func (c buildDescendants) filterOwnedDescendants(build *buildv1.Build) ([]client
 	return ownedDescendants, nil
 }
 
+// filterOwnedBy removes the items of the list which are not owned by the Build.
+func filterOwnedBy(list client.ObjectList, build *buildv1.Build) error {
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract the items of %T", list)
+	}
+	owned := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(client.Object); ok && util.IsOwnedByObject(obj, build) {
+			owned = append(owned, item)
+		}
+	}
+	return meta.SetList(list, owned)
+}
+
 func (c *buildDescendants) descendantNames() string {
 	descendants := make([]string, 0)
 	infraBuildNames := make([]string, len(c.infraBuild.Items))
@@ -688,6 +1373,25 @@ func (c *buildDescendants) descendantNames() string {
 	if len(provisionersNames) > 0 {
 		descendants = append(descendants, "Provisioners: "+strings.Join(provisionersNames, ","))
 	}
+	lists := []struct {
+		kind string
+		list client.ObjectList
+	}{
+		{kind: "Builds", list: &c.builds},
+		{kind: "Jobs", list: &c.jobs},
+		{kind: "Secrets", list: &c.secrets},
+		{kind: "ConfigMaps", list: &c.configMaps},
+	}
+	for _, l := range lists {
+		names := []string{}
+		_ = meta.EachListItem(l.list, func(o runtime.Object) error {
+			names = append(names, o.(client.Object).GetName())
+			return nil
+		})
+		if len(names) > 0 {
+			descendants = append(descendants, l.kind+": "+strings.Join(names, ","))
+		}
+	}
 
 	return strings.Join(descendants, ";")
 }
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// publicKeyKey is the key of the public key in the ephemeral credentials secrets.
+const publicKeyKey = "publicKey"
+
+// reconcileEphemeralCredentials generates the key pair of a Build with ephemeral credentials before its machine is
+// created, in a credentials secret owned by the Build. The infrastructure provider authorizes its public key on the
+// machine, completing the secret with the user it authorized the key for.
+func (r *BuildReconciler) reconcileEphemeralCredentials(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if !build.Spec.Connector.EphemeralCredentials || isFinished(build) {
+		return ctrl.Result{}, nil
+	}
+	if build.Spec.Connector.Credentials == nil {
+		build.Spec.Connector.Credentials = &corev1.LocalObjectReference{Name: fmt.Sprintf("%s-ssh-credentials", build.Name)}
+	}
+	name := build.Spec.Connector.Credentials.Name
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get the connector credentials secret %s", name)
+	case !isEphemeralCredentials(build, secret):
+		// A secret not generated for this Build is never shredded.
+		return ctrl.Result{}, forgeerrors.ConfigErrorf("the connector credentials secret %s already exists and was not generated for Build %s", name, build.Name)
+	default:
+		return ctrl.Result{}, nil
+	}
+
+	keyPair, err := ssh.NewKeyPair()
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to generate the ephemeral key pair")
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: build.Namespace,
+			Name:      name,
+			Labels: map[string]string{
+				buildv1.BuildNameLabel:            build.Name,
+				buildv1.EphemeralCredentialsLabel: "true",
+			},
+		},
+		Type: buildv1.BuildSecretType,
+		Data: map[string][]byte{
+			providersdk.PrivateKeyKey: keyPair.PrivateKey,
+			publicKeyKey:              keyPair.PublicKey,
+		},
+	}
+	if username := build.Spec.Connector.Username; username != "" {
+		secret.Data[providersdk.UsernameKey] = []byte(username)
+	}
+	if err := controllerutil.SetControllerReference(build, secret, r.Client.Scheme()); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Client.Create(ctx, secret); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to create the connector credentials secret %s", name)
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "EphemeralCredentialsGenerated", "Generated an ephemeral key pair in secret %s", name)
+	return ctrl.Result{}, nil
+}
+
+// shredEphemeralCredentials erases the key pair of a finished Build with ephemeral credentials and deletes its
+// secret, the connector no longer connects to the machine.
+func (r *BuildReconciler) shredEphemeralCredentials(ctx context.Context, build *buildv1.Build) error {
+	if !build.Spec.Connector.EphemeralCredentials || build.Spec.Connector.Credentials == nil {
+		return nil
+	}
+	name := build.Spec.Connector.Credentials.Name
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !isEphemeralCredentials(build, secret) {
+		return nil
+	}
+
+	// The data is erased first, the secret may outlive the deletion request, e.g. held by a finalizer.
+	if len(secret.Data) > 0 {
+		secret.Data = nil
+		if err := r.Client.Update(ctx, secret); err != nil {
+			return errors.Wrapf(err, "failed to erase the connector credentials secret %s", name)
+		}
+	}
+	if err := r.Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the connector credentials secret %s", name)
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "EphemeralCredentialsShredded", "Shredded the ephemeral key pair in secret %s", name)
+	return nil
+}
+
+// isEphemeralCredentials returns true if the secret holds the ephemeral credentials generated for the Build.
+func isEphemeralCredentials(build *buildv1.Build, secret *corev1.Secret) bool {
+	return secret.Labels[buildv1.EphemeralCredentialsLabel] == "true" &&
+		secret.Labels[buildv1.BuildNameLabel] == build.Name &&
+		metav1.IsControlledBy(secret, build)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileEphemeralCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Type: "ssh", EphemeralCredentials: true},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	// A key pair is generated in a secret owned by the Build.
+	_, err := r.reconcileEphemeralCredentials(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Spec.Connector.Credentials).To(HaveValue(Equal(corev1.LocalObjectReference{Name: "ubuntu-ssh-credentials"})))
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: "images", Name: "ubuntu-ssh-credentials"}
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+	g.Expect(secret.Labels).To(HaveKeyWithValue(buildv1.EphemeralCredentialsLabel, "true"))
+	g.Expect(metav1.IsControlledBy(secret, build)).To(BeTrue())
+	g.Expect(secret.Data).To(HaveKey(providersdk.PrivateKeyKey))
+	g.Expect(secret.Data).To(HaveKey(publicKeyKey))
+	g.Expect(secret.Data).ToNot(HaveKey(providersdk.UsernameKey))
+	g.Expect(<-recorder.Events).To(ContainSubstring("Generated an ephemeral key pair"))
+
+	// The key pair is kept until the Build has finished.
+	privateKey := secret.Data[providersdk.PrivateKeyKey]
+	_, err = r.reconcileEphemeralCredentials(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+	g.Expect(secret.Data[providersdk.PrivateKeyKey]).To(Equal(privateKey))
+	g.Expect(r.shredEphemeralCredentials(ctx, build)).To(Succeed())
+
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	g.Expect(isFinished(build)).To(BeTrue())
+	g.Expect(r.shredEphemeralCredentials(ctx, build)).To(Succeed())
+	g.Expect(c.Get(ctx, key, secret)).ToNot(Succeed())
+	g.Expect(<-recorder.Events).To(ContainSubstring("Shredded the ephemeral key pair"))
+}
+
+func TestReconcileEphemeralCredentialsExistingSecret(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-ssh", Namespace: "images"},
+		Data:       map[string][]byte{providersdk.PasswordKey: []byte("secret")},
+	}
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{
+				Type:                 "ssh",
+				Credentials:          &corev1.LocalObjectReference{Name: "shared-ssh"},
+				EphemeralCredentials: true,
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	// A secret not generated for the Build is neither overwritten nor shredded.
+	_, err := r.reconcileEphemeralCredentials(ctx, build)
+	g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	g.Expect(r.shredEphemeralCredentials(ctx, build)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+	g.Expect(secret.Data).To(HaveKey(providersdk.PasswordKey))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	forgeutil "github.com/forge-build/forge/util"
+)
+
+func TestReconcileDelete(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		TypeMeta: metav1.TypeMeta{APIVersion: buildv1.GroupVersion.String(), Kind: "Build"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ubuntu", Namespace: "images", UID: "build-uid",
+			Finalizers: []string{buildv1.BuildFinalizer},
+		},
+	}
+	labels := map[string]string{buildv1.BuildNameLabel: "ubuntu"}
+	owned := metav1.ObjectMeta{Namespace: "images", Labels: labels,
+		OwnerReferences: []metav1.OwnerReference{forgeutil.BuildOwnerReference(build)}}
+	withName := func(meta metav1.ObjectMeta, name string) metav1.ObjectMeta {
+		meta.Name = name
+		return meta
+	}
+
+	credentials := &corev1.Secret{ObjectMeta: withName(owned, "ubuntu-ssh-credentials")}
+	simulation := &corev1.ConfigMap{ObjectMeta: withName(owned, "ubuntu-simulation")}
+	export := &batchv1.Job{ObjectMeta: withName(owned, "ubuntu-export-ova")}
+	architecture := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "images", Name: "ubuntu-arm64",
+		Labels:          map[string]string{buildv1.ParentBuildNameLabel: "ubuntu"},
+		OwnerReferences: []metav1.OwnerReference{forgeutil.BuildOwnerReference(build)},
+	}}
+	// Objects labeled with the name of the Build but not owned by it are left untouched.
+	unowned := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "registry", Labels: labels}}
+	shellJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Namespace: shellcontroller.ForgeCoreNamespace, Name: "shell-provisioner",
+		Labels: map[string]string{buildv1.BuildNameLabel: "ubuntu", buildv1.BuildNamespaceLabel: "images"},
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build.DeepCopy(), credentials, simulation, export, architecture, unowned, shellJob).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	res, err := r.reconcileDelete(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deleteRequeueAfter))
+	g.Expect(build.Finalizers).To(ContainElement(buildv1.BuildFinalizer))
+
+	for _, obj := range []client.Object{credentials, simulation, export, architecture, shellJob} {
+		err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%T %s has not been deleted", obj, obj.GetName())
+	}
+	g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(unowned), unowned)).To(Succeed())
+
+	// The finalizer is removed once the descendants are gone.
+	res, err = r.reconcileDelete(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeZero())
+	g.Expect(build.Finalizers).To(BeEmpty())
+}
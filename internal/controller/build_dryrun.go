@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// planProvisioner completes a provisioner of a Build run in dry-run mode without running it. The object returned by
+// plan, e.g. the provisioner itself or the request sent to the webhook of its class, is recorded in the plan of the
+// Build under the key of the provisioner with the given suffix.
+func (r *BuildReconciler) planProvisioner(ctx context.Context, build *buildv1.Build, spec *buildv1.ProvisionerSpec, suffix string, plan func() interface{}) error {
+	if ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending) == buildv1.ProvisionerStatusCompleted {
+		return nil
+	}
+	if spec.UUID == nil {
+		spec.UUID = ptr.To(uuid.New().String())
+	}
+	if err := shellcontroller.RecordObjectPlan(ctx, r.Client, build, shellcontroller.PlanKey(spec)+suffix, plan()); err != nil {
+		return err
+	}
+	spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	return nil
+}
+
+// reconcileDryRun completes a Build run in dry-run mode once the plan of its provisioners has been rendered.
+func (r *BuildReconciler) reconcileDryRun(_ context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if !build.Status.ProvisionersReady || conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		return ctrl.Result{}, nil
+	}
+
+	r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.DryRunReason,
+		"Dry run completed, the plan of %d provisioner(s) is rendered in ConfigMap %s",
+		len(build.Spec.Provisioners), shellcontroller.PlanConfigMapName(build.Name))
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.DryRunReason,
+		"No image is exported in dry-run mode")
+	return ctrl.Result{}, nil
+}
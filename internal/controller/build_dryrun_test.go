@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec: buildv1.BuildSpec{
+			DryRun: true,
+			Connector: buildv1.ConnectorSpec{
+				Type:        buildv1.ConnectorTypeSSH,
+				Username:    "ubuntu",
+				Credentials: &corev1.LocalObjectReference{Name: "ubuntu-ssh"},
+			},
+			Provisioners: []buildv1.ProvisionerSpec{
+				{Name: "packages", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("apt-get install -y nginx"), RequireApproval: true},
+				{Name: "reboot", Type: buildv1.ProvisionerTypeRestart},
+			},
+		},
+	}
+
+	// The provisioners complete right away without running, neither approved nor connected.
+	_, err := r.reconcileProvisioners(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.ProvisionersReady).To(BeTrue())
+	for _, p := range build.Spec.Provisioners {
+		g.Expect(p.Status).To(Equal(ptr.To(buildv1.ProvisionerStatusCompleted)))
+	}
+	jobs := &batchv1.JobList{}
+	g.Expect(c.List(context.Background(), jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(BeEmpty())
+
+	plan := &corev1.ConfigMap{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: shellcontroller.PlanConfigMapName("ubuntu")}, plan)).To(Succeed())
+	g.Expect(plan.Data).To(HaveKeyWithValue(shellcontroller.ConnectorPlanKey, ContainSubstring("name: ubuntu-ssh")))
+	g.Expect(plan.Data).To(HaveKeyWithValue("packages-job.yaml", And(ContainSubstring("kind: Job"), ContainSubstring("apt-get install -y nginx"))))
+	g.Expect(plan.Data).To(HaveKeyWithValue("reboot.yaml", ContainSubstring("type: built-in/restart")))
+
+	_, err = r.reconcileDryRun(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conditions.GetReason(build, buildv1.ImageExportedCondition)).To(Equal(buildv1.DryRunReason))
+}
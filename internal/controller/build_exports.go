@@ -0,0 +1,293 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter"
+	exporterjob "github.com/forge-build/forge/exporter/job"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// boxVersionFormat is the format of the default version of the Vagrant boxes and of the default tag of the OCI
+// artifacts, the creation time of the Build.
+const boxVersionFormat = "20060102.150405"
+
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+
+// reconcileExports runs a Job per export of the Build once its image has been exported, and reports
+// their progress in the status of the Build. A failed export fails the Build.
+func (r *BuildReconciler) reconcileExports(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if len(build.Spec.Exports) == 0 || !conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		return ctrl.Result{}, nil
+	}
+	if conditions.IsTrue(build, buildv1.ExportsPublishedCondition) {
+		log.V(4).Info("Skipping reconcileExports because the exports are published")
+		return ctrl.Result{}, nil
+	}
+	if !conditions.Has(build, buildv1.ExportsPublishedCondition) {
+		conditions.MarkFalse(build, buildv1.ExportsPublishedCondition, buildv1.WaitingForExportsReason,
+			"Waiting for %d export(s) to be published", len(build.Spec.Exports))
+	}
+	if build.Status.Artifact == nil || build.Status.Artifact.Location == "" {
+		conditions.MarkFalse(build, buildv1.ExportsPublishedCondition, buildv1.ExportFailedReason,
+			"The infrastructure provider reported no image location to export")
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError,
+			"the exports of Build %s require the infrastructure provider to report the location of the image in status.artifact", build.Name)
+	}
+
+	published := 0
+	for i := range build.Spec.Exports {
+		spec := &build.Spec.Exports[i]
+		status := setExportStatus(build, spec.Name)
+		if status.Phase == buildv1.ExportPhasePending {
+			if err := r.createExportJob(ctx, build, spec, status); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if status.Phase == buildv1.ExportPhaseRunning {
+			if err := r.observeExportJob(ctx, build, spec, status); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		switch status.Phase {
+		case buildv1.ExportPhaseSucceeded:
+			published++
+		case buildv1.ExportPhaseFailed:
+			message := ptr.Deref(status.FailureMessage, "unknown")
+			conditions.MarkFalse(build, buildv1.ExportsPublishedCondition, buildv1.ExportFailedReason,
+				"Export %s failed: %s", spec.Name, message)
+			return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "export %s failed: %s", spec.Name, message)
+		}
+	}
+
+	if published < len(build.Spec.Exports) {
+		// The Build is reconciled again when the export Jobs change.
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkTrue(build, buildv1.ExportsPublishedCondition, buildv1.ExportsPublishedReason,
+		"%d export(s) published", published)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, buildv1.ExportsPublishedReason, "%d export(s) published", published)
+	return ctrl.Result{}, nil
+}
+
+// createExportJob creates the Job of an export, owned by the Build.
+func (r *BuildReconciler) createExportJob(ctx context.Context, build *buildv1.Build, spec *buildv1.ExportSpec, status *buildv1.ExportStatus) error {
+	builder := exporterjob.NewExportJobBuilder(build, spec).
+		WithImage(r.exporterImage()).
+		WithBoxVersion(boxVersion(build, spec)).
+		WithOCITags(ociTags(build, spec))
+	if build.Spec.Artifact != nil && build.Spec.Artifact.Signing != nil {
+		provenance, err := provenanceFor(build)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate the provenance of export %s", spec.Name)
+		}
+		builder.WithProvenance(provenance)
+	}
+	job, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(build, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Client.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create the Job of export %s", spec.Name)
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Created export Job", "export", spec.Name, "job", job.Name)
+	status.Phase = buildv1.ExportPhaseRunning
+	status.JobName = job.Name
+	return nil
+}
+
+// observeExportJob updates the status of an export from its Job.
+func (r *BuildReconciler) observeExportJob(ctx context.Context, build *buildv1.Build, spec *buildv1.ExportSpec, status *buildv1.ExportStatus) error {
+	job := &batchv1.Job{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: status.JobName}, job); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The Job may not be in the cache yet, it is observed when it is.
+			return nil
+		}
+		return err
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			result, err := r.exportResult(ctx, job)
+			if err != nil {
+				return err
+			}
+			status.Phase = buildv1.ExportPhaseSucceeded
+			status.URL = cmp.Or(result.URL, exportURL(build, spec))
+			status.Checksum = result.Checksum
+			if result.SizeBytes > 0 {
+				status.SizeBytes = ptr.To(result.SizeBytes)
+			}
+			status.Signature = result.Signature
+			status.Attestation = result.Attestation
+			status.SBOM = result.SBOM
+			status.CompletionTime = ptr.To(metav1.Now())
+			r.recorder.Eventf(build, corev1.EventTypeNormal, "ExportSucceeded", "Export %s published to %s", spec.Name, status.URL)
+		case batchv1.JobFailed:
+			status.Phase = buildv1.ExportPhaseFailed
+			status.FailureMessage = ptr.To(fmt.Sprintf("Job %s failed: %s, see the logs of its Pods", job.Name, c.Message))
+			status.CompletionTime = ptr.To(metav1.Now())
+			r.recorder.Eventf(build, corev1.EventTypeWarning, buildv1.ExportFailedReason, "Export %s failed: %s", spec.Name, *status.FailureMessage)
+		}
+	}
+	return nil
+}
+
+// exportResult returns the Result reported by the exporter in the termination message of the succeeded Pod of a
+// complete export Job, empty if the Pod is gone or the message is not a Result, e.g. from an older exporter.
+func (r *BuildReconciler) exportResult(ctx context.Context, job *batchv1.Job) (*exporter.Result, error) {
+	result := &exporter.Result{}
+	if job.Spec.Selector == nil {
+		return result, nil
+	}
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	pods := &corev1.PodList{}
+	if err := reader.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels(job.Spec.Selector.MatchLabels)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Pods of Job %s", job.Name)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, container := range pod.Status.ContainerStatuses {
+			terminated := container.State.Terminated
+			if container.Name != exporterjob.ContainerName || terminated == nil {
+				continue
+			}
+			if err := json.Unmarshal([]byte(terminated.Message), result); err != nil {
+				ctrl.LoggerFrom(ctx).V(4).Info("Ignoring the termination message of the export Pod", "pod", pod.Name, "reason", err.Error())
+				return &exporter.Result{}, nil
+			}
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+func (r *BuildReconciler) exporterImage() string {
+	if r.ExporterImage == "" {
+		return exporterjob.DefaultImage
+	}
+	return r.ExporterImage
+}
+
+// setExportStatus returns the status of the export with the given name, adding a Pending status if there is none.
+func setExportStatus(build *buildv1.Build, name string) *buildv1.ExportStatus {
+	for i := range build.Status.Exports {
+		if build.Status.Exports[i].Name == name {
+			return &build.Status.Exports[i]
+		}
+	}
+	build.Status.Exports = append(build.Status.Exports, buildv1.ExportStatus{Name: name, Phase: buildv1.ExportPhasePending})
+	return &build.Status.Exports[len(build.Status.Exports)-1]
+}
+
+// boxVersion returns the version the Vagrant box of an export is published as.
+func boxVersion(build *buildv1.Build, spec *buildv1.ExportSpec) string {
+	if spec.Vagrant != nil && spec.Vagrant.Version != "" {
+		return spec.Vagrant.Version
+	}
+	return build.CreationTimestamp.UTC().Format(boxVersionFormat)
+}
+
+// ociTags returns the tags the disk of an export is pushed to an OCI registry with.
+func ociTags(build *buildv1.Build, spec *buildv1.ExportSpec) []string {
+	if registry := spec.Destination.OCIRegistry; registry != nil && len(registry.Tags) > 0 {
+		return registry.Tags
+	}
+	return []string{build.CreationTimestamp.UTC().Format(boxVersionFormat)}
+}
+
+// exportURL returns where an export is published: the version of the box in a registry, the box catalog
+// in an object storage, the OVA or disk file, or the tag of the OCI artifact. It is used when the exporter reported
+// no URL.
+func exportURL(build *buildv1.Build, spec *buildv1.ExportSpec) string {
+	if registry := spec.Destination.BoxRegistry; registry != nil {
+		return publish.VersionURL(registry.GetURL(), spec.Vagrant.BoxName, boxVersion(build, spec))
+	}
+	if destination := spec.Destination.AzureBlob; destination != nil {
+		containerURL, prefix, err := publish.ParseAzureBlobURL(destination.URL)
+		if err != nil {
+			return ""
+		}
+		container, err := publish.NewAzureBlob(containerURL, "")
+		if err != nil {
+			return ""
+		}
+		return container.BlobURL(exporter.ArtifactKey(prefix, build.Name, spec.Name, spec.Format))
+	}
+	if destination := spec.Destination.OCIRegistry; destination != nil {
+		return destination.Repository + ":" + ociTags(build, spec)[0]
+	}
+	if destination := spec.Destination.HTTP; destination != nil {
+		return publish.NewHTTPEndpoint(destination.URL).FileURL(exporter.ArtifactKey("", build.Name, spec.Name, spec.Format))
+	}
+
+	destination := spec.Destination.ObjectStorage
+	bucket, prefix, err := publish.ParseURL(destination.URL)
+	if err != nil {
+		return ""
+	}
+	key := exporter.ArtifactKey(prefix, build.Name, spec.Name, spec.Format)
+	if spec.Format == buildv1.ExportFormatVagrantBox {
+		key = exporter.CatalogKey(prefix, spec.Vagrant.BoxName)
+	}
+	storage, err := publish.NewObjectStorage(destination.Endpoint, destination.Region, publish.Credentials{})
+	if err != nil {
+		return fmt.Sprintf("s3://%s/%s", bucket, key)
+	}
+	return storage.ObjectURL(bucket, key)
+}
+
+// exportsPublished returns true if the Build has no export to publish, or if all of them have been published.
+func exportsPublished(build *buildv1.Build) bool {
+	return len(build.Spec.Exports) == 0 || build.Spec.Rehearsal() || conditions.IsTrue(build, buildv1.ExportsPublishedCondition)
+}
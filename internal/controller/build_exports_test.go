@@ -0,0 +1,273 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	exporterjob "github.com/forge-build/forge/exporter/job"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func newExportingBuild() *buildv1.Build {
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "ubuntu",
+			Namespace:         "images",
+			UID:               "build-uid",
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)),
+		},
+		Spec: buildv1.BuildSpec{
+			Exports: []buildv1.ExportSpec{
+				{
+					Name:   "vagrant",
+					Format: buildv1.ExportFormatVagrantBox,
+					Vagrant: &buildv1.VagrantExport{
+						BoxName:   "forge/ubuntu",
+						Providers: []buildv1.VagrantProvider{buildv1.VagrantProviderLibvirt},
+					},
+					Destination: buildv1.ExportDestination{BoxRegistry: &buildv1.BoxRegistryDestination{
+						CredentialsRef: corev1.LocalObjectReference{Name: "vagrant-cloud"},
+					}},
+				},
+				{
+					Name:   "desktop",
+					Format: buildv1.ExportFormatOVA,
+					Destination: buildv1.ExportDestination{ObjectStorage: &buildv1.ObjectStorageDestination{
+						URL:            "s3://images/desktop",
+						Endpoint:       "https://minio.example.com",
+						CredentialsRef: corev1.LocalObjectReference{Name: "minio"},
+					}},
+				},
+			},
+		},
+		Status: buildv1.BuildStatus{
+			Artifact: &buildv1.BuildArtifact{ID: "ubuntu", Location: "s3://images/ubuntu.qcow2", Format: "qcow2"},
+		},
+	}
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	return build
+}
+
+func setJobCondition(g *WithT, c client.Client, name string, conditionType batchv1.JobConditionType) {
+	job := &batchv1.Job{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: name}, job)).To(Succeed())
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+		Type: conditionType, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit",
+	})
+	g.Expect(c.Status().Update(context.Background(), job)).To(Succeed())
+}
+
+// setExportResult reports result in the termination message of a succeeded Pod of the export Job, with the
+// selector set by the API server.
+func setExportResult(g *WithT, c client.Client, name, result string) {
+	job := &batchv1.Job{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: name}, job)).To(Succeed())
+	job.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": name}}
+	g.Expect(c.Update(context.Background(), job)).To(Succeed())
+	g.Expect(c.Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: name + "-abcde", Labels: job.Spec.Selector.MatchLabels},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  exporterjob.ContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: result}},
+			}},
+		},
+	})).To(Succeed())
+}
+
+func TestReconcileExports(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := newExportingBuild()
+	build.Spec.Exports = append(build.Spec.Exports, buildv1.ExportSpec{
+		Name:   "azure",
+		Format: buildv1.ExportFormatVHD,
+		Destination: buildv1.ExportDestination{AzureBlob: &buildv1.AzureBlobDestination{
+			URL:            "https://forge.blob.core.windows.net/images/exports",
+			CredentialsRef: corev1.LocalObjectReference{Name: "azure-sas"},
+		}},
+	})
+	_, err := r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Exports).To(HaveLen(3))
+	g.Expect(conditions.IsFalse(build, buildv1.ExportsPublishedCondition)).To(BeTrue())
+	g.Expect(exportsPublished(build)).To(BeFalse())
+
+	jobs := &batchv1.JobList{}
+	g.Expect(c.List(context.Background(), jobs, client.InNamespace("images"))).To(Succeed())
+	g.Expect(jobs.Items).To(HaveLen(3))
+	for _, job := range jobs.Items {
+		g.Expect(metav1.IsControlledBy(&job, build)).To(BeTrue())
+	}
+	for _, status := range build.Status.Exports {
+		g.Expect(status.Phase).To(Equal(buildv1.ExportPhaseRunning))
+		setJobCondition(g, c, status.JobName, batchv1.JobComplete)
+	}
+	// The exporter reports the URL, the checksum and the size of the OVA, the other exports fall back to their
+	// computed URL.
+	setExportResult(g, c, build.Status.Exports[1].JobName,
+		`{"url":"https://minio.example.com/images/desktop/ubuntu/desktop.ova","checksum":"sha256:abc","sizeBytes":1024}`)
+
+	_, err = r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conditions.IsTrue(build, buildv1.ExportsPublishedCondition)).To(BeTrue())
+	g.Expect(exportsPublished(build)).To(BeTrue())
+	g.Expect(build.Status.Exports[0].Phase).To(Equal(buildv1.ExportPhaseSucceeded))
+	g.Expect(build.Status.Exports[0].URL).To(Equal("https://app.vagrantup.com/api/v1/box/forge/ubuntu/version/20240501.103000"))
+	g.Expect(build.Status.Exports[1].URL).To(Equal("https://minio.example.com/images/desktop/ubuntu/desktop.ova"))
+	g.Expect(build.Status.Exports[1].Checksum).To(Equal("sha256:abc"))
+	g.Expect(build.Status.Exports[1].SizeBytes).To(HaveValue(BeEquivalentTo(1024)))
+	g.Expect(build.Status.Exports[2].URL).To(Equal("https://forge.blob.core.windows.net/images/exports/ubuntu/azure.vhd"))
+	g.Expect(build.Status.Exports[2].Checksum).To(BeEmpty())
+}
+
+func TestReconcileExportsFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := newExportingBuild()
+	_, err := r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	setJobCondition(g, c, build.Status.Exports[1].JobName, batchv1.JobFailed)
+
+	_, err = r.reconcileExports(context.Background(), build)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTerminal))
+	g.Expect(forgeerrors.ReasonFor(err)).To(Equal(forgeerrors.ExportFailedBuildError))
+	g.Expect(build.Status.Exports[1].Phase).To(Equal(buildv1.ExportPhaseFailed))
+	g.Expect(*build.Status.Exports[1].FailureMessage).To(ContainSubstring("backoff limit"))
+	g.Expect(conditions.Get(build, buildv1.ExportsPublishedCondition).Reason).To(Equal(buildv1.ExportFailedReason))
+}
+
+func TestReconcileExportsWithoutImageLocation(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &BuildReconciler{recorder: record.NewFakeRecorder(10)}
+	build := newExportingBuild()
+	build.Status.Artifact.Location = ""
+
+	_, err := r.reconcileExports(context.Background(), build)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTerminal))
+	g.Expect(build.Status.Exports).To(BeEmpty())
+}
+
+func TestReconcileExportsOCIRegistry(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := newExportingBuild()
+	build.Spec.Exports = []buildv1.ExportSpec{{
+		Name:   "disk",
+		Format: buildv1.ExportFormatQCOW2,
+		Destination: buildv1.ExportDestination{OCIRegistry: &buildv1.OCIRegistryDestination{
+			Repository:  "ghcr.io/forge-build/images/ubuntu",
+			Annotations: map[string]string{"team": "platform"},
+		}},
+	}}
+	build.Spec.Artifact = &buildv1.ArtifactSpec{SBOM: &buildv1.SBOMSpec{}}
+	build.Status.SBOM = &buildv1.SBOMStatus{Format: buildv1.SBOMFormatSPDX, Location: "configmap/ubuntu-sbom/sbom.spdx.json"}
+	_, err := r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The artifact is tagged with the creation time of the Build by default.
+	job := &batchv1.Job{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: build.Status.Exports[0].JobName}, job)).To(Succeed())
+	g.Expect(job.Spec.Template.Spec.Containers[0].Args).To(ContainElements(
+		"--oci-repository", "ghcr.io/forge-build/images/ubuntu", "--oci-tags", "20240501.103000", "--oci-annotation", "team=platform"))
+	g.Expect(job.Spec.Template.Spec.Volumes[0].EmptyDir).ToNot(BeNil())
+
+	// The SBOM stored in the ConfigMap of the Build is attached as a referrer.
+	g.Expect(job.Spec.Template.Spec.Containers[0].Args).To(ContainElements(
+		"--sbom", "/var/run/forge/sbom/sbom.spdx.json", "--sbom-media-type", buildv1.SPDXMediaType))
+	g.Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.ConfigMap.Name", "ubuntu-sbom")))
+
+	setJobCondition(g, c, build.Status.Exports[0].JobName, batchv1.JobComplete)
+	_, err = r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Exports[0].URL).To(Equal("ghcr.io/forge-build/images/ubuntu:20240501.103000"))
+}
+
+func TestReconcileExportsSigning(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := newExportingBuild()
+	build.Spec.Artifact = &buildv1.ArtifactSpec{Signing: &buildv1.SigningSpec{Keyless: &buildv1.KeylessSigning{}}}
+	_, err := r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The Vagrant boxes are not signed.
+	vagrant := &batchv1.Job{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: build.Status.Exports[0].JobName}, vagrant)).To(Succeed())
+	g.Expect(vagrant.Spec.Template.Spec.Containers[0].Args).ToNot(ContainElement("--identity-token"))
+
+	// The other exports are signed keyless with a projected service account token, along with their provenance.
+	desktop := &batchv1.Job{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: build.Status.Exports[1].JobName}, desktop)).To(Succeed())
+	pod := desktop.Spec.Template.Spec
+	g.Expect(pod.Containers[0].Args).To(ContainElements(
+		"--identity-token", "/var/run/secrets/signing/token",
+		"--fulcio-url", buildv1.DefaultFulcioURL, "--rekor-url", buildv1.DefaultRekorURL))
+	g.Expect(pod.Volumes[len(pod.Volumes)-1].Projected.Sources[0].ServiceAccountToken.Audience).To(Equal("sigstore"))
+	g.Expect(pod.Containers[0].Env).To(ContainElement(HaveField("Name", "FORGE_PROVENANCE")))
+
+	setJobCondition(g, c, build.Status.Exports[1].JobName, batchv1.JobComplete)
+	setExportResult(g, c, build.Status.Exports[1].JobName,
+		`{"url":"s3://images/desktop/ubuntu.ova","signature":"s3://images/desktop/ubuntu.ova.sig.bundle","attestation":"s3://images/desktop/ubuntu.ova.att.bundle"}`)
+	_, err = r.reconcileExports(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Exports[1].Signature).To(Equal("s3://images/desktop/ubuntu.ova.sig.bundle"))
+	g.Expect(build.Status.Exports[1].Attestation).To(Equal("s3://images/desktop/ubuntu.ova.att.bundle"))
+}
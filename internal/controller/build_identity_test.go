@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileIdentity(t *testing.T) {
+	testcases := []struct {
+		name            string
+		created         time.Time
+		condition       *metav1.Condition
+		expectedReason  string
+		expectedRequeue bool
+		expectedErr     forgeerrors.Category
+	}{
+		{
+			name:            "waiting for the validation",
+			created:         time.Now().Add(-time.Minute),
+			expectedReason:  buildv1.WaitingForCredentialsValidationReason,
+			expectedRequeue: true,
+		},
+		{
+			name:           "never validated",
+			created:        time.Now().Add(-IdentityValidationTimeout - time.Minute),
+			expectedReason: buildv1.IdentityNotValidatedReason,
+			expectedErr:    forgeerrors.CategoryConfigError,
+		},
+		{
+			name:    "valid credentials",
+			created: time.Now().Add(-IdentityValidationTimeout - time.Minute),
+			condition: &metav1.Condition{
+				Type: buildv1.CredentialsValidCondition, Status: metav1.ConditionTrue, Reason: buildv1.CredentialsValidReason,
+			},
+			expectedReason: buildv1.CredentialsValidReason,
+		},
+		{
+			name:    "invalid credentials",
+			created: time.Now().Add(-time.Minute),
+			condition: &metav1.Condition{
+				Type: buildv1.CredentialsValidCondition, Status: metav1.ConditionFalse, Reason: buildv1.CredentialsInvalidReason,
+			},
+			expectedReason: buildv1.CredentialsInvalidReason,
+			expectedErr:    forgeerrors.CategoryTerminal,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+			identity := &buildv1.ProviderIdentity{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "aws", CreationTimestamp: metav1.NewTime(tc.created)},
+				Spec:       buildv1.ProviderIdentitySpec{Provider: "aws"},
+			}
+			if tc.condition != nil {
+				identity.Status.Conditions = []metav1.Condition{*tc.condition}
+			}
+			build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+			build.Spec.IdentityRef = &corev1.LocalObjectReference{Name: "aws"}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(identity).Build()
+			r := &BuildReconciler{Client: c, Scheme: scheme}
+
+			res, err := r.reconcileIdentity(context.Background(), build)
+			if tc.expectedErr != "" {
+				g.Expect(forgeerrors.Classify(err)).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(res.RequeueAfter > 0).To(Equal(tc.expectedRequeue))
+			g.Expect(conditions.Get(build, buildv1.CredentialsValidCondition).Reason).To(Equal(tc.expectedReason))
+		})
+	}
+}
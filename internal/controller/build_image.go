@@ -0,0 +1,146 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// imageNameUIDLength is the length of the prefix of the UID of a Build suffixed to the name of its Image, when an
+// Image of another Build already has its name.
+const imageNameUIDLength = 8
+
+// reconcileImage creates the Image cataloguing the machine image of a completed Build. The Image is not owned by the
+// Build, it is kept once the Build is deleted, e.g. when its TTL after finished expired.
+func (r *BuildReconciler) reconcileImage(ctx context.Context, build *buildv1.Build) error {
+	if isFailed(build) || build.Status.ImageRef == "" || build.Status.Image != "" {
+		return nil
+	}
+	if build.Status.CompletionTime == nil {
+		build.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	}
+	image, err := imageFor(build)
+	if err != nil {
+		return err
+	}
+
+	names := []string{build.Name}
+	if uid := string(build.UID); uid != "" {
+		names = append(names, build.Name+"-"+uid[:min(len(uid), imageNameUIDLength)])
+	}
+	for _, name := range names {
+		image.Name = name
+		err := r.Client.Create(ctx, image)
+		if err == nil {
+			build.Status.Image = name
+			r.recorder.Eventf(build, corev1.EventTypeNormal, "ImageCatalogued", "Created Image %s for image %s", name, image.Spec.ImageRef)
+			return nil
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create Image %s", name)
+		}
+		existing := &buildv1.Image{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, existing); err != nil {
+			return errors.Wrapf(err, "failed to get Image %s", name)
+		}
+		// The Image has been created by a previous reconcile, whose status has not been patched.
+		if existing.Spec.BuildRef.UID == build.UID {
+			build.Status.Image = name
+			return nil
+		}
+	}
+	return errors.Errorf("failed to create the Image of Build %s, Images of other Builds are named %v", build.Name, names)
+}
+
+// imageFor returns the Image cataloguing the machine image of the Build, without its name. It is labeled with the
+// labels of the Build and with its lineage, so the images can be selected by label.
+func imageFor(build *buildv1.Build) (*buildv1.Image, error) {
+	specDigest, err := digestOf(build.Spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to digest the spec of the Build")
+	}
+	labels := map[string]string{}
+	maps.Copy(labels, build.Labels)
+	labels[buildv1.BuildNameLabel] = build.Name
+	labels[buildv1.BuildUIDLabel] = string(build.UID)
+
+	image := &buildv1.Image{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: build.Namespace,
+			Labels:    labels,
+		},
+		Spec: buildv1.ImageSpec{
+			BuildRef:     buildv1.ImageBuildReference{Name: build.Name, UID: build.UID},
+			TemplateRef:  build.Spec.TemplateRef.DeepCopy(),
+			IdentityRef:  build.Spec.IdentityRef.DeepCopy(),
+			Architecture: buildv1.Architecture(build.Labels[buildv1.ArchitectureLabel]),
+			ImageRef:     build.Status.ImageRef,
+			Artifact:     build.Status.Artifact.DeepCopy(),
+			Provenance: buildv1.ImageProvenance{
+				GitSHA:         build.Annotations[buildv1.GitSHAAnnotation],
+				SpecDigest:     "sha256:" + specDigest,
+				SBOM:           build.Status.SBOM.DeepCopy(),
+				Scan:           build.Status.Scan.DeepCopy(),
+				StartTime:      build.Status.StartTime.DeepCopy(),
+				CompletionTime: build.Status.CompletionTime.DeepCopy(),
+			},
+			Metadata: maps.Clone(build.Status.ImageMetadata),
+		},
+	}
+	if build.Status.Cache != nil {
+		image.Spec.Provenance.Layers = slices.Clone(build.Status.Cache.Layers)
+	}
+	if ref := build.Spec.TemplateRef; ref != nil {
+		labels[buildv1.BuildTemplateNameLabel] = ref.Name
+	}
+	if ref := build.Spec.InfrastructureRef; ref != nil {
+		image.Spec.InfrastructureRef = &corev1.ObjectReference{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+		labels[buildv1.InfrastructureKindLabel] = ref.Kind
+	}
+	for _, replication := range build.Status.Replications {
+		if replication.Phase == buildv1.ReplicationPhaseReplicated {
+			image.Spec.Replications = append(image.Spec.Replications, replication)
+		}
+	}
+	for _, export := range build.Status.Exports {
+		if export.Phase != buildv1.ExportPhaseSucceeded {
+			continue
+		}
+		image.Spec.Exports = append(image.Spec.Exports, buildv1.ImageExport{
+			Name:        export.Name,
+			URL:         export.URL,
+			Checksum:    export.Checksum,
+			Signature:   export.Signature,
+			Attestation: export.Attestation,
+		})
+	}
+	if artifact := build.Spec.Artifact; artifact != nil && artifact.ExpireAfter != nil {
+		image.Spec.ExpirationTime = &metav1.Time{Time: build.Status.CompletionTime.Add(artifact.ExpireAfter.Duration)}
+	}
+	return image, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestReconcileImage(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	// An Image of a previous Build with the same name is kept.
+	previous := &buildv1.Image{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec:       buildv1.ImageSpec{BuildRef: buildv1.ImageBuildReference{Name: "ubuntu", UID: "previous-uid"}, ImageRef: "ami-0"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(previous).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	completion := metav1.NewTime(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ubuntu",
+			Namespace:   "images",
+			UID:         "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0",
+			Labels:      map[string]string{"os": "ubuntu", buildv1.ArchitectureLabel: "arm64"},
+			Annotations: map[string]string{buildv1.GitSHAAnnotation: "2c26b46"},
+		},
+		Spec: buildv1.BuildSpec{
+			TemplateRef:       &buildv1.BuildTemplateReference{Name: "ubuntu-2204"},
+			InfrastructureRef: &corev1.ObjectReference{Kind: "AWSBuild", Name: "ubuntu", UID: "infra-uid"},
+			Artifact:          &buildv1.ArtifactSpec{ExpireAfter: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+		},
+		Status: buildv1.BuildStatus{
+			ImageRef:       "ami-1",
+			Artifact:       &buildv1.BuildArtifact{ID: "ami-1", Format: "ami"},
+			CompletionTime: &completion,
+			Replications: []buildv1.ReplicationStatus{
+				{Type: buildv1.ReplicationTargetRegion, Name: "eu-west-1", Phase: buildv1.ReplicationPhaseReplicated, ImageRef: "ami-2"},
+				{Type: buildv1.ReplicationTargetRegion, Name: "us-east-2", Phase: buildv1.ReplicationPhaseFailed},
+			},
+			Exports: []buildv1.ExportStatus{
+				{Name: "oci", Phase: buildv1.ExportPhaseSucceeded, URL: "ghcr.io/forge-build/ubuntu@sha256:abc", Signature: "ghcr.io/forge-build/ubuntu:sha256-abc.sig"},
+				{Name: "vhd", Phase: buildv1.ExportPhaseFailed, FailureMessage: ptr.To("denied")},
+			},
+			Scan: &buildv1.ScanStatus{Scanner: buildv1.ScannerTrivy, Passed: true},
+		},
+	}
+
+	// There is no image to catalog for a failed Build.
+	failed := build.DeepCopy()
+	failed.Status.FailureMessage = ptr.To("provisioner failed")
+	g.Expect(r.reconcileImage(context.Background(), failed)).To(Succeed())
+	g.Expect(failed.Status.Image).To(BeEmpty())
+
+	// The Image is named after the Build, suffixed with its UID as the name is taken by the previous Build.
+	g.Expect(r.reconcileImage(context.Background(), build)).To(Succeed())
+	g.Expect(build.Status.Image).To(Equal("ubuntu-0f1e2d3c"))
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("Created Image ubuntu-0f1e2d3c for image ami-1")))
+
+	image := &buildv1.Image{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "ubuntu-0f1e2d3c"}, image)).To(Succeed())
+	g.Expect(image.Labels).To(Equal(map[string]string{
+		"os":                            "ubuntu",
+		buildv1.ArchitectureLabel:       "arm64",
+		buildv1.BuildNameLabel:          "ubuntu",
+		buildv1.BuildUIDLabel:           "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0",
+		buildv1.BuildTemplateNameLabel:  "ubuntu-2204",
+		buildv1.InfrastructureKindLabel: "AWSBuild",
+	}))
+	g.Expect(image.OwnerReferences).To(BeEmpty())
+	g.Expect(image.Spec.BuildRef).To(Equal(buildv1.ImageBuildReference{Name: "ubuntu", UID: build.UID}))
+	g.Expect(image.Spec.Architecture).To(Equal(buildv1.ArchitectureARM64))
+	g.Expect(image.Spec.InfrastructureRef).To(Equal(&corev1.ObjectReference{Kind: "AWSBuild", Name: "ubuntu"}))
+	g.Expect(image.Spec.ImageRef).To(Equal("ami-1"))
+	g.Expect(image.Spec.Replications).To(HaveLen(1))
+	g.Expect(image.Spec.Exports).To(Equal([]buildv1.ImageExport{
+		{Name: "oci", URL: "ghcr.io/forge-build/ubuntu@sha256:abc", Signature: "ghcr.io/forge-build/ubuntu:sha256-abc.sig"},
+	}))
+	g.Expect(image.Spec.Provenance.GitSHA).To(Equal("2c26b46"))
+	g.Expect(image.Spec.Provenance.SpecDigest).To(HavePrefix("sha256:"))
+	g.Expect(image.Spec.Provenance.Scan.Passed).To(BeTrue())
+	g.Expect(image.Spec.ExpirationTime.Time).To(BeTemporally("==", completion.Add(30*24*time.Hour)))
+
+	// The Image is recorded again when the status of the Build has not been patched.
+	build.Status.Image = ""
+	g.Expect(r.reconcileImage(context.Background(), build)).To(Succeed())
+	g.Expect(build.Status.Image).To(Equal("ubuntu-0f1e2d3c"))
+	g.Expect(recorder.Events).To(BeEmpty())
+}
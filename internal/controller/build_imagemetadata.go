@@ -0,0 +1,60 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// reconcileImageMetadata resolves the metadata the infrastructure provider sets on the image of the Build,
+// until the image has been exported so changes to the labels and annotations of the Build are taken into account.
+func (r *BuildReconciler) reconcileImageMetadata(_ context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		return ctrl.Result{}, nil
+	}
+	build.Status.ImageMetadata = imageMetadata(build)
+	return ctrl.Result{}, nil
+}
+
+// imageMetadata returns the metadata of the image of the Build: its name, namespace and UID, and the labels
+// and annotations mapped by spec.imageMetadata. The labels and annotations the Build does not have are skipped.
+func imageMetadata(build *buildv1.Build) map[string]string {
+	metadata := map[string]string{
+		buildv1.ImageMetadataBuildNameKey:      build.Name,
+		buildv1.ImageMetadataBuildNamespaceKey: build.Namespace,
+		buildv1.ImageMetadataBuildUIDKey:       string(build.UID),
+	}
+	if build.Spec.ImageMetadata == nil {
+		return metadata
+	}
+	for _, mapping := range build.Spec.ImageMetadata.Labels {
+		if value, ok := build.Labels[mapping.Key]; ok {
+			metadata[mapping.GetName()] = value
+		}
+	}
+	for _, mapping := range build.Spec.ImageMetadata.Annotations {
+		if value, ok := build.Annotations[mapping.Key]; ok {
+			metadata[mapping.GetName()] = value
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestImageMetadata(t *testing.T) {
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ubuntu",
+			Namespace:   "images",
+			UID:         "build-uid",
+			Labels:      map[string]string{"example.com/team": "platform", "Compliance.Profile": "cis-l1"},
+			Annotations: map[string]string{"example.com/git-sha": "2c26b46"},
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		metadata *buildv1.ImageMetadataSpec
+		want     map[string]string
+	}{
+		{
+			name: "no mapping",
+			want: map[string]string{
+				"forge-build-name":      "ubuntu",
+				"forge-build-namespace": "images",
+				"forge-build-uid":       "build-uid",
+			},
+		},
+		{
+			name: "labels and annotations",
+			metadata: &buildv1.ImageMetadataSpec{
+				Labels: []buildv1.ImageMetadataMapping{
+					{Key: "example.com/team"},
+					{Key: "Compliance.Profile"},
+					{Key: "example.com/missing"},
+				},
+				Annotations: []buildv1.ImageMetadataMapping{{Key: "example.com/git-sha", Name: "commit"}},
+			},
+			want: map[string]string{
+				"forge-build-name":      "ubuntu",
+				"forge-build-namespace": "images",
+				"forge-build-uid":       "build-uid",
+				"team":                  "platform",
+				"compliance-profile":    "cis-l1",
+				"commit":                "2c26b46",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			build := build.DeepCopy()
+			build.Spec.ImageMetadata = tc.metadata
+			g.Expect(imageMetadata(build)).To(Equal(tc.want))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileImageProvided(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.forge.build/v1alpha1",
+				Kind:       "GCPBuild",
+				Name:       "ubuntu",
+			},
+		},
+		Status: buildv1.BuildStatus{ProvisionersReady: true},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{recorder: recorder}
+
+	// The image is exported by the infrastructure provider once the provisioners are ready.
+	_, err := r.reconcileImageProvided(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conditions.GetReason(build, buildv1.ImageExportedCondition)).To(Equal(buildv1.WaitingForImageExportReason))
+
+	build.Status.Ready = true
+	build.Status.Artifact = &buildv1.BuildArtifact{ID: "projects/forge/global/images/ubuntu", Format: "gce-image"}
+	_, err = r.reconcileImageProvided(ctx, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conditions.IsTrue(build, buildv1.ImageExportedCondition)).To(BeTrue())
+	g.Expect(recorder.Events).To(Receive(Equal("Normal ArtifactExported Exported gce-image artifact projects/forge/global/images/ubuntu")))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// buildObservation is the progress of a Build before a reconcile, to report the transitions made by the reconcile.
+type buildObservation struct {
+	started           bool
+	machineReady      bool
+	provisionersReady bool
+	imageExported     bool
+	finished          bool
+}
+
+func observeBuild(build *buildv1.Build) buildObservation {
+	return buildObservation{
+		started:           build.Status.StartTime != nil,
+		machineReady:      conditions.IsTrue(build, buildv1.MachineReadyCondition),
+		provisionersReady: conditions.IsTrue(build, buildv1.ProvisionersReadyCondition),
+		imageExported:     conditions.IsTrue(build, buildv1.ImageExportedCondition),
+		finished:          isFinished(build),
+	}
+}
+
+// reportBuildMetrics reports the phase of the Build, to alert on Builds stuck in a phase, along with the transitions
+// of the Build since it has been observed: its start and completion, and the duration of the phases it completed.
+func reportBuildMetrics(build *buildv1.Build, observed buildObservation) {
+	infrastructure := infrastructureKind(build)
+	metrics.SetBuild(build.Namespace, build.Name, build.Status.Phase, infrastructure, build.CreationTimestamp.Time)
+
+	// Only the Builds of a machine are counted, the Builds of the architectures of a multi-architecture Build are
+	// counted on their own.
+	if build.Spec.Rehearsal() || len(build.Spec.Architectures) > 0 {
+		return
+	}
+	if !observed.started && build.Status.StartTime != nil {
+		metrics.BuildsStarted.WithLabelValues(build.Namespace, infrastructure).Inc()
+	}
+	if !observed.machineReady && conditions.IsTrue(build, buildv1.MachineReadyCondition) && build.Status.StartTime != nil {
+		metrics.ObservePhase(infrastructure, metrics.PhaseMachineReady,
+			build.Status.StartTime.Time, transitionTime(build, buildv1.MachineReadyCondition, time.Time{}))
+	}
+	if !observed.provisionersReady && conditions.IsTrue(build, buildv1.ProvisionersReadyCondition) {
+		metrics.ObservePhase(infrastructure, metrics.PhaseProvisioning,
+			transitionTime(build, buildv1.ConnectedCondition, time.Time{}),
+			transitionTime(build, buildv1.ProvisionersReadyCondition, time.Time{}))
+	}
+	if !observed.imageExported && conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		metrics.ObservePhase(infrastructure, metrics.PhaseExport,
+			transitionTime(build, buildv1.ProvisionersReadyCondition, time.Time{}),
+			transitionTime(build, buildv1.ImageExportedCondition, time.Time{}))
+	}
+	if !observed.finished && isFinished(build) {
+		if isFailed(build) {
+			reason := ""
+			if build.Status.FailureReason != nil {
+				reason = string(*build.Status.FailureReason)
+			}
+			metrics.BuildsFailed.WithLabelValues(build.Namespace, infrastructure, reason).Inc()
+		} else {
+			metrics.BuildsSucceeded.WithLabelValues(build.Namespace, infrastructure).Inc()
+		}
+	}
+}
+
+// infrastructureKind returns the kind of the infrastructure of the Build, if any.
+func infrastructureKind(build *buildv1.Build) string {
+	if build.Spec.InfrastructureRef == nil {
+		return ""
+	}
+	return build.Spec.InfrastructureRef.Kind
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReportBuildMetrics(t *testing.T) {
+	g := NewWithT(t)
+
+	start := time.Now().Add(-time.Hour)
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "metrics", Name: "ubuntu"},
+		Spec:       buildv1.BuildSpec{InfrastructureRef: &corev1.ObjectReference{Kind: "ProxmoxBuild"}},
+	}
+	started := func() float64 {
+		return testutil.ToFloat64(metrics.BuildsStarted.WithLabelValues("metrics", "ProxmoxBuild"))
+	}
+	succeeded := func() float64 {
+		return testutil.ToFloat64(metrics.BuildsSucceeded.WithLabelValues("metrics", "ProxmoxBuild"))
+	}
+
+	// The Build is counted once it starts.
+	observed := observeBuild(build)
+	build.Status.StartTime = &metav1.Time{Time: start}
+	build.Status.Phase = string(buildv1.BuildPhaseProvisioning)
+	reportBuildMetrics(build, observed)
+	g.Expect(started()).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.BuildsActive.WithLabelValues("metrics", "ProxmoxBuild"))).To(Equal(1.0))
+
+	// The transitions are only reported by the reconcile making them.
+	observed = observeBuild(build)
+	conditions.MarkTrue(build, buildv1.MachineReadyCondition, buildv1.MachineProvisionedReason, "")
+	reportBuildMetrics(build, observed)
+	reportBuildMetrics(build, observeBuild(build))
+	g.Expect(started()).To(Equal(1.0))
+	g.Expect(testutil.CollectAndCount(metrics.BuildPhaseDuration, metrics.BuildPhaseDurationName)).To(BeNumerically(">=", 1))
+
+	// A failed Build is counted with its failure reason.
+	failed := build.DeepCopy()
+	failed.Name = "failed"
+	observed = observeBuild(failed)
+	failed.Status.FailureReason = ptr.To(forgeerrors.ProvisionerFailedError)
+	failed.Status.FailureMessage = ptr.To("provisioner failed")
+	reportBuildMetrics(failed, observed)
+	g.Expect(testutil.ToFloat64(metrics.BuildsFailed.WithLabelValues("metrics", "ProxmoxBuild", string(forgeerrors.ProvisionerFailedError)))).To(Equal(1.0))
+	g.Expect(succeeded()).To(BeZero())
+	metrics.DeleteBuild("metrics", "failed")
+
+	// A Build whose image has been exported is counted as succeeded.
+	observed = observeBuild(build)
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ReadyReason, "")
+	build.Status.Phase = string(buildv1.BuildPhaseCompleted)
+	reportBuildMetrics(build, observed)
+	g.Expect(succeeded()).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.BuildsActive.WithLabelValues("metrics", "ProxmoxBuild"))).To(BeZero())
+	metrics.DeleteBuild("metrics", "ubuntu")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// isPreempted returns true if the infrastructure object of the Build reported the preemption of its machine.
+func isPreempted(build *buildv1.Build) bool {
+	return ptr.Deref(build.Status.FailureReason, "") == forgeerrors.PreemptedBuildError
+}
+
+// reconcilePreemption recovers the Build from the preemption of its spot machine: the infrastructure object is
+// recreated, without its spec.spot once the machine has been preempted more than the MaxPreemptions of the
+// PreemptionPolicy, and the provisioners are run again from the start on the new machine. The Build is left failed
+// with the Preempted reason when the PreemptionPolicy falls back to Fail.
+func (r *BuildReconciler) reconcilePreemption(ctx context.Context, build *buildv1.Build, infraConfig *unstructured.Unstructured) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	maxPreemptions, fallback := int32(buildv1.DefaultMaxPreemptions), buildv1.PreemptionFallbackOnDemand
+	if policy := build.Spec.PreemptionPolicy; policy != nil {
+		maxPreemptions = ptr.Deref(policy.MaxPreemptions, maxPreemptions)
+		fallback = cmp.Or(policy.Fallback, fallback)
+	}
+	onDemand := build.Status.Preemptions >= maxPreemptions
+	if onDemand && fallback == buildv1.PreemptionFallbackFail {
+		log.Info("Machine preempted too many times, failing the Build", "preemptions", build.Status.Preemptions+1)
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.recreateInfrastructure(ctx, build, fmt.Sprintf("preempted-%d", build.Status.Preemptions+1), onDemand); err != nil {
+		// The preemption is still reported by the infrastructure object, it is recovered from on the next reconcile.
+		build.Status.FailureReason = nil
+		build.Status.FailureMessage = nil
+		return ctrl.Result{}, err
+	}
+	resetMachine(build)
+	build.Status.Preemptions++
+	build.Status.LastPreemptionTime = ptr.To(metav1.Now())
+
+	machine := "a spot machine"
+	if onDemand {
+		machine = "an on-demand machine"
+	}
+	conditions.MarkFalse(build, buildv1.MachineReadyCondition, buildv1.MachinePreemptedReason,
+		"%s %q was preempted, recreating %s (preemption %d)", infraConfig.GetKind(), infraConfig.GetName(), machine, build.Status.Preemptions)
+	log.Info("Machine preempted, recreating it", "preemptions", build.Status.Preemptions, "onDemand", onDemand)
+	r.recorder.Eventf(build, corev1.EventTypeWarning, buildv1.MachinePreemptedReason,
+		"The machine of %s %q was preempted, recreating %s and running the provisioners again (preemption %d)",
+		infraConfig.GetKind(), infraConfig.GetName(), machine, build.Status.Preemptions)
+	return ctrl.Result{Requeue: true}, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func newPreemptedBuild() (*buildv1.Build, *unstructured.Unstructured) {
+	infra := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"instanceType": "m7i.large",
+			"spot":         map[string]interface{}{"maxPrice": "0.05"},
+		},
+	}}
+	infra.SetAPIVersion("infrastructure.forge.build/v1alpha1")
+	infra.SetKind("AWSBuild")
+	infra.SetNamespace("images")
+	infra.SetName("ubuntu")
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.forge.build/v1alpha1",
+				Kind:       "AWSBuild",
+				Name:       "ubuntu",
+			},
+			Provisioners: []buildv1.ProvisionerSpec{{Name: "packages", Status: ptr.To(buildv1.ProvisionerStatusCompleted)}},
+		},
+		Status: buildv1.BuildStatus{
+			InfrastructureReady: true,
+			Connected:           true,
+			FailureReason:       ptr.To(forgeerrors.PreemptedBuildError),
+			FailureMessage:      ptr.To("Spot Instance i-1 has been interrupted"),
+		},
+	}
+	return build, infra
+}
+
+func TestReconcilePreemption(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build, infra := newPreemptedBuild()
+	build.Spec.PreemptionPolicy = &buildv1.PreemptionPolicy{MaxPreemptions: ptr.To(int32(1))}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(infra).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	// The first preemption recreates the spot machine and restarts the provisioners.
+	res, err := r.reconcilePreemption(ctx, build, infra)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.Requeue).To(BeTrue())
+	g.Expect(build.Status.Preemptions).To(BeEquivalentTo(1))
+	g.Expect(build.Status.LastPreemptionTime).ToNot(BeNil())
+	g.Expect(isFailed(build)).To(BeFalse())
+	g.Expect(build.Status.InfrastructureReady).To(BeFalse())
+	g.Expect(build.Spec.Provisioners[0].Status).To(BeNil())
+	g.Expect(build.Spec.InfrastructureRef.Name).To(Equal("ubuntu-preempted-1"))
+	g.Expect(conditions.GetReason(build, buildv1.MachineReadyCondition)).To(Equal(buildv1.MachinePreemptedReason))
+	g.Expect(<-recorder.Events).To(ContainSubstring("recreating a spot machine"))
+
+	replacement := &unstructured.Unstructured{}
+	replacement.SetGroupVersionKind(infra.GroupVersionKind())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-preempted-1"}, replacement)).To(Succeed())
+	g.Expect(replacement.Object["spec"]).To(HaveKey("spot"))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(infra), infra.DeepCopy())).ToNot(Succeed())
+
+	// The machine is recreated on demand once it has been preempted more than MaxPreemptions.
+	build.Status.FailureReason = ptr.To(forgeerrors.PreemptedBuildError)
+	_, err = r.reconcilePreemption(ctx, build, replacement)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Preemptions).To(BeEquivalentTo(2))
+	g.Expect(build.Spec.InfrastructureRef.Name).To(Equal("ubuntu-preempted-2"))
+	g.Expect(<-recorder.Events).To(ContainSubstring("recreating an on-demand machine"))
+	onDemand := &unstructured.Unstructured{}
+	onDemand.SetGroupVersionKind(infra.GroupVersionKind())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu-preempted-2"}, onDemand)).To(Succeed())
+	g.Expect(onDemand.Object["spec"]).To(Equal(map[string]interface{}{"instanceType": "m7i.large"}))
+}
+
+func TestReconcilePreemptionFallbackFail(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build, infra := newPreemptedBuild()
+	build.Spec.PreemptionPolicy = &buildv1.PreemptionPolicy{MaxPreemptions: ptr.To(int32(0)), Fallback: buildv1.PreemptionFallbackFail}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(infra).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	// The Build is left failed with the Preempted reason.
+	_, err := r.reconcilePreemption(ctx, build, infra)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.FailureReason).To(HaveValue(Equal(forgeerrors.PreemptedBuildError)))
+	g.Expect(build.Status.Preemptions).To(BeZero())
+	g.Expect(build.Spec.InfrastructureRef.Name).To(Equal("ubuntu"))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(infra), infra)).To(Succeed())
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// progressStep is a step of a Build, message describes it while it is in progress.
+type progressStep struct {
+	message string
+	done    bool
+}
+
+// buildProgress returns the progress of the Build, it must be called once the phase of the Build has been reconciled.
+func buildProgress(build *buildv1.Build) *buildv1.BuildProgress {
+	var steps []progressStep
+	if len(build.Spec.Architectures) > 0 {
+		steps = architectureSteps(build)
+	} else {
+		steps = buildSteps(build)
+	}
+
+	done := 0
+	current := -1
+	for i, step := range steps {
+		if step.done {
+			done++
+		} else if current < 0 {
+			current = i
+		}
+	}
+	progress := &buildv1.BuildProgress{
+		CurrentStep: int32(len(steps)),
+		TotalSteps:  int32(len(steps)),
+		Percent:     int32(done * 100 / len(steps)),
+	}
+	if current >= 0 {
+		progress.CurrentStep = int32(current + 1)
+		progress.Message = steps[current].message
+	}
+
+	switch phase := build.Status.GetTypedPhase(); {
+	case phase == buildv1.BuildPhaseCompleted:
+		// A completed Build is done with all its steps, the ones it skipped included.
+		progress.CurrentStep, progress.Percent = progress.TotalSteps, 100
+		progress.Message = "Build completed"
+	case phase == buildv1.BuildPhaseFailed:
+		progress.Message = fmt.Sprintf("Build failed at step %d of %d", progress.CurrentStep, progress.TotalSteps)
+	case build.Status.AwaitingApproval != "":
+		progress.Message = fmt.Sprintf("Provisioner %s is waiting to be approved", build.Status.AwaitingApproval)
+	case conditions.IsFalse(build, buildv1.AdmittedCondition):
+		progress.Message = "Build is queued"
+	}
+	return progress
+}
+
+// buildSteps returns the steps of a Build, the provisioners are in their execution order.
+func buildSteps(build *buildv1.Build) []progressStep {
+	steps := []progressStep{}
+	if !build.Spec.Rehearsal() {
+		steps = append(steps,
+			progressStep{message: "Provisioning the infrastructure", done: build.Status.InfrastructureReady},
+			progressStep{message: "Connecting to the machine", done: build.Status.Connected},
+		)
+	}
+
+	order, err := buildv1.ProvisionerExecutionOrder(build.Spec.Provisioners)
+	if err != nil {
+		order = make([]int, len(build.Spec.Provisioners))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	running := []string{}
+	for _, i := range order {
+		p := build.Spec.Provisioners[i]
+		if ptr.Deref(p.Status, "") == buildv1.ProvisionerStatusRunning {
+			running = append(running, p.Name)
+		}
+	}
+	for _, i := range order {
+		p := build.Spec.Provisioners[i]
+		status := ptr.Deref(p.Status, buildv1.ProvisionerStatusPending)
+		step := progressStep{
+			message: fmt.Sprintf("Running provisioner %s", p.Name),
+			done:    status == buildv1.ProvisionerStatusCompleted || status == buildv1.ProvisionerStatusFailed && p.AllowFail,
+		}
+		// The provisioners run concurrently are reported together.
+		if len(running) > 1 {
+			step.message = fmt.Sprintf("Running provisioners %s", strings.Join(running, ", "))
+		}
+		steps = append(steps, step)
+	}
+
+	steps = append(steps, progressStep{message: "Exporting the image", done: conditions.IsTrue(build, buildv1.ImageExportedCondition)})
+	if len(build.Spec.Exports) > 0 && !build.Spec.Rehearsal() {
+		steps = append(steps, progressStep{message: "Publishing the exports", done: exportsPublished(build)})
+	}
+	return steps
+}
+
+// architectureSteps returns the steps of a multi-architecture Build, a step per architecture.
+func architectureSteps(build *buildv1.Build) []progressStep {
+	phases := map[buildv1.Architecture]string{}
+	for _, status := range build.Status.Architectures {
+		phases[status.Architecture] = status.Phase
+	}
+	building := []string{}
+	for _, architecture := range build.Spec.Architectures {
+		if buildv1.BuildPhase(phases[architecture]) != buildv1.BuildPhaseCompleted {
+			building = append(building, string(architecture))
+		}
+	}
+	steps := []progressStep{}
+	for _, architecture := range build.Spec.Architectures {
+		steps = append(steps, progressStep{
+			message: fmt.Sprintf("Building the images of %s", strings.Join(building, ", ")),
+			done:    buildv1.BuildPhase(phases[architecture]) == buildv1.BuildPhaseCompleted,
+		})
+	}
+	return steps
+}
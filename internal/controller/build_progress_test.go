@@ -0,0 +1,142 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestBuildProgress(t *testing.T) {
+	build := func(phase buildv1.BuildPhase, provisioners ...buildv1.ProvisionerStatus) *buildv1.Build {
+		b := &buildv1.Build{}
+		for i, status := range provisioners {
+			p := buildv1.ProvisionerSpec{Name: []string{"base", "harden", "test"}[i], Type: buildv1.ProvisionerTypeShell}
+			if status != "" {
+				p.Status = ptr.To(status)
+			}
+			b.Spec.Provisioners = append(b.Spec.Provisioners, p)
+		}
+		b.Status.SetTypedPhase(phase)
+		return b
+	}
+	pending := build(buildv1.BuildPhasePending, "", "", "")
+	queued := pending.DeepCopy()
+	conditions.MarkFalse(queued, buildv1.AdmittedCondition, buildv1.ConcurrencyLimitReachedReason, "the limit is 2")
+	building := build(buildv1.BuildPhaseBuilding, buildv1.ProvisionerStatusCompleted, buildv1.ProvisionerStatusRunning, "")
+	building.Status.InfrastructureReady, building.Status.Connected = true, true
+	concurrent := build(buildv1.BuildPhaseBuilding, buildv1.ProvisionerStatusCompleted, buildv1.ProvisionerStatusRunning,
+		buildv1.ProvisionerStatusRunning)
+	concurrent.Status.InfrastructureReady, concurrent.Status.Connected = true, true
+	allowedFailure := build(buildv1.BuildPhaseExporting, buildv1.ProvisionerStatusCompleted, buildv1.ProvisionerStatusFailed)
+	allowedFailure.Spec.Provisioners[1].AllowFail = true
+	allowedFailure.Status.InfrastructureReady, allowedFailure.Status.Connected = true, true
+	awaitingApproval := building.DeepCopy()
+	awaitingApproval.Spec.Provisioners[1].Status = nil
+	awaitingApproval.Status.AwaitingApproval = "harden"
+	publishing := build(buildv1.BuildPhaseExporting, buildv1.ProvisionerStatusCompleted)
+	publishing.Spec.Exports = []buildv1.ExportSpec{{Name: "ova"}}
+	publishing.Status.InfrastructureReady, publishing.Status.Connected = true, true
+	conditions.MarkTrue(publishing, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	rehearsal := build(buildv1.BuildPhaseBuilding, buildv1.ProvisionerStatusRunning)
+	rehearsal.Spec.DryRun = true
+	failed := build(buildv1.BuildPhaseFailed, buildv1.ProvisionerStatusCompleted, buildv1.ProvisionerStatusFailed, "")
+	failed.Status.InfrastructureReady, failed.Status.Connected = true, true
+	failed.Status.FailureReason = ptr.To(forgeerrors.ProvisionerFailedError)
+	completed := build(buildv1.BuildPhaseCompleted, buildv1.ProvisionerStatusCompleted)
+	architectures := build(buildv1.BuildPhaseBuilding)
+	architectures.Spec.Architectures = []buildv1.Architecture{buildv1.ArchitectureAMD64, buildv1.ArchitectureARM64}
+	architectures.Status.Architectures = []buildv1.ArchitectureStatus{
+		{Architecture: buildv1.ArchitectureAMD64, Phase: string(buildv1.BuildPhaseCompleted)},
+		{Architecture: buildv1.ArchitectureARM64, Phase: string(buildv1.BuildPhaseBuilding)},
+	}
+
+	testcases := []struct {
+		name  string
+		build *buildv1.Build
+		want  buildv1.BuildProgress
+	}{
+		{
+			name:  "pending",
+			build: pending,
+			want:  buildv1.BuildProgress{CurrentStep: 1, TotalSteps: 6, Message: "Provisioning the infrastructure"},
+		},
+		{
+			name:  "queued",
+			build: queued,
+			want:  buildv1.BuildProgress{CurrentStep: 1, TotalSteps: 6, Message: "Build is queued"},
+		},
+		{
+			name:  "running a provisioner",
+			build: building,
+			want:  buildv1.BuildProgress{CurrentStep: 4, TotalSteps: 6, Percent: 50, Message: "Running provisioner harden"},
+		},
+		{
+			name:  "running provisioners concurrently",
+			build: concurrent,
+			want:  buildv1.BuildProgress{CurrentStep: 4, TotalSteps: 6, Percent: 50, Message: "Running provisioners harden, test"},
+		},
+		{
+			name:  "provisioner allowed to fail",
+			build: allowedFailure,
+			want:  buildv1.BuildProgress{CurrentStep: 5, TotalSteps: 5, Percent: 80, Message: "Exporting the image"},
+		},
+		{
+			name:  "awaiting approval",
+			build: awaitingApproval,
+			want:  buildv1.BuildProgress{CurrentStep: 4, TotalSteps: 6, Percent: 50, Message: "Provisioner harden is waiting to be approved"},
+		},
+		{
+			name:  "publishing the exports",
+			build: publishing,
+			want:  buildv1.BuildProgress{CurrentStep: 5, TotalSteps: 5, Percent: 80, Message: "Publishing the exports"},
+		},
+		{
+			name:  "rehearsal",
+			build: rehearsal,
+			want:  buildv1.BuildProgress{CurrentStep: 1, TotalSteps: 2, Message: "Running provisioner base"},
+		},
+		{
+			name:  "failed",
+			build: failed,
+			want:  buildv1.BuildProgress{CurrentStep: 4, TotalSteps: 6, Percent: 50, Message: "Build failed at step 4 of 6"},
+		},
+		{
+			name:  "completed",
+			build: completed,
+			want:  buildv1.BuildProgress{CurrentStep: 4, TotalSteps: 4, Percent: 100, Message: "Build completed"},
+		},
+		{
+			name:  "architectures",
+			build: architectures,
+			want:  buildv1.BuildProgress{CurrentStep: 2, TotalSteps: 2, Percent: 50, Message: "Building the images of arm64"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(buildProgress(tc.build)).To(Equal(&tc.want))
+		})
+	}
+}
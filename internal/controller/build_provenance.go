@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+const (
+	// provenanceBuildType is the buildType of the SLSA provenance of the exports, describing how a Build is run.
+	provenanceBuildType = "https://forge.build/provenance/build/v1"
+	// provenanceBuilderID identifies the Build controller as the builder in the SLSA provenance of the exports.
+	provenanceBuilderID = "https://forge.build/controller"
+)
+
+// provenance is the SLSA v1 provenance predicate of the exports of a Build.
+type provenance struct {
+	BuildDefinition provenanceBuildDefinition `json:"buildDefinition"`
+	RunDetails      provenanceRunDetails      `json:"runDetails"`
+}
+
+type provenanceBuildDefinition struct {
+	BuildType            string                    `json:"buildType"`
+	ExternalParameters   map[string]string         `json:"externalParameters"`
+	InternalParameters   map[string]string         `json:"internalParameters,omitempty"`
+	ResolvedDependencies []provenanceResourceDescr `json:"resolvedDependencies,omitempty"`
+}
+
+type provenanceResourceDescr struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type provenanceRunDetails struct {
+	Builder  provenanceBuilder  `json:"builder"`
+	Metadata provenanceMetadata `json:"metadata"`
+}
+
+type provenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+type provenanceMetadata struct {
+	InvocationID string     `json:"invocationId"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// provenanceFor returns the SLSA provenance predicate of the exports of the Build: the spec of the Build as the
+// external parameters, its provisioners as the resolved dependencies, and the times the Build started and its
+// image was exported.
+func provenanceFor(build *buildv1.Build) ([]byte, error) {
+	specDigest, err := digestOf(build.Spec)
+	if err != nil {
+		return nil, err
+	}
+	predicate := provenance{
+		BuildDefinition: provenanceBuildDefinition{
+			BuildType: provenanceBuildType,
+			ExternalParameters: map[string]string{
+				"namespace":  build.Namespace,
+				"name":       build.Name,
+				"specDigest": "sha256:" + specDigest,
+			},
+		},
+		RunDetails: provenanceRunDetails{
+			Builder:  provenanceBuilder{ID: provenanceBuilderID},
+			Metadata: provenanceMetadata{InvocationID: string(build.UID)},
+		},
+	}
+	if ref := build.Spec.InfrastructureRef; ref != nil {
+		predicate.BuildDefinition.InternalParameters = map[string]string{
+			"infrastructureKind": ref.Kind,
+			"imageRef":           build.Status.ImageRef,
+		}
+	}
+	for _, p := range build.Spec.Provisioners {
+		digest, err := provisionerDigest(p, nil)
+		if err != nil {
+			return nil, err
+		}
+		predicate.BuildDefinition.ResolvedDependencies = append(predicate.BuildDefinition.ResolvedDependencies,
+			provenanceResourceDescr{Name: "provisioner/" + p.Name, Digest: map[string]string{"sha256": digest}})
+	}
+	if build.Status.StartTime != nil {
+		predicate.RunDetails.Metadata.StartedOn = ptrToUTC(build.Status.StartTime.Time)
+	}
+	if c := conditions.Get(build, buildv1.ImageExportedCondition); c != nil {
+		predicate.RunDetails.Metadata.FinishedOn = ptrToUTC(c.LastTransitionTime.Time)
+	}
+	return json.Marshal(predicate)
+}
+
+// provisionerDigest returns the hex sha256 digest of the definition of the provisioner, not of the progress of the
+// Build, along with the scripts of its configmaps if any.
+func provisionerDigest(p buildv1.ProvisionerSpec, scripts map[string]map[string]string) (string, error) {
+	p.UUID, p.Status, p.FailureReason, p.FailureMessage, p.Issues, p.Results = nil, nil, nil, nil, nil, nil
+	if len(scripts) == 0 {
+		return digestOf(p)
+	}
+	return digestOf(struct {
+		Provisioner buildv1.ProvisionerSpec      `json:"provisioner"`
+		Scripts     map[string]map[string]string `json:"scripts"`
+	}{p, scripts})
+}
+
+// digestOf returns the hex sha256 digest of the JSON of v.
+func digestOf(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func ptrToUTC(t time.Time) *time.Time {
+	t = t.UTC()
+	return &t
+}
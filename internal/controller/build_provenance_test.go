@@ -0,0 +1,56 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestProvenanceFor(t *testing.T) {
+	g := NewWithT(t)
+
+	build := newExportingBuild()
+	build.Status.StartTime = ptr.To(metav1.NewTime(time.Date(2024, 5, 1, 10, 31, 0, 0, time.UTC)))
+	build.Spec.Provisioners = []buildv1.ProvisionerSpec{{Name: "packages", Type: buildv1.ProvisionerTypeShell, Run: ptr.To("apt-get upgrade -y")}}
+
+	data, err := provenanceFor(build)
+	g.Expect(err).ToNot(HaveOccurred())
+	predicate := provenance{}
+	g.Expect(json.Unmarshal(data, &predicate)).To(Succeed())
+	g.Expect(predicate.BuildDefinition.BuildType).To(Equal(provenanceBuildType))
+	g.Expect(predicate.BuildDefinition.ExternalParameters).To(HaveKeyWithValue("name", "ubuntu"))
+	g.Expect(predicate.BuildDefinition.ResolvedDependencies).To(HaveLen(1))
+	g.Expect(predicate.RunDetails.Metadata.InvocationID).To(Equal("build-uid"))
+	g.Expect(predicate.RunDetails.Metadata.StartedOn).To(HaveValue(Equal(build.Status.StartTime.Time)))
+	g.Expect(predicate.RunDetails.Metadata.FinishedOn).ToNot(BeNil())
+
+	// The progress of the provisioners does not change their digest.
+	digest := predicate.BuildDefinition.ResolvedDependencies[0].Digest["sha256"]
+	build.Spec.Provisioners[0].Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	data, err = provenanceFor(build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(json.Unmarshal(data, &predicate)).To(Succeed())
+	g.Expect(predicate.BuildDefinition.ResolvedDependencies[0].Digest["sha256"]).To(Equal(digest))
+}
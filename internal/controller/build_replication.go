@@ -0,0 +1,69 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// reconcileReplications reports the status of the targets of spec.artifact.replication from the replications
+// reported by the infrastructure object of the given kind. The targets not reported once the infrastructure object is
+// ready have not been replicated to by its provider, they are failed. A failed target does not fail the Build, it is
+// reported with a Warning event.
+func (r *BuildReconciler) reconcileReplications(build *buildv1.Build, kind string, reported []buildv1.ReplicationStatus, ready bool) {
+	var replication *buildv1.ReplicationSpec
+	if build.Spec.Artifact != nil {
+		replication = build.Spec.Artifact.Replication
+	}
+	targets := replication.Targets()
+	if len(targets) == 0 {
+		build.Status.Replications = nil
+		return
+	}
+
+	for i := range targets {
+		target := &targets[i]
+		if status := findReplication(reported, target.Type, target.Name); status != nil {
+			*target = *status
+		} else if ready {
+			target.Phase = buildv1.ReplicationPhaseFailed
+			target.Message = fmt.Sprintf("the %s provider does not replicate images to this %s", kind, strings.ToLower(string(target.Type)))
+		}
+
+		previous := findReplication(build.Status.Replications, target.Type, target.Name)
+		if target.Phase == buildv1.ReplicationPhaseFailed && (previous == nil || previous.Phase != buildv1.ReplicationPhaseFailed) {
+			r.recorder.Eventf(build, corev1.EventTypeWarning, "ReplicationFailed", "Failed to replicate the image to %s %s: %s",
+				target.Type, target.Name, target.Message)
+		}
+	}
+	build.Status.Replications = targets
+}
+
+// findReplication returns the status of the replication to the target, nil if not found.
+func findReplication(replications []buildv1.ReplicationStatus, targetType buildv1.ReplicationTargetType, name string) *buildv1.ReplicationStatus {
+	for i := range replications {
+		if replications[i].Type == targetType && replications[i].Name == name {
+			return &replications[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestReconcileReplications(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{
+		Spec: buildv1.BuildSpec{
+			Artifact: &buildv1.ArtifactSpec{Replication: &buildv1.ReplicationSpec{
+				Regions:  []string{"us-east-1"},
+				Accounts: []string{"111111111111", "222222222222"},
+			}},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{recorder: recorder}
+
+	// The targets not reported yet are pending.
+	reported := []buildv1.ReplicationStatus{
+		{Type: buildv1.ReplicationTargetAccount, Name: "111111111111", Phase: buildv1.ReplicationPhaseReplicated, ImageRef: "ami-1"},
+	}
+	r.reconcileReplications(build, "AWSBuild", reported, false)
+	g.Expect(build.Status.Replications).To(Equal([]buildv1.ReplicationStatus{
+		{Type: buildv1.ReplicationTargetRegion, Name: "us-east-1", Phase: buildv1.ReplicationPhasePending},
+		{Type: buildv1.ReplicationTargetAccount, Name: "111111111111", Phase: buildv1.ReplicationPhaseReplicated, ImageRef: "ami-1"},
+		{Type: buildv1.ReplicationTargetAccount, Name: "222222222222", Phase: buildv1.ReplicationPhasePending},
+	}))
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// The targets not reported once the infrastructure object is ready are failed, without failing the Build.
+	reported = append(reported, buildv1.ReplicationStatus{
+		Type: buildv1.ReplicationTargetRegion, Name: "us-east-1", Phase: buildv1.ReplicationPhaseFailed, Message: "AMI ami-2 is failed",
+	})
+	r.reconcileReplications(build, "AWSBuild", reported, true)
+	g.Expect(build.Status.Replications[0].Phase).To(Equal(buildv1.ReplicationPhaseFailed))
+	g.Expect(build.Status.Replications[2].Phase).To(Equal(buildv1.ReplicationPhaseFailed))
+	g.Expect(build.Status.Replications[2].Message).To(Equal("the AWSBuild provider does not replicate images to this account"))
+	g.Expect(isFailed(build)).To(BeFalse())
+	g.Expect(recorder.Events).To(HaveLen(2))
+
+	// The failures are only reported once.
+	r.reconcileReplications(build, "AWSBuild", reported, true)
+	g.Expect(recorder.Events).To(HaveLen(2))
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/external"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// attemptSuffix matches the suffix of the infrastructure objects recreated for a retry or after a preemption.
+var attemptSuffix = regexp.MustCompile(`-(retry|preempted)-[0-9]+$`)
+
+// reconcileRetry retries a failed Build according to its RetryPolicy. It returns true if the Build is waiting
+// for a retry or has been reset for a retry, in which case the other phases must not run in this reconcile.
+func (r *BuildReconciler) reconcileRetry(ctx context.Context, build *buildv1.Build) (ctrl.Result, bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if !isFailed(build) || !shouldRetry(build) {
+		return ctrl.Result{}, false, nil
+	}
+
+	now := time.Now()
+	if build.Status.NextRetryTime == nil {
+		build.Status.FailedAttempts = append(build.Status.FailedAttempts, buildv1.BuildAttempt{
+			Attempt:        build.Status.Retries,
+			FailureReason:  build.Status.FailureReason,
+			FailureMessage: build.Status.FailureMessage,
+			FailureTime:    metav1.NewTime(now),
+		})
+		delay := retryBackoff(build.Spec.RetryPolicy, build.Status.Retries)
+		build.Status.NextRetryTime = &metav1.Time{Time: now.Add(delay)}
+		log.Info("Build failed, scheduling a retry", "retry", build.Status.Retries+1, "after", delay)
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "RetryScheduled", "Build %s is going to be retried in %s (retry %d/%d)",
+			build.Name, delay, build.Status.Retries+1, build.Spec.RetryPolicy.MaxRetries)
+		return ctrl.Result{RequeueAfter: delay}, true, nil
+	}
+
+	if wait := build.Status.NextRetryTime.Sub(now); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, true, nil
+	}
+
+	if err := r.recreateInfrastructure(ctx, build, fmt.Sprintf("retry-%d", build.Status.Retries+1), false); err != nil {
+		return ctrl.Result{}, true, err
+	}
+	resetForRetry(build)
+	log.Info("Retrying Build", "retry", build.Status.Retries)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "Retrying", "Retrying Build %s (retry %d/%d)",
+		build.Name, build.Status.Retries, build.Spec.RetryPolicy.MaxRetries)
+	return ctrl.Result{Requeue: true}, true, nil
+}
+
+// isFailed returns true if the Build reports a failure.
+func isFailed(build *buildv1.Build) bool {
+	return build.Status.FailureReason != nil || build.Status.FailureMessage != nil
+}
+
+// shouldRetry returns true if the failure of the Build is retried by its RetryPolicy.
+func shouldRetry(build *buildv1.Build) bool {
+	policy := build.Spec.RetryPolicy
+	// The Builds of the architectures of a multi-architecture Build are retried instead.
+	if policy == nil || build.Spec.Rehearsal() || len(build.Spec.Architectures) > 0 || build.Status.Retries >= policy.MaxRetries || !build.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	reason := forgeerrors.UpdateBuildError
+	if build.Status.FailureReason != nil {
+		reason = *build.Status.FailureReason
+	}
+	if len(policy.RetryOn) == 0 {
+		return reason != forgeerrors.InvalidConfigurationBuildError && reason != forgeerrors.InvalidCredentialsBuildError
+	}
+	return slices.Contains(policy.RetryOn, reason)
+}
+
+// retryBackoff returns the delay before the given retry, the delay doubles after every retry up to the maximum.
+func retryBackoff(policy *buildv1.RetryPolicy, retries int32) time.Duration {
+	initial, maxDelay := buildv1.DefaultRetryInitialBackoff, buildv1.DefaultRetryMaxBackoff
+	if policy.Backoff != nil {
+		if policy.Backoff.Initial != nil {
+			initial = policy.Backoff.Initial.Duration
+		}
+		if policy.Backoff.Max != nil {
+			maxDelay = policy.Backoff.Max.Duration
+		}
+	}
+
+	delay := initial
+	for i := int32(0); i < retries && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// recreateInfrastructure replaces the infrastructure object of the Build with a copy of its spec named with the
+// given suffix, so the infrastructure provider provisions a new machine, on demand if onDemand is true. The copy is
+// created before the deletion of the failed object, so the infrastructure definition is never lost.
+func (r *BuildReconciler) recreateInfrastructure(ctx context.Context, build *buildv1.Build, suffix string, onDemand bool) error {
+	ref := build.Spec.InfrastructureRef
+	if ref == nil {
+		return nil
+	}
+
+	failed, err := external.Get(ctx, r.Client, ref, build.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return forgeerrors.ConfigErrorf("%s %s not found, it can't be recreated", ref.Kind, ref.Name)
+		}
+		return err
+	}
+
+	name := attemptSuffix.ReplaceAllString(failed.GetName(), "") + "-" + suffix
+	replacement := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	replacement.SetAPIVersion(failed.GetAPIVersion())
+	replacement.SetKind(failed.GetKind())
+	replacement.SetNamespace(failed.GetNamespace())
+	replacement.SetName(name)
+	replacement.SetLabels(failed.GetLabels())
+	replacement.SetAnnotations(failed.GetAnnotations())
+	replacement.SetOwnerReferences(failed.GetOwnerReferences())
+	if spec, ok := failed.Object["spec"].(map[string]interface{}); ok {
+		spec = runtime.DeepCopyJSON(spec)
+		// The provider ID identifies the machine of the failed attempt.
+		delete(spec, "providerID")
+		if onDemand {
+			delete(spec, "spot")
+		}
+		replacement.Object["spec"] = spec
+	}
+	if err := r.Client.Create(ctx, replacement); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create %s %s to replace %s", replacement.GetKind(), name, failed.GetName())
+	}
+
+	if err := r.Client.Delete(ctx, failed); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete %s %s of the failed machine", failed.GetKind(), failed.GetName())
+	}
+
+	build.Spec.InfrastructureRef = &corev1.ObjectReference{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  ref.Namespace,
+		Name:       name,
+	}
+	return nil
+}
+
+// resetForRetry resets the status of the Build and of its provisioners, so the Build runs from the start.
+func resetForRetry(build *buildv1.Build) {
+	build.Status.Retries++
+	build.Status.NextRetryTime = nil
+	build.Status.StartTime = nil
+	build.Status.CompletionTime = nil
+	build.Status.SetTypedPhase(buildv1.BuildPhasePending)
+	resetMachine(build)
+}
+
+// resetMachine resets the status of the Build related to its machine and the status of its provisioners, so the
+// provisioners run from the start on a new machine.
+func resetMachine(build *buildv1.Build) {
+	build.Status.FailureReason = nil
+	build.Status.FailureMessage = nil
+	build.Status.InfrastructureReady = false
+	build.Status.Connected = false
+	build.Status.Connection = nil
+	build.Status.ProvisionersReady = false
+	build.Status.Ready = false
+	build.Status.ImageRef = ""
+	build.Status.Artifact = nil
+	build.Status.Exports = nil
+	build.Status.SBOM = nil
+	build.Status.Scan = nil
+	build.Status.Cache = nil
+
+	build.Status.Provisioners = nil
+	for i := range build.Spec.Provisioners {
+		p := &build.Spec.Provisioners[i]
+		p.UUID = nil
+		p.Status = nil
+		p.FailureReason = nil
+		p.FailureMessage = nil
+		p.Issues = nil
+		p.Results = nil
+	}
+
+	for _, c := range slices.Clone(build.Status.Conditions) {
+		switch {
+		case strings.HasPrefix(c.Type, buildv1.ProvisionerReadyConditionPrefix),
+			c.Type == buildv1.InfrastructureReadyCondition,
+			c.Type == buildv1.MachineReadyCondition,
+			c.Type == buildv1.ConnectedCondition,
+			c.Type == buildv1.ProvisionersReadyCondition,
+			c.Type == buildv1.ImageExportedCondition,
+			c.Type == buildv1.ExportsPublishedCondition,
+			c.Type == buildv1.DeadlineExceededCondition:
+			conditions.Delete(build, c.Type)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestShouldRetry(t *testing.T) {
+	testcases := []struct {
+		name     string
+		policy   *buildv1.RetryPolicy
+		retries  int32
+		reason   forgeerrors.BuildStatusError
+		expected bool
+	}{
+		{
+			name:     "no retry policy",
+			reason:   forgeerrors.CreateBuildError,
+			expected: false,
+		},
+		{
+			name:     "any failure but invalid configuration",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2},
+			reason:   forgeerrors.CreateBuildError,
+			expected: true,
+		},
+		{
+			name:     "invalid configuration",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2},
+			reason:   forgeerrors.InvalidConfigurationBuildError,
+			expected: false,
+		},
+		{
+			name:     "invalid credentials",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2},
+			reason:   forgeerrors.InvalidCredentialsBuildError,
+			expected: false,
+		},
+		{
+			name:     "retries exhausted",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2},
+			retries:  2,
+			reason:   forgeerrors.CreateBuildError,
+			expected: false,
+		},
+		{
+			name:     "reason in retryOn",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2, RetryOn: []forgeerrors.BuildStatusError{"SpotPreempted", "QuotaExceeded"}},
+			reason:   "QuotaExceeded",
+			expected: true,
+		},
+		{
+			name:     "reason not in retryOn",
+			policy:   &buildv1.RetryPolicy{MaxRetries: 2, RetryOn: []forgeerrors.BuildStatusError{"SpotPreempted"}},
+			reason:   forgeerrors.ProvisionerFailedError,
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{}
+			build.Spec.RetryPolicy = tc.policy
+			build.Status.Retries = tc.retries
+			build.Status.FailureReason = ptr.To(tc.reason)
+			g.Expect(shouldRetry(build)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	g := NewWithT(t)
+
+	policy := &buildv1.RetryPolicy{MaxRetries: 10}
+	g.Expect(retryBackoff(policy, 0)).To(Equal(time.Minute))
+	g.Expect(retryBackoff(policy, 3)).To(Equal(8 * time.Minute))
+	g.Expect(retryBackoff(policy, 9)).To(Equal(30 * time.Minute))
+
+	policy.Backoff = &buildv1.RetryBackoff{
+		Initial: &metav1.Duration{Duration: 10 * time.Second},
+		Max:     &metav1.Duration{Duration: time.Minute},
+	}
+	g.Expect(retryBackoff(policy, 0)).To(Equal(10 * time.Second))
+	g.Expect(retryBackoff(policy, 1)).To(Equal(20 * time.Second))
+	g.Expect(retryBackoff(policy, 5)).To(Equal(time.Minute))
+}
+
+func TestResetForRetry(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{}
+	build.Spec.Provisioners = []buildv1.ProvisionerSpec{{
+		UUID:          ptr.To("p1"),
+		Status:        ptr.To(buildv1.ProvisionerStatusFailed),
+		FailureReason: ptr.To("Error"),
+	}}
+	build.Status.FailureReason = ptr.To(forgeerrors.ProvisionerFailedError)
+	build.Status.FailureMessage = ptr.To("provisioner failed")
+	build.Status.InfrastructureReady = true
+	build.Status.Connected = true
+	build.Status.Connection = &buildv1.ConnectionStatus{Address: "10.0.0.1:22", AuthMethod: "key"}
+	build.Status.NextRetryTime = &metav1.Time{Time: time.Now()}
+	build.Status.Exports = []buildv1.ExportStatus{{Name: "vagrant", Phase: buildv1.ExportPhaseFailed}}
+	build.Status.SetTypedPhase(buildv1.BuildPhaseFailed)
+	conditions.MarkTrue(build, buildv1.TemplateResolvedCondition, buildv1.TemplateResolvedReason, "")
+	conditions.MarkTrue(build, buildv1.ConnectedCondition, buildv1.ConnectionEstablishedReason, "")
+	conditions.MarkFalse(build, buildv1.ProvisionerReadyCondition("p1"), buildv1.ProvisionerFailedReason, "")
+	conditions.MarkFalse(build, buildv1.ExportsPublishedCondition, buildv1.ExportFailedReason, "")
+
+	resetForRetry(build)
+
+	g.Expect(build.Status.Retries).To(Equal(int32(1)))
+	g.Expect(build.Status.NextRetryTime).To(BeNil())
+	g.Expect(build.Status.FailureReason).To(BeNil())
+	g.Expect(build.Status.FailureMessage).To(BeNil())
+	g.Expect(build.Status.InfrastructureReady).To(BeFalse())
+	g.Expect(build.Status.Connected).To(BeFalse())
+	g.Expect(build.Status.Connection).To(BeNil())
+	g.Expect(build.Status.Exports).To(BeNil())
+	g.Expect(build.Status.GetTypedPhase()).To(Equal(buildv1.BuildPhasePending))
+	g.Expect(build.Spec.Provisioners[0].UUID).To(BeNil())
+	g.Expect(build.Spec.Provisioners[0].Status).To(BeNil())
+	g.Expect(build.Status.Conditions).To(HaveLen(1))
+	g.Expect(conditions.IsTrue(build, buildv1.TemplateResolvedCondition)).To(BeTrue())
+}
+
+func TestReconcilePhases(t *testing.T) {
+	testcases := []struct {
+		name           string
+		err            error
+		expectedRan    []string
+		expectedFailed bool
+		expectedErr    bool
+	}{
+		{
+			name:        "retryable error",
+			err:         forgeerrors.NewTransient(errors.New("connection refused")),
+			expectedRan: []string{"template", "infrastructure", "provisioners"},
+		},
+		{
+			name:        "unclassified error",
+			err:         errors.New("conflict"),
+			expectedRan: []string{"template", "infrastructure", "provisioners"},
+			expectedErr: true,
+		},
+		{
+			name:           "terminal error",
+			err:            forgeerrors.ConfigErrorf("invalid template"),
+			expectedRan:    []string{"template", "infrastructure"},
+			expectedFailed: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ran := []string{}
+			phase := func(name string, err error) func(context.Context, *buildv1.Build) (ctrl.Result, error) {
+				return func(context.Context, *buildv1.Build) (ctrl.Result, error) {
+					ran = append(ran, name)
+					return ctrl.Result{}, err
+				}
+			}
+			build := &buildv1.Build{}
+			r := &BuildReconciler{}
+			_, err := r.reconcilePhases(context.Background(), build, []func(context.Context, *buildv1.Build) (ctrl.Result, error){
+				phase("template", nil),
+				phase("infrastructure", tc.err),
+				phase("provisioners", nil),
+			})
+			g.Expect(err != nil).To(Equal(tc.expectedErr))
+			g.Expect(ran).To(Equal(tc.expectedRan))
+			g.Expect(isFailed(build)).To(Equal(tc.expectedFailed))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/sbom"
+)
+
+// reconcileSBOM generates the SBOM of the machine of the Build as per spec.artifact.sbom once its provisioners
+// completed. The machine is not provisioned in dry-run and simulation modes, so there is nothing to inventory.
+func (r *BuildReconciler) reconcileSBOM(ctx context.Context, build *buildv1.Build) error {
+	if build.Spec.Artifact == nil || build.Spec.Artifact.SBOM == nil || build.Status.SBOM != nil ||
+		build.Spec.DryRun || build.Spec.Simulate {
+		return nil
+	}
+	if err := sbom.Reconcile(ctx, r.Client, build); err != nil {
+		return err
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "SBOMGenerated", "%s SBOM generated with %s, stored in %s",
+		build.Status.SBOM.Format, build.Status.SBOM.Generator, build.Status.SBOM.Location)
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestReconcileSBOM(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec:       buildv1.BuildSpec{Artifact: &buildv1.ArtifactSpec{SBOM: &buildv1.SBOMSpec{}}},
+	}
+
+	// There is no machine to inventory in dry-run mode.
+	build.Spec.DryRun = true
+	g.Expect(r.reconcileSBOM(context.Background(), build)).To(Succeed())
+	g.Expect(build.Status.SBOM).To(BeNil())
+
+	// The SBOM is generated through the connector, it is required.
+	build.Spec.DryRun = false
+	err := r.reconcileSBOM(context.Background(), build)
+	g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("requires the connector credentials")))
+
+	// The SBOM is only generated once.
+	build.Status.SBOM = &buildv1.SBOMStatus{Location: "configmap/ubuntu-sbom/sbom.spdx.json"}
+	g.Expect(r.reconcileSBOM(context.Background(), build)).To(Succeed())
+	g.Expect(recorder.Events).To(BeEmpty())
+}
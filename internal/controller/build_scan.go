@@ -0,0 +1,43 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/scan"
+)
+
+// reconcileScan scans the machine of the Build for vulnerabilities as per spec.scan once its provisioners completed,
+// the Build fails when the scan does not pass. The machine is not provisioned in dry-run and simulation modes, so
+// there is nothing to scan.
+func (r *BuildReconciler) reconcileScan(ctx context.Context, build *buildv1.Build) error {
+	if build.Spec.Scan == nil || build.Status.Scan != nil || build.Spec.DryRun || build.Spec.Simulate {
+		return nil
+	}
+	if err := scan.Reconcile(ctx, r.Client, build); err != nil {
+		return err
+	}
+	v := build.Status.Scan.Vulnerabilities
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "ScanPassed",
+		"Scan passed with %d critical, %d high, %d medium and %d low vulnerabilities (%d ignored), report stored in %s",
+		v.Critical, v.High, v.Medium, v.Low, build.Status.Scan.Ignored, build.Status.Scan.Report)
+	return nil
+}
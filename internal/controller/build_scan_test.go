@@ -0,0 +1,62 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestReconcileScan(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: recorder}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+		Spec:       buildv1.BuildSpec{Scan: &buildv1.ScanSpec{}},
+	}
+
+	// There is no machine to scan in simulation mode.
+	build.Spec.Simulate = true
+	g.Expect(r.reconcileScan(context.Background(), build)).To(Succeed())
+	g.Expect(build.Status.Scan).To(BeNil())
+
+	// The machine is scanned through the connector, it is required.
+	build.Spec.Simulate = false
+	err := r.reconcileScan(context.Background(), build)
+	g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("requires the connector credentials")))
+
+	// The machine is only scanned once.
+	build.Status.Scan = &buildv1.ScanStatus{Passed: true, Report: "configmap/ubuntu-scan/scan-report.json"}
+	g.Expect(r.reconcileScan(context.Background(), build)).To(Succeed())
+	g.Expect(recorder.Events).To(BeEmpty())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// allowAllInfrastructureKinds allows every kind of infrastructure in the allowed-infrastructure-kinds annotation.
+const allowAllInfrastructureKinds = "*"
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// reconcileTenancy fails the Build, in multi-tenancy mode, when its infrastructure object is in another namespace
+// or of a kind not allowed by the allowed-infrastructure-kinds annotation of its namespace. The infrastructure
+// providers only read the credentials from the namespace of the Build, so a namespace can only build images with
+// the providers and the credentials of its own.
+func (r *BuildReconciler) reconcileTenancy(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	ref := build.Spec.InfrastructureRef
+	if !r.MultiTenancy || ref == nil || build.Status.InfrastructureReady {
+		return ctrl.Result{}, nil
+	}
+
+	if ref.Namespace != "" && ref.Namespace != build.Namespace {
+		conditions.MarkFalse(build, buildv1.InfrastructureReadyCondition, buildv1.InfrastructureNotAllowedReason,
+			"%s %s is in namespace %s, not in the namespace of the Build", ref.Kind, ref.Name, ref.Namespace)
+		return ctrl.Result{}, forgeerrors.ConfigErrorf("%s %s is in namespace %s, the infrastructure must be in namespace %s",
+			ref.Kind, ref.Name, ref.Namespace, build.Namespace)
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: build.Namespace}, namespace); err != nil {
+		return ctrl.Result{}, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get namespace %s", build.Namespace))
+	}
+	if !infrastructureKindAllowed(namespace, ref.Kind) {
+		conditions.MarkFalse(build, buildv1.InfrastructureReadyCondition, buildv1.InfrastructureNotAllowedReason,
+			"%s is not allowed in namespace %s", ref.Kind, build.Namespace)
+		return ctrl.Result{}, forgeerrors.ConfigErrorf("%s is not allowed in namespace %s, allowed kinds are %q",
+			ref.Kind, build.Namespace, namespace.Annotations[buildv1.AllowedInfrastructureKindsAnnotation])
+	}
+	return ctrl.Result{}, nil
+}
+
+// infrastructureKindAllowed returns true if the allowed-infrastructure-kinds annotation of the namespace lists the kind.
+func infrastructureKindAllowed(namespace *corev1.Namespace, kind string) bool {
+	for _, allowed := range strings.Split(namespace.Annotations[buildv1.AllowedInfrastructureKindsAnnotation], ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == allowAllInfrastructureKinds || allowed == kind {
+			return true
+		}
+	}
+	return false
+}
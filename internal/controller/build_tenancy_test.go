@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileTenancy(t *testing.T) {
+	testcases := []struct {
+		name           string
+		multiTenancy   bool
+		allowedKinds   *string
+		infraNamespace string
+		expectedErr    forgeerrors.Category
+	}{
+		{
+			name: "multi-tenancy disabled",
+		},
+		{
+			name:         "kind allowed",
+			multiTenancy: true,
+			allowedKinds: ptr.To("GCPBuild, AWSBuild"),
+		},
+		{
+			name:         "all kinds allowed",
+			multiTenancy: true,
+			allowedKinds: ptr.To("*"),
+		},
+		{
+			name:         "kind not allowed",
+			multiTenancy: true,
+			allowedKinds: ptr.To("GCPBuild"),
+			expectedErr:  forgeerrors.CategoryConfigError,
+		},
+		{
+			name:         "namespace without allowed kinds",
+			multiTenancy: true,
+			expectedErr:  forgeerrors.CategoryConfigError,
+		},
+		{
+			name:           "infrastructure in another namespace",
+			multiTenancy:   true,
+			allowedKinds:   ptr.To("*"),
+			infraNamespace: "platform",
+			expectedErr:    forgeerrors.CategoryConfigError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+			if tc.allowedKinds != nil {
+				namespace.Annotations = map[string]string{buildv1.AllowedInfrastructureKindsAnnotation: *tc.allowedKinds}
+			}
+			build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "ubuntu"}}
+			build.Spec.InfrastructureRef = &corev1.ObjectReference{
+				APIVersion: "infrastructure.forge.build/v1alpha1",
+				Kind:       "AWSBuild",
+				Namespace:  tc.infraNamespace,
+				Name:       "ubuntu",
+			}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).Build()
+			r := &BuildReconciler{Client: c, Scheme: scheme, MultiTenancy: tc.multiTenancy}
+
+			_, err := r.reconcileTenancy(context.Background(), build)
+			if tc.expectedErr != "" {
+				g.Expect(forgeerrors.Classify(err)).To(Equal(tc.expectedErr))
+				g.Expect(conditions.GetReason(build, buildv1.InfrastructureReadyCondition)).To(Equal(buildv1.InfrastructureNotAllowedReason))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(conditions.Has(build, buildv1.InfrastructureReadyCondition)).To(BeFalse())
+		})
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/external"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// deadline is a timeout of the Build which applies to its current phase.
+type deadline struct {
+	reason  string
+	message string
+	at      time.Time
+}
+
+// reconcileTimeouts fails the Build when one of its timeouts is exceeded. It returns true if the Build has been failed,
+// in which case the other phases must not run in this reconcile, otherwise the Build is requeued at the next deadline.
+func (r *BuildReconciler) reconcileTimeouts(ctx context.Context, build *buildv1.Build) (ctrl.Result, bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	now := time.Now()
+	if build.Status.StartTime == nil {
+		build.Status.StartTime = &metav1.Time{Time: now}
+	}
+	if conditions.IsTrue(build, buildv1.DeadlineExceededCondition) {
+		// The Build has been failed by a deadline and is not retried, its resources are deleted until they are gone.
+		return ctrl.Result{}, true, r.deleteExpiredResources(ctx, build)
+	}
+	if isFailed(build) || conditions.IsTrue(build, buildv1.ImageExportedCondition) {
+		return ctrl.Result{}, false, nil
+	}
+
+	deadlines := activeDeadlines(build)
+	if len(deadlines) == 0 {
+		return ctrl.Result{}, false, nil
+	}
+	next := deadlines[0]
+	for _, d := range deadlines[1:] {
+		if d.at.Before(next.at) {
+			next = d
+		}
+	}
+	if now.Before(next.at) {
+		return ctrl.Result{RequeueAfter: next.at.Sub(now)}, false, nil
+	}
+
+	log.Info("Build exceeded its deadline", "reason", next.reason)
+	conditions.MarkTrue(build, buildv1.DeadlineExceededCondition, next.reason, "%s", next.message)
+	build.Status.FailureReason = ptr.To(forgeerrors.DeadlineExceededBuildError)
+	build.Status.FailureMessage = ptr.To(next.message)
+	r.recorder.Eventf(build, corev1.EventTypeWarning, buildv1.DeadlineExceededCondition, "%s", next.message)
+
+	// The infrastructure is recreated if the Build is going to be retried.
+	if shouldRetry(build) {
+		return ctrl.Result{}, true, shellcontroller.DeleteJobs(ctx, r.Client, build)
+	}
+	return ctrl.Result{}, true, r.deleteExpiredResources(ctx, build)
+}
+
+// deleteExpiredResources deletes the provisioner Jobs and the infrastructure of a Build failed by a deadline, so the
+// Jobs stop running against the machine and the provider releases it. The Jobs are deleted as on the Build deletion.
+func (r *BuildReconciler) deleteExpiredResources(ctx context.Context, build *buildv1.Build) error {
+	if err := shellcontroller.DeleteJobs(ctx, r.Client, build); err != nil {
+		return err
+	}
+	return r.deleteInfrastructure(ctx, build)
+}
+
+// activeDeadlines returns the deadlines of the Build applying to its current phase.
+func activeDeadlines(build *buildv1.Build) []deadline {
+	timeouts := build.Spec.Timeouts
+	// The Builds of the architectures of a multi-architecture Build enforce its timeouts.
+	if timeouts == nil || build.Status.StartTime == nil || len(build.Spec.Architectures) > 0 {
+		return nil
+	}
+	start := build.Status.StartTime.Time
+
+	deadlines := []deadline{}
+	add := func(timeout *metav1.Duration, since time.Time, reason, format string) {
+		if timeout == nil || timeout.Duration <= 0 {
+			return
+		}
+		deadlines = append(deadlines, deadline{
+			reason:  reason,
+			message: fmt.Sprintf(format, timeout.Duration),
+			at:      since.Add(timeout.Duration),
+		})
+	}
+
+	add(timeouts.OverallDeadline, start, buildv1.OverallDeadlineExceededReason,
+		"Build has not completed within the overall deadline of %s")
+	switch {
+	case !build.Status.InfrastructureReady:
+		since := start
+		// The machine recreated after a preemption has its own MachineReadyTimeout.
+		if preempted := build.Status.LastPreemptionTime; preempted != nil && preempted.After(start) {
+			since = preempted.Time
+		}
+		add(timeouts.MachineReadyTimeout, since, buildv1.MachineReadyTimeoutReason,
+			"Machine has not been ready within %s")
+	case !build.Status.Connected:
+		add(timeouts.ConnectionTimeout, transitionTime(build, buildv1.MachineReadyCondition, start), buildv1.ConnectionTimeoutReason,
+			"Connection to the machine has not been established within %s")
+	case !build.Status.ProvisionersReady:
+		add(timeouts.ProvisioningTimeout, transitionTime(build, buildv1.ConnectedCondition, start), buildv1.ProvisioningTimeoutReason,
+			"Provisioners have not completed within %s")
+	}
+	return deadlines
+}
+
+// transitionTime returns the last time the condition with the given type became True, or fallback if it is not True.
+func transitionTime(build *buildv1.Build, t string, fallback time.Time) time.Time {
+	condition := conditions.Get(build, t)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.LastTransitionTime.Before(&metav1.Time{Time: fallback}) {
+		return fallback
+	}
+	return condition.LastTransitionTime.Time
+}
+
+// deleteInfrastructure deletes the infrastructure object of the Build, so the provider releases the machine.
+func (r *BuildReconciler) deleteInfrastructure(ctx context.Context, build *buildv1.Build) error {
+	ref := build.Spec.InfrastructureRef
+	if ref == nil {
+		return nil
+	}
+	obj, err := external.Get(ctx, r.Client, ref, build.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get %s %s", ref.Kind, ref.Name)
+	}
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	if err := r.Client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "failed to delete %s %s", ref.Kind, ref.Name)
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "InfrastructureDeleted", "Deleted %s %s", ref.Kind, ref.Name)
+	return nil
+}
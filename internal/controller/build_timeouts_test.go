@@ -0,0 +1,181 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+)
+
+func TestActiveDeadlines(t *testing.T) {
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	timeouts := &buildv1.BuildTimeouts{
+		MachineReadyTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+		ConnectionTimeout:   &metav1.Duration{Duration: 5 * time.Minute},
+		ProvisioningTimeout: &metav1.Duration{Duration: time.Hour},
+		OverallDeadline:     &metav1.Duration{Duration: 2 * time.Hour},
+	}
+
+	testcases := []struct {
+		name     string
+		timeouts *buildv1.BuildTimeouts
+		status   buildv1.BuildStatus
+		expected map[string]time.Time
+	}{
+		{
+			name:     "no timeouts",
+			status:   buildv1.BuildStatus{},
+			expected: map[string]time.Time{},
+		},
+		{
+			name:     "waiting for the machine",
+			timeouts: timeouts,
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+				buildv1.MachineReadyTimeoutReason:     start.Add(10 * time.Minute),
+			},
+		},
+		{
+			name:     "waiting for the machine recreated after a preemption",
+			timeouts: timeouts,
+			status:   buildv1.BuildStatus{LastPreemptionTime: ptr.To(metav1.NewTime(start.Add(time.Hour)))},
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+				buildv1.MachineReadyTimeoutReason:     start.Add(70 * time.Minute),
+			},
+		},
+		{
+			name:     "waiting for the connection",
+			timeouts: timeouts,
+			status: buildv1.BuildStatus{
+				InfrastructureReady: true,
+				Conditions: []metav1.Condition{{
+					Type:               buildv1.MachineReadyCondition,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(start.Add(8 * time.Minute)),
+				}},
+			},
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+				buildv1.ConnectionTimeoutReason:       start.Add(13 * time.Minute),
+			},
+		},
+		{
+			name:     "provisioning",
+			timeouts: timeouts,
+			status: buildv1.BuildStatus{
+				InfrastructureReady: true,
+				Connected:           true,
+				Conditions: []metav1.Condition{{
+					Type:               buildv1.ConnectedCondition,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(start.Add(15 * time.Minute)),
+				}},
+			},
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+				buildv1.ProvisioningTimeoutReason:     start.Add(75 * time.Minute),
+			},
+		},
+		{
+			name:     "condition from a previous attempt",
+			timeouts: timeouts,
+			status: buildv1.BuildStatus{
+				InfrastructureReady: true,
+				Connected:           true,
+				Conditions: []metav1.Condition{{
+					Type:               buildv1.ConnectedCondition,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(start.Add(-time.Hour)),
+				}},
+			},
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+				buildv1.ProvisioningTimeoutReason:     start.Add(time.Hour),
+			},
+		},
+		{
+			name:     "exporting",
+			timeouts: timeouts,
+			status: buildv1.BuildStatus{
+				InfrastructureReady: true,
+				Connected:           true,
+				ProvisionersReady:   true,
+			},
+			expected: map[string]time.Time{
+				buildv1.OverallDeadlineExceededReason: start.Add(2 * time.Hour),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{}
+			build.Spec.Timeouts = tc.timeouts
+			build.Status = tc.status
+			build.Status.StartTime = &metav1.Time{Time: start}
+
+			got := map[string]time.Time{}
+			for _, d := range activeDeadlines(build) {
+				got[d.reason] = d.at
+			}
+			g.Expect(got).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcileTimeoutsDeletesJobs(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+	build.Spec.Timeouts = &buildv1.BuildTimeouts{OverallDeadline: &metav1.Duration{Duration: time.Hour}}
+	build.Status.StartTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	shellJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Namespace: shellcontroller.ForgeCoreNamespace, Name: "shell-provisioner",
+		Labels: map[string]string{buildv1.BuildNameLabel: "ubuntu", buildv1.BuildNamespaceLabel: "images"},
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(shellJob).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	_, handled, err := r.reconcileTimeouts(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(handled).To(BeTrue())
+	err = c.Get(context.Background(), client.ObjectKeyFromObject(shellJob), &batchv1.Job{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// reconcileTTL records the completion time of a finished Build and deletes the Build once its TTL after finishing
+// has expired. It returns true if the Build has been deleted, in which case the other phases must not run
+// in this reconcile, otherwise the Build is requeued when its TTL expires.
+func (r *BuildReconciler) reconcileTTL(ctx context.Context, build *buildv1.Build) (ctrl.Result, bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if !isFinished(build) {
+		return ctrl.Result{}, false, nil
+	}
+	now := time.Now()
+	if build.Status.CompletionTime == nil {
+		build.Status.CompletionTime = &metav1.Time{Time: now}
+	}
+
+	expiry, ok := ttlExpiry(build)
+	if !ok {
+		return ctrl.Result{}, false, nil
+	}
+	if wait := expiry.Sub(now); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, false, nil
+	}
+
+	log.Info("Deleting Build, its TTL after finished has expired", "completionTime", build.Status.CompletionTime)
+	if err := shellcontroller.DeleteJobs(ctx, r.Client, build); err != nil {
+		return ctrl.Result{}, true, err
+	}
+	if err := r.Client.Delete(ctx, build, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, true, errors.Wrapf(err, "failed to delete Build %s", build.Name)
+	}
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "TTLExpired", "Build %s finished %s ago, deleting it",
+		build.Name, now.Sub(build.Status.CompletionTime.Time).Round(time.Second))
+	return ctrl.Result{}, true, nil
+}
+
+// isFinished returns true if the Build completed, or failed and is not going to be retried.
+func isFinished(build *buildv1.Build) bool {
+	if isFailed(build) {
+		return !shouldRetry(build)
+	}
+	return conditions.IsTrue(build, buildv1.ImageExportedCondition) && exportsPublished(build)
+}
+
+// ttlExpiry returns the time a finished Build is deleted at, it returns false if the Build has no TTL.
+func ttlExpiry(build *buildv1.Build) (time.Time, bool) {
+	if build.Spec.TTLSecondsAfterFinished == nil || build.Status.CompletionTime == nil {
+		return time.Time{}, false
+	}
+	return build.Status.CompletionTime.Add(time.Duration(*build.Spec.TTLSecondsAfterFinished) * time.Second), true
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestIsFinished(t *testing.T) {
+	completed := &buildv1.Build{}
+	conditions.MarkTrue(completed, buildv1.ImageExportedCondition, buildv1.ImageExportedCondition, "")
+	exporting := completed.DeepCopy()
+	exporting.Spec.Exports = []buildv1.ExportSpec{{Name: "vagrant"}}
+
+	testcases := []struct {
+		name  string
+		build *buildv1.Build
+		want  bool
+	}{
+		{
+			name:  "running",
+			build: &buildv1.Build{},
+		},
+		{
+			name:  "completed",
+			build: completed,
+			want:  true,
+		},
+		{
+			name:  "exports not published",
+			build: exporting,
+		},
+		{
+			name: "failed",
+			build: &buildv1.Build{Status: buildv1.BuildStatus{
+				FailureReason: ptr.To(forgeerrors.CreateBuildError),
+			}},
+			want: true,
+		},
+		{
+			name: "failed and retried",
+			build: &buildv1.Build{
+				Spec: buildv1.BuildSpec{RetryPolicy: &buildv1.RetryPolicy{MaxRetries: 2}},
+				Status: buildv1.BuildStatus{
+					FailureReason: ptr.To(forgeerrors.CreateBuildError),
+				},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(isFinished(tc.build)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestReconcileTTL(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = buildv1.AddToScheme(scheme)
+
+	newBuild := func(ttl *int32, completedAgo time.Duration) *buildv1.Build {
+		build := &buildv1.Build{
+			ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", Finalizers: []string{buildv1.BuildFinalizer}},
+			Spec:       buildv1.BuildSpec{TTLSecondsAfterFinished: ttl},
+			Status: buildv1.BuildStatus{
+				FailureReason: ptr.To(forgeerrors.CreateBuildError),
+			},
+		}
+		if completedAgo > 0 {
+			build.Status.CompletionTime = &metav1.Time{Time: time.Now().Add(-completedAgo)}
+		}
+		return build
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name:      "shell-provisioner",
+		Namespace: shellcontroller.ForgeCoreNamespace,
+		Labels: map[string]string{
+			buildv1.BuildNameLabel:      "ubuntu",
+			buildv1.BuildNamespaceLabel: "images",
+		},
+	}}
+
+	t.Run("records the completion time without a TTL", func(t *testing.T) {
+		g := NewWithT(t)
+		build := newBuild(nil, 0)
+		r := &BuildReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build()}
+
+		res, handled, err := r.reconcileTTL(context.Background(), build)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(handled).To(BeFalse())
+		g.Expect(res.RequeueAfter).To(BeZero())
+		g.Expect(build.Status.CompletionTime).ToNot(BeNil())
+	})
+
+	t.Run("requeues until the TTL expires", func(t *testing.T) {
+		g := NewWithT(t)
+		build := newBuild(ptr.To[int32](3600), time.Minute)
+		r := &BuildReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).Build()}
+
+		res, handled, err := r.reconcileTTL(context.Background(), build)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(handled).To(BeFalse())
+		g.Expect(res.RequeueAfter).To(BeNumerically("~", 59*time.Minute, time.Second))
+	})
+
+	t.Run("deletes the Build and its Jobs once the TTL expired", func(t *testing.T) {
+		g := NewWithT(t)
+		build := newBuild(ptr.To[int32](60), time.Hour)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, job.DeepCopy()).Build()
+		r := &BuildReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+
+		_, handled, err := r.reconcileTTL(context.Background(), build)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(handled).To(BeTrue())
+
+		deleted := &buildv1.Build{}
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(build), deleted)).To(Succeed())
+		g.Expect(deleted.DeletionTimestamp.IsZero()).To(BeFalse())
+		err = c.Get(context.Background(), client.ObjectKeyFromObject(job), &batchv1.Job{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+}
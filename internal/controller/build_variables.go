@@ -0,0 +1,155 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// reconcileVariables resolves the variables of the Build and writes their values to the variables secret handed
+// to the shell provisioners. The values are resolved until the provisioners are ready, and the secret is deleted
+// once the Build has finished.
+func (r *BuildReconciler) reconcileVariables(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	if isFinished(build) {
+		return ctrl.Result{}, r.deleteVariables(ctx, build)
+	}
+	if len(build.Spec.Variables) == 0 || build.Status.ProvisionersReady {
+		return ctrl.Result{}, nil
+	}
+
+	data := map[string][]byte{}
+	masked := []string{}
+	for _, v := range build.Spec.Variables {
+		value, found, err := r.resolveVariable(ctx, build.Namespace, v)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !found {
+			continue
+		}
+		data[v.Name] = value
+		if v.ValueFrom != nil && v.ValueFrom.SecretKeyRef != nil {
+			masked = append(masked, v.Name)
+		}
+	}
+	sort.Strings(masked)
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: variablesSecretName(build)}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Type = buildv1.BuildSecretType
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[buildv1.BuildNameLabel] = build.Name
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[buildv1.MaskedVariablesAnnotation] = strings.Join(masked, ",")
+		secret.Data = data
+		return controllerutil.SetControllerReference(build, secret, r.Scheme)
+	}); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to write the variables to secret %s", secret.Name)
+	}
+	build.Status.VariablesSecretName = secret.Name
+	return ctrl.Result{}, nil
+}
+
+// resolveVariable returns the value of a variable, found is false when the source of an optional variable is missing.
+func (r *BuildReconciler) resolveVariable(ctx context.Context, namespace string, v buildv1.BuildVariable) (value []byte, found bool, err error) {
+	switch {
+	case v.ValueFrom == nil:
+		return []byte(v.Value), true, nil
+	case v.ValueFrom.SecretKeyRef != nil:
+		ref := v.ValueFrom.SecretKeyRef
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+			return variableSourceError(v.Name, "Secret", ref.Name, ptr.Deref(ref.Optional, false), err)
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			return variableKeyError(v.Name, "Secret", ref.Name, ref.Key, ptr.Deref(ref.Optional, false))
+		}
+		return value, true, nil
+	case v.ValueFrom.ConfigMapKeyRef != nil:
+		ref := v.ValueFrom.ConfigMapKeyRef
+		configMap := &corev1.ConfigMap{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, configMap); err != nil {
+			return variableSourceError(v.Name, "ConfigMap", ref.Name, ptr.Deref(ref.Optional, false), err)
+		}
+		if value, ok := configMap.Data[ref.Key]; ok {
+			return []byte(value), true, nil
+		}
+		if value, ok := configMap.BinaryData[ref.Key]; ok {
+			return value, true, nil
+		}
+		return variableKeyError(v.Name, "ConfigMap", ref.Name, ref.Key, ptr.Deref(ref.Optional, false))
+	}
+	return nil, false, forgeerrors.ConfigErrorf("variable %s has no value source", v.Name)
+}
+
+// variableSourceError returns the error of a variable whose source could not be read, a missing source is
+// a configuration error unless the variable is optional.
+func variableSourceError(name, kind, source string, optional bool, err error) ([]byte, bool, error) {
+	if !apierrors.IsNotFound(err) {
+		return nil, false, errors.Wrapf(err, "failed to get %s %s of variable %s", kind, source, name)
+	}
+	if optional {
+		return nil, false, nil
+	}
+	return nil, false, forgeerrors.ConfigErrorf("%s %s of variable %s not found", kind, source, name)
+}
+
+// variableKeyError returns the error of a variable whose key is missing from its source, unless the variable is optional.
+func variableKeyError(name, kind, source, key string, optional bool) ([]byte, bool, error) {
+	if optional {
+		return nil, false, nil
+	}
+	return nil, false, forgeerrors.ConfigErrorf("%s %s of variable %s has no key %s", kind, source, name, key)
+}
+
+// deleteVariables deletes the variables secret of the Build, the values sourced from Secrets must not outlive the Build.
+func (r *BuildReconciler) deleteVariables(ctx context.Context, build *buildv1.Build) error {
+	if build.Status.VariablesSecretName == "" {
+		return nil
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: build.Status.VariablesSecretName}}
+	if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "failed to delete the variables secret %s", secret.Name)
+	}
+	build.Status.VariablesSecretName = ""
+	return nil
+}
+
+// variablesSecretName returns the name of the secret holding the values of the variables of the Build.
+func variablesSecretName(build *buildv1.Build) string {
+	return fmt.Sprintf("%s-variables", build.Name)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcileVariables(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+	registry := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "registry"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	mirrors := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "mirrors"},
+		Data:       map[string]string{"apt": "https://mirror.example.com"},
+	}
+	secretRef := func(name, key string, optional bool) *buildv1.BuildVariableSource {
+		return &buildv1.BuildVariableSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key, Optional: ptr.To(optional),
+		}}
+	}
+
+	testcases := []struct {
+		name         string
+		variables    []buildv1.BuildVariable
+		expectedData map[string][]byte
+		expectMasked string
+		wantErr      string
+	}{
+		{
+			name: "values and sources",
+			variables: []buildv1.BuildVariable{
+				{Name: "HOSTNAME", Value: "builder"},
+				{Name: "REGISTRY_TOKEN", ValueFrom: secretRef("registry", "token", false)},
+				{Name: "APT_MIRROR", ValueFrom: &buildv1.BuildVariableSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "mirrors"}, Key: "apt",
+				}}},
+			},
+			expectedData: map[string][]byte{
+				"HOSTNAME":       []byte("builder"),
+				"REGISTRY_TOKEN": []byte("s3cr3t"),
+				"APT_MIRROR":     []byte("https://mirror.example.com"),
+			},
+			expectMasked: "REGISTRY_TOKEN",
+		},
+		{
+			name: "optional sources are skipped",
+			variables: []buildv1.BuildVariable{
+				{Name: "HOSTNAME", Value: "builder"},
+				{Name: "PROXY", ValueFrom: secretRef("proxy", "url", true)},
+				{Name: "PROXY_USER", ValueFrom: secretRef("registry", "user", true)},
+			},
+			expectedData: map[string][]byte{"HOSTNAME": []byte("builder")},
+		},
+		{
+			name:      "missing secret",
+			variables: []buildv1.BuildVariable{{Name: "PROXY", ValueFrom: secretRef("proxy", "url", false)}},
+			wantErr:   "Secret proxy of variable PROXY not found",
+		},
+		{
+			name:      "missing key",
+			variables: []buildv1.BuildVariable{{Name: "PROXY", ValueFrom: secretRef("registry", "url", false)}},
+			wantErr:   "Secret registry of variable PROXY has no key url",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(registry.DeepCopy(), mirrors.DeepCopy()).Build()
+			r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+			build := &buildv1.Build{
+				ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "build-uid"},
+				Spec:       buildv1.BuildSpec{Variables: tc.variables},
+			}
+
+			_, err := r.reconcileVariables(context.Background(), build)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(build.Status.VariablesSecretName).To(Equal("ubuntu-variables"))
+
+			secret := &corev1.Secret{}
+			g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "ubuntu-variables"}, secret)).To(Succeed())
+			g.Expect(metav1.IsControlledBy(secret, build)).To(BeTrue())
+			g.Expect(secret.Data).To(Equal(tc.expectedData))
+			g.Expect(secret.Annotations).To(HaveKeyWithValue(buildv1.MaskedVariablesAnnotation, tc.expectMasked))
+
+			// The variables secret is deleted once the Build has finished.
+			conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+			_, err = r.reconcileVariables(context.Background(), build)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(build.Status.VariablesSecretName).To(BeEmpty())
+			err = c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "ubuntu-variables"}, secret)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	}
+}
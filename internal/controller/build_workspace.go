@@ -0,0 +1,170 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;patch;update;delete
+
+// reconcileWorkspace creates the workspace of the Build and keeps the presigned URLs of its objects valid in the
+// workspace secret handed to the shell provisioners. The workspace is purged once the Build has finished.
+func (r *BuildReconciler) reconcileWorkspace(ctx context.Context, build *buildv1.Build) (ctrl.Result, error) {
+	spec := build.Spec.Workspace
+	if spec == nil {
+		return ctrl.Result{}, nil
+	}
+	if isFinished(build) {
+		return ctrl.Result{}, r.purgeWorkspace(ctx, build)
+	}
+
+	now := time.Now()
+	expiration := spec.GetURLExpiration()
+	if status := build.Status.Workspace; status != nil && status.SecretName != "" && status.ExpirationTime != nil {
+		// The URLs are renewed once half of their validity has elapsed.
+		if wait := status.ExpirationTime.Add(-expiration / 2).Sub(now); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	storage, err := r.workspaceStorage(ctx, build)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return ctrl.Result{}, forgeerrors.NewConfigError(err)
+		}
+		return ctrl.Result{}, err
+	}
+	bucket, prefix, err := workspacePrefix(build)
+	if err != nil {
+		return ctrl.Result{}, forgeerrors.NewConfigError(err)
+	}
+
+	workspaceURL := fmt.Sprintf("s3://%s/%s", bucket, prefix)
+	data := map[string][]byte{buildv1.WorkspaceURLEnv: []byte(workspaceURL)}
+	for _, object := range spec.Objects {
+		getEnv, putEnv := buildv1.WorkspaceObjectEnv(object)
+		for env, method := range map[string]string{getEnv: http.MethodGet, putEnv: http.MethodPut} {
+			presigned, err := storage.Presign(method, bucket, prefix+object, expiration)
+			if err != nil {
+				return ctrl.Result{}, forgeerrors.NewConfigError(err)
+			}
+			data[env] = []byte(presigned)
+		}
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: workspaceSecretName(build)}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Type = buildv1.BuildSecretType
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[buildv1.BuildNameLabel] = build.Name
+		secret.Data = data
+		return controllerutil.SetControllerReference(build, secret, r.Scheme)
+	}); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to write the presigned URLs of the workspace to secret %s", secret.Name)
+	}
+
+	if build.Status.Workspace == nil {
+		r.recorder.Eventf(build, corev1.EventTypeNormal, "WorkspaceCreated", "Created workspace %s", workspaceURL)
+	}
+	build.Status.Workspace = &buildv1.WorkspaceStatus{
+		URL:            workspaceURL,
+		SecretName:     secret.Name,
+		ExpirationTime: &metav1.Time{Time: now.Add(expiration)},
+	}
+	return ctrl.Result{RequeueAfter: expiration / 2}, nil
+}
+
+// purgeWorkspace deletes the objects of the workspace of the Build, and the secret holding their presigned URLs.
+func (r *BuildReconciler) purgeWorkspace(ctx context.Context, build *buildv1.Build) error {
+	status := build.Status.Workspace
+	if build.Spec.Workspace == nil || status == nil || status.Purged {
+		return nil
+	}
+
+	storage, err := r.workspaceStorage(ctx, build)
+	if err != nil {
+		return err
+	}
+	bucket, prefix, err := publish.ParseURL(status.URL)
+	if err != nil {
+		return err
+	}
+	deleted, err := storage.DeletePrefix(ctx, bucket, prefix)
+	if err != nil {
+		return errors.Wrapf(err, "failed to purge workspace %s", status.URL)
+	}
+
+	if status.SecretName != "" {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: status.SecretName}}
+		if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed to delete the secret %s of the workspace", status.SecretName)
+		}
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Purged workspace", "url", status.URL, "objects", deleted)
+	r.recorder.Eventf(build, corev1.EventTypeNormal, "WorkspacePurged", "Deleted %d object(s) of workspace %s", deleted, status.URL)
+	build.Status.Workspace = &buildv1.WorkspaceStatus{URL: status.URL, Purged: true}
+	return nil
+}
+
+// workspaceStorage returns a client of the object storage of the workspace, using the credentials of its secret.
+func (r *BuildReconciler) workspaceStorage(ctx context.Context, build *buildv1.Build) (*publish.ObjectStorage, error) {
+	destination := build.Spec.Workspace.ObjectStorage
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: build.Namespace, Name: destination.CredentialsRef.Name}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the credentials of the workspace from secret %s", key.Name)
+	}
+	return publish.NewObjectStorage(destination.Endpoint, destination.Region, publish.Credentials{
+		AccessKeyID:     string(secret.Data[buildv1.ObjectStorageAccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[buildv1.ObjectStorageSecretAccessKeyKey]),
+	})
+}
+
+// workspacePrefix returns the bucket and the prefix of the workspace of the Build, the prefix ends with a slash.
+// The UID of the Build is part of the prefix, so a Build recreated with the same name gets an empty workspace.
+func workspacePrefix(build *buildv1.Build) (bucket, prefix string, err error) {
+	bucket, prefix, err = publish.ParseURL(build.Spec.Workspace.ObjectStorage.URL)
+	if err != nil {
+		return "", "", err
+	}
+	return bucket, path.Join(prefix, build.Namespace, fmt.Sprintf("%s-%s", build.Name, build.UID)) + "/", nil
+}
+
+// workspaceSecretName returns the name of the secret holding the presigned URLs of the workspace of the Build.
+func workspaceSecretName(build *buildv1.Build) string {
+	return fmt.Sprintf("%s-workspace", build.Name)
+}
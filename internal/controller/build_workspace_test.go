@@ -0,0 +1,135 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// workspaceStorage is an in-memory object storage listing and deleting the objects of a single bucket.
+type workspaceStorage struct {
+	mu      sync.Mutex
+	objects map[string]bool
+}
+
+func (s *workspaceStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		type contents struct {
+			Key string `xml:"Key"`
+		}
+		result := struct {
+			XMLName  xml.Name   `xml:"ListBucketResult"`
+			Contents []contents `xml:"Contents"`
+		}{}
+		for key := range s.objects {
+			if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, contents{Key: key})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+	case http.MethodDelete:
+		delete(s.objects, strings.TrimPrefix(r.URL.Path, "/images/"))
+	}
+}
+
+func TestReconcileWorkspace(t *testing.T) {
+	g := NewWithT(t)
+
+	storage := &workspaceStorage{objects: map[string]bool{
+		"workspaces/images/ubuntu-build-uid/rootfs.tar": true,
+		"workspaces/images/debian-other-uid/rootfs.tar": true,
+	}}
+	server := httptest.NewServer(storage)
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "minio"},
+		Data: map[string][]byte{
+			buildv1.ObjectStorageAccessKeyIDKey:     []byte("id"),
+			buildv1.ObjectStorageSecretAccessKeyKey: []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(credentials).Build()
+	r := &BuildReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			Workspace: &buildv1.WorkspaceSpec{
+				ObjectStorage: buildv1.ObjectStorageDestination{
+					URL:            "s3://images/workspaces",
+					Endpoint:       server.URL,
+					CredentialsRef: corev1.LocalObjectReference{Name: "minio"},
+				},
+				Objects: []string{"rootfs.tar"},
+			},
+		},
+	}
+
+	res, err := r.reconcileWorkspace(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(buildv1.DefaultWorkspaceURLExpiration / 2))
+	g.Expect(build.Status.Workspace.URL).To(Equal("s3://images/workspaces/images/ubuntu-build-uid/"))
+	g.Expect(build.Status.Workspace.SecretName).To(Equal("ubuntu-workspace"))
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "ubuntu-workspace"}, secret)).To(Succeed())
+	g.Expect(metav1.IsControlledBy(secret, build)).To(BeTrue())
+	g.Expect(secret.Data).To(HaveKeyWithValue(buildv1.WorkspaceURLEnv, []byte(build.Status.Workspace.URL)))
+	g.Expect(string(secret.Data["FORGE_WORKSPACE_ROOTFS_TAR_GET_URL"])).To(HavePrefix(server.URL + "/images/workspaces/images/ubuntu-build-uid/rootfs.tar?"))
+	g.Expect(string(secret.Data["FORGE_WORKSPACE_ROOTFS_TAR_PUT_URL"])).To(ContainSubstring("X-Amz-Signature="))
+
+	// The URLs are not renewed before half of their validity has elapsed.
+	res, err = r.reconcileWorkspace(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeNumerically("<=", buildv1.DefaultWorkspaceURLExpiration/2))
+
+	// The workspace is purged once the Build has finished.
+	conditions.MarkTrue(build, buildv1.ImageExportedCondition, buildv1.ImageExportedReason, "")
+	_, err = r.reconcileWorkspace(context.Background(), build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Status.Workspace.Purged).To(BeTrue())
+	g.Expect(storage.objects).To(Equal(map[string]bool{"workspaces/images/debian-other-uid/rootfs.tar": true}))
+	err = c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "ubuntu-workspace"}, secret)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+// BuildSetControllerName is the name of the BuildSet controller, used in the controller metrics.
+const BuildSetControllerName = "buildset"
+
+// BuildSetReconciler reconciles a BuildSet object
+type BuildSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BuildSetReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&buildv1.BuildSet{}).
+		Owns(&buildv1.Build{}).
+		Named(BuildSetControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(BuildSetControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("buildset-controller")
+	return nil
+}
+
+//+kubebuilder:rbac:groups=forge.build,resources=buildsets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=buildsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=buildsets/finalizers,verbs=update
+
+// Reconcile creates a Build for every target of a BuildSet, deletes the Builds of the removed targets
+// and aggregates the statuses of the Builds.
+func (r *BuildSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	buildSet := &buildv1.BuildSet{}
+	if err := r.Client.Get(ctx, req.NamespacedName, buildSet); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(buildSet) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(buildSet, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		buildSet.Status.Health = buildSetHealth(buildSet)
+		if err := patchHelper.Patch(ctx, buildSet); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	builds := &buildv1.BuildList{}
+	if err := r.Client.List(ctx, builds, client.InNamespace(buildSet.Namespace),
+		client.MatchingLabels{buildv1.BuildSetNameLabel: buildSet.Name}); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list Builds")
+	}
+
+	targets := map[string]bool{}
+	for _, target := range buildSet.Spec.Targets {
+		targets[target.Name] = true
+	}
+
+	var errs []error
+	byTarget := map[string]*buildv1.Build{}
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if !metav1.IsControlledBy(build, buildSet) {
+			continue
+		}
+		target := build.Labels[buildv1.BuildSetTargetLabel]
+		if targets[target] {
+			byTarget[target] = build
+			continue
+		}
+		if !build.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Client.Delete(ctx, build, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to delete Build %s of removed target %s", build.Name, target))
+			continue
+		}
+		r.recorder.Eventf(buildSet, corev1.EventTypeNormal, "Deleted", "Deleted Build %s of removed target %s", build.Name, target)
+	}
+
+	finished := finishedTargets(buildSet)
+	for _, target := range buildSet.Spec.Targets {
+		if _, ok := byTarget[target.Name]; ok {
+			continue
+		}
+		if finished[target.Name] {
+			// The Build has finished and has been deleted since, e.g. after its TTL.
+			continue
+		}
+		build, err := r.buildForTarget(buildSet, target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := r.Client.Create(ctx, build); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				errs = append(errs, errors.Wrapf(err, "failed to create Build %s", build.Name))
+			}
+			continue
+		}
+		log.Info("Created Build", "Build", build.Name, "target", target.Name)
+		r.recorder.Eventf(buildSet, corev1.EventTypeNormal, "Created", "Created Build %s", build.Name)
+		byTarget[target.Name] = build
+	}
+
+	wasReady := buildSet.Status.Ready
+	summarizeBuildSet(buildSet, byTarget)
+	if buildSet.Status.Ready && !wasReady {
+		r.recorder.Eventf(buildSet, corev1.EventTypeNormal, "Ready", "%d of %d Builds completed",
+			buildSet.Status.Completed, len(buildSet.Spec.Targets))
+	}
+	return ctrl.Result{}, kerrors.NewAggregate(errs)
+}
+
+// buildForTarget returns the Build of the given target of the BuildSet.
+func (r *BuildSetReconciler) buildForTarget(buildSet *buildv1.BuildSet, target buildv1.BuildSetTarget) (*buildv1.Build, error) {
+	template := buildSet.Spec.BuildTemplate.DeepCopy()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s", buildSet.Name, target.Name),
+			Namespace:   buildSet.Namespace,
+			Labels:      template.Metadata.Labels,
+			Annotations: template.Metadata.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if build.Labels == nil {
+		build.Labels = map[string]string{}
+	}
+	build.Labels[buildv1.BuildSetNameLabel] = buildSet.Name
+	build.Labels[buildv1.BuildSetTargetLabel] = target.Name
+
+	build.Spec.InfrastructureRef = target.InfrastructureRef.DeepCopy()
+	if target.Connector != nil {
+		build.Spec.Connector = *target.Connector.DeepCopy()
+	}
+	if target.IdentityRef != nil {
+		build.Spec.IdentityRef = target.IdentityRef.DeepCopy()
+	}
+	build.Spec.Variables = mergeVariables(build.Spec.Variables, target.Variables)
+
+	if err := controllerutil.SetControllerReference(buildSet, build, r.Scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to set the controller reference of the Build")
+	}
+	return build, nil
+}
+
+// mergeVariables returns the variables with the overrides applied, overrides replace the variables with the same name.
+func mergeVariables(variables, overrides []buildv1.BuildVariable) []buildv1.BuildVariable {
+	if len(overrides) == 0 {
+		return variables
+	}
+	merged := []buildv1.BuildVariable{}
+	overridden := map[string]bool{}
+	for _, override := range overrides {
+		overridden[override.Name] = true
+	}
+	for _, variable := range variables {
+		if !overridden[variable.Name] {
+			merged = append(merged, variable)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// finishedTargets returns the targets whose Build has been reported finished, either completed or failed.
+func finishedTargets(buildSet *buildv1.BuildSet) map[string]bool {
+	finished := map[string]bool{}
+	for _, status := range buildSet.Status.Builds {
+		switch buildv1.BuildPhase(status.Phase) {
+		case buildv1.BuildPhaseCompleted, buildv1.BuildPhaseFailed:
+			finished[status.Target] = true
+		}
+	}
+	return finished
+}
+
+// summarizeBuildSet sets the status of the BuildSet from the Builds of its targets,
+// the BuildSet is ready once its quorum of Builds has completed. The status of the finished Builds
+// which have been deleted since is kept.
+func summarizeBuildSet(buildSet *buildv1.BuildSet, byTarget map[string]*buildv1.Build) {
+	previous := map[string]buildv1.BuildSetBuildStatus{}
+	for _, status := range buildSet.Status.Builds {
+		previous[status.Target] = status
+	}
+	finished := finishedTargets(buildSet)
+
+	buildSet.Status.Builds = nil
+	buildSet.Status.Completed = 0
+	buildSet.Status.Failed = 0
+	for _, target := range buildSet.Spec.Targets {
+		var status buildv1.BuildSetBuildStatus
+		retrying := false
+		if build, ok := byTarget[target.Name]; ok {
+			status = buildv1.BuildSetBuildStatus{
+				Target:         target.Name,
+				Name:           build.Name,
+				Phase:          build.Status.Phase,
+				ImageRef:       build.Status.ImageRef,
+				FailureMessage: build.Status.FailureMessage,
+			}
+			retrying = build.Status.NextRetryTime != nil
+		} else if finished[target.Name] {
+			status = previous[target.Name]
+		} else {
+			continue
+		}
+		buildSet.Status.Builds = append(buildSet.Status.Builds, status)
+
+		switch buildv1.BuildPhase(status.Phase) {
+		case buildv1.BuildPhaseCompleted:
+			buildSet.Status.Completed++
+		case buildv1.BuildPhaseFailed:
+			// A Build which is going to be retried has not failed yet.
+			if !retrying {
+				buildSet.Status.Failed++
+			}
+		}
+	}
+
+	total := int32(len(buildSet.Spec.Targets))
+	quorum := buildSet.GetQuorum()
+	buildSet.Status.Ready = buildSet.Status.Completed >= quorum
+	switch {
+	case buildSet.Status.Ready:
+		conditions.MarkTrue(buildSet, buildv1.ReadyCondition, buildv1.QuorumReachedReason,
+			"%d of %d Builds completed", buildSet.Status.Completed, total)
+	case buildSet.Status.Failed > total-quorum:
+		conditions.MarkFalse(buildSet, buildv1.ReadyCondition, buildv1.QuorumUnreachableReason,
+			"%d of %d Builds failed, %d must complete", buildSet.Status.Failed, total, quorum)
+	default:
+		conditions.MarkFalse(buildSet, buildv1.ReadyCondition, buildv1.WaitingForBuildsReason,
+			"%d of %d Builds completed, %d must complete", buildSet.Status.Completed, total, quorum)
+	}
+}
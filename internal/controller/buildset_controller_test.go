@@ -0,0 +1,227 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func newBuildSet(quorum *int32, targets ...string) *buildv1.BuildSet {
+	buildSet := &buildv1.BuildSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images", UID: "buildset-uid"},
+		Spec: buildv1.BuildSetSpec{
+			Quorum: quorum,
+			BuildTemplate: buildv1.BuildSetTemplate{
+				Spec: buildv1.BuildSpec{
+					Connector: buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH},
+					Variables: []buildv1.BuildVariable{{Name: "release", Value: "22.04"}, {Name: "user", Value: "root"}},
+				},
+			},
+		},
+	}
+	for _, target := range targets {
+		buildSet.Spec.Targets = append(buildSet.Spec.Targets, buildv1.BuildSetTarget{
+			Name:              target,
+			InfrastructureRef: corev1.ObjectReference{Kind: "InfraBuild", Name: target},
+		})
+	}
+	return buildSet
+}
+
+func TestMergeVariables(t *testing.T) {
+	g := NewWithT(t)
+
+	variables := []buildv1.BuildVariable{{Name: "release", Value: "22.04"}, {Name: "user", Value: "root"}}
+	g.Expect(mergeVariables(variables, nil)).To(Equal(variables))
+	g.Expect(mergeVariables(variables, []buildv1.BuildVariable{{Name: "user", Value: "ubuntu"}, {Name: "zone", Value: "a"}})).To(Equal(
+		[]buildv1.BuildVariable{{Name: "release", Value: "22.04"}, {Name: "user", Value: "ubuntu"}, {Name: "zone", Value: "a"}}))
+}
+
+func TestSummarizeBuildSet(t *testing.T) {
+	build := func(target string, phase buildv1.BuildPhase, retrying bool) *buildv1.Build {
+		b := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Name: "ubuntu-" + target}}
+		b.Status.SetTypedPhase(phase)
+		if retrying {
+			b.Status.NextRetryTime = &metav1.Time{}
+		}
+		return b
+	}
+
+	testcases := []struct {
+		name           string
+		quorum         *int32
+		builds         map[string]*buildv1.Build
+		previous       []buildv1.BuildSetBuildStatus
+		expectedReady  bool
+		expectedReason string
+		expectedCount  [2]int32
+	}{
+		{
+			name: "waiting for all the Builds",
+			builds: map[string]*buildv1.Build{
+				"aws": build("aws", buildv1.BuildPhaseCompleted, false),
+				"gcp": build("gcp", buildv1.BuildPhaseBuilding, false),
+			},
+			expectedReason: buildv1.WaitingForBuildsReason,
+			expectedCount:  [2]int32{1, 0},
+		},
+		{
+			name: "all the Builds completed",
+			builds: map[string]*buildv1.Build{
+				"aws": build("aws", buildv1.BuildPhaseCompleted, false),
+				"gcp": build("gcp", buildv1.BuildPhaseCompleted, false),
+			},
+			expectedReady:  true,
+			expectedReason: buildv1.QuorumReachedReason,
+			expectedCount:  [2]int32{2, 0},
+		},
+		{
+			name:   "quorum reached",
+			quorum: ptr.To[int32](1),
+			builds: map[string]*buildv1.Build{
+				"aws": build("aws", buildv1.BuildPhaseCompleted, false),
+				"gcp": build("gcp", buildv1.BuildPhaseFailed, false),
+			},
+			expectedReady:  true,
+			expectedReason: buildv1.QuorumReachedReason,
+			expectedCount:  [2]int32{1, 1},
+		},
+		{
+			name: "quorum unreachable",
+			builds: map[string]*buildv1.Build{
+				"aws": build("aws", buildv1.BuildPhaseBuilding, false),
+				"gcp": build("gcp", buildv1.BuildPhaseFailed, false),
+			},
+			expectedReason: buildv1.QuorumUnreachableReason,
+			expectedCount:  [2]int32{0, 1},
+		},
+		{
+			name: "failed Build going to be retried",
+			builds: map[string]*buildv1.Build{
+				"aws": build("aws", buildv1.BuildPhaseCompleted, false),
+				"gcp": build("gcp", buildv1.BuildPhaseFailed, true),
+			},
+			expectedReason: buildv1.WaitingForBuildsReason,
+			expectedCount:  [2]int32{1, 0},
+		},
+		{
+			name: "completed Build deleted since",
+			builds: map[string]*buildv1.Build{
+				"gcp": build("gcp", buildv1.BuildPhaseCompleted, false),
+			},
+			previous:       []buildv1.BuildSetBuildStatus{{Target: "aws", Name: "ubuntu-aws", Phase: string(buildv1.BuildPhaseCompleted)}},
+			expectedReady:  true,
+			expectedReason: buildv1.QuorumReachedReason,
+			expectedCount:  [2]int32{2, 0},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			buildSet := newBuildSet(tc.quorum, "aws", "gcp")
+			buildSet.Status.Builds = tc.previous
+			summarizeBuildSet(buildSet, tc.builds)
+
+			g.Expect(buildSet.Status.Ready).To(Equal(tc.expectedReady))
+			g.Expect(buildSet.Status.Completed).To(Equal(tc.expectedCount[0]))
+			g.Expect(buildSet.Status.Failed).To(Equal(tc.expectedCount[1]))
+			g.Expect(buildSet.Status.Builds).To(HaveLen(2))
+			condition := conditions.Get(buildSet, buildv1.ReadyCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+		})
+	}
+}
+
+func TestBuildSetReconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+	buildSet := newBuildSet(nil, "aws", "gcp")
+	buildSet.Spec.Targets[1].Variables = []buildv1.BuildVariable{{Name: "user", Value: "ubuntu"}}
+	// The Build of the vsphere target has been removed from the targets.
+	removed := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{
+		Name:      "ubuntu-vsphere",
+		Namespace: "images",
+		Labels:    map[string]string{buildv1.BuildSetNameLabel: "ubuntu", buildv1.BuildSetTargetLabel: "vsphere"},
+	}}
+	r := &BuildSetReconciler{Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+	controlled, err := r.buildForTarget(buildSet, buildv1.BuildSetTarget{Name: "vsphere"})
+	g.Expect(err).ToNot(HaveOccurred())
+	removed.OwnerReferences = controlled.OwnerReferences
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(buildSet, removed).WithStatusSubresource(buildSet).Build()
+	r.Client = c
+
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(buildSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	builds := &buildv1.BuildList{}
+	g.Expect(c.List(context.Background(), builds, client.InNamespace("images"))).To(Succeed())
+	g.Expect(builds.Items).To(HaveLen(2))
+	for _, build := range builds.Items {
+		target := build.Labels[buildv1.BuildSetTargetLabel]
+		g.Expect(build.Name).To(Equal("ubuntu-" + target))
+		g.Expect(build.Spec.InfrastructureRef.Name).To(Equal(target))
+		g.Expect(metav1.IsControlledBy(&build, buildSet)).To(BeTrue())
+		if target == "gcp" {
+			g.Expect(build.Spec.Variables).To(ContainElement(buildv1.BuildVariable{Name: "user", Value: "ubuntu"}))
+		}
+	}
+
+	g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(buildSet), buildSet)).To(Succeed())
+	g.Expect(buildSet.Status.Builds).To(HaveLen(2))
+	g.Expect(buildSet.Status.Ready).To(BeFalse())
+	g.Expect(conditions.Get(buildSet, buildv1.ReadyCondition).Reason).To(Equal(buildv1.WaitingForBuildsReason))
+}
+
+func TestBuildSetReconcilePaused(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+	buildSet := newBuildSet(nil, "aws")
+	buildSet.Annotations = map[string]string{buildv1.PausedAnnotation: ""}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(buildSet).WithStatusSubresource(buildSet).Build()
+	r := &BuildSetReconciler{Client: c, Scheme: scheme, recorder: record.NewFakeRecorder(10)}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(buildSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	builds := &buildv1.BuildList{}
+	g.Expect(c.List(context.Background(), builds, client.InNamespace("images"))).To(Succeed())
+	g.Expect(builds.Items).To(BeEmpty())
+}
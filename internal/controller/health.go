@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// buildHealth returns the health of the Build, it must be called once the phase of the Build has been reconciled.
+func buildHealth(build *buildv1.Build) *buildv1.HealthStatus {
+	phase := build.Status.GetTypedPhase()
+	switch {
+	case phase == buildv1.BuildPhaseTerminating:
+		return &buildv1.HealthStatus{Status: buildv1.HealthProgressing, Message: "Build is being deleted"}
+	case phase == buildv1.BuildPhaseFailed && shouldRetry(build):
+		return &buildv1.HealthStatus{Status: buildv1.HealthProgressing,
+			Message: fmt.Sprintf("Build failed and is going to be retried: %s", ptr.Deref(build.Status.FailureMessage, "unknown"))}
+	case phase == buildv1.BuildPhaseFailed:
+		return &buildv1.HealthStatus{Status: buildv1.HealthDegraded, Message: ptr.Deref(build.Status.FailureMessage, "Build failed")}
+	case phase == buildv1.BuildPhaseCompleted:
+		message := "Build completed"
+		if build.Status.ImageRef != "" {
+			message = fmt.Sprintf("Build completed, image: %s", build.Status.ImageRef)
+		}
+		return &buildv1.HealthStatus{Status: buildv1.HealthHealthy, Message: message}
+	case build.Status.AwaitingApproval != "":
+		return &buildv1.HealthStatus{Status: buildv1.HealthSuspended,
+			Message: fmt.Sprintf("Provisioner %s is waiting to be approved", build.Status.AwaitingApproval)}
+	case conditions.IsFalse(build, buildv1.AdmittedCondition):
+		return &buildv1.HealthStatus{Status: buildv1.HealthProgressing,
+			Message: fmt.Sprintf("Build is queued: %s", conditions.GetMessage(build, buildv1.AdmittedCondition))}
+	default:
+		return &buildv1.HealthStatus{Status: buildv1.HealthProgressing, Message: fmt.Sprintf("Build is %s", phase)}
+	}
+}
+
+// buildSetHealth returns the health of the BuildSet, it must be called once the BuildSet has been summarized.
+func buildSetHealth(buildSet *buildv1.BuildSet) *buildv1.HealthStatus {
+	message := conditions.GetMessage(buildSet, buildv1.ReadyCondition)
+	switch {
+	case buildSet.Status.Ready:
+		return &buildv1.HealthStatus{Status: buildv1.HealthHealthy, Message: message}
+	case conditions.GetReason(buildSet, buildv1.ReadyCondition) == buildv1.QuorumUnreachableReason:
+		return &buildv1.HealthStatus{Status: buildv1.HealthDegraded, Message: message}
+	default:
+		return &buildv1.HealthStatus{Status: buildv1.HealthProgressing, Message: message}
+	}
+}
+
+// scheduledBuildHealth returns the health of the ScheduledBuild. A ScheduledBuild is degraded when its schedule
+// is invalid or when its last finished Build failed, the running Builds don't affect its health.
+func scheduledBuildHealth(scheduledBuild *buildv1.ScheduledBuild) *buildv1.HealthStatus {
+	status := scheduledBuild.Status
+	switch {
+	case conditions.IsFalse(scheduledBuild, buildv1.ScheduleValidCondition):
+		return &buildv1.HealthStatus{Status: buildv1.HealthDegraded,
+			Message: conditions.GetMessage(scheduledBuild, buildv1.ScheduleValidCondition)}
+	case scheduledBuild.Spec.Suspend:
+		return &buildv1.HealthStatus{Status: buildv1.HealthSuspended, Message: "ScheduledBuild is suspended"}
+	case status.LastFailedTime != nil && (status.LastSuccessfulTime == nil || status.LastSuccessfulTime.Before(status.LastFailedTime)):
+		return &buildv1.HealthStatus{Status: buildv1.HealthDegraded,
+			Message: fmt.Sprintf("The Build scheduled at %s failed", status.LastFailedTime.UTC().Format(time.RFC3339))}
+	case len(status.Active) > 0:
+		return &buildv1.HealthStatus{Status: buildv1.HealthHealthy, Message: fmt.Sprintf("%d Build(s) running", len(status.Active))}
+	default:
+		return &buildv1.HealthStatus{Status: buildv1.HealthHealthy}
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestBuildHealth(t *testing.T) {
+	build := func(phase buildv1.BuildPhase) *buildv1.Build {
+		b := &buildv1.Build{}
+		b.Status.SetTypedPhase(phase)
+		return b
+	}
+	failed := build(buildv1.BuildPhaseFailed)
+	failed.Status.FailureReason = ptr.To(forgeerrors.CreateBuildError)
+	failed.Status.FailureMessage = ptr.To("machine creation failed")
+	retried := failed.DeepCopy()
+	retried.Spec.RetryPolicy = &buildv1.RetryPolicy{MaxRetries: 2}
+	completed := build(buildv1.BuildPhaseCompleted)
+	completed.Status.ImageRef = "ami-123"
+	awaitingApproval := build(buildv1.BuildPhaseBuilding)
+	awaitingApproval.Status.AwaitingApproval = "harden"
+	queued := build(buildv1.BuildPhasePending)
+	conditions.MarkFalse(queued, buildv1.AdmittedCondition, buildv1.ConcurrencyLimitReachedReason, "the limit is 2")
+
+	testcases := []struct {
+		name    string
+		build   *buildv1.Build
+		want    buildv1.Health
+		message string
+	}{
+		{
+			name:    "building",
+			build:   build(buildv1.BuildPhaseBuilding),
+			want:    buildv1.HealthProgressing,
+			message: "Build is Building",
+		},
+		{
+			name:    "queued",
+			build:   queued,
+			want:    buildv1.HealthProgressing,
+			message: "Build is queued: the limit is 2",
+		},
+		{
+			name:    "awaiting approval",
+			build:   awaitingApproval,
+			want:    buildv1.HealthSuspended,
+			message: "Provisioner harden is waiting to be approved",
+		},
+		{
+			name:    "completed",
+			build:   completed,
+			want:    buildv1.HealthHealthy,
+			message: "Build completed, image: ami-123",
+		},
+		{
+			name:    "failed",
+			build:   failed,
+			want:    buildv1.HealthDegraded,
+			message: "machine creation failed",
+		},
+		{
+			name:    "failed and retried",
+			build:   retried,
+			want:    buildv1.HealthProgressing,
+			message: "Build failed and is going to be retried: machine creation failed",
+		},
+		{
+			name:    "terminating",
+			build:   build(buildv1.BuildPhaseTerminating),
+			want:    buildv1.HealthProgressing,
+			message: "Build is being deleted",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			health := buildHealth(tc.build)
+			g.Expect(health.Status).To(Equal(tc.want))
+			g.Expect(health.Message).To(Equal(tc.message))
+		})
+	}
+}
+
+func TestBuildSetHealth(t *testing.T) {
+	testcases := []struct {
+		name   string
+		ready  bool
+		reason string
+		want   buildv1.Health
+	}{
+		{
+			name:   "quorum reached",
+			ready:  true,
+			reason: buildv1.QuorumReachedReason,
+			want:   buildv1.HealthHealthy,
+		},
+		{
+			name:   "waiting for Builds",
+			reason: buildv1.WaitingForBuildsReason,
+			want:   buildv1.HealthProgressing,
+		},
+		{
+			name:   "quorum unreachable",
+			reason: buildv1.QuorumUnreachableReason,
+			want:   buildv1.HealthDegraded,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			buildSet := &buildv1.BuildSet{Status: buildv1.BuildSetStatus{Ready: tc.ready}}
+			if tc.ready {
+				conditions.MarkTrue(buildSet, buildv1.ReadyCondition, tc.reason, "2 of 3 Builds completed")
+			} else {
+				conditions.MarkFalse(buildSet, buildv1.ReadyCondition, tc.reason, "2 of 3 Builds completed")
+			}
+
+			health := buildSetHealth(buildSet)
+			g.Expect(health.Status).To(Equal(tc.want))
+			g.Expect(health.Message).To(Equal("2 of 3 Builds completed"))
+		})
+	}
+}
+
+func TestScheduledBuildHealth(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC))
+	invalid := &buildv1.ScheduledBuild{}
+	conditions.MarkFalse(invalid, buildv1.ScheduleValidCondition, buildv1.InvalidScheduleReason, "Invalid schedule")
+
+	testcases := []struct {
+		name           string
+		scheduledBuild *buildv1.ScheduledBuild
+		want           buildv1.Health
+	}{
+		{
+			name:           "no Build yet",
+			scheduledBuild: &buildv1.ScheduledBuild{},
+			want:           buildv1.HealthHealthy,
+		},
+		{
+			name:           "invalid schedule",
+			scheduledBuild: invalid,
+			want:           buildv1.HealthDegraded,
+		},
+		{
+			name:           "suspended",
+			scheduledBuild: &buildv1.ScheduledBuild{Spec: buildv1.ScheduledBuildSpec{Suspend: true}},
+			want:           buildv1.HealthSuspended,
+		},
+		{
+			name: "last Build failed",
+			scheduledBuild: &buildv1.ScheduledBuild{Status: buildv1.ScheduledBuildStatus{
+				LastSuccessfulTime: &earlier,
+				LastFailedTime:     &later,
+			}},
+			want: buildv1.HealthDegraded,
+		},
+		{
+			name: "last Build succeeded",
+			scheduledBuild: &buildv1.ScheduledBuild{Status: buildv1.ScheduledBuildStatus{
+				LastSuccessfulTime: &later,
+				LastFailedTime:     &earlier,
+			}},
+			want: buildv1.HealthHealthy,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(scheduledBuildHealth(tc.scheduledBuild).Status).To(Equal(tc.want))
+		})
+	}
+}
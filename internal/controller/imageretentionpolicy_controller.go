@@ -0,0 +1,232 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/retention"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+// ImageRetentionPolicyControllerName is the name of the ImageRetentionPolicy controller, used in the controller
+// metrics.
+const ImageRetentionPolicyControllerName = "imageretentionpolicy"
+
+// ImageRetentionPolicyReconciler reconciles an ImageRetentionPolicy object
+type ImageRetentionPolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Deleters delete the machine images, by kind of infrastructure, e.g. AWSBuild. The expired Images of the other
+	// kinds are not deleted.
+	Deleters map[string]retention.Deleter
+
+	// Clock is used to determine the current time, defaults to the real clock.
+	Clock clock.PassiveClock
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImageRetentionPolicyReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&buildv1.ImageRetentionPolicy{}).
+		Watches(
+			&buildv1.Image{},
+			handler.EnqueueRequestsFromMapFunc(r.imageToPolicies),
+		).
+		Named(ImageRetentionPolicyControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ImageRetentionPolicyControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("imageretentionpolicy-controller")
+	return nil
+}
+
+//+kubebuilder:rbac:groups=forge.build,resources=imageretentionpolicies,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=imageretentionpolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=imageretentionpolicies/finalizers,verbs=update
+//+kubebuilder:rbac:groups=forge.build,resources=images,verbs=get;list;watch;delete
+
+// Reconcile evaluates the Images of the namespace of an ImageRetentionPolicy, and deletes the expired ones along
+// with their machine images.
+func (r *ImageRetentionPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	policy := &buildv1.ImageRetentionPolicy{}
+	if err := r.Client.Get(ctx, req.NamespacedName, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(policy) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(policy, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		policy.Status.ObservedGeneration = policy.Generation
+		if err := patchHelper.Patch(ctx, policy); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	images := &buildv1.ImageList{}
+	if err := r.Client.List(ctx, images, client.InNamespace(policy.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list Images")
+	}
+	now := r.Clock.Now()
+	evaluation, err := retention.Evaluate(policy, images.Items, now)
+	if err != nil {
+		// The selectors have to be fixed by the user, there is no point in requeuing.
+		conditions.MarkFalse(policy, buildv1.ReadyCondition, buildv1.InvalidSelectorReason, "%v", err)
+		return ctrl.Result{}, nil
+	}
+	policy.Status.Selected = int32(len(evaluation.Selected))
+	policy.Status.LastEvaluationTime = &metav1.Time{Time: now}
+	policy.Status.Expired = nil
+	result := ctrl.Result{RequeueAfter: policy.GetInterval()}
+
+	if policy.Spec.DryRun {
+		for _, image := range evaluation.Expired {
+			policy.Status.Expired = append(policy.Status.Expired, image.Name)
+		}
+		conditions.MarkTrue(policy, buildv1.ReadyCondition, buildv1.RetentionAppliedReason,
+			"%d of %d Images would be deleted", len(evaluation.Expired), len(evaluation.Selected))
+		return result, nil
+	}
+
+	var errs []error
+	for _, image := range evaluation.Expired {
+		reclaimed, err := r.deleteImage(ctx, image)
+		if err != nil {
+			policy.Status.Expired = append(policy.Status.Expired, image.Name)
+			errs = append(errs, errors.Wrapf(err, "failed to delete Image %s", image.Name))
+			continue
+		}
+		policy.Status.Deleted++
+		policy.Status.ReclaimedBytes += reclaimed
+		r.recorder.Eventf(policy, corev1.EventTypeNormal, "ImageDeleted", "Deleted Image %s and machine image %s, reclaimed %s",
+			image.Name, image.Spec.ImageRef, resource.NewQuantity(reclaimed, resource.BinarySI))
+	}
+	if len(errs) > 0 {
+		err := kerrors.NewAggregate(errs)
+		conditions.MarkFalse(policy, buildv1.ReadyCondition, buildv1.ImageDeletionFailedReason, "%v", err)
+		r.recorder.Event(policy, corev1.EventTypeWarning, buildv1.ImageDeletionFailedReason, err.Error())
+		if !forgeerrors.IsRetryable(err) {
+			// The Images are deleted again at the next evaluation, e.g. once their credentials have been fixed.
+			return result, nil
+		}
+		return ctrl.Result{}, err
+	}
+	conditions.MarkTrue(policy, buildv1.ReadyCondition, buildv1.RetentionAppliedReason,
+		"%d of %d Images kept", len(evaluation.Selected)-len(evaluation.Expired), len(evaluation.Selected))
+	return result, nil
+}
+
+// deleteImage deletes the machine image of the Image with the credentials of the ProviderIdentity of its Build,
+// then the Image. It returns the bytes of storage reclaimed.
+func (r *ImageRetentionPolicyReconciler) deleteImage(ctx context.Context, image *buildv1.Image) (int64, error) {
+	if image.Spec.InfrastructureRef == nil {
+		return 0, forgeerrors.ConfigErrorf("Image %s has no infrastructure", image.Name)
+	}
+	kind := image.Spec.InfrastructureRef.Kind
+	deleter, ok := r.Deleters[kind]
+	if !ok {
+		return 0, forgeerrors.ConfigErrorf("no infrastructure provider deletes the machine images of %s", kind)
+	}
+	if image.Spec.IdentityRef == nil {
+		return 0, forgeerrors.ConfigErrorf("Image %s has no identityRef, the credentials of its machine image are unknown", image.Name)
+	}
+	identity := &buildv1.ProviderIdentity{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: image.Namespace, Name: image.Spec.IdentityRef.Name}, identity); err != nil {
+		return 0, errors.Wrapf(err, "failed to get ProviderIdentity %s", image.Spec.IdentityRef.Name)
+	}
+	secret := &corev1.Secret{}
+	if ref := identity.Spec.CredentialsProviderRef; ref != nil {
+		var err error
+		if secret, err = credentialsprovider.Secret(ctx, r.Client, image.Namespace, ref); err != nil {
+			return 0, errors.Wrapf(err, "failed to resolve the credentials of ProviderIdentity %s", identity.Name)
+		}
+	} else if err := r.Client.Get(ctx, client.ObjectKey{Namespace: image.Namespace, Name: identity.Spec.SecretRef.Name}, secret); err != nil {
+		return 0, errors.Wrapf(err, "failed to get credentials secret %s", identity.Spec.SecretRef.Name)
+	}
+
+	reclaimed, err := deleter.Delete(ctx, image, secret)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to delete machine image %s", image.Spec.ImageRef)
+	}
+	if err := r.Client.Delete(ctx, image); client.IgnoreNotFound(err) != nil {
+		return 0, err
+	}
+	return reclaimed, nil
+}
+
+// imageToPolicies returns the ImageRetentionPolicies of the namespace of the Image, to evaluate the new Images.
+func (r *ImageRetentionPolicyReconciler) imageToPolicies(ctx context.Context, o client.Object) []ctrl.Request {
+	policies := &buildv1.ImageRetentionPolicyList{}
+	if err := r.Client.List(ctx, policies, client.InNamespace(o.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list ImageRetentionPolicies", "namespace", o.GetNamespace())
+		return nil
+	}
+	requests := make([]ctrl.Request, 0, len(policies.Items))
+	for _, policy := range policies.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&policy)})
+	}
+	return requests
+}
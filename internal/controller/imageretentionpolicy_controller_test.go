@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/retention"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestImageRetentionPolicyReconcile(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	image := func(name, kind string, daysAgo int) *buildv1.Image {
+		return &buildv1.Image{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "images", Labels: map[string]string{"os": "ubuntu"}},
+			Spec: buildv1.ImageSpec{
+				InfrastructureRef: &corev1.ObjectReference{Kind: kind, Name: name},
+				IdentityRef:       &corev1.LocalObjectReference{Name: "aws"},
+				ImageRef:          "ami-" + name,
+				Provenance:        buildv1.ImageProvenance{CompletionTime: ptr.To(metav1.NewTime(now.AddDate(0, 0, -daysAgo)))},
+			},
+		}
+	}
+	newPolicy := func(dryRun bool) *buildv1.ImageRetentionPolicy {
+		return &buildv1.ImageRetentionPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "images"},
+			Spec: buildv1.ImageRetentionPolicySpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"os": "ubuntu"}},
+				KeepLast: ptr.To[int32](1),
+				DryRun:   dryRun,
+			},
+		}
+	}
+	objects := func(policy *buildv1.ImageRetentionPolicy) []client.Object {
+		return []client.Object{
+			policy,
+			image("recent", "AWSBuild", 1),
+			image("old", "AWSBuild", 10),
+			image("older", "VSphereBuild", 20),
+			&buildv1.ProviderIdentity{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: "images"},
+				Spec:       buildv1.ProviderIdentitySpec{Provider: "aws", SecretRef: corev1.LocalObjectReference{Name: "aws-credentials"}},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "aws-credentials", Namespace: "images"}},
+		}
+	}
+
+	t.Run("deletes the expired images", func(t *testing.T) {
+		g := NewWithT(t)
+
+		scheme := runtime.NewScheme()
+		g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+		g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		policy := newPolicy(false)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects(policy)...).WithStatusSubresource(policy).Build()
+		var deleted []string
+		r := &ImageRetentionPolicyReconciler{
+			Client: c,
+			Scheme: scheme,
+			Clock:  clocktesting.NewFakePassiveClock(now),
+			Deleters: map[string]retention.Deleter{
+				"AWSBuild": retention.DeleterFunc(func(_ context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error) {
+					g.Expect(secret.Name).To(Equal("aws-credentials"))
+					deleted = append(deleted, image.Spec.ImageRef)
+					return 8 << 30, nil
+				}),
+			},
+			recorder: record.NewFakeRecorder(10),
+		}
+
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(Equal(buildv1.DefaultRetentionInterval))
+		g.Expect(deleted).To(Equal([]string{"ami-old"}))
+
+		err = c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "old"}, &buildv1.Image{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "images", Name: "recent"}, &buildv1.Image{})).To(Succeed())
+
+		// The Image of the infrastructure without a deleter is reported as expired.
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+		g.Expect(policy.Status.Selected).To(Equal(int32(3)))
+		g.Expect(policy.Status.Expired).To(Equal([]string{"older"}))
+		g.Expect(policy.Status.Deleted).To(Equal(int64(1)))
+		g.Expect(policy.Status.ReclaimedBytes).To(Equal(int64(8 << 30)))
+		g.Expect(policy.Status.LastEvaluationTime.Time).To(BeTemporally("==", now))
+		g.Expect(conditions.Get(policy, buildv1.ReadyCondition).Reason).To(Equal(buildv1.ImageDeletionFailedReason))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		g := NewWithT(t)
+
+		scheme := runtime.NewScheme()
+		g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+		g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		policy := newPolicy(true)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects(policy)...).WithStatusSubresource(policy).Build()
+		r := &ImageRetentionPolicyReconciler{
+			Client: c,
+			Scheme: scheme,
+			Clock:  clocktesting.NewFakePassiveClock(now),
+			Deleters: map[string]retention.Deleter{
+				"AWSBuild": retention.DeleterFunc(func(context.Context, *buildv1.Image, *corev1.Secret) (int64, error) {
+					return 0, errors.New("unexpected deletion")
+				}),
+			},
+			recorder: record.NewFakeRecorder(10),
+		}
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		images := &buildv1.ImageList{}
+		g.Expect(c.List(context.Background(), images, client.InNamespace("images"))).To(Succeed())
+		g.Expect(images.Items).To(HaveLen(3))
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+		g.Expect(policy.Status.Expired).To(Equal([]string{"old", "older"}))
+		g.Expect(policy.Status.Deleted).To(BeZero())
+		g.Expect(conditions.IsTrue(policy, buildv1.ReadyCondition)).To(BeTrue())
+	})
+}
@@ -6,7 +6,8 @@ import (
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
-	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/forge-build/forge/util/conditions"
 )
 
 func (r *BuildReconciler) reconcilePhase(_ context.Context, build *buildv1.Build) {
@@ -18,13 +19,29 @@ func (r *BuildReconciler) reconcilePhase(_ context.Context, build *buildv1.Build
 	}
 
 	if build.Spec.InfrastructureRef != nil && conditions.Has(build, buildv1.InfrastructureReadyCondition) {
-		build.Status.SetTypedPhase(buildv1.BuildPhaseBuilding)
+		build.Status.SetTypedPhase(buildv1.BuildPhaseProvisioning)
 	}
 
 	if build.Status.InfrastructureReady {
+		build.Status.SetTypedPhase(buildv1.BuildPhaseConnecting)
+	}
+
+	if build.Status.Connected || (build.Spec.Rehearsal() && conditions.Has(build, buildv1.ProvisionersReadyCondition)) {
 		build.Status.SetTypedPhase(buildv1.BuildPhaseBuilding)
 	}
 
+	if build.Status.ProvisionersReady {
+		build.Status.SetTypedPhase(buildv1.BuildPhaseExporting)
+	}
+
+	if phase, ok := architecturesPhase(build); ok {
+		build.Status.SetTypedPhase(phase)
+	}
+
+	if conditions.IsTrue(build, buildv1.ImageExportedCondition) && exportsPublished(build) {
+		build.Status.SetTypedPhase(buildv1.BuildPhaseCompleted)
+	}
+
 	if build.Status.FailureReason != nil || build.Status.FailureMessage != nil {
 		build.Status.SetTypedPhase(buildv1.BuildPhaseFailed)
 	}
@@ -35,7 +52,7 @@ func (r *BuildReconciler) reconcilePhase(_ context.Context, build *buildv1.Build
 
 	// Only record the event if the status has changed
 	if preReconcilePhase != build.Status.GetTypedPhase() {
-		// Failed clusters should get a Warning event
+		// Failed builds should get a Warning event
 		if build.Status.GetTypedPhase() == buildv1.BuildPhaseFailed {
 			r.recorder.Eventf(build, corev1.EventTypeWarning, string(build.Status.GetTypedPhase()), "Build %s is %s: %s", build.Name, string(build.Status.GetTypedPhase()), ptr.Deref(build.Status.FailureMessage, "unknown"))
 		} else {
@@ -1,5 +1,57 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
 package controller
 
-//func (r *BuildReconciler) reconcileProvisioners() (ctrl.Result, error) {
-//
-//}
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	externalprovisioner "github.com/forge-build/forge/provisioner/external"
+	shellcontroller "github.com/forge-build/forge/provisioner/shell/controller"
+)
+
+// reconcileExternalProvisioner runs an external provisioner with the Job or the webhook of its ProvisionerClass.
+// The parameters of the provisioner are checked against the schema of the class before it starts.
+func (r *BuildReconciler) reconcileExternalProvisioner(ctx context.Context, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	class, err := externalprovisioner.GetClass(ctx, r.Client, spec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if spec.UUID == nil {
+		if err := externalprovisioner.CheckParameters(class, spec); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if job := class.Spec.Job; job != nil {
+		image := shellcontroller.ImageOptions{
+			Image:       job.Image,
+			PullPolicy:  job.ImagePullPolicy,
+			PullSecrets: r.ShellProvisionerImage.PullSecrets,
+		}
+		return shellcontroller.Reconcile(ctx, r.Client, build, spec, image, r.ShellProvisionerPlacement)
+	}
+	if build.Spec.DryRun {
+		// Record the request which would be sent to the webhook, it is not called in dry-run mode.
+		return ctrl.Result{}, r.planProvisioner(ctx, build, spec, "-request.yaml", func() interface{} {
+			return externalprovisioner.NewRequest(build, spec)
+		})
+	}
+	return (&externalprovisioner.Webhook{}).Reconcile(ctx, build, spec, class)
+}
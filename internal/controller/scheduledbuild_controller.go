@@ -0,0 +1,399 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ScheduledBuildControllerName is the name of the ScheduledBuild controller, used in the controller metrics.
+	ScheduledBuildControllerName = "scheduledbuild"
+
+	// maxMissedSchedules is the number of missed schedules after which the missed schedules are no longer counted,
+	// e.g. when the controller has been down for a long time.
+	maxMissedSchedules = 100
+
+	// imageVersionLayout is the layout of the scheduled time suffixed to the image names.
+	imageVersionLayout = "20060102-1504"
+)
+
+// ScheduledBuildReconciler reconciles a ScheduledBuild object
+type ScheduledBuildReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Clock is used to determine the current time, defaults to the real clock.
+	Clock clock.PassiveClock
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScheduledBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&buildv1.ScheduledBuild{}).
+		Owns(&buildv1.Build{}).
+		Named(ScheduledBuildControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ScheduledBuildControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("scheduledbuild-controller")
+	return nil
+}
+
+//+kubebuilder:rbac:groups=forge.build,resources=scheduledbuilds,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=scheduledbuilds/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=forge.build,resources=scheduledbuilds/finalizers,verbs=update
+
+// Reconcile creates the Builds of a ScheduledBuild when they are due and garbage collects the finished ones.
+func (r *ScheduledBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	scheduledBuild := &buildv1.ScheduledBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, scheduledBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(scheduledBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(scheduledBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		scheduledBuild.Status.Health = scheduledBuildHealth(scheduledBuild)
+		if err := patchHelper.Patch(ctx, scheduledBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	schedule, err := cron.ParseStandard(scheduledBuild.Spec.Schedule)
+	if err != nil {
+		// The schedule has to be fixed by the user, there is no point in requeuing.
+		log.Error(err, "Invalid schedule", "schedule", scheduledBuild.Spec.Schedule)
+		conditions.MarkFalse(scheduledBuild, buildv1.ScheduleValidCondition, buildv1.InvalidScheduleReason,
+			"Invalid schedule %q: %v", scheduledBuild.Spec.Schedule, err)
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkTrue(scheduledBuild, buildv1.ScheduleValidCondition, buildv1.ScheduleValidReason, "")
+
+	active, err := r.reconcileHistory(ctx, scheduledBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if scheduledBuild.Spec.Suspend {
+		log.V(4).Info("ScheduledBuild is suspended, skipping")
+		return ctrl.Result{}, nil
+	}
+
+	now := r.Clock.Now()
+	missed, missedCount, next := nextSchedules(schedule, earliestSchedule(scheduledBuild, now), now)
+	result := ctrl.Result{RequeueAfter: next.Sub(now)}
+	if missed.IsZero() {
+		log.V(4).Info("No Build is due", "next", next)
+		return result, nil
+	}
+	if missedCount > maxMissedSchedules {
+		r.recorder.Eventf(scheduledBuild, corev1.EventTypeWarning, "TooManyMissedSchedules",
+			"More than %d scheduled times have been missed, only the last one is started", maxMissedSchedules)
+	}
+
+	switch scheduledBuild.Spec.ConcurrencyPolicy {
+	case buildv1.ForbidConcurrent:
+		if len(active) > 0 {
+			log.V(4).Info("Build is due but the previous Build is still running", "scheduledTime", missed)
+			return result, nil
+		}
+	case buildv1.ReplaceConcurrent:
+		for _, build := range active {
+			if err := r.Client.Delete(ctx, build, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete running Build %s", build.Name)
+			}
+			r.recorder.Eventf(scheduledBuild, corev1.EventTypeNormal, "Replaced", "Deleted running Build %s", build.Name)
+		}
+	}
+
+	build, err := r.buildForSchedule(scheduledBuild, missed)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Client.Create(ctx, build); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to create Build %s", build.Name)
+		}
+	} else {
+		log.Info("Created Build", "Build", build.Name, "scheduledTime", missed)
+		r.recorder.Eventf(scheduledBuild, corev1.EventTypeNormal, "Created", "Created Build %s", build.Name)
+		scheduledBuild.Status.Active = append(scheduledBuild.Status.Active, corev1.ObjectReference{
+			APIVersion: buildv1.GroupVersion.String(),
+			Kind:       "Build",
+			Namespace:  build.Namespace,
+			Name:       build.Name,
+		})
+	}
+	scheduledBuild.Status.LastScheduleTime = &metav1.Time{Time: missed}
+	return result, nil
+}
+
+// reconcileHistory updates the status of the ScheduledBuild from its Builds and deletes the finished Builds
+// exceeding the history limits, it returns the running Builds.
+func (r *ScheduledBuildReconciler) reconcileHistory(ctx context.Context, scheduledBuild *buildv1.ScheduledBuild) ([]*buildv1.Build, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	builds := &buildv1.BuildList{}
+	if err := r.Client.List(ctx, builds, client.InNamespace(scheduledBuild.Namespace),
+		client.MatchingLabels{buildv1.ScheduledBuildNameLabel: scheduledBuild.Name}); err != nil {
+		return nil, errors.Wrap(err, "failed to list Builds")
+	}
+
+	var active, succeeded, failed []*buildv1.Build
+	for i := range builds.Items {
+		build := &builds.Items[i]
+		if !metav1.IsControlledBy(build, scheduledBuild) {
+			continue
+		}
+		switch build.Status.GetTypedPhase() {
+		case buildv1.BuildPhaseCompleted:
+			succeeded = append(succeeded, build)
+		case buildv1.BuildPhaseFailed:
+			if build.Status.NextRetryTime != nil {
+				// The Build is going to be retried.
+				active = append(active, build)
+				break
+			}
+			failed = append(failed, build)
+		default:
+			active = append(active, build)
+		}
+
+		scheduledTime, err := scheduledTimeFor(build)
+		if err != nil {
+			log.Error(err, "Unable to parse the scheduled time of Build", "Build", build.Name)
+			continue
+		}
+		if scheduledTime.IsZero() {
+			continue
+		}
+		if last := scheduledBuild.Status.LastScheduleTime; last == nil || last.Time.Before(scheduledTime) {
+			scheduledBuild.Status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+		}
+	}
+
+	sortByScheduledTime(succeeded)
+	sortByScheduledTime(failed)
+	if len(succeeded) > 0 {
+		last := succeeded[len(succeeded)-1]
+		if scheduledTime, err := scheduledTimeFor(last); err == nil && !scheduledTime.IsZero() {
+			scheduledBuild.Status.LastSuccessfulTime = &metav1.Time{Time: scheduledTime}
+		}
+		scheduledBuild.Status.LastImageRef = last.Status.ImageRef
+	}
+	if len(failed) > 0 {
+		if scheduledTime, err := scheduledTimeFor(failed[len(failed)-1]); err == nil && !scheduledTime.IsZero() {
+			scheduledBuild.Status.LastFailedTime = &metav1.Time{Time: scheduledTime}
+		}
+	}
+
+	scheduledBuild.Status.Active = nil
+	for _, build := range active {
+		scheduledBuild.Status.Active = append(scheduledBuild.Status.Active, corev1.ObjectReference{
+			APIVersion: buildv1.GroupVersion.String(),
+			Kind:       "Build",
+			Namespace:  build.Namespace,
+			Name:       build.Name,
+			UID:        build.UID,
+		})
+	}
+
+	var errs []error
+	for _, build := range exceedingHistory(succeeded, scheduledBuild.Spec.SuccessfulBuildsHistoryLimit) {
+		errs = append(errs, r.deleteFinishedBuild(ctx, scheduledBuild, build))
+	}
+	for _, build := range exceedingHistory(failed, scheduledBuild.Spec.FailedBuildsHistoryLimit) {
+		errs = append(errs, r.deleteFinishedBuild(ctx, scheduledBuild, build))
+	}
+	return active, kerrors.NewAggregate(errs)
+}
+
+func (r *ScheduledBuildReconciler) deleteFinishedBuild(ctx context.Context, scheduledBuild *buildv1.ScheduledBuild, build *buildv1.Build) error {
+	if !build.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := r.Client.Delete(ctx, build, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "failed to delete finished Build %s", build.Name)
+	}
+	r.recorder.Eventf(scheduledBuild, corev1.EventTypeNormal, "Deleted", "Deleted finished Build %s", build.Name)
+	return nil
+}
+
+// buildForSchedule returns the Build of the ScheduledBuild for the given scheduled time.
+// The Build name is derived from the scheduled time, so a Build is never created twice for the same time.
+func (r *ScheduledBuildReconciler) buildForSchedule(scheduledBuild *buildv1.ScheduledBuild, scheduledTime time.Time) (*buildv1.Build, error) {
+	template := scheduledBuild.Spec.BuildTemplate.DeepCopy()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", scheduledBuild.Name, scheduledTime.Unix()/60),
+			Namespace:   scheduledBuild.Namespace,
+			Labels:      template.Metadata.Labels,
+			Annotations: template.Metadata.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if build.Labels == nil {
+		build.Labels = map[string]string{}
+	}
+	build.Labels[buildv1.ScheduledBuildNameLabel] = scheduledBuild.Name
+	if build.Annotations == nil {
+		build.Annotations = map[string]string{}
+	}
+	build.Annotations[buildv1.ScheduledTimeAnnotation] = scheduledTime.UTC().Format(time.RFC3339)
+	if scheduledBuild.Spec.ImageName != "" {
+		build.Spec.ImageName = imageNameFor(scheduledBuild.Spec.ImageName, scheduledTime)
+	}
+
+	if err := controllerutil.SetControllerReference(scheduledBuild, build, r.Scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to set the controller reference of the Build")
+	}
+	return build, nil
+}
+
+// earliestSchedule returns the time from which the scheduled times are considered as missed.
+func earliestSchedule(scheduledBuild *buildv1.ScheduledBuild, now time.Time) time.Time {
+	earliest := scheduledBuild.CreationTimestamp.Time
+	if scheduledBuild.Status.LastScheduleTime != nil {
+		earliest = scheduledBuild.Status.LastScheduleTime.Time
+	}
+	if deadline := scheduledBuild.Spec.StartingDeadlineSeconds; deadline != nil {
+		if start := now.Add(-time.Duration(*deadline) * time.Second); start.After(earliest) {
+			earliest = start
+		}
+	}
+	return earliest
+}
+
+// nextSchedules returns the last scheduled time missed after earliest, if any, the number of scheduled times
+// missed, up to maxMissedSchedules+1, and the next scheduled time after now.
+func nextSchedules(schedule cron.Schedule, earliest, now time.Time) (time.Time, int, time.Time) {
+	var missed time.Time
+	count := 0
+	for t := schedule.Next(earliest); !t.After(now); t = schedule.Next(t) {
+		missed = t
+		count++
+		if count > maxMissedSchedules {
+			// Avoid iterating over years of missed schedules, only the last one matters.
+			missed = lastScheduleBefore(schedule, t, now)
+			break
+		}
+	}
+	return missed, count, schedule.Next(now)
+}
+
+// lastScheduleBefore returns the last scheduled time between from and now, from being a scheduled time.
+func lastScheduleBefore(schedule cron.Schedule, from, now time.Time) time.Time {
+	// Jump close to now, the schedule period is estimated from two consecutive scheduled times.
+	period := schedule.Next(from).Sub(from)
+	last := from
+	if period > 0 {
+		if t := schedule.Next(now.Add(-2 * period)); !t.After(now) && t.After(last) {
+			last = t
+		}
+	}
+	for t := schedule.Next(last); !t.After(now); t = schedule.Next(t) {
+		last = t
+	}
+	return last
+}
+
+// scheduledTimeFor returns the time the Build has been scheduled for, zero if the Build has no scheduled time.
+func scheduledTimeFor(build *buildv1.Build) (time.Time, error) {
+	value, ok := build.Annotations[buildv1.ScheduledTimeAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// sortByScheduledTime sorts the Builds by scheduled time, oldest first.
+func sortByScheduledTime(builds []*buildv1.Build) {
+	sort.SliceStable(builds, func(i, j int) bool {
+		ti, _ := scheduledTimeFor(builds[i])
+		tj, _ := scheduledTimeFor(builds[j])
+		if ti.Equal(tj) {
+			return builds[i].CreationTimestamp.Before(&builds[j].CreationTimestamp)
+		}
+		return ti.Before(tj)
+	})
+}
+
+// exceedingHistory returns the oldest Builds exceeding the history limit, builds are sorted oldest first.
+func exceedingHistory(builds []*buildv1.Build, limit *int32) []*buildv1.Build {
+	if limit == nil || len(builds) <= int(*limit) {
+		return nil
+	}
+	return builds[:len(builds)-int(*limit)]
+}
+
+// imageNameFor returns the name of the image built at the given scheduled time.
+func imageNameFor(base string, scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%s", base, scheduledTime.UTC().Format(imageVersionLayout))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func mustTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNextSchedules(t *testing.T) {
+	testcases := []struct {
+		name          string
+		schedule      string
+		earliest      time.Time
+		now           time.Time
+		expectedLast  time.Time
+		expectedCount int
+		expectedNext  time.Time
+	}{
+		{
+			name:         "nothing missed",
+			schedule:     "0 3 1 * *",
+			earliest:     mustTime("2024-06-01T03:00:00Z"),
+			now:          mustTime("2024-06-15T00:00:00Z"),
+			expectedNext: mustTime("2024-07-01T03:00:00Z"),
+		},
+		{
+			name:          "one schedule missed",
+			schedule:      "0 3 1 * *",
+			earliest:      mustTime("2024-06-01T03:00:00Z"),
+			now:           mustTime("2024-07-01T03:00:30Z"),
+			expectedLast:  mustTime("2024-07-01T03:00:00Z"),
+			expectedCount: 1,
+			expectedNext:  mustTime("2024-08-01T03:00:00Z"),
+		},
+		{
+			name:          "several schedules missed",
+			schedule:      "0 3 1 * *",
+			earliest:      mustTime("2024-01-01T03:00:00Z"),
+			now:           mustTime("2024-04-10T00:00:00Z"),
+			expectedLast:  mustTime("2024-04-01T03:00:00Z"),
+			expectedCount: 3,
+			expectedNext:  mustTime("2024-05-01T03:00:00Z"),
+		},
+		{
+			name:          "too many schedules missed",
+			schedule:      "*/5 * * * *",
+			earliest:      mustTime("2024-01-01T00:00:00Z"),
+			now:           mustTime("2024-02-01T00:02:00Z"),
+			expectedLast:  mustTime("2024-02-01T00:00:00Z"),
+			expectedCount: maxMissedSchedules + 1,
+			expectedNext:  mustTime("2024-02-01T00:05:00Z"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			schedule, err := cron.ParseStandard(tc.schedule)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			last, count, next := nextSchedules(schedule, tc.earliest, tc.now)
+			g.Expect(last).To(Equal(tc.expectedLast))
+			g.Expect(count).To(Equal(tc.expectedCount))
+			g.Expect(next).To(Equal(tc.expectedNext))
+		})
+	}
+}
+
+func TestEarliestSchedule(t *testing.T) {
+	g := NewWithT(t)
+
+	now := mustTime("2024-07-01T12:00:00Z")
+	scheduledBuild := &buildv1.ScheduledBuild{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: mustTime("2024-01-01T00:00:00Z")}},
+	}
+	g.Expect(earliestSchedule(scheduledBuild, now)).To(Equal(mustTime("2024-01-01T00:00:00Z")))
+
+	scheduledBuild.Status.LastScheduleTime = &metav1.Time{Time: mustTime("2024-06-01T03:00:00Z")}
+	g.Expect(earliestSchedule(scheduledBuild, now)).To(Equal(mustTime("2024-06-01T03:00:00Z")))
+
+	scheduledBuild.Spec.StartingDeadlineSeconds = ptr.To[int64](3600)
+	g.Expect(earliestSchedule(scheduledBuild, now)).To(Equal(mustTime("2024-07-01T11:00:00Z")))
+}
+
+func TestExceedingHistory(t *testing.T) {
+	g := NewWithT(t)
+
+	builds := []*buildv1.Build{{}, {}, {}}
+	g.Expect(exceedingHistory(builds, nil)).To(BeEmpty())
+	g.Expect(exceedingHistory(builds, ptr.To[int32](3))).To(BeEmpty())
+	g.Expect(exceedingHistory(builds, ptr.To[int32](1))).To(Equal(builds[:2]))
+	g.Expect(exceedingHistory(builds, ptr.To[int32](0))).To(Equal(builds))
+}
+
+func TestBuildForSchedule(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	r := &ScheduledBuildReconciler{Scheme: scheme}
+
+	scheduledBuild := &buildv1.ScheduledBuild{
+		ObjectMeta: metav1.ObjectMeta{Name: "golden", Namespace: "images", UID: "uid"},
+		Spec: buildv1.ScheduledBuildSpec{
+			ImageName: "ubuntu-golden",
+			BuildTemplate: buildv1.ScheduledBuildTemplate{
+				Metadata: buildv1.ScheduledBuildTemplateMetadata{Labels: map[string]string{"team": "platform"}},
+				Spec: buildv1.BuildSpec{
+					TemplateRef: &buildv1.BuildTemplateReference{Name: "ubuntu"},
+				},
+			},
+		},
+	}
+
+	scheduledTime := mustTime("2024-07-01T03:00:00Z")
+	build, err := r.buildForSchedule(scheduledBuild, scheduledTime)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Name).To(Equal("golden-28663380"))
+	g.Expect(build.Namespace).To(Equal("images"))
+	g.Expect(build.Labels).To(Equal(map[string]string{"team": "platform", buildv1.ScheduledBuildNameLabel: "golden"}))
+	g.Expect(build.Annotations).To(HaveKeyWithValue(buildv1.ScheduledTimeAnnotation, "2024-07-01T03:00:00Z"))
+	g.Expect(build.Spec.ImageName).To(Equal("ubuntu-golden-20240701-0300"))
+	g.Expect(build.Spec.TemplateRef.Name).To(Equal("ubuntu"))
+	g.Expect(metav1.IsControlledBy(build, scheduledBuild)).To(BeTrue())
+
+	// The template of the ScheduledBuild is not modified.
+	g.Expect(scheduledBuild.Spec.BuildTemplate.Metadata.Labels).To(HaveLen(1))
+	g.Expect(scheduledBuild.Spec.BuildTemplate.Spec.ImageName).To(BeEmpty())
+}
@@ -24,6 +24,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/storage/names"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -237,6 +238,73 @@ func FailuresFrom(obj *unstructured.Unstructured) (string, string, error) {
 	return failureReason, failureMessage, nil
 }
 
+// ImageRefFrom returns the Status.ImageRef field from the external object status, if any.
+func ImageRefFrom(obj *unstructured.Unstructured) (string, error) {
+	imageRef, _, err := unstructured.NestedString(obj.Object, "status", "imageRef")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine imageRef on %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	return imageRef, nil
+}
+
+// SourceImageRefFrom returns the Status.SourceImageRef field from the external object status, if any.
+func SourceImageRefFrom(obj *unstructured.Unstructured) (string, error) {
+	sourceImageRef, _, err := unstructured.NestedString(obj.Object, "status", "sourceImageRef")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine sourceImageRef on %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	return sourceImageRef, nil
+}
+
+// ArtifactFrom returns the Status.Artifact field from the external object status, if any.
+func ArtifactFrom(obj *unstructured.Unstructured) (*buildv1.BuildArtifact, error) {
+	raw, found, err := unstructured.NestedMap(obj.Object, "status", "artifact")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine artifact on %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	if !found {
+		return nil, nil
+	}
+	artifact := &buildv1.BuildArtifact{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, artifact); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode artifact on %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	if artifact.ID == "" {
+		return nil, errors.Errorf("artifact on %v %q has no id", obj.GroupVersionKind(), obj.GetName())
+	}
+	return artifact, nil
+}
+
+// ReplicationsFrom returns the Status.Replications field from the external object status, if any.
+func ReplicationsFrom(obj *unstructured.Unstructured) ([]buildv1.ReplicationStatus, error) {
+	raw, found, err := unstructured.NestedSlice(obj.Object, "status", "replications")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine replications on %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	if !found {
+		return nil, nil
+	}
+	replications := make([]buildv1.ReplicationStatus, 0, len(raw))
+	for _, item := range raw {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid replication on %v %q", obj.GroupVersionKind(), obj.GetName())
+		}
+		replication := buildv1.ReplicationStatus{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(data, &replication); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode replication on %v %q",
+				obj.GroupVersionKind(), obj.GetName())
+		}
+		replications = append(replications, replication)
+	}
+	return replications, nil
+}
+
 // IsReady returns true if the Status.Ready field on an external object is true.
 func IsReady(obj *unstructured.Unstructured) (bool, error) {
 	ready, found, err := unstructured.NestedBool(obj.Object, "status", "ready")
@@ -18,6 +18,7 @@ package external
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -324,3 +325,53 @@ func TestCloneTemplateMissingSpecTemplate(t *testing.T) {
 	})
 	g.Expect(err).To(HaveOccurred())
 }
+
+func TestImageRefFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	imageRef, err := ImageRefFrom(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(imageRef).To(BeEmpty())
+
+	g.Expect(unstructured.SetNestedField(obj.Object, "ami-0123456789", "status", "imageRef")).To(Succeed())
+	imageRef, err = ImageRefFrom(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(imageRef).To(Equal("ami-0123456789"))
+
+	g.Expect(unstructured.SetNestedField(obj.Object, int64(1), "status", "imageRef")).To(Succeed())
+	_, err = ImageRefFrom(obj)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestArtifactFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	artifact, err := ArtifactFrom(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(artifact).To(BeNil())
+
+	g.Expect(unstructured.SetNestedMap(obj.Object, map[string]interface{}{
+		"id":        "ami-0123456789",
+		"location":  "arn:aws:ec2:eu-west-1::image/ami-0123456789",
+		"checksum":  "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		"sizeBytes": int64(8589934592),
+		"format":    "ami",
+		"createdAt": "2024-07-01T12:00:00Z",
+	}, "status", "artifact")).To(Succeed())
+	artifact, err = ArtifactFrom(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(artifact.ID).To(Equal("ami-0123456789"))
+	g.Expect(artifact.Format).To(Equal("ami"))
+	g.Expect(*artifact.SizeBytes).To(Equal(int64(8589934592)))
+	g.Expect(artifact.CreatedAt.UTC()).To(Equal(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)))
+
+	g.Expect(unstructured.SetNestedField(obj.Object, "", "status", "artifact", "id")).To(Succeed())
+	_, err = ArtifactFrom(obj)
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(unstructured.SetNestedField(obj.Object, "ami-0123456789", "status", "artifact")).To(Succeed())
+	_, err = ArtifactFrom(obj)
+	g.Expect(err).To(HaveOccurred())
+}
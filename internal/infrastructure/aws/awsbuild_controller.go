@@ -0,0 +1,702 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the AWSBuild controller, used in the controller metrics.
+	ControllerName = "awsbuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "aws"
+
+	// instanceRequeueAfter is how often an instance being launched or terminated is checked.
+	instanceRequeueAfter = 10 * time.Second
+
+	// imageRequeueAfter is how often an AMI being created or copied is checked.
+	imageRequeueAfter = 30 * time.Second
+
+	// buildTag is the tag of the resources of a Build, holding its namespaced name.
+	buildTag = "forge.build/build"
+
+	// spotInterruptionReason prefixes the state reason of the interrupted Spot Instances.
+	spotInterruptionReason = "Server.SpotInstanceTermination"
+)
+
+// AWSBuildReconciler reconciles a AWSBuild object
+type AWSBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewEC2 returns the client of the APIs of a region, NewEC2 is used if nil.
+	NewEC2 func(credentials publish.Credentials, region string) EC2
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AWSBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.AWSBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("AWSBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("awsbuild-controller")
+	return nil
+}
+
+// Reconcile launches the instance of an AWSBuild with an ephemeral key pair, publishes its address in the connector
+// credentials of the Build and, once the provisioners of the Build are ready, creates the AMI and its copies.
+func (r *AWSBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	awsBuild := &infrav1.AWSBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, awsBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(awsBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(awsBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(awsBuild)
+		if err := patchHelper.Patch(ctx, awsBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, awsBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !awsBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, awsBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(awsBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the AWSBuild")
+		return ctrl.Result{}, nil
+	}
+	if awsBuild.Status.FailureReason != nil || awsBuild.Status.Ready {
+		// The instance of a failed or completed AWSBuild is terminated along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(awsBuild, infrav1.AWSBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, awsBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, awsBuild, res, err)
+}
+
+func (r *AWSBuildReconciler) reconcileNormal(ctx context.Context, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (ctrl.Result, error) {
+	ec2, err := r.ec2(ctx, awsBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileKeyPair(ctx, ec2, awsBuild, build); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	instance, err := r.reconcileInstance(ctx, ec2, awsBuild, build)
+	if err != nil || instance == nil {
+		return ctrl.Result{RequeueAfter: instanceRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(awsBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileImage(ctx, ec2, awsBuild, build)
+}
+
+// reconcileKeyPair imports the public key of the connector credentials of the Build as the key pair of the instance.
+func (r *AWSBuildReconciler) reconcileKeyPair(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build) error {
+	if awsBuild.Status.KeyPairName != "" {
+		return nil
+	}
+	creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(awsBuild.Spec.Username, infrav1.DefaultAWSUsername))
+	if err != nil {
+		return err
+	}
+	if creds.AuthorizedKey == "" {
+		return forgeerrors.ConfigErrorf("the connector credentials of Build %s have no private key, AWSBuilds only authorize keys", build.Name)
+	}
+	name := providersdk.MachineName(awsBuild)
+	if err := ec2.ImportKeyPair(ctx, name, creds.AuthorizedKey, tagsFor(awsBuild)); err != nil && !IsDuplicate(err) {
+		return errors.Wrapf(err, "failed to import key pair %s", name)
+	}
+	awsBuild.Status.KeyPairName = name
+	return nil
+}
+
+// reconcileInstance launches the instance and publishes its address once it is running. It returns nil while the
+// instance is being launched.
+func (r *AWSBuildReconciler) reconcileInstance(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (*Instance, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &awsBuild.Spec
+	status := &awsBuild.Status
+
+	if status.InstanceID == "" {
+		sourceAMI, ok := providersdk.SourceImage(build, spec.SourceAMI)
+		if !ok {
+			conditions.MarkFalse(awsBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the source image of the Build")
+			return nil, nil
+		}
+		input, err := r.runInstanceInput(ctx, ec2, awsBuild, sourceAMI)
+		if err != nil {
+			return nil, err
+		}
+		instance, err := ec2.RunInstance(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to launch the instance")
+		}
+		log.Info("Launching instance", "instance", instance.ID)
+		r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, "InstanceLaunching", "Launching instance %s", instance.ID)
+		status.InstanceID = instance.ID
+		status.SourceImageRef = sourceAMI
+		conditions.MarkFalse(awsBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Launching instance %s", instance.ID)
+		return nil, nil
+	}
+
+	instance, err := ec2.DescribeInstance(ctx, status.InstanceID)
+	if IsNotFound(err) {
+		if status.MachineReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s has been deleted", status.InstanceID)
+		}
+		// The instances are eventually consistent, a new instance may not be found yet.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe instance %s", status.InstanceID)
+	}
+
+	switch instance.State {
+	case InstancePending:
+		conditions.MarkFalse(awsBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Instance %s is pending", instance.ID)
+		return nil, nil
+	case InstanceRunning:
+	case InstanceStopping, InstanceStopped:
+		// The instance is rebooted while the AMI is created.
+		if status.MachineReady && build.Status.ProvisionersReady {
+			return instance, nil
+		}
+		fallthrough
+	default:
+		if spec.Spot != nil && strings.HasPrefix(instance.StateReason, spotInterruptionReason) {
+			return nil, forgeerrors.Terminalf(forgeerrors.PreemptedBuildError, "Spot Instance %s has been interrupted: %s", instance.ID, instance.StateReason)
+		}
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s is %s: %s", instance.ID, instance.State, instance.StateReason)
+	}
+	if status.MachineReady {
+		return instance, nil
+	}
+
+	address := instance.PrivateIP
+	if ptr.Deref(spec.PublicIP, true) {
+		address = instance.PublicIP
+	}
+	if address == "" {
+		return nil, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(awsBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "Instance %s is running at %s", instance.ID, address)
+	r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "Instance %s is running at %s", instance.ID, address)
+	return instance, nil
+}
+
+// runInstanceInput returns the parameters of the instance of the AWSBuild, launched from the source AMI.
+func (r *AWSBuildReconciler) runInstanceInput(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, sourceAMI string) (*RunInstanceInput, error) {
+	spec := &awsBuild.Spec
+	input := &RunInstanceInput{
+		ImageID:            sourceAMI,
+		InstanceType:       cmp.Or(spec.InstanceType, infrav1.DefaultAWSInstanceType),
+		KeyName:            awsBuild.Status.KeyPairName,
+		SubnetID:           spec.SubnetID,
+		SecurityGroupIDs:   spec.SecurityGroupIDs,
+		PublicIP:           ptr.Deref(spec.PublicIP, true),
+		RootVolumeSize:     spec.RootVolumeSize,
+		RootVolumeType:     spec.RootVolumeType,
+		IAMInstanceProfile: spec.IAMInstanceProfile,
+		ClientToken:        string(awsBuild.UID),
+		Tags:               tagsFor(awsBuild),
+	}
+	input.Tags["Name"] = providersdk.MachineName(awsBuild)
+	if spec.Spot != nil {
+		input.Spot = true
+		input.SpotMaxPrice = spec.Spot.MaxPrice
+	}
+	if spec.RootVolumeSize > 0 || spec.RootVolumeType != "" {
+		// The root volume is overridden by the block device mapping of the root device of the source AMI.
+		source, err := ec2.DescribeImage(ctx, sourceAMI)
+		if IsNotFound(err) {
+			return nil, forgeerrors.ConfigErrorf("source AMI %s not found in %s", sourceAMI, spec.Region)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to describe source AMI %s", sourceAMI)
+		}
+		input.RootDeviceName = source.RootDeviceName
+	}
+	return input, nil
+}
+
+// reconcileImage creates the AMI from the instance, shares it and copies it, then terminates the instance.
+func (r *AWSBuildReconciler) reconcileImage(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &awsBuild.Spec
+	status := &awsBuild.Status
+
+	name := imageName(awsBuild, build)
+	if status.ImageID == "" {
+		// The AMI may have been created by a reconciliation whose status was lost.
+		image, err := ec2.FindImage(ctx, name)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to find AMI %s", name)
+		}
+		if image == nil {
+			id, err := ec2.CreateImage(ctx, status.InstanceID, name, spec.AMI.Description, imageTags(awsBuild, build))
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to create AMI %s", name)
+			}
+			log.Info("Creating AMI", "image", id)
+			r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Creating AMI %s from instance %s", id, status.InstanceID)
+			image = &Image{ID: id}
+		}
+		status.ImageID = image.ID
+		conditions.MarkFalse(awsBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Creating AMI %s", image.ID)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+
+	image, err := ec2.DescribeImage(ctx, status.ImageID)
+	if IsNotFound(err) {
+		// The images are eventually consistent, a new AMI may not be found yet.
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to describe AMI %s", status.ImageID)
+	}
+	switch image.State {
+	case ImageAvailable:
+	case ImagePending:
+		conditions.MarkFalse(awsBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "AMI %s is pending", image.ID)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	default:
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "AMI %s is %s: %s", image.ID, image.State, image.StateReason)
+	}
+
+	copied, err := r.reconcileCopies(ctx, ec2, awsBuild, build, image)
+	if err != nil || !copied {
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, err
+	}
+	replicated, err := r.reconcileReplication(ctx, ec2, awsBuild, build, image)
+	if err != nil || !replicated {
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, err
+	}
+
+	// The instance is not needed anymore, the key pair is deleted along with the AWSBuild.
+	if err := terminateInstance(ctx, ec2, status.InstanceID); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status.ImageRef = image.ID
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       image.ID,
+		Location: fmt.Sprintf("arn:aws:ec2:%s::image/%s", spec.Region, image.ID),
+		Format:   "ami",
+	}
+	if size := image.SizeBytes(); size > 0 {
+		status.Artifact.SizeBytes = ptr.To(size)
+	}
+	if created, err := time.Parse(time.RFC3339, image.CreationDate); err == nil {
+		status.Artifact.CreatedAt = &metav1.Time{Time: created}
+	}
+	status.Ready = true
+	conditions.MarkTrue(awsBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "AMI %s is available", image.ID)
+	r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "AMI %s is available", image.ID)
+	return ctrl.Result{}, nil
+}
+
+// reconcileCopies shares the AMI and copies it to the regions and accounts of spec.ami.copies, it returns true once
+// all the copies are available.
+func (r *AWSBuildReconciler) reconcileCopies(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build, image *Image) (bool, error) {
+	spec := &awsBuild.Spec
+	status := &awsBuild.Status
+
+	accountIDs := append([]string{}, spec.AMI.LaunchPermissions...)
+	for _, c := range spec.AMI.Copies {
+		if c.AccountID != "" {
+			accountIDs = append(accountIDs, c.AccountID)
+		}
+	}
+	if err := ec2.ShareImage(ctx, image, accountIDs); err != nil {
+		return false, errors.Wrapf(err, "failed to share AMI %s", image.ID)
+	}
+
+	copied := true
+	for _, c := range spec.AMI.Copies {
+		copyStatus := copyStatusFor(status, c, spec.Region)
+		if copyStatus.State == ImageAvailable {
+			continue
+		}
+
+		target, err := r.copyClient(ctx, ec2, awsBuild, build, c)
+		if err != nil {
+			return false, err
+		}
+		if copyStatus.ImageID == "" {
+			id, err := target.CopyImage(ctx, &CopyImageInput{
+				SourceRegion:  spec.Region,
+				SourceImageID: image.ID,
+				Name:          image.Name,
+				Description:   spec.AMI.Description,
+				Encrypted:     c.Encrypted,
+				KMSKeyID:      c.KMSKeyID,
+				ClientToken:   string(awsBuild.UID) + "-" + c.Name,
+			})
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to copy AMI %s to %s", image.ID, c.Name)
+			}
+			copyStatus.ImageID = id
+			copyStatus.State = ImagePending
+			copied = false
+			r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, infrav1.ImageExportingReason, "Copying AMI %s to %s as %s", image.ID, c.Name, id)
+			continue
+		}
+
+		copyImage, err := target.DescribeImage(ctx, copyStatus.ImageID)
+		if IsNotFound(err) {
+			copied = false
+			continue
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to describe AMI %s of copy %s", copyStatus.ImageID, c.Name)
+		}
+		copyStatus.State = copyImage.State
+		switch copyImage.State {
+		case ImageAvailable:
+		case ImageFailed:
+			return false, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "copy %s of AMI %s failed: %s", c.Name, image.ID, copyImage.StateReason)
+		default:
+			copied = false
+		}
+	}
+	if !copied {
+		conditions.MarkFalse(awsBuild, infrav1.ImageReadyCondition, infrav1.ImageExportingReason, "Copying AMI %s", image.ID)
+	}
+	return copied, nil
+}
+
+// reconcileReplication replicates the AMI to the targets of the spec.artifact.replication of the Build: the AMI is
+// shared with the accounts, then copied to the regions where the copies are shared with the same accounts. It
+// returns true once every target is either replicated or failed, a failed target does not fail the AWSBuild.
+func (r *AWSBuildReconciler) reconcileReplication(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build, image *Image) (bool, error) {
+	if build.Spec.Artifact == nil || build.Spec.Artifact.Replication == nil {
+		return true, nil
+	}
+	replication := build.Spec.Artifact.Replication
+
+	replicated := true
+	var accountIDs []string
+	for _, account := range replication.Accounts {
+		status := providersdk.ReplicationFor(awsBuild, buildv1.ReplicationStatus{Type: buildv1.ReplicationTargetAccount, Name: account})
+		if status.Phase == buildv1.ReplicationPhasePending {
+			if err := ec2.ShareImage(ctx, image, []string{account}); err != nil {
+				if !forgeerrors.IsTerminal(err) {
+					return false, errors.Wrapf(err, "failed to share AMI %s with account %s", image.ID, account)
+				}
+				r.failReplication(awsBuild, status, "failed to share AMI %s: %v", image.ID, err)
+				continue
+			}
+			status.Phase = buildv1.ReplicationPhaseReplicated
+			status.ImageRef = image.ID
+		}
+		if status.Phase == buildv1.ReplicationPhaseReplicated {
+			accountIDs = append(accountIDs, account)
+		}
+	}
+
+	for _, region := range replication.Regions {
+		status := providersdk.ReplicationFor(awsBuild, buildv1.ReplicationStatus{Type: buildv1.ReplicationTargetRegion, Name: region})
+		if status.Phase != buildv1.ReplicationPhasePending {
+			continue
+		}
+		if err := r.replicateToRegion(ctx, awsBuild, build, image, status, accountIDs); err != nil {
+			return false, err
+		}
+		replicated = replicated && status.Phase != buildv1.ReplicationPhasePending
+	}
+	if !replicated {
+		conditions.MarkFalse(awsBuild, infrav1.ImageReadyCondition, infrav1.ImageExportingReason, "Replicating AMI %s", image.ID)
+	}
+	return replicated, nil
+}
+
+// replicateToRegion copies the AMI to the region of the replication status, then shares the copy with the accounts.
+func (r *AWSBuildReconciler) replicateToRegion(ctx context.Context, awsBuild *infrav1.AWSBuild, build *buildv1.Build, image *Image, status *buildv1.ReplicationStatus, accountIDs []string) error {
+	creds, err := r.credentials(ctx, awsBuild, build)
+	if err != nil {
+		return err
+	}
+	target := r.newEC2(creds, status.Name)
+
+	if status.ImageRef == "" {
+		id, err := target.CopyImage(ctx, &CopyImageInput{
+			SourceRegion:  awsBuild.Spec.Region,
+			SourceImageID: image.ID,
+			Name:          image.Name,
+			Description:   awsBuild.Spec.AMI.Description,
+			ClientToken:   string(awsBuild.UID) + "-" + status.Name,
+		})
+		if err != nil {
+			if !forgeerrors.IsTerminal(err) {
+				return errors.Wrapf(err, "failed to copy AMI %s to %s", image.ID, status.Name)
+			}
+			r.failReplication(awsBuild, status, "failed to copy AMI %s: %v", image.ID, err)
+			return nil
+		}
+		status.ImageRef = id
+		r.recorder.Eventf(awsBuild, corev1.EventTypeNormal, infrav1.ImageExportingReason, "Copying AMI %s to %s as %s", image.ID, status.Name, id)
+		return nil
+	}
+
+	copyImage, err := target.DescribeImage(ctx, status.ImageRef)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to describe AMI %s in %s", status.ImageRef, status.Name)
+	}
+	switch copyImage.State {
+	case ImageAvailable:
+		if err := target.ShareImage(ctx, copyImage, accountIDs); err != nil {
+			if !forgeerrors.IsTerminal(err) {
+				return errors.Wrapf(err, "failed to share AMI %s in %s", copyImage.ID, status.Name)
+			}
+			r.failReplication(awsBuild, status, "failed to share AMI %s: %v", copyImage.ID, err)
+			return nil
+		}
+		status.Phase = buildv1.ReplicationPhaseReplicated
+	case ImageFailed:
+		r.failReplication(awsBuild, status, "AMI %s is %s: %s", copyImage.ID, copyImage.State, copyImage.StateReason)
+	}
+	return nil
+}
+
+// failReplication reports the replication to the target failed, with a Warning event.
+func (r *AWSBuildReconciler) failReplication(awsBuild *infrav1.AWSBuild, status *buildv1.ReplicationStatus, messageFormat string, messageArgs ...interface{}) {
+	status.Phase = buildv1.ReplicationPhaseFailed
+	status.Message = fmt.Sprintf(messageFormat, messageArgs...)
+	r.recorder.Eventf(awsBuild, corev1.EventTypeWarning, "ReplicationFailed", "Failed to replicate to %s %s: %s", status.Type, status.Name, status.Message)
+}
+
+// copyClient returns the client of the region of the copy, with the credentials of its role if any.
+func (r *AWSBuildReconciler) copyClient(ctx context.Context, ec2 EC2, awsBuild *infrav1.AWSBuild, build *buildv1.Build, c infrav1.AWSAMICopy) (EC2, error) {
+	creds, err := r.credentials(ctx, awsBuild, build)
+	if err != nil {
+		return nil, err
+	}
+	if c.RoleARN != "" {
+		creds, err = ec2.AssumeRole(ctx, c.RoleARN, providersdk.SanitizeName(awsBuild.Name, 64))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to assume role %s", c.RoleARN)
+		}
+	}
+	return r.newEC2(creds, cmp.Or(c.Region, awsBuild.Spec.Region)), nil
+}
+
+// reconcileDelete terminates the instance and deletes the key pair, the AMI and its copies are kept.
+func (r *AWSBuildReconciler) reconcileDelete(ctx context.Context, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(awsBuild, infrav1.AWSBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(awsBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	status := &awsBuild.Status
+	if status.InstanceID != "" || status.KeyPairName != "" {
+		ec2, err := r.ec2(ctx, awsBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if status.InstanceID != "" {
+			instance, err := ec2.DescribeInstance(ctx, status.InstanceID)
+			switch {
+			case IsNotFound(err):
+			case err != nil:
+				return ctrl.Result{}, errors.Wrapf(err, "failed to describe instance %s", status.InstanceID)
+			case instance.State != InstanceTerminated:
+				if err := terminateInstance(ctx, ec2, instance.ID); err != nil {
+					return ctrl.Result{}, err
+				}
+				if instance.SpotInstanceRequestID != "" {
+					if err := ec2.CancelSpotInstanceRequest(ctx, instance.SpotInstanceRequestID); err != nil && !IsNotFound(err) {
+						return ctrl.Result{}, errors.Wrapf(err, "failed to cancel Spot Instance request %s", instance.SpotInstanceRequestID)
+					}
+				}
+				log.Info("Terminating instance", "instance", instance.ID)
+				return ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+			}
+		}
+		if status.KeyPairName != "" {
+			if err := ec2.DeleteKeyPair(ctx, status.KeyPairName); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete key pair %s", status.KeyPairName)
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(awsBuild, infrav1.AWSBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// ec2 returns the client of the region of the AWSBuild authenticated with its credentials.
+func (r *AWSBuildReconciler) ec2(ctx context.Context, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (EC2, error) {
+	creds, err := r.credentials(ctx, awsBuild, build)
+	if err != nil {
+		return nil, err
+	}
+	return r.newEC2(creds, awsBuild.Spec.Region), nil
+}
+
+// credentials returns the credentials of the AWSBuild, the Build may be nil once deleted.
+func (r *AWSBuildReconciler) credentials(ctx context.Context, awsBuild *infrav1.AWSBuild, build *buildv1.Build) (publish.Credentials, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the AWSBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: awsBuild.Namespace, Name: awsBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, awsBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return publish.Credentials{}, err
+	}
+	creds := publish.Credentials{
+		AccessKeyID:     string(secret.Data[infrav1.AWSAccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[infrav1.AWSSecretAccessKeyKey]),
+		SessionToken:    string(secret.Data[infrav1.AWSSessionTokenKey]),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return publish.Credentials{}, forgeerrors.ConfigErrorf("secret %s has no %s or %s key", secret.Name, infrav1.AWSAccessKeyIDKey, infrav1.AWSSecretAccessKeyKey)
+	}
+	return creds, nil
+}
+
+func (r *AWSBuildReconciler) newEC2(creds publish.Credentials, region string) EC2 {
+	if r.NewEC2 != nil {
+		return r.NewEC2(creds, region)
+	}
+	return NewEC2(creds, region)
+}
+
+// terminateInstance terminates the instance, if it still exists.
+func terminateInstance(ctx context.Context, ec2 EC2, id string) error {
+	if err := ec2.TerminateInstance(ctx, id); err != nil && !IsNotFound(err) {
+		return errors.Wrapf(err, "failed to terminate instance %s", id)
+	}
+	return nil
+}
+
+// copyStatusFor returns the status of the copy, added to the status of the AWSBuild if missing.
+func copyStatusFor(status *infrav1.AWSBuildStatus, c infrav1.AWSAMICopy, region string) *infrav1.AWSAMICopyStatus {
+	for i := range status.Copies {
+		if status.Copies[i].Name == c.Name {
+			return &status.Copies[i]
+		}
+	}
+	status.Copies = append(status.Copies, infrav1.AWSAMICopyStatus{
+		Name:      c.Name,
+		Region:    cmp.Or(c.Region, region),
+		AccountID: c.AccountID,
+	})
+	return &status.Copies[len(status.Copies)-1]
+}
+
+// tagsFor returns the tags of the resources of the AWSBuild.
+func tagsFor(awsBuild *infrav1.AWSBuild) map[string]string {
+	tags := map[string]string{buildTag: awsBuild.Namespace + "/" + awsBuild.Name}
+	for key, value := range awsBuild.Spec.Tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// imageTags returns the tags of the AMI, with the image metadata of the Build.
+func imageTags(awsBuild *infrav1.AWSBuild, build *buildv1.Build) map[string]string {
+	tags := tagsFor(awsBuild)
+	for key, value := range build.Status.ImageMetadata {
+		tags[key] = value
+	}
+	return tags
+}
+
+// imageName returns the name of the AMI, the spec.imageName of the Build, or the name of the AWSBuild.
+func imageName(awsBuild *infrav1.AWSBuild, build *buildv1.Build) string {
+	return cmp.Or(build.Spec.ImageName, awsBuild.Name)
+}
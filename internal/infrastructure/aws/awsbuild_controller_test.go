@@ -0,0 +1,401 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeEC2 is an in-memory EC2 of a region and account.
+type fakeEC2 struct {
+	region      string
+	credentials publish.Credentials
+
+	keyPairs  map[string]string
+	instances map[string]*Instance
+	images    map[string]*Image
+	runs      []*RunInstanceInput
+	shared    []string
+	copies    []*CopyImageInput
+
+	// invalidAccounts are the accounts images cannot be shared with.
+	invalidAccounts []string
+}
+
+func newFakeEC2(region string) *fakeEC2 {
+	return &fakeEC2{
+		region:    region,
+		keyPairs:  map[string]string{},
+		instances: map[string]*Instance{},
+		images:    map[string]*Image{},
+	}
+}
+
+func (f *fakeEC2) ImportKeyPair(_ context.Context, name, publicKey string, _ map[string]string) error {
+	if _, ok := f.keyPairs[name]; ok {
+		return &APIError{StatusCode: http.StatusBadRequest, Code: "InvalidKeyPair.Duplicate"}
+	}
+	f.keyPairs[name] = publicKey
+	return nil
+}
+
+func (f *fakeEC2) DeleteKeyPair(_ context.Context, name string) error {
+	delete(f.keyPairs, name)
+	return nil
+}
+
+func (f *fakeEC2) RunInstance(_ context.Context, input *RunInstanceInput) (*Instance, error) {
+	f.runs = append(f.runs, input)
+	instance := &Instance{ID: fmt.Sprintf("i-%d", len(f.runs)), State: InstancePending}
+	f.instances[instance.ID] = instance
+	return instance, nil
+}
+
+func (f *fakeEC2) DescribeInstance(_ context.Context, id string) (*Instance, error) {
+	instance, ok := f.instances[id]
+	if !ok {
+		return nil, &APIError{StatusCode: http.StatusBadRequest, Code: "InvalidInstanceID.NotFound"}
+	}
+	return instance, nil
+}
+
+func (f *fakeEC2) TerminateInstance(_ context.Context, id string) error {
+	f.instances[id].State = InstanceTerminated
+	return nil
+}
+
+func (f *fakeEC2) CancelSpotInstanceRequest(_ context.Context, _ string) error {
+	return nil
+}
+
+func (f *fakeEC2) CreateImage(_ context.Context, _, name, _ string, _ map[string]string) (string, error) {
+	image := &Image{ID: fmt.Sprintf("ami-%s-%d", f.region, len(f.images)+1), Name: name, State: ImagePending}
+	f.images[image.ID] = image
+	return image.ID, nil
+}
+
+func (f *fakeEC2) DescribeImage(_ context.Context, id string) (*Image, error) {
+	image, ok := f.images[id]
+	if !ok {
+		return nil, &APIError{StatusCode: http.StatusBadRequest, Code: "InvalidAMIID.NotFound"}
+	}
+	return image, nil
+}
+
+func (f *fakeEC2) FindImage(_ context.Context, name string) (*Image, error) {
+	for _, image := range f.images {
+		if image.Name == name {
+			return image, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeEC2) ShareImage(_ context.Context, _ *Image, accountIDs []string) error {
+	for _, id := range accountIDs {
+		if slices.Contains(f.invalidAccounts, id) {
+			return forgeerrors.NewConfigError(&APIError{StatusCode: http.StatusBadRequest, Code: "InvalidUserID.Malformed", Message: id})
+		}
+	}
+	f.shared = accountIDs
+	return nil
+}
+
+func (f *fakeEC2) CopyImage(_ context.Context, input *CopyImageInput) (string, error) {
+	f.copies = append(f.copies, input)
+	image := &Image{ID: fmt.Sprintf("ami-%s-%d", f.region, len(f.images)+1), Name: input.Name, State: ImagePending}
+	f.images[image.ID] = image
+	return image.ID, nil
+}
+
+func (f *fakeEC2) AssumeRole(_ context.Context, roleARN, _ string) (publish.Credentials, error) {
+	return publish.Credentials{AccessKeyID: roleARN}, nil
+}
+
+func TestAWSBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, awsBuild := setupTest(g)
+	source := newFakeEC2("eu-west-1")
+	source.images["ami-0"] = &Image{ID: "ami-0", RootDeviceName: "/dev/xvda", State: ImageAvailable}
+	target := newFakeEC2("us-east-1")
+	r := &AWSBuildReconciler{
+		Client: c,
+		NewEC2: func(credentials publish.Credentials, region string) EC2 {
+			if region == "us-east-1" {
+				target.credentials = credentials
+				return target
+			}
+			return source
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(awsBuild)}
+
+	// The key pair is imported and the instance launched.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(instanceRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	g.Expect(awsBuild.Finalizers).To(ContainElement(infrav1.AWSBuildFinalizer))
+	g.Expect(source.keyPairs).To(HaveKeyWithValue(awsBuild.Status.KeyPairName, HavePrefix("ssh-rsa ")))
+	g.Expect(awsBuild.Status.InstanceID).To(Equal("i-1"))
+	run := source.runs[0]
+	g.Expect(run.ImageID).To(Equal("ami-0"))
+	g.Expect(run.InstanceType).To(Equal(infrav1.DefaultAWSInstanceType))
+	g.Expect(run.KeyName).To(Equal(awsBuild.Status.KeyPairName))
+	g.Expect(run.RootDeviceName).To(Equal("/dev/xvda"))
+	g.Expect(run.Spot).To(BeTrue())
+	g.Expect(run.ClientToken).To(Equal(string(awsBuild.UID)))
+	g.Expect(run.Tags).To(HaveKeyWithValue(buildTag, "default/ubuntu"))
+
+	// The address is published once the instance is running.
+	source.instances["i-1"].State = InstanceRunning
+	source.instances["i-1"].PublicIP = "34.1.2.3"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	g.Expect(awsBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(awsBuild.Status.Address).To(Equal("34.1.2.3"))
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("34.1.2.3"))
+	g.Expect(string(secret.Data[providersdk.UsernameKey])).To(Equal("ubuntu"))
+
+	// The AMI is created once the provisioners are ready.
+	build.Status.ProvisionersReady = true
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(imageRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	image := source.images[awsBuild.Status.ImageID]
+	g.Expect(image.Name).To(Equal("ubuntu-22.04"))
+
+	// The AMI is shared and copied to the other account once available.
+	image.State = ImageAvailable
+	image.CreationDate = "2024-05-01T10:00:00.000Z"
+	image.BlockDevices = append(image.BlockDevices, struct {
+		SnapshotID string `xml:"ebs>snapshotId"`
+		VolumeSize int64  `xml:"ebs>volumeSize"`
+	}{SnapshotID: "snap-1", VolumeSize: 20})
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(source.shared).To(ConsistOf("111111111111", "222222222222"))
+	g.Expect(target.credentials.AccessKeyID).To(Equal("arn:aws:iam::222222222222:role/forge"))
+	g.Expect(target.copies).To(HaveLen(1))
+	g.Expect(target.copies[0].SourceRegion).To(Equal("eu-west-1"))
+	g.Expect(target.copies[0].Encrypted).To(BeTrue())
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	g.Expect(awsBuild.Status.Ready).To(BeFalse())
+	g.Expect(awsBuild.Status.Copies).To(HaveLen(1))
+	copyID := awsBuild.Status.Copies[0].ImageID
+	g.Expect(copyID).To(HavePrefix("ami-us-east-1-"))
+
+	// The instance is terminated and the AWSBuild ready once the copy is available.
+	target.images[copyID].State = ImageAvailable
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	g.Expect(awsBuild.Status.Ready).To(BeTrue())
+	g.Expect(awsBuild.Status.ImageRef).To(Equal(image.ID))
+	g.Expect(awsBuild.Status.Artifact.Location).To(Equal("arn:aws:ec2:eu-west-1::image/" + image.ID))
+	g.Expect(*awsBuild.Status.Artifact.SizeBytes).To(BeEquivalentTo(20 << 30))
+	g.Expect(awsBuild.Status.Copies[0].State).To(Equal(ImageAvailable))
+	g.Expect(source.instances["i-1"].State).To(Equal(InstanceTerminated))
+	g.Expect(conditions.IsTrue(awsBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The key pair is deleted with the AWSBuild, the AMIs are kept.
+	g.Expect(c.Delete(ctx, awsBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(source.keyPairs).To(BeEmpty())
+	g.Expect(source.images).To(HaveKey(image.ID))
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).ToNot(Succeed())
+}
+
+func TestAWSBuildReconcileInterrupted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, awsBuild := setupTest(g)
+	ec2 := newFakeEC2("eu-west-1")
+	ec2.images["ami-0"] = &Image{ID: "ami-0", RootDeviceName: "/dev/xvda", State: ImageAvailable}
+	r := &AWSBuildReconciler{
+		Client:   c,
+		NewEC2:   func(publish.Credentials, string) EC2 { return ec2 },
+		recorder: record.NewFakeRecorder(100),
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(awsBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The Spot Instance is interrupted while the provisioners are running.
+	ec2.instances["i-1"].State = InstanceTerminated
+	ec2.instances["i-1"].StateReason = "Server.SpotInstanceTermination: Spot Instance termination"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, awsBuild)).To(Succeed())
+	g.Expect(awsBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.PreemptedBuildError)))
+	g.Expect(*awsBuild.Status.FailureMessage).To(ContainSubstring("Spot Instance termination"))
+}
+
+func TestAWSBuildReconcileReplication(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, awsBuild := setupTest(g)
+	build.Spec.Artifact = &buildv1.ArtifactSpec{Replication: &buildv1.ReplicationSpec{
+		Regions:  []string{"us-east-1", "ap-south-1"},
+		Accounts: []string{"333333333333", "invalid"},
+	}}
+	source := newFakeEC2("eu-west-1")
+	source.invalidAccounts = []string{"invalid"}
+	image := &Image{ID: "ami-1", Name: "ubuntu-22.04", State: ImageAvailable}
+	targets := map[string]*fakeEC2{"us-east-1": newFakeEC2("us-east-1"), "ap-south-1": newFakeEC2("ap-south-1")}
+	recorder := record.NewFakeRecorder(100)
+	r := &AWSBuildReconciler{
+		Client:   c,
+		NewEC2:   func(_ publish.Credentials, region string) EC2 { return targets[region] },
+		recorder: recorder,
+	}
+
+	// The AMI is shared with the accounts and copied to the regions, the invalid account is failed.
+	replicated, err := r.reconcileReplication(ctx, source, awsBuild, build, image)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(replicated).To(BeFalse())
+	g.Expect(source.shared).To(ConsistOf("333333333333"))
+	g.Expect(targets["us-east-1"].copies).To(HaveLen(1))
+	g.Expect(targets["us-east-1"].copies[0].SourceImageID).To(Equal("ami-1"))
+	replication := func(targetType buildv1.ReplicationTargetType, name string) buildv1.ReplicationStatus {
+		return *providersdk.ReplicationFor(awsBuild, buildv1.ReplicationStatus{Type: targetType, Name: name})
+	}
+	g.Expect(awsBuild.Status.Replications).To(HaveLen(4))
+	g.Expect(replication(buildv1.ReplicationTargetAccount, "333333333333").Phase).To(Equal(buildv1.ReplicationPhaseReplicated))
+	invalid := replication(buildv1.ReplicationTargetAccount, "invalid")
+	g.Expect(invalid.Phase).To(Equal(buildv1.ReplicationPhaseFailed))
+	g.Expect(invalid.Message).To(ContainSubstring("InvalidUserID.Malformed"))
+	usEast := replication(buildv1.ReplicationTargetRegion, "us-east-1")
+	g.Expect(usEast.Phase).To(Equal(buildv1.ReplicationPhasePending))
+	g.Expect(usEast.ImageRef).To(HavePrefix("ami-us-east-1-"))
+	g.Expect(<-recorder.Events).To(ContainSubstring("Failed to replicate to Account invalid"))
+
+	// The copies are shared with the accounts once available, a failed copy does not fail the AWSBuild.
+	apSouth := replication(buildv1.ReplicationTargetRegion, "ap-south-1")
+	targets["us-east-1"].images[usEast.ImageRef].State = ImageAvailable
+	targets["ap-south-1"].images[apSouth.ImageRef].State = ImageFailed
+	targets["ap-south-1"].images[apSouth.ImageRef].StateReason = "snapshot copy failed"
+	replicated, err = r.reconcileReplication(ctx, source, awsBuild, build, image)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(replicated).To(BeTrue())
+	g.Expect(targets["us-east-1"].shared).To(ConsistOf("333333333333"))
+	g.Expect(replication(buildv1.ReplicationTargetRegion, "us-east-1").Phase).To(Equal(buildv1.ReplicationPhaseReplicated))
+	apSouth = replication(buildv1.ReplicationTargetRegion, "ap-south-1")
+	g.Expect(apSouth.Phase).To(Equal(buildv1.ReplicationPhaseFailed))
+	g.Expect(apSouth.Message).To(ContainSubstring("snapshot copy failed"))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.AWSBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName:   "ubuntu-22.04",
+			Connector:   buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			IdentityRef: &corev1.LocalObjectReference{Name: "aws"},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "AWSBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	awsBuild := &infrav1.AWSBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "awsbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.AWSBuildSpec{
+			Region:         "eu-west-1",
+			SourceAMI:      "ami-0",
+			RootVolumeSize: 20,
+			Spot:           &infrav1.AWSSpotOptions{},
+			Username:       "ubuntu",
+			AMI: infrav1.AWSAMISpec{
+				LaunchPermissions: []string{"111111111111"},
+				Copies: []infrav1.AWSAMICopy{{
+					Name:      "production",
+					Region:    "us-east-1",
+					AccountID: "222222222222",
+					RoleARN:   "arn:aws:iam::222222222222:role/forge",
+					Encrypted: true,
+				}},
+			},
+		},
+	}
+	identity := &buildv1.ProviderIdentity{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "aws"},
+		Spec:       buildv1.ProviderIdentitySpec{Provider: ProviderName, SecretRef: corev1.LocalObjectReference{Name: "aws"}},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "aws"},
+		Data: map[string][]byte{
+			infrav1.AWSAccessKeyIDKey:     []byte("AKID"),
+			infrav1.AWSSecretAccessKeyKey: []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, awsBuild, identity, credentials).
+		WithStatusSubresource(build, awsBuild).
+		Build()
+	return c, build, awsBuild
+}
@@ -0,0 +1,520 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package aws implements the AWSBuild infrastructure provider: the build machine is an EC2 instance, and the AMI
+// is created from it once the provisioners of the Build are ready, then copied to other regions and accounts.
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// ec2APIVersion is the version of the EC2 Query API.
+	ec2APIVersion = "2016-11-15"
+
+	// stsAPIVersion is the version of the STS Query API.
+	stsAPIVersion = "2011-06-15"
+)
+
+// The states of the instances and images.
+const (
+	InstancePending      = "pending"
+	InstanceRunning      = "running"
+	InstanceShuttingDown = "shutting-down"
+	InstanceTerminated   = "terminated"
+	InstanceStopping     = "stopping"
+	InstanceStopped      = "stopped"
+
+	ImagePending   = "pending"
+	ImageAvailable = "available"
+	ImageFailed    = "failed"
+)
+
+// EC2 is the part of the EC2 and STS APIs used by the controller, in a region.
+type EC2 interface {
+	ImportKeyPair(ctx context.Context, name, publicKey string, tags map[string]string) error
+	DeleteKeyPair(ctx context.Context, name string) error
+	RunInstance(ctx context.Context, input *RunInstanceInput) (*Instance, error)
+	DescribeInstance(ctx context.Context, id string) (*Instance, error)
+	TerminateInstance(ctx context.Context, id string) error
+	CancelSpotInstanceRequest(ctx context.Context, id string) error
+	CreateImage(ctx context.Context, instanceID, name, description string, tags map[string]string) (string, error)
+	DescribeImage(ctx context.Context, id string) (*Image, error)
+	FindImage(ctx context.Context, name string) (*Image, error)
+	ShareImage(ctx context.Context, image *Image, accountIDs []string) error
+	CopyImage(ctx context.Context, input *CopyImageInput) (string, error)
+	AssumeRole(ctx context.Context, roleARN, sessionName string) (publish.Credentials, error)
+}
+
+// RunInstanceInput are the parameters of the instance launched by RunInstance.
+type RunInstanceInput struct {
+	ImageID            string
+	InstanceType       string
+	KeyName            string
+	SubnetID           string
+	SecurityGroupIDs   []string
+	PublicIP           bool
+	RootDeviceName     string
+	RootVolumeSize     int32
+	RootVolumeType     string
+	Spot               bool
+	SpotMaxPrice       string
+	IAMInstanceProfile string
+	// ClientToken makes the launch idempotent, the instance launched with the same token is returned.
+	ClientToken string
+	Tags        map[string]string
+}
+
+// CopyImageInput are the parameters of CopyImage, the copy is made in the region of the client.
+type CopyImageInput struct {
+	SourceRegion  string
+	SourceImageID string
+	Name          string
+	Description   string
+	Encrypted     bool
+	KMSKeyID      string
+	ClientToken   string
+}
+
+// Instance is an EC2 instance.
+type Instance struct {
+	ID                    string `xml:"instanceId"`
+	State                 string `xml:"instanceState>name"`
+	StateReason           string `xml:"stateReason>message"`
+	PrivateIP             string `xml:"privateIpAddress"`
+	PublicIP              string `xml:"ipAddress"`
+	SpotInstanceRequestID string `xml:"spotInstanceRequestId"`
+}
+
+// Image is an AMI.
+type Image struct {
+	ID             string `xml:"imageId"`
+	Name           string `xml:"name"`
+	State          string `xml:"imageState"`
+	StateReason    string `xml:"stateReason>message"`
+	CreationDate   string `xml:"creationDate"`
+	RootDeviceName string `xml:"rootDeviceName"`
+	BlockDevices   []struct {
+		SnapshotID string `xml:"ebs>snapshotId"`
+		VolumeSize int64  `xml:"ebs>volumeSize"`
+	} `xml:"blockDeviceMapping>item"`
+}
+
+// SnapshotIDs returns the IDs of the EBS snapshots of the image.
+func (i *Image) SnapshotIDs() []string {
+	var ids []string
+	for _, device := range i.BlockDevices {
+		if device.SnapshotID != "" {
+			ids = append(ids, device.SnapshotID)
+		}
+	}
+	return ids
+}
+
+// SizeBytes returns the total size of the volumes of the image.
+func (i *Image) SizeBytes() int64 {
+	var size int64
+	for _, device := range i.BlockDevices {
+		size += device.VolumeSize << 30
+	}
+	return size
+}
+
+// APIError is an error returned by the APIs.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the APIs, e.g. InvalidInstanceID.NotFound.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.HasSuffix(apiErr.Code, "NotFound")
+}
+
+// IsDuplicate returns true if err reports a resource already existing, e.g. InvalidKeyPair.Duplicate.
+func IsDuplicate(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.HasSuffix(apiErr.Code, ".Duplicate")
+}
+
+// Client is a client of the EC2 and STS APIs of a region.
+type Client struct {
+	// HTTPClient sends the requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// Credentials sign the requests.
+	Credentials publish.Credentials
+	// Region is the region of the APIs.
+	Region string
+	// EC2Endpoint is the endpoint of the EC2 API, https://ec2.<region>.amazonaws.com if empty.
+	EC2Endpoint string
+	// STSEndpoint is the endpoint of the STS API, https://sts.<region>.amazonaws.com if empty.
+	STSEndpoint string
+}
+
+var _ EC2 = &Client{}
+
+// NewEC2 returns a client of the APIs of the region signing its requests with the credentials.
+func NewEC2(credentials publish.Credentials, region string) EC2 {
+	return &Client{Credentials: credentials, Region: region}
+}
+
+func (c *Client) ImportKeyPair(ctx context.Context, name, publicKey string, tags map[string]string) error {
+	params := url.Values{
+		"KeyName":           {name},
+		"PublicKeyMaterial": {base64.StdEncoding.EncodeToString([]byte(publicKey))},
+	}
+	addTags(params, "TagSpecification.1.", "key-pair", tags)
+	return c.ec2(ctx, "ImportKeyPair", params, nil)
+}
+
+func (c *Client) DeleteKeyPair(ctx context.Context, name string) error {
+	return c.ec2(ctx, "DeleteKeyPair", url.Values{"KeyName": {name}}, nil)
+}
+
+func (c *Client) RunInstance(ctx context.Context, input *RunInstanceInput) (*Instance, error) {
+	params := url.Values{
+		"ImageId":      {input.ImageID},
+		"InstanceType": {input.InstanceType},
+		"KeyName":      {input.KeyName},
+		"MinCount":     {"1"},
+		"MaxCount":     {"1"},
+		"ClientToken":  {input.ClientToken},
+
+		"NetworkInterface.1.DeviceIndex":              {"0"},
+		"NetworkInterface.1.AssociatePublicIpAddress": {strconv.FormatBool(input.PublicIP)},
+		"NetworkInterface.1.DeleteOnTermination":      {"true"},
+	}
+	if input.SubnetID != "" {
+		params.Set("NetworkInterface.1.SubnetId", input.SubnetID)
+	}
+	for i, id := range input.SecurityGroupIDs {
+		params.Set(fmt.Sprintf("NetworkInterface.1.SecurityGroupId.%d", i+1), id)
+	}
+	if input.RootVolumeSize > 0 || input.RootVolumeType != "" {
+		params.Set("BlockDeviceMapping.1.DeviceName", input.RootDeviceName)
+		params.Set("BlockDeviceMapping.1.Ebs.DeleteOnTermination", "true")
+		if input.RootVolumeSize > 0 {
+			params.Set("BlockDeviceMapping.1.Ebs.VolumeSize", strconv.Itoa(int(input.RootVolumeSize)))
+		}
+		if input.RootVolumeType != "" {
+			params.Set("BlockDeviceMapping.1.Ebs.VolumeType", input.RootVolumeType)
+		}
+	}
+	if input.Spot {
+		params.Set("InstanceMarketOptions.MarketType", "spot")
+		params.Set("InstanceMarketOptions.SpotOptions.SpotInstanceType", "one-time")
+		params.Set("InstanceMarketOptions.SpotOptions.InstanceInterruptionBehavior", "terminate")
+		if input.SpotMaxPrice != "" {
+			params.Set("InstanceMarketOptions.SpotOptions.MaxPrice", input.SpotMaxPrice)
+		}
+	}
+	if input.IAMInstanceProfile != "" {
+		params.Set("IamInstanceProfile.Name", input.IAMInstanceProfile)
+	}
+	addTags(params, "TagSpecification.1.", "instance", input.Tags)
+	addTags(params, "TagSpecification.2.", "volume", input.Tags)
+
+	resp := &struct {
+		Instances []Instance `xml:"instancesSet>item"`
+	}{}
+	if err := c.ec2(ctx, "RunInstances", params, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Instances) == 0 {
+		return nil, errors.New("RunInstances returned no instance")
+	}
+	return &resp.Instances[0], nil
+}
+
+func (c *Client) DescribeInstance(ctx context.Context, id string) (*Instance, error) {
+	resp := &struct {
+		Instances []Instance `xml:"reservationSet>item>instancesSet>item"`
+	}{}
+	if err := c.ec2(ctx, "DescribeInstances", url.Values{"InstanceId.1": {id}}, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Instances) == 0 {
+		return nil, &APIError{StatusCode: http.StatusBadRequest, Code: "InvalidInstanceID.NotFound", Message: fmt.Sprintf("instance %s not found", id)}
+	}
+	return &resp.Instances[0], nil
+}
+
+func (c *Client) TerminateInstance(ctx context.Context, id string) error {
+	return c.ec2(ctx, "TerminateInstances", url.Values{"InstanceId.1": {id}}, nil)
+}
+
+func (c *Client) CancelSpotInstanceRequest(ctx context.Context, id string) error {
+	return c.ec2(ctx, "CancelSpotInstanceRequests", url.Values{"SpotInstanceRequestId.1": {id}}, nil)
+}
+
+// CreateImage creates an AMI from the instance and returns its ID. The instance is rebooted so its file systems
+// are consistent.
+func (c *Client) CreateImage(ctx context.Context, instanceID, name, description string, tags map[string]string) (string, error) {
+	params := url.Values{
+		"InstanceId": {instanceID},
+		"Name":       {name},
+	}
+	if description != "" {
+		params.Set("Description", description)
+	}
+	addTags(params, "TagSpecification.1.", "image", tags)
+	addTags(params, "TagSpecification.2.", "snapshot", tags)
+	resp := &struct {
+		ImageID string `xml:"imageId"`
+	}{}
+	if err := c.ec2(ctx, "CreateImage", params, resp); err != nil {
+		return "", err
+	}
+	return resp.ImageID, nil
+}
+
+func (c *Client) DescribeImage(ctx context.Context, id string) (*Image, error) {
+	images, err := c.describeImages(ctx, url.Values{"ImageId.1": {id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, &APIError{StatusCode: http.StatusBadRequest, Code: "InvalidAMIID.NotFound", Message: fmt.Sprintf("image %s not found", id)}
+	}
+	return &images[0], nil
+}
+
+// FindImage returns the AMI of the account with the name, nil if there is none.
+func (c *Client) FindImage(ctx context.Context, name string) (*Image, error) {
+	images, err := c.describeImages(ctx, url.Values{
+		"Owner.1":          {"self"},
+		"Filter.1.Name":    {"name"},
+		"Filter.1.Value.1": {name},
+	})
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+	return &images[0], nil
+}
+
+func (c *Client) describeImages(ctx context.Context, params url.Values) ([]Image, error) {
+	resp := &struct {
+		Images []Image `xml:"imagesSet>item"`
+	}{}
+	if err := c.ec2(ctx, "DescribeImages", params, resp); err != nil {
+		return nil, err
+	}
+	return resp.Images, nil
+}
+
+// ShareImage grants the accounts the permissions to launch the AMI and to create volumes from its snapshots.
+func (c *Client) ShareImage(ctx context.Context, image *Image, accountIDs []string) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+	params := url.Values{"ImageId": {image.ID}}
+	for i, id := range accountIDs {
+		params.Set(fmt.Sprintf("LaunchPermission.Add.%d.UserId", i+1), id)
+	}
+	if err := c.ec2(ctx, "ModifyImageAttribute", params, nil); err != nil {
+		return err
+	}
+	for _, snapshotID := range image.SnapshotIDs() {
+		params := url.Values{
+			"SnapshotId":    {snapshotID},
+			"Attribute":     {"createVolumePermission"},
+			"OperationType": {"add"},
+		}
+		for i, id := range accountIDs {
+			params.Set(fmt.Sprintf("UserId.%d", i+1), id)
+		}
+		if err := c.ec2(ctx, "ModifySnapshotAttribute", params, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) CopyImage(ctx context.Context, input *CopyImageInput) (string, error) {
+	params := url.Values{
+		"SourceRegion":  {input.SourceRegion},
+		"SourceImageId": {input.SourceImageID},
+		"Name":          {input.Name},
+		"ClientToken":   {input.ClientToken},
+	}
+	if input.Description != "" {
+		params.Set("Description", input.Description)
+	}
+	if input.Encrypted {
+		params.Set("Encrypted", "true")
+		if input.KMSKeyID != "" {
+			params.Set("KmsKeyId", input.KMSKeyID)
+		}
+	}
+	resp := &struct {
+		ImageID string `xml:"imageId"`
+	}{}
+	if err := c.ec2(ctx, "CopyImage", params, resp); err != nil {
+		return "", err
+	}
+	return resp.ImageID, nil
+}
+
+// AssumeRole returns temporary credentials of the role.
+func (c *Client) AssumeRole(ctx context.Context, roleARN, sessionName string) (publish.Credentials, error) {
+	resp := &struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleResult>Credentials"`
+	}{}
+	params := url.Values{"RoleArn": {roleARN}, "RoleSessionName": {sessionName}}
+	if err := c.sts(ctx, "AssumeRole", params, resp); err != nil {
+		return publish.Credentials{}, err
+	}
+	return publish.Credentials{
+		AccessKeyID:     resp.Credentials.AccessKeyID,
+		SecretAccessKey: resp.Credentials.SecretAccessKey,
+		SessionToken:    resp.Credentials.SessionToken,
+	}, nil
+}
+
+// GetCallerIdentity returns the ARN of the identity of the credentials.
+func (c *Client) GetCallerIdentity(ctx context.Context) (string, error) {
+	resp := &struct {
+		ARN string `xml:"GetCallerIdentityResult>Arn"`
+	}{}
+	if err := c.sts(ctx, "GetCallerIdentity", url.Values{}, resp); err != nil {
+		return "", err
+	}
+	return resp.ARN, nil
+}
+
+func (c *Client) ec2(ctx context.Context, action string, params url.Values, out interface{}) error {
+	endpoint := c.EC2Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://ec2.%s.amazonaws.com", c.Region)
+	}
+	params.Set("Version", ec2APIVersion)
+	return c.do(ctx, endpoint, "ec2", action, params, out)
+}
+
+func (c *Client) sts(ctx context.Context, action string, params url.Values, out interface{}) error {
+	endpoint := c.STSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", c.Region)
+	}
+	params.Set("Version", stsAPIVersion)
+	return c.do(ctx, endpoint, "sts", action, params, out)
+}
+
+// do sends the signed Query API request and decodes the XML response into out. The errors are classified:
+// throttling and server errors are retried, the invalid requests are configuration errors.
+func (c *Client) do(ctx context.Context, endpoint, service, action string, params url.Values, out interface{}) error {
+	params.Set("Action", action)
+	body := []byte(params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	publish.SignRequest(req, c.Credentials, c.Region, service, body)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", service, action))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return classifier.Classify(parseError(resp.StatusCode, resp.Body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "invalid response to %s %s", service, action)
+	}
+	return nil
+}
+
+// parseError parses the error responses of EC2, <Response><Errors><Error>, and of STS, <ErrorResponse><Error>.
+func parseError(statusCode int, body io.Reader) *APIError {
+	return apierror.Parse(statusCode, body, func(data []byte) (string, string) {
+		resp := &struct {
+			Errors []struct {
+				Code    string `xml:"Code"`
+				Message string `xml:"Message"`
+			} `xml:"Errors>Error"`
+			Error struct {
+				Code    string `xml:"Code"`
+				Message string `xml:"Message"`
+			} `xml:"Error"`
+		}{}
+		if err := xml.Unmarshal(data, resp); err != nil {
+			return "", ""
+		}
+		if len(resp.Errors) > 0 {
+			return resp.Errors[0].Code, resp.Errors[0].Message
+		}
+		return resp.Error.Code, resp.Error.Message
+	})
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing and existing
+// resources.
+var classifier = apierror.Classifier{
+	Handled: func(err *APIError) bool {
+		return strings.HasSuffix(err.Code, "NotFound") || strings.HasSuffix(err.Code, ".Duplicate")
+	},
+	Throttled: func(err *APIError) bool {
+		return err.Code == "RequestLimitExceeded" || err.Code == "Throttling"
+	},
+	Transient: func(err *APIError) bool {
+		return err.Code == "InsufficientInstanceCapacity" || err.Code == "IncorrectState"
+	},
+}
+
+// addTags adds the tags of the resource type to the TagSpecification at prefix, sorted by key.
+func addTags(params url.Values, prefix, resourceType string, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params.Set(prefix+"ResourceType", resourceType)
+	for i, key := range keys {
+		params.Set(fmt.Sprintf("%sTag.%d.Key", prefix, i+1), key)
+		params.Set(fmt.Sprintf("%sTag.%d.Value", prefix, i+1), tags[key])
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.ParseForm()).To(Succeed())
+		g.Expect(r.Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/ec2/aws4_request"))
+		g.Expect(r.Header.Get("X-Amz-Security-Token")).To(Equal("token"))
+		requests = append(requests, r.PostForm)
+		switch r.PostForm.Get("Action") {
+		case "RunInstances":
+			_, _ = w.Write([]byte(`<RunInstancesResponse><instancesSet><item><instanceId>i-1</instanceId><instanceState><name>pending</name></instanceState></item></instancesSet></RunInstancesResponse>`))
+		case "DescribeInstances":
+			if r.PostForm.Get("InstanceId.1") == "i-missing" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code><Message>The instance ID 'i-missing' does not exist</Message></Error></Errors></Response>`))
+				return
+			}
+			_, _ = w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet><item>` +
+				`<instanceId>i-1</instanceId><instanceState><name>running</name></instanceState>` +
+				`<privateIpAddress>10.0.0.2</privateIpAddress><ipAddress>34.1.2.3</ipAddress>` +
+				`</item></instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+		case "DescribeImages":
+			_, _ = w.Write([]byte(`<DescribeImagesResponse><imagesSet><item><imageId>ami-1</imageId><name>ubuntu</name>` +
+				`<imageState>available</imageState><rootDeviceName>/dev/sda1</rootDeviceName><blockDeviceMapping>` +
+				`<item><deviceName>/dev/sda1</deviceName><ebs><snapshotId>snap-1</snapshotId><volumeSize>8</volumeSize></ebs></item>` +
+				`</blockDeviceMapping></item></imagesSet></DescribeImagesResponse>`))
+		case "ModifyImageAttribute", "ModifySnapshotAttribute":
+			_, _ = w.Write([]byte(`<Response><return>true</return></Response>`))
+		case "CreateImage":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "CopyImage":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`<Response><Errors><Error><Code>RequestLimitExceeded</Code><Message>Request limit exceeded.</Message></Error></Errors></Response>`))
+		case "ImportKeyPair":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`<Response><Errors><Error><Code>AuthFailure</Code><Message>AWS was not able to validate the provided access credentials</Message></Error></Errors></Response>`))
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{
+		HTTPClient:  server.Client(),
+		Credentials: publish.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+		Region:      "eu-west-1",
+		EC2Endpoint: server.URL,
+	}
+
+	instance, err := c.RunInstance(ctx, &RunInstanceInput{
+		ImageID:          "ami-0",
+		InstanceType:     "t3.medium",
+		KeyName:          "ubuntu",
+		SecurityGroupIDs: []string{"sg-1", "sg-2"},
+		PublicIP:         true,
+		RootDeviceName:   "/dev/sda1",
+		RootVolumeSize:   20,
+		Spot:             true,
+		ClientToken:      "uid",
+		Tags:             map[string]string{"Name": "ubuntu", "team": "images"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(instance.ID).To(Equal("i-1"))
+	run := requests[0]
+	g.Expect(run.Get("Version")).To(Equal(ec2APIVersion))
+	g.Expect(run.Get("NetworkInterface.1.SecurityGroupId.2")).To(Equal("sg-2"))
+	g.Expect(run.Get("NetworkInterface.1.AssociatePublicIpAddress")).To(Equal("true"))
+	g.Expect(run.Get("BlockDeviceMapping.1.DeviceName")).To(Equal("/dev/sda1"))
+	g.Expect(run.Get("BlockDeviceMapping.1.Ebs.VolumeSize")).To(Equal("20"))
+	g.Expect(run.Get("InstanceMarketOptions.MarketType")).To(Equal("spot"))
+	g.Expect(run.Get("TagSpecification.1.ResourceType")).To(Equal("instance"))
+	g.Expect(run.Get("TagSpecification.1.Tag.2.Key")).To(Equal("team"))
+	g.Expect(run.Get("TagSpecification.2.ResourceType")).To(Equal("volume"))
+
+	instance, err = c.DescribeInstance(ctx, "i-1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(instance.State).To(Equal(InstanceRunning))
+	g.Expect(instance.PublicIP).To(Equal("34.1.2.3"))
+	g.Expect(instance.PrivateIP).To(Equal("10.0.0.2"))
+
+	_, err = c.DescribeInstance(ctx, "i-missing")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+
+	image, err := c.DescribeImage(ctx, "ami-1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(image.SnapshotIDs()).To(ConsistOf("snap-1"))
+	g.Expect(image.SizeBytes()).To(BeEquivalentTo(8 << 30))
+
+	requests = nil
+	g.Expect(c.ShareImage(ctx, image, []string{"123456789012"})).To(Succeed())
+	g.Expect(requests).To(HaveLen(2))
+	g.Expect(requests[0].Get("LaunchPermission.Add.1.UserId")).To(Equal("123456789012"))
+	g.Expect(requests[1].Get("SnapshotId")).To(Equal("snap-1"))
+	g.Expect(requests[1].Get("UserId.1")).To(Equal("123456789012"))
+
+	_, err = c.CreateImage(ctx, "i-1", "ubuntu", "", nil)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+	_, err = c.CopyImage(ctx, &CopyImageInput{SourceRegion: "eu-west-1", SourceImageID: "ami-1", Name: "ubuntu"})
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+	err = c.ImportKeyPair(ctx, "ubuntu", "ssh-rsa AAAA", nil)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	g.Expect(err.Error()).To(ContainSubstring("AuthFailure"))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/retention"
+)
+
+// ImageDeleter deregisters the AMIs of the expired Images of AWSBuilds, along with their copies to other regions,
+// and deletes their snapshots.
+type ImageDeleter struct {
+	// HTTPClient sends the requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// EC2Endpoint is the endpoint of the EC2 API of every region, https://ec2.<region>.amazonaws.com if empty.
+	EC2Endpoint string
+}
+
+var _ retention.Deleter = &ImageDeleter{}
+
+// Delete deregisters the AMI of the Image and its copies, and returns the size of their deleted snapshots.
+func (d *ImageDeleter) Delete(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error) {
+	creds, err := credentialsFrom(secret)
+	if err != nil {
+		return 0, err
+	}
+	region, err := imageRegion(image)
+	if err != nil {
+		return 0, err
+	}
+	amis := map[string]string{region: image.Spec.ImageRef}
+	for _, replication := range image.Spec.Replications {
+		if replication.Type == buildv1.ReplicationTargetRegion && replication.ImageRef != "" {
+			amis[replication.Name] = replication.ImageRef
+		}
+	}
+
+	var reclaimed int64
+	for region, ami := range amis {
+		c := &Client{HTTPClient: d.HTTPClient, Credentials: creds, Region: region, EC2Endpoint: d.EC2Endpoint}
+		size, err := c.DeleteImage(ctx, ami)
+		if err != nil {
+			return reclaimed, err
+		}
+		reclaimed += size
+	}
+	return reclaimed, nil
+}
+
+// DeleteImage deregisters the AMI and deletes its snapshots, it returns their size. Deleting an AMI which does not
+// exist succeeds.
+func (c *Client) DeleteImage(ctx context.Context, id string) (int64, error) {
+	ami, err := c.DescribeImage(ctx, id)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err := c.ec2(ctx, "DeregisterImage", url.Values{"ImageId": {id}}, nil); err != nil && !IsNotFound(err) {
+		return 0, err
+	}
+	// The snapshots can only be deleted once the AMI has been deregistered.
+	for _, snapshotID := range ami.SnapshotIDs() {
+		if err := c.ec2(ctx, "DeleteSnapshot", url.Values{"SnapshotId": {snapshotID}}, nil); err != nil && !IsNotFound(err) {
+			return 0, err
+		}
+	}
+	return ami.SizeBytes(), nil
+}
+
+// imageRegion returns the region of the AMI of the Image, from the ARN of its artifact.
+func imageRegion(image *buildv1.Image) (string, error) {
+	if image.Spec.Artifact != nil {
+		// arn:aws:ec2:<region>::image/<ami>
+		if parts := strings.Split(image.Spec.Artifact.Location, ":"); len(parts) == 6 && parts[0] == "arn" && parts[3] != "" {
+			return parts[3], nil
+		}
+	}
+	return "", forgeerrors.ConfigErrorf("the region of AMI %s is unknown, Image %s has no artifact ARN", image.Spec.ImageRef, image.Name)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestImageDeleter(t *testing.T) {
+	g := NewWithT(t)
+
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.ParseForm()).To(Succeed())
+		mu.Lock()
+		defer mu.Unlock()
+		action := r.PostForm.Get("Action")
+		switch action {
+		case "DescribeImages":
+			if r.PostForm.Get("ImageId.1") == "ami-2" {
+				// The copy has already been deregistered.
+				_, _ = w.Write([]byte(`<DescribeImagesResponse><imagesSet/></DescribeImagesResponse>`))
+				return
+			}
+			g.Expect(r.Header.Get("Authorization")).To(ContainSubstring("/us-west-2/ec2/aws4_request"))
+			_, _ = w.Write([]byte(`<DescribeImagesResponse><imagesSet><item><imageId>ami-1</imageId><blockDeviceMapping>
+<item><ebs><snapshotId>snap-1</snapshotId><volumeSize>8</volumeSize></ebs></item>
+<item><ebs><snapshotId>snap-2</snapshotId><volumeSize>2</volumeSize></ebs></item>
+</blockDeviceMapping></item></imagesSet></DescribeImagesResponse>`))
+		case "DeregisterImage":
+			calls = append(calls, action+" "+r.PostForm.Get("ImageId"))
+		case "DeleteSnapshot":
+			calls = append(calls, action+" "+r.PostForm.Get("SnapshotId"))
+		default:
+			t.Errorf("unexpected action %s", action)
+		}
+	}))
+	defer server.Close()
+
+	image := &buildv1.Image{Spec: buildv1.ImageSpec{
+		ImageRef: "ami-1",
+		Artifact: &buildv1.BuildArtifact{ID: "ami-1", Location: "arn:aws:ec2:us-west-2::image/ami-1"},
+		Replications: []buildv1.ReplicationStatus{
+			{Type: buildv1.ReplicationTargetRegion, Name: "eu-west-1", ImageRef: "ami-2"},
+			{Type: buildv1.ReplicationTargetAccount, Name: "123456789012"},
+		},
+	}}
+	secret := &corev1.Secret{Data: map[string][]byte{
+		infrav1.AWSAccessKeyIDKey:     []byte("AKID"),
+		infrav1.AWSSecretAccessKeyKey: []byte("secret"),
+	}}
+	d := &ImageDeleter{HTTPClient: server.Client(), EC2Endpoint: server.URL}
+	reclaimed, err := d.Delete(context.Background(), image, secret)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reclaimed).To(Equal(int64(10 << 30)))
+	g.Expect(calls).To(Equal([]string{"DeregisterImage ami-1", "DeleteSnapshot snap-1", "DeleteSnapshot snap-2"}))
+
+	// The region of the AMI is required.
+	image.Spec.Artifact = nil
+	_, err = d.Delete(context.Background(), image, secret)
+	g.Expect(err).To(MatchError(ContainSubstring("the region of AMI ami-1 is unknown")))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/identity"
+)
+
+// stsRegion is the region of the STS endpoint the credentials are validated with.
+const stsRegion = "us-east-1"
+
+// Validator validates the credentials of the aws ProviderIdentities with STS GetCallerIdentity.
+type Validator struct {
+	// HTTPClient sends the requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// STSEndpoint is the endpoint of the STS API, the endpoint of us-east-1 if empty.
+	STSEndpoint string
+}
+
+var _ identity.Validator = &Validator{}
+
+// Validate returns an error if the credentials are invalid or rejected.
+func (v *Validator) Validate(ctx context.Context, _ *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	creds, err := credentialsFrom(secret)
+	if err != nil {
+		return err
+	}
+	c := &Client{HTTPClient: v.HTTPClient, Credentials: creds, Region: stsRegion, STSEndpoint: v.STSEndpoint}
+	_, err = c.GetCallerIdentity(ctx)
+	return err
+}
+
+// credentialsFrom returns the credentials of the secret of an aws ProviderIdentity.
+func credentialsFrom(secret *corev1.Secret) (publish.Credentials, error) {
+	creds := publish.Credentials{
+		AccessKeyID:     string(secret.Data[infrav1.AWSAccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[infrav1.AWSSecretAccessKeyKey]),
+		SessionToken:    string(secret.Data[infrav1.AWSSessionTokenKey]),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, forgeerrors.ConfigErrorf("the secret has no %s or %s key", infrav1.AWSAccessKeyIDKey, infrav1.AWSSecretAccessKeyKey)
+	}
+	return creds, nil
+}
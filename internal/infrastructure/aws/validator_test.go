@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		body      string
+		valid     bool
+		retryable bool
+	}{
+		{
+			name:   "valid credentials",
+			status: http.StatusOK,
+			body:   `<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123456789012:user/forge</Arn></GetCallerIdentityResult></GetCallerIdentityResponse>`,
+			valid:  true,
+		},
+		{
+			name:   "invalid credentials",
+			status: http.StatusForbidden,
+			body:   `<ErrorResponse><Error><Type>Sender</Type><Code>InvalidClientTokenId</Code><Message>The security token included in the request is invalid.</Message></Error></ErrorResponse>`,
+		},
+		{
+			name:      "STS unavailable",
+			status:    http.StatusServiceUnavailable,
+			retryable: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.ParseForm()).To(Succeed())
+				g.Expect(r.PostForm.Get("Action")).To(Equal("GetCallerIdentity"))
+				g.Expect(r.Header.Get("Authorization")).To(ContainSubstring("/us-east-1/sts/aws4_request"))
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: map[string][]byte{
+				infrav1.AWSAccessKeyIDKey:     []byte("AKID"),
+				infrav1.AWSSecretAccessKeyKey: []byte("secret"),
+			}}
+			v := &Validator{HTTPClient: server.Client(), STSEndpoint: server.URL}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
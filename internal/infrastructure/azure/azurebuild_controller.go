@@ -0,0 +1,703 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the AzureBuild controller, used in the controller metrics.
+	ControllerName = "azurebuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "azure"
+
+	// vmRequeueAfter is how often a VM being created, deprovisioned, deallocated or deleted is checked.
+	vmRequeueAfter = 10 * time.Second
+
+	// imageRequeueAfter is how often an image version being created and replicated is checked.
+	imageRequeueAfter = 30 * time.Second
+
+	// buildTag is the tag of the resources of a Build, holding its namespaced name. The tag names of Azure cannot
+	// hold slashes.
+	buildTag = "forge-build"
+)
+
+// The run commands deprovisioning the VMs before they are generalized.
+var (
+	linuxDeprovisionScript = []string{"/usr/sbin/waagent -force -deprovision+user && export HISTSIZE=0 && sync"}
+
+	windowsDeprovisionScript = []string{
+		`& $env:SystemRoot\System32\Sysprep\Sysprep.exe /oobe /generalize /quiet /quit /mode:vm`,
+		`while ((Get-ItemProperty HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Setup\State).ImageState -ne 'IMAGE_STATE_GENERALIZE_RESEAL_TO_OOBE') { Start-Sleep -Seconds 10 }`,
+	}
+)
+
+// AzureBuildReconciler reconciles a AzureBuild object
+type AzureBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewCompute returns the client of the API authenticated with a service principal, NewCompute is used if nil.
+	NewCompute func(ctx context.Context, sp *ServicePrincipal, subscriptionID string) Compute
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AzureBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.AzureBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("AzureBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("azurebuild-controller")
+	return nil
+}
+
+// Reconcile creates the VM of an AzureBuild, publishes its address in the connector credentials of the Build and,
+// once the provisioners of the Build are ready, deprovisions, generalizes and captures it into a gallery image
+// version.
+func (r *AzureBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	azureBuild := &infrav1.AzureBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, azureBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(azureBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(azureBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(azureBuild)
+		if err := patchHelper.Patch(ctx, azureBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, azureBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !azureBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, azureBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the AzureBuild")
+		return ctrl.Result{}, nil
+	}
+	if azureBuild.Status.FailureReason != nil || azureBuild.Status.Ready {
+		// The VM of a failed or completed AzureBuild is deleted along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(azureBuild, infrav1.AzureBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, azureBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, azureBuild, res, err)
+}
+
+func (r *AzureBuildReconciler) reconcileNormal(ctx context.Context, azureBuild *infrav1.AzureBuild, build *buildv1.Build) (ctrl.Result, error) {
+	compute, err := r.compute(ctx, azureBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	vm, err := r.reconcileVM(ctx, compute, azureBuild, build)
+	if err != nil || vm == nil {
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(azureBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileImage(ctx, compute, azureBuild, build, vm)
+}
+
+// reconcileVM creates the network interface and the VM, and publishes the address of the VM once it is running.
+// It returns nil while the VM is being created.
+func (r *AzureBuildReconciler) reconcileVM(ctx context.Context, compute Compute, azureBuild *infrav1.AzureBuild, build *buildv1.Build) (*VirtualMachine, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &azureBuild.Spec
+	status := &azureBuild.Status
+
+	name := cmp.Or(status.VMName, providersdk.MachineName(azureBuild))
+	vm, err := compute.GetVirtualMachine(ctx, spec.ResourceGroup, name)
+	if IsNotFound(err) {
+		if status.MachineReady && spec.Spot != nil {
+			return nil, forgeerrors.Terminalf(forgeerrors.PreemptedBuildError, "Spot VM %s has been evicted", name)
+		}
+		if status.MachineReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s has been deleted", name)
+		}
+		if done, err := r.checkOperation(ctx, compute, azureBuild, "create VM "+name); err != nil || !done {
+			return nil, err
+		}
+
+		// The marketplace images have no resource ID, the source image of the Build is always an image version.
+		sourceImageID, ok := providersdk.SourceImage(build, spec.SourceImage.ID)
+		if !ok {
+			conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the source image of the Build")
+			return nil, nil
+		}
+		creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultAzureUsername))
+		if err != nil {
+			return nil, err
+		}
+		nic, err := r.reconcileNetwork(ctx, compute, azureBuild, name)
+		if err != nil || nic == nil {
+			return nil, err
+		}
+		vm, err := vmFor(azureBuild, build, name, sourceImageID, nic, creds)
+		if err != nil {
+			return nil, err
+		}
+		op, err := compute.CreateVirtualMachine(ctx, spec.ResourceGroup, vm)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create VM %s", name)
+		}
+		log.Info("Creating VM", "vm", name)
+		r.recorder.Eventf(azureBuild, corev1.EventTypeNormal, "VMCreating", "Creating VM %s", name)
+		status.VMName = name
+		status.PendingOperation = op
+		status.SourceImageRef = sourceImageID
+		conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Creating VM %s", name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VM %s", name)
+	}
+	status.VMName = vm.Name
+	status.VMID = vm.Properties.VMID
+
+	if status.MachineReady {
+		if power := vm.PowerState(); power != PowerRunning && !build.Status.ProvisionersReady {
+			if spec.Spot != nil {
+				return nil, forgeerrors.Terminalf(forgeerrors.PreemptedBuildError, "Spot VM %s is %s, it has been evicted",
+					name, strings.TrimPrefix(power, "PowerState/"))
+			}
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s while the provisioners are running",
+				name, strings.TrimPrefix(power, "PowerState/"))
+		}
+		return vm, nil
+	}
+
+	if done, err := r.checkOperation(ctx, compute, azureBuild, "create VM "+name); err != nil || !done {
+		conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Creating VM %s", name)
+		return nil, err
+	}
+	switch vm.Properties.ProvisioningState {
+	case ProvisioningSucceeded:
+	case ProvisioningFailed:
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s failed to provision", name)
+	default:
+		conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "VM %s is %s", name, vm.Properties.ProvisioningState)
+		return nil, nil
+	}
+	switch power := vm.PowerState(); power {
+	case PowerRunning:
+	case "", PowerStarting:
+		conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "VM %s is starting", name)
+		return nil, nil
+	default:
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s", name, strings.TrimPrefix(power, "PowerState/"))
+	}
+
+	address, err := r.address(ctx, compute, azureBuild, name)
+	if err != nil || address == "" {
+		return nil, err
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(azureBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	r.recorder.Eventf(azureBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	return vm, nil
+}
+
+// reconcileNetwork creates the public IP, if any, and the network interface of the VM. It returns nil until they
+// are provisioned.
+func (r *AzureBuildReconciler) reconcileNetwork(ctx context.Context, compute Compute, azureBuild *infrav1.AzureBuild, name string) (*NetworkInterface, error) {
+	spec := &azureBuild.Spec
+
+	ipConfig := IPConfiguration{Name: "ipconfig1"}
+	ipConfig.Properties.PrivateIPAllocationMethod = "Dynamic"
+	ipConfig.Properties.Subnet = &SubResource{ID: spec.SubnetID}
+	if ptr.Deref(spec.PublicIP, true) {
+		ip, err := compute.GetPublicIPAddress(ctx, spec.ResourceGroup, publicIPName(name))
+		if IsNotFound(err) {
+			ip, err = compute.CreatePublicIPAddress(ctx, spec.ResourceGroup, &PublicIPAddress{
+				Name:       publicIPName(name),
+				Location:   spec.Location,
+				Tags:       tagsFor(azureBuild),
+				SKU:        &SKU{Name: "Standard"},
+				Properties: PublicIPAddressProperties{PublicIPAllocationMethod: "Static"},
+			})
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create public IP %s", publicIPName(name))
+		}
+		if ip.Properties.ProvisioningState != ProvisioningSucceeded {
+			return nil, nil
+		}
+		ipConfig.Properties.PublicIPAddress = &SubResource{ID: ip.ID}
+	}
+
+	nic, err := compute.GetNetworkInterface(ctx, spec.ResourceGroup, nicName(name))
+	if IsNotFound(err) {
+		nic = &NetworkInterface{
+			Name:     nicName(name),
+			Location: spec.Location,
+			Tags:     tagsFor(azureBuild),
+			Properties: NetworkInterfaceProperties{
+				IPConfigurations: []IPConfiguration{ipConfig},
+			},
+		}
+		if spec.NetworkSecurityGroupID != "" {
+			nic.Properties.NetworkSecurityGroup = &SubResource{ID: spec.NetworkSecurityGroupID}
+		}
+		nic, err = compute.CreateNetworkInterface(ctx, spec.ResourceGroup, nic)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create network interface %s", nicName(name))
+	}
+	if nic.Properties.ProvisioningState != ProvisioningSucceeded {
+		return nil, nil
+	}
+	return nic, nil
+}
+
+// address returns the public IP of the VM if public, its private IP otherwise.
+func (r *AzureBuildReconciler) address(ctx context.Context, compute Compute, azureBuild *infrav1.AzureBuild, name string) (string, error) {
+	spec := &azureBuild.Spec
+	if ptr.Deref(spec.PublicIP, true) {
+		ip, err := compute.GetPublicIPAddress(ctx, spec.ResourceGroup, publicIPName(name))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get public IP %s", publicIPName(name))
+		}
+		return ip.Properties.IPAddress, nil
+	}
+	nic, err := compute.GetNetworkInterface(ctx, spec.ResourceGroup, nicName(name))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get network interface %s", nicName(name))
+	}
+	for _, ipConfig := range nic.Properties.IPConfigurations {
+		if ipConfig.Properties.PrivateIPAddress != "" {
+			return ipConfig.Properties.PrivateIPAddress, nil
+		}
+	}
+	return "", nil
+}
+
+// reconcileImage deprovisions, deallocates and generalizes the VM, then captures it into the gallery image version.
+func (r *AzureBuildReconciler) reconcileImage(ctx context.Context, compute Compute, azureBuild *infrav1.AzureBuild, build *buildv1.Build, vm *VirtualMachine) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &azureBuild.Spec
+	status := &azureBuild.Status
+
+	if !status.Deprovisioned {
+		if status.PendingOperation == "" {
+			commandID, script := "RunShellScript", linuxDeprovisionScript
+			if spec.OSType == infrav1.AzureOSTypeWindows {
+				commandID, script = "RunPowerShellScript", windowsDeprovisionScript
+			}
+			op, err := compute.RunCommand(ctx, spec.ResourceGroup, vm.Name, commandID, script)
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to deprovision VM %s", vm.Name)
+			}
+			log.Info("Deprovisioning VM", "vm", vm.Name)
+			r.recorder.Eventf(azureBuild, corev1.EventTypeNormal, infrav1.MachineGeneralizingReason, "Deprovisioning VM %s", vm.Name)
+			status.PendingOperation = op
+			conditions.MarkFalse(azureBuild, infrav1.ImageReadyCondition, infrav1.MachineGeneralizingReason, "Deprovisioning VM %s", vm.Name)
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+		}
+		if done, err := r.checkOperation(ctx, compute, azureBuild, "deprovision VM "+vm.Name); err != nil || !done {
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+		}
+		status.Deprovisioned = true
+	}
+
+	if done, err := r.checkOperation(ctx, compute, azureBuild, "deallocate VM "+vm.Name); err != nil || !done {
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+	}
+	if power := vm.PowerState(); power != PowerDeallocated {
+		if power != PowerDeallocating {
+			op, err := compute.DeallocateVirtualMachine(ctx, spec.ResourceGroup, vm.Name)
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to deallocate VM %s", vm.Name)
+			}
+			log.Info("Deallocating VM", "vm", vm.Name)
+			status.PendingOperation = op
+		}
+		conditions.MarkFalse(azureBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Deallocating VM %s", vm.Name)
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+	}
+
+	if !vm.Generalized() {
+		if err := compute.GeneralizeVirtualMachine(ctx, spec.ResourceGroup, vm.Name); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to generalize VM %s", vm.Name)
+		}
+		log.Info("Generalized VM", "vm", vm.Name)
+	}
+
+	gallery := &spec.Gallery
+	resourceGroup := cmp.Or(gallery.ResourceGroup, spec.ResourceGroup)
+	if status.ImageVersion == "" {
+		status.ImageVersion = cmp.Or(gallery.Version, versionAt(time.Now().UTC()))
+	}
+	name := fmt.Sprintf("%s/%s/%s", gallery.Name, gallery.ImageDefinition, status.ImageVersion)
+	version, err := compute.GetImageVersion(ctx, resourceGroup, gallery.Name, gallery.ImageDefinition, status.ImageVersion)
+	if IsNotFound(err) {
+		if done, err := r.checkOperation(ctx, compute, azureBuild, "create image version "+name); err != nil || !done {
+			return ctrl.Result{RequeueAfter: imageRequeueAfter}, err
+		}
+		op, err := compute.CreateImageVersion(ctx, resourceGroup, gallery.Name, gallery.ImageDefinition, imageVersionFor(azureBuild, build, vm))
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to create image version %s", name)
+		}
+		log.Info("Creating image version", "version", name)
+		r.recorder.Eventf(azureBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Creating image version %s", name)
+		status.PendingOperation = op
+		conditions.MarkFalse(azureBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Creating image version %s", name)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get image version %s", name)
+	}
+	if source := version.Properties.StorageProfile.Source; source == nil || !strings.EqualFold(source.VirtualMachineID, vm.ID) {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "image version %s already exists and was not captured from VM %s", name, vm.Name)
+	}
+	switch version.Properties.ProvisioningState {
+	case ProvisioningSucceeded:
+	case ProvisioningFailed:
+		if _, err := r.checkOperation(ctx, compute, azureBuild, "create image version "+name); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "image version %s failed", name)
+	default:
+		conditions.MarkFalse(azureBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Image version %s is %s", name, version.Properties.ProvisioningState)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+	status.PendingOperation = ""
+
+	ref := cmp.Or(version.ID, ResourceID(spec.SubscriptionID, resourceGroup, imageVersionPath(gallery.Name, gallery.ImageDefinition, status.ImageVersion)))
+	status.ImageRef = ref
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       ref,
+		Location: ref,
+		Format:   "azure-gallery",
+	}
+	if size := version.SizeBytes(); size > 0 {
+		status.Artifact.SizeBytes = ptr.To(size)
+	}
+	if publishing := version.Properties.PublishingProfile; publishing != nil {
+		if published, err := time.Parse(time.RFC3339, publishing.PublishedDate); err == nil {
+			status.Artifact.CreatedAt = &metav1.Time{Time: published}
+		}
+	}
+	status.Ready = true
+	conditions.MarkTrue(azureBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Image version %s is ready", name)
+	r.recorder.Eventf(azureBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Image version %s is ready", ref)
+	return ctrl.Result{}, nil
+}
+
+// checkOperation checks the pending operation of the AzureBuild, if any. It returns true once it is done,
+// and an error if it failed.
+func (r *AzureBuildReconciler) checkOperation(ctx context.Context, compute Compute, azureBuild *infrav1.AzureBuild, description string) (bool, error) {
+	if azureBuild.Status.PendingOperation == "" {
+		return true, nil
+	}
+	op, err := compute.GetOperation(ctx, azureBuild.Status.PendingOperation)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get the operation to %s", description)
+	}
+	if op.Status == OperationInProgress {
+		return false, nil
+	}
+	azureBuild.Status.PendingOperation = ""
+	if err := op.Err(); err != nil {
+		return false, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "failed to %s: %v", description, err)
+	}
+	return true, nil
+}
+
+// reconcileDelete deletes the VM, then its network interface and public IP, the image version is kept.
+func (r *AzureBuildReconciler) reconcileDelete(ctx context.Context, azureBuild *infrav1.AzureBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(azureBuild, infrav1.AzureBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(azureBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	if name := azureBuild.Status.VMName; name != "" {
+		compute, err := r.compute(ctx, azureBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		resourceGroup := azureBuild.Spec.ResourceGroup
+		resources := []struct {
+			kind, name string
+			get        func() error
+			delete     func() error
+		}{
+			{
+				kind: "VM", name: name,
+				get: func() error { _, err := compute.GetVirtualMachine(ctx, resourceGroup, name); return err },
+				delete: func() error {
+					_, err := compute.DeleteVirtualMachine(ctx, resourceGroup, name)
+					return err
+				},
+			},
+			{
+				kind: "network interface", name: nicName(name),
+				get: func() error { _, err := compute.GetNetworkInterface(ctx, resourceGroup, nicName(name)); return err },
+				delete: func() error {
+					return compute.DeleteNetworkInterface(ctx, resourceGroup, nicName(name))
+				},
+			},
+			{
+				kind: "public IP", name: publicIPName(name),
+				get: func() error { _, err := compute.GetPublicIPAddress(ctx, resourceGroup, publicIPName(name)); return err },
+				delete: func() error {
+					return compute.DeletePublicIPAddress(ctx, resourceGroup, publicIPName(name))
+				},
+			},
+		}
+		// The resources are deleted one after the other, the network interface is in use until the VM is gone.
+		for _, resource := range resources {
+			err := resource.get()
+			switch {
+			case IsNotFound(err):
+				continue
+			case err != nil:
+				return ctrl.Result{}, errors.Wrapf(err, "failed to get %s %s", resource.kind, resource.name)
+			}
+			if err := resource.delete(); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete %s %s", resource.kind, resource.name)
+			}
+			log.Info("Deleting "+resource.kind, "name", resource.name)
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(azureBuild, infrav1.AzureBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// compute returns the client of the API authenticated with the service principal of the AzureBuild.
+func (r *AzureBuildReconciler) compute(ctx context.Context, azureBuild *infrav1.AzureBuild, build *buildv1.Build) (Compute, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the AzureBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: azureBuild.Namespace, Name: azureBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, azureBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := servicePrincipalFrom(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+	}
+	newCompute := r.NewCompute
+	if newCompute == nil {
+		newCompute = NewCompute
+	}
+	return newCompute(ctx, sp, azureBuild.Spec.SubscriptionID), nil
+}
+
+// vmFor returns the VM of the AzureBuild, booting from the image with the resource ID, or from the marketplace image
+// of its spec if empty. The connector key is authorized for the admin user of the Linux VMs, the connector password
+// is the admin password of the Windows VMs.
+func vmFor(azureBuild *infrav1.AzureBuild, build *buildv1.Build, name, sourceImageID string, nic *NetworkInterface, creds *providersdk.Credentials) (*VirtualMachine, error) {
+	spec := &azureBuild.Spec
+	osProfile := &OSProfile{ComputerName: name, AdminUsername: creds.Username}
+	if spec.OSType == infrav1.AzureOSTypeWindows {
+		if creds.Password == "" {
+			return nil, forgeerrors.ConfigErrorf("the connector credentials of Build %s have no password, Windows AzureBuilds only authorize passwords", build.Name)
+		}
+		// The computer names of Windows are NetBIOS names, at most 15 characters.
+		osProfile.ComputerName = strings.TrimRight(name[:min(len(name), 15)], "-")
+		osProfile.AdminPassword = creds.Password
+		osProfile.WindowsConfiguration = &WindowsConfiguration{ProvisionVMAgent: true}
+	} else {
+		if creds.AuthorizedKey == "" {
+			return nil, forgeerrors.ConfigErrorf("the connector credentials of Build %s have no private key, Linux AzureBuilds only authorize keys", build.Name)
+		}
+		osProfile.LinuxConfiguration = &LinuxConfiguration{
+			DisablePasswordAuthentication: true,
+			SSH: &SSHConfiguration{PublicKeys: []SSHPublicKey{{
+				Path:    fmt.Sprintf("/home/%s/.ssh/authorized_keys", creds.Username),
+				KeyData: strings.TrimSpace(creds.AuthorizedKey),
+			}}},
+		}
+	}
+
+	source := spec.SourceImage
+	image := &ImageReference{ID: sourceImageID}
+	if sourceImageID == "" {
+		image = &ImageReference{Publisher: source.Publisher, Offer: source.Offer, SKU: source.SKU, Version: cmp.Or(source.Version, "latest")}
+	}
+	osDisk := &OSDisk{CreateOption: "FromImage", DeleteOption: "Delete", DiskSizeGB: spec.OSDiskSizeGB}
+	if spec.OSDiskType != "" {
+		osDisk.ManagedDisk = &ManagedDisk{StorageAccountType: spec.OSDiskType}
+	}
+	nicRef := NetworkInterfaceReference{ID: nic.ID}
+	nicRef.Properties.Primary = true
+	vm := &VirtualMachine{
+		Name:     name,
+		Location: spec.Location,
+		Tags:     tagsFor(azureBuild),
+		Properties: VirtualMachineProperties{
+			HardwareProfile: &HardwareProfile{VMSize: cmp.Or(spec.VMSize, infrav1.DefaultAzureVMSize)},
+			StorageProfile: &StorageProfile{
+				ImageReference: image,
+				OSDisk:         osDisk,
+			},
+			OSProfile:      osProfile,
+			NetworkProfile: &NetworkProfile{NetworkInterfaces: []NetworkInterfaceReference{nicRef}},
+		},
+	}
+	if spot := spec.Spot; spot != nil {
+		maxPrice := -1.0
+		if spot.MaxPrice != "" {
+			price, err := strconv.ParseFloat(spot.MaxPrice, 64)
+			if err != nil {
+				return nil, forgeerrors.ConfigErrorf("invalid Spot max price %q: %v", spot.MaxPrice, err)
+			}
+			maxPrice = price
+		}
+		// An evicted VM is deleted along with its OS disk, the AzureBuild is preempted.
+		vm.Properties.Priority = "Spot"
+		vm.Properties.EvictionPolicy = "Delete"
+		vm.Properties.BillingProfile = &BillingProfile{MaxPrice: maxPrice}
+	}
+	return vm, nil
+}
+
+// imageVersionFor returns the gallery image version captured from the VM, tagged with the image metadata of the
+// Build.
+func imageVersionFor(azureBuild *infrav1.AzureBuild, build *buildv1.Build, vm *VirtualMachine) *ImageVersion {
+	spec := &azureBuild.Spec
+	gallery := &spec.Gallery
+	publishing := &ImageVersionPublishing{
+		TargetRegions:     []TargetRegion{{Name: spec.Location}},
+		ReplicaCount:      gallery.ReplicaCount,
+		ExcludeFromLatest: gallery.ExcludeFromLatest,
+	}
+	for _, region := range gallery.TargetRegions {
+		target := TargetRegion{Name: region.Name, RegionalReplicaCount: region.ReplicaCount, StorageAccountType: region.StorageAccountType}
+		if strings.EqualFold(region.Name, spec.Location) {
+			publishing.TargetRegions[0] = target
+			continue
+		}
+		publishing.TargetRegions = append(publishing.TargetRegions, target)
+	}
+	tags := tagsFor(azureBuild)
+	for key, value := range build.Status.ImageMetadata {
+		tags[key] = value
+	}
+	return &ImageVersion{
+		Name:     azureBuild.Status.ImageVersion,
+		Location: spec.Location,
+		Tags:     tags,
+		Properties: ImageVersionProperties{
+			PublishingProfile: publishing,
+			StorageProfile: ImageVersionStorageProfile{
+				Source: &ImageVersionSource{VirtualMachineID: vm.ID},
+			},
+		},
+	}
+}
+
+// tagsFor returns the tags of the resources of the AzureBuild.
+func tagsFor(azureBuild *infrav1.AzureBuild) map[string]string {
+	tags := map[string]string{buildTag: azureBuild.Namespace + "/" + azureBuild.Name}
+	for key, value := range azureBuild.Spec.Tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// versionAt returns the gallery image version of an image captured at t, <year>.<month><day>.<hour><minute><second>.
+func versionAt(t time.Time) string {
+	return fmt.Sprintf("%d.%d.%d", t.Year(), int(t.Month())*100+t.Day(), t.Hour()*10000+t.Minute()*100+t.Second())
+}
+
+// nicName returns the name of the network interface of the VM.
+func nicName(vm string) string {
+	return vm + "-nic"
+}
+
+// publicIPName returns the name of the public IP of the VM.
+func publicIPName(vm string) string {
+	return vm + "-ip"
+}
@@ -0,0 +1,432 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeCompute is an in-memory Compute, the operations are done once returned unless listed in operations.
+type fakeCompute struct {
+	vms        map[string]*VirtualMachine
+	nics       map[string]*NetworkInterface
+	ips        map[string]*PublicIPAddress
+	versions   map[string]*ImageVersion
+	commands   []string
+	operations map[string]*Operation
+}
+
+func newFakeCompute() *fakeCompute {
+	return &fakeCompute{
+		vms:        map[string]*VirtualMachine{},
+		nics:       map[string]*NetworkInterface{},
+		ips:        map[string]*PublicIPAddress{},
+		versions:   map[string]*ImageVersion{},
+		operations: map[string]*Operation{},
+	}
+}
+
+func notFound() error {
+	return &APIError{StatusCode: http.StatusNotFound, Code: "ResourceNotFound"}
+}
+
+func (f *fakeCompute) GetVirtualMachine(_ context.Context, _, name string) (*VirtualMachine, error) {
+	vm, ok := f.vms[name]
+	if !ok {
+		return nil, notFound()
+	}
+	return vm, nil
+}
+
+func (f *fakeCompute) CreateVirtualMachine(_ context.Context, resourceGroup string, vm *VirtualMachine) (string, error) {
+	vm.ID = ResourceID("sub", resourceGroup, "Microsoft.Compute/virtualMachines/"+vm.Name)
+	vm.Properties.VMID = "vm-uid"
+	vm.Properties.ProvisioningState = ProvisioningCreating
+	vm.Properties.InstanceView = &InstanceView{Statuses: []InstanceViewStatus{{Code: PowerStarting}}}
+	f.vms[vm.Name] = vm
+	return "operations/create-" + vm.Name, nil
+}
+
+func (f *fakeCompute) RunCommand(_ context.Context, _, name, commandID string, _ []string) (string, error) {
+	f.commands = append(f.commands, commandID)
+	return "operations/run-" + name, nil
+}
+
+func (f *fakeCompute) DeallocateVirtualMachine(_ context.Context, _, name string) (string, error) {
+	f.vms[name].Properties.InstanceView.Statuses[0].Code = PowerDeallocating
+	return "operations/deallocate-" + name, nil
+}
+
+func (f *fakeCompute) GeneralizeVirtualMachine(_ context.Context, _, name string) error {
+	view := f.vms[name].Properties.InstanceView
+	view.Statuses = append(view.Statuses, InstanceViewStatus{Code: OSStateGeneralized})
+	return nil
+}
+
+func (f *fakeCompute) DeleteVirtualMachine(_ context.Context, _, name string) (string, error) {
+	delete(f.vms, name)
+	return "operations/delete-" + name, nil
+}
+
+func (f *fakeCompute) GetNetworkInterface(_ context.Context, _, name string) (*NetworkInterface, error) {
+	nic, ok := f.nics[name]
+	if !ok {
+		return nil, notFound()
+	}
+	return nic, nil
+}
+
+func (f *fakeCompute) CreateNetworkInterface(_ context.Context, resourceGroup string, nic *NetworkInterface) (*NetworkInterface, error) {
+	nic.ID = ResourceID("sub", resourceGroup, "Microsoft.Network/networkInterfaces/"+nic.Name)
+	nic.Properties.ProvisioningState = ProvisioningSucceeded
+	nic.Properties.IPConfigurations[0].Properties.PrivateIPAddress = "10.0.0.4"
+	f.nics[nic.Name] = nic
+	return nic, nil
+}
+
+func (f *fakeCompute) DeleteNetworkInterface(_ context.Context, _, name string) error {
+	delete(f.nics, name)
+	return nil
+}
+
+func (f *fakeCompute) GetPublicIPAddress(_ context.Context, _, name string) (*PublicIPAddress, error) {
+	ip, ok := f.ips[name]
+	if !ok {
+		return nil, notFound()
+	}
+	return ip, nil
+}
+
+func (f *fakeCompute) CreatePublicIPAddress(_ context.Context, resourceGroup string, ip *PublicIPAddress) (*PublicIPAddress, error) {
+	ip.ID = ResourceID("sub", resourceGroup, "Microsoft.Network/publicIPAddresses/"+ip.Name)
+	ip.Properties.ProvisioningState = ProvisioningUpdating
+	f.ips[ip.Name] = ip
+	return ip, nil
+}
+
+func (f *fakeCompute) DeletePublicIPAddress(_ context.Context, _, name string) error {
+	delete(f.ips, name)
+	return nil
+}
+
+func (f *fakeCompute) GetImageVersion(_ context.Context, _, gallery, image, version string) (*ImageVersion, error) {
+	v, ok := f.versions[gallery+"/"+image+"/"+version]
+	if !ok {
+		return nil, notFound()
+	}
+	return v, nil
+}
+
+func (f *fakeCompute) CreateImageVersion(_ context.Context, resourceGroup, gallery, image string, version *ImageVersion) (string, error) {
+	version.ID = ResourceID("sub", resourceGroup, imageVersionPath(gallery, image, version.Name))
+	version.Properties.ProvisioningState = ProvisioningCreating
+	f.versions[gallery+"/"+image+"/"+version.Name] = version
+	return "operations/version-" + version.Name, nil
+}
+
+func (f *fakeCompute) GetOperation(_ context.Context, url string) (*Operation, error) {
+	if op, ok := f.operations[url]; ok {
+		return op, nil
+	}
+	return &Operation{Status: OperationSucceeded}, nil
+}
+
+func TestAzureBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, azureBuild := setupTest(g)
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(azureBuild)}
+
+	// The public IP is created first, the network interface once it is provisioned.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Finalizers).To(ContainElement(infrav1.AzureBuildFinalizer))
+	name := providersdk.MachineName(azureBuild)
+	g.Expect(compute.ips).To(HaveKey(publicIPName(name)))
+	g.Expect(compute.nics).To(BeEmpty())
+	g.Expect(compute.vms).To(BeEmpty())
+
+	// The VM is created with the generated connector key once the network interface is provisioned.
+	compute.ips[publicIPName(name)].Properties.ProvisioningState = ProvisioningSucceeded
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.VMName).To(Equal(name))
+	nic := compute.nics[nicName(name)]
+	g.Expect(nic.Properties.IPConfigurations[0].Properties.PublicIPAddress.ID).To(HaveSuffix("/publicIPAddresses/" + publicIPName(name)))
+	g.Expect(nic.Properties.NetworkSecurityGroup.ID).To(HaveSuffix("/networkSecurityGroups/ssh"))
+	vm := compute.vms[name]
+	g.Expect(vm.Properties.HardwareProfile.VMSize).To(Equal(infrav1.DefaultAzureVMSize))
+	g.Expect(vm.Properties.StorageProfile.ImageReference.SKU).To(Equal("22_04-lts-gen2"))
+	g.Expect(vm.Properties.StorageProfile.ImageReference.Version).To(Equal("latest"))
+	g.Expect(vm.Properties.OSProfile.AdminUsername).To(Equal(infrav1.DefaultAzureUsername))
+	g.Expect(vm.Properties.OSProfile.LinuxConfiguration.SSH.PublicKeys[0].KeyData).To(HavePrefix("ssh-rsa "))
+	g.Expect(vm.Properties.NetworkProfile.NetworkInterfaces[0].ID).To(Equal(nic.ID))
+	g.Expect(vm.Tags).To(HaveKeyWithValue(buildTag, "default/ubuntu"))
+
+	// The address is published once the VM is running.
+	vm.Properties.ProvisioningState = ProvisioningSucceeded
+	vm.Properties.InstanceView.Statuses[0].Code = PowerRunning
+	compute.ips[publicIPName(name)].Properties.IPAddress = "20.1.2.3"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(azureBuild.Status.Address).To(Equal("20.1.2.3"))
+	g.Expect(conditions.IsFalse(azureBuild, infrav1.ImageReadyCondition)).To(BeTrue())
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("20.1.2.3"))
+
+	// The VM is deprovisioned once the provisioners are ready.
+	build.Status.ProvisionersReady = true
+	build.Status.ImageMetadata = map[string]string{"os_version": "22.04"}
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	compute.operations["operations/run-"+name] = &Operation{Status: OperationInProgress}
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(compute.commands).To(Equal([]string{"RunShellScript"}))
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.Deprovisioned).To(BeFalse())
+	g.Expect(conditions.GetReason(azureBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.MachineGeneralizingReason))
+
+	// The VM is deallocated once deprovisioned.
+	compute.operations["operations/run-"+name].Status = OperationSucceeded
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.Deprovisioned).To(BeTrue())
+	g.Expect(vm.PowerState()).To(Equal(PowerDeallocating))
+
+	// The VM is generalized and captured into the gallery once deallocated.
+	vm.Properties.InstanceView.Statuses[0].Code = PowerDeallocated
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(imageRequeueAfter))
+	g.Expect(vm.Generalized()).To(BeTrue())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.ImageVersion).To(Equal("1.0.0"))
+	version := compute.versions["images/ubuntu/1.0.0"]
+	g.Expect(version.Properties.StorageProfile.Source.VirtualMachineID).To(Equal(vm.ID))
+	g.Expect(version.Properties.PublishingProfile.TargetRegions).To(Equal([]TargetRegion{
+		{Name: "westeurope"},
+		{Name: "northeurope", RegionalReplicaCount: 2},
+	}))
+	g.Expect(version.Tags).To(HaveKeyWithValue("os_version", "22.04"))
+
+	// The gallery image version is reported once created.
+	version.Properties.ProvisioningState = ProvisioningSucceeded
+	version.Properties.StorageProfile.OSDiskImage = &OSDiskImage{SizeInGB: 30}
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.Ready).To(BeTrue())
+	g.Expect(azureBuild.Status.ImageRef).To(Equal("/subscriptions/sub/resourceGroups/images/providers/Microsoft.Compute/galleries/images/images/ubuntu/versions/1.0.0"))
+	g.Expect(azureBuild.Status.Artifact.Format).To(Equal("azure-gallery"))
+	g.Expect(*azureBuild.Status.Artifact.SizeBytes).To(BeEquivalentTo(30 << 30))
+	g.Expect(conditions.IsTrue(azureBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The VM, then its network interface and public IP are deleted with the AzureBuild, the image version is kept.
+	g.Expect(c.Delete(ctx, azureBuild)).To(Succeed())
+	for range 3 {
+		res, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	}
+	g.Expect(compute.vms).To(BeEmpty())
+	g.Expect(compute.nics).To(BeEmpty())
+	g.Expect(compute.ips).To(BeEmpty())
+	g.Expect(compute.versions).To(HaveLen(1))
+
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).ToNot(Succeed())
+}
+
+func TestAzureBuildReconcileFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, azureBuild := setupTest(g)
+	azureBuild.Spec.PublicIP = ptr.To(false)
+	g.Expect(c.Update(ctx, azureBuild)).To(Succeed())
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(azureBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(compute.ips).To(BeEmpty())
+	g.Expect(compute.vms).To(HaveLen(1))
+
+	// The failed creation of the VM fails the AzureBuild.
+	name := providersdk.MachineName(azureBuild)
+	compute.operations["operations/create-"+name] = &Operation{
+		Status: OperationFailed,
+		Error:  &OperationError{Code: "SkuNotAvailable", Message: "The requested size is not available in location westeurope"},
+	}
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.CreateBuildError)))
+	g.Expect(*azureBuild.Status.FailureMessage).To(ContainSubstring("SkuNotAvailable"))
+	g.Expect(conditions.GetReason(azureBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+}
+
+func TestAzureBuildReconcileEvicted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, azureBuild := setupTest(g)
+	azureBuild.Spec.PublicIP = ptr.To(false)
+	azureBuild.Spec.Spot = &infrav1.AzureSpotOptions{MaxPrice: "0.05"}
+	g.Expect(c.Update(ctx, azureBuild)).To(Succeed())
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(azureBuild)}
+
+	// The VM is created as a Spot VM.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	name := providersdk.MachineName(azureBuild)
+	vm := compute.vms[name]
+	g.Expect(vm.Properties.Priority).To(Equal("Spot"))
+	g.Expect(vm.Properties.EvictionPolicy).To(Equal("Delete"))
+	g.Expect(vm.Properties.BillingProfile.MaxPrice).To(Equal(0.05))
+	vm.Properties.ProvisioningState = ProvisioningSucceeded
+	vm.Properties.InstanceView.Statuses[0].Code = PowerRunning
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.MachineReady).To(BeTrue())
+
+	// The Spot VM is evicted while the provisioners are running.
+	delete(compute.vms, name)
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, azureBuild)).To(Succeed())
+	g.Expect(azureBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.PreemptedBuildError)))
+	g.Expect(*azureBuild.Status.FailureMessage).To(ContainSubstring("has been evicted"))
+}
+
+func TestVersionAt(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(versionAt(time.Date(2024, 3, 7, 9, 5, 1, 0, time.UTC))).To(Equal("2024.307.90501"))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.AzureBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "AzureBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	azureBuild := &infrav1.AzureBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "azurebuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.AzureBuildSpec{
+			SubscriptionID: "sub",
+			ResourceGroup:  "builds",
+			Location:       "westeurope",
+			SourceImage: infrav1.AzureImageReference{
+				Publisher: "Canonical",
+				Offer:     "0001-com-ubuntu-server-jammy",
+				SKU:       "22_04-lts-gen2",
+			},
+			SubnetID:               "/subscriptions/sub/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/builds/subnets/default",
+			NetworkSecurityGroupID: "/subscriptions/sub/resourceGroups/network/providers/Microsoft.Network/networkSecurityGroups/ssh",
+			CredentialsRef:         &corev1.LocalObjectReference{Name: "azure"},
+			Gallery: infrav1.AzureGallerySpec{
+				ResourceGroup:   "images",
+				Name:            "images",
+				ImageDefinition: "ubuntu",
+				Version:         "1.0.0",
+				TargetRegions:   []infrav1.AzureTargetRegion{{Name: "northeurope", ReplicaCount: 2}},
+			},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "azure"},
+		Data: map[string][]byte{
+			infrav1.AzureTenantIDKey:     []byte("tenant"),
+			infrav1.AzureClientIDKey:     []byte("client"),
+			infrav1.AzureClientSecretKey: []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, azureBuild, credentials).
+		WithStatusSubresource(build, azureBuild).
+		Build()
+	return c, build, azureBuild
+}
+
+func newReconciler(c client.Client, compute Compute) *AzureBuildReconciler {
+	return &AzureBuildReconciler{
+		Client: c,
+		NewCompute: func(_ context.Context, _ *ServicePrincipal, _ string) Compute {
+			return compute
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+}
@@ -0,0 +1,624 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure implements the AzureBuild infrastructure provider: the build machine is an Azure VM, which is
+// deprovisioned, generalized and captured into an Azure Compute Gallery image version once the provisioners of
+// the Build are ready.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/clientcredentials"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// ResourceManagerEndpoint is the endpoint of the Azure Resource Manager API.
+	ResourceManagerEndpoint = "https://management.azure.com/"
+
+	// LoginEndpoint is the endpoint of Microsoft Entra ID the tokens of the service principals are requested from.
+	LoginEndpoint = "https://login.microsoftonline.com/"
+
+	// scope is the OAuth scope of the tokens of the service principals.
+	scope = "https://management.azure.com/.default"
+
+	// The versions of the APIs of the resource providers.
+	computeAPIVersion = "2024-03-01"
+	galleryAPIVersion = "2023-07-03"
+	networkAPIVersion = "2023-09-01"
+)
+
+// The provisioning states of the resources, the power states of the VMs and the statuses of the operations.
+const (
+	ProvisioningCreating  = "Creating"
+	ProvisioningUpdating  = "Updating"
+	ProvisioningSucceeded = "Succeeded"
+	ProvisioningFailed    = "Failed"
+
+	PowerStarting     = "PowerState/starting"
+	PowerRunning      = "PowerState/running"
+	PowerStopping     = "PowerState/stopping"
+	PowerStopped      = "PowerState/stopped"
+	PowerDeallocating = "PowerState/deallocating"
+	PowerDeallocated  = "PowerState/deallocated"
+
+	// OSStateGeneralized is the status of the instance view of the generalized VMs.
+	OSStateGeneralized = "OSState/generalized"
+
+	OperationInProgress = "InProgress"
+	OperationSucceeded  = "Succeeded"
+	OperationFailed     = "Failed"
+	OperationCanceled   = "Canceled"
+)
+
+// Compute is the part of the Azure Resource Manager API used by the controller. The methods starting a long
+// running operation return its URL.
+type Compute interface {
+	GetVirtualMachine(ctx context.Context, resourceGroup, name string) (*VirtualMachine, error)
+	CreateVirtualMachine(ctx context.Context, resourceGroup string, vm *VirtualMachine) (string, error)
+	RunCommand(ctx context.Context, resourceGroup, name, commandID string, script []string) (string, error)
+	DeallocateVirtualMachine(ctx context.Context, resourceGroup, name string) (string, error)
+	GeneralizeVirtualMachine(ctx context.Context, resourceGroup, name string) error
+	DeleteVirtualMachine(ctx context.Context, resourceGroup, name string) (string, error)
+	GetNetworkInterface(ctx context.Context, resourceGroup, name string) (*NetworkInterface, error)
+	CreateNetworkInterface(ctx context.Context, resourceGroup string, nic *NetworkInterface) (*NetworkInterface, error)
+	DeleteNetworkInterface(ctx context.Context, resourceGroup, name string) error
+	GetPublicIPAddress(ctx context.Context, resourceGroup, name string) (*PublicIPAddress, error)
+	CreatePublicIPAddress(ctx context.Context, resourceGroup string, ip *PublicIPAddress) (*PublicIPAddress, error)
+	DeletePublicIPAddress(ctx context.Context, resourceGroup, name string) error
+	GetImageVersion(ctx context.Context, resourceGroup, gallery, image, version string) (*ImageVersion, error)
+	CreateImageVersion(ctx context.Context, resourceGroup, gallery, image string, version *ImageVersion) (string, error)
+	GetOperation(ctx context.Context, url string) (*Operation, error)
+}
+
+// VirtualMachine is an Azure VM.
+type VirtualMachine struct {
+	ID         string                   `json:"id,omitempty"`
+	Name       string                   `json:"name"`
+	Location   string                   `json:"location"`
+	Tags       map[string]string        `json:"tags,omitempty"`
+	Properties VirtualMachineProperties `json:"properties"`
+}
+
+// VirtualMachineProperties are the properties of a VM.
+type VirtualMachineProperties struct {
+	VMID              string           `json:"vmId,omitempty"`
+	ProvisioningState string           `json:"provisioningState,omitempty"`
+	HardwareProfile   *HardwareProfile `json:"hardwareProfile,omitempty"`
+	StorageProfile    *StorageProfile  `json:"storageProfile,omitempty"`
+	OSProfile         *OSProfile       `json:"osProfile,omitempty"`
+	NetworkProfile    *NetworkProfile  `json:"networkProfile,omitempty"`
+	InstanceView      *InstanceView    `json:"instanceView,omitempty"`
+	Priority          string           `json:"priority,omitempty"`
+	EvictionPolicy    string           `json:"evictionPolicy,omitempty"`
+	BillingProfile    *BillingProfile  `json:"billingProfile,omitempty"`
+}
+
+// BillingProfile is the maximum price of a Spot VM, -1 to only be evicted for capacity.
+type BillingProfile struct {
+	MaxPrice float64 `json:"maxPrice"`
+}
+
+// HardwareProfile is the size of a VM.
+type HardwareProfile struct {
+	VMSize string `json:"vmSize"`
+}
+
+// StorageProfile are the image and the OS disk of a VM.
+type StorageProfile struct {
+	ImageReference *ImageReference `json:"imageReference,omitempty"`
+	OSDisk         *OSDisk         `json:"osDisk,omitempty"`
+}
+
+// ImageReference is the image a VM boots from.
+type ImageReference struct {
+	Publisher string `json:"publisher,omitempty"`
+	Offer     string `json:"offer,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Version   string `json:"version,omitempty"`
+	ID        string `json:"id,omitempty"`
+}
+
+// OSDisk is the OS disk of a VM.
+type OSDisk struct {
+	CreateOption string       `json:"createOption"`
+	DeleteOption string       `json:"deleteOption,omitempty"`
+	DiskSizeGB   int32        `json:"diskSizeGB,omitempty"`
+	ManagedDisk  *ManagedDisk `json:"managedDisk,omitempty"`
+}
+
+// ManagedDisk is the storage of a managed disk.
+type ManagedDisk struct {
+	StorageAccountType string `json:"storageAccountType,omitempty"`
+}
+
+// OSProfile is the admin account of a VM.
+type OSProfile struct {
+	ComputerName         string                `json:"computerName"`
+	AdminUsername        string                `json:"adminUsername"`
+	AdminPassword        string                `json:"adminPassword,omitempty"`
+	LinuxConfiguration   *LinuxConfiguration   `json:"linuxConfiguration,omitempty"`
+	WindowsConfiguration *WindowsConfiguration `json:"windowsConfiguration,omitempty"`
+}
+
+// LinuxConfiguration are the SSH keys of the admin account of a Linux VM.
+type LinuxConfiguration struct {
+	DisablePasswordAuthentication bool              `json:"disablePasswordAuthentication"`
+	SSH                           *SSHConfiguration `json:"ssh,omitempty"`
+}
+
+// SSHConfiguration are the public keys authorized for the admin account of a Linux VM.
+type SSHConfiguration struct {
+	PublicKeys []SSHPublicKey `json:"publicKeys"`
+}
+
+// SSHPublicKey is a public key authorized for the admin account of a Linux VM.
+type SSHPublicKey struct {
+	Path    string `json:"path"`
+	KeyData string `json:"keyData"`
+}
+
+// WindowsConfiguration configures a Windows VM.
+type WindowsConfiguration struct {
+	ProvisionVMAgent bool `json:"provisionVMAgent"`
+}
+
+// NetworkProfile are the network interfaces of a VM.
+type NetworkProfile struct {
+	NetworkInterfaces []NetworkInterfaceReference `json:"networkInterfaces"`
+}
+
+// NetworkInterfaceReference is a network interface of a VM.
+type NetworkInterfaceReference struct {
+	ID         string                              `json:"id"`
+	Properties NetworkInterfaceReferenceProperties `json:"properties"`
+}
+
+// NetworkInterfaceReferenceProperties are the properties of a network interface of a VM.
+type NetworkInterfaceReferenceProperties struct {
+	Primary      bool   `json:"primary,omitempty"`
+	DeleteOption string `json:"deleteOption,omitempty"`
+}
+
+// InstanceView is the runtime state of a VM.
+type InstanceView struct {
+	Statuses []InstanceViewStatus `json:"statuses,omitempty"`
+}
+
+// InstanceViewStatus is a status of the instance view of a VM.
+type InstanceViewStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// PowerState returns the power state of the VM, empty if unknown.
+func (vm *VirtualMachine) PowerState() string {
+	return vm.status("PowerState/")
+}
+
+// Generalized returns true if the VM has been generalized.
+func (vm *VirtualMachine) Generalized() bool {
+	return vm.status("OSState/") == OSStateGeneralized
+}
+
+func (vm *VirtualMachine) status(prefix string) string {
+	if vm.Properties.InstanceView == nil {
+		return ""
+	}
+	for _, status := range vm.Properties.InstanceView.Statuses {
+		if strings.HasPrefix(status.Code, prefix) {
+			return status.Code
+		}
+	}
+	return ""
+}
+
+// NetworkInterface is a network interface.
+type NetworkInterface struct {
+	ID         string                     `json:"id,omitempty"`
+	Name       string                     `json:"name"`
+	Location   string                     `json:"location"`
+	Tags       map[string]string          `json:"tags,omitempty"`
+	Properties NetworkInterfaceProperties `json:"properties"`
+}
+
+// NetworkInterfaceProperties are the properties of a network interface.
+type NetworkInterfaceProperties struct {
+	ProvisioningState    string            `json:"provisioningState,omitempty"`
+	IPConfigurations     []IPConfiguration `json:"ipConfigurations"`
+	NetworkSecurityGroup *SubResource      `json:"networkSecurityGroup,omitempty"`
+}
+
+// IPConfiguration is an IP configuration of a network interface.
+type IPConfiguration struct {
+	Name       string                    `json:"name"`
+	Properties IPConfigurationProperties `json:"properties"`
+}
+
+// IPConfigurationProperties are the properties of an IP configuration.
+type IPConfigurationProperties struct {
+	PrivateIPAddress          string       `json:"privateIPAddress,omitempty"`
+	PrivateIPAllocationMethod string       `json:"privateIPAllocationMethod,omitempty"`
+	Subnet                    *SubResource `json:"subnet,omitempty"`
+	PublicIPAddress           *SubResource `json:"publicIPAddress,omitempty"`
+}
+
+// SubResource is a reference to another resource.
+type SubResource struct {
+	ID string `json:"id"`
+}
+
+// PublicIPAddress is a public IP.
+type PublicIPAddress struct {
+	ID         string                    `json:"id,omitempty"`
+	Name       string                    `json:"name"`
+	Location   string                    `json:"location"`
+	Tags       map[string]string         `json:"tags,omitempty"`
+	SKU        *SKU                      `json:"sku,omitempty"`
+	Properties PublicIPAddressProperties `json:"properties"`
+}
+
+// PublicIPAddressProperties are the properties of a public IP.
+type PublicIPAddressProperties struct {
+	ProvisioningState        string `json:"provisioningState,omitempty"`
+	PublicIPAllocationMethod string `json:"publicIPAllocationMethod,omitempty"`
+	IPAddress                string `json:"ipAddress,omitempty"`
+}
+
+// SKU is the SKU of a resource.
+type SKU struct {
+	Name string `json:"name"`
+}
+
+// ImageVersion is a version of an image definition of an Azure Compute Gallery.
+type ImageVersion struct {
+	ID         string                 `json:"id,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	Location   string                 `json:"location"`
+	Tags       map[string]string      `json:"tags,omitempty"`
+	Properties ImageVersionProperties `json:"properties"`
+}
+
+// ImageVersionProperties are the properties of a gallery image version.
+type ImageVersionProperties struct {
+	ProvisioningState string                     `json:"provisioningState,omitempty"`
+	PublishingProfile *ImageVersionPublishing    `json:"publishingProfile,omitempty"`
+	StorageProfile    ImageVersionStorageProfile `json:"storageProfile"`
+}
+
+// ImageVersionPublishing are the regions a gallery image version is replicated to.
+type ImageVersionPublishing struct {
+	TargetRegions     []TargetRegion `json:"targetRegions,omitempty"`
+	ReplicaCount      int32          `json:"replicaCount,omitempty"`
+	ExcludeFromLatest bool           `json:"excludeFromLatest,omitempty"`
+	PublishedDate     string         `json:"publishedDate,omitempty"`
+}
+
+// TargetRegion is a region a gallery image version is replicated to.
+type TargetRegion struct {
+	Name                 string `json:"name"`
+	RegionalReplicaCount int32  `json:"regionalReplicaCount,omitempty"`
+	StorageAccountType   string `json:"storageAccountType,omitempty"`
+}
+
+// ImageVersionStorageProfile is the source and the OS disk image of a gallery image version.
+type ImageVersionStorageProfile struct {
+	Source      *ImageVersionSource `json:"source,omitempty"`
+	OSDiskImage *OSDiskImage        `json:"osDiskImage,omitempty"`
+}
+
+// ImageVersionSource is the VM a gallery image version is captured from.
+type ImageVersionSource struct {
+	VirtualMachineID string `json:"virtualMachineId,omitempty"`
+}
+
+// OSDiskImage is the OS disk image of a gallery image version.
+type OSDiskImage struct {
+	SizeInGB int64 `json:"sizeInGB,omitempty"`
+}
+
+// SizeBytes returns the size of the OS disk image, 0 if unknown.
+func (v *ImageVersion) SizeBytes() int64 {
+	if v.Properties.StorageProfile.OSDiskImage == nil {
+		return 0
+	}
+	return v.Properties.StorageProfile.OSDiskImage.SizeInGB << 30
+}
+
+// Operation is a long running operation of the Azure Resource Manager API.
+type Operation struct {
+	Status string          `json:"status"`
+	Error  *OperationError `json:"error,omitempty"`
+}
+
+// OperationError is the error of a failed operation.
+type OperationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Err returns the error of the operation, nil if it succeeded or is not done.
+func (o *Operation) Err() error {
+	switch o.Status {
+	case OperationFailed, OperationCanceled:
+		if o.Error == nil {
+			return errors.Errorf("operation %s", strings.ToLower(o.Status))
+		}
+		return errors.Errorf("%s: %s", o.Error.Code, o.Error.Message)
+	}
+	return nil
+}
+
+// APIError is an error returned by the Azure Resource Manager API.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the API.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client is a client of the Azure Resource Manager API.
+type Client struct {
+	// HTTPClient sends the requests, it authenticates them.
+	HTTPClient *http.Client
+	// Endpoint is the endpoint of the Azure Resource Manager API.
+	Endpoint string
+	// SubscriptionID is the subscription of the resources.
+	SubscriptionID string
+}
+
+var _ Compute = &Client{}
+
+// ServicePrincipal are the credentials of a service principal.
+type ServicePrincipal struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// servicePrincipalFrom returns the service principal of the data of a credentials secret.
+func servicePrincipalFrom(data map[string][]byte) (*ServicePrincipal, error) {
+	sp := &ServicePrincipal{
+		TenantID:     string(data[infrav1.AzureTenantIDKey]),
+		ClientID:     string(data[infrav1.AzureClientIDKey]),
+		ClientSecret: string(data[infrav1.AzureClientSecretKey]),
+	}
+	if sp.TenantID == "" || sp.ClientID == "" || sp.ClientSecret == "" {
+		return nil, forgeerrors.ConfigErrorf("the secret must have the %s, %s and %s keys",
+			infrav1.AzureTenantIDKey, infrav1.AzureClientIDKey, infrav1.AzureClientSecretKey)
+	}
+	return sp, nil
+}
+
+// tokenConfig returns the configuration of the tokens of the service principal, requested from loginEndpoint.
+func (sp *ServicePrincipal) tokenConfig(loginEndpoint string) *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:     sp.ClientID,
+		ClientSecret: sp.ClientSecret,
+		TokenURL:     strings.TrimSuffix(loginEndpoint, "/") + "/" + sp.TenantID + "/oauth2/v2.0/token",
+		Scopes:       []string{scope},
+	}
+}
+
+// NewCompute returns a client of the API for the resources of the subscription, authenticated with the service
+// principal.
+func NewCompute(ctx context.Context, sp *ServicePrincipal, subscriptionID string) Compute {
+	return &Client{
+		HTTPClient:     sp.tokenConfig(LoginEndpoint).Client(ctx),
+		Endpoint:       ResourceManagerEndpoint,
+		SubscriptionID: subscriptionID,
+	}
+}
+
+func (c *Client) GetVirtualMachine(ctx context.Context, resourceGroup, name string) (*VirtualMachine, error) {
+	vm := &VirtualMachine{}
+	url := c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+name, computeAPIVersion) + "&$expand=instanceView"
+	_, err := c.do(ctx, http.MethodGet, url, nil, vm)
+	return vm, err
+}
+
+func (c *Client) CreateVirtualMachine(ctx context.Context, resourceGroup string, vm *VirtualMachine) (string, error) {
+	return c.do(ctx, http.MethodPut, c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+vm.Name, computeAPIVersion), vm, nil)
+}
+
+// RunCommand runs the script on the VM with the RunShellScript or RunPowerShellScript command.
+func (c *Client) RunCommand(ctx context.Context, resourceGroup, name, commandID string, script []string) (string, error) {
+	body := map[string]interface{}{"commandId": commandID, "script": script}
+	return c.do(ctx, http.MethodPost, c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+name+"/runCommand", computeAPIVersion), body, nil)
+}
+
+func (c *Client) DeallocateVirtualMachine(ctx context.Context, resourceGroup, name string) (string, error) {
+	return c.do(ctx, http.MethodPost, c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+name+"/deallocate", computeAPIVersion), nil, nil)
+}
+
+func (c *Client) GeneralizeVirtualMachine(ctx context.Context, resourceGroup, name string) error {
+	_, err := c.do(ctx, http.MethodPost, c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+name+"/generalize", computeAPIVersion), nil, nil)
+	return err
+}
+
+func (c *Client) DeleteVirtualMachine(ctx context.Context, resourceGroup, name string) (string, error) {
+	return c.do(ctx, http.MethodDelete, c.resourceURL(resourceGroup, "Microsoft.Compute/virtualMachines/"+name, computeAPIVersion), nil, nil)
+}
+
+func (c *Client) GetNetworkInterface(ctx context.Context, resourceGroup, name string) (*NetworkInterface, error) {
+	nic := &NetworkInterface{}
+	_, err := c.do(ctx, http.MethodGet, c.resourceURL(resourceGroup, "Microsoft.Network/networkInterfaces/"+name, networkAPIVersion), nil, nic)
+	return nic, err
+}
+
+func (c *Client) CreateNetworkInterface(ctx context.Context, resourceGroup string, nic *NetworkInterface) (*NetworkInterface, error) {
+	created := &NetworkInterface{}
+	_, err := c.do(ctx, http.MethodPut, c.resourceURL(resourceGroup, "Microsoft.Network/networkInterfaces/"+nic.Name, networkAPIVersion), nic, created)
+	return created, err
+}
+
+func (c *Client) DeleteNetworkInterface(ctx context.Context, resourceGroup, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.resourceURL(resourceGroup, "Microsoft.Network/networkInterfaces/"+name, networkAPIVersion), nil, nil)
+	return err
+}
+
+func (c *Client) GetPublicIPAddress(ctx context.Context, resourceGroup, name string) (*PublicIPAddress, error) {
+	ip := &PublicIPAddress{}
+	_, err := c.do(ctx, http.MethodGet, c.resourceURL(resourceGroup, "Microsoft.Network/publicIPAddresses/"+name, networkAPIVersion), nil, ip)
+	return ip, err
+}
+
+func (c *Client) CreatePublicIPAddress(ctx context.Context, resourceGroup string, ip *PublicIPAddress) (*PublicIPAddress, error) {
+	created := &PublicIPAddress{}
+	_, err := c.do(ctx, http.MethodPut, c.resourceURL(resourceGroup, "Microsoft.Network/publicIPAddresses/"+ip.Name, networkAPIVersion), ip, created)
+	return created, err
+}
+
+func (c *Client) DeletePublicIPAddress(ctx context.Context, resourceGroup, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.resourceURL(resourceGroup, "Microsoft.Network/publicIPAddresses/"+name, networkAPIVersion), nil, nil)
+	return err
+}
+
+func (c *Client) GetImageVersion(ctx context.Context, resourceGroup, gallery, image, version string) (*ImageVersion, error) {
+	v := &ImageVersion{}
+	_, err := c.do(ctx, http.MethodGet, c.resourceURL(resourceGroup, imageVersionPath(gallery, image, version), galleryAPIVersion), nil, v)
+	return v, err
+}
+
+func (c *Client) CreateImageVersion(ctx context.Context, resourceGroup, gallery, image string, version *ImageVersion) (string, error) {
+	return c.do(ctx, http.MethodPut, c.resourceURL(resourceGroup, imageVersionPath(gallery, image, version.Name), galleryAPIVersion), version, nil)
+}
+
+// DeleteImageVersion deletes the image version, along with its replicas.
+func (c *Client) DeleteImageVersion(ctx context.Context, resourceGroup, gallery, image, version string) (string, error) {
+	return c.do(ctx, http.MethodDelete, c.resourceURL(resourceGroup, imageVersionPath(gallery, image, version), galleryAPIVersion), nil, nil)
+}
+
+// GetOperation returns the status of the long running operation. The operations tracked with an
+// Azure-AsyncOperation URL report their status, the ones tracked with a Location URL are in progress until their
+// URL returns 200 or 204.
+func (c *Client) GetOperation(ctx context.Context, url string) (*Operation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "GET %s", url))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return &Operation{Status: OperationInProgress}, nil
+	case resp.StatusCode >= 300:
+		return nil, classifier.Classify(parseError(resp.StatusCode, resp.Body))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "GET %s", url))
+	}
+	op := &Operation{}
+	if err := json.Unmarshal(data, op); err != nil || op.Status == "" {
+		// The result of an operation tracked with a Location URL.
+		op.Status = OperationSucceeded
+	}
+	return op, nil
+}
+
+// ResourceID returns the resource ID of a resource of the resource group of the subscription.
+func ResourceID(subscriptionID, resourceGroup, resource string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/%s", subscriptionID, resourceGroup, resource)
+}
+
+func (c *Client) resourceURL(resourceGroup, resource, apiVersion string) string {
+	return strings.TrimSuffix(c.Endpoint, "/") + ResourceID(c.SubscriptionID, resourceGroup, resource) + "?api-version=" + apiVersion
+}
+
+func imageVersionPath(gallery, image, version string) string {
+	return fmt.Sprintf("Microsoft.Compute/galleries/%s/images/%s/versions/%s", gallery, image, version)
+}
+
+// do sends the request with the JSON body, if any, and decodes the response into out. It returns the URL of the
+// long running operation started by the request, if any. The errors are classified: throttling and server errors
+// are retried, the invalid requests are configuration errors.
+func (c *Client) do(ctx context.Context, method, url string, body, out interface{}) (string, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, url))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", classifier.Classify(parseError(resp.StatusCode, resp.Body))
+	}
+	operation := resp.Header.Get("Azure-AsyncOperation")
+	if operation == "" && resp.StatusCode == http.StatusAccepted {
+		operation = resp.Header.Get("Location")
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return operation, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", errors.Wrapf(err, "invalid response to %s %s", method, url)
+	}
+	return operation, nil
+}
+
+// parseError returns the error of an error response of the API.
+func parseError(statusCode int, body io.Reader) *APIError {
+	return apierror.Parse(statusCode, body, func(data []byte) (string, string) {
+		apiErr := &struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		_ = json.Unmarshal(data, apiErr)
+		return apiErr.Error.Code, apiErr.Error.Message
+	})
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing and busy
+// resources.
+var classifier = apierror.Classifier{
+	Handled: apierror.StatusCodes(http.StatusNotFound, http.StatusConflict),
+	Transient: func(err *APIError) bool {
+		return err.Code == "AllocationFailed" || err.Code == "ZonalAllocationFailed" || err.Code == "RetryableError"
+	},
+}
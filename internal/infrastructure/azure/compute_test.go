@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/virtualMachines/vm"):
+			g.Expect(r.URL.Query().Get("api-version")).To(Equal(computeAPIVersion))
+			g.Expect(r.URL.Query().Get("$expand")).To(Equal("instanceView"))
+			_, _ = w.Write([]byte(`{"id":"/subscriptions/sub/resourceGroups/builds/providers/Microsoft.Compute/virtualMachines/vm","name":"vm",` +
+				`"properties":{"vmId":"uid","provisioningState":"Succeeded","instanceView":{"statuses":[` +
+				`{"code":"ProvisioningState/succeeded"},{"code":"OSState/generalized"},{"code":"PowerState/deallocated"}]}}}`))
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/virtualMachines/missing"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"ResourceNotFound","message":"The Resource 'Microsoft.Compute/virtualMachines/missing' was not found."}}`))
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/virtualMachines/vm"):
+			vm := &VirtualMachine{}
+			g.Expect(json.NewDecoder(r.Body).Decode(vm)).To(Succeed())
+			g.Expect(vm.Properties.HardwareProfile.VMSize).To(Equal("Standard_D2s_v5"))
+			w.Header().Set("Azure-AsyncOperation", server.URL+"/operations/create")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/virtualMachines/vm/deallocate"):
+			w.Header().Set("Location", server.URL+"/operations/deallocate")
+			w.WriteHeader(http.StatusAccepted)
+		case r.URL.Path == "/operations/create":
+			_, _ = w.Write([]byte(`{"status":"Failed","error":{"code":"AllocationFailed","message":"Allocation failed."}}`))
+		case r.URL.Path == "/operations/deallocate":
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasSuffix(r.URL.Path, "/versions/1.0.0"):
+			g.Expect(r.URL.Query().Get("api-version")).To(Equal(galleryAPIVersion))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case strings.HasSuffix(r.URL.Path, "/networkInterfaces/nic"):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"InvalidResourceReference","message":"Resource /subscriptions/sub/.../subnets/default referenced by resource nic was not found."}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), Endpoint: server.URL, SubscriptionID: "sub"}
+
+	vm, err := c.GetVirtualMachine(ctx, "builds", "vm")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vm.Properties.VMID).To(Equal("uid"))
+	g.Expect(vm.PowerState()).To(Equal(PowerDeallocated))
+	g.Expect(vm.Generalized()).To(BeTrue())
+
+	_, err = c.GetVirtualMachine(ctx, "builds", "missing")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+
+	op, err := c.CreateVirtualMachine(ctx, "builds", &VirtualMachine{Name: "vm", Properties: VirtualMachineProperties{
+		HardwareProfile: &HardwareProfile{VMSize: "Standard_D2s_v5"},
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(op).To(Equal(server.URL + "/operations/create"))
+	status, err := c.GetOperation(ctx, op)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.Err()).To(MatchError(ContainSubstring("AllocationFailed")))
+
+	op, err = c.DeallocateVirtualMachine(ctx, "builds", "vm")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(op).To(Equal(server.URL + "/operations/deallocate"))
+	status, err = c.GetOperation(ctx, op)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.Status).To(Equal(OperationInProgress))
+
+	_, err = c.GetImageVersion(ctx, "images", "images", "ubuntu", "1.0.0")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+	_, err = c.CreateNetworkInterface(ctx, "builds", &NetworkInterface{Name: "nic"})
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	g.Expect(err.Error()).To(ContainSubstring("InvalidResourceReference"))
+	err = c.DeletePublicIPAddress(ctx, "builds", "ip")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/retention"
+)
+
+// ImageDeleter deletes the gallery image versions of the expired Images of AzureBuilds, along with their replicas.
+type ImageDeleter struct {
+	// HTTPClient sends the token and API requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// LoginEndpoint is the endpoint the tokens are requested from, LoginEndpoint if empty.
+	LoginEndpoint string
+	// Endpoint is the endpoint of the Azure Resource Manager API, ResourceManagerEndpoint if empty.
+	Endpoint string
+}
+
+var _ retention.Deleter = &ImageDeleter{}
+
+// Delete deletes the image version of the Image and returns the size of its OS disk. The deletion operation is not
+// waited for.
+func (d *ImageDeleter) Delete(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error) {
+	// /subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/galleries/<gallery>/images/<image>/versions/<version>
+	parts := strings.Split(strings.TrimPrefix(image.Spec.ImageRef, "/"), "/")
+	if len(parts) != 12 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[5], "Microsoft.Compute") || !strings.EqualFold(parts[6], "galleries") {
+		return 0, forgeerrors.ConfigErrorf("invalid image %s, it must be the resource ID of a gallery image version", image.Spec.ImageRef)
+	}
+	resourceGroup, gallery, definition, version := parts[3], parts[7], parts[9], parts[11]
+
+	sp, err := servicePrincipalFrom(secret.Data)
+	if err != nil {
+		return 0, err
+	}
+	if d.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, d.HTTPClient)
+	}
+	c := &Client{
+		HTTPClient:     sp.tokenConfig(cmp.Or(d.LoginEndpoint, LoginEndpoint)).Client(ctx),
+		Endpoint:       cmp.Or(d.Endpoint, ResourceManagerEndpoint),
+		SubscriptionID: parts[1],
+	}
+
+	v, err := c.GetImageVersion(ctx, resourceGroup, gallery, definition, version)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if _, err := c.DeleteImageVersion(ctx, resourceGroup, gallery, definition, version); err != nil && !IsNotFound(err) {
+		return 0, err
+	}
+	return v.SizeBytes(), nil
+}
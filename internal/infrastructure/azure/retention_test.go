@@ -0,0 +1,73 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestImageDeleter(t *testing.T) {
+	g := NewWithT(t)
+
+	const versionPath = "/subscriptions/sub/resourceGroups/images/providers/Microsoft.Compute/galleries/forge/images/ubuntu/versions/1.0.0"
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/tenant/oauth2/v2.0/token":
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+		case r.URL.Path == versionPath && r.Method == http.MethodGet:
+			g.Expect(r.URL.Query().Get("api-version")).To(Equal(galleryAPIVersion))
+			_, _ = w.Write([]byte(`{"name":"1.0.0","properties":{"storageProfile":{"osDiskImage":{"sizeInGB":30}}}}`))
+		case r.URL.Path == versionPath && r.Method == http.MethodDelete:
+			deleted = true
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"NotFound","message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{Data: map[string][]byte{
+		infrav1.AzureTenantIDKey:     []byte("tenant"),
+		infrav1.AzureClientIDKey:     []byte("client"),
+		infrav1.AzureClientSecretKey: []byte("secret"),
+	}}
+	d := &ImageDeleter{HTTPClient: server.Client(), LoginEndpoint: server.URL, Endpoint: server.URL}
+	reclaimed, err := d.Delete(context.Background(), &buildv1.Image{Spec: buildv1.ImageSpec{ImageRef: versionPath}}, secret)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reclaimed).To(Equal(int64(30 << 30)))
+	g.Expect(deleted).To(BeTrue())
+
+	// Deleting an image version which no longer exists succeeds.
+	reclaimed, err = d.Delete(context.Background(), &buildv1.Image{Spec: buildv1.ImageSpec{ImageRef: versionPath + "1"}}, secret)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reclaimed).To(BeZero())
+
+	_, err = d.Delete(context.Background(), &buildv1.Image{Spec: buildv1.ImageSpec{ImageRef: "ubuntu"}}, secret)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid image ubuntu")))
+}
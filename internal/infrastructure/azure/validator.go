@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/identity"
+)
+
+// Validator validates the service principals of the azure ProviderIdentities, by requesting a token.
+type Validator struct {
+	// HTTPClient sends the token requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// LoginEndpoint is the endpoint the tokens are requested from, LoginEndpoint if empty.
+	LoginEndpoint string
+}
+
+var _ identity.Validator = &Validator{}
+
+// Validate returns an error if the service principal is invalid or rejected.
+func (v *Validator) Validate(ctx context.Context, _ *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	sp, err := servicePrincipalFrom(secret.Data)
+	if err != nil {
+		return err
+	}
+	if v.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, v.HTTPClient)
+	}
+	_, err = sp.tokenConfig(cmp.Or(v.LoginEndpoint, LoginEndpoint)).Token(ctx)
+	var retrieveErr *oauth2.RetrieveError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &retrieveErr) && retrieveErr.Response.StatusCode < 500:
+		return errors.Errorf("the service principal %s of tenant %s has been rejected: %s", sp.ClientID, sp.TenantID, retrieveErr.ErrorCode)
+	}
+	return forgeerrors.NewTransient(errors.Wrap(err, "failed to request a token"))
+}
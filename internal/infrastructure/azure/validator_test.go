@@ -0,0 +1,83 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		secret    map[string][]byte
+		valid     bool
+		retryable bool
+	}{
+		{name: "valid service principal", status: http.StatusOK, valid: true},
+		{name: "rejected service principal", status: http.StatusUnauthorized},
+		{name: "token endpoint unavailable", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "incomplete secret", secret: map[string][]byte{infrav1.AzureTenantIDKey: []byte("tenant")}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.URL.Path).To(Equal("/tenant/oauth2/v2.0/token"))
+				g.Expect(r.ParseForm()).To(Succeed())
+				g.Expect(r.Form.Get("grant_type")).To(Equal("client_credentials"))
+				g.Expect(r.Form.Get("scope")).To(Equal(scope))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				if tc.status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: tc.secret}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{
+					infrav1.AzureTenantIDKey:     []byte("tenant"),
+					infrav1.AzureClientIDKey:     []byte("client"),
+					infrav1.AzureClientSecretKey: []byte("secret"),
+				}
+			}
+			v := &Validator{HTTPClient: server.Client(), LoginEndpoint: server.URL}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
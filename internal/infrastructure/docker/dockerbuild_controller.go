@@ -0,0 +1,467 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker implements the DockerBuild infrastructure provider, building container images with the
+// provisioners of a Build: they run in a container of a Docker Engine with the docker-exec connector, and the
+// container is committed and pushed to a registry once they completed.
+package docker
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/oci"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the DockerBuild controller, used in the controller metrics.
+	ControllerName = "dockerbuild"
+
+	// ProviderName is the name of the provider in the --infrastructure-providers flag.
+	ProviderName = "docker"
+
+	// containerRequeueAfter is how often a container being started is checked.
+	containerRequeueAfter = 5 * time.Second
+
+	// stopTimeout is how long the container may take to stop before it is killed.
+	stopTimeout = 10 * time.Second
+
+	// keepAliveCommand keeps the container running until it is stopped, whatever the command of the base image.
+	keepAliveCommand = "trap 'exit 0' TERM; sleep infinity & wait"
+)
+
+// DockerBuildReconciler reconciles a DockerBuild object
+type DockerBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DockerBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.DockerBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("DockerBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("dockerbuild-controller")
+	return nil
+}
+
+// Reconcile creates the container of a DockerBuild, publishes it in the connector credentials of the Build and,
+// once the provisioners of the Build are ready, commits it and pushes the image.
+func (r *DockerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	dockerBuild := &infrav1.DockerBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, dockerBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(dockerBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(dockerBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(dockerBuild)
+		if err := patchHelper.Patch(ctx, dockerBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, dockerBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !dockerBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, dockerBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(dockerBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the DockerBuild")
+		return ctrl.Result{}, nil
+	}
+	if dockerBuild.Status.FailureReason != nil || dockerBuild.Status.Ready {
+		// The container of a failed or completed DockerBuild is removed along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(dockerBuild, infrav1.DockerBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, dockerBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, dockerBuild, res, err)
+}
+
+func (r *DockerBuildReconciler) reconcileNormal(ctx context.Context, dockerBuild *infrav1.DockerBuild, build *buildv1.Build) (ctrl.Result, error) {
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, dockerBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	engine, err := docker.NewClient(secret.Data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+	}
+
+	ready, err := r.reconcileContainer(ctx, dockerBuild, build, engine, secret)
+	if err != nil || !ready {
+		return ctrl.Result{RequeueAfter: containerRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(dockerBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.reconcileImage(ctx, dockerBuild, build, engine)
+}
+
+// reconcileContainer pulls the base image, creates and starts the container, and publishes it in the connector
+// credentials of the Build. It returns false while the container is being started.
+func (r *DockerBuildReconciler) reconcileContainer(ctx context.Context, dockerBuild *infrav1.DockerBuild, build *buildv1.Build, engine *docker.Client, secret *corev1.Secret) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &dockerBuild.Status
+
+	if status.ContainerID == "" {
+		id, err := r.createContainer(ctx, dockerBuild, engine)
+		if err != nil {
+			return false, err
+		}
+		status.ContainerID = id
+		conditions.MarkFalse(dockerBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Created container %s", status.ContainerName)
+	}
+
+	container, err := engine.InspectContainer(ctx, status.ContainerID)
+	if docker.IsNotFound(err) {
+		return false, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "container %s has been removed", status.ContainerName)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get container %s", status.ContainerName)
+	}
+
+	if status.MachineReady {
+		if !container.State.Running && !build.Status.ProvisionersReady {
+			return false, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "container %s exited with code %d while the provisioners are running",
+				status.ContainerName, container.State.ExitCode)
+		}
+		return true, nil
+	}
+
+	if !container.State.Running {
+		if container.State.Status == "exited" || container.State.Status == "dead" {
+			return false, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "container %s exited with code %d: %s",
+				status.ContainerName, container.State.ExitCode, cmp.Or(container.State.Error, "the image may lack /bin/sh"))
+		}
+		if err := engine.StartContainer(ctx, status.ContainerID); err != nil {
+			return false, errors.Wrapf(err, "failed to start container %s", status.ContainerName)
+		}
+		log.Info("Started container", "container", status.ContainerName)
+		conditions.MarkFalse(dockerBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Starting container %s", status.ContainerName)
+		return false, nil
+	}
+
+	if err := r.publishContainer(ctx, dockerBuild, build, secret); err != nil {
+		return false, err
+	}
+	status.MachineReady = true
+	conditions.MarkTrue(dockerBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "Container %s is running", status.ContainerName)
+	r.recorder.Eventf(dockerBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "Container %s is running", status.ContainerName)
+	return true, nil
+}
+
+// createContainer pulls the base image and creates the container, kept running until it is stopped, and returns
+// its ID. A container left by a previous attempt is reused.
+func (r *DockerBuildReconciler) createContainer(ctx context.Context, dockerBuild *infrav1.DockerBuild, engine *docker.Client) (string, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &dockerBuild.Spec
+	status := &dockerBuild.Status
+	status.ContainerName = cmp.Or(status.ContainerName, providersdk.MachineName(dockerBuild))
+
+	auth, err := r.registryAuth(ctx, dockerBuild.Namespace, spec.PullSecretRef, spec.Image)
+	if err != nil {
+		return "", err
+	}
+	log.Info("Pulling image", "image", spec.Image)
+	if err := engine.PullImage(ctx, spec.Image, spec.Platform, auth); err != nil {
+		return "", errors.Wrapf(err, "failed to pull image %s", spec.Image)
+	}
+
+	config := &docker.ContainerConfig{
+		Image:      spec.Image,
+		Env:        spec.Env,
+		Entrypoint: []string{"/bin/sh", "-c"},
+		Cmd:        []string{keepAliveCommand},
+	}
+	id, err := engine.CreateContainer(ctx, status.ContainerName, spec.Platform, config)
+	if docker.IsConflict(err) {
+		container, err := engine.InspectContainer(ctx, status.ContainerName)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get container %s", status.ContainerName)
+		}
+		return container.ID, nil
+	}
+	if docker.IsNotFound(err) {
+		return "", forgeerrors.NewConfigError(errors.Wrapf(err, "image %s not found", spec.Image))
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create container %s", status.ContainerName)
+	}
+	log.Info("Created container", "container", status.ContainerName)
+	r.recorder.Eventf(dockerBuild, corev1.EventTypeNormal, "ContainerCreated", "Created container %s from %s", status.ContainerName, spec.Image)
+	return id, nil
+}
+
+// publishContainer sets the Docker Engine and the container in the connector credentials secret of the Build. The
+// secret is created, owned by the Build, when it does not exist.
+func (r *DockerBuildReconciler) publishContainer(ctx context.Context, dockerBuild *infrav1.DockerBuild, build *buildv1.Build, engine *corev1.Secret) error {
+	if build.Spec.Connector.Credentials == nil {
+		return forgeerrors.ConfigErrorf("the connector of Build %s has no credentials secret", build.Name)
+	}
+	data := map[string][]byte{docker.ContainerKey: []byte(dockerBuild.Status.ContainerID)}
+	for _, key := range []string{docker.HostKey, docker.CACertKey, docker.ClientCertKey, docker.ClientKeyKey} {
+		if value := engine.Data[key]; len(value) > 0 {
+			data[key] = value
+		}
+	}
+
+	name := build.Spec.Connector.Credentials.Name
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret)
+	if apierrors.IsNotFound(err) {
+		if dockerBuild.Spec.Username != "" {
+			data[docker.UsernameKey] = []byte(dockerBuild.Spec.Username)
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: build.Namespace,
+				Name:      name,
+				Labels:    map[string]string{buildv1.BuildNameLabel: build.Name},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(build, secret, r.Client.Scheme()); err != nil {
+			return err
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return errors.Wrapf(err, "failed to create the connector credentials secret %s", name)
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the connector credentials secret %s", name)
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range data {
+		secret.Data[key] = value
+	}
+	if err := r.Client.Patch(ctx, secret, patch); err != nil {
+		return errors.Wrapf(err, "failed to publish the container in secret %s", name)
+	}
+	return nil
+}
+
+// reconcileImage stops and commits the container, restoring the entrypoint and the command of the base image, and
+// pushes the image to the repository.
+func (r *DockerBuildReconciler) reconcileImage(ctx context.Context, dockerBuild *infrav1.DockerBuild, build *buildv1.Build, engine *docker.Client) error {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &dockerBuild.Spec
+	status := &dockerBuild.Status
+	tag := imageTag(dockerBuild, build)
+
+	if status.ImageID == "" {
+		if err := engine.StopContainer(ctx, status.ContainerID, stopTimeout); err != nil && !docker.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to stop container %s", status.ContainerName)
+		}
+		base, err := engine.InspectImage(ctx, spec.Image)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get image %s", spec.Image)
+		}
+		changes, err := commitChanges(&base.Config, spec.Changes)
+		if err != nil {
+			return err
+		}
+		comment := fmt.Sprintf("Built by Build %s/%s", build.Namespace, build.Name)
+		id, err := engine.CommitContainer(ctx, status.ContainerID, spec.Repository, tag, comment, changes)
+		if docker.IsNotFound(err) {
+			return forgeerrors.Terminalf(forgeerrors.CreateBuildError, "container %s has been removed", status.ContainerName)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to commit container %s", status.ContainerName)
+		}
+		log.Info("Committed container", "container", status.ContainerName, "image", id)
+		status.ImageID = id
+		conditions.MarkFalse(dockerBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Pushing %s:%s", spec.Repository, tag)
+	}
+
+	auth, err := r.registryAuth(ctx, dockerBuild.Namespace, spec.PushSecretRef, spec.Repository)
+	if err != nil {
+		return err
+	}
+	digest, err := engine.PushImage(ctx, spec.Repository, tag, auth)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push %s:%s", spec.Repository, tag)
+	}
+
+	imageRef := fmt.Sprintf("%s:%s@%s", spec.Repository, tag, digest)
+	status.Digest = digest
+	status.ImageRef = imageRef
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:        status.ImageID,
+		Location:  imageRef,
+		Checksum:  digest,
+		Format:    "oci",
+		CreatedAt: &metav1.Time{Time: time.Now()},
+	}
+	status.Ready = true
+	conditions.MarkTrue(dockerBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Image %s is ready", imageRef)
+	r.recorder.Eventf(dockerBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Image %s is ready", imageRef)
+	return nil
+}
+
+// registryAuth returns the credentials of the registry of the reference held by the kubernetes.io/dockerconfigjson
+// secret, nil when the secret is not set.
+func (r *DockerBuildReconciler) registryAuth(ctx context.Context, namespace string, ref *corev1.LocalObjectReference, reference string) (*docker.RegistryAuth, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	parsed, err := oci.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get registry secret %s", ref.Name)
+	}
+	creds, err := oci.CredentialsFor(secret, parsed.Registry)
+	if err != nil {
+		return nil, err
+	}
+	return &docker.RegistryAuth{Username: creds.Username, Password: creds.Password, ServerAddress: parsed.Registry}, nil
+}
+
+// reconcileDelete removes the container and the local tag of the image, the pushed image is kept.
+func (r *DockerBuildReconciler) reconcileDelete(ctx context.Context, dockerBuild *infrav1.DockerBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(dockerBuild, infrav1.DockerBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(dockerBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	status := &dockerBuild.Status
+	if status.ContainerID != "" {
+		if build == nil {
+			// The Build is already gone, only the credentials referenced by the DockerBuild can be used.
+			build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: dockerBuild.Namespace, Name: dockerBuild.Name}}
+		}
+		secret, err := providersdk.ProviderCredentials(ctx, r.Client, dockerBuild.Spec.CredentialsRef, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		engine, err := docker.NewClient(secret.Data)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+		}
+		if err := engine.RemoveContainer(ctx, status.ContainerID); err != nil && !docker.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to remove container %s", status.ContainerName)
+		}
+		log.Info("Removed container", "container", status.ContainerName)
+		if status.ImageID != "" {
+			ref := dockerBuild.Spec.Repository + ":" + imageTag(dockerBuild, build)
+			// The image is in use by another container, or has been removed, when it conflicts.
+			if err := engine.RemoveImage(ctx, ref); err != nil && !docker.IsNotFound(err) && !docker.IsConflict(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to remove image %s", ref)
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(dockerBuild, infrav1.DockerBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// commitChanges returns the Dockerfile instructions applied when committing the container: the entrypoint and the
+// command of the base image replace the ones keeping the container running, followed by the changes of the spec.
+func commitChanges(base *docker.ContainerConfig, changes []string) ([]string, error) {
+	instructions := make([]string, 0, len(changes)+2)
+	for _, instruction := range []struct {
+		name string
+		args []string
+	}{{"ENTRYPOINT", base.Entrypoint}, {"CMD", base.Cmd}} {
+		// The empty JSON array resets the instruction.
+		args, err := json.Marshal(append([]string{}, instruction.args...))
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, instruction.name+" "+string(args))
+	}
+	return append(instructions, changes...), nil
+}
+
+// imageTag returns the tag of the image, the tag of the spec, the spec.imageName of the Build, or the name of the
+// DockerBuild.
+func imageTag(dockerBuild *infrav1.DockerBuild, build *buildv1.Build) string {
+	return cmp.Or(dockerBuild.Spec.Tag, build.Spec.ImageName, dockerBuild.Name)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeEngine is a Docker Engine holding a single container.
+type fakeEngine struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	pulled    string
+	name      string
+	config    docker.ContainerConfig
+	state     string
+	committed url.Values
+	pushAuth  docker.RegistryAuth
+	removed   []string
+}
+
+func newFakeEngine(g *WithT) *fakeEngine {
+	e := &fakeEngine{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/v1.41")
+		switch {
+		case r.Method == http.MethodPost && path == "/images/create":
+			e.pulled = r.URL.Query().Get("fromImage")
+			_, _ = w.Write([]byte(`{"status":"Downloaded newer image"}` + "\n"))
+		case r.Method == http.MethodGet && path == "/images/ubuntu:24.04/json":
+			_, _ = w.Write([]byte(`{"Id":"sha256:base","Config":{"Entrypoint":null,"Cmd":["/bin/bash"]}}`))
+		case r.Method == http.MethodPost && path == "/containers/create":
+			e.name = r.URL.Query().Get("name")
+			g.Expect(json.NewDecoder(r.Body).Decode(&e.config)).To(Succeed())
+			e.state = "created"
+			_, _ = w.Write([]byte(`{"Id":"3f1c"}`))
+		case r.Method == http.MethodGet && path == "/containers/3f1c/json":
+			if e.state == "" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"No such container: 3f1c"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"Id":"3f1c","State":{"Status":"` + e.state + `","Running":` +
+				map[bool]string{true: "true", false: "false"}[e.state == "running"] + `}}`))
+		case r.Method == http.MethodPost && path == "/containers/3f1c/start":
+			e.state = "running"
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && path == "/containers/3f1c/stop":
+			e.state = "exited"
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && path == "/commit":
+			e.committed = r.URL.Query()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"sha256:built"}`))
+		case r.Method == http.MethodPost && path == "/images/ghcr.io/example/base/push":
+			data, _ := base64.URLEncoding.DecodeString(r.Header.Get("X-Registry-Auth"))
+			g.Expect(json.Unmarshal(data, &e.pushAuth)).To(Succeed())
+			_, _ = w.Write([]byte(`{"aux":{"Tag":"ubuntu-24.04","Digest":"sha256:0123","Size":529}}` + "\n"))
+		case r.Method == http.MethodDelete:
+			e.removed = append(e.removed, path)
+			if path == "/containers/3f1c" {
+				e.state = ""
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"page not found"}`))
+		}
+	}))
+	return e
+}
+
+func TestDockerBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	engine := newFakeEngine(g)
+	defer engine.Close()
+	c, build, dockerBuild := setupTest(g, engine.URL)
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(dockerBuild)}
+
+	// The container is created from the pulled base image, kept running by sh.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(containerRequeueAfter))
+	g.Expect(engine.pulled).To(Equal("ubuntu:24.04"))
+	g.Expect(engine.name).To(Equal(providersdk.MachineName(dockerBuild)))
+	g.Expect(engine.config.Entrypoint).To(Equal([]string{"/bin/sh", "-c"}))
+	g.Expect(engine.config.Cmd).To(Equal([]string{keepAliveCommand}))
+	g.Expect(engine.config.Env).To(Equal([]string{"DEBIAN_FRONTEND=noninteractive"}))
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).To(Succeed())
+	g.Expect(dockerBuild.Finalizers).To(ContainElement(infrav1.DockerBuildFinalizer))
+	g.Expect(dockerBuild.Status.ContainerID).To(Equal("3f1c"))
+	g.Expect(engine.state).To(Equal("running"))
+
+	// The container is published in the connector credentials once running.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).To(Succeed())
+	g.Expect(dockerBuild.Status.MachineReady).To(BeTrue())
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[docker.HostKey])).To(Equal(engine.URL))
+	g.Expect(string(secret.Data[docker.ContainerKey])).To(Equal("3f1c"))
+	g.Expect(string(secret.Data[docker.UsernameKey])).To(Equal("root"))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(dockerBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.WaitingForProvisionersReason))
+
+	// The container is committed, restoring the command of the base image, and pushed once the provisioners are ready.
+	build.Status.ProvisionersReady = true
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(engine.state).To(Equal("exited"))
+	g.Expect(engine.committed.Get("repo")).To(Equal("ghcr.io/example/base"))
+	g.Expect(engine.committed.Get("tag")).To(Equal("ubuntu-24.04"))
+	g.Expect(engine.committed["changes"]).To(Equal([]string{`ENTRYPOINT []`, `CMD ["/bin/bash"]`, `USER app`}))
+	g.Expect(engine.pushAuth).To(Equal(docker.RegistryAuth{Username: "forge", Password: "token", ServerAddress: "ghcr.io"}))
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).To(Succeed())
+	g.Expect(dockerBuild.Status.Ready).To(BeTrue())
+	g.Expect(dockerBuild.Status.ImageID).To(Equal("sha256:built"))
+	g.Expect(dockerBuild.Status.ImageRef).To(Equal("ghcr.io/example/base:ubuntu-24.04@sha256:0123"))
+	g.Expect(dockerBuild.Status.Artifact.ID).To(Equal("sha256:built"))
+	g.Expect(dockerBuild.Status.Artifact.Format).To(Equal("oci"))
+	g.Expect(conditions.IsTrue(dockerBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The container and the local tag are removed along with the DockerBuild.
+	g.Expect(c.Delete(ctx, dockerBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(engine.removed).To(Equal([]string{"/containers/3f1c", "/images/ghcr.io/example/base:ubuntu-24.04"}))
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).ToNot(Succeed())
+}
+
+func TestDockerBuildReconcileRemovedContainer(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	engine := newFakeEngine(g)
+	defer engine.Close()
+	c, _, dockerBuild := setupTest(g, engine.URL)
+	dockerBuild.Status.ContainerName = "ubuntu-1234"
+	dockerBuild.Status.ContainerID = "3f1c"
+	dockerBuild.Status.MachineReady = true
+	g.Expect(c.Status().Update(ctx, dockerBuild)).To(Succeed())
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(dockerBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, dockerBuild)).To(Succeed())
+	g.Expect(dockerBuild.Status.FailureReason).To(Equal(ptr.To(forgeerrors.CreateBuildError)))
+	g.Expect(*dockerBuild.Status.FailureMessage).To(ContainSubstring("container ubuntu-1234 has been removed"))
+}
+
+func TestCommitChanges(t *testing.T) {
+	g := NewWithT(t)
+
+	changes, err := commitChanges(&docker.ContainerConfig{Entrypoint: []string{"/docker-entrypoint.sh"}, Cmd: []string{"nginx", "-g", "daemon off;"}}, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changes).To(Equal([]string{`ENTRYPOINT ["/docker-entrypoint.sh"]`, `CMD ["nginx","-g","daemon off;"]`}))
+}
+
+func newReconciler(c client.Client) *DockerBuildReconciler {
+	return &DockerBuildReconciler{
+		Client:   c,
+		recorder: record.NewFakeRecorder(100),
+	}
+}
+
+func setupTest(g *WithT, endpoint string) (client.Client, *buildv1.Build, *infrav1.DockerBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-24.04",
+			Connector: buildv1.ConnectorSpec{
+				Type:        buildv1.ConnectorTypeDockerExec,
+				Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"},
+			},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "DockerBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	dockerBuild := &infrav1.DockerBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "dockerbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.DockerBuildSpec{
+			Image:          "ubuntu:24.04",
+			Username:       "root",
+			Env:            []string{"DEBIAN_FRONTEND=noninteractive"},
+			Changes:        []string{"USER app"},
+			Repository:     "ghcr.io/example/base",
+			PushSecretRef:  &corev1.LocalObjectReference{Name: "registry"},
+			CredentialsRef: &corev1.LocalObjectReference{Name: "docker"},
+		},
+	}
+	engine := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "docker"},
+		Data:       map[string][]byte{docker.HostKey: []byte(endpoint)},
+	}
+	registry := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "registry"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"ghcr.io":{"username":"forge","password":"token"}}}`),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, dockerBuild, engine, registry).
+		WithStatusSubresource(build, dockerBuild).
+		Build()
+	return c, build, dockerBuild
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	"github.com/forge-build/forge/pkg/identity"
+)
+
+// Validator validates the Docker Engines of the docker ProviderIdentities, by requesting the version of the Docker
+// Engine of their secret.
+type Validator struct{}
+
+var _ identity.Validator = &Validator{}
+
+// Validate returns an error if the secret is incomplete or the Docker Engine rejects its client certificate.
+func (v *Validator) Validate(ctx context.Context, _ *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	engine, err := docker.NewClient(secret.Data)
+	if err != nil {
+		return err
+	}
+	_, err = engine.Version(ctx)
+	return err
+}
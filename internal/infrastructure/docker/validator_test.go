@@ -0,0 +1,78 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		secret    map[string][]byte
+		valid     bool
+		retryable bool
+	}{
+		{name: "reachable engine", status: http.StatusOK, valid: true},
+		{name: "rejected client", status: http.StatusForbidden},
+		{name: "engine unavailable", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "missing host", secret: map[string][]byte{}},
+		{name: "invalid CA certificate", secret: map[string][]byte{
+			docker.HostKey:   []byte("tcp://docker.example.com:2376"),
+			docker.CACertKey: []byte("not a certificate"),
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.URL.Path).To(HaveSuffix("/version"))
+				w.WriteHeader(tc.status)
+				if tc.status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"Version":"27.3.1","ApiVersion":"1.47"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"message":"error"}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: tc.secret}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{docker.HostKey: []byte(server.URL)}
+			}
+			err := (&Validator{}).Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
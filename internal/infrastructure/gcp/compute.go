@@ -0,0 +1,419 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gcp implements the GCPBuild infrastructure provider: the build machine is a Compute Engine instance,
+// and the image is created from its boot disk once the provisioners of the Build are ready.
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/jwt"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// ComputeEndpoint is the endpoint of the Compute Engine API.
+	ComputeEndpoint = "https://compute.googleapis.com/compute/v1/"
+
+	// CloudBuildEndpoint is the endpoint of the Cloud Build API.
+	CloudBuildEndpoint = "https://cloudbuild.googleapis.com/v1/"
+
+	// ExportImage is the image of the Cloud Build step exporting an image to Cloud Storage,
+	// as run by gcloud compute images export.
+	ExportImage = "gcr.io/compute-image-tools/gce_vm_image_export:release"
+
+	// scope is the OAuth scope of the tokens of the service accounts.
+	scope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// defaultTokenURL is the token URL of the service account keys without one.
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// The statuses of the instances, images, operations and Cloud Builds.
+const (
+	InstanceProvisioning = "PROVISIONING"
+	InstanceStaging      = "STAGING"
+	InstanceRunning      = "RUNNING"
+	InstanceStopping     = "STOPPING"
+	InstanceTerminated   = "TERMINATED"
+
+	ImagePending = "PENDING"
+	ImageReady   = "READY"
+	ImageFailed  = "FAILED"
+
+	OperationDone = "DONE"
+
+	CloudBuildSuccess = "SUCCESS"
+	CloudBuildQueued  = "QUEUED"
+	CloudBuildPending = "PENDING"
+	CloudBuildWorking = "WORKING"
+)
+
+// Compute is the part of the Compute Engine and Cloud Build APIs used by the controller.
+type Compute interface {
+	GetInstance(ctx context.Context, project, zone, name string) (*Instance, error)
+	InsertInstance(ctx context.Context, project, zone string, instance *Instance) (*Operation, error)
+	StopInstance(ctx context.Context, project, zone, name string) (*Operation, error)
+	DeleteInstance(ctx context.Context, project, zone, name string) (*Operation, error)
+	GetImage(ctx context.Context, project, name string) (*Image, error)
+	InsertImage(ctx context.Context, project string, image *Image) (*Operation, error)
+	GetOperation(ctx context.Context, selfLink string) (*Operation, error)
+	ExportImage(ctx context.Context, project, image, destination string) (string, error)
+	GetCloudBuild(ctx context.Context, project, id string) (*CloudBuild, error)
+}
+
+// Instance is a Compute Engine instance.
+type Instance struct {
+	ID                string             `json:"id,omitempty"`
+	Name              string             `json:"name"`
+	MachineType       string             `json:"machineType,omitempty"`
+	Status            string             `json:"status,omitempty"`
+	Disks             []AttachedDisk     `json:"disks,omitempty"`
+	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
+	Metadata          *Metadata          `json:"metadata,omitempty"`
+	Tags              *Tags              `json:"tags,omitempty"`
+	Labels            map[string]string  `json:"labels,omitempty"`
+	Scheduling        *Scheduling        `json:"scheduling,omitempty"`
+}
+
+// Scheduling is the scheduling of an instance, e.g. as a Spot VM.
+type Scheduling struct {
+	ProvisioningModel         string `json:"provisioningModel,omitempty"`
+	InstanceTerminationAction string `json:"instanceTerminationAction,omitempty"`
+	AutomaticRestart          *bool  `json:"automaticRestart,omitempty"`
+	OnHostMaintenance         string `json:"onHostMaintenance,omitempty"`
+}
+
+// AttachedDisk is a disk of an instance.
+type AttachedDisk struct {
+	Boot             bool                  `json:"boot,omitempty"`
+	AutoDelete       bool                  `json:"autoDelete,omitempty"`
+	Source           string                `json:"source,omitempty"`
+	InitializeParams *DiskInitializeParams `json:"initializeParams,omitempty"`
+}
+
+// DiskInitializeParams are the parameters of a disk created along with its instance.
+type DiskInitializeParams struct {
+	SourceImage string `json:"sourceImage,omitempty"`
+	DiskSizeGb  int64  `json:"diskSizeGb,omitempty,string"`
+	DiskType    string `json:"diskType,omitempty"`
+}
+
+// NetworkInterface is a network interface of an instance.
+type NetworkInterface struct {
+	Network       string         `json:"network,omitempty"`
+	Subnetwork    string         `json:"subnetwork,omitempty"`
+	NetworkIP     string         `json:"networkIP,omitempty"`
+	AccessConfigs []AccessConfig `json:"accessConfigs,omitempty"`
+}
+
+// AccessConfig is the external access of a network interface.
+type AccessConfig struct {
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name,omitempty"`
+	NatIP string `json:"natIP,omitempty"`
+}
+
+// Metadata is the metadata of an instance.
+type Metadata struct {
+	Items []MetadataItem `json:"items,omitempty"`
+}
+
+// MetadataItem is a metadata key of an instance.
+type MetadataItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Tags are the network tags of an instance.
+type Tags struct {
+	Items []string `json:"items,omitempty"`
+}
+
+// Image is a Compute Engine image.
+type Image struct {
+	Name              string            `json:"name"`
+	SelfLink          string            `json:"selfLink,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	SourceDisk        string            `json:"sourceDisk,omitempty"`
+	Family            string            `json:"family,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	StorageLocations  []string          `json:"storageLocations,omitempty"`
+	ArchiveSizeBytes  int64             `json:"archiveSizeBytes,omitempty,string"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+}
+
+// Operation is a Compute Engine operation.
+type Operation struct {
+	Name     string          `json:"name"`
+	SelfLink string          `json:"selfLink"`
+	Status   string          `json:"status"`
+	Error    *OperationError `json:"error,omitempty"`
+}
+
+// OperationError holds the errors of a failed operation.
+type OperationError struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Err returns the errors of the operation as an error, nil if it succeeded or is not done.
+func (o *Operation) Err() error {
+	if o.Error == nil || len(o.Error.Errors) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(o.Error.Errors))
+	for _, e := range o.Error.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.Code, e.Message))
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// CloudBuild is a Cloud Build.
+type CloudBuild struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	StatusDetail string `json:"statusDetail,omitempty"`
+}
+
+// APIError is an error returned by the APIs.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the APIs.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client is a client of the Compute Engine and Cloud Build APIs.
+type Client struct {
+	// HTTPClient sends the requests, it authenticates them.
+	HTTPClient *http.Client
+	// ComputeEndpoint is the endpoint of the Compute Engine API.
+	ComputeEndpoint string
+	// CloudBuildEndpoint is the endpoint of the Cloud Build API.
+	CloudBuildEndpoint string
+}
+
+var _ Compute = &Client{}
+
+// serviceAccountKey is a service account key, as downloaded from the console.
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// parseServiceAccountKey parses a service account key in JSON.
+func parseServiceAccountKey(data []byte) (*serviceAccountKey, error) {
+	key := &serviceAccountKey{}
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid service account key: %v", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, forgeerrors.ConfigErrorf("invalid service account key, it must be the JSON key of a service account")
+	}
+	if block, _ := pem.Decode([]byte(key.PrivateKey)); block == nil {
+		return nil, forgeerrors.ConfigErrorf("invalid service account key, the private key of %s is not PEM encoded", key.ClientEmail)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = defaultTokenURL
+	}
+	return key, nil
+}
+
+// jwtConfig returns the configuration of the tokens of the service account.
+func (k *serviceAccountKey) jwtConfig() *jwt.Config {
+	return &jwt.Config{
+		Email:        k.ClientEmail,
+		PrivateKey:   []byte(k.PrivateKey),
+		PrivateKeyID: k.PrivateKeyID,
+		Scopes:       []string{scope},
+		TokenURL:     k.TokenURI,
+	}
+}
+
+// NewCompute returns a client of the APIs authenticated with the service account key, in JSON.
+func NewCompute(ctx context.Context, credentials []byte) (Compute, error) {
+	key, err := parseServiceAccountKey(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		HTTPClient:         key.jwtConfig().Client(ctx),
+		ComputeEndpoint:    ComputeEndpoint,
+		CloudBuildEndpoint: CloudBuildEndpoint,
+	}, nil
+}
+
+func (c *Client) GetInstance(ctx context.Context, project, zone, name string) (*Instance, error) {
+	instance := &Instance{}
+	err := c.do(ctx, http.MethodGet, c.computeURL("projects/%s/zones/%s/instances/%s", project, zone, name), nil, instance)
+	return instance, err
+}
+
+func (c *Client) InsertInstance(ctx context.Context, project, zone string, instance *Instance) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodPost, c.computeURL("projects/%s/zones/%s/instances", project, zone), instance, op)
+	return op, err
+}
+
+func (c *Client) StopInstance(ctx context.Context, project, zone, name string) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodPost, c.computeURL("projects/%s/zones/%s/instances/%s/stop", project, zone, name), nil, op)
+	return op, err
+}
+
+func (c *Client) DeleteInstance(ctx context.Context, project, zone, name string) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodDelete, c.computeURL("projects/%s/zones/%s/instances/%s", project, zone, name), nil, op)
+	return op, err
+}
+
+func (c *Client) GetImage(ctx context.Context, project, name string) (*Image, error) {
+	image := &Image{}
+	err := c.do(ctx, http.MethodGet, c.computeURL("projects/%s/global/images/%s", project, name), nil, image)
+	return image, err
+}
+
+// DeleteImage deletes the image, along with its storage.
+func (c *Client) DeleteImage(ctx context.Context, project, name string) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodDelete, c.computeURL("projects/%s/global/images/%s", project, name), nil, op)
+	return op, err
+}
+
+func (c *Client) InsertImage(ctx context.Context, project string, image *Image) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodPost, c.computeURL("projects/%s/global/images", project), image, op)
+	return op, err
+}
+
+func (c *Client) GetOperation(ctx context.Context, selfLink string) (*Operation, error) {
+	op := &Operation{}
+	err := c.do(ctx, http.MethodGet, selfLink, nil, op)
+	return op, err
+}
+
+// ExportImage starts a Cloud Build exporting the image to the gs:// destination and returns its ID.
+func (c *Client) ExportImage(ctx context.Context, project, image, destination string) (string, error) {
+	build := map[string]interface{}{
+		"steps": []map[string]interface{}{{
+			"name": ExportImage,
+			"args": []string{
+				"-timeout=7000s",
+				"-source_image=" + image,
+				"-destination_uri=" + destination,
+				"-client_id=forge",
+			},
+		}},
+		"timeout": "7200s",
+		"tags":    []string{"gce-daisy", "gce-daisy-image-export"},
+	}
+	op := &struct {
+		Metadata struct {
+			Build CloudBuild `json:"build"`
+		} `json:"metadata"`
+	}{}
+	if err := c.do(ctx, http.MethodPost, c.cloudBuildURL("projects/%s/builds", project), build, op); err != nil {
+		return "", err
+	}
+	if op.Metadata.Build.ID == "" {
+		return "", errors.New("the Cloud Build API returned no build")
+	}
+	return op.Metadata.Build.ID, nil
+}
+
+func (c *Client) GetCloudBuild(ctx context.Context, project, id string) (*CloudBuild, error) {
+	build := &CloudBuild{}
+	err := c.do(ctx, http.MethodGet, c.cloudBuildURL("projects/%s/builds/%s", project, id), nil, build)
+	return build, err
+}
+
+func (c *Client) computeURL(format string, args ...interface{}) string {
+	return strings.TrimSuffix(c.ComputeEndpoint, "/") + "/" + fmt.Sprintf(format, args...)
+}
+
+func (c *Client) cloudBuildURL(format string, args ...interface{}) string {
+	return strings.TrimSuffix(c.CloudBuildEndpoint, "/") + "/" + fmt.Sprintf(format, args...)
+}
+
+// do sends the request with the JSON body, if any, and decodes the response into out. The errors are classified:
+// throttling and server errors are retried, the invalid requests are configuration errors.
+func (c *Client) do(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, url))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return classifier.Classify(apierror.Parse(resp.StatusCode, resp.Body, errorMessage))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "invalid response to %s %s", method, url)
+	}
+	return nil
+}
+
+// errorMessage returns the message of an error response of the APIs.
+func errorMessage(data []byte) (string, string) {
+	apiErr := &struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	_ = json.Unmarshal(data, apiErr)
+	return "", apiErr.Error.Message
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing and existing
+// resources.
+var classifier = apierror.Classifier{Handled: apierror.StatusCodes(http.StatusNotFound, http.StatusConflict)}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var inserted Instance
+	var export map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /compute/projects/forge/zones/europe-west1-b/instances/ubuntu", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"42","name":"ubuntu","status":"RUNNING","networkInterfaces":[{"networkIP":"10.0.0.2","accessConfigs":[{"natIP":"34.1.2.3"}]}]}`))
+	})
+	mux.HandleFunc("GET /compute/projects/forge/zones/europe-west1-b/instances/missing", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"message":"The resource 'missing' was not found"}}`))
+	})
+	mux.HandleFunc("POST /compute/projects/forge/zones/europe-west1-b/instances", func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(json.NewDecoder(r.Body).Decode(&inserted)).To(Succeed())
+		_, _ = w.Write([]byte(`{"name":"operation-1","selfLink":"http://` + r.Host + `/compute/projects/forge/zones/europe-west1-b/operations/operation-1","status":"RUNNING"}`))
+	})
+	mux.HandleFunc("GET /compute/projects/forge/zones/europe-west1-b/operations/operation-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"operation-1","status":"DONE","error":{"errors":[{"code":"QUOTA_EXCEEDED","message":"Quota 'CPUS' exceeded"}]}}`))
+	})
+	mux.HandleFunc("GET /compute/projects/forge/global/images/throttled", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("GET /compute/projects/forge/global/images/unavailable", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("GET /compute/projects/forge/global/images/forbidden", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":403,"message":"Required 'compute.images.get' permission"}}`))
+	})
+	mux.HandleFunc("GET /compute/projects/forge/global/images/ubuntu", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"ubuntu","status":"READY","archiveSizeBytes":"1073741824"}`))
+	})
+	mux.HandleFunc("POST /cloudbuild/projects/forge/builds", func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(json.NewDecoder(r.Body).Decode(&export)).To(Succeed())
+		_, _ = w.Write([]byte(`{"name":"operations/build/forge/b1","metadata":{"build":{"id":"b1","status":"QUEUED"}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &Client{
+		HTTPClient:         server.Client(),
+		ComputeEndpoint:    server.URL + "/compute/",
+		CloudBuildEndpoint: server.URL + "/cloudbuild",
+	}
+
+	instance, err := c.GetInstance(ctx, "forge", "europe-west1-b", "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(instance.ID).To(Equal("42"))
+	g.Expect(instanceAddress(instance, true)).To(Equal("34.1.2.3"))
+	g.Expect(instanceAddress(instance, false)).To(Equal("10.0.0.2"))
+
+	_, err = c.GetInstance(ctx, "forge", "europe-west1-b", "missing")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("The resource 'missing' was not found"))
+
+	op, err := c.InsertInstance(ctx, "forge", "europe-west1-b", &Instance{
+		Name:  "ubuntu",
+		Disks: []AttachedDisk{{Boot: true, InitializeParams: &DiskInitializeParams{DiskSizeGb: 20}}},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inserted.Name).To(Equal("ubuntu"))
+	g.Expect(inserted.Disks[0].InitializeParams.DiskSizeGb).To(BeEquivalentTo(20))
+
+	op, err = c.GetOperation(ctx, op.SelfLink)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(op.Status).To(Equal(OperationDone))
+	g.Expect(op.Err()).To(MatchError("QUOTA_EXCEEDED: Quota 'CPUS' exceeded"))
+
+	image, err := c.GetImage(ctx, "forge", "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(image.ArchiveSizeBytes).To(BeEquivalentTo(1 << 30))
+
+	_, err = c.GetImage(ctx, "forge", "throttled")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+	_, err = c.GetImage(ctx, "forge", "unavailable")
+	g.Expect(forgeerrors.IsRetryable(err)).To(BeTrue())
+	_, err = c.GetImage(ctx, "forge", "forbidden")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+
+	id, err := c.ExportImage(ctx, "forge", "projects/forge/global/images/ubuntu", "gs://images/ubuntu.tar.gz")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("b1"))
+	g.Expect(export["steps"]).To(ConsistOf(HaveKeyWithValue("args", ContainElements(
+		"-source_image=projects/forge/global/images/ubuntu",
+		"-destination_uri=gs://images/ubuntu.tar.gz",
+	))))
+}
+
+func TestParseServiceAccountKey(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := parseServiceAccountKey([]byte(`not json`))
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+
+	_, err = parseServiceAccountKey([]byte(`{"type":"authorized_user","client_email":"forge@forge.iam.gserviceaccount.com"}`))
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+
+	_, err = parseServiceAccountKey([]byte(`{"type":"service_account","client_email":"forge@forge.iam.gserviceaccount.com","private_key":"key"}`))
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+
+	key, err := parseServiceAccountKey(serviceAccountKeyJSON(g, "https://oauth2.example.com/token"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(key.ClientEmail).To(Equal("forge@forge.iam.gserviceaccount.com"))
+	g.Expect(key.jwtConfig().Scopes).To(ConsistOf(scope))
+}
@@ -0,0 +1,537 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the GCPBuild controller, used in the controller metrics.
+	ControllerName = "gcpbuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "gcp"
+
+	// instanceRequeueAfter is how often an instance being created, stopped or deleted is checked.
+	instanceRequeueAfter = 10 * time.Second
+
+	// imageRequeueAfter is how often an image being created or exported is checked.
+	imageRequeueAfter = 30 * time.Second
+)
+
+// GCPBuildReconciler reconciles a GCPBuild object
+type GCPBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewCompute returns the client of the APIs authenticated with a service account key, NewCompute is used if nil.
+	NewCompute func(ctx context.Context, credentials []byte) (Compute, error)
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GCPBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.GCPBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("GCPBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("gcpbuild-controller")
+	return nil
+}
+
+// Reconcile creates the instance of a GCPBuild, publishes its address in the connector credentials of the Build
+// and, once the provisioners of the Build are ready, stops it and creates the image from its boot disk.
+func (r *GCPBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	gcpBuild := &infrav1.GCPBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, gcpBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(gcpBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(gcpBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(gcpBuild)
+		if err := patchHelper.Patch(ctx, gcpBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, gcpBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !gcpBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, gcpBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(gcpBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the GCPBuild")
+		return ctrl.Result{}, nil
+	}
+	if gcpBuild.Status.FailureReason != nil {
+		// The Build has failed, the instance is deleted along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(gcpBuild, infrav1.GCPBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, gcpBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, gcpBuild, res, err)
+}
+
+func (r *GCPBuildReconciler) reconcileNormal(ctx context.Context, gcpBuild *infrav1.GCPBuild, build *buildv1.Build) (ctrl.Result, error) {
+	compute, err := r.compute(ctx, gcpBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	instance, res, err := r.reconcileInstance(ctx, compute, gcpBuild, build)
+	if err != nil || instance == nil {
+		return res, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileImage(ctx, compute, gcpBuild, build, instance)
+}
+
+// reconcileInstance creates the instance and publishes its address once it is running. It returns nil while the
+// instance is being created.
+func (r *GCPBuildReconciler) reconcileInstance(ctx context.Context, compute Compute, gcpBuild *infrav1.GCPBuild, build *buildv1.Build) (*Instance, ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &gcpBuild.Spec
+	status := &gcpBuild.Status
+
+	name := status.InstanceName
+	if name == "" {
+		name = providersdk.MachineName(gcpBuild)
+	}
+	instance, err := compute.GetInstance(ctx, spec.Project, spec.Zone, name)
+	if IsNotFound(err) {
+		if status.MachineReady && spec.Spot {
+			return nil, ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.PreemptedBuildError, "Spot VM %s has been preempted", name)
+		}
+		if status.MachineReady {
+			return nil, ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s has been deleted", name)
+		}
+		if done, err := r.checkOperation(ctx, compute, gcpBuild, "create instance "+name); err != nil || !done {
+			return nil, ctrl.Result{RequeueAfter: instanceRequeueAfter}, err
+		}
+
+		sourceImage, ok := providersdk.SourceImage(build, spec.GetSourceImage())
+		if !ok {
+			conditions.MarkFalse(gcpBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the source image of the Build")
+			return nil, ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+		}
+		creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultGCPUsername))
+		if err != nil {
+			return nil, ctrl.Result{}, err
+		}
+		if creds.AuthorizedKey == "" {
+			return nil, ctrl.Result{}, forgeerrors.ConfigErrorf("the connector credentials of Build %s have no private key, GCPBuilds only authorize keys", build.Name)
+		}
+		op, err := compute.InsertInstance(ctx, spec.Project, spec.Zone, instanceFor(gcpBuild, build, name, sourceImage, creds))
+		if err != nil {
+			return nil, ctrl.Result{}, errors.Wrapf(err, "failed to create instance %s", name)
+		}
+		log.Info("Creating instance", "instance", name)
+		r.recorder.Eventf(gcpBuild, corev1.EventTypeNormal, "InstanceCreating", "Creating instance %s", name)
+		status.InstanceName = name
+		status.PendingOperation = op.SelfLink
+		status.SourceImageRef = sourceImage
+		conditions.MarkFalse(gcpBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Creating instance %s", name)
+		return nil, ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+	}
+	if err != nil {
+		return nil, ctrl.Result{}, errors.Wrapf(err, "failed to get instance %s", name)
+	}
+	status.InstanceName = instance.Name
+	status.InstanceID = instance.ID
+
+	if status.MachineReady {
+		if instance.Status != InstanceRunning && !build.Status.ProvisionersReady {
+			if spec.Spot {
+				return nil, ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.PreemptedBuildError,
+					"Spot VM %s is %s, it has been preempted", name, instance.Status)
+			}
+			return nil, ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.CreateBuildError,
+				"instance %s is %s while the provisioners are running", name, instance.Status)
+		}
+		return instance, ctrl.Result{}, nil
+	}
+
+	switch instance.Status {
+	case InstanceProvisioning, InstanceStaging:
+		conditions.MarkFalse(gcpBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Instance %s is %s", name, instance.Status)
+		return nil, ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+	case InstanceRunning:
+	default:
+		return nil, ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s is %s", name, instance.Status)
+	}
+	status.PendingOperation = ""
+
+	address := instanceAddress(instance, ptr.Deref(spec.PublicIP, true))
+	if address == "" {
+		return nil, ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, ctrl.Result{}, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(gcpBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "Instance %s is running at %s", name, address)
+	r.recorder.Eventf(gcpBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "Instance %s is running at %s", name, address)
+	return instance, ctrl.Result{}, nil
+}
+
+// reconcileImage stops the instance, creates the image from its boot disk and exports it to Cloud Storage.
+func (r *GCPBuildReconciler) reconcileImage(ctx context.Context, compute Compute, gcpBuild *infrav1.GCPBuild, build *buildv1.Build, instance *Instance) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &gcpBuild.Spec
+	status := &gcpBuild.Status
+	if status.Ready {
+		return ctrl.Result{}, nil
+	}
+
+	switch instance.Status {
+	case InstanceRunning:
+		if _, err := compute.StopInstance(ctx, spec.Project, spec.Zone, instance.Name); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to stop instance %s", instance.Name)
+		}
+		log.Info("Stopping instance", "instance", instance.Name)
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Stopping instance %s", instance.Name)
+		return ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+	case InstanceTerminated:
+	default:
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Instance %s is %s", instance.Name, instance.Status)
+		return ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+	}
+
+	name := imageName(gcpBuild, build)
+	image, err := compute.GetImage(ctx, spec.Project, name)
+	if IsNotFound(err) {
+		if done, err := r.checkOperation(ctx, compute, gcpBuild, "create image "+name); err != nil || !done {
+			return ctrl.Result{RequeueAfter: imageRequeueAfter}, err
+		}
+		op, err := compute.InsertImage(ctx, spec.Project, imageFor(gcpBuild, build, name, instance))
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to create image %s", name)
+		}
+		log.Info("Creating image", "image", name)
+		r.recorder.Eventf(gcpBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Creating image %s", name)
+		status.PendingOperation = op.SelfLink
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Creating image %s", name)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get image %s", name)
+	}
+	switch image.Status {
+	case ImageReady:
+	case ImageFailed:
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "image %s failed", name)
+	default:
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Image %s is %s", name, image.Status)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+	status.PendingOperation = ""
+
+	ref := fmt.Sprintf("projects/%s/global/images/%s", spec.Project, name)
+	if spec.Image.ExportURL != "" {
+		exported, err := r.reconcileExport(ctx, compute, gcpBuild, ref)
+		if err != nil || !exported {
+			return ctrl.Result{RequeueAfter: imageRequeueAfter}, err
+		}
+	}
+
+	status.ImageRef = ref
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       ref,
+		Location: ref,
+		Format:   "gce",
+	}
+	if image.ArchiveSizeBytes > 0 {
+		status.Artifact.SizeBytes = ptr.To(image.ArchiveSizeBytes)
+	}
+	if created, err := time.Parse(time.RFC3339, image.CreationTimestamp); err == nil {
+		status.Artifact.CreatedAt = &metav1.Time{Time: created}
+	}
+	status.Ready = true
+	conditions.MarkTrue(gcpBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Image %s is ready", ref)
+	r.recorder.Eventf(gcpBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Image %s is ready", ref)
+	return ctrl.Result{}, nil
+}
+
+// reconcileExport exports the image to spec.image.exportURL with Cloud Build, it returns true once exported.
+func (r *GCPBuildReconciler) reconcileExport(ctx context.Context, compute Compute, gcpBuild *infrav1.GCPBuild, image string) (bool, error) {
+	spec := &gcpBuild.Spec
+	status := &gcpBuild.Status
+	if status.ExportBuildID == "" {
+		id, err := compute.ExportImage(ctx, spec.Project, image, spec.Image.ExportURL)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to export image %s", image)
+		}
+		status.ExportBuildID = id
+		r.recorder.Eventf(gcpBuild, corev1.EventTypeNormal, infrav1.ImageExportingReason, "Exporting image %s to %s", image, spec.Image.ExportURL)
+	}
+
+	export, err := compute.GetCloudBuild(ctx, spec.Project, status.ExportBuildID)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get Cloud Build %s", status.ExportBuildID)
+	}
+	switch export.Status {
+	case CloudBuildSuccess:
+		return true, nil
+	case CloudBuildQueued, CloudBuildPending, CloudBuildWorking:
+		conditions.MarkFalse(gcpBuild, infrav1.ImageReadyCondition, infrav1.ImageExportingReason,
+			"Exporting image %s to %s, Cloud Build %s is %s", image, spec.Image.ExportURL, export.ID, export.Status)
+		return false, nil
+	}
+	return false, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "export of image %s to %s failed, Cloud Build %s is %s: %s",
+		image, spec.Image.ExportURL, export.ID, export.Status, export.StatusDetail)
+}
+
+// checkOperation checks the pending operation of the GCPBuild, if any. It returns true once it is done,
+// and an error if it failed.
+func (r *GCPBuildReconciler) checkOperation(ctx context.Context, compute Compute, gcpBuild *infrav1.GCPBuild, description string) (bool, error) {
+	if gcpBuild.Status.PendingOperation == "" {
+		return true, nil
+	}
+	op, err := compute.GetOperation(ctx, gcpBuild.Status.PendingOperation)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get operation %s", gcpBuild.Status.PendingOperation)
+	}
+	if op.Status != OperationDone {
+		return false, nil
+	}
+	gcpBuild.Status.PendingOperation = ""
+	if err := op.Err(); err != nil {
+		return false, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "failed to %s: %v", description, err)
+	}
+	return true, nil
+}
+
+// reconcileDelete deletes the instance, the image is kept.
+func (r *GCPBuildReconciler) reconcileDelete(ctx context.Context, gcpBuild *infrav1.GCPBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(gcpBuild, infrav1.GCPBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(gcpBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	if gcpBuild.Status.InstanceName != "" {
+		compute, err := r.compute(ctx, gcpBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		spec := &gcpBuild.Spec
+		instance, err := compute.GetInstance(ctx, spec.Project, spec.Zone, gcpBuild.Status.InstanceName)
+		switch {
+		case IsNotFound(err):
+		case err != nil:
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get instance %s", gcpBuild.Status.InstanceName)
+		default:
+			if _, err := compute.DeleteInstance(ctx, spec.Project, spec.Zone, instance.Name); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete instance %s", instance.Name)
+			}
+			log.Info("Deleting instance", "instance", instance.Name)
+			return ctrl.Result{RequeueAfter: instanceRequeueAfter}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(gcpBuild, infrav1.GCPBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// compute returns the client of the APIs authenticated with the credentials of the GCPBuild.
+func (r *GCPBuildReconciler) compute(ctx context.Context, gcpBuild *infrav1.GCPBuild, build *buildv1.Build) (Compute, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the GCPBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: gcpBuild.Namespace, Name: gcpBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, gcpBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return nil, err
+	}
+	credentials := secret.Data[infrav1.GCPCredentialsKey]
+	if len(credentials) == 0 {
+		return nil, forgeerrors.ConfigErrorf("secret %s has no %s key", secret.Name, infrav1.GCPCredentialsKey)
+	}
+	newCompute := r.NewCompute
+	if newCompute == nil {
+		newCompute = NewCompute
+	}
+	return newCompute(ctx, credentials)
+}
+
+// instanceFor returns the instance of the GCPBuild booting from the source image, the connector key is authorized
+// with the ssh-keys metadata.
+func instanceFor(gcpBuild *infrav1.GCPBuild, build *buildv1.Build, name, sourceImage string, creds *providersdk.Credentials) *Instance {
+	spec := &gcpBuild.Spec
+	machineType := cmp.Or(spec.MachineType, infrav1.DefaultGCPMachineType)
+	disk := &DiskInitializeParams{SourceImage: sourceImage, DiskSizeGb: spec.DiskSizeGB}
+	if spec.DiskType != "" {
+		disk.DiskType = fmt.Sprintf("zones/%s/diskTypes/%s", spec.Zone, spec.DiskType)
+	}
+	nic := NetworkInterface{
+		Network:    cmp.Or(spec.Network, infrav1.DefaultGCPNetwork),
+		Subnetwork: spec.Subnetwork,
+	}
+	if ptr.Deref(spec.PublicIP, true) {
+		nic.AccessConfigs = []AccessConfig{{Type: "ONE_TO_ONE_NAT", Name: "External NAT"}}
+	}
+	instance := &Instance{
+		Name:              name,
+		MachineType:       fmt.Sprintf("zones/%s/machineTypes/%s", spec.Zone, machineType),
+		Disks:             []AttachedDisk{{Boot: true, AutoDelete: true, InitializeParams: disk}},
+		NetworkInterfaces: []NetworkInterface{nic},
+		Metadata: &Metadata{Items: []MetadataItem{
+			{Key: "ssh-keys", Value: creds.Username + ":" + strings.TrimSpace(creds.AuthorizedKey)},
+		}},
+		Labels: map[string]string{"forge-build": labelValue(build.Name)},
+	}
+	if len(spec.Tags) > 0 {
+		instance.Tags = &Tags{Items: spec.Tags}
+	}
+	if spec.Spot {
+		// The Spot VMs can't be live migrated nor restarted, a preempted instance is deleted.
+		instance.Scheduling = &Scheduling{
+			ProvisioningModel:         "SPOT",
+			InstanceTerminationAction: "DELETE",
+			AutomaticRestart:          ptr.To(false),
+			OnHostMaintenance:         "TERMINATE",
+		}
+	}
+	return instance
+}
+
+// imageFor returns the image created from the boot disk of the instance, labeled with the image metadata of the Build.
+func imageFor(gcpBuild *infrav1.GCPBuild, build *buildv1.Build, name string, instance *Instance) *Image {
+	image := &Image{
+		Name:             name,
+		Family:           gcpBuild.Spec.Image.Family,
+		StorageLocations: gcpBuild.Spec.Image.StorageLocations,
+		Labels:           map[string]string{"forge-build": labelValue(build.Name)},
+	}
+	for _, disk := range instance.Disks {
+		if disk.Boot {
+			image.SourceDisk = disk.Source
+		}
+	}
+	for key, value := range build.Status.ImageMetadata {
+		image.Labels[labelKey(key)] = labelValue(value)
+	}
+	return image
+}
+
+// imageName returns the name of the image, the spec.imageName of the Build, or the name of the GCPBuild.
+func imageName(gcpBuild *infrav1.GCPBuild, build *buildv1.Build) string {
+	return providersdk.SanitizeName(cmp.Or(build.Spec.ImageName, gcpBuild.Name), 63)
+}
+
+// instanceAddress returns the external IP of the instance if public, its internal IP otherwise.
+func instanceAddress(instance *Instance, public bool) string {
+	for _, nic := range instance.NetworkInterfaces {
+		if !public {
+			return nic.NetworkIP
+		}
+		for _, access := range nic.AccessConfigs {
+			if access.NatIP != "" {
+				return access.NatIP
+			}
+		}
+	}
+	return ""
+}
+
+// labelKey returns s as a label key: lower case letters, digits, dashes and underscores, starting with a letter.
+func labelKey(s string) string {
+	return providersdk.SanitizeName(strings.ReplaceAll(s, "_", "-"), 63)
+}
+
+// labelValue returns s as a label value: at most 63 lower case letters, digits, dashes and underscores.
+func labelValue(s string) string {
+	value := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, s)
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return value
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeCompute is an in-memory Compute, the operations are done once returned.
+type fakeCompute struct {
+	instances map[string]*Instance
+	images    map[string]*Image
+	builds    map[string]*CloudBuild
+	failOps   bool
+}
+
+func newFakeCompute() *fakeCompute {
+	return &fakeCompute{instances: map[string]*Instance{}, images: map[string]*Image{}, builds: map[string]*CloudBuild{}}
+}
+
+func (f *fakeCompute) GetInstance(_ context.Context, _, _, name string) (*Instance, error) {
+	instance, ok := f.instances[name]
+	if !ok {
+		return nil, &APIError{StatusCode: http.StatusNotFound}
+	}
+	return instance, nil
+}
+
+func (f *fakeCompute) InsertInstance(_ context.Context, _, _ string, instance *Instance) (*Operation, error) {
+	instance.ID = "42"
+	instance.Status = InstanceProvisioning
+	instance.Disks[0].Source = "zones/europe-west1-b/disks/" + instance.Name
+	if !f.failOps {
+		f.instances[instance.Name] = instance
+	}
+	return &Operation{SelfLink: "operations/insert-" + instance.Name}, nil
+}
+
+func (f *fakeCompute) StopInstance(_ context.Context, _, _, name string) (*Operation, error) {
+	f.instances[name].Status = InstanceStopping
+	return &Operation{SelfLink: "operations/stop-" + name}, nil
+}
+
+func (f *fakeCompute) DeleteInstance(_ context.Context, _, _, name string) (*Operation, error) {
+	delete(f.instances, name)
+	return &Operation{SelfLink: "operations/delete-" + name}, nil
+}
+
+func (f *fakeCompute) GetImage(_ context.Context, _, name string) (*Image, error) {
+	image, ok := f.images[name]
+	if !ok {
+		return nil, &APIError{StatusCode: http.StatusNotFound}
+	}
+	return image, nil
+}
+
+func (f *fakeCompute) InsertImage(_ context.Context, _ string, image *Image) (*Operation, error) {
+	image.Status = ImagePending
+	f.images[image.Name] = image
+	return &Operation{SelfLink: "operations/insert-" + image.Name}, nil
+}
+
+func (f *fakeCompute) GetOperation(_ context.Context, selfLink string) (*Operation, error) {
+	op := &Operation{SelfLink: selfLink, Status: OperationDone}
+	if f.failOps {
+		op.Error = &OperationError{}
+		op.Error.Errors = append(op.Error.Errors, struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}{Code: "QUOTA_EXCEEDED", Message: "Quota 'CPUS' exceeded"})
+	}
+	return op, nil
+}
+
+func (f *fakeCompute) ExportImage(_ context.Context, _, _, _ string) (string, error) {
+	f.builds["b1"] = &CloudBuild{ID: "b1", Status: CloudBuildQueued}
+	return "b1", nil
+}
+
+func (f *fakeCompute) GetCloudBuild(_ context.Context, _, id string) (*CloudBuild, error) {
+	return f.builds[id], nil
+}
+
+func TestGCPBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, gcpBuild := setupTest(g)
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gcpBuild)}
+
+	// The instance is created with the generated connector key.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(instanceRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Finalizers).To(ContainElement(infrav1.GCPBuildFinalizer))
+	name := gcpBuild.Status.InstanceName
+	g.Expect(name).To(HavePrefix("ubuntu-"))
+	instance := compute.instances[name]
+	g.Expect(instance.MachineType).To(Equal("zones/europe-west1-b/machineTypes/e2-standard-2"))
+	g.Expect(instance.Disks[0].InitializeParams.SourceImage).To(Equal("projects/ubuntu-os-cloud/global/images/family/ubuntu-2204-lts"))
+	g.Expect(instance.Metadata.Items[0].Value).To(HavePrefix("forge:ssh-rsa "))
+	g.Expect(instance.NetworkInterfaces[0].AccessConfigs).To(HaveLen(1))
+
+	// The address is published once the instance is running.
+	instance.Status = InstanceRunning
+	instance.NetworkInterfaces[0].AccessConfigs[0].NatIP = "34.1.2.3"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(gcpBuild.Status.Address).To(Equal("34.1.2.3"))
+	g.Expect(conditions.IsFalse(gcpBuild, infrav1.ImageReadyCondition)).To(BeTrue())
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("34.1.2.3"))
+
+	// The instance is stopped once the provisioners are ready.
+	build.Status.ProvisionersReady = true
+	build.Status.ImageMetadata = map[string]string{"os_version": "22.04"}
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(instanceRequeueAfter))
+	g.Expect(instance.Status).To(Equal(InstanceStopping))
+
+	// The image is created from the boot disk once the instance is stopped.
+	instance.Status = InstanceTerminated
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(imageRequeueAfter))
+	image := compute.images["ubuntu-22-04"]
+	g.Expect(image.SourceDisk).To(Equal("zones/europe-west1-b/disks/" + name))
+	g.Expect(image.Family).To(Equal("ubuntu"))
+	g.Expect(image.Labels).To(HaveKeyWithValue("os-version", "22-04"))
+
+	// The image is exported once ready.
+	image.Status = ImageReady
+	image.ArchiveSizeBytes = 1 << 30
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Status.ExportBuildID).To(Equal("b1"))
+	g.Expect(gcpBuild.Status.Ready).To(BeFalse())
+
+	compute.builds["b1"].Status = CloudBuildSuccess
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Status.Ready).To(BeTrue())
+	g.Expect(gcpBuild.Status.ImageRef).To(Equal("projects/forge/global/images/ubuntu-22-04"))
+	g.Expect(gcpBuild.Status.Artifact.Format).To(Equal("gce"))
+	g.Expect(*gcpBuild.Status.Artifact.SizeBytes).To(BeEquivalentTo(1 << 30))
+	g.Expect(conditions.IsTrue(gcpBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The instance is deleted with the GCPBuild, the image is kept.
+	g.Expect(c.Delete(ctx, gcpBuild)).To(Succeed())
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(instanceRequeueAfter))
+	g.Expect(compute.instances).To(BeEmpty())
+	g.Expect(compute.images).To(HaveKey("ubuntu-22-04"))
+
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).ToNot(Succeed())
+}
+
+func TestGCPBuildReconcileFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, gcpBuild := setupTest(g)
+	compute := newFakeCompute()
+	compute.failOps = true
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gcpBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The failed operation fails the GCPBuild.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.CreateBuildError)))
+	g.Expect(*gcpBuild.Status.FailureMessage).To(ContainSubstring("Quota 'CPUS' exceeded"))
+	g.Expect(conditions.GetReason(gcpBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+}
+
+func TestGCPBuildReconcilePreempted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, gcpBuild := setupTest(g)
+	gcpBuild.Spec.Spot = true
+	g.Expect(c.Update(ctx, gcpBuild)).To(Succeed())
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gcpBuild)}
+
+	// The instance is created as a Spot VM.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	instance := compute.instances[gcpBuild.Status.InstanceName]
+	g.Expect(instance.Scheduling.ProvisioningModel).To(Equal("SPOT"))
+	g.Expect(instance.Scheduling.InstanceTerminationAction).To(Equal("DELETE"))
+	instance.Status = InstanceRunning
+	instance.NetworkInterfaces[0].AccessConfigs[0].NatIP = "34.1.2.3"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The Spot VM is deleted while the provisioners are running.
+	delete(compute.instances, instance.Name)
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, gcpBuild)).To(Succeed())
+	g.Expect(gcpBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.PreemptedBuildError)))
+	g.Expect(*gcpBuild.Status.FailureMessage).To(ContainSubstring("has been preempted"))
+}
+
+func TestGCPBuildReconcileWithoutOwner(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, gcpBuild := setupTest(g)
+	gcpBuild.OwnerReferences = nil
+	g.Expect(c.Update(ctx, gcpBuild)).To(Succeed())
+	compute := newFakeCompute()
+	r := newReconciler(c, compute)
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gcpBuild)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(compute.instances).To(BeEmpty())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(gcpBuild), gcpBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(gcpBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.WaitingForBuildReason))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.GCPBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "GCPBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	gcpBuild := &infrav1.GCPBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "gcpbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.GCPBuildSpec{
+			Project:            "forge",
+			Zone:               "europe-west1-b",
+			SourceImageFamily:  "ubuntu-2204-lts",
+			SourceImageProject: "ubuntu-os-cloud",
+			CredentialsRef:     &corev1.LocalObjectReference{Name: "gcp"},
+			Image: infrav1.GCPImageSpec{
+				Family:    "ubuntu",
+				ExportURL: "gs://images/ubuntu.tar.gz",
+			},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "gcp"},
+		Data:       map[string][]byte{infrav1.GCPCredentialsKey: []byte(`{}`)},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, gcpBuild, credentials).
+		WithStatusSubresource(build, gcpBuild).
+		Build()
+	return c, build, gcpBuild
+}
+
+func newReconciler(c client.Client, compute Compute) *GCPBuildReconciler {
+	return &GCPBuildReconciler{
+		Client: c,
+		NewCompute: func(_ context.Context, _ []byte) (Compute, error) {
+			return compute, nil
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/retention"
+)
+
+// ImageDeleter deletes the Compute Engine images of the expired Images of GCPBuilds.
+type ImageDeleter struct {
+	// HTTPClient sends the token and API requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// ComputeEndpoint is the endpoint of the Compute Engine API, ComputeEndpoint if empty.
+	ComputeEndpoint string
+}
+
+var _ retention.Deleter = &ImageDeleter{}
+
+// Delete deletes the image of the Image and returns its archive size. The deletion operation is not waited for.
+func (d *ImageDeleter) Delete(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error) {
+	// projects/<project>/global/images/<name>
+	parts := strings.Split(image.Spec.ImageRef, "/")
+	if len(parts) != 5 || parts[0] != "projects" || parts[2] != "global" || parts[3] != "images" {
+		return 0, forgeerrors.ConfigErrorf("invalid image %s, it must be projects/<project>/global/images/<name>", image.Spec.ImageRef)
+	}
+	project, name := parts[1], parts[4]
+
+	key, err := parseServiceAccountKey(secret.Data[infrav1.GCPCredentialsKey])
+	if err != nil {
+		return 0, err
+	}
+	if d.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, d.HTTPClient)
+	}
+	c := &Client{HTTPClient: key.jwtConfig().Client(ctx), ComputeEndpoint: d.ComputeEndpoint}
+	if c.ComputeEndpoint == "" {
+		c.ComputeEndpoint = ComputeEndpoint
+	}
+
+	gceImage, err := c.GetImage(ctx, project, name)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if _, err := c.DeleteImage(ctx, project, name); err != nil && !IsNotFound(err) {
+		return 0, err
+	}
+	return gceImage.ArchiveSizeBytes, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		retryable bool
+		valid     bool
+	}{
+		{name: "valid key", status: http.StatusOK, valid: true},
+		{name: "rejected key", status: http.StatusBadRequest},
+		{name: "token endpoint unavailable", status: http.StatusServiceUnavailable, retryable: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.ParseForm()).To(Succeed())
+				g.Expect(r.Form.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:jwt-bearer"))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				if tc.status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid JWT Signature."}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: map[string][]byte{infrav1.GCPCredentialsKey: serviceAccountKeyJSON(g, server.URL)}}
+			v := &Validator{HTTPClient: server.Client()}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
+
+// serviceAccountKeyJSON returns a service account key with a new private key, requesting its tokens from tokenURL.
+func serviceAccountKeyJSON(g *WithT, tokenURL string) []byte {
+	keyPair, err := ssh.NewKeyPair()
+	g.Expect(err).ToNot(HaveOccurred())
+	data, err := json.Marshal(serviceAccountKey{
+		Type:         "service_account",
+		ProjectID:    "forge",
+		PrivateKeyID: "key",
+		PrivateKey:   string(keyPair.PrivateKey),
+		ClientEmail:  "forge@forge.iam.gserviceaccount.com",
+		TokenURI:     tokenURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	return data
+}
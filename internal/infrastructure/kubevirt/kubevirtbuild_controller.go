@@ -0,0 +1,686 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubevirt implements the KubeVirtBuild infrastructure provider, building qcow2 images in a KubeVirt VM of
+// the cluster. The KubeVirt and CDI objects are handled as unstructured objects, the provider does not depend on
+// their API packages.
+package kubevirt
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter"
+	exporterjob "github.com/forge-build/forge/exporter/job"
+	"github.com/forge-build/forge/exporter/publish"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the KubeVirtBuild controller, used in the controller metrics.
+	ControllerName = "kubevirtbuild"
+
+	// ProviderName is the name of the provider in the --infrastructure-providers flag.
+	ProviderName = "kubevirt"
+
+	// vmRequeueAfter is how often a VM being started or stopped is checked.
+	vmRequeueAfter = 10 * time.Second
+
+	// The names of the objects of a VM are the name of the VM with these suffixes, the longest one must keep them
+	// valid labels.
+	cloudInitSuffix = "-cloudinit"
+	exportSuffix    = "-export"
+	maxVMNameLength = 63 - len(cloudInitSuffix)
+
+	// The disk of the VMs is the disk.img file of the filesystem PVCs of CDI, owned by the qemu user of KubeVirt.
+	diskFile = "disk.img"
+	qemuUID  = 107
+
+	// The mount paths of the disk of the VM and of the destination PVC in the export Job.
+	sourceDir = "/var/lib/forge-exporter/source"
+	outputDir = "/var/lib/forge-exporter/output"
+
+	// The hardware of the VMs when not set.
+	defaultCPUCores = 2
+)
+
+var (
+	// defaultMemory is the memory of the VMs when not set.
+	defaultMemory = resource.MustParse("4Gi")
+
+	// VirtualMachineGVK is the kind of the KubeVirt VMs.
+	VirtualMachineGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachine"}
+
+	// VirtualMachineInstanceGVK is the kind of the running instances of the KubeVirt VMs.
+	VirtualMachineInstanceGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstance"}
+)
+
+// KubeVirtBuildReconciler reconciles a KubeVirtBuild object
+type KubeVirtBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// ExporterImage is the image of the Jobs exporting the disks, exporterjob.DefaultImage if empty.
+	ExporterImage string
+
+	// MultiTenancy restricts the source PVCs to the namespace of the KubeVirtBuild, the VMs are created with the
+	// identity of the controller, which may clone the PVCs of any namespace.
+	MultiTenancy bool
+
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachines,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachineinstances,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KubeVirtBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	vm := &unstructured.Unstructured{}
+	vm.SetGroupVersionKind(VirtualMachineGVK)
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.KubeVirtBuild{}).
+		Owns(vm).
+		Owns(&batchv1.Job{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("KubeVirtBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("kubevirtbuild-controller")
+	return nil
+}
+
+// Reconcile creates the VM of a KubeVirtBuild, publishes its address in the connector credentials of the Build and,
+// once the provisioners of the Build are ready, stops it and exports its disk as a qcow2 image.
+func (r *KubeVirtBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	kubevirtBuild := &infrav1.KubeVirtBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, kubevirtBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(kubevirtBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(kubevirtBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(kubevirtBuild)
+		if err := patchHelper.Patch(ctx, kubevirtBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, kubevirtBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !kubevirtBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, kubevirtBuild)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the KubeVirtBuild")
+		return ctrl.Result{}, nil
+	}
+	if kubevirtBuild.Status.FailureReason != nil || kubevirtBuild.Status.Ready {
+		// The VM of a failed or completed KubeVirtBuild is deleted along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(kubevirtBuild, infrav1.KubeVirtBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, kubevirtBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, kubevirtBuild, res, err)
+}
+
+func (r *KubeVirtBuildReconciler) reconcileNormal(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build) (ctrl.Result, error) {
+	if pvc := kubevirtBuild.Spec.Source.PVC; r.MultiTenancy && pvc != nil && pvc.Namespace != "" && pvc.Namespace != kubevirtBuild.Namespace {
+		return ctrl.Result{}, forgeerrors.ConfigErrorf("source PVC %s/%s is not in namespace %s, which is not allowed in multi-tenancy mode",
+			pvc.Namespace, pvc.Name, kubevirtBuild.Namespace)
+	}
+
+	vm, err := r.reconcileVM(ctx, kubevirtBuild, build)
+	if err != nil || vm == nil {
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileExport(ctx, kubevirtBuild, build, vm)
+}
+
+// reconcileVM creates the VM with its cloud-init secret and publishes its address once KubeVirt reports it. It
+// returns nil while the VM is being started.
+func (r *KubeVirtBuildReconciler) reconcileVM(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build) (*unstructured.Unstructured, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &kubevirtBuild.Status
+
+	name := cmp.Or(status.VMName, vmNameFor(kubevirtBuild))
+	vm := &unstructured.Unstructured{}
+	vm.SetGroupVersionKind(VirtualMachineGVK)
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: name}, vm)
+	if apierrors.IsNotFound(err) {
+		if status.VMName != "" {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VirtualMachine %s has been deleted", name)
+		}
+		if err := r.createVM(ctx, kubevirtBuild, build, name); err != nil {
+			return nil, err
+		}
+		log.Info("Created VirtualMachine", "vm", name)
+		r.recorder.Eventf(kubevirtBuild, corev1.EventTypeNormal, "VMCreated", "Created VirtualMachine %s", name)
+		status.VMName = name
+		conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Created VirtualMachine %s", name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VirtualMachine %s", name)
+	}
+	status.VMName = name
+
+	printableStatus, _, _ := unstructured.NestedString(vm.Object, "status", "printableStatus")
+	if status.MachineReady {
+		if printableStatus != "Running" && !build.Status.ProvisionersReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VirtualMachine %s is %s while the provisioners are running", name, printableStatus)
+		}
+		return vm, nil
+	}
+
+	vmi := &unstructured.Unstructured{}
+	vmi.SetGroupVersionKind(VirtualMachineInstanceGVK)
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: name}, vmi)
+	if apierrors.IsNotFound(err) {
+		// The disk is imported by CDI before the instance is created.
+		conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "VirtualMachine %s is %s", name, cmp.Or(printableStatus, "Pending"))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VirtualMachineInstance %s", name)
+	}
+
+	switch phase, _, _ := unstructured.NestedString(vmi.Object, "status", "phase"); phase {
+	case "Running":
+	case "Failed", "Succeeded":
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VirtualMachineInstance %s is %s", name, phase)
+	default:
+		conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "VirtualMachine %s is %s", name, cmp.Or(printableStatus, phase, "Pending"))
+		return nil, nil
+	}
+
+	address := instanceAddress(vmi)
+	if address == "" {
+		conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Waiting for VirtualMachineInstance %s to report its IP", name)
+		return nil, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "VirtualMachine %s is running at %s", name, address)
+	r.recorder.Eventf(kubevirtBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "VirtualMachine %s is running at %s", name, address)
+	return vm, nil
+}
+
+// createVM creates the cloud-init secret authorizing the connector credentials and the VM, both owned by the
+// KubeVirtBuild.
+func (r *KubeVirtBuildReconciler) createVM(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build, name string) error {
+	creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(kubevirtBuild.Spec.Username, infrav1.DefaultKubeVirtUsername))
+	if err != nil {
+		return err
+	}
+	userdata, err := providersdk.CloudConfig(creds)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: kubevirtBuild.Namespace,
+			Name:      name + cloudInitSuffix,
+			Labels:    map[string]string{buildv1.BuildNameLabel: build.Name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"userdata": []byte(userdata)},
+	}
+	if err := controllerutil.SetControllerReference(kubevirtBuild, secret, r.Client.Scheme()); err != nil {
+		return err
+	}
+	if err := r.Client.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create the cloud-init secret %s", secret.Name)
+	}
+
+	vm := vmFor(kubevirtBuild, build, name)
+	if err := controllerutil.SetControllerReference(kubevirtBuild, vm, r.Client.Scheme()); err != nil {
+		return err
+	}
+	if err := r.Client.Create(ctx, vm); err != nil && !apierrors.IsAlreadyExists(err) {
+		if apierrors.IsInvalid(err) {
+			// The spec was rejected by the admission of KubeVirt.
+			return forgeerrors.NewConfigError(err)
+		}
+		return errors.Wrapf(err, "failed to create VirtualMachine %s", name)
+	}
+	return nil
+}
+
+// reconcileExport stops the VM and exports its disk with a Job of the exporter.
+func (r *KubeVirtBuildReconciler) reconcileExport(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build, vm *unstructured.Unstructured) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &kubevirtBuild.Status
+	name := status.VMName
+
+	if runStrategy, _, _ := unstructured.NestedString(vm.Object, "spec", "runStrategy"); runStrategy != "Halted" {
+		patch := client.MergeFrom(vm.DeepCopy())
+		if err := unstructured.SetNestedField(vm.Object, "Halted", "spec", "runStrategy"); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Patch(ctx, vm, patch); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to stop VirtualMachine %s", name)
+		}
+		log.Info("Stopping VirtualMachine", "vm", name)
+		r.recorder.Eventf(kubevirtBuild, corev1.EventTypeNormal, infrav1.MachineStoppingReason, "Stopping VirtualMachine %s", name)
+		conditions.MarkFalse(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Stopping VirtualMachine %s", name)
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+	}
+	vmi := &unstructured.Unstructured{}
+	vmi.SetGroupVersionKind(VirtualMachineInstanceGVK)
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: name}, vmi)
+	if err == nil {
+		// The disk is mounted by the launcher Pod of the instance until it is gone.
+		conditions.MarkFalse(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Stopping VirtualMachine %s", name)
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get VirtualMachineInstance %s", name)
+	}
+
+	if pvc := kubevirtBuild.Spec.Export.PVC; pvc != nil {
+		if err := r.ensureOutputPVC(ctx, kubevirtBuild, pvc); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	job := &batchv1.Job{}
+	jobName := cmp.Or(status.ExportJobName, name+exportSuffix)
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: jobName}, job)
+	if apierrors.IsNotFound(err) {
+		job = r.exportJobFor(kubevirtBuild, build, jobName)
+		if err := controllerutil.SetControllerReference(kubevirtBuild, job, r.Client.Scheme()); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, job); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to create the export Job %s", jobName)
+		}
+		log.Info("Exporting the disk of VirtualMachine", "vm", name, "job", jobName)
+		r.recorder.Eventf(kubevirtBuild, corev1.EventTypeNormal, infrav1.ImageExportingReason, "Exporting the disk of VirtualMachine %s with Job %s", name, jobName)
+		status.ExportJobName = jobName
+		conditions.MarkFalse(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.ImageExportingReason, "Exporting the disk of VirtualMachine %s", name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get the export Job %s", jobName)
+	}
+	status.ExportJobName = jobName
+
+	if failed := jobCondition(job, batchv1.JobFailed); failed != nil {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError,
+			"the export Job %s failed: %s", jobName, cmp.Or(failed.Message, failed.Reason))
+	}
+	complete := jobCondition(job, batchv1.JobComplete)
+	if complete == nil {
+		conditions.MarkFalse(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.ImageExportingReason, "Exporting the disk of VirtualMachine %s", name)
+		return ctrl.Result{}, nil
+	}
+
+	location := imageLocation(kubevirtBuild, build)
+	status.ImageRef = location
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       location,
+		Location: location,
+		Format:   "qcow2",
+	}
+	if job.Status.CompletionTime != nil {
+		status.Artifact.CreatedAt = job.Status.CompletionTime.DeepCopy()
+	}
+	status.Ready = true
+	conditions.MarkTrue(kubevirtBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Image %s is ready", location)
+	r.recorder.Eventf(kubevirtBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Image %s is ready", location)
+	return ctrl.Result{}, nil
+}
+
+// ensureOutputPVC creates the PVC the image is written to when it does not exist. It is not owned by the
+// KubeVirtBuild, the image outlives it.
+func (r *KubeVirtBuildReconciler) ensureOutputPVC(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild, export *infrav1.KubeVirtPVCExport) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: export.Name}, pvc)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get PVC %s", export.Name)
+	}
+	pvc = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtBuild.Namespace, Name: export.Name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: export.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: *cmp.Or(export.Size, &kubevirtBuild.Spec.DiskSize)},
+			},
+		},
+	}
+	if err := r.Client.Create(ctx, pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create PVC %s", export.Name)
+	}
+	ctrl.LoggerFrom(ctx).Info("Created PVC", "pvc", export.Name)
+	return nil
+}
+
+// exportJobFor returns the Job converting the disk of the VM to qcow2 into the destination PVC, or uploading it to
+// the object storage.
+func (r *KubeVirtBuildReconciler) exportJobFor(kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build, name string) *batchv1.Job {
+	export := &kubevirtBuild.Spec.Export
+	labels := map[string]string{
+		buildv1.ManagedByLabel: exporter.ForgeExporterName,
+		buildv1.BuildNameLabel: build.Name,
+	}
+	args := []string{
+		"--name", imageName(kubevirtBuild, build),
+		"--build-name", build.Name,
+		"--format", string(buildv1.ExportFormatQCOW2),
+		"--source", path.Join(sourceDir, diskFile),
+		"--source-format", "raw",
+	}
+	mounts := []corev1.VolumeMount{
+		{Name: "source", MountPath: sourceDir, ReadOnly: true},
+		{Name: "work", MountPath: exporter.WorkDir},
+	}
+	volumes := []corev1.Volume{
+		{Name: "source", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: kubevirtBuild.Status.VMName, ReadOnly: true,
+		}}},
+		{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	if pvc := export.PVC; pvc != nil {
+		args = append(args, "--output-dir", outputDir)
+		mounts = append(mounts, corev1.VolumeMount{Name: "output", MountPath: outputDir})
+		volumes = append(volumes, corev1.Volume{Name: "output", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+		}})
+	}
+	if storage := export.ObjectStorage; storage != nil {
+		args = append(args, "--object-storage-url", storage.URL)
+		if storage.Endpoint != "" {
+			args = append(args, "--object-storage-endpoint", storage.Endpoint)
+		}
+		if storage.Region != "" {
+			args = append(args, "--object-storage-region", storage.Region)
+		}
+		mounts = append(mounts, corev1.VolumeMount{Name: "credentials", MountPath: exporter.CredentialsDir, ReadOnly: true})
+		volumes = append(volumes, corev1.Volume{Name: "credentials", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: storage.CredentialsRef.Name},
+		}})
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: kubevirtBuild.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(2)),
+			Completions:           ptr.To(int32(1)),
+			ActiveDeadlineSeconds: shelljob.DurationSecondsPtr(exporterjob.DefaultTimeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Affinity:      shelljob.LinuxNodeAffinity(),
+					RestartPolicy: corev1.RestartPolicyNever,
+					// The disk is only readable by the qemu user, the image is written as the same user.
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  ptr.To(int64(qemuUID)),
+						RunAsGroup: ptr.To(int64(qemuUID)),
+						FSGroup:    ptr.To(int64(qemuUID)),
+					},
+					Containers: []corev1.Container{{
+						Name:                     exporterjob.ContainerName,
+						Image:                    cmp.Or(r.ExporterImage, exporterjob.DefaultImage),
+						ImagePullPolicy:          corev1.PullIfNotPresent,
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						Args:                     args,
+						VolumeMounts:             mounts,
+					}},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// reconcileDelete deletes the VM, along with its disk, and waits for it to be gone. The image is kept.
+func (r *KubeVirtBuildReconciler) reconcileDelete(ctx context.Context, kubevirtBuild *infrav1.KubeVirtBuild) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(kubevirtBuild, infrav1.KubeVirtBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(kubevirtBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	if name := kubevirtBuild.Status.VMName; name != "" {
+		vm := &unstructured.Unstructured{}
+		vm.SetGroupVersionKind(VirtualMachineGVK)
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: kubevirtBuild.Namespace, Name: name}, vm)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get VirtualMachine %s", name)
+		default:
+			if vm.GetDeletionTimestamp().IsZero() {
+				if err := r.Client.Delete(ctx, vm, client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, errors.Wrapf(err, "failed to delete VirtualMachine %s", name)
+				}
+				log.Info("Deleting VirtualMachine", "vm", name)
+			}
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(kubevirtBuild, infrav1.KubeVirtBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// vmFor returns the VM of the KubeVirtBuild, booting from a DataVolume imported by CDI from the source with the
+// cloud-init secret.
+func vmFor(kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build, name string) *unstructured.Unstructured {
+	spec := &kubevirtBuild.Spec
+
+	source := map[string]interface{}{}
+	switch {
+	case spec.Source.HTTP != "":
+		source["http"] = map[string]interface{}{"url": spec.Source.HTTP}
+	case spec.Source.Registry != "":
+		source["registry"] = map[string]interface{}{"url": spec.Source.Registry}
+	case spec.Source.PVC != nil:
+		source["pvc"] = map[string]interface{}{
+			"namespace": cmp.Or(spec.Source.PVC.Namespace, kubevirtBuild.Namespace),
+			"name":      spec.Source.PVC.Name,
+		}
+	}
+	storage := map[string]interface{}{
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"storage": spec.DiskSize.String()},
+		},
+	}
+	if spec.StorageClassName != nil {
+		storage["storageClassName"] = *spec.StorageClassName
+	}
+
+	labels := map[string]interface{}{buildv1.BuildNameLabel: build.Name}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": VirtualMachineGVK.GroupVersion().String(),
+		"kind":       VirtualMachineGVK.Kind,
+		"metadata": map[string]interface{}{
+			"namespace": kubevirtBuild.Namespace,
+			"name":      name,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"runStrategy": "Always",
+			"dataVolumeTemplates": []interface{}{map[string]interface{}{
+				"metadata": map[string]interface{}{"name": name},
+				"spec": map[string]interface{}{
+					"source":  source,
+					"storage": storage,
+				},
+			}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+				"spec": map[string]interface{}{
+					"domain": map[string]interface{}{
+						"cpu": map[string]interface{}{"cores": int64(cmp.Or(spec.CPUCores, defaultCPUCores))},
+						"resources": map[string]interface{}{
+							"requests": map[string]interface{}{"memory": cmp.Or(spec.Memory, &defaultMemory).String()},
+						},
+						"devices": map[string]interface{}{
+							"disks": []interface{}{
+								map[string]interface{}{"name": "root", "disk": map[string]interface{}{"bus": "virtio"}},
+								map[string]interface{}{"name": "cloudinit", "disk": map[string]interface{}{"bus": "virtio"}},
+							},
+							"interfaces": []interface{}{
+								map[string]interface{}{"name": "default", "masquerade": map[string]interface{}{}},
+							},
+						},
+					},
+					"networks": []interface{}{
+						map[string]interface{}{"name": "default", "pod": map[string]interface{}{}},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "root", "dataVolume": map[string]interface{}{"name": name}},
+						map[string]interface{}{"name": "cloudinit", "cloudInitNoCloud": map[string]interface{}{
+							"secretRef": map[string]interface{}{"name": name + cloudInitSuffix},
+						}},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// instanceAddress returns the IP of the first interface of the VirtualMachineInstance, empty until reported.
+func instanceAddress(vmi *unstructured.Unstructured) string {
+	interfaces, _, _ := unstructured.NestedSlice(vmi.Object, "status", "interfaces")
+	if len(interfaces) == 0 {
+		return ""
+	}
+	iface, ok := interfaces[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	address, _, _ := unstructured.NestedString(iface, "ipAddress")
+	return address
+}
+
+// jobCondition returns the condition of the given type of the Job if true, nil otherwise.
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		if c := &job.Status.Conditions[i]; c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return c
+		}
+	}
+	return nil
+}
+
+// imageLocation returns where the image is exported to: a pvc://<namespace>/<pvc>/<file> or s3:// URL.
+func imageLocation(kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build) string {
+	export := &kubevirtBuild.Spec.Export
+	if pvc := export.PVC; pvc != nil {
+		file := path.Base(exporter.ArtifactKey("", build.Name, imageName(kubevirtBuild, build), buildv1.ExportFormatQCOW2))
+		return fmt.Sprintf("pvc://%s/%s/%s", kubevirtBuild.Namespace, pvc.Name, file)
+	}
+	// The URL is validated by the CRD.
+	bucket, prefix, _ := publish.ParseURL(export.ObjectStorage.URL)
+	return "s3://" + bucket + "/" + exporter.ArtifactKey(prefix, build.Name, imageName(kubevirtBuild, build), buildv1.ExportFormatQCOW2)
+}
+
+// imageName returns the name of the image, the spec.imageName of the Build, or the name of the KubeVirtBuild.
+func imageName(kubevirtBuild *infrav1.KubeVirtBuild, build *buildv1.Build) string {
+	return cmp.Or(build.Spec.ImageName, kubevirtBuild.Name)
+}
+
+// vmNameFor returns the name of the VM, the machine name of the KubeVirtBuild shortened to keep the names of its
+// objects valid labels.
+func vmNameFor(kubevirtBuild *infrav1.KubeVirtBuild) string {
+	name := providersdk.MachineName(kubevirtBuild)
+	if len(name) <= maxVMNameLength {
+		return name
+	}
+	// Keep the hash suffix of the machine name, unique to the KubeVirtBuild.
+	suffix := name[strings.LastIndex(name, "-"):]
+	return strings.TrimRight(name[:maxVMNameLength-len(suffix)], "-") + suffix
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestKubeVirtBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, kubevirtBuild := setupTest(g)
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kubevirtBuild)}
+
+	// The VM is created with its cloud-init secret, importing its disk from the source.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Finalizers).To(ContainElement(infrav1.KubeVirtBuildFinalizer))
+	name := providersdk.MachineName(kubevirtBuild)
+	g.Expect(kubevirtBuild.Status.VMName).To(Equal(name))
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name + cloudInitSuffix}, secret)).To(Succeed())
+	g.Expect(string(secret.Data["userdata"])).To(HavePrefix("#cloud-config\n"))
+	g.Expect(string(secret.Data["userdata"])).To(ContainSubstring("name: " + infrav1.DefaultKubeVirtUsername))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+
+	vm := getVM(ctx, g, c, name)
+	g.Expect(vm.GetOwnerReferences()).To(HaveLen(1))
+	g.Expect(nestedString(vm.Object, "spec", "runStrategy")).To(Equal("Always"))
+	templates, _, _ := unstructured.NestedSlice(vm.Object, "spec", "dataVolumeTemplates")
+	g.Expect(templates).To(HaveLen(1))
+	g.Expect(nestedString(templates[0].(map[string]interface{}), "spec", "source", "http", "url")).
+		To(Equal("https://cloud-images.ubuntu.com/jammy/current/jammy-server-cloudimg-amd64.img"))
+	g.Expect(nestedString(templates[0].(map[string]interface{}), "spec", "storage", "resources", "requests", "storage")).
+		To(Equal("20Gi"))
+	cores, _, _ := unstructured.NestedInt64(vm.Object, "spec", "template", "spec", "domain", "cpu", "cores")
+	g.Expect(cores).To(BeEquivalentTo(defaultCPUCores))
+	g.Expect(nestedString(vm.Object, "spec", "template", "spec", "domain", "resources", "requests", "memory")).To(Equal("4Gi"))
+
+	// The address is published once the instance reports it.
+	vmi := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": metav1.NamespaceDefault, "name": name},
+		"status":   map[string]interface{}{"phase": "Scheduling"},
+	}}
+	vmi.SetGroupVersionKind(VirtualMachineInstanceGVK)
+	g.Expect(c.Create(ctx, vmi)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.MachineReady).To(BeFalse())
+
+	g.Expect(unstructured.SetNestedField(vmi.Object, "Running", "status", "phase")).To(Succeed())
+	g.Expect(unstructured.SetNestedSlice(vmi.Object, []interface{}{
+		map[string]interface{}{"name": "default", "ipAddress": "10.244.1.17"},
+	}, "status", "interfaces")).To(Succeed())
+	g.Expect(c.Update(ctx, vmi)).To(Succeed())
+	g.Expect(unstructured.SetNestedField(vm.Object, "Running", "status", "printableStatus")).To(Succeed())
+	g.Expect(c.Update(ctx, vm)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(kubevirtBuild.Status.Address).To(Equal("10.244.1.17"))
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("10.244.1.17"))
+
+	// The VM is halted once the provisioners are ready, the export waits for the instance to be gone.
+	build.Status.ProvisionersReady = true
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	for range 2 {
+		res, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	}
+	vm = getVM(ctx, g, c, name)
+	g.Expect(nestedString(vm.Object, "spec", "runStrategy")).To(Equal("Halted"))
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(kubevirtBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.MachineStoppingReason))
+
+	// The disk is exported into the destination PVC, created with the size of the disk.
+	g.Expect(c.Delete(ctx, vmi)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	pvc := &corev1.PersistentVolumeClaim{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "images"}, pvc)).To(Succeed())
+	g.Expect(pvc.OwnerReferences).To(BeEmpty())
+	g.Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("20Gi"))
+	job := &batchv1.Job{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name + exportSuffix}, job)).To(Succeed())
+	g.Expect(job.OwnerReferences).To(HaveLen(1))
+	pod := job.Spec.Template.Spec
+	g.Expect(pod.Containers[0].Image).To(Equal("exporter:test"))
+	g.Expect(strings.Join(pod.Containers[0].Args, " ")).To(Equal("--name ubuntu-22.04 --build-name ubuntu --format QCOW2 " +
+		"--source /var/lib/forge-exporter/source/disk.img --source-format raw --output-dir /var/lib/forge-exporter/output"))
+	g.Expect(pod.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal(name))
+	g.Expect(pod.Volumes[2].PersistentVolumeClaim.ClaimName).To(Equal("images"))
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.ExportJobName).To(Equal(name + exportSuffix))
+	g.Expect(conditions.GetReason(kubevirtBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.ImageExportingReason))
+
+	// The image is ready once the Job completes.
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	job.Status.CompletionTime = ptr.To(metav1.Now())
+	g.Expect(c.Status().Update(ctx, job)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.Ready).To(BeTrue())
+	g.Expect(kubevirtBuild.Status.ImageRef).To(Equal("pvc://default/images/ubuntu-22.04.qcow2"))
+	g.Expect(kubevirtBuild.Status.Artifact.ID).To(Equal(kubevirtBuild.Status.ImageRef))
+	g.Expect(kubevirtBuild.Status.Artifact.Format).To(Equal("qcow2"))
+	g.Expect(kubevirtBuild.Status.Artifact.CreatedAt).ToNot(BeNil())
+	g.Expect(conditions.IsTrue(kubevirtBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The VM is deleted with the KubeVirtBuild, the image is kept.
+	g.Expect(c.Delete(ctx, kubevirtBuild)).To(Succeed())
+	for range 2 {
+		_, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(vm), vm)).ToNot(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)).To(Succeed())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).ToNot(Succeed())
+}
+
+func TestExportJobForObjectStorage(t *testing.T) {
+	g := NewWithT(t)
+
+	c, build, kubevirtBuild := setupTest(g)
+	kubevirtBuild.Spec.Export = infrav1.KubeVirtExportSpec{ObjectStorage: &buildv1.ObjectStorageDestination{
+		URL:            "s3://images/kubevirt",
+		Endpoint:       "https://minio.example.com",
+		CredentialsRef: corev1.LocalObjectReference{Name: "minio"},
+	}}
+	kubevirtBuild.Status.VMName = "ubuntu-vm"
+
+	job := newReconciler(c).exportJobFor(kubevirtBuild, build, "ubuntu-vm-export")
+	pod := job.Spec.Template.Spec
+	g.Expect(strings.Join(pod.Containers[0].Args, " ")).To(HaveSuffix(
+		"--object-storage-url s3://images/kubevirt --object-storage-endpoint https://minio.example.com"))
+	g.Expect(pod.Volumes[2].Secret.SecretName).To(Equal("minio"))
+	g.Expect(imageLocation(kubevirtBuild, build)).To(Equal("s3://images/kubevirt/ubuntu/ubuntu-22.04.qcow2"))
+}
+
+func TestKubeVirtBuildReconcileFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, kubevirtBuild := setupTest(g)
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kubevirtBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	name := kubevirtBuild.Status.VMName
+
+	// A failed instance fails the KubeVirtBuild.
+	vmi := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": metav1.NamespaceDefault, "name": name},
+		"status":   map[string]interface{}{"phase": "Failed"},
+	}}
+	vmi.SetGroupVersionKind(VirtualMachineInstanceGVK)
+	g.Expect(c.Create(ctx, vmi)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.CreateBuildError)))
+	g.Expect(conditions.GetReason(kubevirtBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+}
+
+func TestKubeVirtBuildReconcileMultiTenancy(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, kubevirtBuild := setupTest(g)
+	kubevirtBuild.Spec.Source = infrav1.KubeVirtDiskSource{PVC: &infrav1.KubeVirtPVCSource{Namespace: "golden-images", Name: "ubuntu"}}
+	g.Expect(c.Update(ctx, kubevirtBuild)).To(Succeed())
+	r := newReconciler(c)
+	r.MultiTenancy = true
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kubevirtBuild)}
+
+	// The PVCs of other namespaces cannot be cloned.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, kubevirtBuild)).To(Succeed())
+	g.Expect(kubevirtBuild.Status.FailureReason).ToNot(BeNil())
+	g.Expect(kubevirtBuild.Status.VMName).To(BeEmpty())
+}
+
+func TestVMNameFor(t *testing.T) {
+	g := NewWithT(t)
+
+	kubevirtBuild := &infrav1.KubeVirtBuild{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("ubuntu-", 10), UID: "uid"}}
+	name := vmNameFor(kubevirtBuild)
+	g.Expect(len(name)).To(BeNumerically("<=", maxVMNameLength))
+	machineName := providersdk.MachineName(kubevirtBuild)
+	g.Expect(name).To(HaveSuffix(machineName[strings.LastIndex(machineName, "-"):]))
+
+	kubevirtBuild.Name = "ubuntu"
+	g.Expect(vmNameFor(kubevirtBuild)).To(Equal(providersdk.MachineName(kubevirtBuild)))
+}
+
+func nestedString(obj map[string]interface{}, fields ...string) string {
+	value, _, _ := unstructured.NestedString(obj, fields...)
+	return value
+}
+
+func getVM(ctx context.Context, g *WithT, c client.Client, name string) *unstructured.Unstructured {
+	vm := &unstructured.Unstructured{}
+	vm.SetGroupVersionKind(VirtualMachineGVK)
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: name}, vm)).To(Succeed())
+	return vm
+}
+
+func newReconciler(c client.Client) *KubeVirtBuildReconciler {
+	return &KubeVirtBuildReconciler{
+		Client:        c,
+		ExporterImage: "exporter:test",
+		recorder:      record.NewFakeRecorder(100),
+	}
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.KubeVirtBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(batchv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	// The KubeVirt objects are only known as unstructured objects.
+	scheme.AddKnownTypeWithName(VirtualMachineGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(VirtualMachineInstanceGVK, &unstructured.Unstructured{})
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "KubeVirtBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	kubevirtBuild := &infrav1.KubeVirtBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "kubevirtbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.KubeVirtBuildSpec{
+			Source:   infrav1.KubeVirtDiskSource{HTTP: "https://cloud-images.ubuntu.com/jammy/current/jammy-server-cloudimg-amd64.img"},
+			DiskSize: resource.MustParse("20Gi"),
+			Export:   infrav1.KubeVirtExportSpec{PVC: &infrav1.KubeVirtPVCExport{Name: "images"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, kubevirtBuild).
+		WithStatusSubresource(build, kubevirtBuild, &batchv1.Job{}).
+		Build()
+	return c, build, kubevirtBuild
+}
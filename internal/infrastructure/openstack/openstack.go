@@ -0,0 +1,693 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openstack implements the OpenStackBuild infrastructure provider: the build machine is a Nova instance,
+// reachable through a floating IP or on a provider network, which is stopped and snapshotted into a Glance image
+// once the provisioners of the Build are ready.
+package openstack
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// tokenHeader is the header of the requests holding the token, and of the response of the Identity service
+	// issuing it.
+	tokenHeader = "X-Auth-Token"
+
+	// subjectTokenHeader is the header of the response of the Identity service holding the issued token.
+	subjectTokenHeader = "X-Subject-Token"
+
+	// computeMicroversion is the microversion of the Compute API requested: the network of the instances can be
+	// allocated automatically, and createImage returns the ID of the image.
+	computeMicroversion = "2.47"
+)
+
+// The statuses of the instances.
+const (
+	ServerActive  = "ACTIVE"
+	ServerBuild   = "BUILD"
+	ServerShutoff = "SHUTOFF"
+	ServerError   = "ERROR"
+)
+
+// The statuses of the images.
+const (
+	ImageQueued    = "queued"
+	ImageSaving    = "saving"
+	ImageImporting = "importing"
+	ImageActive    = "active"
+	ImageKilled    = "killed"
+	ImageDeleted   = "deleted"
+)
+
+// Service is a service of the catalog of the Identity service.
+type Service string
+
+const (
+	ServiceCompute Service = "compute"
+	ServiceImage   Service = "image"
+	ServiceNetwork Service = "network"
+)
+
+// OpenStack is the part of the OpenStack APIs used by the controller: Nova, Glance and Neutron.
+type OpenStack interface {
+	FindImage(ctx context.Context, nameOrID string) (*Image, error)
+	ListImages(ctx context.Context, properties map[string]string) ([]Image, error)
+	GetImage(ctx context.Context, id string) (*Image, error)
+
+	FindFlavor(ctx context.Context, nameOrID string) (string, error)
+	FindNetwork(ctx context.Context, nameOrID string) (string, error)
+
+	FindServer(ctx context.Context, name string) (*Server, error)
+	GetServer(ctx context.Context, id string) (*Server, error)
+	CreateServer(ctx context.Context, spec *ServerSpec) (string, error)
+	StopServer(ctx context.Context, id string) error
+	DeleteServer(ctx context.Context, id string) error
+	CreateServerImage(ctx context.Context, id, name string, metadata map[string]string) (string, error)
+
+	ServerPort(ctx context.Context, server string) (string, error)
+	CreateFloatingIP(ctx context.Context, network, port, description string) (*FloatingIP, error)
+	GetFloatingIP(ctx context.Context, id string) (*FloatingIP, error)
+	DeleteFloatingIP(ctx context.Context, id string) error
+}
+
+// Server is a Nova instance.
+type Server struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Addresses are the addresses of the instance by network name.
+	Addresses map[string][]Address `json:"addresses,omitempty"`
+	Metadata  map[string]string    `json:"metadata,omitempty"`
+	Fault     *struct {
+		Message string `json:"message"`
+	} `json:"fault,omitempty"`
+}
+
+// Address is an address of an instance.
+type Address struct {
+	Addr    string `json:"addr"`
+	Version int    `json:"version"`
+	// Type is fixed or floating.
+	Type string `json:"OS-EXT-IPS:type,omitempty"`
+}
+
+// FixedIPv4 returns the first fixed IPv4 address of the instance, empty if it has none yet.
+func (s *Server) FixedIPv4() string {
+	for _, addresses := range s.Addresses {
+		for _, address := range addresses {
+			if address.Version == 4 && address.Type != "floating" {
+				return address.Addr
+			}
+		}
+	}
+	return ""
+}
+
+// ServerSpec is a new Nova instance.
+type ServerSpec struct {
+	Name   string
+	Image  string
+	Flavor string
+	// Network is the ID of the network of the instance, a network is allocated when empty.
+	Network          string
+	SecurityGroups   []string
+	AvailabilityZone string
+	UserData         string
+	Metadata         map[string]string
+}
+
+// Image is a Glance image.
+type Image struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DiskFormat string `json:"disk_format,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	HashAlgo   string `json:"os_hash_algo,omitempty"`
+	HashValue  string `json:"os_hash_value,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	// Properties are the additional properties of the image, e.g. os_distro.
+	Properties map[string]string `json:"-"`
+}
+
+func (i *Image) UnmarshalJSON(data []byte) error {
+	type image Image
+	if err := json.Unmarshal(data, (*image)(i)); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	i.Properties = map[string]string{}
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			i.Properties[key] = s
+		}
+	}
+	return nil
+}
+
+// FloatingIP is a Neutron floating IP.
+type FloatingIP struct {
+	ID                string `json:"id"`
+	FloatingIPAddress string `json:"floating_ip_address"`
+	PortID            string `json:"port_id,omitempty"`
+	Status            string `json:"status,omitempty"`
+}
+
+// APIError is an error returned by an OpenStack API.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the API.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// notFound returns the not found error of an object missing from the results of a lookup.
+func notFound(format string, args ...interface{}) error {
+	return &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Credentials are the Identity service and the user or the application credential the controller authenticates
+// as.
+type Credentials struct {
+	AuthURL    string
+	Region     string
+	Username   string
+	Password   string
+	DomainName string
+	// ProjectName or ProjectID is the project the user is scoped to.
+	ProjectName string
+	ProjectID   string
+	// ApplicationCredentialID and ApplicationCredentialSecret authenticate instead of the user.
+	ApplicationCredentialID     string
+	ApplicationCredentialSecret string
+	// CACert are the PEM encoded CA certificates of the endpoints, the system ones are used if empty.
+	CACert []byte
+}
+
+// credentialsFrom returns the credentials of the data of a credentials secret.
+func credentialsFrom(data map[string][]byte) (*Credentials, error) {
+	creds := &Credentials{
+		AuthURL:                     string(data[infrav1.OpenStackAuthURLKey]),
+		Region:                      string(data[infrav1.OpenStackRegionKey]),
+		Username:                    string(data[infrav1.OpenStackUsernameKey]),
+		Password:                    string(data[infrav1.OpenStackPasswordKey]),
+		DomainName:                  cmp.Or(string(data[infrav1.OpenStackDomainNameKey]), "Default"),
+		ProjectName:                 string(data[infrav1.OpenStackProjectNameKey]),
+		ProjectID:                   string(data[infrav1.OpenStackProjectIDKey]),
+		ApplicationCredentialID:     string(data[infrav1.OpenStackApplicationCredentialIDKey]),
+		ApplicationCredentialSecret: string(data[infrav1.OpenStackApplicationCredentialSecretKey]),
+		CACert:                      data[infrav1.OpenStackCACertKey],
+	}
+	switch {
+	case creds.AuthURL == "":
+		return nil, forgeerrors.ConfigErrorf("the secret must have the %s key", infrav1.OpenStackAuthURLKey)
+	case creds.ApplicationCredentialID != "":
+		if creds.ApplicationCredentialSecret == "" {
+			return nil, forgeerrors.ConfigErrorf("the secret must have the %s key along with the %s key",
+				infrav1.OpenStackApplicationCredentialSecretKey, infrav1.OpenStackApplicationCredentialIDKey)
+		}
+	case creds.Username == "" || creds.Password == "" || (creds.ProjectName == "" && creds.ProjectID == ""):
+		return nil, forgeerrors.ConfigErrorf("the secret must have either the %s and %s keys, or the %s, %s and %s or %s keys",
+			infrav1.OpenStackApplicationCredentialIDKey, infrav1.OpenStackApplicationCredentialSecretKey,
+			infrav1.OpenStackUsernameKey, infrav1.OpenStackPasswordKey, infrav1.OpenStackProjectNameKey, infrav1.OpenStackProjectIDKey)
+	}
+	return creds, nil
+}
+
+// Client is a client of the OpenStack APIs. It authenticates on the first request, and again when its token
+// expires, and looks the endpoints of the services up in the catalog of the token.
+type Client struct {
+	// HTTPClient sends the requests.
+	HTTPClient  *http.Client
+	Credentials *Credentials
+
+	token     string
+	endpoints map[Service]string
+}
+
+var _ OpenStack = &Client{}
+
+// NewOpenStack returns a client of the OpenStack APIs of the credentials.
+func NewOpenStack(_ context.Context, creds *Credentials) (OpenStack, error) {
+	return newClient(creds)
+}
+
+// newClient returns a client of the OpenStack APIs of the credentials, verifying the certificates of the endpoints
+// with the CA certificates of the credentials.
+func newClient(creds *Credentials) (*Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(creds.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(creds.CACert) {
+			return nil, forgeerrors.ConfigErrorf("the %s of the secret holds no PEM certificate", infrav1.OpenStackCACertKey)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{HTTPClient: &http.Client{Transport: transport}, Credentials: creds}, nil
+}
+
+// Authenticate issues a token scoped to the project of the credentials, and records the endpoints of its catalog.
+func (c *Client) Authenticate(ctx context.Context) error {
+	creds := c.Credentials
+	identity := map[string]interface{}{}
+	auth := map[string]interface{}{"identity": identity}
+	if creds.ApplicationCredentialID != "" {
+		identity["methods"] = []string{"application_credential"}
+		identity["application_credential"] = map[string]string{
+			"id":     creds.ApplicationCredentialID,
+			"secret": creds.ApplicationCredentialSecret,
+		}
+	} else {
+		domain := map[string]string{"name": creds.DomainName}
+		identity["methods"] = []string{"password"}
+		identity["password"] = map[string]interface{}{
+			"user": map[string]interface{}{"name": creds.Username, "domain": domain, "password": creds.Password},
+		}
+		project := map[string]interface{}{"id": creds.ProjectID}
+		if creds.ProjectID == "" {
+			project = map[string]interface{}{"name": creds.ProjectName, "domain": domain}
+		}
+		auth["scope"] = map[string]interface{}{"project": project}
+	}
+	data, err := json.Marshal(map[string]interface{}{"auth": auth})
+	if err != nil {
+		return err
+	}
+
+	authURL := strings.TrimSuffix(creds.AuthURL, "/")
+	if !strings.HasSuffix(authURL, "/v3") {
+		authURL += "/v3"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authURL+"/auth/tokens", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrap(err, "failed to authenticate"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := parseError(resp.StatusCode, resp.Body)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return forgeerrors.NewConfigError(errors.Wrap(apiErr, "the credentials have been rejected"))
+		}
+		return classifier.Classify(apiErr)
+	}
+	token := &struct {
+		Token struct {
+			Catalog []struct {
+				Type      Service `json:"type"`
+				Endpoints []struct {
+					Interface string `json:"interface"`
+					Region    string `json:"region"`
+					URL       string `json:"url"`
+				} `json:"endpoints"`
+			} `json:"catalog"`
+		} `json:"token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return errors.Wrap(err, "invalid token")
+	}
+	c.endpoints = map[Service]string{}
+	for _, service := range token.Token.Catalog {
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Interface == "public" && (creds.Region == "" || endpoint.Region == creds.Region) {
+				c.endpoints[service.Type] = strings.TrimSuffix(endpoint.URL, "/")
+				break
+			}
+		}
+	}
+	c.token = resp.Header.Get(subjectTokenHeader)
+	return nil
+}
+
+// endpoint returns the versioned URL of the service, the endpoints of the catalog may or not be versioned.
+func (c *Client) endpoint(service Service) (string, error) {
+	endpoint, ok := c.endpoints[service]
+	if !ok {
+		return "", forgeerrors.ConfigErrorf("the catalog has no public %s endpoint in region %q", service, c.Credentials.Region)
+	}
+	// The endpoint of the Compute API is always versioned, e.g. https://nova.example.com/v2.1.
+	version := map[Service]string{ServiceImage: "/v2", ServiceNetwork: "/v2.0"}[service]
+	if !strings.HasSuffix(endpoint, version) {
+		endpoint += version
+	}
+	return endpoint, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// FindImage returns the image of the given ID, or the only image of the given name.
+func (c *Client) FindImage(ctx context.Context, nameOrID string) (*Image, error) {
+	if uuidPattern.MatchString(nameOrID) {
+		image, err := c.GetImage(ctx, nameOrID)
+		if !IsNotFound(err) {
+			return image, err
+		}
+	}
+	images, err := c.ListImages(ctx, map[string]string{"name": nameOrID})
+	if err != nil {
+		return nil, err
+	}
+	switch len(images) {
+	case 0:
+		return nil, notFound("image %s not found", nameOrID)
+	case 1:
+		return &images[0], nil
+	}
+	return nil, forgeerrors.ConfigErrorf("%d images are named %s, use the ID of the image", len(images), nameOrID)
+}
+
+// ListImages returns the images with the given properties, e.g. name.
+func (c *Client) ListImages(ctx context.Context, properties map[string]string) ([]Image, error) {
+	query := url.Values{}
+	for key, value := range properties {
+		query.Set(key, value)
+	}
+	result := &struct {
+		Images []Image `json:"images"`
+	}{}
+	err := c.do(ctx, ServiceImage, http.MethodGet, "/images", query, nil, result)
+	return result.Images, err
+}
+
+func (c *Client) GetImage(ctx context.Context, id string) (*Image, error) {
+	image := &Image{}
+	if err := c.do(ctx, ServiceImage, http.MethodGet, "/images/"+id, nil, nil, image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// FindFlavor returns the ID of the flavor of the given ID or name.
+func (c *Client) FindFlavor(ctx context.Context, nameOrID string) (string, error) {
+	err := c.do(ctx, ServiceCompute, http.MethodGet, "/flavors/"+url.PathEscape(nameOrID), nil, nil, nil)
+	if err == nil {
+		return nameOrID, nil
+	}
+	if !IsNotFound(err) {
+		return "", err
+	}
+	result := &struct {
+		Flavors []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"flavors"`
+	}{}
+	if err := c.do(ctx, ServiceCompute, http.MethodGet, "/flavors", url.Values{"is_public": {"None"}}, nil, result); err != nil {
+		return "", err
+	}
+	for _, flavor := range result.Flavors {
+		if flavor.Name == nameOrID {
+			return flavor.ID, nil
+		}
+	}
+	return "", notFound("flavor %s not found", nameOrID)
+}
+
+// FindNetwork returns the ID of the only network of the given name, or of the given ID.
+func (c *Client) FindNetwork(ctx context.Context, nameOrID string) (string, error) {
+	for _, field := range []string{"name", "id"} {
+		result := &struct {
+			Networks []struct {
+				ID string `json:"id"`
+			} `json:"networks"`
+		}{}
+		if err := c.do(ctx, ServiceNetwork, http.MethodGet, "/networks", url.Values{field: {nameOrID}, "fields": {"id"}}, nil, result); err != nil {
+			return "", err
+		}
+		switch len(result.Networks) {
+		case 0:
+			continue
+		case 1:
+			return result.Networks[0].ID, nil
+		}
+		return "", forgeerrors.ConfigErrorf("%d networks are named %s, use the ID of the network", len(result.Networks), nameOrID)
+	}
+	return "", notFound("network %s not found", nameOrID)
+}
+
+// FindServer returns the instance of the given name.
+func (c *Client) FindServer(ctx context.Context, name string) (*Server, error) {
+	result := &struct {
+		Servers []Server `json:"servers"`
+	}{}
+	// The name is a regular expression.
+	query := url.Values{"name": {"^" + regexp.QuoteMeta(name) + "$"}}
+	if err := c.do(ctx, ServiceCompute, http.MethodGet, "/servers/detail", query, nil, result); err != nil {
+		return nil, err
+	}
+	if len(result.Servers) == 0 {
+		return nil, notFound("instance %s not found", name)
+	}
+	return &result.Servers[0], nil
+}
+
+func (c *Client) GetServer(ctx context.Context, id string) (*Server, error) {
+	result := &struct {
+		Server *Server `json:"server"`
+	}{}
+	if err := c.do(ctx, ServiceCompute, http.MethodGet, "/servers/"+id, nil, nil, result); err != nil {
+		return nil, err
+	}
+	return result.Server, nil
+}
+
+// CreateServer creates the instance, it returns its ID while it is being built.
+func (c *Client) CreateServer(ctx context.Context, spec *ServerSpec) (string, error) {
+	server := map[string]interface{}{
+		"name":      spec.Name,
+		"imageRef":  spec.Image,
+		"flavorRef": spec.Flavor,
+		"networks":  "auto",
+		"user_data": base64.StdEncoding.EncodeToString([]byte(spec.UserData)),
+	}
+	if spec.Network != "" {
+		server["networks"] = []map[string]string{{"uuid": spec.Network}}
+	}
+	if len(spec.SecurityGroups) > 0 {
+		groups := make([]map[string]string, 0, len(spec.SecurityGroups))
+		for _, group := range spec.SecurityGroups {
+			groups = append(groups, map[string]string{"name": group})
+		}
+		server["security_groups"] = groups
+	}
+	if spec.AvailabilityZone != "" {
+		server["availability_zone"] = spec.AvailabilityZone
+	}
+	if len(spec.Metadata) > 0 {
+		server["metadata"] = spec.Metadata
+	}
+	result := &struct {
+		Server struct {
+			ID string `json:"id"`
+		} `json:"server"`
+	}{}
+	err := c.do(ctx, ServiceCompute, http.MethodPost, "/servers", nil, map[string]interface{}{"server": server}, result)
+	return result.Server.ID, err
+}
+
+func (c *Client) StopServer(ctx context.Context, id string) error {
+	return c.do(ctx, ServiceCompute, http.MethodPost, "/servers/"+id+"/action", nil, map[string]interface{}{"os-stop": nil}, nil)
+}
+
+func (c *Client) DeleteServer(ctx context.Context, id string) error {
+	return c.do(ctx, ServiceCompute, http.MethodDelete, "/servers/"+id, nil, nil, nil)
+}
+
+// CreateServerImage snapshots the instance into an image with the given metadata, it returns the ID of the image
+// while it is being saved.
+func (c *Client) CreateServerImage(ctx context.Context, id, name string, metadata map[string]string) (string, error) {
+	body := map[string]interface{}{"createImage": map[string]interface{}{"name": name, "metadata": metadata}}
+	result := &struct {
+		ImageID string `json:"image_id"`
+	}{}
+	err := c.do(ctx, ServiceCompute, http.MethodPost, "/servers/"+id+"/action", nil, body, result)
+	return result.ImageID, err
+}
+
+// ServerPort returns the ID of the first port of the instance.
+func (c *Client) ServerPort(ctx context.Context, server string) (string, error) {
+	result := &struct {
+		Ports []struct {
+			ID string `json:"id"`
+		} `json:"ports"`
+	}{}
+	if err := c.do(ctx, ServiceNetwork, http.MethodGet, "/ports", url.Values{"device_id": {server}}, nil, result); err != nil {
+		return "", err
+	}
+	if len(result.Ports) == 0 {
+		return "", notFound("instance %s has no port", server)
+	}
+	return result.Ports[0].ID, nil
+}
+
+// CreateFloatingIP allocates a floating IP from the network and associates it to the port.
+func (c *Client) CreateFloatingIP(ctx context.Context, network, port, description string) (*FloatingIP, error) {
+	body := map[string]interface{}{"floatingip": map[string]string{
+		"floating_network_id": network,
+		"port_id":             port,
+		"description":         description,
+	}}
+	result := &struct {
+		FloatingIP *FloatingIP `json:"floatingip"`
+	}{}
+	if err := c.do(ctx, ServiceNetwork, http.MethodPost, "/floatingips", nil, body, result); err != nil {
+		return nil, err
+	}
+	return result.FloatingIP, nil
+}
+
+func (c *Client) GetFloatingIP(ctx context.Context, id string) (*FloatingIP, error) {
+	result := &struct {
+		FloatingIP *FloatingIP `json:"floatingip"`
+	}{}
+	if err := c.do(ctx, ServiceNetwork, http.MethodGet, "/floatingips/"+id, nil, nil, result); err != nil {
+		return nil, err
+	}
+	return result.FloatingIP, nil
+}
+
+func (c *Client) DeleteFloatingIP(ctx context.Context, id string) error {
+	return c.do(ctx, ServiceNetwork, http.MethodDelete, "/floatingips/"+id, nil, nil, nil)
+}
+
+// do sends the request to the endpoint of the service with the JSON body, if any, and decodes the response into
+// out. It authenticates first when there is no token, and again once when the token has expired. The errors are
+// classified: throttling, conflicts, exceeded quotas and server errors are retried, the invalid requests are
+// configuration errors.
+func (c *Client) do(ctx context.Context, service Service, method, path string, query url.Values, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if c.token == "" {
+			if err := c.Authenticate(ctx); err != nil {
+				return err
+			}
+		}
+		endpoint, err := c.endpoint(service)
+		if err != nil {
+			return err
+		}
+		target := endpoint + path
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(tokenHeader, c.token)
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if service == ServiceCompute {
+			req.Header.Set("OpenStack-API-Version", "compute "+computeMicroversion)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, path))
+		}
+		err = decode(resp, out)
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			c.token = ""
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s %s", method, path)
+		}
+		return nil
+	}
+}
+
+// decode decodes the response into out, or returns its classified error.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return classifier.Classify(parseError(resp.StatusCode, resp.Body))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "invalid response")
+	}
+	return nil
+}
+
+// parseError returns the error of an error response of the APIs. Nova, Neutron and Keystone wrap the message in
+// an object named after the error, e.g. {"itemNotFound": {"message": "..."}}, Glance replies with text.
+func parseError(statusCode int, body io.Reader) *APIError {
+	return apierror.Parse(statusCode, body, func(data []byte) (string, string) {
+		wrapped := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &wrapped); err != nil || len(wrapped) != 1 {
+			return "", ""
+		}
+		apiErr := &struct {
+			Message string `json:"message"`
+		}{}
+		for _, raw := range wrapped {
+			_ = json.Unmarshal(raw, apiErr)
+		}
+		return "", apiErr.Message
+	})
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing objects, do
+// authenticate again. The conflicts are instances busy with another task.
+var classifier = apierror.Classifier{
+	Handled: apierror.StatusCodes(http.StatusNotFound, http.StatusUnauthorized),
+	Transient: func(err *APIError) bool {
+		return err.StatusCode == http.StatusConflict || strings.Contains(err.Message, "Quota exceeded")
+	},
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// catalog returns the catalog of a token with the endpoints of the services on the server, the image and
+// network endpoints are not versioned.
+func catalog(server string) string {
+	return fmt.Sprintf(`{"token":{"catalog":[
+		{"type":"compute","endpoints":[
+			{"interface":"internal","region":"RegionOne","url":"http://nova.internal/v2.1"},
+			{"interface":"public","region":"RegionOne","url":"%[1]s/compute/v2.1"}]},
+		{"type":"image","endpoints":[{"interface":"public","region":"RegionOne","url":"%[1]s/image"}]},
+		{"type":"network","endpoints":[{"interface":"public","region":"RegionOne","url":"%[1]s/network/"}]}]}}`, server)
+}
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	tokens := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identity/v3/auth/tokens" {
+			body := map[string]interface{}{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			g.Expect(body).To(HaveKeyWithValue("auth", HaveKeyWithValue("scope", map[string]interface{}{
+				"project": map[string]interface{}{"name": "builds", "domain": map[string]interface{}{"name": "Default"}},
+			})))
+			tokens++
+			w.Header().Set(subjectTokenHeader, fmt.Sprintf("token-%d", tokens))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(catalog(server.URL)))
+			return
+		}
+		token := r.Header.Get(tokenHeader)
+		switch {
+		case token == "token-1":
+			// The first token expires.
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"message":"The request you have made requires authentication.","title":"Unauthorized"}}`))
+		case r.URL.Path == "/image/v2/images":
+			g.Expect(r.URL.Query().Get("name")).To(Equal("ubuntu-22.04"))
+			_, _ = w.Write([]byte(`{"images":[{"id":"b1a2","name":"ubuntu-22.04","status":"active","disk_format":"qcow2","os_distro":"ubuntu","min_disk":0}]}`))
+		case r.URL.Path == "/compute/v2.1/flavors/m1.medium":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"itemNotFound":{"code":404,"message":"Flavor m1.medium could not be found."}}`))
+		case r.URL.Path == "/compute/v2.1/flavors":
+			_, _ = w.Write([]byte(`{"flavors":[{"id":"1","name":"m1.small"},{"id":"3","name":"m1.medium"}]}`))
+		case r.URL.Path == "/network/v2.0/networks":
+			if r.URL.Query().Get("name") == "builds" {
+				_, _ = w.Write([]byte(`{"networks":[]}`))
+				return
+			}
+			g.Expect(r.URL.Query().Get("id")).To(Equal("builds"))
+			_, _ = w.Write([]byte(`{"networks":[{"id":"n3t"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/compute/v2.1/servers":
+			g.Expect(r.Header.Get("OpenStack-API-Version")).To(Equal("compute " + computeMicroversion))
+			body := map[string]map[string]interface{}{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			g.Expect(body["server"]).To(HaveKeyWithValue("networks", "auto"))
+			g.Expect(body["server"]).To(HaveKeyWithValue("security_groups", []interface{}{map[string]interface{}{"name": "ssh"}}))
+			g.Expect(body["server"]).To(HaveKeyWithValue("user_data", base64.StdEncoding.EncodeToString([]byte("#cloud-config\n"))))
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"server":{"id":"5e7v"}}`))
+		case r.URL.Path == "/compute/v2.1/servers/detail":
+			g.Expect(r.URL.Query().Get("name")).To(Equal(`^ubuntu\.1$`))
+			_, _ = w.Write([]byte(`{"servers":[]}`))
+		case r.URL.Path == "/compute/v2.1/servers/5e7v/action":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"conflictingRequest":{"code":409,"message":"Cannot 'createImage' instance while it is in task_state image_snapshot"}}`))
+		case r.URL.Path == "/network/v2.0/floatingips":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"NeutronError":{"type":"ExternalGatewayForFloatingIPNotFound","message":"External network n3t is not reachable.","detail":""}}`))
+		case r.URL.Path == "/compute/v2.1/servers/5e7v":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), Credentials: &Credentials{
+		AuthURL: server.URL + "/identity", Username: "forge", Password: "password", DomainName: "Default", ProjectName: "builds",
+	}}
+
+	// The client authenticates again when its token expires.
+	image, err := c.FindImage(ctx, "ubuntu-22.04")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(image.ID).To(Equal("b1a2"))
+	g.Expect(image.Properties).To(HaveKeyWithValue("os_distro", "ubuntu"))
+	g.Expect(tokens).To(Equal(2))
+
+	flavor, err := c.FindFlavor(ctx, "m1.medium")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(flavor).To(Equal("3"))
+	network, err := c.FindNetwork(ctx, "builds")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(network).To(Equal("n3t"))
+
+	id, err := c.CreateServer(ctx, &ServerSpec{Name: "ubuntu.1", Image: image.ID, Flavor: flavor, SecurityGroups: []string{"ssh"}, UserData: "#cloud-config\n"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("5e7v"))
+	_, err = c.FindServer(ctx, "ubuntu.1")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+
+	_, err = c.CreateServerImage(ctx, id, "ubuntu-22.04", nil)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+	g.Expect(err.Error()).To(ContainSubstring("task_state image_snapshot"))
+	_, err = c.CreateFloatingIP(ctx, network, "port", "")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	g.Expect(err.Error()).To(ContainSubstring("External network n3t is not reachable."))
+	_, err = c.GetServer(ctx, id)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+}
+
+func TestCredentialsFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	creds, err := credentialsFrom(map[string][]byte{
+		infrav1.OpenStackAuthURLKey:                     []byte("https://keystone.example.com:5000"),
+		infrav1.OpenStackApplicationCredentialIDKey:     []byte("forge"),
+		infrav1.OpenStackApplicationCredentialSecretKey: []byte("secret"),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(creds.DomainName).To(Equal("Default"))
+
+	_, err = credentialsFrom(map[string][]byte{
+		infrav1.OpenStackAuthURLKey:  []byte("https://keystone.example.com:5000"),
+		infrav1.OpenStackUsernameKey: []byte("forge"),
+		infrav1.OpenStackPasswordKey: []byte("password"),
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("projectName")))
+
+	_, err = credentialsFrom(map[string][]byte{infrav1.OpenStackApplicationCredentialIDKey: []byte("forge")})
+	g.Expect(err).To(MatchError(ContainSubstring(infrav1.OpenStackAuthURLKey)))
+}
@@ -0,0 +1,475 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the OpenStackBuild controller, used in the controller metrics.
+	ControllerName = "openstackbuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "openstack"
+
+	// serverRequeueAfter is how often an instance being built or stopped is checked.
+	serverRequeueAfter = 10 * time.Second
+
+	// imageRequeueAfter is how often an image being saved is checked.
+	imageRequeueAfter = 30 * time.Second
+
+	// markerKey is the key of the metadata of the instances and of the property of the images set to the UID of
+	// their OpenStackBuild, identifying them when their creation is interrupted.
+	markerKey = "forge_build_uid"
+)
+
+// OpenStackBuildReconciler reconciles an OpenStackBuild object
+type OpenStackBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewOpenStack returns the client of the OpenStack APIs, NewOpenStack is used if nil.
+	NewOpenStack func(ctx context.Context, creds *Credentials) (OpenStack, error)
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OpenStackBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.OpenStackBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("OpenStackBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("openstackbuild-controller")
+	return nil
+}
+
+// Reconcile creates the instance of an OpenStackBuild, publishes its address in the connector credentials of the
+// Build and, once the provisioners of the Build are ready, stops it and snapshots it into a Glance image.
+func (r *OpenStackBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	openstackBuild := &infrav1.OpenStackBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, openstackBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(openstackBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(openstackBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(openstackBuild)
+		if err := patchHelper.Patch(ctx, openstackBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, openstackBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !openstackBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, openstackBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(openstackBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the OpenStackBuild")
+		return ctrl.Result{}, nil
+	}
+	if openstackBuild.Status.FailureReason != nil || openstackBuild.Status.Ready {
+		// The instance of a failed or completed OpenStackBuild is deleted along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(openstackBuild, infrav1.OpenStackBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, openstackBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, openstackBuild, res, err)
+}
+
+func (r *OpenStackBuildReconciler) reconcileNormal(ctx context.Context, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) (ctrl.Result, error) {
+	cloud, err := r.openstack(ctx, openstackBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	server, err := r.reconcileServer(ctx, cloud, openstackBuild, build)
+	if err != nil || server == nil {
+		return ctrl.Result{RequeueAfter: serverRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(openstackBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileImage(ctx, cloud, openstackBuild, build, server)
+}
+
+// reconcileServer creates the instance, allocates its floating IP if any, and publishes its address once it is
+// active. It returns nil while the instance is being built.
+func (r *OpenStackBuildReconciler) reconcileServer(ctx context.Context, cloud OpenStack, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) (*Server, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &openstackBuild.Status
+
+	name := cmp.Or(status.InstanceName, providersdk.MachineName(openstackBuild))
+	var server *Server
+	var err error
+	if status.InstanceID != "" {
+		server, err = cloud.GetServer(ctx, status.InstanceID)
+	} else {
+		// The instance may have been created by a reconciliation which failed to record it.
+		server, err = cloud.FindServer(ctx, name)
+	}
+	if IsNotFound(err) {
+		if status.InstanceID != "" {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s has been deleted", name)
+		}
+		log.Info("Creating instance", "instance", name)
+		id, err := r.createServer(ctx, cloud, openstackBuild, build, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create instance %s", name)
+		}
+		r.recorder.Eventf(openstackBuild, corev1.EventTypeNormal, "InstanceCreated", "Created instance %s", name)
+		status.InstanceName = name
+		status.InstanceID = id
+		conditions.MarkFalse(openstackBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Created instance %s", name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get instance %s", name)
+	}
+	status.InstanceName = name
+	status.InstanceID = server.ID
+
+	if status.MachineReady {
+		if server.Status != ServerActive && !build.Status.ProvisionersReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s is %s while the provisioners are running", name, server.Status)
+		}
+		return server, nil
+	}
+
+	switch server.Status {
+	case ServerActive:
+	case ServerBuild:
+		conditions.MarkFalse(openstackBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Building instance %s", name)
+		return nil, nil
+	case ServerError:
+		message := "unknown fault"
+		if server.Fault != nil {
+			message = server.Fault.Message
+		}
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s failed: %s", name, message)
+	default:
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "instance %s is %s", name, server.Status)
+	}
+
+	address := server.FixedIPv4()
+	if openstackBuild.Spec.FloatingIP != nil {
+		if address, err = r.reconcileFloatingIP(ctx, cloud, openstackBuild, server); err != nil {
+			return nil, err
+		}
+	}
+	if address == "" {
+		conditions.MarkFalse(openstackBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Waiting for the address of instance %s", name)
+		return nil, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(openstackBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "Instance %s is running at %s", name, address)
+	r.recorder.Eventf(openstackBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "Instance %s is running at %s", name, address)
+	return server, nil
+}
+
+// createServer creates the instance from the image, authorizing the connector credentials with cloud-init. It
+// returns the ID of the instance.
+func (r *OpenStackBuildReconciler) createServer(ctx context.Context, cloud OpenStack, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build, name string) (string, error) {
+	spec := &openstackBuild.Spec
+	creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultOpenStackUsername))
+	if err != nil {
+		return "", err
+	}
+	if creds.AuthorizedKey == "" && creds.Password == "" {
+		return "", forgeerrors.ConfigErrorf("the connector credentials of Build %s have neither a private key nor a password", build.Name)
+	}
+	userdata, err := providersdk.CloudConfig(creds)
+	if err != nil {
+		return "", err
+	}
+
+	image, err := cloud.FindImage(ctx, spec.Image)
+	if err != nil {
+		return "", configIfNotFound(err)
+	}
+	if image.Status != ImageActive {
+		return "", forgeerrors.ConfigErrorf("image %s is %s", spec.Image, image.Status)
+	}
+	flavor, err := cloud.FindFlavor(ctx, spec.Flavor)
+	if err != nil {
+		return "", configIfNotFound(err)
+	}
+	var network string
+	if spec.Network != "" {
+		if network, err = cloud.FindNetwork(ctx, spec.Network); err != nil {
+			return "", configIfNotFound(err)
+		}
+	}
+	return cloud.CreateServer(ctx, &ServerSpec{
+		Name:             name,
+		Image:            image.ID,
+		Flavor:           flavor,
+		Network:          network,
+		SecurityGroups:   spec.SecurityGroups,
+		AvailabilityZone: spec.AvailabilityZone,
+		UserData:         userdata,
+		Metadata:         map[string]string{markerKey: string(openstackBuild.UID)},
+	})
+}
+
+// reconcileFloatingIP allocates the floating IP of the instance from the external network, it returns its address.
+func (r *OpenStackBuildReconciler) reconcileFloatingIP(ctx context.Context, cloud OpenStack, openstackBuild *infrav1.OpenStackBuild, server *Server) (string, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &openstackBuild.Status
+
+	if status.FloatingIPID != "" {
+		floatingIP, err := cloud.GetFloatingIP(ctx, status.FloatingIPID)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get the floating IP of instance %s", server.Name)
+		}
+		return floatingIP.FloatingIPAddress, nil
+	}
+	network, err := cloud.FindNetwork(ctx, openstackBuild.Spec.FloatingIP.Network)
+	if err != nil {
+		return "", configIfNotFound(err)
+	}
+	port, err := cloud.ServerPort(ctx, server.ID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the port of instance %s", server.Name)
+	}
+	floatingIP, err := cloud.CreateFloatingIP(ctx, network, port, "Forge build "+openstackBuild.Namespace+"/"+openstackBuild.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to allocate a floating IP to instance %s", server.Name)
+	}
+	log.Info("Allocated floating IP", "instance", server.Name, "address", floatingIP.FloatingIPAddress)
+	status.FloatingIPID = floatingIP.ID
+	return floatingIP.FloatingIPAddress, nil
+}
+
+// reconcileImage stops the instance and snapshots it into an image, once saved.
+func (r *OpenStackBuildReconciler) reconcileImage(ctx context.Context, cloud OpenStack, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build, server *Server) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	status := &openstackBuild.Status
+
+	if server.Status != ServerShutoff {
+		if conditions.GetReason(openstackBuild, infrav1.ImageReadyCondition) != infrav1.MachineStoppingReason {
+			if err := cloud.StopServer(ctx, server.ID); err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to stop instance %s", server.Name)
+			}
+			log.Info("Stopping instance", "instance", server.Name)
+			r.recorder.Eventf(openstackBuild, corev1.EventTypeNormal, infrav1.MachineStoppingReason, "Stopping instance %s", server.Name)
+		}
+		conditions.MarkFalse(openstackBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Stopping instance %s", server.Name)
+		return ctrl.Result{RequeueAfter: serverRequeueAfter}, nil
+	}
+
+	name := imageName(openstackBuild, build)
+	if status.ImageID == "" {
+		// The snapshot may have been created by a reconciliation which failed to record it.
+		images, err := cloud.ListImages(ctx, map[string]string{markerKey: string(openstackBuild.UID)})
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to list the images of instance %s", server.Name)
+		}
+		if len(images) > 0 {
+			status.ImageID = images[0].ID
+		} else {
+			log.Info("Creating image", "image", name, "instance", server.Name)
+			id, err := cloud.CreateServerImage(ctx, server.ID, name, imageProperties(openstackBuild, build))
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to create image %s", name)
+			}
+			r.recorder.Eventf(openstackBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Creating image %s from instance %s", name, server.Name)
+			status.ImageID = id
+		}
+	}
+
+	image, err := cloud.GetImage(ctx, status.ImageID)
+	if IsNotFound(err) {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "image %s has been deleted", name)
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get image %s", name)
+	}
+	switch image.Status {
+	case ImageActive:
+	case ImageKilled, ImageDeleted:
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError, "image %s is %s", name, image.Status)
+	default:
+		conditions.MarkFalse(openstackBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Image %s is %s", name, image.Status)
+		return ctrl.Result{RequeueAfter: imageRequeueAfter}, nil
+	}
+
+	status.ImageRef = image.ID
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       image.ID,
+		Location: image.Name,
+		Format:   image.DiskFormat,
+	}
+	if image.HashValue != "" {
+		status.Artifact.Checksum = image.HashAlgo + ":" + image.HashValue
+	}
+	if image.Size > 0 {
+		status.Artifact.SizeBytes = ptr.To(image.Size)
+	}
+	if created, err := time.Parse(time.RFC3339, image.CreatedAt); err == nil {
+		status.Artifact.CreatedAt = &metav1.Time{Time: created}
+	}
+	status.Ready = true
+	conditions.MarkTrue(openstackBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Image %s (%s) is ready", name, image.ID)
+	r.recorder.Eventf(openstackBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Image %s (%s) is ready", name, image.ID)
+	return ctrl.Result{}, nil
+}
+
+// reconcileDelete releases the floating IP and deletes the instance, the image is kept.
+func (r *OpenStackBuildReconciler) reconcileDelete(ctx context.Context, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(openstackBuild, infrav1.OpenStackBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(openstackBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	status := &openstackBuild.Status
+	if status.FloatingIPID != "" || status.InstanceID != "" {
+		cloud, err := r.openstack(ctx, openstackBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if id := status.FloatingIPID; id != "" {
+			if err := cloud.DeleteFloatingIP(ctx, id); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to release the floating IP of instance %s", status.InstanceName)
+			}
+			log.Info("Released floating IP", "instance", status.InstanceName)
+			status.FloatingIPID = ""
+		}
+		if id := status.InstanceID; id != "" {
+			if err := cloud.DeleteServer(ctx, id); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete instance %s", status.InstanceName)
+			}
+			log.Info("Deleted instance", "instance", status.InstanceName)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(openstackBuild, infrav1.OpenStackBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// openstack returns the client of the OpenStack APIs of the credentials of the OpenStackBuild.
+func (r *OpenStackBuildReconciler) openstack(ctx context.Context, openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) (OpenStack, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the OpenStackBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: openstackBuild.Namespace, Name: openstackBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, openstackBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := credentialsFrom(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+	}
+	newOpenStack := r.NewOpenStack
+	if newOpenStack == nil {
+		newOpenStack = NewOpenStack
+	}
+	return newOpenStack(ctx, creds)
+}
+
+// configIfNotFound returns the not found errors of the objects named in the spec as configuration errors.
+func configIfNotFound(err error) error {
+	if IsNotFound(err) {
+		return forgeerrors.NewConfigError(err)
+	}
+	return err
+}
+
+// imageName returns the name of the image, the spec.imageName of the Build, or the name of the OpenStackBuild.
+func imageName(openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) string {
+	return cmp.Or(build.Spec.ImageName, openstackBuild.Name)
+}
+
+// imageProperties returns the properties of the image: the properties of the spec, the image metadata of the
+// Build, and the marker of the OpenStackBuild.
+func imageProperties(openstackBuild *infrav1.OpenStackBuild, build *buildv1.Build) map[string]string {
+	properties := map[string]string{}
+	for key, value := range openstackBuild.Spec.Snapshot.Properties {
+		properties[key] = value
+	}
+	for key, value := range build.Status.ImageMetadata {
+		properties[key] = value
+	}
+	properties[markerKey] = string(openstackBuild.UID)
+	return properties
+}
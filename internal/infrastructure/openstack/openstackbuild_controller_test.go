@@ -0,0 +1,402 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeOpenStack is an in-memory OpenStack, the flavors and networks are named after their ID.
+type fakeOpenStack struct {
+	images      map[string]*Image
+	servers     map[string]*Server
+	specs       map[string]*ServerSpec
+	floatingIPs map[string]*FloatingIP
+	stops       int
+}
+
+func newFakeOpenStack() *fakeOpenStack {
+	return &fakeOpenStack{
+		images:      map[string]*Image{"image-1": {ID: "image-1", Name: "ubuntu-22.04-cloud", Status: ImageActive}},
+		servers:     map[string]*Server{},
+		specs:       map[string]*ServerSpec{},
+		floatingIPs: map[string]*FloatingIP{},
+	}
+}
+
+func (f *fakeOpenStack) FindImage(_ context.Context, nameOrID string) (*Image, error) {
+	for _, image := range f.images {
+		if image.ID == nameOrID || image.Name == nameOrID {
+			return image, nil
+		}
+	}
+	return nil, notFound("image %s not found", nameOrID)
+}
+
+func (f *fakeOpenStack) ListImages(_ context.Context, properties map[string]string) ([]Image, error) {
+	images := []Image{}
+	for _, image := range f.images {
+		matches := true
+		for key, value := range properties {
+			matches = matches && image.Properties[key] == value
+		}
+		if matches {
+			images = append(images, *image)
+		}
+	}
+	return images, nil
+}
+
+func (f *fakeOpenStack) GetImage(_ context.Context, id string) (*Image, error) {
+	image, ok := f.images[id]
+	if !ok {
+		return nil, notFound("image %s not found", id)
+	}
+	return image, nil
+}
+
+func (f *fakeOpenStack) FindFlavor(_ context.Context, nameOrID string) (string, error) {
+	if nameOrID == "missing" {
+		return "", notFound("flavor %s not found", nameOrID)
+	}
+	return "flavor-" + nameOrID, nil
+}
+
+func (f *fakeOpenStack) FindNetwork(_ context.Context, nameOrID string) (string, error) {
+	return "network-" + nameOrID, nil
+}
+
+func (f *fakeOpenStack) FindServer(_ context.Context, name string) (*Server, error) {
+	for _, server := range f.servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+	return nil, notFound("instance %s not found", name)
+}
+
+func (f *fakeOpenStack) GetServer(_ context.Context, id string) (*Server, error) {
+	server, ok := f.servers[id]
+	if !ok {
+		return nil, notFound("instance %s not found", id)
+	}
+	return server, nil
+}
+
+func (f *fakeOpenStack) CreateServer(_ context.Context, spec *ServerSpec) (string, error) {
+	id := fmt.Sprintf("server-%d", len(f.servers)+1)
+	f.servers[id] = &Server{ID: id, Name: spec.Name, Status: ServerBuild, Metadata: spec.Metadata}
+	f.specs[id] = spec
+	return id, nil
+}
+
+func (f *fakeOpenStack) StopServer(_ context.Context, _ string) error {
+	f.stops++
+	return nil
+}
+
+func (f *fakeOpenStack) DeleteServer(_ context.Context, id string) error {
+	delete(f.servers, id)
+	return nil
+}
+
+func (f *fakeOpenStack) CreateServerImage(_ context.Context, _, name string, metadata map[string]string) (string, error) {
+	id := fmt.Sprintf("image-%d", len(f.images)+1)
+	f.images[id] = &Image{ID: id, Name: name, Status: ImageQueued, Properties: metadata}
+	return id, nil
+}
+
+func (f *fakeOpenStack) ServerPort(_ context.Context, server string) (string, error) {
+	return "port-" + server, nil
+}
+
+func (f *fakeOpenStack) CreateFloatingIP(_ context.Context, network, port, _ string) (*FloatingIP, error) {
+	id := fmt.Sprintf("fip-%d", len(f.floatingIPs)+1)
+	f.floatingIPs[id] = &FloatingIP{ID: id, FloatingIPAddress: "203.0.113.7", PortID: port}
+	return f.floatingIPs[id], nil
+}
+
+func (f *fakeOpenStack) GetFloatingIP(_ context.Context, id string) (*FloatingIP, error) {
+	floatingIP, ok := f.floatingIPs[id]
+	if !ok {
+		return nil, notFound("floating IP %s not found", id)
+	}
+	return floatingIP, nil
+}
+
+func (f *fakeOpenStack) DeleteFloatingIP(_ context.Context, id string) error {
+	delete(f.floatingIPs, id)
+	return nil
+}
+
+func TestOpenStackBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, openstackBuild := setupTest(g)
+	cloud := newFakeOpenStack()
+	r := newReconciler(c, cloud)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(openstackBuild)}
+
+	// The instance is created from the image, the connector key is authorized with cloud-init.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(serverRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+	g.Expect(openstackBuild.Finalizers).To(ContainElement(infrav1.OpenStackBuildFinalizer))
+	name := providersdk.MachineName(openstackBuild)
+	g.Expect(openstackBuild.Status.InstanceName).To(Equal(name))
+	g.Expect(openstackBuild.Status.InstanceID).To(Equal("server-1"))
+	spec := cloud.specs["server-1"]
+	g.Expect(spec.Image).To(Equal("image-1"))
+	g.Expect(spec.Flavor).To(Equal("flavor-m1.medium"))
+	g.Expect(spec.Network).To(Equal("network-builds"))
+	g.Expect(spec.SecurityGroups).To(Equal([]string{"ssh"}))
+	g.Expect(spec.Metadata).To(Equal(map[string]string{markerKey: "openstackbuild-uid"}))
+	g.Expect(spec.UserData).To(HavePrefix("#cloud-config\n"))
+	g.Expect(spec.UserData).To(ContainSubstring("name: " + infrav1.DefaultOpenStackUsername))
+
+	// The floating IP is allocated and published once the instance is active.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloud.floatingIPs).To(BeEmpty())
+	cloud.servers["server-1"].Status = ServerActive
+	cloud.servers["server-1"].Addresses = map[string][]Address{"builds": {{Addr: "10.0.0.12", Version: 4, Type: "fixed"}}}
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloud.floatingIPs["fip-1"].PortID).To(Equal("port-server-1"))
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+	g.Expect(openstackBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(openstackBuild.Status.FloatingIPID).To(Equal("fip-1"))
+	g.Expect(openstackBuild.Status.Address).To(Equal("203.0.113.7"))
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("203.0.113.7"))
+
+	// The instance is stopped once the provisioners are ready, once.
+	build.Status.ProvisionersReady = true
+	build.Status.ImageMetadata = map[string]string{"os_version": "22.04"}
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	for range 2 {
+		res, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).To(Equal(serverRequeueAfter))
+	}
+	g.Expect(cloud.stops).To(Equal(1))
+
+	// The instance is snapshotted once stopped, the OpenStackBuild is ready once the image is saved.
+	cloud.servers["server-1"].Status = ServerShutoff
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(imageRequeueAfter))
+	image := cloud.images["image-2"]
+	g.Expect(image.Name).To(Equal("ubuntu-22.04"))
+	g.Expect(image.Properties).To(Equal(map[string]string{"os_distro": "ubuntu", "os_version": "22.04", markerKey: "openstackbuild-uid"}))
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(openstackBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.ImageCreatingReason))
+	image.Status = ImageActive
+	image.DiskFormat = "qcow2"
+	image.Size = 2 << 30
+	image.HashAlgo = "sha512"
+	image.HashValue = "ab12"
+	image.CreatedAt = "2024-03-07T09:05:01Z"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+	g.Expect(openstackBuild.Status.Ready).To(BeTrue())
+	g.Expect(openstackBuild.Status.ImageID).To(Equal("image-2"))
+	g.Expect(openstackBuild.Status.ImageRef).To(Equal("image-2"))
+	g.Expect(openstackBuild.Status.Artifact.ID).To(Equal("image-2"))
+	g.Expect(openstackBuild.Status.Artifact.Format).To(Equal("qcow2"))
+	g.Expect(openstackBuild.Status.Artifact.Checksum).To(Equal("sha512:ab12"))
+	g.Expect(*openstackBuild.Status.Artifact.SizeBytes).To(BeEquivalentTo(2 << 30))
+	g.Expect(openstackBuild.Status.Artifact.CreatedAt).ToNot(BeNil())
+	g.Expect(conditions.IsTrue(openstackBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The instance and the floating IP are deleted with the OpenStackBuild, the image is kept.
+	g.Expect(c.Delete(ctx, openstackBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloud.servers).To(BeEmpty())
+	g.Expect(cloud.floatingIPs).To(BeEmpty())
+	g.Expect(cloud.images).To(HaveKey("image-2"))
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).ToNot(Succeed())
+}
+
+func TestOpenStackBuildReconcileProviderNetwork(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, openstackBuild := setupTest(g)
+	openstackBuild.Spec.FloatingIP = nil
+	openstackBuild.Spec.Network = ""
+	g.Expect(c.Update(ctx, openstackBuild)).To(Succeed())
+	cloud := newFakeOpenStack()
+	r := newReconciler(c, cloud)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(openstackBuild)}
+
+	// The network is allocated, the connector connects to the fixed IP.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloud.specs["server-1"].Network).To(BeEmpty())
+	cloud.servers["server-1"].Status = ServerActive
+	cloud.servers["server-1"].Addresses = map[string][]Address{"public": {
+		{Addr: "2001:db8::12", Version: 6, Type: "fixed"},
+		{Addr: "192.0.2.12", Version: 4, Type: "fixed"},
+	}}
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloud.floatingIPs).To(BeEmpty())
+	g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+	g.Expect(openstackBuild.Status.Address).To(Equal("192.0.2.12"))
+}
+
+func TestOpenStackBuildReconcileFailure(t *testing.T) {
+	testcases := []struct {
+		name    string
+		prepare func(openstackBuild *infrav1.OpenStackBuild, cloud *fakeOpenStack)
+		reason  forgeerrors.BuildStatusError
+		message string
+	}{
+		{
+			name: "missing flavor",
+			prepare: func(openstackBuild *infrav1.OpenStackBuild, _ *fakeOpenStack) {
+				openstackBuild.Spec.Flavor = "missing"
+			},
+			reason:  forgeerrors.InvalidConfigurationBuildError,
+			message: "flavor missing not found",
+		},
+		{
+			name: "instance in error",
+			prepare: func(openstackBuild *infrav1.OpenStackBuild, cloud *fakeOpenStack) {
+				openstackBuild.Status.InstanceID = "server-1"
+				cloud.servers["server-1"] = &Server{ID: "server-1", Name: "ubuntu", Status: ServerError, Fault: &struct {
+					Message string `json:"message"`
+				}{Message: "No valid host was found."}}
+			},
+			reason:  forgeerrors.CreateBuildError,
+			message: "No valid host was found.",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c, _, openstackBuild := setupTest(g)
+			cloud := newFakeOpenStack()
+			tc.prepare(openstackBuild, cloud)
+			status := openstackBuild.Status.DeepCopy()
+			g.Expect(c.Update(ctx, openstackBuild)).To(Succeed())
+			openstackBuild.Status = *status
+			g.Expect(c.Status().Update(ctx, openstackBuild)).To(Succeed())
+			r := newReconciler(c, cloud)
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(openstackBuild)}
+
+			_, err := r.Reconcile(ctx, req)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.Get(ctx, req.NamespacedName, openstackBuild)).To(Succeed())
+			g.Expect(openstackBuild.Status.FailureReason).To(HaveValue(Equal(tc.reason)))
+			g.Expect(*openstackBuild.Status.FailureMessage).To(ContainSubstring(tc.message))
+			g.Expect(conditions.GetReason(openstackBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+		})
+	}
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.OpenStackBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "OpenStackBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	openstackBuild := &infrav1.OpenStackBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "openstackbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.OpenStackBuildSpec{
+			Image:          "ubuntu-22.04-cloud",
+			Flavor:         "m1.medium",
+			Network:        "builds",
+			FloatingIP:     &infrav1.OpenStackFloatingIP{Network: "public"},
+			SecurityGroups: []string{"ssh"},
+			CredentialsRef: &corev1.LocalObjectReference{Name: "openstack"},
+			Snapshot:       infrav1.OpenStackSnapshotSpec{Properties: map[string]string{"os_distro": "ubuntu"}},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "openstack"},
+		Data: map[string][]byte{
+			infrav1.OpenStackAuthURLKey:                     []byte("https://keystone.example.com:5000/v3"),
+			infrav1.OpenStackApplicationCredentialIDKey:     []byte("forge"),
+			infrav1.OpenStackApplicationCredentialSecretKey: []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, openstackBuild, credentials).
+		WithStatusSubresource(build, openstackBuild).
+		Build()
+	return c, build, openstackBuild
+}
+
+func newReconciler(c client.Client, cloud OpenStack) *OpenStackBuildReconciler {
+	return &OpenStackBuildReconciler{
+		Client: c,
+		NewOpenStack: func(_ context.Context, _ *Credentials) (OpenStack, error) {
+			return cloud, nil
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+}
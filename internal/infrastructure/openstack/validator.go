@@ -0,0 +1,62 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/identity"
+)
+
+// Validator validates the users and application credentials of the openstack ProviderIdentities, by
+// authenticating with the Identity service of their secret.
+type Validator struct {
+	// HTTPClient sends the requests, a client verifying the certificates with the caCert of the secret is used
+	// if nil.
+	HTTPClient *http.Client
+}
+
+var _ identity.Validator = &Validator{}
+
+// Validate returns an error if the secret is incomplete, its credentials are rejected, or the catalog of the
+// project has no Compute or Image endpoint.
+func (v *Validator) Validate(ctx context.Context, _ *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	creds, err := credentialsFrom(secret.Data)
+	if err != nil {
+		return err
+	}
+	c, err := newClient(creds)
+	if err != nil {
+		return err
+	}
+	if v.HTTPClient != nil {
+		c.HTTPClient = v.HTTPClient
+	}
+	if err := c.Authenticate(ctx); err != nil {
+		return err
+	}
+	for _, service := range []Service{ServiceCompute, ServiceImage} {
+		if _, err := c.endpoint(service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
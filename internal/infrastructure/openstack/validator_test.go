@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		region    string
+		secret    map[string][]byte
+		valid     bool
+		retryable bool
+	}{
+		{name: "valid application credential", status: http.StatusCreated, valid: true},
+		{name: "rejected application credential", status: http.StatusUnauthorized},
+		{name: "Identity service unavailable", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "region without endpoints", status: http.StatusCreated, region: "RegionTwo"},
+		{name: "incomplete secret", secret: map[string][]byte{infrav1.OpenStackUsernameKey: []byte("forge")}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.URL.Path).To(Equal("/v3/auth/tokens"))
+				if tc.status == http.StatusCreated {
+					w.Header().Set(subjectTokenHeader, "token")
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(catalog(server.URL)))
+					return
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"error":{"code":401,"message":"The request you have made requires authentication.","title":"Unauthorized"}}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: tc.secret}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{
+					infrav1.OpenStackAuthURLKey:                     []byte(server.URL),
+					infrav1.OpenStackRegionKey:                      []byte(tc.region),
+					infrav1.OpenStackApplicationCredentialIDKey:     []byte("forge"),
+					infrav1.OpenStackApplicationCredentialSecretKey: []byte("secret"),
+				}
+			}
+			v := &Validator{HTTPClient: server.Client()}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
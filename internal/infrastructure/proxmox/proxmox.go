@@ -0,0 +1,525 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxmox implements the ProxmoxBuild infrastructure provider: the build machine is a Proxmox VE VM,
+// cloned from a template with a cloud-init drive or booted from an ISO image, which is shut down and converted into
+// a template once the provisioners of the Build are ready.
+package proxmox
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// apiPath is the path of the JSON API.
+	apiPath = "/api2/json"
+
+	// csrfHeader is the header of the requests authenticated with a ticket holding the CSRF prevention token.
+	csrfHeader = "CSRFPreventionToken"
+)
+
+// The statuses of the VMs.
+const (
+	VMRunning = "running"
+	VMStopped = "stopped"
+)
+
+// TaskStopped is the status of the finished tasks, TaskOK their exit status when they succeeded.
+const (
+	TaskStopped = "stopped"
+	TaskOK      = "OK"
+)
+
+// Proxmox is the part of the Proxmox VE API used by the controller. The VMs are identified by their node and their
+// ID, the tasks by their node and their UPID.
+type Proxmox interface {
+	NextID(ctx context.Context) (int32, error)
+	FindVM(ctx context.Context, name string) (*VM, error)
+	GetVM(ctx context.Context, node string, vmid int32) (*VM, error)
+	CloneVM(ctx context.Context, node string, source int32, spec *CloneSpec) (string, error)
+	CreateVM(ctx context.Context, node string, spec *CreateSpec) (string, error)
+	ConfigureVM(ctx context.Context, node string, vmid int32, config url.Values) error
+	StartVM(ctx context.Context, node string, vmid int32) (string, error)
+	ShutdownVM(ctx context.Context, node string, vmid int32) (string, error)
+	StopVM(ctx context.Context, node string, vmid int32) (string, error)
+	GuestIPAddress(ctx context.Context, node string, vmid int32) (string, error)
+	ConvertToTemplate(ctx context.Context, node string, vmid int32) (string, error)
+	DeleteVM(ctx context.Context, node string, vmid int32) (string, error)
+	GetTask(ctx context.Context, node, upid string) (*Task, error)
+}
+
+// VM is a virtual machine, or a template.
+type VM struct {
+	VMID   int32  `json:"vmid"`
+	Name   string `json:"name"`
+	Node   string `json:"node,omitempty"`
+	Status string `json:"status"`
+	// Template is 1 for the templates.
+	Template int `json:"template,omitempty"`
+}
+
+// IsTemplate returns true if the VM has been converted into a template.
+func (vm *VM) IsTemplate() bool {
+	return vm.Template == 1
+}
+
+// CloneSpec is a VM cloned from a template.
+type CloneSpec struct {
+	VMID int32
+	Name string
+	Pool string
+	// Target is the node of the VM, the node of the template if empty.
+	Target string
+	// Full copies the disks of the template, to the storage if set.
+	Full    bool
+	Storage string
+}
+
+// CreateSpec is a new VM booting from an ISO image.
+type CreateSpec struct {
+	VMID        int32
+	Name        string
+	Pool        string
+	OSType      string
+	Cores       int32
+	MemoryMiB   int64
+	ISO         string
+	Storage     string
+	DiskSizeGiB int64
+	Bridge      string
+}
+
+// Task is an asynchronous task of a node.
+type Task struct {
+	// Status is running or stopped.
+	Status string `json:"status"`
+	// ExitStatus is OK, or the error, once stopped.
+	ExitStatus string `json:"exitstatus,omitempty"`
+}
+
+// Succeeded returns true if the task is finished and succeeded.
+func (t *Task) Succeeded() bool {
+	return t.Status == TaskStopped && t.ExitStatus == TaskOK
+}
+
+// APIError is an error returned by the Proxmox VE API.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the API. The API replies to the requests of missing VMs
+// with a server error.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || strings.Contains(apiErr.Message, "does not exist"))
+}
+
+// notFound returns the not found error of an object missing from the results of a lookup.
+func notFound(format string, args ...interface{}) error {
+	return &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Credentials are the address of the API and the API token or the user the controller authenticates as.
+type Credentials struct {
+	URL         string
+	TokenID     string
+	TokenSecret string
+	Username    string
+	Password    string
+	// CACert are the PEM encoded CA certificates of the API, the system ones are used if empty.
+	CACert   []byte
+	Insecure bool
+}
+
+// credentialsFrom returns the credentials of the data of a credentials secret.
+func credentialsFrom(data map[string][]byte) (*Credentials, error) {
+	creds := &Credentials{
+		URL:         string(data[infrav1.ProxmoxURLKey]),
+		TokenID:     string(data[infrav1.ProxmoxTokenIDKey]),
+		TokenSecret: string(data[infrav1.ProxmoxTokenSecretKey]),
+		Username:    string(data[infrav1.ProxmoxUsernameKey]),
+		Password:    string(data[infrav1.ProxmoxPasswordKey]),
+		CACert:      data[infrav1.ProxmoxCACertKey],
+		Insecure:    string(data[infrav1.ProxmoxInsecureKey]) == "true",
+	}
+	switch {
+	case creds.URL == "":
+		return nil, forgeerrors.ConfigErrorf("the secret must have the %s key", infrav1.ProxmoxURLKey)
+	case creds.TokenID != "" && creds.TokenSecret != "":
+	case creds.Username != "" && creds.Password != "":
+	default:
+		return nil, forgeerrors.ConfigErrorf("the secret must have either the %s and %s keys, or the %s and %s keys",
+			infrav1.ProxmoxTokenIDKey, infrav1.ProxmoxTokenSecretKey, infrav1.ProxmoxUsernameKey, infrav1.ProxmoxPasswordKey)
+	}
+	return creds, nil
+}
+
+// Client is a client of the Proxmox VE API. It authenticates with the API token, or logs in with the password on
+// the first request and again when its ticket expires.
+type Client struct {
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+	// Endpoint is the URL of the API, e.g. https://pve.example.com:8006.
+	Endpoint    string
+	Credentials *Credentials
+
+	ticket string
+	csrf   string
+}
+
+var _ Proxmox = &Client{}
+
+// NewProxmox returns a client of the API of the credentials.
+func NewProxmox(_ context.Context, creds *Credentials) (Proxmox, error) {
+	return newClient(creds)
+}
+
+// newClient returns a client of the API of the credentials, verifying its certificate with the CA certificates of
+// the credentials.
+func newClient(creds *Credentials) (*Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: creds.Insecure} //nolint:gosec
+	if len(creds.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(creds.CACert) {
+			return nil, forgeerrors.ConfigErrorf("the %s of the secret holds no PEM certificate", infrav1.ProxmoxCACertKey)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	endpoint := creds.URL
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &Client{
+		HTTPClient:  &http.Client{Transport: transport},
+		Endpoint:    strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), apiPath),
+		Credentials: creds,
+	}, nil
+}
+
+// Login creates a ticket with the password, the API tokens need none.
+func (c *Client) Login(ctx context.Context) error {
+	form := url.Values{"username": {c.Credentials.Username}, "password": {c.Credentials.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+apiPath+"/access/ticket", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrap(err, "failed to log in"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := parseError(resp)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return forgeerrors.NewConfigError(errors.Wrapf(apiErr, "user %s has been rejected", c.Credentials.Username))
+		}
+		return classifier.Classify(apiErr)
+	}
+	ticket := &struct {
+		Data struct {
+			Ticket string `json:"ticket"`
+			CSRF   string `json:"CSRFPreventionToken"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(ticket); err != nil {
+		return errors.Wrap(err, "invalid ticket")
+	}
+	c.ticket = ticket.Data.Ticket
+	c.csrf = ticket.Data.CSRF
+	return nil
+}
+
+// NextID returns a free VM ID of the cluster.
+func (c *Client) NextID(ctx context.Context) (int32, error) {
+	var id string
+	if err := c.do(ctx, http.MethodGet, "/cluster/nextid", nil, &id); err != nil {
+		return 0, err
+	}
+	vmid, err := strconv.ParseInt(id, 10, 32)
+	return int32(vmid), err
+}
+
+// FindVM returns the VM or template of the given name, in any node of the cluster.
+func (c *Client) FindVM(ctx context.Context, name string) (*VM, error) {
+	var resources []VM
+	if err := c.do(ctx, http.MethodGet, "/cluster/resources", url.Values{"type": {"vm"}}, &resources); err != nil {
+		return nil, err
+	}
+	for i := range resources {
+		if resources[i].Name == name {
+			return &resources[i], nil
+		}
+	}
+	return nil, notFound("VM %s not found", name)
+}
+
+// GetVM returns the current status of the VM.
+func (c *Client) GetVM(ctx context.Context, node string, vmid int32) (*VM, error) {
+	vm := &VM{}
+	if err := c.do(ctx, http.MethodGet, vmPath(node, vmid)+"/status/current", nil, vm); err != nil {
+		return nil, err
+	}
+	vm.VMID = vmid
+	vm.Node = node
+	return vm, nil
+}
+
+// CloneVM clones the template, it returns the UPID of the clone task.
+func (c *Client) CloneVM(ctx context.Context, node string, source int32, spec *CloneSpec) (string, error) {
+	form := url.Values{"newid": {strconv.Itoa(int(spec.VMID))}, "name": {spec.Name}, "full": {"0"}}
+	if spec.Full {
+		form.Set("full", "1")
+		if spec.Storage != "" {
+			form.Set("storage", spec.Storage)
+		}
+	}
+	if spec.Target != "" && spec.Target != node {
+		form.Set("target", spec.Target)
+	}
+	if spec.Pool != "" {
+		form.Set("pool", spec.Pool)
+	}
+	var upid string
+	err := c.do(ctx, http.MethodPost, vmPath(node, source)+"/clone", form, &upid)
+	return upid, err
+}
+
+// CreateVM creates the VM with a disk, the ISO image and a network device, it returns the UPID of the create task.
+func (c *Client) CreateVM(ctx context.Context, node string, spec *CreateSpec) (string, error) {
+	form := url.Values{
+		"vmid":    {strconv.Itoa(int(spec.VMID))},
+		"name":    {spec.Name},
+		"ostype":  {spec.OSType},
+		"cores":   {strconv.Itoa(int(spec.Cores))},
+		"memory":  {strconv.FormatInt(spec.MemoryMiB, 10)},
+		"scsihw":  {"virtio-scsi-single"},
+		"scsi0":   {fmt.Sprintf("%s:%d", spec.Storage, spec.DiskSizeGiB)},
+		"ide2":    {spec.ISO + ",media=cdrom"},
+		"net0":    {"virtio,bridge=" + spec.Bridge},
+		"boot":    {"order=scsi0;ide2"},
+		"agent":   {"1"},
+		"onboot":  {"0"},
+		"serial0": {"socket"},
+	}
+	if spec.Pool != "" {
+		form.Set("pool", spec.Pool)
+	}
+	var upid string
+	err := c.do(ctx, http.MethodPost, "/nodes/"+node+"/qemu", form, &upid)
+	return upid, err
+}
+
+// ConfigureVM sets the configuration of the VM synchronously, the keys listed in delete are removed.
+func (c *Client) ConfigureVM(ctx context.Context, node string, vmid int32, config url.Values) error {
+	return c.do(ctx, http.MethodPut, vmPath(node, vmid)+"/config", config, nil)
+}
+
+// StartVM starts the VM, it returns the UPID of the start task.
+func (c *Client) StartVM(ctx context.Context, node string, vmid int32) (string, error) {
+	return c.vmTask(ctx, node, vmid, "/status/start")
+}
+
+// ShutdownVM shuts the guest OS down with ACPI, or with the guest agent when enabled.
+func (c *Client) ShutdownVM(ctx context.Context, node string, vmid int32) (string, error) {
+	return c.vmTask(ctx, node, vmid, "/status/shutdown")
+}
+
+// StopVM stops the VM immediately.
+func (c *Client) StopVM(ctx context.Context, node string, vmid int32) (string, error) {
+	return c.vmTask(ctx, node, vmid, "/status/stop")
+}
+
+// ConvertToTemplate converts the stopped VM into a template, the UPID is empty when the conversion is synchronous.
+func (c *Client) ConvertToTemplate(ctx context.Context, node string, vmid int32) (string, error) {
+	return c.vmTask(ctx, node, vmid, "/template")
+}
+
+func (c *Client) vmTask(ctx context.Context, node string, vmid int32, action string) (string, error) {
+	var upid *string
+	if err := c.do(ctx, http.MethodPost, vmPath(node, vmid)+action, url.Values{}, &upid); err != nil || upid == nil {
+		return "", err
+	}
+	return *upid, nil
+}
+
+// GuestIPAddress returns the first global IPv4 address reported by the guest agent, empty while the agent is not
+// running.
+func (c *Client) GuestIPAddress(ctx context.Context, node string, vmid int32) (string, error) {
+	result := &struct {
+		Result []struct {
+			Name        string `json:"name"`
+			IPAddresses []struct {
+				Address string `json:"ip-address"`
+				Type    string `json:"ip-address-type"`
+			} `json:"ip-addresses"`
+		} `json:"result"`
+	}{}
+	err := c.do(ctx, http.MethodGet, vmPath(node, vmid)+"/agent/network-get-interfaces", nil, result)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "not running") {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range result.Result {
+		for _, address := range iface.IPAddresses {
+			ip := net.ParseIP(address.Address)
+			if address.Type == "ipv4" && ip != nil && ip.IsGlobalUnicast() {
+				return address.Address, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// DeleteVM deletes the stopped VM and its disks, it returns the UPID of the destroy task.
+func (c *Client) DeleteVM(ctx context.Context, node string, vmid int32) (string, error) {
+	var upid string
+	query := url.Values{"purge": {"1"}, "destroy-unreferenced-disks": {"1"}}
+	err := c.do(ctx, http.MethodDelete, vmPath(node, vmid)+"?"+query.Encode(), nil, &upid)
+	return upid, err
+}
+
+// GetTask returns the status of the task.
+func (c *Client) GetTask(ctx context.Context, node, upid string) (*Task, error) {
+	task := &Task{}
+	if err := c.do(ctx, http.MethodGet, "/nodes/"+node+"/tasks/"+url.PathEscape(upid)+"/status", nil, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func vmPath(node string, vmid int32) string {
+	return fmt.Sprintf("/nodes/%s/qemu/%d", node, vmid)
+}
+
+// do sends the request with the form, as the query of the GET requests, and decodes the data of the response into
+// out. It logs in first when authenticating with a password and there is no ticket, and again once when the
+// ticket has expired. The errors are classified: throttling, locked VMs and server errors are retried, the invalid
+// requests are configuration errors.
+func (c *Client) do(ctx context.Context, method, path string, form url.Values, out interface{}) error {
+	target := c.Endpoint + apiPath + path
+	var body string
+	if method == http.MethodGet && len(form) > 0 {
+		target += "?" + form.Encode()
+	} else if form != nil {
+		body = form.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		useToken := c.Credentials.TokenID != ""
+		if !useToken && c.ticket == "" {
+			if err := c.Login(ctx); err != nil {
+				return err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if useToken {
+			req.Header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", c.Credentials.TokenID, c.Credentials.TokenSecret))
+		} else {
+			req.AddCookie(&http.Cookie{Name: "PVEAuthCookie", Value: c.ticket})
+			if method != http.MethodGet {
+				req.Header.Set(csrfHeader, c.csrf)
+			}
+		}
+		if form != nil && method != http.MethodGet {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, path))
+		}
+		err = decode(resp, out)
+		if resp.StatusCode == http.StatusUnauthorized && !useToken && attempt == 0 {
+			c.ticket = ""
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s %s", method, path)
+		}
+		return nil
+	}
+}
+
+// decode decodes the data of the response into out, or returns its classified error.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return classifier.Classify(parseError(resp))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	data := &struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return errors.Wrap(err, "invalid response")
+	}
+	return nil
+}
+
+// parseError returns the error of an error response of the API. The API reports the error in the reason phrase of
+// the status line, and the invalid parameters in the body.
+func parseError(resp *http.Response) *APIError {
+	message := strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+	data := apierror.ReadBody(resp.Body)
+	body := &struct {
+		Errors map[string]string `json:"errors"`
+	}{}
+	if err := json.Unmarshal(data, body); err == nil && len(body.Errors) > 0 {
+		params := make([]string, 0, len(body.Errors))
+		for param, reason := range body.Errors {
+			params = append(params, param+": "+strings.TrimSpace(reason))
+		}
+		message += " (" + strings.Join(params, ", ") + ")"
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing VMs, do log
+// in again.
+var classifier = apierror.Classifier{
+	Handled: func(err *APIError) bool {
+		return IsNotFound(err) || err.StatusCode == http.StatusUnauthorized
+	},
+	Transient: func(err *APIError) bool {
+		return strings.Contains(err.Message, "can't lock file")
+	},
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	tickets := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.ParseForm()).To(Succeed())
+		if r.URL.Path == apiPath+"/access/ticket" {
+			g.Expect(r.PostForm.Get("username")).To(Equal("forge@pve"))
+			tickets++
+			_, _ = fmt.Fprintf(w, `{"data":{"ticket":"PVE:forge@pve:%d","CSRFPreventionToken":"csrf-%d"}}`, tickets, tickets)
+			return
+		}
+		cookie, err := r.Cookie("PVEAuthCookie")
+		g.Expect(err).ToNot(HaveOccurred())
+		if r.Method != http.MethodGet {
+			g.Expect(r.Header.Get(csrfHeader)).To(Equal(fmt.Sprintf("csrf-%d", tickets)))
+		}
+		switch {
+		case cookie.Value == "PVE:forge@pve:1":
+			// The first ticket expires.
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == apiPath+"/cluster/resources":
+			g.Expect(r.URL.Query().Get("type")).To(Equal("vm"))
+			_, _ = w.Write([]byte(`{"data":[{"vmid":9000,"name":"ubuntu-22.04-cloud","node":"pve2","status":"stopped","template":1}]}`))
+		case r.URL.Path == apiPath+"/nodes/pve2/qemu/9000/clone":
+			g.Expect(r.PostForm).To(Equal(url.Values{
+				"newid": {"100"}, "name": {"ubuntu"}, "full": {"1"}, "storage": {"local-lvm"}, "target": {"pve1"},
+			}))
+			_, _ = w.Write([]byte(`{"data":"UPID:pve2:0001A2B3:qmclone:100:forge@pve:"}`))
+		case r.URL.Path == apiPath+"/nodes/pve1/qemu/100/config":
+			g.Expect(r.Method).To(Equal(http.MethodPut))
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"data":null,"errors":{"sshkeys":"invalid format - invalid urlencoded string\n"}}`))
+		case r.URL.Path == apiPath+"/nodes/pve1/qemu/100/agent/network-get-interfaces":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == apiPath+"/nodes/pve1/qemu/101/agent/network-get-interfaces":
+			_, _ = w.Write([]byte(`{"data":{"result":[
+				{"name":"lo","ip-addresses":[{"ip-address":"127.0.0.1","ip-address-type":"ipv4"}]},
+				{"name":"eth0","ip-addresses":[{"ip-address":"fe80::1","ip-address-type":"ipv6"},{"ip-address":"192.0.2.15","ip-address-type":"ipv4"}]}]}}`))
+		case r.URL.Path == apiPath+"/nodes/pve1/qemu/101/template":
+			_, _ = w.Write([]byte(`{"data":null}`))
+		case r.URL.Path == apiPath+"/nodes/pve1/tasks/UPID:pve1:0001A2B4:qmstart:101:forge@pve:/status":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), Endpoint: server.URL, Credentials: &Credentials{Username: "forge@pve", Password: "password"}}
+
+	// The client logs in again when its ticket expires.
+	template, err := c.FindVM(ctx, "ubuntu-22.04-cloud")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(template.IsTemplate()).To(BeTrue())
+	g.Expect(template.Node).To(Equal("pve2"))
+	g.Expect(tickets).To(Equal(2))
+	_, err = c.FindVM(ctx, "ubuntu")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+
+	upid, err := c.CloneVM(ctx, template.Node, template.VMID, &CloneSpec{VMID: 100, Name: "ubuntu", Target: "pve1", Full: true, Storage: "local-lvm"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(upid).To(Equal("UPID:pve2:0001A2B3:qmclone:100:forge@pve:"))
+
+	err = c.ConfigureVM(ctx, "pve1", 100, url.Values{"sshkeys": {"ssh-ed25519 AAAA"}})
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	g.Expect(err.Error()).To(ContainSubstring("sshkeys: invalid format - invalid urlencoded string"))
+	_, err = c.GuestIPAddress(ctx, "pve1", 100)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+
+	address, err := c.GuestIPAddress(ctx, "pve1", 101)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(address).To(Equal("192.0.2.15"))
+	upid, err = c.ConvertToTemplate(ctx, "pve1", 101)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(upid).To(BeEmpty())
+	_, err = c.GetTask(ctx, "pve1", "UPID:pve1:0001A2B4:qmstart:101:forge@pve:")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+}
+
+func TestClientAPIToken(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("Authorization")).To(Equal("PVEAPIToken=forge@pve!builds=secret"))
+		switch r.URL.Path {
+		case apiPath + "/cluster/nextid":
+			_, _ = w.Write([]byte(`{"data":"104"}`))
+		case apiPath + "/nodes/pve1/qemu/104/status/current":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"data":null}`))
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), Endpoint: server.URL, Credentials: &Credentials{TokenID: "forge@pve!builds", TokenSecret: "secret"}}
+
+	vmid, err := c.NextID(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vmid).To(BeEquivalentTo(104))
+	// The server errors are retried.
+	_, err = c.GetVM(ctx, "pve1", 104)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+}
+
+func TestIsNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(IsNotFound(&APIError{StatusCode: http.StatusInternalServerError, Message: "Configuration file 'nodes/pve1/qemu-server/104.conf' does not exist"})).To(BeTrue())
+	g.Expect(IsNotFound(forgeerrors.NewConfigError(notFound("VM %s not found", "ubuntu")))).To(BeTrue())
+	g.Expect(IsNotFound(&APIError{StatusCode: http.StatusInternalServerError, Message: "can't lock file '/var/lock/qemu-server/lock-104.conf'"})).To(BeFalse())
+}
+
+func TestCredentialsFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	creds, err := credentialsFrom(map[string][]byte{
+		infrav1.ProxmoxURLKey:         []byte("https://pve.example.com:8006"),
+		infrav1.ProxmoxTokenIDKey:     []byte("forge@pve!builds"),
+		infrav1.ProxmoxTokenSecretKey: []byte("secret"),
+		infrav1.ProxmoxInsecureKey:    []byte("true"),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(creds.Insecure).To(BeTrue())
+
+	_, err = credentialsFrom(map[string][]byte{
+		infrav1.ProxmoxURLKey:      []byte("https://pve.example.com:8006"),
+		infrav1.ProxmoxUsernameKey: []byte("forge@pve"),
+	})
+	g.Expect(err).To(MatchError(ContainSubstring(infrav1.ProxmoxPasswordKey)))
+
+	_, err = credentialsFrom(map[string][]byte{infrav1.ProxmoxTokenIDKey: []byte("forge@pve!builds")})
+	g.Expect(err).To(MatchError(ContainSubstring(infrav1.ProxmoxURLKey)))
+}
@@ -0,0 +1,581 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the ProxmoxBuild controller, used in the controller metrics.
+	ControllerName = "proxmoxbuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "proxmox"
+
+	// taskRequeueAfter is how often a task of a VM is checked.
+	taskRequeueAfter = 5 * time.Second
+
+	// vmRequeueAfter is how often a VM being started or shut down is checked.
+	vmRequeueAfter = 10 * time.Second
+
+	// The defaults of the VMs booted from an ISO image.
+	defaultCores     = 2
+	defaultMemoryMiB = 4096
+	defaultOSType    = "l26"
+	defaultBridge    = "vmbr0"
+
+	// templateFormat is the format of the artifacts of the ProxmoxBuilds.
+	templateFormat = "proxmox-template"
+)
+
+// ProxmoxBuildReconciler reconciles a ProxmoxBuild object
+type ProxmoxBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewProxmox returns the client of the Proxmox VE API, NewProxmox is used if nil.
+	NewProxmox func(ctx context.Context, creds *Credentials) (Proxmox, error)
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProxmoxBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ProxmoxBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("ProxmoxBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("proxmoxbuild-controller")
+	return nil
+}
+
+// Reconcile creates the VM of a ProxmoxBuild, publishes its address in the connector credentials of the Build and,
+// once the provisioners of the Build are ready, shuts it down and converts it into a template.
+func (r *ProxmoxBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	proxmoxBuild := &infrav1.ProxmoxBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, proxmoxBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(proxmoxBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(proxmoxBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(proxmoxBuild)
+		if err := patchHelper.Patch(ctx, proxmoxBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, proxmoxBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !proxmoxBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, proxmoxBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the ProxmoxBuild")
+		return ctrl.Result{}, nil
+	}
+	if proxmoxBuild.Status.FailureReason != nil || proxmoxBuild.Status.Ready {
+		// The VM of a failed ProxmoxBuild is deleted along with it, the VM of a completed one is the template.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(proxmoxBuild, infrav1.ProxmoxBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, proxmoxBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, proxmoxBuild, res, err)
+}
+
+func (r *ProxmoxBuildReconciler) reconcileNormal(ctx context.Context, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) (ctrl.Result, error) {
+	pve, err := r.proxmox(ctx, proxmoxBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if running, err := r.waitForTask(ctx, pve, proxmoxBuild); err != nil || running {
+		return ctrl.Result{RequeueAfter: taskRequeueAfter}, err
+	}
+
+	vm, err := r.reconcileVM(ctx, pve, proxmoxBuild, build)
+	if err != nil || vm == nil {
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(proxmoxBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileTemplate(ctx, pve, proxmoxBuild, build, vm)
+}
+
+// waitForTask returns true while the task of the VM is running, and a terminal error if it failed.
+func (r *ProxmoxBuildReconciler) waitForTask(ctx context.Context, pve Proxmox, proxmoxBuild *infrav1.ProxmoxBuild) (bool, error) {
+	status := &proxmoxBuild.Status
+	if status.Task == "" {
+		return false, nil
+	}
+	task, err := pve.GetTask(ctx, proxmoxBuild.Spec.Node, status.Task)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get task %s", status.Task)
+	}
+	if task.Status != TaskStopped {
+		return true, nil
+	}
+	if !task.Succeeded() {
+		reason := forgeerrors.CreateBuildError
+		if status.MachineReady {
+			reason = forgeerrors.ExportFailedBuildError
+		}
+		return false, forgeerrors.Terminalf(reason, "task %s of VM %s failed: %s", status.Task, status.VMName, task.ExitStatus)
+	}
+	ctrl.LoggerFrom(ctx).Info("Task succeeded", "task", status.Task)
+	status.Task = ""
+	return false, nil
+}
+
+// reconcileVM creates and configures the VM, starts it, and publishes its address once the guest agent reports it.
+// It returns nil while the VM is being started.
+func (r *ProxmoxBuildReconciler) reconcileVM(ctx context.Context, pve Proxmox, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) (*VM, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &proxmoxBuild.Spec
+	status := &proxmoxBuild.Status
+
+	name := cmp.Or(status.VMName, providersdk.MachineName(proxmoxBuild))
+	if status.VMID == 0 {
+		// The VM may have been created by a reconciliation which failed to record it.
+		vm, err := pve.FindVM(ctx, name)
+		if IsNotFound(err) {
+			log.Info("Creating VM", "vm", name)
+			if err := r.createVM(ctx, pve, proxmoxBuild, build, name); err != nil {
+				return nil, errors.Wrapf(err, "failed to create VM %s", name)
+			}
+			r.recorder.Eventf(proxmoxBuild, corev1.EventTypeNormal, "VMCreated", "Created VM %s (%d) on node %s", name, status.VMID, spec.Node)
+			conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Creating VM %s", name)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find VM %s", name)
+		}
+		status.VMName = name
+		status.VMID = vm.VMID
+	}
+	vm, err := pve.GetVM(ctx, spec.Node, status.VMID)
+	if IsNotFound(err) {
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s has been deleted", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VM %s", name)
+	}
+
+	if status.MachineReady {
+		if vm.Status != VMRunning && !build.Status.ProvisionersReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s while the provisioners are running", name, vm.Status)
+		}
+		return vm, nil
+	}
+
+	// The configuration is set on the stopped VM, it is started by the next reconciliation.
+	if !status.Configured && vm.Status == VMStopped {
+		config, err := r.configFor(ctx, proxmoxBuild, build)
+		if err != nil {
+			return nil, err
+		}
+		if len(config) > 0 {
+			if err := pve.ConfigureVM(ctx, spec.Node, vm.VMID, config); err != nil {
+				return nil, errors.Wrapf(err, "failed to configure VM %s", name)
+			}
+			log.Info("Configured VM", "vm", name)
+		}
+		status.Configured = true
+		conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Configured VM %s", name)
+		return nil, nil
+	}
+
+	switch vm.Status {
+	case VMRunning:
+	case VMStopped:
+		upid, err := pve.StartVM(ctx, spec.Node, vm.VMID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to start VM %s", name)
+		}
+		log.Info("Starting VM", "vm", name)
+		status.Task = upid
+		conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Starting VM %s", name)
+		return nil, nil
+	default:
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s", name, vm.Status)
+	}
+
+	address, err := pve.GuestIPAddress(ctx, spec.Node, vm.VMID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the IP of VM %s", name)
+	}
+	if address == "" {
+		conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Waiting for the guest agent of VM %s to report its IP", name)
+		return nil, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	r.recorder.Eventf(proxmoxBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	return vm, nil
+}
+
+// createVM clones the VM from the template, or creates it to boot from the ISO image, and records the task
+// creating it.
+func (r *ProxmoxBuildReconciler) createVM(ctx context.Context, pve Proxmox, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build, name string) error {
+	spec := &proxmoxBuild.Spec
+	status := &proxmoxBuild.Status
+
+	if customization(proxmoxBuild) == infrav1.ProxmoxCustomizationCloudInit {
+		creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultProxmoxUsername))
+		if err != nil {
+			return err
+		}
+		if creds.AuthorizedKey == "" && creds.Password == "" {
+			return forgeerrors.ConfigErrorf("the connector credentials of Build %s have neither a private key nor a password", build.Name)
+		}
+	}
+	vmid, err := pve.NextID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a free VM ID")
+	}
+
+	var upid string
+	if source := spec.Clone; source != nil {
+		template, err := pve.FindVM(ctx, source.Template)
+		if err != nil {
+			return configIfNotFound(err)
+		}
+		if !template.IsTemplate() {
+			return forgeerrors.ConfigErrorf("VM %s is not a template", source.Template)
+		}
+		upid, err = pve.CloneVM(ctx, template.Node, template.VMID, &CloneSpec{
+			VMID:    vmid,
+			Name:    name,
+			Pool:    spec.Pool,
+			Target:  spec.Node,
+			Full:    !source.Linked,
+			Storage: source.Storage,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		iso := spec.ISO
+		upid, err = pve.CreateVM(ctx, spec.Node, &CreateSpec{
+			VMID:        vmid,
+			Name:        name,
+			Pool:        spec.Pool,
+			OSType:      cmp.Or(iso.OSType, defaultOSType),
+			Cores:       cmp.Or(spec.Cores, defaultCores),
+			MemoryMiB:   cmp.Or(spec.MemoryMiB, defaultMemoryMiB),
+			ISO:         iso.ISO,
+			Storage:     iso.Storage,
+			DiskSizeGiB: iso.DiskSizeGiB,
+			Bridge:      cmp.Or(iso.Bridge, defaultBridge),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	status.VMName = name
+	status.VMID = vmid
+	status.Task = upid
+	return nil
+}
+
+// configFor returns the configuration of a cloned VM: its hardware, and the connector credentials of its cloud-init
+// drive. The VMs booted from an ISO image are configured when created.
+func (r *ProxmoxBuildReconciler) configFor(ctx context.Context, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) (url.Values, error) {
+	spec := &proxmoxBuild.Spec
+	config := url.Values{}
+	if spec.Clone == nil {
+		return config, nil
+	}
+	if spec.Cores > 0 {
+		config.Set("cores", strconv.Itoa(int(spec.Cores)))
+	}
+	if spec.MemoryMiB > 0 {
+		config.Set("memory", strconv.FormatInt(spec.MemoryMiB, 10))
+	}
+	if customization(proxmoxBuild) != infrav1.ProxmoxCustomizationCloudInit {
+		return config, nil
+	}
+
+	creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultProxmoxUsername))
+	if err != nil {
+		return nil, err
+	}
+	if spec.Clone.CloudInitStorage != "" {
+		config.Set("ide2", spec.Clone.CloudInitStorage+":cloudinit")
+	}
+	config.Set("agent", "1")
+	config.Set("ciuser", creds.Username)
+	config.Set("ipconfig0", "ip=dhcp")
+	if creds.AuthorizedKey != "" {
+		config.Set("sshkeys", encodeSSHKeys(creds.AuthorizedKey))
+	}
+	if creds.Password != "" {
+		config.Set("cipassword", creds.Password)
+	}
+	return config, nil
+}
+
+// reconcileTemplate shuts the VM down, removes the connector credentials from its cloud-init drive, and converts
+// it into the template.
+func (r *ProxmoxBuildReconciler) reconcileTemplate(ctx context.Context, pve Proxmox, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build, vm *VM) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &proxmoxBuild.Spec
+	status := &proxmoxBuild.Status
+	name := imageName(proxmoxBuild, build)
+
+	if !vm.IsTemplate() {
+		if vm.Status != VMStopped {
+			if conditions.GetReason(proxmoxBuild, infrav1.ImageReadyCondition) != infrav1.MachineStoppingReason {
+				if _, err := pve.ShutdownVM(ctx, spec.Node, vm.VMID); err != nil {
+					return ctrl.Result{}, errors.Wrapf(err, "failed to shut down VM %s", status.VMName)
+				}
+				log.Info("Shutting down VM", "vm", status.VMName)
+				r.recorder.Eventf(proxmoxBuild, corev1.EventTypeNormal, infrav1.MachineStoppingReason, "Shutting down VM %s", status.VMName)
+			}
+			conditions.MarkFalse(proxmoxBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Shutting down VM %s", status.VMName)
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+		}
+
+		config := url.Values{"name": {name}, "description": {descriptionFor(proxmoxBuild, build)}}
+		if customization(proxmoxBuild) == infrav1.ProxmoxCustomizationCloudInit {
+			config.Set("delete", "ciuser,cipassword,sshkeys")
+		}
+		if err := pve.ConfigureVM(ctx, spec.Node, vm.VMID, config); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to configure VM %s", status.VMName)
+		}
+		upid, err := pve.ConvertToTemplate(ctx, spec.Node, vm.VMID)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to convert VM %s into a template", status.VMName)
+		}
+		log.Info("Converting VM into template", "vm", status.VMName, "template", name)
+		r.recorder.Eventf(proxmoxBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Converting VM %s into template %s", status.VMName, name)
+		if upid != "" {
+			status.Task = upid
+			conditions.MarkFalse(proxmoxBuild, infrav1.ImageReadyCondition, infrav1.ImageCreatingReason, "Converting VM %s into template %s", status.VMName, name)
+			return ctrl.Result{RequeueAfter: taskRequeueAfter}, nil
+		}
+	}
+
+	ref := fmt.Sprintf("%s/%d", spec.Node, vm.VMID)
+	status.ImageRef = ref
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:        strconv.Itoa(int(vm.VMID)),
+		Location:  ref,
+		Format:    templateFormat,
+		CreatedAt: ptrToNow(),
+	}
+	status.Ready = true
+	conditions.MarkTrue(proxmoxBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Template %s (%s) is ready", name, ref)
+	r.recorder.Eventf(proxmoxBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Template %s (%s) is ready", name, ref)
+	return ctrl.Result{}, nil
+}
+
+// reconcileDelete stops and deletes the VM of a ProxmoxBuild which is not ready, the VM of a ready one is the
+// template and is kept.
+func (r *ProxmoxBuildReconciler) reconcileDelete(ctx context.Context, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(proxmoxBuild, infrav1.ProxmoxBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(proxmoxBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	status := &proxmoxBuild.Status
+	if !status.Ready && status.VMID != 0 {
+		pve, err := r.proxmox(ctx, proxmoxBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// The VM is locked by its running task, e.g. its clone.
+		if status.Task != "" {
+			task, err := pve.GetTask(ctx, proxmoxBuild.Spec.Node, status.Task)
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to get task %s", status.Task)
+			}
+			if task.Status != TaskStopped {
+				return ctrl.Result{RequeueAfter: taskRequeueAfter}, nil
+			}
+			status.Task = ""
+		}
+
+		name := status.VMName
+		vm, err := pve.GetVM(ctx, proxmoxBuild.Spec.Node, status.VMID)
+		switch {
+		case IsNotFound(err):
+		case err != nil:
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get VM %s", name)
+		case vm.IsTemplate():
+			log.Info("Keeping VM converted into a template", "vm", name)
+		case vm.Status != VMStopped:
+			if status.Task, err = pve.StopVM(ctx, proxmoxBuild.Spec.Node, status.VMID); err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to stop VM %s", name)
+			}
+			log.Info("Stopping VM", "vm", name)
+			return ctrl.Result{RequeueAfter: taskRequeueAfter}, nil
+		default:
+			if _, err := pve.DeleteVM(ctx, proxmoxBuild.Spec.Node, status.VMID); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete VM %s", name)
+			}
+			log.Info("Deleted VM", "vm", name)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(proxmoxBuild, infrav1.ProxmoxBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// proxmox returns the client of the Proxmox VE API of the credentials of the ProxmoxBuild.
+func (r *ProxmoxBuildReconciler) proxmox(ctx context.Context, proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) (Proxmox, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the ProxmoxBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: proxmoxBuild.Namespace, Name: proxmoxBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, proxmoxBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := credentialsFrom(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+	}
+	newProxmox := r.NewProxmox
+	if newProxmox == nil {
+		newProxmox = NewProxmox
+	}
+	return newProxmox(ctx, creds)
+}
+
+// configIfNotFound returns the not found errors of the objects named in the spec as configuration errors.
+func configIfNotFound(err error) error {
+	if IsNotFound(err) {
+		return forgeerrors.NewConfigError(err)
+	}
+	return err
+}
+
+// customization returns how the connector credentials are authorized on the VM.
+func customization(proxmoxBuild *infrav1.ProxmoxBuild) infrav1.ProxmoxCustomization {
+	if proxmoxBuild.Spec.Clone == nil {
+		return infrav1.ProxmoxCustomizationNone
+	}
+	return cmp.Or(proxmoxBuild.Spec.Clone.Customization, infrav1.ProxmoxCustomizationCloudInit)
+}
+
+// encodeSSHKeys returns the authorized keys percent-encoded, as expected by the sshkeys option of the API.
+func encodeSSHKeys(keys string) string {
+	return strings.ReplaceAll(url.QueryEscape(keys), "+", "%20")
+}
+
+// imageName returns the name of the template, the spec.imageName of the Build, or the name of the ProxmoxBuild.
+// The names of the VMs are DNS names.
+func imageName(proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) string {
+	return providersdk.SanitizeName(cmp.Or(build.Spec.ImageName, proxmoxBuild.Name), 63)
+}
+
+// descriptionFor returns the description of the template: the description of the spec, and the image metadata of
+// the Build.
+func descriptionFor(proxmoxBuild *infrav1.ProxmoxBuild, build *buildv1.Build) string {
+	lines := []string{}
+	if proxmoxBuild.Spec.Template.Description != "" {
+		lines = append(lines, proxmoxBuild.Spec.Template.Description)
+	}
+	for _, key := range sortedKeys(build.Status.ImageMetadata) {
+		lines = append(lines, key+"="+build.Status.ImageMetadata[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ptrToNow() *metav1.Time {
+	now := metav1.Now()
+	return &now
+}
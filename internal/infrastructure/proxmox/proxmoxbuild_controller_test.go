@@ -0,0 +1,433 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeProxmox is an in-memory Proxmox VE cluster, its tasks run until they are finished by the tests.
+type fakeProxmox struct {
+	nextID    int32
+	vms       map[int32]*VM
+	clones    map[int32]*CloneSpec
+	creates   map[int32]*CreateSpec
+	configs   map[int32]url.Values
+	addresses map[int32]string
+	tasks     map[string]*Task
+	shutdowns int
+}
+
+func newFakeProxmox() *fakeProxmox {
+	return &fakeProxmox{
+		nextID:    100,
+		vms:       map[int32]*VM{9000: {VMID: 9000, Name: "ubuntu-22.04-cloud", Node: "pve2", Status: VMStopped, Template: 1}},
+		clones:    map[int32]*CloneSpec{},
+		creates:   map[int32]*CreateSpec{},
+		configs:   map[int32]url.Values{},
+		addresses: map[int32]string{},
+		tasks:     map[string]*Task{},
+	}
+}
+
+// task starts a task and returns its UPID.
+func (f *fakeProxmox) task(action string, vmid int32) string {
+	upid := fmt.Sprintf("UPID:pve1:%08X:%s:%d", len(f.tasks)+1, action, vmid)
+	f.tasks[upid] = &Task{Status: "running"}
+	return upid
+}
+
+// finish finishes the task with the exit status.
+func (f *fakeProxmox) finish(upid, exitStatus string) {
+	f.tasks[upid] = &Task{Status: TaskStopped, ExitStatus: exitStatus}
+}
+
+func (f *fakeProxmox) NextID(_ context.Context) (int32, error) {
+	id := f.nextID
+	f.nextID++
+	return id, nil
+}
+
+func (f *fakeProxmox) FindVM(_ context.Context, name string) (*VM, error) {
+	for _, vm := range f.vms {
+		if vm.Name == name {
+			return vm, nil
+		}
+	}
+	return nil, notFound("VM %s not found", name)
+}
+
+func (f *fakeProxmox) GetVM(_ context.Context, _ string, vmid int32) (*VM, error) {
+	vm, ok := f.vms[vmid]
+	if !ok {
+		return nil, notFound("Configuration file 'nodes/pve1/qemu-server/%d.conf' does not exist", vmid)
+	}
+	return vm, nil
+}
+
+func (f *fakeProxmox) CloneVM(_ context.Context, _ string, _ int32, spec *CloneSpec) (string, error) {
+	f.vms[spec.VMID] = &VM{VMID: spec.VMID, Name: spec.Name, Node: spec.Target, Status: VMStopped}
+	f.clones[spec.VMID] = spec
+	return f.task("qmclone", spec.VMID), nil
+}
+
+func (f *fakeProxmox) CreateVM(_ context.Context, node string, spec *CreateSpec) (string, error) {
+	f.vms[spec.VMID] = &VM{VMID: spec.VMID, Name: spec.Name, Node: node, Status: VMStopped}
+	f.creates[spec.VMID] = spec
+	return f.task("qmcreate", spec.VMID), nil
+}
+
+func (f *fakeProxmox) ConfigureVM(_ context.Context, _ string, vmid int32, config url.Values) error {
+	if f.configs[vmid] == nil {
+		f.configs[vmid] = url.Values{}
+	}
+	for key, values := range config {
+		f.configs[vmid][key] = values
+	}
+	return nil
+}
+
+func (f *fakeProxmox) StartVM(_ context.Context, _ string, vmid int32) (string, error) {
+	return f.task("qmstart", vmid), nil
+}
+
+func (f *fakeProxmox) ShutdownVM(_ context.Context, _ string, vmid int32) (string, error) {
+	f.shutdowns++
+	return f.task("qmshutdown", vmid), nil
+}
+
+func (f *fakeProxmox) StopVM(_ context.Context, _ string, vmid int32) (string, error) {
+	f.vms[vmid].Status = VMStopped
+	return f.task("qmstop", vmid), nil
+}
+
+func (f *fakeProxmox) GuestIPAddress(_ context.Context, _ string, vmid int32) (string, error) {
+	return f.addresses[vmid], nil
+}
+
+func (f *fakeProxmox) ConvertToTemplate(_ context.Context, _ string, vmid int32) (string, error) {
+	f.vms[vmid].Template = 1
+	return f.task("qmtemplate", vmid), nil
+}
+
+func (f *fakeProxmox) DeleteVM(_ context.Context, _ string, vmid int32) (string, error) {
+	delete(f.vms, vmid)
+	return f.task("qmdestroy", vmid), nil
+}
+
+func (f *fakeProxmox) GetTask(_ context.Context, _, upid string) (*Task, error) {
+	task, ok := f.tasks[upid]
+	if !ok {
+		return nil, notFound("task %s not found", upid)
+	}
+	return task, nil
+}
+
+func TestProxmoxBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, proxmoxBuild := setupTest(g)
+	pve := newFakeProxmox()
+	r := newReconciler(c, pve)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxBuild)}
+
+	// The VM is cloned from the template, to the node of the spec.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Finalizers).To(ContainElement(infrav1.ProxmoxBuildFinalizer))
+	name := providersdk.MachineName(proxmoxBuild)
+	g.Expect(proxmoxBuild.Status.VMName).To(Equal(name))
+	g.Expect(proxmoxBuild.Status.VMID).To(BeEquivalentTo(100))
+	g.Expect(pve.clones[100]).To(Equal(&CloneSpec{VMID: 100, Name: name, Pool: "builds", Target: "pve1", Full: true, Storage: "local-lvm"}))
+	clone := proxmoxBuild.Status.Task
+	g.Expect(clone).ToNot(BeEmpty())
+
+	// The VM is configured once cloned, and started.
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(taskRequeueAfter))
+	g.Expect(pve.configs).To(BeEmpty())
+	pve.finish(clone, TaskOK)
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	config := pve.configs[100]
+	g.Expect(config.Get("cores")).To(Equal("4"))
+	g.Expect(config.Get("ide2")).To(Equal("local-lvm:cloudinit"))
+	g.Expect(config.Get("ciuser")).To(Equal(infrav1.DefaultProxmoxUsername))
+	g.Expect(config.Get("ipconfig0")).To(Equal("ip=dhcp"))
+	g.Expect(config.Get("sshkeys")).To(HavePrefix("ssh-rsa%20"))
+	g.Expect(config).ToNot(HaveKey("memory"))
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Status.Configured).To(BeTrue())
+	pve.finish(proxmoxBuild.Status.Task, TaskOK)
+	pve.vms[100].Status = VMRunning
+
+	// The address is published once reported by the guest agent.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Status.Task).To(BeEmpty())
+	g.Expect(proxmoxBuild.Status.MachineReady).To(BeFalse())
+	pve.addresses[100] = "192.0.2.15"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(proxmoxBuild.Status.Address).To(Equal("192.0.2.15"))
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("192.0.2.15"))
+
+	// The VM is shut down once the provisioners are ready, once.
+	build.Status.ProvisionersReady = true
+	build.Status.ImageMetadata = map[string]string{"os_version": "22.04"}
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	for range 2 {
+		res, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	}
+	g.Expect(pve.shutdowns).To(Equal(1))
+
+	// The VM is converted into a template once stopped, without the connector credentials.
+	pve.vms[100].Status = VMStopped
+	res, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(taskRequeueAfter))
+	g.Expect(config.Get("name")).To(Equal("ubuntu-22-04"))
+	g.Expect(config.Get("description")).To(Equal("Ubuntu 22.04 built by forge\nos_version=22.04"))
+	g.Expect(config.Get("delete")).To(Equal("ciuser,cipassword,sshkeys"))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(proxmoxBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.ImageCreatingReason))
+	pve.finish(proxmoxBuild.Status.Task, TaskOK)
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Status.Ready).To(BeTrue())
+	g.Expect(proxmoxBuild.Status.ImageRef).To(Equal("pve1/100"))
+	g.Expect(proxmoxBuild.Status.Artifact.ID).To(Equal("100"))
+	g.Expect(proxmoxBuild.Status.Artifact.Format).To(Equal(templateFormat))
+	g.Expect(conditions.IsTrue(proxmoxBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The template is kept when the ProxmoxBuild is deleted.
+	g.Expect(c.Delete(ctx, proxmoxBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pve.vms).To(HaveKey(BeEquivalentTo(100)))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).ToNot(Succeed())
+}
+
+func TestProxmoxBuildReconcileISO(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, proxmoxBuild := setupTest(g)
+	proxmoxBuild.Spec.Clone = nil
+	proxmoxBuild.Spec.Cores = 0
+	proxmoxBuild.Spec.ISO = &infrav1.ProxmoxISOSource{ISO: "local:iso/ubuntu-24.04-autoinstall.iso", Storage: "local-lvm", DiskSizeGiB: 20}
+	g.Expect(c.Update(ctx, proxmoxBuild)).To(Succeed())
+	pve := newFakeProxmox()
+	r := newReconciler(c, pve)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxBuild)}
+
+	// The VM is created with the defaults, and started without configuration.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pve.creates[100]).To(Equal(&CreateSpec{
+		VMID: 100, Name: providersdk.MachineName(proxmoxBuild), Pool: "builds", OSType: "l26", Cores: 2, MemoryMiB: 4096,
+		ISO: "local:iso/ubuntu-24.04-autoinstall.iso", Storage: "local-lvm", DiskSizeGiB: 20, Bridge: "vmbr0",
+	}))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	pve.finish(proxmoxBuild.Status.Task, TaskOK)
+	for range 2 {
+		_, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(pve.configs).To(BeEmpty())
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	g.Expect(proxmoxBuild.Status.Task).To(ContainSubstring("qmstart"))
+
+	// The running VM is stopped and deleted with the ProxmoxBuild.
+	pve.finish(proxmoxBuild.Status.Task, TaskOK)
+	pve.vms[100].Status = VMRunning
+	g.Expect(c.Delete(ctx, proxmoxBuild)).To(Succeed())
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(taskRequeueAfter))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+	pve.finish(proxmoxBuild.Status.Task, TaskOK)
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pve.vms).ToNot(HaveKey(BeEquivalentTo(100)))
+	g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).ToNot(Succeed())
+}
+
+func TestProxmoxBuildReconcileFailure(t *testing.T) {
+	testcases := []struct {
+		name    string
+		prepare func(proxmoxBuild *infrav1.ProxmoxBuild, pve *fakeProxmox)
+		reason  forgeerrors.BuildStatusError
+		message string
+	}{
+		{
+			name: "source is not a template",
+			prepare: func(_ *infrav1.ProxmoxBuild, pve *fakeProxmox) {
+				pve.vms[9000].Template = 0
+			},
+			reason:  forgeerrors.InvalidConfigurationBuildError,
+			message: "VM ubuntu-22.04-cloud is not a template",
+		},
+		{
+			name: "failed clone",
+			prepare: func(proxmoxBuild *infrav1.ProxmoxBuild, pve *fakeProxmox) {
+				proxmoxBuild.Status.VMName = "ubuntu"
+				proxmoxBuild.Status.VMID = 100
+				proxmoxBuild.Status.Task = pve.task("qmclone", 100)
+				pve.finish(proxmoxBuild.Status.Task, "storage 'local-lvm' does not exist")
+			},
+			reason:  forgeerrors.CreateBuildError,
+			message: "storage 'local-lvm' does not exist",
+		},
+		{
+			name: "deleted VM",
+			prepare: func(proxmoxBuild *infrav1.ProxmoxBuild, _ *fakeProxmox) {
+				proxmoxBuild.Status.VMName = "ubuntu"
+				proxmoxBuild.Status.VMID = 100
+			},
+			reason:  forgeerrors.CreateBuildError,
+			message: "VM ubuntu has been deleted",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c, _, proxmoxBuild := setupTest(g)
+			pve := newFakeProxmox()
+			tc.prepare(proxmoxBuild, pve)
+			status := proxmoxBuild.Status.DeepCopy()
+			g.Expect(c.Update(ctx, proxmoxBuild)).To(Succeed())
+			proxmoxBuild.Status = *status
+			g.Expect(c.Status().Update(ctx, proxmoxBuild)).To(Succeed())
+			r := newReconciler(c, pve)
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxBuild)}
+
+			_, err := r.Reconcile(ctx, req)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c.Get(ctx, req.NamespacedName, proxmoxBuild)).To(Succeed())
+			g.Expect(proxmoxBuild.Status.FailureReason).To(HaveValue(Equal(tc.reason)))
+			g.Expect(*proxmoxBuild.Status.FailureMessage).To(ContainSubstring(tc.message))
+			g.Expect(conditions.GetReason(proxmoxBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+		})
+	}
+}
+
+func TestEncodeSSHKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(encodeSSHKeys("ssh-ed25519 AAAA+b/c= forge\n")).To(Equal("ssh-ed25519%20AAAA%2Bb%2Fc%3D%20forge%0A"))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.ProxmoxBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "ProxmoxBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	proxmoxBuild := &infrav1.ProxmoxBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "proxmoxbuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.ProxmoxBuildSpec{
+			Node:           "pve1",
+			Clone:          &infrav1.ProxmoxCloneSource{Template: "ubuntu-22.04-cloud", Storage: "local-lvm", CloudInitStorage: "local-lvm"},
+			Pool:           "builds",
+			Cores:          4,
+			CredentialsRef: &corev1.LocalObjectReference{Name: "proxmox"},
+			Template:       infrav1.ProxmoxTemplateSpec{Description: "Ubuntu 22.04 built by forge"},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "proxmox"},
+		Data: map[string][]byte{
+			infrav1.ProxmoxURLKey:         []byte("https://pve.example.com:8006"),
+			infrav1.ProxmoxTokenIDKey:     []byte("forge@pve!builds"),
+			infrav1.ProxmoxTokenSecretKey: []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, proxmoxBuild, credentials).
+		WithStatusSubresource(build, proxmoxBuild).
+		Build()
+	return c, build, proxmoxBuild
+}
+
+func newReconciler(c client.Client, pve Proxmox) *ProxmoxBuildReconciler {
+	return &ProxmoxBuildReconciler{
+		Client: c,
+		NewProxmox: func(_ context.Context, _ *Credentials) (Proxmox, error) {
+			return pve, nil
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+}
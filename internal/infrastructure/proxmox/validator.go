@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/identity"
+)
+
+// Validator validates the API tokens and users of the proxmox ProviderIdentities, by getting the version of the API
+// of their secret.
+type Validator struct {
+	// HTTPClient sends the requests, a client verifying the certificate with the caCert of the secret is used if
+	// nil.
+	HTTPClient *http.Client
+}
+
+var _ identity.Validator = &Validator{}
+
+// Validate returns an error if the secret is incomplete or its API token or user is rejected.
+func (v *Validator) Validate(ctx context.Context, _ *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	creds, err := credentialsFrom(secret.Data)
+	if err != nil {
+		return err
+	}
+	c, err := newClient(creds)
+	if err != nil {
+		return err
+	}
+	if v.HTTPClient != nil {
+		c.HTTPClient = v.HTTPClient
+	}
+	// The users are rejected by the login, the API tokens by the request.
+	err = c.do(ctx, http.MethodGet, "/version", nil, nil)
+	apiErr := &APIError{}
+	if creds.TokenID != "" && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return forgeerrors.NewConfigError(errors.Wrapf(err, "API token %s has been rejected", creds.TokenID))
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		secret    map[string][]byte
+		valid     bool
+		retryable bool
+	}{
+		{name: "valid API token", status: http.StatusOK, valid: true},
+		{name: "rejected API token", status: http.StatusUnauthorized},
+		{name: "API unavailable", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "rejected user", status: http.StatusUnauthorized, secret: map[string][]byte{
+			infrav1.ProxmoxUsernameKey: []byte("forge@pve"),
+			infrav1.ProxmoxPasswordKey: []byte("password"),
+		}},
+		{name: "incomplete secret", secret: map[string][]byte{infrav1.ProxmoxTokenIDKey: []byte("forge@pve!builds")}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.URL.Path).To(BeElementOf(apiPath+"/version", apiPath+"/access/ticket"))
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"data":{"version":"8.2.4","release":"8.2"}}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: map[string][]byte{infrav1.ProxmoxURLKey: []byte(server.URL)}}
+			if tc.secret == nil {
+				tc.secret = map[string][]byte{
+					infrav1.ProxmoxTokenIDKey:     []byte("forge@pve!builds"),
+					infrav1.ProxmoxTokenSecretKey: []byte("secret"),
+				}
+			}
+			for key, value := range tc.secret {
+				secret.Data[key] = value
+			}
+			v := &Validator{HTTPClient: server.Client()}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package static implements the StaticBuild infrastructure provider, running the provisioners of a Build on a
+// pre-existing machine: its address and credentials are published in the connector credentials of the Build, and
+// the machine is neither created, captured nor deleted.
+package static
+
+import (
+	"context"
+	"maps"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the StaticBuild controller, used in the controller metrics.
+	ControllerName = "staticbuild"
+
+	// ProviderName is the name of the provider in the --infrastructure-providers flag.
+	ProviderName = "static"
+)
+
+// StaticBuildReconciler reconciles a StaticBuild object
+type StaticBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StaticBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.StaticBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("StaticBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("staticbuild-controller")
+	return nil
+}
+
+// Reconcile publishes the address and the credentials of the machine of a StaticBuild in the connector credentials
+// of the Build, reporting the machine ready at once, and reports the StaticBuild ready once the provisioners of
+// the Build are.
+func (r *StaticBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	staticBuild := &infrav1.StaticBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, staticBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(staticBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// The machine outlives the StaticBuild, there is nothing to clean up.
+	if !staticBuild.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(staticBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(staticBuild)
+		if err := patchHelper.Patch(ctx, staticBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, staticBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(staticBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the StaticBuild")
+		return ctrl.Result{}, nil
+	}
+	if staticBuild.Status.FailureReason != nil || staticBuild.Status.Ready {
+		return ctrl.Result{}, nil
+	}
+
+	res, err := r.reconcileNormal(ctx, staticBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, staticBuild, res, err)
+}
+
+func (r *StaticBuildReconciler) reconcileNormal(ctx context.Context, staticBuild *infrav1.StaticBuild, build *buildv1.Build) (ctrl.Result, error) {
+	address := staticBuild.Spec.Address
+
+	if !staticBuild.Status.MachineReady {
+		if err := r.publishCredentials(ctx, staticBuild, build); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+			return ctrl.Result{}, err
+		}
+		providersdk.MarkMachineReady(staticBuild, "Machine %s is ready", address)
+		r.recorder.Eventf(staticBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "Machine %s is ready", address)
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(staticBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+
+	providersdk.MarkImageReady(staticBuild, staticBuild.Spec.ImageRef, nil, "The provisioners of Build %s are done", build.Name)
+	r.recorder.Eventf(staticBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "The provisioners of Build %s are done on machine %s", build.Name, address)
+	return ctrl.Result{}, nil
+}
+
+// publishCredentials copies the credentials secret of the StaticBuild into the connector credentials secret of the
+// Build, which is created owned by the Build when it does not exist.
+func (r *StaticBuildReconciler) publishCredentials(ctx context.Context, staticBuild *infrav1.StaticBuild, build *buildv1.Build) error {
+	ref := staticBuild.Spec.CredentialsRef
+	if build.Spec.Connector.Credentials == nil {
+		return forgeerrors.ConfigErrorf("the connector of Build %s has no credentials secret", build.Name)
+	}
+	name := build.Spec.Connector.Credentials.Name
+	if ref == nil || ref.Name == name {
+		return nil
+	}
+
+	source := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: staticBuild.Namespace, Name: ref.Name}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return forgeerrors.ConfigErrorf("credentials secret %s not found", ref.Name)
+		}
+		return errors.Wrapf(err, "failed to get credentials secret %s", ref.Name)
+	}
+	if len(source.Data[providersdk.PrivateKeyKey]) == 0 && len(source.Data[providersdk.PasswordKey]) == 0 {
+		return forgeerrors.ConfigErrorf("credentials secret %s has neither a %s nor a %s",
+			ref.Name, providersdk.PrivateKeyKey, providersdk.PasswordKey)
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: build.Namespace,
+				Name:      name,
+				Labels:    map[string]string{buildv1.BuildNameLabel: build.Name},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: maps.Clone(source.Data),
+		}
+		if err := controllerutil.SetControllerReference(build, secret, r.Client.Scheme()); err != nil {
+			return err
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return errors.Wrapf(err, "failed to create the connector credentials secret %s", name)
+		}
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "failed to get the connector credentials secret %s", name)
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	maps.Copy(secret.Data, source.Data)
+	if err := r.Client.Patch(ctx, secret, patch); err != nil {
+		return errors.Wrapf(err, "failed to copy the credentials of secret %s into secret %s", ref.Name, name)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestStaticBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, staticBuild := setupTest(g)
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(staticBuild)}
+
+	// The credentials and the address of the machine are published at once.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, staticBuild)).To(Succeed())
+	g.Expect(staticBuild.Finalizers).To(BeEmpty())
+	g.Expect(staticBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(conditions.GetReason(staticBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.WaitingForProvisionersReason))
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "builder-credentials"}, secret)).To(Succeed())
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(string(secret.Data[providersdk.UsernameKey])).To(Equal("forge"))
+	g.Expect(string(secret.Data[providersdk.PrivateKeyKey])).To(Equal("private key"))
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("builder.lab.example.com"))
+
+	// The StaticBuild is ready once the provisioners are.
+	build.Status.ProvisionersReady = true
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, staticBuild)).To(Succeed())
+	g.Expect(staticBuild.Status.Ready).To(BeTrue())
+	g.Expect(staticBuild.Status.ImageRef).To(Equal("builder"))
+	g.Expect(conditions.IsTrue(staticBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// Nothing is cleaned up when the StaticBuild is deleted.
+	g.Expect(c.Delete(ctx, staticBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, staticBuild)).ToNot(Succeed())
+}
+
+func TestStaticBuildReconcileConnectorCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// The connector credentials secret of the Build already holds the credentials of the machine.
+	c, build, staticBuild := setupTest(g)
+	staticBuild.Spec.CredentialsRef = nil
+	g.Expect(c.Update(ctx, staticBuild)).To(Succeed())
+	g.Expect(c.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: "builder-credentials"},
+		Data:       map[string][]byte{providersdk.UsernameKey: []byte("lab"), providersdk.PasswordKey: []byte("password")},
+	})).To(Succeed())
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(staticBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "builder-credentials"}, secret)).To(Succeed())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{
+		providersdk.UsernameKey: []byte("lab"),
+		providersdk.PasswordKey: []byte("password"),
+		providersdk.HostKey:     []byte("builder.lab.example.com"),
+	}))
+}
+
+func TestStaticBuildReconcileFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, staticBuild := setupTest(g)
+	staticBuild.Spec.CredentialsRef.Name = "missing"
+	g.Expect(c.Update(ctx, staticBuild)).To(Succeed())
+	r := newReconciler(c)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(staticBuild)}
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, staticBuild)).To(Succeed())
+	g.Expect(staticBuild.Status.MachineReady).To(BeFalse())
+	g.Expect(staticBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.InvalidConfigurationBuildError)))
+	g.Expect(*staticBuild.Status.FailureMessage).To(ContainSubstring("credentials secret missing not found"))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.StaticBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "builder", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "builder-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "StaticBuild",
+				Name:       "builder",
+			},
+		},
+	}
+	staticBuild := &infrav1.StaticBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "builder",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "builder",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.StaticBuildSpec{
+			Address:        "builder.lab.example.com",
+			CredentialsRef: &corev1.LocalObjectReference{Name: "lab"},
+			ImageRef:       "builder",
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "lab"},
+		Data: map[string][]byte{
+			providersdk.UsernameKey:   []byte("forge"),
+			providersdk.PrivateKeyKey: []byte("private key"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, staticBuild, credentials).
+		WithStatusSubresource(build, staticBuild).
+		Build()
+	return c, build, staticBuild
+}
+
+func newReconciler(c client.Client) *StaticBuildReconciler {
+	return &StaticBuildReconciler{
+		Client:   c,
+		recorder: record.NewFakeRecorder(100),
+	}
+}
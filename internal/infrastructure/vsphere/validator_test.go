@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		secret    map[string][]byte
+		valid     bool
+		retryable bool
+	}{
+		{name: "valid user", status: http.StatusOK, valid: true},
+		{name: "rejected user", status: http.StatusUnauthorized},
+		{name: "vCenter unavailable", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "incomplete secret", secret: map[string][]byte{infrav1.VSphereUsernameKey: []byte("forge@vsphere.local")}},
+		{name: "invalid CA certificate", secret: map[string][]byte{
+			infrav1.VSphereServerKey:   []byte("vcenter.example.com"),
+			infrav1.VSphereUsernameKey: []byte("forge@vsphere.local"),
+			infrav1.VSpherePasswordKey: []byte("password"),
+			infrav1.VSphereCACertKey:   []byte("not a certificate"),
+		}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			logouts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.URL.Path).To(Equal("/api/session"))
+				if r.Method == http.MethodDelete {
+					g.Expect(r.Header.Get(sessionHeader)).To(Equal("session"))
+					logouts++
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.WriteHeader(tc.status)
+				if tc.status == http.StatusOK {
+					_, _ = w.Write([]byte(`"session"`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"error_type":"UNAUTHENTICATED","messages":[{"default_message":"Authentication required."}]}`))
+			}))
+			defer server.Close()
+
+			secret := &corev1.Secret{Data: tc.secret}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{
+					infrav1.VSphereServerKey:   []byte(server.URL),
+					infrav1.VSphereUsernameKey: []byte("forge@vsphere.local"),
+					infrav1.VSpherePasswordKey: []byte("password"),
+				}
+			}
+			v := &Validator{HTTPClient: server.Client()}
+			err := v.Validate(context.Background(), &buildv1.ProviderIdentity{}, secret)
+			if tc.valid {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(logouts).To(Equal(1))
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
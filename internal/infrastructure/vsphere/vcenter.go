@@ -0,0 +1,685 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vsphere implements the VSphereBuild infrastructure provider: the build machine is a vSphere VM, cloned
+// from a template or booted from ISO images, which is shut down and captured into a content library template once
+// the provisioners of the Build are ready.
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+const (
+	// sessionHeader is the header of the requests holding the session ID.
+	sessionHeader = "vmware-api-session-id"
+)
+
+// The power states of the VMs.
+const (
+	PoweredOn  = "POWERED_ON"
+	PoweredOff = "POWERED_OFF"
+	Suspended  = "SUSPENDED"
+)
+
+// The error types of the vSphere Automation API handled by the controller.
+const (
+	ErrorNotFound              = "NOT_FOUND"
+	ErrorNotAllowedInState     = "NOT_ALLOWED_IN_CURRENT_STATE"
+	ErrorServiceUnavailable    = "SERVICE_UNAVAILABLE"
+	ErrorResourceBusy          = "RESOURCE_BUSY"
+	ErrorResourceInaccessible  = "RESOURCE_INACCESSIBLE"
+	ErrorUnableToAllocResource = "UNABLE_TO_ALLOCATE_RESOURCE"
+)
+
+// InventoryKind is a kind of object of the vCenter inventory looked up by name.
+type InventoryKind string
+
+const (
+	KindDatacenter   InventoryKind = "datacenter"
+	KindFolder       InventoryKind = "folder"
+	KindResourcePool InventoryKind = "resource-pool"
+	KindCluster      InventoryKind = "cluster"
+	KindHost         InventoryKind = "host"
+	KindDatastore    InventoryKind = "datastore"
+)
+
+// VCenter is the part of the vSphere Automation API used by the controller. The objects are identified by their
+// managed object IDs, e.g. vm-42, the library items by their UUIDs.
+type VCenter interface {
+	// Logout ends the session, if any.
+	Logout(ctx context.Context) error
+
+	FindInventory(ctx context.Context, kind InventoryKind, name, datacenter string) (string, error)
+	FindNetwork(ctx context.Context, name, datacenter string) (*Network, error)
+
+	FindVM(ctx context.Context, name, datacenter string) (*VM, error)
+	GetVM(ctx context.Context, id string) (*VM, error)
+	CloneVM(ctx context.Context, spec *CloneSpec) (string, error)
+	DeployLibraryItem(ctx context.Context, spec *CloneSpec) (string, error)
+	CreateVM(ctx context.Context, spec *CreateSpec) (string, error)
+	SetCloudInit(ctx context.Context, vm, metadata, userdata string) error
+	PowerOn(ctx context.Context, vm string) error
+	PowerOff(ctx context.Context, vm string) error
+	ShutdownGuest(ctx context.Context, vm string) error
+	GuestIPAddress(ctx context.Context, vm string) (string, error)
+	DeleteVM(ctx context.Context, vm string) error
+
+	FindLibrary(ctx context.Context, name string) (string, error)
+	FindLibraryItem(ctx context.Context, library, name string) (string, error)
+	GetLibraryItem(ctx context.Context, id string) (*LibraryItem, error)
+	CreateTemplate(ctx context.Context, spec *TemplateSpec) (string, error)
+	CreateOVF(ctx context.Context, spec *TemplateSpec) (string, error)
+}
+
+// VM is a virtual machine.
+type VM struct {
+	ID         string `json:"vm,omitempty"`
+	Name       string `json:"name"`
+	PowerState string `json:"power_state"`
+}
+
+// Network is a network VMs are connected to.
+type Network struct {
+	ID   string `json:"network"`
+	Name string `json:"name"`
+	// Type is the type of the network, e.g. STANDARD_PORTGROUP or DISTRIBUTED_PORTGROUP.
+	Type string `json:"type"`
+}
+
+// Placement is where a VM or template is created, by managed object ID.
+type Placement struct {
+	Folder       string `json:"folder,omitempty"`
+	ResourcePool string `json:"resource_pool,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+	Host         string `json:"host,omitempty"`
+	Datastore    string `json:"datastore,omitempty"`
+}
+
+// CloneSpec is a VM cloned from a VM, or deployed from a VM template library item.
+type CloneSpec struct {
+	Name string
+	// Source is the VM, or the library item.
+	Source    string
+	Placement Placement
+	// NumCPUs and MemoryMiB change the hardware of the source when set.
+	NumCPUs   int32
+	MemoryMiB int64
+}
+
+// CreateSpec is a new VM booting from ISO images.
+type CreateSpec struct {
+	Name      string
+	GuestOS   string
+	Placement Placement
+	// Firmware is BIOS or EFI.
+	Firmware    string
+	NumCPUs     int32
+	MemoryMiB   int64
+	DiskSizeGiB int64
+	// ISOPaths are the datastore paths of the ISO images of the CD-ROM drives.
+	ISOPaths []string
+	Network  *Network
+}
+
+// TemplateSpec is a content library item captured from a VM.
+type TemplateSpec struct {
+	Name        string
+	Description string
+	Library     string
+	SourceVM    string
+	// Placement is where the template of the inventory of a VMTX item is created.
+	Placement Placement
+}
+
+// LibraryItem is an item of a content library.
+type LibraryItem struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type is the type of the item, vm-template or ovf.
+	Type         string `json:"type,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	CreationTime string `json:"creation_time,omitempty"`
+}
+
+// APIError is an error returned by the vSphere Automation API, its code is the error type, e.g. NOT_FOUND.
+type APIError = apierror.Error
+
+// IsNotFound returns true if err is a not found error of the API.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.Code == ErrorNotFound || apiErr.StatusCode == http.StatusNotFound)
+}
+
+// IsType returns true if err is an error of the API of the given type.
+func IsType(err error, errorType string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == errorType
+}
+
+// notFound returns the not found error of an object missing from the results of a lookup.
+func notFound(format string, args ...interface{}) error {
+	return &APIError{StatusCode: http.StatusNotFound, Code: ErrorNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Credentials are the address of vCenter and the user the controller logs in as.
+type Credentials struct {
+	Server   string
+	Username string
+	Password string
+	// CACert are the PEM encoded CA certificates of vCenter, the system ones are used if empty.
+	CACert   []byte
+	Insecure bool
+}
+
+// credentialsFrom returns the credentials of the data of a credentials secret.
+func credentialsFrom(data map[string][]byte) (*Credentials, error) {
+	creds := &Credentials{
+		Server:   string(data[infrav1.VSphereServerKey]),
+		Username: string(data[infrav1.VSphereUsernameKey]),
+		Password: string(data[infrav1.VSpherePasswordKey]),
+		CACert:   data[infrav1.VSphereCACertKey],
+		Insecure: string(data[infrav1.VSphereInsecureKey]) == "true",
+	}
+	if creds.Server == "" || creds.Username == "" || creds.Password == "" {
+		return nil, forgeerrors.ConfigErrorf("the secret must have the %s, %s and %s keys",
+			infrav1.VSphereServerKey, infrav1.VSphereUsernameKey, infrav1.VSpherePasswordKey)
+	}
+	return creds, nil
+}
+
+// Client is a client of the vSphere Automation API. It logs in on the first request, and again when its session
+// expires.
+type Client struct {
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+	// Endpoint is the URL of vCenter, e.g. https://vcenter.example.com.
+	Endpoint string
+	Username string
+	Password string
+
+	session string
+}
+
+var _ VCenter = &Client{}
+
+// NewVCenter returns a client of the API of the vCenter of the credentials.
+func NewVCenter(_ context.Context, creds *Credentials) (VCenter, error) {
+	return newClient(creds)
+}
+
+// newClient returns a client of the API of the vCenter of the credentials, verifying its certificate with the CA
+// certificates of the credentials.
+func newClient(creds *Credentials) (*Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: creds.Insecure} //nolint:gosec
+	if len(creds.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(creds.CACert) {
+			return nil, forgeerrors.ConfigErrorf("the %s of the secret holds no PEM certificate", infrav1.VSphereCACertKey)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{
+		HTTPClient: &http.Client{Transport: transport},
+		Endpoint:   endpointOf(creds.Server),
+		Username:   creds.Username,
+		Password:   creds.Password,
+	}, nil
+}
+
+// endpointOf returns the URL of a server, https is used when it has no scheme.
+func endpointOf(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	return strings.TrimSuffix(server, "/")
+}
+
+// Login creates a session.
+func (c *Client) Login(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+"/api/session", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrap(err, "failed to log in"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := parseError(resp.StatusCode, resp.Body)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return forgeerrors.NewConfigError(errors.Wrapf(apiErr, "user %s has been rejected", c.Username))
+		}
+		return classifier.Classify(apiErr)
+	}
+	var session string
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return errors.Wrap(err, "invalid session")
+	}
+	c.session = session
+	return nil
+}
+
+func (c *Client) Logout(ctx context.Context) error {
+	if c.session == "" {
+		return nil
+	}
+	err := c.do(ctx, http.MethodDelete, "/api/session", nil, nil, nil)
+	c.session = ""
+	return err
+}
+
+// FindInventory returns the ID of the object of the inventory of the given kind and name, in the datacenter if
+// set. The VM folders are looked up for KindFolder.
+func (c *Client) FindInventory(ctx context.Context, kind InventoryKind, name, datacenter string) (string, error) {
+	query := url.Values{"names": {name}}
+	if datacenter != "" && kind != KindDatacenter {
+		query.Set("datacenters", datacenter)
+	}
+	if kind == KindFolder {
+		query.Set("type", "VIRTUAL_MACHINE")
+	}
+	var objects []map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, "/api/vcenter/"+string(kind), query, nil, &objects); err != nil {
+		return "", err
+	}
+	switch len(objects) {
+	case 0:
+		return "", notFound("%s %s not found", kind, name)
+	case 1:
+	default:
+		return "", forgeerrors.ConfigErrorf("%d objects of kind %s are named %s, set the datacenter", len(objects), kind, name)
+	}
+	id, _ := objects[0][strings.ReplaceAll(string(kind), "-", "_")].(string)
+	return id, nil
+}
+
+func (c *Client) FindNetwork(ctx context.Context, name, datacenter string) (*Network, error) {
+	query := url.Values{"names": {name}}
+	if datacenter != "" {
+		query.Set("datacenters", datacenter)
+	}
+	var networks []Network
+	if err := c.do(ctx, http.MethodGet, "/api/vcenter/network", query, nil, &networks); err != nil {
+		return nil, err
+	}
+	if len(networks) == 0 {
+		return nil, notFound("network %s not found", name)
+	}
+	return &networks[0], nil
+}
+
+func (c *Client) FindVM(ctx context.Context, name, datacenter string) (*VM, error) {
+	query := url.Values{"names": {name}}
+	if datacenter != "" {
+		query.Set("datacenters", datacenter)
+	}
+	var vms []VM
+	if err := c.do(ctx, http.MethodGet, "/api/vcenter/vm", query, nil, &vms); err != nil {
+		return nil, err
+	}
+	if len(vms) == 0 {
+		return nil, notFound("VM %s not found", name)
+	}
+	return &vms[0], nil
+}
+
+func (c *Client) GetVM(ctx context.Context, id string) (*VM, error) {
+	vm := &VM{}
+	if err := c.do(ctx, http.MethodGet, "/api/vcenter/vm/"+id, nil, nil, vm); err != nil {
+		return nil, err
+	}
+	vm.ID = id
+	return vm, nil
+}
+
+// CloneVM clones the VM, powered off so it can be customized.
+func (c *Client) CloneVM(ctx context.Context, spec *CloneSpec) (string, error) {
+	body := map[string]interface{}{
+		"name":      spec.Name,
+		"source":    spec.Source,
+		"placement": spec.Placement,
+		"power_on":  false,
+	}
+	if customization := hardwareCustomization(spec); customization != nil {
+		body["hardware_customization"] = customization
+	}
+	var id string
+	err := c.do(ctx, http.MethodPost, "/api/vcenter/vm", url.Values{"action": {"clone"}}, body, &id)
+	return id, err
+}
+
+// DeployLibraryItem deploys the VM template library item, powered off so it can be customized.
+func (c *Client) DeployLibraryItem(ctx context.Context, spec *CloneSpec) (string, error) {
+	placement := spec.Placement
+	body := map[string]interface{}{
+		"name":       spec.Name,
+		"powered_on": false,
+	}
+	if placement.Datastore != "" {
+		body["disk_storage"] = map[string]string{"datastore": placement.Datastore}
+		placement.Datastore = ""
+	}
+	body["placement"] = placement
+	if customization := hardwareCustomization(spec); customization != nil {
+		body["hardware_customization"] = customization
+	}
+	var id string
+	err := c.do(ctx, http.MethodPost, "/api/vcenter/vm-template/library-items/"+spec.Source, url.Values{"action": {"deploy"}}, body, &id)
+	return id, err
+}
+
+func hardwareCustomization(spec *CloneSpec) map[string]interface{} {
+	if spec.NumCPUs == 0 && spec.MemoryMiB == 0 {
+		return nil
+	}
+	customization := map[string]interface{}{}
+	if spec.NumCPUs > 0 {
+		customization["cpu_update"] = map[string]interface{}{"num_cpus": spec.NumCPUs}
+	}
+	if spec.MemoryMiB > 0 {
+		customization["memory_update"] = map[string]interface{}{"memory": spec.MemoryMiB}
+	}
+	return customization
+}
+
+// CreateVM creates the VM with a new disk, the ISO images in SATA CD-ROM drives, and a VMXNET3 network adapter. It
+// boots from the disk, from the first CD-ROM drive while the disk is empty.
+func (c *Client) CreateVM(ctx context.Context, spec *CreateSpec) (string, error) {
+	cdroms := make([]map[string]interface{}, 0, len(spec.ISOPaths))
+	for _, path := range spec.ISOPaths {
+		cdroms = append(cdroms, map[string]interface{}{
+			"type":            "SATA",
+			"backing":         map[string]string{"type": "ISO_FILE", "iso_file": path},
+			"start_connected": true,
+		})
+	}
+	body := map[string]interface{}{
+		"name":      spec.Name,
+		"guest_OS":  spec.GuestOS,
+		"placement": spec.Placement,
+		"boot":      map[string]string{"type": spec.Firmware},
+		"cpu":       map[string]interface{}{"count": spec.NumCPUs},
+		"memory":    map[string]interface{}{"size_MiB": spec.MemoryMiB},
+		"disks": []map[string]interface{}{{
+			"new_vmdk": map[string]interface{}{"capacity": spec.DiskSizeGiB << 30},
+		}},
+		"cdroms": cdroms,
+		"nics": []map[string]interface{}{{
+			"type":            "VMXNET3",
+			"backing":         map[string]string{"type": spec.Network.Type, "network": spec.Network.ID},
+			"start_connected": true,
+		}},
+		"boot_devices": []map[string]string{{"type": "DISK"}, {"type": "CDROM"}},
+	}
+	var id string
+	err := c.do(ctx, http.MethodPost, "/api/vcenter/vm", nil, body, &id)
+	return id, err
+}
+
+// SetCloudInit sets the cloud-init guest customization of the powered off VM, applied on its next boot.
+func (c *Client) SetCloudInit(ctx context.Context, vm, metadata, userdata string) error {
+	body := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"configuration_spec": map[string]interface{}{
+				"cloud_config": map[string]interface{}{
+					"type":      "CLOUDINIT",
+					"cloudinit": map[string]string{"metadata": metadata, "userdata": userdata},
+				},
+			},
+			"global_DNS_settings": map[string]interface{}{},
+			"interfaces":          []interface{}{},
+		},
+	}
+	return c.do(ctx, http.MethodPut, "/api/vcenter/vm/"+vm+"/guest/customization", nil, body, nil)
+}
+
+func (c *Client) PowerOn(ctx context.Context, vm string) error {
+	return c.do(ctx, http.MethodPost, "/api/vcenter/vm/"+vm+"/power", url.Values{"action": {"start"}}, nil, nil)
+}
+
+func (c *Client) PowerOff(ctx context.Context, vm string) error {
+	return c.do(ctx, http.MethodPost, "/api/vcenter/vm/"+vm+"/power", url.Values{"action": {"stop"}}, nil, nil)
+}
+
+// ShutdownGuest shuts the guest OS down with the VMware Tools.
+func (c *Client) ShutdownGuest(ctx context.Context, vm string) error {
+	return c.do(ctx, http.MethodPost, "/api/vcenter/vm/"+vm+"/guest/power", url.Values{"action": {"shutdown"}}, nil, nil)
+}
+
+// GuestIPAddress returns the IP of the guest OS reported by the VMware Tools, empty while they are not running.
+func (c *Client) GuestIPAddress(ctx context.Context, vm string) (string, error) {
+	identity := &struct {
+		IPAddress string `json:"ip_address"`
+	}{}
+	err := c.do(ctx, http.MethodGet, "/api/vcenter/vm/"+vm+"/guest/identity", nil, nil, identity)
+	if IsType(err, ErrorServiceUnavailable) {
+		return "", nil
+	}
+	return identity.IPAddress, err
+}
+
+func (c *Client) DeleteVM(ctx context.Context, vm string) error {
+	return c.do(ctx, http.MethodDelete, "/api/vcenter/vm/"+vm, nil, nil, nil)
+}
+
+func (c *Client) FindLibrary(ctx context.Context, name string) (string, error) {
+	var ids []string
+	if err := c.do(ctx, http.MethodPost, "/api/content/library", url.Values{"action": {"find"}}, map[string]string{"name": name}, &ids); err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", notFound("content library %s not found", name)
+	}
+	return ids[0], nil
+}
+
+func (c *Client) FindLibraryItem(ctx context.Context, library, name string) (string, error) {
+	var ids []string
+	body := map[string]string{"library_id": library, "name": name}
+	if err := c.do(ctx, http.MethodPost, "/api/content/library/item", url.Values{"action": {"find"}}, body, &ids); err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", notFound("library item %s not found", name)
+	}
+	return ids[0], nil
+}
+
+func (c *Client) GetLibraryItem(ctx context.Context, id string) (*LibraryItem, error) {
+	item := &LibraryItem{}
+	err := c.do(ctx, http.MethodGet, "/api/content/library/item/"+id, nil, nil, item)
+	return item, err
+}
+
+// CreateTemplate creates a VM template library item from the powered off VM, it returns once the template is
+// captured.
+func (c *Client) CreateTemplate(ctx context.Context, spec *TemplateSpec) (string, error) {
+	placement := spec.Placement
+	body := map[string]interface{}{
+		"source_vm":   spec.SourceVM,
+		"name":        spec.Name,
+		"description": spec.Description,
+		"library":     spec.Library,
+	}
+	if placement.Datastore != "" {
+		body["disk_storage"] = map[string]string{"datastore": placement.Datastore}
+		placement.Datastore = ""
+	}
+	body["placement"] = placement
+	var id string
+	err := c.do(ctx, http.MethodPost, "/api/vcenter/vm-template/library-items", nil, body, &id)
+	return id, err
+}
+
+// CreateOVF creates an OVF template library item from the powered off VM, it returns once the template is
+// exported.
+func (c *Client) CreateOVF(ctx context.Context, spec *TemplateSpec) (string, error) {
+	body := map[string]interface{}{
+		"source": map[string]string{"type": "VirtualMachine", "id": spec.SourceVM},
+		"target": map[string]string{"library_id": spec.Library},
+		"create_spec": map[string]interface{}{
+			"name":        spec.Name,
+			"description": spec.Description,
+		},
+	}
+	result := &struct {
+		Succeeded bool   `json:"succeeded"`
+		ID        string `json:"ovf_library_item_id"`
+		Error     *struct {
+			Errors []struct {
+				Error *struct {
+					Messages []message `json:"messages"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"error"`
+	}{}
+	if err := c.do(ctx, http.MethodPost, "/api/vcenter/ovf/library-item", nil, body, result); err != nil {
+		return "", err
+	}
+	if !result.Succeeded {
+		var reasons []string
+		if result.Error != nil {
+			for _, e := range result.Error.Errors {
+				if e.Error != nil {
+					reasons = append(reasons, messagesOf(e.Error.Messages))
+				}
+			}
+		}
+		return "", errors.Errorf("failed to export VM %s: %s", spec.SourceVM, strings.Join(reasons, ", "))
+	}
+	return result.ID, nil
+}
+
+// do sends the request with the JSON body, if any, and decodes the response into out. It logs in first when there
+// is no session, and again once when the session has expired. The errors are classified: throttling, busy
+// resources and server errors are retried, the invalid requests are configuration errors.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	target := c.Endpoint + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if c.session == "" {
+			if err := c.Login(ctx); err != nil {
+				return err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(sessionHeader, c.session)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, path))
+		}
+		err = decode(resp, out)
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			c.session = ""
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s %s", method, path)
+		}
+		return nil
+	}
+}
+
+// decode decodes the response into out, or returns its classified error.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return classifier.Classify(parseError(resp.StatusCode, resp.Body))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "invalid response")
+	}
+	return nil
+}
+
+// message is a localizable message of the API.
+type message struct {
+	DefaultMessage string `json:"default_message"`
+}
+
+func messagesOf(messages []message) string {
+	texts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		texts = append(texts, m.DefaultMessage)
+	}
+	return strings.Join(texts, ": ")
+}
+
+// parseError returns the error of an error response of the API.
+func parseError(statusCode int, body io.Reader) *APIError {
+	return apierror.Parse(statusCode, body, func(data []byte) (string, string) {
+		apiErr := &struct {
+			ErrorType string    `json:"error_type"`
+			Messages  []message `json:"messages"`
+		}{}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.ErrorType == "" {
+			return "", ""
+		}
+		return apiErr.ErrorType, messagesOf(apiErr.Messages)
+	})
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing objects and
+// the objects in the wrong state, do logs in again.
+var classifier = apierror.Classifier{
+	Handled: func(err *APIError) bool {
+		return err.StatusCode == http.StatusNotFound || err.StatusCode == http.StatusUnauthorized || err.Code == ErrorNotAllowedInState
+	},
+	Transient: func(err *APIError) bool {
+		return err.Code == ErrorResourceBusy || err.Code == ErrorResourceInaccessible || err.Code == ErrorUnableToAllocResource
+	},
+}
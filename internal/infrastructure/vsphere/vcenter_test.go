@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/session" {
+			switch r.Method {
+			case http.MethodPost:
+				user, password, ok := r.BasicAuth()
+				g.Expect(ok).To(BeTrue())
+				g.Expect(user).To(Equal("forge@vsphere.local"))
+				g.Expect(password).To(Equal("password"))
+				logins++
+				_, _ = fmt.Fprintf(w, `"session-%d"`, logins)
+			case http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+		session := r.Header.Get(sessionHeader)
+		switch {
+		case session == "session-1":
+			// The first session expires.
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error_type":"UNAUTHENTICATED","messages":[{"default_message":"Session expired."}]}`))
+		case r.URL.Path == "/api/vcenter/folder":
+			g.Expect(r.URL.Query().Get("names")).To(Equal("builds"))
+			g.Expect(r.URL.Query().Get("type")).To(Equal("VIRTUAL_MACHINE"))
+			g.Expect(r.URL.Query().Get("datacenters")).To(Equal("datacenter-1"))
+			_, _ = w.Write([]byte(`[{"folder":"group-v42","name":"builds","type":"VIRTUAL_MACHINE"}]`))
+		case r.URL.Path == "/api/vcenter/resource-pool":
+			_, _ = w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/vcenter/vm" && r.URL.Query().Get("action") == "clone":
+			body := map[string]interface{}{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			g.Expect(body).To(HaveKeyWithValue("source", "vm-1"))
+			g.Expect(body).To(HaveKeyWithValue("power_on", false))
+			g.Expect(body).To(HaveKeyWithValue("placement", map[string]interface{}{"folder": "group-v42"}))
+			g.Expect(body).To(HaveKeyWithValue("hardware_customization", map[string]interface{}{"cpu_update": map[string]interface{}{"num_cpus": 4.0}}))
+			_, _ = w.Write([]byte(`"vm-43"`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/vcenter/vm/vm-43":
+			_, _ = w.Write([]byte(`{"name":"ubuntu","power_state":"POWERED_ON"}`))
+		case r.URL.Path == "/api/vcenter/vm/vm-43/guest/identity":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error_type":"SERVICE_UNAVAILABLE","messages":[{"default_message":"VMware Tools are not running."}]}`))
+		case r.URL.Path == "/api/vcenter/vm/vm-43/power":
+			g.Expect(r.URL.Query().Get("action")).To(Equal("start"))
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error_type":"NOT_ALLOWED_IN_CURRENT_STATE","messages":[{"default_message":"The VM is powered on."}]}`))
+		case r.URL.Path == "/api/content/library" && r.URL.Query().Get("action") == "find":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/api/vcenter/vm-template/library-items":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error_type":"INVALID_ARGUMENT","messages":[{"default_message":"Invalid library."}]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTPClient: server.Client(), Endpoint: server.URL, Username: "forge@vsphere.local", Password: "password"}
+
+	// The client logs in again when its session expires.
+	folder, err := c.FindInventory(ctx, KindFolder, "builds", "datacenter-1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(folder).To(Equal("group-v42"))
+	g.Expect(logins).To(Equal(2))
+
+	_, err = c.FindInventory(ctx, KindResourcePool, "builds", "")
+	g.Expect(IsNotFound(err)).To(BeTrue())
+
+	id, err := c.CloneVM(ctx, &CloneSpec{Name: "ubuntu", Source: "vm-1", Placement: Placement{Folder: folder}, NumCPUs: 4})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("vm-43"))
+	vm, err := c.GetVM(ctx, id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vm).To(Equal(&VM{ID: "vm-43", Name: "ubuntu", PowerState: PoweredOn}))
+
+	address, err := c.GuestIPAddress(ctx, id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(address).To(BeEmpty())
+	err = c.PowerOn(ctx, id)
+	g.Expect(IsType(err, ErrorNotAllowedInState)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("The VM is powered on."))
+
+	_, err = c.FindLibrary(ctx, "images")
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryThrottled))
+	_, err = c.CreateTemplate(ctx, &TemplateSpec{Name: "ubuntu", Library: "lib", SourceVM: id})
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	err = c.DeleteVM(ctx, id)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+
+	g.Expect(c.Logout(ctx)).To(Succeed())
+	g.Expect(c.session).To(BeEmpty())
+}
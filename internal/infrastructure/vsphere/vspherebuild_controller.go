@@ -0,0 +1,588 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/yaml"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+const (
+	// ControllerName is the name of the VSphereBuild controller, used in the controller metrics.
+	ControllerName = "vspherebuild"
+
+	// ProviderName is the name of the provider in the ProviderIdentities.
+	ProviderName = "vsphere"
+
+	// vmRequeueAfter is how often a VM being started or shut down is checked.
+	vmRequeueAfter = 10 * time.Second
+
+	// The hardware of the VMs booted from ISO images when not set.
+	defaultNumCPUs   = 2
+	defaultMemoryMiB = 4096
+)
+
+// VSphereBuildReconciler reconciles a VSphereBuild object
+type VSphereBuildReconciler struct {
+	Client client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// NewVCenter returns the client of the API of vCenter, NewVCenter is used if nil.
+	NewVCenter func(ctx context.Context, creds *Credentials) (VCenter, error)
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VSphereBuildReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.VSphereBuild{}).
+		Watches(&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(providersdk.BuildToInfrastructure(infrav1.GroupVersion.WithKind("VSphereBuild")))).
+		Named(ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r)))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("vspherebuild-controller")
+	return nil
+}
+
+// Reconcile creates the VM of a VSphereBuild, publishes its address in the connector credentials of the Build and,
+// once the provisioners of the Build are ready, shuts it down and captures it into a content library template.
+func (r *VSphereBuildReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	vsphereBuild := &infrav1.VSphereBuild{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vsphereBuild); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(vsphereBuild) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(vsphereBuild, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		providersdk.SetSummary(vsphereBuild)
+		if err := patchHelper.Patch(ctx, vsphereBuild); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	build, err := providersdk.GetOwnerBuild(ctx, r.Client, vsphereBuild)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !vsphereBuild.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, vsphereBuild, build)
+	}
+
+	if build == nil {
+		log.Info("Waiting for the Build controller to set the owner reference")
+		conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.WaitingForBuildReason, "Waiting for the Build to own the VSphereBuild")
+		return ctrl.Result{}, nil
+	}
+	if vsphereBuild.Status.FailureReason != nil || vsphereBuild.Status.Ready {
+		// The VM of a failed or completed VSphereBuild is deleted along with it.
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(vsphereBuild, infrav1.VSphereBuildFinalizer)
+	res, err := r.reconcileNormal(ctx, vsphereBuild, build)
+	return providersdk.HandleReconcileError(ctx, r.recorder, vsphereBuild, res, err)
+}
+
+func (r *VSphereBuildReconciler) reconcileNormal(ctx context.Context, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) (ctrl.Result, error) {
+	vcenter, err := r.vcenter(ctx, vsphereBuild, build)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer r.logout(ctx, vcenter)
+
+	vm, err := r.reconcileVM(ctx, vcenter, vsphereBuild, build)
+	if err != nil || vm == nil {
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, err
+	}
+
+	if !build.Status.ProvisionersReady {
+		conditions.MarkFalse(vsphereBuild, infrav1.ImageReadyCondition, infrav1.WaitingForProvisionersReason, "Waiting for the provisioners of the Build")
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileTemplate(ctx, vcenter, vsphereBuild, build, vm)
+}
+
+// reconcileVM creates and customizes the VM, powers it on, and publishes its address once the VMware Tools report
+// it. It returns nil while the VM is being started.
+func (r *VSphereBuildReconciler) reconcileVM(ctx context.Context, vcenter VCenter, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) (*VM, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &vsphereBuild.Spec
+	status := &vsphereBuild.Status
+
+	name := cmp.Or(status.VMName, providersdk.MachineName(vsphereBuild))
+	var vm *VM
+	var err error
+	if status.VMID != "" {
+		vm, err = vcenter.GetVM(ctx, status.VMID)
+	} else {
+		// The VM may have been created by a reconciliation which failed to record it.
+		var datacenter string
+		if datacenter, err = r.datacenter(ctx, vcenter, vsphereBuild); err != nil {
+			return nil, err
+		}
+		vm, err = vcenter.FindVM(ctx, name, datacenter)
+	}
+	if IsNotFound(err) {
+		if status.VMID != "" {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s has been deleted", name)
+		}
+		creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultVSphereUsername))
+		if err != nil {
+			return nil, err
+		}
+		if customization(vsphereBuild) == infrav1.VSphereCustomizationCloudInit && creds.AuthorizedKey == "" && creds.Password == "" {
+			return nil, forgeerrors.ConfigErrorf("the connector credentials of Build %s have neither a private key nor a password", build.Name)
+		}
+		log.Info("Creating VM", "vm", name)
+		id, err := r.createVM(ctx, vcenter, vsphereBuild, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create VM %s", name)
+		}
+		r.recorder.Eventf(vsphereBuild, corev1.EventTypeNormal, "VMCreated", "Created VM %s", name)
+		status.VMName = name
+		status.VMID = id
+		conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Created VM %s", name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VM %s", name)
+	}
+	status.VMName = name
+	status.VMID = vm.ID
+
+	if status.MachineReady {
+		if vm.PowerState != PoweredOn && !build.Status.ProvisionersReady {
+			return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s while the provisioners are running", name, vm.PowerState)
+		}
+		return vm, nil
+	}
+
+	// The guest customization is set on the powered off VM, it is powered on by the next reconciliation.
+	if customization(vsphereBuild) == infrav1.VSphereCustomizationCloudInit && !status.Customized && vm.PowerState == PoweredOff {
+		creds, err := providersdk.EnsureCredentials(ctx, r.Client, build, cmp.Or(spec.Username, infrav1.DefaultVSphereUsername))
+		if err != nil {
+			return nil, err
+		}
+		metadata, userdata, err := cloudInitFor(vsphereBuild, name, creds)
+		if err != nil {
+			return nil, err
+		}
+		if err := vcenter.SetCloudInit(ctx, vm.ID, metadata, userdata); err != nil {
+			return nil, errors.Wrapf(err, "failed to customize VM %s", name)
+		}
+		log.Info("Customized VM", "vm", name)
+		status.Customized = true
+		conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Customized VM %s", name)
+		return nil, nil
+	}
+
+	switch vm.PowerState {
+	case PoweredOn:
+	case PoweredOff:
+		if err := vcenter.PowerOn(ctx, vm.ID); err != nil && !IsType(err, ErrorNotAllowedInState) {
+			return nil, errors.Wrapf(err, "failed to power on VM %s", name)
+		}
+		log.Info("Powering on VM", "vm", name)
+		conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Powering on VM %s", name)
+		return nil, nil
+	default:
+		return nil, forgeerrors.Terminalf(forgeerrors.CreateBuildError, "VM %s is %s", name, vm.PowerState)
+	}
+
+	address, err := vcenter.GuestIPAddress(ctx, vm.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the IP of VM %s", name)
+	}
+	if address == "" {
+		conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.MachineProvisioningReason, "Waiting for the VMware Tools of VM %s to report its IP", name)
+		return nil, nil
+	}
+	if err := providersdk.PublishAddress(ctx, r.Client, build, address); err != nil {
+		return nil, err
+	}
+	status.Address = address
+	status.MachineReady = true
+	conditions.MarkTrue(vsphereBuild, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	r.recorder.Eventf(vsphereBuild, corev1.EventTypeNormal, infrav1.MachineRunningReason, "VM %s is running at %s", name, address)
+	return vm, nil
+}
+
+// createVM clones the VM from the template of the inventory or of the content library, or creates it to boot from
+// the ISO images. It returns the ID of the VM.
+func (r *VSphereBuildReconciler) createVM(ctx context.Context, vcenter VCenter, vsphereBuild *infrav1.VSphereBuild, name string) (string, error) {
+	spec := &vsphereBuild.Spec
+	datacenter, err := r.datacenter(ctx, vcenter, vsphereBuild)
+	if err != nil {
+		return "", err
+	}
+	placement, err := resolvePlacement(ctx, vcenter, &spec.Placement, datacenter)
+	if err != nil {
+		return "", err
+	}
+
+	switch source := spec.Clone; {
+	case source != nil && source.Library != "":
+		library, err := vcenter.FindLibrary(ctx, source.Library)
+		if err != nil {
+			return "", configIfNotFound(err)
+		}
+		item, err := vcenter.FindLibraryItem(ctx, library, source.Template)
+		if err != nil {
+			return "", configIfNotFound(err)
+		}
+		return vcenter.DeployLibraryItem(ctx, &CloneSpec{
+			Name: name, Source: item, Placement: placement, NumCPUs: spec.NumCPUs, MemoryMiB: spec.MemoryMiB,
+		})
+	case source != nil:
+		template, err := vcenter.FindVM(ctx, source.Template, datacenter)
+		if err != nil {
+			return "", configIfNotFound(err)
+		}
+		return vcenter.CloneVM(ctx, &CloneSpec{
+			Name: name, Source: template.ID, Placement: placement, NumCPUs: spec.NumCPUs, MemoryMiB: spec.MemoryMiB,
+		})
+	}
+
+	iso := spec.ISO
+	network, err := vcenter.FindNetwork(ctx, iso.Network, datacenter)
+	if err != nil {
+		return "", configIfNotFound(err)
+	}
+	return vcenter.CreateVM(ctx, &CreateSpec{
+		Name:        name,
+		GuestOS:     iso.GuestOS,
+		Placement:   placement,
+		Firmware:    cmp.Or(iso.Firmware, "EFI"),
+		NumCPUs:     cmp.Or(spec.NumCPUs, defaultNumCPUs),
+		MemoryMiB:   cmp.Or(spec.MemoryMiB, defaultMemoryMiB),
+		DiskSizeGiB: iso.DiskSizeGiB,
+		ISOPaths:    iso.Paths,
+		Network:     network,
+	})
+}
+
+// reconcileTemplate shuts the VM down and captures it into the content library item.
+func (r *VSphereBuildReconciler) reconcileTemplate(ctx context.Context, vcenter VCenter, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build, vm *VM) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &vsphereBuild.Spec
+	status := &vsphereBuild.Status
+
+	if vm.PowerState != PoweredOff {
+		if conditions.GetReason(vsphereBuild, infrav1.ImageReadyCondition) != infrav1.MachineStoppingReason {
+			err := vcenter.ShutdownGuest(ctx, vm.ID)
+			if IsType(err, ErrorServiceUnavailable) || IsType(err, ErrorNotAllowedInState) {
+				// The VMware Tools are not running, the VM cannot be shut down cleanly.
+				err = vcenter.PowerOff(ctx, vm.ID)
+			}
+			if err != nil {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to shut down VM %s", vm.Name)
+			}
+			log.Info("Shutting down VM", "vm", vm.Name)
+			r.recorder.Eventf(vsphereBuild, corev1.EventTypeNormal, infrav1.MachineStoppingReason, "Shutting down VM %s", vm.Name)
+		}
+		conditions.MarkFalse(vsphereBuild, infrav1.ImageReadyCondition, infrav1.MachineStoppingReason, "Shutting down VM %s", vm.Name)
+		return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+	}
+
+	template := &spec.Template
+	name := imageName(vsphereBuild, build)
+	library, err := vcenter.FindLibrary(ctx, template.Library)
+	if err != nil {
+		return ctrl.Result{}, configIfNotFound(err)
+	}
+	if status.LibraryItemID == "" {
+		id, err := vcenter.FindLibraryItem(ctx, library, name)
+		if IsNotFound(err) {
+			id, err = r.createTemplate(ctx, vcenter, vsphereBuild, build, vm, library, name)
+		}
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to create template %s", name)
+		}
+		status.LibraryItemID = id
+	}
+	item, err := vcenter.GetLibraryItem(ctx, status.LibraryItemID)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get template %s", name)
+	}
+	if !strings.Contains(item.Description, markerOf(vsphereBuild)) {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ExportFailedBuildError,
+			"template %s already exists in content library %s and was not captured from VM %s", name, template.Library, vm.Name)
+	}
+
+	ref := template.Library + "/" + name
+	status.ImageRef = ref
+	status.Artifact = &buildv1.BuildArtifact{
+		ID:       item.ID,
+		Location: ref,
+		Format:   strings.ToLower(string(cmp.Or(template.Format, infrav1.VSphereTemplateFormatVMTX))),
+	}
+	if item.Size > 0 {
+		status.Artifact.SizeBytes = ptr.To(item.Size)
+	}
+	if created, err := time.Parse(time.RFC3339, item.CreationTime); err == nil {
+		status.Artifact.CreatedAt = &metav1.Time{Time: created}
+	}
+	status.Ready = true
+	conditions.MarkTrue(vsphereBuild, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, "Template %s is ready", ref)
+	r.recorder.Eventf(vsphereBuild, corev1.EventTypeNormal, infrav1.ImageAvailableReason, "Template %s is ready", ref)
+	return ctrl.Result{}, nil
+}
+
+// createTemplate captures the VM into a VMTX or OVF item of the content library, it returns once the item is
+// created.
+func (r *VSphereBuildReconciler) createTemplate(ctx context.Context, vcenter VCenter, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build, vm *VM, library, name string) (string, error) {
+	log := ctrl.LoggerFrom(ctx)
+	spec := &vsphereBuild.Spec
+	template := &spec.Template
+
+	templateSpec := &TemplateSpec{
+		Name:        name,
+		Description: descriptionFor(vsphereBuild, build),
+		Library:     library,
+		SourceVM:    vm.ID,
+	}
+	log.Info("Creating template", "template", name, "library", template.Library)
+	r.recorder.Eventf(vsphereBuild, corev1.EventTypeNormal, infrav1.ImageCreatingReason, "Creating template %s in content library %s", name, template.Library)
+	if template.Format == infrav1.VSphereTemplateFormatOVF {
+		return vcenter.CreateOVF(ctx, templateSpec)
+	}
+
+	datacenter, err := r.datacenter(ctx, vcenter, vsphereBuild)
+	if err != nil {
+		return "", err
+	}
+	if templateSpec.Placement, err = resolvePlacement(ctx, vcenter, cmp.Or(template.Placement, &spec.Placement), datacenter); err != nil {
+		return "", err
+	}
+	return vcenter.CreateTemplate(ctx, templateSpec)
+}
+
+// reconcileDelete powers off and deletes the VM, the template is kept.
+func (r *VSphereBuildReconciler) reconcileDelete(ctx context.Context, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(vsphereBuild, infrav1.VSphereBuildFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(vsphereBuild, infrav1.MachineReadyCondition, infrav1.DeletingReason, "")
+
+	if id := vsphereBuild.Status.VMID; id != "" {
+		vcenter, err := r.vcenter(ctx, vsphereBuild, build)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		defer r.logout(ctx, vcenter)
+
+		name := vsphereBuild.Status.VMName
+		vm, err := vcenter.GetVM(ctx, id)
+		switch {
+		case IsNotFound(err):
+		case err != nil:
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get VM %s", name)
+		case vm.PowerState != PoweredOff:
+			if err := vcenter.PowerOff(ctx, id); err != nil && !IsType(err, ErrorNotAllowedInState) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to power off VM %s", name)
+			}
+			log.Info("Powering off VM", "vm", name)
+			return ctrl.Result{RequeueAfter: vmRequeueAfter}, nil
+		default:
+			if err := vcenter.DeleteVM(ctx, id); err != nil && !IsNotFound(err) {
+				return ctrl.Result{}, errors.Wrapf(err, "failed to delete VM %s", name)
+			}
+			log.Info("Deleted VM", "vm", name)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(vsphereBuild, infrav1.VSphereBuildFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// vcenter returns the client of the API of the vCenter of the credentials of the VSphereBuild.
+func (r *VSphereBuildReconciler) vcenter(ctx context.Context, vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) (VCenter, error) {
+	if build == nil {
+		// The Build is already gone, only the credentials referenced by the VSphereBuild can be used.
+		build = &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: vsphereBuild.Namespace, Name: vsphereBuild.Name}}
+	}
+	secret, err := providersdk.ProviderCredentials(ctx, r.Client, vsphereBuild.Spec.CredentialsRef, build)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := credentialsFrom(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials secret %s", secret.Name)
+	}
+	newVCenter := r.NewVCenter
+	if newVCenter == nil {
+		newVCenter = NewVCenter
+	}
+	return newVCenter(ctx, creds)
+}
+
+// logout ends the session of the client, the sessions of vCenter are limited.
+func (r *VSphereBuildReconciler) logout(ctx context.Context, vcenter VCenter) {
+	if err := vcenter.Logout(ctx); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to log out of vCenter")
+	}
+}
+
+// datacenter returns the ID of the datacenter of the VSphereBuild, empty if not set.
+func (r *VSphereBuildReconciler) datacenter(ctx context.Context, vcenter VCenter, vsphereBuild *infrav1.VSphereBuild) (string, error) {
+	if vsphereBuild.Spec.Datacenter == "" {
+		return "", nil
+	}
+	id, err := vcenter.FindInventory(ctx, KindDatacenter, vsphereBuild.Spec.Datacenter, "")
+	return id, configIfNotFound(err)
+}
+
+// resolvePlacement returns the IDs of the objects of the placement, looked up in the datacenter.
+func resolvePlacement(ctx context.Context, vcenter VCenter, placement *infrav1.VSpherePlacement, datacenter string) (Placement, error) {
+	resolved := Placement{}
+	for _, object := range []struct {
+		kind InventoryKind
+		name string
+		id   *string
+	}{
+		{kind: KindFolder, name: placement.Folder, id: &resolved.Folder},
+		{kind: KindResourcePool, name: placement.ResourcePool, id: &resolved.ResourcePool},
+		{kind: KindCluster, name: placement.Cluster, id: &resolved.Cluster},
+		{kind: KindHost, name: placement.Host, id: &resolved.Host},
+		{kind: KindDatastore, name: placement.Datastore, id: &resolved.Datastore},
+	} {
+		if object.name == "" {
+			continue
+		}
+		id, err := vcenter.FindInventory(ctx, object.kind, object.name, datacenter)
+		if err != nil {
+			return Placement{}, configIfNotFound(err)
+		}
+		*object.id = id
+	}
+	return resolved, nil
+}
+
+// configIfNotFound returns the not found errors of the objects named in the spec as configuration errors.
+func configIfNotFound(err error) error {
+	if IsNotFound(err) {
+		return forgeerrors.NewConfigError(err)
+	}
+	return err
+}
+
+// customization returns how the connector credentials are authorized on the VM.
+func customization(vsphereBuild *infrav1.VSphereBuild) infrav1.VSphereCustomization {
+	if vsphereBuild.Spec.Clone == nil {
+		return infrav1.VSphereCustomizationNone
+	}
+	return cmp.Or(vsphereBuild.Spec.Clone.Customization, infrav1.VSphereCustomizationCloudInit)
+}
+
+// cloudInitFor returns the cloud-init metadata and user data of the VM.
+func cloudInitFor(vsphereBuild *infrav1.VSphereBuild, name string, creds *providersdk.Credentials) (string, string, error) {
+	metadata, err := yaml.Marshal(map[string]string{
+		"instance-id":    string(vsphereBuild.UID),
+		"local-hostname": name,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	userdata, err := providersdk.CloudConfig(creds)
+	if err != nil {
+		return "", "", err
+	}
+	return string(metadata), userdata, nil
+}
+
+// imageName returns the name of the template, the spec.imageName of the Build, or the name of the VSphereBuild.
+func imageName(vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) string {
+	return cmp.Or(build.Spec.ImageName, vsphereBuild.Name)
+}
+
+// markerOf returns the marker of the descriptions of the templates captured by the VSphereBuild, identifying them
+// when their creation is interrupted.
+func markerOf(vsphereBuild *infrav1.VSphereBuild) string {
+	return fmt.Sprintf("[forge-build %s]", vsphereBuild.UID)
+}
+
+// descriptionFor returns the description of the template: the description of the spec, the image metadata of
+// the Build, and the marker of the VSphereBuild.
+func descriptionFor(vsphereBuild *infrav1.VSphereBuild, build *buildv1.Build) string {
+	lines := []string{}
+	if vsphereBuild.Spec.Template.Description != "" {
+		lines = append(lines, vsphereBuild.Spec.Template.Description)
+	}
+	keys := make([]string, 0, len(build.Status.ImageMetadata))
+	for key := range build.Status.ImageMetadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+"="+build.Status.ImageMetadata[key])
+	}
+	return strings.Join(append(lines, markerOf(vsphereBuild)), "\n")
+}
@@ -0,0 +1,406 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// fakeVCenter is an in-memory VCenter, the inventory objects are named after their kind, e.g. folder-builds.
+type fakeVCenter struct {
+	vms       map[string]*VM
+	clones    map[string]*CloneSpec
+	creates   map[string]*CreateSpec
+	userdata  map[string]string
+	ips       map[string]string
+	libraries map[string]string
+	items     map[string]*LibraryItem
+	templates []*TemplateSpec
+	shutdowns int
+	logouts   int
+}
+
+func newFakeVCenter() *fakeVCenter {
+	return &fakeVCenter{
+		vms:       map[string]*VM{"vm-1": {ID: "vm-1", Name: "ubuntu-base", PowerState: PoweredOff}},
+		clones:    map[string]*CloneSpec{},
+		creates:   map[string]*CreateSpec{},
+		userdata:  map[string]string{},
+		ips:       map[string]string{},
+		libraries: map[string]string{"images": "lib-images"},
+		items:     map[string]*LibraryItem{},
+	}
+}
+
+func notFoundError() error {
+	return &APIError{StatusCode: http.StatusNotFound, Code: ErrorNotFound}
+}
+
+func (f *fakeVCenter) Logout(_ context.Context) error {
+	f.logouts++
+	return nil
+}
+
+func (f *fakeVCenter) FindInventory(_ context.Context, kind InventoryKind, name, _ string) (string, error) {
+	if name == "missing" {
+		return "", notFoundError()
+	}
+	return fmt.Sprintf("%s-%s", kind, name), nil
+}
+
+func (f *fakeVCenter) FindNetwork(_ context.Context, name, _ string) (*Network, error) {
+	return &Network{ID: "network-" + name, Name: name, Type: "DISTRIBUTED_PORTGROUP"}, nil
+}
+
+func (f *fakeVCenter) FindVM(_ context.Context, name, _ string) (*VM, error) {
+	for _, vm := range f.vms {
+		if vm.Name == name {
+			return vm, nil
+		}
+	}
+	return nil, notFoundError()
+}
+
+func (f *fakeVCenter) GetVM(_ context.Context, id string) (*VM, error) {
+	vm, ok := f.vms[id]
+	if !ok {
+		return nil, notFoundError()
+	}
+	return vm, nil
+}
+
+func (f *fakeVCenter) addVM(name string) string {
+	id := fmt.Sprintf("vm-%d", len(f.vms)+1)
+	f.vms[id] = &VM{ID: id, Name: name, PowerState: PoweredOff}
+	return id
+}
+
+func (f *fakeVCenter) CloneVM(_ context.Context, spec *CloneSpec) (string, error) {
+	id := f.addVM(spec.Name)
+	f.clones[id] = spec
+	return id, nil
+}
+
+func (f *fakeVCenter) DeployLibraryItem(_ context.Context, spec *CloneSpec) (string, error) {
+	return f.CloneVM(context.Background(), spec)
+}
+
+func (f *fakeVCenter) CreateVM(_ context.Context, spec *CreateSpec) (string, error) {
+	id := f.addVM(spec.Name)
+	f.creates[id] = spec
+	return id, nil
+}
+
+func (f *fakeVCenter) SetCloudInit(_ context.Context, vm, _, userdata string) error {
+	f.userdata[vm] = userdata
+	return nil
+}
+
+func (f *fakeVCenter) PowerOn(_ context.Context, vm string) error {
+	f.vms[vm].PowerState = PoweredOn
+	return nil
+}
+
+func (f *fakeVCenter) PowerOff(_ context.Context, vm string) error {
+	f.vms[vm].PowerState = PoweredOff
+	return nil
+}
+
+func (f *fakeVCenter) ShutdownGuest(_ context.Context, _ string) error {
+	f.shutdowns++
+	return nil
+}
+
+func (f *fakeVCenter) GuestIPAddress(_ context.Context, vm string) (string, error) {
+	return f.ips[vm], nil
+}
+
+func (f *fakeVCenter) DeleteVM(_ context.Context, vm string) error {
+	delete(f.vms, vm)
+	return nil
+}
+
+func (f *fakeVCenter) FindLibrary(_ context.Context, name string) (string, error) {
+	id, ok := f.libraries[name]
+	if !ok {
+		return "", notFoundError()
+	}
+	return id, nil
+}
+
+func (f *fakeVCenter) FindLibraryItem(_ context.Context, _, name string) (string, error) {
+	for _, item := range f.items {
+		if item.Name == name {
+			return item.ID, nil
+		}
+	}
+	return "", notFoundError()
+}
+
+func (f *fakeVCenter) GetLibraryItem(_ context.Context, id string) (*LibraryItem, error) {
+	item, ok := f.items[id]
+	if !ok {
+		return nil, notFoundError()
+	}
+	return item, nil
+}
+
+func (f *fakeVCenter) CreateTemplate(_ context.Context, spec *TemplateSpec) (string, error) {
+	f.templates = append(f.templates, spec)
+	id := fmt.Sprintf("item-%d", len(f.items)+1)
+	f.items[id] = &LibraryItem{ID: id, Name: spec.Name, Description: spec.Description, Type: "vm-template", Size: 10 << 30, CreationTime: "2024-03-07T09:05:01.000Z"}
+	return id, nil
+}
+
+func (f *fakeVCenter) CreateOVF(ctx context.Context, spec *TemplateSpec) (string, error) {
+	return f.CreateTemplate(ctx, spec)
+}
+
+func TestVSphereBuildReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, build, vsphereBuild := setupTest(g)
+	vcenter := newFakeVCenter()
+	r := newReconciler(c, vcenter)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(vsphereBuild)}
+
+	// The VM is cloned from the template into the placement.
+	res, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	g.Expect(vcenter.logouts).To(Equal(1))
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Finalizers).To(ContainElement(infrav1.VSphereBuildFinalizer))
+	name := providersdk.MachineName(vsphereBuild)
+	g.Expect(vsphereBuild.Status.VMName).To(Equal(name))
+	g.Expect(vsphereBuild.Status.VMID).To(Equal("vm-2"))
+	g.Expect(vcenter.clones["vm-2"]).To(Equal(&CloneSpec{
+		Name:      name,
+		Source:    "vm-1",
+		Placement: Placement{Folder: "folder-builds", Cluster: "cluster-compute", Datastore: "datastore-vsan"},
+		NumCPUs:   4,
+	}))
+
+	// The connector key is authorized with a cloud-init customization of the powered off VM.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Status.Customized).To(BeTrue())
+	g.Expect(vcenter.userdata["vm-2"]).To(HavePrefix("#cloud-config\n"))
+	g.Expect(vcenter.userdata["vm-2"]).To(ContainSubstring("name: " + infrav1.DefaultVSphereUsername))
+	g.Expect(vcenter.userdata["vm-2"]).To(ContainSubstring("- ssh-rsa "))
+	g.Expect(vcenter.vms["vm-2"].PowerState).To(Equal(PoweredOff))
+
+	// The VM is powered on, its address is published once reported by the VMware Tools.
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vcenter.vms["vm-2"].PowerState).To(Equal(PoweredOn))
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Status.MachineReady).To(BeFalse())
+	vcenter.ips["vm-2"] = "10.0.0.12"
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Status.MachineReady).To(BeTrue())
+	g.Expect(vsphereBuild.Status.Address).To(Equal("10.0.0.12"))
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(string(secret.Data[providersdk.HostKey])).To(Equal("10.0.0.12"))
+
+	// The VM is shut down once the provisioners are ready, once.
+	build.Status.ProvisionersReady = true
+	build.Status.ImageMetadata = map[string]string{"os_version": "22.04"}
+	g.Expect(c.Status().Update(ctx, build)).To(Succeed())
+	for range 2 {
+		res, err = r.Reconcile(ctx, req)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).To(Equal(vmRequeueAfter))
+	}
+	g.Expect(vcenter.shutdowns).To(Equal(1))
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(conditions.GetReason(vsphereBuild, infrav1.ImageReadyCondition)).To(Equal(infrav1.MachineStoppingReason))
+
+	// The template is captured once the VM is powered off.
+	vcenter.vms["vm-2"].PowerState = PoweredOff
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vcenter.templates).To(HaveLen(1))
+	template := vcenter.templates[0]
+	g.Expect(template.Name).To(Equal("ubuntu-22.04"))
+	g.Expect(template.Library).To(Equal("lib-images"))
+	g.Expect(template.SourceVM).To(Equal("vm-2"))
+	g.Expect(template.Placement).To(Equal(Placement{Folder: "folder-templates"}))
+	g.Expect(template.Description).To(Equal("Ubuntu 22.04\nos_version=22.04\n[forge-build vspherebuild-uid]"))
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Status.Ready).To(BeTrue())
+	g.Expect(vsphereBuild.Status.LibraryItemID).To(Equal("item-1"))
+	g.Expect(vsphereBuild.Status.ImageRef).To(Equal("images/ubuntu-22.04"))
+	g.Expect(vsphereBuild.Status.Artifact.Format).To(Equal("vmtx"))
+	g.Expect(*vsphereBuild.Status.Artifact.SizeBytes).To(BeEquivalentTo(10 << 30))
+	g.Expect(vsphereBuild.Status.Artifact.CreatedAt).ToNot(BeNil())
+	g.Expect(conditions.IsTrue(vsphereBuild, infrav1.ReadyCondition)).To(BeTrue())
+
+	// The VM is deleted with the VSphereBuild, the template is kept.
+	g.Expect(c.Delete(ctx, vsphereBuild)).To(Succeed())
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vcenter.vms).ToNot(HaveKey("vm-2"))
+	g.Expect(vcenter.items).To(HaveLen(1))
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).ToNot(Succeed())
+}
+
+func TestVSphereBuildReconcileISO(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, vsphereBuild := setupTest(g)
+	vsphereBuild.Spec.Clone = nil
+	vsphereBuild.Spec.ISO = &infrav1.VSphereISOSource{
+		Paths:       []string{"[datastore1] iso/ubuntu-22.04-autoinstall.iso"},
+		GuestOS:     "UBUNTU_64",
+		DiskSizeGiB: 40,
+		Network:     "builds",
+	}
+	g.Expect(c.Update(ctx, vsphereBuild)).To(Succeed())
+	vcenter := newFakeVCenter()
+	r := newReconciler(c, vcenter)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(vsphereBuild)}
+
+	// The VM is created with the defaults and powered on without customization.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	spec := vcenter.creates["vm-2"]
+	g.Expect(spec.Firmware).To(Equal("EFI"))
+	g.Expect(spec.NumCPUs).To(BeEquivalentTo(4))
+	g.Expect(spec.MemoryMiB).To(BeEquivalentTo(defaultMemoryMiB))
+	g.Expect(spec.Network.ID).To(Equal("network-builds"))
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vcenter.userdata).To(BeEmpty())
+	g.Expect(vcenter.vms["vm-2"].PowerState).To(Equal(PoweredOn))
+}
+
+func TestVSphereBuildReconcileFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c, _, vsphereBuild := setupTest(g)
+	vsphereBuild.Spec.Placement.Folder = "missing"
+	g.Expect(c.Update(ctx, vsphereBuild)).To(Succeed())
+	vcenter := newFakeVCenter()
+	r := newReconciler(c, vcenter)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(vsphereBuild)}
+
+	// A missing object of the placement fails the VSphereBuild.
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vcenter.clones).To(BeEmpty())
+	g.Expect(c.Get(ctx, req.NamespacedName, vsphereBuild)).To(Succeed())
+	g.Expect(vsphereBuild.Status.FailureReason).To(HaveValue(Equal(forgeerrors.InvalidConfigurationBuildError)))
+	g.Expect(conditions.GetReason(vsphereBuild, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+}
+
+func setupTest(g *WithT) (client.Client, *buildv1.Build, *infrav1.VSphereBuild) {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "build-uid"},
+		Spec: buildv1.BuildSpec{
+			ImageName: "ubuntu-22.04",
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "VSphereBuild",
+				Name:       "ubuntu",
+			},
+		},
+	}
+	vsphereBuild := &infrav1.VSphereBuild{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu",
+			UID:       "vspherebuild-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: buildv1.GroupVersion.String(),
+				Kind:       "Build",
+				Name:       "ubuntu",
+				UID:        "build-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: infrav1.VSphereBuildSpec{
+			Datacenter:     "dc1",
+			Clone:          &infrav1.VSphereCloneSource{Template: "ubuntu-base"},
+			Placement:      infrav1.VSpherePlacement{Folder: "builds", Cluster: "compute", Datastore: "vsan"},
+			NumCPUs:        4,
+			CredentialsRef: &corev1.LocalObjectReference{Name: "vsphere"},
+			Template: infrav1.VSphereTemplateSpec{
+				Library:     "images",
+				Description: "Ubuntu 22.04",
+				Placement:   &infrav1.VSpherePlacement{Folder: "templates"},
+			},
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "vsphere"},
+		Data: map[string][]byte{
+			infrav1.VSphereServerKey:   []byte("vcenter.example.com"),
+			infrav1.VSphereUsernameKey: []byte("forge@vsphere.local"),
+			infrav1.VSpherePasswordKey: []byte("password"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(build, vsphereBuild, credentials).
+		WithStatusSubresource(build, vsphereBuild).
+		Build()
+	return c, build, vsphereBuild
+}
+
+func newReconciler(c client.Client, vcenter VCenter) *VSphereBuildReconciler {
+	return &VSphereBuildReconciler{
+		Client: c,
+		NewVCenter: func(_ context.Context, _ *Credentials) (VCenter, error) {
+			return vcenter, nil
+		},
+		recorder: record.NewFakeRecorder(100),
+	}
+}
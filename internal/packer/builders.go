@@ -0,0 +1,262 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packer
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// Builders translated by Import.
+const (
+	// AmazonEBSBuilder is translated into an AWSBuild.
+	AmazonEBSBuilder = "amazon-ebs"
+	// GoogleComputeBuilder is translated into a GCPBuild.
+	GoogleComputeBuilder = "googlecompute"
+	// VSphereISOBuilder is translated into a VSphereBuild booting from ISO images.
+	VSphereISOBuilder = "vsphere-iso"
+)
+
+const (
+	// credentialsReason is why the credentials of the cloud APIs are not imported.
+	credentialsReason = "set the credentialsRef of the infrastructure or use a ProviderIdentity"
+	// connectorReason is why the credentials of the communicator are not imported.
+	connectorReason = "the connector credentials are generated for the Build"
+)
+
+// translateSource returns the infrastructure object of the source, the name of the image it creates and the
+// connector of the Build, false if the builder is not supported.
+func (t *translator) translateSource(block *hclsyntax.Block, name string) (client.Object, string, buildv1.ConnectorSpec, bool) {
+	b := t.body(block.Body, strings.TrimPrefix(sourceName(block), "source."))
+	meta := metav1.ObjectMeta{Namespace: t.opts.Namespace, Name: name}
+
+	var (
+		infra     client.Object
+		imageName string
+	)
+	switch block.Labels[0] {
+	case AmazonEBSBuilder:
+		infra, imageName = t.amazonEBS(b, meta)
+	case GoogleComputeBuilder:
+		infra, imageName = t.googleCompute(b, meta)
+	case VSphereISOBuilder:
+		infra, imageName = t.vsphereISO(b, meta)
+	default:
+		t.warnf(block.DefRange(), "builder %s is not supported, only %s, %s and %s are", block.Labels[0],
+			AmazonEBSBuilder, GoogleComputeBuilder, VSphereISOBuilder)
+		return nil, "", buildv1.ConnectorSpec{}, false
+	}
+
+	connector := buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH, Port: int32(b.integer("ssh_port"))}
+	if communicator := b.str("communicator"); communicator != "" && communicator != "ssh" {
+		t.warnf(b.body.Attributes["communicator"].SrcRange, "the %s communicator of %s is not supported, the Build connects with ssh",
+			communicator, b.name)
+	}
+	b.drop(connectorReason, "ssh_password", "ssh_private_key_file", "ssh_keypair_name", "temporary_key_pair_type",
+		"ssh_agent_auth")
+	b.ignore("ssh_timeout", "ssh_handshake_attempts", "ssh_pty")
+	b.warnUnused()
+	return infra, imageName, connector, true
+}
+
+// amazonEBS translates an amazon-ebs source into an AWSBuild.
+func (t *translator) amazonEBS(b *body, meta metav1.ObjectMeta) (client.Object, string) {
+	infra := &infrav1.AWSBuild{
+		TypeMeta:   metav1.TypeMeta{APIVersion: infrav1.GroupVersion.String(), Kind: "AWSBuild"},
+		ObjectMeta: meta,
+		Spec: infrav1.AWSBuildSpec{
+			Region:             b.str("region"),
+			SourceAMI:          b.str("source_ami"),
+			InstanceType:       b.str("instance_type"),
+			SubnetID:           b.str("subnet_id"),
+			SecurityGroupIDs:   b.strs("security_group_ids"),
+			PublicIP:           b.boolean("associate_public_ip_address"),
+			IAMInstanceProfile: b.str("iam_instance_profile"),
+			Username:           b.str("ssh_username"),
+			AMI: infrav1.AWSAMISpec{
+				Description:       b.str("ami_description"),
+				LaunchPermissions: b.strs("ami_users"),
+			},
+		},
+	}
+	if id := b.str("security_group_id"); id != "" {
+		infra.Spec.SecurityGroupIDs = append(infra.Spec.SecurityGroupIDs, id)
+	}
+	for _, filter := range b.blocks("source_ami_filter") {
+		t.warnf(filter.block.DefRange(), "source_ami_filter of %s is not supported, set the sourceAMI of AWSBuild %s", b.name, meta.Name)
+		filter.ignore("filters", "owners", "most_recent")
+	}
+	for k, v := range b.strMap("run_tags") {
+		if infra.Spec.Tags == nil {
+			infra.Spec.Tags = map[string]string{}
+		}
+		infra.Spec.Tags[k] = v
+	}
+	for k, v := range b.strMap("tags") {
+		if infra.Spec.Tags == nil {
+			infra.Spec.Tags = map[string]string{}
+		}
+		infra.Spec.Tags[k] = v
+	}
+	switch price := b.str("spot_price"); price {
+	case "":
+	case "auto":
+		infra.Spec.Spot = &infrav1.AWSSpotOptions{}
+	default:
+		infra.Spec.Spot = &infrav1.AWSSpotOptions{MaxPrice: price}
+	}
+	for _, region := range b.strs("ami_regions") {
+		infra.Spec.AMI.Copies = append(infra.Spec.AMI.Copies, infrav1.AWSAMICopy{Name: region, Region: region})
+	}
+	for i, mapping := range b.blocks("launch_block_device_mappings") {
+		if i > 0 {
+			t.warnf(mapping.block.DefRange(), "only the root volume of %s is imported", b.name)
+			mapping.ignore("device_name", "volume_size", "volume_type", "delete_on_termination")
+			continue
+		}
+		infra.Spec.RootVolumeSize = int32(mapping.integer("volume_size"))
+		infra.Spec.RootVolumeType = mapping.str("volume_type")
+		mapping.ignore("device_name", "delete_on_termination")
+		mapping.warnUnused()
+	}
+	b.drop(credentialsReason, "access_key", "secret_key", "token", "profile", "assume_role")
+	return infra, b.str("ami_name")
+}
+
+// googleCompute translates a googlecompute source into a GCPBuild.
+func (t *translator) googleCompute(b *body, meta metav1.ObjectMeta) (client.Object, string) {
+	infra := &infrav1.GCPBuild{
+		TypeMeta:   metav1.TypeMeta{APIVersion: infrav1.GroupVersion.String(), Kind: "GCPBuild"},
+		ObjectMeta: meta,
+		Spec: infrav1.GCPBuildSpec{
+			Project:           b.str("project_id"),
+			Zone:              b.str("zone"),
+			MachineType:       b.str("machine_type"),
+			SourceImage:       b.str("source_image"),
+			SourceImageFamily: b.str("source_image_family"),
+			DiskSizeGB:        b.integer("disk_size"),
+			DiskType:          b.str("disk_type"),
+			Network:           b.str("network"),
+			Subnetwork:        b.str("subnetwork"),
+			Tags:              b.strs("tags"),
+			Username:          b.str("ssh_username"),
+			Image: infrav1.GCPImageSpec{
+				Family:           b.str("image_family"),
+				StorageLocations: b.strs("image_storage_locations"),
+			},
+		},
+	}
+	if projects := b.strs("source_image_project_id"); len(projects) > 0 {
+		infra.Spec.SourceImageProject = projects[0]
+		if len(projects) > 1 {
+			t.warnf(b.body.Attributes["source_image_project_id"].SrcRange,
+				"only the first project of source_image_project_id of %s is imported", b.name)
+		}
+	}
+	if preemptible := b.boolean("preemptible"); preemptible != nil {
+		infra.Spec.Spot = *preemptible
+	}
+	if omit := b.boolean("omit_external_ip"); omit != nil {
+		infra.Spec.PublicIP = ptr.To(!*omit)
+	}
+	b.drop(credentialsReason, "account_file", "credentials_file", "credentials_json", "impersonate_service_account")
+	return infra, b.str("image_name")
+}
+
+// vsphereISO translates a vsphere-iso source into a VSphereBuild booting from ISO images.
+func (t *translator) vsphereISO(b *body, meta metav1.ObjectMeta) (client.Object, string) {
+	infra := &infrav1.VSphereBuild{
+		TypeMeta:   metav1.TypeMeta{APIVersion: infrav1.GroupVersion.String(), Kind: "VSphereBuild"},
+		ObjectMeta: meta,
+		Spec: infrav1.VSphereBuildSpec{
+			Datacenter: b.str("datacenter"),
+			ISO: &infrav1.VSphereISOSource{
+				Paths:   b.strs("iso_paths"),
+				GuestOS: b.str("guest_os_type"),
+			},
+			Placement: infrav1.VSpherePlacement{
+				Folder:       b.str("folder"),
+				ResourcePool: b.str("resource_pool"),
+				Cluster:      b.str("cluster"),
+				Host:         b.str("host"),
+				Datastore:    b.str("datastore"),
+			},
+			NumCPUs:   int32(b.integer("CPUs")),
+			MemoryMiB: b.integer("RAM"),
+			Username:  b.str("ssh_username"),
+		},
+	}
+	switch firmware := b.str("firmware"); firmware {
+	case "":
+	case "bios":
+		infra.Spec.ISO.Firmware = "BIOS"
+	case "efi", "efi-secure":
+		infra.Spec.ISO.Firmware = "EFI"
+	default:
+		t.warnf(b.body.Attributes["firmware"].SrcRange, "firmware %s of %s is not supported", firmware, b.name)
+	}
+	for i, storage := range b.blocks("storage") {
+		if i == 0 {
+			// The disk size of Packer is in MiB.
+			infra.Spec.ISO.DiskSizeGiB = (storage.integer("disk_size") + 1023) / 1024
+			storage.ignore("disk_thin_provisioned", "disk_controller_index")
+			storage.warnUnused()
+			continue
+		}
+		t.warnf(storage.block.DefRange(), "only the first disk of %s is imported", b.name)
+	}
+	for i, adapter := range b.blocks("network_adapters") {
+		if i == 0 {
+			infra.Spec.ISO.Network = adapter.str("network")
+			adapter.ignore("network_card")
+			adapter.warnUnused()
+			continue
+		}
+		t.warnf(adapter.block.DefRange(), "only the first network adapter of %s is imported", b.name)
+	}
+
+	imageName := b.str("vm_name")
+	destinations := b.blocks("content_library_destination")
+	if len(destinations) == 0 {
+		t.warnf(b.body.SrcRange, "%s has no content_library_destination, set the template library of VSphereBuild %s", b.name, meta.Name)
+	}
+	for _, destination := range destinations {
+		infra.Spec.Template = infrav1.VSphereTemplateSpec{
+			Library:     destination.str("library"),
+			Description: destination.str("description"),
+		}
+		if ovf := destination.boolean("ovf"); ovf != nil && *ovf {
+			infra.Spec.Template.Format = infrav1.VSphereTemplateFormatOVF
+		}
+		if name := destination.str("name"); name != "" {
+			imageName = name
+		}
+		destination.ignore("destroy")
+		destination.warnUnused()
+	}
+	b.drop(credentialsReason, "vcenter_server", "username", "password", "insecure_connection")
+	b.drop("the installation must authorize the connector credentials, e.g. with a kickstart file on the ISO images",
+		"boot_command", "boot_wait", "http_directory", "http_content", "cd_files", "cd_content", "floppy_files")
+	return infra, imageName
+}
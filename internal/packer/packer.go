@@ -0,0 +1,427 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package packer translates Packer HCL2 templates into Builds and the infrastructure objects of their machines.
+// The common builders and provisioners are translated, the parts of the template which have no equivalent are
+// reported as warnings to be completed by hand.
+package packer
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// Options configures the translation of a template.
+type Options struct {
+	// Namespace is the namespace of the objects, they have none if empty.
+	Namespace string
+
+	// Variables override the defaults of the variables of the template.
+	Variables map[string]string
+
+	// Dir is the directory the paths of the template are relative to, the scripts, files and playbooks of the
+	// provisioners are read from it.
+	Dir string
+}
+
+// Result holds the objects translated from a template.
+type Result struct {
+	// Objects are the ConfigMaps holding the files of the provisioners, the infrastructure objects and the Builds.
+	Objects []client.Object
+
+	// Warnings describe the parts of the template which have not been translated.
+	Warnings []string
+}
+
+// functions are the functions of the Packer templates which can be evaluated without running Packer.
+var functions = map[string]function.Function{
+	"coalesce":  stdlib.CoalesceFunc,
+	"concat":    stdlib.ConcatFunc,
+	"format":    stdlib.FormatFunc,
+	"join":      stdlib.JoinFunc,
+	"length":    stdlib.LengthFunc,
+	"lower":     stdlib.LowerFunc,
+	"replace":   stdlib.ReplaceFunc,
+	"split":     stdlib.SplitFunc,
+	"substr":    stdlib.SubstrFunc,
+	"title":     stdlib.TitleFunc,
+	"trimspace": stdlib.TrimSpaceFunc,
+	"upper":     stdlib.UpperFunc,
+}
+
+// Import translates the Packer template, every source of every build block is translated into a Build and its
+// infrastructure object, named after the source.
+func Import(filename string, data []byte, opts Options) (*Result, error) {
+	file, diags := hclsyntax.ParseConfig(data, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	t := &translator{
+		opts:   opts,
+		src:    data,
+		ctx:    &hcl.EvalContext{Variables: map[string]cty.Value{}, Functions: functions},
+		result: &Result{},
+	}
+	variables, declared := map[string]cty.Value{}, map[string]bool{}
+	locals := []*hclsyntax.Attribute{}
+	sources := map[string]*hclsyntax.Block{}
+	builds := []*hclsyntax.Block{}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		switch block.Type {
+		case "variable":
+			if len(block.Labels) != 1 {
+				return nil, errors.Errorf("%s: a variable block must have a name", position(block.DefRange()))
+			}
+			name := block.Labels[0]
+			declared[name] = true
+			if value, ok := opts.Variables[name]; ok {
+				variables[name] = cty.StringVal(value)
+				continue
+			}
+			if attr, ok := block.Body.Attributes["default"]; ok {
+				value, diags := attr.Expr.Value(nil)
+				if diags.HasErrors() {
+					t.warnf(attr.SrcRange, "the default of variable %s is not evaluated: %s", name, diags[0].Summary)
+					continue
+				}
+				variables[name] = value
+			}
+		case "locals":
+			locals = append(locals, sortedAttributes(block.Body)...)
+		case "source":
+			if len(block.Labels) != 2 {
+				return nil, errors.Errorf("%s: a source block must have a type and a name", position(block.DefRange()))
+			}
+			sources[sourceName(block)] = block
+		case "build":
+			builds = append(builds, block)
+		case "packer":
+		default:
+			t.warnf(block.DefRange(), "%s blocks are not supported", block.Type)
+		}
+	}
+
+	for name := range opts.Variables {
+		if !declared[name] {
+			return nil, errors.Errorf("variable %s is not declared by the template", name)
+		}
+	}
+	t.ctx.Variables["var"] = cty.ObjectVal(variables)
+	t.resolveLocals(locals)
+
+	builders := []client.Object{}
+	names := map[string]bool{}
+	for _, block := range builds {
+		for _, ref := range t.buildSources(block) {
+			source, ok := sources[ref]
+			if !ok {
+				return nil, errors.Errorf("%s: build references undeclared source %s", position(block.DefRange()), ref)
+			}
+			// A source built by several build blocks is translated into a Build per block.
+			name := dnsName(source.Labels[1])
+			for i := 2; names[name]; i++ {
+				name = fmt.Sprintf("%s-%d", dnsName(source.Labels[1]), i)
+			}
+			names[name] = true
+
+			infra, imageName, connector, ok := t.translateSource(source, name)
+			if !ok {
+				continue
+			}
+			build := &buildv1.Build{
+				TypeMeta:   metav1.TypeMeta{APIVersion: buildv1.GroupVersion.String(), Kind: "Build"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: opts.Namespace, Name: name},
+				Spec: buildv1.BuildSpec{
+					Connector: connector,
+					InfrastructureRef: &corev1.ObjectReference{
+						APIVersion: infra.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+						Kind:       infra.GetObjectKind().GroupVersionKind().Kind,
+						Name:       infra.GetName(),
+					},
+					ImageName: imageName,
+				},
+			}
+			build.Spec.Provisioners = t.translateProvisioners(block, source, build)
+			builders = append(builders, infra, build)
+		}
+	}
+	if len(builders) == 0 {
+		return nil, errors.New("the template holds no source of a supported builder: amazon-ebs, googlecompute or vsphere-iso")
+	}
+	t.result.Objects = append(t.result.Objects, builders...)
+	return t.result, nil
+}
+
+// translator holds the state of the translation of a template.
+type translator struct {
+	opts   Options
+	src    []byte
+	ctx    *hcl.EvalContext
+	result *Result
+
+	// files is the ConfigMap of the files of the provisioner being translated.
+	files *corev1.ConfigMap
+}
+
+func (t *translator) warnf(r hcl.Range, format string, args ...interface{}) {
+	t.result.Warnings = append(t.result.Warnings, position(r)+": "+fmt.Sprintf(format, args...))
+}
+
+// resolveLocals evaluates the locals, in the order of their dependencies. The locals which can't be evaluated, e.g.
+// calling timestamp(), are left out.
+func (t *translator) resolveLocals(attrs []*hclsyntax.Attribute) {
+	locals := map[string]cty.Value{}
+	t.ctx.Variables["local"] = cty.EmptyObjectVal
+	failed := map[*hclsyntax.Attribute]hcl.Diagnostics{}
+	for pending := attrs; len(pending) > 0; {
+		next := []*hclsyntax.Attribute{}
+		for _, attr := range pending {
+			value, diags := attr.Expr.Value(t.ctx)
+			if diags.HasErrors() {
+				failed[attr] = diags
+				next = append(next, attr)
+				continue
+			}
+			locals[attr.Name] = value
+			t.ctx.Variables["local"] = cty.ObjectVal(locals)
+		}
+		if len(next) == len(pending) {
+			for _, attr := range next {
+				t.warnf(attr.SrcRange, "local %s is not evaluated: %s", attr.Name, failed[attr][0].Summary)
+			}
+			return
+		}
+		pending = next
+	}
+}
+
+// buildSources returns the sources of the build block, as source.TYPE.NAME.
+func (t *translator) buildSources(block *hclsyntax.Block) []string {
+	name := "build"
+	if n := blockName(t, block); n != "" {
+		name += " " + n
+	}
+	b := t.body(block.Body, name)
+	refs := b.strs("sources")
+	for _, nested := range b.blocks("source") {
+		if len(nested.block.Labels) != 1 {
+			continue
+		}
+		refs = append(refs, nested.block.Labels[0])
+		if len(nested.body.Attributes) > 0 || len(nested.body.Blocks) > 0 {
+			t.warnf(nested.block.DefRange(), "the overrides of source %s are not supported", nested.block.Labels[0])
+		}
+	}
+	b.used["name"] = true
+	b.ignore("provisioner")
+	b.warnUnused()
+	return refs
+}
+
+// blockName returns the name of a build block, empty if it has none.
+func blockName(t *translator, block *hclsyntax.Block) string {
+	attr, ok := block.Body.Attributes["name"]
+	if !ok {
+		return ""
+	}
+	value, diags := attr.Expr.Value(t.ctx)
+	if diags.HasErrors() || value.IsNull() || !value.Type().Equals(cty.String) {
+		return ""
+	}
+	return value.AsString()
+}
+
+// sourceName returns the reference of a source block, as source.TYPE.NAME.
+func sourceName(block *hclsyntax.Block) string {
+	return "source." + block.Labels[0] + "." + block.Labels[1]
+}
+
+// body gives access to the attributes and blocks of a block, recording the ones which have been translated.
+type body struct {
+	t     *translator
+	name  string
+	block *hclsyntax.Block
+	body  *hclsyntax.Body
+	used  map[string]bool
+}
+
+func (t *translator) body(b *hclsyntax.Body, name string) *body {
+	return &body{t: t, name: name, body: b, used: map[string]bool{}}
+}
+
+// value returns the value of the attribute, false if it is not set or can't be evaluated.
+func (b *body) value(name string, typ cty.Type) (cty.Value, bool) {
+	attr, ok := b.body.Attributes[name]
+	if !ok {
+		return cty.NilVal, false
+	}
+	b.used[name] = true
+	value, diags := attr.Expr.Value(b.t.ctx)
+	if diags.HasErrors() {
+		b.t.warnf(attr.SrcRange, "%s of %s is not evaluated: %s", name, b.name, diags[0].Summary)
+		return cty.NilVal, false
+	}
+	if value.IsNull() {
+		return cty.NilVal, false
+	}
+	value, err := convert.Convert(value, typ)
+	if err != nil || !value.IsWhollyKnown() {
+		b.t.warnf(attr.SrcRange, "%s of %s is not a %s", name, b.name, typ.FriendlyName())
+		return cty.NilVal, false
+	}
+	return value, true
+}
+
+// str returns the string attribute, the expression itself if it can't be evaluated so it can be completed by hand.
+func (b *body) str(name string) string {
+	value, ok := b.value(name, cty.String)
+	if !ok {
+		if attr, set := b.body.Attributes[name]; set {
+			return strings.Trim(string(attr.Expr.Range().SliceBytes(b.t.src)), `"`)
+		}
+		return ""
+	}
+	return value.AsString()
+}
+
+func (b *body) strs(name string) []string {
+	value, ok := b.value(name, cty.List(cty.String))
+	if !ok {
+		return nil
+	}
+	strs := []string{}
+	for _, v := range value.AsValueSlice() {
+		strs = append(strs, v.AsString())
+	}
+	return strs
+}
+
+func (b *body) strMap(name string) map[string]string {
+	value, ok := b.value(name, cty.Map(cty.String))
+	if !ok {
+		return nil
+	}
+	m := map[string]string{}
+	for k, v := range value.AsValueMap() {
+		m[k] = v.AsString()
+	}
+	return m
+}
+
+func (b *body) integer(name string) int64 {
+	value, ok := b.value(name, cty.Number)
+	if !ok {
+		return 0
+	}
+	i, accuracy := value.AsBigFloat().Int64()
+	if accuracy != big.Exact {
+		b.t.warnf(b.body.Attributes[name].SrcRange, "%s of %s is not an integer", name, b.name)
+	}
+	return i
+}
+
+func (b *body) boolean(name string) *bool {
+	value, ok := b.value(name, cty.Bool)
+	if !ok {
+		return nil
+	}
+	v := value.True()
+	return &v
+}
+
+// blocks returns the nested blocks of the given type.
+func (b *body) blocks(typ string) []*body {
+	b.used[typ] = true
+	blocks := []*body{}
+	for _, block := range b.body.Blocks {
+		if block.Type == typ {
+			nested := b.t.body(block.Body, typ+" of "+b.name)
+			nested.block = block
+			blocks = append(blocks, nested)
+		}
+	}
+	return blocks
+}
+
+// ignore marks the attributes or blocks as translated, e.g. the settings of the communicator which are not relevant.
+func (b *body) ignore(names ...string) {
+	for _, name := range names {
+		b.used[name] = true
+	}
+}
+
+// drop warns with the given reason when any of the attributes is set, and marks them as translated.
+func (b *body) drop(reason string, names ...string) {
+	for _, name := range names {
+		if attr, ok := b.body.Attributes[name]; ok && !b.used[name] {
+			b.t.warnf(attr.SrcRange, "%s of %s is not imported, %s", name, b.name, reason)
+		}
+		b.used[name] = true
+	}
+}
+
+// warnUnused warns about the attributes and blocks which have not been translated.
+func (b *body) warnUnused() {
+	for _, attr := range sortedAttributes(b.body) {
+		if !b.used[attr.Name] {
+			b.t.warnf(attr.SrcRange, "%s of %s is not supported", attr.Name, b.name)
+		}
+	}
+	for _, block := range b.body.Blocks {
+		if !b.used[block.Type] {
+			b.t.warnf(block.DefRange(), "%s blocks of %s are not supported", block.Type, b.name)
+		}
+	}
+}
+
+// sortedAttributes returns the attributes of the body in the order they are defined.
+func sortedAttributes(b *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(b.Attributes))
+	for _, attr := range b.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+	return attrs
+}
+
+// position returns the file and line of the range.
+func position(r hcl.Range) string {
+	return fmt.Sprintf("%s:%d", r.Filename, r.Start.Line)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// dnsName returns the name turned into a valid object name.
+func dnsName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const template = `
+packer {
+  required_plugins {
+    amazon = {
+      version = ">= 1.2.0"
+      source  = "github.com/hashicorp/amazon"
+    }
+  }
+}
+
+variable "region" {
+  type    = string
+  default = "eu-west-1"
+}
+
+variable "release" {
+  type = string
+}
+
+locals {
+  image_name = "ubuntu-${local.suffix}"
+  suffix     = replace(var.release, ".", "-")
+  timestamp  = regex_replace(timestamp(), "[- TZ:]", "")
+}
+
+source "amazon-ebs" "ubuntu" {
+  region        = var.region
+  source_ami    = "ami-0c1c30571d2dae5c9"
+  instance_type = "t3.small"
+  ssh_username  = "ubuntu"
+  ami_name      = local.image_name
+  ami_regions   = ["us-east-1"]
+  spot_price    = "auto"
+  access_key    = "AKIA"
+  tags = {
+    os = "ubuntu"
+  }
+  launch_block_device_mappings {
+    device_name = "/dev/sda1"
+    volume_size = 20
+    volume_type = "gp3"
+  }
+}
+
+source "googlecompute" "ubuntu_gcp" {
+  project_id              = "images"
+  zone                    = "europe-west1-b"
+  source_image_family     = "ubuntu-2204-lts"
+  source_image_project_id = ["ubuntu-os-cloud"]
+  ssh_username            = "packer"
+  image_name              = "ubuntu-${local.timestamp}"
+  omit_external_ip        = true
+}
+
+source "vsphere-iso" "rhel" {
+  datacenter    = "dc1"
+  cluster       = "cluster1"
+  CPUs          = 2
+  RAM           = 4096
+  guest_os_type = "RHEL_9_64"
+  firmware      = "efi"
+  iso_paths     = ["[datastore1] iso/rhel-9.4-ks.iso"]
+  vm_name       = "rhel-9"
+  boot_command  = ["<up><wait>"]
+  storage {
+    disk_size = 32768
+  }
+  network_adapters {
+    network = "VM Network"
+  }
+  content_library_destination {
+    library = "images"
+    ovf     = true
+  }
+}
+
+build {
+  name    = "linux"
+  sources = ["source.amazon-ebs.ubuntu", "source.googlecompute.ubuntu_gcp"]
+
+  source "source.vsphere-iso.rhel" {
+  }
+
+  provisioner "shell" {
+    environment_vars = ["DEBIAN_FRONTEND=noninteractive"]
+    inline           = ["apt-get update", "apt-get upgrade -y"]
+    max_retries      = 2
+    timeout          = "10m"
+  }
+
+  provisioner "shell" {
+    scripts = ["scripts/base.sh", "scripts/cleanup.sh"]
+    only    = ["amazon-ebs.ubuntu"]
+  }
+
+  provisioner "file" {
+    content     = "Built with Forge"
+    destination = "/etc/motd"
+  }
+
+  provisioner "ansible" {
+    playbook_file   = "scripts/playbook.yml"
+    extra_arguments = ["--extra-vars", "env=prod tier=web", "--tags=base", "-vvv"]
+  }
+
+  provisioner "breakpoint" {
+  }
+
+  post-processor "manifest" {
+  }
+}
+`
+
+func TestImport(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	g.Expect(os.Mkdir(filepath.Join(dir, "scripts"), 0o755)).To(Succeed())
+	for name, content := range map[string]string{
+		"base.sh":      "apt-get install -y curl\n",
+		"cleanup.sh":   "apt-get clean\n",
+		"playbook.yml": "- hosts: all\n  tasks: []\n",
+	} {
+		g.Expect(os.WriteFile(filepath.Join(dir, "scripts", name), []byte(content), 0o600)).To(Succeed())
+	}
+
+	result, err := Import("ubuntu.pkr.hcl", []byte(template), Options{
+		Namespace: "images",
+		Variables: map[string]string{"release": "24.04"},
+		Dir:       dir,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	objects := map[string]interface{}{}
+	for _, obj := range result.Objects {
+		g.Expect(obj.GetNamespace()).To(Equal("images"))
+		objects[obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName()] = obj
+	}
+	g.Expect(objects).To(HaveLen(13))
+
+	aws := objects["AWSBuild/ubuntu"].(*infrav1.AWSBuild)
+	g.Expect(aws.Spec).To(Equal(infrav1.AWSBuildSpec{
+		Region:         "eu-west-1",
+		SourceAMI:      "ami-0c1c30571d2dae5c9",
+		InstanceType:   "t3.small",
+		Username:       "ubuntu",
+		Tags:           map[string]string{"os": "ubuntu"},
+		Spot:           &infrav1.AWSSpotOptions{},
+		RootVolumeSize: 20,
+		RootVolumeType: "gp3",
+		AMI:            infrav1.AWSAMISpec{Copies: []infrav1.AWSAMICopy{{Name: "us-east-1", Region: "us-east-1"}}},
+	}))
+	gcp := objects["GCPBuild/ubuntu-gcp"].(*infrav1.GCPBuild)
+	g.Expect(gcp.Spec.SourceImageProject).To(Equal("ubuntu-os-cloud"))
+	g.Expect(gcp.Spec.PublicIP).To(Equal(ptr.To(false)))
+	vsphere := objects["VSphereBuild/rhel"].(*infrav1.VSphereBuild)
+	g.Expect(vsphere.Spec.ISO).To(Equal(&infrav1.VSphereISOSource{
+		Paths:       []string{"[datastore1] iso/rhel-9.4-ks.iso"},
+		GuestOS:     "RHEL_9_64",
+		Firmware:    "EFI",
+		DiskSizeGiB: 32,
+		Network:     "VM Network",
+	}))
+	g.Expect(vsphere.Spec.Template).To(Equal(infrav1.VSphereTemplateSpec{Library: "images", Format: infrav1.VSphereTemplateFormatOVF}))
+
+	build := objects["Build/ubuntu"].(*buildv1.Build)
+	g.Expect(build.Spec.ImageName).To(Equal("ubuntu-24-04"))
+	g.Expect(build.Spec.Connector).To(Equal(buildv1.ConnectorSpec{Type: buildv1.ConnectorTypeSSH}))
+	g.Expect(build.Spec.InfrastructureRef.Kind).To(Equal("AWSBuild"))
+	g.Expect(build.Spec.Provisioners).To(HaveLen(4))
+	g.Expect(build.Spec.Provisioners[0]).To(Equal(buildv1.ProvisionerSpec{
+		Name:                  "shell-1",
+		Type:                  buildv1.ProvisionerTypeShell,
+		Run:                   ptr.To("export DEBIAN_FRONTEND='noninteractive'\napt-get update\napt-get upgrade -y"),
+		Retries:               ptr.To(int32(2)),
+		ActiveDeadlineSeconds: ptr.To(int64(600)),
+	}))
+	g.Expect(build.Spec.Provisioners[1].RunConfigMapRef.Name).To(Equal("ubuntu-shell-2"))
+	g.Expect(objects["ConfigMap/ubuntu-shell-2"].(*corev1.ConfigMap).Data).To(Equal(map[string]string{
+		"01-base.sh":    "apt-get install -y curl\n",
+		"02-cleanup.sh": "apt-get clean\n",
+	}))
+	g.Expect(build.Spec.Provisioners[2].File.Files).To(Equal([]buildv1.FileUpload{{
+		Destination: "/etc/motd",
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "ubuntu-file-3"},
+			Key:                  "motd",
+		},
+	}}))
+	g.Expect(build.Spec.Provisioners[3].Ansible).To(Equal(&buildv1.AnsibleSpec{
+		PlaybookConfigMapRef: &corev1.LocalObjectReference{Name: "ubuntu-ansible-4"},
+		PlaybookPath:         "playbook.yml",
+		ExtraVars:            map[string]string{"env": "prod", "tier": "web"},
+		Tags:                 []string{"base"},
+	}))
+
+	// The provisioners only run for the amazon-ebs source are left out, the expressions which can't be evaluated
+	// are kept to be completed by hand.
+	gcpBuild := objects["Build/ubuntu-gcp"].(*buildv1.Build)
+	g.Expect(gcpBuild.Spec.Provisioners).To(HaveLen(3))
+	g.Expect(gcpBuild.Spec.ImageName).To(Equal("ubuntu-${local.timestamp}"))
+	g.Expect(objects["Build/rhel"].(*buildv1.Build).Spec.ImageName).To(Equal("rhel-9"))
+
+	g.Expect(result.Warnings).To(ContainElements(
+		"ubuntu.pkr.hcl:23: local timestamp is not evaluated: Call to unknown function",
+		"ubuntu.pkr.hcl:34: access_key of amazon-ebs.ubuntu is not imported, set the credentialsRef of the infrastructure or use a ProviderIdentity",
+		"ubuntu.pkr.hcl:51: image_name of googlecompute.ubuntu_gcp is not evaluated: Unsupported attribute",
+		"ubuntu.pkr.hcl:64: boot_command of vsphere-iso.rhel is not imported, the installation must authorize the connector credentials, e.g. with a kickstart file on the ISO images",
+		"ubuntu.pkr.hcl:109: post-processor blocks of build linux are not supported",
+		"ubuntu.pkr.hcl:103: extra argument -vvv of provisioner ansible-4 of Build ubuntu is not supported",
+		"ubuntu.pkr.hcl:106: provisioner breakpoint of Build ubuntu is not supported, only shell, file and ansible are",
+	))
+}
+
+func TestImportErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := Import("ubuntu.pkr.hcl", []byte(template), Options{Variables: map[string]string{"zone": "a"}})
+	g.Expect(err).To(MatchError("variable zone is not declared by the template"))
+
+	_, err = Import("qemu.pkr.hcl", []byte(`
+source "qemu" "ubuntu" {}
+build {
+  sources = ["source.qemu.ubuntu"]
+}
+`), Options{})
+	g.Expect(err).To(MatchError("the template holds no source of a supported builder: amazon-ebs, googlecompute or vsphere-iso"))
+
+	_, err = Import("missing.pkr.hcl", []byte(`build {
+  sources = ["source.amazon-ebs.ubuntu"]
+}`), Options{})
+	g.Expect(err).To(MatchError("missing.pkr.hcl:1: build references undeclared source source.amazon-ebs.ubuntu"))
+
+	_, err = Import("invalid.pkr.hcl", []byte("build {"), Options{})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// Provisioners translated by Import.
+const (
+	// ShellProvisioner is translated into a built-in/shell provisioner.
+	ShellProvisioner = "shell"
+	// FileProvisioner is translated into a built-in/file provisioner.
+	FileProvisioner = "file"
+	// AnsibleProvisioner is translated into a built-in/ansible provisioner.
+	AnsibleProvisioner = "ansible"
+)
+
+// translateProvisioners returns the provisioners of the build block run for the source. The files of the
+// provisioners are added to ConfigMaps named after the Build and the provisioner.
+func (t *translator) translateProvisioners(block, source *hclsyntax.Block, build *buildv1.Build) []buildv1.ProvisionerSpec {
+	ref := strings.TrimPrefix(sourceName(source), "source.")
+	provisioners := []buildv1.ProvisionerSpec{}
+	index := 0
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "provisioner" || len(nested.Labels) != 1 {
+			continue
+		}
+		// The provisioners are numbered before being filtered so they are named alike in every Build.
+		index++
+		name := fmt.Sprintf("%s-%d", dnsName(nested.Labels[0]), index)
+		p := t.body(nested.Body, fmt.Sprintf("provisioner %s of Build %s", name, build.Name))
+		p.block = nested
+		if only := p.strs("only"); len(only) > 0 && !contains(only, ref) {
+			continue
+		}
+		if contains(p.strs("except"), ref) {
+			continue
+		}
+
+		spec := buildv1.ProvisionerSpec{Name: name}
+		if retries := p.integer("max_retries"); retries > 0 {
+			spec.Retries = ptr.To(int32(retries))
+		}
+		if timeout := p.str("timeout"); timeout != "" {
+			d, err := time.ParseDuration(timeout)
+			if err != nil {
+				t.warnf(p.body.Attributes["timeout"].SrcRange, "timeout %s of %s is not a duration", timeout, p.name)
+			} else {
+				spec.ActiveDeadlineSeconds = ptr.To(int64(d.Seconds()))
+			}
+		}
+
+		t.files = nil
+		var ok bool
+		switch nested.Labels[0] {
+		case ShellProvisioner:
+			ok = t.shell(p, build, &spec)
+		case FileProvisioner:
+			ok = t.file(p, build, &spec)
+		case AnsibleProvisioner:
+			ok = t.ansible(p, build, &spec)
+		default:
+			t.warnf(nested.DefRange(), "provisioner %s of Build %s is not supported, only %s, %s and %s are",
+				nested.Labels[0], build.Name, ShellProvisioner, FileProvisioner, AnsibleProvisioner)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		p.warnUnused()
+		if t.files != nil {
+			t.result.Objects = append(t.result.Objects, t.files)
+		}
+		provisioners = append(provisioners, spec)
+	}
+	return provisioners
+}
+
+// shell translates a shell provisioner, the inline commands are run as a script and the scripts are added to
+// a ConfigMap run by the provisioner.
+func (t *translator) shell(p *body, build *buildv1.Build, spec *buildv1.ProvisionerSpec) bool {
+	spec.Type = buildv1.ProvisionerTypeShell
+	env := p.strs("environment_vars")
+	scripts := p.strs("scripts")
+	if script := p.str("script"); script != "" {
+		scripts = append([]string{script}, scripts...)
+	}
+
+	if inline := p.strs("inline"); len(inline) > 0 {
+		lines := []string{}
+		for _, v := range env {
+			name, value, _ := strings.Cut(v, "=")
+			lines = append(lines, fmt.Sprintf("export %s=%s", name, ssh.Quote(value)))
+		}
+		spec.Run = ptr.To(strings.Join(append(lines, inline...), "\n"))
+		return true
+	}
+	if len(scripts) == 0 {
+		t.warnf(p.block.DefRange(), "%s runs nothing", p.name)
+		return false
+	}
+	if len(env) > 0 {
+		t.warnf(p.body.Attributes["environment_vars"].SrcRange, "environment_vars of %s are only imported with inline, export them in the scripts", p.name)
+	}
+	cm := t.configMap(build, spec.Name)
+	for i, script := range scripts {
+		// The scripts of the ConfigMap are run in the lexical order of their keys.
+		t.addFile(cm, fmt.Sprintf("%02d-%s", i+1, filepath.Base(script)), script, p.body.SrcRange)
+	}
+	spec.RunConfigMapRef = &corev1.ObjectReference{Name: cm.Name}
+	return true
+}
+
+// file translates a file provisioner uploading files or content, the files are added to a ConfigMap.
+func (t *translator) file(p *body, build *buildv1.Build, spec *buildv1.ProvisionerSpec) bool {
+	spec.Type = buildv1.ProvisionerTypeFile
+	if direction := p.str("direction"); direction != "" && direction != "upload" {
+		t.warnf(p.block.DefRange(), "%s downloads files, use a built-in/extract provisioner", p.name)
+		return false
+	}
+	destination := p.str("destination")
+	if !path.IsAbs(destination) {
+		t.warnf(p.body.Attributes["destination"].SrcRange, "destination %s of %s must be an absolute path", destination, p.name)
+	}
+	p.ignore("generated")
+
+	cm := t.configMap(build, spec.Name)
+	spec.File = &buildv1.FileProvisionerSpec{}
+	add := func(key string) {
+		dest := destination
+		if strings.HasSuffix(dest, "/") {
+			dest = path.Join(dest, key)
+		}
+		spec.File.Files = append(spec.File.Files, buildv1.FileUpload{
+			Destination: dest,
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: cm.Name},
+				Key:                  key,
+			},
+		})
+	}
+	if content, ok := p.value("content", cty.String); ok {
+		key := path.Base(destination)
+		cm.Data[key] = content.AsString()
+		add(key)
+	}
+	sources := p.strs("sources")
+	if source := p.str("source"); source != "" {
+		sources = append([]string{source}, sources...)
+	}
+	for _, source := range sources {
+		key := filepath.Base(source)
+		if t.addFile(cm, key, source, p.body.SrcRange) {
+			add(key)
+		}
+	}
+	if len(spec.File.Files) == 0 {
+		t.warnf(p.block.DefRange(), "%s uploads nothing", p.name)
+		return false
+	}
+	return true
+}
+
+// ansible translates an ansible provisioner, the playbook is added to a ConfigMap.
+func (t *translator) ansible(p *body, build *buildv1.Build, spec *buildv1.ProvisionerSpec) bool {
+	spec.Type = buildv1.ProvisionerTypeAnsible
+	playbook := p.str("playbook_file")
+	if playbook == "" {
+		t.warnf(p.block.DefRange(), "%s has no playbook_file", p.name)
+		return false
+	}
+	cm := t.configMap(build, spec.Name)
+	if !t.addFile(cm, filepath.Base(playbook), playbook, p.body.Attributes["playbook_file"].SrcRange) {
+		return false
+	}
+	spec.Ansible = &buildv1.AnsibleSpec{
+		PlaybookConfigMapRef: &corev1.LocalObjectReference{Name: cm.Name},
+		PlaybookPath:         filepath.Base(playbook),
+	}
+	if galaxy := p.str("galaxy_file"); galaxy != "" {
+		if data, ok := t.readFile(galaxy, p.body.Attributes["galaxy_file"].SrcRange); ok {
+			spec.Ansible.Requirements = string(data)
+		}
+	}
+
+	args := p.strs("extra_arguments")
+	for i := 0; i < len(args); i++ {
+		flag, value, inline := strings.Cut(args[i], "=")
+		if !inline && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch flag {
+		case "-e", "--extra-vars":
+			if strings.HasPrefix(value, "{") || strings.HasPrefix(value, "@") {
+				t.warnf(p.body.Attributes["extra_arguments"].SrcRange, "the JSON and file extra vars of %s are not supported", p.name)
+			} else {
+				for _, v := range strings.Fields(value) {
+					if k, v, ok := strings.Cut(v, "="); ok {
+						if spec.Ansible.ExtraVars == nil {
+							spec.Ansible.ExtraVars = map[string]string{}
+						}
+						spec.Ansible.ExtraVars[k] = strings.Trim(v, `"'`)
+					}
+				}
+			}
+		case "-t", "--tags":
+			spec.Ansible.Tags = append(spec.Ansible.Tags, strings.Split(value, ",")...)
+		case "--skip-tags":
+			spec.Ansible.SkipTags = append(spec.Ansible.SkipTags, strings.Split(value, ",")...)
+		default:
+			t.warnf(p.body.Attributes["extra_arguments"].SrcRange, "extra argument %s of %s is not supported", args[i], p.name)
+			continue
+		}
+		if !inline {
+			i++
+		}
+	}
+	p.ignore("user", "use_proxy")
+	return true
+}
+
+// configMap returns the ConfigMap holding the files of the provisioner of the Build, it is added to the objects
+// once the provisioner has been translated.
+func (t *translator) configMap(build *buildv1.Build, provisioner string) *corev1.ConfigMap {
+	t.files = &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: build.Name + "-" + provisioner},
+		Data:       map[string]string{},
+	}
+	return t.files
+}
+
+// addFile adds the file to the ConfigMap under the key, the binary files are added to its binary data.
+func (t *translator) addFile(cm *corev1.ConfigMap, key, filename string, r hcl.Range) bool {
+	data, ok := t.readFile(filename, r)
+	if !ok {
+		return false
+	}
+	if utf8.Valid(data) {
+		cm.Data[key] = string(data)
+		return true
+	}
+	if cm.BinaryData == nil {
+		cm.BinaryData = map[string][]byte{}
+	}
+	cm.BinaryData[key] = data
+	return true
+}
+
+// readFile reads the file referenced by the template, relative to the directory of the template.
+func (t *translator) readFile(filename string, r hcl.Range) ([]byte, bool) {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(t.opts.Dir, filename)
+	}
+	info, err := os.Stat(filename)
+	if err == nil && info.IsDir() {
+		t.warnf(r, "directory %s is not imported, only files are", filename)
+		return nil, false
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.warnf(r, "%s is not imported: %v", filename, err)
+		return nil, false
+	}
+	return data, true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package connections keeps an inventory of the connections opened to build machines,
+// so connection leaks and stuck transfers can be diagnosed on a running controller.
+package connections
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Path is the path the inventory is served on by the controller debug endpoints.
+const Path = "/debug/connections"
+
+// DefaultInventory is the inventory used by the connectors of the controller.
+var DefaultInventory = NewInventory()
+
+// Info describes an open connection.
+type Info struct {
+	// ID uniquely identifies the connection within the inventory.
+	ID string `json:"id"`
+	// Protocol is the protocol of the connection, e.g. ssh.
+	Protocol string `json:"protocol"`
+	// Owner is the object the connection has been opened for, e.g. the namespace/name of a Build.
+	Owner string `json:"owner,omitempty"`
+	// Address is the remote address of the connection.
+	Address string `json:"address"`
+	// OpenedAt is the time the connection has been opened.
+	OpenedAt time.Time `json:"openedAt"`
+	// LastActivity is the last time data has been sent or received on the connection.
+	LastActivity time.Time `json:"lastActivity"`
+	// BytesSent is the number of bytes sent to the remote machine.
+	BytesSent int64 `json:"bytesSent"`
+	// BytesReceived is the number of bytes received from the remote machine.
+	BytesReceived int64 `json:"bytesReceived"`
+}
+
+// Inventory keeps track of the open connections.
+type Inventory struct {
+	mu     sync.RWMutex
+	nextID uint64
+	conns  map[string]*Tracker
+}
+
+// NewInventory returns an empty Inventory.
+func NewInventory() *Inventory {
+	return &Inventory{conns: map[string]*Tracker{}}
+}
+
+// Open registers a new connection, the returned Tracker must be closed when the connection is closed.
+func (i *Inventory) Open(protocol, owner, address string) *Tracker {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.nextID++
+	now := time.Now()
+	t := &Tracker{
+		inventory: i,
+		id:        strconv.FormatUint(i.nextID, 10),
+		protocol:  protocol,
+		owner:     owner,
+		address:   address,
+		openedAt:  now,
+	}
+	t.lastActivity.Store(now.UnixNano())
+	i.conns[t.id] = t
+	return t
+}
+
+// List returns the open connections, oldest first.
+func (i *Inventory) List() []Info {
+	i.mu.RLock()
+	infos := make([]Info, 0, len(i.conns))
+	for _, t := range i.conns {
+		infos = append(infos, t.Info())
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(infos, func(a, b int) bool {
+		if infos[a].OpenedAt.Equal(infos[b].OpenedAt) {
+			return infos[a].ID < infos[b].ID
+		}
+		return infos[a].OpenedAt.Before(infos[b].OpenedAt)
+	})
+	return infos
+}
+
+// ServeHTTP serves the open connections as JSON, the owner query parameter filters the connections by owner.
+func (i *Inventory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	infos := i.List()
+	if owner := r.URL.Query().Get("owner"); owner != "" {
+		filtered := make([]Info, 0, len(infos))
+		for _, info := range infos {
+			if info.Owner == owner {
+				filtered = append(filtered, info)
+			}
+		}
+		infos = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(infos); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (i *Inventory) remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.conns, id)
+}
+
+// Tracker records the activity of a single connection. A nil Tracker is valid and records nothing.
+type Tracker struct {
+	inventory *Inventory
+	id        string
+	protocol  string
+	owner     string
+	address   string
+	openedAt  time.Time
+
+	lastActivity  atomic.Int64
+	bytesSent     atomic.Int64
+	bytesReceived atomic.Int64
+}
+
+// Info returns the current state of the connection.
+func (t *Tracker) Info() Info {
+	return Info{
+		ID:            t.id,
+		Protocol:      t.protocol,
+		Owner:         t.owner,
+		Address:       t.address,
+		OpenedAt:      t.openedAt,
+		LastActivity:  time.Unix(0, t.lastActivity.Load()),
+		BytesSent:     t.bytesSent.Load(),
+		BytesReceived: t.bytesReceived.Load(),
+	}
+}
+
+// Touch records activity on the connection without data being transferred, e.g. running a command.
+func (t *Tracker) Touch() {
+	if t == nil {
+		return
+	}
+	t.lastActivity.Store(time.Now().UnixNano())
+}
+
+// Sent records n bytes sent to the remote machine.
+func (t *Tracker) Sent(n int) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.bytesSent.Add(int64(n))
+	t.Touch()
+}
+
+// Received records n bytes received from the remote machine.
+func (t *Tracker) Received(n int) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.bytesReceived.Add(int64(n))
+	t.Touch()
+}
+
+// SentWriter returns a writer recording the bytes written to w as sent.
+func (t *Tracker) SentWriter(w io.Writer) io.Writer {
+	if t == nil || w == nil {
+		return w
+	}
+	return &countingWriter{w: w, count: t.Sent}
+}
+
+// ReceivedWriter returns a writer recording the bytes written to w as received.
+func (t *Tracker) ReceivedWriter(w io.Writer) io.Writer {
+	if t == nil || w == nil {
+		return w
+	}
+	return &countingWriter{w: w, count: t.Received}
+}
+
+// ReceivedReader returns a reader recording the bytes read from r as received.
+func (t *Tracker) ReceivedReader(r io.Reader) io.Reader {
+	if t == nil || r == nil {
+		return r
+	}
+	return &countingReader{r: r, count: t.Received}
+}
+
+// SentReader returns a reader recording the bytes read from r as sent.
+func (t *Tracker) SentReader(r io.Reader) io.Reader {
+	if t == nil || r == nil {
+		return r
+	}
+	return &countingReader{r: r, count: t.Sent}
+}
+
+// Close removes the connection from the inventory, it is safe to call Close multiple times.
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+	t.inventory.remove(t.id)
+}
+
+type countingWriter struct {
+	w     io.Writer
+	count func(int)
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count(n)
+	return n, err
+}
+
+type countingReader struct {
+	r     io.Reader
+	count func(int)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.count(n)
+	return n, err
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connections
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestInventory(t *testing.T) {
+	g := NewWithT(t)
+
+	inventory := NewInventory()
+	first := inventory.Open("ssh", "default/ubuntu", "10.0.0.1:22")
+	second := inventory.Open("ssh", "default/debian", "10.0.0.2:22")
+
+	_, err := io.Copy(first.SentWriter(io.Discard), strings.NewReader("hello"))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = io.ReadAll(first.ReceivedReader(strings.NewReader("hi")))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = first.ReceivedWriter(&bytes.Buffer{}).Write([]byte("output"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	infos := inventory.List()
+	g.Expect(infos).To(HaveLen(2))
+	g.Expect(infos[0].ID).To(Equal("1"))
+	g.Expect(infos[0].Owner).To(Equal("default/ubuntu"))
+	g.Expect(infos[0].BytesSent).To(Equal(int64(5)))
+	g.Expect(infos[0].BytesReceived).To(Equal(int64(8)))
+	g.Expect(infos[0].LastActivity).NotTo(BeTemporally("<", infos[0].OpenedAt))
+
+	second.Close()
+	second.Close()
+	g.Expect(inventory.List()).To(HaveLen(1))
+}
+
+func TestNilTracker(t *testing.T) {
+	g := NewWithT(t)
+
+	var tracker *Tracker
+	w := &bytes.Buffer{}
+	r := strings.NewReader("")
+	g.Expect(tracker.SentWriter(w)).To(BeIdenticalTo(w))
+	g.Expect(tracker.ReceivedReader(r)).To(BeIdenticalTo(r))
+	g.Expect(tracker.ReceivedWriter(nil)).To(BeNil())
+	tracker.Sent(10)
+	tracker.Touch()
+	tracker.Close()
+}
+
+func TestServeHTTP(t *testing.T) {
+	g := NewWithT(t)
+
+	inventory := NewInventory()
+	inventory.Open("ssh", "default/ubuntu", "10.0.0.1:22")
+	inventory.Open("ssh", "default/debian", "10.0.0.2:22")
+
+	rec := httptest.NewRecorder()
+	inventory.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path+"?owner=default/debian", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+
+	infos := []Info{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &infos)).To(Succeed())
+	g.Expect(infos).To(HaveLen(1))
+	g.Expect(infos[0].Address).To(Equal("10.0.0.2:22"))
+
+	rec = httptest.NewRecorder()
+	inventory.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+}
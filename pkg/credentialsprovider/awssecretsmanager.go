@@ -0,0 +1,118 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialsprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// awsSecretsManager reads the secrets of AWS Secrets Manager.
+type awsSecretsManager struct {
+	region     string
+	endpoint   string
+	creds      publish.Credentials
+	httpClient *http.Client
+}
+
+func newAWSSecretsManager(ctx context.Context, c client.Client, namespace string, spec *buildv1.AWSSecretsManagerCredentialsProvider) (*awsSecretsManager, error) {
+	data, err := secretData(ctx, c, namespace, spec.CredentialsRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	creds := publish.Credentials{
+		AccessKeyID:     string(data[infrav1.AWSAccessKeyIDKey]),
+		SecretAccessKey: string(data[infrav1.AWSSecretAccessKeyKey]),
+		SessionToken:    string(data[infrav1.AWSSessionTokenKey]),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, forgeerrors.ConfigErrorf("secret %s must have the %s and %s keys", spec.CredentialsRef.Name,
+			infrav1.AWSAccessKeyIDKey, infrav1.AWSSecretAccessKeyKey)
+	}
+	endpoint := spec.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", spec.Region)
+	}
+	return &awsSecretsManager{
+		region:     spec.Region,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		creds:      creds,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+func (a *awsSecretsManager) Get(ctx context.Context, path, version string) (*Value, error) {
+	body, err := json.Marshal(&struct {
+		SecretID  string `json:"SecretId"`
+		VersionID string `json:"VersionId,omitempty"`
+	}{SecretID: path, VersionID: version})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	publish.SignRequest(req, a.creds, a.region, "secretsmanager", body)
+
+	resp := &struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary string  `json:"SecretBinary"`
+	}{}
+	if err := do(a.httpClient, req, resp, awsErrorMessage); err != nil {
+		return nil, err
+	}
+	if resp.SecretString != nil {
+		return &Value{Raw: []byte(*resp.SecretString)}, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(resp.SecretBinary)
+	if err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid binary value of secret %s: %v", path, err)
+	}
+	return &Value{Raw: raw}, nil
+}
+
+// awsErrorMessage returns the message of an error response of AWS Secrets Manager, {"__type": ..., "message": ...}.
+func awsErrorMessage(data []byte) string {
+	resp := &struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}{}
+	if err := json.Unmarshal(data, resp); err != nil || resp.Type == "" {
+		return ""
+	}
+	// The type may be prefixed with its namespace, e.g. com.amazonaws.secretsmanager#ResourceNotFoundException.
+	errType := resp.Type[strings.LastIndex(resp.Type, "#")+1:]
+	if resp.Message == "" {
+		return errType
+	}
+	return errType + ": " + resp.Message
+}
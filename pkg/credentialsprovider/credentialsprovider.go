@@ -0,0 +1,194 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentialsprovider resolves the credentials referenced by a CredentialsProviderReference from Vault,
+// AWS Secrets Manager or GCP Secret Manager. The credentials are fetched on every call and returned in memory,
+// as the data of a Secret so the consumers of the Secrets can use them as is, they are never stored.
+package credentialsprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+// Value is the value of a secret of an external secret manager: the fields of a Vault secret, or the raw value
+// of an AWS or GCP secret.
+type Value struct {
+	Fields map[string]interface{}
+	Raw    []byte
+}
+
+// Store is an external secret manager.
+type Store interface {
+	// Get returns the value of the secret at path, of the latest version when version is empty.
+	Get(ctx context.Context, path, version string) (*Value, error)
+}
+
+// NewStore returns the Store of the CredentialsProvider, the secrets it references are read with c.
+func NewStore(ctx context.Context, c client.Client, provider *buildv1.CredentialsProvider) (Store, error) {
+	switch spec := provider.Spec; {
+	case spec.Vault != nil:
+		return newVault(ctx, c, provider.Namespace, spec.Vault)
+	case spec.AWSSecretsManager != nil:
+		return newAWSSecretsManager(ctx, c, provider.Namespace, spec.AWSSecretsManager)
+	case spec.GCPSecretManager != nil:
+		return newGCPSecretManager(ctx, c, provider.Namespace, spec.GCPSecretManager)
+	}
+	return nil, forgeerrors.ConfigErrorf("CredentialsProvider %s has no secret manager", provider.Name)
+}
+
+// Resolve fetches the credentials referenced by ref, the CredentialsProvider is in namespace.
+func Resolve(ctx context.Context, c client.Client, namespace string, ref *buildv1.CredentialsProviderReference) (map[string][]byte, error) {
+	provider := &buildv1.CredentialsProvider{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, provider); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, forgeerrors.ConfigErrorf("CredentialsProvider %s not found", ref.Name)
+		}
+		return nil, errors.Wrapf(err, "failed to get CredentialsProvider %s", ref.Name)
+	}
+	store, err := NewStore(ctx, c, provider)
+	if err != nil {
+		return nil, err
+	}
+	value, err := store.Get(ctx, ref.Path, ref.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s from CredentialsProvider %s", ref.Path, ref.Name)
+	}
+	return Data(value, ref)
+}
+
+// Secret returns the credentials referenced by ref as a Secret which is not stored, named after the
+// CredentialsProvider and the path of the credentials.
+func Secret(ctx context.Context, c client.Client, namespace string, ref *buildv1.CredentialsProviderReference) (*corev1.Secret, error) {
+	data, err := Resolve(ctx, c, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: ref.Name + "/" + ref.Path},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       data,
+	}, nil
+}
+
+// Overlay sets the credentials referenced by ref in the data of the secret, which must not be stored afterwards.
+// It does nothing when ref is nil.
+func Overlay(ctx context.Context, c client.Client, ref *buildv1.CredentialsProviderReference, secret *corev1.Secret) error {
+	if ref == nil {
+		return nil
+	}
+	data, err := Resolve(ctx, c, secret.Namespace, ref)
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range data {
+		secret.Data[k] = v
+	}
+	return nil
+}
+
+// Data returns the keys of the value: its fields, or the fields of the JSON object it holds, renamed as per ref.
+func Data(value *Value, ref *buildv1.CredentialsProviderReference) (map[string][]byte, error) {
+	fields := value.Fields
+	switch {
+	case fields == nil && ref.Key != "":
+		return rename(map[string][]byte{ref.Key: value.Raw}, ref.Keys), nil
+	case fields == nil:
+		if err := json.Unmarshal(value.Raw, &fields); err != nil || fields == nil {
+			return nil, forgeerrors.ConfigErrorf("secret %s does not hold a JSON object, set the key of the reference to use its whole value", ref.Path)
+		}
+	case ref.Key != "":
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		return rename(map[string][]byte{ref.Key: raw}, ref.Keys), nil
+	}
+
+	data := make(map[string][]byte, len(fields))
+	for k, v := range fields {
+		switch v := v.(type) {
+		case string:
+			data[k] = []byte(v)
+		case nil:
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			data[k] = raw
+		}
+	}
+	return rename(data, ref.Keys), nil
+}
+
+// rename renames the keys of data as per keys.
+func rename(data map[string][]byte, keys map[string]string) map[string][]byte {
+	for from, to := range keys {
+		if v, ok := data[from]; ok {
+			delete(data, from)
+			data[to] = v
+		}
+	}
+	return data
+}
+
+// secretData returns the data of the secret referenced by a CredentialsProvider, in its namespace.
+func secretData(ctx context.Context, c client.Client, namespace, name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, forgeerrors.ConfigErrorf("secret %s not found", name)
+		}
+		return nil, errors.Wrapf(err, "failed to get secret %s", name)
+	}
+	return secret.Data, nil
+}
+
+// do sends the request and decodes the JSON response into out, message returns the message of an error response.
+func do(httpClient *http.Client, req *http.Request, out interface{}, message func([]byte) string) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", req.Method, req.URL.Redacted()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// Throttling and server errors are retried, the others, e.g. a missing secret or a denied access, are
+		// configuration errors.
+		return apierror.Classify(apierror.Parse(resp.StatusCode, resp.Body, func(data []byte) (string, string) {
+			return "", message(data)
+		}))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "invalid response to %s %s", req.Method, req.URL.Redacted())
+	}
+	return nil
+}
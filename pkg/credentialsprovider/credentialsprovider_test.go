@@ -0,0 +1,294 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialsprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestResolve(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("service-account-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	serviceAccountTokenFile = tokenFile
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		// Vault
+		case r.URL.Path == "/v1/auth/kubernetes/login":
+			if string(body) != `{"jwt":"service-account-token","role":"forge"}` {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"login-token"}}`))
+		case r.URL.Path == "/v1/secret/data/forge/ssh" && r.Header.Get("X-Vault-Token") == "login-token":
+			if r.URL.Query().Get("version") != "2" || r.Header.Get("X-Vault-Namespace") != "team" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"data":{"username":"ubuntu","key":"PRIVATE KEY"},"metadata":{"version":2}}}`))
+		case r.URL.Path == "/v1/kv/forge/aws" && r.Header.Get("X-Vault-Token") == "static-token":
+			_, _ = w.Write([]byte(`{"data":{"access_key":"AKIA","secret_key":"secret","ttl":3600}}`))
+		case r.URL.Path == "/v1/database/creds/forge":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"errors":["Vault is sealed"]}`))
+		// AWS Secrets Manager
+		case r.URL.Path == "/" && r.Header.Get("X-Amz-Target") == "secretsmanager.GetSecretValue":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIA/") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			request := map[string]string{}
+			_ = json.Unmarshal(body, &request)
+			switch request["SecretId"] {
+			case "forge/gcp":
+				_, _ = w.Write([]byte(`{"SecretString":"{\"type\":\"service_account\"}"}`))
+			case "forge/ssh":
+				_, _ = w.Write([]byte(`{"SecretString":"{\"password\":\"secret\"}"}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+			}
+		// GCP Secret Manager
+		case r.URL.Path == "/token":
+			_, _ = w.Write([]byte(`{"access_token":"gcp-token","token_type":"Bearer","expires_in":3600}`))
+		case r.URL.Path == "/v1/projects/forge/secrets/ssh/versions/latest:access":
+			if r.Header.Get("Authorization") != "Bearer gcp-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			// {"password":"secret"}
+			_, _ = w.Write([]byte(`{"payload":{"data":"eyJwYXNzd29yZCI6InNlY3JldCJ9"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	serviceAccountKey, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "forge@forge.iam.gserviceaccount.com",
+		"private_key":  string(privateKeyPEM),
+		"token_uri":    server.URL + "/token",
+	})
+	objs := []client.Object{
+		&buildv1.CredentialsProvider{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vault"},
+			Spec: buildv1.CredentialsProviderSpec{Vault: &buildv1.VaultCredentialsProvider{
+				Address:   server.URL,
+				Namespace: "team",
+				Auth:      buildv1.VaultAuth{Kubernetes: &buildv1.VaultKubernetesAuth{Role: "forge"}},
+			}},
+		},
+		&buildv1.CredentialsProvider{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vault-kv"},
+			Spec: buildv1.CredentialsProviderSpec{Vault: &buildv1.VaultCredentialsProvider{
+				Address: server.URL,
+				Engine:  buildv1.VaultEngineKVv1,
+				Mount:   "kv",
+				Auth:    buildv1.VaultAuth{TokenSecretRef: &corev1.LocalObjectReference{Name: "vault-token"}},
+			}},
+		},
+		&buildv1.CredentialsProvider{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vault-dynamic"},
+			Spec: buildv1.CredentialsProviderSpec{Vault: &buildv1.VaultCredentialsProvider{
+				Address: server.URL,
+				Engine:  buildv1.VaultEngineDynamic,
+				Auth:    buildv1.VaultAuth{TokenSecretRef: &corev1.LocalObjectReference{Name: "vault-token"}},
+			}},
+		},
+		&buildv1.CredentialsProvider{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "aws"},
+			Spec: buildv1.CredentialsProviderSpec{AWSSecretsManager: &buildv1.AWSSecretsManagerCredentialsProvider{
+				Region:         "eu-west-1",
+				Endpoint:       server.URL,
+				CredentialsRef: corev1.LocalObjectReference{Name: "aws"},
+			}},
+		},
+		&buildv1.CredentialsProvider{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gcp"},
+			Spec: buildv1.CredentialsProviderSpec{GCPSecretManager: &buildv1.GCPSecretManagerCredentialsProvider{
+				Project:        "forge",
+				Endpoint:       server.URL,
+				CredentialsRef: corev1.LocalObjectReference{Name: "gcp"},
+			}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vault-token"},
+			Data:       map[string][]byte{vaultTokenKey: []byte("static-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "aws"},
+			Data: map[string][]byte{
+				infrav1.AWSAccessKeyIDKey:     []byte("AKIA"),
+				infrav1.AWSSecretAccessKeyKey: []byte("secret"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gcp"},
+			Data:       map[string][]byte{infrav1.GCPCredentialsKey: serviceAccountKey},
+		},
+	}
+
+	testcases := []struct {
+		name          string
+		ref           buildv1.CredentialsProviderReference
+		multiTenancy  bool
+		want          map[string][]byte
+		wantErr       string
+		wantRetryable bool
+	}{
+		{
+			name: "Vault KV version 2 with the Kubernetes auth method",
+			ref: buildv1.CredentialsProviderReference{
+				Name: "vault", Path: "forge/ssh", Version: "2", Keys: map[string]string{"key": "privateKey"},
+			},
+			want: map[string][]byte{"username": []byte("ubuntu"), "privateKey": []byte("PRIVATE KEY")},
+		},
+		{
+			name: "Vault Kubernetes auth method in multi-tenancy mode",
+			ref: buildv1.CredentialsProviderReference{
+				Name: "vault", Path: "forge/ssh", Version: "2",
+			},
+			multiTenancy: true,
+			wantErr:      "the Kubernetes auth method of Vault " + server.URL + " is not allowed in multi-tenancy mode",
+		},
+		{
+			name:         "Vault token in multi-tenancy mode",
+			ref:          buildv1.CredentialsProviderReference{Name: "vault-kv", Path: "forge/aws"},
+			multiTenancy: true,
+			want: map[string][]byte{
+				"access_key": []byte("AKIA"),
+				"secret_key": []byte("secret"),
+				"ttl":        []byte("3600"),
+			},
+		},
+		{
+			name: "Vault KV version 1 with a token",
+			ref: buildv1.CredentialsProviderReference{
+				Name: "vault-kv", Path: "forge/aws",
+				Keys: map[string]string{"access_key": infrav1.AWSAccessKeyIDKey, "secret_key": infrav1.AWSSecretAccessKeyKey},
+			},
+			want: map[string][]byte{
+				infrav1.AWSAccessKeyIDKey:     []byte("AKIA"),
+				infrav1.AWSSecretAccessKeyKey: []byte("secret"),
+				"ttl":                         []byte("3600"),
+			},
+		},
+		{
+			name:          "sealed Vault",
+			ref:           buildv1.CredentialsProviderReference{Name: "vault-dynamic", Path: "database/creds/forge"},
+			wantErr:       "503 Service Unavailable: Vault is sealed",
+			wantRetryable: true,
+		},
+		{
+			name: "AWS Secrets Manager",
+			ref:  buildv1.CredentialsProviderReference{Name: "aws", Path: "forge/ssh"},
+			want: map[string][]byte{"password": []byte("secret")},
+		},
+		{
+			name: "whole value of an AWS secret",
+			ref:  buildv1.CredentialsProviderReference{Name: "aws", Path: "forge/gcp", Key: infrav1.GCPCredentialsKey},
+			want: map[string][]byte{infrav1.GCPCredentialsKey: []byte(`{"type":"service_account"}`)},
+		},
+		{
+			name:    "missing AWS secret",
+			ref:     buildv1.CredentialsProviderReference{Name: "aws", Path: "forge/missing"},
+			wantErr: "400 Bad Request: ResourceNotFoundException: Secrets Manager can't find the specified secret.",
+		},
+		{
+			name: "GCP Secret Manager",
+			ref:  buildv1.CredentialsProviderReference{Name: "gcp", Path: "ssh"},
+			want: map[string][]byte{"password": []byte("secret")},
+		},
+		{
+			name:    "missing CredentialsProvider",
+			ref:     buildv1.CredentialsProviderReference{Name: "missing", Path: "forge/ssh"},
+			wantErr: "CredentialsProvider missing not found",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+			AllowControllerIdentity = !tc.multiTenancy
+			defer func() { AllowControllerIdentity = true }()
+
+			data, err := Resolve(context.Background(), c, "default", &tc.ref)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.wantRetryable))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestData(t *testing.T) {
+	g := NewWithT(t)
+
+	secret := &corev1.Secret{Data: map[string][]byte{"host": []byte("10.0.0.1"), "username": []byte("ubuntu")}}
+	g.Expect(Overlay(context.Background(), nil, nil, secret)).To(Succeed())
+	g.Expect(secret.Data).To(HaveLen(2))
+
+	data, err := Data(&Value{Raw: []byte("not JSON")}, &buildv1.CredentialsProviderReference{Path: "forge/ssh"})
+	g.Expect(err).To(MatchError(ContainSubstring("does not hold a JSON object")))
+	g.Expect(data).To(BeNil())
+
+	data, err = Data(&Value{Fields: map[string]interface{}{"a": "b"}}, &buildv1.CredentialsProviderReference{Key: "value"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(Equal(map[string][]byte{"value": []byte(`{"a":"b"}`)}))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialsprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/jwt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// gcpSecretManagerEndpoint is the endpoint of the Secret Manager API.
+	gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+
+	// gcpScope is the OAuth scope of the tokens of the service account.
+	gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// gcpTokenURL is the token URL of the service account keys without one.
+	gcpTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// gcpSecretManager reads the secrets of the Secret Manager of a project.
+type gcpSecretManager struct {
+	project  string
+	endpoint string
+	// httpClient authenticates the requests with the tokens of the service account.
+	httpClient *http.Client
+}
+
+func newGCPSecretManager(ctx context.Context, c client.Client, namespace string, spec *buildv1.GCPSecretManagerCredentialsProvider) (*gcpSecretManager, error) {
+	data, err := secretData(ctx, c, namespace, spec.CredentialsRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	key := &struct {
+		Type         string `json:"type"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		ClientEmail  string `json:"client_email"`
+		TokenURI     string `json:"token_uri"`
+	}{}
+	if err := json.Unmarshal(data[infrav1.GCPCredentialsKey], key); err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid service account key in secret %s: %v", spec.CredentialsRef.Name, err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" {
+		return nil, forgeerrors.ConfigErrorf("the %s key of secret %s must be the JSON key of a service account",
+			infrav1.GCPCredentialsKey, spec.CredentialsRef.Name)
+	}
+	if block, _ := pem.Decode([]byte(key.PrivateKey)); block == nil {
+		return nil, forgeerrors.ConfigErrorf("invalid service account key, the private key of %s is not PEM encoded", key.ClientEmail)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = gcpTokenURL
+	}
+	endpoint := spec.Endpoint
+	if endpoint == "" {
+		endpoint = gcpSecretManagerEndpoint
+	}
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{gcpScope},
+		TokenURL:     key.TokenURI,
+	}
+	return &gcpSecretManager{
+		project:    spec.Project,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: config.Client(ctx),
+	}, nil
+}
+
+func (g *gcpSecretManager) Get(ctx context.Context, path, version string) (*Value, error) {
+	if version == "" {
+		version = "latest"
+	}
+	u := g.endpoint + "/v1/projects/" + url.PathEscape(g.project) + "/secrets/" + url.PathEscape(path) +
+		"/versions/" + url.PathEscape(version) + ":access"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+
+	resp := &struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}{}
+	if err := do(g.httpClient, req, resp, gcpErrorMessage); err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid value of secret %s: %v", path, err)
+	}
+	return &Value{Raw: raw}, nil
+}
+
+// gcpErrorMessage returns the message of an error response of Google Cloud, {"error": {"message": ...}}.
+func gcpErrorMessage(data []byte) string {
+	resp := &struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return ""
+	}
+	return resp.Error.Message
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialsprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// vaultTokenKey is the key of the Vault token in the secret of tokenSecretRef.
+	vaultTokenKey = "token"
+
+	// defaultVaultKubernetesMountPath is the mount path of the Kubernetes auth method when not set.
+	defaultVaultKubernetesMountPath = "kubernetes"
+)
+
+// serviceAccountTokenFile is the token of the service account of the controller, it logs in with the Kubernetes
+// auth method of Vault.
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// AllowControllerIdentity allows the CredentialsProviders to authenticate as the controller, with the Kubernetes auth
+// method of Vault. It is disabled in multi-tenancy mode, where every namespace brings its own Vault token, so the
+// CredentialsProviders of a namespace cannot read the secrets the controller is allowed to.
+var AllowControllerIdentity = true
+
+// vault reads the secrets of a Vault server, it logs in on every read.
+type vault struct {
+	spec       *buildv1.VaultCredentialsProvider
+	httpClient *http.Client
+	// token is the Vault token of tokenSecretRef, it is empty with the Kubernetes auth method.
+	token string
+}
+
+func newVault(ctx context.Context, c client.Client, namespace string, spec *buildv1.VaultCredentialsProvider) (*vault, error) {
+	v := &vault{spec: spec, httpClient: http.DefaultClient}
+	if len(spec.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(spec.CABundle) {
+			return nil, forgeerrors.ConfigErrorf("the caBundle of Vault %s holds no PEM certificate", spec.Address)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		v.httpClient = &http.Client{Transport: transport}
+	}
+	if ref := spec.Auth.TokenSecretRef; ref != nil {
+		data, err := secretData(ctx, c, namespace, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if v.token = string(data[vaultTokenKey]); v.token == "" {
+			return nil, forgeerrors.ConfigErrorf("secret %s has no %s key", ref.Name, vaultTokenKey)
+		}
+	}
+	return v, nil
+}
+
+// vaultResponse is a response of the Vault API.
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+func (v *vault) Get(ctx context.Context, path, version string) (*Value, error) {
+	token := v.token
+	if token == "" {
+		var err error
+		if token, err = v.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	path = strings.Trim(path, "/")
+	mount := strings.Trim(v.spec.GetMount(), "/")
+	query := url.Values{}
+	switch v.spec.GetEngine() {
+	case buildv1.VaultEngineKVv2:
+		path = mount + "/data/" + path
+		if version != "" {
+			query.Set("version", version)
+		}
+	case buildv1.VaultEngineKVv1:
+		path = mount + "/" + path
+	}
+	resp := &vaultResponse{}
+	if err := v.do(ctx, http.MethodGet, path, query, token, nil, resp); err != nil {
+		return nil, err
+	}
+
+	fields := resp.Data
+	if v.spec.GetEngine() == buildv1.VaultEngineKVv2 {
+		// The data of a KV version 2 secret is wrapped with its metadata.
+		data, _ := fields["data"].(map[string]interface{})
+		fields = data
+	}
+	if fields == nil {
+		return nil, forgeerrors.ConfigErrorf("secret %s has no data", path)
+	}
+	return &Value{Fields: fields}, nil
+}
+
+// login logs in with the Kubernetes auth method and returns the Vault token.
+func (v *vault) login(ctx context.Context) (string, error) {
+	auth := v.spec.Auth.Kubernetes
+	if auth == nil {
+		return "", forgeerrors.ConfigErrorf("Vault %s has no auth method", v.spec.Address)
+	}
+	if !AllowControllerIdentity {
+		return "", forgeerrors.ConfigErrorf("the Kubernetes auth method of Vault %s is not allowed in multi-tenancy mode, use a tokenSecretRef", v.spec.Address)
+	}
+	jwt, err := os.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the service account token of the controller")
+	}
+	mountPath := auth.MountPath
+	if mountPath == "" {
+		mountPath = defaultVaultKubernetesMountPath
+	}
+	body := map[string]string{"role": auth.Role, "jwt": strings.TrimSpace(string(jwt))}
+	resp := &vaultResponse{}
+	if err := v.do(ctx, http.MethodPost, "auth/"+strings.Trim(mountPath, "/")+"/login", nil, "", body, resp); err != nil {
+		return "", errors.Wrapf(err, "failed to log in to Vault as role %s", auth.Role)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.Errorf("no token in the login response of Vault %s", v.spec.Address)
+	}
+	return resp.Auth.ClientToken, nil
+}
+
+// do sends the request to the path of the Vault API and decodes the response into out.
+func (v *vault) do(ctx context.Context, method, path string, query url.Values, token string, body, out interface{}) error {
+	u := strings.TrimSuffix(v.spec.Address, "/") + "/v1/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return forgeerrors.NewConfigError(err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.spec.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.spec.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return do(v.httpClient, req, out, vaultErrorMessage)
+}
+
+// vaultErrorMessage returns the message of an error response of Vault, {"errors": [...]}.
+func vaultErrorMessage(data []byte) string {
+	resp := &struct {
+		Errors []string `json:"errors"`
+	}{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return ""
+	}
+	return strings.Join(resp.Errors, ", ")
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// Connector runs the commands of the provisioners in the container of a Build with docker exec, it is the
+// docker-exec connector.
+type Connector struct {
+	Client *Client
+	// Container is the ID of the container.
+	Container string
+	// User is the user the commands run as, the default user of the container if empty.
+	User string
+}
+
+// NewConnector returns the docker-exec connector described by the connector credentials secret of a Build, username
+// is used when the secret has none.
+func NewConnector(secret *corev1.Secret, username string) (*Connector, error) {
+	container := string(secret.Data[ContainerKey])
+	if container == "" {
+		return nil, errors.Errorf("secret %s has no %s", secret.Name, ContainerKey)
+	}
+	client, err := NewClient(secret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid secret %s", secret.Name)
+	}
+	connector := &Connector{Client: client, Container: container, User: string(secret.Data[UsernameKey])}
+	if connector.User == "" {
+		connector.User = username
+	}
+	return connector, nil
+}
+
+// ExitError is the error of a command which exited with a non-zero status.
+type ExitError struct {
+	Status int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Status)
+}
+
+// Connect checks the container is running and accepts commands, and returns the version of its Docker Engine.
+func (c *Connector) Connect(ctx context.Context) (*Version, error) {
+	version, err := c.Client.Version(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the Docker Engine")
+	}
+	container, err := c.Client.InspectContainer(ctx, c.Container)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get container %s", c.Container)
+	}
+	if !container.State.Running {
+		return nil, forgeerrors.NewTransient(errors.Errorf("container %s is %s", c.Container, container.State.Status))
+	}
+	if err := c.RunContext(ctx, "true", io.Discard, io.Discard); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// WaitForContainer connects to the container until it succeeds or maxWait elapses.
+func (c *Connector) WaitForContainer(ctx context.Context, maxWait time.Duration) (*Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+	for {
+		version, err := c.Connect(ctx)
+		if err == nil || !forgeerrors.IsRetryable(err) {
+			return version, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// Run runs the command with sh in the container.
+func (c *Connector) Run(command string, stdout, stderr io.Writer) error {
+	return c.RunContext(context.Background(), command, stdout, stderr)
+}
+
+// RunContext runs the command with sh in the container, the command is not interrupted when ctx is done.
+func (c *Connector) RunContext(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	result, err := c.Client.Exec(ctx, c.Container, c.User, []string{"/bin/sh", "-c", command}, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return &ExitError{Status: result.ExitCode}
+	}
+	return nil
+}
+
+// Upload writes the content of src to the file dst of the container, its directory must exist.
+func (c *Connector) Upload(src io.Reader, dst string, mode uint32) error {
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	archive := &bytes.Buffer{}
+	tw := tar.NewWriter(archive)
+	header := &tar.Header{
+		Name:    path.Base(dst),
+		Mode:    int64(mode),
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+		Uname:   c.User,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return c.Client.CopyToContainer(context.Background(), c.Container, path.Dir(dst), archive)
+}
+
+// Disconnect releases the connections to the Docker Engine.
+func (c *Connector) Disconnect() {
+	c.Client.HTTPClient.CloseIdleConnections()
+}
@@ -0,0 +1,491 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker implements the parts of the Docker Engine API used by the DockerBuilds and by the docker-exec
+// connector: the containers the provisioners run in, the execution of commands in them, and the commit and the push
+// of the images.
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/providersdk/apierror"
+)
+
+// The keys of the secrets describing a Docker Engine, the credentials secrets of the DockerBuilds and the connector
+// credentials secrets of the docker-exec connector.
+const (
+	// HostKey is the key of the address of the Docker Engine, e.g. tcp://docker.example.com:2376 or
+	// unix:///var/run/docker.sock.
+	HostKey = "host"
+
+	// CACertKey is the optional key of the PEM encoded CA certificates of the Docker Engine.
+	CACertKey = "caCert"
+
+	// ClientCertKey is the optional key of the PEM encoded client certificate authenticating to the Docker Engine.
+	ClientCertKey = "clientCert"
+
+	// ClientKeyKey is the optional key of the PEM encoded private key of the client certificate.
+	ClientKeyKey = "clientKey"
+
+	// ContainerKey is the key of the ID of the container the commands of the docker-exec connector run in.
+	ContainerKey = "container"
+
+	// UsernameKey is the optional key of the user the commands of the docker-exec connector run as.
+	UsernameKey = "username"
+)
+
+// apiVersion is the version of the Docker Engine API used, supported by Docker Engine 20.10 and later.
+const apiVersion = "v1.41"
+
+// Client is a client of the Docker Engine API.
+type Client struct {
+	HTTPClient *http.Client
+	// Endpoint is the base URL of the API, e.g. https://docker.example.com:2376.
+	Endpoint string
+}
+
+// NewClient returns a client of the Docker Engine described by the data of a secret: its host and, for TCP
+// endpoints, its TLS certificates.
+func NewClient(data map[string][]byte) (*Client, error) {
+	host := strings.TrimSpace(string(data[HostKey]))
+	if host == "" {
+		return nil, forgeerrors.ConfigErrorf("no %s", HostKey)
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid %s %q", HostKey, host)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+		return &Client{HTTPClient: &http.Client{Transport: transport}, Endpoint: "http://docker"}, nil
+	case "tcp", "http", "https":
+	default:
+		return nil, forgeerrors.ConfigErrorf("unsupported %s %q, expected a tcp://, http(s):// or unix:// address", HostKey, host)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	secure := u.Scheme == "https"
+	if caCert := data[CACertKey]; len(caCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, forgeerrors.ConfigErrorf("invalid %s", CACertKey)
+		}
+		tlsConfig.RootCAs = pool
+		secure = true
+	}
+	if cert, key := data[ClientCertKey], data[ClientKeyKey]; len(cert) > 0 || len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, forgeerrors.ConfigErrorf("invalid %s or %s: %v", ClientCertKey, ClientKeyKey, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+		secure = true
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &Client{HTTPClient: &http.Client{Transport: transport}, Endpoint: scheme + "://" + u.Host}, nil
+}
+
+// Version is the version of a Docker Engine.
+type Version struct {
+	Version    string `json:"Version"`
+	APIVersion string `json:"ApiVersion"`
+	Os         string `json:"Os"`
+	Arch       string `json:"Arch"`
+}
+
+// Version returns the version of the Docker Engine.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	version := &Version{}
+	return version, c.do(ctx, http.MethodGet, "/version", nil, nil, version)
+}
+
+// ContainerConfig is the configuration of a container, or of an image.
+type ContainerConfig struct {
+	Image      string            `json:"Image,omitempty"`
+	Hostname   string            `json:"Hostname,omitempty"`
+	User       string            `json:"User,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Entrypoint []string          `json:"Entrypoint"`
+	Cmd        []string          `json:"Cmd"`
+	WorkingDir string            `json:"WorkingDir,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
+
+// Image is an image of the Docker Engine.
+type Image struct {
+	ID           string          `json:"Id"`
+	RepoDigests  []string        `json:"RepoDigests"`
+	Architecture string          `json:"Architecture"`
+	Os           string          `json:"Os"`
+	Size         int64           `json:"Size"`
+	Created      string          `json:"Created"`
+	Config       ContainerConfig `json:"Config"`
+}
+
+// Container is a container of the Docker Engine.
+type Container struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	Image string `json:"Image"`
+	State struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		Error    string `json:"Error"`
+	} `json:"State"`
+	Config ContainerConfig `json:"Config"`
+}
+
+// RegistryAuth are the credentials of a registry, the anonymous access is used when empty.
+type RegistryAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+}
+
+// PullImage pulls the image of the reference, e.g. ubuntu:22.04.
+func (c *Client) PullImage(ctx context.Context, ref string, platform string, auth *RegistryAuth) error {
+	query := url.Values{"fromImage": {ref}}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+	_, err := c.stream(ctx, http.MethodPost, "/images/create", query, auth)
+	return err
+}
+
+// InspectImage returns the image of the reference or ID.
+func (c *Client) InspectImage(ctx context.Context, ref string) (*Image, error) {
+	image := &Image{}
+	return image, c.do(ctx, http.MethodGet, "/images/"+ref+"/json", nil, nil, image)
+}
+
+// RemoveImage removes the image of the reference or ID, it is untagged when it has other references.
+func (c *Client) RemoveImage(ctx context.Context, ref string) error {
+	return c.do(ctx, http.MethodDelete, "/images/"+ref, nil, nil, nil)
+}
+
+// CreateContainer creates the container and returns its ID.
+func (c *Client) CreateContainer(ctx context.Context, name, platform string, config *ContainerConfig) (string, error) {
+	query := url.Values{"name": {name}}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+	created := struct {
+		ID string `json:"Id"`
+	}{}
+	return created.ID, c.do(ctx, http.MethodPost, "/containers/create", query, config, &created)
+}
+
+// InspectContainer returns the container of the name or ID.
+func (c *Client) InspectContainer(ctx context.Context, id string) (*Container, error) {
+	container := &Container{}
+	return container, c.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, container)
+}
+
+// StartContainer starts the container, starting a running container succeeds.
+func (c *Client) StartContainer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// StopContainer stops the container, it is killed after the timeout.
+func (c *Client) StopContainer(ctx context.Context, id string, timeout time.Duration) error {
+	query := url.Values{"t": {fmt.Sprint(int(timeout.Seconds()))}}
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/stop", query, nil, nil)
+}
+
+// RemoveContainer removes the container along with its anonymous volumes, it is killed if running.
+func (c *Client) RemoveContainer(ctx context.Context, id string) error {
+	query := url.Values{"force": {"true"}, "v": {"true"}}
+	return c.do(ctx, http.MethodDelete, "/containers/"+id, query, nil, nil)
+}
+
+// CommitContainer creates the image repository:tag from the container and returns its ID. changes are Dockerfile
+// instructions applied to the configuration of the image, e.g. CMD ["/bin/bash"].
+func (c *Client) CommitContainer(ctx context.Context, id, repository, tag, comment string, changes []string) (string, error) {
+	query := url.Values{"container": {id}, "repo": {repository}, "tag": {tag}, "comment": {comment}, "changes": changes}
+	committed := struct {
+		ID string `json:"Id"`
+	}{}
+	return committed.ID, c.do(ctx, http.MethodPost, "/commit", query, nil, &committed)
+}
+
+// PushImage pushes the image repository:tag to its registry and returns the digest of its manifest.
+func (c *Client) PushImage(ctx context.Context, repository, tag string, auth *RegistryAuth) (string, error) {
+	messages, err := c.stream(ctx, http.MethodPost, "/images/"+repository+"/push", url.Values{"tag": {tag}}, auth)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range messages {
+		if m.Aux != nil && m.Aux.Digest != "" {
+			return m.Aux.Digest, nil
+		}
+	}
+	return "", errors.Errorf("the push of %s:%s reported no digest", repository, tag)
+}
+
+// CopyToContainer extracts the tar archive into the directory of the container.
+func (c *Client) CopyToContainer(ctx context.Context, id, dir string, archive io.Reader) error {
+	req, err := c.newRequest(ctx, http.MethodPut, "/containers/"+id+"/archive", url.Values{"path": {dir}}, archive)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+	return c.send(req, nil)
+}
+
+// ExecResult is the outcome of a command run in a container.
+type ExecResult struct {
+	ExitCode int `json:"ExitCode"`
+}
+
+// Exec runs the command in the container as user, the default user of the container if empty, and writes its
+// output to stdout and stderr.
+func (c *Client) Exec(ctx context.Context, id, user string, cmd []string, stdout, stderr io.Writer) (*ExecResult, error) {
+	created := struct {
+		ID string `json:"Id"`
+	}{}
+	config := map[string]interface{}{"Cmd": cmd, "User": user, "AttachStdout": true, "AttachStderr": true}
+	if err := c.do(ctx, http.MethodPost, "/containers/"+id+"/exec", nil, config, &created); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/exec/"+created.ID+"/start", nil, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrap(err, "failed to start exec"))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, errors.Wrap(classifier.Classify(parseError(resp.StatusCode, resp.Body)), "failed to start exec")
+	}
+	if err := demultiplex(resp.Body, stdout, stderr); err != nil {
+		return nil, errors.Wrap(err, "failed to read the output of exec")
+	}
+
+	result := &ExecResult{}
+	return result, c.do(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, result)
+}
+
+// demultiplex copies the multiplexed stdout and stderr streams of an exec to their writers: every frame has a
+// header holding its stream and its size.
+func demultiplex(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+		if w == nil {
+			w = io.Discard
+		}
+		if _, err := io.CopyN(w, r, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return err
+		}
+	}
+}
+
+// streamMessage is a message of the JSON streams of the pulls and the pushes.
+type streamMessage struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Aux    *struct {
+		Tag    string `json:"Tag"`
+		Digest string `json:"Digest"`
+		Size   int64  `json:"Size"`
+	} `json:"aux"`
+}
+
+// stream sends a request answered with a JSON stream, e.g. a pull or a push, and returns its messages. The errors
+// are reported in the stream once it started.
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values, auth *RegistryAuth) ([]streamMessage, error) {
+	req, err := c.newRequest(ctx, method, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		auth = &RegistryAuth{}
+	}
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Registry-Auth", base64.URLEncoding.EncodeToString(data))
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, path))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, errors.Wrapf(classifier.Classify(parseError(resp.StatusCode, resp.Body)), "%s %s", method, path)
+	}
+
+	var messages []streamMessage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		m := streamMessage{}
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			continue
+		}
+		if m.Error != "" {
+			return nil, streamError(m.Error)
+		}
+		messages = append(messages, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", method, path))
+	}
+	return messages, nil
+}
+
+// streamError classifies an error reported in a stream, the registries reject the unauthorized pulls and pushes
+// and the unknown images.
+func streamError(message string) error {
+	err := errors.New(message)
+	lower := strings.ToLower(message)
+	for _, config := range []string{"unauthorized", "denied", "not found", "manifest unknown", "no basic auth credentials"} {
+		if strings.Contains(lower, config) {
+			return forgeerrors.NewConfigError(err)
+		}
+	}
+	if strings.Contains(lower, "toomanyrequests") {
+		return forgeerrors.NewThrottled(err, 0)
+	}
+	return forgeerrors.NewTransient(err)
+}
+
+// do sends a JSON request and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, query, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := c.Endpoint + "/" + apiVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return http.NewRequestWithContext(ctx, method, target, body)
+}
+
+// send sends the request and decodes the response into out, or returns its classified error.
+func (c *Client) send(req *http.Request, out interface{}) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrapf(err, "%s %s", req.Method, req.URL.Path))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		if resp.StatusCode == http.StatusNotModified {
+			// The container is already started or stopped.
+			return nil
+		}
+		return errors.Wrapf(classifier.Classify(parseError(resp.StatusCode, resp.Body)), "%s %s", req.Method, req.URL.Path)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "invalid response to %s %s", req.Method, req.URL.Path)
+	}
+	return nil
+}
+
+// APIError is an error returned by the Docker Engine API.
+type APIError = apierror.Error
+
+// parseError returns the error of a failed response.
+func parseError(statusCode int, body io.Reader) *APIError {
+	return apierror.Parse(statusCode, body, func(data []byte) (string, string) {
+		message := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.Unmarshal(data, &message)
+		return "", message.Message
+	})
+}
+
+// IsNotFound returns true if the error is a missing container, image or exec.
+func IsNotFound(err error) bool {
+	apiErr := &APIError{}
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsConflict returns true if the error is a conflict, e.g. a container with the same name exists.
+func IsConflict(err error) bool {
+	apiErr := &APIError{}
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// classifier annotates the API errors with their forge error category, the callers handle the missing objects and
+// the conflicts.
+var classifier = apierror.Classifier{Handled: apierror.StatusCodes(http.StatusNotFound, http.StatusConflict)}
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// frame returns the output of an exec multiplexed on the stream, 1 for stdout and 2 for stderr.
+func frame(stream byte, data string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func TestConnector(t *testing.T) {
+	g := NewWithT(t)
+
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + strings.TrimPrefix(r.URL.Path, "/"+apiVersion) {
+		case "POST /containers/3f1c/exec":
+			config := map[string]interface{}{}
+			g.Expect(json.NewDecoder(r.Body).Decode(&config)).To(Succeed())
+			g.Expect(config["User"]).To(Equal("root"))
+			g.Expect(config["Cmd"]).To(Equal([]interface{}{"/bin/sh", "-c", "apt-get update"}))
+			_, _ = w.Write([]byte(`{"Id":"exec1"}`))
+		case "POST /exec/exec1/start":
+			_, _ = w.Write(frame(1, "Reading package lists...\n"))
+			_, _ = w.Write(frame(2, "W: no mirror\n"))
+			_, _ = w.Write(frame(1, "Done\n"))
+		case "GET /exec/exec1/json":
+			_, _ = w.Write([]byte(`{"ExitCode":100}`))
+		case "PUT /containers/3f1c/archive":
+			g.Expect(r.URL.Query().Get("path")).To(Equal("/etc/forge"))
+			tr := tar.NewReader(r.Body)
+			header, err := tr.Next()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(header.Name).To(Equal("env"))
+			g.Expect(header.Mode).To(BeEquivalentTo(0o600))
+			uploaded, _ = io.ReadAll(tr)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"page not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	connector, err := NewConnector(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ubuntu-credentials"},
+		Data:       map[string][]byte{HostKey: []byte(server.URL), ContainerKey: []byte("3f1c")},
+	}, "root")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err = connector.Run("apt-get update", stdout, stderr)
+	g.Expect(err).To(MatchError(&ExitError{Status: 100}))
+	g.Expect(stdout.String()).To(Equal("Reading package lists...\nDone\n"))
+	g.Expect(stderr.String()).To(Equal("W: no mirror\n"))
+
+	g.Expect(connector.Upload(strings.NewReader("FOO=bar\n"), "/etc/forge/env", 0o600)).To(Succeed())
+	g.Expect(string(uploaded)).To(Equal("FOO=bar\n"))
+
+	_, err = NewConnector(&corev1.Secret{Data: map[string][]byte{HostKey: []byte(server.URL)}}, "")
+	g.Expect(err).To(MatchError(ContainSubstring("no container")))
+}
+
+func TestPushImage(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/" + apiVersion + "/images/ghcr.io/example/base/push"))
+		g.Expect(r.URL.Query().Get("tag")).To(Equal("v1"))
+		data, err := base64.URLEncoding.DecodeString(r.Header.Get("X-Registry-Auth"))
+		g.Expect(err).ToNot(HaveOccurred())
+		auth := RegistryAuth{}
+		g.Expect(json.Unmarshal(data, &auth)).To(Succeed())
+		if auth.Password != "token" {
+			_, _ = w.Write([]byte(`{"status":"The push refers to repository [ghcr.io/example/base]"}` + "\n"))
+			_, _ = w.Write([]byte(`{"errorDetail":{"message":"denied"},"error":"denied: permission_denied"}` + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"Pushed","id":"a1b2"}` + "\n"))
+		_, _ = w.Write([]byte(`{"status":"v1: digest: sha256:0123 size: 529"}` + "\n"))
+		_, _ = w.Write([]byte(`{"aux":{"Tag":"v1","Digest":"sha256:0123","Size":529}}` + "\n"))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(map[string][]byte{HostKey: []byte(server.URL)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	digest, err := c.PushImage(context.Background(), "ghcr.io/example/base", "v1", &RegistryAuth{Username: "forge", Password: "token"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(digest).To(Equal("sha256:0123"))
+
+	_, err = c.PushImage(context.Background(), "ghcr.io/example/base", "v1", nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(forgeerrors.IsRetryable(err)).To(BeFalse())
+}
+
+func TestErrors(t *testing.T) {
+	testcases := []struct {
+		name      string
+		status    int
+		notFound  bool
+		conflict  bool
+		retryable bool
+	}{
+		{name: "missing container", status: http.StatusNotFound, notFound: true},
+		{name: "container name in use", status: http.StatusConflict, conflict: true},
+		{name: "invalid request", status: http.StatusBadRequest},
+		{name: "daemon error", status: http.StatusInternalServerError, retryable: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"message":"error"}`))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(map[string][]byte{HostKey: []byte(server.URL)})
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = c.InspectContainer(context.Background(), "3f1c")
+			g.Expect(err).To(MatchError(ContainSubstring("%d %s: error", tc.status, http.StatusText(tc.status))))
+			g.Expect(IsNotFound(err)).To(Equal(tc.notFound))
+			g.Expect(IsConflict(err)).To(Equal(tc.conflict))
+			g.Expect(forgeerrors.IsRetryable(err)).To(Equal(tc.retryable))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	g := NewWithT(t)
+
+	c, err := NewClient(map[string][]byte{HostKey: []byte("unix:///var/run/docker.sock")})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Endpoint).To(Equal("http://docker"))
+
+	c, err = NewClient(map[string][]byte{HostKey: []byte("tcp://docker.example.com:2375")})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.Endpoint).To(Equal("http://docker.example.com:2375"))
+
+	_, err = NewClient(map[string][]byte{HostKey: []byte("tcp://docker.example.com:2376"), CACertKey: []byte("not a certificate")})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid caCert")))
+
+	_, err = NewClient(map[string][]byte{HostKey: []byte("ssh://docker.example.com")})
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported host")))
+
+	_, err = NewClient(map[string][]byte{})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drain lets the in-flight reconciles finish when the controller is stopped, e.g. on SIGTERM during a
+// rollout, instead of aborting them half way through, and keeps the reconciles queued afterwards from starting, so
+// the Builds are not driven by two replicas at once: the leader lease is released once the reconciles have drained.
+package drain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultGracePeriod is how long the in-flight reconciles are given to finish, the default graceful shutdown
+// timeout of the controller-runtime managers.
+const DefaultGracePeriod = 30 * time.Second
+
+// DefaultDrainer is the Drainer of the reconcilers of the controller.
+var DefaultDrainer = NewDrainer(DefaultGracePeriod)
+
+// Drainer tracks the in-flight reconciles of the reconcilers it wraps.
+type Drainer struct {
+	gracePeriod time.Duration
+
+	mu       sync.Mutex
+	draining bool
+	inFlight int
+}
+
+var _ manager.Runnable = &Drainer{}
+var _ manager.LeaderElectionRunnable = &Drainer{}
+
+// NewDrainer returns a Drainer giving the in-flight reconciles gracePeriod to finish once the controller is stopped.
+func NewDrainer(gracePeriod time.Duration) *Drainer {
+	return &Drainer{gracePeriod: gracePeriod}
+}
+
+// Reconciler wraps r with the DefaultDrainer.
+func Reconciler(r reconcile.Reconciler) reconcile.Reconciler {
+	return DefaultDrainer.Reconciler(r)
+}
+
+// Reconciler returns a reconciler running the reconciles of r with a context which is only cancelled once the grace
+// period has elapsed after the controller has been stopped. The reconciles dequeued after the controller has been
+// stopped do not run, the requests are reconciled by the next leader.
+func (d *Drainer) Reconciler(r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		if ctx.Err() != nil {
+			return reconcile.Result{}, nil
+		}
+		d.mu.Lock()
+		d.inFlight++
+		d.mu.Unlock()
+		defer func() {
+			d.mu.Lock()
+			d.inFlight--
+			d.mu.Unlock()
+		}()
+
+		ctx, cancel := d.detach(ctx)
+		defer cancel()
+		return r.Reconcile(ctx, req)
+	})
+}
+
+// detach returns a context holding the values of ctx, cancelled once the grace period has elapsed after ctx is done.
+func (d *Drainer) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(d.gracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-detached.Done():
+		}
+	})
+	return detached, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Start marks the Drainer draining once ctx is done, which happens when the manager is stopped.
+func (d *Drainer) Start(ctx context.Context) error {
+	<-ctx.Done()
+	d.mu.Lock()
+	d.draining = true
+	inFlight := d.inFlight
+	d.mu.Unlock()
+	ctrl.LoggerFrom(ctx).Info("Draining the in-flight reconciles", "reconciles", inFlight, "gracePeriod", d.gracePeriod)
+	return nil
+}
+
+// NeedLeaderElection returns false, the replicas which are not leading are drained too.
+func (d *Drainer) NeedLeaderElection() bool {
+	return false
+}
+
+// Checker is a readiness check failing once the Drainer is draining, so the replica stops receiving the webhook
+// requests while it shuts down.
+func (d *Drainer) Checker(_ *http.Request) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return errors.Errorf("draining %d in-flight reconciles", d.inFlight)
+	}
+	return nil
+}
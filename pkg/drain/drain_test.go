@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconciler(t *testing.T) {
+	g := NewWithT(t)
+
+	d := NewDrainer(time.Hour)
+	started, release := make(chan struct{}), make(chan struct{})
+	r := d.Reconciler(reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+		close(started)
+		<-release
+		// The context of the in-flight reconcile is not cancelled with the controller.
+		return reconcile.Result{}, ctx.Err()
+	}))
+
+	ctx, stop := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- d.Start(ctx) }()
+	done := make(chan error)
+	go func() {
+		_, err := r.Reconcile(ctx, reconcile.Request{})
+		done <- err
+	}()
+	<-started
+	g.Expect(d.Checker(nil)).To(Succeed())
+
+	stop()
+	g.Expect(<-stopped).To(Succeed())
+	g.Expect(d.Checker(nil)).To(MatchError("draining 1 in-flight reconciles"))
+	close(release)
+	g.Expect(<-done).To(Succeed())
+	g.Expect(d.Checker(nil)).To(MatchError("draining 0 in-flight reconciles"))
+
+	// The reconciles dequeued once the controller has been stopped do not run.
+	_, err := r.Reconcile(ctx, reconcile.Request{})
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestReconcilerGracePeriod(t *testing.T) {
+	g := NewWithT(t)
+
+	d := NewDrainer(10 * time.Millisecond)
+	started := make(chan struct{})
+	r := d.Reconciler(reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+		close(started)
+		<-ctx.Done()
+		return reconcile.Result{}, ctx.Err()
+	}))
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := r.Reconcile(ctx, reconcile.Request{})
+		done <- err
+	}()
+	<-started
+	stop()
+	// The in-flight reconcile is cancelled once the grace period has elapsed.
+	g.Eventually(done).Should(Receive(MatchError(context.Canceled)))
+}
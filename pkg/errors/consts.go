@@ -27,4 +27,40 @@ const (
 
 	// ProvisionerFailedError indicates that the provisioner failed.
 	ProvisionerFailedError BuildStatusError = "ProvisionerFailed"
+
+	// InvalidCredentialsBuildError indicates that the credentials used by
+	// the infrastructure provider have been rejected.
+	InvalidCredentialsBuildError BuildStatusError = "InvalidCredentials"
+
+	// DeadlineExceededBuildError indicates that the Build
+	// exceeded one of its timeouts.
+	DeadlineExceededBuildError BuildStatusError = "DeadlineExceeded"
+
+	// ExportFailedBuildError indicates that the image could not be
+	// packaged or published by one of the exports of the Build.
+	ExportFailedBuildError BuildStatusError = "ExportFailed"
+
+	// HostKeyMismatchError indicates that the host key presented by the
+	// infrastructure machine is not the expected one.
+	HostKeyMismatchError BuildStatusError = "HostKeyMismatch"
+
+	// ArchitectureBuildFailedError indicates that the Build of one of
+	// the architectures of a multi-architecture Build failed.
+	ArchitectureBuildFailedError BuildStatusError = "ArchitectureBuildFailed"
+
+	// PreemptedBuildError indicates that the spot or preemptible machine
+	// of the Build has been reclaimed by the cloud provider.
+	PreemptedBuildError BuildStatusError = "Preempted"
+
+	// SBOMFailedBuildError indicates that the software bill of materials
+	// of the machine could not be generated or stored.
+	SBOMFailedBuildError BuildStatusError = "SBOMFailed"
+
+	// ScanFailedBuildError indicates that the machine could not be
+	// scanned for vulnerabilities.
+	ScanFailedBuildError BuildStatusError = "ScanFailed"
+
+	// VulnerabilitiesFoundBuildError indicates that the vulnerabilities
+	// found on the machine exceed the thresholds of the scan of the Build.
+	VulnerabilitiesFoundBuildError BuildStatusError = "VulnerabilitiesFound"
 )
@@ -0,0 +1,197 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Category classifies an error so controllers can decide whether to retry or fail a Build.
+type Category string
+
+const (
+	// CategoryUnknown is returned for errors which carry no category and can't be classified,
+	// controllers should fall back to the default controller-runtime error handling.
+	CategoryUnknown Category = ""
+
+	// CategoryTerminal indicates that retrying will not help and the Build should be marked as failed.
+	CategoryTerminal Category = "Terminal"
+
+	// CategoryTransient indicates a temporary failure which is expected to resolve by itself.
+	CategoryTransient Category = "Transient"
+
+	// CategoryThrottled indicates that a remote API rejected the request because of rate limiting or quota.
+	CategoryThrottled Category = "Throttled"
+
+	// CategoryConfigError indicates that the Build or its referenced objects are misconfigured.
+	CategoryConfigError Category = "ConfigError"
+)
+
+const (
+	// DefaultTransientRequeueAfter is the delay before retrying a transient error.
+	DefaultTransientRequeueAfter = 5 * time.Second
+
+	// DefaultThrottledRequeueAfter is the delay before retrying a throttled error without retry hint.
+	DefaultThrottledRequeueAfter = 30 * time.Second
+)
+
+// Error is an error annotated with a Category, and the BuildStatusError to report
+// when it fails a Build.
+type Error struct {
+	// Category of the error.
+	Category Category
+	// Reason is reported as Build FailureReason when the error is not retryable.
+	Reason BuildStatusError
+	// RequeueAfter is the delay before retrying, only meaningful for retryable errors.
+	RequeueAfter time.Duration
+	// Err is the wrapped error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return string(e.Category)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewTerminal returns an error which fails the Build with the given reason.
+func NewTerminal(reason BuildStatusError, err error) error {
+	return &Error{Category: CategoryTerminal, Reason: reason, Err: err}
+}
+
+// NewTransient returns an error which is retried after DefaultTransientRequeueAfter.
+func NewTransient(err error) error {
+	return &Error{Category: CategoryTransient, RequeueAfter: DefaultTransientRequeueAfter, Err: err}
+}
+
+// NewTransientAfter returns an error which is retried after the given delay.
+func NewTransientAfter(err error, after time.Duration) error {
+	return &Error{Category: CategoryTransient, RequeueAfter: after, Err: err}
+}
+
+// NewThrottled returns an error which is retried after the given delay,
+// DefaultThrottledRequeueAfter is used if after is zero.
+func NewThrottled(err error, after time.Duration) error {
+	if after <= 0 {
+		after = DefaultThrottledRequeueAfter
+	}
+	return &Error{Category: CategoryThrottled, RequeueAfter: after, Err: err}
+}
+
+// NewConfigError returns an error which fails the Build with InvalidConfigurationBuildError.
+func NewConfigError(err error) error {
+	return &Error{Category: CategoryConfigError, Reason: InvalidConfigurationBuildError, Err: err}
+}
+
+// Terminalf formats a terminal error with the given reason.
+func Terminalf(reason BuildStatusError, format string, args ...interface{}) error {
+	return NewTerminal(reason, fmt.Errorf(format, args...))
+}
+
+// ConfigErrorf formats a configuration error.
+func ConfigErrorf(format string, args ...interface{}) error {
+	return NewConfigError(fmt.Errorf(format, args...))
+}
+
+// Classify returns the Category of err. Errors created by this package report their own category,
+// Kubernetes API and network errors are classified by their type, anything else is CategoryUnknown.
+func Classify(err error) Category {
+	if err == nil {
+		return CategoryUnknown
+	}
+
+	var forgeErr *Error
+	if errors.As(err, &forgeErr) {
+		return forgeErr.Category
+	}
+
+	switch {
+	case apierrors.IsTooManyRequests(err):
+		return CategoryThrottled
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return CategoryConfigError
+	case apierrors.IsConflict(err),
+		apierrors.IsNotFound(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsInternalError(err):
+		return CategoryTransient
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CategoryTransient
+	}
+
+	return CategoryUnknown
+}
+
+// IsRetryable returns true if err is transient or throttled.
+func IsRetryable(err error) bool {
+	switch Classify(err) {
+	case CategoryTransient, CategoryThrottled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal returns true if err should fail the Build, i.e. it is terminal or a configuration error.
+func IsTerminal(err error) bool {
+	switch Classify(err) {
+	case CategoryTerminal, CategoryConfigError:
+		return true
+	}
+	return false
+}
+
+// RequeueAfter returns the delay before retrying err, or zero if err is not retryable.
+func RequeueAfter(err error) time.Duration {
+	var forgeErr *Error
+	if errors.As(err, &forgeErr) {
+		return forgeErr.RequeueAfter
+	}
+
+	switch Classify(err) {
+	case CategoryThrottled:
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return DefaultThrottledRequeueAfter
+	case CategoryTransient:
+		return DefaultTransientRequeueAfter
+	}
+	return 0
+}
+
+// ReasonFor returns the BuildStatusError to report for err when it fails a Build.
+func ReasonFor(err error) BuildStatusError {
+	var forgeErr *Error
+	if errors.As(err, &forgeErr) && forgeErr.Reason != "" {
+		return forgeErr.Reason
+	}
+	if Classify(err) == CategoryConfigError {
+		return InvalidConfigurationBuildError
+	}
+	return UpdateBuildError
+}
+
+// FromFailure returns the terminal error reported by an external object, e.g. an InfraBuild,
+// through its status.failureReason and status.failureMessage fields.
+func FromFailure(reason, message string) error {
+	return &Error{Category: CategoryTerminal, Reason: BuildStatusError(reason), Err: errors.New(message)}
+}
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	pkgerrors "github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	gr := schema.GroupResource{Group: "forge.build", Resource: "builds"}
+
+	testcases := []struct {
+		name     string
+		err      error
+		expected Category
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: CategoryUnknown,
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			expected: CategoryUnknown,
+		},
+		{
+			name:     "terminal error",
+			err:      NewTerminal(ProvisionerFailedError, errors.New("boom")),
+			expected: CategoryTerminal,
+		},
+		{
+			name:     "wrapped transient error",
+			err:      pkgerrors.Wrap(NewTransient(errors.New("boom")), "failed to connect"),
+			expected: CategoryTransient,
+		},
+		{
+			name:     "fmt wrapped config error",
+			err:      fmt.Errorf("invalid build: %w", ConfigErrorf("missing %s", "connector")),
+			expected: CategoryConfigError,
+		},
+		{
+			name:     "throttled error",
+			err:      NewThrottled(errors.New("quota exceeded"), 0),
+			expected: CategoryThrottled,
+		},
+		{
+			name:     "api too many requests",
+			err:      apierrors.NewTooManyRequests("slow down", 10),
+			expected: CategoryThrottled,
+		},
+		{
+			name:     "api not found",
+			err:      pkgerrors.Wrap(apierrors.NewNotFound(gr, "foo"), "failed to get"),
+			expected: CategoryTransient,
+		},
+		{
+			name:     "api conflict",
+			err:      apierrors.NewConflict(gr, "foo", errors.New("modified")),
+			expected: CategoryTransient,
+		},
+		{
+			name:     "api bad request",
+			err:      apierrors.NewBadRequest("bad"),
+			expected: CategoryConfigError,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      pkgerrors.Wrap(context.DeadlineExceeded, "waiting"),
+			expected: CategoryTransient,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(Classify(tc.err)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestRequeueAfter(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(RequeueAfter(NewTransient(errors.New("boom")))).To(Equal(DefaultTransientRequeueAfter))
+	g.Expect(RequeueAfter(NewTransientAfter(errors.New("boom"), time.Minute))).To(Equal(time.Minute))
+	g.Expect(RequeueAfter(NewThrottled(errors.New("boom"), 0))).To(Equal(DefaultThrottledRequeueAfter))
+	g.Expect(RequeueAfter(apierrors.NewTooManyRequests("slow down", 10))).To(Equal(10 * time.Second))
+	g.Expect(RequeueAfter(NewTerminal(ProvisionerFailedError, errors.New("boom")))).To(BeZero())
+	g.Expect(RequeueAfter(errors.New("boom"))).To(BeZero())
+}
+
+func TestReasonFor(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ReasonFor(NewTerminal(ProvisionerFailedError, errors.New("boom")))).To(Equal(ProvisionerFailedError))
+	g.Expect(ReasonFor(ConfigErrorf("bad"))).To(Equal(InvalidConfigurationBuildError))
+	g.Expect(ReasonFor(apierrors.NewBadRequest("bad"))).To(Equal(InvalidConfigurationBuildError))
+	g.Expect(ReasonFor(FromFailure("CreateError", "instance failed"))).To(Equal(CreateBuildError))
+	g.Expect(ReasonFor(errors.New("boom"))).To(Equal(UpdateBuildError))
+}
+
+func TestIsRetryableAndTerminal(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(IsRetryable(NewTransient(errors.New("boom")))).To(BeTrue())
+	g.Expect(IsRetryable(NewThrottled(errors.New("boom"), time.Second))).To(BeTrue())
+	g.Expect(IsRetryable(NewConfigError(errors.New("boom")))).To(BeFalse())
+	g.Expect(IsTerminal(NewConfigError(errors.New("boom")))).To(BeTrue())
+	g.Expect(IsTerminal(FromFailure("CreateError", "boom"))).To(BeTrue())
+	g.Expect(IsTerminal(errors.New("boom"))).To(BeFalse())
+}
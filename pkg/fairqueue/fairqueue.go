@@ -0,0 +1,271 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fairqueue provides a controller workqueue which shares the workers of a controller fairly between
+// tenants, so a tenant queueing many objects, e.g. a bulk bake of hundreds of Builds in a namespace,
+// does not delay the reconciles of the objects of the other tenants.
+package fairqueue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/forge-build/forge/pkg/metrics"
+)
+
+// KeyFunc returns the tenant of a queued item, the items of every tenant are processed in turn.
+type KeyFunc func(item interface{}) string
+
+// ByNamespace returns the namespace of the reconcile requests.
+func ByNamespace(item interface{}) string {
+	if req, ok := item.(reconcile.Request); ok {
+		return req.Namespace
+	}
+	return ""
+}
+
+// NewRateLimitingQueue returns a rate limiting queue processing the reconcile requests of every namespace in turn,
+// it can be used as the NewQueue option of a controller. The queue reports the controller-runtime workqueue
+// metrics but the unfinished work and longest running processor ones.
+func NewRateLimitingQueue(controllerName string, rateLimiter ratelimiter.RateLimiter) workqueue.RateLimitingInterface {
+	queue := New(ByNamespace, Config{Name: controllerName, MetricsProvider: metrics.WorkqueueMetricsProvider{}})
+	return workqueue.NewRateLimitingQueueWithConfig(rateLimiter, workqueue.RateLimitingQueueConfig{
+		Name: controllerName,
+		DelayingQueue: workqueue.NewDelayingQueueWithConfig(workqueue.DelayingQueueConfig{
+			Name:  controllerName,
+			Queue: queue,
+		}),
+	})
+}
+
+// Config configures a Queue.
+type Config struct {
+	// Name of the queue, the metrics are reported if set.
+	Name string
+
+	// MetricsProvider provides the metrics of the queue.
+	MetricsProvider workqueue.MetricsProvider
+
+	// Clock is used to measure the latencies, defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+// Queue is a workqueue.Interface processing the items of every tenant in turn, the items of a tenant
+// are processed in the order they have been added. Like the client-go workqueue, an item is never
+// processed concurrently and an item added multiple times before being processed is processed once.
+type Queue struct {
+	cond    *sync.Cond
+	keyFunc KeyFunc
+	clock   clock.PassiveClock
+
+	// queues holds the queued items of every tenant, tenants lists the tenants with queued items
+	// in turn order, next being the index of the tenant whose item is processed next.
+	queues  map[string][]interface{}
+	tenants []string
+	next    int
+	length  int
+
+	dirty        map[interface{}]struct{}
+	processing   map[interface{}]struct{}
+	shuttingDown bool
+	drain        bool
+
+	addTimes        map[interface{}]time.Time
+	processingTimes map[interface{}]time.Time
+	depth           workqueue.GaugeMetric
+	adds            workqueue.CounterMetric
+	latency         workqueue.HistogramMetric
+	workDuration    workqueue.HistogramMetric
+}
+
+var _ workqueue.Interface = &Queue{}
+
+// New returns a Queue grouping the items by the tenant returned by keyFunc.
+func New(keyFunc KeyFunc, config Config) *Queue {
+	q := &Queue{
+		cond:            sync.NewCond(&sync.Mutex{}),
+		keyFunc:         keyFunc,
+		clock:           config.Clock,
+		queues:          map[string][]interface{}{},
+		dirty:           map[interface{}]struct{}{},
+		processing:      map[interface{}]struct{}{},
+		addTimes:        map[interface{}]time.Time{},
+		processingTimes: map[interface{}]time.Time{},
+		depth:           noopMetric{},
+		adds:            noopMetric{},
+		latency:         noopMetric{},
+		workDuration:    noopMetric{},
+	}
+	if q.clock == nil {
+		q.clock = clock.RealClock{}
+	}
+	if config.Name != "" && config.MetricsProvider != nil {
+		q.depth = config.MetricsProvider.NewDepthMetric(config.Name)
+		q.adds = config.MetricsProvider.NewAddsMetric(config.Name)
+		q.latency = config.MetricsProvider.NewLatencyMetric(config.Name)
+		q.workDuration = config.MetricsProvider.NewWorkDurationMetric(config.Name)
+	}
+	return q
+}
+
+// Add marks item as needing processing.
+func (q *Queue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	q.adds.Inc()
+	q.dirty[item] = struct{}{}
+	if _, ok := q.processing[item]; ok {
+		// The item is queued again once processed.
+		return
+	}
+	q.push(item)
+	q.cond.Signal()
+}
+
+// Len returns the number of queued items.
+func (q *Queue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.length
+}
+
+// Get blocks until it can return an item to be processed, the item of the tenant whose turn it is.
+// If shutdown is true, the caller should end its goroutine. Done must be called with the item once processed.
+func (q *Queue) Get() (interface{}, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for q.length == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.length == 0 {
+		return nil, true
+	}
+
+	item := q.pop()
+	now := q.clock.Now()
+	q.latency.Observe(now.Sub(q.addTimes[item]).Seconds())
+	delete(q.addTimes, item)
+	q.processingTimes[item] = now
+	q.processing[item] = struct{}{}
+	delete(q.dirty, item)
+	return item, false
+}
+
+// Done marks item as done processing, it is queued again if it has been added while being processed.
+func (q *Queue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if start, ok := q.processingTimes[item]; ok {
+		q.workDuration.Observe(q.clock.Since(start).Seconds())
+		delete(q.processingTimes, item)
+	}
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+		q.cond.Signal()
+	} else if len(q.processing) == 0 {
+		q.cond.Signal()
+	}
+}
+
+// ShutDown makes the queue ignore the added items and Get return once the queued items have been processed.
+func (q *Queue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = false
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts the queue down like ShutDown, then blocks until the items being processed are done.
+func (q *Queue) ShutDownWithDrain() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = true
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.processing) != 0 && q.drain {
+		q.cond.Wait()
+	}
+}
+
+// ShuttingDown returns whether the queue is shutting down.
+func (q *Queue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.shuttingDown
+}
+
+// push queues item at the end of the queue of its tenant, the tenant takes its turn after the other tenants
+// if it had no queued items.
+func (q *Queue) push(item interface{}) {
+	tenant := q.keyFunc(item)
+	if len(q.queues[tenant]) == 0 {
+		// Insert the tenant right before the tenant whose turn it is, so it waits for the others.
+		q.tenants = append(q.tenants, "")
+		copy(q.tenants[q.next+1:], q.tenants[q.next:])
+		q.tenants[q.next] = tenant
+		q.next++
+	}
+	q.queues[tenant] = append(q.queues[tenant], item)
+	if _, ok := q.addTimes[item]; !ok {
+		q.addTimes[item] = q.clock.Now()
+	}
+	q.length++
+	q.depth.Inc()
+}
+
+// pop returns the first item of the tenant whose turn it is, then passes the turn to the next tenant.
+func (q *Queue) pop() interface{} {
+	if q.next >= len(q.tenants) {
+		q.next = 0
+	}
+	tenant := q.tenants[q.next]
+	item := q.queues[tenant][0]
+	q.queues[tenant][0] = nil
+	q.queues[tenant] = q.queues[tenant][1:]
+	if len(q.queues[tenant]) == 0 {
+		delete(q.queues, tenant)
+		q.tenants = append(q.tenants[:q.next], q.tenants[q.next+1:]...)
+	} else {
+		q.next++
+	}
+	q.length--
+	q.depth.Dec()
+	return item
+}
+
+type noopMetric struct{}
+
+func (noopMetric) Inc()            {}
+func (noopMetric) Dec()            {}
+func (noopMetric) Observe(float64) {}
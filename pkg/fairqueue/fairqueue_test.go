@@ -0,0 +1,142 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairqueue
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func request(namespace, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+}
+
+func getAll(q *Queue) []interface{} {
+	items := []interface{}{}
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		q.Done(item)
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestQueueFairness(t *testing.T) {
+	testcases := []struct {
+		name     string
+		added    []reconcile.Request
+		expected []reconcile.Request
+	}{
+		{
+			name:     "single namespace",
+			added:    []reconcile.Request{request("bulk", "1"), request("bulk", "2"), request("bulk", "3")},
+			expected: []reconcile.Request{request("bulk", "1"), request("bulk", "2"), request("bulk", "3")},
+		},
+		{
+			name: "namespaces processed in turn",
+			added: []reconcile.Request{
+				request("bulk", "1"), request("bulk", "2"), request("bulk", "3"),
+				request("team-a", "1"), request("team-b", "1"), request("team-a", "2"),
+			},
+			expected: []reconcile.Request{
+				request("bulk", "1"), request("team-a", "1"), request("team-b", "1"),
+				request("bulk", "2"), request("team-a", "2"), request("bulk", "3"),
+			},
+		},
+		{
+			name:     "duplicates processed once",
+			added:    []reconcile.Request{request("bulk", "1"), request("team-a", "1"), request("bulk", "1")},
+			expected: []reconcile.Request{request("bulk", "1"), request("team-a", "1")},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			q := New(ByNamespace, Config{})
+			for _, req := range tc.added {
+				q.Add(req)
+			}
+			expected := []interface{}{}
+			for _, req := range tc.expected {
+				expected = append(expected, req)
+			}
+			g.Expect(getAll(q)).To(Equal(expected))
+		})
+	}
+}
+
+func TestQueueNewNamespaceWaitsForItsTurn(t *testing.T) {
+	g := NewWithT(t)
+
+	q := New(ByNamespace, Config{})
+	q.Add(request("bulk", "1"))
+	q.Add(request("bulk", "2"))
+	q.Add(request("team-a", "1"))
+
+	item, _ := q.Get()
+	q.Done(item)
+	g.Expect(item).To(Equal(request("bulk", "1")))
+
+	// team-b is queued after team-a, whose turn it is.
+	q.Add(request("team-b", "1"))
+	g.Expect(getAll(q)).To(Equal([]interface{}{request("team-a", "1"), request("bulk", "2"), request("team-b", "1")}))
+}
+
+func TestQueueAddWhileProcessing(t *testing.T) {
+	g := NewWithT(t)
+
+	q := New(ByNamespace, Config{})
+	q.Add(request("bulk", "1"))
+	item, _ := q.Get()
+
+	// The item is not processed concurrently, it is queued again once done.
+	q.Add(request("bulk", "1"))
+	g.Expect(q.Len()).To(Equal(0))
+	q.Done(item)
+	g.Expect(q.Len()).To(Equal(1))
+	g.Expect(getAll(q)).To(Equal([]interface{}{request("bulk", "1")}))
+}
+
+func TestQueueShutDown(t *testing.T) {
+	g := NewWithT(t)
+
+	q := New(ByNamespace, Config{})
+	q.Add(request("bulk", "1"))
+	item, _ := q.Get()
+
+	done := make(chan struct{})
+	go func() {
+		q.ShutDownWithDrain()
+		close(done)
+	}()
+	g.Eventually(q.ShuttingDown).Should(BeTrue())
+	g.Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+
+	q.Add(request("bulk", "2"))
+	g.Expect(q.Len()).To(Equal(0))
+	q.Done(item)
+	g.Eventually(done).Should(BeClosed())
+
+	_, shutdown := q.Get()
+	g.Expect(shutdown).To(BeTrue())
+}
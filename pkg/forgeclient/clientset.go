@@ -0,0 +1,203 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package forgeclient provides typed clients, listers and informers of the Forge APIs, built on controller-runtime,
+// so Go tools and operators can integrate with Forge without handling unstructured objects.
+//
+// A Clientset reads and writes the Forge objects from the API server:
+//
+//	clientset, err := forgeclient.New(cfg)
+//	build, err := clientset.Builds("images").Get(ctx, "ubuntu-2204")
+//
+// An InformerFactory watches the Forge objects and serves them from a local cache:
+//
+//	factory, err := forgeclient.NewInformerFactory(cfg, cache.Options{})
+//	builds := factory.Builds()
+//	err = builds.AddEventHandler(ctx, handler)
+//	go factory.Start(ctx)
+//	factory.WaitForCacheSync(ctx)
+//	list, err := builds.Lister("images").List(ctx)
+package forgeclient
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// NewScheme returns a scheme with the Forge API types and the Kubernetes built-in types registered.
+func NewScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(buildv1.AddToScheme(scheme))
+	return scheme
+}
+
+// resource describes how to allocate the objects and the lists of a Forge kind.
+type resource[T client.Object, L client.ObjectList] struct {
+	newObject func() T
+	newList   func() L
+}
+
+var (
+	builds = resource[*buildv1.Build, *buildv1.BuildList]{
+		newObject: func() *buildv1.Build { return &buildv1.Build{} },
+		newList:   func() *buildv1.BuildList { return &buildv1.BuildList{} },
+	}
+	buildTemplates = resource[*buildv1.BuildTemplate, *buildv1.BuildTemplateList]{
+		newObject: func() *buildv1.BuildTemplate { return &buildv1.BuildTemplate{} },
+		newList:   func() *buildv1.BuildTemplateList { return &buildv1.BuildTemplateList{} },
+	}
+	buildSets = resource[*buildv1.BuildSet, *buildv1.BuildSetList]{
+		newObject: func() *buildv1.BuildSet { return &buildv1.BuildSet{} },
+		newList:   func() *buildv1.BuildSetList { return &buildv1.BuildSetList{} },
+	}
+	scheduledBuilds = resource[*buildv1.ScheduledBuild, *buildv1.ScheduledBuildList]{
+		newObject: func() *buildv1.ScheduledBuild { return &buildv1.ScheduledBuild{} },
+		newList:   func() *buildv1.ScheduledBuildList { return &buildv1.ScheduledBuildList{} },
+	}
+	providerIdentities = resource[*buildv1.ProviderIdentity, *buildv1.ProviderIdentityList]{
+		newObject: func() *buildv1.ProviderIdentity { return &buildv1.ProviderIdentity{} },
+		newList:   func() *buildv1.ProviderIdentityList { return &buildv1.ProviderIdentityList{} },
+	}
+)
+
+// Clientset gives typed access to the Forge objects.
+type Clientset struct {
+	client client.WithWatch
+}
+
+// New returns a Clientset for the API server of the given config.
+func New(cfg *rest.Config) (*Clientset, error) {
+	c, err := client.NewWithWatch(cfg, client.Options{Scheme: NewScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	return NewForClient(c), nil
+}
+
+// NewForClient returns a Clientset using the given client, whose scheme must have the Forge API types registered,
+// e.g. a fake client in tests.
+func NewForClient(c client.WithWatch) *Clientset {
+	return &Clientset{client: c}
+}
+
+// Client returns the underlying controller-runtime client.
+func (c *Clientset) Client() client.WithWatch {
+	return c.client
+}
+
+// Builds returns a client of the Builds of the namespace.
+func (c *Clientset) Builds(namespace string) *ResourceClient[*buildv1.Build, *buildv1.BuildList] {
+	return newResourceClient(c.client, namespace, builds)
+}
+
+// BuildTemplates returns a client of the BuildTemplates of the namespace.
+func (c *Clientset) BuildTemplates(namespace string) *ResourceClient[*buildv1.BuildTemplate, *buildv1.BuildTemplateList] {
+	return newResourceClient(c.client, namespace, buildTemplates)
+}
+
+// BuildSets returns a client of the BuildSets of the namespace.
+func (c *Clientset) BuildSets(namespace string) *ResourceClient[*buildv1.BuildSet, *buildv1.BuildSetList] {
+	return newResourceClient(c.client, namespace, buildSets)
+}
+
+// ScheduledBuilds returns a client of the ScheduledBuilds of the namespace.
+func (c *Clientset) ScheduledBuilds(namespace string) *ResourceClient[*buildv1.ScheduledBuild, *buildv1.ScheduledBuildList] {
+	return newResourceClient(c.client, namespace, scheduledBuilds)
+}
+
+// ProviderIdentities returns a client of the ProviderIdentities of the namespace.
+func (c *Clientset) ProviderIdentities(namespace string) *ResourceClient[*buildv1.ProviderIdentity, *buildv1.ProviderIdentityList] {
+	return newResourceClient(c.client, namespace, providerIdentities)
+}
+
+// ResourceClient reads and writes the objects of a Forge kind in a namespace, an empty namespace
+// lists and watches the objects of all the namespaces.
+type ResourceClient[T client.Object, L client.ObjectList] struct {
+	client    client.WithWatch
+	namespace string
+	resource  resource[T, L]
+}
+
+func newResourceClient[T client.Object, L client.ObjectList](c client.WithWatch, namespace string, r resource[T, L]) *ResourceClient[T, L] {
+	return &ResourceClient[T, L]{client: c, namespace: namespace, resource: r}
+}
+
+// Get returns the object with the given name.
+func (c *ResourceClient[T, L]) Get(ctx context.Context, name string) (T, error) {
+	obj := c.resource.newObject()
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: name}, obj); err != nil {
+		var zero T
+		return zero, err
+	}
+	return obj, nil
+}
+
+// List returns the objects matching the options.
+func (c *ResourceClient[T, L]) List(ctx context.Context, opts ...client.ListOption) (L, error) {
+	list := c.resource.newList()
+	if err := c.client.List(ctx, list, append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...); err != nil {
+		var zero L
+		return zero, err
+	}
+	return list, nil
+}
+
+// Watch watches the objects matching the options.
+func (c *ResourceClient[T, L]) Watch(ctx context.Context, opts ...client.ListOption) (watch.Interface, error) {
+	return c.client.Watch(ctx, c.resource.newList(), append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...)
+}
+
+// Create creates the object in the namespace of the client, obj is updated with the response of the API server.
+func (c *ResourceClient[T, L]) Create(ctx context.Context, obj T, opts ...client.CreateOption) error {
+	obj.SetNamespace(c.namespace)
+	return c.client.Create(ctx, obj, opts...)
+}
+
+// Update updates the object, obj is updated with the response of the API server.
+func (c *ResourceClient[T, L]) Update(ctx context.Context, obj T, opts ...client.UpdateOption) error {
+	obj.SetNamespace(c.namespace)
+	return c.client.Update(ctx, obj, opts...)
+}
+
+// UpdateStatus updates the status of the object, obj is updated with the response of the API server.
+func (c *ResourceClient[T, L]) UpdateStatus(ctx context.Context, obj T, opts ...client.SubResourceUpdateOption) error {
+	obj.SetNamespace(c.namespace)
+	return c.client.Status().Update(ctx, obj, opts...)
+}
+
+// Patch patches the object, obj is updated with the response of the API server.
+func (c *ResourceClient[T, L]) Patch(ctx context.Context, obj T, patch client.Patch, opts ...client.PatchOption) error {
+	obj.SetNamespace(c.namespace)
+	return c.client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete deletes the object with the given name.
+func (c *ResourceClient[T, L]) Delete(ctx context.Context, name string, opts ...client.DeleteOption) error {
+	obj := c.resource.newObject()
+	obj.SetNamespace(c.namespace)
+	obj.SetName(name)
+	return c.client.Delete(ctx, obj, opts...)
+}
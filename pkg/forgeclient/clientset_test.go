@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forgeclient
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestClientset(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithScheme(NewScheme()).WithStatusSubresource(&buildv1.Build{}).Build()
+	clientset := NewForClient(c)
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Labels: map[string]string{"os": "ubuntu"}}}
+	g.Expect(clientset.Builds("images").Create(ctx, build)).To(Succeed())
+	g.Expect(build.Namespace).To(Equal("images"))
+	g.Expect(clientset.Builds("other").Create(ctx, &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Name: "centos"}})).To(Succeed())
+
+	got, err := clientset.Builds("images").Get(ctx, "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	got.Status.Phase = string(buildv1.BuildPhaseBuilding)
+	g.Expect(clientset.Builds("images").UpdateStatus(ctx, got)).To(Succeed())
+
+	list, err := clientset.Builds("images").List(ctx, client.MatchingLabels{"os": "ubuntu"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(list.Items).To(HaveLen(1))
+	g.Expect(list.Items[0].Status.Phase).To(Equal(string(buildv1.BuildPhaseBuilding)))
+
+	all, err := clientset.Builds("").List(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(all.Items).To(HaveLen(2))
+
+	lister := newLister(c, "other", builds)
+	listed, err := lister.List(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(listed.Items).To(HaveLen(1))
+	g.Expect(listed.Items[0].Name).To(Equal("centos"))
+
+	g.Expect(clientset.Builds("images").Delete(ctx, "ubuntu")).To(Succeed())
+	_, err = clientset.Builds("images").Get(ctx, "ubuntu")
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forgeclient
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// InformerFactory creates the informers of the Forge kinds, they share a single cache.
+type InformerFactory struct {
+	cache cache.Cache
+}
+
+// NewInformerFactory returns an InformerFactory watching the API server of the given config, the options
+// can restrict the cached namespaces and objects. The Forge API types are registered when opts has no scheme.
+func NewInformerFactory(cfg *rest.Config, opts cache.Options) (*InformerFactory, error) {
+	if opts.Scheme == nil {
+		opts.Scheme = NewScheme()
+	}
+	c, err := cache.New(cfg, opts)
+	if err != nil {
+		return nil, fmt.Errorf("creating cache: %w", err)
+	}
+	return &InformerFactory{cache: c}, nil
+}
+
+// Start runs the informers until the context is done.
+func (f *InformerFactory) Start(ctx context.Context) error {
+	return f.cache.Start(ctx)
+}
+
+// WaitForCacheSync waits for the informers to be synced, it returns false if the context is done first.
+func (f *InformerFactory) WaitForCacheSync(ctx context.Context) bool {
+	return f.cache.WaitForCacheSync(ctx)
+}
+
+// Cache returns the underlying controller-runtime cache.
+func (f *InformerFactory) Cache() cache.Cache {
+	return f.cache
+}
+
+// Builds returns the informer of the Builds.
+func (f *InformerFactory) Builds() *Informer[*buildv1.Build, *buildv1.BuildList] {
+	return newInformer(f.cache, builds)
+}
+
+// BuildTemplates returns the informer of the BuildTemplates.
+func (f *InformerFactory) BuildTemplates() *Informer[*buildv1.BuildTemplate, *buildv1.BuildTemplateList] {
+	return newInformer(f.cache, buildTemplates)
+}
+
+// BuildSets returns the informer of the BuildSets.
+func (f *InformerFactory) BuildSets() *Informer[*buildv1.BuildSet, *buildv1.BuildSetList] {
+	return newInformer(f.cache, buildSets)
+}
+
+// ScheduledBuilds returns the informer of the ScheduledBuilds.
+func (f *InformerFactory) ScheduledBuilds() *Informer[*buildv1.ScheduledBuild, *buildv1.ScheduledBuildList] {
+	return newInformer(f.cache, scheduledBuilds)
+}
+
+// ProviderIdentities returns the informer of the ProviderIdentities.
+func (f *InformerFactory) ProviderIdentities() *Informer[*buildv1.ProviderIdentity, *buildv1.ProviderIdentityList] {
+	return newInformer(f.cache, providerIdentities)
+}
+
+// Informer notifies of the changes of the objects of a Forge kind and lists them from the cache.
+type Informer[T client.Object, L client.ObjectList] struct {
+	cache    cache.Cache
+	resource resource[T, L]
+}
+
+func newInformer[T client.Object, L client.ObjectList](c cache.Cache, r resource[T, L]) *Informer[T, L] {
+	return &Informer[T, L]{cache: c, resource: r}
+}
+
+// AddEventHandler registers the handler of the changes of the objects, the informer is created if needed.
+// The handlers added before the factory is started are notified of the objects listed on start.
+func (i *Informer[T, L]) AddEventHandler(ctx context.Context, handler toolscache.ResourceEventHandler) error {
+	informer, err := i.cache.GetInformer(ctx, i.resource.newObject())
+	if err != nil {
+		return fmt.Errorf("getting informer: %w", err)
+	}
+	if _, err := informer.AddEventHandler(handler); err != nil {
+		return fmt.Errorf("adding event handler: %w", err)
+	}
+	return nil
+}
+
+// Lister returns a lister of the objects of the namespace, an empty namespace lists the objects
+// of all the namespaces.
+func (i *Informer[T, L]) Lister(namespace string) *Lister[T, L] {
+	return newLister(i.cache, namespace, i.resource)
+}
+
+// Lister reads the objects of a Forge kind in a namespace, usually from a cache.
+type Lister[T client.Object, L client.ObjectList] struct {
+	reader    client.Reader
+	namespace string
+	resource  resource[T, L]
+}
+
+func newLister[T client.Object, L client.ObjectList](reader client.Reader, namespace string, r resource[T, L]) *Lister[T, L] {
+	return &Lister[T, L]{reader: reader, namespace: namespace, resource: r}
+}
+
+// Get returns the object with the given name.
+func (l *Lister[T, L]) Get(ctx context.Context, name string) (T, error) {
+	obj := l.resource.newObject()
+	if err := l.reader.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, obj); err != nil {
+		var zero T
+		return zero, err
+	}
+	return obj, nil
+}
+
+// List returns the objects matching the options.
+func (l *Lister[T, L]) List(ctx context.Context, opts ...client.ListOption) (L, error) {
+	list := l.resource.newList()
+	if err := l.reader.List(ctx, list, append([]client.ListOption{client.InNamespace(l.namespace)}, opts...)...); err != nil {
+		var zero L
+		return zero, err
+	}
+	return list, nil
+}
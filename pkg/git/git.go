@@ -0,0 +1,63 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package git clones the git repositories holding the files of the provisioners with the git command.
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Clone checks out the ref of the repository in dir, credentials are the username and password keys of the git secret.
+// The ref is fetched on its own so a commit can be checked out as well as a branch or a tag.
+func Clone(ctx context.Context, url, ref, dir string, credentials map[string][]byte) error {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	gitArgs := []string{}
+	if len(credentials) > 0 {
+		// The credentials are handed to git by a helper so they are not part of the URL, and of its error messages.
+		env = append(env,
+			"FORGE_GIT_USERNAME="+string(credentials["username"]),
+			"FORGE_GIT_PASSWORD="+string(credentials["password"]))
+		gitArgs = append(gitArgs, "-c",
+			`credential.helper=!f() { echo "username=${FORGE_GIT_USERNAME}"; echo "password=${FORGE_GIT_PASSWORD}"; }; f`)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create the repository directory")
+	}
+	commands := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", url},
+		{"fetch", "--quiet", "--depth", "1", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range commands {
+		cmd := exec.CommandContext(ctx, "git", append(gitArgs, args...)...)
+		cmd.Dir = dir
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
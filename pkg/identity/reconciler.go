@@ -0,0 +1,214 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package identity validates the credentials of the ProviderIdentities. Infrastructure providers run a Reconciler
+// with a Validator doing a lightweight, read-only call against their API, so Builds referencing broken
+// credentials can be failed before any infrastructure is created.
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+)
+
+// ControllerName is the name of the ProviderIdentity controller, used in the controller metrics.
+const ControllerName = "provideridentity"
+
+// Validator validates the credentials of a ProviderIdentity.
+type Validator interface {
+	// Validate returns an error if the credentials in the secret are rejected by the provider.
+	// Errors classified as transient or throttled by the forge errors package are retried without
+	// marking the credentials invalid, e.g. when the provider API is not reachable.
+	Validate(ctx context.Context, identity *buildv1.ProviderIdentity, secret *corev1.Secret) error
+}
+
+// ValidatorFunc is a function implementing Validator.
+type ValidatorFunc func(ctx context.Context, identity *buildv1.ProviderIdentity, secret *corev1.Secret) error
+
+// Validate calls f(ctx, identity, secret).
+func (f ValidatorFunc) Validate(ctx context.Context, identity *buildv1.ProviderIdentity, secret *corev1.Secret) error {
+	return f(ctx, identity, secret)
+}
+
+// Reconciler periodically validates the credentials of the ProviderIdentities of a provider.
+type Reconciler struct {
+	Client client.Client
+
+	// Provider is the name of the provider, only the ProviderIdentities with this provider are reconciled.
+	Provider string
+
+	// Validator validates the credentials of the provider.
+	Validator Validator
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	recorder record.EventRecorder
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Provider == "" || r.Validator == nil {
+		return errors.New("provider and validator are required")
+	}
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&buildv1.ProviderIdentity{}, builder.WithPredicates(r.isProviderIdentity())).
+		Named(r.Provider + "-" + ControllerName).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(drain.Reconciler(r))
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor(r.Provider + "-" + ControllerName)
+	return nil
+}
+
+// isProviderIdentity returns a predicate accepting the ProviderIdentities of the provider.
+func (r *Reconciler) isProviderIdentity() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		identity, ok := obj.(*buildv1.ProviderIdentity)
+		return ok && identity.Spec.Provider == r.Provider
+	})
+}
+
+// Reconcile validates the credentials of a ProviderIdentity and requeues it for the next validation.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	identity := &buildv1.ProviderIdentity{}
+	if err := r.Client.Get(ctx, req.NamespacedName, identity); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if identity.Spec.Provider != r.Provider || !identity.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	// Return early if the object is paused, events are filtered but requeues are not.
+	if annotations.HasPaused(identity) {
+		log.Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Skip the validation if the credentials have been validated recently, e.g. on a resync.
+	interval := identity.GetValidationInterval()
+	if last := identity.Status.LastValidationTime; last != nil &&
+		identity.Status.ObservedGeneration == identity.Generation &&
+		conditions.Has(identity, buildv1.CredentialsValidCondition) {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(identity, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, identity); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	wasValid := conditions.IsTrue(identity, buildv1.CredentialsValidCondition)
+	err = r.validate(ctx, identity)
+	if forgeerrors.IsRetryable(err) {
+		log.Info("Credentials validation hit a retryable error, requeuing", "reason", err.Error())
+		return ctrl.Result{RequeueAfter: forgeerrors.RequeueAfter(err)}, nil
+	}
+
+	identity.Status.LastValidationTime = &metav1.Time{Time: time.Now()}
+	identity.Status.ObservedGeneration = identity.Generation
+	switch {
+	case err != nil:
+		log.Info("Credentials are invalid", "reason", err.Error())
+		if wasValid || !conditions.Has(identity, buildv1.CredentialsValidCondition) {
+			r.recorder.Eventf(identity, corev1.EventTypeWarning, buildv1.CredentialsInvalidReason, "Credentials are invalid: %v", err)
+		}
+	case !wasValid:
+		r.recorder.Event(identity, corev1.EventTypeNormal, buildv1.CredentialsValidReason, "Credentials have been validated")
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// validate validates the credentials of the identity and sets the CredentialsValid condition accordingly.
+func (r *Reconciler) validate(ctx context.Context, identity *buildv1.ProviderIdentity) error {
+	secret, err := r.secret(ctx, identity)
+	if err != nil {
+		return err
+	}
+
+	err = r.Validator.Validate(ctx, identity, secret)
+	if err == nil {
+		conditions.MarkTrue(identity, buildv1.CredentialsValidCondition, buildv1.CredentialsValidReason, "")
+		return nil
+	}
+	if !forgeerrors.IsRetryable(err) {
+		conditions.MarkFalse(identity, buildv1.CredentialsValidCondition, buildv1.CredentialsInvalidReason, "%s", err.Error())
+	}
+	return err
+}
+
+// secret returns the secret holding the credentials of the identity, resolved by its CredentialsProvider if any.
+// It sets the CredentialsValid condition when the credentials cannot be found.
+func (r *Reconciler) secret(ctx context.Context, identity *buildv1.ProviderIdentity) (*corev1.Secret, error) {
+	if ref := identity.Spec.CredentialsProviderRef; ref != nil {
+		secret, err := credentialsprovider.Secret(ctx, r.Client, identity.Namespace, ref)
+		if err != nil && !forgeerrors.IsRetryable(err) {
+			conditions.MarkFalse(identity, buildv1.CredentialsValidCondition, buildv1.CredentialsInvalidReason,
+				"Failed to resolve the credentials: %v", err)
+		}
+		return secret, err
+	}
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: identity.Namespace, Name: identity.Spec.SecretRef.Name}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(identity, buildv1.CredentialsValidCondition, buildv1.CredentialsSecretNotFoundReason,
+				"Secret %s not found", key.Name)
+			// Not wrapping the API error, which would be classified as transient.
+			return nil, errors.Errorf("secret %s not found", key.Name)
+		}
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get secret %s", key.Name))
+	}
+	return secret, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	testcases := []struct {
+		name           string
+		provider       string
+		withSecret     bool
+		validateErr    error
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+		expectedResult ctrl.Result
+	}{
+		{
+			name:           "valid credentials",
+			provider:       "aws",
+			withSecret:     true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: buildv1.CredentialsValidReason,
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:           "invalid credentials",
+			provider:       "aws",
+			withSecret:     true,
+			validateErr:    errors.New("AuthFailure: AWS was not able to validate the provided access credentials"),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: buildv1.CredentialsInvalidReason,
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:           "secret not found",
+			provider:       "aws",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: buildv1.CredentialsSecretNotFoundReason,
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:           "provider API not reachable",
+			provider:       "aws",
+			withSecret:     true,
+			validateErr:    forgeerrors.NewTransientAfter(errors.New("connection refused"), 10*time.Second),
+			expectedResult: ctrl.Result{RequeueAfter: 10 * time.Second},
+		},
+		{
+			name:     "identity of another provider",
+			provider: "gcp",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			scheme := runtime.NewScheme()
+			g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+			identity := &buildv1.ProviderIdentity{
+				ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "identity"},
+				Spec: buildv1.ProviderIdentitySpec{
+					Provider:           tc.provider,
+					SecretRef:          corev1.LocalObjectReference{Name: "credentials"},
+					ValidationInterval: &metav1.Duration{Duration: time.Minute},
+				},
+			}
+			objs := []client.Object{identity}
+			if tc.withSecret {
+				objs = append(objs, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "credentials"}})
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(identity).Build()
+
+			validated := false
+			r := &Reconciler{
+				Client:   c,
+				Provider: "aws",
+				Validator: ValidatorFunc(func(_ context.Context, _ *buildv1.ProviderIdentity, _ *corev1.Secret) error {
+					validated = true
+					return tc.validateErr
+				}),
+				recorder: record.NewFakeRecorder(10),
+			}
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(identity)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res).To(Equal(tc.expectedResult))
+			g.Expect(validated).To(Equal(tc.withSecret && tc.provider == "aws"))
+
+			got := &buildv1.ProviderIdentity{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(identity), got)).To(Succeed())
+			if tc.expectedReason == "" {
+				g.Expect(conditions.Has(got, buildv1.CredentialsValidCondition)).To(BeFalse())
+				g.Expect(got.Status.LastValidationTime).To(BeNil())
+				return
+			}
+			g.Expect(conditions.Get(got, buildv1.CredentialsValidCondition).Status).To(Equal(tc.expectedStatus))
+			g.Expect(conditions.GetReason(got, buildv1.CredentialsValidCondition)).To(Equal(tc.expectedReason))
+			g.Expect(got.Status.LastValidationTime).ToNot(BeNil())
+		})
+	}
+}
+
+func TestReconcileSkipsRecentValidation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+
+	identity := &buildv1.ProviderIdentity{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "identity"},
+		Spec:       buildv1.ProviderIdentitySpec{Provider: "aws"},
+		Status: buildv1.ProviderIdentityStatus{
+			LastValidationTime: &metav1.Time{Time: time.Now().Add(-time.Minute)},
+		},
+	}
+	conditions.MarkTrue(identity, buildv1.CredentialsValidCondition, buildv1.CredentialsValidReason, "")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(identity).WithStatusSubresource(identity).Build()
+
+	r := &Reconciler{
+		Client:   c,
+		Provider: "aws",
+		Validator: ValidatorFunc(func(_ context.Context, _ *buildv1.ProviderIdentity, _ *corev1.Secret) error {
+			t.Fatal("credentials validated again")
+			return nil
+		}),
+		recorder: record.NewFakeRecorder(10),
+	}
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(identity)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeNumerically("~", buildv1.DefaultIdentityValidationInterval-time.Minute, time.Second))
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package log configures the structured logger of the Forge binaries. The core controller, the provisioners and the
+// SSH client log with the same standard fields, so the lines of a Build can be correlated across processes.
+package log
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Standard keys of the log lines.
+const (
+	// BuildKey is the name of the Build.
+	BuildKey = "build"
+	// NamespaceKey is the namespace of the Build.
+	NamespaceKey = "namespace"
+	// ProvisionerIDKey is the UUID of the provisioner of the Build.
+	ProvisionerIDKey = "provisionerID"
+	// ProviderKey is the kind of the infrastructure provider of the Build, e.g. AWSBuild.
+	ProviderKey = "provider"
+)
+
+// Environment variables of the provisioner Jobs holding the Build and provisioner they run for.
+const (
+	// BuildNameEnv is the environment variable holding the name of the Build.
+	BuildNameEnv = "FORGE_BUILD_NAME"
+	// ProvisionerIDEnv is the environment variable holding the UUID of the provisioner.
+	ProvisionerIDEnv = "FORGE_PROVISIONER_ID"
+)
+
+// Format is the encoding of the log lines.
+type Format string
+
+const (
+	// FormatJSON encodes the log lines as JSON objects.
+	FormatJSON Format = "json"
+	// FormatConsole encodes the log lines as human-readable text.
+	FormatConsole Format = "console"
+)
+
+// Options configures the logger.
+type Options struct {
+	// Level is the minimum level logged: error, info, debug or a verbosity N enabling logger.V(N).
+	Level string
+	// Format is the encoding of the log lines.
+	Format Format
+}
+
+// BindFlags binds the --log-level and --log-format flags to the options, defaulting to info and console.
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Level, "log-level", "info", "The minimum level logged: error, info, debug or a verbosity N logging the lines of verbosity up to N")
+	fs.Var(&formatValue{format: &o.Format}, "log-format", "The encoding of the log lines: json or console")
+}
+
+// debugVerbosity is the verbosity enabled by the debug level, the highest one the Forge binaries log at.
+const debugVerbosity = 5
+
+// level returns the zap level of the options.
+func (o *Options) level() (zapcore.Level, error) {
+	switch strings.ToLower(o.Level) {
+	case "", "info":
+		return zapcore.InfoLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	case "debug":
+		return zapcore.Level(-debugVerbosity), nil
+	}
+	v, err := strconv.Atoi(o.Level)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid log level %q, expected error, info, debug or a verbosity", o.Level)
+	}
+	return zapcore.Level(-v), nil
+}
+
+// New returns a zap logger configured by the options.
+func New(o Options) (logr.Logger, error) {
+	level, err := o.level()
+	if err != nil {
+		return logr.Logger{}, err
+	}
+	config := zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	var encoder zapcore.Encoder
+	switch o.Format {
+	case "", FormatConsole:
+		config.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(config)
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(config)
+	default:
+		return logr.Logger{}, fmt.Errorf("invalid log format %q, expected json or console", o.Format)
+	}
+	return zap.New(zap.Level(level), zap.Encoder(encoder)), nil
+}
+
+// Setup configures the logger of controller-runtime and klog from the options.
+func Setup(o Options) (logr.Logger, error) {
+	logger, err := New(o)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+	ctrl.SetLogger(logger)
+	klog.SetLogger(logger)
+	return logger, nil
+}
+
+// WithBuild returns the logger with the name and namespace of the Build.
+func WithBuild(logger logr.Logger, build client.Object) logr.Logger {
+	return logger.WithValues(BuildKey, build.GetName(), NamespaceKey, build.GetNamespace())
+}
+
+// WithProvisioner returns the logger with the UUID of the provisioner.
+func WithProvisioner(logger logr.Logger, id string) logr.Logger {
+	return logger.WithValues(ProvisionerIDKey, id)
+}
+
+// WithProvider returns the logger with the kind of the infrastructure provider.
+func WithProvider(logger logr.Logger, kind string) logr.Logger {
+	return logger.WithValues(ProviderKey, kind)
+}
+
+// BuildLogConstructor returns the constructor of the loggers of the reconciles of a controller of Builds. The
+// request is logged with the standard Build fields instead of the name and namespace keys of controller-runtime.
+func BuildLogConstructor(logger logr.Logger, controller string) func(*reconcile.Request) logr.Logger {
+	logger = logger.WithValues("controller", controller)
+	return func(req *reconcile.Request) logr.Logger {
+		if req == nil {
+			return logger
+		}
+		return logger.WithValues(BuildKey, req.Name, NamespaceKey, req.Namespace)
+	}
+}
+
+// formatValue is the flag.Value of a Format.
+type formatValue struct {
+	format *Format
+}
+
+func (f *formatValue) String() string {
+	if f.format == nil || *f.format == "" {
+		return string(FormatConsole)
+	}
+	return string(*f.format)
+}
+
+func (f *formatValue) Set(s string) error {
+	switch Format(s) {
+	case FormatJSON, FormatConsole:
+		*f.format = Format(s)
+		return nil
+	}
+	return fmt.Errorf("invalid log format %q, expected json or console", s)
+}
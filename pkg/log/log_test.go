@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	var o Options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o.BindFlags(fs)
+	g.Expect(fs.Parse([]string{"--log-level", "4", "--log-format", "json"})).To(Succeed())
+	g.Expect(o.Format).To(Equal(FormatJSON))
+	level, err := o.level()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(level).To(Equal(zapcore.Level(-4)))
+
+	logger, err := New(o)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(logger.V(4).Enabled()).To(BeTrue())
+	g.Expect(logger.V(5).Enabled()).To(BeFalse())
+
+	o.Level = "error"
+	logger, err = New(o)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(logger.Enabled()).To(BeFalse())
+
+	o.Level = "verbose"
+	_, err = New(o)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid log level")))
+	g.Expect(fs.Parse([]string{"--log-format", "text"})).ToNot(Succeed())
+}
+
+func TestBuildLogConstructor(t *testing.T) {
+	g := NewWithT(t)
+
+	var line string
+	logger := funcr.New(func(_, args string) { line = args }, funcr.Options{})
+	logger = BuildLogConstructor(logger, "build")(&reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "images", Name: "ubuntu"}})
+	WithProvisioner(WithProvider(logger, "AWSBuild"), "0b5f").Info("Reconciling")
+	g.Expect(line).To(HaveSuffix(`"msg"="Reconciling" "controller"="build" "build"="ubuntu" "namespace"="images" "provider"="AWSBuild" "provisionerID"="0b5f"`))
+}
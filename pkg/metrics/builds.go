@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// BuildsStartedName, BuildsSucceededName and BuildsFailedName are the names of the Build counters.
+	BuildsStartedName   = "forge_builds_started_total"
+	BuildsSucceededName = "forge_builds_succeeded_total"
+	BuildsFailedName    = "forge_builds_failed_total"
+	// BuildsActiveName is the name of the gauge of the Builds in a non-terminal phase.
+	BuildsActiveName = "forge_builds_active"
+	// BuildPhaseDurationName is the name of the histogram of the duration of the phases of the Builds.
+	BuildPhaseDurationName = "forge_build_phase_duration_seconds"
+	// ConnectionRetriesName is the name of the counter of the failed connections to the machines of the Builds.
+	ConnectionRetriesName = "forge_build_connection_retries_total"
+)
+
+// Build phases reported by BuildPhaseDuration.
+const (
+	// PhaseMachineReady is the time from the start of the Build to its machine being ready.
+	PhaseMachineReady = "machine_ready"
+	// PhaseProvisioning is the time from the connection to the machine to its provisioners being ready.
+	PhaseProvisioning = "provisioning"
+	// PhaseExport is the time from the provisioners being ready to the image being exported.
+	PhaseExport = "export"
+)
+
+var (
+	// BuildsStarted counts the Builds started, retries included, per namespace and infrastructure kind.
+	BuildsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: BuildsStartedName,
+		Help: "Total number of Builds started, per namespace and infrastructure kind.",
+	}, []string{"namespace", "infrastructure"})
+
+	// BuildsSucceeded counts the Builds which completed, per namespace and infrastructure kind.
+	BuildsSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: BuildsSucceededName,
+		Help: "Total number of Builds which completed, per namespace and infrastructure kind.",
+	}, []string{"namespace", "infrastructure"})
+
+	// BuildsFailed counts the Builds which failed and are not retried, per namespace, infrastructure kind and
+	// failure reason.
+	BuildsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: BuildsFailedName,
+		Help: "Total number of Builds which failed, per namespace, infrastructure kind and failure reason.",
+	}, []string{"namespace", "infrastructure", "reason"})
+
+	// BuildsActive reports the number of Builds in a non-terminal phase, per namespace and infrastructure kind.
+	BuildsActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: BuildsActiveName,
+		Help: "Number of Builds in a non-terminal phase, per namespace and infrastructure kind.",
+	}, []string{"namespace", "infrastructure"})
+
+	// BuildPhaseDuration reports the duration of the phases of the Builds, per infrastructure kind and phase.
+	BuildPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    BuildPhaseDurationName,
+		Help:    "Duration of the phases of the Builds, per infrastructure kind and phase.",
+		Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400},
+	}, []string{"infrastructure", "phase"})
+
+	// ConnectionRetries counts the failed connections to the machines of the Builds, per infrastructure kind and
+	// connector type.
+	ConnectionRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ConnectionRetriesName,
+		Help: "Total number of failed connections to the machines of the Builds, per infrastructure kind and connector type.",
+	}, []string{"infrastructure", "connector"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(BuildsStarted, BuildsSucceeded, BuildsFailed, BuildsActive, BuildPhaseDuration, ConnectionRetries)
+}
+
+// activeBuilds tracks the Builds reported by SetBuild to derive BuildsActive.
+var activeBuilds = struct {
+	sync.Mutex
+	// builds are the namespace and infrastructure labels of the active Builds, by namespace/name.
+	builds map[string][2]string
+}{builds: map[string][2]string{}}
+
+// setActive records whether the Build is active, and updates BuildsActive accordingly.
+func setActive(namespace, name, infrastructure string, active bool) {
+	activeBuilds.Lock()
+	defer activeBuilds.Unlock()
+
+	key := namespace + "/" + name
+	if previous, ok := activeBuilds.builds[key]; ok {
+		delete(activeBuilds.builds, key)
+		BuildsActive.WithLabelValues(previous[0], previous[1]).Dec()
+	}
+	if active {
+		activeBuilds.builds[key] = [2]string{namespace, infrastructure}
+		BuildsActive.WithLabelValues(namespace, infrastructure).Inc()
+	}
+}
+
+// isActive returns true if the Build phase is not terminal.
+func isActive(phase string) bool {
+	return !slices.Contains(terminalPhases, phase)
+}
+
+// ObservePhase records the duration of a phase of a Build, ignored when the start of the phase is unknown.
+func ObservePhase(infrastructure, phase string, start, end time.Time) {
+	if start.IsZero() || end.Before(start) {
+		return
+	}
+	BuildPhaseDuration.WithLabelValues(infrastructure, phase).Observe(end.Sub(start).Seconds())
+}
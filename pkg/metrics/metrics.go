@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the Forge controllers, on top of the workqueue
+// and reconcile metrics already exposed by controller-runtime, and generates alerting rules for them.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ReconcileDurationName is the name of the reconcile duration quantiles metric.
+	ReconcileDurationName = "forge_controller_reconcile_duration_seconds"
+	// ReconcileTotalName is the name of the reconcile results counter.
+	ReconcileTotalName = "forge_controller_reconcile_total"
+	// BuildCreatedName is the name of the metric reporting the creation time of the Builds.
+	BuildCreatedName = "forge_build_created_timestamp_seconds"
+
+	// WorkqueueDepthName and WorkqueueRetriesName are the per-controller workqueue metrics of controller-runtime,
+	// the controller name is set in the name label.
+	WorkqueueDepthName   = "workqueue_depth"
+	WorkqueueRetriesName = "workqueue_retries_total"
+)
+
+// Reconcile results reported by ReconcileTotal.
+const (
+	ResultSuccess      = "success"
+	ResultError        = "error"
+	ResultRequeue      = "requeue"
+	ResultRequeueAfter = "requeue_after"
+)
+
+var (
+	// ReconcileDuration reports quantiles of the reconcile duration, per controller.
+	ReconcileDuration = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       ReconcileDurationName,
+		Help:       "Quantiles of the time per reconcile, per controller.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		MaxAge:     10 * time.Minute,
+	}, []string{"controller"})
+
+	// ReconcileTotal counts the reconciles per controller and result.
+	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ReconcileTotalName,
+		Help: "Total number of reconciles, per controller and result.",
+	}, []string{"controller", "result"})
+
+	// BuildCreated reports the creation time of the Builds, along with their phase and infrastructure kind,
+	// so Builds stuck in a phase can be alerted on.
+	BuildCreated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: BuildCreatedName,
+		Help: "Unix creation timestamp of the Build, per Build, phase and infrastructure kind.",
+	}, []string{"namespace", "name", "phase", "infrastructure"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(ReconcileDuration, ReconcileTotal, BuildCreated)
+}
+
+// SetBuild reports the given Build phase, replacing the previously reported phase of the Build.
+func SetBuild(namespace, name, phase, infrastructure string, created time.Time) {
+	BuildCreated.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	BuildCreated.WithLabelValues(namespace, name, phase, infrastructure).Set(float64(created.Unix()))
+	setActive(namespace, name, infrastructure, isActive(phase))
+}
+
+// DeleteBuild stops reporting the given Build.
+func DeleteBuild(namespace, name string) {
+	BuildCreated.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	setActive(namespace, name, "", false)
+}
+
+// Instrument returns a reconciler recording the duration and the result of the reconciles of r
+// under the given controller name.
+func Instrument(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		start := time.Now()
+		res, err := r.Reconcile(ctx, req)
+		ReconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+		ReconcileTotal.WithLabelValues(controller, resultLabel(res, err)).Inc()
+		return res, err
+	})
+}
+
+func resultLabel(res reconcile.Result, err error) string {
+	switch {
+	case err != nil:
+		return ResultError
+	case res.RequeueAfter > 0:
+		return ResultRequeueAfter
+	case res.Requeue:
+		return ResultRequeue
+	default:
+		return ResultSuccess
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestInstrument(t *testing.T) {
+	testcases := []struct {
+		name     string
+		result   reconcile.Result
+		err      error
+		expected string
+	}{
+		{name: "success", expected: ResultSuccess},
+		{name: "error", err: errors.New("boom"), expected: ResultError},
+		{name: "requeue", result: reconcile.Result{Requeue: true}, expected: ResultRequeue},
+		{name: "requeue after", result: reconcile.Result{RequeueAfter: time.Second}, expected: ResultRequeueAfter},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			controller := "test-" + tc.expected
+			r := Instrument(controller, reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+				return tc.result, tc.err
+			}))
+			res, err := r.Reconcile(context.Background(), reconcile.Request{})
+			g.Expect(res).To(Equal(tc.result))
+			if tc.err != nil {
+				g.Expect(err).To(MatchError(tc.err))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(testutil.ToFloat64(ReconcileTotal.WithLabelValues(controller, tc.expected))).To(Equal(1.0))
+		})
+	}
+}
+
+func TestSetBuild(t *testing.T) {
+	g := NewWithT(t)
+
+	created := time.Unix(1700000000, 0)
+	SetBuild("default", "ubuntu", "Provisioning", "DockerBuild", created)
+	SetBuild("default", "ubuntu", "Building", "DockerBuild", created)
+	g.Expect(testutil.CollectAndCount(BuildCreated)).To(Equal(1))
+	g.Expect(testutil.ToFloat64(BuildCreated.WithLabelValues("default", "ubuntu", "Building", "DockerBuild"))).To(Equal(1700000000.0))
+
+	g.Expect(testutil.ToFloat64(BuildsActive.WithLabelValues("default", "DockerBuild"))).To(Equal(1.0))
+
+	// The Builds in a terminal phase are no longer active.
+	SetBuild("default", "debian", "Provisioning", "DockerBuild", created)
+	g.Expect(testutil.ToFloat64(BuildsActive.WithLabelValues("default", "DockerBuild"))).To(Equal(2.0))
+	SetBuild("default", "debian", "Completed", "DockerBuild", created)
+	g.Expect(testutil.ToFloat64(BuildsActive.WithLabelValues("default", "DockerBuild"))).To(Equal(1.0))
+
+	DeleteBuild("default", "ubuntu")
+	DeleteBuild("default", "debian")
+	g.Expect(testutil.CollectAndCount(BuildCreated)).To(Equal(0))
+	g.Expect(testutil.ToFloat64(BuildsActive.WithLabelValues("default", "DockerBuild"))).To(BeZero())
+}
+
+func TestObservePhase(t *testing.T) {
+	g := NewWithT(t)
+	start := time.Unix(1700000000, 0)
+	ObservePhase("AWSBuild", PhaseMachineReady, start, start.Add(90*time.Second))
+	// A phase whose start is unknown is not observed.
+	ObservePhase("AWSBuild", PhaseMachineReady, time.Time{}, start)
+	g.Expect(testutil.CollectAndCount(BuildPhaseDuration)).To(Equal(1))
+	g.Expect(testutil.CollectAndCompare(BuildPhaseDuration, strings.NewReader(`
+# HELP forge_build_phase_duration_seconds Duration of the phases of the Builds, per infrastructure kind and phase.
+# TYPE forge_build_phase_duration_seconds histogram
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="10"} 0
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="30"} 0
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="60"} 0
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="120"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="300"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="600"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="1200"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="1800"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="3600"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="7200"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="14400"} 1
+forge_build_phase_duration_seconds_bucket{infrastructure="AWSBuild",phase="machine_ready",le="+Inf"} 1
+forge_build_phase_duration_seconds_sum{infrastructure="AWSBuild",phase="machine_ready"} 90
+forge_build_phase_duration_seconds_count{infrastructure="AWSBuild",phase="machine_ready"} 1
+`))).To(Succeed())
+}
+
+func TestNewPrometheusRule(t *testing.T) {
+	g := NewWithT(t)
+
+	rule := NewPrometheusRule(RuleOptions{
+		Name:             "forge-slo",
+		Namespace:        "forge-system",
+		Providers:        []Provider{{Kind: "DockerBuild", StuckAfter: 30 * time.Minute}, {Kind: "AWSBuild"}},
+		StuckAfter:       2 * time.Hour,
+		ErrorRate:        0.1,
+		ReconcileLatency: 30 * time.Second,
+		QueueDepth:       100,
+	})
+
+	g.Expect(rule.Kind).To(Equal("PrometheusRule"))
+	g.Expect(rule.Spec.Groups).To(HaveLen(2))
+
+	controllerRules := rule.Spec.Groups[0].Rules
+	g.Expect(controllerRules).To(HaveLen(4))
+	g.Expect(controllerRules[0].Expr).To(ContainSubstring(`controller=~"build|scheduledbuild|buildset|shelljob",result="error"`))
+	g.Expect(controllerRules[0].Expr).To(HaveSuffix("> 0.1"))
+	g.Expect(controllerRules[1].Expr).To(HaveSuffix(`quantile="0.99"}) > 30`))
+
+	buildRules := rule.Spec.Groups[1].Rules
+	g.Expect(buildRules).To(HaveLen(3))
+	g.Expect(buildRules[0].Expr).To(Equal(`time() - forge_build_created_timestamp_seconds{infrastructure="AWSBuild",phase!~"Completed|Failed|Terminating"} > 7200`))
+	g.Expect(buildRules[1].Expr).To(Equal(`time() - forge_build_created_timestamp_seconds{infrastructure="DockerBuild",phase!~"Completed|Failed|Terminating"} > 1800`))
+	g.Expect(buildRules[2].Expr).To(Equal(`time() - forge_build_created_timestamp_seconds{infrastructure!~"AWSBuild|DockerBuild",phase!~"Completed|Failed|Terminating"} > 7200`))
+}
+
+func TestWorkqueueMetricsProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	depth := WorkqueueMetricsProvider{}.NewDepthMetric("fair-test")
+	depth.Inc()
+	depth.Inc()
+	depth.Dec()
+
+	// The depth is reported by the workqueue_depth metric of controller-runtime.
+	families, err := metrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	found := false
+	for _, family := range families {
+		if family.GetName() != WorkqueueDepthName {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetLabel()[0].GetValue() == "fair-test" {
+				found = true
+				g.Expect(metric.GetGauge().GetValue()).To(Equal(1.0))
+			}
+		}
+	}
+	g.Expect(found).To(BeTrue())
+}
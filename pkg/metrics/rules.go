@@ -0,0 +1,209 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultControllers are the names of the Forge core controllers, the Build, ScheduledBuild, BuildSet and ShellJob controllers.
+var DefaultControllers = []string{"build", "scheduledbuild", "buildset", "shelljob"}
+
+// terminalPhases are the Build phases which are not considered stuck.
+var terminalPhases = []string{"Completed", "Failed", "Terminating"}
+
+// RuleOptions configures the alerting rules generated by PrometheusRules.
+type RuleOptions struct {
+	// Name and Namespace of the PrometheusRule.
+	Name      string
+	Namespace string
+	// Labels are added to the PrometheusRule, e.g. to match the rule selector of Prometheus.
+	Labels map[string]string
+
+	// Controllers are the controllers the controller alerts are generated for.
+	Controllers []string
+	// Providers are the infrastructure kinds installed, a Build stuck alert is generated for each of them.
+	Providers []Provider
+
+	// StuckAfter is how long a Build can stay in a non-terminal phase, unless overridden by the provider.
+	StuckAfter time.Duration
+	// ErrorRate is the ratio of failed reconciles above which the controller is alerted on.
+	ErrorRate float64
+	// ReconcileLatency is the 99th percentile of the reconcile duration above which the controller is alerted on.
+	ReconcileLatency time.Duration
+	// QueueDepth is the workqueue depth above which the controller is alerted on.
+	QueueDepth int
+}
+
+// Provider is an installed infrastructure provider.
+type Provider struct {
+	// Kind is the kind of the infrastructure objects of the provider, e.g. DockerBuild.
+	Kind string
+	// StuckAfter overrides RuleOptions.StuckAfter for the Builds of the provider, if set.
+	StuckAfter time.Duration
+}
+
+// PrometheusRule is a monitoring.coreos.com/v1 PrometheusRule manifest.
+type PrometheusRule struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   RuleMetadata       `json:"metadata"`
+	Spec       PrometheusRuleSpec `json:"spec"`
+}
+
+// RuleMetadata is the metadata of a PrometheusRule.
+type RuleMetadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// PrometheusRuleSpec is the spec of a PrometheusRule.
+type PrometheusRuleSpec struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+// RuleGroup is a group of alerting rules.
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is an alerting rule.
+type Rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NewPrometheusRule returns a PrometheusRule alerting on the controller SLOs and the Builds stuck
+// for each of the installed providers.
+func NewPrometheusRule(opts RuleOptions) *PrometheusRule {
+	controllers := opts.Controllers
+	if len(controllers) == 0 {
+		controllers = DefaultControllers
+	}
+	controllerMatcher := strings.Join(controllers, "|")
+
+	controllerRules := []Rule{
+		{
+			Alert: "ForgeReconcileErrorRateHigh",
+			Expr: fmt.Sprintf(`sum by (controller) (rate(%s{controller=~"%s",result="%s"}[5m]))
+  / sum by (controller) (rate(%s{controller=~"%s"}[5m])) > %g`,
+				ReconcileTotalName, controllerMatcher, ResultError, ReconcileTotalName, controllerMatcher, opts.ErrorRate),
+			For:    "15m",
+			Labels: severity("warning"),
+			Annotations: map[string]string{
+				"summary":     "Forge controller {{ $labels.controller }} reconciles are failing",
+				"description": fmt.Sprintf("More than %g%% of the reconciles of the {{ $labels.controller }} controller failed in the last 15 minutes.", opts.ErrorRate*100),
+			},
+		},
+		{
+			Alert: "ForgeReconcileLatencyHigh",
+			Expr: fmt.Sprintf(`max by (controller) (%s{controller=~"%s",quantile="0.99"}) > %g`,
+				ReconcileDurationName, controllerMatcher, opts.ReconcileLatency.Seconds()),
+			For:    "15m",
+			Labels: severity("warning"),
+			Annotations: map[string]string{
+				"summary":     "Forge controller {{ $labels.controller }} reconciles are slow",
+				"description": fmt.Sprintf("The 99th percentile of the reconcile duration of the {{ $labels.controller }} controller is above %s.", opts.ReconcileLatency),
+			},
+		},
+		{
+			Alert:  "ForgeWorkqueueDepthHigh",
+			Expr:   fmt.Sprintf(`max by (name) (%s{name=~"%s"}) > %d`, WorkqueueDepthName, controllerMatcher, opts.QueueDepth),
+			For:    "15m",
+			Labels: severity("warning"),
+			Annotations: map[string]string{
+				"summary":     "Forge controller {{ $labels.name }} is falling behind",
+				"description": fmt.Sprintf("The workqueue of the {{ $labels.name }} controller holds more than %d items.", opts.QueueDepth),
+			},
+		},
+		{
+			Alert:  "ForgeWorkqueueRetriesHigh",
+			Expr:   fmt.Sprintf(`sum by (name) (rate(%s{name=~"%s"}[15m])) > 1`, WorkqueueRetriesName, controllerMatcher),
+			For:    "15m",
+			Labels: severity("info"),
+			Annotations: map[string]string{
+				"summary":     "Forge controller {{ $labels.name }} is retrying reconciles",
+				"description": "The {{ $labels.name }} controller retries more than one reconcile per second.",
+			},
+		},
+	}
+
+	providers := append([]Provider{}, opts.Providers...)
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Kind < providers[j].Kind })
+	buildRules := []Rule{}
+	kinds := []string{}
+	for _, p := range providers {
+		stuckAfter := p.StuckAfter
+		if stuckAfter == 0 {
+			stuckAfter = opts.StuckAfter
+		}
+		buildRules = append(buildRules, buildStuckRule(fmt.Sprintf(`infrastructure="%s"`, p.Kind), stuckAfter))
+		kinds = append(kinds, p.Kind)
+	}
+	// Builds of the providers without a dedicated rule, or without infrastructure yet.
+	fallbackMatcher := ""
+	if len(kinds) > 0 {
+		fallbackMatcher = fmt.Sprintf(`infrastructure!~"%s"`, strings.Join(kinds, "|"))
+	}
+	buildRules = append(buildRules, buildStuckRule(fallbackMatcher, opts.StuckAfter))
+
+	return &PrometheusRule{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata: RuleMetadata{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Labels:    opts.Labels,
+		},
+		Spec: PrometheusRuleSpec{
+			Groups: []RuleGroup{
+				{Name: "forge-controllers", Rules: controllerRules},
+				{Name: "forge-builds", Rules: buildRules},
+			},
+		},
+	}
+}
+
+// buildStuckRule alerts on the Builds matching matcher which are not in a terminal phase after stuckAfter.
+func buildStuckRule(matcher string, stuckAfter time.Duration) Rule {
+	selector := fmt.Sprintf(`phase!~"%s"`, strings.Join(terminalPhases, "|"))
+	if matcher != "" {
+		selector = matcher + "," + selector
+	}
+	return Rule{
+		Alert:  "ForgeBuildStuck",
+		Expr:   fmt.Sprintf(`time() - %s{%s} > %g`, BuildCreatedName, selector, stuckAfter.Seconds()),
+		For:    "5m",
+		Labels: severity("warning"),
+		Annotations: map[string]string{
+			"summary":     "Build {{ $labels.namespace }}/{{ $labels.name }} is stuck",
+			"description": fmt.Sprintf("Build {{ $labels.namespace }}/{{ $labels.name }} is {{ $labels.phase }} and has not completed %s after its creation.", stuckAfter),
+		},
+	}
+}
+
+func severity(s string) map[string]string {
+	return map[string]string{"severity": s}
+}
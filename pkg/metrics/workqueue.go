@@ -0,0 +1,133 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// The workqueue metrics of controller-runtime, they are declared identically so registering them returns the
+// collectors already registered by controller-runtime.
+var (
+	workqueueDepth = registeredCollector(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.DepthKey,
+		Help:      "Current depth of workqueue",
+	}, []string{"name"}))
+
+	workqueueAdds = registeredCollector(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.AddsKey,
+		Help:      "Total number of adds handled by workqueue",
+	}, []string{"name"}))
+
+	workqueueLatency = registeredCollector(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.QueueLatencyKey,
+		Help:      "How long in seconds an item stays in workqueue before being requested",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 12),
+	}, []string{"name"}))
+
+	workqueueWorkDuration = registeredCollector(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.WorkDurationKey,
+		Help:      "How long in seconds processing an item from workqueue takes.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 12),
+	}, []string{"name"}))
+
+	workqueueUnfinished = registeredCollector(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.UnfinishedWorkKey,
+		Help: "How many seconds of work has been done that " +
+			"is in progress and hasn't been observed by work_duration. Large " +
+			"values indicate stuck threads. One can deduce the number of stuck " +
+			"threads by observing the rate at which this increases.",
+	}, []string{"name"}))
+
+	workqueueLongestRunningProcessor = registeredCollector(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.LongestRunningProcessorKey,
+		Help: "How many seconds has the longest running " +
+			"processor for workqueue been running.",
+	}, []string{"name"}))
+
+	workqueueRetries = registeredCollector(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.RetriesKey,
+		Help:      "Total number of retries handled by workqueue",
+	}, []string{"name"}))
+)
+
+// registeredCollector registers c, returning the collector already registered if any.
+func registeredCollector[T prometheus.Collector](c T) T {
+	if err := metrics.Registry.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// WorkqueueMetricsProvider reports the metrics of the workqueues which are not built by controller-runtime,
+// e.g. custom controller queues, as the controller-runtime workqueue metrics.
+type WorkqueueMetricsProvider struct{}
+
+var _ workqueue.MetricsProvider = WorkqueueMetricsProvider{}
+
+// NewDepthMetric returns the workqueue_depth metric of the named queue.
+func (WorkqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+// NewAddsMetric returns the workqueue_adds_total metric of the named queue.
+func (WorkqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+// NewLatencyMetric returns the workqueue_queue_duration_seconds metric of the named queue.
+func (WorkqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+// NewWorkDurationMetric returns the workqueue_work_duration_seconds metric of the named queue.
+func (WorkqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+// NewUnfinishedWorkSecondsMetric returns the workqueue_unfinished_work_seconds metric of the named queue.
+func (WorkqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinished.WithLabelValues(name)
+}
+
+// NewLongestRunningProcessorSecondsMetric returns the workqueue_longest_running_processor_seconds metric
+// of the named queue.
+func (WorkqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+// NewRetriesMetric returns the workqueue_retries_total metric of the named queue.
+func (WorkqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oci implements the parts of the OCI distribution API used by the provisioners and the exporter: the
+// references of the artifacts, the credentials and the authentication of the registries, and the push of artifacts.
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// TitleAnnotation is the annotation of the layers of an artifact holding the name of their file.
+	TitleAnnotation = "org.opencontainers.image.title"
+
+	// ManifestMediaType is the media type of the OCI image manifests.
+	ManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	dockerHub         = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	dockerHubIndex    = "https://index.docker.io/v1/"
+
+	// maxTokenSize is the size of the largest token response read from a registry.
+	maxTokenSize = 1 << 20
+)
+
+// Reference is a parsed OCI artifact reference.
+type Reference struct {
+	Registry   string
+	Repository string
+	// Ref is the tag or the digest of the artifact.
+	Ref string
+}
+
+// ParseReference parses references such as ghcr.io/example/assets:v1 or ghcr.io/example/assets@sha256:...,
+// the references without registry are Docker Hub references and the tag defaults to latest.
+func ParseReference(s string) (Reference, error) {
+	r := Reference{Registry: dockerHub}
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, r.Ref = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.Ref = name[:i], name[i+1:]
+	}
+	if r.Ref == "" {
+		r.Ref = "latest"
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			r.Registry, name = host, name[i+1:]
+		}
+	}
+	if r.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" {
+		return r, forgeerrors.ConfigErrorf("invalid OCI reference %q", s)
+	}
+	r.Repository = name
+	return r, nil
+}
+
+// Host returns the host serving the registry API.
+func (r Reference) Host() string {
+	if r.Registry == dockerHub {
+		return dockerHubRegistry
+	}
+	return r.Registry
+}
+
+// Manifest is an OCI image manifest.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion,omitempty"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Descriptor describes a blob of an artifact.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Credentials are the credentials of a registry, the anonymous access is used when empty.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFor returns the credentials of the registry held by a kubernetes.io/dockerconfigjson secret.
+func CredentialsFor(secret *corev1.Secret, registry string) (Credentials, error) {
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		return Credentials{}, forgeerrors.NewConfigError(errors.Wrapf(err, "invalid %s of secret %s", corev1.DockerConfigJsonKey, secret.Name))
+	}
+	keys := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == dockerHub {
+		keys = append(keys, dockerHubIndex, dockerHubRegistry)
+	}
+	for _, key := range keys {
+		auth, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		creds := Credentials{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return Credentials{}, forgeerrors.ConfigErrorf("invalid auth of registry %s in secret %s", registry, secret.Name)
+			}
+			creds.Username, creds.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		return creds, nil
+	}
+	return Credentials{}, forgeerrors.ConfigErrorf("secret %s has no credentials for registry %s", secret.Name, registry)
+}
+
+// Authorize returns the Authorization header answering the challenge of the registry, a bearer token is requested
+// to the token service of the registry for the actions of the repository, e.g. pull or push,pull.
+func Authorize(ctx context.Context, httpClient *http.Client, challenge string, ref Reference, creds Credentials, actions string) (string, error) {
+	scheme, params := ParseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds.Username == "" {
+			return "", forgeerrors.ConfigErrorf("registry %s requires credentials", ref.Registry)
+		}
+		return basicAuth(creds), nil
+	case "bearer":
+	default:
+		return "", forgeerrors.ConfigErrorf("registry %s requires an unsupported authentication: %q", ref.Registry, challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", forgeerrors.ConfigErrorf("registry %s returned an invalid challenge: %q", ref.Registry, challenge)
+	}
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:%s", ref.Repository, actions)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), http.NoBody)
+	if err != nil {
+		return "", forgeerrors.NewConfigError(errors.Wrapf(err, "invalid token URL of registry %s", ref.Registry))
+	}
+	if creds.Username != "" {
+		req.Header.Set("Authorization", basicAuth(creds))
+	}
+	resp, err := do(httpClient, req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to authenticate to registry %s", ref.Registry)
+	}
+	defer resp.Body.Close()
+	if err := StatusError(resp, http.StatusOK); err != nil {
+		return "", errors.Wrapf(err, "failed to authenticate to registry %s", ref.Registry)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenSize)).Decode(&token); err != nil {
+		return "", errors.Wrapf(err, "invalid token of registry %s", ref.Registry)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// ParseChallenge returns the scheme and the parameters of a WWW-Authenticate header,
+// e.g. Bearer realm="https://ghcr.io/token",service="ghcr.io".
+func ParseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return scheme, params
+}
+
+// StatusError returns the error of a response without the expected status. The server errors are retried,
+// the client errors are not.
+func StatusError(resp *http.Response, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+	err := fmt.Errorf("%s %s: unexpected status %s", resp.Request.Method, resp.Request.URL, resp.Status)
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return forgeerrors.NewTransient(err)
+	}
+	return forgeerrors.NewConfigError(err)
+}
+
+// do sends the request, the failures to reach the server are retried.
+func do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to %s %s", req.Method, req.URL))
+	}
+	return resp, nil
+}
+
+func basicAuth(creds Credentials) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      Reference
+	}{
+		{reference: "ghcr.io/example/assets:v1", want: Reference{Registry: "ghcr.io", Repository: "example/assets", Ref: "v1"}},
+		{reference: "localhost:5000/assets", want: Reference{Registry: "localhost:5000", Repository: "assets", Ref: "latest"}},
+		{
+			reference: "ghcr.io/example/assets@sha256:0123",
+			want:      Reference{Registry: "ghcr.io", Repository: "example/assets", Ref: "sha256:0123"},
+		},
+		{reference: "alpine:3.20", want: Reference{Registry: "docker.io", Repository: "library/alpine", Ref: "3.20"}},
+		{reference: "example/assets", want: Reference{Registry: "docker.io", Repository: "example/assets", Ref: "latest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ref, err := ParseReference(tt.reference)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ref).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, params := ParseChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:example/assets:pull"`)
+	g.Expect(scheme).To(Equal("Bearer"))
+	g.Expect(params).To(Equal(map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:example/assets:pull",
+	}))
+}
+
+func TestCredentialsFor(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry"},
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(
+			`{"auths":{"ghcr.io":{"auth":"cm9ib3Q6c2VjcmV0"},"https://index.docker.io/v1/":{"username":"hub","password":"token"}}}`)},
+	}
+	tests := []struct {
+		registry    string
+		expected    Credentials
+		expectedErr string
+	}{
+		{registry: "ghcr.io", expected: Credentials{Username: "robot", Password: "secret"}},
+		{registry: "docker.io", expected: Credentials{Username: "hub", Password: "token"}},
+		{registry: "quay.io", expectedErr: "secret registry has no credentials for registry quay.io"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.registry, func(t *testing.T) {
+			g := NewWithT(t)
+
+			creds, err := CredentialsFor(secret, tt.registry)
+			if tt.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectedErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(creds).To(Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// DefaultLayerMediaType is the media type of the layers pushed without media type.
+	DefaultLayerMediaType = "application/octet-stream"
+
+	// emptyMediaType is the media type of the empty config of the artifacts pushed.
+	emptyMediaType = "application/vnd.oci.empty.v1+json"
+)
+
+// Layer is a file of an artifact pushed.
+type Layer struct {
+	// Title is the name of the file, recorded in the TitleAnnotation of the layer.
+	Title string
+	// MediaType is the media type of the layer, defaults to DefaultLayerMediaType.
+	MediaType string
+	// Data is the content of the file.
+	Data []byte
+	// File is the path of the file, streamed instead of Data when set, e.g. a disk image.
+	File string
+}
+
+// Artifact is an artifact pushed.
+type Artifact struct {
+	// Type is the artifact type of the manifest.
+	Type string
+	// Layers are the files of the artifact.
+	Layers []Layer
+	// Annotations are the annotations of the manifest.
+	Annotations map[string]string
+	// Tags are the tags of the artifact, on top of the ref of the reference it is pushed to.
+	Tags []string
+	// Subject is the manifest the artifact refers to, e.g. the SBOM of a disk is a referrer of the disk.
+	Subject *Descriptor
+}
+
+// Pusher pushes artifacts to their registry.
+type Pusher struct {
+	// HTTPClient sends the requests to the registry, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// PlainHTTP pushes over http instead of https, it is only meant for tests.
+	PlainHTTP bool
+}
+
+// Push pushes an artifact of the given type made of the layers, and tags it with the ref of the reference. The blobs
+// the registry already holds are not uploaded again. It returns the digest of the manifest of the artifact.
+func (p *Pusher) Push(ctx context.Context, ref Reference, creds Credentials, artifactType string, layers []Layer) (string, error) {
+	d, err := p.PushArtifact(ctx, ref, creds, Artifact{Type: artifactType, Layers: layers})
+	return d.Digest, err
+}
+
+// PushArtifact pushes the artifact as Push does, and tags it with its tags too. The artifact is only pushed by
+// digest when the reference has no ref, e.g. a referrer. It returns the descriptor of the manifest of the artifact.
+func (p *Pusher) PushArtifact(ctx context.Context, ref Reference, creds Credentials, artifact Artifact) (Descriptor, error) {
+	scheme := "https"
+	if p.PlainHTTP {
+		scheme = "http"
+	}
+	s := &pushSession{
+		httpClient: p.HTTPClient,
+		ref:        ref,
+		creds:      creds,
+		base:       fmt.Sprintf("%s://%s/v2/%s", scheme, ref.Host(), ref.Repository),
+	}
+
+	empty := []byte("{}")
+	config := descriptorOf(emptyMediaType, empty, nil)
+	if err := s.pushBlob(ctx, config, bytesBody(empty)); err != nil {
+		return Descriptor{}, err
+	}
+	m := Manifest{
+		SchemaVersion: 2,
+		MediaType:     ManifestMediaType,
+		ArtifactType:  artifact.Type,
+		Config:        &config,
+		Annotations:   artifact.Annotations,
+		Subject:       artifact.Subject,
+	}
+	for _, layer := range artifact.Layers {
+		mediaType := layer.MediaType
+		if mediaType == "" {
+			mediaType = DefaultLayerMediaType
+		}
+		d, body := descriptorOf(mediaType, layer.Data, map[string]string{TitleAnnotation: layer.Title}), bytesBody(layer.Data)
+		if layer.File != "" {
+			f, err := os.Open(layer.File)
+			if err != nil {
+				return Descriptor{}, err
+			}
+			defer f.Close()
+			if d, err = fileDescriptorOf(mediaType, f, d.Annotations); err != nil {
+				return Descriptor{}, errors.Wrapf(err, "failed to compute the digest of %s", layer.File)
+			}
+			body = func() io.Reader { return io.NewSectionReader(f, 0, d.Size) }
+		}
+		if err := s.pushBlob(ctx, d, body); err != nil {
+			return Descriptor{}, errors.Wrapf(err, "failed to push %s", layer.Title)
+		}
+		m.Layers = append(m.Layers, d)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	manifest := descriptorOf(ManifestMediaType, data, nil)
+	header := http.Header{"Content-Type": {ManifestMediaType}}
+	for _, tag := range append([]string{cmp.Or(ref.Ref, manifest.Digest)}, artifact.Tags...) {
+		resp, err := s.do(ctx, http.MethodPut, s.base+"/manifests/"+tag, bytesBody(data), header)
+		if err != nil {
+			return Descriptor{}, err
+		}
+		resp.Body.Close()
+		if err := StatusError(resp, http.StatusCreated); err != nil {
+			return Descriptor{}, errors.Wrap(err, "failed to push the manifest")
+		}
+	}
+	return manifest, nil
+}
+
+// pushSession holds the authorization of the requests pushing an artifact.
+type pushSession struct {
+	httpClient    *http.Client
+	ref           Reference
+	creds         Credentials
+	base          string
+	authorization string
+}
+
+// body returns a reader of the body of a request, a new one every time the request is sent.
+type body func() io.Reader
+
+func bytesBody(data []byte) body {
+	return func() io.Reader { return bytes.NewReader(data) }
+}
+
+// pushBlob uploads the blob with a monolithic upload, unless the registry already holds it.
+func (s *pushSession) pushBlob(ctx context.Context, d Descriptor, data body) error {
+	resp, err := s.do(ctx, http.MethodHead, s.base+"/blobs/"+d.Digest, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = s.do(ctx, http.MethodPost, s.base+"/blobs/uploads/", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := StatusError(resp, http.StatusAccepted); err != nil {
+		return err
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return forgeerrors.NewTransient(errors.Wrapf(err, "registry %s returned no upload location", s.ref.Registry))
+	}
+	query := location.Query()
+	query.Set("digest", d.Digest)
+	location.RawQuery = query.Encode()
+
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	if resp, err = s.doSized(ctx, http.MethodPut, location.String(), data, d.Size, header); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return StatusError(resp, http.StatusCreated)
+}
+
+// do sends the request with the authorization of the session. When the request is not authorized, it authenticates
+// with the challenge of the registry and sends the request again.
+func (s *pushSession) do(ctx context.Context, method, u string, data body, header http.Header) (*http.Response, error) {
+	return s.doSized(ctx, method, u, data, -1, header)
+}
+
+// doSized sends the request as do does, with the size of its body, -1 if it is unknown.
+func (s *pushSession) doSized(ctx context.Context, method, u string, data body, size int64, header http.Header) (*http.Response, error) {
+	resp, err := s.send(ctx, method, u, data, size, header)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if s.authorization, err = Authorize(ctx, s.httpClient, challenge, s.ref, s.creds, "pull,push"); err != nil {
+		return nil, err
+	}
+	return s.send(ctx, method, u, data, size, header)
+}
+
+func (s *pushSession) send(ctx context.Context, method, u string, data body, size int64, header http.Header) (*http.Response, error) {
+	var reader io.Reader = http.NoBody
+	if data != nil {
+		reader = data()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(errors.Wrapf(err, "invalid URL %s", u))
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if s.authorization != "" {
+		req.Header.Set("Authorization", s.authorization)
+	}
+	return do(s.httpClient, req)
+}
+
+// descriptorOf returns the descriptor of the blob.
+func descriptorOf(mediaType string, data []byte, annotations map[string]string) Descriptor {
+	return Descriptor{MediaType: mediaType, Digest: digest(data), Size: int64(len(data)), Annotations: annotations}
+}
+
+// fileDescriptorOf returns the descriptor of the blob read from f.
+func fileDescriptorOf(mediaType string, f io.Reader, annotations map[string]string) (Descriptor, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	return Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(hash.Sum(nil)), Size: size, Annotations: annotations}, nil
+}
+
+// digest returns the sha256 digest of the data.
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
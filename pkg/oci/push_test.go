@@ -0,0 +1,167 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeRegistry accepts the pushes authorized by a bearer token granted to robot:secret, it records the blobs
+// and the manifests pushed.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		if username, password, _ := r.BasicAuth(); username != "robot" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "push-token"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer push-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="registry"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/v2/example/logs/blobs/"):
+		if _, ok := f.blobs[strings.TrimPrefix(r.URL.Path, "/v2/example/logs/blobs/")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/example/logs/blobs/uploads/":
+		w.Header().Set("Location", "/v2/example/logs/blobs/uploads/session?state=1")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && r.URL.Path == "/v2/example/logs/blobs/uploads/session":
+		if r.URL.Query().Get("state") != "1" || r.URL.Query().Get("digest") != digest(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest(body)] = body
+		f.uploads++
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v2/example/logs/manifests/"):
+		f.manifests[strings.TrimPrefix(r.URL.Path, "/v2/example/logs/manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPush(t *testing.T) {
+	g := NewWithT(t)
+
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/example/logs:ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pusher := &Pusher{HTTPClient: server.Client(), PlainHTTP: true}
+	layers := []Layer{{Title: "build.log", Data: []byte("built")}, {Title: "packages.txt", MediaType: "text/plain", Data: []byte("curl")}}
+	manifestDigest, err := pusher.Push(context.Background(), ref, Credentials{Username: "robot", Password: "secret"},
+		"application/vnd.forge.build.extract.v1", layers)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(registry.uploads).To(Equal(3))
+
+	data := registry.manifests["ubuntu"]
+	g.Expect(digest(data)).To(Equal(manifestDigest))
+	m := &Manifest{}
+	g.Expect(json.Unmarshal(data, m)).To(Succeed())
+	g.Expect(m.ArtifactType).To(Equal("application/vnd.forge.build.extract.v1"))
+	g.Expect(m.Config.MediaType).To(Equal(emptyMediaType))
+	g.Expect(m.Layers).To(HaveLen(2))
+	g.Expect(m.Layers[0].MediaType).To(Equal(DefaultLayerMediaType))
+	g.Expect(m.Layers[1].Annotations).To(HaveKeyWithValue(TitleAnnotation, "packages.txt"))
+	g.Expect(registry.blobs[m.Layers[1].Digest]).To(Equal([]byte("curl")))
+
+	// The blobs are not uploaded again.
+	_, err = pusher.Push(context.Background(), ref, Credentials{Username: "robot", Password: "secret"}, "", layers)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(registry.uploads).To(Equal(3))
+
+	_, err = pusher.Push(context.Background(), ref, Credentials{}, "", layers)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to authenticate to registry")))
+}
+
+func TestPushArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/example/logs:24.04")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The files are streamed, and the artifact is tagged with the ref and the tags.
+	file := filepath.Join(t.TempDir(), "disk.qcow2")
+	g.Expect(os.WriteFile(file, []byte("disk"), 0o600)).To(Succeed())
+	pusher := &Pusher{HTTPClient: server.Client(), PlainHTTP: true}
+	manifest, err := pusher.PushArtifact(context.Background(), ref, Credentials{Username: "robot", Password: "secret"}, Artifact{
+		Type:        "application/vnd.forge.build.disk.v1",
+		Layers:      []Layer{{Title: "disk.qcow2", MediaType: "application/vnd.forge.build.disk.v1.qcow2", File: file}},
+		Annotations: map[string]string{"team": "platform"},
+		Tags:        []string{"latest"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(registry.manifests).To(HaveLen(2))
+	g.Expect(registry.manifests["latest"]).To(Equal(registry.manifests["24.04"]))
+	g.Expect(digest(registry.manifests["latest"])).To(Equal(manifest.Digest))
+	g.Expect(manifest.Size).To(BeEquivalentTo(len(registry.manifests["latest"])))
+
+	m := &Manifest{}
+	g.Expect(json.Unmarshal(registry.manifests["latest"], m)).To(Succeed())
+	g.Expect(m.Annotations).To(HaveKeyWithValue("team", "platform"))
+	g.Expect(m.Layers).To(HaveLen(1))
+	g.Expect(m.Layers[0].Digest).To(Equal(digest([]byte("disk"))))
+	g.Expect(m.Layers[0].Size).To(BeEquivalentTo(4))
+	g.Expect(registry.blobs[m.Layers[0].Digest]).To(Equal([]byte("disk")))
+
+	// A referrer without ref is pushed by digest, and refers to its subject.
+	ref.Ref = ""
+	referrer, err := pusher.PushArtifact(context.Background(), ref, Credentials{Username: "robot", Password: "secret"}, Artifact{
+		Type:    "application/spdx+json",
+		Layers:  []Layer{{Title: "sbom.spdx.json", MediaType: "application/spdx+json", Data: []byte("{}")}},
+		Subject: &manifest,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(registry.manifests).To(HaveKey(referrer.Digest))
+	g.Expect(json.Unmarshal(registry.manifests[referrer.Digest], m)).To(Succeed())
+	g.Expect(m.Subject).To(HaveValue(Equal(manifest)))
+}
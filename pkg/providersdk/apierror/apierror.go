@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apierror is the error of the HTTP APIs the providers call, e.g. the APIs of the clouds, of the Docker
+// Engine or of the secret managers, and its forge error category.
+package apierror
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// MaxBody is how much of the body of an error response is read.
+const MaxBody = 4096
+
+// Error is an error response of an API.
+type Error struct {
+	StatusCode int
+	// Code is the code of the error when the API reports one, e.g. InvalidInstanceID.NotFound.
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Code, e.Message)
+}
+
+// ReadBody returns the body of an error response, up to MaxBody.
+func ReadBody(body io.Reader) []byte {
+	data, _ := io.ReadAll(io.LimitReader(body, MaxBody))
+	return data
+}
+
+// Parse returns the error of an error response. parse returns the code and the message reported in the body, the
+// body is the message when parse returns neither, e.g. when the response is not the error document of the API.
+func Parse(statusCode int, body io.Reader, parse func(data []byte) (code, message string)) *Error {
+	data := ReadBody(body)
+	if code, message := parse(data); code != "" || message != "" {
+		return &Error{StatusCode: statusCode, Code: code, Message: message}
+	}
+	return &Error{StatusCode: statusCode, Message: strings.Join(strings.Fields(string(data)), " ")}
+}
+
+// Classifier annotates the errors of an API with their forge error category. The errors handled by the callers are
+// returned as is, the throttling errors are retried after a while, the server errors and the transient errors are
+// retried, and the other errors are configuration errors.
+type Classifier struct {
+	// Handled returns true if the callers handle the error, e.g. a missing resource.
+	Handled func(err *Error) bool
+	// Throttled returns true if the error is a throttling error besides the 429 responses, e.g. RequestLimitExceeded.
+	Throttled func(err *Error) bool
+	// Transient returns true if the error is transient besides the server errors, e.g. a busy resource.
+	Transient func(err *Error) bool
+}
+
+// Classify returns err annotated with its forge error category.
+func (c Classifier) Classify(err *Error) error {
+	switch {
+	case c.Handled != nil && c.Handled(err):
+		return err
+	case err.StatusCode == http.StatusTooManyRequests, c.Throttled != nil && c.Throttled(err):
+		return forgeerrors.NewThrottled(err, 0)
+	case err.StatusCode >= 500, c.Transient != nil && c.Transient(err):
+		return forgeerrors.NewTransient(err)
+	}
+	return forgeerrors.NewConfigError(err)
+}
+
+// Classify returns err annotated with its forge error category, for the APIs without errors handled by the callers
+// nor transient errors besides the server errors.
+func Classify(err *Error) error {
+	return Classifier{}.Classify(err)
+}
+
+// StatusCodes returns true for the errors with one of the status codes, e.g. to set the Handled errors of a
+// Classifier.
+func StatusCodes(statusCodes ...int) func(err *Error) bool {
+	return func(err *Error) bool {
+		for _, statusCode := range statusCodes {
+			if err.StatusCode == statusCode {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestParse(t *testing.T) {
+	g := NewWithT(t)
+
+	parse := func(data []byte) (string, string) {
+		body := &struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}{}
+		_ = json.Unmarshal(data, body)
+		return body.Code, body.Message
+	}
+	err := Parse(http.StatusBadRequest, strings.NewReader(`{"code": "InvalidParameter", "message": "invalid size"}`), parse)
+	g.Expect(err).To(Equal(&Error{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "invalid size"}))
+	g.Expect(err.Error()).To(Equal("400 Bad Request: InvalidParameter: invalid size"))
+
+	// The body is the message of the responses that are not error documents, e.g. of a proxy.
+	err = Parse(http.StatusBadGateway, strings.NewReader("<html>\n  bad gateway\n</html>\n"), parse)
+	g.Expect(err).To(Equal(&Error{StatusCode: http.StatusBadGateway, Message: "<html> bad gateway </html>"}))
+	g.Expect(err.Error()).To(Equal("502 Bad Gateway: <html> bad gateway </html>"))
+
+	// Only the beginning of the body is read.
+	err = Parse(http.StatusBadRequest, strings.NewReader(strings.Repeat("a", 2*MaxBody)), parse)
+	g.Expect(err.Message).To(HaveLen(MaxBody))
+}
+
+func TestClassify(t *testing.T) {
+	classifier := Classifier{
+		Handled:   StatusCodes(http.StatusNotFound),
+		Throttled: func(err *Error) bool { return err.Code == "RequestLimitExceeded" },
+		Transient: func(err *Error) bool { return err.Code == "ResourceBusy" },
+	}
+	testcases := []struct {
+		name     string
+		err      *Error
+		expected forgeerrors.Category
+	}{
+		{
+			name:     "handled",
+			err:      &Error{StatusCode: http.StatusNotFound},
+			expected: forgeerrors.CategoryUnknown,
+		},
+		{
+			name:     "too many requests",
+			err:      &Error{StatusCode: http.StatusTooManyRequests},
+			expected: forgeerrors.CategoryThrottled,
+		},
+		{
+			name:     "throttled",
+			err:      &Error{StatusCode: http.StatusServiceUnavailable, Code: "RequestLimitExceeded"},
+			expected: forgeerrors.CategoryThrottled,
+		},
+		{
+			name:     "server error",
+			err:      &Error{StatusCode: http.StatusInternalServerError},
+			expected: forgeerrors.CategoryTransient,
+		},
+		{
+			name:     "transient",
+			err:      &Error{StatusCode: http.StatusBadRequest, Code: "ResourceBusy"},
+			expected: forgeerrors.CategoryTransient,
+		},
+		{
+			name:     "config error",
+			err:      &Error{StatusCode: http.StatusForbidden},
+			expected: forgeerrors.CategoryConfigError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := classifier.Classify(tc.err)
+			g.Expect(forgeerrors.Classify(err)).To(Equal(tc.expected))
+			var apiErr *Error
+			g.Expect(errors.As(err, &apiErr)).To(BeTrue())
+			g.Expect(apiErr).To(Equal(tc.err))
+		})
+	}
+
+	// Without errors handled by the callers, the missing resources are configuration errors.
+	NewWithT(t).Expect(forgeerrors.Classify(Classify(&Error{StatusCode: http.StatusNotFound}))).To(Equal(forgeerrors.CategoryConfigError))
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package providersdk is the SDK of the infrastructure providers, in-tree and out-of-tree. It holds the
+// interfaces, helpers and test scaffolding implementing the infrastructure provider contract read by the Build
+// controller:
+//
+//   - the infrastructure build is owned by its Build, and enqueued when the Build changes;
+//   - the public key of the connector credentials of the Build is authorized on the machine through its user data
+//     or metadata, the credentials being generated for the Build alone when its connector has ephemeralCredentials;
+//   - the machine is created from the source image resolved from the spec.from of the Build, if any, and the image
+//     it has been created from is reported in status.sourceImageRef;
+//   - the machine is reported in status.machineReady once its address is in the connector credentials of the
+//     Build;
+//   - the image is reported in status.ready, status.imageRef and status.artifact once it has been created after
+//     the provisioners;
+//   - the replication of the image to the targets of the spec.artifact.replication of the Build is reported in
+//     status.replications before status.ready, a failed target does not fail the Build;
+//   - the terminal failures are reported in status.failureReason and status.failureMessage, which fail the Build;
+//   - the preemption of a spot machine is reported with the Preempted failure reason, the Build recreates the
+//     infrastructure build, without its spec.spot to fall back to an on-demand machine;
+//   - the infrastructure build holds a finalizer while it has resources to clean up.
+package providersdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// maxNameLength is the maximum length of the names of the machines, the names are RFC 1035 labels.
+const maxNameLength = 63
+
+// GetOwnerBuild returns the Build owning the infrastructure build, or nil if the Build controller has not set
+// itself as the owner yet.
+func GetOwnerBuild(ctx context.Context, c client.Client, obj metav1.Object) (*buildv1.Build, error) {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind != "Build" || !ptr.Deref(ref.Controller, false) {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != buildv1.GroupVersion.Group {
+			continue
+		}
+		build := &buildv1.Build{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: ref.Name}, build); err != nil {
+			return nil, errors.Wrapf(err, "failed to get Build %s", ref.Name)
+		}
+		return build, nil
+	}
+	return nil, nil
+}
+
+// BuildToInfrastructure returns a handler.MapFunc enqueuing the infrastructure build of the given kind referenced
+// by a Build, so the infrastructure build is reconciled when the provisioners of the Build are ready.
+func BuildToInfrastructure(gvk schema.GroupVersionKind) handler.MapFunc {
+	return func(_ context.Context, o client.Object) []ctrl.Request {
+		build, ok := o.(*buildv1.Build)
+		if !ok || build.Spec.InfrastructureRef == nil {
+			return nil
+		}
+		ref := build.Spec.InfrastructureRef
+		if ref.Kind != gvk.Kind || ref.GroupVersionKind().Group != gvk.Group {
+			return nil
+		}
+		return []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: build.Namespace, Name: ref.Name}}}
+	}
+}
+
+// ProviderCredentials returns the secret holding the credentials of the provider API: the secret referenced by
+// the infrastructure build if any, the secret of the ProviderIdentity of the Build otherwise. The credentials of a
+// ProviderIdentity with a credentialsProviderRef are resolved as a Secret which is not stored.
+func ProviderCredentials(ctx context.Context, c client.Client, ref *corev1.LocalObjectReference, build *buildv1.Build) (*corev1.Secret, error) {
+	name := ""
+	switch {
+	case ref != nil:
+		name = ref.Name
+	case build.Spec.IdentityRef != nil:
+		identity := &buildv1.ProviderIdentity{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.IdentityRef.Name}, identity); err != nil {
+			return nil, errors.Wrapf(err, "failed to get ProviderIdentity %s", build.Spec.IdentityRef.Name)
+		}
+		if ref := identity.Spec.CredentialsProviderRef; ref != nil {
+			return credentialsprovider.Secret(ctx, c, build.Namespace, ref)
+		}
+		name = identity.Spec.SecretRef.Name
+	default:
+		return nil, forgeerrors.ConfigErrorf("no credentials, neither the infrastructure build has a credentialsRef nor Build %s an identityRef", build.Name)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get credentials secret %s", name)
+	}
+	return secret, nil
+}
+
+// SourceImage returns the image the machine of the Build is created from: the source image resolved from the
+// spec.from of the Build if any, source otherwise. It returns false while the Build controller has not resolved
+// spec.from yet, the machine must not be created until then.
+func SourceImage(build *buildv1.Build, source string) (string, bool) {
+	if build.Spec.From == nil {
+		return source, true
+	}
+	if build.Status.Cache == nil || build.Status.Cache.SourceImageRef == "" {
+		return "", false
+	}
+	return build.Status.Cache.SourceImageRef, true
+}
+
+// SetFailure records the terminal error in the status of the infrastructure build, the Build controller fails the
+// Build with the same reason.
+func SetFailure(status *infrav1.BuildStatus, err error) {
+	status.FailureReason = ptr.To(forgeerrors.ReasonFor(err))
+	status.FailureMessage = ptr.To(err.Error())
+}
+
+// MachineName returns the name of the machine of the infrastructure build, a RFC 1035 label unique to the object:
+// its name, sanitized, suffixed with a hash of its UID.
+func MachineName(obj metav1.Object) string {
+	sum := sha256.Sum256([]byte(obj.GetUID()))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return SanitizeName(obj.GetName(), maxNameLength-len(suffix)) + suffix
+}
+
+// SanitizeName returns s as a RFC 1035 label of at most max characters: lower case letters, digits and dashes,
+// starting with a letter.
+func SanitizeName(s string, max int) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, s)
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "forge-" + name
+	}
+	if len(name) > max {
+		name = name[:max]
+	}
+	return strings.TrimRight(name, "-")
+}
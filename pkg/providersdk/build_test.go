@@ -0,0 +1,261 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providersdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+func TestSanitizeName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		max      int
+		expected string
+	}{
+		{name: "ubuntu-22.04", max: 63, expected: "ubuntu-22-04"},
+		{name: "Ubuntu_Base", max: 63, expected: "ubuntu-base"},
+		{name: "2024-base", max: 63, expected: "forge-2024-base"},
+		{name: "", max: 63, expected: "forge"},
+		{name: "base-image-", max: 63, expected: "base-image"},
+		{name: "base-image", max: 5, expected: "base"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(SanitizeName(tc.name, tc.max)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestMachineName(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &metav1.ObjectMeta{Name: "ubuntu.base", UID: "3f6f3c3e-0b5c-4c1b-9a57-4b1f1f0d4c7e"}
+	name := MachineName(obj)
+	g.Expect(name).To(MatchRegexp(`^ubuntu-base-[0-9a-f]{8}$`))
+
+	other := &metav1.ObjectMeta{Name: "ubuntu.base", UID: "a4c9b8e2-5f0d-4c8e-8d55-3c4a4e5f6a7b"}
+	g.Expect(MachineName(other)).ToNot(Equal(name))
+
+	long := &metav1.ObjectMeta{Name: strings.Repeat("a", 80), UID: "3f6f3c3e-0b5c-4c1b-9a57-4b1f1f0d4c7e"}
+	g.Expect(MachineName(long)).To(HaveLen(63))
+}
+
+func TestGetOwnerBuild(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu"}}
+	c := fake.NewClientBuilder().WithScheme(newScheme(g)).WithObjects(build).Build()
+
+	infra := &infrav1.GCPBuild{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu"}}
+	owner, err := GetOwnerBuild(ctx, c, infra)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(owner).To(BeNil())
+
+	infra.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: buildv1.GroupVersion.String(),
+		Kind:       "Build",
+		Name:       "ubuntu",
+		Controller: ptr.To(true),
+	}}
+	owner, err = GetOwnerBuild(ctx, c, infra)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(owner.Name).To(Equal("ubuntu"))
+}
+
+func TestBuildToInfrastructure(t *testing.T) {
+	g := NewWithT(t)
+
+	mapFunc := BuildToInfrastructure(infrav1.GroupVersion.WithKind("GCPBuild"))
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "GCPBuild",
+				Name:       "ubuntu-gcp",
+			},
+		},
+	}
+	requests := mapFunc(context.Background(), build)
+	g.Expect(requests).To(HaveLen(1))
+	g.Expect(requests[0].NamespacedName).To(Equal(client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: "ubuntu-gcp"}))
+
+	build.Spec.InfrastructureRef.Kind = "DockerBuild"
+	g.Expect(mapFunc(context.Background(), build)).To(BeEmpty())
+}
+
+func TestEnsureCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "uid"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: "ubuntu-credentials"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(newScheme(g)).WithObjects(build).Build()
+
+	creds, err := EnsureCredentials(ctx, c, build, "forge")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(creds.Username).To(Equal("forge"))
+	g.Expect(creds.AuthorizedKey).To(HavePrefix("ssh-rsa "))
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: "ubuntu-credentials"}, secret)).To(Succeed())
+	g.Expect(secret.Data).To(HaveKey(PrivateKeyKey))
+	g.Expect(secret.Labels).To(HaveKeyWithValue(buildv1.BuildNameLabel, "ubuntu"))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+
+	// The existing secret is reused.
+	again, err := EnsureCredentials(ctx, c, build, "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(again.Username).To(Equal("forge"))
+	g.Expect(again.AuthorizedKey).To(Equal(creds.AuthorizedKey))
+
+	g.Expect(PublishAddress(ctx, c, build, "10.0.0.2")).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+	g.Expect(string(secret.Data[HostKey])).To(Equal("10.0.0.2"))
+
+	secret.Data = map[string][]byte{UsernameKey: []byte("forge")}
+	g.Expect(c.Update(ctx, secret)).To(Succeed())
+	_, err = EnsureCredentials(ctx, c, build, "forge")
+	g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+}
+
+func TestEnsureEphemeralCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu", UID: "uid"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{
+				Credentials:          &corev1.LocalObjectReference{Name: "ubuntu-ssh-credentials"},
+				EphemeralCredentials: true,
+			},
+		},
+	}
+	keyPair, err := ssh.NewKeyPair()
+	g.Expect(err).ToNot(HaveOccurred())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: metav1.NamespaceDefault,
+			Name:      "ubuntu-ssh-credentials",
+			Labels:    map[string]string{buildv1.BuildNameLabel: "ubuntu", buildv1.EphemeralCredentialsLabel: "true"},
+		},
+		Data: map[string][]byte{PrivateKeyKey: keyPair.PrivateKey},
+	}
+	c := fake.NewClientBuilder().WithScheme(newScheme(g)).WithObjects(build, secret).Build()
+
+	// The ephemeral credentials generated by the Build controller are completed with the user of the provider.
+	creds, err := EnsureCredentials(ctx, c, build, "ec2-user")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(creds.Username).To(Equal("ec2-user"))
+	g.Expect(creds.AuthorizedKey).To(Equal(string(keyPair.PublicKey)))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+	g.Expect(string(secret.Data[UsernameKey])).To(Equal("ec2-user"))
+}
+
+func TestCloudConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	userdata, err := CloudConfig(&Credentials{Username: "forge", AuthorizedKey: "ssh-ed25519 AAAA\n"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(userdata).To(HavePrefix("#cloud-config\n"))
+	g.Expect(userdata).To(ContainSubstring("- ssh-ed25519 AAAA\n"))
+	g.Expect(userdata).To(ContainSubstring("lock_passwd: true"))
+	g.Expect(userdata).ToNot(ContainSubstring("ssh_pwauth"))
+
+	// The password is authorized when there is no key.
+	userdata, err = CloudConfig(&Credentials{Username: "forge", Password: "secret"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(userdata).To(ContainSubstring("plain_text_passwd: secret"))
+	g.Expect(userdata).To(ContainSubstring("ssh_pwauth: true"))
+}
+
+func TestProviderCredentials(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	objs := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "gcp"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "identity"}},
+		&buildv1.ProviderIdentity{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "gcp"},
+			Spec:       buildv1.ProviderIdentitySpec{Provider: "gcp", SecretRef: corev1.LocalObjectReference{Name: "identity"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(newScheme(g)).WithObjects(objs...).Build()
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "ubuntu"}}
+
+	_, err := ProviderCredentials(ctx, c, nil, build)
+	g.Expect(forgeerrors.IsTerminal(err)).To(BeTrue())
+
+	secret, err := ProviderCredentials(ctx, c, &corev1.LocalObjectReference{Name: "gcp"}, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(secret.Name).To(Equal("gcp"))
+
+	build.Spec.IdentityRef = &corev1.LocalObjectReference{Name: "gcp"}
+	secret, err = ProviderCredentials(ctx, c, nil, build)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(secret.Name).To(Equal("identity"))
+}
+
+func newScheme(g *WithT) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+func TestSourceImage(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{}
+	source, ok := SourceImage(build, "ami-0")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(source).To(Equal("ami-0"))
+
+	// The machine waits for the Build controller to resolve the source image of spec.from.
+	build.Spec.From = &buildv1.BuildSource{BuildRef: &corev1.LocalObjectReference{Name: "ubuntu"}}
+	_, ok = SourceImage(build, "ami-0")
+	g.Expect(ok).To(BeFalse())
+
+	build.Status.Cache = &buildv1.CacheStatus{SourceImageRef: "ami-1"}
+	source, ok = SourceImage(build, "ami-0")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(source).To(Equal("ami-1"))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providersdk
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// InfrastructureBuild is an infrastructure build: an object with conditions, whose status embeds the BuildStatus
+// read by the Build controller.
+type InfrastructureBuild interface {
+	conditions.Setter
+
+	// GetBuildStatus returns the part of the status read by the Build controller.
+	GetBuildStatus() *infrav1.BuildStatus
+}
+
+// SetSummary sets the Ready condition of the infrastructure build, summarizing its MachineReady and ImageReady
+// conditions. It is mirrored by the InfrastructureReady condition of the Build.
+func SetSummary(obj InfrastructureBuild) {
+	conditions.SetSummary(obj, infrav1.ReadyCondition, infrav1.ImageAvailableReason,
+		infrav1.MachineReadyCondition, infrav1.ImageReadyCondition)
+}
+
+// MarkMachineReady reports the machine ready, once its address has been published with PublishAddress: the Build
+// controller connects to it and runs the provisioners.
+func MarkMachineReady(obj InfrastructureBuild, messageFormat string, messageArgs ...interface{}) {
+	obj.GetBuildStatus().MachineReady = true
+	conditions.MarkTrue(obj, infrav1.MachineReadyCondition, infrav1.MachineRunningReason, messageFormat, messageArgs...)
+}
+
+// MarkImageReady reports the image created from the machine after the provisioners: the Build controller copies
+// the reference and the artifact into the Build, and completes it. The artifact is optional, the Build controller
+// reports the reference as its ID otherwise.
+func MarkImageReady(obj InfrastructureBuild, imageRef string, artifact *buildv1.BuildArtifact, messageFormat string, messageArgs ...interface{}) {
+	status := obj.GetBuildStatus()
+	status.ImageRef = imageRef
+	status.Artifact = artifact
+	status.Ready = true
+	conditions.MarkTrue(obj, infrav1.ImageReadyCondition, infrav1.ImageAvailableReason, messageFormat, messageArgs...)
+}
+
+// ReplicationFor returns the status of the replication of the image to the target of the spec.artifact.replication
+// of the Build, added in the Pending phase to the status of the infrastructure build if missing. The replications
+// are reported before the image, a target is either replicated or failed once the image is reported ready.
+func ReplicationFor(obj InfrastructureBuild, target buildv1.ReplicationStatus) *buildv1.ReplicationStatus {
+	status := obj.GetBuildStatus()
+	for i := range status.Replications {
+		if status.Replications[i].Type == target.Type && status.Replications[i].Name == target.Name {
+			return &status.Replications[i]
+		}
+	}
+	status.Replications = append(status.Replications, buildv1.ReplicationStatus{
+		Type:  target.Type,
+		Name:  target.Name,
+		Phase: buildv1.ReplicationPhasePending,
+	})
+	return &status.Replications[len(status.Replications)-1]
+}
+
+// MarkFailed records the terminal error in the status of the infrastructure build: the Build controller fails the
+// Build with the same reason, and the infrastructure build is no longer reconciled but for its deletion.
+func MarkFailed(obj InfrastructureBuild, err error) {
+	SetFailure(obj.GetBuildStatus(), err)
+	conditions.MarkFalse(obj, infrav1.MachineReadyCondition, infrav1.ProvisioningFailedReason, "%s", err.Error())
+}
+
+// HandleReconcileError returns the result of a reconciliation of the infrastructure build ending with err, as
+// classified by the errors package: the retryable errors are requeued after their delay, and the terminal errors
+// fail the infrastructure build with a Warning event. The other errors are returned, to be retried with backoff.
+func HandleReconcileError(ctx context.Context, recorder record.EventRecorder, obj InfrastructureBuild, res ctrl.Result, err error) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	kind := kindOf(obj)
+	switch {
+	case forgeerrors.IsRetryable(err):
+		log.Info(kind+" hit a retryable error, requeuing", "reason", err.Error())
+		return ctrl.Result{RequeueAfter: forgeerrors.RequeueAfter(err)}, nil
+	case forgeerrors.IsTerminal(err):
+		log.Error(err, kind+" failed")
+		MarkFailed(obj, err)
+		recorder.Eventf(obj, corev1.EventTypeWarning, infrav1.ProvisioningFailedReason, "%s failed: %v", kind, err)
+		return ctrl.Result{}, nil
+	}
+	return res, err
+}
+
+// kindOf returns the kind of the object, the name of its type when its TypeMeta is not set.
+func kindOf(obj InfrastructureBuild) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providersdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+var (
+	_ InfrastructureBuild = &infrav1.AWSBuild{}
+	_ InfrastructureBuild = &infrav1.AzureBuild{}
+	_ InfrastructureBuild = &infrav1.DockerBuild{}
+	_ InfrastructureBuild = &infrav1.GCPBuild{}
+	_ InfrastructureBuild = &infrav1.KubeVirtBuild{}
+	_ InfrastructureBuild = &infrav1.OpenStackBuild{}
+	_ InfrastructureBuild = &infrav1.ProxmoxBuild{}
+	_ InfrastructureBuild = &infrav1.StaticBuild{}
+	_ InfrastructureBuild = &infrav1.VSphereBuild{}
+)
+
+func TestMarkReady(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &infrav1.StaticBuild{}
+	MarkMachineReady(obj, "Machine %s is ready", "builder")
+	g.Expect(obj.Status.MachineReady).To(BeTrue())
+	g.Expect(conditions.IsTrue(obj, infrav1.MachineReadyCondition)).To(BeTrue())
+	SetSummary(obj)
+	g.Expect(conditions.IsTrue(obj, infrav1.ReadyCondition)).To(BeFalse())
+
+	MarkImageReady(obj, "image", &buildv1.BuildArtifact{ID: "image-id"}, "Image %s is available", "image")
+	g.Expect(obj.Status.Ready).To(BeTrue())
+	g.Expect(obj.Status.ImageRef).To(Equal("image"))
+	g.Expect(obj.Status.Artifact.ID).To(Equal("image-id"))
+	SetSummary(obj)
+	g.Expect(conditions.IsTrue(obj, infrav1.ReadyCondition)).To(BeTrue())
+}
+
+func TestHandleReconcileError(t *testing.T) {
+	testcases := []struct {
+		name          string
+		err           error
+		expectedRes   ctrl.Result
+		expectedErr   bool
+		expectedEvent bool
+	}{
+		{name: "success", expectedRes: ctrl.Result{RequeueAfter: time.Minute}},
+		{name: "retryable", err: forgeerrors.NewTransientAfter(errors.New("quota"), time.Second), expectedRes: ctrl.Result{RequeueAfter: time.Second}},
+		{name: "terminal", err: forgeerrors.ConfigErrorf("no such image"), expectedEvent: true},
+		{name: "unknown", err: errors.New("boom"), expectedRes: ctrl.Result{RequeueAfter: time.Minute}, expectedErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &infrav1.StaticBuild{}
+			recorder := record.NewFakeRecorder(1)
+			res, err := HandleReconcileError(context.Background(), recorder, obj, ctrl.Result{RequeueAfter: time.Minute}, tc.err)
+			g.Expect(res).To(Equal(tc.expectedRes))
+			g.Expect(err != nil).To(Equal(tc.expectedErr))
+			if !tc.expectedEvent {
+				g.Expect(obj.Status.FailureReason).To(BeNil())
+				g.Expect(recorder.Events).To(BeEmpty())
+				return
+			}
+			g.Expect(obj.Status.FailureReason).To(HaveValue(Equal(forgeerrors.InvalidConfigurationBuildError)))
+			g.Expect(conditions.GetReason(obj, infrav1.MachineReadyCondition)).To(Equal(infrav1.ProvisioningFailedReason))
+			g.Expect(<-recorder.Events).To(Equal("Warning ProvisioningFailed StaticBuild failed: no such image"))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providersdk
+
+import (
+	"context"
+
+	"strings"
+
+	"github.com/pkg/errors"
+	cssh "golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// The keys of the connector credentials secrets.
+const (
+	HostKey       = "host"
+	UsernameKey   = "username"
+	PasswordKey   = "password"
+	PrivateKeyKey = "privateKey"
+)
+
+// Credentials are the connector credentials of a Build, the provider authorizes them on the machine.
+type Credentials struct {
+	// Username is the user the connector connects as.
+	Username string
+	// AuthorizedKey is the public key of the connector in the authorized_keys format, it is empty when the
+	// connector authenticates with a password.
+	AuthorizedKey string
+	// Password is the password of the connector, if any.
+	Password string
+}
+
+// EnsureCredentials returns the connector credentials of the Build. The credentials secret is created, owned by
+// the Build, with a new key pair for username when it does not exist; the username of an existing secret
+// takes precedence. The ephemeral credentials generated by the Build controller without a username are completed
+// with username, the user the key is authorized for. The password and private key resolved by the
+// credentialsProviderRef of the connector, if any, take precedence over the secret.
+func EnsureCredentials(ctx context.Context, c client.Client, build *buildv1.Build, username string) (*Credentials, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.ConfigErrorf("the connector of Build %s has no credentials secret", build.Name)
+	}
+	name := build.Spec.Connector.Credentials.Name
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: build.Namespace,
+				Name:      name,
+				Labels:    map[string]string{buildv1.BuildNameLabel: build.Name},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{UsernameKey: []byte(username)},
+		}
+		// The key of credentials resolved by a CredentialsProvider is never stored.
+		if build.Spec.Connector.CredentialsProviderRef == nil {
+			keyPair, err := ssh.NewKeyPair()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to generate the connector key pair")
+			}
+			secret.Data[PrivateKeyKey] = keyPair.PrivateKey
+		}
+		if err := controllerutil.SetControllerReference(build, secret, c.Scheme()); err != nil {
+			return nil, err
+		}
+		if err := c.Create(ctx, secret); err != nil {
+			return nil, errors.Wrapf(err, "failed to create the connector credentials secret %s", name)
+		}
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to get the connector credentials secret %s", name)
+	case secret.Labels[buildv1.EphemeralCredentialsLabel] == "true" && len(secret.Data[UsernameKey]) == 0:
+		patch := client.MergeFrom(secret.DeepCopy())
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[UsernameKey] = []byte(username)
+		if err := c.Patch(ctx, secret, patch); err != nil {
+			return nil, errors.Wrapf(err, "failed to set the username of the connector credentials secret %s", name)
+		}
+	}
+
+	// The secret must not be patched afterwards, it holds the resolved credentials.
+	if err := credentialsprovider.Overlay(ctx, c, build.Spec.Connector.CredentialsProviderRef, secret); err != nil {
+		return nil, errors.Wrap(err, "failed to resolve the connector credentials")
+	}
+	creds := &Credentials{Username: string(secret.Data[UsernameKey]), Password: string(secret.Data[PasswordKey])}
+	if creds.Username == "" {
+		creds.Username = username
+	}
+	if privateKey := secret.Data[PrivateKeyKey]; len(privateKey) > 0 {
+		signer, err := cssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, forgeerrors.ConfigErrorf("invalid private key in the connector credentials secret %s: %v", name, err)
+		}
+		creds.AuthorizedKey = string(cssh.MarshalAuthorizedKey(signer.PublicKey()))
+	}
+	if creds.AuthorizedKey == "" && creds.Password == "" {
+		return nil, forgeerrors.ConfigErrorf("the connector credentials secret %s has neither a privateKey nor a password", name)
+	}
+	return creds, nil
+}
+
+// PublishAddress sets the address of the machine in the connector credentials secret of the Build.
+func PublishAddress(ctx context.Context, c client.Client, build *buildv1.Build, address string) error {
+	if build.Spec.Connector.Credentials == nil {
+		return forgeerrors.ConfigErrorf("the connector of Build %s has no credentials secret", build.Name)
+	}
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.Connector.Credentials.Name}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return errors.Wrapf(err, "failed to get the connector credentials secret %s", key.Name)
+	}
+	if string(secret.Data[HostKey]) == address {
+		return nil
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[HostKey] = []byte(address)
+	if err := c.Patch(ctx, secret, patch); err != nil {
+		return errors.Wrapf(err, "failed to publish the address of the machine in secret %s", key.Name)
+	}
+	return nil
+}
+
+// cloudConfigUser is a user of a cloud-config.
+type cloudConfigUser struct {
+	Name              string   `json:"name"`
+	Sudo              string   `json:"sudo"`
+	Shell             string   `json:"shell"`
+	LockPasswd        bool     `json:"lock_passwd"`
+	PlainTextPasswd   string   `json:"plain_text_passwd,omitempty"`
+	SSHAuthorizedKeys []string `json:"ssh_authorized_keys,omitempty"`
+}
+
+// CloudConfig returns the cloud-init user data creating the connector user with its key, or its password when it
+// has no key.
+func CloudConfig(creds *Credentials) (string, error) {
+	user := cloudConfigUser{Name: creds.Username, Sudo: "ALL=(ALL) NOPASSWD:ALL", Shell: "/bin/bash", LockPasswd: true}
+	config := map[string]interface{}{}
+	if creds.AuthorizedKey != "" {
+		user.SSHAuthorizedKeys = []string{strings.TrimSpace(creds.AuthorizedKey)}
+	} else {
+		user.LockPasswd = false
+		user.PlainTextPasswd = creds.Password
+		config["ssh_pwauth"] = true
+	}
+	config["users"] = []cloudConfigUser{user}
+	userdata, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return "#cloud-config\n" + string(userdata), nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package providertest is the test scaffolding of the infrastructure providers: it sets up a Build owning an
+// infrastructure build in a fake client, drives the Build as the Build controller does, and reads the
+// infrastructure build as the Build controller reads it.
+package providertest
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/internal/external"
+	"github.com/forge-build/forge/pkg/providersdk"
+)
+
+// Fixture is a Build owning an infrastructure build in a fake client.
+type Fixture struct {
+	Client client.Client
+	Build  *buildv1.Build
+}
+
+// NewFixture returns a Build named after the infrastructure build obj, referencing it, with the connector
+// credentials secret credentials, and sets the Build as the controller owner of obj as the Build controller
+// does. The scheme must hold the core types, the Builds and the type of obj; the status of the Build and of obj
+// are subresources of the fake client, as in the cluster.
+func NewFixture(scheme *runtime.Scheme, obj providersdk.InfrastructureBuild, credentials string, objs ...client.Object) (*Fixture, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: obj.GetNamespace(), Name: obj.GetName(), UID: obj.GetUID() + "-build"},
+		Spec: buildv1.BuildSpec{
+			Connector: buildv1.ConnectorSpec{Credentials: &corev1.LocalObjectReference{Name: credentials}},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       obj.GetName(),
+			},
+		},
+	}
+	obj.SetOwnerReferences(append(obj.GetOwnerReferences(), metav1.OwnerReference{
+		APIVersion: buildv1.GroupVersion.String(),
+		Kind:       "Build",
+		Name:       build.Name,
+		UID:        build.UID,
+		Controller: ptr.To(true),
+	}))
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[buildv1.BuildNameLabel] = build.Name
+	obj.SetLabels(labels)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(append(objs, build, obj)...).
+		WithStatusSubresource(build, obj).
+		Build()
+	return &Fixture{Client: c, Build: build}, nil
+}
+
+// SetProvisionersReady reports the provisioners of the Build ready, as the Build controller does once they
+// completed: the infrastructure build is expected to create the image.
+func (f *Fixture) SetProvisionersReady(ctx context.Context, metadata map[string]string) error {
+	if err := f.Client.Get(ctx, client.ObjectKeyFromObject(f.Build), f.Build); err != nil {
+		return err
+	}
+	f.Build.Status.ProvisionersReady = true
+	f.Build.Status.ImageMetadata = metadata
+	return f.Client.Status().Update(ctx, f.Build)
+}
+
+// ConnectorAddress returns the address of the machine published in the connector credentials secret of the
+// Build, empty if not published yet.
+func (f *Fixture) ConnectorAddress(ctx context.Context) (string, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: f.Build.Namespace, Name: f.Build.Spec.Connector.Credentials.Name}
+	if err := f.Client.Get(ctx, key, secret); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return string(secret.Data[providersdk.HostKey]), nil
+}
+
+// Contract is the status of an infrastructure build as read by the Build controller.
+type Contract struct {
+	MachineReady   bool
+	Ready          bool
+	ImageRef       string
+	Artifact       *buildv1.BuildArtifact
+	FailureReason  string
+	FailureMessage string
+	Finalizers     []string
+}
+
+// ReadContract gets the infrastructure build obj and returns its status as read by the Build controller, or an
+// error if the Build controller cannot read it, e.g. an artifact without ID.
+func (f *Fixture) ReadContract(ctx context.Context, obj providersdk.InfrastructureBuild) (*Contract, error) {
+	if err := f.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return nil, err
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: data}
+
+	contract := &Contract{Finalizers: u.GetFinalizers()}
+	if contract.MachineReady, err = external.IsMachineReady(u); err != nil {
+		return nil, err
+	}
+	if contract.Ready, err = external.IsReady(u); err != nil {
+		return nil, err
+	}
+	if contract.ImageRef, err = external.ImageRefFrom(u); err != nil {
+		return nil, err
+	}
+	if contract.Artifact, err = external.ArtifactFrom(u); err != nil {
+		return nil, err
+	}
+	if contract.FailureReason, contract.FailureMessage, err = external.FailuresFrom(u); err != nil {
+		return nil, err
+	}
+	// The Build controller does not read status.ready until status.machineReady is true.
+	if contract.Ready && !contract.MachineReady {
+		return nil, errors.New("status.ready is true but status.machineReady is not")
+	}
+	if contract.FailureReason != "" && contract.FailureMessage == "" {
+		return nil, errors.New("status.failureReason is set without status.failureMessage")
+	}
+	return contract, nil
+}
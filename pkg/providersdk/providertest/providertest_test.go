@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providertest
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/forge-build/forge/api/infrastructure/v1alpha1"
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/providersdk"
+)
+
+func TestFixture(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	staticBuild := &infrav1.StaticBuild{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "builder", UID: "uid"},
+		Spec:       infrav1.StaticBuildSpec{Address: "192.0.2.7"},
+	}
+	f, err := NewFixture(scheme, staticBuild, "builder-credentials")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(f.Build.Spec.InfrastructureRef.Kind).To(Equal("StaticBuild"))
+
+	// The infrastructure build is owned by the Build.
+	build, err := providersdk.GetOwnerBuild(ctx, f.Client, staticBuild)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(build.Name).To(Equal("builder"))
+	address, err := f.ConnectorAddress(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(address).To(BeEmpty())
+
+	g.Expect(f.SetProvisionersReady(ctx, map[string]string{"os": "linux"})).To(Succeed())
+	g.Expect(f.Client.Get(ctx, client.ObjectKeyFromObject(build), build)).To(Succeed())
+	g.Expect(build.Status.ProvisionersReady).To(BeTrue())
+
+	// The contract is read as the Build controller reads it.
+	contract, err := f.ReadContract(ctx, staticBuild)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(contract.MachineReady).To(BeFalse())
+	providersdk.MarkMachineReady(staticBuild, "ready")
+	providersdk.MarkImageReady(staticBuild, "image", &buildv1.BuildArtifact{ID: "image"}, "ready")
+	g.Expect(f.Client.Status().Update(ctx, staticBuild)).To(Succeed())
+	contract, err = f.ReadContract(ctx, staticBuild)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(contract.MachineReady).To(BeTrue())
+	g.Expect(contract.Ready).To(BeTrue())
+	g.Expect(contract.Artifact.ID).To(Equal("image"))
+
+	// The Build controller does not read the image of a machine which is not ready.
+	staticBuild.Status.MachineReady = false
+	g.Expect(f.Client.Status().Update(ctx, staticBuild)).To(Succeed())
+	_, err = f.ReadContract(ctx, staticBuild)
+	g.Expect(err).To(MatchError(ContainSubstring("status.machineReady")))
+}
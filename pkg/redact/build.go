@@ -0,0 +1,153 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redact
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// ForBuild returns the Redactor of the secrets of the Build: the password and private key of its connector and of its
+// bastion, resolved by the CredentialsProvider of the connector if any, and its variables sourced from a Secret.
+// The secrets which do not exist hold nothing to mask, nor do the credentials the CredentialsProvider fails to
+// resolve for good.
+func ForBuild(ctx context.Context, c client.Client, build *buildv1.Build) (*Redactor, error) {
+	connector := &build.Spec.Connector
+	var values []string
+	if connector.Credentials != nil {
+		secret, err := getSecret(ctx, c, build.Namespace, connector.Credentials.Name)
+		if err != nil {
+			return nil, err
+		}
+		if secret == nil && connector.CredentialsProviderRef != nil {
+			secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace}}
+		}
+		if secret != nil {
+			// The credentials are resolved on every connection, they are masked as long as they are valid.
+			if err := credentialsprovider.Overlay(ctx, c, connector.CredentialsProviderRef, secret); forgeerrors.IsRetryable(err) {
+				return nil, err
+			}
+			values = append(values, CredentialsValues(secret)...)
+		}
+	}
+	if connector.Bastion != nil && connector.Bastion.Credentials.Name != "" {
+		secret, err := getSecret(ctx, c, build.Namespace, connector.Bastion.Credentials.Name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, CredentialsValues(secret)...)
+	}
+	if name := build.Status.VariablesSecretName; name != "" {
+		secret, err := getSecret(ctx, c, build.Namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, MaskedVariables(secret)...)
+	}
+	return New(values...), nil
+}
+
+// getSecret returns the secret, nil if it does not exist.
+func getSecret(ctx context.Context, c client.Client, namespace, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get secret %s", name))
+	}
+	return secret, nil
+}
+
+// Build masks the values in the messages of the Build: its failure messages, the messages of its conditions and
+// the messages and logs of its provisioners.
+func (r *Redactor) Build(build *buildv1.Build) {
+	if r.Empty() {
+		return
+	}
+	redactPtr := func(s *string) {
+		if s != nil {
+			*s = r.String(*s)
+		}
+	}
+
+	for i := range build.Spec.Provisioners {
+		p := &build.Spec.Provisioners[i]
+		redactPtr(p.FailureMessage)
+		for j := range p.Issues {
+			p.Issues[j] = r.String(p.Issues[j])
+		}
+	}
+
+	status := &build.Status
+	redactPtr(status.FailureMessage)
+	for i := range status.Conditions {
+		status.Conditions[i].Message = r.String(status.Conditions[i].Message)
+	}
+	for i := range status.FailedAttempts {
+		redactPtr(status.FailedAttempts[i].FailureMessage)
+	}
+	if status.Progress != nil {
+		status.Progress.Message = r.String(status.Progress.Message)
+	}
+	for i := range status.Provisioners {
+		p := &status.Provisioners[i]
+		p.Message = r.String(p.Message)
+		p.Logs = r.String(p.Logs)
+		for j := range p.Steps {
+			p.Steps[j].Message = r.String(p.Steps[j].Message)
+		}
+		for j := range p.Plays {
+			p.Plays[j].Message = r.String(p.Plays[j].Message)
+		}
+		if p.Report != nil {
+			for j := range p.Report.Failures {
+				p.Report.Failures[j].Message = r.String(p.Report.Failures[j].Message)
+			}
+		}
+		if p.Compliance != nil {
+			for j := range p.Compliance.Rules {
+				p.Compliance.Rules[j].Message = r.String(p.Compliance.Rules[j].Message)
+			}
+		}
+	}
+}
+
+// redactorKey is the key of the Redactor in a context.
+type redactorKey struct{}
+
+// IntoContext returns a context holding the Redactor.
+func IntoContext(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// FromContext returns the Redactor of the context, a Redactor masking nothing if it has none.
+func FromContext(ctx context.Context) *Redactor {
+	if r, ok := ctx.Value(redactorKey{}).(*Redactor); ok {
+		return r
+	}
+	return &Redactor{}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redact
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+)
+
+// Logger returns a logger masking the values in the messages, the errors and the string values of the log lines
+// of logger.
+func (r *Redactor) Logger(logger logr.Logger) logr.Logger {
+	if r.Empty() || logger.GetSink() == nil {
+		return logger
+	}
+	return logger.WithSink(&sink{redactor: r, sink: logger.GetSink()})
+}
+
+// sink is a logr.LogSink masking the values in what it logs.
+type sink struct {
+	redactor *Redactor
+	sink     logr.LogSink
+}
+
+var _ logr.CallDepthLogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	// The sink adds a frame to the stack of the callers.
+	info.CallDepth++
+	s.sink.Init(info)
+}
+
+func (s *sink) Enabled(level int) bool {
+	return s.sink.Enabled(level)
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.sink.Info(level, s.redactor.String(msg), s.values(keysAndValues)...)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(s.redactor.Error(err), s.redactor.String(msg), s.values(keysAndValues)...)
+}
+
+func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &sink{redactor: s.redactor, sink: s.sink.WithValues(s.values(keysAndValues)...)}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	return &sink{redactor: s.redactor, sink: s.sink.WithName(name)}
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	if withCallDepth, ok := s.sink.(logr.CallDepthLogSink); ok {
+		return &sink{redactor: s.redactor, sink: withCallDepth.WithCallDepth(depth)}
+	}
+	return s
+}
+
+// values returns the key and values with the values masked in the strings, the errors and the stringers.
+func (s *sink) values(keysAndValues []interface{}) []interface{} {
+	redacted := make([]interface{}, len(keysAndValues))
+	for i, v := range keysAndValues {
+		if i%2 == 0 {
+			redacted[i] = v
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			redacted[i] = s.redactor.String(v)
+		case []byte:
+			redacted[i] = s.redactor.String(string(v))
+		case error:
+			redacted[i] = s.redactor.Error(v)
+		case fmt.Stringer:
+			redacted[i] = s.redactor.String(v.String())
+		case []string:
+			values := make([]string, len(v))
+			for j := range v {
+				values[j] = s.redactor.String(v[j])
+			}
+			redacted[i] = values
+		default:
+			redacted[i] = v
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact masks the values of the secrets of a Build, e.g. the password of its connector or the variables
+// sourced from a Secret, in everything written about the Build: the logs of the controllers and provisioners, the
+// events, the conditions and messages of its status, and the provisioner logs stored in ConfigMaps.
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// Mask replaces the values of the secrets.
+const Mask = "***"
+
+// minLineLength is the minimum length of the lines of a multi-line secret masked on their own, e.g. the lines of a
+// private key printed by a command, so the short lines holding no secret are left as is.
+const minLineLength = 16
+
+// pemArmorPrefix starts the armor lines of a PEM block, which are the same for every key and are left as is.
+const pemArmorPrefix = "-----"
+
+// The keys of the credentials secrets of the connectors holding secrets.
+const (
+	passwordKey   = "password"
+	privateKeyKey = "privateKey"
+)
+
+// Redactor masks the values of secrets in text. The zero value and a nil Redactor mask nothing.
+type Redactor struct {
+	// values are the masked values, the longest first so a value containing another one is masked whole.
+	values []string
+}
+
+// New returns a Redactor masking the given values, the empty values are ignored. The lines of the multi-line values
+// are masked on their own too.
+func New(values ...string) *Redactor {
+	seen := map[string]bool{}
+	r := &Redactor{}
+	add := func(value string) {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			r.values = append(r.values, value)
+		}
+	}
+	for _, value := range values {
+		add(value)
+		if !strings.Contains(value, "\n") {
+			continue
+		}
+		for _, line := range strings.Split(value, "\n") {
+			if line = strings.TrimSpace(line); len(line) >= minLineLength && !strings.HasPrefix(line, pemArmorPrefix) {
+				add(line)
+			}
+		}
+	}
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	return r
+}
+
+// With returns a Redactor masking the values of r and the given values.
+func (r *Redactor) With(values ...string) *Redactor {
+	if r == nil {
+		return New(values...)
+	}
+	return New(append(append([]string{}, r.values...), values...)...)
+}
+
+// Empty returns true if the Redactor masks nothing.
+func (r *Redactor) Empty() bool {
+	return r == nil || len(r.values) == 0
+}
+
+// String returns s with the values masked.
+func (r *Redactor) String(s string) string {
+	if r.Empty() {
+		return s
+	}
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, Mask)
+	}
+	return s
+}
+
+// Error returns err with the values masked in its message. The error is wrapped, so it is still classified as err
+// by the forge errors package. It returns nil if err is nil.
+func (r *Redactor) Error(err error) error {
+	if err == nil || r.Empty() {
+		return err
+	}
+	msg := r.String(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactedError is an error whose message has been redacted.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Writer returns a writer masking the values in what is written to w. The writes are buffered up to the end of the
+// lines, so a value split across writes is masked, the last line is written on Close.
+func (r *Redactor) Writer(w io.Writer) io.WriteCloser {
+	return &writer{redactor: r, w: w}
+}
+
+// writer masks the values written to w, line by line.
+type writer struct {
+	redactor *Redactor
+	w        io.Writer
+	buf      bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	if i := bytes.LastIndexByte(w.buf.Bytes(), '\n'); i >= 0 {
+		lines := w.buf.Next(i + 1)
+		if _, err := io.WriteString(w.w, w.redactor.String(string(lines))); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes the last line, it does not close the underlying writer.
+func (w *writer) Close() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.w, w.redactor.String(w.buf.String()))
+	w.buf.Reset()
+	return err
+}
+
+// CredentialsValues returns the values of the credentials secret of a connector to mask: its password and private key.
+func CredentialsValues(secret *corev1.Secret) []string {
+	if secret == nil {
+		return nil
+	}
+	return []string{string(secret.Data[passwordKey]), string(secret.Data[privateKeyKey])}
+}
+
+// MaskedVariables returns the values of the variables listed by the masked-variables annotation of the variables
+// secret of a Build, the variables sourced from a Secret.
+func MaskedVariables(secret *corev1.Secret) []string {
+	if secret == nil {
+		return nil
+	}
+	values := []string{}
+	for _, name := range strings.Split(secret.Annotations[buildv1.MaskedVariablesAnnotation], ",") {
+		if value := string(secret.Data[name]); name != "" && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
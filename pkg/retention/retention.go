@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package retention selects the Images expired as per an ImageRetentionPolicy. Infrastructure providers implement a
+// Deleter deregistering the machine images of the expired Images, along with their backing storage.
+package retention
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// Deleter deletes the machine images of an infrastructure provider.
+type Deleter interface {
+	// Delete deletes the machine image of the Image, its copies and their backing storage, e.g. the snapshots of
+	// an AMI, with the credentials in the secret. It returns the bytes of storage reclaimed. Deleting a machine
+	// image which no longer exists succeeds.
+	Delete(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error)
+}
+
+// DeleterFunc is a function implementing Deleter.
+type DeleterFunc func(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error)
+
+// Delete calls f(ctx, image, secret).
+func (f DeleterFunc) Delete(ctx context.Context, image *buildv1.Image, secret *corev1.Secret) (int64, error) {
+	return f(ctx, image, secret)
+}
+
+// Evaluation is the outcome of the evaluation of the Images of a namespace against a policy.
+type Evaluation struct {
+	// Selected are the Images selected by the policy, the most recent first.
+	Selected []*buildv1.Image
+	// Expired are the selected Images to delete.
+	Expired []*buildv1.Image
+}
+
+// Evaluate returns the Images selected by the policy, and the expired ones at now.
+func Evaluate(policy *buildv1.ImageRetentionPolicy, images []buildv1.Image, now time.Time) (*Evaluation, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid selector")
+	}
+	protect := labels.Nothing()
+	if policy.Spec.Protect != nil {
+		if protect, err = metav1.LabelSelectorAsSelector(policy.Spec.Protect); err != nil {
+			return nil, errors.Wrap(err, "invalid protect selector")
+		}
+	}
+
+	evaluation := &Evaluation{}
+	for i := range images {
+		image := &images[i]
+		if image.DeletionTimestamp.IsZero() && selector.Matches(labels.Set(image.Labels)) {
+			evaluation.Selected = append(evaluation.Selected, image)
+		}
+	}
+	sort.SliceStable(evaluation.Selected, func(i, j int) bool {
+		return completionTime(evaluation.Selected[i]).After(completionTime(evaluation.Selected[j]))
+	})
+
+	spec := &policy.Spec
+	for i, image := range evaluation.Selected {
+		if protect.Matches(labels.Set(image.Labels)) || (spec.KeepLast != nil && i < int(*spec.KeepLast)) {
+			continue
+		}
+		expired := spec.KeepLast != nil && spec.ExpireAfter == nil
+		if spec.ExpireAfter != nil && !now.Before(completionTime(image).Add(spec.ExpireAfter.Duration)) {
+			expired = true
+		}
+		if image.Spec.ExpirationTime != nil && !now.Before(image.Spec.ExpirationTime.Time) {
+			expired = true
+		}
+		if expired {
+			evaluation.Expired = append(evaluation.Expired, image)
+		}
+	}
+	return evaluation, nil
+}
+
+// completionTime returns the time the Build of the Image completed, the creation time of the Image if unknown.
+func completionTime(image *buildv1.Image) time.Time {
+	if t := image.Spec.Provenance.CompletionTime; t != nil {
+		return t.Time
+	}
+	return image.CreationTimestamp.Time
+}
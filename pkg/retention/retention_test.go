@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	image := func(name string, daysAgo int, labels map[string]string) buildv1.Image {
+		return buildv1.Image{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec: buildv1.ImageSpec{Provenance: buildv1.ImageProvenance{
+				CompletionTime: ptr.To(metav1.NewTime(now.AddDate(0, 0, -daysAgo))),
+			}},
+		}
+	}
+	images := []buildv1.Image{
+		image("ubuntu-10", 10, map[string]string{"os": "ubuntu"}),
+		image("ubuntu-1", 1, map[string]string{"os": "ubuntu"}),
+		image("ubuntu-40", 40, map[string]string{"os": "ubuntu"}),
+		image("ubuntu-60", 60, map[string]string{"os": "ubuntu", "pinned": "true"}),
+		image("ubuntu-20", 20, map[string]string{"os": "ubuntu"}),
+		image("debian-90", 90, map[string]string{"os": "debian"}),
+	}
+	expiring := image("ubuntu-2", 2, map[string]string{"os": "ubuntu"})
+	expiring.Spec.ExpirationTime = ptr.To(metav1.NewTime(now.Add(-time.Hour)))
+
+	tests := []struct {
+		name     string
+		spec     buildv1.ImageRetentionPolicySpec
+		images   []buildv1.Image
+		selected []string
+		expired  []string
+	}{
+		{
+			name:     "keep last",
+			spec:     buildv1.ImageRetentionPolicySpec{KeepLast: ptr.To[int32](2)},
+			images:   images,
+			selected: []string{"ubuntu-1", "ubuntu-10", "ubuntu-20", "ubuntu-40", "ubuntu-60", "debian-90"},
+			expired:  []string{"ubuntu-20", "ubuntu-40", "ubuntu-60", "debian-90"},
+		},
+		{
+			name: "expire after, keeping at least the last ones and the protected ones",
+			spec: buildv1.ImageRetentionPolicySpec{
+				Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"os": "ubuntu"}},
+				KeepLast:    ptr.To[int32](3),
+				ExpireAfter: &metav1.Duration{Duration: 15 * 24 * time.Hour},
+				Protect:     &metav1.LabelSelector{MatchLabels: map[string]string{"pinned": "true"}},
+			},
+			images:   images,
+			selected: []string{"ubuntu-1", "ubuntu-10", "ubuntu-20", "ubuntu-40", "ubuntu-60"},
+			expired:  []string{"ubuntu-40"},
+		},
+		{
+			name:     "expiration time of the Image",
+			spec:     buildv1.ImageRetentionPolicySpec{ExpireAfter: &metav1.Duration{Duration: 365 * 24 * time.Hour}},
+			images:   []buildv1.Image{images[1], expiring},
+			selected: []string{"ubuntu-1", "ubuntu-2"},
+			expired:  []string{"ubuntu-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			evaluation, err := Evaluate(&buildv1.ImageRetentionPolicy{Spec: tt.spec}, tt.images, now)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(names(evaluation.Selected)).To(Equal(tt.selected))
+			g.Expect(names(evaluation.Expired)).To(Equal(tt.expired))
+		})
+	}
+
+	_, err := Evaluate(&buildv1.ImageRetentionPolicy{Spec: buildv1.ImageRetentionPolicySpec{
+		Protect: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "pinned", Operator: "Is"}}},
+	}}, images, now)
+	NewWithT(t).Expect(err).To(MatchError(ContainSubstring("invalid protect selector")))
+}
+
+func names(images []*buildv1.Image) []string {
+	var names []string
+	for _, image := range images {
+		names = append(names, image.Name)
+	}
+	return names
+}
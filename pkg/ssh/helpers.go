@@ -1,12 +1,54 @@
 package ssh
 
 import (
+	"errors"
+	"fmt"
 	"net"
 
+	cssh "golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
+
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
 )
 
 func NewSSHClient(secret *corev1.Secret) (*SSHClient, error) {
+	sshClient := &SSHClient{
+		Creds: credentialsFrom(secret),
+		IP:    net.ParseIP(string(secret.Data["host"])),
+		Port:  22,
+	}
+
+	return sshClient, nil
+}
+
+// NewBastion returns the bastion described by a secret in the format of the credentials secrets,
+// the port defaults to 22.
+func NewBastion(secret *corev1.Secret, port int) *Bastion {
+	if port == 0 {
+		port = sshPort
+	}
+	return &Bastion{
+		Creds: credentialsFrom(secret),
+		IP:    net.ParseIP(string(secret.Data["host"])),
+		Port:  port,
+	}
+}
+
+// HostKeyFingerprint returns the SHA256 fingerprint of the host key held by the hostKey key of a credentials secret,
+// in the authorized_keys format. It returns an empty fingerprint if the secret has no host key.
+func HostKeyFingerprint(secret *corev1.Secret) (string, error) {
+	hostKey := secret.Data["hostKey"]
+	if len(hostKey) == 0 {
+		return "", nil
+	}
+	key, _, _, _, err := cssh.ParseAuthorizedKey(hostKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host key in secret %s: %v", ErrPublicKey, secret.Name, err)
+	}
+	return cssh.FingerprintSHA256(key), nil
+}
+
+func credentialsFrom(secret *corev1.Secret) *Credentials {
 	creds := &Credentials{
 		SSHUser: string(secret.Data["username"]),
 	}
@@ -16,13 +58,32 @@ func NewSSHClient(secret *corev1.Secret) (*SSHClient, error) {
 	if privateKey, ok := secret.Data["privateKey"]; ok {
 		creds.SSHPrivateKey = string(privateKey)
 	}
-	ip := net.ParseIP(string(secret.Data["host"]))
+	return creds
+}
 
-	sshClient := &SSHClient{
-		Creds: creds,
-		IP:    ip,
-		Port:  22,
+// SetConnectorDefaults sets the port to connect to, and the username used when the credentials have none.
+// Zero values are ignored.
+func (client *SSHClient) SetConnectorDefaults(port int, username string) {
+	if port != 0 {
+		client.Port = port
+	}
+	if client.Creds.SSHUser == "" {
+		client.Creds.SSHUser = username
 	}
+}
 
-	return sshClient, nil
+// ClassifyError annotates SSH errors with their forge error category, so callers can decide
+// whether to retry the connection or fail the Build. Unknown errors are returned unchanged.
+func ClassifyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrInvalidUsername), errors.Is(err, ErrInvalidAuth):
+		return forgeerrors.NewConfigError(err)
+	case errors.Is(err, ErrHostKeyMismatch):
+		return forgeerrors.NewTerminal(forgeerrors.HostKeyMismatchError, err)
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrTransferTimeout), errors.Is(err, ErrCommandTimeout):
+		return forgeerrors.NewTransient(err)
+	}
+	return err
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package ssh
 
 import (
+	"context"
 	"io"
 	"time"
 )
@@ -52,6 +53,11 @@ func (c *MockSSHClient) Run(command string, stdout io.Writer, stderr io.Writer)
 	return ErrNotImplemented
 }
 
+// RunContext calls the mocked run, the context is ignored.
+func (c *MockSSHClient) RunContext(_ context.Context, command string, stdout io.Writer, stderr io.Writer) error {
+	return c.Run(command, stdout, stderr)
+}
+
 // Upload calls the mocked upload
 func (c *MockSSHClient) Upload(src io.Reader, dst string, mode uint32) error {
 	if c.MockUpload != nil {
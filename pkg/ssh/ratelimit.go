@@ -0,0 +1,133 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssh
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// maxTransferBurst is the largest chunk, in bytes, handed to the underlying
+// reader or writer in a single call when a transfer rate is enforced.
+const maxTransferBurst = 32 * 1024
+
+// newTransferLimiter returns a limiter enforcing bytesPerSec, or nil if the transfer is unlimited.
+func newTransferLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := maxTransferBurst
+	if bytesPerSec < int64(burst) {
+		burst = int(bytesPerSec)
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+// rateLimitedReader is an io.Reader which does not read faster than its limiter allows.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// newRateLimitedReader wraps r with the given limiter, r is returned unchanged if limiter is nil.
+func newRateLimitedReader(ctx context.Context, r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &rateLimitedReader{ctx: ctx, r: r, limiter: limiter}
+}
+
+// Read reads at most one burst from the underlying reader and waits until the limiter allows it.
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, ErrTransferTimeout
+		}
+	}
+	return n, err
+}
+
+// rateLimitedWriter is an io.Writer which does not write faster than its limiter allows.
+type rateLimitedWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+// newRateLimitedWriter wraps w with the given limiter, w is returned unchanged if limiter is nil.
+func newRateLimitedWriter(ctx context.Context, w io.Writer, limiter *rate.Limiter) io.Writer {
+	if limiter == nil {
+		return w
+	}
+	return &rateLimitedWriter{ctx: ctx, w: w, limiter: limiter}
+}
+
+// Write splits p into bursts and writes each one once the limiter allows it.
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > w.limiter.Burst() {
+			chunk = chunk[:w.limiter.Burst()]
+		}
+		if err := w.limiter.WaitN(w.ctx, len(chunk)); err != nil {
+			return written, ErrTransferTimeout
+		}
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// transferContext returns the context bounding a single Upload or Download,
+// honoring Options.TransferTimeout when set.
+func (client *SSHClient) transferContext() (context.Context, context.CancelFunc) {
+	if client.Options.TransferTimeout > 0 {
+		return context.WithTimeout(context.Background(), client.Options.TransferTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// waitForTransfer waits for the transfer goroutines to finish, closing the session
+// when the transfer deadline is exceeded so that blocked reads and writes are released.
+func waitForTransfer(ctx context.Context, wg *sync.WaitGroup, session io.Closer) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		_ = session.Close()
+		<-done
+		return ErrTransferTimeout
+	}
+}
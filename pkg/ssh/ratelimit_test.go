@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestNewTransferLimiter(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(newTransferLimiter(0)).To(BeNil())
+	g.Expect(newTransferLimiter(-1)).To(BeNil())
+	g.Expect(newTransferLimiter(100).Burst()).To(Equal(100))
+	g.Expect(newTransferLimiter(10 * 1024 * 1024).Burst()).To(Equal(maxTransferBurst))
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	g := NewWithT(t)
+
+	data := bytes.Repeat([]byte("a"), 500)
+	limiter := newTransferLimiter(1000)
+	// Drain the initial burst so the measurement only covers throttled reads.
+	g.Expect(limiter.WaitN(context.Background(), 1000)).To(Succeed())
+
+	start := time.Now()
+	out, err := io.ReadAll(newRateLimitedReader(context.Background(), bytes.NewReader(data), limiter))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal(data))
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 450*time.Millisecond))
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	g := NewWithT(t)
+
+	data := bytes.Repeat([]byte("b"), 500)
+	limiter := newTransferLimiter(1000)
+	g.Expect(limiter.WaitN(context.Background(), 1000)).To(Succeed())
+
+	out := &bytes.Buffer{}
+	start := time.Now()
+	n, err := newRateLimitedWriter(context.Background(), out, limiter).Write(data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(n).To(Equal(len(data)))
+	g.Expect(out.Bytes()).To(Equal(data))
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 450*time.Millisecond))
+}
+
+func TestRateLimitedWriterDeadline(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	limiter := newTransferLimiter(10)
+	_, err := newRateLimitedWriter(ctx, io.Discard, limiter).Write(bytes.Repeat([]byte("c"), 100))
+	g.Expect(err).To(MatchError(ErrTransferTimeout))
+}
+
+func TestUnlimitedPassThrough(t *testing.T) {
+	g := NewWithT(t)
+
+	r := bytes.NewReader(nil)
+	g.Expect(newRateLimitedReader(context.Background(), r, nil)).To(BeIdenticalTo(r))
+	w := &bytes.Buffer{}
+	g.Expect(newRateLimitedWriter(context.Background(), w, nil)).To(BeIdenticalTo(w))
+}
+
+func TestWaitForTransfer(t *testing.T) {
+	g := NewWithT(t)
+
+	// The transfer completes before the deadline.
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go wg.Done()
+	g.Expect(waitForTransfer(context.Background(), &wg, closerFunc(func() error { return nil }))).To(Succeed())
+
+	// The transfer is stuck until the session is closed.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-release
+	}()
+	err := waitForTransfer(ctx, &wg, closerFunc(func() error {
+		close(release)
+		return nil
+	}))
+	g.Expect(err).To(MatchError(ErrTransferTimeout))
+}
@@ -19,19 +19,23 @@ package ssh
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	cssh "golang.org/x/crypto/ssh"
+
+	"github.com/forge-build/forge/pkg/connections"
 )
 
 var (
@@ -43,6 +47,10 @@ var (
 	ErrSSHInvalidMessageLength = errors.New("invalid message length")
 	// ErrTimeout is returned when a timeout occurs waiting for sshd to respond.
 	ErrTimeout = errors.New("timed out waiting for sshd to respond")
+	// ErrTransferTimeout is returned when an Upload or Download does not complete within Options.TransferTimeout.
+	ErrTransferTimeout = errors.New("timed out waiting for file transfer to complete")
+	// ErrCommandTimeout is returned when a command run by RunContext does not complete before its context is done.
+	ErrCommandTimeout = errors.New("timed out waiting for command to complete")
 	// ErrKeyGeneration is returned when the library fails to generate a key.
 	ErrKeyGeneration = errors.New("unable to generate key")
 	// ErrValidation is returned when we fail to validate a key.
@@ -51,6 +59,8 @@ var (
 	ErrPublicKey = errors.New("unable to convert public key")
 	// ErrUnableToWriteFile is returned when the library fails to write to a file.
 	ErrUnableToWriteFile = errors.New("unable to write file")
+	// ErrHostKeyMismatch is returned when the host key presented by the server is not one of Options.HostKeyFingerprints.
+	ErrHostKeyMismatch = errors.New("host key mismatch")
 	// ErrNotImplemented is returned when a function is not implemented (typically by the Mock implementation).
 	ErrNotImplemented = errors.New("operation not implemented")
 	// Setup a mutex for the close channel for thread safety.
@@ -68,6 +78,9 @@ const (
 
 	// Timeout for connecting to an SSH server.
 	Timeout = 60 * time.Second
+
+	// maxServerVersionLength is the maximum length of the version banner of a server, as per RFC 4253.
+	maxServerVersionLength = 253
 )
 
 // Client represents an interface for abstracting common ssh operations.
@@ -76,6 +89,7 @@ type Client interface {
 	Disconnect()
 	Download(src io.WriteCloser, dst string) error
 	Run(command string, stdout io.Writer, stderr io.Writer) error
+	RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
 	Upload(src io.Reader, dst string, mode uint32) error
 	Validate() error
 	WaitForSSH(maxWait time.Duration) error
@@ -99,6 +113,56 @@ type Options struct {
 	IPs       []net.IP
 	KeepAlive int
 	Pty       bool
+
+	// MaxTransferRate caps Upload and Download throughput in bytes per second, 0 means unlimited.
+	MaxTransferRate int64
+	// TransferTimeout is the deadline for a single Upload or Download, 0 means no deadline.
+	TransferTimeout time.Duration
+
+	// Owner identifies the object the connection is opened for, e.g. the namespace/name of a Build,
+	// it is reported in the connection inventory.
+	Owner string
+
+	// ConnectTimeout is the timeout of the TCP connection and of the SSH handshake, defaults to Timeout.
+	ConnectTimeout time.Duration
+	// Bastion is the machine the connection is tunneled through, if any.
+	Bastion *Bastion
+	// HostKeyFingerprints are the SHA256 fingerprints of the accepted host keys, any host key is accepted if empty.
+	HostKeyFingerprints []string
+	// Sudo runs the commands with sudo, if set.
+	Sudo *Sudo
+
+	// Logger logs the errors closing the sessions and the connection attempts, nothing is logged if unset.
+	Logger logr.Logger
+}
+
+// Bastion is a machine an SSH connection is tunneled through. Its host key is not verified.
+type Bastion struct {
+	Creds *Credentials
+	IP    net.IP
+	Port  int
+}
+
+// Sudo defines how the commands are run with sudo.
+type Sudo struct {
+	// User is the user the commands run as, defaults to root.
+	User string
+	// Password is sent to sudo when set, otherwise sudo must not require a password.
+	Password string
+}
+
+// ConnectionInfo describes a connection negotiated with an SSH server, it holds no credentials.
+type ConnectionInfo struct {
+	// Address is the address connected to, as host:port.
+	Address string
+	// AuthMethod is the authentication method used, KeyAuth or PasswordAuth.
+	AuthMethod string
+	// HostKeyType is the algorithm of the host key presented by the server, e.g. ssh-ed25519.
+	HostKeyType string
+	// HostKeyFingerprint is the SHA256 fingerprint of the host key presented by the server.
+	HostKeyFingerprint string
+	// ServerVersion is the version banner of the server, e.g. SSH-2.0-OpenSSH_9.6.
+	ServerVersion string
 }
 
 // SSHClient provides details for the SSH connection.
@@ -108,8 +172,11 @@ type SSHClient struct {
 	Port    int
 	Options Options
 
-	cryptoClient *cssh.Client
-	close        chan bool
+	cryptoClient  *cssh.Client
+	bastionClient *cssh.Client
+	close         chan bool
+	tracker       *connections.Tracker
+	info          ConnectionInfo
 }
 
 // MockSSHClient represents a Mock Client wrapper.
@@ -130,17 +197,37 @@ type MockSSHClient struct {
 
 // dial will attempt to connect to an SSH server.
 var dial = func(network, addr string, config *cssh.ClientConfig) (*cssh.Client, error) {
-	d := net.Dialer{Timeout: Timeout, KeepAlive: 2 * time.Second}
+	d := net.Dialer{Timeout: config.Timeout, KeepAlive: 2 * time.Second}
 
 	conn, err := d.Dial(network, addr)
 	if err != nil {
 		return nil, err
 	}
 
+	return newClientConn(conn, addr, config)
+}
+
+// dialThrough will attempt to connect to an SSH server through an SSH connection to a bastion.
+var dialThrough = func(bastion *cssh.Client, network, addr string, config *cssh.ClientConfig) (*cssh.Client, error) {
+	conn, err := bastion.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClientConn(conn, addr, config)
+}
+
+// newClientConn runs the SSH handshake over conn, within the timeout of the config.
+func newClientConn(conn net.Conn, addr string, config *cssh.ClientConfig) (*cssh.Client, error) {
+	if config.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(config.Timeout))
+	}
 	c, chans, reqs, err := cssh.NewClientConn(conn, addr, config)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
+	_ = conn.SetDeadline(time.Time{})
 
 	return cssh.NewClient(c, chans, reqs), nil
 }
@@ -171,46 +258,66 @@ var getAuth = func(c *Credentials, authType string) (cssh.AuthMethod, error) {
 
 // Connect connects to a machine using SSH.
 func (client *SSHClient) Connect() error {
-	var (
-		auth cssh.AuthMethod
-		err  error
-	)
+	if err := client.Validate(); err != nil {
+		return err
+	}
 
-	if err = client.Validate(); err != nil {
+	auth, authType, err := authMethod(client.Creds)
+	if err != nil {
 		return err
 	}
 
-	if client.Creds.SSHPrivateKey != "" {
-		auth, err = getAuth(client.Creds, KeyAuth)
-		if err != nil {
-			return err
-		}
-	} else if client.Creds.SSHPassword != "" {
-		auth, err = getAuth(client.Creds, PasswordAuth)
-		if err != nil {
-			return err
-		}
+	port := sshPort
+	if client.Port != 0 {
+		port = client.Port
 	}
 
+	addr := fmt.Sprintf("%s:%d", client.IP, port)
+	info := ConnectionInfo{Address: addr, AuthMethod: authType}
 	config := &cssh.ClientConfig{
 		User: client.Creds.SSHUser,
 		Auth: []cssh.AuthMethod{
 			auth,
 		},
-		HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+		// The host key is recorded so the machine connected to can be audited, it is only verified
+		// when the accepted host keys are known.
+		HostKeyCallback: func(_ string, _ net.Addr, key cssh.PublicKey) error {
+			info.HostKeyType = key.Type()
+			info.HostKeyFingerprint = cssh.FingerprintSHA256(key)
+			if len(client.Options.HostKeyFingerprints) > 0 && !slices.Contains(client.Options.HostKeyFingerprints, info.HostKeyFingerprint) {
+				return fmt.Errorf("%w: %s presented %s %s", ErrHostKeyMismatch, addr, info.HostKeyType, info.HostKeyFingerprint)
+			}
+			return nil
+		},
+		Timeout: client.connectTimeout(),
 	}
 
-	port := sshPort
-	if client.Port != 0 {
-		port = client.Port
+	var c, bastion *cssh.Client
+	if client.Options.Bastion != nil {
+		bastion, err = client.dialBastion()
+		if err != nil {
+			return err
+		}
+		c, err = dialThrough(bastion, "tcp", addr, config)
+		if err != nil {
+			bastion.Close()
+			return fmt.Errorf("failed to connect to %s through the bastion: %w", addr, err)
+		}
+	} else {
+		c, err = dial("tcp", addr, config)
+		if err != nil {
+			return err
+		}
 	}
-
-	c, err := dial("tcp", fmt.Sprintf("%s:%d", client.IP, port), config)
-	if err != nil {
-		return err
+	if c != nil {
+		info.ServerVersion = sanitizeServerVersion(c.ServerVersion())
 	}
 
 	client.cryptoClient = c
+	client.bastionClient = bastion
+	client.info = info
+	client.tracker.Close()
+	client.tracker = connections.DefaultInventory.Open("ssh", client.Options.Owner, addr)
 
 	closeMutex.Lock()
 	defer closeMutex.Unlock()
@@ -224,6 +331,80 @@ func (client *SSHClient) Connect() error {
 	return nil
 }
 
+// dialBastion connects to the bastion of the connection.
+func (client *SSHClient) dialBastion() (*cssh.Client, error) {
+	bastion := client.Options.Bastion
+	if bastion.Creds == nil || bastion.Creds.SSHUser == "" {
+		return nil, fmt.Errorf("bastion: %w", ErrInvalidUsername)
+	}
+	auth, _, err := authMethod(bastion.Creds)
+	if err != nil {
+		return nil, fmt.Errorf("bastion: %w", err)
+	}
+	port := sshPort
+	if bastion.Port != 0 {
+		port = bastion.Port
+	}
+
+	addr := fmt.Sprintf("%s:%d", bastion.IP, port)
+	c, err := dial("tcp", addr, &cssh.ClientConfig{
+		User:            bastion.Creds.SSHUser,
+		Auth:            []cssh.AuthMethod{auth},
+		HostKeyCallback: cssh.InsecureIgnoreHostKey(), //nolint:gosec // The bastion host key is not verified.
+		Timeout:         client.connectTimeout(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the bastion %s: %w", addr, err)
+	}
+	return c, nil
+}
+
+// authMethod returns the authentication method of the credentials, key based auth takes precedence over password.
+func authMethod(creds *Credentials) (cssh.AuthMethod, string, error) {
+	authType := ""
+	if creds.SSHPrivateKey != "" {
+		authType = KeyAuth
+	} else if creds.SSHPassword != "" {
+		authType = PasswordAuth
+	}
+	if authType == "" {
+		return nil, "", ErrInvalidAuth
+	}
+	auth, err := getAuth(creds, authType)
+	if err != nil {
+		return nil, "", err
+	}
+	return auth, authType, nil
+}
+
+func (client *SSHClient) connectTimeout() time.Duration {
+	if client.Options.ConnectTimeout > 0 {
+		return client.Options.ConnectTimeout
+	}
+	return Timeout
+}
+
+// ConnectionInfo returns the details of the last connection established by Connect,
+// they remain available once the client is disconnected.
+func (client *SSHClient) ConnectionInfo() ConnectionInfo {
+	return client.info
+}
+
+// sanitizeServerVersion keeps the printable characters of the version banner of a server,
+// up to maxServerVersionLength, as the banner is controlled by the remote machine.
+func sanitizeServerVersion(version []byte) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, string(version))
+	if len(sanitized) > maxServerVersionLength {
+		sanitized = sanitized[:maxServerVersionLength]
+	}
+	return sanitized
+}
+
 func (client *SSHClient) keepAlive() {
 	t := time.NewTicker(time.Duration(client.Options.KeepAlive) * time.Second)
 	defer t.Stop()
@@ -255,13 +436,22 @@ func (client *SSHClient) Disconnect() {
 			client.close = nil
 		}
 	}
+
+	if client.cryptoClient != nil {
+		_ = client.cryptoClient.Close()
+	}
+	if client.bastionClient != nil {
+		_ = client.bastionClient.Close()
+		client.bastionClient = nil
+	}
+	client.tracker.Close()
 }
 
 // Download downloads a file via SSH (SCP)
 func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 	defer func() {
 		if err := dst.Close(); err != nil {
-			log.Println(err)
+			client.logCloseError(err)
 		}
 	}()
 
@@ -272,7 +462,7 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 
 	defer func() {
 		if err := session.Close(); err != nil {
-			log.Println(err)
+			client.logCloseError(err)
 		}
 	}()
 
@@ -286,6 +476,10 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 		return err
 	}
 
+	ctx, cancel := client.transferContext()
+	defer cancel()
+	limiter := newTransferLimiter(client.Options.MaxTransferRate)
+
 	errorChan := make(chan error, 3)
 
 	wg := sync.WaitGroup{}
@@ -297,7 +491,7 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 
 		defer func() {
 			if err := ackPipe.Close(); err != nil {
-				log.Println(err)
+				client.logCloseError(err)
 			}
 		}()
 
@@ -338,7 +532,7 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 		}
 
 		// Copy content to file
-		_, err = io.CopyN(dst, dr, length)
+		_, err = io.CopyN(dst, client.tracker.ReceivedReader(newRateLimitedReader(ctx, dr, limiter)), length)
 		if err != nil {
 			errorChan <- err
 			return
@@ -355,7 +549,9 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 		}
 	}()
 
-	wg.Wait()
+	if err := waitForTransfer(ctx, &wg, session); err != nil {
+		return err
+	}
 
 	select {
 	case err := <-errorChan:
@@ -367,6 +563,12 @@ func (client *SSHClient) Download(dst io.WriteCloser, remotePath string) error {
 
 // Run runs a command via SSH.
 func (client *SSHClient) Run(command string, stdout io.Writer, stderr io.Writer) error {
+	return client.RunContext(context.Background(), command, stdout, stderr)
+}
+
+// RunContext runs a command via SSH until the context is done, the session of the command is then closed and
+// ErrCommandTimeout is returned.
+func (client *SSHClient) RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error {
 	session, err := client.cryptoClient.NewSession()
 	if err != nil {
 		return err
@@ -374,12 +576,13 @@ func (client *SSHClient) Run(command string, stdout io.Writer, stderr io.Writer)
 
 	defer func() {
 		if err := session.Close(); err != nil {
-			log.Println(err)
+			client.logCloseError(err)
 		}
 	}()
 
-	session.Stdout = stdout
-	session.Stderr = stderr
+	client.tracker.Touch()
+	session.Stdout = client.tracker.ReceivedWriter(stdout)
+	session.Stderr = client.tracker.ReceivedWriter(stderr)
 
 	if client.Options.Pty {
 		modes := cssh.TerminalModes{
@@ -393,7 +596,132 @@ func (client *SSHClient) Run(command string, stdout io.Writer, stderr io.Writer)
 		}
 	}
 
-	return session.Run(command)
+	if sudo := client.Options.Sudo; sudo != nil {
+		command = sudoCommand(command, sudo)
+		if sudo.Password != "" {
+			session.Stdin = strings.NewReader(sudo.Password + "\n")
+		}
+	}
+	if err := session.Start(command); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Closing the session releases Wait, the command itself is killed by sshd when the channel closes.
+		_ = session.Close()
+		<-done
+		return fmt.Errorf("%w: %w", ErrCommandTimeout, ctx.Err())
+	}
+}
+
+// Terminal describes the local terminal of an interactive session.
+type Terminal struct {
+	// Term is the terminal type, e.g. xterm-256color, defaults to xterm.
+	Term string
+	// Width and Height are the size of the terminal in characters, default to 80x24.
+	Width  int
+	Height int
+	// Resize receives the new sizes of the terminal, if set.
+	Resize <-chan TerminalSize
+}
+
+// TerminalSize is the size of a terminal in characters.
+type TerminalSize struct {
+	Width  int
+	Height int
+}
+
+// size returns the size of the terminal, defaulted.
+func (t Terminal) size() (int, int) {
+	width, height := t.Width, t.Height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return width, height
+}
+
+// Shell runs an interactive login shell in a pseudo terminal until it exits or the context is done. The input is
+// read from stdin and the output of the shell written to stdout and stderr, the local terminal is expected to be
+// in raw mode.
+func (client *SSHClient) Shell(ctx context.Context, terminal Terminal, stdin io.Reader, stdout, stderr io.Writer) error {
+	session, err := client.cryptoClient.NewSession()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			client.logCloseError(err)
+		}
+	}()
+
+	client.tracker.Touch()
+	session.Stdin = client.tracker.SentReader(stdin)
+	session.Stdout = client.tracker.ReceivedWriter(stdout)
+	session.Stderr = client.tracker.ReceivedWriter(stderr)
+
+	term := terminal.Term
+	if term == "" {
+		term = "xterm"
+	}
+	width, height := terminal.size()
+	modes := cssh.TerminalModes{
+		cssh.ECHO:          1,
+		cssh.TTY_OP_ISPEED: 14400,
+		cssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, height, width, modes); err != nil {
+		return fmt.Errorf("requesting a pseudo terminal: %w", err)
+	}
+	if err := session.Shell(); err != nil {
+		return fmt.Errorf("starting the shell: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case size := <-terminal.Resize:
+			if err := session.WindowChange(size.Height, size.Width); err != nil {
+				client.Options.Logger.V(4).Info("Failed to resize the terminal", "address", client.address(), "error", err.Error())
+			}
+		case <-ctx.Done():
+			_ = session.Close()
+			<-done
+			return ctx.Err()
+		}
+	}
+}
+
+// sudoCommand returns the command run by sh with sudo. The password is read from the standard input if set,
+// otherwise sudo fails rather than prompting for it.
+func sudoCommand(command string, sudo *Sudo) string {
+	user := sudo.User
+	if user == "" {
+		user = "root"
+	}
+	flags := "-n"
+	if sudo.Password != "" {
+		flags = "-S -p ''"
+	}
+	return fmt.Sprintf("sudo %s -u %s -- sh -c %s", flags, Quote(user), Quote(command))
+}
+
+// Quote returns s quoted for a POSIX shell, as a single word.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // Upload uploads a new file via SSH (SCP)
@@ -410,7 +738,7 @@ func (client *SSHClient) Upload(src io.Reader, dst string, mode uint32) error {
 
 	defer func() {
 		if err := session.Close(); err != nil {
-			log.Println(err)
+			client.logCloseError(err)
 		}
 	}()
 
@@ -419,6 +747,10 @@ func (client *SSHClient) Upload(src io.Reader, dst string, mode uint32) error {
 		return err
 	}
 
+	ctx, cancel := client.transferContext()
+	defer cancel()
+	limiter := newTransferLimiter(client.Options.MaxTransferRate)
+
 	errorChan := make(chan error, 2)
 	remoteDir := path.Dir(dst)
 	remoteFileName := path.Base(dst)
@@ -429,14 +761,14 @@ func (client *SSHClient) Upload(src io.Reader, dst string, mode uint32) error {
 	go func() {
 		defer func() {
 			if err := w.Close(); err != nil {
-				log.Println(err)
+				client.logCloseError(err)
 			}
 		}()
 		defer wg.Done()
 
 		// Signals to the SSH receiver that content is being passed.
 		fmt.Fprintf(w, "C%#o %d %s\n", mode, len(fileContent), remoteFileName)
-		_, err = io.Copy(w, bytes.NewReader(fileContent))
+		_, err := io.Copy(client.tracker.SentWriter(newRateLimitedWriter(ctx, w, limiter)), bytes.NewReader(fileContent))
 		if err != nil {
 			errorChan <- err
 			return
@@ -454,7 +786,9 @@ func (client *SSHClient) Upload(src io.Reader, dst string, mode uint32) error {
 		}
 	}()
 
-	wg.Wait()
+	if err := waitForTransfer(ctx, &wg, session); err != nil {
+		return err
+	}
 
 	select {
 	case err := <-errorChan:
@@ -490,7 +824,12 @@ func (client *SSHClient) WaitForSSH(maxWait time.Duration) error {
 			defer client.Disconnect()
 			return nil
 		}
+		if errors.Is(err, ErrHostKeyMismatch) {
+			// Connecting again would present the same host key.
+			return err
+		}
 
+		client.Options.Logger.V(4).Info("Waiting for SSH", "address", client.address(), "error", err.Error())
 		timePassed := time.Since(start)
 		if timePassed >= maxWait {
 			break
@@ -502,6 +841,19 @@ func (client *SSHClient) WaitForSSH(maxWait time.Duration) error {
 	return ErrTimeout
 }
 
+// address returns the address of the machine, as host:port.
+func (client *SSHClient) address() string {
+	return net.JoinHostPort(client.IP.String(), strconv.Itoa(client.Port))
+}
+
+// logCloseError logs the error closing a session or a pipe, the sessions already closed by the server are not logged.
+func (client *SSHClient) logCloseError(err error) {
+	if errors.Is(err, io.EOF) {
+		return
+	}
+	client.Options.Logger.Error(err, "Failed to close", "address", client.address())
+}
+
 // SetSSHPrivateKey sets the private key on the clients credentials.
 func (client *SSHClient) SetSSHPrivateKey(s string) {
 	client.Creds.mu.Lock()
@@ -17,8 +17,12 @@ limitations under the License.
 package ssh
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -86,6 +90,145 @@ func TestConnectAuthPrecedence(t *testing.T) {
 	}
 }
 
+// TestConnectRecordsConnectionInfo tests that the negotiated connection details are recorded.
+func TestConnectRecordsConnectionInfo(t *testing.T) {
+	c := requireMockedClient()
+	c.Creds = &Credentials{
+		SSHUser:     "test",
+		SSHPassword: "test",
+	}
+	c.IP = net.ParseIP("10.0.0.1")
+	c.Port = 2222
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := cssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial = func(_ string, addr string, config *cssh.ClientConfig) (*cssh.Client, error) {
+		return nil, config.HostKeyCallback(addr, nil, hostKey)
+	}
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Expected nil error, got %s", err)
+	}
+	want := ConnectionInfo{
+		Address:            "10.0.0.1:2222",
+		AuthMethod:         PasswordAuth,
+		HostKeyType:        "ssh-ed25519",
+		HostKeyFingerprint: cssh.FingerprintSHA256(hostKey),
+	}
+	if got := c.ConnectionInfo(); got != want {
+		t.Errorf("Expected connection info %+v, got %+v", want, got)
+	}
+}
+
+func TestConnectHostKeyMismatch(t *testing.T) {
+	c := requireMockedClient()
+	c.Creds = &Credentials{
+		SSHUser:     "test",
+		SSHPassword: "test",
+	}
+	c.IP = net.ParseIP("10.0.0.1")
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := cssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial = func(_ string, addr string, config *cssh.ClientConfig) (*cssh.Client, error) {
+		return nil, config.HostKeyCallback(addr, nil, hostKey)
+	}
+
+	c.Options.HostKeyFingerprints = []string{cssh.FingerprintSHA256(hostKey)}
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Expected nil error, got %s", err)
+	}
+
+	// A mismatching host key is not retried until maxWait.
+	c.Options.HostKeyFingerprints = []string{"SHA256:pinned"}
+	start := time.Now()
+	if err := c.WaitForSSH(time.Minute); !errors.Is(err, ErrHostKeyMismatch) {
+		t.Fatalf("Expected error %s, got %v", ErrHostKeyMismatch, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected WaitForSSH to return immediately, took %s", elapsed)
+	}
+}
+
+func TestSudoCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		sudo *Sudo
+		want string
+	}{
+		{
+			name: "passwordless sudo defaults to root",
+			sudo: &Sudo{},
+			want: `sudo -n -u 'root' -- sh -c 'echo '\''ready'\'''`,
+		},
+		{
+			name: "sudo with password",
+			sudo: &Sudo{User: "builder", Password: "secret"},
+			want: `sudo -S -p '' -u 'builder' -- sh -c 'echo '\''ready'\'''`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sudoCommand("echo 'ready'", tt.sudo); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                 `''`,
+		"/opt/app data":    `'/opt/app data'`,
+		"it's $HOME; rm *": `'it'\''s $HOME; rm *'`,
+	}
+	for s, want := range tests {
+		if got := Quote(s); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestTerminalSize(t *testing.T) {
+	if width, height := (Terminal{}).size(); width != 80 || height != 24 {
+		t.Errorf("Expected the default size 80x24, got %dx%d", width, height)
+	}
+	if width, height := (Terminal{Width: 120, Height: 40}).size(); width != 120 || height != 40 {
+		t.Errorf("Expected the size 120x40, got %dx%d", width, height)
+	}
+}
+
+func TestSanitizeServerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version []byte
+		want    string
+	}{
+		{name: "openssh", version: []byte("SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13"), want: "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13"},
+		{name: "control characters", version: []byte("SSH-2.0-evil\x1b[2J\r\n"), want: "SSH-2.0-evil[2J"},
+		{name: "too long", version: []byte("SSH-2.0-" + strings.Repeat("a", 300)), want: ("SSH-2.0-" + strings.Repeat("a", 300))[:maxServerVersionLength]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeServerVersion(tt.version); got != tt.want {
+				t.Errorf("sanitizeServerVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestSetSSHPrivateKey tests the SetSSHPrivateKey method of SSHClient.
 func TestSetSSHPrivateKey(t *testing.T) {
 	c := requireMockedClient()
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ansible holds what the ansible provisioner Job and the controller running it share: the keys of the
+// ConfigMap mounted by the Job, its arguments and the outcomes of the plays it reports.
+package ansible
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const (
+	// DefaultImage is the image of the ansible provisioner used when not configured.
+	DefaultImage = "ghcr.io/forge-build/forge-provisioner-ansible:latest"
+
+	// PlaybookKey is the key of the ConfigMap mounted by the Job holding the inline playbook,
+	// it is also the playbook run when the playbook path is not set.
+	PlaybookKey = "playbook.yml"
+	// RequirementsKey is the key of the ConfigMap mounted by the Job holding the requirements of the playbook.
+	RequirementsKey = "requirements.yml"
+	// ExtraVarsKey is the key of the ConfigMap mounted by the Job holding the extra variables, as a JSON object.
+	ExtraVarsKey = "forge-extra-vars.json"
+)
+
+// PlayStatus is the outcome of a play, the Job reports the outcomes of its plays as a JSON list in its
+// termination message.
+type PlayStatus struct {
+	Name        string `json:"name"`
+	Phase       string `json:"phase"`
+	Ok          int32  `json:"ok"`
+	Changed     int32  `json:"changed"`
+	Failed      int32  `json:"failed"`
+	Skipped     int32  `json:"skipped"`
+	Unreachable int32  `json:"unreachable"`
+	Message     string `json:"message,omitempty"`
+}
+
+// ParsePlayStatuses returns the outcomes of the plays reported in the given termination message.
+func ParsePlayStatuses(message string) ([]PlayStatus, error) {
+	statuses := []PlayStatus{}
+	if err := json.Unmarshal([]byte(message), &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// Args returns the arguments of the ansible provisioner running the playbook of the given spec,
+// dir is the directory the ConfigMap holding the playbook, its requirements and its extra variables is mounted on.
+func Args(spec *buildv1.AnsibleSpec, dir string) []string {
+	playbook := spec.PlaybookPath
+	if playbook == "" {
+		playbook = PlaybookKey
+	}
+	args := []string{"--playbook", playbook}
+	if git := spec.Git; git != nil {
+		args = append(args, "--git-url", git.URL)
+		if git.Ref != "" {
+			args = append(args, "--git-ref", git.Ref)
+		}
+		if git.SecretRef != nil {
+			args = append(args, "--git-secret-name", git.SecretRef.Name)
+		}
+	} else {
+		args = append(args, "--playbook-dir", dir)
+	}
+	if spec.Requirements != "" {
+		args = append(args, "--requirements-file", path.Join(dir, RequirementsKey))
+	}
+	if len(spec.ExtraVars) > 0 {
+		args = append(args, "--extra-vars-file", path.Join(dir, ExtraVarsKey))
+	}
+	if len(spec.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(spec.Tags, ","))
+	}
+	if len(spec.SkipTags) > 0 {
+		args = append(args, "--skip-tags", strings.Join(spec.SkipTags, ","))
+	}
+	return args
+}
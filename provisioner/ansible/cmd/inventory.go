@@ -0,0 +1,244 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+)
+
+// inventoryHost is the name of the machine of the Build in the inventory, the plays target it or all.
+const inventoryHost = "forge"
+
+// inventoryOptions are the connection options of the inventory, zero values are left to the ansible defaults.
+type inventoryOptions struct {
+	ConnectorType  string
+	Port           int
+	Username       string
+	BastionPort    int
+	Become         bool
+	BecomeUser     string
+	BecomePassword bool
+	Timeout        time.Duration
+}
+
+// writeInventory writes the inventory of the machine described by the credentials secret to dir, along with the
+// private keys and the known hosts it references, and returns its path.
+func writeInventory(dir string, secret, bastion *corev1.Secret, opts inventoryOptions) (string, error) {
+	files := map[string]string{}
+	vars, err := hostVars(dir, secret, bastion, opts, files)
+	if err != nil {
+		return "", err
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			return "", errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+
+	inventory := map[string]interface{}{
+		"all": map[string]interface{}{
+			"hosts": map[string]interface{}{inventoryHost: vars},
+		},
+	}
+	data, err := json.Marshal(inventory)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the inventory")
+	}
+	// The yaml inventory plugin parses the JSON inventory, no quoting is required.
+	path := filepath.Join(dir, "inventory.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", errors.Wrap(err, "failed to write the inventory")
+	}
+	return path, nil
+}
+
+// hostVars returns the variables of the machine in the inventory, the files they reference are added to files,
+// by path under dir.
+func hostVars(dir string, secret, bastion *corev1.Secret, opts inventoryOptions, files map[string]string) (map[string]interface{}, error) {
+	if opts.ConnectorType == buildv1.ConnectorTypeDockerExec {
+		return dockerHostVars(dir, secret, opts, files)
+	}
+	host := string(secret.Data["host"])
+	if host == "" {
+		return nil, errors.Errorf("secret %s has no host", secret.Name)
+	}
+	username := string(secret.Data["username"])
+	if username == "" {
+		username = opts.Username
+	}
+	port := opts.Port
+	if port == 0 {
+		port = 22
+	}
+	vars := map[string]interface{}{
+		"ansible_host": host,
+		"ansible_port": port,
+		"ansible_user": username,
+	}
+
+	password := string(secret.Data["password"])
+	if privateKey := secret.Data["privateKey"]; len(privateKey) > 0 {
+		keyFile := filepath.Join(dir, "id_machine")
+		files[keyFile] = ensureTrailingNewline(string(privateKey))
+		vars["ansible_ssh_private_key_file"] = keyFile
+	} else if password != "" {
+		vars["ansible_password"] = password
+	} else {
+		return nil, errors.Errorf("secret %s has neither a privateKey nor a password", secret.Name)
+	}
+
+	if opts.Become {
+		vars["ansible_become"] = true
+		if opts.BecomeUser != "" {
+			vars["ansible_become_user"] = opts.BecomeUser
+		}
+		if opts.BecomePassword {
+			if password == "" {
+				return nil, errors.New("sudo requires a password but the ssh-credentials secret has none")
+			}
+			vars["ansible_become_password"] = password
+		}
+	}
+	if opts.Timeout > 0 {
+		vars["ansible_timeout"] = int(opts.Timeout.Seconds())
+	}
+
+	// The host key has been verified by the provisioner before running ansible, it is only pinned again
+	// when the secret holds it.
+	sshArgs := []string{"-o StrictHostKeyChecking=no", "-o UserKnownHostsFile=/dev/null"}
+	if hostKey := strings.TrimSpace(string(secret.Data["hostKey"])); hostKey != "" {
+		knownHosts := filepath.Join(dir, "known_hosts")
+		files[knownHosts] = fmt.Sprintf("%s %s\n", knownHostsPattern(host, port), hostKey)
+		sshArgs = []string{"-o StrictHostKeyChecking=yes", "-o UserKnownHostsFile=" + knownHosts}
+	}
+	if bastion != nil {
+		proxy, err := proxyCommand(dir, bastion, opts.BastionPort, files)
+		if err != nil {
+			return nil, err
+		}
+		sshArgs = append(sshArgs, fmt.Sprintf("-o ProxyCommand=%q", proxy))
+	}
+	vars["ansible_ssh_common_args"] = strings.Join(sshArgs, " ")
+	return vars, nil
+}
+
+// dockerHostVars returns the variables of the container in the inventory, ansible runs the tasks in it with the
+// docker_api connection of the community.docker collection.
+func dockerHostVars(dir string, secret *corev1.Secret, opts inventoryOptions, files map[string]string) (map[string]interface{}, error) {
+	container := string(secret.Data[docker.ContainerKey])
+	if container == "" {
+		return nil, errors.Errorf("secret %s has no %s", secret.Name, docker.ContainerKey)
+	}
+	endpoint := string(secret.Data[docker.HostKey])
+	if endpoint == "" {
+		return nil, errors.Errorf("secret %s has no %s", secret.Name, docker.HostKey)
+	}
+	vars := map[string]interface{}{
+		"ansible_connection":         "community.docker.docker_api",
+		"ansible_host":               container,
+		"ansible_docker_docker_host": endpoint,
+	}
+	username := string(secret.Data[docker.UsernameKey])
+	if username == "" {
+		username = opts.Username
+	}
+	if username != "" {
+		vars["ansible_user"] = username
+	}
+	if opts.Timeout > 0 {
+		vars["ansible_docker_timeout"] = int(opts.Timeout.Seconds())
+	}
+
+	tlsFiles := []struct{ key, file, variable string }{
+		{docker.CACertKey, "docker_ca.pem", "ansible_docker_ca_cert"},
+		{docker.ClientCertKey, "docker_cert.pem", "ansible_docker_client_cert"},
+		{docker.ClientKeyKey, "docker_key.pem", "ansible_docker_client_key"},
+	}
+	for _, f := range tlsFiles {
+		content := secret.Data[f.key]
+		if len(content) == 0 {
+			continue
+		}
+		name := filepath.Join(dir, f.file)
+		files[name] = ensureTrailingNewline(string(content))
+		vars[f.variable] = name
+		vars["ansible_docker_tls"] = true
+	}
+	if _, ok := vars["ansible_docker_ca_cert"]; ok {
+		vars["ansible_docker_validate_certs"] = true
+	}
+	return vars, nil
+}
+
+// proxyCommand returns the ssh command tunneling the connections to the machine through the bastion,
+// the host key of the bastion is not verified.
+func proxyCommand(dir string, bastion *corev1.Secret, port int, files map[string]string) (string, error) {
+	host := string(bastion.Data["host"])
+	if host == "" {
+		return "", errors.Errorf("bastion secret %s has no host", bastion.Name)
+	}
+	if port == 0 {
+		port = 22
+	}
+	command := []string{"ssh", "-W", "%h:%p", "-p", strconv.Itoa(port),
+		"-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null"}
+	prefix := []string{}
+	if privateKey := bastion.Data["privateKey"]; len(privateKey) > 0 {
+		keyFile := filepath.Join(dir, "id_bastion")
+		files[keyFile] = ensureTrailingNewline(string(privateKey))
+		command = append(command, "-i", keyFile)
+	} else if password := bastion.Data["password"]; len(password) > 0 {
+		passwordFile := filepath.Join(dir, "bastion_password")
+		files[passwordFile] = string(password)
+		prefix = []string{"sshpass", "-f", passwordFile}
+	} else {
+		return "", errors.Errorf("bastion secret %s has neither a privateKey nor a password", bastion.Name)
+	}
+	if username := string(bastion.Data["username"]); username != "" {
+		command = append(command, "-l", username)
+	}
+	command = append(command, host)
+	return strings.Join(append(prefix, command...), " "), nil
+}
+
+// knownHostsPattern returns the host pattern of a known_hosts entry, the port is only set when it is not 22.
+func knownHostsPattern(host string, port int) string {
+	if port == 22 {
+		return host
+	}
+	return fmt.Sprintf("[%s]:%d", host, port)
+}
+
+// ensureTrailingNewline returns s ending with a newline, ssh rejects private keys without it.
+func ensureTrailingNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
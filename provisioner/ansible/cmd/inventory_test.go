@@ -0,0 +1,164 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHostVars(t *testing.T) {
+	dir := "/tmp/work"
+	secret := func(data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds"}, Data: map[string][]byte{}}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		secret    *corev1.Secret
+		bastion   *corev1.Secret
+		opts      inventoryOptions
+		want      map[string]interface{}
+		wantFiles map[string]string
+		wantErr   bool
+	}{
+		{
+			name:   "private key",
+			secret: secret(map[string]string{"host": "10.0.0.1", "privateKey": "KEY"}),
+			opts:   inventoryOptions{Username: "ubuntu", Timeout: 30 * time.Second},
+			want: map[string]interface{}{
+				"ansible_host":                 "10.0.0.1",
+				"ansible_port":                 22,
+				"ansible_user":                 "ubuntu",
+				"ansible_ssh_private_key_file": filepath.Join(dir, "id_machine"),
+				"ansible_timeout":              30,
+				"ansible_ssh_common_args":      "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null",
+			},
+			wantFiles: map[string]string{filepath.Join(dir, "id_machine"): "KEY\n"},
+		},
+		{
+			name:   "password, become and pinned host key",
+			secret: secret(map[string]string{"host": "10.0.0.1", "username": "admin", "password": "secret", "hostKey": "ssh-ed25519 AAAA"}),
+			opts:   inventoryOptions{Port: 2222, Become: true, BecomeUser: "app", BecomePassword: true},
+			want: map[string]interface{}{
+				"ansible_host":            "10.0.0.1",
+				"ansible_port":            2222,
+				"ansible_user":            "admin",
+				"ansible_password":        "secret",
+				"ansible_become":          true,
+				"ansible_become_user":     "app",
+				"ansible_become_password": "secret",
+				"ansible_ssh_common_args": "-o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + filepath.Join(dir, "known_hosts"),
+			},
+			wantFiles: map[string]string{filepath.Join(dir, "known_hosts"): "[10.0.0.1]:2222 ssh-ed25519 AAAA\n"},
+		},
+		{
+			name:    "bastion",
+			secret:  secret(map[string]string{"host": "10.0.0.1", "username": "ubuntu", "privateKey": "KEY\n"}),
+			bastion: secret(map[string]string{"host": "192.168.0.1", "username": "jump", "privateKey": "BASTION"}),
+			opts:    inventoryOptions{BastionPort: 2200},
+			want: map[string]interface{}{
+				"ansible_host":                 "10.0.0.1",
+				"ansible_port":                 22,
+				"ansible_user":                 "ubuntu",
+				"ansible_ssh_private_key_file": filepath.Join(dir, "id_machine"),
+				"ansible_ssh_common_args": `-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null ` +
+					`-o ProxyCommand="ssh -W %h:%p -p 2200 -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null ` +
+					`-i /tmp/work/id_bastion -l jump 192.168.0.1"`,
+			},
+			wantFiles: map[string]string{
+				filepath.Join(dir, "id_machine"): "KEY\n",
+				filepath.Join(dir, "id_bastion"): "BASTION\n",
+			},
+		},
+		{
+			name:    "become password without password",
+			secret:  secret(map[string]string{"host": "10.0.0.1", "privateKey": "KEY"}),
+			opts:    inventoryOptions{Become: true, BecomePassword: true},
+			wantErr: true,
+		},
+		{
+			name:    "no credentials",
+			secret:  secret(map[string]string{"host": "10.0.0.1"}),
+			wantErr: true,
+		},
+		{
+			name:   "docker-exec unix socket",
+			secret: secret(map[string]string{"host": "unix:///var/run/docker.sock", "container": "3f1c"}),
+			opts:   inventoryOptions{ConnectorType: "docker-exec", Username: "root", Timeout: 30 * time.Second},
+			want: map[string]interface{}{
+				"ansible_connection":         "community.docker.docker_api",
+				"ansible_host":               "3f1c",
+				"ansible_docker_docker_host": "unix:///var/run/docker.sock",
+				"ansible_user":               "root",
+				"ansible_docker_timeout":     30,
+			},
+			wantFiles: map[string]string{},
+		},
+		{
+			name: "docker-exec tls",
+			secret: secret(map[string]string{"host": "tcp://10.0.0.1:2376", "container": "3f1c",
+				"caCert": "CA", "clientCert": "CERT", "clientKey": "KEY"}),
+			opts: inventoryOptions{ConnectorType: "docker-exec"},
+			want: map[string]interface{}{
+				"ansible_connection":            "community.docker.docker_api",
+				"ansible_host":                  "3f1c",
+				"ansible_docker_docker_host":    "tcp://10.0.0.1:2376",
+				"ansible_docker_tls":            true,
+				"ansible_docker_validate_certs": true,
+				"ansible_docker_ca_cert":        filepath.Join(dir, "docker_ca.pem"),
+				"ansible_docker_client_cert":    filepath.Join(dir, "docker_cert.pem"),
+				"ansible_docker_client_key":     filepath.Join(dir, "docker_key.pem"),
+			},
+			wantFiles: map[string]string{
+				filepath.Join(dir, "docker_ca.pem"):   "CA\n",
+				filepath.Join(dir, "docker_cert.pem"): "CERT\n",
+				filepath.Join(dir, "docker_key.pem"):  "KEY\n",
+			},
+		},
+		{
+			name:    "docker-exec without container",
+			secret:  secret(map[string]string{"host": "unix:///var/run/docker.sock"}),
+			opts:    inventoryOptions{ConnectorType: "docker-exec"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			files := map[string]string{}
+			vars, err := hostVars(dir, tt.secret, tt.bastion, tt.opts, files)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(vars).To(Equal(tt.want))
+			g.Expect(files).To(Equal(tt.wantFiles))
+		})
+	}
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main is the ansible provisioner, it runs ansible-playbook against the machine of a Build.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	"github.com/forge-build/forge/pkg/git"
+	"github.com/forge-build/forge/pkg/redact"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+const (
+	SSHTimeout = 2 * time.Minute
+
+	// TerminationMessagePath is the file the outcomes of the plays, or the issues found by a dry run,
+	// are written to, the controller reads it from the status of the container.
+	TerminationMessagePath = "/dev/termination-log"
+)
+
+var (
+	// Namespace is the namespace where the build is running
+	Namespace string
+	// Playbook is the path of the playbook to run, relative to the playbook directory or the repository
+	Playbook string
+	// PlaybookDir is the directory of the mounted configmap containing the playbook
+	PlaybookDir string
+	// GitURL is the URL of the repository containing the playbook, instead of PlaybookDir
+	GitURL string
+	// GitRef is the branch, tag or commit of the repository checked out
+	GitRef string
+	// GitSecretName is the name of the secret containing the username and password used to clone the repository
+	GitSecretName string
+	// RequirementsFile is the file of the roles and collections installed before running the playbook
+	RequirementsFile string
+	// ExtraVarsFile is the JSON file of the extra variables of the playbook
+	ExtraVarsFile string
+	// Tags are the comma separated tags of the tasks to run
+	Tags string
+	// SkipTags are the comma separated tags of the tasks to skip
+	SkipTags string
+	// ConnectorType is the type of the connector to the machine, ssh or docker-exec
+	ConnectorType string
+	// SSHCredentialsSecretName is the name of the secret containing the credentials
+	SSHCredentialsSecretName string
+	// SSHPort is the port to connect to on the machine
+	SSHPort int
+	// SSHUsername is the username used when the ssh-credentials secret has none
+	SSHUsername string
+	// SSHBastionSecretName is the name of the secret containing the credentials of the bastion host
+	SSHBastionSecretName string
+	// SSHBastionPort is the port to connect to on the bastion host
+	SSHBastionPort int
+	// SSHHostKeyFingerprints are the comma separated fingerprints of the accepted host keys
+	SSHHostKeyFingerprints string
+	// SSHSudo runs the tasks with become
+	SSHSudo bool
+	// SSHSudoUser is the user the tasks become
+	SSHSudoUser string
+	// SSHSudoPassword uses the password of the ssh-credentials secret as the become password
+	SSHSudoPassword bool
+	// SSHConnectTimeout is how long a single connection attempt may take
+	SSHConnectTimeout time.Duration
+	// WorkspaceSecretName is the name of the secret containing the presigned URLs of the workspace of the Build
+	WorkspaceSecretName string
+	// VariablesSecretName is the name of the secret containing the variables of the Build
+	VariablesSecretName string
+	// DryRun checks the syntax of the playbook instead of running it against the machine
+	DryRun bool
+)
+
+func main() {
+	ctrl.SetLogger(klog.Background())
+	klog.InitFlags(nil)
+
+	flag.StringVar(&Namespace, "namespace", "forge-core", "The Build namespace")
+	flag.StringVar(&Playbook, "playbook", "playbook.yml", "The path of the playbook to run, relative to --playbook-dir or to the root of the repository")
+	flag.StringVar(&PlaybookDir, "playbook-dir", "", "The directory of the mounted configmap containing the playbook")
+	flag.StringVar(&GitURL, "git-url", "", "The URL of the repository containing the playbook, instead of --playbook-dir")
+	flag.StringVar(&GitRef, "git-ref", "", "The branch, tag or commit of the repository checked out, defaults to the default branch")
+	flag.StringVar(&GitSecretName, "git-secret-name", "", "The name of secret containing the username and password used to clone the repository")
+	flag.StringVar(&RequirementsFile, "requirements-file", "", "The file of the roles and collections installed with ansible-galaxy before running the playbook")
+	flag.StringVar(&ExtraVarsFile, "extra-vars-file", "", "The JSON file of the extra variables of the playbook")
+	flag.StringVar(&Tags, "tags", "", "The comma separated tags of the tasks to run")
+	flag.StringVar(&SkipTags, "skip-tags", "", "The comma separated tags of the tasks to skip")
+	flag.StringVar(&ConnectorType, "connector-type", buildv1.ConnectorTypeSSH, "The type of the connector to the machine, ssh or docker-exec")
+	flag.StringVar(&SSHCredentialsSecretName, "ssh-credentials-secret-name", "", "The name of secret containing the ssh credentials")
+	flag.IntVar(&SSHPort, "ssh-port", 0, "The port to connect to on the machine, defaults to 22")
+	flag.StringVar(&SSHUsername, "ssh-username", "", "The username used when the ssh-credentials secret has none")
+	flag.StringVar(&SSHBastionSecretName, "ssh-bastion-secret-name", "", "The name of secret containing the credentials of the bastion host to connect through")
+	flag.IntVar(&SSHBastionPort, "ssh-bastion-port", 0, "The port to connect to on the bastion host, defaults to 22")
+	flag.StringVar(&SSHHostKeyFingerprints, "ssh-host-key-fingerprints", "", "The comma separated SHA256 fingerprints of the accepted host keys, any host key is accepted if empty")
+	flag.BoolVar(&SSHSudo, "ssh-sudo", false, "Run the tasks with become")
+	flag.StringVar(&SSHSudoUser, "ssh-sudo-user", "", "The user the tasks become, defaults to root")
+	flag.BoolVar(&SSHSudoPassword, "ssh-sudo-password", false, "Use the password of the ssh-credentials secret as the become password")
+	flag.DurationVar(&SSHConnectTimeout, "ssh-timeout", 0, "How long a single connection attempt may take, defaults to 10s")
+	flag.StringVar(&WorkspaceSecretName, "workspace-secret-name", "", "The name of secret containing the presigned URLs of the workspace exported to the playbook")
+	flag.StringVar(&VariablesSecretName, "variables-secret-name", "", "The name of secret containing the variables of the Build exported to the playbook")
+	flag.BoolVar(&DryRun, "dry-run", false, "Check the syntax of the playbook instead of running it against the machine")
+
+	flag.Parse()
+
+	ctrl.SetLogger(klog.NewKlogr())
+	logger := ctrl.Log.WithName("ansible-provisioner")
+	ctx := context.Background()
+
+	logger.Info("Starting ansible provisioner")
+
+	k8sClient, err := initClient()
+	if err != nil {
+		logger.Error(err, "Error creating Kubernetes client")
+		klog.Exit(err)
+	}
+
+	workDir, err := os.MkdirTemp("", "forge-ansible")
+	if err != nil {
+		logger.Error(err, "Error creating the work directory")
+		klog.Exit(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	playbookDir := PlaybookDir
+	if GitURL != "" {
+		var credentials map[string][]byte
+		if GitSecretName != "" {
+			logger.Info("Fetching the git secret", "secret", GitSecretName)
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: GitSecretName}, secret); err != nil {
+				logger.Error(err, "Error getting git secret")
+				klog.Exit(err)
+			}
+			credentials = secret.Data
+		}
+		playbookDir = filepath.Join(workDir, "repository")
+		logger.Info("Cloning the repository", "url", GitURL, "ref", GitRef)
+		if err := git.Clone(ctx, GitURL, GitRef, playbookDir, credentials); err != nil {
+			logger.Error(err, "Error cloning the repository")
+			klog.Exit(err)
+		}
+	}
+
+	env := ansibleEnv(workDir)
+	if RequirementsFile != "" {
+		logger.Info("Installing the requirements", "file", RequirementsFile)
+		if err := installRequirements(ctx, RequirementsFile, workDir, env); err != nil {
+			logger.Error(err, "Error installing the requirements")
+			klog.Exit(err)
+		}
+	}
+
+	if DryRun {
+		dryRun(ctx, logger, playbookDir, env)
+		return
+	}
+
+	logger.Info("Fetching the ssh-credentials secret")
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: SSHCredentialsSecretName}, secret); err != nil {
+		logger.Error(err, "Error getting secret")
+		klog.Exit(err)
+	}
+
+	var bastion *corev1.Secret
+	if SSHBastionSecretName != "" {
+		logger.Info("Fetching the bastion secret", "secret", SSHBastionSecretName)
+		bastion = &corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: SSHBastionSecretName}, bastion); err != nil {
+			logger.Error(err, "Error getting bastion secret")
+			klog.Exit(err)
+		}
+	}
+
+	secrets := redact.CredentialsValues(secret)
+	for _, name := range []string{WorkspaceSecretName, VariablesSecretName} {
+		if name == "" {
+			continue
+		}
+		logger.Info("Fetching the secret exported to the playbook", "secret", name)
+		exported := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: name}, exported); err != nil {
+			logger.Error(err, "Error getting secret", "secret", name)
+			klog.Exit(err)
+		}
+		for key, value := range exported.Data {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+		if name == VariablesSecretName {
+			secrets = append(secrets, redact.MaskedVariables(exported)...)
+		}
+	}
+	// The secrets are masked in the output of the playbook, so they are not leaked in the logs and the status of
+	// the provisioner.
+	redactor := redact.New(secrets...)
+	logger = redactor.Logger(logger)
+
+	if err := checkConnection(logger, secret, bastion); err != nil {
+		logger.Error(err, "Error connecting to the machine")
+		klog.Exit(redactor.Error(err))
+	}
+
+	inventory, err := writeInventory(workDir, secret, bastion, connectionOptions())
+	if err != nil {
+		logger.Error(err, "Error writing the inventory")
+		klog.Exit(redactor.Error(err))
+	}
+
+	statuses, err := runPlaybook(ctx, logger, playbookDir, inventory, env)
+	for i := range statuses {
+		statuses[i].Message = redactor.String(statuses[i].Message)
+	}
+	if statuses != nil {
+		if err := reportPlayStatuses(statuses); err != nil {
+			logger.Error(err, "Error reporting the outcomes of the plays")
+		}
+	}
+	if err != nil {
+		logger.Error(err, "Error running the playbook")
+		klog.Exit(redactor.Error(err))
+	}
+}
+
+// dryRun checks the syntax of the playbook and reports the issues found, it exits with an error if the playbook is invalid.
+func dryRun(ctx context.Context, logger logr.Logger, playbookDir string, env []string) {
+	logger.Info("Checking the playbook", "playbook", Playbook)
+	issues, err := syntaxCheck(ctx, playbookDir, env)
+	if len(issues) > 0 {
+		logger.Info("Issues found in the playbook", "issues", issues)
+		if err := os.WriteFile(TerminationMessagePath, []byte(strings.Join(issues, "\n")), 0o644); err != nil {
+			logger.Error(err, "Error reporting the issues")
+		}
+	}
+	if err != nil {
+		logger.Error(err, "Playbook check failed")
+		klog.Exit(err)
+	}
+	logger.Info("Playbook checked")
+}
+
+// checkConnection connects to the machine with the connector options, so the host key of the machine is verified
+// before ansible connects to it.
+func checkConnection(logger logr.Logger, secret, bastion *corev1.Secret) error {
+	if ConnectorType == buildv1.ConnectorTypeDockerExec {
+		connector, err := docker.NewConnector(secret, SSHUsername)
+		if err != nil {
+			return errors.Wrap(err, "Error creating docker-exec connector")
+		}
+		defer connector.Disconnect()
+		logger.Info("Connecting to the container via docker exec")
+		if _, err := connector.WaitForContainer(context.Background(), SSHTimeout); err != nil {
+			return errors.Wrap(err, "failed to connect to the container")
+		}
+		logger.Info("Container connection established")
+		return nil
+	}
+	sshClient, err := ssh.NewSSHClient(secret)
+	if err != nil {
+		return errors.Wrap(err, "Error creating SSH client")
+	}
+	sshClient.SetConnectorDefaults(SSHPort, SSHUsername)
+	sshClient.Options.ConnectTimeout = SSHConnectTimeout
+	if bastion != nil {
+		sshClient.Options.Bastion = ssh.NewBastion(bastion, SSHBastionPort)
+	}
+	if SSHHostKeyFingerprints != "" {
+		sshClient.Options.HostKeyFingerprints = strings.Split(SSHHostKeyFingerprints, ",")
+	}
+	logger.Info("Connecting to the machine via ssh")
+	if err := sshClient.WaitForSSH(SSHTimeout); err != nil {
+		return errors.Wrap(err, "failed to connect to the machine via ssh")
+	}
+	sshClient.Disconnect()
+	logger.Info("SSH connection established")
+	return nil
+}
+
+// connectionOptions returns the connection options of the inventory set by the flags.
+func connectionOptions() inventoryOptions {
+	return inventoryOptions{
+		ConnectorType:  ConnectorType,
+		Port:           SSHPort,
+		Username:       SSHUsername,
+		BastionPort:    SSHBastionPort,
+		Become:         SSHSudo,
+		BecomeUser:     SSHSudoUser,
+		BecomePassword: SSHSudoPassword,
+		Timeout:        SSHConnectTimeout,
+	}
+}
+
+func initClient() (client.Client, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(s))
+
+	return client.New(cfg, client.Options{Scheme: s})
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/ansible"
+)
+
+// playMessageBytes is the maximum size of the message of a failed play, the outcomes of all the plays
+// must fit in the termination message of the container.
+const playMessageBytes = 512
+
+// ansibleEnv returns the environment of the ansible commands, their state is kept in the work directory
+// as the container may have no writable home directory.
+func ansibleEnv(workDir string) []string {
+	return append(os.Environ(),
+		"HOME="+workDir,
+		"ANSIBLE_LOCAL_TEMP="+filepath.Join(workDir, "tmp"),
+		"ANSIBLE_ROLES_PATH="+filepath.Join(workDir, "roles"),
+		"ANSIBLE_COLLECTIONS_PATH="+filepath.Join(workDir, "collections"),
+		"ANSIBLE_RETRY_FILES_ENABLED=false",
+		"ANSIBLE_NOCOLOR=true",
+	)
+}
+
+// installRequirements installs the roles and the collections of the requirements file in the work directory.
+func installRequirements(ctx context.Context, file, workDir string, env []string) error {
+	commands := [][]string{
+		{"role", "install", "-r", file, "-p", filepath.Join(workDir, "roles")},
+		{"collection", "install", "-r", file, "-p", filepath.Join(workDir, "collections")},
+	}
+	for _, args := range commands {
+		cmd := exec.CommandContext(ctx, "ansible-galaxy", args...)
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "ansible-galaxy %s install failed: %s", args[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// playbookArgs returns the arguments of ansible-playbook common to the check and the run of the playbook.
+func playbookArgs(inventory string) []string {
+	args := []string{"-i", inventory}
+	if ExtraVarsFile != "" {
+		args = append(args, "--extra-vars", "@"+ExtraVarsFile)
+	}
+	if Tags != "" {
+		args = append(args, "--tags", Tags)
+	}
+	if SkipTags != "" {
+		args = append(args, "--skip-tags", SkipTags)
+	}
+	return append(args, Playbook)
+}
+
+// syntaxCheck checks the syntax of the playbook, it returns the lines of the output of ansible-playbook when it fails.
+func syntaxCheck(ctx context.Context, playbookDir string, env []string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "ansible-playbook", append([]string{"--syntax-check"}, playbookArgs(inventoryHost+",")...)...)
+	cmd.Dir = playbookDir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	issues := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			issues = append(issues, line)
+		}
+	}
+	return issues, errors.Wrap(err, "the syntax check of the playbook failed")
+}
+
+// runPlaybook runs the playbook against the machine of the inventory and returns the outcomes of its plays.
+// No outcome is returned when ansible-playbook reported none, e.g. the playbook could not be parsed.
+func runPlaybook(ctx context.Context, logger logr.Logger, playbookDir, inventory string, env []string) ([]ansible.PlayStatus, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "ansible-playbook", playbookArgs(inventory)...)
+	cmd.Dir = playbookDir
+	// The json callback prints the results of all the tasks once the playbook ran.
+	cmd.Env = append(env, "ANSIBLE_STDOUT_CALLBACK=json")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info("Running the playbook", "playbook", Playbook)
+	runErr := cmd.Run()
+	statuses, err := playStatuses(stdout.Bytes(), runErr == nil)
+	if err != nil {
+		logger.Error(err, "Could not read the results of the playbook")
+	}
+	for _, status := range statuses {
+		logger.Info("Play finished", "play", status.Name, "phase", status.Phase, "ok", status.Ok, "changed", status.Changed,
+			"failed", status.Failed, "skipped", status.Skipped, "unreachable", status.Unreachable, "message", status.Message)
+	}
+	if runErr != nil {
+		return statuses, errors.Wrapf(runErr, "ansible-playbook failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	logger.Info("Playbook ran")
+	return statuses, nil
+}
+
+// playbookResult is the output of the json stdout callback of ansible.
+type playbookResult struct {
+	Plays []struct {
+		Play struct {
+			Name string `json:"name"`
+		} `json:"play"`
+		Tasks []struct {
+			Task struct {
+				Name string `json:"name"`
+			} `json:"task"`
+			Hosts map[string]taskResult `json:"hosts"`
+		} `json:"tasks"`
+	} `json:"plays"`
+}
+
+// taskResult is the result of a task on a host.
+type taskResult struct {
+	Changed     bool            `json:"changed"`
+	Failed      bool            `json:"failed"`
+	Skipped     bool            `json:"skipped"`
+	Unreachable bool            `json:"unreachable"`
+	Msg         json.RawMessage `json:"msg"`
+}
+
+// playStatuses returns the outcomes of the plays of the output of the json callback. The failed tasks of a playbook
+// which succeeded have been ignored or rescued, its plays are completed.
+func playStatuses(output []byte, succeeded bool) ([]ansible.PlayStatus, error) {
+	// Warnings may be printed before the results.
+	if i := bytes.IndexByte(output, '{'); i > 0 {
+		output = output[i:]
+	}
+	result := &playbookResult{}
+	if err := json.Unmarshal(output, result); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the output of the json callback")
+	}
+
+	statuses := make([]ansible.PlayStatus, 0, len(result.Plays))
+	for _, play := range result.Plays {
+		status := ansible.PlayStatus{Name: play.Play.Name}
+		for _, task := range play.Tasks {
+			for _, host := range task.Hosts {
+				switch {
+				case host.Unreachable:
+					status.Unreachable++
+				case host.Failed:
+					status.Failed++
+				case host.Skipped:
+					status.Skipped++
+				default:
+					status.Ok++
+					if host.Changed {
+						status.Changed++
+					}
+				}
+				if (host.Unreachable || host.Failed) && status.Message == "" {
+					status.Message = truncate(fmt.Sprintf("task %s: %s", task.Task.Name, message(host.Msg)), playMessageBytes)
+				}
+			}
+		}
+		status.Phase = string(buildv1.ProvisionerStatusCompleted)
+		if !succeeded && status.Failed+status.Unreachable > 0 {
+			status.Phase = string(buildv1.ProvisionerStatusFailed)
+		} else {
+			status.Message = ""
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// message returns the msg of a task result, it is not always a string.
+func message(msg json.RawMessage) string {
+	s := ""
+	if err := json.Unmarshal(msg, &s); err == nil {
+		return s
+	}
+	return string(msg)
+}
+
+// truncate returns the last n bytes of s.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[len(s)-n:]
+	}
+	return s
+}
+
+// reportPlayStatuses writes the outcomes of the plays to the termination message of the container.
+func reportPlayStatuses(statuses []ansible.PlayStatus) error {
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(TerminationMessagePath, data, 0o644)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/forge-build/forge/provisioner/ansible"
+)
+
+func TestPlayStatuses(t *testing.T) {
+	output := `[WARNING]: provided hosts list is empty
+{
+  "plays": [
+    {
+      "play": {"name": "Install nginx"},
+      "tasks": [
+        {"task": {"name": "Update the cache"}, "hosts": {"forge": {"changed": true}}},
+        {"task": {"name": "Install"}, "hosts": {"forge": {"changed": false}}},
+        {"task": {"name": "Debug"}, "hosts": {"forge": {"skipped": true}}}
+      ]
+    },
+    {
+      "play": {"name": "Configure nginx"},
+      "tasks": [
+        {"task": {"name": "Template"}, "hosts": {"forge": {"failed": true, "msg": "template not found"}}},
+        {"task": {"name": "Restart"}, "hosts": {"forge": {"failed": true, "msg": ["a", "list"]}}}
+      ]
+    }
+  ],
+  "stats": {"forge": {"changed": 1, "failures": 1, "ok": 2, "skipped": 1, "unreachable": 0}}
+}`
+
+	tests := []struct {
+		name      string
+		succeeded bool
+		want      []ansible.PlayStatus
+	}{
+		{
+			name: "failed playbook",
+			want: []ansible.PlayStatus{
+				{Name: "Install nginx", Phase: "Completed", Ok: 2, Changed: 1, Skipped: 1},
+				{Name: "Configure nginx", Phase: "Failed", Failed: 2, Message: "task Template: template not found"},
+			},
+		},
+		{
+			name:      "failures ignored by the playbook",
+			succeeded: true,
+			want: []ansible.PlayStatus{
+				{Name: "Install nginx", Phase: "Completed", Ok: 2, Changed: 1, Skipped: 1},
+				{Name: "Configure nginx", Phase: "Completed", Failed: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			statuses, err := playStatuses([]byte(output), tt.succeeded)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(statuses).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("no results", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := playStatuses([]byte("ERROR! the playbook could not be found"), false)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
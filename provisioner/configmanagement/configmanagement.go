@@ -0,0 +1,200 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configmanagement adapts the built-in/chef-solo and built-in/puppet-apply provisioners to the steps of
+// a built-in/shell provisioner: their source is copied to the machine, where chef-solo or puppet apply runs.
+package configmanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+const (
+	// StagingDir is the directory of the machine the source of a provisioner is copied to, it is removed once
+	// the provisioner ran so it does not end up in the image.
+	StagingDir = "/tmp/forge-config-management"
+
+	// chefConfigFile and chefNodeFile are the configuration and the node attributes of chef-solo,
+	// added to the source.
+	chefConfigFile = ".forge/solo.rb"
+	chefNodeFile   = ".forge/node.json"
+
+	defaultCookbookPath = "cookbooks"
+	defaultManifestPath = "manifests/site.pp"
+	defaultModulePath   = "modules"
+)
+
+// factPattern matches the names of the facts, which are exported as environment variables.
+var factPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ChefSoloSteps returns the steps copying the source of the provisioner to the machine and running chef-solo,
+// files are the files of its ConfigMap by path.
+func ChefSoloSteps(spec *buildv1.ChefSoloSpec, files map[string]string) ([]shell.Step, error) {
+	node := map[string]interface{}{}
+	if spec.Attributes != "" {
+		if err := json.Unmarshal([]byte(spec.Attributes), &node); err != nil {
+			return nil, forgeerrors.ConfigErrorf("the attributes of chef-solo are not a JSON object: %v", err)
+		}
+	}
+	node["run_list"] = spec.RunList
+	nodeJSON, err := json.Marshal(node)
+	if err != nil {
+		return nil, forgeerrors.ConfigErrorf("failed to encode the node of chef-solo: %v", err)
+	}
+
+	cookbookPaths := spec.CookbookPaths
+	if len(cookbookPaths) == 0 {
+		cookbookPaths = []string{defaultCookbookPath}
+	}
+	quoted := make([]string, 0, len(cookbookPaths))
+	for _, p := range cookbookPaths {
+		quoted = append(quoted, rubyQuote(staged(p)))
+	}
+	config := []string{
+		fmt.Sprintf("cookbook_path [%s]", strings.Join(quoted, ", ")),
+		fmt.Sprintf("file_cache_path %s", rubyQuote(staged(".forge/cache"))),
+	}
+	if spec.RolesPath != "" {
+		config = append(config, fmt.Sprintf("role_path %s", rubyQuote(staged(spec.RolesPath))))
+	}
+	if spec.DataBagsPath != "" {
+		config = append(config, fmt.Sprintf("data_bag_path %s", rubyQuote(staged(spec.DataBagsPath))))
+	}
+
+	generated := map[string]string{
+		chefConfigFile: strings.Join(config, "\n") + "\n",
+		chefNodeFile:   string(nodeJSON),
+	}
+	run := fmt.Sprintf("cd %s\nchef-solo --chef-license accept-silent --no-color --config %s --json-attributes %s",
+		ssh.Quote(StagingDir), ssh.Quote(staged(chefConfigFile)), ssh.Quote(staged(chefNodeFile)))
+	return steps(&spec.Source, files, generated, tool{name: "chef-solo", command: "chef-solo"}, spec.InstallCommand, run), nil
+}
+
+// PuppetApplySteps returns the steps copying the source of the provisioner to the machine and applying its manifest
+// with puppet apply, files are the files of its ConfigMap by path.
+func PuppetApplySteps(spec *buildv1.PuppetApplySpec, files map[string]string) ([]shell.Step, error) {
+	manifest := spec.ManifestPath
+	if manifest == "" {
+		manifest = defaultManifestPath
+	}
+	modulePaths := spec.ModulePaths
+	if len(modulePaths) == 0 {
+		modulePaths = []string{defaultModulePath}
+	}
+	staging := make([]string, 0, len(modulePaths))
+	for _, p := range modulePaths {
+		staging = append(staging, staged(p))
+	}
+
+	facts := make([]string, 0, len(spec.Facts))
+	for name := range spec.Facts {
+		if !factPattern.MatchString(name) {
+			return nil, forgeerrors.ConfigErrorf("the fact %q is not a valid environment variable name", name)
+		}
+		facts = append(facts, name)
+	}
+	sort.Strings(facts)
+
+	command := []string{}
+	for _, name := range facts {
+		command = append(command, fmt.Sprintf("FACTER_%s=%s", name, ssh.Quote(spec.Facts[name])))
+	}
+	command = append(command, "puppet", "apply", "--detailed-exitcodes", "--color=false",
+		"--modulepath", ssh.Quote(strings.Join(staging, ":")))
+	if spec.HieraConfigPath != "" {
+		command = append(command, "--hiera_config", ssh.Quote(staged(spec.HieraConfigPath)))
+	}
+	for _, arg := range spec.ExtraArguments {
+		command = append(command, ssh.Quote(arg))
+	}
+	command = append(command, ssh.Quote(staged(manifest)))
+
+	run := strings.Join([]string{
+		"cd " + ssh.Quote(StagingDir),
+		"code=0",
+		strings.Join(command, " ") + " || code=$?",
+		"# puppet apply exits with 2 when it changed the machine.",
+		`if [ "$code" -ne 0 ] && [ "$code" -ne 2 ]; then exit "$code"; fi`,
+	}, "\n")
+	// The puppet packages install the command in a directory which is not in the PATH of sudo.
+	puppet := tool{name: "puppet-apply", command: "puppet", env: `export PATH="$PATH:/opt/puppetlabs/bin"`}
+	return steps(&spec.Source, files, nil, puppet, spec.InstallCommand, run), nil
+}
+
+// tool is the configuration management tool run by a provisioner.
+type tool struct {
+	// name is the name of the step running the tool.
+	name string
+	// command is the command checked to decide whether the tool must be installed.
+	command string
+	// env prepares the environment of the scripts, if set.
+	env string
+}
+
+// script returns the content of a script running the commands in the environment of the tool.
+func (t tool) script(commands string) string {
+	if t.env == "" {
+		return commands
+	}
+	return t.env + "\n" + commands
+}
+
+// steps returns the steps copying the source, installing the tool when missing, running it and removing the source.
+func steps(source *buildv1.ConfigManagementSource, files, generated map[string]string, t tool, install, run string) []shell.Step {
+	src := &shell.Source{Destination: StagingDir, Files: map[string]string{}}
+	for p, content := range files {
+		src.Files[p] = content
+	}
+	for p, content := range generated {
+		src.Files[p] = content
+	}
+	if g := source.Git; g != nil {
+		src.Git = &shell.GitSource{URL: g.URL, Ref: g.Ref}
+		if g.SecretRef != nil {
+			src.Git.SecretName = g.SecretRef.Name
+		}
+	}
+
+	result := []shell.Step{{Name: "source", Source: src}}
+	if install != "" {
+		script := t.script(fmt.Sprintf("if ! command -v %s >/dev/null 2>&1; then\n%s\nfi", t.command, install))
+		result = append(result, shell.Step{Name: "install", Scripts: []shell.Script{{Name: "install", Content: script}}})
+	}
+	return append(result,
+		shell.Step{Name: t.name, Scripts: []shell.Script{{Name: t.name, Content: t.script(run)}}},
+		shell.Step{Name: "cleanup", Scripts: []shell.Script{{Name: "cleanup", Content: "rm -rf " + ssh.Quote(StagingDir)}}},
+	)
+}
+
+// staged returns the path of the machine of the given path of the source.
+func staged(p string) string {
+	return path.Join(StagingDir, p)
+}
+
+// rubyQuote returns s as a single quoted ruby string.
+func rubyQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
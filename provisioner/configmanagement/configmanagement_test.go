@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmanagement
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+func TestChefSoloSteps(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.ChefSoloSpec{
+		Source: buildv1.ConfigManagementSource{Git: &buildv1.AnsibleGitSource{
+			URL:       "https://github.com/example/cookbooks.git",
+			Ref:       "v1",
+			SecretRef: &corev1.LocalObjectReference{Name: "git"},
+		}},
+		RolesPath:      "roles",
+		RunList:        []string{"role[web]"},
+		Attributes:     `{"nginx": {"port": 8080}}`,
+		InstallCommand: "curl -L https://omnitruck.chef.io/install.sh | bash",
+	}
+	steps, err := ChefSoloSteps(spec, map[string]string{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	names := []string{}
+	for _, s := range steps {
+		names = append(names, s.Name)
+	}
+	g.Expect(names).To(Equal([]string{"source", "install", "chef-solo", "cleanup"}))
+	g.Expect(steps[0].Source).To(Equal(&shell.Source{
+		Destination: StagingDir,
+		Files: map[string]string{
+			chefConfigFile: "cookbook_path ['/tmp/forge-config-management/cookbooks']\n" +
+				"file_cache_path '/tmp/forge-config-management/.forge/cache'\n" +
+				"role_path '/tmp/forge-config-management/roles'\n",
+			chefNodeFile: `{"nginx":{"port":8080},"run_list":["role[web]"]}`,
+		},
+		Git: &shell.GitSource{URL: "https://github.com/example/cookbooks.git", Ref: "v1", SecretName: "git"},
+	}))
+	g.Expect(steps[1].Scripts[0].Content).To(HavePrefix("if ! command -v chef-solo >/dev/null 2>&1; then\n"))
+	g.Expect(steps[2].Scripts[0].Content).To(Equal("cd '/tmp/forge-config-management'\n" +
+		"chef-solo --chef-license accept-silent --no-color --config '/tmp/forge-config-management/.forge/solo.rb' " +
+		"--json-attributes '/tmp/forge-config-management/.forge/node.json'"))
+	g.Expect(steps[3].Scripts[0].Content).To(Equal("rm -rf '/tmp/forge-config-management'"))
+
+	spec.Attributes = `["not", "an", "object"]`
+	_, err = ChefSoloSteps(spec, nil)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+}
+
+func TestPuppetApplySteps(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.PuppetApplySpec{
+		Source:          buildv1.ConfigManagementSource{ConfigMapRef: &corev1.LocalObjectReference{Name: "manifests"}},
+		ModulePaths:     []string{"modules", "site-modules"},
+		HieraConfigPath: "hiera.yaml",
+		Facts:           map[string]string{"role": "web", "env": "it's prod"},
+		ExtraArguments:  []string{"--noop"},
+	}
+	files := map[string]string{"manifests/site.pp": "include nginx"}
+	steps, err := PuppetApplySteps(spec, files)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(steps).To(HaveLen(3))
+	g.Expect(steps[0].Source).To(Equal(&shell.Source{Destination: StagingDir, Files: files}))
+	g.Expect(steps[1].Name).To(Equal("puppet-apply"))
+	g.Expect(steps[1].Scripts[0].Content).To(Equal(`export PATH="$PATH:/opt/puppetlabs/bin"` + "\n" +
+		"cd '/tmp/forge-config-management'\n" +
+		"code=0\n" +
+		`FACTER_env='it'\''s prod' FACTER_role='web' puppet apply --detailed-exitcodes --color=false ` +
+		"--modulepath '/tmp/forge-config-management/modules:/tmp/forge-config-management/site-modules' " +
+		"--hiera_config '/tmp/forge-config-management/hiera.yaml' '--noop' " +
+		"'/tmp/forge-config-management/manifests/site.pp' || code=$?\n" +
+		"# puppet apply exits with 2 when it changed the machine.\n" +
+		`if [ "$code" -ne 0 ] && [ "$code" -ne 2 ]; then exit "$code"; fi`))
+
+	spec.Facts = map[string]string{"server-role": "web"}
+	_, err = PuppetApplySteps(spec, files)
+	g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package external implements the external provisioners, which are run by the Job or the webhook of the
+// ProvisionerClass they reference, so third parties can add provisioners without changing the core controller.
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// GetClass returns the ProvisionerClass of the external provisioner.
+func GetClass(ctx context.Context, c client.Client, spec *buildv1.ProvisionerSpec) (*buildv1.ProvisionerClass, error) {
+	if spec.Class == "" {
+		return nil, forgeerrors.ConfigErrorf("external provisioner %q has no class", spec.Name)
+	}
+	class := &buildv1.ProvisionerClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: spec.Class}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, forgeerrors.ConfigErrorf("ProvisionerClass %s of provisioner %q not found", spec.Class, spec.Name)
+		}
+		return nil, errors.Wrapf(err, "failed to get ProvisionerClass %s", spec.Class)
+	}
+	if (class.Spec.Job == nil) == (class.Spec.Webhook == nil) {
+		return nil, forgeerrors.ConfigErrorf("ProvisionerClass %s must have either a job or a webhook", class.Name)
+	}
+	return class, nil
+}
+
+// CheckParameters checks the parameters of the provisioner against the schema of its class.
+func CheckParameters(class *buildv1.ProvisionerClass, spec *buildv1.ProvisionerSpec) error {
+	if class.Spec.Schema == nil {
+		return nil
+	}
+	schema := &apiextensionsv1.JSONSchemaProps{}
+	if err := json.Unmarshal(class.Spec.Schema.Raw, schema); err != nil {
+		return forgeerrors.ConfigErrorf("invalid schema of ProvisionerClass %s: %v", class.Name, err)
+	}
+	var parameters interface{} = map[string]interface{}{}
+	if spec.Parameters != nil && len(spec.Parameters.Raw) > 0 {
+		if err := json.Unmarshal(spec.Parameters.Raw, &parameters); err != nil {
+			return forgeerrors.ConfigErrorf("invalid parameters of provisioner %q: %v", spec.Name, err)
+		}
+	}
+	if problems := validate(schema, parameters, "parameters"); len(problems) > 0 {
+		return forgeerrors.ConfigErrorf("the parameters of provisioner %q do not match the schema of ProvisionerClass %s: %s",
+			spec.Name, class.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
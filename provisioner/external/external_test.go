@@ -0,0 +1,202 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const schema = `{
+	"type": "object",
+	"required": ["path"],
+	"additionalProperties": false,
+	"properties": {
+		"path": {"type": "string"},
+		"engine": {"type": "string", "enum": ["kv1", "kv2"]},
+		"keys": {"type": "array", "items": {"type": "string"}},
+		"ttl": {"type": "integer"}
+	}
+}`
+
+func TestCheckParameters(t *testing.T) {
+	class := &buildv1.ProvisionerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault"},
+		Spec:       buildv1.ProvisionerClassSpec{Schema: &apiextensionsv1.JSON{Raw: []byte(schema)}},
+	}
+
+	testcases := []struct {
+		name        string
+		parameters  string
+		expectedErr string
+	}{
+		{
+			name:       "valid",
+			parameters: `{"path": "secret/ci", "engine": "kv2", "keys": ["token"], "ttl": 3600}`,
+		},
+		{
+			name:        "missing required",
+			parameters:  `{"engine": "kv2"}`,
+			expectedErr: "parameters.path: is required",
+		},
+		{
+			name:        "no parameters",
+			expectedErr: "parameters.path: is required",
+		},
+		{
+			name:        "wrong type",
+			parameters:  `{"path": "secret/ci", "ttl": 1.5}`,
+			expectedErr: "parameters.ttl: must be of type integer",
+		},
+		{
+			name:        "not in enum",
+			parameters:  `{"path": "secret/ci", "engine": "kv3"}`,
+			expectedErr: "parameters.engine: must be one of the enum values",
+		},
+		{
+			name:        "wrong item type",
+			parameters:  `{"path": "secret/ci", "keys": [1]}`,
+			expectedErr: "parameters.keys[0]: must be of type string",
+		},
+		{
+			name:        "unknown property",
+			parameters:  `{"path": "secret/ci", "role": "ci"}`,
+			expectedErr: "parameters.role: is not allowed",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := &buildv1.ProvisionerSpec{Name: "seed", Type: buildv1.ProvisionerTypeExternal, Class: "vault"}
+			if tc.parameters != "" {
+				spec.Parameters = &apiextensionsv1.JSON{Raw: []byte(tc.parameters)}
+			}
+			err := CheckParameters(class, spec)
+			if tc.expectedErr == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(ContainSubstring(tc.expectedErr)))
+			g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+		})
+	}
+}
+
+func TestGetClass(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	jobClass := &buildv1.ProvisionerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault"},
+		Spec:       buildv1.ProvisionerClassSpec{Job: &buildv1.ProvisionerClassJob{Image: "example.com/vault-seed:v1"}},
+	}
+	emptyClass := &buildv1.ProvisionerClass{ObjectMeta: metav1.ObjectMeta{Name: "empty"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(jobClass, emptyClass).Build()
+
+	class, err := GetClass(context.Background(), c, &buildv1.ProvisionerSpec{Name: "seed", Class: "vault"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(class.Spec.Job.Image).To(Equal("example.com/vault-seed:v1"))
+
+	for _, name := range []string{"empty", "missing"} {
+		_, err = GetClass(context.Background(), c, &buildv1.ProvisionerSpec{Name: "seed", Class: name})
+		g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	}
+}
+
+func TestWebhookReconcile(t *testing.T) {
+	responses := []buildv1.ProvisionResponse{}
+	requests := []buildv1.ProvisionRequest{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := buildv1.ProvisionRequest{}
+		_ = json.Unmarshal(body, &request)
+		requests = append(requests, request)
+		response := responses[0]
+		responses = responses[1:]
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	class := &buildv1.ProvisionerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault"},
+		Spec:       buildv1.ProvisionerClassSpec{Webhook: &buildv1.ProvisionerClassWebhook{URL: server.URL}},
+	}
+	webhook := &Webhook{HTTPClient: server.Client()}
+
+	t.Run("completed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+		spec := &buildv1.ProvisionerSpec{Name: "seed", Type: buildv1.ProvisionerTypeExternal, Class: "vault",
+			Parameters: &apiextensionsv1.JSON{Raw: []byte(`{"path":"secret/ci"}`)}}
+		responses = []buildv1.ProvisionResponse{
+			{Phase: buildv1.ProvisionerStatusRunning, Message: "seeding", RequeueAfterSeconds: ptr.To[int32](5)},
+			{Phase: buildv1.ProvisionerStatusCompleted, Message: "seeded 3 secrets"},
+		}
+
+		res, err := webhook.Reconcile(context.Background(), build, spec, class)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter.Seconds()).To(BeEquivalentTo(5))
+		g.Expect(*spec.Status).To(Equal(buildv1.ProvisionerStatusRunning))
+		g.Expect(build.Status.Provisioners[0].Message).To(Equal("seeding"))
+
+		_, err = webhook.Reconcile(context.Background(), build, spec, class)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(*spec.Status).To(Equal(buildv1.ProvisionerStatusCompleted))
+		g.Expect(build.Status.Provisioners[0].Message).To(Equal("seeded 3 secrets"))
+		g.Expect(build.Status.Provisioners[0].CompletedAt).ToNot(BeNil())
+
+		// The same run of the provisioner is reported to the webhook.
+		g.Expect(requests[0].UUID).To(Equal(requests[1].UUID))
+		g.Expect(requests[1].Build.Name).To(Equal("ubuntu"))
+		g.Expect(string(requests[1].Parameters.Raw)).To(Equal(`{"path":"secret/ci"}`))
+
+		// The webhook is not called once the provisioner completed.
+		_, err = webhook.Reconcile(context.Background(), build, spec, class)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(requests).To(HaveLen(2))
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+		spec := &buildv1.ProvisionerSpec{Name: "seed", Type: buildv1.ProvisionerTypeExternal, Class: "vault"}
+		responses = []buildv1.ProvisionResponse{{Phase: buildv1.ProvisionerStatusFailed, Message: "permission denied"}}
+
+		_, err := webhook.Reconcile(context.Background(), build, spec, class)
+		g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTerminal))
+		g.Expect(*spec.FailureReason).To(Equal(WebhookFailedReason))
+		g.Expect(*spec.FailureMessage).To(Equal("permission denied"))
+	})
+}
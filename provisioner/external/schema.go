@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// validate checks value, decoded from JSON, against the type, properties, required, additionalProperties, enum
+// and items of the schema. It returns the problems found, prefixed by the path of the values.
+func validate(schema *apiextensionsv1.JSONSchemaProps, value interface{}, path string) []string {
+	if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields && schema.Type == "" {
+		return nil
+	}
+	if schema.Type != "" && !hasType(value, schema.Type) {
+		return []string{fmt.Sprintf("%s: must be of type %s", path, schema.Type)}
+	}
+
+	var problems []string
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		problems = append(problems, fmt.Sprintf("%s: must be one of the enum values", path))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: is required", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := schema.Properties[name]; ok {
+				problems = append(problems, validate(&property, v[name], path+"."+name)...)
+				continue
+			}
+			additional := schema.AdditionalProperties
+			switch {
+			case additional != nil && additional.Schema != nil:
+				problems = append(problems, validate(additional.Schema, v[name], path+"."+name)...)
+			case additional != nil && !additional.Allows:
+				problems = append(problems, fmt.Sprintf("%s.%s: is not allowed", path, name))
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i := range v {
+				problems = append(problems, validate(schema.Items.Schema, v[i], fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// hasType returns true if the JSON value is of the OpenAPI type.
+func hasType(value interface{}, t string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	}
+	return false
+}
+
+// inEnum returns true if the JSON value is one of the enum values.
+func inEnum(value interface{}, enum []apiextensionsv1.JSON) bool {
+	for _, e := range enum {
+		var allowed interface{}
+		if err := json.Unmarshal(e.Raw, &allowed); err == nil && reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/util"
+)
+
+const (
+	// WebhookFailedReason is the failure reason of an external provisioner reported failed by its webhook.
+	WebhookFailedReason = "WebhookFailed"
+
+	// DefaultWebhookTimeout is how long a call to a webhook may take when the class does not set a timeout.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultRequeueAfter is when a webhook is called again while its provisioner runs, when it does not say.
+	DefaultRequeueAfter = 10 * time.Second
+
+	// maxResponseSize is the maximum size of the response of a webhook.
+	maxResponseSize = 1 << 20
+)
+
+// Webhook calls the webhooks of the ProvisionerClasses.
+type Webhook struct {
+	// HTTPClient sends the requests, a client trusting the CA bundle of the class is used if nil.
+	HTTPClient *http.Client
+}
+
+// Reconcile runs an external provisioner with the webhook of its class. The webhook is called until it reports the
+// provisioner completed or failed, the Build is requeued meanwhile. The failures to reach the webhook are retried.
+func (w *Webhook) Reconcile(ctx context.Context, build *buildv1.Build, spec *buildv1.ProvisionerSpec, class *buildv1.ProvisionerClass) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+			spec.Status = ptr.To(buildv1.ProvisionerStatusRunning)
+			util.RecordProvisionerStatus(build, spec).StartedAt = ptr.To(metav1.Now())
+		}
+
+		resp, err := w.call(ctx, class, NewRequest(build, spec))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		switch resp.Phase {
+		case buildv1.ProvisionerStatusRunning:
+			util.RecordProvisionerStatus(build, spec).Message = resp.Message
+			requeueAfter := DefaultRequeueAfter
+			if resp.RequeueAfterSeconds != nil && *resp.RequeueAfterSeconds > 0 {
+				requeueAfter = time.Duration(*resp.RequeueAfterSeconds) * time.Second
+			}
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		case buildv1.ProvisionerStatusCompleted:
+			spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+		case buildv1.ProvisionerStatusFailed:
+			spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+			spec.FailureReason = ptr.To(WebhookFailedReason)
+			spec.FailureMessage = ptr.To(resp.Message)
+		default:
+			return ctrl.Result{}, forgeerrors.ConfigErrorf("the webhook of ProvisionerClass %s responded with phase %q, one of Running, Completed or Failed is expected",
+				class.Name, resp.Phase)
+		}
+		record := util.RecordProvisionerStatus(build, spec)
+		record.Message = resp.Message
+		record.CompletedAt = ptr.To(metav1.Now())
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// NewRequest returns the request sent to the webhook of the class of the provisioner.
+func NewRequest(build *buildv1.Build, spec *buildv1.ProvisionerSpec) *buildv1.ProvisionRequest {
+	return &buildv1.ProvisionRequest{
+		TypeMeta:    metav1.TypeMeta{APIVersion: buildv1.GroupVersion.String(), Kind: "ProvisionRequest"},
+		Build:       corev1.ObjectReference{Namespace: build.Namespace, Name: build.Name, UID: build.UID},
+		Provisioner: spec.Name,
+		UUID:        ptr.Deref(spec.UUID, ""),
+		Parameters:  spec.Parameters,
+		Connector:   build.Spec.Connector,
+	}
+}
+
+// call posts the request to the webhook of the class and returns its response. The server errors are retried,
+// the client errors are not.
+func (w *Webhook) call(ctx context.Context, class *buildv1.ProvisionerClass, request *buildv1.ProvisionRequest) (*buildv1.ProvisionResponse, error) {
+	webhook := class.Spec.Webhook
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		var err error
+		if httpClient, err = newHTTPClient(webhook.CABundle); err != nil {
+			return nil, forgeerrors.ConfigErrorf("invalid CA bundle of ProvisionerClass %s: %v", class.Name, err)
+		}
+	}
+	timeout := DefaultWebhookTimeout
+	if webhook.TimeoutSeconds != nil {
+		timeout = time.Duration(*webhook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the ProvisionRequest")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid webhook URL of ProvisionerClass %s: %v", class.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to call the webhook of ProvisionerClass %s", class.Name))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to read the response of the webhook of ProvisionerClass %s", class.Name))
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("the webhook of ProvisionerClass %s responded with status %s", class.Name, resp.Status)
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return nil, forgeerrors.NewTransient(err)
+		}
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	response := &buildv1.ProvisionResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, forgeerrors.ConfigErrorf("invalid response of the webhook of ProvisionerClass %s: %v", class.Name, err)
+	}
+	return response, nil
+}
+
+// newHTTPClient returns a client trusting the CA bundle, or the system roots when it is empty.
+func newHTTPClient(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return http.DefaultClient, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("no certificate found")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
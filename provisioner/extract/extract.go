@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package extract implements the built-in/extract provisioner, which downloads files from the infrastructure
+// machine, e.g. build logs or package manifests, and stores them in a ConfigMap, a Secret, an object storage
+// bucket or an OCI artifact.
+package extract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// MaxFileSize is the maximum size of a file downloaded by the provisioner, the content is held in memory.
+const MaxFileSize = 64 << 20
+
+// missing is the output of the size command for a file which does not exist.
+const missing = "missing"
+
+// Machine runs commands on and downloads files from the infrastructure machine, it is implemented by the SSH clients.
+type Machine interface {
+	Run(command string, stdout io.Writer, stderr io.Writer) error
+	Download(dst io.WriteCloser, remotePath string) error
+}
+
+// File is a file downloaded from the machine.
+type File struct {
+	// Key is the name the file is stored under.
+	Key string
+	// Path is the path of the file on the machine.
+	Path string
+	// Content is the content of the file, it is nil when the file is missing.
+	Content []byte
+	// Missing is true when the optional file does not exist on the machine.
+	Missing bool
+}
+
+// Status returns the outcome of the file, before it is stored.
+func (f *File) Status() buildv1.ExtractedFileStatus {
+	status := buildv1.ExtractedFileStatus{Path: f.Path, Key: f.Key, Missing: f.Missing}
+	if !f.Missing {
+		sum := sha256.Sum256(f.Content)
+		status.SHA256 = hex.EncodeToString(sum[:])
+		status.Size = int64(len(f.Content))
+	}
+	return status
+}
+
+// Download downloads the file from the machine. The file is copied to a temporary file readable by the connector's
+// user first, so that the connector's sudo applies when it is read. A missing file fails unless it is optional.
+func Download(machine Machine, f *buildv1.ExtractFile) (*File, error) {
+	file := &File{Key: f.GetKey(), Path: f.Path}
+	src := ssh.Quote(f.Path)
+	out, err := runCommand(machine, fmt.Sprintf("if [ -f %s ]; then stat -c %%s -- %s; else echo %s; fi", src, src, missing))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check %s", f.Path)
+	}
+	if out == missing {
+		if !f.Optional {
+			return nil, forgeerrors.ConfigErrorf("%s does not exist on the machine", f.Path)
+		}
+		file.Missing = true
+		return file, nil
+	}
+	size, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid size of %s: %q", f.Path, out)
+	}
+	if size > MaxFileSize {
+		return nil, forgeerrors.ConfigErrorf("%s is larger than %d bytes", f.Path, MaxFileSize)
+	}
+
+	pathSum := sha256.Sum256([]byte(f.Path))
+	tmp := fmt.Sprintf("/tmp/.forge-extract-%s", hex.EncodeToString(pathSum[:8]))
+	if _, err := runCommand(machine, fmt.Sprintf("install -m 0644 %s %s", src, tmp)); err != nil {
+		return nil, errors.Wrapf(err, "failed to copy %s", f.Path)
+	}
+	content := &buffer{}
+	err = machine.Download(content, tmp)
+	// The temporary file is removed even if the download failed.
+	if _, rmErr := runCommand(machine, fmt.Sprintf("rm -f %s", tmp)); err == nil && rmErr != nil {
+		err = errors.Wrapf(rmErr, "failed to remove the copy of %s", f.Path)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", f.Path)
+	}
+	if int64(content.Len()) != size {
+		return nil, errors.Errorf("downloaded %d bytes of %s, %d are expected", content.Len(), f.Path, size)
+	}
+	file.Content = content.Bytes()
+	return file, nil
+}
+
+// buffer is a bytes.Buffer closed by the Download of the machine.
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Close() error {
+	return nil
+}
+
+// runCommand runs the command on the machine and returns its trimmed output.
+func runCommand(machine Machine, command string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := machine.Run(command, stdout, stderr); err != nil {
+		return "", errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// fakeMachine holds files, the commands run are recorded and the temporary copies are served by Download.
+type fakeMachine struct {
+	files    map[string]string
+	commands []string
+	copies   map[string]string
+}
+
+func (f *fakeMachine) Run(command string, stdout io.Writer, _ io.Writer) error {
+	f.commands = append(f.commands, command)
+	switch {
+	case strings.HasPrefix(command, "if [ -f"):
+		path := strings.Trim(strings.Fields(command)[3], "'")
+		content, ok := f.files[path]
+		if !ok {
+			_, err := io.WriteString(stdout, "missing\n")
+			return err
+		}
+		_, err := io.WriteString(stdout, strconv.Itoa(len(content))+"\n")
+		return err
+	case strings.HasPrefix(command, "install"):
+		fields := strings.Fields(command)
+		if f.copies == nil {
+			f.copies = map[string]string{}
+		}
+		f.copies[fields[4]] = f.files[strings.Trim(fields[3], "'")]
+	}
+	return nil
+}
+
+func (f *fakeMachine) Download(dst io.WriteCloser, remotePath string) error {
+	defer dst.Close()
+	_, err := io.WriteString(dst, f.copies[remotePath])
+	return err
+}
+
+func TestDownload(t *testing.T) {
+	machine := &fakeMachine{files: map[string]string{"/var/log/build.log": "done\n"}}
+
+	tests := []struct {
+		name        string
+		file        buildv1.ExtractFile
+		expected    *File
+		expectedErr bool
+	}{
+		{
+			name:     "file",
+			file:     buildv1.ExtractFile{Path: "/var/log/build.log"},
+			expected: &File{Key: "build.log", Path: "/var/log/build.log", Content: []byte("done\n")},
+		},
+		{
+			name:     "missing optional file",
+			file:     buildv1.ExtractFile{Path: "/var/log/dpkg.log", Key: "packages.log", Optional: true},
+			expected: &File{Key: "packages.log", Path: "/var/log/dpkg.log", Missing: true},
+		},
+		{
+			name:        "missing file",
+			file:        buildv1.ExtractFile{Path: "/var/log/dpkg.log"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			f, err := Download(machine, &tt.file)
+			if tt.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(f).To(Equal(tt.expected))
+		})
+	}
+	// The temporary copy of the file is removed.
+	g := NewWithT(t)
+	g.Expect(machine.commands).To(ContainElement(HavePrefix("rm -f /tmp/.forge-extract-")))
+}
+
+func TestStore(t *testing.T) {
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Name: "ubuntu", Namespace: "default", UID: "uid"}}
+	files := []*File{
+		{Key: "build.log", Path: "/var/log/build.log", Content: []byte("done\n")},
+		{Key: "initrd", Path: "/boot/initrd", Content: []byte{0xff, 0xfe}},
+		{Key: "dpkg.log", Path: "/var/log/dpkg.log", Missing: true},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = buildv1.AddToScheme(scheme)
+
+	t.Run("configmap", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		dest := &buildv1.ExtractDestination{ConfigMapRef: &corev1.LocalObjectReference{Name: "ubuntu-files"}}
+		statuses, err := (&Storer{Client: c}).Store(context.Background(), build, dest, files)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(statuses).To(HaveLen(3))
+		g.Expect(statuses[0].Location).To(Equal("configmap/ubuntu-files/build.log"))
+		g.Expect(statuses[0].SHA256).To(HaveLen(64))
+		g.Expect(statuses[2]).To(Equal(buildv1.ExtractedFileStatus{Path: "/var/log/dpkg.log", Key: "dpkg.log", Missing: true}))
+
+		cm := &corev1.ConfigMap{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "ubuntu-files"}, cm)).To(Succeed())
+		g.Expect(cm.Data).To(Equal(map[string]string{"build.log": "done\n"}))
+		g.Expect(cm.BinaryData).To(Equal(map[string][]byte{"initrd": {0xff, 0xfe}}))
+		g.Expect(cm.OwnerReferences).To(HaveLen(1))
+	})
+
+	t.Run("too large for a secret", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		large := []*File{{Key: "build.log", Path: "/var/log/build.log", Content: make([]byte, MaxObjectDataSize)}}
+		dest := &buildv1.ExtractDestination{SecretRef: &corev1.LocalObjectReference{Name: "ubuntu-files"}}
+		_, err := (&Storer{Client: c}).Store(context.Background(), build, dest, large)
+		g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+	})
+
+	t.Run("object storage", func(t *testing.T) {
+		g := NewWithT(t)
+
+		mu := sync.Mutex{}
+		uploads := map[string]string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := io.ReadAll(r.Body)
+			uploads[r.Method+" "+r.URL.Path] = string(body)
+		}))
+		defer server.Close()
+
+		credentials := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "storage", Namespace: "default"},
+			Data:       map[string][]byte{buildv1.ObjectStorageAccessKeyIDKey: []byte("id"), buildv1.ObjectStorageSecretAccessKeyKey: []byte("key")},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(credentials).Build()
+		dest := &buildv1.ExtractDestination{ObjectStorage: &buildv1.ObjectStorageDestination{
+			URL:            "s3://logs/ubuntu",
+			Endpoint:       server.URL,
+			CredentialsRef: corev1.LocalObjectReference{Name: "storage"},
+		}}
+		statuses, err := (&Storer{Client: c, HTTPClient: server.Client()}).Store(context.Background(), build, dest, files[:1])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(statuses[0].Location).To(Equal("s3://logs/ubuntu/build.log"))
+		g.Expect(uploads).To(Equal(map[string]string{"PUT /logs/ubuntu/build.log": "done\n"}))
+	})
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	cssh "golang.org/x/crypto/ssh"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+// ExtractFailedReason is the failure reason of an extract provisioner which could not download or store a file.
+const ExtractFailedReason = "ExtractFailed"
+
+// Reconcile downloads the files of a built-in/extract provisioner from the infrastructure machine and stores them
+// at their destination, the outcomes of the files are recorded in the status of the Build. The errors worth retrying
+// are returned, the provisioner fails otherwise.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.Extract == nil {
+			return ctrl.Result{}, forgeerrors.ConfigErrorf("extract provisioner %q has no files", spec.Name)
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		files, err := run(ctx, c, build, spec.Extract)
+		if forgeerrors.IsRetryable(err) {
+			return ctrl.Result{}, err
+		}
+		if err != nil {
+			spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+			spec.FailureReason = ptr.To(ExtractFailedReason)
+			spec.FailureMessage = ptr.To(err.Error())
+		} else {
+			spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+		}
+		util.RecordProvisionerStatus(build, spec).Extracted = files
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// run connects to the infrastructure machine, downloads the files and stores them.
+func run(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ExtractProvisionerSpec) ([]buildv1.ExtractedFileStatus, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.NewConfigError(errors.New("extract provisioners require the connector credentials"))
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return nil, err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	files, err := DownloadAll(ctx, sshClient, spec.Files)
+	if err != nil {
+		return nil, err
+	}
+	return (&Storer{Client: c}).Store(ctx, build, &spec.Destination, files)
+}
+
+// DownloadAll downloads the files from the machine, in order. The failures to reach the machine are retried,
+// the commands failing on the machine are not.
+func DownloadAll(ctx context.Context, machine Machine, files []buildv1.ExtractFile) ([]*File, error) {
+	log := ctrl.LoggerFrom(ctx)
+	downloaded := make([]*File, 0, len(files))
+	for i := range files {
+		f, err := Download(machine, &files[i])
+		if err != nil {
+			var exitErr *cssh.ExitError
+			if forgeerrors.Classify(err) == forgeerrors.CategoryUnknown && !errors.As(err, &exitErr) {
+				err = forgeerrors.NewTransient(err)
+			}
+			return nil, err
+		}
+		log.Info("File extracted", "path", f.Path, "key", f.Key, "size", len(f.Content), "missing", f.Missing)
+		downloaded = append(downloaded, f)
+	}
+	return downloaded, nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/exporter/publish"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/oci"
+)
+
+const (
+	// MaxObjectDataSize is the maximum size of the files stored in a ConfigMap or a Secret, the size of the
+	// objects is limited by etcd.
+	MaxObjectDataSize = 1 << 20
+
+	// ArtifactType is the artifact type of the OCI artifacts pushed by the provisioner.
+	ArtifactType = "application/vnd.forge.build.extract.v1"
+)
+
+// Storer stores the files downloaded from the machine at their destination.
+type Storer struct {
+	// Client writes the ConfigMaps and the Secrets and reads the credentials, in the namespace of the Build.
+	Client client.Client
+	// HTTPClient sends the requests to the object storages and the registries, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// PlainHTTP pushes the OCI artifacts over http instead of https, it is only meant for tests.
+	PlainHTTP bool
+}
+
+// Store stores the files at the destination and returns their outcomes, the missing files are not stored.
+func (s *Storer) Store(ctx context.Context, build *buildv1.Build, dest *buildv1.ExtractDestination, files []*File) ([]buildv1.ExtractedFileStatus, error) {
+	stored := make([]*File, 0, len(files))
+	for _, f := range files {
+		if !f.Missing {
+			stored = append(stored, f)
+		}
+	}
+
+	var locations map[string]string
+	var err error
+	switch {
+	case dest.ConfigMapRef != nil:
+		locations, err = s.storeConfigMap(ctx, build, dest.ConfigMapRef.Name, stored)
+	case dest.SecretRef != nil:
+		locations, err = s.storeSecret(ctx, build, dest.SecretRef.Name, stored)
+	case dest.ObjectStorage != nil:
+		locations, err = s.storeObjectStorage(ctx, build, dest.ObjectStorage, stored)
+	case dest.OCI != nil:
+		locations, err = s.pushArtifact(ctx, build, dest.OCI, stored)
+	default:
+		return nil, forgeerrors.ConfigErrorf("the extracted files have no destination")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]buildv1.ExtractedFileStatus, 0, len(files))
+	for _, f := range files {
+		status := f.Status()
+		status.Location = locations[f.Key]
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (s *Storer) storeConfigMap(ctx context.Context, build *buildv1.Build, name string, files []*File) (map[string]string, error) {
+	if err := checkObjectDataSize(files, "ConfigMap "+name); err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: name}}
+	_, err := controllerutil.CreateOrPatch(ctx, s.Client, cm, func() error {
+		setBuildLabel(&cm.ObjectMeta, build)
+		for _, f := range files {
+			// The files which are not valid UTF-8 text are stored as binary data.
+			if utf8.Valid(f.Content) {
+				if cm.Data == nil {
+					cm.Data = map[string]string{}
+				}
+				cm.Data[f.Key] = string(f.Content)
+				delete(cm.BinaryData, f.Key)
+				continue
+			}
+			if cm.BinaryData == nil {
+				cm.BinaryData = map[string][]byte{}
+			}
+			cm.BinaryData[f.Key] = f.Content
+			delete(cm.Data, f.Key)
+		}
+		return controllerutil.SetControllerReference(build, cm, s.Client.Scheme())
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to store the files in ConfigMap %s", name)
+	}
+	return objectLocations("configmap", name, files), nil
+}
+
+func (s *Storer) storeSecret(ctx context.Context, build *buildv1.Build, name string, files []*File) (map[string]string, error) {
+	if err := checkObjectDataSize(files, "Secret "+name); err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: build.Namespace, Name: name}}
+	_, err := controllerutil.CreateOrPatch(ctx, s.Client, secret, func() error {
+		setBuildLabel(&secret.ObjectMeta, build)
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for _, f := range files {
+			secret.Data[f.Key] = f.Content
+		}
+		return controllerutil.SetControllerReference(build, secret, s.Client.Scheme())
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to store the files in Secret %s", name)
+	}
+	return objectLocations("secret", name, files), nil
+}
+
+func (s *Storer) storeObjectStorage(ctx context.Context, build *buildv1.Build, dest *buildv1.ObjectStorageDestination, files []*File) (map[string]string, error) {
+	bucket, prefix, err := publish.ParseURL(dest.URL)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: dest.CredentialsRef.Name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the object storage credentials from secret %s", dest.CredentialsRef.Name)
+	}
+	storage, err := publish.NewObjectStorage(dest.Endpoint, dest.Region, publish.Credentials{
+		AccessKeyID:     string(secret.Data[buildv1.ObjectStorageAccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[buildv1.ObjectStorageSecretAccessKeyKey]),
+	})
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	if s.HTTPClient != nil {
+		storage.Client = s.HTTPClient
+	}
+
+	locations := map[string]string{}
+	for _, f := range files {
+		key := path.Join(prefix, f.Key)
+		if err := storage.Put(ctx, bucket, key, f.Content, "application/octet-stream"); err != nil {
+			return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to upload %s", f.Path))
+		}
+		locations[f.Key] = fmt.Sprintf("s3://%s/%s", bucket, key)
+	}
+	return locations, nil
+}
+
+func (s *Storer) pushArtifact(ctx context.Context, build *buildv1.Build, dest *buildv1.OCIArtifactDestination, files []*File) (map[string]string, error) {
+	ref, err := oci.ParseReference(dest.Reference)
+	if err != nil {
+		return nil, err
+	}
+	creds := oci.Credentials{}
+	if dest.PushSecretRef != nil {
+		secret := &corev1.Secret{}
+		if err := s.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: dest.PushSecretRef.Name}, secret); err != nil {
+			return nil, errors.Wrapf(err, "failed to get push secret %s", dest.PushSecretRef.Name)
+		}
+		if creds, err = oci.CredentialsFor(secret, ref.Registry); err != nil {
+			return nil, err
+		}
+	}
+
+	layers := make([]oci.Layer, 0, len(files))
+	for _, f := range files {
+		layers = append(layers, oci.Layer{Title: f.Key, Data: f.Content})
+	}
+	pusher := &oci.Pusher{HTTPClient: s.HTTPClient, PlainHTTP: s.PlainHTTP}
+	digest, err := pusher.Push(ctx, ref, creds, ArtifactType, layers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to push %s", dest.Reference)
+	}
+	location := fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, digest)
+	locations := map[string]string{}
+	for _, f := range files {
+		locations[f.Key] = location
+	}
+	return locations, nil
+}
+
+// checkObjectDataSize fails if the files do not fit in a ConfigMap or a Secret.
+func checkObjectDataSize(files []*File, object string) error {
+	size := 0
+	for _, f := range files {
+		size += len(f.Key) + len(f.Content)
+	}
+	if size > MaxObjectDataSize {
+		return forgeerrors.ConfigErrorf("the files are %d bytes, %s may hold at most %d bytes", size, object, MaxObjectDataSize)
+	}
+	return nil
+}
+
+// objectLocations returns the locations of the files stored in a ConfigMap or a Secret, e.g. configmap/logs/build.log.
+func objectLocations(kind, name string, files []*File) map[string]string {
+	locations := map[string]string{}
+	for _, f := range files {
+		locations[f.Key] = path.Join(kind, name, f.Key)
+	}
+	return locations
+}
+
+func setBuildLabel(meta *metav1.ObjectMeta, build *buildv1.Build) {
+	if meta.Labels == nil {
+		meta.Labels = map[string]string{}
+	}
+	meta.Labels[buildv1.BuildNameLabel] = build.Name
+}
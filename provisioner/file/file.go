@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package file implements the built-in/file provisioner, which uploads files fetched from ConfigMaps, Secrets,
+// URLs or OCI artifacts to the infrastructure machine.
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// MaxFileSize is the maximum size of a file uploaded by the provisioner, the content is held in memory.
+const MaxFileSize = 64 << 20
+
+// Machine runs commands on and uploads files to the infrastructure machine, it is implemented by the SSH clients.
+type Machine interface {
+	Run(command string, stdout io.Writer, stderr io.Writer) error
+	Upload(src io.Reader, dst string, mode uint32) error
+}
+
+// Upload uploads the content of the file to the machine, unless the machine already holds it, and sets its
+// mode and its owner. The content is uploaded to a temporary file first so that the connector's sudo applies
+// when it is installed at its destination.
+func Upload(machine Machine, f *buildv1.FileUpload, content []byte) (buildv1.UploadedFileStatus, error) {
+	sum := sha256.Sum256(content)
+	status := buildv1.UploadedFileStatus{
+		Destination: f.Destination,
+		SHA256:      hex.EncodeToString(sum[:]),
+		Size:        int64(len(content)),
+	}
+	if f.SHA256 != "" && !strings.EqualFold(f.SHA256, status.SHA256) {
+		return status, forgeerrors.ConfigErrorf("the content of %s has sha256 %s, %s is expected",
+			f.Destination, status.SHA256, strings.ToLower(f.SHA256))
+	}
+
+	dst := ssh.Quote(f.Destination)
+	current, err := runCommand(machine, fmt.Sprintf("sha256sum -- %s 2>/dev/null | cut -d ' ' -f 1; true", dst))
+	if err != nil {
+		return status, errors.Wrapf(err, "failed to check %s", f.Destination)
+	}
+
+	commands := []string{}
+	if current != status.SHA256 {
+		tmp := fmt.Sprintf("/tmp/.forge-upload-%s", status.SHA256[:16])
+		if err := machine.Upload(bytes.NewReader(content), tmp, 0o600); err != nil {
+			return status, errors.Wrapf(err, "failed to upload %s", f.Destination)
+		}
+		commands = append(commands, fmt.Sprintf("install -D -m %#o %s %s && rm -f %s", f.GetMode(), ssh.Quote(tmp), dst, ssh.Quote(tmp)))
+		status.Uploaded = true
+	} else {
+		commands = append(commands, fmt.Sprintf("chmod %#o %s", f.GetMode(), dst))
+	}
+	if f.Owner != "" {
+		commands = append(commands, fmt.Sprintf("chown %s %s", ssh.Quote(f.Owner), dst))
+	}
+	if _, err := runCommand(machine, strings.Join(commands, " && ")); err != nil {
+		return status, errors.Wrapf(err, "failed to install %s", f.Destination)
+	}
+	return status, nil
+}
+
+// runCommand runs the command on the machine and returns its trimmed output.
+func runCommand(machine Machine, command string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := machine.Run(command, stdout, stderr); err != nil {
+		return "", errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
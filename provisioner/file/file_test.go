@@ -0,0 +1,201 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// fakeMachine records the commands run and the files uploaded, sha256sum reports checksum.
+type fakeMachine struct {
+	checksum string
+	commands []string
+	uploads  map[string]string
+}
+
+func (f *fakeMachine) Run(command string, stdout io.Writer, _ io.Writer) error {
+	f.commands = append(f.commands, command)
+	if strings.HasPrefix(command, "sha256sum") {
+		_, err := io.WriteString(stdout, f.checksum+"\n")
+		return err
+	}
+	return nil
+}
+
+func (f *fakeMachine) Upload(src io.Reader, dst string, _ uint32) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if f.uploads == nil {
+		f.uploads = map[string]string{}
+	}
+	f.uploads[dst] = string(data)
+	return nil
+}
+
+func TestUpload(t *testing.T) {
+	// checksum is the checksum of another content.
+	const checksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	content := []byte("welcome\n")
+	hash := sha256.Sum256(content)
+	sum := hex.EncodeToString(hash[:])
+
+	tests := []struct {
+		name             string
+		file             buildv1.FileUpload
+		remoteChecksum   string
+		expectedUploaded bool
+		expectedCommand  string
+		expectedErr      bool
+	}{
+		{
+			name:             "new file",
+			file:             buildv1.FileUpload{Destination: "/etc/motd", SHA256: strings.ToUpper(sum)},
+			remoteChecksum:   checksum,
+			expectedUploaded: true,
+			expectedCommand: "install -D -m 0644 '/tmp/.forge-upload-" + sum[:16] + "' '/etc/motd' && " +
+				"rm -f '/tmp/.forge-upload-" + sum[:16] + "'",
+		},
+		{
+			name:            "file already present",
+			file:            buildv1.FileUpload{Destination: "/opt/app/run.sh", Mode: ptr.To[int32](0o755), Owner: "app:app"},
+			remoteChecksum:  sum,
+			expectedCommand: "chmod 0755 '/opt/app/run.sh' && chown 'app:app' '/opt/app/run.sh'",
+		},
+		{
+			name:        "checksum mismatch",
+			file:        buildv1.FileUpload{Destination: "/etc/motd", SHA256: checksum},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machine := &fakeMachine{checksum: tt.remoteChecksum}
+			status, err := Upload(machine, &tt.file, content)
+			if tt.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+				g.Expect(machine.commands).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(status).To(Equal(buildv1.UploadedFileStatus{
+				Destination: tt.file.Destination,
+				SHA256:      sum,
+				Size:        int64(len(content)),
+				Uploaded:    tt.expectedUploaded,
+			}))
+			g.Expect(machine.commands).To(HaveLen(2))
+			g.Expect(machine.commands[1]).To(Equal(tt.expectedCommand))
+			if tt.expectedUploaded {
+				g.Expect(machine.uploads).To(Equal(map[string]string{"/tmp/.forge-upload-" + sum[:16]: string(content)}))
+			} else {
+				g.Expect(machine.uploads).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestUploadAll(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+	assets := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "assets"},
+		Data:       map[string]string{"motd": "welcome\n"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50, 0x4e, 0x47}},
+	}
+	certs := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "certs"},
+		Data:       map[string][]byte{"ca.crt": []byte("CERTIFICATE")},
+	}
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(assets, certs).Build()
+
+	configMapKey := func(key string) *corev1.ConfigMapKeySelector {
+		return &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: assets.Name}, Key: key}
+	}
+	files := []buildv1.FileUpload{
+		{Destination: "/etc/motd", ConfigMapKeyRef: configMapKey("motd")},
+		{Destination: "/usr/share/logo.png", ConfigMapKeyRef: configMapKey("logo.png")},
+		{Destination: "/etc/ssl/ca.crt", SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: certs.Name}, Key: "ca.crt"}},
+	}
+	machine := &fakeMachine{}
+	statuses, err := UploadAll(context.Background(), &Fetcher{Client: c}, machine, build, files)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(statuses).To(HaveLen(3))
+	g.Expect(machine.uploads).To(HaveLen(3))
+
+	// The files fetched so far are reported when a source is missing.
+	files[1].ConfigMapKeyRef = configMapKey("missing")
+	statuses, err = UploadAll(context.Background(), &Fetcher{Client: c}, &fakeMachine{}, build, files)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(forgeerrors.IsRetryable(err)).To(BeFalse())
+	g.Expect(statuses).To(HaveLen(1))
+}
+
+func TestFetchTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+	fetcher := &Fetcher{HTTPClient: server.Client(), Timeout: 50 * time.Millisecond}
+	_, err := fetcher.Fetch(context.Background(), build, &buildv1.FileUpload{Destination: "/etc/motd", URL: server.URL + "/motd"})
+	g.Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+	g.Expect(forgeerrors.IsRetryable(err)).To(BeTrue())
+}
+
+func TestTransferTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{}
+	g.Expect(TransferTimeout(build)).To(Equal(5 * time.Minute))
+	build.Spec.Connector.Timeout = &metav1.Duration{Duration: time.Minute}
+	g.Expect(TransferTimeout(build)).To(Equal(30 * time.Minute))
+}
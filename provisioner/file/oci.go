@@ -0,0 +1,312 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+const (
+	// titleAnnotation is the annotation of the layers of an artifact holding the name of their file.
+	titleAnnotation = "org.opencontainers.image.title"
+
+	manifestMediaTypes = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+
+	dockerHub         = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	dockerHubIndex    = "https://index.docker.io/v1/"
+)
+
+// reference is a parsed OCI artifact reference.
+type reference struct {
+	registry   string
+	repository string
+	// ref is the tag or the digest of the artifact.
+	ref string
+}
+
+// parseReference parses references such as ghcr.io/example/assets:v1 or ghcr.io/example/assets@sha256:...,
+// the references without registry are Docker Hub references and the tag defaults to latest.
+func parseReference(s string) (reference, error) {
+	r := reference{registry: dockerHub}
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, r.ref = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.ref = name[:i], name[i+1:]
+	}
+	if r.ref == "" {
+		r.ref = "latest"
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			r.registry, name = host, name[i+1:]
+		}
+	}
+	if r.registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" {
+		return r, forgeerrors.ConfigErrorf("invalid OCI reference %q", s)
+	}
+	r.repository = name
+	return r, nil
+}
+
+// host returns the host serving the registry API.
+func (r reference) host() string {
+	if r.registry == dockerHub {
+		return dockerHubRegistry
+	}
+	return r.registry
+}
+
+// manifest is an OCI image manifest, only the layers are used.
+type manifest struct {
+	Layers []descriptor `json:"layers"`
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// registryCredentials are the credentials of a registry, the anonymous access is used when empty.
+type registryCredentials struct {
+	username string
+	password string
+}
+
+// pullArtifact returns the content of the layer of the artifact holding the file of the source.
+func (f *Fetcher) pullArtifact(ctx context.Context, build *buildv1.Build, src *buildv1.OCIArtifactSource) ([]byte, error) {
+	ref, err := parseReference(src.Reference)
+	if err != nil {
+		return nil, err
+	}
+	creds := registryCredentials{}
+	if src.PullSecretRef != nil {
+		secret := &corev1.Secret{}
+		if err := f.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: src.PullSecretRef.Name}, secret); err != nil {
+			return nil, errors.Wrapf(err, "failed to get pull secret %s", src.PullSecretRef.Name)
+		}
+		if creds, err = credentialsFor(secret, ref.registry); err != nil {
+			return nil, err
+		}
+	}
+
+	scheme := "https"
+	if f.PlainHTTP {
+		scheme = "http"
+	}
+	base := fmt.Sprintf("%s://%s/v2/%s", scheme, ref.host(), ref.repository)
+	data, err := f.registryGet(ctx, base+"/manifests/"+ref.ref, manifestMediaTypes, ref, creds)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, forgeerrors.NewConfigError(errors.Wrapf(err, "invalid manifest of %s", src.Reference))
+	}
+	layer, err := selectLayer(m, src)
+	if err != nil {
+		return nil, err
+	}
+	if layer.Size > MaxFileSize {
+		return nil, forgeerrors.ConfigErrorf("the layer %s of %s is larger than %d bytes", layer.Digest, src.Reference, MaxFileSize)
+	}
+
+	data, err = f.registryGet(ctx, base+"/blobs/"+layer.Digest, "", ref, creds)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if digest := "sha256:" + hex.EncodeToString(sum[:]); digest != layer.Digest {
+		return nil, forgeerrors.NewTransient(errors.Errorf("the layer of %s has digest %s, %s is expected", src.Reference, digest, layer.Digest))
+	}
+	return data, nil
+}
+
+// selectLayer returns the layer titled with the file of the source, or the only layer of the artifact.
+func selectLayer(m *manifest, src *buildv1.OCIArtifactSource) (descriptor, error) {
+	if src.File == "" {
+		if len(m.Layers) != 1 {
+			return descriptor{}, forgeerrors.ConfigErrorf("%s has %d layers, the file to upload must be set", src.Reference, len(m.Layers))
+		}
+		return m.Layers[0], nil
+	}
+	titles := make([]string, 0, len(m.Layers))
+	for _, layer := range m.Layers {
+		if layer.Annotations[titleAnnotation] == src.File {
+			return layer, nil
+		}
+		titles = append(titles, layer.Annotations[titleAnnotation])
+	}
+	return descriptor{}, forgeerrors.ConfigErrorf("%s has no file %s, it has %s", src.Reference, src.File, strings.Join(titles, ", "))
+}
+
+// registryGet gets the URL of the registry, it authenticates with the challenge of the registry when the anonymous
+// request is not authorized.
+func (f *Fetcher) registryGet(ctx context.Context, u, accept string, ref reference, creds registryCredentials) ([]byte, error) {
+	header := http.Header{}
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	resp, err := f.get(ctx, u, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		authorization, err := f.authorize(ctx, challenge, ref, creds)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", authorization)
+		if resp, err = f.get(ctx, u, header); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if err := statusError(resp, u); err != nil {
+		return nil, err
+	}
+	return readLimited(resp.Body, u)
+}
+
+// authorize returns the Authorization header answering the challenge of the registry, a bearer token is requested
+// to the token service of the registry for the pull scope of the repository.
+func (f *Fetcher) authorize(ctx context.Context, challenge string, ref reference, creds registryCredentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds.username == "" {
+			return "", forgeerrors.ConfigErrorf("registry %s requires credentials", ref.registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.username+":"+creds.password)), nil
+	case "bearer":
+	default:
+		return "", forgeerrors.ConfigErrorf("registry %s requires an unsupported authentication: %q", ref.registry, challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", forgeerrors.ConfigErrorf("registry %s returned an invalid challenge: %q", ref.registry, challenge)
+	}
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.repository)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	header := http.Header{}
+	if creds.username != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(creds.username+":"+creds.password)))
+	}
+	data, err := f.download(ctx, tokenURL.String(), header)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to authenticate to registry %s", ref.registry)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", errors.Wrapf(err, "invalid token of registry %s", ref.registry)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge returns the scheme and the parameters of a WWW-Authenticate header,
+// e.g. Bearer realm="https://ghcr.io/token",service="ghcr.io".
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return scheme, params
+}
+
+// credentialsFor returns the credentials of the registry held by a kubernetes.io/dockerconfigjson secret.
+func credentialsFor(secret *corev1.Secret, registry string) (registryCredentials, error) {
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		return registryCredentials{}, forgeerrors.NewConfigError(errors.Wrapf(err, "invalid %s of secret %s", corev1.DockerConfigJsonKey, secret.Name))
+	}
+	keys := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == dockerHub {
+		keys = append(keys, dockerHubIndex, dockerHubRegistry)
+	}
+	for _, key := range keys {
+		auth, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		creds := registryCredentials{username: auth.Username, password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return registryCredentials{}, forgeerrors.ConfigErrorf("invalid auth of registry %s in secret %s", registry, secret.Name)
+			}
+			creds.username, creds.password, _ = strings.Cut(string(decoded), ":")
+		}
+		return creds, nil
+	}
+	return registryCredentials{}, forgeerrors.ConfigErrorf("secret %s has no credentials for registry %s", secret.Name, registry)
+}
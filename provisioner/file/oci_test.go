@@ -0,0 +1,183 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      reference
+	}{
+		{reference: "ghcr.io/example/assets:v1", want: reference{registry: "ghcr.io", repository: "example/assets", ref: "v1"}},
+		{reference: "localhost:5000/assets", want: reference{registry: "localhost:5000", repository: "assets", ref: "latest"}},
+		{
+			reference: "ghcr.io/example/assets@sha256:0123",
+			want:      reference{registry: "ghcr.io", repository: "example/assets", ref: "sha256:0123"},
+		},
+		{reference: "alpine:3.20", want: reference{registry: "docker.io", repository: "library/alpine", ref: "3.20"}},
+		{reference: "example/assets", want: reference{registry: "docker.io", repository: "example/assets", ref: "latest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ref, err := parseReference(tt.reference)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ref).To(Equal(tt.want))
+		})
+	}
+}
+
+// fakeRegistry serves an artifact holding the given files, the pulls require a bearer token granted
+// to the given credentials.
+type fakeRegistry struct {
+	files    map[string]string
+	username string
+	password string
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		if username, password, _ := r.BasicAuth(); username != f.username || password != f.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "pull-token"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer pull-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="registry",scope="repository:example/assets:pull"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m := manifest{}
+	blobs := map[string]string{}
+	for name, content := range f.files {
+		sum := sha256.Sum256([]byte(content))
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = content
+		m.Layers = append(m.Layers, descriptor{Digest: digest, Size: int64(len(content)), Annotations: map[string]string{titleAnnotation: name}})
+	}
+	switch {
+	case r.URL.Path == "/v2/example/assets/manifests/v1":
+		_ = json.NewEncoder(w).Encode(m)
+	case strings.HasPrefix(r.URL.Path, "/v2/example/assets/blobs/"):
+		content, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/example/assets/blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPullArtifact(t *testing.T) {
+	registry := &fakeRegistry{files: map[string]string{"agent.tar.gz": "agent", "README.md": "readme"}, username: "robot", password: "secret"}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "registry"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(
+			`{"auths":{%q:{"auth":"cm9ib3Q6c2VjcmV0"}}}`, host))},
+	}
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+
+	tests := []struct {
+		name        string
+		source      buildv1.OCIArtifactSource
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "file of the artifact",
+			source:   buildv1.OCIArtifactSource{File: "agent.tar.gz", PullSecretRef: &corev1.LocalObjectReference{Name: pullSecret.Name}},
+			expected: "agent",
+		},
+		{
+			name:        "missing file",
+			source:      buildv1.OCIArtifactSource{File: "agent.zip", PullSecretRef: &corev1.LocalObjectReference{Name: pullSecret.Name}},
+			expectedErr: "has no file agent.zip",
+		},
+		{
+			name:        "file required with several layers",
+			source:      buildv1.OCIArtifactSource{PullSecretRef: &corev1.LocalObjectReference{Name: pullSecret.Name}},
+			expectedErr: "has 2 layers",
+		},
+		{
+			name:        "anonymous pull",
+			source:      buildv1.OCIArtifactSource{File: "agent.tar.gz"},
+			expectedErr: "failed to authenticate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			scheme := runtime.NewScheme()
+			g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pullSecret).Build()
+
+			tt.source.Reference = host + "/example/assets:v1"
+			fetcher := &Fetcher{Client: c, HTTPClient: server.Client(), PlainHTTP: true}
+			content, err := fetcher.Fetch(context.Background(), build, &buildv1.FileUpload{Destination: "/opt/agent", OCI: &tt.source})
+			if tt.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectedErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(content)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, params := parseChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:example/assets:pull"`)
+	g.Expect(scheme).To(Equal("Bearer"))
+	g.Expect(params).To(Equal(map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:example/assets:pull",
+	}))
+}
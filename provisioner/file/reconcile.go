@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	cssh "golang.org/x/crypto/ssh"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+const (
+	// UploadFailedReason is the failure reason of a file provisioner which could not upload a file.
+	UploadFailedReason = "UploadFailed"
+
+	// TransferTimeoutFactor is the deadline of fetching or uploading a single file, in connector timeouts,
+	// i.e. 5m with the default connector timeout.
+	TransferTimeoutFactor = 30
+)
+
+// TransferTimeout returns the deadline of fetching or uploading a single file of the Build.
+func TransferTimeout(build *buildv1.Build) time.Duration {
+	return TransferTimeoutFactor * build.Spec.Connector.GetTimeout()
+}
+
+// Reconcile uploads the files of a built-in/file provisioner to the infrastructure machine, the outcomes of the files
+// are recorded in the status of the Build. The errors worth retrying are returned, the provisioner fails otherwise.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.File == nil {
+			return ctrl.Result{}, forgeerrors.ConfigErrorf("file provisioner %q has no files", spec.Name)
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		files, err := run(ctx, c, build, spec.File)
+		if forgeerrors.IsRetryable(err) {
+			return ctrl.Result{}, err
+		}
+		if err != nil {
+			spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+			spec.FailureReason = ptr.To(UploadFailedReason)
+			spec.FailureMessage = ptr.To(err.Error())
+		} else {
+			spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+		}
+		util.RecordProvisionerStatus(build, spec).Files = files
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// run connects to the infrastructure machine and uploads the files, in order. It returns the outcomes of the files
+// uploaded until a file failed.
+func run(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.FileProvisionerSpec) ([]buildv1.UploadedFileStatus, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.NewConfigError(errors.New("file provisioners require the connector credentials"))
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return nil, err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	// A stalled transfer is abandoned and retried rather than holding the reconcile forever.
+	timeout := TransferTimeout(build)
+	sshClient.Options.TransferTimeout = timeout
+	return UploadAll(ctx, &Fetcher{Client: c, Timeout: timeout}, sshClient, build, spec.Files)
+}
+
+// UploadAll fetches and uploads the files to the machine, in order. It returns the outcomes of the files uploaded
+// until a file failed. The failures to fetch a file or to reach the machine are retried, the commands failing on
+// the machine are not.
+func UploadAll(ctx context.Context, fetcher *Fetcher, machine Machine, build *buildv1.Build, files []buildv1.FileUpload) ([]buildv1.UploadedFileStatus, error) {
+	log := ctrl.LoggerFrom(ctx)
+	statuses := make([]buildv1.UploadedFileStatus, 0, len(files))
+	for i := range files {
+		f := &files[i]
+		content, err := fetcher.Fetch(ctx, build, f)
+		if err != nil {
+			return statuses, errors.Wrapf(err, "failed to fetch %s", f.Destination)
+		}
+		status, err := Upload(machine, f, content)
+		if err != nil {
+			var exitErr *cssh.ExitError
+			if forgeerrors.Classify(err) == forgeerrors.CategoryUnknown && !errors.As(err, &exitErr) {
+				err = forgeerrors.NewTransient(err)
+			}
+			return statuses, err
+		}
+		log.Info("File provisioned", "destination", f.Destination, "sha256", status.SHA256, "uploaded", status.Uploaded)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+// Fetcher fetches the content of the files from their sources.
+type Fetcher struct {
+	// Client reads the ConfigMaps and the Secrets of the files, in the namespace of the Build.
+	Client client.Client
+	// HTTPClient downloads the URLs and the OCI artifacts, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+	// PlainHTTP pulls the OCI artifacts over http instead of https, it is only meant for tests.
+	PlainHTTP bool
+	// Timeout is the deadline of fetching a single file, 0 means no deadline.
+	Timeout time.Duration
+}
+
+// Fetch returns the content of the file from its source.
+func (f *Fetcher) Fetch(ctx context.Context, build *buildv1.Build, file *buildv1.FileUpload) ([]byte, error) {
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	switch {
+	case file.ConfigMapKeyRef != nil:
+		return f.fetchConfigMap(ctx, build, file.ConfigMapKeyRef)
+	case file.SecretKeyRef != nil:
+		return f.fetchSecret(ctx, build, file.SecretKeyRef)
+	case file.URL != "":
+		return f.download(ctx, file.URL, nil)
+	case file.OCI != nil:
+		return f.pullArtifact(ctx, build, file.OCI)
+	}
+	return nil, forgeerrors.ConfigErrorf("file %s has no source", file.Destination)
+}
+
+func (f *Fetcher) fetchConfigMap(ctx context.Context, build *buildv1.Build, ref *corev1.ConfigMapKeySelector) ([]byte, error) {
+	cm := &corev1.ConfigMap{}
+	if err := f.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: ref.Name}, cm); err != nil {
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s", ref.Name)
+	}
+	if data, ok := cm.Data[ref.Key]; ok {
+		return []byte(data), nil
+	}
+	if data, ok := cm.BinaryData[ref.Key]; ok {
+		return data, nil
+	}
+	return nil, forgeerrors.ConfigErrorf("ConfigMap %s has no key %s", ref.Name, ref.Key)
+}
+
+func (f *Fetcher) fetchSecret(ctx context.Context, build *buildv1.Build, ref *corev1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := f.Client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Secret %s", ref.Name)
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, forgeerrors.ConfigErrorf("Secret %s has no key %s", ref.Name, ref.Key)
+	}
+	return data, nil
+}
+
+// download returns the content at the URL, up to MaxFileSize bytes. The server errors are retried,
+// the client errors are not.
+func (f *Fetcher) download(ctx context.Context, url string, header http.Header) ([]byte, error) {
+	resp, err := f.get(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := statusError(resp, url); err != nil {
+		return nil, err
+	}
+	return readLimited(resp.Body, url)
+}
+
+// get sends a GET request, the failures to reach the server are retried.
+func (f *Fetcher) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(errors.Wrapf(err, "invalid URL %s", url))
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	httpClient := f.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to get %s", url))
+	}
+	return resp, nil
+}
+
+// statusError returns the error of an unsuccessful response.
+func statusError(resp *http.Response, url string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	err := fmt.Errorf("failed to get %s: unexpected status %s", url, resp.Status)
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return forgeerrors.NewTransient(err)
+	}
+	return forgeerrors.NewConfigError(err)
+}
+
+// readLimited reads r, it fails if it holds more than MaxFileSize bytes.
+func readLimited(r io.Reader, source string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, forgeerrors.NewTransient(errors.Wrapf(err, "failed to read %s", source))
+	}
+	if len(data) > MaxFileSize {
+		return nil, forgeerrors.ConfigErrorf("%s is larger than %d bytes", source, MaxFileSize)
+	}
+	return data, nil
+}
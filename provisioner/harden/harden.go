@@ -0,0 +1,188 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package harden implements the built-in/harden provisioner, which applies the recommendations of a hardening
+// profile bundled with the controller to the infrastructure machine and reports its compliance.
+package harden
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/powershell"
+)
+
+// CommandTimeout is the deadline of the scripts applying and checking a recommendation, a recommendation whose
+// script does not complete in time is not compliant.
+const CommandTimeout = 10 * time.Minute
+
+// Runner runs a command on the infrastructure machine until the context is done, it is implemented by the SSH clients.
+type Runner interface {
+	RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
+}
+
+// Apply applies the rules of the profile of spec on the machine then checks them, in order, and returns the
+// compliance of the machine. user is the user the connector logs in as. The error reports the commands which could
+// not be run, e.g. because the connection has been lost or ctx is done.
+func Apply(ctx context.Context, runner Runner, spec *buildv1.HardenSpec, user string) (*buildv1.ComplianceSummary, error) {
+	b, rules, excluded, err := selectRules(spec, user)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &buildv1.ComplianceSummary{
+		Benchmark: fmt.Sprintf("%s Level %d", b.name, level(spec.Profile)),
+		Excluded:  int32(excluded),
+		Rules:     make([]buildv1.ComplianceRuleStatus, 0, len(rules)),
+	}
+	for _, r := range rules {
+		status := buildv1.ComplianceRuleStatus{ID: r.id, Title: r.title}
+		code, output, err := runScript(ctx, runner, spec.Platform, r.apply)
+		switch {
+		case timedOut(ctx, err):
+			status.Message = fmt.Sprintf("applying timed out after %s", CommandTimeout)
+		case err != nil:
+			return nil, errors.Wrapf(err, "failed to apply recommendation %s", r.id)
+		case code != 0:
+			status.Message = fmt.Sprintf("applying exited with code %d: %s", code, abbreviate(output))
+		default:
+			code, _, err = runScript(ctx, runner, spec.Platform, r.check)
+			if timedOut(ctx, err) {
+				status.Message = fmt.Sprintf("checking timed out after %s", CommandTimeout)
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to check recommendation %s", r.id)
+			}
+			status.Compliant = code == 0
+			if !status.Compliant {
+				status.Message = "the machine does not comply once applied"
+			}
+		}
+		if status.Compliant {
+			summary.Compliant++
+		} else {
+			summary.NonCompliant++
+		}
+		summary.Rules = append(summary.Rules, status)
+	}
+	return summary, nil
+}
+
+// selectRules returns the benchmark of the platform and its rules included in the profile, but the excluded ones.
+// The rules disabling the SSH root login are excluded when the connector logs in as root, user, so that the later
+// provisioners can still connect to the machine.
+func selectRules(spec *buildv1.HardenSpec, user string) (benchmark, []rule, int, error) {
+	b, ok := benchmarks[spec.Platform]
+	if !ok {
+		return benchmark{}, nil, 0, forgeerrors.ConfigErrorf("no hardening profile for platform %q", spec.Platform)
+	}
+	maxLevel := level(spec.Profile)
+	if maxLevel == 0 {
+		return benchmark{}, nil, 0, forgeerrors.ConfigErrorf("unknown hardening profile %q", spec.Profile)
+	}
+
+	excludes := map[string]bool{}
+	for _, id := range spec.Exclude {
+		excludes[id] = true
+	}
+	rules := []rule{}
+	excluded := 0
+	for _, r := range b.rules {
+		if r.level > maxLevel {
+			continue
+		}
+		if excludes[r.id] {
+			delete(excludes, r.id)
+			excluded++
+			continue
+		}
+		if r.rootLogin && user == "root" {
+			excluded++
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if len(excludes) > 0 {
+		unknown := make([]string, 0, len(excludes))
+		for _, id := range spec.Exclude {
+			if excludes[id] {
+				unknown = append(unknown, id)
+			}
+		}
+		return benchmark{}, nil, 0, forgeerrors.ConfigErrorf("the %s profile of %s has no recommendation %s",
+			spec.Profile, spec.Platform, strings.Join(unknown, ", "))
+	}
+	return b, rules, excluded, nil
+}
+
+// level returns the highest level of the recommendations of the profile, 0 for an unknown profile.
+func level(profile buildv1.HardenProfile) int {
+	switch profile {
+	case buildv1.HardenProfileCISLevel1:
+		return 1
+	case buildv1.HardenProfileCISLevel2:
+		return 2
+	}
+	return 0
+}
+
+// runScript runs the script of a rule for at most CommandTimeout and returns its exit code and output, the scripts
+// of the windows rules run with PowerShell and stop at the first error.
+func runScript(ctx context.Context, runner Runner, platform buildv1.HardenPlatform, script string) (int, string, error) {
+	command := script
+	if platform == buildv1.HardenPlatformWindows {
+		command = powershell.Command("$ErrorActionPreference = 'Stop'; $ProgressPreference = 'SilentlyContinue'; " + script)
+	}
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+	output := &bytes.Buffer{}
+	err := runner.RunContext(ctx, command, output, output)
+	var exitErr interface{ ExitStatus() int }
+	if err != nil && errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), strings.TrimSpace(output.String()), nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return 0, strings.TrimSpace(output.String()), nil
+}
+
+// timedOut returns true if the script did not complete within CommandTimeout, rather than ctx being done.
+func timedOut(ctx context.Context, err error) bool {
+	return errors.Is(err, ssh.ErrCommandTimeout) && ctx.Err() == nil
+}
+
+// abbreviate returns the beginning of the output of a command, to describe a recommendation which failed to apply.
+func abbreviate(output string) string {
+	const size = 256
+	if output == "" {
+		return "no output"
+	}
+	if len(output) > size {
+		return output[:size] + "..."
+	}
+	return output
+}
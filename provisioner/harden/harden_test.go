@@ -0,0 +1,235 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// exitError is the error of a command which exited with a non-zero code.
+type exitError int
+
+func (e exitError) Error() string   { return fmt.Sprintf("exited with %d", int(e)) }
+func (e exitError) ExitStatus() int { return int(e) }
+
+// fakeRunner records the commands, the commands containing a key of codes exit with its code and the commands
+// containing hang do not complete before the deadline of their context.
+type fakeRunner struct {
+	codes    map[string]int
+	hang     string
+	err      error
+	commands []string
+}
+
+func (f *fakeRunner) RunContext(ctx context.Context, command string, stdout io.Writer, _ io.Writer) error {
+	f.commands = append(f.commands, command)
+	if f.err != nil {
+		return f.err
+	}
+	if f.hang != "" && strings.Contains(command, f.hang) {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		return fmt.Errorf("%w: %w", ssh.ErrCommandTimeout, context.DeadlineExceeded)
+	}
+	for s, code := range f.codes {
+		if strings.Contains(command, s) {
+			_, _ = io.WriteString(stdout, "E: Unable to locate package\n")
+			return exitError(code)
+		}
+	}
+	return nil
+}
+
+func TestBenchmarks(t *testing.T) {
+	for platform, b := range benchmarks {
+		t.Run(string(platform), func(t *testing.T) {
+			g := NewWithT(t)
+
+			ids := map[string]bool{}
+			for _, r := range b.rules {
+				g.Expect(ids).ToNot(HaveKey(r.id))
+				ids[r.id] = true
+				g.Expect(r.level).To(BeElementOf(1, 2))
+				g.Expect(r.title).ToNot(BeEmpty())
+				g.Expect(r.apply).ToNot(BeEmpty())
+				g.Expect(r.check).ToNot(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestSelectRules(t *testing.T) {
+	tests := []struct {
+		name             string
+		spec             *buildv1.HardenSpec
+		user             string
+		expectedLevel2   bool
+		expectedExcluded int
+		expectedErr      bool
+	}{
+		{
+			name: "level 1",
+			spec: &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu},
+		},
+		{
+			name:           "level 2 includes level 1",
+			spec:           &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel2, Platform: buildv1.HardenPlatformRHEL},
+			expectedLevel2: true,
+		},
+		{
+			name: "excluded recommendations",
+			spec: &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu,
+				Exclude: []string{"5.2.7", "3.2.2"}},
+			expectedExcluded: 2,
+		},
+		{
+			name:             "root login kept for a root connector",
+			spec:             &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu},
+			user:             "root",
+			expectedExcluded: 1,
+		},
+		{
+			name: "root login excluded once for a root connector",
+			spec: &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu,
+				Exclude: []string{"5.2.7"}},
+			user:             "root",
+			expectedExcluded: 1,
+		},
+		{
+			name: "recommendation not in the profile",
+			spec: &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu,
+				Exclude: []string{"4.1.1.1"}},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown platform",
+			spec:        &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: "debian"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b, rules, excluded, err := selectRules(tt.spec, tt.user)
+			if tt.expectedErr {
+				g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(excluded).To(Equal(tt.expectedExcluded))
+
+			level1, level2 := 0, 0
+			for _, r := range b.rules {
+				if r.level == 1 {
+					level1++
+				} else {
+					level2++
+				}
+			}
+			if tt.expectedLevel2 {
+				g.Expect(rules).To(HaveLen(level1 + level2))
+			} else {
+				g.Expect(rules).To(HaveLen(level1 - tt.expectedExcluded))
+			}
+			for _, r := range rules {
+				g.Expect(tt.spec.Exclude).ToNot(ContainElement(r.id))
+				if tt.user == "root" {
+					g.Expect(r.rootLogin).To(BeFalse())
+				}
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel2, Platform: buildv1.HardenPlatformUbuntu}
+	runner := &fakeRunner{codes: map[string]int{
+		"apt-get install":               100,
+		"sysctl -n net.ipv4.ip_forward": 1,
+	}}
+	summary, err := Apply(context.Background(), runner, spec, "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(summary.Benchmark).To(Equal("Forge subset of the CIS Ubuntu Linux 22.04 LTS Benchmark v1.0.0 Level 2"))
+	g.Expect(summary.Rules).To(HaveLen(len(benchmarks[buildv1.HardenPlatformUbuntu].rules)))
+	g.Expect(summary.NonCompliant).To(BeEquivalentTo(2))
+	g.Expect(summary.Compliant).To(BeEquivalentTo(len(summary.Rules) - 2))
+
+	failed := []buildv1.ComplianceRuleStatus{}
+	for _, r := range summary.Rules {
+		if !r.Compliant {
+			failed = append(failed, r)
+		}
+	}
+	g.Expect(failed).To(Equal([]buildv1.ComplianceRuleStatus{
+		{ID: "3.2.2", Title: "Ensure IP forwarding is disabled", Message: "the machine does not comply once applied"},
+		{ID: "4.1.1.1", Title: "Ensure auditd is installed", Message: "applying exited with code 100: E: Unable to locate package"},
+	}))
+	// The rules are applied, then checked, but the ones which failed to apply.
+	g.Expect(runner.commands).To(HaveLen(2*len(summary.Rules) - 1))
+
+	_, err = Apply(context.Background(), &fakeRunner{err: errors.New("connection reset")}, spec, "ubuntu")
+	g.Expect(err).To(MatchError(ContainSubstring("failed to apply recommendation 1.1.1.1: connection reset")))
+}
+
+func TestApplyTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformUbuntu}
+	runner := &fakeRunner{hang: "sysctl -n net.ipv4.ip_forward"}
+	summary, err := Apply(context.Background(), runner, spec, "ubuntu")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(summary.NonCompliant).To(BeEquivalentTo(1))
+	for _, r := range summary.Rules {
+		if r.ID == "3.2.2" {
+			g.Expect(r.Message).To(Equal("checking timed out after 10m0s"))
+		}
+	}
+
+	// The scripts are not run once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runner = &fakeRunner{hang: "apt-get"}
+	_, err = Apply(ctx, runner, &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel2, Platform: buildv1.HardenPlatformUbuntu}, "ubuntu")
+	g.Expect(err).To(MatchError(ContainSubstring("timed out waiting for command to complete")))
+}
+
+func TestApplyWindows(t *testing.T) {
+	g := NewWithT(t)
+
+	runner := &fakeRunner{}
+	summary, err := Apply(context.Background(), runner, &buildv1.HardenSpec{Profile: buildv1.HardenProfileCISLevel1, Platform: buildv1.HardenPlatformWindows}, "Administrator")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(summary.NonCompliant).To(BeZero())
+	for _, command := range runner.commands {
+		g.Expect(command).To(HavePrefix("powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -EncodedCommand "))
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harden
+
+import (
+	"fmt"
+	"strings"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// rule is a recommendation of a benchmark, apply enforces it and check exits with 0 when the machine complies.
+type rule struct {
+	id    string
+	title string
+	level int
+	apply string
+	check string
+	// rootLogin is true for the rules disabling the SSH root login.
+	rootLogin bool
+}
+
+// benchmark is the set of rules bundled for a platform, a subset of the recommendations of a CIS benchmark.
+type benchmark struct {
+	name  string
+	rules []rule
+}
+
+// benchmarks are the rules applied by the profiles, by platform. They are subsets of the CIS benchmarks, covering
+// some of the recommendations which can be enforced on a machine being built, e.g. not the partitioning of its disks:
+//   - Ubuntu and RHEL: the filesystem kernel modules (1.1.1), ASLR (1.5), Avahi (2.2), the network parameters and
+//     protocols (3.2 to 3.4), auditd (4.1.1), the SSH server (5.2) and the password aging (5.5.1 and 5.6.1).
+//   - Windows: the password and lockout policies (1.1 and 1.2), the local security options (2.3), the firewall
+//     profiles (9.1 to 9.3) and some administrative templates (18.4 and 18.8).
+//
+// A machine complying with a profile complies with these recommendations only, not with the whole benchmark.
+var benchmarks = map[buildv1.HardenPlatform]benchmark{
+	buildv1.HardenPlatformUbuntu: {
+		name: "Forge subset of the CIS Ubuntu Linux 22.04 LTS Benchmark v1.0.0",
+		rules: []rule{
+			kernelModule("1.1.1.1", 1, "cramfs"),
+			kernelModule("1.1.1.3", 2, "udf"),
+			kernelModule("1.1.10", 1, "usb-storage"),
+			sysctl("1.5.1", "Ensure address space layout randomization (ASLR) is enabled", 1, "kernel.randomize_va_space", "2"),
+			serviceDisabled("2.2.2", "Ensure Avahi Server is not enabled", 1, "avahi-daemon"),
+			sysctl("3.2.1", "Ensure packet redirect sending is disabled", 1, "net.ipv4.conf.all.send_redirects", "0"),
+			sysctl("3.2.2", "Ensure IP forwarding is disabled", 1, "net.ipv4.ip_forward", "0"),
+			sysctl("3.3.2", "Ensure ICMP redirects are not accepted", 1, "net.ipv4.conf.all.accept_redirects", "0"),
+			sysctl("3.3.4", "Ensure suspicious packets are logged", 1, "net.ipv4.conf.all.log_martians", "1"),
+			sysctl("3.3.5", "Ensure broadcast ICMP requests are ignored", 1, "net.ipv4.icmp_echo_ignore_broadcasts", "1"),
+			sysctl("3.3.8", "Ensure TCP SYN Cookies is enabled", 1, "net.ipv4.tcp_syncookies", "1"),
+			kernelModule("3.4.1", 2, "dccp"),
+			kernelModule("3.4.2", 2, "sctp"),
+			{
+				id:    "4.1.1.1",
+				title: "Ensure auditd is installed",
+				level: 2,
+				apply: "DEBIAN_FRONTEND=noninteractive apt-get install -y -q auditd audispd-plugins || " +
+					"(apt-get update -q && DEBIAN_FRONTEND=noninteractive apt-get install -y -q auditd audispd-plugins)",
+				check: "dpkg-query -W -f='${db:Status-Status}' auditd 2>/dev/null | grep -qx installed",
+			},
+			serviceEnabled("4.1.1.2", "Ensure auditd service is enabled and active", 2, "auditd"),
+			sshd("5.2.7", "Ensure SSH root login is disabled", 1, "PermitRootLogin", "no"),
+			sshd("5.2.8", "Ensure SSH HostbasedAuthentication is disabled", 1, "HostbasedAuthentication", "no"),
+			sshd("5.2.9", "Ensure SSH PermitEmptyPasswords is disabled", 1, "PermitEmptyPasswords", "no"),
+			sshd("5.2.11", "Ensure SSH IgnoreRhosts is enabled", 1, "IgnoreRhosts", "yes"),
+			sshd("5.2.12", "Ensure SSH X11 forwarding is disabled", 1, "X11Forwarding", "no"),
+			sshd("5.2.18", "Ensure SSH MaxAuthTries is set to 4 or less", 1, "MaxAuthTries", "4"),
+			loginDefs("5.5.1.1", "Ensure minimum days between password changes is configured", 1, "PASS_MIN_DAYS", "1"),
+			loginDefs("5.5.1.2", "Ensure password expiration is 365 days or less", 1, "PASS_MAX_DAYS", "365"),
+		},
+	},
+	buildv1.HardenPlatformRHEL: {
+		name: "Forge subset of the CIS Red Hat Enterprise Linux 9 Benchmark v1.0.0",
+		rules: []rule{
+			kernelModule("1.1.1.1", 1, "cramfs"),
+			kernelModule("1.1.1.3", 2, "udf"),
+			kernelModule("1.1.9", 1, "usb-storage"),
+			sysctl("1.5.3", "Ensure address space layout randomization (ASLR) is enabled", 1, "kernel.randomize_va_space", "2"),
+			serviceDisabled("2.2.3", "Ensure Avahi Server is not enabled", 1, "avahi-daemon"),
+			kernelModule("3.2.1", 2, "dccp"),
+			kernelModule("3.2.2", 2, "sctp"),
+			sysctl("3.3.1", "Ensure IP forwarding is disabled", 1, "net.ipv4.ip_forward", "0"),
+			sysctl("3.3.2", "Ensure packet redirect sending is disabled", 1, "net.ipv4.conf.all.send_redirects", "0"),
+			sysctl("3.3.4", "Ensure ICMP redirects are not accepted", 1, "net.ipv4.conf.all.accept_redirects", "0"),
+			sysctl("3.3.6", "Ensure suspicious packets are logged", 1, "net.ipv4.conf.all.log_martians", "1"),
+			sysctl("3.3.7", "Ensure broadcast ICMP requests are ignored", 1, "net.ipv4.icmp_echo_ignore_broadcasts", "1"),
+			sysctl("3.3.8", "Ensure TCP SYN Cookies is enabled", 1, "net.ipv4.tcp_syncookies", "1"),
+			{
+				id:    "4.1.1.1",
+				title: "Ensure auditd is installed",
+				level: 2,
+				apply: "if command -v dnf >/dev/null 2>&1; then dnf install -y -q audit; else yum install -y -q audit; fi",
+				check: "rpm -q audit >/dev/null 2>&1",
+			},
+			serviceEnabled("4.1.1.2", "Ensure auditd service is enabled", 2, "auditd"),
+			sshd("5.2.8", "Ensure SSH IgnoreRhosts is enabled", 1, "IgnoreRhosts", "yes"),
+			sshd("5.2.9", "Ensure SSH HostbasedAuthentication is disabled", 1, "HostbasedAuthentication", "no"),
+			sshd("5.2.10", "Ensure SSH root login is disabled", 1, "PermitRootLogin", "no"),
+			sshd("5.2.11", "Ensure SSH PermitEmptyPasswords is disabled", 1, "PermitEmptyPasswords", "no"),
+			sshd("5.2.13", "Ensure SSH X11 forwarding is disabled", 1, "X11Forwarding", "no"),
+			sshd("5.2.18", "Ensure SSH MaxAuthTries is set to 4 or less", 1, "MaxAuthTries", "4"),
+			loginDefs("5.6.1.1", "Ensure password expiration is 365 days or less", 1, "PASS_MAX_DAYS", "365"),
+			loginDefs("5.6.1.2", "Ensure minimum days between password changes is configured", 1, "PASS_MIN_DAYS", "1"),
+		},
+	},
+	buildv1.HardenPlatformWindows: {
+		name: "Forge subset of the CIS Microsoft Windows Server 2022 Benchmark v1.0.0",
+		rules: []rule{
+			netAccounts("1.1.1", "Ensure 'Enforce password history' is set to '24 or more password(s)'", "uniquepw", "24",
+				"Length of password history maintained"),
+			netAccounts("1.1.2", "Ensure 'Maximum password age' is set to '365 or fewer days, but not 0'", "maxpwage", "365",
+				"Maximum password age (days)"),
+			netAccounts("1.1.3", "Ensure 'Minimum password age' is set to '1 or more day(s)'", "minpwage", "1",
+				"Minimum password age (days)"),
+			netAccounts("1.1.4", "Ensure 'Minimum password length' is set to '14 or more character(s)'", "minpwlen", "14",
+				"Minimum password length"),
+			netAccounts("1.2.2", "Ensure 'Account lockout threshold' is set to '5 or fewer invalid logon attempt(s), but not 0'",
+				"lockoutthreshold", "5", "Lockout threshold"),
+			{
+				id:    "2.3.1.2",
+				title: "Ensure 'Accounts: Guest account status' is set to 'Disabled'",
+				level: 1,
+				apply: "Disable-LocalUser -Name Guest",
+				check: "if ((Get-LocalUser -Name Guest).Enabled) { exit 1 }",
+			},
+			registryValue("2.3.7.2", "Ensure 'Interactive logon: Don't display last signed-in' is set to 'Enabled'", 1,
+				`HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Policies\System`, "DontDisplayLastUserName", 1),
+			firewallProfile("9.1.1", "Domain"),
+			firewallProfile("9.2.1", "Private"),
+			firewallProfile("9.3.1", "Public"),
+			{
+				id:    "18.4.3",
+				title: "Ensure 'Configure SMB v1 server' is set to 'Disabled'",
+				level: 1,
+				apply: "Set-SmbServerConfiguration -EnableSMB1Protocol $false -Force",
+				check: "if ((Get-SmbServerConfiguration).EnableSMB1Protocol) { exit 1 }",
+			},
+			registryValue("18.8.36.1", "Ensure 'Configure Offer Remote Assistance' is set to 'Disabled'", 2,
+				`HKLM:\SOFTWARE\Policies\Microsoft\Windows NT\Terminal Services`, "fAllowUnsolicited", 0),
+			registryValue("18.8.36.2", "Ensure 'Configure Solicited Remote Assistance' is set to 'Disabled'", 2,
+				`HKLM:\SOFTWARE\Policies\Microsoft\Windows NT\Terminal Services`, "fAllowToGetHelp", 0),
+		},
+	},
+}
+
+// kernelModule returns the rule preventing the kernel module from being loaded.
+func kernelModule(id string, level int, module string) rule {
+	conf := ssh.Quote(fmt.Sprintf("/etc/modprobe.d/forge-cis-%s.conf", module))
+	name := strings.ReplaceAll(module, "-", "_")
+	return rule{
+		id:    id,
+		title: fmt.Sprintf("Ensure %s kernel module is not available", module),
+		level: level,
+		apply: fmt.Sprintf("printf 'install %s /bin/false\\nblacklist %s\\n' > %s && { modprobe -r %s 2>/dev/null || true; }",
+			module, module, conf, name),
+		check: fmt.Sprintf("modprobe -n -v %s 2>/dev/null | grep -q /bin/false && ! lsmod | grep -q '^%s '", module, name),
+	}
+}
+
+// sysctl returns the rule setting the kernel parameter, now and at boot.
+func sysctl(id, title string, level int, key, value string) rule {
+	conf := ssh.Quote(fmt.Sprintf("/etc/sysctl.d/60-forge-cis-%s.conf", key))
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("printf '%s = %s\\n' > %s && sysctl -q -w %s=%s", key, value, conf, key, value),
+		check: fmt.Sprintf(`[ "$(sysctl -n %s)" = %s ]`, key, ssh.Quote(value)),
+	}
+}
+
+// sshd returns the rule setting the keyword of the SSH server. The keyword is set at the start of the configuration,
+// the first value of a keyword is used, and its other values are removed. The SSH server is not reloaded, the value
+// applies to the machines started from the image.
+func sshd(id, title string, level int, keyword, value string) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("for f in /etc/ssh/sshd_config /etc/ssh/sshd_config.d/*.conf; do "+
+			"[ -f \"$f\" ] && sed -i -E '/^[[:space:]]*%s[[:space:]]/Id' \"$f\"; done; sed -i '1i %s %s' /etc/ssh/sshd_config",
+			keyword, keyword, value),
+		check:     fmt.Sprintf("sshd -T 2>/dev/null | grep -qix '%s %s'", keyword, value),
+		rootLogin: keyword == "PermitRootLogin",
+	}
+}
+
+// loginDefs returns the rule setting the key of /etc/login.defs.
+func loginDefs(id, title string, level int, key, value string) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("if grep -qE '^[[:space:]]*%s[[:space:]]' /etc/login.defs; then "+
+			"sed -i -E 's/^[[:space:]]*%s[[:space:]].*/%s %s/' /etc/login.defs; else echo '%s %s' >> /etc/login.defs; fi",
+			key, key, key, value, key, value),
+		check: fmt.Sprintf("grep -qE '^%s[[:space:]]+%s$' /etc/login.defs", key, value),
+	}
+}
+
+// serviceDisabled returns the rule stopping and disabling the systemd service, if installed.
+func serviceDisabled(id, title string, level int, service string) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("systemctl disable --now %s.service %s.socket 2>/dev/null || true", service, service),
+		check: fmt.Sprintf("! systemctl is-enabled %s.service 2>/dev/null | grep -qx enabled", service),
+	}
+}
+
+// serviceEnabled returns the rule enabling and starting the systemd service.
+func serviceEnabled(id, title string, level int, service string) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("systemctl enable --now %s", service),
+		check: fmt.Sprintf("systemctl is-enabled %s 2>/dev/null | grep -qx enabled && systemctl is-active -q %s", service, service),
+	}
+}
+
+// netAccounts returns the rule setting the account policy with net accounts, label is the line of the policy
+// in the output of net accounts.
+func netAccounts(id, title, option, value, label string) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: 1,
+		apply: fmt.Sprintf("net accounts /%s:%s | Out-Null; exit $LASTEXITCODE", option, value),
+		check: fmt.Sprintf("$line = net accounts | Where-Object { $_ -like '%s*' }; "+
+			"if (-not $line -or ($line -split ':')[-1].Trim() -ne '%s') { exit 1 }", label, value),
+	}
+}
+
+// registryValue returns the rule setting the DWORD value of the registry key.
+func registryValue(id, title string, level int, key, name string, value int) rule {
+	return rule{
+		id:    id,
+		title: title,
+		level: level,
+		apply: fmt.Sprintf("New-Item -Path '%s' -Force | Out-Null; "+
+			"New-ItemProperty -Path '%s' -Name '%s' -PropertyType DWord -Value %d -Force | Out-Null", key, key, name, value),
+		check: fmt.Sprintf("if ((Get-ItemProperty -Path '%s' -Name '%s' -ErrorAction SilentlyContinue).'%s' -ne %d) { exit 1 }",
+			key, name, name, value),
+	}
+}
+
+// firewallProfile returns the rule turning on the Windows Firewall for the profile.
+func firewallProfile(id, profile string) rule {
+	return rule{
+		id:    id,
+		title: fmt.Sprintf("Ensure 'Windows Firewall: %s: Firewall state' is set to 'On (recommended)'", profile),
+		level: 1,
+		apply: fmt.Sprintf("Set-NetFirewallProfile -Profile %s -Enabled True", profile),
+		check: fmt.Sprintf("if (-not (Get-NetFirewallProfile -Profile %s).Enabled) { exit 1 }", profile),
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harden
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+// NonCompliantReason is the failure reason of a harden provisioner whose machine does not comply with
+// recommendations of its profile.
+const NonCompliantReason = "NonCompliant"
+
+// Reconcile applies the profile of a built-in/harden provisioner to the infrastructure machine, the compliance
+// of the machine is recorded in the status of the provisioner and it fails if the machine does not comply with
+// a recommendation.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.Harden == nil {
+			return ctrl.Result{}, forgeerrors.ConfigErrorf("harden provisioner %q has no profile", spec.Name)
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		summary, err := run(ctx, c, build, spec.Harden)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+		if summary.NonCompliant > 0 {
+			ids := []string{}
+			for _, r := range summary.Rules {
+				if !r.Compliant {
+					ids = append(ids, r.ID)
+				}
+			}
+			spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+			spec.FailureReason = ptr.To(NonCompliantReason)
+			spec.FailureMessage = ptr.To(fmt.Sprintf("the machine does not comply with %d of %d recommendation(s) of %s: %s",
+				summary.NonCompliant, len(summary.Rules), summary.Benchmark, strings.Join(ids, ", ")))
+		}
+		util.RecordProvisionerStatus(build, spec).Compliance = summary
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// run connects to the infrastructure machine and applies the profile.
+func run(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.HardenSpec) (*buildv1.ComplianceSummary, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.NewConfigError(errors.New("harden provisioners require the connector credentials"))
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return nil, err
+	}
+	// The profile is checked before connecting to the machine.
+	user := sshClient.Creds.SSHUser
+	if _, _, _, err := selectRules(spec, user); err != nil {
+		return nil, err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	return Apply(ctx, sshClient, spec, user)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package powershell implements the built-in/powershell provisioner, which runs PowerShell scripts on a Windows
+// infrastructure machine through the ssh connector and reboots the machine when the scripts request it.
+package powershell
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RebootRequiredExitCode is the exit code of a script requesting a reboot, ERROR_SUCCESS_REBOOT_REQUIRED.
+	RebootRequiredExitCode = 3010
+
+	// RebootInitiatedExitCode is the exit code of a script which initiated a reboot, ERROR_SUCCESS_REBOOT_INITIATED.
+	RebootInitiatedExitCode = 1641
+
+	// RebootCountEnv is the environment variable holding the number of reboots requested by the running script.
+	RebootCountEnv = "FORGE_REBOOT_COUNT"
+
+	// chunkSize is the number of bytes of a script written by a single command, the encoded command must fit
+	// in the 32767 characters of a Windows command line.
+	chunkSize = 6 << 10
+)
+
+// utf8BOM marks the scripts as UTF-8, Windows PowerShell reads the scripts without it with the ANSI code page.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// Runner runs a command on the infrastructure machine, it is implemented by the SSH clients.
+type Runner interface {
+	Run(command string, stdout io.Writer, stderr io.Writer) error
+}
+
+// Command returns the command running script with powershell. The script is passed encoded so the command does
+// not depend on the default shell of the OpenSSH server, either cmd or powershell.
+func Command(script string) string {
+	units := utf16.Encode([]rune(script))
+	encoded := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(encoded[2*i:], u)
+	}
+	return "powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -EncodedCommand " +
+		base64.StdEncoding.EncodeToString(encoded)
+}
+
+// RunScript writes the script to the temporary directory of the machine and runs it, reboots is exported to the
+// script in RebootCountEnv. The output of the script is written to output. It returns the exit code of the script,
+// the error reports the commands which could not be run, e.g. because the connection has been lost.
+func RunScript(runner Runner, content string, reboots int32, output io.Writer) (int, error) {
+	sum := sha256.Sum256([]byte(content))
+	path := fmt.Sprintf(`$env:TEMP\forge-%x.ps1`, sum[:8])
+
+	data := []byte(content)
+	if !bytes.HasPrefix(data, utf8BOM) {
+		data = append(append([]byte{}, utf8BOM...), data...)
+	}
+	// The first chunk truncates the script left by a previous run, the next ones are appended to it.
+	mode := "Create"
+	for offset := 0; offset < len(data); offset += chunkSize {
+		end := min(offset+chunkSize, len(data))
+		write := fmt.Sprintf("$ErrorActionPreference = 'Stop'; $b = [Convert]::FromBase64String('%s'); "+
+			"$f = [IO.File]::Open(\"%s\", '%s', 'Write'); try { $f.Write($b, 0, $b.Length) } finally { $f.Close() }",
+			base64.StdEncoding.EncodeToString(data[offset:end]), path, mode)
+		stderr := &bytes.Buffer{}
+		if err := runner.Run(Command(write), io.Discard, stderr); err != nil {
+			return 0, errors.Wrapf(err, "failed to write the script: %s", strings.TrimSpace(stderr.String()))
+		}
+		mode = "Append"
+	}
+
+	// The errors terminating the script are reported with the exit code 1, as powershell -File does.
+	run := strings.Join([]string{
+		"$ProgressPreference = 'SilentlyContinue'",
+		fmt.Sprintf("$env:%s = '%d'", RebootCountEnv, reboots),
+		fmt.Sprintf("$p = \"%s\"", path),
+		"$global:LASTEXITCODE = 0",
+		"try { & $p; $code = $LASTEXITCODE } catch { [Console]::Error.WriteLine($_); $code = 1 }",
+		"Remove-Item -LiteralPath $p -Force -ErrorAction SilentlyContinue",
+		"exit $code",
+	}, "\n")
+	err := runner.Run(Command(run), output, output)
+	if code, ok := exitCode(err); ok {
+		return code, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to run the script")
+	}
+	return 0, nil
+}
+
+// Restart schedules the reboot of the machine a few seconds later, so the command returns before the connection
+// is lost.
+func Restart(runner Runner) error {
+	stdout := &bytes.Buffer{}
+	restart := "shutdown.exe /r /f /t 5 /d p:4:1 /c 'Reboot requested by a forge powershell provisioner'; exit $LASTEXITCODE"
+	if err := runner.Run(Command(restart), stdout, stdout); err != nil {
+		return errors.Wrapf(err, "failed to reboot the machine: %s", strings.TrimSpace(stdout.String()))
+	}
+	return nil
+}
+
+// exitCode returns the exit code of the command which failed with err, if the command ran.
+func exitCode(err error) (int, bool) {
+	var exitErr interface{ ExitStatus() int }
+	if err != nil && errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
+
+// tail returns the end of the output fitting in size bytes, starting at a line when possible.
+func tail(output string, size int) string {
+	if len(output) <= size {
+		return output
+	}
+	output = output[len(output)-size:]
+	if i := strings.IndexByte(output, '\n'); i >= 0 && i < len(output)-1 {
+		return output[i+1:]
+	}
+	return output
+}
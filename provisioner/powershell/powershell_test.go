@@ -0,0 +1,326 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package powershell
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// exitError is the error of a command which exited with a non-zero code.
+type exitError int
+
+func (e exitError) Error() string   { return fmt.Sprintf("exited with %d", int(e)) }
+func (e exitError) ExitStatus() int { return int(e) }
+
+// fakeMachine records the decoded commands and writes of the scripts, the scripts exit with the codes in order.
+type fakeMachine struct {
+	codes    []int
+	output   string
+	commands []string
+	script   []byte
+	restarts int
+}
+
+var chunkPattern = regexp.MustCompile(`FromBase64String\('([^']*)'\)`)
+
+func (f *fakeMachine) Run(command string, stdout io.Writer, _ io.Writer) error {
+	encoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(command,
+		"powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -EncodedCommand "))
+	if err != nil {
+		return err
+	}
+	units := make([]uint16, len(encoded)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(encoded[2*i:])
+	}
+	decoded := string(utf16.Decode(units))
+	f.commands = append(f.commands, decoded)
+
+	switch {
+	case strings.HasPrefix(decoded, "shutdown.exe"):
+		f.restarts++
+	case chunkPattern.MatchString(decoded):
+		chunk, err := base64.StdEncoding.DecodeString(chunkPattern.FindStringSubmatch(decoded)[1])
+		if err != nil {
+			return err
+		}
+		if strings.Contains(decoded, "'Create'") {
+			f.script = nil
+		}
+		f.script = append(f.script, chunk...)
+	default:
+		_, _ = io.WriteString(stdout, f.output)
+		code := 0
+		if len(f.codes) > 0 {
+			code, f.codes = f.codes[0], f.codes[1:]
+		}
+		if code != 0 {
+			return exitError(code)
+		}
+	}
+	return nil
+}
+
+func TestRunScript(t *testing.T) {
+	g := NewWithT(t)
+
+	content := strings.Repeat("Write-Output 'é'\n", 1000)
+	machine := &fakeMachine{codes: []int{RebootRequiredExitCode}}
+	code, err := RunScript(machine, content, 2, io.Discard)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(code).To(Equal(RebootRequiredExitCode))
+	g.Expect(string(machine.script)).To(Equal("\ufeff" + content))
+	// The script does not fit in a single command.
+	g.Expect(len(machine.commands)).To(BeNumerically(">", 2))
+	g.Expect(machine.commands[len(machine.commands)-1]).To(ContainSubstring("$env:FORGE_REBOOT_COUNT = '2'"))
+
+	_, err = RunScript(&failingRunner{}, "exit 0", 0, io.Discard)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to write the script")))
+}
+
+// failingRunner fails to run the commands as if the connection has been lost.
+type failingRunner struct{}
+
+func (failingRunner) Run(string, io.Writer, io.Writer) error { return errors.New("connection lost") }
+
+func TestStep(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	scripts := []buildv1.PowerShellScript{
+		{Name: "features", Inline: "Install-WindowsFeature Web-Server"},
+		{Name: "updates", Inline: "Install-WindowsUpdates"},
+	}
+
+	tests := []struct {
+		name              string
+		progress          []buildv1.PowerShellScriptStatus
+		codes             []int
+		connectErr        error
+		expectedStatus    buildv1.ProvisionerStatus
+		expectedPhases    []string
+		expectedReboots   int32
+		expectedRestarts  int
+		expectedRequeue   bool
+		expectedReason    string
+		expectedMessage   string
+		expectedErr       bool
+		expectedLogs      string
+		expectedNoCommand bool
+	}{
+		{
+			name:              "starts the first script",
+			expectedStatus:    buildv1.ProvisionerStatusRunning,
+			expectedPhases:    []string{ScriptPhaseRunning, ScriptPhasePending},
+			expectedRequeue:   true,
+			expectedMessage:   "Running script features (1/2)",
+			expectedNoCommand: true,
+		},
+		{
+			name: "runs the script",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRunning},
+			},
+			expectedStatus:  buildv1.ProvisionerStatusRunning,
+			expectedPhases:  []string{ScriptPhaseCompleted, ScriptPhasePending},
+			expectedRequeue: true,
+		},
+		{
+			name: "completes after the last script",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseCompleted},
+				{Name: "updates", Phase: ScriptPhaseRunning},
+			},
+			expectedStatus: buildv1.ProvisionerStatusCompleted,
+			expectedPhases: []string{ScriptPhaseCompleted, ScriptPhaseCompleted},
+		},
+		{
+			name: "reboots the machine requested by the script",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseCompleted},
+				{Name: "updates", Phase: ScriptPhaseRunning, Reboots: 1},
+			},
+			codes:            []int{RebootRequiredExitCode},
+			expectedStatus:   buildv1.ProvisionerStatusRunning,
+			expectedPhases:   []string{ScriptPhaseCompleted, ScriptPhaseRebooting},
+			expectedReboots:  2,
+			expectedRestarts: 1,
+			expectedRequeue:  true,
+			expectedMessage:  "Rebooting the machine for script updates (2/2), reboot 2",
+		},
+		{
+			name: "waits for the reboot initiated by the script",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRunning},
+			},
+			codes:           []int{RebootInitiatedExitCode},
+			expectedStatus:  buildv1.ProvisionerStatusRunning,
+			expectedPhases:  []string{ScriptPhaseRebooting, ScriptPhasePending},
+			expectedReboots: 1,
+			expectedRequeue: true,
+		},
+		{
+			name: "fails when the script requests too many reboots",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRunning, Reboots: buildv1.DefaultPowerShellMaxReboots},
+			},
+			codes:           []int{RebootRequiredExitCode},
+			expectedStatus:  buildv1.ProvisionerStatusFailed,
+			expectedPhases:  []string{ScriptPhaseFailed, ScriptPhasePending},
+			expectedReboots: buildv1.DefaultPowerShellMaxReboots,
+			expectedReason:  TooManyRebootsReason,
+		},
+		{
+			name: "fails when the script fails",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRunning},
+			},
+			codes:           []int{1},
+			expectedStatus:  buildv1.ProvisionerStatusFailed,
+			expectedPhases:  []string{ScriptPhaseFailed, ScriptPhasePending},
+			expectedReason:  ScriptFailedReason,
+			expectedMessage: "script features exited with code 1",
+			expectedLogs:    "feature not found\n",
+		},
+		{
+			name: "waits for the machine to shut down",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRebooting, Reboots: 1, RebootStartedAt: &metav1.Time{Time: now.Add(-10 * time.Second)}},
+			},
+			expectedStatus:    buildv1.ProvisionerStatusRunning,
+			expectedPhases:    []string{ScriptPhaseRebooting, ScriptPhasePending},
+			expectedReboots:   1,
+			expectedRequeue:   true,
+			expectedNoCommand: true,
+		},
+		{
+			name: "waits for the machine to be reachable",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRebooting, Reboots: 1, RebootStartedAt: &metav1.Time{Time: now.Add(-time.Minute)}},
+			},
+			connectErr:        errors.New("connection refused"),
+			expectedStatus:    buildv1.ProvisionerStatusRunning,
+			expectedPhases:    []string{ScriptPhaseRebooting, ScriptPhasePending},
+			expectedReboots:   1,
+			expectedRequeue:   true,
+			expectedNoCommand: true,
+		},
+		{
+			name: "resumes the script once the machine is reachable",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRebooting, Reboots: 1, RebootStartedAt: &metav1.Time{Time: now.Add(-time.Minute)}},
+			},
+			expectedStatus:    buildv1.ProvisionerStatusRunning,
+			expectedPhases:    []string{ScriptPhaseRunning, ScriptPhasePending},
+			expectedReboots:   1,
+			expectedRequeue:   true,
+			expectedMessage:   "Resuming script features (1/2) after reboot 1",
+			expectedNoCommand: true,
+		},
+		{
+			name: "fails when the machine is not reachable after the reboot timeout",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRebooting, Reboots: 1, RebootStartedAt: &metav1.Time{Time: now.Add(-time.Hour)}},
+			},
+			connectErr:        errors.New("connection refused"),
+			expectedStatus:    buildv1.ProvisionerStatusFailed,
+			expectedPhases:    []string{ScriptPhaseRebooting, ScriptPhasePending},
+			expectedReboots:   1,
+			expectedReason:    RebootTimeoutReason,
+			expectedNoCommand: true,
+		},
+		{
+			name: "retries the script when the connection fails",
+			progress: []buildv1.PowerShellScriptStatus{
+				{Name: "features", Phase: ScriptPhaseRunning},
+			},
+			connectErr:        errors.New("connection refused"),
+			expectedStatus:    buildv1.ProvisionerStatusRunning,
+			expectedPhases:    []string{ScriptPhaseRunning, ScriptPhasePending},
+			expectedErr:       true,
+			expectedNoCommand: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := &buildv1.ProvisionerSpec{
+				Type:       buildv1.ProvisionerTypePowerShell,
+				UUID:       ptr.To("9b2f0d4e-4a61-4b1e-9d0a-3f7c2b8e6a15"),
+				PowerShell: &buildv1.PowerShellProvisionerSpec{Scripts: scripts},
+			}
+			build := &buildv1.Build{
+				Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{*spec}},
+				Status: buildv1.BuildStatus{Provisioners: []buildv1.BuildProvisionerStatus{
+					{UUID: *spec.UUID, Scripts: tt.progress},
+				}},
+			}
+			machine := &fakeMachine{codes: tt.codes, output: "feature not found\n"}
+			connect := func() (Runner, func(), error) {
+				if tt.connectErr != nil {
+					return nil, nil, tt.connectErr
+				}
+				return machine, func() {}, nil
+			}
+
+			res, err := Step(context.Background(), fake.NewClientBuilder().Build(), connect, build, spec, now)
+			if tt.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(res.Requeue || res.RequeueAfter > 0).To(Equal(tt.expectedRequeue))
+			g.Expect(spec.Status).To(HaveValue(Equal(tt.expectedStatus)))
+			g.Expect(ptr.Deref(spec.FailureReason, "")).To(Equal(tt.expectedReason))
+
+			status := build.Status.Provisioners[0]
+			g.Expect(status.Phase).To(Equal(tt.expectedStatus))
+			phases := []string{}
+			reboots := int32(0)
+			for _, s := range status.Scripts {
+				phases = append(phases, s.Phase)
+				reboots += s.Reboots
+			}
+			g.Expect(phases).To(Equal(tt.expectedPhases))
+			g.Expect(reboots).To(Equal(tt.expectedReboots))
+			g.Expect(machine.restarts).To(Equal(tt.expectedRestarts))
+			g.Expect(status.Logs).To(Equal(tt.expectedLogs))
+			if tt.expectedMessage != "" {
+				g.Expect(status.Message).To(Equal(tt.expectedMessage))
+			}
+			if tt.expectedNoCommand {
+				g.Expect(machine.commands).To(BeEmpty())
+			}
+		})
+	}
+}
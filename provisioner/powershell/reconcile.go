@@ -0,0 +1,282 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package powershell
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+const (
+	// ScriptFailedReason is the failure reason of a powershell provisioner with a script exiting with an error.
+	ScriptFailedReason = "ScriptFailed"
+
+	// TooManyRebootsReason is the failure reason of a powershell provisioner with a script requesting more reboots
+	// than allowed.
+	TooManyRebootsReason = "TooManyReboots"
+
+	// RebootTimeoutReason is the failure reason of a powershell provisioner whose machine could not be reached
+	// after a reboot.
+	RebootTimeoutReason = "RebootTimeout"
+)
+
+// The phases of the scripts of a powershell provisioner.
+const (
+	ScriptPhasePending   = "Pending"
+	ScriptPhaseRunning   = "Running"
+	ScriptPhaseRebooting = "Rebooting"
+	ScriptPhaseCompleted = "Completed"
+	ScriptPhaseFailed    = "Failed"
+)
+
+const (
+	// rebootGracePeriod is how long the machine is left to shut down before connecting to it after a reboot.
+	rebootGracePeriod = 30 * time.Second
+
+	// rebootPollInterval is how often the connection to a rebooting machine is attempted.
+	rebootPollInterval = 15 * time.Second
+
+	// outputSize is the size of the end of the output of a failed script recorded in the status of the provisioner.
+	outputSize = 2048
+)
+
+// Connect opens a connection to the infrastructure machine, close releases it.
+type Connect func() (runner Runner, close func(), err error)
+
+// Reconcile runs the scripts of a built-in/powershell provisioner on the infrastructure machine. Each call moves
+// the provisioner one step forward, so its progress is recorded in the status of the Build while the machine
+// reboots: the result requeues the Build until the provisioner completes or fails.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.PowerShell == nil {
+			return ctrl.Result{}, forgeerrors.ConfigErrorf("powershell provisioner %q has no scripts", spec.Name)
+		}
+		if build.Spec.Connector.Credentials == nil {
+			return ctrl.Result{}, forgeerrors.NewConfigError(errors.New("powershell provisioners require the connector credentials"))
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		res, err := Step(ctx, c, connector(ctx, c, build), build, spec, time.Now())
+		if err != nil || res.Requeue || res.RequeueAfter > 0 {
+			return res, err
+		}
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// connector returns a Connect opening an SSH connection to the infrastructure machine of the Build.
+func connector(ctx context.Context, c client.Client, build *buildv1.Build) Connect {
+	return func() (Runner, func(), error) {
+		sshClient, err := util.NewSSHClient(ctx, c, build)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := sshClient.Validate(); err != nil {
+			return nil, nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+		}
+		if err := sshClient.Connect(); err != nil {
+			return nil, nil, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+		}
+		return sshClient, sshClient.Disconnect, nil
+	}
+}
+
+// Step moves the provisioner one step forward: it starts the next script, runs it, or waits for the machine
+// to be reachable after a reboot. The progress of the scripts is recorded in the status of the Build.
+func Step(ctx context.Context, c client.Client, connect Connect, build *buildv1.Build, spec *buildv1.ProvisionerSpec, now time.Time) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	scripts := spec.PowerShell.Scripts
+
+	spec.Status = ptr.To(buildv1.ProvisionerStatusRunning)
+	status := util.RecordProvisionerStatus(build, spec)
+	// The phase recorded above is updated with the outcome of the step.
+	defer util.RecordProvisionerStatus(build, spec)
+	if status.StartedAt == nil {
+		status.StartedAt = &metav1.Time{Time: now}
+	}
+	progress := scriptStatuses(status, scripts)
+
+	i := 0
+	for i < len(scripts) && progress[i].Phase == ScriptPhaseCompleted {
+		i++
+	}
+	if i == len(scripts) {
+		complete(spec, status, now)
+		return ctrl.Result{}, nil
+	}
+	script, p := &scripts[i], &progress[i]
+
+	switch p.Phase {
+	case ScriptPhasePending:
+		// The script runs in the next step, once it is reported running in the status.
+		p.Phase = ScriptPhaseRunning
+		status.Message = fmt.Sprintf("Running script %s (%d/%d)", script.Name, i+1, len(scripts))
+		return ctrl.Result{Requeue: true}, nil
+
+	case ScriptPhaseRebooting:
+		since := now.Sub(ptr.Deref(p.RebootStartedAt, metav1.Time{Time: now}).Time)
+		if since < rebootGracePeriod {
+			return ctrl.Result{RequeueAfter: rebootGracePeriod - since}, nil
+		}
+		_, closeConnection, err := connect()
+		if forgeerrors.Classify(err) == forgeerrors.CategoryConfigError {
+			return ctrl.Result{}, err
+		}
+		if err != nil {
+			if since >= spec.PowerShell.GetRebootTimeout() {
+				fail(spec, status, now, RebootTimeoutReason, fmt.Sprintf("the machine is not reachable %s after reboot %d of script %s: %v",
+					since.Round(time.Second), p.Reboots, script.Name, err))
+				return ctrl.Result{}, nil
+			}
+			log.V(4).Info("Waiting for the machine to reboot", "script", script.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: rebootPollInterval}, nil
+		}
+		closeConnection()
+		p.Phase = ScriptPhaseRunning
+		p.RebootStartedAt = nil
+		status.Message = fmt.Sprintf("Resuming script %s (%d/%d) after reboot %d", script.Name, i+1, len(scripts), p.Reboots)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	content, err := scriptContent(ctx, c, build, script)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	runner, closeConnection, err := connect()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer closeConnection()
+
+	log.Info("Running PowerShell script", "script", script.Name, "reboots", p.Reboots)
+	output := &bytes.Buffer{}
+	code, err := RunScript(runner, content, p.Reboots, output)
+	if err != nil {
+		// The script runs again from its start, e.g. when it rebooted the machine without exiting with 1641.
+		return ctrl.Result{}, forgeerrors.NewTransient(errors.Wrapf(err, "script %s", script.Name))
+	}
+	p.ExitCode = ptr.To(int32(code))
+
+	switch code {
+	case 0:
+		p.Phase = ScriptPhaseCompleted
+		if i == len(scripts)-1 {
+			complete(spec, status, now)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{Requeue: true}, nil
+
+	case RebootRequiredExitCode, RebootInitiatedExitCode:
+		if p.Reboots >= spec.PowerShell.GetMaxReboots() {
+			p.Phase = ScriptPhaseFailed
+			fail(spec, status, now, TooManyRebootsReason, fmt.Sprintf("script %s requested more than %d reboot(s)",
+				script.Name, spec.PowerShell.GetMaxReboots()))
+			return ctrl.Result{}, nil
+		}
+		if code == RebootRequiredExitCode {
+			if err := Restart(runner); err != nil {
+				return ctrl.Result{}, forgeerrors.NewTransient(err)
+			}
+		}
+		p.Reboots++
+		p.Phase = ScriptPhaseRebooting
+		p.RebootStartedAt = &metav1.Time{Time: now}
+		status.Message = fmt.Sprintf("Rebooting the machine for script %s (%d/%d), reboot %d",
+			script.Name, i+1, len(scripts), p.Reboots)
+		log.Info("Rebooting the machine", "script", script.Name, "reboots", p.Reboots)
+		return ctrl.Result{RequeueAfter: rebootGracePeriod}, nil
+
+	default:
+		p.Phase = ScriptPhaseFailed
+		status.Logs = tail(output.String(), outputSize)
+		fail(spec, status, now, ScriptFailedReason, fmt.Sprintf("script %s exited with code %d", script.Name, code))
+		return ctrl.Result{}, nil
+	}
+}
+
+// scriptStatuses returns the progress of the scripts, in order, the scripts without progress are pending.
+func scriptStatuses(status *buildv1.BuildProvisionerStatus, scripts []buildv1.PowerShellScript) []buildv1.PowerShellScriptStatus {
+	existing := map[string]buildv1.PowerShellScriptStatus{}
+	for _, s := range status.Scripts {
+		existing[s.Name] = s
+	}
+	progress := make([]buildv1.PowerShellScriptStatus, 0, len(scripts))
+	for _, script := range scripts {
+		s, ok := existing[script.Name]
+		if !ok {
+			s = buildv1.PowerShellScriptStatus{Name: script.Name, Phase: ScriptPhasePending}
+		}
+		progress = append(progress, s)
+	}
+	status.Scripts = progress
+	return progress
+}
+
+// scriptContent returns the content of the script, from the spec or from its ConfigMap.
+func scriptContent(ctx context.Context, c client.Client, build *buildv1.Build, script *buildv1.PowerShellScript) (string, error) {
+	ref := script.ConfigMapKeyRef
+	if ref == nil {
+		return script.Inline, nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: ref.Name}, cm); err != nil {
+		return "", errors.Wrapf(err, "failed to get ConfigMap %s of script %s", ref.Name, script.Name)
+	}
+	content, ok := cm.Data[ref.Key]
+	if !ok {
+		return "", forgeerrors.ConfigErrorf("ConfigMap %s has no key %s for script %s", ref.Name, ref.Key, script.Name)
+	}
+	return content, nil
+}
+
+func complete(spec *buildv1.ProvisionerSpec, status *buildv1.BuildProvisionerStatus, now time.Time) {
+	spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	status.Message = ""
+	status.CompletedAt = &metav1.Time{Time: now}
+}
+
+func fail(spec *buildv1.ProvisionerSpec, status *buildv1.BuildProvisionerStatus, now time.Time, reason, message string) {
+	spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+	spec.FailureReason = ptr.To(reason)
+	spec.FailureMessage = ptr.To(message)
+	status.Message = message
+	status.CompletedAt = &metav1.Time{Time: now}
+}
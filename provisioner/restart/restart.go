@@ -0,0 +1,241 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package restart implements the built-in/restart provisioner, which reboots the infrastructure machine between
+// provisioners and waits for it to be reachable and ready again.
+package restart
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+// DefaultCommand reboots a Linux machine once the command returned, so the connection is closed cleanly.
+const DefaultCommand = "nohup sh -c 'sleep 2; shutdown -r now || reboot' >/dev/null 2>&1 &"
+
+const (
+	// RebootFailedReason is the failure reason of a restart provisioner whose reboot command exited with an error.
+	RebootFailedReason = "RebootFailed"
+
+	// RebootTimeoutReason is the failure reason of a restart provisioner whose machine could not be reached,
+	// or was not ready, before the timeout.
+	RebootTimeoutReason = "RebootTimeout"
+)
+
+const (
+	// rebootGracePeriod is how long the machine is left to shut down before connecting to it after the reboot.
+	rebootGracePeriod = 30 * time.Second
+
+	// pollInterval is how often the machine is checked while it reboots.
+	pollInterval = 15 * time.Second
+
+	// connectWait is how long a single check waits for the SSH server of the machine.
+	connectWait = 10 * time.Second
+
+	// outputSize is the size of the end of the output of a failed command recorded in the status of the provisioner.
+	outputSize = 2048
+)
+
+// Client is a connection to the infrastructure machine, it is implemented by the SSH clients.
+type Client interface {
+	Connect() error
+	Disconnect()
+	Run(command string, stdout io.Writer, stderr io.Writer) error
+	WaitForSSH(maxWait time.Duration) error
+}
+
+// NewClient returns a client to the infrastructure machine.
+type NewClient func() (Client, error)
+
+// Reconcile reboots the infrastructure machine of a built-in/restart provisioner. Each call moves the provisioner
+// one step forward, so the Build is requeued while the machine reboots, until the provisioner completes or fails.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if build.Spec.Connector.Credentials == nil {
+			return ctrl.Result{}, forgeerrors.NewConfigError(errors.New("restart provisioners require the connector credentials"))
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		newClient := func() (Client, error) {
+			sshClient, err := util.NewSSHClient(ctx, c, build)
+			if err != nil {
+				return nil, err
+			}
+			if err := sshClient.Validate(); err != nil {
+				return nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+			}
+			return sshClient, nil
+		}
+		res, err := Step(ctx, newClient, build, spec, time.Now())
+		if err != nil || res.Requeue || res.RequeueAfter > 0 {
+			return res, err
+		}
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// Step moves the provisioner one step forward: it runs the reboot command, waits for the machine to be reachable
+// again, then for the check command to succeed. The progress is recorded in the status of the Build.
+func Step(ctx context.Context, newClient NewClient, build *buildv1.Build, spec *buildv1.ProvisionerSpec, now time.Time) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	restart := ptr.Deref(spec.Restart, buildv1.RestartSpec{})
+
+	spec.Status = ptr.To(buildv1.ProvisionerStatusRunning)
+	status := util.RecordProvisionerStatus(build, spec)
+	// The phase recorded above is updated with the outcome of the step.
+	defer util.RecordProvisionerStatus(build, spec)
+	if status.StartedAt == nil {
+		status.StartedAt = &metav1.Time{Time: now}
+	}
+
+	machine, err := newClient()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if status.RestartedAt == nil {
+		command := restart.Command
+		if command == "" {
+			command = DefaultCommand
+		}
+		output := &bytes.Buffer{}
+		code, ran, err := run(machine, command, output)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if ran && code != 0 {
+			status.Logs = tail(output.String(), outputSize)
+			fail(spec, status, now, RebootFailedReason, fmt.Sprintf("the reboot command exited with code %d", code))
+			return ctrl.Result{}, nil
+		}
+		// The connection may also be lost while the reboot command runs, as the machine shuts down.
+		log.Info("Rebooting the machine")
+		status.RestartedAt = &metav1.Time{Time: now}
+		status.Message = "Waiting for the machine to reboot"
+		return ctrl.Result{RequeueAfter: rebootGracePeriod}, nil
+	}
+
+	since := now.Sub(status.RestartedAt.Time)
+	if since < rebootGracePeriod {
+		return ctrl.Result{RequeueAfter: rebootGracePeriod - since}, nil
+	}
+	timeout := restart.GetTimeout()
+
+	if err := machine.WaitForSSH(connectWait); err != nil {
+		if !errors.Is(err, ssh.ErrTimeout) {
+			return ctrl.Result{}, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+		}
+		if since >= timeout {
+			fail(spec, status, now, RebootTimeoutReason, fmt.Sprintf("the machine is not reachable %s after the reboot",
+				since.Round(time.Second)))
+			return ctrl.Result{}, nil
+		}
+		log.V(4).Info("Waiting for the machine to reboot")
+		return ctrl.Result{RequeueAfter: pollInterval}, nil
+	}
+
+	if restart.CheckCommand != "" {
+		output := &bytes.Buffer{}
+		code, ran, err := run(machine, restart.CheckCommand, output)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !ran {
+			// The machine went away again, e.g. it reboots once more to finish installing updates.
+			return ctrl.Result{RequeueAfter: pollInterval}, nil
+		}
+		if code != 0 {
+			if since >= timeout {
+				status.Logs = tail(output.String(), outputSize)
+				fail(spec, status, now, RebootTimeoutReason, fmt.Sprintf("the check command still exits with code %d %s after the reboot",
+					code, since.Round(time.Second)))
+				return ctrl.Result{}, nil
+			}
+			status.Message = fmt.Sprintf("Waiting for the check command to succeed, it exited with code %d", code)
+			return ctrl.Result{RequeueAfter: pollInterval}, nil
+		}
+	}
+
+	spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	status.Message = ""
+	status.CompletedAt = &metav1.Time{Time: now}
+	log.Info("The machine is ready after the reboot", "duration", since.Round(time.Second))
+	return ctrl.Result{}, nil
+}
+
+// run runs the command on the machine and returns its exit code, ran is false when the connection has been lost
+// before the command exited.
+func run(machine Client, command string, output io.Writer) (code int, ran bool, err error) {
+	if err := machine.Connect(); err != nil {
+		return 0, false, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer machine.Disconnect()
+
+	err = machine.Run(command, output, output)
+	var exitErr interface{ ExitStatus() int }
+	switch {
+	case err == nil:
+		return 0, true, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus(), true, nil
+	}
+	return 0, false, nil
+}
+
+func fail(spec *buildv1.ProvisionerSpec, status *buildv1.BuildProvisionerStatus, now time.Time, reason, message string) {
+	spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+	spec.FailureReason = ptr.To(reason)
+	spec.FailureMessage = ptr.To(message)
+	status.Message = message
+	status.CompletedAt = &metav1.Time{Time: now}
+}
+
+// tail returns the end of the output fitting in size bytes, starting at a line when possible.
+func tail(output string, size int) string {
+	if len(output) <= size {
+		return output
+	}
+	output = output[len(output)-size:]
+	if i := strings.IndexByte(output, '\n'); i >= 0 && i < len(output)-1 {
+		return output[i+1:]
+	}
+	return output
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// exitError is the error of a command which exited with a non-zero code.
+type exitError int
+
+func (e exitError) Error() string   { return fmt.Sprintf("exited with %d", int(e)) }
+func (e exitError) ExitStatus() int { return int(e) }
+
+func TestStep(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		restart          *buildv1.RestartSpec
+		restartedAt      time.Duration
+		runErr           error
+		waitErr          error
+		expectedStatus   buildv1.ProvisionerStatus
+		expectedCommands []string
+		expectedWait     bool
+		expectedRequeue  bool
+		expectedReason   string
+		expectedMessage  string
+		expectedLogs     string
+		expectedErr      bool
+	}{
+		{
+			name:             "reboots the machine",
+			expectedStatus:   buildv1.ProvisionerStatusRunning,
+			expectedCommands: []string{DefaultCommand},
+			expectedRequeue:  true,
+			expectedMessage:  "Waiting for the machine to reboot",
+		},
+		{
+			name:             "reboots the machine when the connection is lost",
+			restart:          &buildv1.RestartSpec{Command: "shutdown.exe /r /t 0"},
+			runErr:           errors.New("connection lost"),
+			expectedStatus:   buildv1.ProvisionerStatusRunning,
+			expectedCommands: []string{"shutdown.exe /r /t 0"},
+			expectedRequeue:  true,
+		},
+		{
+			name:             "fails when the reboot command fails",
+			runErr:           exitError(1),
+			expectedStatus:   buildv1.ProvisionerStatusFailed,
+			expectedCommands: []string{DefaultCommand},
+			expectedReason:   RebootFailedReason,
+			expectedMessage:  "the reboot command exited with code 1",
+			expectedLogs:     "permission denied\n",
+		},
+		{
+			name:            "waits for the machine to shut down",
+			restartedAt:     10 * time.Second,
+			expectedStatus:  buildv1.ProvisionerStatusRunning,
+			expectedRequeue: true,
+		},
+		{
+			name:            "waits for the machine to be reachable",
+			restartedAt:     time.Minute,
+			waitErr:         ssh.ErrTimeout,
+			expectedStatus:  buildv1.ProvisionerStatusRunning,
+			expectedWait:    true,
+			expectedRequeue: true,
+		},
+		{
+			name:           "fails when the machine is not reachable after the timeout",
+			restartedAt:    time.Hour,
+			waitErr:        ssh.ErrTimeout,
+			expectedStatus: buildv1.ProvisionerStatusFailed,
+			expectedWait:   true,
+			expectedReason: RebootTimeoutReason,
+		},
+		{
+			name:           "fails on a host key mismatch",
+			restartedAt:    time.Minute,
+			waitErr:        ssh.ErrHostKeyMismatch,
+			expectedStatus: buildv1.ProvisionerStatusRunning,
+			expectedWait:   true,
+			expectedErr:    true,
+		},
+		{
+			name:           "completes once the machine is reachable",
+			restartedAt:    time.Minute,
+			expectedStatus: buildv1.ProvisionerStatusCompleted,
+			expectedWait:   true,
+		},
+		{
+			name:             "completes once the check command succeeds",
+			restart:          &buildv1.RestartSpec{CheckCommand: "systemctl is-active nginx"},
+			restartedAt:      time.Minute,
+			expectedStatus:   buildv1.ProvisionerStatusCompleted,
+			expectedCommands: []string{"systemctl is-active nginx"},
+			expectedWait:     true,
+		},
+		{
+			name:             "waits for the check command to succeed",
+			restart:          &buildv1.RestartSpec{CheckCommand: "systemctl is-active nginx"},
+			restartedAt:      time.Minute,
+			runErr:           exitError(3),
+			expectedStatus:   buildv1.ProvisionerStatusRunning,
+			expectedCommands: []string{"systemctl is-active nginx"},
+			expectedWait:     true,
+			expectedRequeue:  true,
+			expectedMessage:  "Waiting for the check command to succeed, it exited with code 3",
+		},
+		{
+			name: "fails when the check command fails after the timeout",
+			restart: &buildv1.RestartSpec{
+				CheckCommand: "systemctl is-active nginx",
+				Timeout:      &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			restartedAt:      10 * time.Minute,
+			runErr:           exitError(3),
+			expectedStatus:   buildv1.ProvisionerStatusFailed,
+			expectedCommands: []string{"systemctl is-active nginx"},
+			expectedWait:     true,
+			expectedReason:   RebootTimeoutReason,
+			expectedMessage:  "the check command still exits with code 3 10m0s after the reboot",
+			expectedLogs:     "permission denied\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := &buildv1.ProvisionerSpec{
+				Type:    buildv1.ProvisionerTypeRestart,
+				UUID:    ptr.To("0c5e7f1a-6d2b-4e8f-a3c9-7b1d4f2e8a60"),
+				Restart: tt.restart,
+			}
+			status := buildv1.BuildProvisionerStatus{UUID: *spec.UUID}
+			if tt.restartedAt > 0 {
+				status.RestartedAt = &metav1.Time{Time: now.Add(-tt.restartedAt)}
+			}
+			build := &buildv1.Build{
+				Spec:   buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{*spec}},
+				Status: buildv1.BuildStatus{Provisioners: []buildv1.BuildProvisionerStatus{status}},
+			}
+
+			commands := []string{}
+			waited := false
+			machine := &ssh.MockSSHClient{
+				MockConnect:    func() error { return nil },
+				MockDisconnect: func() {},
+				MockRun: func(command string, stdout io.Writer, _ io.Writer) error {
+					commands = append(commands, command)
+					_, _ = io.WriteString(stdout, "permission denied\n")
+					return tt.runErr
+				},
+				MockWaitForSSH: func(time.Duration) error {
+					waited = true
+					return tt.waitErr
+				},
+			}
+			newClient := func() (Client, error) { return machine, nil }
+
+			res, err := Step(context.Background(), newClient, build, spec, now)
+			if tt.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(res.Requeue || res.RequeueAfter > 0).To(Equal(tt.expectedRequeue))
+			g.Expect(spec.Status).To(HaveValue(Equal(tt.expectedStatus)))
+			g.Expect(ptr.Deref(spec.FailureReason, "")).To(Equal(tt.expectedReason))
+			g.Expect(commands).To(ConsistOf(tt.expectedCommands))
+			g.Expect(waited).To(Equal(tt.expectedWait))
+
+			recorded := build.Status.Provisioners[0]
+			g.Expect(recorded.Phase).To(Equal(tt.expectedStatus))
+			g.Expect(recorded.RestartedAt == nil).To(Equal(tt.expectedReason == RebootFailedReason))
+			g.Expect(recorded.Logs).To(Equal(tt.expectedLogs))
+			if tt.expectedMessage != "" {
+				g.Expect(recorded.Message).To(Equal(tt.expectedMessage))
+			}
+		})
+	}
+}
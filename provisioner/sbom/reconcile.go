@@ -0,0 +1,90 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	cssh "golang.org/x/crypto/ssh"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/extract"
+	"github.com/forge-build/forge/util"
+)
+
+// Reconcile generates the SBOM of the machine of the Build as per spec.artifact.sbom, stores it at its destination
+// and records it in status.sbom, once. The errors worth retrying are returned as is, the others fail the Build.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build) error {
+	if build.Spec.Artifact == nil || build.Spec.Artifact.SBOM == nil || build.Status.SBOM != nil {
+		return nil
+	}
+	spec := build.Spec.Artifact.SBOM
+	if build.Spec.Connector.Credentials == nil {
+		return forgeerrors.ConfigErrorf("spec.artifact.sbom requires the connector credentials")
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	file, err := Generate(ctx, sshClient, build, spec, time.Now())
+	if err != nil {
+		var exitErr *cssh.ExitError
+		if forgeerrors.Classify(err) == forgeerrors.CategoryUnknown && !errors.As(err, &exitErr) {
+			return forgeerrors.NewTransient(err)
+		}
+		return failed(err)
+	}
+	dest := spec.GetDestination(build)
+	stored, err := (&extract.Storer{Client: c}).Store(ctx, build, &dest, []*extract.File{file})
+	if err != nil {
+		return failed(errors.Wrap(err, "failed to store the SBOM"))
+	}
+
+	status := stored[0]
+	build.Status.SBOM = &buildv1.SBOMStatus{
+		Generator: spec.GetGenerator(),
+		Format:    spec.GetFormat(),
+		Location:  status.Location,
+		SHA256:    status.SHA256,
+		Size:      status.Size,
+	}
+	ctrl.LoggerFrom(ctx).Info("SBOM generated", "location", status.Location, "size", status.Size)
+	return nil
+}
+
+// failed returns err if it is worth retrying, the terminal error failing the Build with the SBOMFailed reason
+// otherwise.
+func failed(err error) error {
+	if forgeerrors.IsRetryable(err) {
+		return err
+	}
+	return forgeerrors.NewTerminal(forgeerrors.SBOMFailedBuildError, err)
+}
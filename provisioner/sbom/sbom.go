@@ -0,0 +1,240 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sbom generates the software bill of materials of the machine of a Build once its provisioners completed,
+// with syft or from the packages of its package manager, and stores it as the extract provisioner stores files.
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/provisioner/extract"
+)
+
+const (
+	// GenerateTimeout bounds the generation of the SBOM on the machine, syft scans its whole filesystem.
+	GenerateTimeout = 15 * time.Minute
+
+	// syftInstallURL is the install script of syft, run when the machine has no syft.
+	syftInstallURL = "https://raw.githubusercontent.com/anchore/syft/main/install.sh"
+
+	// remotePath is where syft writes the SBOM on the machine before it is downloaded.
+	remotePath = "/tmp/.forge-sbom.json"
+
+	// creator is the tool recorded as the creator of the SBOMs of the package inventories.
+	creator = "forge"
+)
+
+// Machine runs commands on and downloads files from the infrastructure machine, it is implemented by the SSH clients.
+type Machine interface {
+	extract.Machine
+	RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
+}
+
+// Generate generates the SBOM of the machine of the Build as per spec, at now.
+func Generate(ctx context.Context, machine Machine, build *buildv1.Build, spec *buildv1.SBOMSpec, now time.Time) (*extract.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, GenerateTimeout)
+	defer cancel()
+
+	format := spec.GetFormat()
+	if spec.GetGenerator() == buildv1.SBOMGeneratorPackageInventory {
+		out, err := run(ctx, machine, inventoryScript)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the packages of the machine")
+		}
+		inventory, err := ParseInventory(out)
+		if err != nil {
+			return nil, err
+		}
+		content, err := inventory.Document(build, format, now)
+		if err != nil {
+			return nil, err
+		}
+		return &extract.File{Key: format.Key(), Content: content}, nil
+	}
+
+	if _, err := run(ctx, machine, syftCommand(spec)); err != nil {
+		return nil, errors.Wrap(err, "failed to scan the machine with syft")
+	}
+	file, err := extract.Download(machine, &buildv1.ExtractFile{Path: remotePath, Key: format.Key()})
+	// The SBOM is removed from the machine even if the download failed, it must not end up in the image.
+	if _, rmErr := run(ctx, machine, "rm -f "+remotePath); err == nil && rmErr != nil {
+		err = errors.Wrap(rmErr, "failed to remove the SBOM from the machine")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// syftCommand returns the command scanning the filesystem of the machine with syft. syft is installed in a
+// temporary directory, removed once the scan is done, when it is not on the PATH.
+func syftCommand(spec *buildv1.SBOMSpec) string {
+	version := spec.SyftVersion
+	if version == "" {
+		version = buildv1.DefaultSyftVersion
+	}
+	output := "spdx-json"
+	if spec.GetFormat() == buildv1.SBOMFormatCycloneDX {
+		output = "cyclonedx-json"
+	}
+	return strings.Join([]string{
+		"set -e",
+		"syft=$(command -v syft || true)",
+		"dir=''",
+		`trap '[ -z "$dir" ] || rm -rf "$dir"' EXIT`,
+		`if [ -z "$syft" ]; then dir=$(mktemp -d); curl -sSfL ` + syftInstallURL + ` | sh -s -- -b "$dir" ` + version + ` >/dev/null; syft="$dir/syft"; fi`,
+		`"$syft" scan dir:/ -q --exclude ./proc --exclude ./sys --exclude ./dev --exclude ./run --exclude ./tmp -o ` + output + "=" + remotePath,
+	}, "\n")
+}
+
+// inventoryScript prints the distribution of the machine, then its packages, one per line with their type, name,
+// version and architecture separated by tabs.
+const inventoryScript = `. /etc/os-release 2>/dev/null || true
+printf 'os\t%s\t%s\n' "${ID:-unknown}" "${VERSION_ID:-}"
+if command -v dpkg-query >/dev/null 2>&1; then
+  dpkg-query -W -f='deb\t${Package}\t${Version}\t${Architecture}\n'
+elif command -v rpm >/dev/null 2>&1; then
+  rpm -qa --qf 'rpm\t%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n'
+elif [ -f /lib/apk/db/installed ]; then
+  awk -F: '/^P:/ { p = $2 } /^V:/ { v = $2 } /^A:/ { printf "apk\t%s\t%s\t%s\n", p, v, $2 }' /lib/apk/db/installed
+else
+  echo 'no dpkg, rpm or apk package database' >&2
+  exit 1
+fi`
+
+// Package is a package installed on the machine.
+type Package struct {
+	// Type is the purl type of the package manager, deb, rpm or apk.
+	Type    string
+	Name    string
+	Version string
+	Arch    string
+}
+
+// Inventory is the list of the packages installed on the machine.
+type Inventory struct {
+	// Distro and DistroVersion are the ID and the VERSION_ID of the os-release of the machine.
+	Distro        string
+	DistroVersion string
+	Packages      []Package
+}
+
+// ParseInventory parses the output of the inventory script.
+func ParseInventory(out string) (*Inventory, error) {
+	inventory := &Inventory{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		switch {
+		case fields[0] == "os" && len(fields) == 3:
+			inventory.Distro, inventory.DistroVersion = fields[1], fields[2]
+		case len(fields) == 4 && fields[1] != "":
+			inventory.Packages = append(inventory.Packages, Package{Type: fields[0], Name: fields[1], Version: fields[2], Arch: fields[3]})
+		case strings.TrimSpace(scanner.Text()) != "":
+			return nil, errors.Errorf("invalid package inventory line %q", scanner.Text())
+		}
+	}
+	if len(inventory.Packages) == 0 {
+		return nil, forgeerrors.ConfigErrorf("no package is installed on the machine")
+	}
+	return inventory, nil
+}
+
+// PURL returns the package URL of the package, e.g. pkg:deb/ubuntu/openssl@3.0.2-0ubuntu1?arch=amd64&distro=ubuntu-22.04.
+func (i *Inventory) PURL(p Package) string {
+	version := strings.ReplaceAll(url.PathEscape(p.Version), ":", "%3A")
+	purl := fmt.Sprintf("pkg:%s/%s/%s@%s", p.Type, url.PathEscape(i.Distro), url.PathEscape(p.Name), version)
+	query := url.Values{}
+	if p.Arch != "" {
+		query.Set("arch", p.Arch)
+	}
+	query.Set("distro", strings.TrimSuffix(i.Distro+"-"+i.DistroVersion, "-"))
+	return purl + "?" + query.Encode()
+}
+
+// Document returns the SBOM of the packages of the machine of the Build in the format.
+func (i *Inventory) Document(build *buildv1.Build, format buildv1.SBOMFormat, now time.Time) ([]byte, error) {
+	name := build.Namespace + "/" + build.Name
+	created := now.UTC().Format(time.RFC3339)
+	var doc interface{}
+	if format == buildv1.SBOMFormatCycloneDX {
+		components := make([]map[string]interface{}, 0, len(i.Packages))
+		for _, p := range i.Packages {
+			purl := i.PURL(p)
+			components = append(components, map[string]interface{}{
+				"type": "library", "bom-ref": purl, "name": p.Name, "version": p.Version, "purl": purl,
+			})
+		}
+		doc = map[string]interface{}{
+			"bomFormat":    "CycloneDX",
+			"specVersion":  "1.5",
+			"serialNumber": "urn:uuid:" + uuid.NewString(),
+			"version":      1,
+			"metadata": map[string]interface{}{
+				"timestamp": created,
+				"tools":     map[string]interface{}{"components": []map[string]string{{"type": "application", "name": creator}}},
+				"component": map[string]string{"type": "operating-system", "name": i.Distro, "version": i.DistroVersion, "description": name},
+			},
+			"components": components,
+		}
+	} else {
+		packages := make([]map[string]interface{}, 0, len(i.Packages))
+		for n, p := range i.Packages {
+			packages = append(packages, map[string]interface{}{
+				"SPDXID":           fmt.Sprintf("SPDXRef-Package-%d", n+1),
+				"name":             p.Name,
+				"versionInfo":      p.Version,
+				"downloadLocation": "NOASSERTION",
+				"externalRefs": []map[string]string{{
+					"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": i.PURL(p),
+				}},
+			})
+		}
+		doc = map[string]interface{}{
+			"spdxVersion":       "SPDX-2.3",
+			"dataLicense":       "CC0-1.0",
+			"SPDXID":            "SPDXRef-DOCUMENT",
+			"name":              name,
+			"documentNamespace": fmt.Sprintf("https://forge.build/spdx/%s/%s-%s", build.Namespace, build.Name, build.UID),
+			"creationInfo":      map[string]interface{}{"created": created, "creators": []string{"Tool: " + creator}},
+			"packages":          packages,
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// run runs the command on the machine and returns its output.
+func run(ctx context.Context, machine Machine, command string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := machine.RunContext(ctx, command, stdout, stderr); err != nil {
+		return "", errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const inventoryOutput = "os\tubuntu\t22.04\n" +
+	"deb\topenssl\t3.0.2-0ubuntu1.15\tamd64\n" +
+	"deb\tlibc6\t2.35-0ubuntu3.6\tamd64\n" +
+	"deb\tbash\t5.1-6ubuntu1:1\tamd64\n"
+
+// fakeMachine answers the inventory script and syft, the commands run are recorded.
+type fakeMachine struct {
+	commands []string
+	sbom     string
+	copies   map[string]string
+}
+
+func (f *fakeMachine) RunContext(_ context.Context, command string, stdout io.Writer, stderr io.Writer) error {
+	return f.Run(command, stdout, stderr)
+}
+
+func (f *fakeMachine) Run(command string, stdout io.Writer, _ io.Writer) error {
+	f.commands = append(f.commands, command)
+	switch {
+	case command == inventoryScript:
+		_, err := io.WriteString(stdout, inventoryOutput)
+		return err
+	case strings.HasPrefix(command, "if [ -f"):
+		_, err := io.WriteString(stdout, strconv.Itoa(len(f.sbom))+"\n")
+		return err
+	case strings.HasPrefix(command, "install"):
+		f.copies = map[string]string{strings.Fields(command)[4]: f.sbom}
+	}
+	return nil
+}
+
+func (f *fakeMachine) Download(dst io.WriteCloser, remotePath string) error {
+	defer dst.Close()
+	_, err := io.WriteString(dst, f.copies[remotePath])
+	return err
+}
+
+func newBuild() *buildv1.Build {
+	return &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu", UID: "build-uid"}}
+}
+
+func TestParseInventory(t *testing.T) {
+	g := NewWithT(t)
+
+	inventory, err := ParseInventory(inventoryOutput)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inventory.Distro).To(Equal("ubuntu"))
+	g.Expect(inventory.DistroVersion).To(Equal("22.04"))
+	g.Expect(inventory.Packages).To(HaveLen(3))
+	g.Expect(inventory.PURL(inventory.Packages[0])).To(Equal("pkg:deb/ubuntu/openssl@3.0.2-0ubuntu1.15?arch=amd64&distro=ubuntu-22.04"))
+	// The epochs are escaped.
+	g.Expect(inventory.PURL(inventory.Packages[2])).To(HavePrefix("pkg:deb/ubuntu/bash@5.1-6ubuntu1%3A1?"))
+
+	_, err = ParseInventory("os\tubuntu\t22.04\n")
+	g.Expect(err).To(MatchError(ContainSubstring("no package")))
+	_, err = ParseInventory("os\tubuntu\t22.04\nopenssl 3.0.2\n")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid package inventory line")))
+}
+
+func TestGeneratePackageInventory(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	spdx, err := Generate(context.Background(), &fakeMachine{}, newBuild(),
+		&buildv1.SBOMSpec{Generator: buildv1.SBOMGeneratorPackageInventory}, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(spdx.Key).To(Equal("sbom.spdx.json"))
+	doc := map[string]interface{}{}
+	g.Expect(json.Unmarshal(spdx.Content, &doc)).To(Succeed())
+	g.Expect(doc).To(HaveKeyWithValue("spdxVersion", "SPDX-2.3"))
+	g.Expect(doc).To(HaveKeyWithValue("documentNamespace", "https://forge.build/spdx/images/ubuntu-build-uid"))
+	g.Expect(doc["packages"]).To(HaveLen(3))
+	g.Expect(doc["creationInfo"]).To(HaveKeyWithValue("created", "2024-05-01T10:30:00Z"))
+
+	cdx, err := Generate(context.Background(), &fakeMachine{}, newBuild(),
+		&buildv1.SBOMSpec{Generator: buildv1.SBOMGeneratorPackageInventory, Format: buildv1.SBOMFormatCycloneDX}, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cdx.Key).To(Equal("sbom.cdx.json"))
+	doc = map[string]interface{}{}
+	g.Expect(json.Unmarshal(cdx.Content, &doc)).To(Succeed())
+	g.Expect(doc).To(HaveKeyWithValue("bomFormat", "CycloneDX"))
+	g.Expect(doc["components"]).To(ContainElement(HaveKeyWithValue("purl", "pkg:deb/ubuntu/libc6@2.35-0ubuntu3.6?arch=amd64&distro=ubuntu-22.04")))
+}
+
+func TestGenerateSyft(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &fakeMachine{sbom: `{"bomFormat":"CycloneDX"}`}
+	file, err := Generate(context.Background(), machine, newBuild(),
+		&buildv1.SBOMSpec{Format: buildv1.SBOMFormatCycloneDX, SyftVersion: "v1.10.0"}, time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(file.Key).To(Equal("sbom.cdx.json"))
+	g.Expect(string(file.Content)).To(Equal(machine.sbom))
+
+	// syft is installed when missing, and the SBOM is removed from the machine once downloaded.
+	g.Expect(machine.commands[0]).To(ContainSubstring(`sh -s -- -b "$dir" v1.10.0`))
+	g.Expect(machine.commands[0]).To(ContainSubstring("-o cyclonedx-json=" + remotePath))
+	g.Expect(machine.commands[len(machine.commands)-1]).To(Equal("rm -f " + remotePath))
+}
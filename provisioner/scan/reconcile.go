@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scan
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	cssh "golang.org/x/crypto/ssh"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/extract"
+	"github.com/forge-build/forge/util"
+)
+
+// Reconcile scans the machine of the Build as per spec.scan, stores the report at its destination and records the
+// outcome in status.scan, once. The Build fails with the VulnerabilitiesFound reason when the scan does not pass.
+// The errors worth retrying are returned as is, the others fail the Build.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build) error {
+	spec := build.Spec.Scan
+	if spec == nil || build.Status.Scan != nil {
+		return nil
+	}
+	if build.Spec.Connector.Credentials == nil {
+		return forgeerrors.ConfigErrorf("spec.scan requires the connector credentials")
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	findings, err := Run(ctx, sshClient, spec)
+	if err != nil {
+		var exitErr *cssh.ExitError
+		if forgeerrors.Classify(err) == forgeerrors.CategoryUnknown && !errors.As(err, &exitErr) {
+			return forgeerrors.NewTransient(err)
+		}
+		return failed(err)
+	}
+	now := metav1.Now()
+	status := Evaluate(spec, findings, now.Time)
+	report, err := json.Marshal(Report{Scanner: status.Scanner, Findings: findings})
+	if err != nil {
+		return err
+	}
+	dest := spec.GetDestination(build)
+	stored, err := (&extract.Storer{Client: c}).Store(ctx, build, &dest, []*extract.File{{Key: buildv1.ScanReportKey, Content: report}})
+	if err != nil {
+		return failed(errors.Wrap(err, "failed to store the scan report"))
+	}
+	status.Report = stored[0].Location
+	status.CompletionTime = now
+	build.Status.Scan = &status
+	ctrl.LoggerFrom(ctx).Info("Machine scanned", "passed", status.Passed, "vulnerabilities", status.Vulnerabilities, "report", status.Report)
+
+	if !status.Passed {
+		return forgeerrors.Terminalf(forgeerrors.VulnerabilitiesFoundBuildError, "the scan of the machine did not pass: %s", status.Message)
+	}
+	return nil
+}
+
+// failed returns err if it is worth retrying, the terminal error failing the Build with the ScanFailed reason
+// otherwise.
+func failed(err error) error {
+	if forgeerrors.IsRetryable(err) {
+		return err
+	}
+	return forgeerrors.NewTerminal(forgeerrors.ScanFailedBuildError, err)
+}
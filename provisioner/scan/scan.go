@@ -0,0 +1,295 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scan scans the machine of a Build for vulnerabilities with trivy or grype once its provisioners completed,
+// and gates the creation of its image on the thresholds of the scan.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/extract"
+)
+
+const (
+	// ScanTimeout bounds the scan of the machine, the database of the scanner is downloaded first.
+	ScanTimeout = 30 * time.Minute
+
+	trivyInstallURL = "https://raw.githubusercontent.com/aquasecurity/trivy/main/contrib/install.sh"
+	grypeInstallURL = "https://raw.githubusercontent.com/anchore/grype/main/install.sh"
+
+	// remotePath is where the scanner writes its report on the machine before it is downloaded.
+	remotePath = "/tmp/.forge-scan.json"
+
+	// maxReportedIDs is the maximum number of vulnerabilities named in the message of a failed scan.
+	maxReportedIDs = 5
+)
+
+// excludedDirs are the directories of the machine which are not scanned, they are not part of the image.
+var excludedDirs = []string{"/proc", "/sys", "/dev", "/run", "/tmp"}
+
+// Machine runs commands on and downloads files from the infrastructure machine, it is implemented by the SSH clients.
+type Machine interface {
+	extract.Machine
+	RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
+}
+
+// Finding is a vulnerability of a package found on the machine.
+type Finding struct {
+	ID               string `json:"id"`
+	Severity         string `json:"severity"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	FixedVersion     string `json:"fixedVersion,omitempty"`
+	// Ignored is true when the finding is not counted, because of spec.scan.ignore or spec.scan.ignoreUnfixed.
+	Ignored bool `json:"ignored,omitempty"`
+}
+
+// Report is the report of a scan stored at the destination of spec.scan, the findings of the scanner in a format
+// common to the scanners.
+type Report struct {
+	Scanner  buildv1.Scanner `json:"scanner"`
+	Findings []Finding       `json:"findings"`
+}
+
+// Run scans the filesystem of the machine and returns the findings of the scanner.
+func Run(ctx context.Context, machine Machine, spec *buildv1.ScanSpec) ([]Finding, error) {
+	ctx, cancel := context.WithTimeout(ctx, ScanTimeout)
+	defer cancel()
+
+	if _, err := run(ctx, machine, scanCommand(spec)); err != nil {
+		return nil, errors.Wrapf(err, "failed to scan the machine with %s", spec.GetScanner())
+	}
+	file, err := extract.Download(machine, &buildv1.ExtractFile{Path: remotePath})
+	// The report is removed from the machine even if the download failed, it must not end up in the image.
+	if _, rmErr := run(ctx, machine, "rm -f "+remotePath); err == nil && rmErr != nil {
+		err = errors.Wrap(rmErr, "failed to remove the scan report from the machine")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if spec.GetScanner() == buildv1.ScannerGrype {
+		return ParseGrype(file.Content)
+	}
+	return ParseTrivy(file.Content)
+}
+
+// scanCommand returns the command scanning the filesystem of the machine. The scanner is installed in a temporary
+// directory when it is not on the PATH, its database is always downloaded in the temporary directory, which is
+// removed once the scan is done.
+func scanCommand(spec *buildv1.ScanSpec) string {
+	commands := []string{
+		"set -e",
+		"dir=$(mktemp -d)",
+		`trap 'rm -rf "$dir"' EXIT`,
+	}
+	if spec.GetScanner() == buildv1.ScannerGrype {
+		excludes := make([]string, 0, len(excludedDirs))
+		for _, dir := range excludedDirs {
+			excludes = append(excludes, "--exclude ."+dir)
+		}
+		return strings.Join(append(commands,
+			installCommand("grype", grypeInstallURL, spec.GetScannerVersion()),
+			`export GRYPE_DB_CACHE_DIR="$dir/db" GRYPE_CHECK_FOR_APP_UPDATE=false`,
+			`"$scanner" dir:/ -q `+strings.Join(excludes, " ")+" -o json --file "+remotePath,
+		), "\n")
+	}
+	return strings.Join(append(commands,
+		installCommand("trivy", trivyInstallURL, spec.GetScannerVersion()),
+		`"$scanner" rootfs --quiet --scanners vuln --cache-dir "$dir/cache" --skip-dirs `+strings.Join(excludedDirs, ",")+
+			" --format json --output "+remotePath+" /",
+	), "\n")
+}
+
+// installCommand returns the command setting $scanner to the scanner on the PATH, or installing it in $dir.
+func installCommand(name, installURL, version string) string {
+	return fmt.Sprintf(`scanner=$(command -v %s || true); if [ -z "$scanner" ]; then curl -sSfL %s | sh -s -- -b "$dir" %s >/dev/null; scanner="$dir/%s"; fi`,
+		name, installURL, version, name)
+}
+
+// ParseTrivy returns the findings of a trivy JSON report.
+func ParseTrivy(data []byte) ([]Finding, error) {
+	report := struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				FixedVersion     string
+				Severity         string
+			}
+		}
+	}{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, errors.Wrap(err, "invalid trivy report")
+	}
+	var findings []Finding
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			findings = append(findings, Finding{
+				ID:               v.VulnerabilityID,
+				Severity:         v.Severity,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+			})
+		}
+	}
+	return dedup(findings), nil
+}
+
+// ParseGrype returns the findings of a grype JSON report.
+func ParseGrype(data []byte) ([]Finding, error) {
+	report := struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+				Fix      struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, errors.Wrap(err, "invalid grype report")
+	}
+	var findings []Finding
+	for _, m := range report.Matches {
+		findings = append(findings, Finding{
+			ID:               m.Vulnerability.ID,
+			Severity:         m.Vulnerability.Severity,
+			Package:          m.Artifact.Name,
+			InstalledVersion: m.Artifact.Version,
+			FixedVersion:     strings.Join(m.Vulnerability.Fix.Versions, ", "),
+		})
+	}
+	return dedup(findings), nil
+}
+
+// dedup removes the findings of a vulnerability reported more than once for the same package, e.g. by several
+// targets of the scanner, and sorts them.
+func dedup(findings []Finding) []Finding {
+	seen := map[Finding]bool{}
+	unique := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		if !seen[f] {
+			seen[f] = true
+			unique = append(unique, f)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].ID != unique[j].ID {
+			return unique[i].ID < unique[j].ID
+		}
+		return unique[i].Package < unique[j].Package
+	})
+	return unique
+}
+
+// Evaluate marks the findings ignored by the spec at now, and returns the outcome of the scan, without its report
+// and its completion time.
+func Evaluate(spec *buildv1.ScanSpec, findings []Finding, now time.Time) buildv1.ScanStatus {
+	ignored := map[string]bool{}
+	for _, ignore := range spec.Ignore {
+		if ignore.ExpirationTime == nil || now.Before(ignore.ExpirationTime.Time) {
+			ignored[ignore.ID] = true
+		}
+	}
+
+	status := buildv1.ScanStatus{Scanner: spec.GetScanner()}
+	ids := map[string][]string{}
+	for i := range findings {
+		f := &findings[i]
+		if ignored[f.ID] || (spec.IgnoreUnfixed && f.FixedVersion == "") {
+			f.Ignored = true
+			status.Ignored++
+			continue
+		}
+		severity := normalizeSeverity(f.Severity)
+		ids[severity] = append(ids[severity], f.ID)
+		switch severity {
+		case "critical":
+			status.Vulnerabilities.Critical++
+		case "high":
+			status.Vulnerabilities.High++
+		case "medium":
+			status.Vulnerabilities.Medium++
+		case "low":
+			status.Vulnerabilities.Low++
+		default:
+			status.Vulnerabilities.Unknown++
+		}
+	}
+
+	thresholds := spec.GetThresholds()
+	var exceeded []string
+	for _, t := range []struct {
+		severity  string
+		found     int32
+		threshold *int32
+	}{
+		{"critical", status.Vulnerabilities.Critical, thresholds.Critical},
+		{"high", status.Vulnerabilities.High, thresholds.High},
+		{"medium", status.Vulnerabilities.Medium, thresholds.Medium},
+		{"low", status.Vulnerabilities.Low, thresholds.Low},
+	} {
+		if t.threshold == nil || t.found <= *t.threshold {
+			continue
+		}
+		named := ids[t.severity][:min(len(ids[t.severity]), maxReportedIDs)]
+		exceeded = append(exceeded, fmt.Sprintf("%d %s vulnerabilities, at most %d tolerated (%s)",
+			t.found, t.severity, ptr.Deref(t.threshold, 0), strings.Join(named, ", ")))
+	}
+	status.Passed = len(exceeded) == 0
+	if !status.Passed {
+		status.Message = strings.Join(exceeded, "; ")
+	}
+	return status
+}
+
+// normalizeSeverity returns the lower cased severity of a finding, the negligible vulnerabilities of grype are low.
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(severity)
+	if severity == "negligible" {
+		return "low"
+	}
+	return severity
+}
+
+// run runs the command on the machine and returns its output.
+func run(ctx context.Context, machine Machine, command string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := machine.RunContext(ctx, command, stdout, stderr); err != nil {
+		return "", errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scan
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+const trivyReport = `{
+  "Results": [
+    {"Target": "ubuntu", "Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2024-6387", "PkgName": "openssh-server", "InstalledVersion": "1:8.9p1-3ubuntu0.7", "FixedVersion": "1:8.9p1-3ubuntu0.10", "Severity": "HIGH"},
+      {"VulnerabilityID": "CVE-2024-2961", "PkgName": "libc6", "InstalledVersion": "2.35-0ubuntu3.6", "FixedVersion": "2.35-0ubuntu3.7", "Severity": "CRITICAL"},
+      {"VulnerabilityID": "CVE-2023-0001", "PkgName": "zlib1g", "InstalledVersion": "1.2.11", "Severity": "LOW"}
+    ]},
+    {"Target": "usr/lib/python3", "Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2024-6387", "PkgName": "openssh-server", "InstalledVersion": "1:8.9p1-3ubuntu0.7", "FixedVersion": "1:8.9p1-3ubuntu0.10", "Severity": "HIGH"}
+    ]}
+  ]
+}`
+
+const grypeReport = `{
+  "matches": [
+    {"vulnerability": {"id": "CVE-2024-2961", "severity": "Critical", "fix": {"versions": ["2.35-0ubuntu3.7"], "state": "fixed"}},
+     "artifact": {"name": "libc6", "version": "2.35-0ubuntu3.6"}},
+    {"vulnerability": {"id": "CVE-2022-3219", "severity": "Negligible", "fix": {"versions": [], "state": "not-fixed"}},
+     "artifact": {"name": "gpgv", "version": "2.2.27"}}
+  ]
+}`
+
+// fakeMachine answers the scanner with its report, the commands run are recorded.
+type fakeMachine struct {
+	commands []string
+	report   string
+	copies   map[string]string
+}
+
+func (f *fakeMachine) RunContext(_ context.Context, command string, stdout io.Writer, stderr io.Writer) error {
+	return f.Run(command, stdout, stderr)
+}
+
+func (f *fakeMachine) Run(command string, stdout io.Writer, _ io.Writer) error {
+	f.commands = append(f.commands, command)
+	switch {
+	case strings.HasPrefix(command, "if [ -f"):
+		_, err := io.WriteString(stdout, strconv.Itoa(len(f.report))+"\n")
+		return err
+	case strings.HasPrefix(command, "install"):
+		f.copies = map[string]string{strings.Fields(command)[4]: f.report}
+	}
+	return nil
+}
+
+func (f *fakeMachine) Download(dst io.WriteCloser, remotePath string) error {
+	defer dst.Close()
+	_, err := io.WriteString(dst, f.copies[remotePath])
+	return err
+}
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &fakeMachine{report: trivyReport}
+	findings, err := Run(context.Background(), machine, &buildv1.ScanSpec{})
+	g.Expect(err).ToNot(HaveOccurred())
+	// The vulnerabilities reported by several targets are counted once.
+	g.Expect(findings).To(HaveLen(3))
+	g.Expect(findings[0]).To(Equal(Finding{ID: "CVE-2023-0001", Severity: "LOW", Package: "zlib1g", InstalledVersion: "1.2.11"}))
+	g.Expect(machine.commands[0]).To(ContainSubstring(`"$scanner" rootfs --quiet --scanners vuln`))
+	g.Expect(machine.commands[0]).To(ContainSubstring(`sh -s -- -b "$dir" ` + buildv1.DefaultTrivyVersion))
+	g.Expect(machine.commands[len(machine.commands)-1]).To(Equal("rm -f " + remotePath))
+
+	machine = &fakeMachine{report: grypeReport}
+	findings, err = Run(context.Background(), machine, &buildv1.ScanSpec{Scanner: buildv1.ScannerGrype})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(findings).To(HaveLen(2))
+	g.Expect(findings[1].FixedVersion).To(Equal("2.35-0ubuntu3.7"))
+	g.Expect(machine.commands[0]).To(ContainSubstring(`export GRYPE_DB_CACHE_DIR="$dir/db"`))
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	trivyFindings, err := ParseTrivy([]byte(trivyReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grypeFindings, err := ParseGrype([]byte(grypeReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     buildv1.ScanSpec
+		findings []Finding
+		expected buildv1.ScanStatus
+	}{
+		{
+			name:     "default thresholds",
+			findings: trivyFindings,
+			expected: buildv1.ScanStatus{
+				Scanner:         buildv1.ScannerTrivy,
+				Vulnerabilities: buildv1.ScanSummary{Critical: 1, High: 1, Low: 1},
+				Message: "1 critical vulnerabilities, at most 0 tolerated (CVE-2024-2961); " +
+					"1 high vulnerabilities, at most 0 tolerated (CVE-2024-6387)",
+			},
+		},
+		{
+			name: "ignored vulnerabilities",
+			spec: buildv1.ScanSpec{
+				Thresholds: &buildv1.ScanThresholds{Critical: ptr.To[int32](0), Low: ptr.To[int32](0)},
+				Ignore: []buildv1.ScanIgnore{
+					{ID: "CVE-2024-2961", ExpirationTime: ptr.To(metav1.NewTime(now.Add(time.Hour)))},
+					{ID: "CVE-2024-6387", ExpirationTime: ptr.To(metav1.NewTime(now.Add(-time.Hour)))},
+				},
+				IgnoreUnfixed: true,
+			},
+			findings: trivyFindings,
+			expected: buildv1.ScanStatus{
+				Scanner:         buildv1.ScannerTrivy,
+				Passed:          true,
+				Vulnerabilities: buildv1.ScanSummary{High: 1},
+				Ignored:         2,
+			},
+		},
+		{
+			name:     "negligible vulnerabilities of grype",
+			spec:     buildv1.ScanSpec{Scanner: buildv1.ScannerGrype, Thresholds: &buildv1.ScanThresholds{Low: ptr.To[int32](1)}},
+			findings: grypeFindings,
+			expected: buildv1.ScanStatus{
+				Scanner:         buildv1.ScannerGrype,
+				Passed:          true,
+				Vulnerabilities: buildv1.ScanSummary{Critical: 1, Low: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			findings := append([]Finding(nil), tt.findings...)
+			g.Expect(Evaluate(&tt.spec, findings, now)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// withExports returns the script with the given variables exported, sorted by name.
+// The values are single quoted, e.g. the query of the presigned URLs of the workspace contains characters
+// interpreted by the shell.
+func withExports(script string, variables map[string][]byte) string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	exports := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(exports, "export %s=%s\n", name, ssh.Quote(string(variables[name])))
+	}
+	return exports.String() + script
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithExports(t *testing.T) {
+	g := NewWithT(t)
+
+	script := withExports("curl -T rootfs.tar \"$FORGE_WORKSPACE_ROOTFS_TAR_PUT_URL\"\n", map[string][]byte{
+		"FORGE_WORKSPACE_URL":                []byte("s3://images/workspaces/images/ubuntu-uid/"),
+		"FORGE_WORKSPACE_ROOTFS_TAR_PUT_URL": []byte("https://minio.example.com/images/rootfs.tar?X-Amz-Date=20240501T103000Z&X-Amz-Signature=it's"),
+	})
+	g.Expect(script).To(Equal("export FORGE_WORKSPACE_ROOTFS_TAR_PUT_URL='https://minio.example.com/images/rootfs.tar?X-Amz-Date=20240501T103000Z&X-Amz-Signature=it'\\''s'\n" +
+		"export FORGE_WORKSPACE_URL='s3://images/workspaces/images/ubuntu-uid/'\n" +
+		"curl -T rootfs.tar \"$FORGE_WORKSPACE_ROOTFS_TAR_PUT_URL\"\n"))
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// remoteKubeconfigPath returns a unique path on the machine to upload the kubeconfig to.
+func remoteKubeconfigPath() string {
+	return fmt.Sprintf("/tmp/forge-kubeconfig-%s", uuid.New().String())
+}
+
+// withKubeconfig returns the script with the KUBECONFIG environment variable set to the given path.
+func withKubeconfig(script, path string) string {
+	return fmt.Sprintf("KUBECONFIG=%s\nexport KUBECONFIG\n%s", path, script)
+}
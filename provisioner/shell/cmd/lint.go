@@ -0,0 +1,100 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TerminationMessagePath is the file the issues found by a dry run are written to,
+// so they are reported in the status of the provisioner.
+const TerminationMessagePath = "/dev/termination-log"
+
+// scriptName is the name of the script given by --run-script in the reported issues.
+const scriptName = "script"
+
+// lint checks the syntax of the script with bash -n and, when it is installed, with shellcheck.
+// It returns the issues found, referring to the script by name, and an error if the script can't be run.
+func lint(ctx context.Context, name, script string) ([]string, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, fmt.Errorf("%s to run is empty", name)
+	}
+
+	f, err := os.CreateTemp("", "forge-script-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, "bash", "-n", f.Name()).CombinedOutput()
+	issues := issueLines(output, f.Name(), name)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("running bash -n: %w", err)
+		}
+		return issues, fmt.Errorf("%s has syntax errors", name)
+	}
+
+	if _, err := exec.LookPath("shellcheck"); err != nil {
+		return append(issues, "shellcheck is not installed, only the syntax has been checked"), nil
+	}
+	output, err = exec.CommandContext(ctx, "shellcheck", "--shell=bash", "--format=gcc", "--severity=warning", f.Name()).Output()
+	issues = append(issues, issueLines(output, f.Name(), name)...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("running shellcheck: %w", err)
+		}
+		for _, issue := range issues {
+			if strings.Contains(issue, ": error: ") {
+				return issues, errors.New("shellcheck reported errors")
+			}
+		}
+	}
+	return issues, nil
+}
+
+// issueLines splits the output of a checker into issues, referring to the script at path by name.
+func issueLines(output []byte, path, name string) []string {
+	issues := []string{}
+	for _, line := range strings.Split(string(bytes.TrimSpace(output)), "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, path, name))
+		if line != "" {
+			issues = append(issues, line)
+		}
+	}
+	return issues
+}
+
+// reportIssues writes the issues to the termination message of the container.
+func reportIssues(issues []string) error {
+	return os.WriteFile(TerminationMessagePath, []byte(strings.Join(issues, "\n")), 0o644)
+}
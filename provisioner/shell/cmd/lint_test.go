@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLint(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	testcases := []struct {
+		name          string
+		script        string
+		expectErr     bool
+		expectedIssue string
+	}{
+		{
+			name:   "valid script",
+			script: "#!/bin/bash\nset -e\napt-get update\n",
+		},
+		{
+			name:          "syntax error",
+			script:        "if true; then\n  echo missing fi\n",
+			expectErr:     true,
+			expectedIssue: "script: line 3: syntax error: unexpected end of file",
+		},
+		{
+			name:      "empty script",
+			script:    "  \n",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			issues, err := lint(context.Background(), scriptName, tc.script)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			if tc.expectedIssue != "" {
+				g.Expect(issues).To(ContainElement(tc.expectedIssue))
+			}
+		})
+	}
+}
+
+func TestIssueLines(t *testing.T) {
+	g := NewWithT(t)
+
+	output := []byte("/tmp/forge-script-1.sh:3:6: warning: Quote this to prevent word splitting. [SC2046]\n\n" +
+		"/tmp/forge-script-1.sh:5:1: error: Couldn't parse this if expression. [SC1073]\n")
+	g.Expect(issueLines(output, "/tmp/forge-script-1.sh", scriptName)).To(Equal([]string{
+		"script:3:6: warning: Quote this to prevent word splitting. [SC2046]",
+		"script:5:1: error: Couldn't parse this if expression. [SC1073]",
+	}))
+	g.Expect(issueLines(nil, "/tmp/forge-script-1.sh", scriptName)).To(BeEmpty())
+}
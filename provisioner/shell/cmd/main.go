@@ -22,6 +22,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -30,12 +33,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/docker"
+	forgelog "github.com/forge-build/forge/pkg/log"
+	"github.com/forge-build/forge/pkg/redact"
 	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/shell"
 )
 
 const (
@@ -49,25 +56,76 @@ var (
 	Namespace string
 	// ScriptToRun is the script to run
 	ScriptToRun string
-	// ScriptToRunRef is the name of the configmap containing the script to run
-	ScriptToRunRef string
+	// ScriptsDir is the directory of the mounted configmap containing the scripts to run, instead of ScriptToRun
+	ScriptsDir string
+	// StepsFile is the file of the mounted configmap containing the steps to run, instead of ScriptToRun
+	StepsFile string
+	// ConnectorType is the type of the connector to the machine, ssh or docker-exec
+	ConnectorType string
 	// SSHCredentialsSecretName is the name of the secret containing the credentials
 	SSHCredentialsSecretName string
+	// SSHPort is the port to connect to on the machine
+	SSHPort int
+	// SSHUsername is the username used when the ssh-credentials secret has none
+	SSHUsername string
+	// SSHBastionSecretName is the name of the secret containing the credentials of the bastion host
+	SSHBastionSecretName string
+	// SSHBastionPort is the port to connect to on the bastion host
+	SSHBastionPort int
+	// SSHHostKeyFingerprints are the comma separated fingerprints of the accepted host keys
+	SSHHostKeyFingerprints string
+	// SSHSudo runs the script with sudo
+	SSHSudo bool
+	// SSHSudoUser is the user sudo runs the script as
+	SSHSudoUser string
+	// SSHSudoPassword pipes the password of the ssh-credentials secret to sudo
+	SSHSudoPassword bool
+	// SSHConnectTimeout is how long a single connection attempt may take
+	SSHConnectTimeout time.Duration
+	// KubeconfigSecretName is the name of the secret containing the kubeconfig made available to the script
+	KubeconfigSecretName string
+	// KubeconfigSecretKey is the key of the secret containing the kubeconfig
+	KubeconfigSecretKey string
+	// WorkspaceSecretName is the name of the secret containing the presigned URLs of the workspace of the Build
+	WorkspaceSecretName string
+	// VariablesSecretName is the name of the secret containing the variables of the Build
+	VariablesSecretName string
+	// DryRun checks the script instead of running it on the machine
+	DryRun bool
 )
 
 func main() {
-	ctrl.SetLogger(klog.Background())
-	klog.InitFlags(nil)
-
+	var logOptions forgelog.Options
+	logOptions.BindFlags(flag.CommandLine)
 	flag.StringVar(&Namespace, "namespace", "forge-core", "The Build namespace")
 	flag.StringVar(&ScriptToRun, "run-script", "", "The script to run")
-	flag.StringVar(&ScriptToRunRef, "run-script-ref", "", "The name of configmap containing the script to run")
+	flag.StringVar(&ScriptsDir, "run-scripts-dir", "", "The directory of the mounted configmap containing the scripts to run in lexical order, instead of --run-script")
+	flag.StringVar(&StepsFile, "run-steps", "", "The file of the mounted configmap containing the steps to run in order, instead of --run-script")
+	flag.StringVar(&ConnectorType, "connector-type", buildv1.ConnectorTypeSSH, "The type of the connector to the machine, ssh or docker-exec")
 	flag.StringVar(&SSHCredentialsSecretName, "ssh-credentials-secret-name", "", "The name of secret containing the ssh credentials")
+	flag.IntVar(&SSHPort, "ssh-port", 0, "The port to connect to on the machine, defaults to 22")
+	flag.StringVar(&SSHUsername, "ssh-username", "", "The username used when the ssh-credentials secret has none")
+	flag.StringVar(&SSHBastionSecretName, "ssh-bastion-secret-name", "", "The name of secret containing the credentials of the bastion host to connect through")
+	flag.IntVar(&SSHBastionPort, "ssh-bastion-port", 0, "The port to connect to on the bastion host, defaults to 22")
+	flag.StringVar(&SSHHostKeyFingerprints, "ssh-host-key-fingerprints", "", "The comma separated SHA256 fingerprints of the accepted host keys, any host key is accepted if empty")
+	flag.BoolVar(&SSHSudo, "ssh-sudo", false, "Run the script with sudo")
+	flag.StringVar(&SSHSudoUser, "ssh-sudo-user", "", "The user sudo runs the script as, defaults to root")
+	flag.BoolVar(&SSHSudoPassword, "ssh-sudo-password", false, "Pipe the password of the ssh-credentials secret to sudo")
+	flag.DurationVar(&SSHConnectTimeout, "ssh-timeout", 0, "How long a single connection attempt may take, defaults to 10s")
+	flag.StringVar(&KubeconfigSecretName, "kubeconfig-secret-name", "", "The name of secret containing the kubeconfig made available to the script")
+	flag.StringVar(&KubeconfigSecretKey, "kubeconfig-secret-key", "value", "The key of the secret containing the kubeconfig")
+	flag.StringVar(&WorkspaceSecretName, "workspace-secret-name", "", "The name of secret containing the presigned URLs of the workspace exported to the script")
+	flag.StringVar(&VariablesSecretName, "variables-secret-name", "", "The name of secret containing the variables of the Build exported to the script")
+	flag.BoolVar(&DryRun, "dry-run", false, "Check the script with bash -n and shellcheck instead of running it on the machine")
 
 	flag.Parse()
 
-	ctrl.SetLogger(klog.NewKlogr())
-	logger := ctrl.Log.WithName("shell-provisioner")
+	if _, err := forgelog.Setup(logOptions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger := ctrl.Log.WithName("shell-provisioner").WithValues(forgelog.BuildKey, os.Getenv(forgelog.BuildNameEnv),
+		forgelog.NamespaceKey, Namespace, forgelog.ProvisionerIDKey, os.Getenv(forgelog.ProvisionerIDEnv))
 	ctx := context.Background()
 
 	logger.Info("Starting shell provisioner")
@@ -75,7 +133,27 @@ func main() {
 	k8sClient, err := initClient()
 	if err != nil {
 		logger.Error(err, "Error creating Kubernetes client")
-		klog.Exit(err)
+		os.Exit(1)
+	}
+
+	// The script to run and the scripts of a configmap are run as a single step.
+	steps := []shell.Step{{Name: scriptName, Scripts: []shell.Script{{Name: scriptName, Content: ScriptToRun}}}}
+	switch {
+	case StepsFile != "":
+		logger.Info("Reading the steps", "file", StepsFile)
+		steps, err = readSteps(StepsFile)
+	case ScriptsDir != "":
+		logger.Info("Reading the scripts", "dir", ScriptsDir)
+		steps[0].Scripts, err = readScripts(ScriptsDir)
+	}
+	if err != nil {
+		logger.Error(err, "Error reading the scripts")
+		os.Exit(1)
+	}
+
+	if DryRun {
+		dryRun(ctx, logger, steps)
+		return
 	}
 
 	logger.Info("Fetching the ssh-credentials secret")
@@ -84,60 +162,259 @@ func main() {
 	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: SSHCredentialsSecretName}, secret)
 	if err != nil {
 		logger.Error(err, "Error getting secret")
-		klog.Exit(err)
+		os.Exit(1)
+	}
+
+	var bastion *corev1.Secret
+	if SSHBastionSecretName != "" {
+		logger.Info("Fetching the bastion secret", "secret", SSHBastionSecretName)
+		bastion = &corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: SSHBastionSecretName}, bastion); err != nil {
+			logger.Error(err, "Error getting bastion secret")
+			os.Exit(1)
+		}
+	}
+
+	var kubeconfig []byte
+	if KubeconfigSecretName != "" {
+		logger.Info("Fetching the kubeconfig secret", "secret", KubeconfigSecretName)
+		kubeconfigSecret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: KubeconfigSecretName}, kubeconfigSecret); err != nil {
+			logger.Error(err, "Error getting kubeconfig secret")
+			os.Exit(1)
+		}
+		kubeconfig = kubeconfigSecret.Data[KubeconfigSecretKey]
+		if len(kubeconfig) == 0 {
+			err := errors.Errorf("secret %s has no kubeconfig in key %s", KubeconfigSecretName, KubeconfigSecretKey)
+			logger.Error(err, "Error getting kubeconfig secret")
+			os.Exit(1)
+		}
 	}
 
-	// Read scriptToRunRef
-	if ScriptToRunRef != "" {
-		logger.Info("Fetching the script-to-run from ConfigMap")
-		cm := &corev1.ConfigMap{}
-		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: ScriptToRunRef}, cm); err != nil {
-			logger.Error(err, "Error getting configmap")
-			klog.Exit(err)
+	var workspace map[string][]byte
+	if WorkspaceSecretName != "" {
+		logger.Info("Fetching the workspace secret", "secret", WorkspaceSecretName)
+		workspaceSecret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: WorkspaceSecretName}, workspaceSecret); err != nil {
+			logger.Error(err, "Error getting workspace secret")
+			os.Exit(1)
 		}
-		for _, v := range cm.Data {
-			ScriptToRun = v
+		workspace = workspaceSecret.Data
+	}
+
+	variables := &corev1.Secret{}
+	if VariablesSecretName != "" {
+		logger.Info("Fetching the variables secret", "secret", VariablesSecretName)
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: VariablesSecretName}, variables); err != nil {
+			logger.Error(err, "Error getting variables secret")
+			os.Exit(1)
 		}
 	}
 
-	err = run(logger, secret)
+	archives, err := sourceArchives(ctx, k8sClient, steps)
+	if err != nil {
+		logger.Error(err, "Error preparing the sources")
+		os.Exit(1)
+	}
+
+	statuses, err := run(logger, steps, secret, bastion, kubeconfig, workspace, variables, archives)
+	if StepsFile != "" && statuses != nil {
+		if err := reportStepStatuses(statuses); err != nil {
+			logger.Error(err, "Error reporting the outcomes of the steps")
+		}
+	}
 	if err != nil {
 		logger.Error(err, "Error running script")
-		klog.Exit(err)
+		os.Exit(1)
 	}
 }
 
-func run(logger logr.Logger, secret *corev1.Secret) error {
-	sshClient, err := ssh.NewSSHClient(secret)
+// dryRun checks the scripts of the steps and reports the issues found, it exits with an error if a script is invalid.
+func dryRun(ctx context.Context, logger logr.Logger, steps []shell.Step) {
+	var issues []string
+	var err error
+	for _, step := range steps {
+		for _, s := range step.Scripts {
+			logger.Info("Checking the script", "step", step.Name, "script", s.Name)
+			scriptIssues, lintErr := lint(ctx, s.Name, s.Content)
+			issues = append(issues, scriptIssues...)
+			if err == nil {
+				err = lintErr
+			}
+		}
+	}
+	if len(issues) > 0 {
+		logger.Info("Issues found in the script", "issues", issues)
+		if err := reportIssues(issues); err != nil {
+			logger.Error(err, "Error reporting the issues")
+		}
+	}
 	if err != nil {
-		return errors.Wrap(err, "Error creating SSH client")
+		logger.Error(err, "Script check failed")
+		os.Exit(1)
 	}
-	logger.Info("Connecting to the machine via ssh")
-	if err := sshClient.WaitForSSH(SSHTimeout); err != nil {
-		return errors.Wrap(err, "failed to connect to the machine via ssh")
+	logger.Info("Scripts checked")
+}
+
+// run runs the steps on the machine, in order, and returns their outcomes. No outcome is returned when the steps
+// could not be run, e.g. the machine is unreachable. archives are the archives of the sources of the steps.
+func run(logger logr.Logger, steps []shell.Step, secret, bastion *corev1.Secret, kubeconfig []byte, workspace map[string][]byte, variables *corev1.Secret, archives map[string][]byte) ([]shell.StepStatus, error) {
+	m, err := connect(logger, secret, bastion)
+	if err != nil {
+		return nil, err
 	}
-	defer sshClient.Disconnect()
+	defer m.Disconnect()
 
-	logger.Info("SSH connection established")
-	script := ScriptToRun
-	if script == "" {
-		return errors.New("script to run is empty")
+	for _, step := range steps {
+		for _, s := range step.Scripts {
+			if s.Content == "" {
+				return nil, errors.Errorf("%s to run is empty", s.Name)
+			}
+		}
+	}
+
+	if len(kubeconfig) > 0 {
+		kubeconfigPath := remoteKubeconfigPath()
+		logger.Info("Uploading the kubeconfig to the machine", "path", kubeconfigPath)
+		if err := m.Upload(bytes.NewReader(kubeconfig), kubeconfigPath, 0o600); err != nil {
+			return nil, errors.Wrap(err, "failed to upload the kubeconfig")
+		}
+		defer func() {
+			// The kubeconfig must not be left on the machine, it would end up in the image.
+			if err := m.Run(fmt.Sprintf("rm -f %s", kubeconfigPath), io.Discard, io.Discard); err != nil {
+				logger.Error(err, "Failed to remove the kubeconfig from the machine", "path", kubeconfigPath)
+			}
+		}()
+	}
+	// The secrets are masked in the output of the scripts, so they are not leaked in the logs and the status of the
+	// provisioner.
+	redactor := redact.New(append(redact.CredentialsValues(secret), redact.MaskedVariables(variables)...)...)
+	logger = redactor.Logger(logger)
+
+	statuses := pendingStatuses(steps)
+	for i, step := range steps {
+		if err := runStep(logger, m, step, kubeconfig, workspace, variables, redactor, archives[step.Name]); err != nil {
+			statuses[i] = failedStatus(step.Name, err)
+			return statuses, err
+		}
+		statuses[i].Phase = string(buildv1.ProvisionerStatusCompleted)
+	}
+	return statuses, nil
+}
+
+// runStep runs the scripts of the step, or uploads its file or the archive of its source, on the machine.
+func runStep(logger logr.Logger, m machine, step shell.Step, kubeconfig []byte, workspace map[string][]byte, variables *corev1.Secret, redactor *redact.Redactor, archive []byte) error {
+	if step.Source != nil {
+		logger.Info("Copying the source to the machine", "step", step.Name, "path", step.Source.Destination)
+		if err := m.Upload(bytes.NewReader(archive), remoteArchivePath, 0o600); err != nil {
+			return errors.Wrapf(err, "failed to upload the source of %s", step.Name)
+		}
+		return runScript(logger, m, shell.Script{Name: step.Name, Content: extractCommand(step.Source.Destination)},
+			nil, nil, &corev1.Secret{}, redactor)
+	}
+	if step.File != nil {
+		logger.Info("Uploading the file to the machine", "step", step.Name, "path", step.File.Destination)
+		if err := m.Upload(strings.NewReader(step.File.Content), step.File.Destination, step.File.Mode); err != nil {
+			return errors.Wrapf(err, "failed to upload %s", step.File.Destination)
+		}
+		return nil
+	}
+	for _, s := range step.Scripts {
+		if err := runScript(logger, m, s, kubeconfig, workspace, variables, redactor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runScript runs the script on the machine with the kubeconfig, the workspace and the variables of the Build,
+// the secrets are masked in its output.
+func runScript(logger logr.Logger, m machine, s shell.Script, kubeconfig []byte, workspace map[string][]byte, variables *corev1.Secret, redactor *redact.Redactor) error {
+	content := s.Content
+	if len(kubeconfig) > 0 {
+		content = withKubeconfig(content, remoteKubeconfigPath())
+	}
+	if len(workspace) > 0 {
+		content = withExports(content, workspace)
+	}
+	if len(variables.Data) > 0 {
+		content = withExports(content, variables.Data)
 	}
 
-	logger.Info("Running the script")
+	logger.Info("Running the script", "script", s.Name)
 	output := &bytes.Buffer{}
 	errOutput := &bytes.Buffer{}
-	err = sshClient.Run(
-		script,
+	err := m.Run(
+		content,
 		output,
 		errOutput,
 	)
+	stdout, stderr := redactor.String(output.String()), redactor.String(errOutput.String())
 	if err != nil {
-		logger.Error(err, "Failed to run script", "output", output.String(), "error", errOutput.String())
-		return errors.Wrapf(err, "Failed to run script: error: %s, output: %s", errOutput.String(), output.String())
+		logger.Error(err, "Failed to run script", "script", s.Name, "output", stdout, "error", stderr)
+		return errors.Wrapf(err, "Failed to run %s: error: %s, output: %s", s.Name, stderr, stdout)
 	}
-	logger.WithValues("output", output.String()).Info("Script executed")
+	logger.WithValues("script", s.Name, "output", stdout).Info("Script executed")
+	return nil
+}
+
+// machine runs the commands and uploads the files of the steps on the machine of the Build.
+type machine interface {
+	Run(command string, stdout io.Writer, stderr io.Writer) error
+	Upload(src io.Reader, dst string, mode uint32) error
+	Disconnect()
+}
 
+// connect connects to the machine described by the credentials secret with the connector of the Build.
+func connect(logger logr.Logger, secret, bastion *corev1.Secret) (machine, error) {
+	if ConnectorType == buildv1.ConnectorTypeDockerExec {
+		connector, err := docker.NewConnector(secret, SSHUsername)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating docker-exec connector")
+		}
+		logger.Info("Connecting to the container via docker exec")
+		if _, err := connector.WaitForContainer(context.Background(), SSHTimeout); err != nil {
+			return nil, errors.Wrap(err, "failed to connect to the container")
+		}
+		logger.Info("Container connection established")
+		return connector, nil
+	}
+
+	sshClient, err := ssh.NewSSHClient(secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating SSH client")
+	}
+	sshClient.SetConnectorDefaults(SSHPort, SSHUsername)
+	sshClient.Options.Logger = logger
+	if err := setSSHOptions(sshClient, bastion); err != nil {
+		return nil, err
+	}
+	logger.Info("Connecting to the machine via ssh")
+	if err := sshClient.WaitForSSH(SSHTimeout); err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the machine via ssh")
+	}
+	logger.Info("SSH connection established")
+	return sshClient, nil
+}
+
+// setSSHOptions applies the bastion, host key, sudo and timeout flags to the options of the client.
+func setSSHOptions(sshClient *ssh.SSHClient, bastion *corev1.Secret) error {
+	sshClient.Options.ConnectTimeout = SSHConnectTimeout
+	if bastion != nil {
+		sshClient.Options.Bastion = ssh.NewBastion(bastion, SSHBastionPort)
+	}
+	if SSHHostKeyFingerprints != "" {
+		sshClient.Options.HostKeyFingerprints = strings.Split(SSHHostKeyFingerprints, ",")
+	}
+	if SSHSudo {
+		sshClient.Options.Sudo = &ssh.Sudo{User: SSHSudoUser}
+		if SSHSudoPassword {
+			if sshClient.Creds.SSHPassword == "" {
+				return errors.New("sudo requires a password but the ssh-credentials secret has none")
+			}
+			sshClient.Options.Sudo.Password = sshClient.Creds.SSHPassword
+		}
+	}
 	return nil
 }
 
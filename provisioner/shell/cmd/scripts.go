@@ -0,0 +1,100 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+// stepMessageBytes is the maximum size of the message of a failed step, the outcomes of all the steps
+// must fit in the termination message of the container.
+const stepMessageBytes = 512
+
+// readScripts returns the scripts of the ConfigMap mounted in dir, in the lexical order of their keys.
+// The hidden entries, e.g. the ..data link maintained by the kubelet, are skipped.
+func readScripts(dir string) ([]shell.Script, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading the scripts: %w", err)
+	}
+	scripts := []shell.Script{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading script %s: %w", entry.Name(), err)
+		}
+		scripts = append(scripts, shell.Script{Name: entry.Name(), Content: string(content)})
+	}
+	if len(scripts) == 0 {
+		return nil, fmt.Errorf("no script found in %s", dir)
+	}
+	return scripts, nil
+}
+
+// readSteps returns the steps of the given file, written by the controller.
+func readSteps(file string) ([]shell.Step, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading the steps: %w", err)
+	}
+	steps := []shell.Step{}
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("decoding the steps: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no step found in %s", file)
+	}
+	return steps, nil
+}
+
+// pendingStatuses returns the statuses of the steps before they run.
+func pendingStatuses(steps []shell.Step) []shell.StepStatus {
+	statuses := make([]shell.StepStatus, 0, len(steps))
+	for _, step := range steps {
+		statuses = append(statuses, shell.StepStatus{Name: step.Name, Phase: string(buildv1.ProvisionerStatusPending)})
+	}
+	return statuses
+}
+
+// failedStatus returns the status of a step which failed with the given error, its message is truncated
+// to the last stepMessageBytes.
+func failedStatus(name string, err error) shell.StepStatus {
+	message := err.Error()
+	if len(message) > stepMessageBytes {
+		message = message[len(message)-stepMessageBytes:]
+	}
+	return shell.StepStatus{Name: name, Phase: string(buildv1.ProvisionerStatusFailed), Message: message}
+}
+
+// reportStepStatuses writes the outcomes of the steps to the termination message of the container.
+func reportStepStatuses(statuses []shell.StepStatus) error {
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(TerminationMessagePath, data, 0o644)
+}
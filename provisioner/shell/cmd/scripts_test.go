@@ -0,0 +1,51 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+func TestReadScripts(t *testing.T) {
+	g := NewWithT(t)
+
+	// A ConfigMap volume holds links to the keys of the ConfigMap in a hidden directory.
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..2024_05_01_10_30_00.000000000")
+	g.Expect(os.Mkdir(data, 0o755)).To(Succeed())
+	for name, content := range map[string]string{"20-install.sh": "apt-get install -y nginx", "10-update.sh": "apt-get update"} {
+		g.Expect(os.WriteFile(filepath.Join(data, name), []byte(content), 0o644)).To(Succeed())
+		g.Expect(os.Symlink(filepath.Join(filepath.Base(data), name), filepath.Join(dir, name))).To(Succeed())
+	}
+	g.Expect(os.Symlink(filepath.Base(data), filepath.Join(dir, "..data"))).To(Succeed())
+
+	scripts, err := readScripts(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(scripts).To(Equal([]shell.Script{
+		{Name: "10-update.sh", Content: "apt-get update"},
+		{Name: "20-install.sh", Content: "apt-get install -y nginx"},
+	}))
+
+	_, err = readScripts(t.TempDir())
+	g.Expect(err).To(HaveOccurred())
+}
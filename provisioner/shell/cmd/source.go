@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/forge-build/forge/pkg/git"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+// remoteArchivePath is the path of the machine the archive of a source is uploaded to before it is extracted.
+const remoteArchivePath = "/tmp/forge-source.tar.gz"
+
+// sourceArchives returns the gzipped tar archives of the sources of the steps, by step name. The git repositories
+// are cloned before connecting to the machine, so a source which cannot be cloned fails the Job right away.
+func sourceArchives(ctx context.Context, c client.Client, steps []shell.Step) (map[string][]byte, error) {
+	archives := map[string][]byte{}
+	for _, step := range steps {
+		if step.Source == nil {
+			continue
+		}
+		dir := ""
+		if g := step.Source.Git; g != nil {
+			var credentials map[string][]byte
+			if g.SecretName != "" {
+				secret := &corev1.Secret{}
+				if err := c.Get(ctx, client.ObjectKey{Namespace: Namespace, Name: g.SecretName}, secret); err != nil {
+					return nil, errors.Wrapf(err, "failed to get the git secret %s", g.SecretName)
+				}
+				credentials = secret.Data
+			}
+			tmp, err := os.MkdirTemp("", "source-")
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create the repository directory")
+			}
+			defer os.RemoveAll(tmp)
+			if err := git.Clone(ctx, g.URL, g.Ref, tmp, credentials); err != nil {
+				return nil, errors.Wrapf(err, "failed to clone the source of step %s", step.Name)
+			}
+			dir = tmp
+		}
+		archive, err := sourceArchive(dir, step.Source.Files)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to archive the source of step %s", step.Name)
+		}
+		archives[step.Name] = archive
+	}
+	return archives, nil
+}
+
+// sourceArchive returns a gzipped tar archive of the regular files of dir, but its .git directory, and of the given
+// files, which take precedence. dir is not archived if empty.
+func sourceArchive(dir string, files map[string]string) ([]byte, error) {
+	contents := map[string][]byte{}
+	modes := map[string]int64{}
+	if dir != "" {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			contents[filepath.ToSlash(rel)] = data
+			modes[filepath.ToSlash(rel)] = int64(info.Mode().Perm())
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, content := range files {
+		contents[name] = []byte(content)
+		modes[name] = 0o644
+	}
+
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: modes[name], Size: int64(len(contents[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(contents[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// extractCommand returns the command replacing destination with the archive uploaded to remoteArchivePath,
+// the files are owned by the user running the command rather than by the user of the Job.
+func extractCommand(destination string) string {
+	dst, archive := ssh.Quote(destination), ssh.Quote(remoteArchivePath)
+	return strings.Join([]string{
+		fmt.Sprintf("rm -rf %s", dst),
+		fmt.Sprintf("mkdir -p %s", dst),
+		fmt.Sprintf("tar -xzf %s --no-same-owner -C %s", archive, dst),
+		fmt.Sprintf("rm -f %s", archive),
+	}, " && ")
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSourceArchive(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"site.pp":               "include nginx",
+		"modules/nginx/init.pp": "class nginx {}",
+		".git/config":           "[core]",
+		"hiera.yaml":            "version: 5",
+	} {
+		p := filepath.Join(dir, name)
+		g.Expect(os.MkdirAll(filepath.Dir(p), 0o755)).To(Succeed())
+		g.Expect(os.WriteFile(p, []byte(content), 0o600)).To(Succeed())
+	}
+
+	archive, err := sourceArchive(dir, map[string]string{"hiera.yaml": "version: 6", ".forge/node.json": "{}"})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	g.Expect(err).ToNot(HaveOccurred())
+	tr := tar.NewReader(gz)
+	names := []string{}
+	contents := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(tr)
+		g.Expect(err).ToNot(HaveOccurred())
+		names = append(names, header.Name)
+		contents[header.Name] = string(data)
+	}
+	g.Expect(names).To(Equal([]string{".forge/node.json", "hiera.yaml", "modules/nginx/init.pp", "site.pp"}))
+	g.Expect(contents["hiera.yaml"]).To(Equal("version: 6"))
+	g.Expect(contents["modules/nginx/init.pp"]).To(Equal("class nginx {}"))
+}
+
+func TestExtractCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(extractCommand("/tmp/forge config")).To(Equal("rm -rf '/tmp/forge config' && mkdir -p '/tmp/forge config' && " +
+		"tar -xzf '/tmp/forge-source.tar.gz' --no-same-owner -C '/tmp/forge config' && rm -f '/tmp/forge-source.tar.gz'"))
+}
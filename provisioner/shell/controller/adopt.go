@@ -0,0 +1,99 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sapierror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+	"github.com/forge-build/forge/util"
+	jobpredicates "github.com/forge-build/forge/util/predicates/jobs"
+)
+
+// adoptJobs scans the finished shell Jobs once the controller starts, so the Jobs left over by a controller
+// which stopped while processing them are not left unprocessed:
+// - the Jobs whose provisioner is still running in the Build are queued to record their result.
+// - the Jobs whose result has already been recorded are deleted.
+// - the Jobs whose Build, or provisioner, no longer exists are deleted, their result can't be recorded.
+func (r *ShellJobController) adoptJobs(ctx context.Context) error {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(ctx, jobs, client.InNamespace(r.Namespace),
+		client.MatchingLabels{buildv1.ManagedByLabel: shell.ForgeProvisionerShellName}); err != nil {
+		return errors.Wrap(err, "failed to list the shell jobs")
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if _, finished := jobpredicates.TerminalCondition(job); !finished || !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		key := client.ObjectKey{
+			Namespace: job.GetLabels()[buildv1.BuildNamespaceLabel],
+			Name:      job.GetLabels()[buildv1.BuildNameLabel],
+		}
+		provisionerID := job.GetLabels()[buildv1.ProvisionerIDLabel]
+		if key.Name == "" || provisionerID == "" {
+			continue
+		}
+
+		build := &buildv1.Build{}
+		if err := r.Client.Get(ctx, key, build); err != nil {
+			if !k8sapierror.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get the build of job %s", job.Name)
+			}
+			r.Logger.Info("Deleting job of a deleted build", "job", job.Name, "build", key)
+			if err := r.deleteJob(ctx, job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		provisioner, err := util.GetProvisionerByID(build, provisionerID)
+		if err != nil {
+			// The Job has been created but its provisioner ID has not been recorded in the Build,
+			// the Build creates a new Job for the provisioner.
+			r.Logger.Info("Deleting job of an unknown provisioner", "job", job.Name, "build", key, "provisionerID", provisionerID)
+			if err := r.deleteJob(ctx, job); err != nil {
+				return err
+			}
+			continue
+		}
+		if ptr.Deref(provisioner.Status, "") != buildv1.ProvisionerStatusRunning {
+			r.Logger.Info("Deleting job whose result is recorded", "job", job.Name, "build", key, "provisionerID", provisionerID)
+			if err := r.deleteJob(ctx, job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r.Logger.Info("Adopting unprocessed job", "job", job.Name, "build", key, "provisionerID", provisionerID)
+		select {
+		case r.adoptedJobs <- event.GenericEvent{Object: job}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+func TestAdoptJobs(t *testing.T) {
+	complete := batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}
+	newJob := func(name, buildName, provisionerID string, conditions ...batchv1.JobCondition) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: name, Labels: map[string]string{
+				buildv1.ManagedByLabel:      shell.ForgeProvisionerShellName,
+				buildv1.BuildNameLabel:      buildName,
+				buildv1.BuildNamespaceLabel: "images",
+				buildv1.ProvisionerIDLabel:  provisionerID,
+			}},
+			Status: batchv1.JobStatus{Conditions: conditions},
+		}
+	}
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+			{UUID: ptr.To("running"), Status: ptr.To(buildv1.ProvisionerStatusRunning)},
+			{UUID: ptr.To("recorded"), Status: ptr.To(buildv1.ProvisionerStatusCompleted)},
+			{UUID: ptr.To("unfinished"), Status: ptr.To(buildv1.ProvisionerStatusRunning)},
+		}},
+	}
+
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		build,
+		newJob("unprocessed", "ubuntu", "running", complete),
+		newJob("recorded", "ubuntu", "recorded", complete),
+		newJob("unfinished", "ubuntu", "unfinished"),
+		newJob("unknown-provisioner", "ubuntu", "unknown", complete),
+		newJob("deleted-build", "centos", "1234", complete),
+	).Build()
+
+	r := &ShellJobController{
+		Client:      c,
+		Logger:      logr.Discard(),
+		Namespace:   ForgeCoreNamespace,
+		adoptedJobs: make(chan event.GenericEvent, 5),
+	}
+	g.Expect(r.adoptJobs(context.Background())).To(Succeed())
+
+	close(r.adoptedJobs)
+	adopted := []string{}
+	for e := range r.adoptedJobs {
+		adopted = append(adopted, e.Object.GetName())
+	}
+	g.Expect(adopted).To(ConsistOf("unprocessed"))
+
+	for name, exists := range map[string]bool{
+		"unprocessed":         true,
+		"unfinished":          true,
+		"recorded":            false,
+		"unknown-provisioner": false,
+		"deleted-build":       false,
+	} {
+		err := c.Get(context.Background(), client.ObjectKey{Namespace: ForgeCoreNamespace, Name: name}, &batchv1.Job{})
+		if exists {
+			g.Expect(err).ToNot(HaveOccurred(), name)
+		} else {
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), name)
+		}
+	}
+}
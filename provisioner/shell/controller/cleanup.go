@@ -0,0 +1,118 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+// DeleteJobs deletes the Jobs which ran the shell provisioners of the Build, along with their Pods and the copy
+// of their scripts. The Jobs are not owned by the Build, as the ones in the forge core namespace cannot be, so they
+// are not garbage collected with it wherever they run.
+func DeleteJobs(ctx context.Context, c client.Client, build *buildv1.Build) error {
+	jobs, err := listJobs(ctx, c, build)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		if err := deleteJob(ctx, c, &jobs[i]); err != nil {
+			return err
+		}
+	}
+
+	scripts := &corev1.ConfigMapList{}
+	if err := c.List(ctx, scripts, client.MatchingLabels{
+		buildv1.ManagedByLabel:      shell.ForgeProvisionerShellName,
+		buildv1.BuildNameLabel:      build.Name,
+		buildv1.BuildNamespaceLabel: build.Namespace,
+	}); err != nil {
+		return errors.Wrap(err, "failed to list the scripts ConfigMaps")
+	}
+	for i := range scripts.Items {
+		if err := c.Delete(ctx, &scripts.Items[i]); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed to delete the scripts ConfigMap %s", scripts.Items[i].Name)
+		}
+	}
+	return nil
+}
+
+// DeleteStaleJobs deletes the Jobs of the Build whose provisioner has been removed from the Build,
+// a stale Job would otherwise keep running and block the Jobs of the other provisioners.
+func DeleteStaleJobs(ctx context.Context, c client.Client, build *buildv1.Build) error {
+	jobs, err := listJobs(ctx, c, build)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if !isStaleJob(build, job) {
+			continue
+		}
+		ctrl.LoggerFrom(ctx).Info("Deleting the Job of a removed provisioner", "job", job.Name,
+			"provisionerID", job.Labels[buildv1.ProvisionerIDLabel])
+		if err := deleteJob(ctx, c, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStaleJob returns true if the provisioner which the Job runs is not part of the Build anymore.
+func isStaleJob(build *buildv1.Build, job *batchv1.Job) bool {
+	id := job.Labels[buildv1.ProvisionerIDLabel]
+	for _, p := range build.Spec.Provisioners {
+		if ptr.Deref(p.UUID, "") == id {
+			return false
+		}
+	}
+	return true
+}
+
+// listJobs returns the shell provisioner Jobs of the Build, in any namespace as the placement of the Jobs may
+// have changed since they have been created.
+func listJobs(ctx context.Context, c client.Client, build *buildv1.Build) ([]batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+	if err := c.List(ctx, jobs, client.MatchingLabels{
+		buildv1.BuildNameLabel:      build.Name,
+		buildv1.BuildNamespaceLabel: build.Namespace,
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to list the provisioner Jobs")
+	}
+	return jobs.Items, nil
+}
+
+// deleteJob deletes the Job along with its Pods, unless it is already being deleted.
+func deleteJob(ctx context.Context, c client.Client, job *batchv1.Job) error {
+	if !job.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "failed to delete provisioner Job %s", job.Name)
+	}
+	return nil
+}
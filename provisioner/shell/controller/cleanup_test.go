@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestDeleteStaleJobs(t *testing.T) {
+	testcases := []struct {
+		name            string
+		provisionerID   string
+		expectedDeleted bool
+	}{
+		{name: "provisioner of the build", provisionerID: "1234"},
+		{name: "removed provisioner", provisionerID: "5678", expectedDeleted: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			build := &buildv1.Build{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+				Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+					{UUID: ptr.To("1234"), Type: buildv1.ProvisionerTypeShell},
+				}},
+			}
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Namespace: ForgeCoreNamespace,
+				Name:      "shell",
+				Labels: map[string]string{
+					buildv1.BuildNameLabel:      "ubuntu",
+					buildv1.BuildNamespaceLabel: "images",
+					buildv1.ProvisionerIDLabel:  tc.provisionerID,
+				},
+			}}
+			scheme := runtime.NewScheme()
+			g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, job).Build()
+
+			g.Expect(DeleteStaleJobs(ctx, c, build)).To(Succeed())
+
+			err := c.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{})
+			if tc.expectedDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
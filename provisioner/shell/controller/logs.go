@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
+)
+
+const (
+	// logsTailLines is the number of lines of logs fetched from the Pod of a failed Job.
+	logsTailLines = 500
+
+	// statusLogsBytes is the size of the logs recorded in the status of the Build.
+	statusLogsBytes = 1024
+
+	// configMapLogsBytes is the size of the logs recorded in the logs ConfigMap of the Build.
+	configMapLogsBytes = 64 * 1024
+)
+
+// LogsConfigMapName returns the name of the ConfigMap holding the logs of the failed provisioner Jobs of a Build.
+func LogsConfigMapName(buildName string) string {
+	return fmt.Sprintf("%s-provisioner-logs", buildName)
+}
+
+// jobLogs returns the last logs of the container running the shell provisioner in the Pod of the Job.
+func (r *ShellJobController) jobLogs(ctx context.Context, job *batchv1.Job) (string, error) {
+	pod, err := r.getPodByJob(ctx, job)
+	if err != nil {
+		return "", err
+	}
+	logs, err := r.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: shelljob.ContainerName,
+		TailLines: ptr.To[int64](logsTailLines),
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the logs of pod %s", pod.Name)
+	}
+	return string(logs), nil
+}
+
+// recordLogs records the logs of the failed Job of the provisioner in the logs ConfigMap of the Build,
+// under the name of the provisioner or its UUID, it returns the name of the ConfigMap.
+func recordLogs(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, logs string) (string, error) {
+	key := spec.Name
+	if key == "" {
+		key = ptr.Deref(spec.UUID, "")
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: build.Namespace,
+			Name:      LogsConfigMapName(build.Name),
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, c, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[buildv1.BuildNameLabel] = build.Name
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key+".log"] = tail(logs, configMapLogsBytes)
+		return controllerutil.SetControllerReference(build, cm, c.Scheme())
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to record the provisioner logs in ConfigMap %s", cm.Name)
+	}
+	return cm.Name, nil
+}
+
+// tail returns the end of the logs fitting in size bytes, starting at a line when possible.
+func tail(logs string, size int) string {
+	if len(logs) <= size {
+		return logs
+	}
+	logs = logs[len(logs)-size:]
+	if i := strings.IndexByte(logs, '\n'); i >= 0 && i < len(logs)-1 {
+		return logs[i+1:]
+	}
+	return logs
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+// PlacementOptions define in which namespace the shell provisioner Jobs run, the Builds can override the placement.
+type PlacementOptions struct {
+	// CoreNamespace is the namespace of the Jobs placed in the core namespace, ForgeCoreNamespace is used if empty.
+	CoreNamespace string
+	// Placement is the placement of the Jobs of the Builds which don't set one, Core is used if empty.
+	Placement buildv1.ProvisionerJobPlacement
+}
+
+// JobNamespace returns the namespace of the shell provisioner Jobs of the Build.
+func (o PlacementOptions) JobNamespace(build *buildv1.Build) string {
+	placement := o.Placement
+	if build.Spec.ProvisionerJobPlacement != "" {
+		placement = build.Spec.ProvisionerJobPlacement
+	}
+	if placement == buildv1.ProvisionerJobPlacementBuild {
+		return build.Namespace
+	}
+	if o.CoreNamespace == "" {
+		return ForgeCoreNamespace
+	}
+	return o.CoreNamespace
+}
+
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;patch
+
+// ensureServiceAccount creates the shell provisioner service account in the namespace, bound to the
+// forge-provisioner-shell ClusterRole so the Jobs can read the secrets of the Builds of the namespace.
+func ensureServiceAccount(ctx context.Context, c client.Client, namespace string) error {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: shell.ForgeProvisionerShellName},
+	}
+	if _, err := controllerutil.CreateOrPatch(ctx, c, sa, func() error {
+		if sa.Labels == nil {
+			sa.Labels = map[string]string{}
+		}
+		sa.Labels[buildv1.ManagedByLabel] = shell.ForgeProvisionerShellName
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to create the service account of the shell provisioner in namespace %s", namespace)
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: shell.ForgeProvisionerShellName},
+	}
+	if _, err := controllerutil.CreateOrPatch(ctx, c, binding, func() error {
+		if binding.Labels == nil {
+			binding.Labels = map[string]string{}
+		}
+		binding.Labels[buildv1.ManagedByLabel] = shell.ForgeProvisionerShellName
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     shell.ForgeProvisionerShellName,
+		}
+		binding.Subjects = []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: namespace,
+			Name:      shell.ForgeProvisionerShellName,
+		}}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to bind the service account of the shell provisioner in namespace %s", namespace)
+	}
+	return nil
+}
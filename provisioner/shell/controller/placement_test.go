@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+func TestJobNamespace(t *testing.T) {
+	testcases := []struct {
+		name              string
+		options           PlacementOptions
+		buildPlacement    buildv1.ProvisionerJobPlacement
+		expectedNamespace string
+	}{
+		{name: "defaults", expectedNamespace: ForgeCoreNamespace},
+		{name: "core namespace", options: PlacementOptions{CoreNamespace: "forge-system"}, expectedNamespace: "forge-system"},
+		{
+			name:              "build placement",
+			options:           PlacementOptions{Placement: buildv1.ProvisionerJobPlacementBuild},
+			expectedNamespace: "images",
+		},
+		{
+			name:              "build overrides the core placement",
+			options:           PlacementOptions{CoreNamespace: "forge-system", Placement: buildv1.ProvisionerJobPlacementCore},
+			buildPlacement:    buildv1.ProvisionerJobPlacementBuild,
+			expectedNamespace: "images",
+		},
+		{
+			name:              "build overrides the build placement",
+			options:           PlacementOptions{CoreNamespace: "forge-system", Placement: buildv1.ProvisionerJobPlacementBuild},
+			buildPlacement:    buildv1.ProvisionerJobPlacementCore,
+			expectedNamespace: "forge-system",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+				Spec:       buildv1.BuildSpec{ProvisionerJobPlacement: tc.buildPlacement},
+			}
+			g.Expect(tc.options.JobNamespace(build)).To(Equal(tc.expectedNamespace))
+		})
+	}
+}
+
+func TestEnsureServiceAccount(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	g.Expect(ensureServiceAccount(ctx, c, "images")).To(Succeed())
+	// The service account is left as is when it already exists.
+	g.Expect(ensureServiceAccount(ctx, c, "images")).To(Succeed())
+
+	key := client.ObjectKey{Namespace: "images", Name: shell.ForgeProvisionerShellName}
+	g.Expect(c.Get(ctx, key, &corev1.ServiceAccount{})).To(Succeed())
+	binding := &rbacv1.RoleBinding{}
+	g.Expect(c.Get(ctx, key, binding)).To(Succeed())
+	g.Expect(binding.RoleRef.Kind).To(Equal("ClusterRole"))
+	g.Expect(binding.RoleRef.Name).To(Equal(shell.ForgeProvisionerShellName))
+	g.Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+		Kind: rbacv1.ServiceAccountKind, Namespace: "images", Name: shell.ForgeProvisionerShellName,
+	}))
+}
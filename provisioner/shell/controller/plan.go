@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell/job"
+)
+
+// ConnectorPlanKey is the key of the connector configuration in the plan ConfigMap of a Build.
+const ConnectorPlanKey = "connector.yaml"
+
+// PlanConfigMapName returns the name of the ConfigMap holding the plan of a Build run in dry-run mode: the Jobs,
+// the scripts and the connector configuration its provisioners would use.
+func PlanConfigMapName(buildName string) string {
+	return fmt.Sprintf("%s-plan", buildName)
+}
+
+// PlanKey returns the prefix of the keys of the provisioner in the plan ConfigMap, its name or its UUID.
+func PlanKey(spec *buildv1.ProvisionerSpec) string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+	return ptr.Deref(spec.UUID, "")
+}
+
+// RecordPlan adds the rendered objects to the plan ConfigMap of the Build, see PlanConfigMapName.
+func RecordPlan(ctx context.Context, c client.Client, build *buildv1.Build, data map[string]string) error {
+	return storeRendered(ctx, c, build, PlanConfigMapName(build.Name), data)
+}
+
+// RecordObjectPlan renders the object in YAML and adds it to the plan ConfigMap of the Build under the given key.
+func RecordObjectPlan(ctx context.Context, c client.Client, build *buildv1.Build, key string, obj interface{}) error {
+	rendered, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render %s", key)
+	}
+	return RecordPlan(ctx, c, build, map[string]string{key: string(rendered)})
+}
+
+// PlannedJob returns the Job which would run the first attempt of the provisioner, and the scripts it would mount,
+// without creating anything. The provisioner must run in a Job, see buildv1.ProvisionerType.RunsInJob.
+func PlannedJob(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, defaults ImageOptions, placement PlacementOptions) (*batchv1.Job, map[string]string, error) {
+	id := ptr.Deref(spec.UUID, uuid.New().String())
+	builder, scripts, err := newJobBuilder(ctx, c, build, spec, id, 1, defaults, placement.JobNamespace(build))
+	if err != nil {
+		return nil, nil, err
+	}
+	planned, err := builder.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	planned.TypeMeta = metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"}
+	return planned, scripts, nil
+}
+
+// recordPlannedJob renders the Job which would run the provisioner, and the scripts it would mount, in the plan
+// ConfigMap of the Build.
+func recordPlannedJob(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, builder *job.ShellJobBuilder, scripts map[string]string) error {
+	manifest, err := renderJob(builder)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{PlanKey(spec) + "-job.yaml": manifest}
+	if scripts != nil {
+		rendered, err := yaml.Marshal(scripts)
+		if err != nil {
+			return errors.Wrap(err, "failed to render the provisioner scripts")
+		}
+		data[PlanKey(spec)+"-scripts.yaml"] = string(rendered)
+	}
+	return RecordPlan(ctx, c, build, data)
+}
@@ -2,7 +2,6 @@ package controller
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	builderror "github.com/forge-build/forge/pkg/errors"
@@ -10,47 +9,225 @@ import (
 	"k8s.io/utils/ptr"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/ansible"
 	"github.com/forge-build/forge/provisioner/shell/job"
+	"github.com/forge-build/forge/util"
 	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
-	ShellProvisionerRepo = "ghcr.io/forge-build/forge-provisioner-shell"
-	ShellProvisionerTag  = "latest"
-
 	ForgeCoreNamespace = "forge-core"
 )
 
-func Reconcile(ctx context.Context, client client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (_ ctrl.Result, err error) {
+// ImageOptions are the defaults of the image of the shell provisioner Jobs, the provisioners can override them.
+type ImageOptions struct {
+	// Image is the image of the shell provisioner, job.DefaultImage, or ansible.DefaultImage for the ansible
+	// provisioners, is used if empty.
+	Image string
+	// PullPolicy is the pull policy of the image, IfNotPresent is used if empty.
+	PullPolicy corev1.PullPolicy
+	// PullSecrets are the secrets used to pull the image, in the namespace of the Jobs.
+	PullSecrets []corev1.LocalObjectReference
+}
+
+// imageOptions returns the image options of the provisioner, defaulted from the given options.
+func imageOptions(spec *buildv1.ProvisionerSpec, defaults ImageOptions) ImageOptions {
+	opts := defaults
+	if spec.Image != "" {
+		opts.Image = spec.Image
+	}
+	if spec.ImagePullPolicy != "" {
+		opts.PullPolicy = spec.ImagePullPolicy
+	}
+	if len(spec.ImagePullSecrets) > 0 {
+		opts.PullSecrets = spec.ImagePullSecrets
+	}
+	if opts.Image == "" && spec.Type == buildv1.ProvisionerTypeAnsible {
+		opts.Image = ansible.DefaultImage
+	}
+	return opts
+}
+
+// parametersJSON returns the parameters of an external provisioner as JSON.
+func parametersJSON(spec *buildv1.ProvisionerSpec) string {
+	if spec.Parameters == nil || len(spec.Parameters.Raw) == 0 {
+		return "{}"
+	}
+	return string(spec.Parameters.Raw)
+}
+
+// jobTimeout returns the active deadline of the Job of the provisioner, 0 means none. A kubeconfig is only handed to
+// the provisioner until it expires, the Job is stopped at this time.
+func jobTimeout(spec *buildv1.ProvisionerSpec, now time.Time) (time.Duration, error) {
+	var timeout time.Duration
+	if spec.ActiveDeadlineSeconds != nil {
+		timeout = time.Duration(*spec.ActiveDeadlineSeconds) * time.Second
+	}
+	if spec.Kubeconfig == nil {
+		return timeout, nil
+	}
+	remaining := spec.Kubeconfig.ExpirationTime.Sub(now)
+	if remaining <= 0 {
+		return 0, builderror.ConfigErrorf("the kubeconfig of provisioner %s expired at %s",
+			spec.Name, spec.Kubeconfig.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if timeout == 0 || remaining < timeout {
+		timeout = remaining
+	}
+	return timeout, nil
+}
+
+// newJobBuilder returns the builder of the Job running the given attempt of the provisioner, and the scripts the Job
+// mounts from its scripts ConfigMap if it runs scripts, see usesScriptsConfigMap. It has no side effect, the caller
+// creates the scripts ConfigMap.
+func newJobBuilder(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, id string, attempt int32, defaults ImageOptions, namespace string) (*job.ShellJobBuilder, map[string]string, error) {
+	image := imageOptions(spec, defaults)
+	builder := job.NewShellJobBuilder().
+		WithNamespace(namespace).
+		WithBuildNamespace(build.Namespace).
+		WithBuildName(build.Name).
+		WithJobName(jobName(build, spec, id)).
+		WithUUID(id).
+		WithAttempt(attempt).
+		WithImage(image.Image).
+		WithImagePullPolicy(image.PullPolicy).
+		WithImagePullSecrets(image.PullSecrets).
+		WithBackOffLimit(ptr.Deref(spec.BackoffLimit, 1))
+	if pod := spec.PodTemplate; pod != nil {
+		builder.WithResourceRequirements(pod.Resources).
+			WithNodeSelector(pod.NodeSelector).
+			WithTolerations(pod.Tolerations).
+			WithAffinity(pod.Affinity).
+			WithServiceAccountName(pod.ServiceAccountName).
+			WithPodSecurityContext(pod.SecurityContext).
+			WithSecurityContext(pod.ContainerSecurityContext)
+	}
+
+	builder.WithConnectorType(build.Spec.Connector.Type)
+	if build.Spec.Connector.Credentials != nil {
+		builder.WithSSHCredentialsSecretName(build.Spec.Connector.Credentials.Name)
+	}
+	builder.WithSSHConnector(build.Spec.Connector.Port, build.Spec.Connector.Username)
+	if bastion := build.Spec.Connector.Bastion; bastion != nil {
+		builder.WithSSHBastion(bastion.Credentials.Name, bastion.Port)
+	}
+	fingerprints, err := util.HostKeyFingerprints(ctx, c, build)
+	if err != nil {
+		return nil, nil, err
+	}
+	builder.WithSSHHostKeyFingerprints(fingerprints)
+	if sudo := build.Spec.Connector.Sudo; sudo != nil {
+		builder.WithSSHSudo(sudo.User, sudo.Password)
+	}
+	builder.WithSSHTimeout(build.Spec.Connector.GetTimeout())
+	var scripts map[string]string
+	if usesScriptsConfigMap(spec) {
+		scripts, err = resolveScripts(ctx, c, build, spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		builder.WithScriptsConfigMap(scriptsConfigMapName(build, spec, id), scriptsHash(scripts)).WithSteps(runsSteps(spec))
+		if spec.Ansible != nil {
+			builder.WithAnsible(ansible.Args(spec.Ansible, job.ScriptsMountPath))
+		}
+	} else if spec.Type == buildv1.ProvisionerTypeExternal {
+		builder.WithParameters(parametersJSON(spec))
+	} else if spec.Run != nil {
+		builder.WithScriptToRun(*spec.Run)
+	}
+	timeout, err := jobTimeout(spec, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if spec.Kubeconfig != nil {
+		builder.WithKubeconfig(spec.Kubeconfig.SecretRef.Name, spec.Kubeconfig.GetKey())
+	}
+	builder.WithTimeout(timeout)
+	if workspace := build.Status.Workspace; workspace != nil && workspace.SecretName != "" {
+		builder.WithWorkspace(workspace.SecretName)
+	}
+	if build.Status.VariablesSecretName != "" {
+		builder.WithVariables(build.Status.VariablesSecretName)
+	}
+	return builder, scripts, nil
+}
+
+func Reconcile(ctx context.Context, client client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, defaults ImageOptions, placement PlacementOptions) (_ ctrl.Result, err error) {
+	log := ctrl.LoggerFrom(ctx)
+	namespace := placement.JobNamespace(build)
+
+	// The Job of a provisioner whose previous attempt failed is recreated once its retry delay elapsed.
+	retrying := spec.UUID != nil && ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending) == buildv1.ProvisionerStatusPending
+	var attempts int32
+	if status := util.GetProvisionerStatus(build, spec); retrying && status != nil {
+		if status.NextAttemptAt != nil {
+			if wait := time.Until(status.NextAttemptAt.Time); wait > 0 {
+				return ctrl.Result{RequeueAfter: wait}, nil
+			}
+		}
+		attempts = status.Attempts
+	}
 
 	// Create the Job
-	if spec.UUID == nil {
-		id := uuid.New()
-		builder := job.NewShellJobBuilder().
-			WithNamespace(ForgeCoreNamespace).
-			WithBuildNamespace(build.Namespace).
-			WithBuildName(build.Name).
-			WithUUID(id.String()).
-			// TODO get repo and tag from variables
-			WithRepo("medchiheb/forge-shell-provisioner").
-			WithTag("dev").
-			WithBackOffLimit(ptr.Deref(spec.Retries, 1)).
-			WithSSHCredentialsSecretName(build.Spec.Connector.Credentials.Name)
-
-		if spec.Run != nil {
-			builder.WithScriptToRun(*spec.Run)
-		}
-		if spec.RunConfigMapRef != nil {
-			builder.WithScriptToRun(*spec.Run)
+	if spec.UUID == nil || retrying {
+		id := ptr.Deref(spec.UUID, uuid.New().String())
+		builder, scripts, err := newJobBuilder(ctx, client, build, spec, id, attempts+1, defaults, namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// The service account of the core namespace is not available to the Jobs running in the namespace of the Build.
+		if namespace == build.Namespace && (spec.PodTemplate == nil || spec.PodTemplate.ServiceAccountName == "") && !build.Spec.DryRun {
+			if err := ensureServiceAccount(ctx, client, namespace); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if scripts != nil && !build.Spec.DryRun {
+			if err := copyScripts(ctx, client, build, namespace, scriptsConfigMapName(build, spec, id), scripts); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if spec.Kubeconfig != nil {
+			log.Info("Handing a kubeconfig to the provisioner", "provisioner", spec.Name,
+				"secret", build.Namespace+"/"+spec.Kubeconfig.SecretRef.Name,
+				"expirationTime", spec.Kubeconfig.ExpirationTime)
+		}
+
+		if build.Spec.DryRun {
+			// Record the Job which would run the provisioner and its scripts, nothing runs in dry-run mode.
+			spec.UUID = ptr.To(id)
+			if err := recordPlannedJob(ctx, client, build, spec, builder, scripts); err != nil {
+				return ctrl.Result{}, err
+			}
+			spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+			return ctrl.Result{}, nil
+		}
+
+		if build.Spec.Simulate {
+			// Record the Job which would run the script, then check the script without retrying.
+			if err := recordSimulatedJob(ctx, client, build, spec, id, builder); err != nil {
+				return ctrl.Result{}, err
+			}
+			builder.WithDryRun(true).WithBackOffLimit(0)
 		}
 
 		desired, err := builder.Build()
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+		// The Job replaced after a change of its scripts may still be deleted.
+		terminating, err := jobTerminating(ctx, client, desired)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if terminating {
+			return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+		}
 
 		op, err := controllerutil.CreateOrPatch(ctx, client, desired, func() error {
 			return nil
@@ -59,8 +236,12 @@ func Reconcile(ctx context.Context, client client.Client, build *buildv1.Build,
 			return ctrl.Result{}, err
 		}
 
-		spec.UUID = ptr.To(id.String())
+		spec.UUID = ptr.To(id)
 		spec.Status = ptr.To(buildv1.ProvisionerStatusRunning)
+		status := util.RecordProvisionerStatus(build, spec)
+		status.StartedAt = ptr.To(metav1.Now())
+		status.Attempts = attempts + 1
+		status.NextAttemptAt = nil
 		if op != controllerutil.OperationResultNone {
 			// After job created we RequeueAfter 2 seconds.
 			return ctrl.Result{
@@ -72,6 +253,11 @@ func Reconcile(ctx context.Context, client client.Client, build *buildv1.Build,
 	switch *spec.Status {
 	case buildv1.ProvisionerStatusPending:
 	case buildv1.ProvisionerStatusRunning:
+		if usesScriptsConfigMap(spec) {
+			if err := replaceOutdatedJob(ctx, client, build, namespace, spec); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		// RequeueAfter 2 seconds.
 		return ctrl.Result{
 			RequeueAfter: 2 * time.Second,
@@ -85,9 +271,9 @@ func Reconcile(ctx context.Context, client client.Client, build *buildv1.Build,
 			return ctrl.Result{}, nil
 		}
 		// Fail the Build if provisioner failed.
-		build.Status.FailureReason = ptr.To(builderror.ProvisionerFailedError)
-		build.Status.FailureMessage = ptr.To(fmt.Sprintf("Provisioner %s failed with Reason %s and Message %s", *spec.UUID, *spec.FailureReason, *spec.FailureMessage))
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, builderror.Terminalf(builderror.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			ptr.Deref(spec.UUID, ""), ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
 	default:
 		return ctrl.Result{}, nil
 	}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+)
+
+func TestJobTimeout(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	kubeconfig := func(expiresIn time.Duration) *buildv1.KubeconfigSource {
+		return &buildv1.KubeconfigSource{
+			SecretRef:      corev1.LocalObjectReference{Name: "workload-kubeconfig"},
+			ExpirationTime: metav1.NewTime(now.Add(expiresIn)),
+		}
+	}
+
+	testcases := []struct {
+		name                  string
+		activeDeadlineSeconds *int64
+		kubeconfig            *buildv1.KubeconfigSource
+		expected              time.Duration
+		expectedErr           bool
+	}{
+		{
+			name:     "no deadline",
+			expected: 0,
+		},
+		{
+			name:                  "active deadline",
+			activeDeadlineSeconds: ptr.To[int64](600),
+			expected:              10 * time.Minute,
+		},
+		{
+			name:        "expired kubeconfig",
+			kubeconfig:  kubeconfig(-time.Minute),
+			expectedErr: true,
+		},
+		{
+			name:                  "kubeconfig expiring before the active deadline",
+			activeDeadlineSeconds: ptr.To[int64](3600),
+			kubeconfig:            kubeconfig(15 * time.Minute),
+			expected:              15 * time.Minute,
+		},
+		{
+			name:                  "kubeconfig expiring after the active deadline",
+			activeDeadlineSeconds: ptr.To[int64](600),
+			kubeconfig:            kubeconfig(time.Hour),
+			expected:              10 * time.Minute,
+		},
+		{
+			name:       "kubeconfig without active deadline",
+			kubeconfig: kubeconfig(time.Hour),
+			expected:   time.Hour,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := &buildv1.ProvisionerSpec{
+				Name:                  "join",
+				ActiveDeadlineSeconds: tc.activeDeadlineSeconds,
+				Kubeconfig:            tc.kubeconfig,
+			}
+			timeout, err := jobTimeout(spec, now)
+			if tc.expectedErr {
+				g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryConfigError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(timeout).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcileRetry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// The Job of the first attempt failed, the provisioner waits for its retry delay.
+	nextAttemptAt := metav1.NewTime(time.Now().Add(time.Minute))
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{{
+			UUID:    ptr.To("1234"),
+			Name:    "packages",
+			Type:    buildv1.ProvisionerTypeShell,
+			Run:     ptr.To("apt-get install -y nginx"),
+			Retries: ptr.To[int32](2),
+			Status:  ptr.To(buildv1.ProvisionerStatusPending),
+		}}},
+		Status: buildv1.BuildStatus{Provisioners: []buildv1.BuildProvisionerStatus{
+			{UUID: "1234", Attempts: 1, NextAttemptAt: &nextAttemptAt},
+		}},
+	}
+	spec := &build.Spec.Provisioners[0]
+
+	res, err := Reconcile(ctx, c, build, spec, ImageOptions{}, PlacementOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+	jobs := &batchv1.JobList{}
+	g.Expect(c.List(ctx, jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(BeEmpty())
+
+	// The Job is recreated once the delay elapsed, for the same provisioner.
+	build.Status.Provisioners[0].NextAttemptAt = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	_, err = Reconcile(ctx, c, build, spec, ImageOptions{}, PlacementOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(spec.UUID).To(Equal(ptr.To("1234")))
+	g.Expect(spec.Status).To(Equal(ptr.To(buildv1.ProvisionerStatusRunning)))
+	g.Expect(build.Status.Provisioners[0].Attempts).To(Equal(int32(2)))
+	g.Expect(build.Status.Provisioners[0].NextAttemptAt).To(BeNil())
+	g.Expect(c.List(ctx, jobs)).To(Succeed())
+	g.Expect(jobs.Items).To(HaveLen(1))
+	g.Expect(jobs.Items[0].Annotations).To(HaveKeyWithValue(buildv1.ProvisionerAttemptAnnotation, "2"))
+	g.Expect(jobs.Items[0].Labels).To(HaveKeyWithValue(buildv1.ProvisionerIDLabel, "1234"))
+	// The retries of the provisioner recreate the Job, they are not retries of its Pod.
+	g.Expect(jobs.Items[0].Spec.BackoffLimit).To(Equal(ptr.To[int32](1)))
+}
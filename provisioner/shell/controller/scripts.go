@@ -0,0 +1,304 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	builderror "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/kube"
+	"github.com/forge-build/forge/provisioner/ansible"
+	"github.com/forge-build/forge/provisioner/configmanagement"
+	"github.com/forge-build/forge/provisioner/shell"
+	"github.com/forge-build/forge/provisioner/shell/job"
+	"github.com/forge-build/forge/util/conditions"
+	jobpredicates "github.com/forge-build/forge/util/predicates/jobs"
+)
+
+// ScriptsConfigMapName returns the name of the ConfigMap mounted by the provisioner Jobs of a Build, it holds a copy
+// of the scripts or the steps of the provisioner as ConfigMaps can only be mounted in their namespace.
+func ScriptsConfigMapName(buildName string) string {
+	return fmt.Sprintf("%s-scripts", job.GetShellJobName(buildName))
+}
+
+// jobName returns the name of the Job running the provisioner with the given UUID. The provisioners of a Build run
+// one after the other in a Job named after the Build, the provisioners of a concurrency group run at the same time in
+// their own Job.
+func jobName(build *buildv1.Build, spec *buildv1.ProvisionerSpec, id string) string {
+	if spec.ConcurrencyGroup == "" {
+		return job.GetShellJobName(build.Name)
+	}
+	return job.GetShellJobName(build.Name + "/" + id)
+}
+
+// scriptsConfigMapName returns the name of the ConfigMap mounted by the Job running the provisioner with the given
+// UUID, see ScriptsConfigMapName.
+func scriptsConfigMapName(build *buildv1.Build, spec *buildv1.ProvisionerSpec, id string) string {
+	return fmt.Sprintf("%s-scripts", jobName(build, spec, id))
+}
+
+// usesScriptsConfigMap returns true if the Job of the provisioner mounts the scripts ConfigMap of the Build.
+func usesScriptsConfigMap(spec *buildv1.ProvisionerSpec) bool {
+	return spec.RunConfigMapRef != nil || spec.Ansible != nil || runsSteps(spec)
+}
+
+// runsSteps returns true if the Job of the provisioner runs steps, the chef-solo and puppet-apply provisioners
+// are adapted to steps, see configmanagement.
+func runsSteps(spec *buildv1.ProvisionerSpec) bool {
+	return len(spec.Steps) > 0 || spec.ChefSolo != nil || spec.PuppetApply != nil
+}
+
+// scriptsHash returns the hash of the data of the scripts ConfigMap.
+func scriptsHash(data map[string]string) string {
+	return kube.ComputeHash(data)
+}
+
+// resolveScripts returns the data of the scripts ConfigMap of the provisioner, the scripts of its RunConfigMapRef,
+// its steps under shell.StepsKey or its playbook.
+func resolveScripts(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (map[string]string, error) {
+	if spec.Ansible != nil {
+		return resolvePlaybook(ctx, c, build, spec)
+	}
+	if !runsSteps(spec) {
+		scripts, err := getScripts(ctx, c, build, spec.Name, spec.RunConfigMapRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		return scripts.Data, nil
+	}
+
+	var steps []shell.Step
+	var err error
+	switch {
+	case spec.ChefSolo != nil:
+		files, filesErr := resolveSourceFiles(ctx, c, build, spec.Name, &spec.ChefSolo.Source)
+		if filesErr != nil {
+			return nil, filesErr
+		}
+		steps, err = configmanagement.ChefSoloSteps(spec.ChefSolo, files)
+	case spec.PuppetApply != nil:
+		files, filesErr := resolveSourceFiles(ctx, c, build, spec.Name, &spec.PuppetApply.Source)
+		if filesErr != nil {
+			return nil, filesErr
+		}
+		steps, err = configmanagement.PuppetApplySteps(spec.PuppetApply, files)
+	default:
+		steps, err = resolveSteps(ctx, c, build, spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode the steps of provisioner %s", spec.Name)
+	}
+	return map[string]string{shell.StepsKey: string(data)}, nil
+}
+
+// resolvePlaybook returns the playbook of the ansible provisioner, unless it is cloned by the Job, along with
+// its requirements and its extra variables.
+func resolvePlaybook(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (map[string]string, error) {
+	data := map[string]string{}
+	switch {
+	case spec.Ansible.Playbook != "":
+		data[ansible.PlaybookKey] = spec.Ansible.Playbook
+	case spec.Ansible.PlaybookConfigMapRef != nil:
+		playbook, err := getScripts(ctx, c, build, spec.Name, spec.Ansible.PlaybookConfigMapRef.Name)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range playbook.Data {
+			data[key] = value
+		}
+	}
+	if spec.Ansible.Requirements != "" {
+		data[ansible.RequirementsKey] = spec.Ansible.Requirements
+	}
+	if len(spec.Ansible.ExtraVars) > 0 {
+		extraVars, err := json.Marshal(spec.Ansible.ExtraVars)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode the extra variables of provisioner %s", spec.Name)
+		}
+		data[ansible.ExtraVarsKey] = string(extraVars)
+	}
+	return data, nil
+}
+
+// resolveSteps returns the steps run by the Job of the provisioner.
+func resolveSteps(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) ([]shell.Step, error) {
+	steps := make([]shell.Step, 0, len(spec.Steps))
+	for _, s := range spec.Steps {
+		step := shell.Step{Name: s.Name}
+		switch {
+		case s.Run != nil:
+			step.Scripts = []shell.Script{{Name: s.Name, Content: *s.Run}}
+		case s.RunConfigMapRef != nil:
+			scripts, err := getScripts(ctx, c, build, spec.Name, s.RunConfigMapRef.Name)
+			if err != nil {
+				return nil, err
+			}
+			keys := make([]string, 0, len(scripts.Data))
+			for key := range scripts.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				step.Scripts = append(step.Scripts, shell.Script{Name: key, Content: scripts.Data[key]})
+			}
+		case s.File != nil:
+			step.File = &shell.File{Content: s.File.Content, Destination: s.File.Destination, Mode: uint32(s.File.GetMode())}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// resolveSourceFiles returns the files of the ConfigMap of the source of a chef-solo or puppet-apply provisioner
+// by path, it returns none when the source is a git repository.
+func resolveSourceFiles(ctx context.Context, c client.Client, build *buildv1.Build, provisionerName string, source *buildv1.ConfigManagementSource) (map[string]string, error) {
+	files := map[string]string{}
+	if source.ConfigMapRef == nil {
+		return files, nil
+	}
+	cm, err := getScripts(ctx, c, build, provisionerName, source.ConfigMapRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(source.Items) == 0 {
+		for key, value := range cm.Data {
+			files[key] = value
+		}
+		return files, nil
+	}
+	for _, item := range source.Items {
+		value, ok := cm.Data[item.Key]
+		if !ok {
+			return nil, builderror.ConfigErrorf("the ConfigMap %s of provisioner %s has no key %s", cm.Name, provisionerName, item.Key)
+		}
+		files[item.Path] = value
+	}
+	return files, nil
+}
+
+// getScripts returns the ConfigMap with the given name holding scripts of the provisioner, in the namespace of the Build.
+func getScripts(ctx context.Context, c client.Client, build *buildv1.Build, provisionerName, name string) (*corev1.ConfigMap, error) {
+	scripts := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: build.Namespace, Name: name}
+	if err := c.Get(ctx, key, scripts); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the scripts of provisioner %s", provisionerName)
+	}
+	if len(scripts.Data) == 0 {
+		return nil, builderror.ConfigErrorf("the ConfigMap %s of provisioner %s has no script", key.Name, provisionerName)
+	}
+	return scripts, nil
+}
+
+// copyScripts copies the data of the scripts ConfigMap to the ConfigMap with the given name mounted by the provisioner
+// Jobs of the Build, in the namespace of the Jobs.
+func copyScripts(ctx context.Context, c client.Client, build *buildv1.Build, namespace, name string, data map[string]string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, c, cm, func() error {
+		cm.Labels = map[string]string{
+			buildv1.ManagedByLabel:      shell.ForgeProvisionerShellName,
+			buildv1.BuildNameLabel:      build.Name,
+			buildv1.BuildNamespaceLabel: build.Namespace,
+		}
+		cm.Data = data
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy the scripts to ConfigMap %s", cm.Name)
+	}
+	return nil
+}
+
+// replaceOutdatedJob deletes the running Job of the provisioner when its scripts or steps changed since it has been
+// created, e.g. a referenced ConfigMap has been updated. The provisioner is reset so a Job running the new scripts
+// is created. namespace is the namespace of the Jobs of the Build.
+func replaceOutdatedJob(ctx context.Context, c client.Client, build *buildv1.Build, namespace string, spec *buildv1.ProvisionerSpec) error {
+	data, err := resolveScripts(ctx, c, build, spec)
+	if err != nil {
+		return err
+	}
+
+	running := &batchv1.Job{}
+	key := client.ObjectKey{Namespace: namespace, Name: jobName(build, spec, ptr.Deref(spec.UUID, ""))}
+	if err := c.Get(ctx, key, running); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if running.Labels[buildv1.ProvisionerIDLabel] != ptr.Deref(spec.UUID, "") || !running.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	// The finished Jobs are processed by the ShellJobController.
+	if _, finished := jobpredicates.TerminalCondition(running); finished {
+		return nil
+	}
+	hash := scriptsHash(data)
+	if running.Annotations[buildv1.ScriptsHashAnnotation] == hash {
+		return nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Replacing the provisioner Job, its scripts changed", "provisioner", spec.Name,
+		"job", running.Name)
+	if err := c.Delete(ctx, running, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "failed to delete provisioner Job %s", running.Name)
+	}
+
+	id := *spec.UUID
+	for i := range build.Status.Provisioners {
+		if build.Status.Provisioners[i].UUID == id {
+			build.Status.Provisioners = append(build.Status.Provisioners[:i], build.Status.Provisioners[i+1:]...)
+			break
+		}
+	}
+	conditions.Delete(build, buildv1.ProvisionerReadyCondition(id))
+	spec.UUID = nil
+	spec.Status = ptr.To(buildv1.ProvisionerStatusPending)
+	return nil
+}
+
+// jobTerminating returns true if the existing Job with the name of the desired Job is being deleted, the desired Job
+// cannot be created until it is gone.
+func jobTerminating(ctx context.Context, c client.Client, desired *batchv1.Job) (bool, error) {
+	existing := &batchv1.Job{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get provisioner Job %s", desired.Name)
+	}
+	return !existing.DeletionTimestamp.IsZero(), nil
+}
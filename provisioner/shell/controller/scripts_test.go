@@ -0,0 +1,190 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/ansible"
+	"github.com/forge-build/forge/provisioner/shell"
+	"github.com/forge-build/forge/provisioner/shell/job"
+)
+
+func TestReplaceOutdatedJob(t *testing.T) {
+	scripts := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "scripts"},
+		Data:       map[string]string{"01-update.sh": "apt-get update", "02-install.sh": "apt-get install -y nginx"},
+	}
+	testcases := []struct {
+		name            string
+		hash            string
+		conditions      []batchv1.JobCondition
+		expectedDeleted bool
+	}{
+		{name: "scripts unchanged", hash: scriptsHash(scripts.Data)},
+		{name: "scripts changed", hash: "outdated", expectedDeleted: true},
+		{
+			name:       "finished job",
+			hash:       "outdated",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+				Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{{
+					Name:            "setup",
+					UUID:            ptr.To("1234"),
+					Status:          ptr.To(buildv1.ProvisionerStatusRunning),
+					RunConfigMapRef: &corev1.ObjectReference{Name: scripts.Name},
+				}}},
+				Status: buildv1.BuildStatus{Provisioners: []buildv1.BuildProvisionerStatus{
+					{UUID: "1234", Name: "setup", Phase: buildv1.ProvisionerStatusRunning},
+				}},
+			}
+			running := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   ForgeCoreNamespace,
+					Name:        job.GetShellJobName(build.Name),
+					Labels:      map[string]string{buildv1.ProvisionerIDLabel: "1234"},
+					Annotations: map[string]string{buildv1.ScriptsHashAnnotation: tc.hash},
+				},
+				Status: batchv1.JobStatus{Conditions: tc.conditions},
+			}
+			scheme := runtime.NewScheme()
+			g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scripts, running).Build()
+
+			provisioner := &build.Spec.Provisioners[0]
+			g.Expect(replaceOutdatedJob(context.Background(), c, build, ForgeCoreNamespace, provisioner)).To(Succeed())
+
+			err := c.Get(context.Background(), client.ObjectKeyFromObject(running), &batchv1.Job{})
+			if tc.expectedDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				g.Expect(provisioner.UUID).To(BeNil())
+				g.Expect(provisioner.Status).To(Equal(ptr.To(buildv1.ProvisionerStatusPending)))
+				g.Expect(build.Status.Provisioners).To(BeEmpty())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(provisioner.UUID).To(Equal(ptr.To("1234")))
+				g.Expect(build.Status.Provisioners).To(HaveLen(1))
+			}
+		})
+	}
+}
+
+func TestResolveSteps(t *testing.T) {
+	g := NewWithT(t)
+
+	scripts := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "install-scripts"},
+		Data:       map[string]string{"20-nginx.sh": "apt-get install -y nginx", "10-curl.sh": "apt-get install -y curl"},
+	}
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+	spec := &buildv1.ProvisionerSpec{Name: "setup", Steps: []buildv1.ProvisionerStep{
+		{Name: "motd", File: &buildv1.ProvisionerFile{Content: "Built by Forge", Destination: "/etc/motd"}},
+		{Name: "update", Run: ptr.To("apt-get update")},
+		{Name: "install", RunConfigMapRef: &corev1.LocalObjectReference{Name: scripts.Name}},
+	}}
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scripts).Build()
+
+	steps, err := resolveSteps(context.Background(), c, build, spec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(steps).To(Equal([]shell.Step{
+		{Name: "motd", File: &shell.File{Content: "Built by Forge", Destination: "/etc/motd", Mode: 0o644}},
+		{Name: "update", Scripts: []shell.Script{{Name: "update", Content: "apt-get update"}}},
+		{Name: "install", Scripts: []shell.Script{
+			{Name: "10-curl.sh", Content: "apt-get install -y curl"},
+			{Name: "20-nginx.sh", Content: "apt-get install -y nginx"},
+		}},
+	}))
+
+	spec.Steps[2].RunConfigMapRef.Name = "missing"
+	_, err = resolveSteps(context.Background(), c, build, spec)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestResolvePlaybook(t *testing.T) {
+	playbook := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "playbook"},
+		Data:       map[string]string{"site.yml": "- hosts: all", "vars.yml": "port: 80"},
+	}
+	testcases := []struct {
+		name         string
+		spec         *buildv1.AnsibleSpec
+		expectedData map[string]string
+	}{
+		{
+			name: "inline playbook",
+			spec: &buildv1.AnsibleSpec{
+				Playbook:     "- hosts: all",
+				Requirements: "roles:\n- name: geerlingguy.nginx",
+				ExtraVars:    map[string]string{"port": "80"},
+			},
+			expectedData: map[string]string{
+				ansible.PlaybookKey:     "- hosts: all",
+				ansible.RequirementsKey: "roles:\n- name: geerlingguy.nginx",
+				ansible.ExtraVarsKey:    `{"port":"80"}`,
+			},
+		},
+		{
+			name:         "configmap playbook",
+			spec:         &buildv1.AnsibleSpec{PlaybookConfigMapRef: &corev1.LocalObjectReference{Name: playbook.Name}, PlaybookPath: "site.yml"},
+			expectedData: playbook.Data,
+		},
+		{
+			name:         "git playbook",
+			spec:         &buildv1.AnsibleSpec{Git: &buildv1.AnsibleGitSource{URL: "https://github.com/example/playbooks.git"}},
+			expectedData: map[string]string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+			spec := &buildv1.ProvisionerSpec{Name: "setup", Type: buildv1.ProvisionerTypeAnsible, Ansible: tc.spec}
+			scheme := runtime.NewScheme()
+			g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(playbook).Build()
+
+			g.Expect(usesScriptsConfigMap(spec)).To(BeTrue())
+			data, err := resolveScripts(context.Background(), c, build, spec)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(data).To(Equal(tc.expectedData))
+		})
+	}
+}
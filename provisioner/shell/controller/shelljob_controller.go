@@ -3,13 +3,27 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/forge-build/forge/util"
+	"github.com/forge-build/forge/util/annotations"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/predicates"
+	jobpredicates "github.com/forge-build/forge/util/predicates/jobs"
 	"k8s.io/utils/ptr"
 
-	"sigs.k8s.io/cluster-api/util/patch"
-
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/drain"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	forgelog "github.com/forge-build/forge/pkg/log"
+	"github.com/forge-build/forge/pkg/metrics"
+	"github.com/forge-build/forge/pkg/redact"
+	"github.com/forge-build/forge/provisioner/ansible"
+	"github.com/forge-build/forge/provisioner/shell"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
+	"github.com/forge-build/forge/util/patch"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,37 +32,90 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	k8sapierror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// ControllerName is the name of the ShellJob controller, used in the controller metrics.
+const ControllerName = "shelljob"
+
+// podNotFoundGracePeriod is how long after a Job finished its Pod is waited for,
+// the Pod is considered deleted afterwards.
+const podNotFoundGracePeriod = time.Minute
+
 var podControlledByJobNotFoundErr = errors.New("pod for job not found")
 
 // ShellJobController watches Kubernetes jobs and reports back to the Build
 type ShellJobController struct {
 	Logger logr.Logger
 	client.Client
-	Clientset *kubernetes.Clientset
+	Clientset kubernetes.Interface
+	// Namespace restricts the processed Jobs to a namespace, the Jobs of all the namespaces are processed if empty.
 	Namespace string
+	// PersistLogs records the logs of the failed Jobs in a ConfigMap of the Build, see LogsConfigMapName.
+	PersistLogs bool
 
+	recorder    record.EventRecorder
 	patchHelper *patch.Helper
+	// adoptedJobs queues the Jobs left unprocessed by a previous run of the controller, see adoptJobs.
+	adoptedJobs chan event.GenericEvent
 }
 
-func (r *ShellJobController) SetupWithManager(mgr ctrl.Manager) error {
+// SetupWithManager sets up the controller with the Manager, the RateLimiter of the options drives
+// the backoff of the Jobs hitting transient errors.
+func (r *ShellJobController) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	r.adoptedJobs = make(chan event.GenericEvent)
+	r.recorder = mgr.GetEventRecorderFor("shelljob-controller")
+	err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		// Failing to adopt the Jobs must not stop the manager, they are left to be deleted with their Build.
+		if err := r.adoptJobs(ctx); err != nil {
+			r.Logger.Error(err, "Failed to adopt the unprocessed jobs")
+		}
+		return nil
+	}))
+	if err != nil {
+		return errors.Wrap(err, "failed to add the job adoption to the manager")
+	}
+
+	jobPredicates := []predicate.Predicate{
+		jobpredicates.ManagedBy(shell.ForgeProvisionerShellName),
+		jobpredicates.Finished,
+		jobpredicates.HasBuildLabels,
+		jobpredicates.OwnerBuildExists(mgr.GetClient(), r.Logger),
+		predicates.ResourceNotPaused(r.Logger),
+	}
+	if r.Namespace != "" {
+		jobPredicates = append(jobPredicates, jobpredicates.InNamespace(r.Namespace))
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&batchv1.Job{}, builder.WithPredicates(
-			ManagedByForgeProvisionerShell,
-			InNamespace(r.Namespace),
-			JobHasAnyCondition,
-			HasBuildNameLabel,
-			HasProvisionerIDLabel,
-		)).
-		Complete(r.reconcileJobs())
+		For(&batchv1.Job{}, builder.WithPredicates(jobPredicates...)).
+		// The Jobs which finished while their Build was paused are processed once it is unpaused.
+		Watches(
+			&buildv1.Build{},
+			handler.EnqueueRequestsFromMapFunc(r.buildToJobs),
+			builder.WithPredicates(predicates.BuildUpdateUnpaused(r.Logger)),
+		).
+		WatchesRawSource(source.Channel(r.adoptedJobs, &handler.EnqueueRequestForObject{})).
+		WithOptions(options).
+		Named(ControllerName).
+		Complete(metrics.Instrument(ControllerName, drain.Reconciler(r.reconcileJobs())))
 }
 
-//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;patch;update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;patch;update;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;patch;update;delete
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 
 func (r *ShellJobController) reconcileJobs() reconcile.Func {
 	return func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -62,8 +129,9 @@ func (r *ShellJobController) reconcileJobs() reconcile.Func {
 			return ctrl.Result{}, fmt.Errorf("getting job from cache: %w", err)
 		}
 
-		if len(job.Status.Conditions) == 0 {
-			r.Logger.Info("Ignoring Job without conditions")
+		jobCondition, finished := jobpredicates.TerminalCondition(job)
+		if !finished {
+			r.Logger.Info("Ignoring unfinished Job")
 			return ctrl.Result{}, nil
 		}
 
@@ -80,12 +148,28 @@ func (r *ShellJobController) reconcileJobs() reconcile.Func {
 			}
 			return ctrl.Result{}, fmt.Errorf("getting build from cache: %w", err)
 		}
+		// The termination messages and the logs of the Job are masked before they are recorded.
+		redactor, err := redact.ForBuild(ctx, r.Client, build)
+		if err != nil {
+			return r.handleJobError(job, err)
+		}
+		ctx = redact.IntoContext(ctx, redactor)
+		log := redactor.Logger(forgelog.WithProvisioner(forgelog.WithBuild(r.Logger, build), provisionerID).WithValues("job", job.Name))
+		ctx = ctrl.LoggerInto(ctx, log)
+		if annotations.IsPaused(build, job) {
+			log.Info("Ignoring job, reconciliation is paused")
+			return ctrl.Result{}, nil
+		}
+		if isStaleJob(build, job) {
+			log.Info("Deleting the job of a provisioner removed from the build")
+			return ctrl.Result{}, r.deleteJob(ctx, job)
+		}
 		r.patchHelper, err = patch.NewHelper(build, r.Client)
 		if err != nil {
 			return ctrl.Result{}, errors.Wrap(err, "failed to create patch helper")
 		}
 
-		switch jobCondition := job.Status.Conditions[0].Type; jobCondition {
+		switch jobCondition {
 		case batchv1.JobComplete:
 			err = r.processCompleteScanJob(ctx, job, build, provisionerID)
 		case batchv1.JobFailed:
@@ -94,17 +178,54 @@ func (r *ShellJobController) reconcileJobs() reconcile.Func {
 			err = fmt.Errorf("unrecognized scan job condition: %v", jobCondition)
 		}
 		if err != nil {
-			r.Logger.Error(err, "Failed processing job")
+			return r.handleJobError(job, redactor.Error(err))
 		}
+		return ctrl.Result{}, nil
+	}
+}
 
+// handleJobError decides whether a Job is processed again after err, based on its category.
+// Transient errors are retried with the backoff of the controller rate limiter, throttled errors after
+// their retry hint, other errors are returned as is.
+func (r *ShellJobController) handleJobError(job *batchv1.Job, err error) (ctrl.Result, error) {
+	switch category := forgeerrors.Classify(err); category {
+	case forgeerrors.CategoryTransient:
+		r.Logger.Info("Processing job hit a transient error, requeuing", "job", job.Name, "reason", err.Error())
+		return ctrl.Result{Requeue: true}, nil
+	case forgeerrors.CategoryThrottled:
+		requeueAfter := forgeerrors.RequeueAfter(err)
+		r.Logger.Info("Processing job has been throttled, requeuing", "job", job.Name, "reason", err.Error(), "requeueAfter", requeueAfter)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	default:
+		r.Logger.Error(err, "Failed processing job", "job", job.Name, "category", category)
 		return ctrl.Result{}, err
 	}
 }
 
+// buildToJobs maps a Build to its shell Jobs.
+func (r *ShellJobController) buildToJobs(ctx context.Context, o client.Object) []ctrl.Request {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(ctx, jobs, client.InNamespace(r.Namespace), client.MatchingLabels{
+		buildv1.ManagedByLabel:      shell.ForgeProvisionerShellName,
+		buildv1.BuildNameLabel:      o.GetName(),
+		buildv1.BuildNamespaceLabel: o.GetNamespace(),
+	}); err != nil {
+		r.Logger.Error(err, "Failed to list the jobs of build", "build", o.GetName())
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(jobs.Items))
+	for i := range jobs.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&jobs.Items[i])})
+	}
+	return requests
+}
+
 // processCompleteScanJob handles the completed scan jobs
 // report back to the queue with saving appropriate cache
 func (r *ShellJobController) processCompleteScanJob(ctx context.Context, job *batchv1.Job, build *buildv1.Build, provisionerID string) error {
-	r.Logger.Info("Job complete", "build", build.Name, "provisionerID", provisionerID)
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Job complete")
 
 	// TODO think about how to handle the output of the shell job (providing logs)
 
@@ -113,23 +234,67 @@ func (r *ShellJobController) processCompleteScanJob(ctx context.Context, job *ba
 	if err != nil {
 		return errors.Wrapf(err, "unable to find provisioner with id %s in the build %s", provisionerID, build.Name)
 	}
+	previous := ptr.Deref(provisioner.Status, "")
 	provisioner.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+	recordJobStatus(build, provisioner, job, 0, "")
+	if build.Spec.Simulate || len(provisioner.Steps) > 0 || provisioner.Ansible != nil {
+		// The script has been checked successfully, warnings are reported in the termination message,
+		// as are the outcomes of the steps or of the plays otherwise.
+		statuses, err := r.GetTerminatedContainersStatusesByJob(ctx, job)
+		if forgeerrors.IsRetryable(err) {
+			return err
+		}
+		if err != nil {
+			log.Error(err, "Could not get terminated container statuses")
+		}
+		if status, ok := statuses[shelljob.ContainerName]; ok {
+			switch {
+			case build.Spec.Simulate:
+				provisioner.Issues = issuesFrom(status.Message)
+			case provisioner.Ansible != nil:
+				recordPlayStatuses(build, provisioner, status.Message)
+			default:
+				recordStepStatuses(build, provisioner, status.Message)
+			}
+		}
+	}
+	redact.FromContext(ctx).Build(build)
+	util.SetProvisionerConditions(build)
+	if util.ProvisionersSucceeded(build) {
+		conditions.MarkTrue(build, buildv1.ProvisionersReadyCondition, buildv1.ProvisionersSucceededReason,
+			"%d provisioner(s) completed", len(build.Spec.Provisioners))
+		build.Status.ProvisionersReady = true
+	}
 
+	// The Job is kept until its result is recorded in the Build, so it is processed again on failure.
 	if err := r.patchHelper.Patch(ctx, build); err != nil {
-		r.Logger.Error(err, "failed to patch build")
+		return errors.Wrapf(err, "failed to patch build %s", build.Name)
 	}
-	r.Logger.Info("Job complete - Deleting complete shell job", "job", job.Name)
+	util.RecordProvisionerEvent(r.recorder, build, provisioner, previous)
+	log.Info("Job complete - Deleting complete shell job")
 	return r.deleteJob(ctx, job)
 }
 
 // nolint:gocyclo
 func (r *ShellJobController) processFailedScanJob(ctx context.Context, job *batchv1.Job, build *buildv1.Build, provisionerID string) error {
-	r.Logger.Info("Job failed", "build", build, "provisionerID", provisionerID)
+	log := ctrl.LoggerFrom(ctx)
+	redactor := redact.FromContext(ctx)
+	log.Info("Job failed")
+
+	if attemptRecorded(build, job, provisionerID) {
+		log.Info("Deleting the job of an attempt of the provisioner already recorded")
+		return r.deleteJob(ctx, job)
+	}
 
+	deadlineExceeded := jobDeadlineExceeded(job)
 	statuses, err := r.GetTerminatedContainersStatusesByJob(ctx, job)
 	if err != nil {
-		r.Logger.Error(err, "Could not get terminated container statuses")
-		return err
+		// The Pods of a Job exceeding its deadline are deleted, there may be no container status to record.
+		if !deadlineExceeded {
+			log.Error(err, "Could not get terminated container statuses")
+			return err
+		}
+		log.Info("No terminated container status for the job exceeding its deadline", "error", err.Error())
 	}
 
 	provisioner, err := util.GetProvisionerByID(build, provisionerID)
@@ -137,26 +302,173 @@ func (r *ShellJobController) processFailedScanJob(ctx context.Context, job *batc
 		return errors.Wrapf(err, "unable to find provisioner with id %s in the build %s", provisionerID, build.Name)
 	}
 
+	var exitCode int32
 	for container, status := range statuses {
 		if status.ExitCode == 0 {
 			continue
 		}
+		exitCode = status.ExitCode
+		status.Message = redactor.String(status.Message)
 		errorMsg := fmt.Sprintf("shelljob failed with reason: %s and message: %s", status.Reason, status.Message)
-		r.Logger.Error(errors.New("shell job failed"), "shell failed with reason", "build", build, "provisionerID", provisionerID, "container", container, "errorMessage", errorMsg)
+		log.Error(errors.New("shell job failed"), "shell failed with reason", "container", container, "errorMessage", errorMsg)
 		provisioner.FailureReason = ptr.To(status.Reason)
 		provisioner.FailureMessage = ptr.To(status.Message)
+		if build.Spec.Simulate {
+			provisioner.Issues = issuesFrom(status.Message)
+		} else if provisioner.Ansible != nil {
+			provisioner.FailureMessage = ptr.To(recordPlayStatuses(build, provisioner, status.Message))
+		} else if runsSteps(provisioner) {
+			provisioner.FailureMessage = ptr.To(recordStepStatuses(build, provisioner, status.Message))
+		}
+	}
+
+	if deadlineExceeded {
+		provisioner.FailureReason = ptr.To(batchv1.JobReasonDeadlineExceeded)
+		provisioner.FailureMessage = ptr.To(fmt.Sprintf("the provisioner has been stopped after running for %ds, its active deadline",
+			ptr.Deref(job.Spec.ActiveDeadlineSeconds, 0)))
 	}
 
-	provisioner.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+	message := ptr.Deref(provisioner.FailureMessage, "shell job failed")
+	previous := ptr.Deref(provisioner.Status, "")
+	retrying := ""
+	attempts := util.RecordProvisionerStatus(build, provisioner).Attempts
+	if retries := ptr.Deref(provisioner.Retries, 0); retries > 0 && attempts <= retries && !build.Spec.Simulate {
+		// The Job is recreated by the Build controller once the retry delay elapsed.
+		delay := provisioner.GetRetryDelay()
+		log.Info("Retrying the failed provisioner", "attempt", attempts, "retries", retries, "after", delay)
+		provisioner.Status = ptr.To(buildv1.ProvisionerStatusPending)
+		provisioner.FailureReason = nil
+		provisioner.FailureMessage = nil
+		retrying = fmt.Sprintf("attempt %d of %d failed, retrying in %s: %s", attempts, retries+1, delay, message)
+		recordJobStatus(build, provisioner, job, exitCode, retrying)
+		util.RecordProvisionerStatus(build, provisioner).NextAttemptAt = ptr.To(metav1.NewTime(time.Now().Add(delay)))
+	} else {
+		provisioner.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+		recordJobStatus(build, provisioner, job, exitCode, message)
+	}
+	// The logs are deleted along with the Job, they are recorded so the failure can be diagnosed.
+	if logs, err := r.jobLogs(ctx, job); err != nil {
+		log.Error(err, "Could not get the logs of the failed job")
+	} else {
+		logs = redactor.String(logs)
+		status := util.RecordProvisionerStatus(build, provisioner)
+		status.Logs = tail(logs, statusLogsBytes)
+		if r.PersistLogs {
+			if status.LogsConfigMapName, err = recordLogs(ctx, r.Client, build, provisioner, logs); err != nil {
+				log.Error(err, "Could not record the logs of the failed job")
+			}
+		}
+	}
+	redactor.Build(build)
+	util.SetProvisionerConditions(build)
 
 	if err := r.patchHelper.Patch(ctx, build); err != nil {
-		r.Logger.Error(err, "failed to patch build")
+		return errors.Wrapf(err, "failed to patch build %s", build.Name)
+	}
+	if retrying != "" {
+		r.recorder.Eventf(build, corev1.EventTypeWarning, buildv1.ProvisionerRetryingReason, "Provisioner %s %s", provisioner.Name, retrying)
+	} else {
+		util.RecordProvisionerEvent(r.recorder, build, provisioner, previous)
 	}
 
-	r.Logger.Info("Deleting failed scan job")
+	log.Info("Deleting failed scan job")
 	return r.deleteJob(ctx, job)
 }
 
+// attemptRecorded returns true if the outcome of the attempt of the provisioner run by the failed Job has already been
+// recorded, e.g. the provisioner has been retried since.
+func attemptRecorded(build *buildv1.Build, job *batchv1.Job, provisionerID string) bool {
+	attempt, err := strconv.ParseInt(job.Annotations[buildv1.ProvisionerAttemptAnnotation], 10, 32)
+	if err != nil {
+		return false
+	}
+	for _, status := range build.Status.Provisioners {
+		if status.UUID == provisionerID {
+			return int32(attempt) < status.Attempts || (int32(attempt) == status.Attempts && status.NextAttemptAt != nil)
+		}
+	}
+	return false
+}
+
+// recordJobStatus records the outcome of the finished Job of the provisioner in the status of the Build.
+func recordJobStatus(build *buildv1.Build, provisioner *buildv1.ProvisionerSpec, job *batchv1.Job, exitCode int32, message string) {
+	status := util.RecordProvisionerStatus(build, provisioner)
+	if job.Status.StartTime != nil {
+		status.StartedAt = job.Status.StartTime.DeepCopy()
+	}
+	status.CompletedAt = job.Status.CompletionTime.DeepCopy()
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			status.CompletedAt = condition.LastTransitionTime.DeepCopy()
+		}
+	}
+	if status.CompletedAt == nil {
+		status.CompletedAt = ptr.To(metav1.Now())
+	}
+	status.ExitCode = ptr.To(exitCode)
+	status.Message = message
+}
+
+// jobDeadlineExceeded returns true if the Job failed as it ran longer than its active deadline.
+func jobDeadlineExceeded(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue &&
+			condition.Reason == batchv1.JobReasonDeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// recordStepStatuses records the outcomes of the steps reported in the termination message of the Job in the status
+// of the provisioner. It returns the message of the failed step, or the termination message if it reports no step.
+func recordStepStatuses(build *buildv1.Build, provisioner *buildv1.ProvisionerSpec, message string) string {
+	steps, err := shell.ParseStepStatuses(message)
+	if err != nil {
+		return message
+	}
+	status := util.RecordProvisionerStatus(build, provisioner)
+	status.Steps = make([]buildv1.ProvisionerStepStatus, 0, len(steps))
+	for _, step := range steps {
+		status.Steps = append(status.Steps, buildv1.ProvisionerStepStatus{
+			Name:    step.Name,
+			Phase:   buildv1.ProvisionerStatus(step.Phase),
+			Message: step.Message,
+		})
+		if step.Phase == string(buildv1.ProvisionerStatusFailed) {
+			message = fmt.Sprintf("step %s failed: %s", step.Name, step.Message)
+		}
+	}
+	return message
+}
+
+// recordPlayStatuses records the outcomes of the plays reported in the termination message of the Job of an ansible
+// provisioner in its status. It returns the message of the failed play, or the termination message if it reports no play.
+func recordPlayStatuses(build *buildv1.Build, provisioner *buildv1.ProvisionerSpec, message string) string {
+	plays, err := ansible.ParsePlayStatuses(message)
+	if err != nil {
+		return message
+	}
+	status := util.RecordProvisionerStatus(build, provisioner)
+	status.Plays = make([]buildv1.AnsiblePlayStatus, 0, len(plays))
+	for _, play := range plays {
+		status.Plays = append(status.Plays, buildv1.AnsiblePlayStatus{
+			Name:        play.Name,
+			Phase:       buildv1.ProvisionerStatus(play.Phase),
+			Ok:          play.Ok,
+			Changed:     play.Changed,
+			Failed:      play.Failed,
+			Skipped:     play.Skipped,
+			Unreachable: play.Unreachable,
+			Message:     play.Message,
+		})
+		if play.Phase == string(buildv1.ProvisionerStatusFailed) {
+			message = fmt.Sprintf("play %s failed: %s", play.Name, play.Message)
+		}
+	}
+	return message
+}
+
 func (r *ShellJobController) deleteJob(ctx context.Context, job *batchv1.Job) error {
 	err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
 	if err != nil {
@@ -176,9 +488,12 @@ func (r *ShellJobController) GetTerminatedContainersStatusesByJob(ctx context.Co
 			return nil, err
 		}
 		if IsPodControlledByJobNotFound(err) {
-			r.Logger.Info("Pod must have been deleted")
-			err = r.deleteJob(ctx, job)
-			return nil, err
+			// The Pod may not be listed yet right after the Job finished.
+			if finishedAt := jobFinishedAt(job); !finishedAt.IsZero() && time.Since(finishedAt) < podNotFoundGracePeriod {
+				return nil, forgeerrors.NewTransient(err)
+			}
+			r.Logger.Info("Pod must have been deleted", "job", job.Name)
+			return nil, nil
 		}
 
 		return nil, fmt.Errorf("unknown issue: %w", err)
@@ -200,7 +515,17 @@ func (r *ShellJobController) getPodByJob(ctx context.Context, job *batchv1.Job)
 	if podList != nil && len(podList.Items) > 0 {
 		return &podList.Items[0], nil
 	}
-	return nil, nil
+	return nil, podControlledByJobNotFoundErr
+}
+
+// jobFinishedAt returns the time the Job finished, zero if it is unknown.
+func jobFinishedAt(job *batchv1.Job) time.Time {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
 }
 
 func (r *ShellJobController) podListLookup(ctx context.Context, namespace string, refreshedJob *batchv1.Job) (*corev1.PodList, error) {
@@ -236,6 +561,20 @@ func GetTerminatedContainersStatusesByPod(pod *corev1.Pod) map[string]*corev1.Co
 	return states
 }
 
+// issuesFrom returns the issues reported by a simulated shell job in its termination message, one per line.
+func issuesFrom(message string) []string {
+	issues := []string{}
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			issues = append(issues, line)
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
 func IsPodControlledByJobNotFound(err error) bool {
 	return errors.Is(err, podControlledByJobNotFoundErr)
 }
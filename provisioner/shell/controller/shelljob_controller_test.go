@@ -0,0 +1,571 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	shelljob "github.com/forge-build/forge/provisioner/shell/job"
+	"github.com/forge-build/forge/util/conditions"
+	"github.com/forge-build/forge/util/patch"
+)
+
+func TestProcessCompleteScanJob(t *testing.T) {
+	testcases := []struct {
+		name          string
+		otherStatus   buildv1.ProvisionerStatus
+		expectedReady bool
+	}{
+		{
+			name:          "last provisioner",
+			otherStatus:   buildv1.ProvisionerStatusCompleted,
+			expectedReady: true,
+		},
+		{
+			name:        "other provisioner running",
+			otherStatus: buildv1.ProvisionerStatusRunning,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			build := &buildv1.Build{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+				Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+					{UUID: ptr.To("1234"), Type: buildv1.ProvisionerTypeShell, Status: ptr.To(tc.otherStatus)},
+					{UUID: ptr.To("5678"), Type: buildv1.ProvisionerTypeShell, Status: ptr.To(buildv1.ProvisionerStatusRunning)},
+				}},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-5678"},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+				}},
+			}
+			scheme := runtime.NewScheme()
+			g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, job).
+				WithStatusSubresource(&buildv1.Build{}).Build()
+
+			r := &ShellJobController{Client: c, Logger: logr.Discard(), Namespace: ForgeCoreNamespace, recorder: record.NewFakeRecorder(10)}
+			var err error
+			r.patchHelper, err = patch.NewHelper(build, c)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(r.processCompleteScanJob(ctx, job, build, "5678")).To(Succeed())
+
+			updated := &buildv1.Build{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+			g.Expect(*updated.Spec.Provisioners[1].Status).To(Equal(buildv1.ProvisionerStatusCompleted))
+			g.Expect(conditions.IsTrue(updated, buildv1.ProvisionerReadyCondition("5678"))).To(BeTrue())
+			g.Expect(updated.Status.Provisioners).To(HaveLen(1))
+			g.Expect(updated.Status.Provisioners[0].CompletedAt).ToNot(BeNil())
+			g.Expect(updated.Status.ProvisionersReady).To(Equal(tc.expectedReady))
+			g.Expect(conditions.IsTrue(updated, buildv1.ProvisionersReadyCondition)).To(Equal(tc.expectedReady))
+
+			err = c.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	}
+}
+
+func TestRecordJobStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	startTime := metav1.NewTime(time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC))
+	finishTime := metav1.NewTime(startTime.Add(time.Minute))
+	build := &buildv1.Build{Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+		{UUID: ptr.To("1234"), Name: "install", Type: buildv1.ProvisionerTypeShell, Status: ptr.To(buildv1.ProvisionerStatusCompleted)},
+		{UUID: ptr.To("5678"), Name: "harden", Type: buildv1.ProvisionerTypeShell, Status: ptr.To(buildv1.ProvisionerStatusFailed)},
+	}}}
+	build.Status.Provisioners = []buildv1.BuildProvisionerStatus{
+		{UUID: "1234", Type: buildv1.ProvisionerTypeShell, Phase: buildv1.ProvisionerStatusRunning},
+	}
+
+	recordJobStatus(build, &build.Spec.Provisioners[0], &batchv1.Job{Status: batchv1.JobStatus{
+		StartTime:      &startTime,
+		CompletionTime: &finishTime,
+	}}, 0, "")
+	recordJobStatus(build, &build.Spec.Provisioners[1], &batchv1.Job{Status: batchv1.JobStatus{
+		StartTime: &startTime,
+		Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: finishTime},
+		},
+	}}, 2, "permission denied")
+
+	g.Expect(build.Status.Provisioners).To(Equal([]buildv1.BuildProvisionerStatus{
+		{
+			UUID:        "1234",
+			Name:        "install",
+			Type:        buildv1.ProvisionerTypeShell,
+			Phase:       buildv1.ProvisionerStatusCompleted,
+			StartedAt:   &startTime,
+			CompletedAt: &finishTime,
+			ExitCode:    ptr.To[int32](0),
+		},
+		{
+			UUID:        "5678",
+			Name:        "harden",
+			Type:        buildv1.ProvisionerTypeShell,
+			Phase:       buildv1.ProvisionerStatusFailed,
+			StartedAt:   &startTime,
+			CompletedAt: &finishTime,
+			ExitCode:    ptr.To[int32](2),
+			Message:     "permission denied",
+		},
+	}))
+}
+
+func TestProcessFailedScanJob(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+			{UUID: ptr.To("1234"), Name: "install", Type: buildv1.ProvisionerTypeShell, Status: ptr.To(buildv1.ProvisionerStatusRunning)},
+		}},
+	}
+	selector := map[string]string{"batch.kubernetes.io/controller-uid": "abcd"}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-1234"},
+		Spec:       batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+		}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-1234-xyz", Labels: selector},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  shelljob.ContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3, Reason: "Error", Message: "apt failed"}},
+		}}},
+	}
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, job).
+		WithStatusSubresource(&buildv1.Build{}).Build()
+
+	r := &ShellJobController{
+		Client:      c,
+		Clientset:   kubefake.NewSimpleClientset(job, pod),
+		Logger:      logr.Discard(),
+		Namespace:   ForgeCoreNamespace,
+		PersistLogs: true,
+		recorder:    record.NewFakeRecorder(10),
+	}
+	var err error
+	r.patchHelper, err = patch.NewHelper(build, c)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.processFailedScanJob(ctx, job, build, "1234")).To(Succeed())
+
+	updated := &buildv1.Build{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+	g.Expect(updated.Status.Provisioners).To(HaveLen(1))
+	status := updated.Status.Provisioners[0]
+	g.Expect(status.ExitCode).To(Equal(ptr.To[int32](3)))
+	// The fake clientset returns "fake logs" as the logs of any container.
+	g.Expect(status.Logs).To(Equal("fake logs"))
+	g.Expect(status.LogsConfigMapName).To(Equal(LogsConfigMapName("ubuntu")))
+	g.Expect(conditions.GetMessage(updated, buildv1.ProvisionerReadyCondition("1234"))).To(Equal("apt failed, last logs:\nfake logs"))
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: LogsConfigMapName("ubuntu")}, cm)).To(Succeed())
+	g.Expect(cm.Data).To(HaveKeyWithValue("install.log", "fake logs"))
+	g.Expect(cm.Labels).To(HaveKeyWithValue(buildv1.BuildNameLabel, "ubuntu"))
+}
+
+func TestProcessFailedScanJobRetry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{{
+			UUID:       ptr.To("1234"),
+			Name:       "install",
+			Type:       buildv1.ProvisionerTypeShell,
+			Status:     ptr.To(buildv1.ProvisionerStatusRunning),
+			Retries:    ptr.To[int32](1),
+			RetryDelay: &metav1.Duration{Duration: time.Minute},
+		}}},
+		Status: buildv1.BuildStatus{Provisioners: []buildv1.BuildProvisionerStatus{{UUID: "1234", Attempts: 1}}},
+	}
+	failedJob := func(attempt string) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   ForgeCoreNamespace,
+				Name:        "shell-1234",
+				Annotations: map[string]string{buildv1.ProvisionerAttemptAnnotation: attempt},
+			},
+			Spec: batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "abcd"}}},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: batchv1.JobReasonDeadlineExceeded},
+			}},
+		}
+	}
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, failedJob("1")).
+		WithStatusSubresource(&buildv1.Build{}).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &ShellJobController{
+		Client:    c,
+		Clientset: kubefake.NewSimpleClientset(failedJob("1")),
+		Logger:    logr.Discard(),
+		Namespace: ForgeCoreNamespace,
+		recorder:  recorder,
+	}
+	process := func(job *batchv1.Job) *buildv1.Build {
+		build := &buildv1.Build{}
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "images", Name: "ubuntu"}, build)).To(Succeed())
+		var err error
+		r.patchHelper, err = patch.NewHelper(build, c)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(r.processFailedScanJob(ctx, job, build, "1234")).To(Succeed())
+		updated := &buildv1.Build{}
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+		return updated
+	}
+
+	// The first attempt is retried after the delay.
+	updated := process(failedJob("1"))
+	provisioner := updated.Spec.Provisioners[0]
+	g.Expect(provisioner.Status).To(Equal(ptr.To(buildv1.ProvisionerStatusPending)))
+	g.Expect(provisioner.FailureReason).To(BeNil())
+	status := updated.Status.Provisioners[0]
+	g.Expect(status.Message).To(HavePrefix("attempt 1 of 2 failed, retrying in 1m0s"))
+	g.Expect(status.NextAttemptAt).ToNot(BeNil())
+	g.Expect(conditions.GetReason(updated, buildv1.ProvisionerReadyCondition("1234"))).To(Equal(buildv1.ProvisionerRetryingReason))
+	g.Expect(recorder.Events).To(Receive(HavePrefix("Warning ProvisionerRetrying Provisioner install attempt 1 of 2 failed")))
+
+	// The Job of an attempt already recorded is deleted.
+	g.Expect(process(failedJob("1")).Status.Provisioners[0].NextAttemptAt).To(Equal(status.NextAttemptAt))
+
+	// The last attempt fails the provisioner.
+	updated.Spec.Provisioners[0].Status = ptr.To(buildv1.ProvisionerStatusRunning)
+	g.Expect(c.Update(ctx, updated)).To(Succeed())
+	updated.Status.Provisioners[0].Attempts = 2
+	updated.Status.Provisioners[0].NextAttemptAt = nil
+	g.Expect(c.Status().Update(ctx, updated)).To(Succeed())
+	updated = process(failedJob("2"))
+	g.Expect(updated.Spec.Provisioners[0].Status).To(Equal(ptr.To(buildv1.ProvisionerStatusFailed)))
+	g.Expect(updated.Spec.Provisioners[0].FailureReason).To(Equal(ptr.To(batchv1.JobReasonDeadlineExceeded)))
+	g.Expect(recorder.Events).To(Receive(HavePrefix("Warning ProvisionerFailed Provisioner install failed with reason DeadlineExceeded")))
+}
+
+func TestProcessDeadlineExceededJob(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	build := &buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+		Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{
+			{UUID: ptr.To("1234"), Name: "install", Type: buildv1.ProvisionerTypeShell, Status: ptr.To(buildv1.ProvisionerStatusRunning)},
+		}},
+	}
+	// The Pods of the Job have been deleted when its deadline has been exceeded.
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-1234"},
+		Spec: batchv1.JobSpec{
+			ActiveDeadlineSeconds: ptr.To[int64](600),
+			Selector:              &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "abcd"}},
+		},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: batchv1.JobReasonDeadlineExceeded},
+		}},
+	}
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build, job).
+		WithStatusSubresource(&buildv1.Build{}).Build()
+
+	r := &ShellJobController{
+		Client:    c,
+		Clientset: kubefake.NewSimpleClientset(job),
+		Logger:    logr.Discard(),
+		Namespace: ForgeCoreNamespace,
+		recorder:  record.NewFakeRecorder(10),
+	}
+	var err error
+	r.patchHelper, err = patch.NewHelper(build, c)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.processFailedScanJob(ctx, job, build, "1234")).To(Succeed())
+
+	updated := &buildv1.Build{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+	provisioner := updated.Spec.Provisioners[0]
+	g.Expect(provisioner.Status).To(Equal(ptr.To(buildv1.ProvisionerStatusFailed)))
+	g.Expect(provisioner.FailureReason).To(Equal(ptr.To(batchv1.JobReasonDeadlineExceeded)))
+	g.Expect(conditions.GetReason(updated, buildv1.ProvisionerReadyCondition("1234"))).To(Equal(buildv1.ProvisionerDeadlineExceededReason))
+	g.Expect(conditions.GetMessage(updated, buildv1.ProvisionerReadyCondition("1234"))).To(ContainSubstring("600s"))
+}
+
+func TestTail(t *testing.T) {
+	testcases := []struct {
+		name     string
+		logs     string
+		size     int
+		expected string
+	}{
+		{name: "fits", logs: "line 1\nline 2\n", size: 64, expected: "line 1\nline 2\n"},
+		{name: "starts at a line", logs: "line 1\nline 2\n", size: 10, expected: "line 2\n"},
+		{name: "single line", logs: "0123456789", size: 4, expected: "6789"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tail(tc.logs, tc.size)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestImageOptions(t *testing.T) {
+	defaults := ImageOptions{
+		Image:       "registry.example.com/forge/shell:v1",
+		PullPolicy:  corev1.PullIfNotPresent,
+		PullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+	testcases := []struct {
+		name     string
+		spec     buildv1.ProvisionerSpec
+		expected ImageOptions
+	}{
+		{name: "defaults", expected: defaults},
+		{
+			name: "overridden by the provisioner",
+			spec: buildv1.ProvisionerSpec{
+				Image:            "mirror.example.com/shell:v2",
+				ImagePullPolicy:  corev1.PullAlways,
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}},
+			},
+			expected: ImageOptions{
+				Image:       "mirror.example.com/shell:v2",
+				PullPolicy:  corev1.PullAlways,
+				PullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}},
+			},
+		},
+		{
+			name: "only the pull policy overridden",
+			spec: buildv1.ProvisionerSpec{ImagePullPolicy: corev1.PullNever},
+			expected: ImageOptions{
+				Image:       defaults.Image,
+				PullPolicy:  corev1.PullNever,
+				PullSecrets: defaults.PullSecrets,
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(imageOptions(&tc.spec, defaults)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestRecordStepStatuses(t *testing.T) {
+	testcases := []struct {
+		name            string
+		message         string
+		expectedMessage string
+		expectedSteps   []buildv1.ProvisionerStepStatus
+	}{
+		{
+			name:            "step failed",
+			message:         `[{"name":"update","phase":"Completed"},{"name":"install","phase":"Failed","message":"exit status 100"},{"name":"cleanup","phase":"Pending"}]`,
+			expectedMessage: "step install failed: exit status 100",
+			expectedSteps: []buildv1.ProvisionerStepStatus{
+				{Name: "update", Phase: buildv1.ProvisionerStatusCompleted},
+				{Name: "install", Phase: buildv1.ProvisionerStatusFailed, Message: "exit status 100"},
+				{Name: "cleanup", Phase: buildv1.ProvisionerStatusPending},
+			},
+		},
+		{
+			name:            "no step reported",
+			message:         "failed to connect to the machine via ssh",
+			expectedMessage: "failed to connect to the machine via ssh",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{{UUID: ptr.To("1234")}}}}
+			provisioner := &build.Spec.Provisioners[0]
+			g.Expect(recordStepStatuses(build, provisioner, tc.message)).To(Equal(tc.expectedMessage))
+			if tc.expectedSteps == nil {
+				g.Expect(build.Status.Provisioners).To(BeEmpty())
+				return
+			}
+			g.Expect(build.Status.Provisioners).To(HaveLen(1))
+			g.Expect(build.Status.Provisioners[0].Steps).To(Equal(tc.expectedSteps))
+		})
+	}
+}
+
+func TestRecordPlayStatuses(t *testing.T) {
+	testcases := []struct {
+		name            string
+		message         string
+		expectedMessage string
+		expectedPlays   []buildv1.AnsiblePlayStatus
+	}{
+		{
+			name: "play failed",
+			message: `[{"name":"packages","phase":"Completed","ok":3,"changed":2,"failed":0,"skipped":1,"unreachable":0},` +
+				`{"name":"nginx","phase":"Failed","ok":1,"changed":0,"failed":1,"skipped":0,"unreachable":0,"message":"task Template: template not found"}]`,
+			expectedMessage: "play nginx failed: task Template: template not found",
+			expectedPlays: []buildv1.AnsiblePlayStatus{
+				{Name: "packages", Phase: buildv1.ProvisionerStatusCompleted, Ok: 3, Changed: 2, Skipped: 1},
+				{Name: "nginx", Phase: buildv1.ProvisionerStatusFailed, Ok: 1, Failed: 1, Message: "task Template: template not found"},
+			},
+		},
+		{
+			name:            "no play reported",
+			message:         "ansible-playbook failed: ERROR! the playbook could not be found",
+			expectedMessage: "ansible-playbook failed: ERROR! the playbook could not be found",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			build := &buildv1.Build{Spec: buildv1.BuildSpec{Provisioners: []buildv1.ProvisionerSpec{{UUID: ptr.To("1234")}}}}
+			provisioner := &build.Spec.Provisioners[0]
+			g.Expect(recordPlayStatuses(build, provisioner, tc.message)).To(Equal(tc.expectedMessage))
+			if tc.expectedPlays == nil {
+				g.Expect(build.Status.Provisioners).To(BeEmpty())
+				return
+			}
+			g.Expect(build.Status.Provisioners).To(HaveLen(1))
+			g.Expect(build.Status.Provisioners[0].Plays).To(Equal(tc.expectedPlays))
+		})
+	}
+}
+
+func TestHandleJobError(t *testing.T) {
+	testcases := []struct {
+		name           string
+		err            error
+		expectedResult ctrl.Result
+		expectedErr    bool
+	}{
+		{
+			name:           "transient",
+			err:            forgeerrors.NewTransient(errors.New("pod for job not found")),
+			expectedResult: ctrl.Result{Requeue: true},
+		},
+		{
+			name:           "conflict",
+			err:            apierrors.NewConflict(schema.GroupResource{Resource: "builds"}, "ubuntu", errors.New("conflict")),
+			expectedResult: ctrl.Result{Requeue: true},
+		},
+		{
+			name:           "throttled",
+			err:            forgeerrors.NewThrottled(errors.New("too many requests"), time.Minute),
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:        "permanent",
+			err:         errors.New("unable to find provisioner"),
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &ShellJobController{Logger: logr.Discard()}
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-1234"}}
+			result, err := r.handleJobError(job, tc.err)
+			g.Expect(result).To(Equal(tc.expectedResult))
+			if tc.expectedErr {
+				g.Expect(err).To(MatchError(tc.err))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestGetTerminatedContainersStatusesWithoutPod(t *testing.T) {
+	testcases := []struct {
+		name              string
+		finishedAt        time.Time
+		expectedTransient bool
+	}{
+		{
+			name:              "job just finished",
+			finishedAt:        time.Now(),
+			expectedTransient: true,
+		},
+		{
+			name:       "pod deleted",
+			finishedAt: time.Now().Add(-podNotFoundGracePeriod - time.Minute),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ForgeCoreNamespace, Name: "shell-1234"},
+				Spec: batchv1.JobSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "abcd"}},
+				},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(tc.finishedAt)},
+				}},
+			}
+			r := &ShellJobController{Clientset: kubefake.NewSimpleClientset(job), Logger: logr.Discard()}
+
+			statuses, err := r.GetTerminatedContainersStatusesByJob(context.Background(), job)
+			g.Expect(statuses).To(BeEmpty())
+			if tc.expectedTransient {
+				g.Expect(forgeerrors.Classify(err)).To(Equal(forgeerrors.CategoryTransient))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/provisioner/shell/job"
+)
+
+// SimulationConfigMapName returns the name of the ConfigMap holding the Jobs rendered by the simulation of a Build.
+func SimulationConfigMapName(buildName string) string {
+	return fmt.Sprintf("%s-simulation", buildName)
+}
+
+// recordSimulatedJob renders the Job which would run the provisioner and stores its manifest in the simulation
+// ConfigMap of the Build, under the name of the provisioner or its UUID.
+func recordSimulatedJob(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec, id string, builder *job.ShellJobBuilder) error {
+	manifest, err := renderJob(builder)
+	if err != nil {
+		return err
+	}
+	key := spec.Name
+	if key == "" {
+		key = id
+	}
+	return storeRendered(ctx, c, build, SimulationConfigMapName(build.Name), map[string]string{key + ".yaml": manifest})
+}
+
+// renderJob returns the manifest of the Job built by the builder.
+func renderJob(builder *job.ShellJobBuilder) (string, error) {
+	rendered, err := builder.Build()
+	if err != nil {
+		return "", err
+	}
+	rendered.TypeMeta = metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"}
+	manifest, err := yaml.Marshal(rendered)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to render the provisioner Job")
+	}
+	return string(manifest), nil
+}
+
+// storeRendered adds the rendered objects to the ConfigMap of the Build with the given name.
+func storeRendered(ctx context.Context, c client.Client, build *buildv1.Build, name string, data map[string]string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: build.Namespace,
+			Name:      name,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, c, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[buildv1.BuildNameLabel] = build.Name
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		for k, v := range data {
+			cm.Data[k] = v
+		}
+		return controllerutil.SetControllerReference(build, cm, c.Scheme())
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the rendered objects in ConfigMap %s", cm.Name)
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package job
 
 import (
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/forge-build/forge/pkg/kube"
+	forgelog "github.com/forge-build/forge/pkg/log"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,26 +19,59 @@ import (
 )
 
 const (
-	containerName = "shell-provisioner"
+	// ContainerName is the name of the container running the shell provisioner in the Job.
+	ContainerName = "shell-provisioner"
+
+	// DefaultImage is the image of the shell provisioner used when not configured.
+	DefaultImage = "ghcr.io/forge-build/forge-provisioner-shell:latest"
+
+	// ScriptsMountPath is the directory the ConfigMap holding the scripts to run is mounted on.
+	ScriptsMountPath = "/etc/forge/scripts"
+
+	scriptsVolumeName = "scripts"
 )
 
 type ShellJobBuilder struct {
 	uuid                     string
 	name                     string
+	jobName                  string
+	attempt                  int32
 	namespace                string
 	buildNamespace           string
 	scriptToRun              string
-	scriptToRunRef           string
+	scriptsConfigMapName     string
+	scriptsHash              string
+	steps                    bool
+	ansibleArgs              []string
+	parameters               *string
+	connectorType            string
 	sshCredentialsSecretName string
-
-	repo string
-	tag  string
+	sshPort                  int32
+	sshUsername              string
+	sshBastionSecretName     string
+	sshBastionPort           int32
+	sshHostKeyFingerprints   []string
+	sshSudoUser              string
+	sshSudo                  bool
+	sshSudoPassword          bool
+	sshTimeout               time.Duration
+	kubeconfigSecretName     string
+	kubeconfigSecretKey      string
+	workspaceSecretName      string
+	variablesSecretName      string
+	dryRun                   bool
+
+	image            string
+	imagePullPolicy  corev1.PullPolicy
+	imagePullSecrets []corev1.LocalObjectReference
 
 	ttl                      *time.Duration
 	timeout                  time.Duration
 	backoffLimit             int32
 	tolerations              []corev1.Toleration
 	nodeSelector             map[string]string
+	affinity                 *corev1.Affinity
+	serviceAccountName       string
 	annotations              map[string]string
 	podTemplateLabels        map[string]string
 	podSecurityContext       *corev1.PodSecurityContext
@@ -53,6 +90,18 @@ func (s *ShellJobBuilder) WithBuildName(n string) *ShellJobBuilder {
 	return s
 }
 
+// WithJobName sets the name of the Job, the Jobs are named after their Build by default, see GetShellJobName.
+func (s *ShellJobBuilder) WithJobName(n string) *ShellJobBuilder {
+	s.jobName = n
+	return s
+}
+
+// WithAttempt records the attempt of the provisioner run by the Job, see buildv1.ProvisionerAttemptAnnotation.
+func (s *ShellJobBuilder) WithAttempt(n int32) *ShellJobBuilder {
+	s.attempt = n
+	return s
+}
+
 func (s *ShellJobBuilder) WithBuildNamespace(n string) *ShellJobBuilder {
 	s.buildNamespace = n
 	return s
@@ -63,8 +112,38 @@ func (s *ShellJobBuilder) WithScriptToRun(r string) *ShellJobBuilder {
 	return s
 }
 
-func (s *ShellJobBuilder) WithScriptToRunRef(r string) *ShellJobBuilder {
-	s.scriptToRunRef = r
+// WithSteps makes the Job run the steps held by its scripts ConfigMap, see WithScriptsConfigMap.
+func (s *ShellJobBuilder) WithSteps(steps bool) *ShellJobBuilder {
+	s.steps = steps
+	return s
+}
+
+// WithAnsible makes the Job run the ansible provisioner with the given arguments, see ansible.Args, instead of scripts.
+// The image of the Job must be the image of the ansible provisioner.
+func (s *ShellJobBuilder) WithAnsible(args []string) *ShellJobBuilder {
+	s.ansibleArgs = args
+	return s
+}
+
+// WithParameters makes the Job run the image of a ProvisionerClass instead of a script, the parameters of the
+// provisioner are passed as JSON in the ProvisionerParametersEnv environment variable.
+func (s *ShellJobBuilder) WithParameters(parameters string) *ShellJobBuilder {
+	s.parameters = &parameters
+	return s
+}
+
+// WithScriptsConfigMap makes the Job run the scripts of the given ConfigMap, in the namespace of the Job,
+// instead of the script to run. The hash of the scripts is recorded in the ScriptsHashAnnotation of the Job.
+func (s *ShellJobBuilder) WithScriptsConfigMap(name, hash string) *ShellJobBuilder {
+	s.scriptsConfigMapName = name
+	s.scriptsHash = hash
+	return s
+}
+
+// WithConnectorType sets the type of the connector the Job connects to the machine with, the ssh flags are ignored
+// by the docker-exec connector.
+func (s *ShellJobBuilder) WithConnectorType(connectorType string) *ShellJobBuilder {
+	s.connectorType = connectorType
 	return s
 }
 
@@ -73,13 +152,81 @@ func (s *ShellJobBuilder) WithSSHCredentialsSecretName(name string) *ShellJobBui
 	return s
 }
 
-func (s *ShellJobBuilder) WithRepo(r string) *ShellJobBuilder {
-	s.repo = r
+// WithSSHConnector sets the port to connect to, and the username used when the credentials have none.
+func (s *ShellJobBuilder) WithSSHConnector(port int32, username string) *ShellJobBuilder {
+	s.sshPort = port
+	s.sshUsername = username
+	return s
+}
+
+// WithSSHBastion makes the Job connect to the machine through the bastion described by the given secret.
+func (s *ShellJobBuilder) WithSSHBastion(secretName string, port int32) *ShellJobBuilder {
+	s.sshBastionSecretName = secretName
+	s.sshBastionPort = port
 	return s
 }
 
-func (s *ShellJobBuilder) WithTag(t string) *ShellJobBuilder {
-	s.tag = t
+// WithSSHHostKeyFingerprints restricts the host keys accepted by the Job, any host key is accepted if empty.
+func (s *ShellJobBuilder) WithSSHHostKeyFingerprints(fingerprints []string) *ShellJobBuilder {
+	s.sshHostKeyFingerprints = fingerprints
+	return s
+}
+
+// WithSSHSudo makes the Job run the script with sudo as the given user, password pipes the password
+// of the credentials to sudo.
+func (s *ShellJobBuilder) WithSSHSudo(user string, password bool) *ShellJobBuilder {
+	s.sshSudo = true
+	s.sshSudoUser = user
+	s.sshSudoPassword = password
+	return s
+}
+
+// WithSSHTimeout sets how long a single connection attempt to the machine may take.
+func (s *ShellJobBuilder) WithSSHTimeout(timeout time.Duration) *ShellJobBuilder {
+	s.sshTimeout = timeout
+	return s
+}
+
+// WithKubeconfig makes the kubeconfig in the given key of the secret available to the script.
+func (s *ShellJobBuilder) WithKubeconfig(secretName, key string) *ShellJobBuilder {
+	s.kubeconfigSecretName = secretName
+	s.kubeconfigSecretKey = key
+	return s
+}
+
+// WithWorkspace exports the presigned URLs of the workspace of the Build, held by the given secret, to the script.
+func (s *ShellJobBuilder) WithWorkspace(secretName string) *ShellJobBuilder {
+	s.workspaceSecretName = secretName
+	return s
+}
+
+// WithVariables exports the variables of the Build, held by the given secret, to the script.
+func (s *ShellJobBuilder) WithVariables(secretName string) *ShellJobBuilder {
+	s.variablesSecretName = secretName
+	return s
+}
+
+// WithDryRun makes the Job check the script instead of running it on the machine.
+func (s *ShellJobBuilder) WithDryRun(dryRun bool) *ShellJobBuilder {
+	s.dryRun = dryRun
+	return s
+}
+
+// WithImage sets the image of the shell provisioner, DefaultImage is used if empty.
+func (s *ShellJobBuilder) WithImage(image string) *ShellJobBuilder {
+	s.image = image
+	return s
+}
+
+// WithImagePullPolicy sets the pull policy of the image, IfNotPresent is used if empty.
+func (s *ShellJobBuilder) WithImagePullPolicy(policy corev1.PullPolicy) *ShellJobBuilder {
+	s.imagePullPolicy = policy
+	return s
+}
+
+// WithImagePullSecrets sets the secrets used to pull the image, they must exist in the namespace of the Job.
+func (s *ShellJobBuilder) WithImagePullSecrets(secrets []corev1.LocalObjectReference) *ShellJobBuilder {
+	s.imagePullSecrets = secrets
 	return s
 }
 
@@ -103,6 +250,19 @@ func (s *ShellJobBuilder) WithTolerations(tolerations []corev1.Toleration) *Shel
 	return s
 }
 
+// WithAffinity sets the scheduling constraints of the Pod, the Pod is scheduled on a linux node unless
+// the affinity has a node affinity.
+func (s *ShellJobBuilder) WithAffinity(affinity *corev1.Affinity) *ShellJobBuilder {
+	s.affinity = affinity
+	return s
+}
+
+// WithServiceAccountName sets the service account of the Pod, it defaults to the shell provisioner service account.
+func (s *ShellJobBuilder) WithServiceAccountName(name string) *ShellJobBuilder {
+	s.serviceAccountName = name
+	return s
+}
+
 func (s *ShellJobBuilder) WithAnnotations(annotations map[string]string) *ShellJobBuilder {
 	s.annotations = annotations
 	return s
@@ -188,7 +348,19 @@ func (s *ShellJobBuilder) Build() (*batchv1.Job, error) {
 		},
 		Spec: jobSpec,
 	}
+	if s.kubeconfigSecretName != "" {
+		job.Annotations[buildv1.KubeconfigSecretAnnotation] = fmt.Sprintf("%s/%s", s.buildNamespace, s.kubeconfigSecretName)
+	}
+	if s.scriptsHash != "" {
+		job.Annotations[buildv1.ScriptsHashAnnotation] = s.scriptsHash
+	}
+	if s.attempt > 0 {
+		job.Annotations[buildv1.ProvisionerAttemptAnnotation] = strconv.Itoa(int(s.attempt))
+	}
 	job.SetName(GetShellJobName(s.name))
+	if s.jobName != "" {
+		job.SetName(s.jobName)
+	}
 
 	return job, nil
 }
@@ -207,6 +379,19 @@ func (s *ShellJobBuilder) getPodSpec() corev1.PodSpec {
 			},
 		},
 	})
+	// The provisioner logs with the name of the Build and the UUID of the provisioner it runs for.
+	for _, v := range [][2]string{{forgelog.BuildNameEnv, buildv1.BuildNameLabel}, {forgelog.ProvisionerIDEnv, buildv1.ProvisionerIDLabel}} {
+		name, label := v[0], v[1]
+		env = append(env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.labels['%s']", label)},
+			},
+		})
+	}
+	if s.parameters != nil {
+		env = append(env, corev1.EnvVar{Name: buildv1.ProvisionerParametersEnv, Value: *s.parameters})
+	}
 
 	volumes := make([]corev1.Volume, 0)
 	volumeMounts := make([]corev1.VolumeMount, 0)
@@ -230,33 +415,83 @@ func (s *ShellJobBuilder) getPodSpec() corev1.PodSpec {
 	//		MountPath: mountPath,
 	//	})
 	//}
+	if s.scriptsConfigMapName != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: scriptsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: s.scriptsConfigMapName},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      scriptsVolumeName,
+			ReadOnly:  true,
+			MountPath: ScriptsMountPath,
+		})
+	}
 
 	args := s.getArgs()
 
 	containers = append(
 		containers,
 		corev1.Container{
-			Name:                     containerName,
+			Name:                     ContainerName,
 			Image:                    shelljobImageRef,
-			ImagePullPolicy:          corev1.PullIfNotPresent,
+			ImagePullPolicy:          s.getImagePullPolicy(),
 			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 			Env:                      env,
 			Args:                     args,
 			VolumeMounts:             volumeMounts,
 			Resources:                s.resourceRequirements,
+			SecurityContext:          s.containerSecurityContext,
 		},
 	)
 
+	securityContext := s.podSecurityContext
+	if securityContext == nil {
+		securityContext = &corev1.PodSecurityContext{}
+	}
 	return corev1.PodSpec{
-		ServiceAccountName: shell.ForgeProvisionerShellName,
+		ServiceAccountName: s.getServiceAccountName(),
 		Volumes:            volumes,
-		Affinity:           LinuxNodeAffinity(),
+		Affinity:           s.getAffinity(),
+		NodeSelector:       s.nodeSelector,
+		Tolerations:        s.tolerations,
+		PriorityClassName:  s.podPriorityClassName,
 		RestartPolicy:      corev1.RestartPolicyNever,
 		Containers:         containers,
-		SecurityContext:    &corev1.PodSecurityContext{},
+		ImagePullSecrets:   s.imagePullSecrets,
+		SecurityContext:    securityContext,
 	}
 }
 
+func (s *ShellJobBuilder) getServiceAccountName() string {
+	if s.serviceAccountName == "" {
+		return shell.ForgeProvisionerShellName
+	}
+	return s.serviceAccountName
+}
+
+// getAffinity returns the affinity of the Pod, requiring a linux node when it has no node affinity.
+func (s *ShellJobBuilder) getAffinity() *corev1.Affinity {
+	if s.affinity == nil {
+		return LinuxNodeAffinity()
+	}
+	affinity := s.affinity.DeepCopy()
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = LinuxNodeAffinity().NodeAffinity
+	}
+	return affinity
+}
+
+func (s *ShellJobBuilder) getImagePullPolicy() corev1.PullPolicy {
+	if s.imagePullPolicy == "" {
+		return corev1.PullIfNotPresent
+	}
+	return s.imagePullPolicy
+}
+
 func DurationSecondsPtr(d time.Duration) *int64 {
 	if d > 0 {
 		return ptr.To(int64(d.Seconds()))
@@ -265,26 +500,69 @@ func DurationSecondsPtr(d time.Duration) *int64 {
 }
 
 func (s *ShellJobBuilder) getArgs() []string {
-	if s.scriptToRunRef != "" {
-		args := []string{
-			"--namespace",
-			s.buildNamespace,
-			"--run-script-ref",
-			s.scriptToRunRef,
-			"--ssh-credentials-secret-name",
-			s.sshCredentialsSecretName,
-		}
-
-		return args
-	}
-	return []string{
+	args := []string{
 		"--namespace",
 		s.buildNamespace,
-		"--run-script",
-		s.scriptToRun,
-		"--ssh-credentials-secret-name",
-		s.sshCredentialsSecretName,
 	}
+	switch {
+	case s.parameters != nil:
+		// The image of a ProvisionerClass only receives the connection to the machine.
+	case len(s.ansibleArgs) > 0:
+		args = append(args, s.ansibleArgs...)
+	case s.scriptsConfigMapName != "" && s.steps:
+		args = append(args, "--run-steps", path.Join(ScriptsMountPath, shell.StepsKey))
+	case s.scriptsConfigMapName != "":
+		args = append(args, "--run-scripts-dir", ScriptsMountPath)
+	default:
+		args = append(args, "--run-script", s.scriptToRun)
+	}
+	// The ssh connector is the default of the provisioners, the flag is omitted so older images keep working.
+	if s.connectorType != "" && s.connectorType != buildv1.ConnectorTypeSSH {
+		args = append(args, "--connector-type", s.connectorType)
+	}
+	if s.sshCredentialsSecretName != "" {
+		args = append(args, "--ssh-credentials-secret-name", s.sshCredentialsSecretName)
+	}
+	if s.sshPort != 0 {
+		args = append(args, "--ssh-port", strconv.Itoa(int(s.sshPort)))
+	}
+	if s.sshUsername != "" {
+		args = append(args, "--ssh-username", s.sshUsername)
+	}
+	if s.sshBastionSecretName != "" {
+		args = append(args, "--ssh-bastion-secret-name", s.sshBastionSecretName)
+		if s.sshBastionPort != 0 {
+			args = append(args, "--ssh-bastion-port", strconv.Itoa(int(s.sshBastionPort)))
+		}
+	}
+	if len(s.sshHostKeyFingerprints) > 0 {
+		args = append(args, "--ssh-host-key-fingerprints", strings.Join(s.sshHostKeyFingerprints, ","))
+	}
+	if s.sshSudo {
+		args = append(args, "--ssh-sudo")
+		if s.sshSudoUser != "" {
+			args = append(args, "--ssh-sudo-user", s.sshSudoUser)
+		}
+		if s.sshSudoPassword {
+			args = append(args, "--ssh-sudo-password")
+		}
+	}
+	if s.sshTimeout > 0 {
+		args = append(args, "--ssh-timeout", s.sshTimeout.String())
+	}
+	if s.kubeconfigSecretName != "" {
+		args = append(args, "--kubeconfig-secret-name", s.kubeconfigSecretName, "--kubeconfig-secret-key", s.kubeconfigSecretKey)
+	}
+	if s.workspaceSecretName != "" {
+		args = append(args, "--workspace-secret-name", s.workspaceSecretName)
+	}
+	if s.variablesSecretName != "" {
+		args = append(args, "--variables-secret-name", s.variablesSecretName)
+	}
+	if s.dryRun {
+		args = append(args, "--dry-run")
+	}
+	return args
 }
 
 func GetShellJobName(buildName string) string {
@@ -308,9 +586,12 @@ func GetShellJobName(buildName string) string {
 //	return
 //}
 
-// GetImageRef returns upstream Trivy container image reference.
+// GetImageRef returns the reference of the image of the shell provisioner.
 func (s *ShellJobBuilder) GetImageRef() string {
-	return fmt.Sprintf("%s:%s", s.repo, s.tag)
+	if s.image == "" {
+		return DefaultImage
+	}
+	return s.image
 }
 
 // LinuxNodeAffinity constructs a new Affinity resource with linux supported nodes.
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgelog "github.com/forge-build/forge/pkg/log"
+	"github.com/forge-build/forge/provisioner/shell"
+)
+
+func TestPodTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	job, err := NewShellJobBuilder().WithBuildName("ubuntu").Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	pod := job.Spec.Template.Spec
+	g.Expect(pod.ServiceAccountName).To(Equal(shell.ForgeProvisionerShellName))
+	g.Expect(pod.Affinity).To(Equal(LinuxNodeAffinity()))
+	g.Expect(pod.NodeSelector).To(BeEmpty())
+
+	podAntiAffinity := &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{Weight: 100}},
+	}
+	resources := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}}
+	job, err = NewShellJobBuilder().WithBuildName("ubuntu").
+		WithResourceRequirements(resources).
+		WithNodeSelector(map[string]string{"pool": "builders"}).
+		WithTolerations([]corev1.Toleration{{Key: "dedicated", Value: "builders", Effect: corev1.TaintEffectNoSchedule}}).
+		WithAffinity(&corev1.Affinity{PodAntiAffinity: podAntiAffinity}).
+		WithServiceAccountName("builder").
+		WithPodSecurityContext(&corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)}).
+		WithSecurityContext(&corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(true)}).
+		Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	pod = job.Spec.Template.Spec
+	g.Expect(pod.ServiceAccountName).To(Equal("builder"))
+	g.Expect(pod.NodeSelector).To(Equal(map[string]string{"pool": "builders"}))
+	g.Expect(pod.Tolerations).To(HaveLen(1))
+	g.Expect(pod.Affinity.NodeAffinity).To(Equal(LinuxNodeAffinity().NodeAffinity))
+	g.Expect(pod.Affinity.PodAntiAffinity).To(Equal(podAntiAffinity))
+	g.Expect(pod.SecurityContext.RunAsNonRoot).To(Equal(ptr.To(true)))
+	g.Expect(pod.Containers[0].Resources).To(Equal(resources))
+	g.Expect(pod.Containers[0].SecurityContext.ReadOnlyRootFilesystem).To(Equal(ptr.To(true)))
+}
+
+func TestParameters(t *testing.T) {
+	g := NewWithT(t)
+
+	job, err := NewShellJobBuilder().WithBuildName("ubuntu").WithBuildNamespace("images").
+		WithImage("example.com/vault-seed:v1").
+		WithParameters(`{"path":"secret/ci"}`).
+		WithSSHConnector(22, "ubuntu").
+		Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	container := job.Spec.Template.Spec.Containers[0]
+	g.Expect(container.Image).To(Equal("example.com/vault-seed:v1"))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: buildv1.ProvisionerParametersEnv, Value: `{"path":"secret/ci"}`}))
+	g.Expect(container.Env).To(ContainElement(HaveField("Name", forgelog.BuildNameEnv)))
+	g.Expect(container.Args).To(Equal([]string{"--namespace", "images", "--ssh-port", "22", "--ssh-username", "ubuntu"}))
+}
+
+func TestConnectorType(t *testing.T) {
+	g := NewWithT(t)
+
+	job, err := NewShellJobBuilder().WithBuildName("ubuntu").WithBuildNamespace("images").
+		WithScriptToRun("true").
+		WithConnectorType(buildv1.ConnectorTypeSSH).
+		WithSSHCredentialsSecretName("ubuntu-ssh").
+		Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(job.Spec.Template.Spec.Containers[0].Args).ToNot(ContainElement("--connector-type"))
+
+	job, err = NewShellJobBuilder().WithBuildName("ubuntu").WithBuildNamespace("images").
+		WithScriptToRun("true").
+		WithConnectorType(buildv1.ConnectorTypeDockerExec).
+		WithSSHCredentialsSecretName("ubuntu-docker").
+		Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(job.Spec.Template.Spec.Containers[0].Args).To(Equal([]string{"--namespace", "images", "--run-script", "true",
+		"--connector-type", "docker-exec", "--ssh-credentials-secret-name", "ubuntu-docker"}))
+}
@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"encoding/json"
+)
+
+// StepsKey is the key of the ConfigMap mounted by the shell Jobs holding the steps to run.
+const StepsKey = "steps.json"
+
+// Step is a step run by the shell provisioner Job. The steps of a provisioner are resolved by the controller,
+// e.g. the scripts of the ConfigMaps they reference are read, and handed to the Job in the StepsKey of its ConfigMap.
+type Step struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+	// Scripts are the scripts run by the step, in order.
+	Scripts []Script `json:"scripts,omitempty"`
+	// File is the file uploaded by the step.
+	File *File `json:"file,omitempty"`
+	// Source is the directory tree copied by the step.
+	Source *Source `json:"source,omitempty"`
+}
+
+// Script is a script run by a step.
+type Script struct {
+	// Name is the name of the script in the logs, e.g. the key of its ConfigMap.
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// File is a file uploaded by a step.
+type File struct {
+	Content     string `json:"content"`
+	Destination string `json:"destination"`
+	Mode        uint32 `json:"mode"`
+}
+
+// Source is a directory tree copied to the machine as an archive, from the given files or from a git repository.
+type Source struct {
+	// Destination is the directory of the machine replaced by the tree.
+	Destination string `json:"destination"`
+	// Files are the contents of the files of the tree by their relative path, they are added to the repository
+	// when Git is set.
+	Files map[string]string `json:"files,omitempty"`
+	// Git is the repository cloned by the Job.
+	Git *GitSource `json:"git,omitempty"`
+}
+
+// GitSource is a git repository cloned by the Job.
+type GitSource struct {
+	URL string `json:"url"`
+	Ref string `json:"ref,omitempty"`
+	// SecretName is the secret of the Build holding the username and password used to clone the repository.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// StepStatus is the outcome of a step, the Job reports the outcomes of its steps as a JSON list in its termination message.
+type StepStatus struct {
+	Name    string `json:"name"`
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+}
+
+// ParseStepStatuses returns the outcomes of the steps reported in the given termination message.
+func ParseStepStatuses(message string) ([]StepStatus, error) {
+	statuses := []StepStatus{}
+	if err := json.Unmarshal([]byte(message), &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
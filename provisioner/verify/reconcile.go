@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+	"github.com/forge-build/forge/util"
+)
+
+const (
+	// VerificationFailedReason is the failure reason of a verify provisioner with failed assertions.
+	VerificationFailedReason = "VerificationFailed"
+
+	// maxSuiteSize is the size of the largest suite, it is written to the machine by a single command.
+	maxSuiteSize = 64 * 1024
+)
+
+// Reconcile checks the assertions of a built-in/verify provisioner on the infrastructure machine,
+// the results are recorded in the provisioner, the report in its status, and it fails if any assertion
+// does not hold.
+func Reconcile(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.ProvisionerSpec) (ctrl.Result, error) {
+	status := ptr.Deref(spec.Status, buildv1.ProvisionerStatusPending)
+	if status != buildv1.ProvisionerStatusCompleted && status != buildv1.ProvisionerStatusFailed {
+		if spec.Verify == nil {
+			return ctrl.Result{}, forgeerrors.NewConfigError(errors.Errorf("verify provisioner %q has no assertions", spec.Name))
+		}
+		if spec.UUID == nil {
+			spec.UUID = ptr.To(uuid.New().String())
+		}
+
+		results, err := run(ctx, c, build, spec.Verify)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		spec.Results = results
+
+		failed := Failed(results)
+		if len(failed) == 0 {
+			spec.Status = ptr.To(buildv1.ProvisionerStatusCompleted)
+			util.RecordProvisionerStatus(build, spec).Report = report(results, failed)
+			return ctrl.Result{}, nil
+		}
+
+		messages := make([]string, 0, len(failed))
+		for _, r := range failed {
+			messages = append(messages, fmt.Sprintf("%s: %s", r.Assertion, r.Message))
+		}
+		spec.Status = ptr.To(buildv1.ProvisionerStatusFailed)
+		spec.FailureReason = ptr.To(VerificationFailedReason)
+		spec.FailureMessage = ptr.To(fmt.Sprintf("%d of %d assertion(s) failed: %s",
+			len(failed), len(results), strings.Join(messages, "; ")))
+		util.RecordProvisionerStatus(build, spec).Report = report(results, failed)
+	}
+
+	if *spec.Status == buildv1.ProvisionerStatusFailed && !spec.AllowFail {
+		return ctrl.Result{}, forgeerrors.Terminalf(forgeerrors.ProvisionerFailedError,
+			"Provisioner %s failed with Reason %s and Message %s",
+			spec.Name, ptr.Deref(spec.FailureReason, ""), ptr.Deref(spec.FailureMessage, ""))
+	}
+	return ctrl.Result{}, nil
+}
+
+// run connects to the infrastructure machine and checks the assertions and the suites.
+func run(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.VerifySpec) ([]buildv1.VerificationResult, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.NewConfigError(errors.New("verify provisioners require the connector credentials"))
+	}
+	suites, err := suiteContents(ctx, c, build, spec)
+	if err != nil {
+		return nil, err
+	}
+	sshClient, err := util.NewSSHClient(ctx, c, build)
+	if err != nil {
+		return nil, err
+	}
+	if err := sshClient.Validate(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "invalid SSH credentials")
+	}
+	if err := sshClient.Connect(); err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "failed to connect to the machine via ssh")
+	}
+	defer sshClient.Disconnect()
+
+	return Run(ctx, sshClient, spec, suites)
+}
+
+// suiteContents returns the contents of the suites, by name, from their ConfigMaps.
+func suiteContents(ctx context.Context, c client.Client, build *buildv1.Build, spec *buildv1.VerifySpec) (map[string]string, error) {
+	suites := map[string]string{}
+	for _, suite := range spec.Suites {
+		ref := suite.ConfigMapKeyRef
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: ref.Name}, cm); err != nil {
+			return nil, errors.Wrapf(err, "failed to get ConfigMap %s of suite %s", ref.Name, suite.Name)
+		}
+		content, ok := cm.Data[ref.Key]
+		if !ok {
+			return nil, forgeerrors.ConfigErrorf("ConfigMap %s has no key %s for suite %s", ref.Name, ref.Key, suite.Name)
+		}
+		if len(content) > maxSuiteSize {
+			return nil, forgeerrors.ConfigErrorf("suite %s is larger than %d bytes", suite.Name, maxSuiteSize)
+		}
+		suites[suite.Name] = content
+	}
+	return suites, nil
+}
+
+// report returns the report of the results, failed are the results of the assertions which do not hold.
+func report(results, failed []buildv1.VerificationResult) *buildv1.VerificationReport {
+	return &buildv1.VerificationReport{
+		Total:    int32(len(results)),
+		Passed:   int32(len(results) - len(failed)),
+		Failed:   int32(len(failed)),
+		Failures: failed,
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// gossReport is the part of the JSON report of goss validate used by the provisioner.
+type gossReport struct {
+	Results []struct {
+		ResourceType string `json:"resource-type"`
+		ResourceID   string `json:"resource-id"`
+		Property     string `json:"property"`
+		Successful   bool   `json:"successful"`
+		Skipped      bool   `json:"skipped"`
+		SummaryLine  string `json:"summary-line"`
+	} `json:"results"`
+}
+
+// inspecReport is the part of the JSON report of inspec exec used by the provisioner.
+type inspecReport struct {
+	Profiles []struct {
+		Controls []struct {
+			ID      string `json:"id"`
+			Results []struct {
+				Status   string `json:"status"`
+				CodeDesc string `json:"code_desc"`
+				Message  string `json:"message"`
+			} `json:"results"`
+		} `json:"controls"`
+	} `json:"profiles"`
+}
+
+// runSuite writes the suite to a temporary file of the machine and runs it with its tool, every test of the
+// JSON report of the tool is an assertion. The skipped tests pass.
+func runSuite(ctx context.Context, runner Runner, suite buildv1.VerifySuite, content string) ([]buildv1.VerificationResult, error) {
+	prefix := fmt.Sprintf("%s %s", suite.Type, suite.Name)
+	file := fmt.Sprintf("/tmp/forge-verify-%x", sha256.Sum256([]byte(content)))
+	var tool string
+	switch suite.Type {
+	case buildv1.VerifySuiteTypeGoss:
+		file += ".yaml"
+		tool = fmt.Sprintf("goss --gossfile %s validate --format json --no-color", file)
+	case buildv1.VerifySuiteTypeInSpec:
+		file += ".rb"
+		tool = fmt.Sprintf("inspec exec %s --reporter json --no-color --chef-license accept-silent", file)
+	default:
+		return []buildv1.VerificationResult{{Assertion: prefix + " runs", Message: "unknown suite type"}}, nil
+	}
+
+	// The tools exit with an error when a test fails, the outcome of the suite is its report.
+	command := fmt.Sprintf("printf '%%s' %s | base64 -d > %s && %s; rm -f %s; true",
+		base64.StdEncoding.EncodeToString([]byte(content)), file, tool, file)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := runWithTimeout(ctx, runner, SuiteTimeout, command, stdout, stderr)
+	if timedOut(ctx, err) {
+		return []buildv1.VerificationResult{{
+			Assertion: prefix + " runs",
+			Message:   fmt.Sprintf("the suite timed out after %s", SuiteTimeout),
+		}}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run suite %s: %s", suite.Name, strings.TrimSpace(stderr.String()))
+	}
+
+	results, err := parseReport(suite.Type, prefix, stdout.Bytes())
+	if err != nil {
+		return []buildv1.VerificationResult{{
+			Assertion: prefix + " runs",
+			Message:   fmt.Sprintf("no report: %v: %s", err, abbreviate(strings.TrimSpace(stderr.String()))),
+		}}, nil
+	}
+	return results, nil
+}
+
+// parseReport returns the assertions of the JSON report of a suite, their descriptions start with prefix.
+func parseReport(suiteType buildv1.VerifySuiteType, prefix string, report []byte) ([]buildv1.VerificationResult, error) {
+	// The tools may log warnings before the report.
+	i := bytes.IndexByte(report, '{')
+	if i < 0 {
+		return nil, errors.New("the output is not a JSON report")
+	}
+	report = report[i:]
+
+	results := []buildv1.VerificationResult{}
+	switch suiteType {
+	case buildv1.VerifySuiteTypeGoss:
+		r := &gossReport{}
+		if err := json.Unmarshal(report, r); err != nil {
+			return nil, err
+		}
+		for _, test := range r.Results {
+			result := buildv1.VerificationResult{
+				Assertion: fmt.Sprintf("%s: %s: %s: %s", prefix, test.ResourceType, test.ResourceID, test.Property),
+				Passed:    test.Successful || test.Skipped,
+			}
+			if !result.Passed {
+				result.Message = strings.TrimSpace(test.SummaryLine)
+			}
+			results = append(results, result)
+		}
+	case buildv1.VerifySuiteTypeInSpec:
+		r := &inspecReport{}
+		if err := json.Unmarshal(report, r); err != nil {
+			return nil, err
+		}
+		for _, profile := range r.Profiles {
+			for _, control := range profile.Controls {
+				for _, test := range control.Results {
+					result := buildv1.VerificationResult{
+						Assertion: fmt.Sprintf("%s: %s: %s", prefix, control.ID, test.CodeDesc),
+						Passed:    test.Status == "passed" || test.Status == "skipped",
+					}
+					if !result.Passed {
+						result.Message = strings.TrimSpace(test.Message)
+					}
+					results = append(results, result)
+				}
+			}
+		}
+	}
+	return results, nil
+}
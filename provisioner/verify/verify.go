@@ -0,0 +1,312 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verify implements the built-in/verify provisioner, which asserts the state of the
+// infrastructure machine after provisioning: files, packages, services, commands and test suites.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+const (
+	// CheckTimeout is the deadline of the command of an assertion, an assertion whose command does not complete
+	// in time does not hold.
+	CheckTimeout = 2 * time.Minute
+
+	// SuiteTimeout is the deadline of a suite, a suite which does not complete in time fails.
+	SuiteTimeout = 10 * time.Minute
+)
+
+// Runner runs a command on the infrastructure machine until the context is done, it is implemented by the SSH clients.
+type Runner interface {
+	RunContext(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
+}
+
+// check is a single assertion, its command always succeeds and reports what has been found on stdout
+// so that a failing command means the machine could not be reached.
+type check struct {
+	assertion string
+	command   string
+	evaluate  func(output string) (passed bool, message string)
+}
+
+// exitCodeMarker precedes the exit code of the command of a command assertion in its output.
+const exitCodeMarker = "forge-exit-code="
+
+// Run checks the assertions of spec on the machine, in order, then runs its suites. suites are the contents
+// of the suites by name. The commands run for at most CheckTimeout and the suites for at most SuiteTimeout.
+func Run(ctx context.Context, runner Runner, spec *buildv1.VerifySpec, suites map[string]string) ([]buildv1.VerificationResult, error) {
+	checks := checksFor(spec)
+	results := make([]buildv1.VerificationResult, 0, len(checks))
+	for _, c := range checks {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := runWithTimeout(ctx, runner, CheckTimeout, c.command, stdout, stderr)
+		if timedOut(ctx, err) {
+			results = append(results, buildv1.VerificationResult{
+				Assertion: c.assertion,
+				Message:   fmt.Sprintf("the check timed out after %s", CheckTimeout),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check %s: %s", c.assertion, strings.TrimSpace(stderr.String()))
+		}
+		passed, message := c.evaluate(strings.TrimSpace(stdout.String()))
+		result := buildv1.VerificationResult{Assertion: c.assertion, Passed: passed}
+		if !passed {
+			result.Message = message
+		}
+		results = append(results, result)
+	}
+	if spec == nil {
+		return results, nil
+	}
+	for _, suite := range spec.Suites {
+		suiteResults, err := runSuite(ctx, runner, suite, suites[suite.Name])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, suiteResults...)
+	}
+	return results, nil
+}
+
+// runWithTimeout runs the command on the machine for at most timeout.
+func runWithTimeout(ctx context.Context, runner Runner, timeout time.Duration, command string, stdout, stderr io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return runner.RunContext(ctx, command, stdout, stderr)
+}
+
+// timedOut returns true if the command did not complete within its timeout, rather than ctx being done.
+func timedOut(ctx context.Context, err error) bool {
+	return errors.Is(err, ssh.ErrCommandTimeout) && ctx.Err() == nil
+}
+
+// Failed returns the results of the assertions which do not hold.
+func Failed(results []buildv1.VerificationResult) []buildv1.VerificationResult {
+	failed := []buildv1.VerificationResult{}
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+func checksFor(spec *buildv1.VerifySpec) []check {
+	if spec == nil {
+		return nil
+	}
+	checks := []check{}
+	for _, f := range spec.Files {
+		checks = append(checks, fileChecks(f)...)
+	}
+	for _, p := range spec.Packages {
+		checks = append(checks, packageCheck(p))
+	}
+	for _, s := range spec.Services {
+		checks = append(checks, serviceChecks(s)...)
+	}
+	for _, c := range spec.Commands {
+		checks = append(checks, commandCheck(c))
+	}
+	return checks
+}
+
+func fileChecks(f buildv1.FileAssertion) []check {
+	path := ssh.Quote(f.Path)
+	exists := fmt.Sprintf("test -e %s && echo present || echo absent", path)
+	if f.Absent {
+		return []check{{
+			assertion: fmt.Sprintf("file %s is absent", f.Path),
+			command:   exists,
+			evaluate: func(output string) (bool, string) {
+				return output == "absent", "file exists"
+			},
+		}}
+	}
+
+	checks := []check{{
+		assertion: fmt.Sprintf("file %s exists", f.Path),
+		command:   exists,
+		evaluate: func(output string) (bool, string) {
+			return output == "present", "file does not exist"
+		},
+	}}
+	if f.SHA256 != "" {
+		want := strings.ToLower(f.SHA256)
+		checks = append(checks, check{
+			assertion: fmt.Sprintf("file %s has sha256 %s", f.Path, want),
+			command:   fmt.Sprintf("sha256sum -- %s 2>/dev/null | cut -d ' ' -f 1", path),
+			evaluate: func(output string) (bool, string) {
+				if output == "" {
+					return false, "file cannot be read"
+				}
+				return output == want, fmt.Sprintf("sha256 is %s", output)
+			},
+		})
+	}
+	if f.Contains != "" {
+		checks = append(checks, check{
+			assertion: fmt.Sprintf("file %s contains %q", f.Path, f.Contains),
+			command:   fmt.Sprintf("grep -qF -e %s -- %s 2>/dev/null && echo found || echo missing", ssh.Quote(f.Contains), path),
+			evaluate: func(output string) (bool, string) {
+				return output == "found", "content not found in the file"
+			},
+		})
+	}
+	return checks
+}
+
+func packageCheck(p buildv1.PackageAssertion) check {
+	name := ssh.Quote(p.Name)
+	assertion := fmt.Sprintf("package %s is installed", p.Name)
+	if p.Version != "" {
+		assertion = fmt.Sprintf("package %s has version %s", p.Name, p.Version)
+	}
+	return check{
+		assertion: assertion,
+		// Both dpkg and rpm report "installed <version>", anything else means the package is not installed.
+		command: fmt.Sprintf("if command -v dpkg-query >/dev/null 2>&1; then "+
+			"dpkg-query -W -f='${db:Status-Status} ${Version}' %s 2>/dev/null; "+
+			"elif command -v rpm >/dev/null 2>&1; then "+
+			"rpm -q --qf 'installed %%{VERSION}-%%{RELEASE}' %s 2>/dev/null; "+
+			"else echo unsupported; fi; true", name, name),
+		evaluate: func(output string) (bool, string) {
+			if output == "unsupported" {
+				return false, "neither dpkg nor rpm is available on the machine"
+			}
+			version, installed := strings.CutPrefix(output, "installed ")
+			if !installed {
+				return false, "package is not installed"
+			}
+			return versionMatches(version, p.Version), fmt.Sprintf("installed version is %s", version)
+		},
+	}
+}
+
+func serviceChecks(s buildv1.ServiceAssertion) []check {
+	name := ssh.Quote(s.Name)
+	running := s.Running == nil || *s.Running
+	assertion := fmt.Sprintf("service %s is running", s.Name)
+	if !running {
+		assertion = fmt.Sprintf("service %s is not running", s.Name)
+	}
+	checks := []check{{
+		assertion: assertion,
+		command:   fmt.Sprintf("systemctl is-active %s 2>/dev/null; true", name),
+		evaluate: func(output string) (bool, string) {
+			return (output == "active") == running, fmt.Sprintf("service is %s", stateOrUnknown(output))
+		},
+	}}
+
+	if s.Enabled != nil {
+		enabled := *s.Enabled
+		assertion := fmt.Sprintf("service %s is enabled", s.Name)
+		if !enabled {
+			assertion = fmt.Sprintf("service %s is disabled", s.Name)
+		}
+		checks = append(checks, check{
+			assertion: assertion,
+			command:   fmt.Sprintf("systemctl is-enabled %s 2>/dev/null; true", name),
+			evaluate: func(output string) (bool, string) {
+				return (output == "enabled") == enabled, fmt.Sprintf("service is %s", stateOrUnknown(output))
+			},
+		})
+	}
+	return checks
+}
+
+func commandCheck(c buildv1.CommandAssertion) check {
+	assertion := c.Name
+	if assertion == "" {
+		assertion = fmt.Sprintf("command %s", c.Command)
+	}
+	want := int(ptr.Deref(c.ExitCode, 0))
+	return check{
+		assertion: assertion,
+		// The command runs in its own shell, so exiting does not skip reporting its exit code.
+		command: fmt.Sprintf("sh -c %s </dev/null 2>&1; echo; echo %s$?", ssh.Quote(c.Command), exitCodeMarker),
+		evaluate: func(output string) (bool, string) {
+			i := strings.LastIndex(output, exitCodeMarker)
+			if i < 0 {
+				return false, "the exit code of the command is unknown"
+			}
+			code, err := strconv.Atoi(output[i+len(exitCodeMarker):])
+			if err != nil {
+				return false, "the exit code of the command is unknown"
+			}
+			output = strings.TrimSpace(output[:i])
+			switch {
+			case code != want:
+				return false, fmt.Sprintf("exit code is %d, output: %s", code, abbreviate(output))
+			case c.Contains != "" && !strings.Contains(output, c.Contains):
+				return false, fmt.Sprintf("output does not contain %q: %s", c.Contains, abbreviate(output))
+			case c.Matches != "":
+				pattern, err := regexp.Compile(c.Matches)
+				if err != nil {
+					return false, fmt.Sprintf("invalid pattern: %v", err)
+				}
+				if !pattern.MatchString(output) {
+					return false, fmt.Sprintf("output does not match %q: %s", c.Matches, abbreviate(output))
+				}
+			}
+			return true, ""
+		},
+	}
+}
+
+// versionMatches returns true if version matches want, a trailing * in want matches any suffix.
+func versionMatches(version, want string) bool {
+	if prefix, ok := strings.CutSuffix(want, "*"); ok {
+		return strings.HasPrefix(version, prefix)
+	}
+	return want == "" || version == want
+}
+
+func stateOrUnknown(state string) string {
+	if state == "" {
+		return "unknown"
+	}
+	return state
+}
+
+// abbreviate returns the beginning of the output of a command, to describe a failed assertion.
+func abbreviate(output string) string {
+	const size = 256
+	if output == "" {
+		return "none"
+	}
+	if len(output) > size {
+		return output[:size] + "..."
+	}
+	return output
+}
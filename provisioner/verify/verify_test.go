@@ -0,0 +1,254 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// fakeRunner replies to the commands starting with a key of outputs, the commands starting with hang do not
+// complete before the deadline of their context.
+type fakeRunner struct {
+	outputs map[string]string
+	hang    string
+	err     error
+}
+
+func (f *fakeRunner) RunContext(ctx context.Context, command string, stdout io.Writer, _ io.Writer) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.hang != "" && strings.HasPrefix(command, f.hang) {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		return fmt.Errorf("%w: %w", ssh.ErrCommandTimeout, context.DeadlineExceeded)
+	}
+	for prefix, output := range f.outputs {
+		if strings.HasPrefix(command, prefix) {
+			_, err := io.WriteString(stdout, output+"\n")
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	const checksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		name    string
+		spec    *buildv1.VerifySpec
+		outputs map[string]string
+		want    []buildv1.VerificationResult
+	}{
+		{
+			name: "file exists with the expected checksum and content",
+			spec: &buildv1.VerifySpec{Files: []buildv1.FileAssertion{{Path: "/etc/motd", SHA256: strings.ToUpper(checksum), Contains: "welcome"}}},
+			outputs: map[string]string{
+				"test -e '/etc/motd'": "present",
+				"sha256sum":           checksum,
+				"grep":                "found",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "file /etc/motd exists", Passed: true},
+				{Assertion: "file /etc/motd has sha256 " + checksum, Passed: true},
+				{Assertion: `file /etc/motd contains "welcome"`, Passed: true},
+			},
+		},
+		{
+			name: "missing file",
+			spec: &buildv1.VerifySpec{Files: []buildv1.FileAssertion{{Path: "/etc/motd", SHA256: checksum}}},
+			outputs: map[string]string{
+				"test -e": "absent",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "file /etc/motd exists", Message: "file does not exist"},
+				{Assertion: "file /etc/motd has sha256 " + checksum, Message: "file cannot be read"},
+			},
+		},
+		{
+			name: "absent file",
+			spec: &buildv1.VerifySpec{Files: []buildv1.FileAssertion{{Path: "/root/.bash_history", Absent: true, Contains: "ignored"}}},
+			outputs: map[string]string{
+				"test -e": "present",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "file /root/.bash_history is absent", Message: "file exists"},
+			},
+		},
+		{
+			name: "package versions",
+			spec: &buildv1.VerifySpec{Packages: []buildv1.PackageAssertion{
+				{Name: "nginx", Version: "1.18*"},
+			}},
+			outputs: map[string]string{
+				"if command -v dpkg-query": "installed 1.18.0-6ubuntu14",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "package nginx has version 1.18*", Passed: true},
+			},
+		},
+		{
+			name: "package with another version",
+			spec: &buildv1.VerifySpec{Packages: []buildv1.PackageAssertion{{Name: "nginx", Version: "1.20.1"}}},
+			outputs: map[string]string{
+				"if command -v dpkg-query": "installed 1.18.0",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "package nginx has version 1.20.1", Message: "installed version is 1.18.0"},
+			},
+		},
+		{
+			name: "package not installed",
+			spec: &buildv1.VerifySpec{Packages: []buildv1.PackageAssertion{{Name: "curl"}}},
+			outputs: map[string]string{
+				"if command -v dpkg-query": "package curl is not installed",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "package curl is installed", Message: "package is not installed"},
+			},
+		},
+		{
+			name: "service states",
+			spec: &buildv1.VerifySpec{Services: []buildv1.ServiceAssertion{
+				{Name: "nginx", Enabled: ptr.To(true)},
+				{Name: "apt-daily", Running: ptr.To(false), Enabled: ptr.To(false)},
+			}},
+			outputs: map[string]string{
+				"systemctl is-active 'nginx'":      "active",
+				"systemctl is-enabled 'nginx'":     "disabled",
+				"systemctl is-active 'apt-daily'":  "inactive",
+				"systemctl is-enabled 'apt-daily'": "disabled",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "service nginx is running", Passed: true},
+				{Assertion: "service nginx is enabled", Message: "service is disabled"},
+				{Assertion: "service apt-daily is not running", Passed: true},
+				{Assertion: "service apt-daily is disabled", Passed: true},
+			},
+		},
+		{
+			name: "commands",
+			spec: &buildv1.VerifySpec{Commands: []buildv1.CommandAssertion{
+				{Command: "nginx -v", Matches: `nginx/1\.2[0-9]`},
+				{Name: "sshd rejects passwords", Command: "sshd -T", Contains: "passwordauthentication no"},
+				{Command: "id builder", ExitCode: ptr.To[int32](1)},
+				{Command: "test -f /etc/cloud/cloud-init.disabled"},
+			}},
+			outputs: map[string]string{
+				"sh -c 'nginx -v'":    "nginx version: nginx/1.18.0\n" + exitCodeMarker + "0",
+				"sh -c 'sshd -T'":     "passwordauthentication no\n" + exitCodeMarker + "0",
+				"sh -c 'id builder'":  "id: 'builder': no such user\n" + exitCodeMarker + "1",
+				"sh -c 'test -f /etc": "\n" + exitCodeMarker + "1",
+			},
+			want: []buildv1.VerificationResult{
+				{Assertion: "command nginx -v", Message: `output does not match "nginx/1\\.2[0-9]": nginx version: nginx/1.18.0`},
+				{Assertion: "sshd rejects passwords", Passed: true},
+				{Assertion: "command id builder", Passed: true},
+				{Assertion: "command test -f /etc/cloud/cloud-init.disabled", Message: "exit code is 1, output: none"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			results, err := Run(context.Background(), &fakeRunner{outputs: tt.outputs}, tt.spec, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(results).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRunSuites(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.VerifySpec{Suites: []buildv1.VerifySuite{
+		{Name: "base", Type: buildv1.VerifySuiteTypeGoss},
+		{Name: "cis", Type: buildv1.VerifySuiteTypeInSpec},
+		{Name: "missing", Type: buildv1.VerifySuiteTypeGoss},
+	}}
+	runner := &fakeRunner{outputs: map[string]string{
+		"printf '%s' c2VydmljZToKICBzc2hkOiB7cnVubmluZzogdHJ1ZX0=": `{"results": [
+			{"resource-type": "Service", "resource-id": "sshd", "property": "running", "successful": true},
+			{"resource-type": "Package", "resource-id": "telnet", "property": "installed", "successful": false,
+			 "summary-line": "Package: telnet: installed: Expected false to equal true"}
+		]}`,
+		"printf '%s' Y29udHJvbCAnc3NoLTAxJw==": `WARN: deprecated option
+{"profiles": [{"controls": [{"id": "ssh-01", "results": [
+	{"status": "passed", "code_desc": "SSH Configuration PermitRootLogin is expected to eq \"no\""},
+	{"status": "skipped", "code_desc": "Kernel Parameter net.ipv4.ip_forward"},
+	{"status": "failed", "code_desc": "File /etc/shadow mode is expected to cmp == \"0640\"", "message": "expected: 0640 got: 0644"}
+]}]}]}`,
+	}}
+
+	results, err := Run(context.Background(), runner, spec, map[string]string{
+		"base": "service:\n  sshd: {running: true}",
+		"cis":  "control 'ssh-01'",
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]buildv1.VerificationResult{
+		{Assertion: "goss base: Service: sshd: running", Passed: true},
+		{Assertion: "goss base: Package: telnet: installed", Message: "Package: telnet: installed: Expected false to equal true"},
+		{Assertion: `inspec cis: ssh-01: SSH Configuration PermitRootLogin is expected to eq "no"`, Passed: true},
+		{Assertion: "inspec cis: ssh-01: Kernel Parameter net.ipv4.ip_forward", Passed: true},
+		{Assertion: `inspec cis: ssh-01: File /etc/shadow mode is expected to cmp == "0640"`, Message: "expected: 0640 got: 0644"},
+		{Assertion: "goss missing runs", Message: "no report: the output is not a JSON report: none"},
+	}))
+}
+
+func TestRunError(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.VerifySpec{Files: []buildv1.FileAssertion{{Path: "/etc/motd"}}}
+	_, err := Run(context.Background(), &fakeRunner{err: errors.New("connection reset")}, spec, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("connection reset")))
+}
+
+func TestRunTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &buildv1.VerifySpec{
+		Commands: []buildv1.CommandAssertion{{Name: "slow", Command: "sleep 3600"}},
+		Suites:   []buildv1.VerifySuite{{Name: "base", Type: buildv1.VerifySuiteTypeGoss}},
+	}
+	results, err := Run(context.Background(), &fakeRunner{hang: "sh -c"}, spec, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results[0]).To(Equal(buildv1.VerificationResult{Assertion: "slow", Message: "the check timed out after 2m0s"}))
+
+	results, err = Run(context.Background(), &fakeRunner{hang: "printf"}, spec, map[string]string{"base": "{}"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results[1]).To(Equal(buildv1.VerificationResult{Assertion: "goss base runs", Message: "the suite timed out after 10m0s"}))
+
+	// The commands are not retried once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, &fakeRunner{hang: "sh -c"}, spec, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("timed out waiting for command to complete")))
+}
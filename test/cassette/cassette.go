@@ -0,0 +1,321 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cassette records the HTTP interactions of the cloud SDKs into cassettes and replays them, so the
+// controllers of the infrastructure providers can be tested end to end without cloud credentials.
+//
+// Cassettes are replayed by default, and a request without a recorded interaction fails. They are re-recorded
+// against a real account by running the tests with FORGE_CASSETTE_MODE=record, along with the credentials the SDK
+// expects. The credentials are never recorded, see DefaultRedactedHeaders.
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ModeEnv is the environment variable selecting the Mode of the cassettes started by Start.
+const ModeEnv = "FORGE_CASSETTE_MODE"
+
+// Mode is the mode of a Recorder.
+type Mode string
+
+const (
+	// ModeReplay replays the interactions of the cassette, requests without a recorded interaction fail.
+	ModeReplay Mode = "replay"
+
+	// ModeRecord sends the requests and records the interactions, overwriting the cassette when stopped.
+	ModeRecord Mode = "record"
+)
+
+// redacted replaces the values of the redacted headers.
+const redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers carrying credentials, their values are not recorded.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Amz-Security-Token",
+	"X-Auth-Token",
+	"X-Goog-Api-Key",
+}
+
+// ErrInteractionNotFound is returned when replaying a request which has not been recorded.
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the list of the recorded interactions, in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher returns true if the recorded request matches the request being replayed.
+type Matcher func(r *http.Request, body []byte, recorded Request) bool
+
+// DefaultMatcher matches the method, the URL, with the query parameters in any order, and the body of the requests.
+func DefaultMatcher(r *http.Request, body []byte, recorded Request) bool {
+	if r.Method != recorded.Method || string(body) != recorded.Body {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == r.URL.Scheme && u.Host == r.URL.Host && u.Path == r.URL.Path &&
+		u.Query().Encode() == r.URL.Query().Encode()
+}
+
+// Options configures a Recorder.
+type Options struct {
+	// Mode is the mode of the Recorder, defaults to ModeReplay.
+	Mode Mode
+
+	// Transport sends the requests in ModeRecord, defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// Matcher matches the requests with the recorded interactions, defaults to DefaultMatcher.
+	Matcher Matcher
+
+	// RedactedHeaders are the headers whose values are not recorded, defaults to DefaultRedactedHeaders.
+	RedactedHeaders []string
+
+	// Redact is called on every interaction before it is recorded, e.g. to remove account IDs from the bodies.
+	// The Matcher must ignore the redacted parts of the requests for them to be replayed.
+	Redact func(*Interaction)
+}
+
+// Recorder is an http.RoundTripper recording or replaying the interactions of a cassette.
+type Recorder struct {
+	path    string
+	options Options
+
+	mu       sync.Mutex
+	cassette Cassette
+	// replayed marks the interactions already replayed, so identical requests get the successive responses,
+	// e.g. when polling an operation.
+	replayed []bool
+}
+
+// New returns a Recorder of the cassette at path. The cassette must exist in ModeReplay.
+func New(path string, options Options) (*Recorder, error) {
+	if options.Mode == "" {
+		options.Mode = ModeReplay
+	}
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	if options.Matcher == nil {
+		options.Matcher = DefaultMatcher
+	}
+	if options.RedactedHeaders == nil {
+		options.RedactedHeaders = DefaultRedactedHeaders
+	}
+
+	r := &Recorder{path: path, options: options}
+	switch options.Mode {
+	case ModeRecord:
+		return r, nil
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cassette %s, record it with %s=%s: %w", path, ModeEnv, ModeRecord, err)
+		}
+		if err := yaml.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("decoding cassette %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.cassette.Interactions))
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unsupported cassette mode %q", options.Mode)
+	}
+}
+
+// Start returns a Recorder of the cassette testdata/cassettes/<name>.yaml, in the mode set by FORGE_CASSETTE_MODE.
+// The recorded cassette is written when the test finishes.
+func Start(t testing.TB, name string, options Options) *Recorder {
+	t.Helper()
+	if mode := os.Getenv(ModeEnv); mode != "" {
+		options.Mode = Mode(mode)
+	}
+	r, err := New(filepath.Join("testdata", "cassettes", name+".yaml"), options)
+	if err != nil {
+		t.Fatalf("starting cassette %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Errorf("stopping cassette %s: %v", name, err)
+		}
+	})
+	return r
+}
+
+// Mode returns the mode of the Recorder.
+func (r *Recorder) Mode() Mode {
+	return r.options.Mode
+}
+
+// Client returns an HTTP client sending its requests through the Recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.options.Mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+// Stop writes the recorded interactions to the cassette in ModeRecord, it is a no-op in ModeReplay.
+func (r *Recorder) Stop() error {
+	if r.options.Mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := yaml.Marshal(r.cassette)
+	if err != nil {
+		return fmt.Errorf("encoding cassette %s: %w", r.path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.options.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: r.redactHeaders(req.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    r.redactHeaders(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+	if r.options.Redact != nil {
+		r.options.Redact(&interaction)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	// The caller gets the response as sent by the server, only the recorded interaction is redacted.
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || !r.options.Matcher(req, body, interaction.Request) {
+			continue
+		}
+		r.replayed[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w in cassette %s: %s %s", ErrInteractionNotFound, r.path, req.Method, req.URL)
+}
+
+// redactHeaders returns a copy of the headers with the values of the redacted headers replaced.
+func (r *Recorder) redactHeaders(headers http.Header) http.Header {
+	headers = headers.Clone()
+	for _, name := range r.options.RedactedHeaders {
+		if _, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+			headers.Set(name, redacted)
+		}
+	}
+	return headers
+}
+
+// readBody reads the body of the request and restores it, so the request can still be sent.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading the body of %s %s: %w", req.Method, req.URL, err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
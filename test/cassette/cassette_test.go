@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	g := NewWithT(t)
+
+	// The machine becomes ready on the second poll.
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=s3cr3t")
+		switch r.Method {
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"i-123","request":` + string(body) + `}`))
+		case http.MethodGet:
+			if polls.Add(1) == 1 {
+				_, _ = w.Write([]byte(`{"state":"pending"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"state":"running"}`))
+		}
+	}))
+	path := filepath.Join(t.TempDir(), "machine.yaml")
+
+	run := func(c *http.Client) []string {
+		responses := []string{}
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/machines?zone=a&type=small", strings.NewReader(`{"image":"ubuntu"}`))
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+		for _, req := range []*http.Request{
+			req,
+			mustRequest(http.MethodGet, server.URL+"/machines/i-123"),
+			mustRequest(http.MethodGet, server.URL+"/machines/i-123"),
+		} {
+			resp, err := c.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			responses = append(responses, string(body))
+		}
+		return responses
+	}
+	expected := []string{`{"id":"i-123","request":{"image":"ubuntu"}}`, `{"state":"pending"}`, `{"state":"running"}`}
+
+	recorder, err := New(path, Options{Mode: ModeRecord})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(run(recorder.Client())).To(Equal(expected))
+	g.Expect(recorder.Stop()).To(Succeed())
+	server.Close()
+
+	player, err := New(path, Options{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(player.Mode()).To(Equal(ModeReplay))
+	g.Expect(player.cassette.Interactions).To(HaveLen(3))
+	g.Expect(player.cassette.Interactions[0].Request.Headers.Get("Authorization")).To(Equal(redacted))
+	g.Expect(player.cassette.Interactions[0].Response.Headers.Get("Set-Cookie")).To(Equal(redacted))
+	g.Expect(player.cassette.Interactions[0].Response.StatusCode).To(Equal(http.StatusCreated))
+
+	// The successive responses to identical requests are replayed in order.
+	g.Expect(run(player.Client())).To(Equal(expected))
+
+	_, err = player.Client().Get(server.URL + "/machines/i-123")
+	g.Expect(errors.Is(err, ErrInteractionNotFound)).To(BeTrue())
+}
+
+func TestDefaultMatcher(t *testing.T) {
+	g := NewWithT(t)
+
+	recorded := Request{Method: http.MethodGet, URL: "https://compute.example.com/machines?zone=a&type=small"}
+	g.Expect(DefaultMatcher(mustRequest(http.MethodGet, "https://compute.example.com/machines?type=small&zone=a"), nil, recorded)).To(BeTrue())
+	g.Expect(DefaultMatcher(mustRequest(http.MethodGet, "https://compute.example.com/machines?zone=b&type=small"), nil, recorded)).To(BeFalse())
+	g.Expect(DefaultMatcher(mustRequest(http.MethodDelete, "https://compute.example.com/machines?zone=a&type=small"), nil, recorded)).To(BeFalse())
+	g.Expect(DefaultMatcher(mustRequest(http.MethodGet, "https://compute.example.com/machines?zone=a&type=small"), []byte("{}"), recorded)).To(BeFalse())
+}
+
+func TestRedact(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"account":"123456789012"}`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "account.yaml")
+
+	recorder, err := New(path, Options{Mode: ModeRecord, Redact: func(i *Interaction) {
+		i.Response.Body = strings.ReplaceAll(i.Response.Body, "123456789012", "000000000000")
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+	resp, err := recorder.Client().Get(server.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	body, _ := io.ReadAll(resp.Body)
+	// The caller gets the response of the server, only the cassette is redacted.
+	g.Expect(string(body)).To(ContainSubstring("123456789012"))
+	g.Expect(recorder.Stop()).To(Succeed())
+
+	player, err := New(path, Options{})
+	g.Expect(err).ToNot(HaveOccurred())
+	resp, err = player.Client().Get(server.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	body, _ = io.ReadAll(resp.Body)
+	g.Expect(string(body)).To(Equal(`{"account":"000000000000"}`))
+}
+
+func TestNewMissingCassette(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), Options{})
+	g.Expect(err).To(MatchError(ContainSubstring("FORGE_CASSETTE_MODE=record")))
+	_, err = New(filepath.Join(t.TempDir(), "missing.yaml"), Options{Mode: "rewind"})
+	g.Expect(err).To(MatchError(ContainSubstring(`unsupported cassette mode "rewind"`)))
+}
+
+func mustRequest(method, url string) *http.Request {
+	req, err := http.NewRequest(method, url, http.NoBody)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
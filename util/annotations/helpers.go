@@ -43,6 +43,28 @@ func HasPaused(o metav1.Object) bool {
 	return hasAnnotation(o, buildv1.PausedAnnotation)
 }
 
+// IsProvisionerApproved returns true if the provisioner is listed by the `approved-provisioners` annotation.
+func IsProvisionerApproved(o metav1.Object, name string) bool {
+	for _, approved := range strings.Split(o.GetAnnotations()[buildv1.ApprovedProvisionersAnnotation], ",") {
+		if approved == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ApproveProvisioner adds the provisioner to the `approved-provisioners` annotation and returns true if the annotation has changed.
+func ApproveProvisioner(o metav1.Object, name string) bool {
+	if IsProvisionerApproved(o, name) {
+		return false
+	}
+	approved := o.GetAnnotations()[buildv1.ApprovedProvisionersAnnotation]
+	if approved != "" {
+		approved += ","
+	}
+	return AddAnnotations(o, map[string]string{buildv1.ApprovedProvisionersAnnotation: approved + name})
+}
+
 // HasWithPrefix returns true if at least one of the annotations has the prefix specified.
 func HasWithPrefix(prefix string, annotations map[string]string) bool {
 	for key := range annotations {
@@ -23,6 +23,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
 )
 
 func TestAddAnnotations(t *testing.T) {
@@ -276,3 +278,17 @@ func TestHasTruthyAnnotationValue(t *testing.T) {
 		})
 	}
 }
+
+func TestApproveProvisioner(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &corev1.Node{}
+	g.Expect(IsProvisionerApproved(obj, "install")).To(BeFalse())
+
+	g.Expect(ApproveProvisioner(obj, "install")).To(BeTrue())
+	g.Expect(ApproveProvisioner(obj, "harden")).To(BeTrue())
+	g.Expect(ApproveProvisioner(obj, "install")).To(BeFalse())
+	g.Expect(obj.Annotations).To(HaveKeyWithValue(buildv1.ApprovedProvisionersAnnotation, "install,harden"))
+	g.Expect(IsProvisionerApproved(obj, "harden")).To(BeTrue())
+	g.Expect(IsProvisionerApproved(obj, "wipe")).To(BeFalse())
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -57,3 +62,115 @@ func BuildToInfrastructureMapFunc(ctx context.Context, gvk schema.GroupVersionKi
 		}
 	}
 }
+
+// SetProvisionerConditions sets a ProvisionerReady-<uuid> condition on the build for every provisioner
+// reporting a status, so the progress of each provisioner is visible with kubectl wait and describe. The condition
+// of a running provisioner reports its progress when recorded in its status, e.g. the script being run.
+func SetProvisionerConditions(build *buildv1.Build) {
+	for _, p := range build.Spec.Provisioners {
+		if p.UUID == nil || p.Status == nil {
+			continue
+		}
+		t := buildv1.ProvisionerReadyCondition(*p.UUID)
+		message := ptr.Deref(p.FailureMessage, "")
+		progress := "Provisioner is running"
+		retrying := false
+		for _, status := range build.Status.Provisioners {
+			if status.UUID != *p.UUID {
+				continue
+			}
+			retrying = status.NextAttemptAt != nil
+			if status.Logs != "" {
+				message = fmt.Sprintf("%s, last logs:\n%s", message, status.Logs)
+			}
+			if status.Message != "" {
+				progress = status.Message
+			}
+		}
+		switch *p.Status {
+		case buildv1.ProvisionerStatusPending:
+			if retrying {
+				conditions.MarkFalse(build, t, buildv1.ProvisionerRetryingReason, progress)
+			} else {
+				conditions.MarkFalse(build, t, buildv1.ProvisionerPendingReason, "Provisioner is waiting to run")
+			}
+		case buildv1.ProvisionerStatusRunning:
+			conditions.MarkFalse(build, t, buildv1.ProvisionerRunningReason, progress)
+		case buildv1.ProvisionerStatusCompleted:
+			conditions.MarkTrue(build, t, buildv1.ProvisionerSucceededReason, "")
+		case buildv1.ProvisionerStatusFailed:
+			reason := buildv1.ProvisionerFailedReason
+			if ptr.Deref(p.FailureReason, "") == batchv1.JobReasonDeadlineExceeded {
+				reason = buildv1.ProvisionerDeadlineExceededReason
+			}
+			conditions.MarkFalse(build, t, reason, message)
+		default:
+			conditions.MarkUnknown(build, t, string(*p.Status), message)
+		}
+	}
+}
+
+// RecordProvisionerEvent emits an event on the Build once the status of the provisioner changed from previous: when
+// it started, completed or failed, along with the reason of its failure.
+func RecordProvisionerEvent(recorder record.EventRecorder, build *buildv1.Build, p *buildv1.ProvisionerSpec, previous buildv1.ProvisionerStatus) {
+	status := ptr.Deref(p.Status, "")
+	if status == previous {
+		return
+	}
+	name := p.Name
+	if name == "" {
+		name = fmt.Sprintf("%s %s", p.Type, ptr.Deref(p.UUID, ""))
+	}
+	switch status {
+	case buildv1.ProvisionerStatusRunning:
+		recorder.Eventf(build, corev1.EventTypeNormal, "ProvisionerStarted", "Provisioner %s started", name)
+	case buildv1.ProvisionerStatusCompleted:
+		recorder.Eventf(build, corev1.EventTypeNormal, "ProvisionerCompleted", "Provisioner %s completed", name)
+	case buildv1.ProvisionerStatusFailed:
+		recorder.Eventf(build, corev1.EventTypeWarning, buildv1.ProvisionerFailedReason, "Provisioner %s failed with reason %s: %s",
+			name, ptr.Deref(p.FailureReason, "Unknown"), ptr.Deref(p.FailureMessage, ""))
+	}
+}
+
+// RecordProvisionerStatus records the phase of the provisioner in status.provisioners of the Build and returns
+// its entry, which is added when missing, so the caller can record the progress of the provisioner Job.
+func RecordProvisionerStatus(build *buildv1.Build, provisioner *buildv1.ProvisionerSpec) *buildv1.BuildProvisionerStatus {
+	uuid := ptr.Deref(provisioner.UUID, "")
+	status := GetProvisionerStatus(build, provisioner)
+	if status == nil {
+		build.Status.Provisioners = append(build.Status.Provisioners, buildv1.BuildProvisionerStatus{UUID: uuid})
+		status = &build.Status.Provisioners[len(build.Status.Provisioners)-1]
+	}
+	status.Name = provisioner.Name
+	status.Type = provisioner.Type
+	status.Phase = ptr.Deref(provisioner.Status, buildv1.ProvisionerStatusPending)
+	return status
+}
+
+// GetProvisionerStatus returns the entry of the provisioner in status.provisioners of the Build, nil if it has none.
+func GetProvisionerStatus(build *buildv1.Build, provisioner *buildv1.ProvisionerSpec) *buildv1.BuildProvisionerStatus {
+	uuid := ptr.Deref(provisioner.UUID, "")
+	for i := range build.Status.Provisioners {
+		if build.Status.Provisioners[i].UUID == uuid {
+			return &build.Status.Provisioners[i]
+		}
+	}
+	return nil
+}
+
+// ProvisionersSucceeded returns true if all the provisioners of the Build completed, the provisioners
+// allowed to fail may have failed.
+func ProvisionersSucceeded(build *buildv1.Build) bool {
+	for i := range build.Spec.Provisioners {
+		if !ProvisionerSucceeded(&build.Spec.Provisioners[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ProvisionerSucceeded returns true if the provisioner completed, or failed while it is allowed to fail.
+func ProvisionerSucceeded(p *buildv1.ProvisionerSpec) bool {
+	status := ptr.Deref(p.Status, buildv1.ProvisionerStatusUnknown)
+	return status == buildv1.ProvisionerStatusCompleted || (status == buildv1.ProvisionerStatusFailed && p.AllowFail)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conditions implements helpers for managing metav1.Condition on Forge objects.
+package conditions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxMessageLength is the maximum length of a condition message accepted by the API server.
+	maxMessageLength = 32768
+)
+
+// Getter interface defines methods that an object should implement in order to
+// use the conditions package for getting conditions.
+type Getter interface {
+	client.Object
+
+	// GetConditions returns the list of conditions for an object.
+	GetConditions() []metav1.Condition
+}
+
+// Setter interface defines methods that an object should implement in order to
+// use the conditions package for setting conditions.
+type Setter interface {
+	Getter
+
+	// SetConditions sets the list of conditions for an object.
+	SetConditions([]metav1.Condition)
+}
+
+// Get returns the condition with the given type, if the condition does not exist, it returns nil.
+func Get(from Getter, t string) *metav1.Condition {
+	return meta.FindStatusCondition(from.GetConditions(), t)
+}
+
+// Has returns true if a condition with the given type exists.
+func Has(from Getter, t string) bool {
+	return Get(from, t) != nil
+}
+
+// IsTrue is true if the condition with the given type is True, otherwise it returns false
+// if the condition is not True or if the condition does not exist (is nil).
+func IsTrue(from Getter, t string) bool {
+	return meta.IsStatusConditionTrue(from.GetConditions(), t)
+}
+
+// IsFalse is true if the condition with the given type is False, otherwise it returns false
+// if the condition is not False or if the condition does not exist (is nil).
+func IsFalse(from Getter, t string) bool {
+	return meta.IsStatusConditionFalse(from.GetConditions(), t)
+}
+
+// GetReason returns the reason of the condition with the given type, or an empty string if it does not exist.
+func GetReason(from Getter, t string) string {
+	if c := Get(from, t); c != nil {
+		return c.Reason
+	}
+	return ""
+}
+
+// GetMessage returns the message of the condition with the given type, or an empty string if it does not exist.
+func GetMessage(from Getter, t string) string {
+	if c := Get(from, t); c != nil {
+		return c.Message
+	}
+	return ""
+}
+
+// Set sets the given condition, the LastTransitionTime is only updated when the status changes
+// and the ObservedGeneration is always set to the generation of the object.
+func Set(to Setter, condition metav1.Condition) {
+	if to == nil {
+		return
+	}
+	conditions := to.GetConditions()
+	condition.ObservedGeneration = to.GetGeneration()
+	if len(condition.Message) > maxMessageLength {
+		condition.Message = condition.Message[:maxMessageLength]
+	}
+	meta.SetStatusCondition(&conditions, condition)
+	to.SetConditions(conditions)
+}
+
+// MarkTrue sets Status=True for the condition with the given type.
+func MarkTrue(to Setter, t, reason, messageFormat string, messageArgs ...interface{}) {
+	Set(to, newCondition(t, metav1.ConditionTrue, reason, messageFormat, messageArgs...))
+}
+
+// MarkFalse sets Status=False for the condition with the given type.
+func MarkFalse(to Setter, t, reason, messageFormat string, messageArgs ...interface{}) {
+	Set(to, newCondition(t, metav1.ConditionFalse, reason, messageFormat, messageArgs...))
+}
+
+// MarkUnknown sets Status=Unknown for the condition with the given type.
+func MarkUnknown(to Setter, t, reason, messageFormat string, messageArgs ...interface{}) {
+	Set(to, newCondition(t, metav1.ConditionUnknown, reason, messageFormat, messageArgs...))
+}
+
+// Delete deletes the condition with the given type.
+func Delete(to Setter, t string) {
+	if to == nil {
+		return
+	}
+	conditions := to.GetConditions()
+	meta.RemoveStatusCondition(&conditions, t)
+	to.SetConditions(conditions)
+}
+
+// SetSummary sets a condition with the given type summarizing the given source conditions.
+// The summary is True only if all the source conditions are True, otherwise it reports the
+// reason and message of the first source condition which is not True, in the given order.
+// A missing source condition is reported as WaitingFor<Type>.
+func SetSummary(to Setter, t, trueReason string, from ...string) {
+	for _, sourceType := range from {
+		source := Get(to, sourceType)
+		if source == nil {
+			MarkFalse(to, t, "WaitingFor"+sourceType, "Waiting for %s", sourceType)
+			return
+		}
+		if source.Status != metav1.ConditionTrue {
+			Set(to, metav1.Condition{
+				Type:    t,
+				Status:  metav1.ConditionFalse,
+				Reason:  source.Reason,
+				Message: source.Message,
+			})
+			return
+		}
+	}
+	MarkTrue(to, t, trueReason, "")
+}
+
+func newCondition(t string, status metav1.ConditionStatus, reason, messageFormat string, messageArgs ...interface{}) metav1.Condition {
+	message := messageFormat
+	if len(messageArgs) > 0 {
+		message = fmt.Sprintf(messageFormat, messageArgs...)
+	}
+	return metav1.Condition{
+		Type:    t,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
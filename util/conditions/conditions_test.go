@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestMarkAndGet(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+	g.Expect(Has(build, buildv1.ConnectedCondition)).To(BeFalse())
+
+	MarkFalse(build, buildv1.ConnectedCondition, buildv1.ConnectionFailedReason, "dial %s: refused", "10.0.0.1")
+	g.Expect(IsFalse(build, buildv1.ConnectedCondition)).To(BeTrue())
+	g.Expect(GetReason(build, buildv1.ConnectedCondition)).To(Equal(buildv1.ConnectionFailedReason))
+	g.Expect(GetMessage(build, buildv1.ConnectedCondition)).To(Equal("dial 10.0.0.1: refused"))
+	g.Expect(Get(build, buildv1.ConnectedCondition).ObservedGeneration).To(Equal(int64(3)))
+
+	MarkTrue(build, buildv1.ConnectedCondition, buildv1.ConnectionEstablishedReason, "")
+	g.Expect(IsTrue(build, buildv1.ConnectedCondition)).To(BeTrue())
+	g.Expect(build.GetConditions()).To(HaveLen(1))
+
+	Delete(build, buildv1.ConnectedCondition)
+	g.Expect(Has(build, buildv1.ConnectedCondition)).To(BeFalse())
+}
+
+func TestSetSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{}
+	MarkTrue(build, buildv1.InfrastructureReadyCondition, buildv1.ReadyReason, "")
+
+	SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason, buildv1.InfrastructureReadyCondition, buildv1.ConnectedCondition)
+	g.Expect(IsFalse(build, buildv1.ReadyCondition)).To(BeTrue())
+	g.Expect(GetReason(build, buildv1.ReadyCondition)).To(Equal("WaitingForConnected"))
+
+	MarkFalse(build, buildv1.ConnectedCondition, buildv1.ConnectionFailedReason, "timeout")
+	SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason, buildv1.InfrastructureReadyCondition, buildv1.ConnectedCondition)
+	g.Expect(GetReason(build, buildv1.ReadyCondition)).To(Equal(buildv1.ConnectionFailedReason))
+	g.Expect(GetMessage(build, buildv1.ReadyCondition)).To(Equal("timeout"))
+
+	MarkTrue(build, buildv1.ConnectedCondition, buildv1.ConnectionEstablishedReason, "")
+	SetSummary(build, buildv1.ReadyCondition, buildv1.ReadyReason, buildv1.InfrastructureReadyCondition, buildv1.ConnectedCondition)
+	g.Expect(IsTrue(build, buildv1.ReadyCondition)).To(BeTrue())
+	g.Expect(GetReason(build, buildv1.ReadyCondition)).To(Equal(buildv1.ReadyReason))
+}
+
+func TestSetMirror(t *testing.T) {
+	g := NewWithT(t)
+
+	build := &buildv1.Build{}
+	infra := &unstructured.Unstructured{Object: map[string]interface{}{}}
+
+	SetMirror(build, buildv1.InfrastructureReadyCondition, infra,
+		FalseCondition(buildv1.WaitingForInfrastructureFallbackReason, ""))
+	g.Expect(GetReason(build, buildv1.InfrastructureReadyCondition)).To(Equal(buildv1.WaitingForInfrastructureFallbackReason))
+
+	g.Expect(unstructured.SetNestedSlice(infra.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True"},
+	}, "status", "conditions")).To(Succeed())
+	SetMirror(build, buildv1.InfrastructureReadyCondition, infra,
+		FalseCondition(buildv1.WaitingForInfrastructureFallbackReason, ""))
+	g.Expect(IsTrue(build, buildv1.InfrastructureReadyCondition)).To(BeTrue())
+	g.Expect(GetReason(build, buildv1.InfrastructureReadyCondition)).To(Equal("Ready"))
+}
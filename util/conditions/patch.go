@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Patch is the set of the conditions changed between two versions of an object, by condition type. A nil
+// condition is a deleted condition.
+type Patch map[string]*metav1.Condition
+
+// NewPatch returns the conditions changed from before to after.
+func NewPatch(before, after Getter) Patch {
+	patch := Patch{}
+	for _, condition := range after.GetConditions() {
+		if previous := Get(before, condition.Type); previous == nil || !equality.Semantic.DeepEqual(*previous, condition) {
+			patch[condition.Type] = condition.DeepCopy()
+		}
+	}
+	for _, condition := range before.GetConditions() {
+		if !Has(after, condition.Type) {
+			patch[condition.Type] = nil
+		}
+	}
+	return patch
+}
+
+// IsZero returns true if no condition has changed.
+func (p Patch) IsZero() bool {
+	return len(p) == 0
+}
+
+// Apply sets the changed conditions on latest, as they are, and deletes the deleted ones. The other conditions of
+// latest, e.g. the ones set by another controller in the meantime, are left as is.
+func (p Patch) Apply(latest Setter) {
+	types := make([]string, 0, len(p))
+	for t := range p {
+		types = append(types, t)
+	}
+	// The added conditions are appended in a stable order.
+	sort.Strings(types)
+
+	conditions := latest.GetConditions()
+	for _, t := range types {
+		condition := p[t]
+		if condition == nil {
+			meta.RemoveStatusCondition(&conditions, t)
+			continue
+		}
+		replaced := false
+		for i := range conditions {
+			if conditions[i].Type == t {
+				conditions[i] = *condition.DeepCopy()
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			conditions = append(conditions, *condition.DeepCopy())
+		}
+	}
+	latest.SetConditions(conditions)
+}
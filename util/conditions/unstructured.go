@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// readyType is the condition type mirrored from external objects.
+const readyType = "Ready"
+
+// UnstructuredGet returns the condition with the given type from status.conditions of an unstructured object.
+// Both metav1.Condition and Cluster API style conditions are supported, as they share the type, status,
+// reason and message fields. It returns nil if the object does not report the condition.
+func UnstructuredGet(from *unstructured.Unstructured, t string) *metav1.Condition {
+	if from == nil {
+		return nil
+	}
+	items, found, err := unstructured.NestedSlice(from.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if conditionType, _, _ := unstructured.NestedString(m, "type"); conditionType != t {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(m, "status")
+		reason, _, _ := unstructured.NestedString(m, "reason")
+		message, _, _ := unstructured.NestedString(m, "message")
+		return &metav1.Condition{
+			Type:    t,
+			Status:  metav1.ConditionStatus(status),
+			Reason:  reason,
+			Message: message,
+		}
+	}
+	return nil
+}
+
+// SetMirror sets a condition with the given type mirroring the Ready condition of an external object,
+// the fallback condition is used if the external object does not report a Ready condition.
+func SetMirror(to Setter, t string, from *unstructured.Unstructured, fallback metav1.Condition) {
+	source := UnstructuredGet(from, readyType)
+	if source == nil {
+		fallback.Type = t
+		Set(to, fallback)
+		return
+	}
+
+	source.Type = t
+	if source.Reason == "" {
+		// Cluster API style conditions don't require a reason, while metav1.Condition does.
+		source.Reason = fallback.Reason
+		if source.Status == metav1.ConditionTrue {
+			source.Reason = readyType
+		}
+	}
+	Set(to, *source)
+}
+
+// FalseCondition returns a condition with Status=False, to be used as a fallback value.
+func FalseCondition(reason, messageFormat string, messageArgs ...interface{}) metav1.Condition {
+	return newCondition("", metav1.ConditionFalse, reason, messageFormat, messageArgs...)
+}
+
+// TrueCondition returns a condition with Status=True, to be used as a fallback value.
+func TrueCondition(reason, messageFormat string, messageArgs ...interface{}) metav1.Condition {
+	return newCondition("", metav1.ConditionTrue, reason, messageFormat, messageArgs...)
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/pkg/credentialsprovider"
+	"github.com/forge-build/forge/pkg/docker"
+	forgeerrors "github.com/forge-build/forge/pkg/errors"
+	"github.com/forge-build/forge/pkg/ssh"
+)
+
+// NewSSHClient returns an SSH client to the infrastructure machine of the Build, configured with the connector of the Build.
+func NewSSHClient(ctx context.Context, c client.Client, build *buildv1.Build) (*ssh.SSHClient, error) {
+	connector := &build.Spec.Connector
+	if connector.Credentials == nil {
+		return nil, forgeerrors.ConfigErrorf("the connector of Build %s has no credentials", build.Name)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: connector.Credentials.Name}, secret); err != nil {
+		return nil, errors.Wrap(err, "failed to get secret")
+	}
+	if err := credentialsprovider.Overlay(ctx, c, connector.CredentialsProviderRef, secret); err != nil {
+		return nil, errors.Wrap(err, "failed to resolve the connector credentials")
+	}
+
+	sshClient, err := ssh.NewSSHClient(secret)
+	if err != nil {
+		return nil, errors.Wrap(ssh.ClassifyError(err), "failed to create SSH client")
+	}
+	sshClient.SetConnectorDefaults(int(connector.Port), connector.Username)
+	sshClient.Options.Owner = client.ObjectKeyFromObject(build).String()
+	sshClient.Options.ConnectTimeout = connector.GetTimeout()
+	sshClient.Options.Logger = ctrl.LoggerFrom(ctx).WithName("ssh")
+
+	if connector.Bastion != nil {
+		bastionSecret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: connector.Bastion.Credentials.Name}, bastionSecret); err != nil {
+			return nil, errors.Wrap(err, "failed to get bastion secret")
+		}
+		sshClient.Options.Bastion = ssh.NewBastion(bastionSecret, int(connector.Bastion.Port))
+	}
+
+	sshClient.Options.HostKeyFingerprints, err = hostKeyFingerprints(build, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if connector.Sudo != nil {
+		sshClient.Options.Sudo = &ssh.Sudo{User: connector.Sudo.User}
+		if connector.Sudo.Password {
+			if sshClient.Creds.SSHPassword == "" {
+				return nil, forgeerrors.ConfigErrorf("sudo requires a password but secret %s has none", secret.Name)
+			}
+			sshClient.Options.Sudo.Password = sshClient.Creds.SSHPassword
+		}
+	}
+	return sshClient, nil
+}
+
+// NewDockerConnector returns the docker-exec connector to the container of the Build, configured with the connector
+// of the Build.
+func NewDockerConnector(ctx context.Context, c client.Client, build *buildv1.Build) (*docker.Connector, error) {
+	if build.Spec.Connector.Credentials == nil {
+		return nil, forgeerrors.ConfigErrorf("the connector of Build %s has no credentials", build.Name)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.Connector.Credentials.Name}, secret); err != nil {
+		return nil, errors.Wrap(err, "failed to get secret")
+	}
+	if err := credentialsprovider.Overlay(ctx, c, build.Spec.Connector.CredentialsProviderRef, secret); err != nil {
+		return nil, errors.Wrap(err, "failed to resolve the connector credentials")
+	}
+	connector, err := docker.NewConnector(secret, build.Spec.Connector.Username)
+	if err != nil {
+		return nil, forgeerrors.NewConfigError(err)
+	}
+	return connector, nil
+}
+
+// HostKeyFingerprints returns the fingerprints of the host keys accepted by the connector of the Build,
+// any host key is accepted when it returns none.
+func HostKeyFingerprints(ctx context.Context, c client.Client, build *buildv1.Build) ([]string, error) {
+	secret := &corev1.Secret{}
+	if build.Spec.Connector.HostKeyPolicy == buildv1.HostKeyPolicyStrict {
+		if build.Spec.Connector.Credentials == nil {
+			return nil, forgeerrors.ConfigErrorf("the connector of Build %s has no credentials", build.Name)
+		}
+		key := client.ObjectKey{Namespace: build.Namespace, Name: build.Spec.Connector.Credentials.Name}
+		if err := c.Get(ctx, key, secret); err != nil {
+			return nil, errors.Wrap(err, "failed to get secret")
+		}
+		if err := credentialsprovider.Overlay(ctx, c, build.Spec.Connector.CredentialsProviderRef, secret); err != nil {
+			return nil, errors.Wrap(err, "failed to resolve the connector credentials")
+		}
+	}
+	return hostKeyFingerprints(build, secret)
+}
+
+// hostKeyFingerprints returns the fingerprints accepted by the host key policy of the connector,
+// secret is the credentials secret of the connector.
+func hostKeyFingerprints(build *buildv1.Build, secret *corev1.Secret) ([]string, error) {
+	switch build.Spec.Connector.HostKeyPolicy {
+	case buildv1.HostKeyPolicyStrict:
+		fingerprint, err := ssh.HostKeyFingerprint(secret)
+		if err != nil {
+			return nil, forgeerrors.NewConfigError(err)
+		}
+		if fingerprint == "" {
+			return nil, forgeerrors.ConfigErrorf("the %s host key policy requires the hostKey of secret %s",
+				buildv1.HostKeyPolicyStrict, secret.Name)
+		}
+		return []string{fingerprint}, nil
+	case buildv1.HostKeyPolicyTrustOnFirstUse:
+		// The host key presented by the first connection is recorded in the status of the Build.
+		if build.Status.Connection != nil && build.Status.Connection.HostKeyFingerprint != "" {
+			return []string{build.Status.Connection.HostKeyFingerprint}, nil
+		}
+	}
+	return nil, nil
+}
@@ -9,10 +9,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/patch"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -44,14 +44,7 @@ func EnsureCredentialsSecret(ctx context.Context, client client.Client, build *b
 				buildv1.ManagedByAnnotation: "forge",
 				buildv1.ProviderNameLabel:   provider,
 			},
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					Name:       build.Name,
-					UID:        build.GetUID(),
-					APIVersion: build.APIVersion,
-					Kind:       build.Kind,
-				},
-			},
+			OwnerReferences: []metav1.OwnerReference{BuildOwnerReference(build)},
 		},
 		StringData: map[string]string{
 			"host":     creds.Host,
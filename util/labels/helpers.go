@@ -0,0 +1,91 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package labels implements label helper functions. Labels are read with their canonical forge.build key,
+// falling back to their legacy cluster.x-k8s.io key, and are always written with their canonical key.
+package labels
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// legacyKeys maps the canonical label keys to their legacy key.
+var legacyKeys = map[string]string{
+	buildv1.WatchLabel:        buildv1.LegacyWatchLabel,
+	buildv1.ProviderNameLabel: buildv1.LegacyProviderNameLabel,
+}
+
+// LegacyKey returns the legacy key of the given canonical label key, if any.
+func LegacyKey(key string) (string, bool) {
+	legacy, ok := legacyKeys[key]
+	return legacy, ok
+}
+
+// Get returns the value of the label with the given canonical key, falling back to its legacy key.
+func Get(o metav1.Object, key string) (string, bool) {
+	labels := o.GetLabels()
+	if value, ok := labels[key]; ok {
+		return value, true
+	}
+	if legacy, ok := legacyKeys[key]; ok {
+		value, ok := labels[legacy]
+		return value, ok
+	}
+	return "", false
+}
+
+// Set sets the label with the given canonical key, removing its legacy key.
+func Set(o metav1.Object, key, value string) {
+	labels := o.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[key] = value
+	if legacy, ok := legacyKeys[key]; ok {
+		delete(labels, legacy)
+	}
+	o.SetLabels(labels)
+}
+
+// Migrate replaces the legacy label keys of the object with their canonical key, the value of the canonical
+// key is kept if both are set. It returns true if the labels of the object have been changed.
+func Migrate(o metav1.Object) bool {
+	labels := o.GetLabels()
+	changed := false
+	for key, legacy := range legacyKeys {
+		value, ok := labels[legacy]
+		if !ok {
+			continue
+		}
+		if _, ok := labels[key]; !ok {
+			labels[key] = value
+		}
+		delete(labels, legacy)
+		changed = true
+	}
+	if changed {
+		o.SetLabels(labels)
+	}
+	return changed
+}
+
+// HasWatchLabel returns true if the object has a watch label matching the given value.
+func HasWatchLabel(o metav1.Object, labelValue string) bool {
+	value, ok := Get(o, buildv1.WatchLabel)
+	return ok && value == labelValue
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestHasWatchLabel(t *testing.T) {
+	testcases := []struct {
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:     "canonical key",
+			labels:   map[string]string{buildv1.WatchLabel: "team-a"},
+			expected: true,
+		},
+		{
+			name:     "legacy key",
+			labels:   map[string]string{buildv1.LegacyWatchLabel: "team-a"},
+			expected: true,
+		},
+		{
+			name:     "canonical key takes precedence",
+			labels:   map[string]string{buildv1.WatchLabel: "team-b", buildv1.LegacyWatchLabel: "team-a"},
+			expected: false,
+		},
+		{
+			name:     "no label",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: tc.labels}}
+			g.Expect(HasWatchLabel(obj, "team-a")).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{buildv1.LegacyProviderNameLabel: "aws"}}}
+	Set(obj, buildv1.ProviderNameLabel, "docker")
+	g.Expect(obj.Labels).To(Equal(map[string]string{buildv1.ProviderNameLabel: "docker"}))
+
+	obj = &corev1.Secret{}
+	Set(obj, buildv1.BuildNameLabel, "ubuntu")
+	g.Expect(obj.Labels).To(Equal(map[string]string{buildv1.BuildNameLabel: "ubuntu"}))
+}
+
+func TestMigrate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		labels   map[string]string
+		expected map[string]string
+		changed  bool
+	}{
+		{
+			name:     "legacy keys are replaced",
+			labels:   map[string]string{buildv1.LegacyWatchLabel: "team-a", buildv1.LegacyProviderNameLabel: "aws", "foo": "bar"},
+			expected: map[string]string{buildv1.WatchLabel: "team-a", buildv1.ProviderNameLabel: "aws", "foo": "bar"},
+			changed:  true,
+		},
+		{
+			name:     "canonical value is kept",
+			labels:   map[string]string{buildv1.WatchLabel: "team-b", buildv1.LegacyWatchLabel: "team-a"},
+			expected: map[string]string{buildv1.WatchLabel: "team-b"},
+			changed:  true,
+		},
+		{
+			name:     "no legacy keys",
+			labels:   map[string]string{buildv1.WatchLabel: "team-a"},
+			expected: map[string]string{buildv1.WatchLabel: "team-a"},
+			changed:  false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: tc.labels}}
+			g.Expect(Migrate(obj)).To(Equal(tc.changed))
+			g.Expect(obj.Labels).To(Equal(tc.expected))
+		})
+	}
+}
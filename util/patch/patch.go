@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package patch patches the objects written by several controllers, e.g. the Builds whose conditions are set by
+// the Build controller and by the ShellJob controller. It patches the objects like the patch helper of Cluster API,
+// and their metav1.Conditions separately: only the conditions changed since the helper has been created are applied
+// onto the latest version of the object, with an optimistic lock, so the controllers do not overwrite the conditions
+// set by each other.
+package patch
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	capipatch "sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/forge-build/forge/util/conditions"
+)
+
+// Option configures the patch of the object, see the options of the Cluster API patch helper.
+type Option = capipatch.Option
+
+// WithStatusObservedGeneration sets the status.observedGeneration of the object to its generation.
+type WithStatusObservedGeneration = capipatch.WithStatusObservedGeneration
+
+// conflictBackoff retries the patch of the conditions when the object has been updated in the meantime.
+var conflictBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   1.0,
+}
+
+// Helper patches an object and its conditions.
+type Helper struct {
+	client client.Client
+	helper *capipatch.Helper
+	before client.Object
+}
+
+// NewHelper returns a Helper patching the changes made to obj from now on.
+func NewHelper(obj client.Object, c client.Client) (*Helper, error) {
+	helper, err := capipatch.NewHelper(obj, c)
+	if err != nil {
+		return nil, err
+	}
+	return &Helper{client: c, helper: helper, before: obj.DeepCopyObject().(client.Object)}, nil
+}
+
+// Patch patches the changes made to obj. When obj has metav1.Conditions, the conditions changed are applied onto the
+// latest version of the object and patched with an optimistic lock first, the conditions of obj are then the ones of
+// the latest version.
+func (h *Helper) Patch(ctx context.Context, obj client.Object, opts ...Option) error {
+	setter, ok := obj.(conditions.Setter)
+	before, hasConditions := h.before.(conditions.Setter)
+	if !ok || !hasConditions {
+		return h.helper.Patch(ctx, obj, opts...)
+	}
+
+	merged, err := h.patchConditions(ctx, before, setter)
+	if err != nil {
+		return err
+	}
+
+	// The conditions are left out of the patch of the object, they have been patched already.
+	setter.SetConditions(before.GetConditions())
+	err = h.helper.Patch(ctx, obj, opts...)
+	setter.SetConditions(merged)
+	return err
+}
+
+// patchConditions applies the conditions changed in obj onto the latest version of the object, and returns the
+// resulting conditions.
+func (h *Helper) patchConditions(ctx context.Context, before, obj conditions.Setter) ([]metav1.Condition, error) {
+	diff := conditions.NewPatch(before, obj)
+	if diff.IsZero() {
+		return obj.GetConditions(), nil
+	}
+
+	var merged []metav1.Condition
+	err := wait.ExponentialBackoffWithContext(ctx, conflictBackoff, func(ctx context.Context) (bool, error) {
+		latest := before.DeepCopyObject().(conditions.Setter)
+		if err := h.client.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+			return false, err
+		}
+
+		conditionsPatch := client.MergeFromWithOptions(latest.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+		diff.Apply(latest)
+		err := h.client.Status().Patch(ctx, latest, conditionsPatch)
+		switch {
+		case apierrors.IsConflict(err):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+		merged = latest.GetConditions()
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to patch the conditions of %s", obj.GetName())
+	}
+	return merged, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/conditions"
+)
+
+func TestHelperPatchConditionsOfSeveralControllers(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"}}
+	conditions.MarkFalse(build, buildv1.ProvisionersReadyCondition, buildv1.WaitingForProvisionersReason, "")
+	conditions.MarkFalse(build, buildv1.ImageExportedCondition, buildv1.WaitingForProvisionersReason, "")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(build).WithStatusSubresource(&buildv1.Build{}).Build()
+
+	// The Build controller and the ShellJob controller both read the same version of the Build, and patch their
+	// own conditions at the same time.
+	buildController := &buildv1.Build{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), buildController)).To(Succeed())
+	shellJobController := buildController.DeepCopy()
+	buildHelper, err := NewHelper(buildController, c)
+	g.Expect(err).ToNot(HaveOccurred())
+	shellJobHelper, err := NewHelper(shellJobController, c)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	conditions.MarkTrue(buildController, buildv1.ConnectedCondition, buildv1.ConnectionEstablishedReason, "")
+	conditions.Delete(buildController, buildv1.ImageExportedCondition)
+	buildController.Status.Connected = true
+	conditions.MarkTrue(shellJobController, buildv1.ProvisionersReadyCondition, buildv1.ProvisionersSucceededReason, "")
+	shellJobController.Status.ProvisionersReady = true
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, patch := range []func() error{
+		func() error { return buildHelper.Patch(ctx, buildController, WithStatusObservedGeneration{}) },
+		func() error { return shellJobHelper.Patch(ctx, shellJobController) },
+	} {
+		wg.Add(1)
+		go func(i int, patch func() error) {
+			defer wg.Done()
+			errs[i] = patch()
+		}(i, patch)
+	}
+	wg.Wait()
+	g.Expect(errs).To(HaveEach(Succeed()))
+
+	// None of the conditions is overwritten by the other controller.
+	updated := &buildv1.Build{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(build), updated)).To(Succeed())
+	g.Expect(conditions.IsTrue(updated, buildv1.ConnectedCondition)).To(BeTrue())
+	g.Expect(conditions.IsTrue(updated, buildv1.ProvisionersReadyCondition)).To(BeTrue())
+	g.Expect(conditions.Has(updated, buildv1.ImageExportedCondition)).To(BeFalse())
+	g.Expect(updated.Status.Connected).To(BeTrue())
+	g.Expect(updated.Status.ProvisionersReady).To(BeTrue())
+
+	// The objects patched have the conditions of both controllers.
+	g.Expect(conditions.IsTrue(buildController, buildv1.ProvisionersReadyCondition) ||
+		conditions.IsTrue(shellJobController, buildv1.ConnectedCondition)).To(BeTrue())
+}
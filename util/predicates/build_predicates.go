@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/annotations"
 
 	"github.com/go-logr/logr"
 	"k8s.io/klog/v2"
@@ -118,12 +119,12 @@ func ClusterUpdateInfraReady(logger logr.Logger) predicate.Funcs {
 	}
 }
 
-// BuildUpdateUnpaused returns a predicate that returns true for an update event when a build has Spec.Paused changed from true to false
-// it also returns true if the resource provided is not a Build to allow for use with controller-runtime NewControllerManagedBy.
+// BuildUpdateUnpaused returns a predicate that returns true for an update event when a build is unpaused, either
+// Spec.Paused changed from true to false or the paused annotation has been removed.
 func BuildUpdateUnpaused(logger logr.Logger) predicate.Funcs {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			log := logger.WithValues("predicate", "ClusterUpdateUnpaused", "eventType", "update")
+			log := logger.WithValues("predicate", "BuildUpdateUnpaused", "eventType", "update")
 
 			oldCluster, ok := e.ObjectOld.(*buildv1.Build)
 			if !ok {
@@ -134,14 +135,14 @@ func BuildUpdateUnpaused(logger logr.Logger) predicate.Funcs {
 
 			newCluster := e.ObjectNew.(*buildv1.Build)
 
-			if oldCluster.Spec.Paused && !newCluster.Spec.Paused {
-				log.V(4).Info("Cluster was unpaused, allowing further processing")
+			if annotations.IsPaused(oldCluster, oldCluster) && !annotations.IsPaused(newCluster, newCluster) {
+				log.V(4).Info("Build was unpaused, allowing further processing")
 				return true
 			}
 
 			// This predicate always work in "or" with Paused predicates
 			// so the logs are adjusted to not provide false negatives/verbosity al V<=5.
-			log.V(6).Info("Cluster was not unpaused, blocking further processing")
+			log.V(6).Info("Build was not unpaused, blocking further processing")
 			return false
 		},
 		CreateFunc:  func(event.CreateEvent) bool { return false },
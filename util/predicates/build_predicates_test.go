@@ -0,0 +1,57 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func TestBuildUpdateUnpaused(t *testing.T) {
+	paused := func(spec bool, annotation bool) *buildv1.Build {
+		build := &buildv1.Build{ObjectMeta: metav1.ObjectMeta{Name: "ubuntu"}, Spec: buildv1.BuildSpec{Paused: spec}}
+		if annotation {
+			build.Annotations = map[string]string{buildv1.PausedAnnotation: ""}
+		}
+		return build
+	}
+
+	testcases := []struct {
+		name     string
+		old, new *buildv1.Build
+		expected bool
+	}{
+		{name: "spec unpaused", old: paused(true, false), new: paused(false, false), expected: true},
+		{name: "annotation removed", old: paused(false, true), new: paused(false, false), expected: true},
+		{name: "annotation removed but spec paused", old: paused(true, true), new: paused(true, false), expected: false},
+		{name: "paused", old: paused(false, false), new: paused(true, false), expected: false},
+		{name: "not paused", old: paused(false, false), new: paused(false, false), expected: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := BuildUpdateUnpaused(logr.Discard())
+			g.Expect(p.Update(event.UpdateEvent{ObjectOld: tc.old, ObjectNew: tc.new})).To(Equal(tc.expected))
+		})
+	}
+}
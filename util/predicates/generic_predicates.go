@@ -26,6 +26,8 @@ import (
 
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/labels"
+
+	forgelabels "github.com/forge-build/forge/util/labels"
 )
 
 // All returns a predicate that returns true only if all given predicates return true.
@@ -129,7 +131,7 @@ func Any(logger logr.Logger, predicates ...predicate.Funcs) predicate.Funcs {
 }
 
 // ResourceHasFilterLabel returns a predicate that returns true only if the provided resource contains
-// a label with the WatchLabel key and the configured label value exactly.
+// a label with the WatchLabel key, or its legacy key, and the configured label value exactly.
 func ResourceHasFilterLabel(logger logr.Logger, labelValue string) predicate.Funcs {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
@@ -203,7 +205,7 @@ func processIfLabelMatch(logger logr.Logger, obj client.Object, labelValue strin
 
 	kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
 	log := logger.WithValues("namespace", obj.GetNamespace(), kind, obj.GetName())
-	if labels.HasWatchLabel(obj, labelValue) {
+	if forgelabels.HasWatchLabel(obj, labelValue) {
 		log.V(6).Info("Resource matches label, will attempt to map resource")
 		return true
 	}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jobs implements the predicates shared by the controllers watching the Jobs of the provisioners.
+package jobs
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+// ManagedBy returns a predicate that returns true if the object is managed by the given provisioner,
+// i.e. its managed-by label is set to name.
+func ManagedBy(name string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		managedBy, ok := obj.GetLabels()[buildv1.ManagedByLabel]
+		return ok && managedBy == name
+	})
+}
+
+// InNamespace returns a predicate that returns true if the object is in the given namespace.
+func InNamespace(namespace string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == namespace
+	})
+}
+
+// HasBuildLabels is a predicate that returns true if the object has the labels referencing
+// the Build and the provisioner it runs.
+var HasBuildLabels = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	labels := obj.GetLabels()
+	_, hasName := labels[buildv1.BuildNameLabel]
+	_, hasProvisioner := labels[buildv1.ProvisionerIDLabel]
+	return hasName && hasProvisioner
+})
+
+// Finished is a predicate that returns true if the object is a Job which has completed or failed.
+var Finished = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return false
+	}
+	_, finished := TerminalCondition(job)
+	return finished
+})
+
+// OwnerBuildExists returns a predicate that returns true if the Build referenced by the labels of the object
+// exists, the events of the Jobs left over by deleted Builds are dropped. The object is let through when the
+// Build cannot be read, so the error is handled by the reconciler.
+func OwnerBuildExists(c client.Reader, logger logr.Logger) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		key := client.ObjectKey{
+			Namespace: obj.GetLabels()[buildv1.BuildNamespaceLabel],
+			Name:      obj.GetLabels()[buildv1.BuildNameLabel],
+		}
+		if key.Name == "" {
+			return false
+		}
+		err := c.Get(context.Background(), key, &buildv1.Build{})
+		if apierrors.IsNotFound(err) {
+			logger.V(4).Info("Build of the job not found, ignoring", "predicate", "OwnerBuildExists",
+				"job", client.ObjectKeyFromObject(obj), "build", key)
+			return false
+		}
+		return true
+	})
+}
+
+// TerminalCondition returns the type of the Complete or Failed condition of the Job, if any is true.
+// Jobs can report other conditions before the terminal one, e.g. SuccessCriteriaMet.
+func TerminalCondition(job *batchv1.Job) (batchv1.JobConditionType, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed {
+			return condition.Type, true
+		}
+	}
+	return "", false
+}
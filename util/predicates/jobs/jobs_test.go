@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Forge.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobs
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+)
+
+func newJob(labels map[string]string, conditions ...batchv1.JobCondition) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "forge-core", Name: "shell-1234", Labels: labels},
+		Status:     batchv1.JobStatus{Conditions: conditions},
+	}
+}
+
+func TestJobPredicates(t *testing.T) {
+	labels := map[string]string{
+		buildv1.ManagedByLabel:      "forge-provisioner-shell",
+		buildv1.BuildNameLabel:      "ubuntu",
+		buildv1.BuildNamespaceLabel: "images",
+		buildv1.ProvisionerIDLabel:  "1234",
+	}
+	complete := batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}
+	successCriteriaMet := batchv1.JobCondition{Type: batchv1.JobSuccessCriteriaMet, Status: corev1.ConditionTrue}
+	failed := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}
+	suspended := batchv1.JobCondition{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue}
+
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(buildv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&buildv1.Build{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "ubuntu"},
+	}).Build()
+
+	testcases := []struct {
+		name      string
+		predicate predicate.Predicate
+		job       *batchv1.Job
+		expected  bool
+	}{
+		{name: "managed by", predicate: ManagedBy("forge-provisioner-shell"), job: newJob(labels), expected: true},
+		{name: "managed by another provisioner", predicate: ManagedBy("forge-provisioner-ansible"), job: newJob(labels)},
+		{name: "not managed", predicate: ManagedBy("forge-provisioner-shell"), job: newJob(nil)},
+		{name: "in namespace", predicate: InNamespace("forge-core"), job: newJob(labels), expected: true},
+		{name: "in another namespace", predicate: InNamespace("default"), job: newJob(labels)},
+		{name: "build labels", predicate: HasBuildLabels, job: newJob(labels), expected: true},
+		{name: "missing provisioner label", predicate: HasBuildLabels, job: newJob(map[string]string{buildv1.BuildNameLabel: "ubuntu"})},
+		{name: "complete", predicate: Finished, job: newJob(labels, successCriteriaMet, complete), expected: true},
+		{name: "failed", predicate: Finished, job: newJob(labels, failed), expected: true},
+		{name: "suspended", predicate: Finished, job: newJob(labels, suspended)},
+		{name: "running", predicate: Finished, job: newJob(labels)},
+		{name: "owner build exists", predicate: OwnerBuildExists(c, logr.Discard()), job: newJob(labels), expected: true},
+		{
+			name:      "owner build deleted",
+			predicate: OwnerBuildExists(c, logr.Discard()),
+			job:       newJob(map[string]string{buildv1.BuildNameLabel: "centos", buildv1.BuildNamespaceLabel: "images"}),
+		},
+		{name: "no owner build", predicate: OwnerBuildExists(c, logr.Discard()), job: newJob(nil)},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tc.predicate.Generic(event.GenericEvent{Object: tc.job})).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestTerminalCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	condition, finished := TerminalCondition(newJob(nil,
+		batchv1.JobCondition{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue},
+		batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+	))
+	g.Expect(finished).To(BeTrue())
+	g.Expect(condition).To(Equal(batchv1.JobFailed))
+
+	_, finished = TerminalCondition(newJob(nil, batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionFalse}))
+	g.Expect(finished).To(BeFalse())
+}
@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	buildv1 "github.com/forge-build/forge/api/v1alpha1"
+	"github.com/forge-build/forge/util/labels"
 )
 
 var (
@@ -70,13 +71,10 @@ func hasAnnotation(o metav1.Object, annotation string) bool {
 	return ok
 }
 
-// HasWatchLabel returns true if the object has a label with the WatchLabel key matching the given value.
+// HasWatchLabel returns true if the object has a label with the WatchLabel key, or its legacy key,
+// matching the given value.
 func HasWatchLabel(o metav1.Object, labelValue string) bool {
-	val, ok := o.GetLabels()[buildv1.WatchLabel]
-	if !ok {
-		return false
-	}
-	return val == labelValue
+	return labels.HasWatchLabel(o, labelValue)
 }
 
 // UnstructuredUnmarshalField is a wrapper around json and unstructured objects to decode and copy a specific field
@@ -139,6 +137,17 @@ func GetOwnerBuild(ctx context.Context, c client.Client, obj metav1.ObjectMeta)
 	return nil, nil
 }
 
+// BuildOwnerReference returns an owner reference to the Build, so the object is garbage collected with the Build
+// and deleted along with it. It does not rely on the TypeMeta of the Build, which typed clients may leave empty.
+func BuildOwnerReference(build *buildv1.Build) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: buildv1.GroupVersion.String(),
+		Kind:       "Build",
+		Name:       build.Name,
+		UID:        build.UID,
+	}
+}
+
 // GetBuildByName finds and return a Build object using the specified params.
 func GetBuildByName(ctx context.Context, c client.Client, namespace, name string) (*buildv1.Build, error) {
 	build := &buildv1.Build{}